@@ -5,12 +5,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 
 	"github.com/neohope/chatapp/api-gateway/config"
@@ -19,6 +17,10 @@ import (
 	"github.com/neohope/chatapp/api-gateway/internal/service"
 	"github.com/neohope/chatapp/api-gateway/pkg/auth"
 	"github.com/neohope/chatapp/api-gateway/pkg/logger"
+	"github.com/neohope/chatapp/api-gateway/pkg/metrics"
+	"github.com/neohope/chatapp/api-gateway/pkg/recovery"
+	"github.com/neohope/chatapp/api-gateway/pkg/shutdown"
+	"github.com/neohope/chatapp/api-gateway/pkg/tracing"
 )
 
 func main() {
@@ -40,17 +42,110 @@ func main() {
 		zap.String("log_level", cfg.LogLevel),
 	)
 
+	// 链路追踪：客户端请求没有traceparent时开始新的trace，转发给后端服务时传递W3C trace上下文
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Options{
+		Service:       "api-gateway",
+		Exporter:      cfg.Tracing.Exporter,
+		Endpoint:      cfg.Tracing.Endpoint,
+		Insecure:      cfg.Tracing.Insecure,
+		SamplePercent: cfg.Tracing.SamplePercent,
+	}, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+
 	// 初始化JWT管理器
 	jwtManager := auth.NewJWTManager(cfg.JWT.SecretKey)
+	if cfg.JWT.CacheTTL > 0 {
+		jwtManager.WithCache(auth.NewValidationCache(cfg.JWT.CacheTTL, cfg.JWT.CacheMaxEntries))
+	}
 
 	// 初始化中间件
-	middleware := delivery.NewMiddleware(jwtManager, logger, cfg.RateLimit.Enabled, cfg.RateLimit.RPS)
+	middleware := delivery.NewMiddleware(jwtManager, logger, cfg.JWT.CacheBypass)
+	// 第三方客户端使用外部IdP签发的不透明令牌，内省后按用户服务中的外部身份映射识别用户
+	if cfg.Introspection.Enabled {
+		if cfg.Introspection.Endpoint == "" {
+			logger.Fatal("OAUTH_INTROSPECTION_URL is required when token introspection is enabled")
+		}
+		identityClient := service.NewIdentityClient(cfg.Services.UserService)
+		middleware.WithIntrospector(auth.NewIntrospector(auth.IntrospectionConfig{
+			Endpoint:       cfg.Introspection.Endpoint,
+			ClientID:       cfg.Introspection.ClientID,
+			ClientSecret:   cfg.Introspection.ClientSecret,
+			Issuer:         cfg.Introspection.Issuer,
+			AllowedClients: cfg.Introspection.AllowedClients,
+			CacheTTL:       cfg.Introspection.CacheTTL,
+			Timeout:        cfg.Introspection.Timeout,
+		}, identityClient.Resolve))
+		logger.Info("OAuth2 token introspection enabled", zap.String("endpoint", cfg.Introspection.Endpoint))
+	}
+
+	// 停用、封禁或角色变更后用户服务递增令牌版本，已签发的令牌不必等到过期即失效
+	if cfg.Account.Enabled {
+		middleware.WithAccountCheck(service.NewAccountClient(cfg.Services.UserService, cfg.Account.CacheTTL))
+	}
+
+	// 未同意最新强制协议的用户只能访问协议相关接口，对所有后端服务生效
+	if cfg.Consent.Enabled {
+		middleware.WithConsentCheck(service.NewConsentClient(cfg.Services.UserService, cfg.Consent.CacheTTL))
+	}
+
+	// 防滥用、幂等键和共享限流计数的状态保存在Redis中，多个网关实例共享
+	sharedRateLimit := cfg.RateLimit.Enabled && cfg.RateLimit.Policy.Store == config.RateLimitStoreRedis
+	var redisClient *redis.Client
+	if cfg.Abuse.Enabled || cfg.Idempotency.Enabled || sharedRateLimit {
+		redisClient = redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		defer redisClient.Close()
+
+		pingCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		if err := redisClient.Ping(pingCtx).Err(); err != nil {
+			// Redis不可用时中间件放行请求，不影响网关启动
+			logger.Warn("Redis unavailable, abuse protection and idempotency will fail open, rate limits fall back to local counters", zap.String("addr", cfg.Redis.Addr), zap.Error(err))
+		}
+		cancel()
+	}
+
+	// 令牌桶限流：按IP、用户和路由分别限制，策略来自RATE_LIMIT_FILE
+	if cfg.RateLimit.Enabled {
+		middleware.WithRateLimiter(service.NewRateLimiter(cfg.RateLimit.Policy, redisClient, logger))
+		logger.Info("Rate limiting enabled", zap.String("store", cfg.RateLimit.Policy.Store), zap.Int("routes", len(cfg.RateLimit.Policy.Routes)))
+	}
+
+	// 写请求幂等键：相同Idempotency-Key的重试直接重放首次请求的响应
+	if cfg.Idempotency.Enabled {
+		middleware.WithIdempotency(service.NewIdempotencyStore(redisClient, cfg.Idempotency, logger))
+	}
+
+	// 统一响应信封：客户端通过Accept: application/vnd.chatapp.v1+json选择
+	if cfg.ResponseEnvelope.Enabled {
+		middleware.WithResponseEnvelope(cfg.ResponseEnvelope.MaxBodyBytes)
+	}
 
 	// 初始化代理服务
-	proxyService := service.NewProxyService(&cfg.Services, logger)
+	proxyService := service.NewProxyService(&cfg.Services, cfg.Upload, cfg.WebSocket, cfg.CircuitBreaker, cfg.Retry, service.NewHeaderPolicy(cfg.HeaderPolicy), logger)
+
+	// 公开API的请求体按路由配置引用的JSON Schema校验，格式错误的请求不转发给后端服务
+	if cfg.RequestSchemas != nil {
+		validator, err := service.NewRequestValidator(cfg.RequestSchemas)
+		if err != nil {
+			logger.Fatal("Invalid request schema configuration", zap.Error(err))
+		}
+		proxyService.WithRequestValidator(validator)
+		logger.Info("Request schema validation enabled", zap.Int("routes", validator.Routes()))
+	}
+
+	// API版本路由：v2等版本的路径按配置映射到后端服务，废弃版本返回Deprecation/Sunset响应头
+	apiVersions, err := service.NewAPIVersions(cfg.APIVersions, proxyService)
+	if err != nil {
+		logger.Fatal("Invalid API version configuration", zap.Error(err))
+	}
 
 	// 初始化HTTP处理器
-	handler := httpdelivery.NewHandler(proxyService, middleware, logger)
+	handler := httpdelivery.NewHandler(proxyService, middleware, logger).WithAPIVersions(apiVersions)
 
 	// 初始化路由
 	router := mux.NewRouter()
@@ -64,13 +159,49 @@ func main() {
 		AllowedHeaders: cfg.CORS.AllowedHeaders,
 	})
 
+	// 防滥用：状态保存在Redis中，多实例共享
+	var abuseGuard *service.AbuseGuard
+	if cfg.Abuse.Enabled {
+		abuseGuard = service.NewAbuseGuard(redisClient, cfg.Abuse, logger)
+		router.Use(middleware.AntiAbuse(abuseGuard))
+	}
+	httpdelivery.NewAdminHandler(abuseGuard, proxyService, middleware, cfg.AdminUserIDs, logger).WithAPIVersions(apiVersions).RegisterRoutes(router)
+
+	// 接口文档：合并各服务的/openapi.json，只保留网关转发的接口
+	router.Handle("/openapi.json", service.NewAPIDocs(&cfg.Services, httpdelivery.APIOperations, router, cfg.OpenAPI, logger)).Methods("GET")
+
+	// 处理请求时的panic转换为带请求ID的500响应，配置了SENTRY_DSN时上报到Sentry
+	reporter, err := recovery.ReporterFromDSN(cfg.ErrorReporting.SentryDSN, cfg.ErrorReporting.Environment)
+	if err != nil {
+		logger.Fatal("Invalid error reporting configuration", zap.Error(err))
+	}
+	recoverer := recovery.New(recovery.Options{Service: "api-gateway", Reporter: reporter}, logger)
+
+	// Prometheus指标：按路由模板统计请求数和延迟，以及代理中的WebSocket连接数
+	var handlerChain http.Handler = recoverer.Middleware(router)
+	if cfg.Metrics.Enabled {
+		serviceMetrics := metrics.New("api-gateway")
+		serviceMetrics.WebSocketConnections("proxy", proxyService.WebSocketConnections)
+		router.Handle("/metrics", serviceMetrics.Handler(cfg.Metrics.Token)).Methods("GET")
+		handlerChain = serviceMetrics.Instrument(router, handlerChain)
+	}
+	handlerChain = tracing.Middleware("api-gateway", router, handlerChain)
+
 	// 创建HTTP服务器
+	// 优雅关闭：排空期间健康检查返回503，负载均衡摘除本实例后再停止接收连接
+	coordinator := shutdown.New(shutdown.Options{
+		DrainDelay: cfg.Shutdown.DrainDelay,
+		Timeout:    cfg.Shutdown.Timeout,
+	}, logger)
+
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.HTTPPort),
-		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:    fmt.Sprintf(":%d", cfg.HTTPPort),
+		Handler: coordinator.Middleware(handlerChain),
+		// 读写超时需覆盖大文件上传，慢速攻击由请求头超时限制
+		ReadHeaderTimeout: 15 * time.Second,
+		ReadTimeout:       cfg.Upload.Timeout,
+		WriteTimeout:      cfg.Upload.Timeout + 15*time.Second,
+		IdleTimeout:       60 * time.Second,
 	}
 
 	// 启动HTTP服务器
@@ -81,20 +212,11 @@ func main() {
 		}
 	}()
 
-	// 等待中断信号
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.Info("Shutting down server...")
-
-	// 优雅关闭
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal("Server forced to shutdown", zap.Error(err))
-	}
+	// 收到信号后等待转发中的请求完成，已升级的WebSocket连接不受Shutdown跟踪，随后单独排空
+	coordinator.Register("http", srv.Shutdown)
+	coordinator.Register("websocket", proxyService.DrainWebSockets)
+	coordinator.Register("tracing", shutdownTracing)
+	coordinator.Wait()
 
 	logger.Info("Server exited properly")
 }
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// versionNamePattern API版本名，如v1、v2
+var versionNamePattern = regexp.MustCompile(`^v[1-9][0-9]*$`)
+
+// APIVersionConfig API版本配置，从JSON文件加载
+type APIVersionConfig struct {
+	Versions []APIVersion `json:"versions"`
+}
+
+// APIVersion 一个API版本。v1由网关内置路由处理，其余版本按Routes映射到后端服务
+// Deprecation和Sunset为RFC 3339时间，设置后响应带有Deprecation/Sunset头，过了Sunset时间返回410
+type APIVersion struct {
+	Name        string            `json:"name"`
+	Deprecation string            `json:"deprecation,omitempty"`
+	Sunset      string            `json:"sunset,omitempty"`
+	Link        string            `json:"link,omitempty"` // 迁移说明文档
+	Routes      []APIVersionRoute `json:"routes,omitempty"`
+
+	DeprecatedAt time.Time `json:"-"`
+	SunsetAt     time.Time `json:"-"`
+}
+
+// APIVersionRoute 版本路由：/api/<版本><PathPrefix>下的请求转发到Service的UpstreamPrefix下
+// 按路径前缀匹配（最长前缀优先），Methods为空表示所有方法；Public为true时不需要认证
+type APIVersionRoute struct {
+	PathPrefix     string   `json:"path_prefix"`
+	Methods        []string `json:"methods,omitempty"`
+	Service        string   `json:"service"`
+	UpstreamPrefix string   `json:"upstream_prefix"`
+	Public         bool     `json:"public,omitempty"`
+}
+
+// DefaultAPIVersions 未提供配置文件时只有内置的v1
+func DefaultAPIVersions() *APIVersionConfig {
+	return &APIVersionConfig{Versions: []APIVersion{{Name: "v1"}}}
+}
+
+// LoadAPIVersions 从JSON文件加载API版本配置，文件不存在时使用默认配置
+func LoadAPIVersions(path string) (*APIVersionConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return DefaultAPIVersions(), nil
+		}
+		return nil, fmt.Errorf("failed to read API version file: %w", err)
+	}
+
+	var cfg APIVersionConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid API version file %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range cfg.Versions {
+		version := &cfg.Versions[i]
+		if !versionNamePattern.MatchString(version.Name) {
+			return nil, fmt.Errorf("invalid API version file %s: invalid version name %q", path, version.Name)
+		}
+		if seen[version.Name] {
+			return nil, fmt.Errorf("invalid API version file %s: duplicate version %s", path, version.Name)
+		}
+		seen[version.Name] = true
+
+		if version.Deprecation != "" {
+			if version.DeprecatedAt, err = time.Parse(time.RFC3339, version.Deprecation); err != nil {
+				return nil, fmt.Errorf("invalid API version file %s: %s deprecation: %w", path, version.Name, err)
+			}
+		}
+		if version.Sunset != "" {
+			if version.SunsetAt, err = time.Parse(time.RFC3339, version.Sunset); err != nil {
+				return nil, fmt.Errorf("invalid API version file %s: %s sunset: %w", path, version.Name, err)
+			}
+		}
+
+		if version.Name == "v1" && len(version.Routes) > 0 {
+			return nil, fmt.Errorf("invalid API version file %s: v1 is served by built-in routes and cannot define routes", path)
+		}
+		if version.Name != "v1" && len(version.Routes) == 0 {
+			return nil, fmt.Errorf("invalid API version file %s: %s has no routes", path, version.Name)
+		}
+		for j := range version.Routes {
+			route := &version.Routes[j]
+			if !strings.HasPrefix(route.PathPrefix, "/") || !strings.HasPrefix(route.UpstreamPrefix, "/") || route.Service == "" {
+				return nil, fmt.Errorf("invalid API version file %s: %s routes require path_prefix, service and upstream_prefix", path, version.Name)
+			}
+			route.PathPrefix = strings.TrimSuffix(route.PathPrefix, "/")
+			route.UpstreamPrefix = strings.TrimSuffix(route.UpstreamPrefix, "/")
+			for k, method := range route.Methods {
+				route.Methods[k] = strings.ToUpper(method)
+			}
+		}
+	}
+	if !seen["v1"] {
+		cfg.Versions = append([]APIVersion{{Name: "v1"}}, cfg.Versions...)
+	}
+	return &cfg, nil
+}
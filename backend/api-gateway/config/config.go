@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,11 +15,34 @@ type Config struct {
 	JWT              JWTConfig
 	Services         ServicesConfig
 	RateLimit        RateLimitConfig
+	CircuitBreaker   CircuitBreakerConfig
+	Retry            RetryConfig
 	CORS             CORSConfig
+	HeaderPolicy     *HeaderPolicyConfig
+	RequestSchemas   *RequestSchemaConfig // 为nil时不校验请求体
+	APIVersions      *APIVersionConfig
+	Redis            RedisConfig
+	Abuse            AbuseConfig
+	AdminUserIDs     []string
+	Upload           UploadConfig
+	Introspection    IntrospectionConfig
+	Consent          ConsentConfig
+	Account          AccountConfig
+	Idempotency      IdempotencyConfig
+	ResponseEnvelope ResponseEnvelopeConfig
+	Shutdown         ShutdownConfig
+	ErrorReporting   ErrorReportingConfig
+	Metrics          MetricsConfig
+	Tracing          TracingConfig
+	WebSocket        WebSocketConfig
+	OpenAPI          OpenAPIConfig
 }
 
 type JWTConfig struct {
-	SecretKey string
+	SecretKey       string
+	CacheTTL        time.Duration // 校验结果缓存时间，0表示不缓存
+	CacheMaxEntries int
+	CacheBypass     []string // 不使用缓存的路径前缀
 }
 
 type ServicesConfig struct {
@@ -31,6 +56,128 @@ type ServicesConfig struct {
 type RateLimitConfig struct {
 	Enabled bool
 	RPS     int
+	// Policy 按IP、用户和路由的令牌桶限流策略，来自RATE_LIMIT_FILE
+	Policy *RateLimitPolicy
+}
+
+// CircuitBreakerConfig 每个后端服务的熔断配置：连续失败达到阈值后熔断，冷却后放行少量探测请求
+type CircuitBreakerConfig struct {
+	Enabled          bool
+	FailureThreshold int           // 连续失败多少次后熔断
+	OpenTimeout      time.Duration // 熔断后多久进入半开状态
+	HalfOpenRequests int           // 半开状态下同时放行的探测请求数
+}
+
+// RetryConfig 转发失败时的重试配置，只重试幂等方法且没有请求体的请求
+type RetryConfig struct {
+	MaxRetries int
+	Backoff    time.Duration // 首次重试前的等待时间，之后每次翻倍
+	Methods    []string
+}
+
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// AbuseConfig 防滥用配置：窗口内错误数超过拖延阈值后逐步拖延响应，达到封禁阈值后临时封禁
+type AbuseConfig struct {
+	Enabled           bool
+	PenalizedStatuses []int
+	Window            time.Duration
+	TarpitThreshold   int
+	TarpitStep        time.Duration
+	MaxTarpit         time.Duration
+	BanThreshold      int
+	BanDuration       time.Duration
+	MaxBanDuration    time.Duration
+	StrikeTTL         time.Duration // 违规次数的累计周期
+}
+
+// UploadConfig 请求体流式转发配置
+type UploadConfig struct {
+	BufferSize       int           // 转发缓冲区大小（字节）
+	StreamThreshold  int64         // 超过该大小的请求体按上传处理，使用上传超时
+	MaxBytes         int64         // 请求体上限，0表示不限制
+	Timeout          time.Duration // 上传请求的转发超时
+	ProgressLogBytes int64         // 每转发多少字节记录一次进度
+}
+
+// IntrospectionConfig 第三方客户端不透明令牌的内省（RFC 7662）配置
+type IntrospectionConfig struct {
+	Enabled        bool
+	Endpoint       string
+	ClientID       string
+	ClientSecret   string
+	Issuer         string   // 内省结果不含iss时使用的IdP标识
+	AllowedClients []string // 允许访问的第三方客户端ID，为空表示不限制
+	CacheTTL       time.Duration
+	Timeout        time.Duration
+}
+
+// ConsentConfig 强制协议同意检查配置，启用后未同意最新协议的用户无法访问受保护路由
+type ConsentConfig struct {
+	Enabled  bool
+	CacheTTL time.Duration // 已同意结果的缓存时间
+}
+
+// AccountConfig 账户状态检查配置，启用后每个请求按用户服务中的当前状态、角色和令牌版本校验
+type AccountConfig struct {
+	Enabled  bool
+	CacheTTL time.Duration // 查询结果的缓存时间，也是停用或角色变更生效的最大延迟
+}
+
+// IdempotencyConfig 写请求幂等键配置，响应保存在Redis中
+type IdempotencyConfig struct {
+	Enabled      bool
+	TTL          time.Duration // 已完成响应的保存时间
+	LockTTL      time.Duration // 处理中占位记录的有效期，应覆盖最长的请求处理时间
+	MaxBodyBytes int64         // 参与请求摘要的请求体上限，也是可保存的响应体上限
+}
+
+// ResponseEnvelopeConfig 统一响应信封配置，客户端通过Accept头选择后才转换
+type ResponseEnvelopeConfig struct {
+	Enabled      bool
+	MaxBodyBytes int64 // 可转换的响应体上限，超过时原样返回
+}
+
+// ShutdownConfig 优雅关闭配置
+type ShutdownConfig struct {
+	DrainDelay time.Duration // 进入排空状态（健康检查返回503）后等待多久再停止接收连接
+	Timeout    time.Duration // 关闭步骤的总超时时间
+}
+
+// WebSocketConfig WebSocket代理配置
+type WebSocketConfig struct {
+	HandshakeTimeout time.Duration // 与客户端、后端握手的超时时间
+	BufferSize       int           // 每条连接的读写缓冲区大小（字节）
+}
+
+// ErrorReportingConfig 错误上报配置，SentryDSN为空时panic只记录日志
+// MetricsConfig Prometheus指标配置，Token不为空时/metrics要求携带Bearer令牌
+type MetricsConfig struct {
+	Enabled bool
+	Token   string
+}
+
+// TracingConfig OpenTelemetry链路追踪配置，网关为没有traceparent的请求开始新的trace
+type TracingConfig struct {
+	Exporter      string // none（只生成和传播traceparent）、otlp或jaeger
+	Endpoint      string // OTLP collector的gRPC地址或Jaeger collector的HTTP地址，为空时使用导出器默认值
+	Insecure      bool   // OTLP连接不使用TLS
+	SamplePercent int    // 新trace的采样百分比，后端服务沿用网关的采样决定
+}
+
+// OpenAPIConfig 聚合接口文档配置，各服务的文档缓存CacheTTL后重新获取
+type OpenAPIConfig struct {
+	CacheTTL time.Duration
+	Timeout  time.Duration // 获取单个服务文档的超时
+}
+
+type ErrorReportingConfig struct {
+	SentryDSN   string
+	Environment string
 }
 
 type CORSConfig struct {
@@ -44,14 +191,97 @@ func LoadConfig() (*Config, error) {
 	godotenv.Load()
 
 	httpPort, _ := strconv.Atoi(getEnv("HTTP_PORT", "8080"))
+	metricsEnabled, _ := strconv.ParseBool(getEnv("METRICS_ENABLED", "true"))
+	tracingInsecure, _ := strconv.ParseBool(getEnv("TRACING_INSECURE", "true"))
+	tracingSamplePercent, _ := strconv.Atoi(getEnv("TRACING_SAMPLE_PERCENT", "100"))
 	rps, _ := strconv.Atoi(getEnv("RATE_LIMIT_RPS", "100"))
 	rateLimitEnabled, _ := strconv.ParseBool(getEnv("RATE_LIMIT_ENABLED", "true"))
 
+	breakerEnabled, _ := strconv.ParseBool(getEnv("CIRCUIT_BREAKER_ENABLED", "true"))
+	breakerThreshold, _ := strconv.Atoi(getEnv("CIRCUIT_BREAKER_FAILURE_THRESHOLD", "5"))
+	breakerOpenSeconds, _ := strconv.Atoi(getEnv("CIRCUIT_BREAKER_OPEN_SECONDS", "30"))
+	breakerHalfOpen, _ := strconv.Atoi(getEnv("CIRCUIT_BREAKER_HALF_OPEN_REQUESTS", "1"))
+	retryMax, _ := strconv.Atoi(getEnv("PROXY_RETRY_MAX", "2"))
+	retryBackoff, _ := strconv.Atoi(getEnv("PROXY_RETRY_BACKOFF_MS", "100"))
+
+	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
+	abuseEnabled, _ := strconv.ParseBool(getEnv("ABUSE_PROTECTION_ENABLED", "true"))
+	abuseWindow, _ := strconv.Atoi(getEnv("ABUSE_WINDOW_SECONDS", "60"))
+	tarpitThreshold, _ := strconv.Atoi(getEnv("ABUSE_TARPIT_THRESHOLD", "10"))
+	tarpitStep, _ := strconv.Atoi(getEnv("ABUSE_TARPIT_STEP_MS", "250"))
+	maxTarpit, _ := strconv.Atoi(getEnv("ABUSE_MAX_TARPIT_MS", "5000"))
+	banThreshold, _ := strconv.Atoi(getEnv("ABUSE_BAN_THRESHOLD", "30"))
+	banMinutes, _ := strconv.Atoi(getEnv("ABUSE_BAN_MINUTES", "5"))
+	maxBanMinutes, _ := strconv.Atoi(getEnv("ABUSE_MAX_BAN_MINUTES", "1440"))
+
+	uploadBufferKB, _ := strconv.Atoi(getEnv("UPLOAD_BUFFER_KB", "64"))
+	uploadThresholdMB, _ := strconv.ParseInt(getEnv("UPLOAD_STREAM_THRESHOLD_MB", "1"), 10, 64)
+	uploadMaxMB, _ := strconv.ParseInt(getEnv("UPLOAD_MAX_MB", "600"), 10, 64)
+	uploadTimeout, _ := strconv.Atoi(getEnv("UPLOAD_TIMEOUT_SECONDS", "900"))
+	uploadProgressMB, _ := strconv.ParseInt(getEnv("UPLOAD_PROGRESS_LOG_MB", "50"), 10, 64)
+
+	introspectionEnabled, _ := strconv.ParseBool(getEnv("OAUTH_INTROSPECTION_ENABLED", "false"))
+	introspectionCacheSeconds, _ := strconv.Atoi(getEnv("OAUTH_INTROSPECTION_CACHE_TTL_SECONDS", "60"))
+	introspectionTimeout, _ := strconv.Atoi(getEnv("OAUTH_INTROSPECTION_TIMEOUT_SECONDS", "5"))
+
+	idempotencyEnabled, _ := strconv.ParseBool(getEnv("IDEMPOTENCY_ENABLED", "true"))
+	idempotencyTTLHours, _ := strconv.Atoi(getEnv("IDEMPOTENCY_TTL_HOURS", "24"))
+	idempotencyMaxKB, _ := strconv.ParseInt(getEnv("IDEMPOTENCY_MAX_BODY_KB", "1024"), 10, 64)
+
+	envelopeEnabled, _ := strconv.ParseBool(getEnv("RESPONSE_ENVELOPE_ENABLED", "true"))
+	envelopeMaxKB, _ := strconv.ParseInt(getEnv("RESPONSE_ENVELOPE_MAX_BODY_KB", "4096"), 10, 64)
+
+	shutdownDrainDelay, _ := strconv.Atoi(getEnv("SHUTDOWN_DRAIN_DELAY_SECONDS", "5"))
+	shutdownTimeout, _ := strconv.Atoi(getEnv("SHUTDOWN_TIMEOUT_SECONDS", "30"))
+
+	wsHandshakeTimeout, _ := strconv.Atoi(getEnv("WS_HANDSHAKE_TIMEOUT_SECONDS", "10"))
+	wsBufferKB, _ := strconv.Atoi(getEnv("WS_BUFFER_KB", "4"))
+
+	openAPICacheSeconds, _ := strconv.Atoi(getEnv("OPENAPI_CACHE_TTL_SECONDS", "60"))
+	openAPITimeout, _ := strconv.Atoi(getEnv("OPENAPI_FETCH_TIMEOUT_SECONDS", "5"))
+
+	jwtCacheSeconds, _ := strconv.Atoi(getEnv("JWT_CACHE_TTL_SECONDS", "30"))
+	jwtCacheEntries, _ := strconv.Atoi(getEnv("JWT_CACHE_MAX_ENTRIES", "10000"))
+
+	consentEnabled, _ := strconv.ParseBool(getEnv("CONSENT_CHECK_ENABLED", "true"))
+	consentCacheSeconds, _ := strconv.Atoi(getEnv("CONSENT_CACHE_TTL_SECONDS", "60"))
+	accountEnabled, _ := strconv.ParseBool(getEnv("ACCOUNT_CHECK_ENABLED", "true"))
+	accountCacheSeconds, _ := strconv.Atoi(getEnv("ACCOUNT_CACHE_TTL_SECONDS", "5"))
+
+	headerPolicy, err := LoadHeaderPolicy(getEnv("HEADER_POLICY_FILE", "config/header_policies.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	apiVersions, err := LoadAPIVersions(getEnv("API_VERSIONS_FILE", "config/api_versions.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var rateLimitPolicy *RateLimitPolicy
+	if rateLimitEnabled {
+		rateLimitPolicy, err = LoadRateLimitPolicy(getEnv("RATE_LIMIT_FILE", "config/rate_limits.yaml"), rps)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var requestSchemas *RequestSchemaConfig
+	if schemaValidation, _ := strconv.ParseBool(getEnv("REQUEST_SCHEMA_VALIDATION_ENABLED", "true")); schemaValidation {
+		requestSchemas, err = LoadRequestSchemas(getEnv("REQUEST_SCHEMA_FILE", "config/request_schemas.json"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &Config{
 		HTTPPort: httpPort,
 		LogLevel: getEnv("LOG_LEVEL", "info"),
 		JWT: JWTConfig{
-			SecretKey: getEnv("JWT_SECRET_KEY", "your-secret-key"),
+			SecretKey:       getEnv("JWT_SECRET_KEY", "your-secret-key"),
+			CacheTTL:        time.Duration(jwtCacheSeconds) * time.Second,
+			CacheMaxEntries: jwtCacheEntries,
+			CacheBypass:     splitList(getEnv("JWT_CACHE_BYPASS_PATHS", "/api/v1/admin,/api/v1/auth/validate,/api/v1/users/change-password,/api/v1/users/me/deactivate")),
 		},
 		Services: ServicesConfig{
 			UserService:         getEnv("USER_SERVICE_URL", "http://localhost:8081"),
@@ -63,15 +293,132 @@ func LoadConfig() (*Config, error) {
 		RateLimit: RateLimitConfig{
 			Enabled: rateLimitEnabled,
 			RPS:     rps,
+			Policy:  rateLimitPolicy,
+		},
+		CircuitBreaker: CircuitBreakerConfig{
+			Enabled:          breakerEnabled,
+			FailureThreshold: breakerThreshold,
+			OpenTimeout:      time.Duration(breakerOpenSeconds) * time.Second,
+			HalfOpenRequests: breakerHalfOpen,
+		},
+		Retry: RetryConfig{
+			MaxRetries: retryMax,
+			Backoff:    time.Duration(retryBackoff) * time.Millisecond,
+			Methods:    splitList(strings.ToUpper(getEnv("PROXY_RETRY_METHODS", "GET,HEAD,OPTIONS,PUT,DELETE"))),
 		},
 		CORS: CORSConfig{
 			AllowedOrigins: []string{"http://localhost:3000", "*"},
 			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-			AllowedHeaders: []string{"Content-Type", "Authorization", "X-Requested-With", "Accept", "Origin", "X-User-ID"},
+			AllowedHeaders: []string{"Content-Type", "Authorization", "X-Requested-With", "Accept", "Origin", "X-User-ID", "Idempotency-Key"},
+		},
+		HeaderPolicy:   headerPolicy,
+		RequestSchemas: requestSchemas,
+		APIVersions:    apiVersions,
+		Redis: RedisConfig{
+			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
+			Password: getEnv("REDIS_PASSWORD", ""),
+			DB:       redisDB,
+		},
+		Abuse: AbuseConfig{
+			Enabled:           abuseEnabled,
+			PenalizedStatuses: parseStatuses(getEnv("ABUSE_PENALIZED_STATUSES", "401,404")),
+			Window:            time.Duration(abuseWindow) * time.Second,
+			TarpitThreshold:   tarpitThreshold,
+			TarpitStep:        time.Duration(tarpitStep) * time.Millisecond,
+			MaxTarpit:         time.Duration(maxTarpit) * time.Millisecond,
+			BanThreshold:      banThreshold,
+			BanDuration:       time.Duration(banMinutes) * time.Minute,
+			MaxBanDuration:    time.Duration(maxBanMinutes) * time.Minute,
+			StrikeTTL:         24 * time.Hour,
+		},
+		AdminUserIDs: splitList(getEnv("ADMIN_USER_IDS", "")),
+		Upload: UploadConfig{
+			BufferSize:       uploadBufferKB * 1024,
+			StreamThreshold:  uploadThresholdMB << 20,
+			MaxBytes:         uploadMaxMB << 20,
+			Timeout:          time.Duration(uploadTimeout) * time.Second,
+			ProgressLogBytes: uploadProgressMB << 20,
+		},
+		Introspection: IntrospectionConfig{
+			Enabled:        introspectionEnabled,
+			Endpoint:       getEnv("OAUTH_INTROSPECTION_URL", ""),
+			ClientID:       getEnv("OAUTH_INTROSPECTION_CLIENT_ID", ""),
+			ClientSecret:   getEnv("OAUTH_INTROSPECTION_CLIENT_SECRET", ""),
+			Issuer:         getEnv("OAUTH_INTROSPECTION_ISSUER", ""),
+			AllowedClients: splitList(getEnv("OAUTH_ALLOWED_CLIENT_IDS", "")),
+			CacheTTL:       time.Duration(introspectionCacheSeconds) * time.Second,
+			Timeout:        time.Duration(introspectionTimeout) * time.Second,
+		},
+		Consent: ConsentConfig{
+			Enabled:  consentEnabled,
+			CacheTTL: time.Duration(consentCacheSeconds) * time.Second,
+		},
+		Account: AccountConfig{
+			Enabled:  accountEnabled,
+			CacheTTL: time.Duration(accountCacheSeconds) * time.Second,
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled: idempotencyEnabled,
+			TTL:     time.Duration(idempotencyTTLHours) * time.Hour,
+			// 上传请求处理时间最长，占位记录的有效期与上传超时一致
+			LockTTL:      time.Duration(uploadTimeout) * time.Second,
+			MaxBodyBytes: idempotencyMaxKB << 10,
+		},
+		ResponseEnvelope: ResponseEnvelopeConfig{
+			Enabled:      envelopeEnabled,
+			MaxBodyBytes: envelopeMaxKB << 10,
+		},
+		Shutdown: ShutdownConfig{
+			DrainDelay: time.Duration(shutdownDrainDelay) * time.Second,
+			Timeout:    time.Duration(shutdownTimeout) * time.Second,
+		},
+		ErrorReporting: ErrorReportingConfig{
+			SentryDSN:   getEnv("SENTRY_DSN", ""),
+			Environment: getEnv("SENTRY_ENVIRONMENT", "production"),
+		},
+		Metrics: MetricsConfig{
+			Enabled: metricsEnabled,
+			Token:   getEnv("METRICS_TOKEN", ""),
+		},
+		Tracing: TracingConfig{
+			Exporter:      getEnv("TRACING_EXPORTER", "none"),
+			Endpoint:      getEnv("TRACING_ENDPOINT", ""),
+			Insecure:      tracingInsecure,
+			SamplePercent: tracingSamplePercent,
+		},
+		WebSocket: WebSocketConfig{
+			HandshakeTimeout: time.Duration(wsHandshakeTimeout) * time.Second,
+			BufferSize:       wsBufferKB * 1024,
+		},
+		OpenAPI: OpenAPIConfig{
+			CacheTTL: time.Duration(openAPICacheSeconds) * time.Second,
+			Timeout:  time.Duration(openAPITimeout) * time.Second,
 		},
 	}, nil
 }
 
+// splitList 解析逗号分隔的列表，忽略空项
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseStatuses 解析逗号分隔的HTTP状态码，忽略无效项
+func parseStatuses(value string) []int {
+	statuses := []int{}
+	for _, item := range splitList(value) {
+		if status, err := strconv.Atoi(item); err == nil {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
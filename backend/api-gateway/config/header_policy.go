@@ -0,0 +1,76 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// HeaderPolicyConfig 请求/响应头策略配置，从路由配置文件加载
+type HeaderPolicyConfig struct {
+	StripHopByHop    bool          `json:"strip_hop_by_hop"`  // 剔除Connection等逐跳头
+	ForwardedHeaders bool          `json:"forwarded_headers"` // 注入X-Forwarded-*头
+	Default          RoutePolicy   `json:"default"`
+	Routes           []RoutePolicy `json:"routes"`
+}
+
+// RoutePolicy 单条路由的头策略，按路径前缀匹配，最长前缀优先，与默认策略合并
+type RoutePolicy struct {
+	PathPrefix string      `json:"path_prefix"`
+	Request    HeaderRules `json:"request"`
+	Response   HeaderRules `json:"response"`
+}
+
+// HeaderRules 头操作规则，执行顺序为删除、重命名、设置
+type HeaderRules struct {
+	Remove []string          `json:"remove"`
+	Rename map[string]string `json:"rename"`
+	Set    map[string]string `json:"set"`
+}
+
+// DefaultHeaderPolicy 未提供配置文件时使用的默认策略
+func DefaultHeaderPolicy() *HeaderPolicyConfig {
+	return &HeaderPolicyConfig{
+		StripHopByHop:    true,
+		ForwardedHeaders: true,
+		Default: RoutePolicy{
+			Request: HeaderRules{
+				// 身份头由网关认证后注入，不信任客户端传入的值
+				Remove: []string{"X-User-ID", "X-User-Email", "X-User-Role"},
+			},
+			Response: HeaderRules{
+				Remove: []string{"Server", "X-Powered-By"},
+				Set: map[string]string{
+					"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+					"Content-Security-Policy":   "default-src 'none'; frame-ancestors 'none'",
+					"X-Content-Type-Options":    "nosniff",
+					"X-Frame-Options":           "DENY",
+					"Referrer-Policy":           "no-referrer",
+				},
+			},
+		},
+	}
+}
+
+// LoadHeaderPolicy 从JSON文件加载头策略，文件不存在时使用默认策略
+func LoadHeaderPolicy(path string) (*HeaderPolicyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return DefaultHeaderPolicy(), nil
+		}
+		return nil, fmt.Errorf("failed to read header policy file: %w", err)
+	}
+
+	var policy HeaderPolicyConfig
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid header policy file %s: %w", path, err)
+	}
+	for _, route := range policy.Routes {
+		if route.PathPrefix == "" {
+			return nil, fmt.Errorf("invalid header policy file %s: route path_prefix is required", path)
+		}
+	}
+	return &policy, nil
+}
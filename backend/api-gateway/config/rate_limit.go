@@ -0,0 +1,89 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 限流状态存储
+const (
+	RateLimitStoreMemory = "memory" // 每个网关实例单独计数
+	RateLimitStoreRedis  = "redis"  // 多个网关实例共享
+)
+
+// RateLimitPolicy 限流策略，从config.yaml格式的文件加载
+type RateLimitPolicy struct {
+	Store   string          `yaml:"store"`
+	Default RateLimitRule   `yaml:"default"`
+	Routes  []RateLimitRule `yaml:"routes"`
+}
+
+// RateLimitRule 一组令牌桶：按客户端IP和按用户（JWT中的user_id）分别计数
+// 路由规则按路径前缀匹配（最长前缀优先），Methods为空表示所有方法；与默认规则同时生效
+type RateLimitRule struct {
+	PathPrefix string       `yaml:"path_prefix"`
+	Methods    []string     `yaml:"methods"`
+	IP         *TokenBucket `yaml:"ip"`
+	User       *TokenBucket `yaml:"user"`
+}
+
+// TokenBucket 令牌桶：每秒补充Rate个令牌，最多积累Burst个，每个请求消耗一个
+type TokenBucket struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// DefaultRateLimitPolicy 未提供配置文件时使用的默认策略：每个IP每秒rps个请求
+func DefaultRateLimitPolicy(rps int) *RateLimitPolicy {
+	return &RateLimitPolicy{
+		Store: RateLimitStoreMemory,
+		Default: RateLimitRule{
+			IP: &TokenBucket{Rate: float64(rps), Burst: rps},
+		},
+	}
+}
+
+// LoadRateLimitPolicy 从YAML文件加载限流策略，文件不存在时使用默认策略
+func LoadRateLimitPolicy(path string, rps int) (*RateLimitPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return DefaultRateLimitPolicy(rps), nil
+		}
+		return nil, fmt.Errorf("failed to read rate limit file: %w", err)
+	}
+
+	var policy RateLimitPolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid rate limit file %s: %w", path, err)
+	}
+	if policy.Store == "" {
+		policy.Store = RateLimitStoreMemory
+	}
+	if policy.Store != RateLimitStoreMemory && policy.Store != RateLimitStoreRedis {
+		return nil, fmt.Errorf("invalid rate limit file %s: unknown store %q", path, policy.Store)
+	}
+
+	rules := []*RateLimitRule{&policy.Default}
+	for i := range policy.Routes {
+		if policy.Routes[i].PathPrefix == "" {
+			return nil, fmt.Errorf("invalid rate limit file %s: route path_prefix is required", path)
+		}
+		rules = append(rules, &policy.Routes[i])
+	}
+	for _, rule := range rules {
+		for i, method := range rule.Methods {
+			rule.Methods[i] = strings.ToUpper(method)
+		}
+		for _, bucket := range []*TokenBucket{rule.IP, rule.User} {
+			if bucket != nil && (bucket.Rate <= 0 || bucket.Burst < 1) {
+				return nil, fmt.Errorf("invalid rate limit file %s: rate must be positive and burst at least 1", path)
+			}
+		}
+	}
+	return &policy, nil
+}
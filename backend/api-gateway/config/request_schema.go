@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultSchemaMaxBodyBytes 未配置max_body_bytes时参与校验的请求体上限
+const defaultSchemaMaxBodyBytes = 1 << 20
+
+// RequestSchemaConfig 请求体JSON Schema校验配置，从路由配置文件加载
+type RequestSchemaConfig struct {
+	MaxBodyBytes int64         `json:"max_body_bytes"` // 需要校验的请求体上限，超过时返回413
+	Routes       []SchemaRoute `json:"routes"`
+}
+
+// SchemaRoute 单条路由引用的Schema，Path为网关对外路径，可用{name}匹配单个路径段
+type SchemaRoute struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Schema string `json:"schema"` // Schema文件路径，相对于路由配置文件所在目录
+	// Document 加载后的Schema文件内容
+	Document json.RawMessage `json:"-"`
+}
+
+// LoadRequestSchemas 从JSON文件加载请求体校验路由及其引用的Schema文件，路由配置文件不存在时返回nil（不校验）
+func LoadRequestSchemas(path string) (*RequestSchemaConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read request schema file: %w", err)
+	}
+
+	var cfg RequestSchemaConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid request schema file %s: %w", path, err)
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = defaultSchemaMaxBodyBytes
+	}
+
+	dir := filepath.Dir(path)
+	for i := range cfg.Routes {
+		route := &cfg.Routes[i]
+		if route.Method == "" || !strings.HasPrefix(route.Path, "/") || route.Schema == "" {
+			return nil, fmt.Errorf("invalid request schema file %s: route method, path and schema are required", path)
+		}
+		route.Method = strings.ToUpper(route.Method)
+
+		schemaPath := route.Schema
+		if !filepath.IsAbs(schemaPath) {
+			schemaPath = filepath.Join(dir, schemaPath)
+		}
+		document, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema for %s %s: %w", route.Method, route.Path, err)
+		}
+		route.Document = document
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,125 @@
+package delivery
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/api-gateway/internal/service"
+)
+
+// statusRecorder 记录响应状态码
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Hijack 支持WebSocket升级，升级成功后按101记录
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Flush 支持流式响应（如SSE）
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap 供http.ResponseController访问底层ResponseWriter
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// AntiAbuse 按IP和用户统计401/404等错误，超过阈值后拖延响应，继续违规则临时封禁
+func (m *Middleware) AntiAbuse(guard *service.AbuseGuard) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identities := m.abuseIdentities(r)
+
+			verdict := guard.Check(r.Context(), identities)
+			if verdict.Banned {
+				retryAfter := int(verdict.RetryAfter.Round(time.Second) / time.Second)
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				http.Error(w, "Too many failed requests, try again later", http.StatusTooManyRequests)
+				return
+			}
+
+			// 拖延响应，降低暴力探测速度
+			if verdict.Delay > 0 {
+				m.logger.Debug("Tarpitting client", zap.Strings("identities", identities), zap.Duration("delay", verdict.Delay))
+				select {
+				case <-time.After(verdict.Delay):
+				case <-r.Context().Done():
+					return
+				}
+			}
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			if guard.IsPenalizedStatus(recorder.status) {
+				// 请求可能已被客户端取消，使用独立的上下文记录
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				for _, identity := range identities {
+					guard.RecordError(ctx, identity, recorder.status)
+				}
+			}
+		})
+	}
+}
+
+// abuseIdentities 请求的惩罚维度：客户端IP，以及令牌有效时的用户ID
+func (m *Middleware) abuseIdentities(r *http.Request) []string {
+	identities := []string{"ip:" + m.clientIP(r)}
+	if userID := m.tokenUserID(r); userID != "" {
+		identities = append(identities, "user:"+userID)
+	}
+	return identities
+}
+
+// clientIP 客户端IP，不含端口
+func (m *Middleware) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// tokenUserID 认证中间件之前识别用户：Bearer令牌有效时返回其中的用户ID，否则返回空
+func (m *Middleware) tokenUserID(r *http.Request) string {
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return ""
+	}
+	claims, err := m.jwtManager.ValidateTokenCached(strings.TrimPrefix(authHeader, "Bearer "))
+	if err != nil {
+		return ""
+	}
+	return claims.UserID
+}
@@ -0,0 +1,34 @@
+package delivery
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/api-gateway/internal/service"
+)
+
+// APIVersion 为废弃版本添加Deprecation/Sunset响应头，已下线的版本返回410，并按版本统计请求
+func (m *Middleware) APIVersion(versions *service.APIVersions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := versions.VersionOf(r.URL.Path)
+			if name == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !versions.ApplyLifecycle(name, w.Header(), time.Now()) {
+				m.logger.Debug("Request to retired API version", zap.String("version", name), zap.String("path", r.URL.Path))
+				versions.Record(name, http.StatusGone, true)
+				http.Error(w, "API version "+name+" is no longer available", http.StatusGone)
+				return
+			}
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			versions.Record(name, recorder.status, false)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package delivery
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/api-gateway/internal/service"
+)
+
+// WithResponseEnvelope 启用统一响应信封，maxBodyBytes为可转换的响应体上限
+func (m *Middleware) WithResponseEnvelope(maxBodyBytes int64) *Middleware {
+	m.envelopeMaxBody = maxBodyBytes
+	return m
+}
+
+// ResponseEnvelope 客户端以Accept: application/vnd.chatapp.v1+json选择统一响应信封，未选择时原样返回
+// 只转换JSON和纯文本错误响应；文件下载、事件流和超过上限的响应原样返回；请求的版本不支持时返回406
+func (m *Middleware) ResponseEnvelope() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			version := service.EnvelopeVersion(r.Header.Get("Accept"))
+			if m.envelopeMaxBody <= 0 || version == 0 || service.IsWebSocketUpgrade(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Add("Vary", "Accept")
+			if !service.IsSupportedEnvelopeVersion(version) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotAcceptable)
+				json.NewEncoder(w).Encode(map[string]interface{}{ // nolint: errcheck
+					"error":              "unsupported response version",
+					"supported_versions": service.SupportedEnvelopeVersions,
+				})
+				return
+			}
+
+			// 后端服务只返回普通JSON
+			r.Header.Set("Accept", "application/json")
+
+			writer := &envelopeWriter{ResponseWriter: w, limit: m.envelopeMaxBody, status: http.StatusOK}
+			next.ServeHTTP(writer, r)
+			if writer.hijacked || writer.passthrough {
+				return
+			}
+
+			envelope := service.NormalizeResponse(version, writer.status, w.Header(), writer.body.Bytes())
+			body, err := json.Marshal(envelope)
+			if err != nil {
+				m.logger.Error("Failed to encode response envelope", zap.String("path", r.URL.Path), zap.Error(err))
+				body = writer.body.Bytes()
+			} else {
+				w.Header().Set("Content-Type", service.EnvelopeMediaType(version))
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(writer.status)
+			w.Write(body) // nolint: errcheck
+		})
+	}
+}
+
+// envelopeWriter 缓冲可转换为信封的响应，不可转换或超过上限时切换为直接写出
+type envelopeWriter struct {
+	http.ResponseWriter
+	limit       int64
+	status      int
+	wroteHeader bool
+	passthrough bool
+	hijacked    bool
+	body        bytes.Buffer
+}
+
+func (w *envelopeWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+	if status == http.StatusNoContent || status == http.StatusNotModified || !service.IsEnvelopable(w.Header()) {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *envelopeWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(data)
+	}
+	if int64(w.body.Len()+len(data)) > w.limit {
+		// 响应过大，已缓冲的部分和后续内容原样写出
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(w.status)
+		if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+			return 0, err
+		}
+		w.body.Reset()
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+// Flush 直接写出的响应支持流式刷新，缓冲中的响应在处理结束后统一写出
+func (w *envelopeWriter) Flush() {
+	if !w.passthrough {
+		return
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 支持连接接管
+func (w *envelopeWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+	w.hijacked = true
+	return hijacker.Hijack()
+}
+
+// Unwrap 供http.ResponseController访问底层ResponseWriter
+func (w *envelopeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
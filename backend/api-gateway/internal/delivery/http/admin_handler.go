@@ -0,0 +1,142 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/api-gateway/internal/delivery"
+	"github.com/neohope/chatapp/api-gateway/internal/service"
+)
+
+// AdminHandler 网关管理接口
+type AdminHandler struct {
+	abuseGuard   *service.AbuseGuard
+	proxyService *service.ProxyService
+	middleware   *delivery.Middleware
+	adminUserIDs map[string]bool
+	versions     *service.APIVersions // 为nil时不提供版本流量统计
+	logger       *zap.Logger
+}
+
+// NewAdminHandler 创建网关管理处理器
+func NewAdminHandler(abuseGuard *service.AbuseGuard, proxyService *service.ProxyService, middleware *delivery.Middleware, adminUserIDs []string, logger *zap.Logger) *AdminHandler {
+	admins := make(map[string]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = true
+	}
+
+	return &AdminHandler{
+		abuseGuard:   abuseGuard,
+		proxyService: proxyService,
+		middleware:   middleware,
+		adminUserIDs: admins,
+		logger:       logger,
+	}
+}
+
+// WithAPIVersions 提供各API版本的流量统计
+func (h *AdminHandler) WithAPIVersions(versions *service.APIVersions) *AdminHandler {
+	h.versions = versions
+	return h
+}
+
+// RegisterRoutes 注册管理路由（需要管理员身份）
+func (h *AdminHandler) RegisterRoutes(router *mux.Router) {
+	adminRoutes := router.PathPrefix("/api/v1/admin").Subrouter()
+	adminRoutes.Use(h.middleware.JWTAuth())
+	adminRoutes.Use(h.adminOnly)
+	adminRoutes.HandleFunc("/uploads/metrics", h.uploadMetrics).Methods("GET")
+	adminRoutes.HandleFunc("/ws/sessions", h.listSessions).Methods("GET")
+	adminRoutes.HandleFunc("/ws/users/{userId}/disconnect", h.disconnectUser).Methods("POST")
+	if h.versions != nil {
+		adminRoutes.HandleFunc("/versions/metrics", h.versionMetrics).Methods("GET")
+	}
+	// 未启用防滥用时不注册封禁管理接口
+	if h.abuseGuard != nil {
+		adminRoutes.HandleFunc("/abuse/bans", h.listBans).Methods("GET")
+		adminRoutes.HandleFunc("/abuse/bans/{identity}", h.liftBan).Methods("DELETE")
+	}
+}
+
+// adminOnly 仅允许管理员访问：令牌角色为admin或在配置的管理员列表中
+func (h *AdminHandler) adminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value("user_id").(string)
+		role, _ := r.Context().Value("role").(string)
+		if role != "admin" && !h.adminUserIDs[userID] {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// uploadMetrics 查看上传转发统计
+func (h *AdminHandler) uploadMetrics(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, h.proxyService.UploadMetrics())
+}
+
+// versionMetrics 查看各API版本的请求数、错误数和生命周期状态
+func (h *AdminHandler) versionMetrics(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, h.versions.Metrics(time.Now()))
+}
+
+// listSessions 查看WebSocket会话，支持 ?user_id= 过滤，会话登记由消息服务维护
+func (h *AdminHandler) listSessions(w http.ResponseWriter, r *http.Request) {
+	h.proxyService.ProxyTo(w, r, "messages", "/internal/ws/sessions")
+}
+
+// disconnectUser 断开用户在所有消息服务实例上的WebSocket连接（如封禁账户后）
+func (h *AdminHandler) disconnectUser(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userId"]
+
+	h.logger.Info("Disconnecting user sessions", zap.String("user_id", userID), zap.Any("admin_id", r.Context().Value("user_id")))
+	h.proxyService.ProxyTo(w, r, "messages", "/internal/users/"+url.PathEscape(userID)+"/disconnect")
+}
+
+// listBans 查看当前生效的封禁
+func (h *AdminHandler) listBans(w http.ResponseWriter, r *http.Request) {
+	bans, err := h.abuseGuard.ListBans(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to list bans", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, bans)
+}
+
+// liftBan 解除封禁，identity形如 ip:1.2.3.4 或 user:<用户ID>
+func (h *AdminHandler) liftBan(w http.ResponseWriter, r *http.Request) {
+	identity := mux.Vars(r)["identity"]
+
+	removed, err := h.abuseGuard.Lift(r.Context(), identity)
+	if err != nil {
+		h.logger.Error("Failed to lift ban", zap.String("identity", identity), zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !removed {
+		http.Error(w, "Ban not found", http.StatusNotFound)
+		return
+	}
+
+	h.logger.Info("Ban lifted", zap.String("identity", identity), zap.Any("admin_id", r.Context().Value("user_id")))
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Ban lifted",
+	})
+}
+
+func (h *AdminHandler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("Failed to encode response", zap.Error(err))
+	}
+}
@@ -15,12 +15,16 @@ import (
 type Handler struct {
 	proxyService *service.ProxyService
 	middleware   *delivery.Middleware
-	logger       *zap.Logger
+	// versions v1以外的API版本路由，为nil时只提供v1
+	versions *service.APIVersions
+	logger   *zap.Logger
 }
 
 type HealthResponse struct {
 	Status   string          `json:"status"`
 	Services map[string]bool `json:"services"`
+	// CircuitBreakers 各后端服务的熔断器状态，未启用熔断时不返回
+	CircuitBreakers map[string]service.CircuitBreakerSnapshot `json:"circuit_breakers,omitempty"`
 }
 
 func NewHandler(proxyService *service.ProxyService, middleware *delivery.Middleware, logger *zap.Logger) *Handler {
@@ -31,6 +35,12 @@ func NewHandler(proxyService *service.ProxyService, middleware *delivery.Middlew
 	}
 }
 
+// WithAPIVersions 启用API版本路由和版本生命周期响应头
+func (h *Handler) WithAPIVersions(versions *service.APIVersions) *Handler {
+	h.versions = versions
+	return h
+}
+
 func (h *Handler) RegisterRoutes(router *mux.Router, corsConfig struct {
 	AllowedOrigins []string
 	AllowedMethods []string
@@ -53,8 +63,13 @@ func (h *Handler) RegisterRoutes(router *mux.Router, corsConfig struct {
 
 	// 应用全局中间件 - CORS必须在最前面
 	router.Use(h.middleware.CORS(corsConfig.AllowedOrigins, corsConfig.AllowedMethods, corsConfig.AllowedHeaders))
+	// 统一响应信封在其余中间件之外，网关自身返回的错误（限流、认证失败等）也会被转换
+	router.Use(h.middleware.ResponseEnvelope())
 	router.Use(h.middleware.Logging())
 	router.Use(h.middleware.RateLimit())
+	if h.versions != nil {
+		router.Use(h.middleware.APIVersion(h.versions))
+	}
 
 	// 健康检查端点（无需认证）
 	router.HandleFunc("/health", h.HealthCheck).Methods("GET")
@@ -72,9 +87,22 @@ func (h *Handler) RegisterRoutes(router *mux.Router, corsConfig struct {
 	// 登录和注册不需要认证
 	userRoutes.HandleFunc("/register", h.proxyToUserService).Methods("POST")
 	userRoutes.HandleFunc("/login", h.proxyToUserService).Methods("POST")
+	// 刷新令牌放在请求体中，不经过JWT中间件（中间件拒绝刷新令牌）
+	userRoutes.HandleFunc("/refresh", h.proxyToUserService).Methods("POST")
+	userRoutes.HandleFunc("/logout", h.proxyToUserService).Methods("POST")
+	userRoutes.HandleFunc("/reactivate", h.proxyToUserService).Methods("POST")
+	userRoutes.HandleFunc("/availability", h.proxyToUserService).Methods("GET")
+	userRoutes.HandleFunc("/invitations/accept", h.proxyToUserService).Methods("POST")
+	// 企业单点登录（浏览器跳转流程）
+	userRoutes.HandleFunc("/sso/{tenant}/login", h.proxyToUserService).Methods("GET")
+	userRoutes.HandleFunc("/sso/{tenant}/oidc/callback", h.proxyToUserService).Methods("GET")
+	userRoutes.HandleFunc("/sso/{tenant}/saml/acs", h.proxyToUserService).Methods("POST")
+	userRoutes.HandleFunc("/sso/{tenant}/saml/metadata", h.proxyToUserService).Methods("GET")
 	// 专门的OPTIONS处理器
 	userRoutes.HandleFunc("/register", h.handleOptions).Methods("OPTIONS")
 	userRoutes.HandleFunc("/login", h.handleOptions).Methods("OPTIONS")
+	userRoutes.HandleFunc("/refresh", h.handleOptions).Methods("OPTIONS")
+	userRoutes.HandleFunc("/logout", h.handleOptions).Methods("OPTIONS")
 	// 用户群组相关路由（需要认证）- 代理到群组服务
 	userRoutes.HandleFunc("/{userId}/groups", h.middleware.JWTAuth()(http.HandlerFunc(h.proxyToGroupService)).ServeHTTP).Methods("GET")
 	// 其他用户相关操作需要认证 - 使用更具体的路径模式
@@ -88,6 +116,16 @@ func (h *Handler) RegisterRoutes(router *mux.Router, corsConfig struct {
 	userAuthRoutes.HandleFunc("/contacts/{contactId}", h.proxyToUserService).Methods("DELETE")
 	userAuthRoutes.HandleFunc("/contacts/{contactId}/favorite", h.proxyToUserService).Methods("POST")
 	userAuthRoutes.HandleFunc("/change-password", h.proxyToUserService).Methods("POST")
+	// 服务条款/隐私政策同意
+	userAuthRoutes.HandleFunc("/policies", h.proxyToUserService).Methods("GET")
+	userAuthRoutes.HandleFunc("/me/consents", h.proxyToUserService).Methods("GET", "POST")
+	userAuthRoutes.HandleFunc("/me/privacy", h.proxyToUserService).Methods("GET", "PUT")
+	userAuthRoutes.HandleFunc("/me/deactivate", h.proxyToUserService).Methods("POST")
+	userAuthRoutes.HandleFunc("/admin/policies", h.proxyToUserService).Methods("GET", "POST")
+	userAuthRoutes.HandleFunc("/admin/sso/providers", h.proxyToUserService).Methods("GET", "PUT")
+	userAuthRoutes.HandleFunc("/admin/sso/providers/{tenant}", h.proxyToUserService).Methods("DELETE")
+	userAuthRoutes.HandleFunc("/admin/imports", h.proxyToUserService).Methods("GET", "POST")
+	userAuthRoutes.HandleFunc("/admin/imports/{id}", h.proxyToUserService).Methods("GET")
 	// 避免与 /{userId}/groups 冲突，使用更具体的路径
 	userAuthRoutes.HandleFunc("/{userId}", h.proxyToUserService).Methods("GET", "PUT", "DELETE")
 	userAuthRoutes.HandleFunc("/{userId}/profile", h.proxyToUserService).Methods("GET", "PUT")
@@ -96,6 +134,7 @@ func (h *Handler) RegisterRoutes(router *mux.Router, corsConfig struct {
 	// 好友请求相关路由（需要认证）- 代理到用户服务
 	friendRoutes := api.PathPrefix("/friends").Subrouter()
 	friendRoutes.Use(h.middleware.JWTAuth())
+	friendRoutes.Use(h.middleware.Idempotency())
 	friendRoutes.HandleFunc("/request", h.proxyToUserService).Methods("POST")
 	friendRoutes.HandleFunc("/accept", h.proxyToUserService).Methods("POST")
 	friendRoutes.HandleFunc("/reject", h.proxyToUserService).Methods("POST")
@@ -110,19 +149,31 @@ func (h *Handler) RegisterRoutes(router *mux.Router, corsConfig struct {
 	// 群组服务路由（需要认证）
 	groupRoutes := api.PathPrefix("/groups").Subrouter()
 	groupRoutes.Use(h.middleware.JWTAuth())
+	groupRoutes.Use(h.middleware.Idempotency())
 	groupRoutes.PathPrefix("/").HandlerFunc(h.proxyToGroupService)
 
 	// 消息服务路由（需要认证）
 	messageRoutes := api.PathPrefix("/messages").Subrouter()
 	messageRoutes.Use(h.middleware.JWTAuth())
+	messageRoutes.Use(h.middleware.Idempotency())
 	messageRoutes.PathPrefix("/").HandlerFunc(h.proxyToMessageService)
 
 	// 会话服务路由（需要认证）- 也代理到消息服务
-	api.PathPrefix("/conversations").Handler(h.middleware.JWTAuth()(http.HandlerFunc(h.proxyToMessageService)))
+	api.PathPrefix("/conversations").Handler(h.middleware.JWTAuth()(h.middleware.Idempotency()(http.HandlerFunc(h.proxyToMessageService))))
+
+	// 在线状态路由（需要认证）- 由消息服务维护
+	api.PathPrefix("/presence").Handler(h.middleware.JWTAuth()(http.HandlerFunc(h.proxyToMessageService)))
+
+	// 头像签名链接（无需认证，由媒体服务校验签名），必须在 /media PathPrefix 之前注册
+	api.HandleFunc("/media/avatar/{userId}", h.proxyToMediaService).Methods("GET")
+
+	// 文件签名公开链接（无需认证，由媒体服务校验签名）
+	api.HandleFunc("/media/public/{id}", h.proxyToMediaService).Methods("GET")
 
 	// 媒体服务路由（需要认证）
 	mediaRoutes := api.PathPrefix("/media").Subrouter()
 	mediaRoutes.Use(h.middleware.JWTAuth())
+	mediaRoutes.Use(h.middleware.Idempotency())
 	mediaRoutes.PathPrefix("/").HandlerFunc(h.proxyToMediaService)
 
 	// 通知服务路由（需要认证）
@@ -132,6 +183,38 @@ func (h *Handler) RegisterRoutes(router *mux.Router, corsConfig struct {
 
 	// WebSocket路由（需要认证）
 	api.HandleFunc("/ws", h.middleware.JWTAuth()(http.HandlerFunc(h.proxyToMessageServiceWS)).ServeHTTP).Methods("GET")
+
+	// 其他API版本按配置映射到后端服务的路径
+	if h.versions != nil {
+		for _, version := range h.versions.RoutedVersions() {
+			router.PathPrefix("/api/" + version + "/").Handler(h.versionedHandler(version))
+		}
+	}
+}
+
+// versionedHandler 按版本路由转发请求：路径改写为后端路径后经过与v1相同的认证、幂等键和请求体校验
+func (h *Handler) versionedHandler(version string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, upstreamPath := h.versions.Resolve(version, r.Method, r.URL.Path)
+		if route == nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		rewritten := *r.URL
+		rewritten.Path = upstreamPath
+		rewritten.RawPath = ""
+		upstream := r.WithContext(r.Context())
+		upstream.URL = &rewritten
+
+		var next http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.proxyService.ProxyRequest(w, r, route.Service)
+		})
+		if !route.Public {
+			next = h.middleware.JWTAuth()(h.middleware.Idempotency()(next))
+		}
+		next.ServeHTTP(w, upstream)
+	})
 }
 
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
@@ -151,8 +234,9 @@ func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := HealthResponse{
-		Status:   status,
-		Services: servicesHealth,
+		Status:          status,
+		Services:        servicesHealth,
+		CircuitBreakers: h.proxyService.CircuitBreakers(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -217,23 +301,7 @@ func (h *Handler) proxyToNotificationService(w http.ResponseWriter, r *http.Requ
 }
 
 func (h *Handler) proxyToMessageServiceWS(w http.ResponseWriter, r *http.Request) {
-	// WebSocket代理需要特殊处理
-	// 这里简化处理，直接转发到消息服务的WebSocket端点
-	messageServiceURL := "ws://localhost:8083/ws"
-
-	// 获取用户信息
-	userID := r.Context().Value("user_id")
-	if userID != nil {
-		// 添加用户ID到查询参数
-		if r.URL.RawQuery != "" {
-			r.URL.RawQuery += "&user_id=" + userID.(string)
-		} else {
-			r.URL.RawQuery = "user_id=" + userID.(string)
-		}
-	}
-
-	// 重定向到消息服务的WebSocket
-	http.Redirect(w, r, messageServiceURL+"?"+r.URL.RawQuery, http.StatusTemporaryRedirect)
+	h.proxyService.ProxyWebSocket(w, r, "messages", "/ws")
 }
 
 // 辅助函数：从路径中提取服务名
@@ -0,0 +1,25 @@
+package http
+
+import (
+	"github.com/neohope/chatapp/api-gateway/internal/service"
+	"github.com/neohope/chatapp/api-gateway/pkg/openapi"
+)
+
+// messageResponse 只包含结果和提示信息的响应，只用于生成接口文档
+type messageResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// APIOperations 网关自己处理的接口说明，与各服务的文档合并后由/openapi.json返回
+// 只在启用相应功能时注册的管理接口在合并时按路由过滤
+var APIOperations = openapi.Operations{
+	"GET /api/v1/auth/validate":                       {Summary: "校验访问令牌", Response: messageResponse{}},
+	"GET /api/v1/ws":                                  {Summary: "建立WebSocket连接，校验令牌后转发到消息服务", Description: "浏览器无法设置请求头时可以用token查询参数传递令牌", Query: []string{"token", "device"}, Status: 101},
+	"GET /api/v1/admin/uploads/metrics":               {Summary: "查看上传转发统计", Response: service.UploadMetricsSnapshot{}},
+	"GET /api/v1/admin/versions/metrics":              {Summary: "查看各API版本的请求数、错误数和生命周期状态", Response: map[string]service.APIVersionSnapshot{}},
+	"GET /api/v1/admin/ws/sessions":                   {Summary: "查看WebSocket会话", Query: []string{"user_id"}},
+	"POST /api/v1/admin/ws/users/{userId}/disconnect": {Summary: "断开用户在所有消息服务实例上的WebSocket连接"},
+	"GET /api/v1/admin/abuse/bans":                    {Summary: "查看当前生效的封禁", Response: []*service.AbuseBan{}},
+	"DELETE /api/v1/admin/abuse/bans/{identity}":      {Summary: "解除封禁，identity形如ip:1.2.3.4或user:<用户ID>", Response: messageResponse{}},
+}
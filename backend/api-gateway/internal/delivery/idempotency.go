@@ -0,0 +1,205 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/api-gateway/internal/service"
+)
+
+const (
+	// IdempotencyKeyHeader 客户端传入的幂等键请求头
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader 响应为重放的已保存响应时设置该响应头
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength 幂等键的最大长度
+	maxIdempotencyKeyLength = 255
+)
+
+// WithIdempotency 启用幂等键中间件
+func (m *Middleware) WithIdempotency(store *service.IdempotencyStore) *Middleware {
+	m.idempotency = store
+	return m
+}
+
+// Idempotency 对带有Idempotency-Key请求头的写请求保存响应，相同幂等键的重试直接重放保存的响应
+// 需要在JWTAuth之后使用，幂等键按用户隔离；未启用时直接放行
+func (m *Middleware) Idempotency() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if m.idempotency == nil || key == "" || !isMutatingMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			userID, _ := r.Context().Value("user_id").(string)
+			keyHash := sha256.Sum256([]byte(key))
+			storeKey := hex.EncodeToString(keyHash[:])
+
+			requestHash, err := m.requestHash(r)
+			if err != nil {
+				http.Error(w, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+
+			stored, err := m.idempotency.Begin(r.Context(), userID, storeKey, requestHash)
+			switch {
+			case errors.Is(err, service.ErrIdempotencyInProgress):
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			case errors.Is(err, service.ErrIdempotencyKeyReused):
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			case err != nil:
+				// Redis不可用时放行，不影响正常请求
+				m.logger.Warn("Idempotency store unavailable, request not deduplicated", zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			case stored != nil:
+				replayResponse(w, stored)
+				return
+			}
+
+			recorder := &responseRecorder{
+				ResponseWriter: w,
+				status:         http.StatusOK,
+				limit:          m.idempotency.MaxBodyBytes(),
+			}
+			next.ServeHTTP(recorder, r)
+
+			// 请求可能已被客户端取消，使用独立的上下文保存结果
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			if !m.idempotency.ShouldStore(recorder.status) || recorder.overflow {
+				m.idempotency.Release(ctx, userID, storeKey)
+				return
+			}
+			response := &service.StoredResponse{
+				Status: recorder.status,
+				Header: storableHeader(recorder.Header()),
+				Body:   recorder.body.Bytes(),
+			}
+			if err := m.idempotency.Complete(ctx, userID, storeKey, requestHash, response); err != nil {
+				m.logger.Warn("Failed to store idempotent response", zap.String("user_id", userID), zap.Error(err))
+				m.idempotency.Release(ctx, userID, storeKey)
+			}
+		})
+	}
+}
+
+// requestHash 计算请求摘要，用于识别同一幂等键被用于不同的请求
+// multipart请求每次重试的分隔符不同，超过大小上限的请求体不缓冲，二者只按方法、路径和查询参数计算
+func (m *Middleware) requestHash(r *http.Request) (string, error) {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+"\n") // nolint: errcheck
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	limit := m.idempotency.MaxBodyBytes()
+	if r.Body == nil || r.Body == http.NoBody || mediaType == "multipart/form-data" || r.ContentLength > limit {
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	// 读取不超过上限的请求体参与摘要，读取的内容重新放回请求体继续转发
+	buffered, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(buffered)) > limit {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buffered), r.Body), r.Body}
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(buffered))
+	r.ContentLength = int64(len(buffered))
+	r.Header.Set("Content-Length", strconv.Itoa(len(buffered)))
+	hash.Write(buffered)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// replayResponse 重放保存的响应
+func replayResponse(w http.ResponseWriter, stored *service.StoredResponse) {
+	for name, values := range stored.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body) // nolint: errcheck
+}
+
+// storableHeader 需要保存的响应头，CORS等由网关中间件在每次请求时重新设置的响应头不保存
+func storableHeader(header http.Header) http.Header {
+	stored := make(http.Header, len(header))
+	for name, values := range header {
+		if strings.HasPrefix(name, "Access-Control-") || name == "Date" || name == "Content-Length" {
+			continue
+		}
+		stored[name] = append([]string(nil), values...)
+	}
+	return stored
+}
+
+// isMutatingMethod 是否为写请求
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// responseRecorder 在写出响应的同时记录状态码和响应体，响应体超过上限时停止记录
+type responseRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	limit    int64
+	overflow bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if !r.overflow {
+		if int64(r.body.Len()+len(data)) > r.limit {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(data)
+		}
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// Flush 支持流式响应
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
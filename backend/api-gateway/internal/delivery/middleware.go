@@ -2,43 +2,63 @@ package delivery
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/neohope/chatapp/api-gateway/internal/service"
 	"github.com/neohope/chatapp/api-gateway/pkg/auth"
 )
 
 type Middleware struct {
 	jwtManager  *auth.JWTManager
 	logger      *zap.Logger
-	rateLimiter *RateLimiter
+	// rateLimiter 按IP、用户和路由的令牌桶限流器，为nil时不限流
+	rateLimiter *service.RateLimiter
+	// cacheBypass 这些路径前缀的请求不使用令牌校验缓存，每次完整校验
+	cacheBypass []string
+	// introspector 第三方客户端不透明令牌的内省器，为nil时只接受内部JWT
+	introspector *auth.Introspector
+	// consent 强制协议同意状态查询，为nil时不检查
+	consent *service.ConsentClient
+	// account 账户状态和令牌版本查询，为nil时只校验令牌本身
+	account *service.AccountClient
+	// idempotency 写请求幂等键存储，为nil时幂等键中间件直接放行
+	idempotency *service.IdempotencyStore
+	// envelopeMaxBody 可转换为统一响应信封的响应体上限，0表示不启用
+	envelopeMaxBody int64
 }
 
-type RateLimiter struct {
-	mu      sync.Mutex
-	clients map[string]*Client
-	rps     int
-	enabled bool
+func NewMiddleware(jwtManager *auth.JWTManager, logger *zap.Logger, cacheBypass []string) *Middleware {
+	return &Middleware{
+		jwtManager:  jwtManager,
+		logger:      logger,
+		cacheBypass: cacheBypass,
+	}
 }
 
-type Client struct {
-	lastSeen time.Time
-	tokens   int
+// WithConsentCheck 启用强制协议同意检查
+func (m *Middleware) WithConsentCheck(consent *service.ConsentClient) *Middleware {
+	m.consent = consent
+	return m
 }
 
-func NewMiddleware(jwtManager *auth.JWTManager, logger *zap.Logger, rateLimitEnabled bool, rps int) *Middleware {
-	return &Middleware{
-		jwtManager: jwtManager,
-		logger:     logger,
-		rateLimiter: &RateLimiter{
-			clients: make(map[string]*Client),
-			rps:     rps,
-			enabled: rateLimitEnabled,
-		},
-	}
+// WithAccountCheck 启用账户状态和令牌版本检查，停用、封禁或角色变更后已签发的令牌随即失效
+func (m *Middleware) WithAccountCheck(account *service.AccountClient) *Middleware {
+	m.account = account
+	return m
+}
+
+// WithIntrospector 启用第三方客户端不透明令牌的内省校验
+func (m *Middleware) WithIntrospector(introspector *auth.Introspector) *Middleware {
+	m.introspector = introspector
+	return m
 }
 
 // CORS middleware
@@ -74,6 +94,8 @@ func (m *Middleware) CORS(allowedOrigins, allowedMethods, allowedHeaders []strin
 			w.Header().Set("Access-Control-Allow-Methods", joinStrings(allowedMethods, ", "))
 			w.Header().Set("Access-Control-Allow-Headers", joinStrings(allowedHeaders, ", "))
 			w.Header().Set("Access-Control-Max-Age", "86400") // 24小时预检缓存
+			// 分页接口通过Link和X-Next-Cursor响应头返回翻页链接，幂等重放的响应带有Idempotent-Replayed，废弃的API版本带有Deprecation和Sunset
+			w.Header().Set("Access-Control-Expose-Headers", "Link, X-Next-Cursor, Idempotent-Replayed, Deprecation, Sunset")
 
 			// 处理预检请求
 			if r.Method == "OPTIONS" {
@@ -101,23 +123,49 @@ func (m *Middleware) CORS(allowedOrigins, allowedMethods, allowedHeaders []strin
 func (m *Middleware) JWTAuth() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			token, err := m.jwtManager.ExtractTokenFromHeader(r)
+			token, err := m.extractToken(r)
 			if err != nil {
 				m.logger.Warn("Failed to extract token", zap.Error(err))
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
-			claims, err := m.jwtManager.ValidateToken(token)
+			var claims *auth.Claims
+			introspected := m.introspector != nil && auth.IsOpaqueToken(token)
+			if introspected {
+				claims, err = m.introspector.Validate(r.Context(), token, m.bypassCache(r))
+			} else {
+				claims, err = m.validateToken(r, token)
+			}
 			if err != nil {
 				m.logger.Warn("Invalid token", zap.Error(err))
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
+			claims, ok := m.checkAccount(w, r, claims, introspected)
+			if !ok {
+				return
+			}
+			if introspected {
+				if err := m.forwardInternalToken(r, claims); err != nil {
+					m.logger.Error("Failed to generate internal token", zap.Error(err))
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+					return
+				}
+			}
+			if !m.checkConsent(w, r, claims.UserID) {
+				return
+			}
 
 			// Add user info to context
 			ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
 			ctx = context.WithValue(ctx, "email", claims.Email)
+			if claims.Role != "" {
+				ctx = context.WithValue(ctx, "role", claims.Role)
+			}
+			if claims.ClientID != "" {
+				ctx = context.WithValue(ctx, "client_id", claims.ClientID)
+			}
 			r = r.WithContext(ctx)
 
 			next.ServeHTTP(w, r)
@@ -125,18 +173,156 @@ func (m *Middleware) JWTAuth() func(http.Handler) http.Handler {
 	}
 }
 
+// checkAccount 按用户服务中的当前记录校验账户：不是活跃状态返回403，令牌版本不一致返回401，无法查询时返回503，不放行
+// 通过后返回的声明使用当前角色和令牌版本；内省的外部令牌没有版本，按当前版本签发内部令牌
+func (m *Middleware) checkAccount(w http.ResponseWriter, r *http.Request, claims *auth.Claims, introspected bool) (*auth.Claims, bool) {
+	if m.account == nil {
+		return claims, true
+	}
+
+	account, err := m.account.Get(r.Context(), claims.UserID)
+	if errors.Is(err, service.ErrAccountNotFound) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	if err != nil {
+		m.logger.Warn("Failed to check account status", zap.String("user_id", claims.UserID), zap.Error(err))
+		http.Error(w, "Account status unavailable", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	if !account.Active() {
+		http.Error(w, "Account is not active", http.StatusForbidden)
+		return nil, false
+	}
+	if !introspected && claims.TokenVersion != account.TokenVersion {
+		http.Error(w, "Token has been revoked", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	// 校验结果可能来自缓存，复制后再修改
+	current := *claims
+	current.Role = account.Role
+	current.TokenVersion = account.TokenVersion
+	return &current, true
+}
+
+// checkConsent 用户有尚未同意的强制协议时返回403，无法查询时返回503，不放行
+// 同意协议和查看协议内容的路径不检查
+func (m *Middleware) checkConsent(w http.ResponseWriter, r *http.Request, userID string) bool {
+	if m.consent == nil || isConsentExempt(r.URL.Path) {
+		return true
+	}
+
+	pending, err := m.consent.HasPending(r.Context(), userID)
+	if err != nil {
+		m.logger.Warn("Failed to check consent status", zap.String("user_id", userID), zap.Error(err))
+		http.Error(w, "Consent status unavailable", http.StatusServiceUnavailable)
+		return false
+	}
+	if pending {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "You must accept the latest terms before continuing",
+			"code":  "consent_required",
+		})
+		return false
+	}
+	return true
+}
+
+// consentExemptPaths 未同意最新协议时仍可访问的受保护路径，与用户服务保持一致
+var consentExemptPaths = []string{
+	"/api/v1/auth/validate",
+	"/api/v1/users/logout",
+	"/api/v1/users/me",
+	"/api/v1/users/me/consents",
+	"/api/v1/users/policies",
+	"/api/v1/users/admin/policies",
+}
+
+// isConsentExempt 检查路径是否无需同意协议即可访问
+func isConsentExempt(path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	for _, p := range consentExemptPaths {
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// extractToken 从Authorization头获取令牌；浏览器无法为WebSocket握手设置请求头，升级请求也接受token查询参数
+func (m *Middleware) extractToken(r *http.Request) (string, error) {
+	token, err := m.jwtManager.ExtractTokenFromHeader(r)
+	if err != nil && r.Header.Get("Authorization") == "" && service.IsWebSocketUpgrade(r) {
+		if queryToken := r.URL.Query().Get("token"); queryToken != "" {
+			return queryToken, nil
+		}
+	}
+	return token, err
+}
+
+// validateToken 校验令牌，敏感路径绕过缓存并刷新缓存结果
+func (m *Middleware) validateToken(r *http.Request, token string) (*auth.Claims, error) {
+	if m.bypassCache(r) {
+		m.jwtManager.Invalidate(token)
+		return m.jwtManager.ValidateToken(token)
+	}
+	return m.jwtManager.ValidateTokenCached(token)
+}
+
+// forwardInternalToken 第三方客户端的不透明令牌内省通过后，将请求的Authorization替换为短期内部令牌
+// 后端服务仍按内部JWT校验，不需要感知外部IdP
+func (m *Middleware) forwardInternalToken(r *http.Request, claims *auth.Claims) error {
+	internal, err := m.jwtManager.GenerateInternalToken(claims)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", "Bearer "+internal)
+	return nil
+}
+
+// bypassCache 敏感路径不使用令牌校验缓存
+func (m *Middleware) bypassCache(r *http.Request) bool {
+	for _, prefix := range m.cacheBypass {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithRateLimiter 启用令牌桶限流
+func (m *Middleware) WithRateLimiter(limiter *service.RateLimiter) *Middleware {
+	m.rateLimiter = limiter
+	return m
+}
+
 // Rate limiting middleware
+// 按客户端IP和令牌中的用户ID分别计数，超出限制时返回429和Retry-After
 func (m *Middleware) RateLimit() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if !m.rateLimiter.enabled {
+			if m.rateLimiter == nil || r.Method == http.MethodOptions {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			clientIP := r.RemoteAddr
-			if !m.rateLimiter.Allow(clientIP) {
-				m.logger.Warn("Rate limit exceeded", zap.String("client_ip", clientIP))
+			clientIP, userID := m.clientIP(r), m.tokenUserID(r)
+			result := m.rateLimiter.Allow(r.Context(), r.Method, r.URL.Path, clientIP, userID)
+			if !result.Allowed {
+				m.logger.Warn("Rate limit exceeded",
+					zap.String("client_ip", clientIP),
+					zap.String("user_id", userID),
+					zap.String("scope", result.Scope),
+					zap.String("rule", result.Rule),
+				)
+				retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
 				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
 				return
 			}
@@ -164,36 +350,6 @@ func (m *Middleware) Logging() func(http.Handler) http.Handler {
 	}
 }
 
-func (rl *RateLimiter) Allow(clientIP string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	now := time.Now()
-	client, exists := rl.clients[clientIP]
-
-	if !exists {
-		rl.clients[clientIP] = &Client{
-			lastSeen: now,
-			tokens:   rl.rps - 1,
-		}
-		return true
-	}
-
-	// Clean up old clients
-	if now.Sub(client.lastSeen) > time.Minute {
-		client.tokens = rl.rps
-	}
-
-	client.lastSeen = now
-
-	if client.tokens > 0 {
-		client.tokens--
-		return true
-	}
-
-	return false
-}
-
 func joinStrings(strs []string, sep string) string {
 	if len(strs) == 0 {
 		return ""
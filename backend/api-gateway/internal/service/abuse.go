@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/api-gateway/config"
+)
+
+// Redis键前缀
+const (
+	abuseErrorKeyPrefix  = "gateway:abuse:errors:"
+	abuseStrikeKeyPrefix = "gateway:abuse:strikes:"
+	abuseBanKeyPrefix    = "gateway:abuse:ban:"
+)
+
+// AbuseVerdict 请求处理前的惩罚判定
+type AbuseVerdict struct {
+	Banned     bool
+	RetryAfter time.Duration // 封禁剩余时间
+	Delay      time.Duration // 拖延（tarpit）时长
+}
+
+// AbuseBan 封禁记录
+type AbuseBan struct {
+	Identity  string    `json:"identity"` // ip:<地址> 或 user:<用户ID>
+	Reason    string    `json:"reason"`
+	Strikes   int64     `json:"strikes"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AbuseGuard 统计客户端的401/404等错误率，并施加递进惩罚：先拖延响应，再临时封禁，重复违规时封禁时间翻倍
+type AbuseGuard struct {
+	client *redis.Client
+	cfg    config.AbuseConfig
+	logger *zap.Logger
+}
+
+// NewAbuseGuard 创建防滥用守卫
+func NewAbuseGuard(client *redis.Client, cfg config.AbuseConfig, logger *zap.Logger) *AbuseGuard {
+	return &AbuseGuard{
+		client: client,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// IsPenalizedStatus 是否计入错误率的响应状态码
+func (g *AbuseGuard) IsPenalizedStatus(status int) bool {
+	for _, s := range g.cfg.PenalizedStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Check 在转发前检查各身份的封禁和拖延状态，Redis不可用时放行
+func (g *AbuseGuard) Check(ctx context.Context, identities []string) AbuseVerdict {
+	var verdict AbuseVerdict
+
+	pipe := g.client.Pipeline()
+	banTTLs := make([]*redis.DurationCmd, len(identities))
+	errorCounts := make([]*redis.StringCmd, len(identities))
+	for i, identity := range identities {
+		banTTLs[i] = pipe.PTTL(ctx, abuseBanKeyPrefix+identity)
+		errorCounts[i] = pipe.Get(ctx, abuseErrorKeyPrefix+identity)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		g.logger.Warn("Abuse state lookup failed", zap.Error(err))
+		return verdict
+	}
+
+	for i := range identities {
+		if ttl := banTTLs[i].Val(); ttl > 0 {
+			verdict.Banned = true
+			if ttl > verdict.RetryAfter {
+				verdict.RetryAfter = ttl
+			}
+		}
+
+		count, _ := errorCounts[i].Int64()
+		if delay := g.tarpitDelay(count); delay > verdict.Delay {
+			verdict.Delay = delay
+		}
+	}
+	return verdict
+}
+
+// RecordError 记录一次错误响应，达到阈值时封禁该身份
+func (g *AbuseGuard) RecordError(ctx context.Context, identity string, status int) {
+	errorKey := abuseErrorKeyPrefix + identity
+
+	count, err := g.client.Incr(ctx, errorKey).Result()
+	if err != nil {
+		g.logger.Warn("Failed to record abuse error", zap.String("identity", identity), zap.Error(err))
+		return
+	}
+	// 固定窗口：首次错误时开始计时
+	if count == 1 {
+		g.client.Expire(ctx, errorKey, g.cfg.Window)
+	}
+
+	if count < int64(g.cfg.BanThreshold) {
+		return
+	}
+
+	// 每次违规封禁时间翻倍，违规次数在StrikeTTL内累计
+	strikeKey := abuseStrikeKeyPrefix + identity
+	strikes, err := g.client.Incr(ctx, strikeKey).Result()
+	if err != nil {
+		g.logger.Warn("Failed to record abuse strike", zap.String("identity", identity), zap.Error(err))
+		return
+	}
+	g.client.Expire(ctx, strikeKey, g.cfg.StrikeTTL)
+
+	duration := g.cfg.BanDuration
+	for i := int64(1); i < strikes && duration < g.cfg.MaxBanDuration; i++ {
+		duration *= 2
+	}
+	if duration > g.cfg.MaxBanDuration {
+		duration = g.cfg.MaxBanDuration
+	}
+
+	reason := "too many " + statusClass(status) + " responses"
+	pipe := g.client.TxPipeline()
+	pipe.Set(ctx, abuseBanKeyPrefix+identity, reason, duration)
+	pipe.Del(ctx, errorKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		g.logger.Warn("Failed to ban client", zap.String("identity", identity), zap.Error(err))
+		return
+	}
+
+	g.logger.Warn("Client temporarily banned",
+		zap.String("identity", identity),
+		zap.String("reason", reason),
+		zap.Int64("strikes", strikes),
+		zap.Duration("duration", duration),
+	)
+}
+
+// ListBans 获取当前生效的封禁
+func (g *AbuseGuard) ListBans(ctx context.Context) ([]*AbuseBan, error) {
+	bans := []*AbuseBan{}
+
+	iter := g.client.Scan(ctx, 0, abuseBanKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		identity := strings.TrimPrefix(key, abuseBanKeyPrefix)
+
+		pipe := g.client.Pipeline()
+		reason := pipe.Get(ctx, key)
+		ttl := pipe.PTTL(ctx, key)
+		strikes := pipe.Get(ctx, abuseStrikeKeyPrefix+identity)
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, err
+		}
+		// 扫描期间已过期
+		if ttl.Val() <= 0 {
+			continue
+		}
+
+		count, _ := strikes.Int64()
+		bans = append(bans, &AbuseBan{
+			Identity:  identity,
+			Reason:    reason.Val(),
+			Strikes:   count,
+			ExpiresAt: time.Now().Add(ttl.Val()).UTC(),
+		})
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return bans, nil
+}
+
+// Lift 解除封禁并清空该身份的错误计数和违规次数
+func (g *AbuseGuard) Lift(ctx context.Context, identity string) (bool, error) {
+	removed, err := g.client.Del(ctx,
+		abuseBanKeyPrefix+identity,
+		abuseErrorKeyPrefix+identity,
+		abuseStrikeKeyPrefix+identity,
+	).Result()
+	if err != nil {
+		return false, err
+	}
+	return removed > 0, nil
+}
+
+// tarpitDelay 错误数超过拖延阈值后，每多一次错误增加一个步长的延迟
+func (g *AbuseGuard) tarpitDelay(count int64) time.Duration {
+	if g.cfg.TarpitThreshold <= 0 || count < int64(g.cfg.TarpitThreshold) {
+		return 0
+	}
+	delay := time.Duration(count-int64(g.cfg.TarpitThreshold)+1) * g.cfg.TarpitStep
+	if delay > g.cfg.MaxTarpit {
+		delay = g.cfg.MaxTarpit
+	}
+	return delay
+}
+
+// statusClass 封禁原因中的错误类别描述
+func statusClass(status int) string {
+	switch status {
+	case 401:
+		return "unauthorized"
+	case 403:
+		return "forbidden"
+	case 404:
+		return "not found"
+	}
+	return "error"
+}
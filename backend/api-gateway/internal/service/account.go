@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/neohope/chatapp/api-gateway/pkg/tracing"
+)
+
+// accountCacheMaxEntries 缓存的用户数量上限，超出时先清理过期项
+const accountCacheMaxEntries = 10000
+
+// ErrAccountNotFound 用户服务中不存在该用户
+var ErrAccountNotFound = errors.New("account not found")
+
+// AccountStatus 用户账户的当前状态，令牌中的状态和角色只是签发时的快照
+type AccountStatus struct {
+	UserID       string `json:"user_id"`
+	Status       string `json:"status"`
+	Role         string `json:"role"`
+	TokenVersion int    `json:"token_version"`
+}
+
+// Active 账户是否为活跃状态
+func (s *AccountStatus) Active() bool {
+	return s.Status == "active"
+}
+
+type cachedAccount struct {
+	status    AccountStatus
+	expiresAt time.Time
+}
+
+// AccountClient 调用用户服务的内部接口，查询账户状态、角色和令牌版本
+// 缓存时间应很短，停用、封禁或角色变更最多延迟一个缓存周期生效
+type AccountClient struct {
+	baseURL string
+	client  *http.Client
+	ttl     time.Duration
+
+	mu       sync.Mutex
+	accounts map[string]cachedAccount
+}
+
+// NewAccountClient 创建账户状态客户端，ttl为查询结果的缓存时间，0表示不缓存
+func NewAccountClient(userServiceURL string, ttl time.Duration) *AccountClient {
+	return &AccountClient{
+		baseURL:  userServiceURL,
+		client:   &http.Client{Timeout: 5 * time.Second, Transport: tracing.Transport(nil)},
+		ttl:      ttl,
+		accounts: make(map[string]cachedAccount),
+	}
+}
+
+// Get 查询用户的当前账户状态，用户服务不可用时返回错误
+func (c *AccountClient) Get(ctx context.Context, userID string) (*AccountStatus, error) {
+	now := time.Now()
+	c.mu.Lock()
+	cached, ok := c.accounts[userID]
+	c.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		status := cached.status
+		return &status, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/internal/users/"+url.PathEscape(userID)+"/status", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("user service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrAccountNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user service returned status %d", resp.StatusCode)
+	}
+
+	var status AccountStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, err
+	}
+
+	if c.ttl > 0 {
+		c.store(userID, cachedAccount{status: status, expiresAt: now.Add(c.ttl)})
+	}
+	return &status, nil
+}
+
+// store 写入缓存，达到上限时清理过期项，仍然超出时清空缓存
+func (c *AccountClient) store(userID string, entry cachedAccount) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.accounts) >= accountCacheMaxEntries {
+		for id, cached := range c.accounts {
+			if !now.Before(cached.expiresAt) {
+				delete(c.accounts, id)
+			}
+		}
+		if len(c.accounts) >= accountCacheMaxEntries {
+			c.accounts = make(map[string]cachedAccount)
+		}
+	}
+	c.accounts[userID] = entry
+}
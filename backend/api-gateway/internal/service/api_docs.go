@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/api-gateway/config"
+	"github.com/neohope/chatapp/api-gateway/pkg/openapi"
+	"github.com/neohope/chatapp/api-gateway/pkg/tracing"
+)
+
+// pathParams 匹配文档路径中的路径参数，如{groupId}
+var pathParams = regexp.MustCompile(`\{[^{}]+\}`)
+
+// gatewayDocTitle 聚合文档的标题，也是网关自身接口的schema前缀
+const gatewayDocTitle = "api-gateway"
+
+// APIDocs 聚合各后端服务的/openapi.json，只保留网关实际转发的接口
+// 聚合结果缓存一段时间；有服务取不到文档时结果中列出该服务，并且不缓存，下次请求重试
+type APIDocs struct {
+	services map[string]string // 服务名 -> 基础URL
+	ops      openapi.Operations
+	router   *mux.Router
+	client   *http.Client
+	cfg      config.OpenAPIConfig
+	logger   *zap.Logger
+
+	mu      sync.Mutex
+	cached  []byte
+	expires time.Time
+}
+
+// NewAPIDocs ops为网关自己处理的接口，router为网关路由，用于判断后端接口是否经网关对外提供
+func NewAPIDocs(services *config.ServicesConfig, ops openapi.Operations, router *mux.Router, cfg config.OpenAPIConfig, logger *zap.Logger) *APIDocs {
+	return &APIDocs{
+		services: map[string]string{
+			"user-service":         services.UserService,
+			"group-service":        services.GroupService,
+			"message-service":      services.MessageService,
+			"media-service":        services.MediaService,
+			"notification-service": services.NotificationService,
+		},
+		ops:    ops,
+		router: router,
+		client: &http.Client{Transport: tracing.Transport(http.DefaultTransport)},
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// ServeHTTP 返回聚合后的文档
+func (d *APIDocs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := d.document(r.Context())
+	if err != nil {
+		d.logger.Error("Failed to build OpenAPI document", zap.Error(err))
+		http.Error(w, "failed to build openapi document", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func (d *APIDocs) document(ctx context.Context) ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cached != nil && time.Now().Before(d.expires) {
+		return d.cached, nil
+	}
+
+	names := make([]string, 0, len(d.services))
+	for name := range d.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	docs := make([]*openapi.Document, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			doc, err := d.fetch(ctx, d.services[name])
+			if err != nil {
+				d.logger.Warn("Failed to fetch service OpenAPI document", zap.String("service", name), zap.Error(err))
+				return
+			}
+			docs[i] = doc
+		}(i, name)
+	}
+	wg.Wait()
+
+	// 网关自己的接口先合并，与后端接口重复时以网关为准
+	merged := openapi.NewDocument(gatewayDocTitle)
+	merged.Merge(openapi.Describe(gatewayDocTitle, d.ops), gatewayDocTitle, d.routed)
+	for i, name := range names {
+		if docs[i] == nil {
+			merged.Unavailable = append(merged.Unavailable, name)
+			continue
+		}
+		for _, duplicate := range merged.Merge(docs[i], name, d.routed) {
+			d.logger.Warn("Duplicate operation in service OpenAPI document", zap.String("service", name), zap.String("operation", duplicate))
+		}
+	}
+
+	body, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	if len(merged.Unavailable) == 0 {
+		d.cached = body
+		d.expires = time.Now().Add(d.cfg.CacheTTL)
+	}
+	return body, nil
+}
+
+func (d *APIDocs) fetch(ctx context.Context, baseURL string) (*openapi.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/openapi.json", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var doc openapi.Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode openapi document: %w", err)
+	}
+	if doc.Paths == nil {
+		doc.Paths = map[string]openapi.PathItem{}
+	}
+	return &doc, nil
+}
+
+// routed 判断网关是否有匹配该接口的路由，路径参数以占位值代入
+func (d *APIDocs) routed(method, path string) bool {
+	req, err := http.NewRequest(method, pathParams.ReplaceAllString(path, "x"), nil)
+	if err != nil {
+		return false
+	}
+	var match mux.RouteMatch
+	if !d.router.Match(req, &match) {
+		return false
+	}
+	// 设置了NotFoundHandler或MethodNotAllowedHandler时，没有匹配的路由也会返回true
+	return match.MatchErr == nil
+}
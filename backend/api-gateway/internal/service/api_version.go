@@ -0,0 +1,166 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/neohope/chatapp/api-gateway/config"
+)
+
+// APIVersions 按版本路由请求，为废弃版本添加Deprecation/Sunset响应头，并统计各版本的流量
+type APIVersions struct {
+	versions map[string]*config.APIVersion
+	metrics  map[string]*versionCounters
+}
+
+// versionCounters 单个版本的流量统计
+type versionCounters struct {
+	requests     atomic.Int64
+	clientErrors atomic.Int64
+	serverErrors atomic.Int64
+	gone         atomic.Int64
+}
+
+// APIVersionSnapshot 单个版本的流量统计快照
+type APIVersionSnapshot struct {
+	Requests     int64  `json:"requests"`
+	ClientErrors int64  `json:"client_errors"`
+	ServerErrors int64  `json:"server_errors"`
+	Gone         int64  `json:"gone"` // 下线后仍收到的请求
+	Deprecated   bool   `json:"deprecated"`
+	Sunset       string `json:"sunset,omitempty"`
+}
+
+// NewAPIVersions 创建版本路由，版本路由引用的后端服务必须存在
+func NewAPIVersions(cfg *config.APIVersionConfig, proxy *ProxyService) (*APIVersions, error) {
+	versions := &APIVersions{
+		versions: make(map[string]*config.APIVersion, len(cfg.Versions)),
+		metrics:  make(map[string]*versionCounters, len(cfg.Versions)),
+	}
+	for i := range cfg.Versions {
+		version := &cfg.Versions[i]
+		for _, route := range version.Routes {
+			if _, ok := proxy.services[route.Service]; !ok {
+				return nil, fmt.Errorf("API version %s route %s: unknown service %q", version.Name, route.PathPrefix, route.Service)
+			}
+		}
+		versions.versions[version.Name] = version
+		versions.metrics[version.Name] = &versionCounters{}
+	}
+	return versions, nil
+}
+
+// RoutedVersions 需要按配置路由的版本（v1以外），按名称排序
+func (a *APIVersions) RoutedVersions() []string {
+	var names []string
+	for name, version := range a.versions {
+		if len(version.Routes) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// VersionOf 请求路径所属的已配置版本，如/api/v2/messages返回v2；不是已配置版本时返回空
+func (a *APIVersions) VersionOf(path string) string {
+	if !strings.HasPrefix(path, "/api/") {
+		return ""
+	}
+	name, _, _ := strings.Cut(strings.TrimPrefix(path, "/api/"), "/")
+	if _, ok := a.versions[name]; !ok {
+		return ""
+	}
+	return name
+}
+
+// Resolve 查找版本路由，返回匹配的路由和后端路径；没有匹配的路由时返回nil
+func (a *APIVersions) Resolve(name, method, path string) (*config.APIVersionRoute, string) {
+	version, ok := a.versions[name]
+	if !ok {
+		return nil, ""
+	}
+	rest := strings.TrimPrefix(path, "/api/"+name)
+
+	var matched *config.APIVersionRoute
+	for i := range version.Routes {
+		route := &version.Routes[i]
+		if rest != route.PathPrefix && !strings.HasPrefix(rest, route.PathPrefix+"/") {
+			continue
+		}
+		if !matchMethod(route.Methods, method) {
+			continue
+		}
+		if matched == nil || len(route.PathPrefix) > len(matched.PathPrefix) {
+			matched = route
+		}
+	}
+	if matched == nil {
+		return nil, ""
+	}
+	return matched, matched.UpstreamPrefix + strings.TrimPrefix(rest, matched.PathPrefix)
+}
+
+// ApplyLifecycle 为已废弃的版本设置Deprecation（RFC 9745）、Sunset（RFC 8594）和Link响应头
+// 返回false表示版本已过下线时间，调用方应返回410
+func (a *APIVersions) ApplyLifecycle(name string, header http.Header, now time.Time) bool {
+	version, ok := a.versions[name]
+	if !ok {
+		return true
+	}
+	if !version.DeprecatedAt.IsZero() && !now.Before(version.DeprecatedAt) {
+		header.Set("Deprecation", "@"+strconv.FormatInt(version.DeprecatedAt.Unix(), 10))
+		if version.Link != "" {
+			header.Add("Link", "<"+version.Link+`>; rel="deprecation"; type="text/html"`)
+		}
+	}
+	if !version.SunsetAt.IsZero() {
+		header.Set("Sunset", version.SunsetAt.UTC().Format(http.TimeFormat))
+		if !now.Before(version.SunsetAt) {
+			return false
+		}
+	}
+	return true
+}
+
+// Record 记录一次请求的响应状态
+func (a *APIVersions) Record(name string, status int, gone bool) {
+	counters, ok := a.metrics[name]
+	if !ok {
+		return
+	}
+	counters.requests.Add(1)
+	switch {
+	case gone:
+		counters.gone.Add(1)
+	case status >= http.StatusInternalServerError:
+		counters.serverErrors.Add(1)
+	case status >= http.StatusBadRequest:
+		counters.clientErrors.Add(1)
+	}
+}
+
+// Metrics 获取各版本的流量统计
+func (a *APIVersions) Metrics(now time.Time) map[string]APIVersionSnapshot {
+	snapshots := make(map[string]APIVersionSnapshot, len(a.versions))
+	for name, version := range a.versions {
+		counters := a.metrics[name]
+		snapshot := APIVersionSnapshot{
+			Requests:     counters.requests.Load(),
+			ClientErrors: counters.clientErrors.Load(),
+			ServerErrors: counters.serverErrors.Load(),
+			Gone:         counters.gone.Load(),
+			Deprecated:   !version.DeprecatedAt.IsZero() && !now.Before(version.DeprecatedAt),
+		}
+		if !version.SunsetAt.IsZero() {
+			snapshot.Sunset = version.SunsetAt.UTC().Format(time.RFC3339)
+		}
+		snapshots[name] = snapshot
+	}
+	return snapshots
+}
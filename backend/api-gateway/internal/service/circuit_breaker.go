@@ -0,0 +1,164 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/api-gateway/config"
+)
+
+// CircuitState 熔断器状态
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"    // 正常转发
+	CircuitOpen     CircuitState = "open"      // 熔断，直接返回503
+	CircuitHalfOpen CircuitState = "half_open" // 冷却结束，放行少量探测请求
+)
+
+// CircuitBreakerSnapshot 熔断器状态快照，用于健康检查
+type CircuitBreakerSnapshot struct {
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	RetryAfterSeconds   int          `json:"retry_after_seconds,omitempty"`
+}
+
+// CircuitBreaker 单个后端服务的熔断器
+// 连续失败达到阈值后熔断，OpenTimeout后进入半开状态放行探测请求，探测成功恢复、失败重新熔断
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	name     string
+	cfg      config.CircuitBreakerConfig
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probes   int // 半开状态下进行中的探测请求数
+	logger   *zap.Logger
+}
+
+// newCircuitBreaker 创建熔断器
+func newCircuitBreaker(name string, cfg config.CircuitBreakerConfig, logger *zap.Logger) *CircuitBreaker {
+	if cfg.FailureThreshold < 1 {
+		cfg.FailureThreshold = 1
+	}
+	if cfg.HalfOpenRequests < 1 {
+		cfg.HalfOpenRequests = 1
+	}
+	return &CircuitBreaker{
+		name:   name,
+		cfg:    cfg,
+		state:  CircuitClosed,
+		logger: logger,
+	}
+}
+
+// Allow 是否放行请求，拒绝时返回建议客户端等待的时间
+// 半开状态下放行的请求必须以Success、Failure或Cancel结束
+func (b *CircuitBreaker) Allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen {
+		remaining := b.cfg.OpenTimeout - time.Since(b.openedAt)
+		if remaining > 0 {
+			return false, remaining
+		}
+		b.transition(CircuitHalfOpen)
+	}
+	if b.state == CircuitHalfOpen {
+		if b.probes >= b.cfg.HalfOpenRequests {
+			return false, time.Second
+		}
+		b.probes++
+	}
+	return true, 0
+}
+
+// Success 记录一次成功，半开状态下恢复正常
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	if b.state == CircuitHalfOpen {
+		b.transition(CircuitClosed)
+	}
+}
+
+// Failure 记录一次失败，连续失败达到阈值或半开探测失败时熔断
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	switch b.state {
+	case CircuitHalfOpen:
+		b.transition(CircuitOpen)
+	case CircuitClosed:
+		if b.failures >= b.cfg.FailureThreshold {
+			b.transition(CircuitOpen)
+		}
+	}
+}
+
+// Cancel 请求在得到后端结果前结束（如客户端断开），释放半开状态下占用的探测名额
+func (b *CircuitBreaker) Cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// Snapshot 获取当前状态
+func (b *CircuitBreaker) Snapshot() CircuitBreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := CircuitBreakerSnapshot{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+	}
+	if b.state != CircuitClosed {
+		openedAt := b.openedAt
+		snapshot.OpenedAt = &openedAt
+	}
+	if b.state == CircuitOpen {
+		if remaining := b.cfg.OpenTimeout - time.Since(b.openedAt); remaining > 0 {
+			snapshot.RetryAfterSeconds = retryAfterSeconds(remaining)
+		}
+	}
+	return snapshot
+}
+
+// transition 切换状态，调用方需持有锁
+func (b *CircuitBreaker) transition(state CircuitState) {
+	b.state = state
+	b.probes = 0
+	switch state {
+	case CircuitOpen:
+		b.openedAt = time.Now()
+		b.logger.Warn("Circuit breaker opened",
+			zap.String("service", b.name),
+			zap.Int("consecutive_failures", b.failures),
+			zap.Duration("open_timeout", b.cfg.OpenTimeout),
+		)
+	case CircuitHalfOpen:
+		b.logger.Info("Circuit breaker half-open, probing", zap.String("service", b.name))
+	case CircuitClosed:
+		b.logger.Info("Circuit breaker closed", zap.String("service", b.name))
+	}
+}
+
+// retryAfterSeconds 将等待时间向上取整为Retry-After的秒数
+func retryAfterSeconds(wait time.Duration) int {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/neohope/chatapp/api-gateway/pkg/tracing"
+)
+
+// consentCacheMaxEntries 缓存的用户数量上限，超出时先清理过期项
+const consentCacheMaxEntries = 10000
+
+// ConsentClient 调用用户服务的内部接口，查询用户是否有尚未同意的强制协议
+// 只缓存已同意的结果，用户同意后无需等待缓存过期即可继续访问
+type ConsentClient struct {
+	baseURL string
+	client  *http.Client
+	ttl     time.Duration
+
+	mu       sync.Mutex
+	accepted map[string]time.Time // userID -> 缓存过期时间
+}
+
+// NewConsentClient 创建协议同意状态客户端，ttl为已同意结果的缓存时间，0表示不缓存
+func NewConsentClient(userServiceURL string, ttl time.Duration) *ConsentClient {
+	return &ConsentClient{
+		baseURL:  userServiceURL,
+		client:   &http.Client{Timeout: 5 * time.Second, Transport: tracing.Transport(nil)},
+		ttl:      ttl,
+		accepted: make(map[string]time.Time),
+	}
+}
+
+// HasPending 用户是否有尚未同意的强制协议，用户服务不可用时返回错误
+func (c *ConsentClient) HasPending(ctx context.Context, userID string) (bool, error) {
+	now := time.Now()
+	c.mu.Lock()
+	expiresAt, ok := c.accepted[userID]
+	c.mu.Unlock()
+	if ok && now.Before(expiresAt) {
+		return false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/internal/users/"+url.PathEscape(userID)+"/consents/pending", nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("user service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("user service returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Pending bool `json:"pending"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, err
+	}
+
+	if !result.Pending && c.ttl > 0 {
+		c.store(userID, now.Add(c.ttl))
+	}
+	return result.Pending, nil
+}
+
+// store 写入已同意缓存，达到上限时清理过期项，仍然超出时清空缓存
+func (c *ConsentClient) store(userID string, expiresAt time.Time) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.accepted) >= consentCacheMaxEntries {
+		for id, exp := range c.accepted {
+			if !now.Before(exp) {
+				delete(c.accepted, id)
+			}
+		}
+		if len(c.accepted) >= consentCacheMaxEntries {
+			c.accepted = make(map[string]time.Time)
+		}
+	}
+	c.accepted[userID] = expiresAt
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/neohope/chatapp/api-gateway/pkg/recovery"
+)
+
+const (
+	// envelopeMediaTypePrefix 统一响应信封的媒体类型前缀，完整格式为 application/vnd.chatapp.v<版本>+json
+	envelopeMediaTypePrefix = "application/vnd.chatapp.v"
+	envelopeMediaTypeSuffix = "+json"
+	// nextCursorHeader 后端分页接口返回的下一页游标响应头
+	nextCursorHeader = "X-Next-Cursor"
+)
+
+// SupportedEnvelopeVersions 支持的响应信封版本
+var SupportedEnvelopeVersions = []int{1}
+
+// Envelope 统一响应信封（v1），成功时Error为空，失败时Data为null
+type Envelope struct {
+	Data  json.RawMessage `json:"data"`
+	Error *EnvelopeError  `json:"error,omitempty"`
+	Meta  EnvelopeMeta    `json:"meta"`
+}
+
+// EnvelopeError 错误信息，Code为大写下划线形式（如NOT_FOUND），字段校验错误放在Details中
+type EnvelopeError struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Details json.RawMessage `json:"details,omitempty"`
+}
+
+// EnvelopeMeta 响应元数据
+type EnvelopeMeta struct {
+	Version    int                 `json:"version"`
+	Status     int                 `json:"status"`
+	RequestID  string              `json:"request_id,omitempty"`
+	Pagination *EnvelopePagination `json:"pagination,omitempty"`
+}
+
+// EnvelopePagination 分页元数据，来自后端的Link/X-Next-Cursor响应头或响应体中的pagination对象
+type EnvelopePagination struct {
+	Limit      int    `json:"limit,omitempty"`
+	Offset     *int   `json:"offset,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Next       string `json:"next,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+}
+
+// EnvelopeVersion 解析Accept头中请求的信封版本
+// 返回0表示客户端未选择信封；返回不支持的版本号时由调用方返回406
+func EnvelopeVersion(accept string) int {
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil || !strings.HasPrefix(mediaType, envelopeMediaTypePrefix) || !strings.HasSuffix(mediaType, envelopeMediaTypeSuffix) {
+			continue
+		}
+		version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(mediaType, envelopeMediaTypePrefix), envelopeMediaTypeSuffix))
+		if err != nil || version < 1 {
+			return -1
+		}
+		return version
+	}
+	return 0
+}
+
+// IsSupportedEnvelopeVersion 是否支持该信封版本
+func IsSupportedEnvelopeVersion(version int) bool {
+	for _, supported := range SupportedEnvelopeVersions {
+		if supported == version {
+			return true
+		}
+	}
+	return false
+}
+
+// EnvelopeMediaType 信封版本对应的响应Content-Type
+func EnvelopeMediaType(version int) string {
+	return envelopeMediaTypePrefix + strconv.Itoa(version) + envelopeMediaTypeSuffix
+}
+
+// IsEnvelopable 响应是否可以转换为信封：JSON或网关/后端用http.Error写出的纯文本错误
+func IsEnvelopable(header http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || mediaType == "text/plain"
+}
+
+// NormalizeResponse 将后端的各种响应形式转换为统一信封：
+// 裸数组/对象作为data；{success, data, error}取出data和error；{error, fields}转换为错误，fields放入details；
+// 响应体中的{data, pagination}和分页响应头转换为分页元数据
+func NormalizeResponse(version, status int, header http.Header, body []byte) Envelope {
+	envelope := Envelope{
+		Data: json.RawMessage("null"),
+		Meta: EnvelopeMeta{
+			Version:   version,
+			Status:    status,
+			RequestID: header.Get(recovery.RequestIDHeader),
+		},
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	isJSON := mediaType != "text/plain" && json.Valid(trimmed) && len(trimmed) > 0
+
+	var message string
+	switch {
+	case !isJSON:
+		// http.Error写出的纯文本只作为错误信息
+		message = string(trimmed)
+	case trimmed[0] == '{':
+		var object map[string]json.RawMessage
+		json.Unmarshal(trimmed, &object) // nolint: errcheck
+		message = normalizeObject(&envelope, object, status)
+	default:
+		envelope.Data = json.RawMessage(trimmed)
+	}
+
+	if status >= http.StatusBadRequest {
+		envelope.Data = json.RawMessage("null")
+		if envelope.Error == nil {
+			envelope.Error = &EnvelopeError{Code: statusCode(status)}
+		}
+		if envelope.Error.Message == "" {
+			envelope.Error.Message = message
+		}
+		if envelope.Error.Message == "" {
+			envelope.Error.Message = http.StatusText(status)
+		}
+	} else {
+		envelope.Error = nil
+	}
+
+	if pagination := paginationFromHeader(header); pagination != nil {
+		if envelope.Meta.Pagination == nil {
+			envelope.Meta.Pagination = pagination
+		} else {
+			mergePagination(envelope.Meta.Pagination, pagination)
+		}
+	}
+	return envelope
+}
+
+// normalizeObject 处理JSON对象响应，返回可作为错误信息的文本
+func normalizeObject(envelope *Envelope, object map[string]json.RawMessage, status int) string {
+	_, hasSuccess := object["success"]
+	rawError, hasError := object["error"]
+
+	// 成功响应中的error字段是业务数据的一部分（如处理状态），只有错误状态码才按错误解析
+	if !hasSuccess && (!hasError || status < http.StatusBadRequest) {
+		envelope.Data = mustMarshal(object)
+		return ""
+	}
+
+	var message string
+	if rawMessage, ok := object["message"]; ok {
+		json.Unmarshal(rawMessage, &message) // nolint: errcheck
+	}
+
+	if hasError && string(rawError) != "null" {
+		var text string
+		var detailed struct {
+			Code    string          `json:"code"`
+			Message string          `json:"message"`
+			Details json.RawMessage `json:"details"`
+		}
+		switch {
+		case json.Unmarshal(rawError, &text) == nil:
+			envelope.Error = &EnvelopeError{Code: statusCode(status), Message: text}
+		case json.Unmarshal(rawError, &detailed) == nil:
+			envelope.Error = &EnvelopeError{Code: detailed.Code, Message: detailed.Message, Details: nonNull(detailed.Details)}
+			if envelope.Error.Code == "" {
+				envelope.Error.Code = statusCode(status)
+			}
+		}
+		// 字段校验错误（{"error": "validation failed", "fields": [...]}）
+		if fields, ok := object["fields"]; ok && envelope.Error != nil && string(fields) != "null" {
+			envelope.Error.Code = "VALIDATION_ERROR"
+			envelope.Error.Details = fields
+		}
+	}
+
+	if !hasSuccess {
+		return message
+	}
+
+	// {success, data}形式：取出data，没有data时保留除标记字段以外的内容（如message）
+	data, hasData := object["data"]
+	if !hasData {
+		rest := make(map[string]json.RawMessage, len(object))
+		for key, value := range object {
+			switch key {
+			case "success", "error", "timestamp", "request_id":
+				continue
+			}
+			rest[key] = value
+		}
+		if len(rest) > 0 {
+			envelope.Data = mustMarshal(rest)
+		}
+		return message
+	}
+	envelope.Data = data
+
+	// 媒体服务的分页响应：{data: {data: [...], pagination: {...}}}
+	var paginated struct {
+		Data       json.RawMessage `json:"data"`
+		Pagination *struct {
+			Total  int `json:"total"`
+			Limit  int `json:"limit"`
+			Offset int `json:"offset"`
+		} `json:"pagination"`
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) && json.Unmarshal(data, &paginated) == nil && paginated.Pagination != nil && paginated.Data != nil {
+		envelope.Data = paginated.Data
+		total, offset := paginated.Pagination.Total, paginated.Pagination.Offset
+		envelope.Meta.Pagination = &EnvelopePagination{
+			Limit:   paginated.Pagination.Limit,
+			Offset:  &offset,
+			Total:   &total,
+			HasMore: offset+paginated.Pagination.Limit < total,
+		}
+	}
+	return message
+}
+
+// paginationFromHeader 从Link和X-Next-Cursor响应头解析分页元数据，没有分页头时返回nil
+func paginationFromHeader(header http.Header) *EnvelopePagination {
+	links := parseLinkHeader(header.Values("Link"))
+	cursor := header.Get(nextCursorHeader)
+	if len(links) == 0 && cursor == "" {
+		return nil
+	}
+
+	pagination := &EnvelopePagination{
+		NextCursor: cursor,
+		Next:       links["next"],
+		Prev:       links["prev"],
+	}
+	pagination.HasMore = pagination.Next != "" || cursor != ""
+	for _, rel := range []string{"next", "first"} {
+		if target, ok := links[rel]; ok {
+			if parsed, err := url.Parse(target); err == nil {
+				if limit, err := strconv.Atoi(parsed.Query().Get("limit")); err == nil {
+					pagination.Limit = limit
+					break
+				}
+			}
+		}
+	}
+	if pagination.NextCursor == "" && pagination.Next != "" {
+		if parsed, err := url.Parse(pagination.Next); err == nil {
+			pagination.NextCursor = parsed.Query().Get("cursor")
+		}
+	}
+	return pagination
+}
+
+// parseLinkHeader 解析RFC 5988 Link头，返回rel到URL的映射
+func parseLinkHeader(values []string) map[string]string {
+	links := make(map[string]string)
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			parts := strings.Split(strings.TrimSpace(item), ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				name, rel, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if ok && name == "rel" {
+					links[strings.Trim(rel, `"`)] = strings.Trim(target, "<>")
+				}
+			}
+		}
+	}
+	return links
+}
+
+// mergePagination 用响应头中的翻页信息补充响应体中的分页元数据
+func mergePagination(target, source *EnvelopePagination) {
+	if target.Limit == 0 {
+		target.Limit = source.Limit
+	}
+	target.NextCursor = source.NextCursor
+	target.Next = source.Next
+	target.Prev = source.Prev
+	target.HasMore = target.HasMore || source.HasMore
+}
+
+// statusCode 状态码对应的错误码，如404为NOT_FOUND
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "HTTP_" + strconv.Itoa(status)
+	}
+	return strings.ToUpper(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
+}
+
+// nonNull JSON null视为空
+func nonNull(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	return raw
+}
+
+// mustMarshal 重新编码已解析的JSON对象
+func mustMarshal(object map[string]json.RawMessage) json.RawMessage {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return data
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/neohope/chatapp/api-gateway/config"
+)
+
+// hopByHopHeaders 逐跳头（RFC 7230 6.1），只对单个连接有效，不能转发
+var hopByHopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// headerRules 规范化后的头操作规则
+type headerRules struct {
+	remove []string
+	rename map[string]string
+	set    map[string]string
+}
+
+// routeRules 路由前缀及合并默认策略后的规则
+type routeRules struct {
+	prefix   string
+	request  headerRules
+	response headerRules
+}
+
+// HeaderPolicy 按路由对转发请求和返回响应的头进行改写
+type HeaderPolicy struct {
+	stripHopByHop    bool
+	forwardedHeaders bool
+	defaults         routeRules
+	routes           []routeRules // 按前缀长度倒序
+}
+
+// NewHeaderPolicy 根据配置创建头策略
+func NewHeaderPolicy(cfg *config.HeaderPolicyConfig) *HeaderPolicy {
+	defaults := routeRules{
+		request:  mergeHeaderRules(config.HeaderRules{}, cfg.Default.Request),
+		response: mergeHeaderRules(config.HeaderRules{}, cfg.Default.Response),
+	}
+
+	routes := make([]routeRules, 0, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routes = append(routes, routeRules{
+			prefix:   route.PathPrefix,
+			request:  mergeHeaderRules(cfg.Default.Request, route.Request),
+			response: mergeHeaderRules(cfg.Default.Response, route.Response),
+		})
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].prefix) > len(routes[j].prefix)
+	})
+
+	return &HeaderPolicy{
+		stripHopByHop:    cfg.StripHopByHop,
+		forwardedHeaders: cfg.ForwardedHeaders,
+		defaults:         defaults,
+		routes:           routes,
+	}
+}
+
+// ApplyRequest 改写发往后端的请求头，in为客户端原始请求
+func (p *HeaderPolicy) ApplyRequest(in *http.Request, out http.Header) {
+	if p.stripHopByHop {
+		removeHopByHop(out)
+	}
+
+	applyHeaderRules(out, p.match(in.URL.Path).request)
+
+	if p.forwardedHeaders {
+		clientIP, _, err := net.SplitHostPort(in.RemoteAddr)
+		if err != nil {
+			clientIP = in.RemoteAddr
+		}
+		if prior := in.Header.Get("X-Forwarded-For"); prior != "" {
+			clientIP = prior + ", " + clientIP
+		}
+		out.Set("X-Forwarded-For", clientIP)
+		out.Set("X-Forwarded-Host", in.Host)
+		proto := "http"
+		if in.TLS != nil {
+			proto = "https"
+		}
+		out.Set("X-Forwarded-Proto", proto)
+	}
+}
+
+// ApplyResponse 改写返回给客户端的响应头
+func (p *HeaderPolicy) ApplyResponse(path string, header http.Header) {
+	if p.stripHopByHop {
+		removeHopByHop(header)
+	}
+	applyHeaderRules(header, p.match(path).response)
+}
+
+// match 返回路径匹配的规则，未匹配时使用默认规则
+func (p *HeaderPolicy) match(path string) routeRules {
+	for _, route := range p.routes {
+		if strings.HasPrefix(path, route.prefix) {
+			return route
+		}
+	}
+	return p.defaults
+}
+
+// mergeHeaderRules 合并默认规则和路由规则：删除项取并集，重命名和设置项以路由为准
+func mergeHeaderRules(base, override config.HeaderRules) headerRules {
+	rules := headerRules{
+		rename: make(map[string]string),
+		set:    make(map[string]string),
+	}
+
+	for _, name := range append(append([]string{}, base.Remove...), override.Remove...) {
+		rules.remove = append(rules.remove, http.CanonicalHeaderKey(name))
+	}
+	for _, source := range []map[string]string{base.Rename, override.Rename} {
+		for from, to := range source {
+			rules.rename[http.CanonicalHeaderKey(from)] = http.CanonicalHeaderKey(to)
+		}
+	}
+	for _, source := range []map[string]string{base.Set, override.Set} {
+		for name, value := range source {
+			rules.set[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+	return rules
+}
+
+// applyHeaderRules 依次执行删除、重命名和设置
+func applyHeaderRules(header http.Header, rules headerRules) {
+	for _, name := range rules.remove {
+		header.Del(name)
+	}
+	for from, to := range rules.rename {
+		if values, ok := header[from]; ok {
+			header.Del(from)
+			header[to] = values
+		}
+	}
+	for name, value := range rules.set {
+		header.Set(name, value)
+	}
+}
+
+// removeHopByHop 删除逐跳头以及Connection中声明的头
+func removeHopByHop(header http.Header) {
+	for _, value := range header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				header.Del(name)
+			}
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		header.Del(name)
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/api-gateway/config"
+)
+
+// idempotencyKeyPrefix Redis键前缀，完整格式为 gateway:idempotency:<用户ID>:<幂等键摘要>
+const idempotencyKeyPrefix = "gateway:idempotency:"
+
+var (
+	// ErrIdempotencyInProgress 相同幂等键的请求仍在处理中
+	ErrIdempotencyInProgress = errors.New("request with this idempotency key is still in progress")
+	// ErrIdempotencyKeyReused 幂等键已被请求内容不同的请求使用
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used with a different request")
+)
+
+// StoredResponse 幂等键对应的已完成响应
+type StoredResponse struct {
+	Status int                 `json:"status"`
+	Header map[string][]string `json:"header"`
+	Body   []byte              `json:"body"`
+}
+
+// idempotencyRecord 幂等键的存储记录，Response为空表示请求仍在处理中
+type idempotencyRecord struct {
+	RequestHash string          `json:"request_hash"`
+	Response    *StoredResponse `json:"response,omitempty"`
+}
+
+// IdempotencyStore 基于Redis的幂等键存储，多个网关实例共享
+type IdempotencyStore struct {
+	client *redis.Client
+	cfg    config.IdempotencyConfig
+	logger *zap.Logger
+}
+
+// NewIdempotencyStore 创建幂等键存储
+func NewIdempotencyStore(client *redis.Client, cfg config.IdempotencyConfig, logger *zap.Logger) *IdempotencyStore {
+	return &IdempotencyStore{
+		client: client,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// MaxBodyBytes 参与请求摘要计算和允许保存的最大字节数
+func (s *IdempotencyStore) MaxBodyBytes() int64 {
+	return s.cfg.MaxBodyBytes
+}
+
+// Begin 占用幂等键。首次使用时返回nil响应，调用方处理完成后需调用Complete或Release；
+// 已完成时返回保存的响应供重放。请求摘要不一致、请求仍在处理中时返回对应错误
+func (s *IdempotencyStore) Begin(ctx context.Context, userID, key, requestHash string) (*StoredResponse, error) {
+	redisKey := idempotencyRedisKey(userID, key)
+
+	data, err := json.Marshal(idempotencyRecord{RequestHash: requestHash})
+	if err != nil {
+		return nil, err
+	}
+	// 处理中的占位记录有效期较短（覆盖最长请求处理时间），网关异常退出后幂等键可以重新使用
+	acquired, err := s.client.SetNX(ctx, redisKey, data, s.cfg.LockTTL).Result()
+	if err != nil {
+		return nil, err
+	}
+	if acquired {
+		return nil, nil
+	}
+
+	value, err := s.client.Get(ctx, redisKey).Bytes()
+	if err == redis.Nil {
+		// 占位记录恰好过期，按首次请求处理
+		return s.Begin(ctx, userID, key, requestHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(value, &record); err != nil {
+		return nil, err
+	}
+	if record.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if record.Response == nil {
+		return nil, ErrIdempotencyInProgress
+	}
+	return record.Response, nil
+}
+
+// Complete 保存请求的响应，有效期内相同幂等键的重试直接重放该响应
+func (s *IdempotencyStore) Complete(ctx context.Context, userID, key, requestHash string, response *StoredResponse) error {
+	data, err := json.Marshal(idempotencyRecord{RequestHash: requestHash, Response: response})
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, idempotencyRedisKey(userID, key), data, s.cfg.TTL).Err()
+}
+
+// Release 释放幂等键，用于请求失败（5xx）或响应过大无法保存时，允许客户端重试
+func (s *IdempotencyStore) Release(ctx context.Context, userID, key string) {
+	if err := s.client.Del(ctx, idempotencyRedisKey(userID, key)).Err(); err != nil {
+		s.logger.Warn("Failed to release idempotency key", zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// ShouldStore 响应是否需要保存，服务端错误不保存以便客户端重试
+func (s *IdempotencyStore) ShouldStore(status int) bool {
+	return status < http.StatusInternalServerError
+}
+
+// idempotencyRedisKey 幂等键按用户隔离，key为客户端幂等键的摘要
+func idempotencyRedisKey(userID, key string) string {
+	return idempotencyKeyPrefix + userID + ":" + key
+}
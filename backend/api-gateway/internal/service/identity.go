@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/neohope/chatapp/api-gateway/pkg/tracing"
+)
+
+// IdentityClient 调用用户服务的内部接口，将外部IdP主体解析为内部用户
+type IdentityClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewIdentityClient 创建外部身份解析客户端
+func NewIdentityClient(userServiceURL string) *IdentityClient {
+	return &IdentityClient{
+		baseURL: userServiceURL,
+		client:  &http.Client{Timeout: 5 * time.Second, Transport: tracing.Transport(nil)},
+	}
+}
+
+// Resolve 解析外部主体，未映射或用户不可用时返回错误
+func (c *IdentityClient) Resolve(ctx context.Context, issuer, subject string) (string, string, error) {
+	query := url.Values{"issuer": {issuer}, "subject": {subject}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/internal/identity-links/resolve?"+query.Encode(), nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("user service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", "", errors.New("external identity is not linked to a user")
+	case http.StatusForbidden:
+		return "", "", errors.New("linked user is not active")
+	default:
+		return "", "", fmt.Errorf("user service returned status %d", resp.StatusCode)
+	}
+
+	var identity struct {
+		UserID string `json:"user_id"`
+		Email  string `json:"email"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&identity); err != nil {
+		return "", "", err
+	}
+	return identity.UserID, identity.Email, nil
+}
@@ -1,24 +1,38 @@
 package service
 
 import (
-	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/neohope/chatapp/api-gateway/config"
+	"github.com/neohope/chatapp/api-gateway/pkg/recovery"
+	"github.com/neohope/chatapp/api-gateway/pkg/tracing"
 )
 
 type ProxyService struct {
-	services map[string]string
-	client   *http.Client
-	logger   *zap.Logger
+	services   map[string]string
+	client     *http.Client
+	headers    *HeaderPolicy
+	validator  *RequestValidator          // 为nil时不校验请求体
+	breakers   map[string]*CircuitBreaker // 未启用熔断时为空
+	retry      config.RetryConfig
+	uploads    *bodyStreamer
+	websockets *webSocketProxy
+	timeout    time.Duration
+	upload     time.Duration
+	logger     *zap.Logger
 }
 
-func NewProxyService(cfg *config.ServicesConfig, logger *zap.Logger) *ProxyService {
+func NewProxyService(cfg *config.ServicesConfig, uploadCfg config.UploadConfig, wsCfg config.WebSocketConfig, breakerCfg config.CircuitBreakerConfig, retryCfg config.RetryConfig, headers *HeaderPolicy, logger *zap.Logger) *ProxyService {
 	services := map[string]string{
 		"users":         cfg.UserService,
 		"groups":        cfg.GroupService,
@@ -27,18 +41,73 @@ func NewProxyService(cfg *config.ServicesConfig, logger *zap.Logger) *ProxyServi
 		"notifications": cfg.NotificationService,
 	}
 
+	breakers := make(map[string]*CircuitBreaker)
+	if breakerCfg.Enabled {
+		for name := range services {
+			breakers[name] = newCircuitBreaker(name, breakerCfg, logger)
+		}
+	}
+
+	// 超时由每个请求的上下文控制，大文件上传需要比普通请求更长的时间
+	// 连接超时单独限制，后端宕机时尽快失败，不占满整个请求超时
+	// 每次转发（包括重试）创建client span，并通过traceparent头把trace上下文传给后端
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Transport: tracing.Transport(&http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+			MaxIdleConnsPerHost:   32,
+			IdleConnTimeout:       90 * time.Second,
+			ResponseHeaderTimeout: 30 * time.Second,
+			WriteBufferSize:       uploadCfg.BufferSize,
+		}),
+		// 不跟随后端重定向，交由客户端处理（如SSO跳转到IdP）
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
 	}
 
 	return &ProxyService{
-		services: services,
-		client:   client,
-		logger:   logger,
+		services:   services,
+		client:     client,
+		headers:    headers,
+		breakers:   breakers,
+		retry:      retryCfg,
+		uploads:    newBodyStreamer(uploadCfg, logger),
+		websockets: newWebSocketProxy(wsCfg, logger),
+		timeout:    30 * time.Second,
+		upload:     uploadCfg.Timeout,
+		logger:     logger,
 	}
 }
 
+// WithRequestValidator 启用转发前的请求体Schema校验
+func (p *ProxyService) WithRequestValidator(validator *RequestValidator) *ProxyService {
+	p.validator = validator
+	return p
+}
+
 func (p *ProxyService) ProxyRequest(w http.ResponseWriter, r *http.Request, serviceName string) {
+	// 不符合Schema的请求体在网关直接拒绝，不转发给后端服务
+	if p.validator != nil {
+		if validationErr := p.validator.Validate(r); validationErr != nil {
+			p.logger.Debug("Request body rejected by schema",
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.Int("status", validationErr.Status),
+			)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(validationErr.Status)
+			json.NewEncoder(w).Encode(validationErr) // nolint: errcheck
+			return
+		}
+	}
+
+	// 保持完整的API路径
+	p.ProxyTo(w, r, serviceName, r.URL.Path)
+}
+
+// ProxyTo 将请求转发到目标服务的指定路径，用于网关自身接口调用后端内部接口
+func (p *ProxyService) ProxyTo(w http.ResponseWriter, r *http.Request, serviceName, path string) {
 	// 获取目标服务URL
 	targetURL, exists := p.services[serviceName]
 	if !exists {
@@ -55,48 +124,59 @@ func (p *ProxyService) ProxyRequest(w http.ResponseWriter, r *http.Request, serv
 		return
 	}
 
-	// 保持完整的API路径
-	target.Path = r.URL.Path
+	target.Path = path
 	target.RawQuery = r.URL.RawQuery
 
-	// 读取请求体
-	var body []byte
-	if r.Body != nil {
-		body, err = io.ReadAll(r.Body)
-		if err != nil {
-			p.logger.Error("Failed to read request body", zap.Error(err))
-			http.Error(w, "Bad request", http.StatusBadRequest)
+	// 后端服务熔断时直接返回503，不读取请求体
+	if breaker := p.breakers[serviceName]; breaker != nil {
+		if allowed, wait := breaker.Allow(); !allowed {
+			p.writeCircuitOpen(w, &CircuitOpenError{Service: serviceName, RetryAfter: wait})
 			return
 		}
-		r.Body.Close()
 	}
 
-	// 创建新的请求
-	req, err := http.NewRequest(r.Method, target.String(), bytes.NewReader(body))
-	if err != nil {
-		p.logger.Error("Failed to create request", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+	// 请求体以流的方式转发，不在网关内缓存
+	// SSE事件流（如上传进度）持续时间与上传相当，使用上传超时
+	timeout := p.timeout
+	if isEventStream(r.Header) {
+		timeout = p.upload
 	}
-
-	// 复制请求头
-	for key, values := range r.Header {
-		for _, value := range values {
-			req.Header.Add(key, value)
+	var body io.Reader = http.NoBody
+	var upload *uploadStream
+	if r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0 {
+		if p.uploads.isUpload(r) {
+			timeout = p.upload
 		}
+		upload = p.uploads.Stream(w, r)
+		defer upload.body.Close()
+		body = upload.body
 	}
 
-	// 添加用户信息到请求头（如果存在）
-	if userID := r.Context().Value("user_id"); userID != nil {
-		req.Header.Set("X-User-ID", userID.(string))
-	}
-	if email := r.Context().Value("email"); email != nil {
-		req.Header.Set("X-User-Email", email.(string))
-	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
 
-	// 发送请求
-	resp, err := p.client.Do(req)
+	// 发送请求，后端不可用时按重试策略重试
+	resp, err := p.send(ctx, r, serviceName, target.String(), body, upload)
 	if err != nil {
+		var openErr *CircuitOpenError
+		if errors.As(err, &openErr) {
+			p.writeCircuitOpen(w, openErr)
+			return
+		}
+		// 客户端请求体读取失败（超过大小限制或连接中断）
+		if upload != nil {
+			upload.body.Close()
+			if bodyErr := upload.Err(); bodyErr != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(bodyErr, &maxBytesErr) {
+					http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+					return
+				}
+				p.logger.Warn("Failed to read request body", zap.String("url", target.String()), zap.Error(bodyErr))
+				http.Error(w, "Bad request", http.StatusBadRequest)
+				return
+			}
+		}
 		p.logger.Error("Failed to proxy request",
 			zap.String("service", serviceName),
 			zap.String("url", target.String()),
@@ -107,7 +187,12 @@ func (p *ProxyService) ProxyRequest(w http.ResponseWriter, r *http.Request, serv
 	}
 	defer resp.Body.Close()
 
-	// 复制响应头
+	// 按路由策略改写并复制响应头
+	p.headers.ApplyResponse(r.URL.Path, resp.Header)
+	// 下游服务会原样返回网关传入的请求ID，避免响应中出现重复的请求ID头
+	if resp.Header.Get(recovery.RequestIDHeader) != "" {
+		w.Header().Del(recovery.RequestIDHeader)
+	}
 	for key, values := range resp.Header {
 		for _, value := range values {
 			w.Header().Add(key, value)
@@ -117,8 +202,12 @@ func (p *ProxyService) ProxyRequest(w http.ResponseWriter, r *http.Request, serv
 	// 设置状态码
 	w.WriteHeader(resp.StatusCode)
 
-	// 复制响应体
-	if _, err := io.Copy(w, resp.Body); err != nil {
+	// 复制响应体，事件流逐块刷新给客户端
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		if err := copyFlushing(w, resp.Body); err != nil {
+			p.logger.Debug("Event stream closed", zap.String("path", r.URL.Path), zap.Error(err))
+		}
+	} else if _, err := io.Copy(w, resp.Body); err != nil {
 		p.logger.Error("Failed to copy response body", zap.Error(err))
 	}
 
@@ -130,6 +219,35 @@ func (p *ProxyService) ProxyRequest(w http.ResponseWriter, r *http.Request, serv
 	)
 }
 
+// isEventStream 请求是否期望SSE事件流
+func isEventStream(header http.Header) bool {
+	return strings.Contains(header.Get("Accept"), "text/event-stream")
+}
+
+// copyFlushing 复制响应体，每次写入后立即刷新
+func copyFlushing(w http.ResponseWriter, body io.Reader) error {
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{}) // nolint: errcheck
+	buf := make([]byte, 4096)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if _, writeErr := w.Write(buf[:n]); writeErr != nil {
+				return writeErr
+			}
+			if flushErr := controller.Flush(); flushErr != nil {
+				return flushErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
 func (p *ProxyService) HealthCheck() map[string]bool {
 	result := make(map[string]bool)
 
@@ -153,9 +271,9 @@ func (p *ProxyService) HealthCheck() map[string]bool {
 		
 		if serviceName == "users" {
 			// 对于用户服务，使用HEAD请求测试连接性
-			resp, err = p.client.Head(serviceURL + healthPath)
+			resp, err = p.healthRequest(http.MethodHead, serviceURL+healthPath)
 		} else {
-			resp, err = p.client.Get(serviceURL + healthPath)
+			resp, err = p.healthRequest(http.MethodGet, serviceURL+healthPath)
 		}
 		
 		if err != nil {
@@ -168,3 +286,20 @@ func (p *ProxyService) HealthCheck() map[string]bool {
 
 	return result
 }
+
+// UploadMetrics 获取上传转发统计
+func (p *ProxyService) UploadMetrics() UploadMetricsSnapshot {
+	return p.uploads.metrics.Snapshot()
+}
+
+// healthRequest 发送健康检查请求
+func (p *ProxyService) healthRequest(method, target string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	return p.client.Do(req)
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CircuitOpenError 后端服务已熔断，请求未转发
+type CircuitOpenError struct {
+	Service    string
+	RetryAfter time.Duration
+}
+
+// Error 实现error接口
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker for %s is open", e.Service)
+}
+
+// CircuitBreakers 获取各后端服务的熔断器状态，未启用熔断时返回nil
+func (p *ProxyService) CircuitBreakers() map[string]CircuitBreakerSnapshot {
+	if len(p.breakers) == 0 {
+		return nil
+	}
+	snapshots := make(map[string]CircuitBreakerSnapshot, len(p.breakers))
+	for name, breaker := range p.breakers {
+		snapshots[name] = breaker.Snapshot()
+	}
+	return snapshots
+}
+
+// send 发送请求并向熔断器记录结果，首次尝试需已由调用方通过熔断器放行
+// 幂等方法且没有请求体的请求在连接失败或后端返回502/503/504时按指数退避重试，每次重试重新经过熔断器
+func (p *ProxyService) send(ctx context.Context, r *http.Request, serviceName, target string, body io.Reader, upload *uploadStream) (*http.Response, error) {
+	breaker := p.breakers[serviceName]
+	retries := 0
+	if body == http.NoBody && p.isRetryable(r.Method) {
+		retries = p.retry.MaxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && breaker != nil {
+			if allowed, wait := breaker.Allow(); !allowed {
+				return nil, &CircuitOpenError{Service: serviceName, RetryAfter: wait}
+			}
+		}
+
+		req, err := p.newUpstreamRequest(ctx, r, target, body)
+		if err != nil {
+			if breaker != nil {
+				breaker.Cancel()
+			}
+			return nil, err
+		}
+
+		resp, err := p.client.Do(req)
+		if err != nil && !p.isUpstreamFailure(r, upload) {
+			// 客户端断开或请求体读取失败，与后端是否可用无关
+			if breaker != nil {
+				breaker.Cancel()
+			}
+			return nil, err
+		}
+		if err == nil && !isUnavailableStatus(resp.StatusCode) {
+			if breaker != nil {
+				breaker.Success()
+			}
+			return resp, nil
+		}
+		if breaker != nil {
+			breaker.Failure()
+		}
+		if attempt >= retries || ctx.Err() != nil {
+			return resp, err
+		}
+
+		reason := "transport error"
+		if resp != nil {
+			reason = "status " + strconv.Itoa(resp.StatusCode)
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096)) // nolint: errcheck
+			resp.Body.Close()
+		}
+		backoff := p.retry.Backoff << attempt
+		p.logger.Warn("Retrying proxied request",
+			zap.String("service", serviceName),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Int("attempt", attempt+1),
+			zap.String("reason", reason),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// newUpstreamRequest 创建发往后端的请求，复制并按路由策略改写请求头，注入认证得到的用户信息
+func (p *ProxyService) newUpstreamRequest(ctx context.Context, r *http.Request, target string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, r.Method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = r.ContentLength
+
+	// 复制请求头
+	for key, values := range r.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	// 按路由策略改写请求头
+	p.headers.ApplyRequest(r, req.Header)
+
+	// 添加用户信息到请求头（如果存在）
+	if userID := r.Context().Value("user_id"); userID != nil {
+		req.Header.Set("X-User-ID", userID.(string))
+	}
+	if email := r.Context().Value("email"); email != nil {
+		req.Header.Set("X-User-Email", email.(string))
+	}
+	// 角色只来自网关校验过的令牌，不透传客户端传入的值
+	req.Header.Del("X-User-Role")
+	if role := r.Context().Value("role"); role != nil {
+		req.Header.Set("X-User-Role", role.(string))
+	}
+	// 第三方客户端经令牌内省访问时标记客户端ID，其余请求不允许携带该头
+	req.Header.Del("X-OAuth-Client-ID")
+	if clientID := r.Context().Value("client_id"); clientID != nil {
+		req.Header.Set("X-OAuth-Client-ID", clientID.(string))
+	}
+	return req, nil
+}
+
+// isUpstreamFailure 转发出错时是否归因于后端：客户端已断开或请求体读取失败时不计入熔断
+func (p *ProxyService) isUpstreamFailure(r *http.Request, upload *uploadStream) bool {
+	if r.Context().Err() != nil {
+		return false
+	}
+	if upload != nil {
+		upload.body.Close()
+		if upload.Err() != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// isRetryable 方法是否允许重试
+func (p *ProxyService) isRetryable(method string) bool {
+	for _, allowed := range p.retry.Methods {
+		if allowed == method {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCircuitOpen 后端熔断时返回503和Retry-After
+func (p *ProxyService) writeCircuitOpen(w http.ResponseWriter, err *CircuitOpenError) {
+	p.logger.Debug("Request rejected by circuit breaker", zap.String("service", err.Service))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(err.RetryAfter)))
+	http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
+}
+
+// isUnavailableStatus 表示后端不可用的状态码，计入熔断并可重试
+func isUnavailableStatus(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/api-gateway/config"
+)
+
+const (
+	// rateLimitKeyPrefix Redis键前缀，完整格式为 gateway:ratelimit:<维度>:<规则>:<IP或用户ID>
+	rateLimitKeyPrefix = "gateway:ratelimit:"
+	// rateLimitSweepInterval 清理内存中已回满的令牌桶的间隔
+	rateLimitSweepInterval = time.Minute
+	// rateLimitRedisTimeout 单次Redis限流检查的超时时间
+	rateLimitRedisTimeout = 200 * time.Millisecond
+)
+
+// tokenBucketScript 原子地补充并消耗令牌，返回{是否放行, 需等待的毫秒数}
+// 使用Redis服务器时间，多个网关实例的时钟偏差不影响计数
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, wait}
+`)
+
+// RateLimitResult 限流检查结果，拒绝时Scope和Rule标明触发限制的令牌桶
+type RateLimitResult struct {
+	Allowed    bool
+	RetryAfter time.Duration
+	Scope      string // ip或user
+	Rule       string // default或路由的路径前缀
+}
+
+// RateLimiter 令牌桶限流器：按客户端IP和用户分别计数，默认规则与匹配的路由规则同时生效
+// 策略的store为redis时计数保存在Redis中，多个网关实例共享；Redis出错时退回本实例内存计数
+type RateLimiter struct {
+	policy *config.RateLimitPolicy
+	client *redis.Client
+	logger *zap.Logger
+
+	mu        sync.Mutex
+	buckets   map[string]*bucketState
+	lastSweep time.Time
+}
+
+// bucketState 内存中的令牌桶状态
+type bucketState struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // 此后令牌桶已回满，可以清理
+}
+
+// rateLimitCheck 一次请求需要检查的令牌桶
+type rateLimitCheck struct {
+	scope    string
+	rule     string
+	identity string
+	bucket   *config.TokenBucket
+}
+
+// NewRateLimiter 创建限流器，client为nil时只使用内存计数
+func NewRateLimiter(policy *config.RateLimitPolicy, client *redis.Client, logger *zap.Logger) *RateLimiter {
+	if policy.Store != config.RateLimitStoreRedis {
+		client = nil
+	}
+	return &RateLimiter{
+		policy:    policy,
+		client:    client,
+		logger:    logger,
+		buckets:   make(map[string]*bucketState),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow 检查请求是否超出限制，userID为空时只按IP计数；依次消耗各令牌桶，遇到第一个耗尽的令牌桶即拒绝
+func (l *RateLimiter) Allow(ctx context.Context, method, path, ip, userID string) RateLimitResult {
+	for _, check := range l.checks(method, path, ip, userID) {
+		allowed, wait := l.take(ctx, check)
+		if !allowed {
+			return RateLimitResult{RetryAfter: wait, Scope: check.scope, Rule: check.rule}
+		}
+	}
+	return RateLimitResult{Allowed: true}
+}
+
+// checks 请求适用的令牌桶：默认规则，以及方法匹配的最长前缀路由规则
+func (l *RateLimiter) checks(method, path, ip, userID string) []rateLimitCheck {
+	rules := []*config.RateLimitRule{&l.policy.Default}
+	if route := l.matchRoute(method, path); route != nil {
+		rules = append(rules, route)
+	}
+
+	var checks []rateLimitCheck
+	for _, rule := range rules {
+		name := rule.PathPrefix
+		if name == "" {
+			name = "default"
+		}
+		if rule.IP != nil && ip != "" {
+			checks = append(checks, rateLimitCheck{scope: "ip", rule: name, identity: ip, bucket: rule.IP})
+		}
+		if rule.User != nil && userID != "" {
+			checks = append(checks, rateLimitCheck{scope: "user", rule: name, identity: userID, bucket: rule.User})
+		}
+	}
+	return checks
+}
+
+// matchRoute 查找方法匹配的最长前缀路由规则
+func (l *RateLimiter) matchRoute(method, path string) *config.RateLimitRule {
+	var matched *config.RateLimitRule
+	for i := range l.policy.Routes {
+		route := &l.policy.Routes[i]
+		if !strings.HasPrefix(path, route.PathPrefix) || !matchMethod(route.Methods, method) {
+			continue
+		}
+		if matched == nil || len(route.PathPrefix) > len(matched.PathPrefix) {
+			matched = route
+		}
+	}
+	return matched
+}
+
+// take 从令牌桶中取出一个令牌，返回是否成功以及令牌不足时需等待的时间
+func (l *RateLimiter) take(ctx context.Context, check rateLimitCheck) (bool, time.Duration) {
+	key := check.scope + ":" + check.rule + ":" + check.identity
+	if l.client != nil {
+		redisCtx, cancel := context.WithTimeout(ctx, rateLimitRedisTimeout)
+		result, err := tokenBucketScript.Run(redisCtx, l.client, []string{rateLimitKeyPrefix + key}, check.bucket.Rate, check.bucket.Burst).Int64Slice()
+		cancel()
+		if err == nil && len(result) == 2 {
+			return result[0] == 1, time.Duration(result[1]) * time.Millisecond
+		}
+		l.logger.Warn("Rate limit lookup failed, falling back to local counters", zap.String("key", key), zap.Error(err))
+	}
+	return l.takeLocal(key, check.bucket, time.Now())
+}
+
+// takeLocal 使用本实例内存中的令牌桶
+func (l *RateLimiter) takeLocal(key string, bucket *config.TokenBucket, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= rateLimitSweepInterval {
+		for k, state := range l.buckets {
+			if now.After(state.full) {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	burst := float64(bucket.Burst)
+	state, exists := l.buckets[key]
+	if !exists {
+		state = &bucketState{tokens: burst, updated: now}
+		l.buckets[key] = state
+	}
+	state.tokens = math.Min(burst, state.tokens+now.Sub(state.updated).Seconds()*bucket.Rate)
+	state.updated = now
+
+	allowed := state.tokens >= 1
+	var wait time.Duration
+	if allowed {
+		state.tokens--
+	} else {
+		wait = time.Duration((1 - state.tokens) / bucket.Rate * float64(time.Second))
+	}
+	state.full = now.Add(time.Duration((burst - state.tokens) / bucket.Rate * float64(time.Second)))
+	return allowed, wait
+}
+
+// matchMethod 方法列表为空时匹配所有方法
+func matchMethod(methods []string, method string) bool {
+	if len(methods) == 0 {
+		return true
+	}
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,564 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/neohope/chatapp/api-gateway/config"
+)
+
+// supportedSchemaKeywords 支持的JSON Schema关键字，出现其他关键字时加载失败，避免规则被静默忽略
+var supportedSchemaKeywords = map[string]bool{
+	"$schema": true, "$id": true, "title": true, "description": true,
+	"type": true, "enum": true, "properties": true, "required": true, "additionalProperties": true,
+	"items": true, "minItems": true, "maxItems": true,
+	"minLength": true, "maxLength": true, "pattern": true, "format": true,
+	"minimum": true, "maximum": true,
+}
+
+var schemaUUIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// SchemaViolation 单个字段的校验错误，Field为JSON字段路径，嵌套字段以"."连接，数组元素为"[i]"
+type SchemaViolation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// RequestValidationError 请求体校验失败，以Status返回给客户端，响应体与后端服务的校验错误格式一致
+type RequestValidationError struct {
+	Status  int               `json:"-"`
+	Message string            `json:"error"`
+	Fields  []SchemaViolation `json:"fields,omitempty"`
+}
+
+// Error 实现error接口
+func (e *RequestValidationError) Error() string {
+	return e.Message
+}
+
+// jsonSchema 编译后的Schema，只支持draft-07的常用子集
+type jsonSchema struct {
+	types                []string
+	enum                 []interface{}
+	properties           map[string]*jsonSchema
+	required             []string
+	additionalProperties *bool
+	items                *jsonSchema
+	minItems, maxItems   *int
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	format               string
+	minimum, maximum     *float64
+}
+
+// schemaRoute 方法、按段拆分的路径及其Schema
+type schemaRoute struct {
+	method   string
+	segments []string
+	schema   *jsonSchema
+}
+
+// RequestValidator 按路由配置在转发前校验JSON请求体，不符合Schema的请求不会到达后端服务
+type RequestValidator struct {
+	maxBodyBytes int64
+	routes       []schemaRoute
+}
+
+// NewRequestValidator 编译路由配置引用的Schema，Schema无效时返回错误
+func NewRequestValidator(cfg *config.RequestSchemaConfig) (*RequestValidator, error) {
+	validator := &RequestValidator{maxBodyBytes: cfg.MaxBodyBytes}
+	for _, route := range cfg.Routes {
+		var document interface{}
+		if err := json.Unmarshal(route.Document, &document); err != nil {
+			return nil, fmt.Errorf("invalid schema %s: %w", route.Schema, err)
+		}
+		schema, err := compileSchema(document, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema %s: %w", route.Schema, err)
+		}
+		validator.routes = append(validator.routes, schemaRoute{
+			method:   route.Method,
+			segments: strings.Split(strings.Trim(route.Path, "/"), "/"),
+			schema:   schema,
+		})
+	}
+	return validator, nil
+}
+
+// Routes 已配置校验的路由数
+func (v *RequestValidator) Routes() int {
+	return len(v.routes)
+}
+
+// Validate 校验匹配路由的请求体，未匹配任何路由时直接通过
+// 读取的请求体重新放回r.Body继续转发
+func (v *RequestValidator) Validate(r *http.Request) *RequestValidationError {
+	schema := v.match(r.Method, r.URL.Path)
+	if schema == nil {
+		return nil
+	}
+
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return &RequestValidationError{
+			Status:  http.StatusUnprocessableEntity,
+			Message: "validation failed",
+			Fields:  []SchemaViolation{{Rule: "required", Message: "request body is required"}},
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return &RequestValidationError{Status: http.StatusUnsupportedMediaType, Message: "Content-Type must be application/json"}
+	}
+	if r.ContentLength > v.maxBodyBytes {
+		return &RequestValidationError{Status: http.StatusRequestEntityTooLarge, Message: "Request body too large"}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, v.maxBodyBytes+1))
+	r.Body.Close()
+	if err != nil {
+		return &RequestValidationError{Status: http.StatusBadRequest, Message: "Failed to read request body"}
+	}
+	if int64(len(body)) > v.maxBodyBytes {
+		return &RequestValidationError{Status: http.StatusRequestEntityTooLarge, Message: "Request body too large"}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	value, err := decodeJSONBody(body)
+	if err != nil {
+		return &RequestValidationError{Status: http.StatusBadRequest, Message: "invalid JSON body: " + err.Error()}
+	}
+
+	var violations []SchemaViolation
+	schema.validate(value, "", &violations)
+	if len(violations) > 0 {
+		return &RequestValidationError{
+			Status:  http.StatusUnprocessableEntity,
+			Message: "validation failed",
+			Fields:  violations,
+		}
+	}
+	return nil
+}
+
+// match 返回请求匹配路由的Schema
+func (v *RequestValidator) match(method, path string) *jsonSchema {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, route := range v.routes {
+		if route.method != method || len(route.segments) != len(segments) {
+			continue
+		}
+		matched := true
+		for i, segment := range route.segments {
+			isParam := strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+			if !isParam && segment != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return route.schema
+		}
+	}
+	return nil
+}
+
+// decodeJSONBody 解析请求体，只允许一个JSON值
+func decodeJSONBody(body []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return value, nil
+}
+
+// compileSchema 将Schema文档编译为jsonSchema，at为当前位置，用于错误信息
+func compileSchema(document interface{}, at string) (*jsonSchema, error) {
+	object, ok := document.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object", schemaLocation(at))
+	}
+	for keyword := range object {
+		if !supportedSchemaKeywords[keyword] {
+			return nil, fmt.Errorf("%s: unsupported keyword %q", schemaLocation(at), keyword)
+		}
+	}
+
+	schema := &jsonSchema{}
+	switch types := object["type"].(type) {
+	case nil:
+	case string:
+		schema.types = []string{types}
+	case []interface{}:
+		for _, item := range types {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: type must be a string or an array of strings", schemaLocation(at))
+			}
+			schema.types = append(schema.types, name)
+		}
+	default:
+		return nil, fmt.Errorf("%s: type must be a string or an array of strings", schemaLocation(at))
+	}
+	for _, name := range schema.types {
+		switch name {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return nil, fmt.Errorf("%s: unknown type %q", schemaLocation(at), name)
+		}
+	}
+
+	if enum, ok := object["enum"]; ok {
+		values, ok := enum.([]interface{})
+		if !ok || len(values) == 0 {
+			return nil, fmt.Errorf("%s: enum must be a non-empty array", schemaLocation(at))
+		}
+		schema.enum = values
+	}
+
+	if properties, ok := object["properties"]; ok {
+		fields, ok := properties.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: properties must be an object", schemaLocation(at))
+		}
+		schema.properties = make(map[string]*jsonSchema, len(fields))
+		for name, child := range fields {
+			compiled, err := compileSchema(child, joinField(at, name))
+			if err != nil {
+				return nil, err
+			}
+			schema.properties[name] = compiled
+		}
+	}
+	if required, ok := object["required"]; ok {
+		names, ok := required.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: required must be an array of strings", schemaLocation(at))
+		}
+		for _, item := range names {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: required must be an array of strings", schemaLocation(at))
+			}
+			schema.required = append(schema.required, name)
+		}
+	}
+	if additional, ok := object["additionalProperties"]; ok {
+		allowed, ok := additional.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s: additionalProperties must be a boolean", schemaLocation(at))
+		}
+		schema.additionalProperties = &allowed
+	}
+
+	if items, ok := object["items"]; ok {
+		compiled, err := compileSchema(items, at+"[]")
+		if err != nil {
+			return nil, err
+		}
+		schema.items = compiled
+	}
+
+	counts := map[string]**int{
+		"minItems":  &schema.minItems,
+		"maxItems":  &schema.maxItems,
+		"minLength": &schema.minLength,
+		"maxLength": &schema.maxLength,
+	}
+	for keyword, target := range counts {
+		if raw, ok := object[keyword]; ok {
+			number, ok := raw.(float64)
+			if !ok || number < 0 || number != math.Trunc(number) {
+				return nil, fmt.Errorf("%s: %s must be a non-negative integer", schemaLocation(at), keyword)
+			}
+			count := int(number)
+			*target = &count
+		}
+	}
+	bounds := map[string]**float64{
+		"minimum": &schema.minimum,
+		"maximum": &schema.maximum,
+	}
+	for keyword, target := range bounds {
+		if raw, ok := object[keyword]; ok {
+			number, ok := raw.(float64)
+			if !ok {
+				return nil, fmt.Errorf("%s: %s must be a number", schemaLocation(at), keyword)
+			}
+			*target = &number
+		}
+	}
+
+	if raw, ok := object["pattern"]; ok {
+		expr, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: pattern must be a string", schemaLocation(at))
+		}
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid pattern: %w", schemaLocation(at), err)
+		}
+		schema.pattern = pattern
+	}
+	if raw, ok := object["format"]; ok {
+		format, _ := raw.(string)
+		switch format {
+		case "email", "uuid", "uri", "date-time":
+			schema.format = format
+		default:
+			return nil, fmt.Errorf("%s: unsupported format %v", schemaLocation(at), raw)
+		}
+	}
+	return schema, nil
+}
+
+// validate 校验value，field为当前字段路径
+func (s *jsonSchema) validate(value interface{}, field string, violations *[]SchemaViolation) {
+	if len(s.types) > 0 && !matchesAnyType(value, s.types) {
+		*violations = append(*violations, violation(field, "type", strings.Join(s.types, " "),
+			"must be "+describeTypes(s.types)))
+		return
+	}
+	if s.enum != nil && !inEnum(value, s.enum) {
+		options := make([]string, len(s.enum))
+		for i, option := range s.enum {
+			options[i] = fmt.Sprint(option)
+		}
+		*violations = append(*violations, violation(field, "enum", strings.Join(options, " "),
+			"must be one of: "+strings.Join(options, ", ")))
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		s.validateObject(v, field, violations)
+	case []interface{}:
+		s.validateArray(v, field, violations)
+	case string:
+		s.validateString(v, field, violations)
+	case json.Number:
+		s.validateNumber(v, field, violations)
+	}
+}
+
+// validateObject 校验必填字段、已声明字段和未声明字段
+func (s *jsonSchema) validateObject(object map[string]interface{}, field string, violations *[]SchemaViolation) {
+	for _, name := range s.required {
+		if _, ok := object[name]; !ok {
+			child := joinField(field, name)
+			*violations = append(*violations, SchemaViolation{Field: child, Rule: "required", Message: child + " is required"})
+		}
+	}
+	// 按字段名排序，错误顺序稳定
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		childField := joinField(field, name)
+		if schema, ok := s.properties[name]; ok {
+			schema.validate(object[name], childField, violations)
+		} else if s.additionalProperties != nil && !*s.additionalProperties {
+			*violations = append(*violations, SchemaViolation{Field: childField, Rule: "additionalProperties", Message: childField + " is not allowed"})
+		}
+	}
+}
+
+// validateArray 校验元素个数和每个元素
+func (s *jsonSchema) validateArray(items []interface{}, field string, violations *[]SchemaViolation) {
+	if s.minItems != nil && len(items) < *s.minItems {
+		*violations = append(*violations, violation(field, "minItems", strconv.Itoa(*s.minItems),
+			fmt.Sprintf("must contain at least %d items", *s.minItems)))
+	}
+	if s.maxItems != nil && len(items) > *s.maxItems {
+		*violations = append(*violations, violation(field, "maxItems", strconv.Itoa(*s.maxItems),
+			fmt.Sprintf("must contain at most %d items", *s.maxItems)))
+	}
+	if s.items != nil {
+		for i, item := range items {
+			s.items.validate(item, fmt.Sprintf("%s[%d]", field, i), violations)
+		}
+	}
+}
+
+// validateString 校验长度（按字符数）、正则和格式
+func (s *jsonSchema) validateString(value, field string, violations *[]SchemaViolation) {
+	length := utf8.RuneCountInString(value)
+	if s.minLength != nil && length < *s.minLength {
+		*violations = append(*violations, violation(field, "minLength", strconv.Itoa(*s.minLength),
+			fmt.Sprintf("must contain at least %d characters", *s.minLength)))
+		return
+	}
+	if s.maxLength != nil && length > *s.maxLength {
+		*violations = append(*violations, violation(field, "maxLength", strconv.Itoa(*s.maxLength),
+			fmt.Sprintf("must contain at most %d characters", *s.maxLength)))
+		return
+	}
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		*violations = append(*violations, violation(field, "pattern", s.pattern.String(),
+			"must match pattern "+s.pattern.String()))
+		return
+	}
+
+	var message string
+	switch s.format {
+	case "email":
+		if addr, err := mail.ParseAddress(value); err != nil || addr.Address != value {
+			message = "must be a valid email address"
+		}
+	case "uuid":
+		if !schemaUUIDPattern.MatchString(value) {
+			message = "must be a valid UUID"
+		}
+	case "uri":
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			message = "must be a valid URL"
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			message = "must be an RFC 3339 date-time"
+		}
+	}
+	if message != "" {
+		*violations = append(*violations, violation(field, "format", s.format, message))
+	}
+}
+
+// validateNumber 校验数值范围
+func (s *jsonSchema) validateNumber(value json.Number, field string, violations *[]SchemaViolation) {
+	number, err := value.Float64()
+	if err != nil {
+		return
+	}
+	if s.minimum != nil && number < *s.minimum {
+		param := strconv.FormatFloat(*s.minimum, 'f', -1, 64)
+		*violations = append(*violations, violation(field, "minimum", param, "must be at least "+param))
+	}
+	if s.maximum != nil && number > *s.maximum {
+		param := strconv.FormatFloat(*s.maximum, 'f', -1, 64)
+		*violations = append(*violations, violation(field, "maximum", param, "must be at most "+param))
+	}
+}
+
+// matchesAnyType value是否为types中的任一JSON类型
+func matchesAnyType(value interface{}, types []string) bool {
+	for _, name := range types {
+		switch v := value.(type) {
+		case nil:
+			if name == "null" {
+				return true
+			}
+		case bool:
+			if name == "boolean" {
+				return true
+			}
+		case string:
+			if name == "string" {
+				return true
+			}
+		case json.Number:
+			if name == "number" {
+				return true
+			}
+			if name == "integer" {
+				if _, err := v.Int64(); err == nil {
+					return true
+				}
+				if f, err := v.Float64(); err == nil && f == math.Trunc(f) {
+					return true
+				}
+			}
+		case []interface{}:
+			if name == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if name == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inEnum value是否等于enum中的某个值，数字按数值比较
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, option := range enum {
+		if number, ok := value.(json.Number); ok {
+			expected, isNumber := option.(float64)
+			actual, err := number.Float64()
+			if isNumber && err == nil && actual == expected {
+				return true
+			}
+			continue
+		}
+		if fmt.Sprintf("%T:%v", value, value) == fmt.Sprintf("%T:%v", option, option) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeTypes 类型错误的描述，如"a string or null"
+func describeTypes(types []string) string {
+	described := make([]string, len(types))
+	for i, name := range types {
+		switch name {
+		case "object", "array", "integer":
+			described[i] = "an " + name
+		case "null":
+			described[i] = "null"
+		default:
+			described[i] = "a " + name
+		}
+	}
+	return strings.Join(described, " or ")
+}
+
+// violation 创建字段错误，根字段的描述以"request body"开头
+func violation(field, rule, param, message string) SchemaViolation {
+	subject := field
+	if subject == "" {
+		subject = "request body"
+	}
+	return SchemaViolation{Field: field, Rule: rule, Param: param, Message: subject + " " + message}
+}
+
+// joinField 拼接字段路径
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// schemaLocation Schema中的位置描述
+func schemaLocation(at string) string {
+	if at == "" {
+		return "root"
+	}
+	return at
+}
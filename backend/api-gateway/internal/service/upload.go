@@ -0,0 +1,159 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/api-gateway/config"
+)
+
+// UploadMetrics 上传转发统计
+type UploadMetrics struct {
+	active    atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+	bytes     atomic.Int64
+}
+
+// UploadMetricsSnapshot 上传统计快照
+type UploadMetricsSnapshot struct {
+	Active        int64 `json:"active"`
+	Completed     int64 `json:"completed"`
+	Failed        int64 `json:"failed"`
+	BytesStreamed int64 `json:"bytes_streamed"`
+}
+
+// Snapshot 获取当前统计
+func (m *UploadMetrics) Snapshot() UploadMetricsSnapshot {
+	return UploadMetricsSnapshot{
+		Active:        m.active.Load(),
+		Completed:     m.completed.Load(),
+		Failed:        m.failed.Load(),
+		BytesStreamed: m.bytes.Load(),
+	}
+}
+
+// bodyStreamer 通过io.Pipe把客户端请求体边读边写给后端，内存占用只有一个缓冲区
+type bodyStreamer struct {
+	cfg     config.UploadConfig
+	pool    sync.Pool
+	metrics *UploadMetrics
+	logger  *zap.Logger
+}
+
+func newBodyStreamer(cfg config.UploadConfig, logger *zap.Logger) *bodyStreamer {
+	return &bodyStreamer{
+		cfg: cfg,
+		pool: sync.Pool{New: func() interface{} {
+			buf := make([]byte, cfg.BufferSize)
+			return &buf
+		}},
+		metrics: &UploadMetrics{},
+		logger:  logger,
+	}
+}
+
+// isUpload 是否按上传处理：multipart、分块传输或超过阈值的请求体
+func (s *bodyStreamer) isUpload(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") ||
+		r.ContentLength < 0 ||
+		r.ContentLength > s.cfg.StreamThreshold
+}
+
+// uploadStream 一次请求体转发
+type uploadStream struct {
+	body *io.PipeReader
+	done chan struct{}
+	err  error
+}
+
+// Err 等待转发结束并返回读取客户端请求体时的错误
+func (u *uploadStream) Err() error {
+	<-u.done
+	return u.err
+}
+
+// Stream 启动请求体转发，返回供后端请求读取的管道
+func (s *bodyStreamer) Stream(w http.ResponseWriter, r *http.Request) *uploadStream {
+	pr, pw := io.Pipe()
+	upload := &uploadStream{body: pr, done: make(chan struct{})}
+	body := r.Body
+	if s.cfg.MaxBytes > 0 {
+		body = http.MaxBytesReader(w, r.Body, s.cfg.MaxBytes)
+	}
+
+	s.metrics.active.Add(1)
+	go func() {
+		defer close(upload.done)
+		defer s.metrics.active.Add(-1)
+		defer body.Close()
+
+		bufPtr := s.pool.Get().(*[]byte)
+		defer s.pool.Put(bufPtr)
+
+		start := time.Now()
+		writer := &progressWriter{
+			w:        pw,
+			total:    r.ContentLength,
+			interval: s.cfg.ProgressLogBytes,
+			onProgress: func(written, total int64) {
+				s.logger.Debug("Upload progress",
+					zap.String("path", r.URL.Path),
+					zap.Int64("bytes", written),
+					zap.Int64("total", total),
+				)
+			},
+			metrics: s.metrics,
+		}
+		_, err := io.CopyBuffer(writer, body, *bufPtr)
+		// 后端提前结束读取时写入会失败，不算客户端错误
+		if errors.Is(err, io.ErrClosedPipe) {
+			err = nil
+		}
+		upload.err = err
+		pw.CloseWithError(err)
+
+		if err != nil {
+			s.metrics.failed.Add(1)
+			s.logger.Warn("Upload stream aborted", zap.String("path", r.URL.Path), zap.Int64("bytes", writer.written), zap.Error(err))
+			return
+		}
+		s.metrics.completed.Add(1)
+		s.logger.Debug("Upload streamed",
+			zap.String("path", r.URL.Path),
+			zap.Int64("bytes", writer.written),
+			zap.Duration("duration", time.Since(start)),
+		)
+	}()
+
+	return upload
+}
+
+// progressWriter 统计写入字节数，每跨过一个间隔回调一次
+type progressWriter struct {
+	w          io.Writer
+	written    int64
+	total      int64
+	interval   int64
+	next       int64
+	onProgress func(written, total int64)
+	metrics    *UploadMetrics
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.metrics.bytes.Add(int64(n))
+	if p.interval > 0 && p.written >= p.next+p.interval {
+		p.next = p.written - p.written%p.interval
+		p.onProgress(p.written, p.total)
+	}
+	return n, err
+}
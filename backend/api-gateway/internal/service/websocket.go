@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/api-gateway/config"
+	"github.com/neohope/chatapp/api-gateway/pkg/recovery"
+	"github.com/neohope/chatapp/api-gateway/pkg/shutdown"
+	"github.com/neohope/chatapp/api-gateway/pkg/tracing"
+)
+
+// controlWriteTimeout 转发控制帧（ping/pong/close）的写超时
+const controlWriteTimeout = 5 * time.Second
+
+// webSocketHandshakeHeaders 由websocket库在握手时生成，不能从客户端请求中复制
+var webSocketHandshakeHeaders = map[string]bool{
+	"Upgrade":                  true,
+	"Connection":               true,
+	"Sec-Websocket-Key":        true,
+	"Sec-Websocket-Version":    true,
+	"Sec-Websocket-Extensions": true,
+}
+
+// webSocketProxy 在客户端与后端服务之间逐帧转发WebSocket消息
+// ping/pong也原样转发，后端的心跳检测能反映客户端的真实状态
+type webSocketProxy struct {
+	upgrader websocket.Upgrader
+	dialer   *websocket.Dialer
+
+	mu       sync.Mutex
+	sessions map[*webSocketSession]struct{}
+	draining bool
+	wg       sync.WaitGroup
+
+	logger *zap.Logger
+}
+
+// webSocketSession 一条被代理的连接
+type webSocketSession struct {
+	client  *websocket.Conn
+	backend *websocket.Conn
+}
+
+func newWebSocketProxy(cfg config.WebSocketConfig, logger *zap.Logger) *webSocketProxy {
+	return &webSocketProxy{
+		upgrader: websocket.Upgrader{
+			HandshakeTimeout: cfg.HandshakeTimeout,
+			ReadBufferSize:   cfg.BufferSize,
+			WriteBufferSize:  cfg.BufferSize,
+			// 连接通过令牌认证，与消息服务一致不限制Origin
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		dialer: &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: cfg.HandshakeTimeout,
+			ReadBufferSize:   cfg.BufferSize,
+			WriteBufferSize:  cfg.BufferSize,
+		},
+		sessions: make(map[*webSocketSession]struct{}),
+		logger:   logger,
+	}
+}
+
+// IsWebSocketUpgrade 是否为WebSocket升级请求
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
+
+// ProxyWebSocket 将WebSocket连接转发到目标服务的指定路径，客户端只需连接网关端口
+// 先与后端完成握手再升级客户端连接，后端拒绝时把状态码原样返回给客户端
+func (p *ProxyService) ProxyWebSocket(w http.ResponseWriter, r *http.Request, serviceName, path string) {
+	if !IsWebSocketUpgrade(r) {
+		http.Error(w, "WebSocket upgrade required", http.StatusBadRequest)
+		return
+	}
+
+	targetURL, exists := p.services[serviceName]
+	if !exists {
+		p.logger.Error("Service not found", zap.String("service", serviceName))
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+	target, err := webSocketTarget(targetURL, path, r)
+	if err != nil {
+		p.logger.Error("Invalid target URL", zap.String("url", targetURL), zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	p.websockets.serve(w, r, serviceName, target, p.webSocketHeaders(r))
+}
+
+// DrainWebSockets 关闭时通知所有代理中的连接重连，等待转发结束；ctx取消时强制断开
+// http.Server.Shutdown不跟踪已升级的连接，需要在其后单独执行
+func (p *ProxyService) DrainWebSockets(ctx context.Context) error {
+	return p.websockets.drain(ctx)
+}
+
+// WebSocketConnections 当前代理中的WebSocket连接数
+func (p *ProxyService) WebSocketConnections() int {
+	p.websockets.mu.Lock()
+	defer p.websockets.mu.Unlock()
+	return len(p.websockets.sessions)
+}
+
+// webSocketTarget 构建后端WebSocket地址：http(s)替换为ws(s)，保留客户端的查询参数，
+// 注入网关认证得到的user_id；客户端通过Authorization头认证时令牌转为token参数，后端只从查询参数读取令牌
+func webSocketTarget(serviceURL, path string, r *http.Request) (*url.URL, error) {
+	target, err := url.Parse(serviceURL)
+	if err != nil {
+		return nil, err
+	}
+	switch target.Scheme {
+	case "https":
+		target.Scheme = "wss"
+	default:
+		target.Scheme = "ws"
+	}
+	target.Path = path
+
+	query := r.URL.Query()
+	if userID, ok := r.Context().Value("user_id").(string); ok && userID != "" {
+		query.Set("user_id", userID)
+	} else {
+		query.Del("user_id")
+	}
+	// 内省通过的第三方令牌已在认证中间件中替换为内部令牌，同样以头中的令牌为准
+	if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		query.Set("token", strings.TrimPrefix(authHeader, "Bearer "))
+	}
+	target.RawQuery = query.Encode()
+	return target, nil
+}
+
+func (wp *webSocketProxy) serve(w http.ResponseWriter, r *http.Request, serviceName string, target *url.URL, header http.Header) {
+	wp.mu.Lock()
+	draining := wp.draining
+	wp.mu.Unlock()
+	if draining {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	backend, resp, err := wp.dialer.DialContext(r.Context(), target.String(), header)
+	if err != nil {
+		if resp != nil {
+			// 后端拒绝握手（如令牌无效），将状态码和响应体返回给客户端
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+			w.WriteHeader(resp.StatusCode)
+			w.Write(body) // nolint: errcheck
+			return
+		}
+		wp.logger.Error("Failed to dial backend WebSocket",
+			zap.String("service", serviceName),
+			zap.String("url", target.Host+target.Path),
+			zap.Error(err),
+		)
+		http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	// 返回后端选定的子协议，网关生成的请求ID也需要随握手响应返回
+	responseHeader := http.Header{}
+	if protocol := resp.Header.Get("Sec-Websocket-Protocol"); protocol != "" {
+		responseHeader.Set("Sec-Websocket-Protocol", protocol)
+	}
+	if requestID := w.Header().Get(recovery.RequestIDHeader); requestID != "" {
+		responseHeader.Set(recovery.RequestIDHeader, requestID)
+	}
+	client, err := wp.upgrader.Upgrade(w, r, responseHeader)
+	if err != nil {
+		// Upgrade已向客户端返回错误
+		wp.logger.Warn("Failed to upgrade client connection", zap.Error(err))
+		backend.Close()
+		return
+	}
+
+	session := &webSocketSession{client: client, backend: backend}
+	if !wp.add(session) {
+		closeWith(client, websocket.CloseServiceRestart, "server is shutting down")
+		closeWith(backend, websocket.CloseGoingAway, "client gone")
+		client.Close()
+		backend.Close()
+		return
+	}
+	defer wp.remove(session)
+
+	userID, _ := r.Context().Value("user_id").(string)
+	start := time.Now()
+	wp.logger.Info("WebSocket connection proxied", zap.String("service", serviceName), zap.String("user_id", userID))
+
+	wp.relay(session)
+
+	wp.logger.Info("WebSocket connection closed",
+		zap.String("service", serviceName),
+		zap.String("user_id", userID),
+		zap.Duration("duration", time.Since(start)),
+	)
+}
+
+// webSocketHeaders 复制客户端请求头（握手头除外）并按头策略改写，与普通请求一样注入网关认证得到的用户信息
+func (p *ProxyService) webSocketHeaders(r *http.Request) http.Header {
+	header := http.Header{}
+	for key, values := range r.Header {
+		if webSocketHandshakeHeaders[http.CanonicalHeaderKey(key)] {
+			continue
+		}
+		header[key] = append([]string(nil), values...)
+	}
+	p.headers.ApplyRequest(r, header)
+	// 握手请求携带网关的trace上下文，替换客户端自带的traceparent
+	tracing.Inject(r.Context(), header)
+
+	if userID, ok := r.Context().Value("user_id").(string); ok {
+		header.Set("X-User-ID", userID)
+	}
+	if email, ok := r.Context().Value("email").(string); ok {
+		header.Set("X-User-Email", email)
+	}
+	header.Del("X-User-Role")
+	if role, ok := r.Context().Value("role").(string); ok {
+		header.Set("X-User-Role", role)
+	}
+	header.Del("X-OAuth-Client-ID")
+	if clientID, ok := r.Context().Value("client_id").(string); ok {
+		header.Set("X-OAuth-Client-ID", clientID)
+	}
+	return header
+}
+
+// relay 双向转发直到任意一侧关闭，关闭帧转发给另一侧后断开两条连接
+func (wp *webSocketProxy) relay(session *webSocketSession) {
+	client, backend := session.client, session.backend
+	forwardControl(client, backend)
+	forwardControl(backend, client)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		copyMessages(backend, client)
+		done <- struct{}{}
+	}()
+	go func() {
+		copyMessages(client, backend)
+		done <- struct{}{}
+	}()
+
+	// 一侧结束后等待另一侧收到关闭帧，超时则直接断开
+	<-done
+	select {
+	case <-done:
+	case <-time.After(controlWriteTimeout):
+	}
+	client.Close()
+	backend.Close()
+}
+
+// forwardControl 将src收到的ping/pong转发给dst
+func forwardControl(src, dst *websocket.Conn) {
+	src.SetPingHandler(func(data string) error {
+		return ignoreClosed(dst.WriteControl(websocket.PingMessage, []byte(data), time.Now().Add(controlWriteTimeout)))
+	})
+	src.SetPongHandler(func(data string) error {
+		return ignoreClosed(dst.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(controlWriteTimeout)))
+	})
+}
+
+// copyMessages 将src的消息写入dst，src关闭时把关闭码转发给dst
+func copyMessages(dst, src *websocket.Conn) {
+	for {
+		messageType, data, err := src.ReadMessage()
+		if err != nil {
+			code, text := websocket.CloseGoingAway, ""
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) && closeErr.Code != websocket.CloseAbnormalClosure {
+				code, text = closeErr.Code, closeErr.Text
+			}
+			closeWith(dst, code, text)
+			return
+		}
+		if err := dst.WriteMessage(messageType, data); err != nil {
+			closeWith(src, websocket.CloseGoingAway, "")
+			return
+		}
+	}
+}
+
+// closeWith 发送关闭帧，连接已关闭时忽略错误
+func closeWith(conn *websocket.Conn, code int, text string) {
+	message := []byte{}
+	if code != websocket.CloseNoStatusReceived {
+		message = websocket.FormatCloseMessage(code, text)
+	}
+	conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(controlWriteTimeout)) // nolint: errcheck
+}
+
+func ignoreClosed(err error) error {
+	if err == nil || errors.Is(err, websocket.ErrCloseSent) || errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+func (wp *webSocketProxy) add(session *webSocketSession) bool {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	if wp.draining {
+		return false
+	}
+	wp.sessions[session] = struct{}{}
+	wp.wg.Add(1)
+	return true
+}
+
+func (wp *webSocketProxy) remove(session *webSocketSession) {
+	wp.mu.Lock()
+	delete(wp.sessions, session)
+	wp.mu.Unlock()
+	wp.wg.Done()
+}
+
+// drain 向客户端发送1012（服务重启）关闭帧提示重连，向后端发送1001关闭帧，等待转发结束
+func (wp *webSocketProxy) drain(ctx context.Context) error {
+	wp.mu.Lock()
+	wp.draining = true
+	sessions := make([]*webSocketSession, 0, len(wp.sessions))
+	for session := range wp.sessions {
+		sessions = append(sessions, session)
+	}
+	wp.mu.Unlock()
+
+	wp.logger.Info("Draining proxied WebSocket connections", zap.Int("connections", len(sessions)))
+	for _, session := range sessions {
+		closeWith(session.client, websocket.CloseServiceRestart, "server is shutting down, please reconnect")
+		closeWith(session.backend, websocket.CloseGoingAway, "gateway is shutting down")
+	}
+
+	if err := shutdown.Await(ctx, wp.wg.Wait); err != nil {
+		// 超时仍未结束的连接直接断开
+		wp.mu.Lock()
+		for session := range wp.sessions {
+			session.client.Close()
+			session.backend.Close()
+		}
+		wp.mu.Unlock()
+		return err
+	}
+	return nil
+}
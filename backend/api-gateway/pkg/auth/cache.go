@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// ValidationCache 缓存令牌校验结果，键为令牌的SHA-256摘要，避免高并发下重复解析和验签
+type ValidationCache struct {
+	mu         sync.RWMutex
+	entries    map[[sha256.Size]byte]cacheEntry
+	ttl        time.Duration
+	maxEntries int
+}
+
+type cacheEntry struct {
+	claims    Claims
+	expiresAt time.Time
+}
+
+// NewValidationCache 创建校验结果缓存
+func NewValidationCache(ttl time.Duration, maxEntries int) *ValidationCache {
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	return &ValidationCache{
+		entries:    make(map[[sha256.Size]byte]cacheEntry),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+// get 读取未过期的缓存结果
+func (c *ValidationCache) get(key [sha256.Size]byte) (*Claims, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	claims := entry.claims
+	return &claims, true
+}
+
+// put 写入校验结果，缓存时间不超过令牌本身的过期时间
+func (c *ValidationCache) put(key [sha256.Size]byte, claims *Claims) {
+	now := time.Now()
+	expiresAt := now.Add(c.ttl)
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(expiresAt) {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	if !expiresAt.After(now) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 容量已满时先清理过期项，仍然满则整体清空
+	if len(c.entries) >= c.maxEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			c.entries = make(map[[sha256.Size]byte]cacheEntry)
+		}
+	}
+	c.entries[key] = cacheEntry{claims: *claims, expiresAt: expiresAt}
+}
+
+// remove 删除缓存结果
+func (c *ValidationCache) remove(key [sha256.Size]byte) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+}
+
+// WithCache 为JWT管理器启用校验结果缓存
+func (j *JWTManager) WithCache(cache *ValidationCache) *JWTManager {
+	j.cache = cache
+	return j
+}
+
+// ValidateTokenCached 优先使用缓存的校验结果，未启用缓存时等同于ValidateToken
+func (j *JWTManager) ValidateTokenCached(tokenString string) (*Claims, error) {
+	if j.cache == nil {
+		return j.ValidateToken(tokenString)
+	}
+
+	key := sha256.Sum256([]byte(tokenString))
+	if claims, ok := j.cache.get(key); ok {
+		return claims, nil
+	}
+
+	claims, err := j.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	j.cache.put(key, claims)
+	return claims, nil
+}
+
+// Invalidate 使令牌的缓存结果失效，下一次请求重新完整校验
+func (j *JWTManager) Invalidate(tokenString string) {
+	if j.cache != nil {
+		j.cache.remove(sha256.Sum256([]byte(tokenString)))
+	}
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"testing"
+	"time"
+)
+
+func TestValidateTokenCached(t *testing.T) {
+	manager := NewJWTManager("test-secret").WithCache(NewValidationCache(time.Minute, 100))
+
+	token, err := manager.GenerateToken("user-1", "user1@example.com")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		claims, err := manager.ValidateTokenCached(token)
+		if err != nil {
+			t.Fatalf("ValidateTokenCached: %v", err)
+		}
+		if claims.UserID != "user-1" {
+			t.Fatalf("unexpected user id %q", claims.UserID)
+		}
+	}
+
+	// 签名错误的令牌不应被缓存命中
+	if _, err := manager.ValidateTokenCached(token + "x"); err == nil {
+		t.Fatal("expected tampered token to be rejected")
+	}
+
+	manager.Invalidate(token)
+	if _, ok := manager.cache.get(sha256.Sum256([]byte(token))); ok {
+		t.Fatal("expected cache entry to be removed")
+	}
+}
+
+func BenchmarkValidateToken(b *testing.B) {
+	manager := NewJWTManager("bench-secret")
+	token, _ := manager.GenerateToken("user-1", "user1@example.com")
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := manager.ValidateToken(token); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkValidateTokenCached(b *testing.B) {
+	manager := NewJWTManager("bench-secret").WithCache(NewValidationCache(time.Minute, 10000))
+	token, _ := manager.GenerateToken("user-1", "user1@example.com")
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := manager.ValidateTokenCached(token); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
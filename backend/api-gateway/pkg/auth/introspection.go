@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// IntrospectionConfig 外部IdP令牌内省（RFC 7662）配置
+type IntrospectionConfig struct {
+	Endpoint     string
+	ClientID     string // 网关访问内省端点使用的客户端凭据
+	ClientSecret string
+	// Issuer 内省结果不含iss时使用的IdP标识，与用户服务中外部身份映射的issuer一致
+	Issuer string
+	// AllowedClients 允许访问的第三方客户端ID，为空表示不限制
+	AllowedClients []string
+	CacheTTL       time.Duration // 内省结果缓存时间，不超过令牌本身的过期时间
+	Timeout        time.Duration
+}
+
+// IntrospectionResult RFC 7662 内省响应中使用到的字段
+type IntrospectionResult struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope"`
+	ClientID  string `json:"client_id"`
+	Username  string `json:"username"`
+	TokenType string `json:"token_type"`
+	Exp       int64  `json:"exp"`
+	Sub       string `json:"sub"`
+	Iss       string `json:"iss"`
+}
+
+// IdentityResolver 将外部IdP主体解析为内部用户，返回用户ID和邮箱
+type IdentityResolver func(ctx context.Context, issuer, subject string) (userID, email string, err error)
+
+// Introspector 通过外部IdP内省不透明令牌，并映射为内部用户身份
+type Introspector struct {
+	config         IntrospectionConfig
+	client         *http.Client
+	resolve        IdentityResolver
+	allowedClients map[string]bool
+	cache          *ValidationCache
+}
+
+// NewIntrospector 创建令牌内省器
+func NewIntrospector(config IntrospectionConfig, resolve IdentityResolver) *Introspector {
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	allowed := make(map[string]bool, len(config.AllowedClients))
+	for _, id := range config.AllowedClients {
+		allowed[id] = true
+	}
+
+	introspector := &Introspector{
+		config:         config,
+		client:         &http.Client{Timeout: config.Timeout},
+		resolve:        resolve,
+		allowedClients: allowed,
+	}
+	if config.CacheTTL > 0 {
+		introspector.cache = NewValidationCache(config.CacheTTL, 0)
+	}
+	return introspector
+}
+
+// IsOpaqueToken 判断令牌是否为不透明令牌（不是三段式JWT）
+func IsOpaqueToken(token string) bool {
+	return strings.Count(token, ".") != 2
+}
+
+// Validate 内省令牌并映射为内部用户，bypassCache为true时忽略缓存重新内省
+func (i *Introspector) Validate(ctx context.Context, token string, bypassCache bool) (*Claims, error) {
+	key := sha256.Sum256([]byte(token))
+	if i.cache != nil {
+		if bypassCache {
+			i.cache.remove(key)
+		} else if claims, ok := i.cache.get(key); ok {
+			return claims, nil
+		}
+	}
+
+	result, err := i.introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Active {
+		return nil, errors.New("token is not active")
+	}
+	if result.Exp > 0 && time.Unix(result.Exp, 0).Before(time.Now()) {
+		return nil, errors.New("token is expired")
+	}
+	if len(i.allowedClients) > 0 && !i.allowedClients[result.ClientID] {
+		return nil, fmt.Errorf("client %q is not allowed", result.ClientID)
+	}
+	if result.Sub == "" {
+		return nil, errors.New("introspection result has no subject")
+	}
+
+	issuer := result.Iss
+	if issuer == "" {
+		issuer = i.config.Issuer
+	}
+	userID, email, err := i.resolve(ctx, issuer, result.Sub)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{
+		UserID:   userID,
+		Email:    email,
+		ClientID: result.ClientID,
+		Scope:    result.Scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:  issuer,
+			Subject: result.Sub,
+		},
+	}
+	if result.Exp > 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(time.Unix(result.Exp, 0))
+	}
+	if i.cache != nil {
+		i.cache.put(key, claims)
+	}
+	return claims, nil
+}
+
+// introspect 调用内省端点，客户端凭据使用HTTP Basic认证
+func (i *Introspector) introspect(ctx context.Context, token string) (*IntrospectionResult, error) {
+	form := url.Values{
+		"token":           {token},
+		"token_type_hint": {"access_token"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.config.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(i.config.ClientID), url.QueryEscape(i.config.ClientSecret))
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("introspection endpoint unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspection endpoint returned status %d", resp.StatusCode)
+	}
+
+	var result IntrospectionResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid introspection response: %w", err)
+	}
+	return &result, nil
+}
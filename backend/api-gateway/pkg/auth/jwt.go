@@ -9,16 +9,35 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// 令牌类型，用户服务签发的刷新令牌只能用于 /api/v1/users/refresh
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 type JWTManager struct {
 	secretKey string
+	cache     *ValidationCache
 }
 
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	// Role 用户角色（user/admin），由用户服务签发时写入
+	Role string `json:"role,omitempty"`
+	// 第三方客户端经令牌内省访问时的客户端ID和授权范围
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	// 未携带token_type的旧令牌视为访问令牌
+	TokenType string `json:"token_type,omitempty"`
+	// TokenVersion 签发时用户的令牌版本，与用户服务中的当前版本不一致说明令牌已被吊销
+	TokenVersion int `json:"tv,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// internalTokenTTL 网关为内省通过的请求签发的内部令牌有效期
+const internalTokenTTL = 5 * time.Minute
+
 func NewJWTManager(secretKey string) *JWTManager {
 	return &JWTManager{
 		secretKey: secretKey,
@@ -39,6 +58,31 @@ func (j *JWTManager) GenerateToken(userID, email string) (string, error) {
 	return token.SignedString([]byte(j.secretKey))
 }
 
+// GenerateInternalToken 为内省通过的外部令牌签发短期内部令牌，转发给后端服务使用
+// 有效期不超过外部令牌本身的过期时间
+func (j *JWTManager) GenerateInternalToken(claims *Claims) (string, error) {
+	now := time.Now()
+	expiresAt := now.Add(internalTokenTTL)
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(expiresAt) {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	internal := &Claims{
+		UserID:       claims.UserID,
+		Email:        claims.Email,
+		ClientID:     claims.ClientID,
+		Scope:        claims.Scope,
+		TokenVersion: claims.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, internal)
+	return token.SignedString([]byte(j.secretKey))
+}
+
 func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(j.secretKey), nil
@@ -52,6 +96,9 @@ func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	if !ok || !token.Valid {
 		return nil, errors.New("invalid token")
 	}
+	if claims.TokenType == TokenTypeRefresh {
+		return nil, errors.New("refresh token cannot be used as access token")
+	}
 
 	return claims, nil
 }
@@ -0,0 +1,167 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// qualifiedName 匹配泛型类型名中类型参数的包路径，如media-service/internal/models.
+var qualifiedName = regexp.MustCompile(`[\w\-./]*\.`)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaRegistry 把Go类型转换为schema，命名的结构体放入components并以$ref引用
+type schemaRegistry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry(schemas map[string]*Schema) *schemaRegistry {
+	return &schemaRegistry{schemas: schemas, names: map[reflect.Type]string{}}
+}
+
+func (r *schemaRegistry) schemaFor(value interface{}) *Schema {
+	return r.typeSchema(reflect.TypeOf(value))
+}
+
+func (r *schemaRegistry) typeSchema(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time", Nullable: nullable}
+	case t == rawMessageType:
+		return &Schema{Nullable: nullable}
+	case t.Name() == "UUID" && t.Kind() == reflect.Array:
+		return &Schema{Type: "string", Format: "uuid", Nullable: nullable}
+	case t.Kind() != reflect.Struct && t.Kind() != reflect.Interface && t.Implements(marshalerType):
+		// 自定义序列化的非结构体类型无法从定义推断格式
+		return &Schema{Nullable: nullable}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean", Nullable: nullable}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32", Nullable: nullable}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64", Nullable: nullable}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Nullable: nullable}
+	case reflect.String:
+		return &Schema{Type: "string", Nullable: nullable}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte", Nullable: nullable}
+		}
+		return &Schema{Type: "array", Items: r.typeSchema(t.Elem()), Nullable: t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.typeSchema(t.Elem()), Nullable: true}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		return &Schema{Ref: schemaRefPrefix + r.register(t)}
+	default:
+		return &Schema{}
+	}
+}
+
+// register 结构体第一次出现时生成schema，不同包的同名类型以包名区分
+func (r *schemaRegistry) register(t reflect.Type) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+	name := schemaName(t)
+	if _, taken := r.schemas[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	r.names[t] = name
+	// 先占位，自引用的结构体在生成字段时直接使用$ref
+	r.schemas[name] = &Schema{Type: "object"}
+	*r.schemas[name] = *r.structSchema(t)
+	return name
+}
+
+// schemaName 泛型类型使用基础名和类型参数名，如Response[[]*models.Media]记为Response_MediaList
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	base, args, generic := strings.Cut(name, "[")
+	if !generic {
+		return name
+	}
+	args = qualifiedName.ReplaceAllString(strings.TrimSuffix(args, "]"), "")
+	args = strings.ReplaceAll(args, "map[string]", "Map")
+	for strings.HasPrefix(args, "[]") || strings.HasPrefix(args, "*") {
+		if strings.HasPrefix(args, "[]") {
+			args = strings.TrimPrefix(args, "[]") + "List"
+		} else {
+			args = strings.TrimPrefix(args, "*")
+		}
+	}
+	return base + "_" + strings.NewReplacer("[", "_", "]", "", ",", "_", "*", "").Replace(args)
+}
+
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	r.addFields(schema, t)
+	return schema
+}
+
+func (r *schemaRegistry) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		// 没有json名称的匿名结构体字段在序列化时展开
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = r.typeSchema(field.Type)
+		if isRequired(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// isRequired 按校验标签判断必填字段
+func isRequired(field reflect.StructField) bool {
+	for _, key := range []string{"validate", "binding"} {
+		for _, rule := range strings.Split(field.Tag.Get(key), ",") {
+			if rule == "required" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -2,22 +2,36 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/neohope/chatapp/group-service/config"
+	"github.com/neohope/chatapp/group-service/internal/client"
 	"github.com/neohope/chatapp/group-service/internal/database"
+	grpcdelivery "github.com/neohope/chatapp/group-service/internal/delivery/grpc"
 	"github.com/neohope/chatapp/group-service/internal/handler"
+	"github.com/neohope/chatapp/group-service/internal/models"
 	"github.com/neohope/chatapp/group-service/internal/repository"
 	"github.com/neohope/chatapp/group-service/internal/service"
+	"github.com/neohope/chatapp/group-service/internal/webhook"
+	"github.com/neohope/chatapp/group-service/pkg/events"
+	"github.com/neohope/chatapp/group-service/pkg/jobs"
 	"github.com/neohope/chatapp/group-service/pkg/jwt"
+	"github.com/neohope/chatapp/group-service/pkg/lock"
+	"github.com/neohope/chatapp/group-service/pkg/metrics"
+	"github.com/neohope/chatapp/group-service/pkg/openapi"
+	"github.com/neohope/chatapp/group-service/pkg/recovery"
+	"github.com/neohope/chatapp/group-service/pkg/shutdown"
+	"github.com/neohope/chatapp/group-service/pkg/tracing"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -37,8 +51,21 @@ func main() {
 		logger.Fatal("Failed to load config", zap.Error(err))
 	}
 
+	// 链路追踪：从请求的traceparent继续网关开始的trace，按配置导出到OTLP或Jaeger
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Options{
+		Service:       "group-service",
+		Exporter:      cfg.Tracing.Exporter,
+		Endpoint:      cfg.Tracing.Endpoint,
+		Insecure:      cfg.Tracing.Insecure,
+		SamplePercent: cfg.Tracing.SamplePercent,
+	}, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+
 	logger.Info("Configuration loaded",
 		zap.Int("http_port", cfg.HTTPPort),
+		zap.Int("grpc_port", cfg.GRPCPort),
 		zap.String("log_level", cfg.LogLevel),
 		zap.String("db_host", cfg.Database.Host),
 	)
@@ -63,28 +90,121 @@ func main() {
 		logger.Info("Using memory repository")
 	}
 
+	// 成员关系缓存，Redis不可用时直接访问数据库
+	membershipCache := initMembershipCache(cfg, groupRepo, logger)
+	if membershipCache != nil {
+		groupRepo = membershipCache
+	}
+
+	// 初始化消息服务客户端（用于为频道创建会话）
+	messageClient := client.NewMessageClient(cfg.MessageServiceURL)
+
+	// 初始化通知服务客户端（用于入群申请通知）
+	notificationClient := client.NewNotificationClient(cfg.NotificationServiceURL)
+
+	// 初始化成员同步Webhook投递器
+	dispatcher := webhook.NewDispatcher(time.Duration(cfg.Webhook.TimeoutSeconds)*time.Second, cfg.Webhook.MaxRetries, cfg.Webhook.AllowPrivateNetworks, logger)
+
+	// 初始化事件总线，成员加入等领域事件由通知服务订阅
+	eventBus := initEventBus(cfg, logger)
+
 	// 初始化服务
-	groupService := service.NewGroupService(groupRepo, logger)
+	groupService := service.NewGroupService(groupRepo, messageClient, notificationClient, dispatcher, eventPublisher(eventBus), service.OwnershipConfig{
+		MaxCoOwners:     cfg.Ownership.MaxCoOwners,
+		ConfirmationTTL: time.Duration(cfg.Ownership.ConfirmationTTLHours) * time.Hour,
+		DeleteRetention: time.Duration(cfg.Ownership.DeleteRetentionDays) * 24 * time.Hour,
+	}, service.StorageConfig{
+		Quotas: map[string]int64{
+			models.StorageTierFree:     int64(cfg.StorageQuota.FreeMB) << 20,
+			models.StorageTierStandard: int64(cfg.StorageQuota.StandardMB) << 20,
+			models.StorageTierPremium:  int64(cfg.StorageQuota.PremiumMB) << 20,
+		},
+	}, logger)
+
+	// 订阅消息事件记录成员活跃度，供不活跃成员清理使用
+	if eventBus != nil {
+		consumer := service.NewActivityConsumer(groupService, logger)
+		if err := eventBus.Subscribe("group-service", consumer.Handle, service.ActivityEventTypes...); err != nil {
+			logger.Warn("Failed to subscribe to activity events", zap.Error(err))
+		}
+		// 订阅媒体附加/移除事件统计群组文件存储用量
+		mediaConsumer := service.NewMediaUsageConsumer(groupService, logger)
+		if err := eventBus.Subscribe("group-service", mediaConsumer.Handle, service.MediaUsageEventTypes...); err != nil {
+			logger.Warn("Failed to subscribe to media usage events", zap.Error(err))
+		}
+	}
 
 	// 初始化处理器
 	groupHandler := handler.NewGroupHandler(groupService, jwtManager, logger)
 
+	// 初始化后台任务
+	elector := initLeaderElector(cfg, logger)
+	jobRunner := initJobRunner(db, elector, logger)
+	if err := registerJobs(jobRunner, db, groupRepo, groupService, eventBus != nil, cfg, logger); err != nil {
+		logger.Fatal("Failed to register background jobs", zap.Error(err))
+	}
+
 	// 初始化路由
 	router := mux.NewRouter()
-	setupRoutes(router, groupHandler)
+	setupRoutes(router, groupHandler, membershipCache, jobRunner)
+
+	// 接口文档：由注册的路由和接口说明生成，两者不一致时记录警告，严格模式下拒绝启动
+	apiDoc, problems := openapi.Build(router, "group-service", handler.APIOperations)
+	for _, problem := range problems {
+		logger.Warn("OpenAPI document does not match routes", zap.String("problem", problem))
+	}
+	if len(problems) > 0 && cfg.OpenAPI.Strict {
+		logger.Fatal("OpenAPI document does not match routes", zap.Int("problems", len(problems)))
+	}
+	router.HandleFunc("/openapi.json", openapi.Handler(apiDoc)).Methods("GET")
+
+	// 处理请求时的panic转换为带请求ID的500响应，配置了SENTRY_DSN时上报到Sentry
+	reporter, err := recovery.ReporterFromDSN(cfg.ErrorReporting.SentryDSN, cfg.ErrorReporting.Environment)
+	if err != nil {
+		logger.Fatal("Invalid error reporting configuration", zap.Error(err))
+	}
+	recoverer := recovery.New(recovery.Options{Service: "group-service", Reporter: reporter}, logger)
+
+	// Prometheus指标：按路由模板统计请求数和延迟，以及数据库连接池状态
+	var httpHandler http.Handler = recoverer.Middleware(router)
+	if cfg.Metrics.Enabled {
+		serviceMetrics := metrics.New("group-service")
+		if db.GetDB() != nil {
+			serviceMetrics.RegisterDB(db.GetDB().DB, "postgres")
+		}
+		router.Handle("/metrics", serviceMetrics.Handler(cfg.Metrics.Token)).Methods("GET")
+		httpHandler = serviceMetrics.Instrument(router, httpHandler)
+	}
+	httpHandler = tracing.Middleware("group-service", router, httpHandler)
+
+	// 优雅关闭：排空期间健康检查返回503，负载均衡摘除本实例后再停止接收连接
+	coordinator := shutdown.New(shutdown.Options{
+		DrainDelay: time.Duration(cfg.Shutdown.DrainDelaySeconds) * time.Second,
+		Timeout:    time.Duration(cfg.Shutdown.TimeoutSeconds) * time.Second,
+	}, logger)
 
 	// 启动HTTP服务器
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.HTTPPort),
-		Handler:      router,
+		Handler:      coordinator.Middleware(httpHandler),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
-	// 启动清理任务
-	if db.GetDB() != nil {
-		startCleanupTasks(db, logger)
+	// 启动后台任务
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if elector != nil {
+		elector.Start(jobCtx)
+	}
+	jobRunner.Start(jobCtx)
+
+	// 订阅成员变更失效事件
+	cacheCtx, stopCache := context.WithCancel(context.Background())
+	defer stopCache()
+	if membershipCache != nil {
+		membershipCache.Start(cacheCtx)
 	}
 
 	// 优雅关闭
@@ -95,22 +215,50 @@ func main() {
 		}
 	}()
 
-	// 等待中断信号
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.Info("Shutting down Group Service...")
-
-	// 优雅关闭服务器
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	// 启动gRPC服务器，供消息服务等内部服务查询成员权限
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort > 0 {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+		if err != nil {
+			logger.Fatal("Failed to listen on gRPC port", zap.Int("port", cfg.GRPCPort), zap.Error(err))
+		}
+		grpcServer = grpcdelivery.NewServer(grpcdelivery.NewMembershipServer(groupService, logger), recoverer, logger)
+		go func() {
+			logger.Info("Starting gRPC server", zap.Int("port", cfg.GRPCPort))
+			if err := grpcServer.Serve(listener); err != nil {
+				logger.Fatal("Failed to start gRPC server", zap.Error(err))
+			}
+		}()
+	}
 
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Error("Server forced to shutdown", zap.Error(err))
-	} else {
-		logger.Info("Group Service stopped gracefully")
+	// 关闭顺序：停止接收HTTP和gRPC请求并等待处理中的请求 -> 等待投递中的Webhook -> 发送缓存的事件 -> 等待后台任务 -> 导出剩余的span
+	coordinator.Register("http", server.Shutdown)
+	if grpcServer != nil {
+		coordinator.Register("grpc", func(ctx context.Context) error {
+			if err := shutdown.Await(ctx, grpcServer.GracefulStop); err != nil {
+				grpcServer.Stop()
+				return err
+			}
+			return nil
+		})
+	}
+	coordinator.Register("webhooks", func(ctx context.Context) error {
+		return shutdown.Await(ctx, dispatcher.Wait)
+	})
+	if eventBus != nil {
+		coordinator.Register("events", eventBus.Close)
 	}
+	coordinator.Register("jobs", func(ctx context.Context) error {
+		stopJobs()
+		if elector != nil {
+			defer elector.Wait()
+		}
+		return shutdown.Await(ctx, jobRunner.Wait)
+	})
+	coordinator.Register("tracing", shutdownTracing)
+	coordinator.Wait()
+
+	logger.Info("Group Service stopped gracefully")
 }
 
 // initLogger 初始化日志
@@ -125,6 +273,28 @@ func initLogger() (*zap.Logger, error) {
 	return config.Build()
 }
 
+// initEventBus 连接事件总线，未配置时返回nil
+func initEventBus(cfg *config.Config, logger *zap.Logger) *events.Bus {
+	if cfg.EventBusURL == "" {
+		logger.Info("Event bus not configured, domain events disabled")
+		return nil
+	}
+	bus, err := events.Connect(cfg.EventBusURL, "group-service", logger)
+	if err != nil {
+		logger.Warn("Failed to connect to event bus, domain events disabled", zap.Error(err))
+		return nil
+	}
+	return bus
+}
+
+// eventPublisher 事件总线不可用时返回丢弃事件的发布者
+func eventPublisher(bus *events.Bus) events.Publisher {
+	if bus == nil {
+		return events.NopPublisher()
+	}
+	return bus
+}
+
 // initDatabase 初始化数据库
 func initDatabase(cfg *config.Config, logger *zap.Logger) (*database.Database, error) {
 	db, err := database.NewDatabase(cfg, logger)
@@ -155,8 +325,42 @@ func initDatabase(cfg *config.Config, logger *zap.Logger) (*database.Database, e
 	return db, nil
 }
 
+// initMembershipCache 初始化成员关系缓存，未启用或Redis连接失败时返回nil
+func initMembershipCache(cfg *config.Config, repo repository.GroupRepository, logger *zap.Logger) *repository.CachedGroupRepository {
+	if !cfg.MembershipCache.Enabled {
+		return nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		logger.Warn("Failed to connect to Redis, membership cache disabled", zap.Error(err))
+		client.Close()
+		return nil
+	}
+
+	logger.Info("Membership cache enabled",
+		zap.String("redis_addr", cfg.Redis.Addr),
+		zap.Int("ttl_seconds", cfg.MembershipCache.TTLSeconds),
+	)
+	return repository.NewCachedGroupRepository(
+		repo,
+		client,
+		time.Duration(cfg.MembershipCache.TTLSeconds)*time.Second,
+		time.Duration(cfg.MembershipCache.LocalTTLSeconds)*time.Second,
+		cfg.MembershipCache.LocalMaxEntries,
+		logger,
+	)
+}
+
 // setupRoutes 设置路由
-func setupRoutes(router *mux.Router, groupHandler *handler.GroupHandler) {
+func setupRoutes(router *mux.Router, groupHandler *handler.GroupHandler, membershipCache *repository.CachedGroupRepository, jobRunner *jobs.Runner) {
 	// API版本前缀
 	api := router.PathPrefix("/api/v1").Subrouter()
 
@@ -166,6 +370,25 @@ func setupRoutes(router *mux.Router, groupHandler *handler.GroupHandler) {
 	// 注册群组处理器路由
 	groupHandler.RegisterRoutes(api)
 
+	// 供通知服务等内部调用的路由
+	groupHandler.RegisterInternalRoutes(router)
+
+	// 成员缓存命中统计，供内部监控抓取
+	router.HandleFunc("/internal/membership-cache/metrics", func(w http.ResponseWriter, r *http.Request) {
+		metrics := repository.MembershipCacheMetrics{}
+		if membershipCache != nil {
+			metrics = membershipCache.Metrics()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"enabled": membershipCache != nil,
+			"metrics": metrics,
+		})
+	}).Methods("GET")
+
+	// 后台任务执行统计
+	router.HandleFunc("/internal/jobs/metrics", jobRunner.MetricsHandler).Methods("GET")
+
 	// 根路径重定向到健康检查
 	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/api/v1/health", http.StatusMovedPermanently)
@@ -218,21 +441,114 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// startCleanupTasks 启动清理任务
-func startCleanupTasks(db *database.Database, logger *zap.Logger) {
-	// 每小时清理一次过期邀请
-	ticker := time.NewTicker(1 * time.Hour)
-	go func() {
-		for range ticker.C {
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			if count, err := db.CleanupExpiredInvitations(ctx); err != nil {
-				logger.Error("Failed to cleanup expired invitations", zap.Error(err))
-			} else if count > 0 {
+// initJobRunner 初始化后台任务执行器，有数据库时使用持久化队列
+func initJobRunner(db *database.Database, elector *lock.Elector, logger *zap.Logger) *jobs.Runner {
+	var queue jobs.Queue = jobs.NewMemoryQueue()
+	if db.GetDB() != nil {
+		pgQueue, err := jobs.NewPostgresQueue(db.GetDB().DB, "group-service")
+		if err != nil {
+			logger.Warn("Failed to initialize persistent job queue, using memory queue", zap.Error(err))
+		} else {
+			queue = pgQueue
+		}
+	}
+	opts := jobs.Options{}
+	if elector != nil {
+		opts.Leader = elector
+	}
+	return jobs.NewRunner(queue, opts, logger)
+}
+
+// initLeaderElector 初始化定时任务的主实例选举，未启用或Redis连接失败时返回nil，此时每个实例都触发定时任务
+func initLeaderElector(cfg *config.Config, logger *zap.Logger) *lock.Elector {
+	if !cfg.LeaderElection.Enabled {
+		return nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		logger.Warn("Failed to connect to Redis, leader election disabled", zap.Error(err))
+		client.Close()
+		return nil
+	}
+
+	logger.Info("Leader election enabled for scheduled jobs",
+		zap.String("redis_addr", cfg.Redis.Addr),
+		zap.Int("ttl_seconds", cfg.LeaderElection.TTLSeconds),
+	)
+	return lock.NewElector(lock.NewLocker(client, "group-service"), "jobs", time.Duration(cfg.LeaderElection.TTLSeconds)*time.Second, logger)
+}
+
+// registerJobs 注册定时任务：每小时清理过期邀请和保留期满的已解散群组，定期校正成员计数
+// activityTracked为false时没有成员活跃记录，不执行不活跃成员清理，避免误删活跃成员
+func registerJobs(runner *jobs.Runner, db *database.Database, repo repository.GroupRepository, groupService service.GroupService, activityTracked bool, cfg *config.Config, logger *zap.Logger) error {
+	if db.GetDB() != nil {
+		err := runner.Schedule("cleanup_expired_invitations", "@hourly", func(ctx context.Context, _ json.RawMessage) error {
+			count, err := db.CleanupExpiredInvitations(ctx)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
 				logger.Info("Cleaned up expired invitations", zap.Int("count", count))
 			}
-			cancel()
+			return nil
+		}, jobs.Timeout(30*time.Second))
+		if err != nil {
+			return err
 		}
-	}()
+	}
+
+	// 永久删除保留期满的已解散群组
+	err := runner.Schedule("purge_deleted_groups", "@hourly", func(ctx context.Context, _ json.RawMessage) error {
+		count, err := groupService.PurgeDeletedGroups(ctx)
+		if count > 0 {
+			logger.Info("Purged deleted groups", zap.Int("count", count))
+		}
+		return err
+	}, jobs.Timeout(10*time.Minute), jobs.MaxAttempts(1))
+	if err != nil {
+		return err
+	}
+
+	// 成员计数校正，修正冗余计数与成员表之间的偏差
+	if cfg.MemberCountReconcileMinutes > 0 {
+		spec := fmt.Sprintf("@every %dm", cfg.MemberCountReconcileMinutes)
+		err := runner.Schedule("reconcile_member_counts", spec, func(ctx context.Context, _ json.RawMessage) error {
+			count, err := repo.ReconcileMemberCounts(ctx)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				logger.Warn("Reconciled drifted member counts", zap.Int("count", count))
+			}
+			return nil
+		}, jobs.Timeout(5*time.Minute), jobs.MaxAttempts(1))
+		if err != nil {
+			return err
+		}
+	}
+
+	// 按群组策略清理不活跃成员
+	if cfg.PruneIntervalHours > 0 {
+		if !activityTracked {
+			logger.Warn("Event bus not configured, inactive member pruning disabled")
+			return nil
+		}
+		spec := fmt.Sprintf("@every %dh", cfg.PruneIntervalHours)
+		err := runner.Schedule("prune_inactive_members", spec, func(ctx context.Context, _ json.RawMessage) error {
+			return groupService.RunPrunePolicies(ctx)
+		}, jobs.Timeout(30*time.Minute), jobs.MaxAttempts(1))
+		if err != nil {
+			return err
+		}
+	}
 
-	logger.Info("Cleanup tasks started")
+	return nil
 }
@@ -12,6 +12,8 @@ import (
 type Config struct {
 	// 服务配置
 	HTTPPort int
+	// GRPCPort 供内部服务查询成员权限的gRPC端口，0表示不启动gRPC服务
+	GRPCPort int
 	LogLevel string
 
 	// 数据库配置
@@ -21,7 +23,51 @@ type Config struct {
 	JWT JWTConfig
 
 	// 外部服务配置
-	UserServiceURL string
+	UserServiceURL         string
+	MessageServiceURL      string
+	NotificationServiceURL string
+
+	// NATS地址，为空时不发布领域事件
+	EventBusURL string
+
+	// Webhook配置
+	Webhook WebhookConfig
+
+	// 成员计数校正间隔（分钟）
+	MemberCountReconcileMinutes int
+
+	// 不活跃成员清理检查间隔（小时），0表示不执行
+	PruneIntervalHours int
+
+	// Redis配置
+	Redis RedisConfig
+
+	// 成员缓存配置
+	MembershipCache MembershipCacheConfig
+
+	// 定时任务主实例选举配置
+	LeaderElection LeaderElectionConfig
+
+	// 联合群主与双人确认配置
+	Ownership OwnershipConfig
+
+	// 群组文件存储配额配置
+	StorageQuota StorageQuotaConfig
+
+	// 优雅关闭配置
+	Shutdown ShutdownConfig
+
+	// 错误上报配置
+	ErrorReporting ErrorReportingConfig
+
+	// Prometheus指标配置
+	Metrics MetricsConfig
+
+	// 链路追踪配置
+	Tracing TracingConfig
+
+	// 接口文档配置
+	OpenAPI OpenAPIConfig
 }
 
 // DatabaseConfig 数据库配置
@@ -40,6 +86,80 @@ type JWTConfig struct {
 	ExpirationHours int
 }
 
+// WebhookConfig 成员同步Webhook配置
+type WebhookConfig struct {
+	TimeoutSeconds int
+	MaxRetries     int
+	// AllowPrivateNetworks 允许投递到环回和私有网段地址，仅用于本地开发
+	AllowPrivateNetworks bool
+}
+
+// RedisConfig Redis配置
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// MembershipCacheConfig 成员关系缓存配置
+type MembershipCacheConfig struct {
+	Enabled         bool
+	TTLSeconds      int
+	LocalTTLSeconds int // 进程内缓存时间，0表示只使用Redis
+	LocalMaxEntries int
+}
+
+// LeaderElectionConfig 定时任务主实例选举配置，启用后多个实例中只有持有Redis锁的实例触发定时任务
+type LeaderElectionConfig struct {
+	Enabled    bool
+	TTLSeconds int // 锁的有效期，主实例失联后最长经过这段时间由其他实例接替
+}
+
+// OwnershipConfig 联合群主与双人确认配置
+type OwnershipConfig struct {
+	MaxCoOwners          int
+	ConfirmationTTLHours int // 待确认操作的有效期
+	DeleteRetentionDays  int // 解散的群组保留多少天，期内可以恢复，期满后永久删除
+}
+
+// StorageQuotaConfig 各存储档位的群组文件配额，单位MB
+type StorageQuotaConfig struct {
+	FreeMB     int
+	StandardMB int
+	PremiumMB  int
+}
+
+// ShutdownConfig 优雅关闭配置
+type ShutdownConfig struct {
+	DrainDelaySeconds int // 进入排空状态（健康检查返回503）后等待多久再停止接收连接
+	TimeoutSeconds    int // 关闭步骤的总超时时间
+}
+
+// ErrorReportingConfig 错误上报配置，SentryDSN为空时panic只记录日志
+type ErrorReportingConfig struct {
+	SentryDSN   string // Sentry项目DSN
+	Environment string // 上报事件的环境标识
+}
+
+// MetricsConfig Prometheus指标配置
+type MetricsConfig struct {
+	Enabled bool   // 是否提供/metrics端点
+	Token   string // 不为空时抓取请求需携带 Authorization: Bearer <Token>
+}
+
+// TracingConfig OpenTelemetry链路追踪配置
+type TracingConfig struct {
+	Exporter      string // none（只生成和传播traceparent）、otlp或jaeger
+	Endpoint      string // OTLP collector的gRPC地址或Jaeger collector的HTTP地址，为空时使用导出器默认值
+	Insecure      bool   // OTLP连接不使用TLS
+	SamplePercent int    // 新trace的采样百分比，带traceparent的请求沿用上游的采样决定
+}
+
+// OpenAPIConfig 接口文档配置
+type OpenAPIConfig struct {
+	Strict bool // 注册的路由与接口说明不一致时拒绝启动，默认只记录警告
+}
+
 // LoadConfig 从环境变量加载配置
 func LoadConfig() (*Config, error) {
 	// 加载.env文件
@@ -47,6 +167,7 @@ func LoadConfig() (*Config, error) {
 
 	config := &Config{
 		HTTPPort: getEnvAsInt("HTTP_PORT", 8083),
+		GRPCPort: getEnvAsInt("GRPC_PORT", 9083),
 		LogLevel: getEnv("LOG_LEVEL", "info"),
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -60,7 +181,64 @@ func LoadConfig() (*Config, error) {
 			SecretKey:       getEnv("JWT_SECRET_KEY", "your_super_secret_key_change_in_production"),
 			ExpirationHours: getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
 		},
-		UserServiceURL: getEnv("USER_SERVICE_URL", "http://localhost:8081"),
+		UserServiceURL:         getEnv("USER_SERVICE_URL", "http://localhost:8081"),
+		MessageServiceURL:      getEnv("MESSAGE_SERVICE_URL", "http://localhost:8082"),
+		NotificationServiceURL: getEnv("NOTIFICATION_SERVICE_URL", "http://localhost:8085"),
+		EventBusURL:            getEnv("EVENT_BUS_URL", ""),
+		Webhook: WebhookConfig{
+			TimeoutSeconds: getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10),
+			MaxRetries:     getEnvAsInt("WEBHOOK_MAX_RETRIES", 3),
+
+			AllowPrivateNetworks: getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		},
+		MemberCountReconcileMinutes: getEnvAsInt("MEMBER_COUNT_RECONCILE_MINUTES", 60),
+		PruneIntervalHours:          getEnvAsInt("PRUNE_INTERVAL_HOURS", 24),
+		Redis: RedisConfig{
+			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
+			Password: getEnv("REDIS_PASSWORD", ""),
+			DB:       getEnvAsInt("REDIS_DB", 0),
+		},
+		LeaderElection: LeaderElectionConfig{
+			Enabled:    getEnv("LEADER_ELECTION_ENABLED", "false") == "true",
+			TTLSeconds: getEnvAsInt("LEADER_ELECTION_TTL_SECONDS", 15),
+		},
+		MembershipCache: MembershipCacheConfig{
+			Enabled:         getEnv("MEMBERSHIP_CACHE_ENABLED", "true") == "true",
+			TTLSeconds:      getEnvAsInt("MEMBERSHIP_CACHE_TTL_SECONDS", 300),
+			LocalTTLSeconds: getEnvAsInt("MEMBERSHIP_CACHE_LOCAL_TTL_SECONDS", 5),
+			LocalMaxEntries: getEnvAsInt("MEMBERSHIP_CACHE_LOCAL_MAX_ENTRIES", 10000),
+		},
+		Ownership: OwnershipConfig{
+			MaxCoOwners:          getEnvAsInt("MAX_CO_OWNERS", 3),
+			ConfirmationTTLHours: getEnvAsInt("OWNER_CONFIRMATION_TTL_HOURS", 24),
+			DeleteRetentionDays:  getEnvAsInt("SOFT_DELETE_RETENTION_DAYS", 30),
+		},
+		StorageQuota: StorageQuotaConfig{
+			FreeMB:     getEnvAsInt("GROUP_STORAGE_QUOTA_FREE_MB", 1024),
+			StandardMB: getEnvAsInt("GROUP_STORAGE_QUOTA_STANDARD_MB", 10240),
+			PremiumMB:  getEnvAsInt("GROUP_STORAGE_QUOTA_PREMIUM_MB", 102400),
+		},
+		Shutdown: ShutdownConfig{
+			DrainDelaySeconds: getEnvAsInt("SHUTDOWN_DRAIN_DELAY_SECONDS", 5),
+			TimeoutSeconds:    getEnvAsInt("SHUTDOWN_TIMEOUT_SECONDS", 30),
+		},
+		ErrorReporting: ErrorReportingConfig{
+			SentryDSN:   getEnv("SENTRY_DSN", ""),
+			Environment: getEnv("SENTRY_ENVIRONMENT", "production"),
+		},
+		Metrics: MetricsConfig{
+			Enabled: getEnv("METRICS_ENABLED", "true") == "true",
+			Token:   getEnv("METRICS_TOKEN", ""),
+		},
+		Tracing: TracingConfig{
+			Exporter:      getEnv("TRACING_EXPORTER", "none"),
+			Endpoint:      getEnv("TRACING_ENDPOINT", ""),
+			Insecure:      getEnv("TRACING_INSECURE", "true") == "true",
+			SamplePercent: getEnvAsInt("TRACING_SAMPLE_PERCENT", 100),
+		},
+		OpenAPI: OpenAPIConfig{
+			Strict: getEnv("OPENAPI_STRICT", "false") == "true",
+		},
 	}
 
 	return config, nil
@@ -95,4 +273,14 @@ func getEnvAsInt(key string, defaultValue int) int {
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// getEnvAsBool 获取环境变量并转换为布尔值
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/neohope/chatapp/group-service/pkg/tracing"
+)
+
+// MessageClient 消息服务客户端
+type MessageClient interface {
+	// CreateConversation 在消息服务中创建群聊会话，返回会话ID
+	CreateConversation(ctx context.Context, creatorID uuid.UUID, participants []uuid.UUID) (string, error)
+	// SendSystemMessage 以指定用户的身份在会话中发送系统消息
+	SendSystemMessage(ctx context.Context, senderID uuid.UUID, conversationID, content string, metadata map[string]interface{}) error
+	// AddParticipants 成员加入频道时添加为会话参与者
+	AddParticipants(ctx context.Context, conversationID string, userIDs []uuid.UUID) error
+	// RemoveParticipant 成员离开频道时移出会话，不再收到频道消息
+	RemoveParticipant(ctx context.Context, conversationID string, userID uuid.UUID) error
+}
+
+// httpMessageClient 基于HTTP的消息服务客户端
+type httpMessageClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewMessageClient 创建消息服务客户端
+func NewMessageClient(baseURL string) MessageClient {
+	return &httpMessageClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: tracing.Transport(nil),
+		},
+	}
+}
+
+// CreateConversation 在消息服务中创建群聊会话
+func (c *httpMessageClient) CreateConversation(ctx context.Context, creatorID uuid.UUID, participants []uuid.UUID) (string, error) {
+	ids := make([]string, 0, len(participants))
+	for _, id := range participants {
+		ids = append(ids, id.String())
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"type":         "group",
+		"participants": ids,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/conversations", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// 消息服务信任内部调用传递的用户ID
+	req.Header.Set("X-User-ID", creatorID.String())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call message service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("message service returned status %d", resp.StatusCode)
+	}
+
+	var conversation struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&conversation); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if conversation.ID == "" {
+		return "", fmt.Errorf("message service returned empty conversation id")
+	}
+
+	return conversation.ID, nil
+}
+
+// SendSystemMessage 以指定用户的身份在会话中发送系统消息
+func (c *httpMessageClient) SendSystemMessage(ctx context.Context, senderID uuid.UUID, conversationID, content string, metadata map[string]interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"conversation_id": conversationID,
+		"type":            "system",
+		"content":         content,
+		"metadata":        metadata,
+		"is_group_chat":   true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// 消息服务信任内部调用传递的用户ID
+	req.Header.Set("X-User-ID", senderID.String())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call message service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("message service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// AddParticipants 通过内部接口把用户添加为会话参与者，不经过会话管理员校验
+func (c *httpMessageClient) AddParticipants(ctx context.Context, conversationID string, userIDs []uuid.UUID) error {
+	ids := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		ids = append(ids, id.String())
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"user_ids": ids,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := c.baseURL + "/internal/conversations/" + url.PathEscape(conversationID) + "/participants"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.doInternal(req)
+}
+
+// RemoveParticipant 通过内部接口把用户移出会话，用户不在会话中时同样成功
+func (c *httpMessageClient) RemoveParticipant(ctx context.Context, conversationID string, userID uuid.UUID) error {
+	endpoint := c.baseURL + "/internal/conversations/" + url.PathEscape(conversationID) + "/participants/" + url.PathEscape(userID.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	return c.doInternal(req)
+}
+
+// doInternal 调用消息服务的内部接口，只检查状态码
+func (c *httpMessageClient) doInternal(req *http.Request) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call message service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("message service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
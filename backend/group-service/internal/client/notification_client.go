@@ -0,0 +1,66 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/neohope/chatapp/group-service/pkg/tracing"
+)
+
+// NotificationClient 通知服务客户端
+type NotificationClient interface {
+	// SendGroupNotification 向用户发送群组相关通知（入群申请、审核结果等）
+	SendGroupNotification(ctx context.Context, userID uuid.UUID, title, body string, data map[string]interface{}) error
+}
+
+// httpNotificationClient 基于HTTP的通知服务客户端
+type httpNotificationClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewNotificationClient 创建通知服务客户端
+func NewNotificationClient(baseURL string) NotificationClient {
+	return &httpNotificationClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)},
+	}
+}
+
+// SendGroupNotification 调用通知服务创建群组通知
+func (c *httpNotificationClient) SendGroupNotification(ctx context.Context, userID uuid.UUID, title, body string, data map[string]interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"user_id": userID.String(),
+		"type":    "group_invite",
+		"title":   title,
+		"body":    body,
+		"data":    data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/notifications", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call notification service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
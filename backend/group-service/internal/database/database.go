@@ -8,8 +8,8 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
 	"github.com/neohope/chatapp/group-service/config"
+	"github.com/neohope/chatapp/group-service/pkg/tracing"
 	"go.uber.org/zap"
 )
 
@@ -21,10 +21,12 @@ type Database struct {
 
 // NewDatabase 创建数据库管理器
 func NewDatabase(cfg *config.Config, logger *zap.Logger) (*Database, error) {
-	db, err := sqlx.Connect("postgres", cfg.GetPostgresConnString())
+	// 请求中的查询和写入记录为请求span的子span
+	sqlDB, err := tracing.OpenPostgres(cfg.GetPostgresConnString())
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	db := sqlx.NewDb(sqlDB, "postgres")
 
 	// 配置连接池
 	db.SetMaxOpenConns(25)
@@ -187,7 +189,7 @@ func (d *Database) GetGroupStats(ctx context.Context, groupID string) (map[strin
 
 // ValidateSchema 验证数据库模式
 func (d *Database) ValidateSchema(ctx context.Context) error {
-	requiredTables := []string{"groups", "group_members", "group_invitations"}
+	requiredTables := []string{"groups", "group_members", "group_invitations", "group_channels", "group_channel_members", "group_webhooks", "group_resources", "group_announcements", "group_pending_actions", "group_join_questions", "group_join_requests", "group_member_activity", "group_prune_policies", "group_prune_proposals", "group_mutes", "group_storage_tiers", "group_media_files", "group_onboarding", "schema_backfills"}
 
 	for _, table := range requiredTables {
 		var exists bool
@@ -206,6 +208,27 @@ func (d *Database) ValidateSchema(ctx context.Context) error {
 		}
 	}
 
+	// 检查后续版本新增的列
+	requiredColumns := [][2]string{{"groups", "member_count"}, {"groups", "description_format"}, {"groups", "require_owner_confirmation"}, {"groups", "deleted_at"}}
+	for _, required := range requiredColumns {
+		table, column := required[0], required[1]
+		var exists bool
+		query := `
+			SELECT EXISTS (
+				SELECT FROM information_schema.columns
+				WHERE table_schema = 'public'
+				AND table_name = $1
+				AND column_name = $2
+			)
+		`
+		if err := d.db.GetContext(ctx, &exists, query, table, column); err != nil {
+			return fmt.Errorf("failed to check column %s.%s: %w", table, column, err)
+		}
+		if !exists {
+			return fmt.Errorf("required column %s.%s does not exist", table, column)
+		}
+	}
+
 	d.logger.Info("Database schema validation passed")
 	return nil
 }
@@ -13,18 +13,35 @@ CREATE TABLE IF NOT EXISTS groups (
     updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 );
 
+-- 群组活跃成员计数（冗余字段，随成员变更事务更新，定期校正）
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS member_count INTEGER NOT NULL DEFAULT 0;
+
+-- 群组简介格式（plain 或 markdown）
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS description_format VARCHAR(20) NOT NULL DEFAULT 'plain';
+
+-- 解散群组、转让群主是否需要另一位群主确认
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS require_owner_confirmation BOOLEAN NOT NULL DEFAULT false;
+
+-- 软删除：解散的群组保留一段时间，期间群主可以恢复，期满后由定时任务永久删除
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS deleted_by UUID;
+
 -- 创建群组成员表
 CREATE TABLE IF NOT EXISTS group_members (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
     user_id UUID NOT NULL,
-    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'co_owner', 'admin', 'member')),
     status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'muted', 'banned', 'pending')),
     joined_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
     nickname VARCHAR(30),
     UNIQUE(group_id, user_id)
 );
 
+-- 已有的成员表补充联合群主角色
+ALTER TABLE group_members DROP CONSTRAINT IF EXISTS group_members_role_check;
+ALTER TABLE group_members ADD CONSTRAINT group_members_role_check CHECK (role IN ('owner', 'co_owner', 'admin', 'member'));
+
 -- 创建群组邀请表
 CREATE TABLE IF NOT EXISTS group_invitations (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -38,6 +55,174 @@ CREATE TABLE IF NOT EXISTS group_invitations (
     UNIQUE(group_id, invitee_id, status) -- 防止重复邀请同一用户到同一群组
 );
 
+-- 创建群组频道表
+CREATE TABLE IF NOT EXISTS group_channels (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    topic TEXT,
+    conversation_id VARCHAR(64) NOT NULL DEFAULT '',
+    permission VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (permission IN ('open', 'read_only', 'admin_only')),
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(group_id, name)
+);
+
+-- 创建频道成员表
+CREATE TABLE IF NOT EXISTS group_channel_members (
+    channel_id UUID NOT NULL REFERENCES group_channels(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    joined_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (channel_id, user_id)
+);
+
+-- 创建群组Webhook表（成员变更同步到外部系统）
+CREATE TABLE IF NOT EXISTS group_webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    url VARCHAR(500) NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    events TEXT[] NOT NULL DEFAULT '{}',
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 创建群组置顶资源表（链接、文档等）
+CREATE TABLE IF NOT EXISTS group_resources (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    title VARCHAR(100) NOT NULL,
+    url VARCHAR(500) NOT NULL,
+    description VARCHAR(200) NOT NULL DEFAULT '',
+    position INTEGER NOT NULL DEFAULT 0,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 创建群公告表
+CREATE TABLE IF NOT EXISTS group_announcements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    title VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    pinned_by UUID,
+    pinned_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 创建待确认的群主操作表（解散群组、转让群主等需要第二位群主确认）
+CREATE TABLE IF NOT EXISTS group_pending_actions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    action VARCHAR(30) NOT NULL CHECK (action IN ('delete_group', 'transfer_ownership', 'disable_confirmation')),
+    target_user_id UUID,
+    requested_by UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'confirmed', 'cancelled')),
+    resolved_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+-- 创建入群问题表，每个群组最多3个问题
+CREATE TABLE IF NOT EXISTS group_join_questions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    question VARCHAR(200) NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 创建入群申请表，answers保存回答及提交时的问题文本
+CREATE TABLE IF NOT EXISTS group_join_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    answers JSONB NOT NULL DEFAULT '[]',
+    reviewed_by UUID,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 创建成员活跃记录表，由message-service的message.created事件更新
+CREATE TABLE IF NOT EXISTS group_member_activity (
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    last_active_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (group_id, user_id)
+);
+
+-- 创建不活跃成员清理策略表，需要管理员主动开启
+CREATE TABLE IF NOT EXISTS group_prune_policies (
+    group_id UUID PRIMARY KEY REFERENCES groups(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    inactive_days INTEGER NOT NULL DEFAULT 30 CHECK (inactive_days BETWEEN 7 AND 365),
+    action VARCHAR(20) NOT NULL DEFAULT 'flag' CHECK (action IN ('flag', 'remove')),
+    enabled_at TIMESTAMP WITH TIME ZONE,
+    updated_by UUID NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 创建清理提议表，名单在生成时固定，管理员确认后执行
+CREATE TABLE IF NOT EXISTS group_prune_proposals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    inactive_days INTEGER NOT NULL,
+    candidates JSONB NOT NULL DEFAULT '[]',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    removed_count INTEGER NOT NULL DEFAULT 0,
+    resolved_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+-- 创建成员免打扰设置表，muted_until为NULL表示永久免打扰
+CREATE TABLE IF NOT EXISTS group_mutes (
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    muted_until TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (group_id, user_id)
+);
+
+-- 创建群组存储档位表，没有记录的群组为free档位
+CREATE TABLE IF NOT EXISTS group_storage_tiers (
+    group_id UUID PRIMARY KEY REFERENCES groups(id) ON DELETE CASCADE,
+    tier VARCHAR(20) NOT NULL CHECK (tier IN ('free', 'standard', 'premium')),
+    updated_by UUID NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 创建群组入群设置表，没有记录的群组不发送欢迎消息，新成员为member角色
+CREATE TABLE IF NOT EXISTS group_onboarding (
+    group_id UUID PRIMARY KEY REFERENCES groups(id) ON DELETE CASCADE,
+    welcome_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    welcome_message TEXT NOT NULL DEFAULT '',
+    welcome_channel_id UUID REFERENCES group_channels(id) ON DELETE SET NULL,
+    default_role VARCHAR(20) NOT NULL DEFAULT 'member' CHECK (default_role IN ('admin', 'member')),
+    updated_by UUID,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+
+-- 创建群组文件用量表，记录附加到群聊会话的文件，同一文件只计一次
+CREATE TABLE IF NOT EXISTS group_media_files (
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    file_id VARCHAR(64) NOT NULL,
+    owner_id UUID NOT NULL,
+    size BIGINT NOT NULL CHECK (size >= 0),
+    attached_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (group_id, file_id)
+);
+
 -- 创建索引以提高查询性能
 
 -- 群组表索引
@@ -45,6 +230,7 @@ CREATE INDEX IF NOT EXISTS idx_groups_owner_id ON groups(owner_id);
 CREATE INDEX IF NOT EXISTS idx_groups_name ON groups(name);
 CREATE INDEX IF NOT EXISTS idx_groups_is_private ON groups(is_private);
 CREATE INDEX IF NOT EXISTS idx_groups_created_at ON groups(created_at);
+CREATE INDEX IF NOT EXISTS idx_groups_deleted_at ON groups(deleted_at) WHERE deleted_at IS NOT NULL;
 
 -- 群组成员表索引
 CREATE INDEX IF NOT EXISTS idx_group_members_group_id ON group_members(group_id);
@@ -60,6 +246,33 @@ CREATE INDEX IF NOT EXISTS idx_group_invitations_invitee_id ON group_invitations
 CREATE INDEX IF NOT EXISTS idx_group_invitations_status ON group_invitations(status);
 CREATE INDEX IF NOT EXISTS idx_group_invitations_expires_at ON group_invitations(expires_at);
 
+-- 群组频道表索引
+CREATE INDEX IF NOT EXISTS idx_group_channels_group_id ON group_channels(group_id);
+CREATE INDEX IF NOT EXISTS idx_group_channels_conversation_id ON group_channels(conversation_id);
+CREATE INDEX IF NOT EXISTS idx_group_channel_members_user_id ON group_channel_members(user_id);
+
+-- 群组Webhook表索引
+CREATE INDEX IF NOT EXISTS idx_group_webhooks_group_id ON group_webhooks(group_id);
+
+-- 群组置顶资源表索引
+CREATE INDEX IF NOT EXISTS idx_group_resources_group_id ON group_resources(group_id, position);
+
+-- 群公告表索引
+CREATE INDEX IF NOT EXISTS idx_group_announcements_group_id ON group_announcements(group_id, pinned, created_at DESC);
+
+-- 待确认操作表索引
+CREATE INDEX IF NOT EXISTS idx_group_pending_actions_group_id ON group_pending_actions(group_id, status);
+
+-- 入群问题和入群申请表索引，每个用户在同一群组最多有一条待审核申请
+CREATE INDEX IF NOT EXISTS idx_group_join_questions_group_id ON group_join_questions(group_id, position);
+CREATE INDEX IF NOT EXISTS idx_group_join_requests_group_id ON group_join_requests(group_id, status, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_group_join_requests_pending ON group_join_requests(group_id, user_id) WHERE status = 'pending';
+
+-- 清理策略和清理提议表索引，每个群组最多有一条待确认提议
+CREATE INDEX IF NOT EXISTS idx_group_prune_policies_enabled ON group_prune_policies(enabled) WHERE enabled;
+CREATE INDEX IF NOT EXISTS idx_group_prune_proposals_group_id ON group_prune_proposals(group_id, status, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_group_prune_proposals_pending ON group_prune_proposals(group_id) WHERE status = 'pending';
+
 -- 创建触发器以自动更新 updated_at 字段
 CREATE OR REPLACE FUNCTION update_updated_at_column()
 RETURNS TRIGGER AS $$
@@ -76,6 +289,43 @@ CREATE TRIGGER update_groups_updated_at
     FOR EACH ROW
     EXECUTE FUNCTION update_updated_at_column();
 
+-- 为置顶资源表创建更新时间触发器
+DROP TRIGGER IF EXISTS update_group_resources_updated_at ON group_resources;
+CREATE TRIGGER update_group_resources_updated_at
+    BEFORE UPDATE ON group_resources
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- 为群公告表创建更新时间触发器
+DROP TRIGGER IF EXISTS update_group_announcements_updated_at ON group_announcements;
+CREATE TRIGGER update_group_announcements_updated_at
+    BEFORE UPDATE ON group_announcements
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- 为频道表创建更新时间触发器
+DROP TRIGGER IF EXISTS update_group_channels_updated_at ON group_channels;
+CREATE TRIGGER update_group_channels_updated_at
+    BEFORE UPDATE ON group_channels
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- 一次性数据回填记录，每项回填写入标记行后不再重复执行
+CREATE TABLE IF NOT EXISTS schema_backfills (
+    name VARCHAR(100) PRIMARY KEY,
+    applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 根据现有成员数据回填成员计数（仅首次迁移执行，之后的偏差由定期校正任务修正）
+WITH marker AS (
+    INSERT INTO schema_backfills (name) VALUES ('groups_member_count')
+    ON CONFLICT (name) DO NOTHING
+    RETURNING name
+)
+UPDATE groups g
+SET member_count = (SELECT COUNT(*) FROM group_members gm WHERE gm.group_id = g.id AND gm.status = 'active')
+WHERE EXISTS (SELECT 1 FROM marker);
+
 -- 创建视图以简化常用查询
 
 -- 群组成员统计视图
@@ -104,10 +354,10 @@ SELECT
     gm.status,
     gm.joined_at,
     gm.nickname,
-    (SELECT COUNT(*) FROM group_members WHERE group_id = g.id AND status = 'active') as member_count
+    g.member_count::BIGINT as member_count
 FROM group_members gm
 JOIN groups g ON gm.group_id = g.id
-WHERE gm.status = 'active';
+WHERE gm.status = 'active' AND g.deleted_at IS NULL;
 
 -- 待处理邀请视图
 CREATE OR REPLACE VIEW pending_invitations_view AS
@@ -124,7 +374,7 @@ SELECT
     gi.expires_at
 FROM group_invitations gi
 JOIN groups g ON gi.group_id = g.id
-WHERE gi.status = 'pending' AND gi.expires_at > NOW();
+WHERE gi.status = 'pending' AND gi.expires_at > NOW() AND g.deleted_at IS NULL;
 
 -- 插入一些示例数据（可选，用于测试）
 /*
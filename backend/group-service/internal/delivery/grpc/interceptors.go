@@ -0,0 +1,75 @@
+package grpcdelivery
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/neohope/chatapp/group-service/api/proto/grouppb"
+	"github.com/neohope/chatapp/group-service/pkg/recovery"
+)
+
+// LoggingInterceptor 记录每次调用的方法、状态码和耗时
+func LoggingInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		fields := []zap.Field{
+			zap.String("method", info.FullMethod),
+			zap.String("code", status.Code(err).String()),
+			zap.Duration("duration", time.Since(start)),
+		}
+		if err != nil {
+			logger.Info("gRPC request failed", append(fields, zap.Error(err))...)
+		} else {
+			logger.Debug("gRPC request", fields...)
+		}
+		return resp, err
+	}
+}
+
+// RecoveryInterceptor 将处理函数的panic转换为Internal错误，与HTTP接口一样记录堆栈并上报
+// 请求ID取自x-request-id元数据，没有时生成新的
+func RecoveryInterceptor(recoverer *recovery.Recoverer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		requestID := recovery.NewRequestID()
+		if values := md.Get(strings.ToLower(recovery.RequestIDHeader)); len(values) > 0 && values[0] != "" {
+			requestID = values[0]
+		}
+		ctx = recovery.WithRequestID(ctx, requestID)
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				recoverer.HandlePanic(ctx, &recovery.Event{
+					RequestID:   requestID,
+					Transaction: info.FullMethod,
+					Panic:       recovered,
+				})
+				err = status.Errorf(codes.Internal, "internal server error (request_id: %s)", requestID)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// NewServer 创建gRPC服务器并注册群成员权限服务，拦截器按链路追踪、恢复、日志的顺序执行
+// 与/internal下的HTTP接口一样只在内部网络开放，不做用户认证
+func NewServer(membershipServer *MembershipServer, recoverer *recovery.Recoverer, logger *zap.Logger) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		otelgrpc.UnaryServerInterceptor(),
+		RecoveryInterceptor(recoverer),
+		LoggingInterceptor(logger),
+	))
+	grouppb.RegisterGroupMembershipServiceServer(server, membershipServer)
+	return server
+}
@@ -0,0 +1,63 @@
+package grpcdelivery
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/neohope/chatapp/group-service/api/proto/grouppb"
+	"github.com/neohope/chatapp/group-service/internal/service"
+)
+
+// MembershipServer 群成员权限gRPC接口实现，与HTTP接口共用服务层
+type MembershipServer struct {
+	grouppb.UnimplementedGroupMembershipServiceServer
+	groupService service.GroupService
+	logger       *zap.Logger
+}
+
+// NewMembershipServer 创建群成员权限gRPC接口
+func NewMembershipServer(groupService service.GroupService, logger *zap.Logger) *MembershipServer {
+	return &MembershipServer{
+		groupService: groupService,
+		logger:       logger,
+	}
+}
+
+// GetMembership 获取用户在群组中的角色和权限，group_id也可以是群组频道的会话ID
+func (s *MembershipServer) GetMembership(ctx context.Context, req *grouppb.GetMembershipRequest) (*grouppb.GetMembershipResponse, error) {
+	groupID, err := uuid.Parse(req.GetGroupId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid group_id")
+	}
+	userID, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+	}
+
+	result, err := s.groupService.GetConversationPermissions(ctx, groupID, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "group not found") {
+			return nil, status.Error(codes.NotFound, "group not found")
+		}
+		s.logger.Error("Failed to get member permissions", zap.Error(err), zap.String("group_id", groupID.String()))
+		return nil, status.Error(codes.Internal, "failed to get membership")
+	}
+
+	membership := &grouppb.Membership{
+		GroupId:     result.GroupID.String(),
+		UserId:      result.UserID.String(),
+		IsMember:    result.IsMember,
+		Role:        string(result.Role),
+		Status:      string(result.Status),
+		Permissions: make([]string, 0, len(result.Permissions)),
+	}
+	for _, permission := range result.Permissions {
+		membership.Permissions = append(membership.Permissions, string(permission))
+	}
+	return &grouppb.GetMembershipResponse{Membership: membership}, nil
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/pkg/pagination"
+	"github.com/neohope/chatapp/group-service/pkg/validation"
+	"go.uber.org/zap"
+)
+
+// registerAnnouncementRoutes 注册群公告路由
+func (h *GroupHandler) registerAnnouncementRoutes(router *mux.Router) {
+	router.HandleFunc("/groups/{groupId}/announcements", h.authMiddleware(h.CreateAnnouncement)).Methods("POST")
+	router.HandleFunc("/groups/{groupId}/announcements", h.authMiddleware(h.ListAnnouncements)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/announcements/{announcementId}/pin", h.authMiddleware(h.PinAnnouncement)).Methods("PUT")
+	router.HandleFunc("/groups/{groupId}/announcements/{announcementId}", h.authMiddleware(h.DeleteAnnouncement)).Methods("DELETE")
+}
+
+// CreateAnnouncement 发布群公告
+func (h *GroupHandler) CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.CreateAnnouncementRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	announcement, err := h.groupService.CreateAnnouncement(r.Context(), userID, groupID, &req)
+	if err != nil {
+		h.logger.Error("Failed to create announcement", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeAnnouncementError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusCreated, announcement)
+}
+
+// ListAnnouncements 分页获取群公告列表
+func (h *GroupHandler) ListAnnouncements(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	page, err := pagination.Parse(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	announcements, err := h.groupService.ListAnnouncements(r.Context(), userID, groupID, page.Limit, page.Offset)
+	if err != nil {
+		h.logger.Error("Failed to list announcements", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeAnnouncementError(w, err)
+		return
+	}
+
+	pagination.SetLinkHeader(w, r, page, page.HasMore(len(announcements)))
+	h.writeJSONResponse(w, http.StatusOK, announcements)
+}
+
+// PinAnnouncement 置顶或取消置顶群公告
+func (h *GroupHandler) PinAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	announcementID, err := h.getAnnouncementIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid announcement ID")
+		return
+	}
+
+	var req models.PinAnnouncementRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	announcement, err := h.groupService.PinAnnouncement(r.Context(), userID, groupID, announcementID, req.Pinned)
+	if err != nil {
+		h.logger.Error("Failed to pin announcement", zap.Error(err), zap.String("announcement_id", announcementID.String()))
+		h.writeAnnouncementError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, announcement)
+}
+
+// DeleteAnnouncement 删除群公告
+func (h *GroupHandler) DeleteAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	announcementID, err := h.getAnnouncementIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid announcement ID")
+		return
+	}
+
+	if err := h.groupService.DeleteAnnouncement(r.Context(), userID, groupID, announcementID); err != nil {
+		h.logger.Error("Failed to delete announcement", zap.Error(err), zap.String("announcement_id", announcementID.String()))
+		h.writeAnnouncementError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Announcement deleted successfully"})
+}
+
+// getAnnouncementIDFromPath 从路径中获取群公告ID
+func (h *GroupHandler) getAnnouncementIDFromPath(r *http.Request) (uuid.UUID, error) {
+	vars := mux.Vars(r)
+	return uuid.Parse(vars["announcementId"])
+}
+
+// writeAnnouncementError 根据群公告错误写入对应状态码
+func (h *GroupHandler) writeAnnouncementError(w http.ResponseWriter, err error) {
+	if errs, ok := validation.AsErrors(err); ok {
+		validation.WriteError(w, errs)
+		return
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "access denied") || strings.Contains(msg, "not a member of this group"):
+		h.writeErrorResponse(w, http.StatusForbidden, msg)
+	case strings.Contains(msg, "not found"):
+		h.writeErrorResponse(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "too many pinned announcements"):
+		h.writeErrorResponse(w, http.StatusConflict, msg)
+	default:
+		h.writeErrorResponse(w, http.StatusInternalServerError, msg)
+	}
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// registerChannelRoutes 注册频道路由
+func (h *GroupHandler) registerChannelRoutes(router *mux.Router) {
+	router.HandleFunc("/groups/{groupId}/channels", h.authMiddleware(h.CreateChannel)).Methods("POST")
+	router.HandleFunc("/groups/{groupId}/channels", h.authMiddleware(h.GetChannels)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/channels/mine", h.authMiddleware(h.GetMyChannels)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/channels/{channelId}", h.authMiddleware(h.UpdateChannel)).Methods("PUT")
+	router.HandleFunc("/groups/{groupId}/channels/{channelId}", h.authMiddleware(h.DeleteChannel)).Methods("DELETE")
+	router.HandleFunc("/groups/{groupId}/channels/{channelId}/join", h.authMiddleware(h.JoinChannel)).Methods("POST")
+	router.HandleFunc("/groups/{groupId}/channels/{channelId}/leave", h.authMiddleware(h.LeaveChannel)).Methods("POST")
+}
+
+// CreateChannel 创建频道
+func (h *GroupHandler) CreateChannel(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.CreateChannelRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	channel, err := h.groupService.CreateChannel(r.Context(), userID, groupID, &req)
+	if err != nil {
+		h.logger.Error("Failed to create channel", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeChannelError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusCreated, channel)
+}
+
+// GetChannels 获取群组频道列表
+func (h *GroupHandler) GetChannels(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	channels, err := h.groupService.GetChannels(r.Context(), userID, groupID)
+	if err != nil {
+		h.logger.Error("Failed to get channels", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeChannelError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, channels)
+}
+
+// GetMyChannels 获取当前用户已加入的频道
+func (h *GroupHandler) GetMyChannels(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	channels, err := h.groupService.GetMyChannels(r.Context(), userID, groupID)
+	if err != nil {
+		h.logger.Error("Failed to get my channels", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeChannelError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, channels)
+}
+
+// UpdateChannel 更新频道
+func (h *GroupHandler) UpdateChannel(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	channelID, err := h.getChannelIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid channel ID")
+		return
+	}
+
+	var req models.UpdateChannelRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	channel, err := h.groupService.UpdateChannel(r.Context(), userID, groupID, channelID, &req)
+	if err != nil {
+		h.logger.Error("Failed to update channel", zap.Error(err), zap.String("channel_id", channelID.String()))
+		h.writeChannelError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, channel)
+}
+
+// DeleteChannel 删除频道
+func (h *GroupHandler) DeleteChannel(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	channelID, err := h.getChannelIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid channel ID")
+		return
+	}
+
+	if err := h.groupService.DeleteChannel(r.Context(), userID, groupID, channelID); err != nil {
+		h.logger.Error("Failed to delete channel", zap.Error(err), zap.String("channel_id", channelID.String()))
+		h.writeChannelError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Channel deleted successfully"})
+}
+
+// JoinChannel 加入频道
+func (h *GroupHandler) JoinChannel(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	channelID, err := h.getChannelIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid channel ID")
+		return
+	}
+
+	if err := h.groupService.JoinChannel(r.Context(), userID, groupID, channelID); err != nil {
+		h.logger.Error("Failed to join channel", zap.Error(err), zap.String("channel_id", channelID.String()))
+		h.writeChannelError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Joined channel successfully"})
+}
+
+// LeaveChannel 离开频道
+func (h *GroupHandler) LeaveChannel(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	channelID, err := h.getChannelIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid channel ID")
+		return
+	}
+
+	if err := h.groupService.LeaveChannel(r.Context(), userID, groupID, channelID); err != nil {
+		h.logger.Error("Failed to leave channel", zap.Error(err), zap.String("channel_id", channelID.String()))
+		h.writeChannelError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Left channel successfully"})
+}
+
+// getChannelIDFromPath 从路径中获取频道ID
+func (h *GroupHandler) getChannelIDFromPath(r *http.Request) (uuid.UUID, error) {
+	vars := mux.Vars(r)
+	return uuid.Parse(vars["channelId"])
+}
+
+// writeChannelError 根据频道错误写入对应状态码
+func (h *GroupHandler) writeChannelError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "access denied") || strings.Contains(msg, "not a member of this group"):
+		h.writeErrorResponse(w, http.StatusForbidden, msg)
+	case strings.Contains(msg, "not found"):
+		h.writeErrorResponse(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "cannot delete default channel") || strings.Contains(msg, "not a member of this channel"):
+		h.writeErrorResponse(w, http.StatusConflict, msg)
+	case strings.Contains(msg, "required") || strings.Contains(msg, "too long") ||
+		strings.Contains(msg, "invalid") || strings.Contains(msg, "cannot be empty") || strings.Contains(msg, "no fields"):
+		h.writeErrorResponse(w, http.StatusBadRequest, msg)
+	default:
+		h.writeErrorResponse(w, http.StatusInternalServerError, msg)
+	}
+}
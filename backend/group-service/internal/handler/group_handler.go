@@ -1,9 +1,9 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
@@ -11,9 +11,17 @@ import (
 	"github.com/neohope/chatapp/group-service/internal/models"
 	"github.com/neohope/chatapp/group-service/internal/service"
 	"github.com/neohope/chatapp/group-service/pkg/jwt"
+	"github.com/neohope/chatapp/group-service/pkg/pagination"
+	"github.com/neohope/chatapp/group-service/pkg/validation"
 	"go.uber.org/zap"
 )
 
+// contextKey 请求上下文中保存认证信息的键
+type contextKey string
+
+// roleContextKey 令牌中的用户角色
+const roleContextKey contextKey = "role"
+
 // GroupHandler 群组处理器
 type GroupHandler struct {
 	groupService service.GroupService
@@ -37,6 +45,7 @@ func (h *GroupHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/groups/{groupId}", h.authMiddleware(h.GetGroup)).Methods("GET")
 	router.HandleFunc("/groups/{groupId}", h.authMiddleware(h.UpdateGroup)).Methods("PUT")
 	router.HandleFunc("/groups/{groupId}", h.authMiddleware(h.DeleteGroup)).Methods("DELETE")
+	router.HandleFunc("/groups/{groupId}/restore", h.authMiddleware(h.RestoreGroup)).Methods("POST")
 	router.HandleFunc("/groups/search", h.SearchGroups).Methods("GET")
 	router.HandleFunc("/users/{userId}/groups", h.authMiddleware(h.GetUserGroups)).Methods("GET")
 
@@ -58,6 +67,36 @@ func (h *GroupHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/my-group-invitations", h.authMiddleware(h.GetMyInvitations)).Methods("GET")
 	router.HandleFunc("/group-invitations/received", h.authMiddleware(h.GetReceivedInvitations)).Methods("GET")
 
+	// 频道管理
+	h.registerChannelRoutes(router)
+
+	// 成员同步Webhook
+	h.registerWebhookRoutes(router)
+
+	// 置顶资源
+	h.registerResourceRoutes(router)
+
+	// 群公告
+	h.registerAnnouncementRoutes(router)
+
+	// 群主转让与双人确认
+	h.registerOwnershipRoutes(router)
+
+	// 入群问题与入群申请
+	h.registerJoinRequestRoutes(router)
+
+	// 不活跃成员清理
+	h.registerPruneRoutes(router)
+
+	// 成员免打扰
+	h.registerMuteRoutes(router)
+
+	// 群组统计与文件存储配额
+	h.registerStorageRoutes(router)
+
+	// 新成员入群设置：欢迎消息和默认角色
+	h.registerOnboardingRoutes(router)
+
 	// 健康检查
 	router.HandleFunc("/health", h.HealthCheck).Methods("GET")
 }
@@ -71,6 +110,9 @@ func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	if !validation.Request(w, &req) {
+		return
+	}
 
 	group, err := h.groupService.CreateGroup(r.Context(), userID, &req)
 	if err != nil {
@@ -136,7 +178,7 @@ func (h *GroupHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 	h.writeJSONResponse(w, http.StatusOK, group)
 }
 
-// DeleteGroup 删除群组
+// DeleteGroup 解散群组
 func (h *GroupHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
 	userID := h.getUserIDFromContext(r)
 	groupID, err := h.getGroupIDFromPath(r)
@@ -145,39 +187,60 @@ func (h *GroupHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.groupService.DeleteGroup(r.Context(), userID, groupID); err != nil {
+	action, err := h.groupService.DeleteGroup(r.Context(), userID, groupID)
+	if err != nil {
 		h.logger.Error("Failed to delete group", zap.Error(err), zap.String("group_id", groupID.String()))
-		if strings.Contains(err.Error(), "access denied") {
-			h.writeErrorResponse(w, http.StatusForbidden, err.Error())
-		} else {
-			h.writeErrorResponse(w, http.StatusInternalServerError, err.Error())
-		}
+		h.writeOwnershipError(w, err)
+		return
+	}
+	if action != nil {
+		h.writeJSONResponse(w, http.StatusAccepted, action)
 		return
 	}
 
 	h.writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Group deleted successfully"})
 }
 
+// RestoreGroup 恢复保留期内的已解散群组
+func (h *GroupHandler) RestoreGroup(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	group, err := h.groupService.RestoreGroup(r.Context(), userID, groupID)
+	if err != nil {
+		h.logger.Error("Failed to restore group", zap.Error(err), zap.String("group_id", groupID.String()))
+		if strings.Contains(err.Error(), "restore period expired") {
+			h.writeErrorResponse(w, http.StatusGone, err.Error())
+			return
+		}
+		h.writeOwnershipError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, group)
+}
+
 // SearchGroups 搜索群组
 func (h *GroupHandler) SearchGroups(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-
-	if limit <= 0 {
-		limit = 20
-	}
-	if offset < 0 {
-		offset = 0
+	page, err := pagination.Parse(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	groups, err := h.groupService.SearchGroups(r.Context(), query, limit, offset)
+	groups, err := h.groupService.SearchGroups(r.Context(), query, page.Limit, page.Offset)
 	if err != nil {
 		h.logger.Error("Failed to search groups", zap.Error(err))
 		h.writeErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	pagination.SetLinkHeader(w, r, page, page.HasMore(len(groups)))
 	h.writeJSONResponse(w, http.StatusOK, groups)
 }
 
@@ -283,6 +346,8 @@ func (h *GroupHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
 		h.logger.Error("Failed to update member", zap.Error(err), zap.String("group_id", groupID.String()))
 		if strings.Contains(err.Error(), "access denied") {
 			h.writeErrorResponse(w, http.StatusForbidden, err.Error())
+		} else if strings.Contains(err.Error(), "co-owner") {
+			h.writeErrorResponse(w, http.StatusConflict, err.Error())
 		} else {
 			h.writeErrorResponse(w, http.StatusInternalServerError, err.Error())
 		}
@@ -310,6 +375,8 @@ func (h *GroupHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
 		h.logger.Error("Failed to remove member", zap.Error(err), zap.String("group_id", groupID.String()))
 		if strings.Contains(err.Error(), "access denied") {
 			h.writeErrorResponse(w, http.StatusForbidden, err.Error())
+		} else if strings.Contains(err.Error(), "co-owner") {
+			h.writeErrorResponse(w, http.StatusConflict, err.Error())
 		} else {
 			h.writeErrorResponse(w, http.StatusInternalServerError, err.Error())
 		}
@@ -499,10 +566,17 @@ func (h *GroupHandler) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		r.Header.Set("X-Username", claims.Username)
 		r.Header.Set("X-Email", claims.Email)
 
-		next(w, r)
+		// 角色只来自校验过的令牌，不使用客户端传入的头部
+		next(w, r.WithContext(context.WithValue(r.Context(), roleContextKey, claims.Role)))
 	}
 }
 
+// getRoleFromContext 获取authMiddleware从令牌中解析的用户角色
+func (h *GroupHandler) getRoleFromContext(r *http.Request) string {
+	role, _ := r.Context().Value(roleContextKey).(string)
+	return role
+}
+
 // getUserIDFromContext 从请求中获取用户ID
 func (h *GroupHandler) getUserIDFromContext(r *http.Request) uuid.UUID {
 	userIDStr := r.Header.Get("X-User-ID")
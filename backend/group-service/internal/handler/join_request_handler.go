@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/pkg/pagination"
+	"github.com/neohope/chatapp/group-service/pkg/validation"
+	"go.uber.org/zap"
+)
+
+// registerJoinRequestRoutes 注册入群问题与入群申请路由
+func (h *GroupHandler) registerJoinRequestRoutes(router *mux.Router) {
+	router.HandleFunc("/groups/{groupId}/join-questions", h.authMiddleware(h.GetJoinQuestions)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/join-questions", h.authMiddleware(h.SetJoinQuestions)).Methods("PUT")
+	router.HandleFunc("/groups/{groupId}/join-requests", h.authMiddleware(h.CreateJoinRequest)).Methods("POST")
+	router.HandleFunc("/groups/{groupId}/join-requests", h.authMiddleware(h.ListJoinRequests)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/join-requests/me", h.authMiddleware(h.GetMyJoinRequest)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/join-requests/me", h.authMiddleware(h.CancelJoinRequest)).Methods("DELETE")
+	router.HandleFunc("/groups/{groupId}/join-requests/{requestId}/approve", h.authMiddleware(h.ApproveJoinRequest)).Methods("POST")
+	router.HandleFunc("/groups/{groupId}/join-requests/{requestId}/reject", h.authMiddleware(h.RejectJoinRequest)).Methods("POST")
+}
+
+// GetJoinQuestions 获取群组的入群问题
+func (h *GroupHandler) GetJoinQuestions(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	questions, err := h.groupService.GetJoinQuestions(r.Context(), userID, groupID)
+	if err != nil {
+		h.logger.Error("Failed to get join questions", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeJoinRequestError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, questions)
+}
+
+// SetJoinQuestions 设置群组的入群问题
+func (h *GroupHandler) SetJoinQuestions(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.SetJoinQuestionsRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	questions, err := h.groupService.SetJoinQuestions(r.Context(), userID, groupID, &req)
+	if err != nil {
+		h.logger.Error("Failed to set join questions", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeJoinRequestError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, questions)
+}
+
+// CreateJoinRequest 提交入群申请
+func (h *GroupHandler) CreateJoinRequest(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.CreateJoinRequestRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	request, err := h.groupService.CreateJoinRequest(r.Context(), userID, groupID, &req)
+	if err != nil {
+		h.logger.Error("Failed to create join request", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeJoinRequestError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusCreated, request)
+}
+
+// ListJoinRequests 分页获取群组的入群申请，可通过status筛选，默认待审核
+func (h *GroupHandler) ListJoinRequests(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	page, err := pagination.Parse(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	status := models.JoinRequestStatus(r.URL.Query().Get("status"))
+
+	requests, err := h.groupService.ListJoinRequests(r.Context(), userID, groupID, status, page.Limit, page.Offset)
+	if err != nil {
+		h.logger.Error("Failed to list join requests", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeJoinRequestError(w, err)
+		return
+	}
+
+	pagination.SetLinkHeader(w, r, page, page.HasMore(len(requests)))
+	h.writeJSONResponse(w, http.StatusOK, requests)
+}
+
+// GetMyJoinRequest 获取自己待审核的入群申请
+func (h *GroupHandler) GetMyJoinRequest(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	request, err := h.groupService.GetMyJoinRequest(r.Context(), userID, groupID)
+	if err != nil {
+		h.writeJoinRequestError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, request)
+}
+
+// CancelJoinRequest 撤回自己待审核的入群申请
+func (h *GroupHandler) CancelJoinRequest(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	if err := h.groupService.CancelJoinRequest(r.Context(), userID, groupID); err != nil {
+		h.logger.Error("Failed to cancel join request", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeJoinRequestError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Join request cancelled successfully"})
+}
+
+// ApproveJoinRequest 通过入群申请
+func (h *GroupHandler) ApproveJoinRequest(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	requestID, err := h.getJoinRequestIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid join request ID")
+		return
+	}
+
+	request, err := h.groupService.ApproveJoinRequest(r.Context(), userID, groupID, requestID)
+	if err != nil {
+		h.logger.Error("Failed to approve join request", zap.Error(err), zap.String("request_id", requestID.String()))
+		h.writeJoinRequestError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, request)
+}
+
+// RejectJoinRequest 拒绝入群申请
+func (h *GroupHandler) RejectJoinRequest(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	requestID, err := h.getJoinRequestIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid join request ID")
+		return
+	}
+
+	request, err := h.groupService.RejectJoinRequest(r.Context(), userID, groupID, requestID)
+	if err != nil {
+		h.logger.Error("Failed to reject join request", zap.Error(err), zap.String("request_id", requestID.String()))
+		h.writeJoinRequestError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, request)
+}
+
+// getJoinRequestIDFromPath 从路径中获取入群申请ID
+func (h *GroupHandler) getJoinRequestIDFromPath(r *http.Request) (uuid.UUID, error) {
+	vars := mux.Vars(r)
+	return uuid.Parse(vars["requestId"])
+}
+
+// writeJoinRequestError 根据入群申请错误写入对应状态码
+func (h *GroupHandler) writeJoinRequestError(w http.ResponseWriter, err error) {
+	if errs, ok := validation.AsErrors(err); ok {
+		validation.WriteError(w, errs)
+		return
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "access denied") || strings.Contains(msg, "not a member of this group"):
+		h.writeErrorResponse(w, http.StatusForbidden, msg)
+	case strings.Contains(msg, "not found"):
+		h.writeErrorResponse(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "already pending") || strings.Contains(msg, "already a member") ||
+		strings.Contains(msg, "already resolved") || strings.Contains(msg, "maximum member limit"):
+		h.writeErrorResponse(w, http.StatusConflict, msg)
+	case strings.Contains(msg, "answer required") || strings.Contains(msg, "unknown join question") ||
+		strings.Contains(msg, "invalid join request status"):
+		h.writeErrorResponse(w, http.StatusBadRequest, msg)
+	default:
+		h.writeErrorResponse(w, http.StatusInternalServerError, msg)
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/pkg/validation"
+	"go.uber.org/zap"
+)
+
+// registerMuteRoutes 注册成员免打扰路由
+func (h *GroupHandler) registerMuteRoutes(router *mux.Router) {
+	router.HandleFunc("/groups/{groupId}/mute", h.authMiddleware(h.SetMute)).Methods("PUT")
+}
+
+// RegisterInternalRoutes 注册供其他服务调用的内部路由，不经过API网关
+func (h *GroupHandler) RegisterInternalRoutes(router *mux.Router) {
+	router.HandleFunc("/internal/conversations/{conversationId}/mutes", h.GetConversationMutes).Methods("GET")
+	router.HandleFunc("/internal/conversations/{conversationId}/storage/check", h.CheckStorage).Methods("GET")
+}
+
+// SetMute 设置或取消群组免打扰
+func (h *GroupHandler) SetMute(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.SetMuteRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	mute, err := h.groupService.SetMute(r.Context(), userID, groupID, &req)
+	if err != nil {
+		if errs, ok := validation.AsErrors(err); ok {
+			validation.WriteError(w, errs)
+			return
+		}
+		if strings.Contains(err.Error(), "access denied") {
+			h.writeErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Error("Failed to set group mute", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, mute)
+}
+
+// GetConversationMutes 供通知服务在推送前查询会话所属群组中开启了免打扰的成员
+func (h *GroupHandler) GetConversationMutes(w http.ResponseWriter, r *http.Request) {
+	conversationID := mux.Vars(r)["conversationId"]
+
+	mutes, err := h.groupService.GetConversationMutes(r.Context(), conversationID)
+	if err != nil {
+		h.logger.Error("Failed to get group mutes", zap.Error(err), zap.String("conversation_id", conversationID))
+		h.writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, mutes)
+}
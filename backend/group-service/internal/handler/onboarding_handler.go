@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/pkg/validation"
+	"go.uber.org/zap"
+)
+
+// registerOnboardingRoutes 注册新成员入群设置路由
+func (h *GroupHandler) registerOnboardingRoutes(router *mux.Router) {
+	router.HandleFunc("/groups/{groupId}/onboarding", h.authMiddleware(h.GetOnboarding)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/onboarding", h.authMiddleware(h.SetOnboarding)).Methods("PUT")
+}
+
+// GetOnboarding 获取新成员入群设置
+func (h *GroupHandler) GetOnboarding(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	onboarding, err := h.groupService.GetOnboarding(r.Context(), userID, groupID)
+	if err != nil {
+		h.writeOnboardingError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, onboarding)
+}
+
+// SetOnboarding 设置新成员入群设置
+func (h *GroupHandler) SetOnboarding(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.SetOnboardingRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	onboarding, err := h.groupService.SetOnboarding(r.Context(), userID, groupID, &req)
+	if err != nil {
+		h.logger.Error("Failed to set onboarding", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeOnboardingError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, onboarding)
+}
+
+// writeOnboardingError 根据入群设置相关错误写入对应状态码
+func (h *GroupHandler) writeOnboardingError(w http.ResponseWriter, err error) {
+	if errs, ok := validation.AsErrors(err); ok {
+		validation.WriteError(w, errs)
+		return
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "access denied") || strings.Contains(msg, "not a member of this group"):
+		h.writeErrorResponse(w, http.StatusForbidden, msg)
+	case strings.Contains(msg, "not found"):
+		h.writeErrorResponse(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "invalid welcome"):
+		h.writeErrorResponse(w, http.StatusBadRequest, msg)
+	default:
+		h.writeErrorResponse(w, http.StatusInternalServerError, msg)
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/pkg/openapi"
+)
+
+// APIOperations 群组服务HTTP接口说明，启动时与注册的路由核对后生成/openapi.json
+var APIOperations = openapi.Operations{
+	"DELETE /api/v1/groups/{groupId}":                                    {Summary: "解散群组", Description: "需要另一位群主确认时返回202和待确认操作，否则直接解散并返回200；解散后在保留期内可以恢复", Response: models.GroupPendingAction{}, Status: http.StatusAccepted},
+	"DELETE /api/v1/groups/{groupId}/announcements/{announcementId}":     {Summary: "删除群公告"},
+	"DELETE /api/v1/groups/{groupId}/channels/{channelId}":               {Summary: "删除频道"},
+	"DELETE /api/v1/groups/{groupId}/join-requests/me":                   {Summary: "撤回自己待审核的入群申请"},
+	"DELETE /api/v1/groups/{groupId}/members/{userId}":                   {Summary: "移除群组成员"},
+	"DELETE /api/v1/groups/{groupId}/resources/{resourceId}":             {Summary: "删除置顶资源"},
+	"DELETE /api/v1/groups/{groupId}/webhooks/{webhookId}":               {Summary: "删除Webhook"},
+	"GET /api/v1/group-invitations/received":                             {Summary: "获取收到的邀请"},
+	"GET /api/v1/groups/search":                                          {Summary: "搜索群组", Query: []string{"limit", "offset", "q"}, Response: []*models.GroupWithMemberCount{}, Public: true},
+	"GET /api/v1/groups/{groupId}":                                       {Summary: "获取群组信息", Response: models.Group{}},
+	"GET /api/v1/groups/{groupId}/announcements":                         {Summary: "分页获取群公告列表", Query: []string{"limit", "offset"}, Response: []*models.GroupAnnouncement{}},
+	"GET /api/v1/groups/{groupId}/channels":                              {Summary: "获取群组频道列表", Response: []*models.GroupChannel{}},
+	"GET /api/v1/groups/{groupId}/channels/mine":                         {Summary: "获取当前用户已加入的频道", Response: []*models.GroupChannel{}},
+	"GET /api/v1/groups/{groupId}/invitations":                           {Summary: "获取群组邀请列表"},
+	"GET /api/v1/groups/{groupId}/invitations/sent":                      {Summary: "获取发出的邀请"},
+	"GET /api/v1/groups/{groupId}/join-questions":                        {Summary: "获取群组的入群问题", Response: []*models.GroupJoinQuestion{}},
+	"GET /api/v1/groups/{groupId}/join-requests":                         {Summary: "分页获取群组的入群申请，可通过status筛选，默认待审核", Query: []string{"limit", "offset", "status"}, Response: []*models.GroupJoinRequest{}},
+	"GET /api/v1/groups/{groupId}/join-requests/me":                      {Summary: "获取自己待审核的入群申请", Response: models.GroupJoinRequest{}},
+	"GET /api/v1/groups/{groupId}/members":                               {Summary: "获取群组成员", Response: []*models.GroupMemberWithUser{}},
+	"GET /api/v1/groups/{groupId}/onboarding":                            {Summary: "获取新成员入群设置（欢迎消息和默认角色）", Response: models.GroupOnboarding{}},
+	"GET /api/v1/groups/{groupId}/pending-actions":                       {Summary: "获取群组待确认操作列表", Response: []*models.GroupPendingAction{}},
+	"GET /api/v1/groups/{groupId}/prune-policy":                          {Summary: "获取不活跃成员清理策略", Response: models.GroupPrunePolicy{}},
+	"GET /api/v1/groups/{groupId}/prune-preview":                         {Summary: "预览会被清理的成员，可通过inactive_days试算其他天数", Query: []string{"inactive_days"}, Response: models.PrunePreview{}},
+	"GET /api/v1/groups/{groupId}/prune-proposals":                       {Summary: "分页获取清理提议，可通过status筛选，默认待确认", Query: []string{"limit", "offset", "status"}, Response: []*models.GroupPruneProposal{}},
+	"GET /api/v1/groups/{groupId}/resources":                             {Summary: "获取群组置顶资源列表", Response: []*models.GroupResource{}},
+	"GET /api/v1/groups/{groupId}/stats":                                 {Summary: "获取群组成员统计和文件存储用量", Description: "用量达到配额的90%时storage.warning为true", Response: models.GroupStats{}},
+	"GET /api/v1/groups/{groupId}/webhooks":                              {Summary: "获取群组Webhook列表", Response: []*models.GroupWebhook{}},
+	"GET /api/v1/health":                                                 {Summary: "健康检查", Public: true},
+	"GET /api/v1/my-group-invitations":                                   {Summary: "获取我的群组邀请（前端专用路由）"},
+	"GET /api/v1/users/{userId}/groups":                                  {Summary: "获取用户群组", Response: []*models.GroupWithMemberCount{}},
+	"GET /api/v1/users/{userId}/invitations":                             {Summary: "获取待处理邀请", Response: []*models.GroupInvitation{}},
+	"POST /api/v1/groups":                                                {Summary: "创建群组", Request: models.CreateGroupRequest{}, Response: models.Group{}, Status: http.StatusCreated},
+	"POST /api/v1/groups/{groupId}/announcements":                        {Summary: "发布群公告", Request: models.CreateAnnouncementRequest{}, Response: models.GroupAnnouncement{}, Status: http.StatusCreated},
+	"POST /api/v1/groups/{groupId}/channels":                             {Summary: "创建频道", Request: models.CreateChannelRequest{}, Response: models.GroupChannel{}, Status: http.StatusCreated},
+	"POST /api/v1/groups/{groupId}/channels/{channelId}/join":            {Summary: "加入频道"},
+	"POST /api/v1/groups/{groupId}/channels/{channelId}/leave":           {Summary: "离开频道"},
+	"POST /api/v1/groups/{groupId}/invitations":                          {Summary: "邀请用户", Request: models.InviteRequest{}, Response: models.GroupInvitation{}, Status: http.StatusCreated},
+	"POST /api/v1/groups/{groupId}/join-requests":                        {Summary: "提交入群申请", Request: models.CreateJoinRequestRequest{}, Response: models.GroupJoinRequest{}, Status: http.StatusCreated},
+	"POST /api/v1/groups/{groupId}/join-requests/{requestId}/approve":    {Summary: "通过入群申请", Response: models.GroupJoinRequest{}},
+	"POST /api/v1/groups/{groupId}/join-requests/{requestId}/reject":     {Summary: "拒绝入群申请", Response: models.GroupJoinRequest{}},
+	"POST /api/v1/groups/{groupId}/leave":                                {Summary: "离开群组"},
+	"POST /api/v1/groups/{groupId}/members":                              {Summary: "添加群组成员", Request: models.AddMemberRequest{}},
+	"POST /api/v1/groups/{groupId}/pending-actions/{actionId}/cancel":    {Summary: "取消待确认操作", Response: models.GroupPendingAction{}},
+	"POST /api/v1/groups/{groupId}/pending-actions/{actionId}/confirm":   {Summary: "确认并执行待确认操作", Response: models.GroupPendingAction{}},
+	"POST /api/v1/groups/{groupId}/prune-proposals/{proposalId}/approve": {Summary: "确认清理提议", Response: models.GroupPruneProposal{}},
+	"POST /api/v1/groups/{groupId}/prune-proposals/{proposalId}/reject":  {Summary: "驳回清理提议", Response: models.GroupPruneProposal{}},
+	"POST /api/v1/groups/{groupId}/restore":                              {Summary: "恢复已解散的群组", Description: "群主或发起解散的联合群主可以在保留期内恢复，成员、频道等数据一并恢复", Response: models.Group{}},
+	"POST /api/v1/groups/{groupId}/resources":                            {Summary: "创建置顶资源", Request: models.CreateGroupResourceRequest{}, Response: models.GroupResource{}, Status: http.StatusCreated},
+	"POST /api/v1/groups/{groupId}/transfer":                             {Summary: "转让群主", Description: "需要另一位群主确认时返回202和待确认操作，否则直接转让并返回200", Request: models.TransferOwnershipRequest{}, Response: models.GroupPendingAction{}, Status: http.StatusAccepted},
+	"POST /api/v1/groups/{groupId}/transfer-ownership":                   {Summary: "转让群主", Description: "需要另一位群主确认时返回202和待确认操作，否则直接转让并返回200", Request: models.TransferOwnershipRequest{}, Response: models.GroupPendingAction{}, Status: http.StatusAccepted},
+	"POST /api/v1/groups/{groupId}/webhooks":                             {Summary: "创建成员同步Webhook", Request: models.CreateWebhookRequest{}, Response: models.CreateWebhookResponse{}, Status: http.StatusCreated},
+	"POST /api/v1/invitations/{invitationId}/accept":                     {Summary: "接受邀请"},
+	"POST /api/v1/invitations/{invitationId}/reject":                     {Summary: "拒绝邀请"},
+	"PUT /api/v1/groups/{groupId}":                                       {Summary: "更新群组信息", Request: models.UpdateGroupRequest{}, Response: models.Group{}},
+	"PUT /api/v1/groups/{groupId}/announcements/{announcementId}/pin":    {Summary: "置顶或取消置顶群公告", Request: models.PinAnnouncementRequest{}, Response: models.GroupAnnouncement{}},
+	"PUT /api/v1/groups/{groupId}/channels/{channelId}":                  {Summary: "更新频道", Request: models.UpdateChannelRequest{}, Response: models.GroupChannel{}},
+	"PUT /api/v1/groups/{groupId}/join-questions":                        {Summary: "设置群组的入群问题", Request: models.SetJoinQuestionsRequest{}, Response: []*models.GroupJoinQuestion{}},
+	"PUT /api/v1/groups/{groupId}/members/{userId}":                      {Summary: "更新群组成员", Request: models.UpdateMemberRequest{}},
+	"PUT /api/v1/groups/{groupId}/mute":                                  {Summary: "设置或取消群组免打扰", Description: "duration可选1h、8h、forever，off取消免打扰；免打扰期间通知服务不再推送该群组的新消息", Request: models.SetMuteRequest{}, Response: models.GroupMute{}},
+	"PUT /api/v1/groups/{groupId}/onboarding":                            {Summary: "设置新成员入群设置", Description: "欢迎消息模板支持{{username}}和{{group_name}}占位符，新成员加入后以群主身份发送到欢迎频道；default_role为新成员的角色", Request: models.SetOnboardingRequest{}, Response: models.GroupOnboarding{}},
+	"PUT /api/v1/groups/{groupId}/owner-confirmation":                    {Summary: "开启或关闭群主双人确认", Description: "关闭需要另一位群主确认时返回202和待确认操作", Request: models.OwnerConfirmationRequest{}, Response: models.GroupPendingAction{}, Status: http.StatusAccepted},
+	"PUT /api/v1/groups/{groupId}/prune-policy":                          {Summary: "设置不活跃成员清理策略", Request: models.SetPrunePolicyRequest{}, Response: models.GroupPrunePolicy{}},
+	"PUT /api/v1/groups/{groupId}/resources/{resourceId}":                {Summary: "更新置顶资源", Request: models.UpdateGroupResourceRequest{}, Response: models.GroupResource{}},
+	"PUT /api/v1/groups/{groupId}/storage/tier":                          {Summary: "设置群组存储档位（平台管理员）", Request: models.SetStorageTierRequest{}, Response: models.GroupStorageUsage{}},
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// registerOwnershipRoutes 注册群主转让与待确认操作路由
+func (h *GroupHandler) registerOwnershipRoutes(router *mux.Router) {
+	router.HandleFunc("/groups/{groupId}/transfer", h.authMiddleware(h.TransferOwnership)).Methods("POST")
+	router.HandleFunc("/groups/{groupId}/transfer-ownership", h.authMiddleware(h.TransferOwnership)).Methods("POST")
+	router.HandleFunc("/groups/{groupId}/owner-confirmation", h.authMiddleware(h.SetOwnerConfirmation)).Methods("PUT")
+	router.HandleFunc("/groups/{groupId}/pending-actions", h.authMiddleware(h.GetPendingActions)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/pending-actions/{actionId}/confirm", h.authMiddleware(h.ConfirmPendingAction)).Methods("POST")
+	router.HandleFunc("/groups/{groupId}/pending-actions/{actionId}/cancel", h.authMiddleware(h.CancelPendingAction)).Methods("POST")
+}
+
+// TransferOwnership 转让群主，需要另一位群主确认时返回202和待确认操作
+func (h *GroupHandler) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.TransferOwnershipRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	action, err := h.groupService.TransferOwnership(r.Context(), userID, groupID, &req)
+	if err != nil {
+		h.logger.Error("Failed to transfer ownership", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeOwnershipError(w, err)
+		return
+	}
+	if action != nil {
+		h.writeJSONResponse(w, http.StatusAccepted, action)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Ownership transferred successfully"})
+}
+
+// SetOwnerConfirmation 开启或关闭群主双人确认，关闭需要确认时返回202和待确认操作
+func (h *GroupHandler) SetOwnerConfirmation(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.OwnerConfirmationRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	action, err := h.groupService.SetOwnerConfirmation(r.Context(), userID, groupID, req.Enabled)
+	if err != nil {
+		h.logger.Error("Failed to set owner confirmation", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeOwnershipError(w, err)
+		return
+	}
+	if action != nil {
+		h.writeJSONResponse(w, http.StatusAccepted, action)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, map[string]bool{"require_owner_confirmation": req.Enabled})
+}
+
+// GetPendingActions 获取群组待确认操作列表
+func (h *GroupHandler) GetPendingActions(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	actions, err := h.groupService.GetPendingActions(r.Context(), userID, groupID)
+	if err != nil {
+		h.logger.Error("Failed to get pending actions", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeOwnershipError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, actions)
+}
+
+// ConfirmPendingAction 确认并执行待确认操作
+func (h *GroupHandler) ConfirmPendingAction(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	actionID, err := h.getActionIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid action ID")
+		return
+	}
+
+	action, err := h.groupService.ConfirmPendingAction(r.Context(), userID, groupID, actionID)
+	if err != nil {
+		h.logger.Error("Failed to confirm pending action", zap.Error(err), zap.String("action_id", actionID.String()))
+		h.writeOwnershipError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, action)
+}
+
+// CancelPendingAction 取消待确认操作
+func (h *GroupHandler) CancelPendingAction(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	actionID, err := h.getActionIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid action ID")
+		return
+	}
+
+	action, err := h.groupService.CancelPendingAction(r.Context(), userID, groupID, actionID)
+	if err != nil {
+		h.logger.Error("Failed to cancel pending action", zap.Error(err), zap.String("action_id", actionID.String()))
+		h.writeOwnershipError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, action)
+}
+
+// getActionIDFromPath 从路径中获取待确认操作ID
+func (h *GroupHandler) getActionIDFromPath(r *http.Request) (uuid.UUID, error) {
+	vars := mux.Vars(r)
+	return uuid.Parse(vars["actionId"])
+}
+
+// writeOwnershipError 根据群主级操作错误写入对应状态码
+func (h *GroupHandler) writeOwnershipError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "access denied") || strings.Contains(msg, "not a member of this group"):
+		h.writeErrorResponse(w, http.StatusForbidden, msg)
+	case strings.Contains(msg, "not found"):
+		h.writeErrorResponse(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "already exists") || strings.Contains(msg, "already resolved") ||
+		strings.Contains(msg, "expired") || strings.Contains(msg, "owner has changed"):
+		h.writeErrorResponse(w, http.StatusConflict, msg)
+	case strings.Contains(msg, "required") || strings.Contains(msg, "invalid") ||
+		strings.Contains(msg, "not an active member"):
+		h.writeErrorResponse(w, http.StatusBadRequest, msg)
+	default:
+		h.writeErrorResponse(w, http.StatusInternalServerError, msg)
+	}
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/pkg/pagination"
+	"github.com/neohope/chatapp/group-service/pkg/validation"
+	"go.uber.org/zap"
+)
+
+// registerPruneRoutes 注册不活跃成员清理路由
+func (h *GroupHandler) registerPruneRoutes(router *mux.Router) {
+	router.HandleFunc("/groups/{groupId}/prune-policy", h.authMiddleware(h.GetPrunePolicy)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/prune-policy", h.authMiddleware(h.SetPrunePolicy)).Methods("PUT")
+	router.HandleFunc("/groups/{groupId}/prune-preview", h.authMiddleware(h.PreviewPrune)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/prune-proposals", h.authMiddleware(h.ListPruneProposals)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/prune-proposals/{proposalId}/approve", h.authMiddleware(h.ApprovePruneProposal)).Methods("POST")
+	router.HandleFunc("/groups/{groupId}/prune-proposals/{proposalId}/reject", h.authMiddleware(h.RejectPruneProposal)).Methods("POST")
+}
+
+// GetPrunePolicy 获取不活跃成员清理策略
+func (h *GroupHandler) GetPrunePolicy(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	policy, err := h.groupService.GetPrunePolicy(r.Context(), userID, groupID)
+	if err != nil {
+		h.writePruneError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, policy)
+}
+
+// SetPrunePolicy 设置不活跃成员清理策略
+func (h *GroupHandler) SetPrunePolicy(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.SetPrunePolicyRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	policy, err := h.groupService.SetPrunePolicy(r.Context(), userID, groupID, &req)
+	if err != nil {
+		h.logger.Error("Failed to set prune policy", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writePruneError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, policy)
+}
+
+// PreviewPrune 预览会被清理的成员，可通过inactive_days试算其他天数
+func (h *GroupHandler) PreviewPrune(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var inactiveDays int
+	if value := r.URL.Query().Get("inactive_days"); value != "" {
+		inactiveDays, err = strconv.Atoi(value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "Invalid inactive_days")
+			return
+		}
+	}
+
+	preview, err := h.groupService.PreviewPrune(r.Context(), userID, groupID, inactiveDays)
+	if err != nil {
+		h.logger.Error("Failed to preview prune", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writePruneError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, preview)
+}
+
+// ListPruneProposals 分页获取清理提议，可通过status筛选，默认待确认
+func (h *GroupHandler) ListPruneProposals(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	page, err := pagination.Parse(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	status := models.PruneProposalStatus(r.URL.Query().Get("status"))
+
+	proposals, err := h.groupService.ListPruneProposals(r.Context(), userID, groupID, status, page.Limit, page.Offset)
+	if err != nil {
+		h.logger.Error("Failed to list prune proposals", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writePruneError(w, err)
+		return
+	}
+
+	pagination.SetLinkHeader(w, r, page, page.HasMore(len(proposals)))
+	h.writeJSONResponse(w, http.StatusOK, proposals)
+}
+
+// ApprovePruneProposal 确认清理提议
+func (h *GroupHandler) ApprovePruneProposal(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	proposalID, err := h.getPruneProposalIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid prune proposal ID")
+		return
+	}
+
+	proposal, err := h.groupService.ApprovePruneProposal(r.Context(), userID, groupID, proposalID)
+	if err != nil {
+		h.logger.Error("Failed to approve prune proposal", zap.Error(err), zap.String("proposal_id", proposalID.String()))
+		h.writePruneError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, proposal)
+}
+
+// RejectPruneProposal 驳回清理提议
+func (h *GroupHandler) RejectPruneProposal(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	proposalID, err := h.getPruneProposalIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid prune proposal ID")
+		return
+	}
+
+	proposal, err := h.groupService.RejectPruneProposal(r.Context(), userID, groupID, proposalID)
+	if err != nil {
+		h.logger.Error("Failed to reject prune proposal", zap.Error(err), zap.String("proposal_id", proposalID.String()))
+		h.writePruneError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, proposal)
+}
+
+// getPruneProposalIDFromPath 从路径中获取清理提议ID
+func (h *GroupHandler) getPruneProposalIDFromPath(r *http.Request) (uuid.UUID, error) {
+	vars := mux.Vars(r)
+	return uuid.Parse(vars["proposalId"])
+}
+
+// writePruneError 根据清理相关错误写入对应状态码
+func (h *GroupHandler) writePruneError(w http.ResponseWriter, err error) {
+	if errs, ok := validation.AsErrors(err); ok {
+		validation.WriteError(w, errs)
+		return
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "access denied") || strings.Contains(msg, "not a member of this group"):
+		h.writeErrorResponse(w, http.StatusForbidden, msg)
+	case strings.Contains(msg, "not found"):
+		h.writeErrorResponse(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "already resolved"):
+		h.writeErrorResponse(w, http.StatusConflict, msg)
+	case strings.Contains(msg, "invalid inactive days") || strings.Contains(msg, "invalid prune proposal status"):
+		h.writeErrorResponse(w, http.StatusBadRequest, msg)
+	default:
+		h.writeErrorResponse(w, http.StatusInternalServerError, msg)
+	}
+}
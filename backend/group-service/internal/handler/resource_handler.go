@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// registerResourceRoutes 注册置顶资源路由
+func (h *GroupHandler) registerResourceRoutes(router *mux.Router) {
+	router.HandleFunc("/groups/{groupId}/resources", h.authMiddleware(h.CreateResource)).Methods("POST")
+	router.HandleFunc("/groups/{groupId}/resources", h.authMiddleware(h.GetResources)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/resources/{resourceId}", h.authMiddleware(h.UpdateResource)).Methods("PUT")
+	router.HandleFunc("/groups/{groupId}/resources/{resourceId}", h.authMiddleware(h.DeleteResource)).Methods("DELETE")
+}
+
+// CreateResource 创建置顶资源
+func (h *GroupHandler) CreateResource(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.CreateGroupResourceRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	resource, err := h.groupService.CreateResource(r.Context(), userID, groupID, &req)
+	if err != nil {
+		h.logger.Error("Failed to create resource", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeResourceError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusCreated, resource)
+}
+
+// GetResources 获取群组置顶资源列表
+func (h *GroupHandler) GetResources(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	resources, err := h.groupService.GetResources(r.Context(), userID, groupID)
+	if err != nil {
+		h.logger.Error("Failed to get resources", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeResourceError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, resources)
+}
+
+// UpdateResource 更新置顶资源
+func (h *GroupHandler) UpdateResource(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	resourceID, err := h.getResourceIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid resource ID")
+		return
+	}
+
+	var req models.UpdateGroupResourceRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	resource, err := h.groupService.UpdateResource(r.Context(), userID, groupID, resourceID, &req)
+	if err != nil {
+		h.logger.Error("Failed to update resource", zap.Error(err), zap.String("resource_id", resourceID.String()))
+		h.writeResourceError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, resource)
+}
+
+// DeleteResource 删除置顶资源
+func (h *GroupHandler) DeleteResource(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	resourceID, err := h.getResourceIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid resource ID")
+		return
+	}
+
+	if err := h.groupService.DeleteResource(r.Context(), userID, groupID, resourceID); err != nil {
+		h.logger.Error("Failed to delete resource", zap.Error(err), zap.String("resource_id", resourceID.String()))
+		h.writeResourceError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Resource deleted successfully"})
+}
+
+// getResourceIDFromPath 从路径中获取置顶资源ID
+func (h *GroupHandler) getResourceIDFromPath(r *http.Request) (uuid.UUID, error) {
+	vars := mux.Vars(r)
+	return uuid.Parse(vars["resourceId"])
+}
+
+// writeResourceError 根据置顶资源错误写入对应状态码
+func (h *GroupHandler) writeResourceError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "access denied") || strings.Contains(msg, "not a member of this group"):
+		h.writeErrorResponse(w, http.StatusForbidden, msg)
+	case strings.Contains(msg, "not found"):
+		h.writeErrorResponse(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "too many resources"):
+		h.writeErrorResponse(w, http.StatusConflict, msg)
+	case strings.Contains(msg, "required") || strings.Contains(msg, "too long") ||
+		strings.Contains(msg, "invalid") || strings.Contains(msg, "no fields"):
+		h.writeErrorResponse(w, http.StatusBadRequest, msg)
+	default:
+		h.writeErrorResponse(w, http.StatusInternalServerError, msg)
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/pkg/validation"
+	"go.uber.org/zap"
+)
+
+// platformAdminRole 用户服务签发的令牌中平台管理员的角色
+const platformAdminRole = "admin"
+
+// registerStorageRoutes 注册群组统计与存储配额路由
+func (h *GroupHandler) registerStorageRoutes(router *mux.Router) {
+	router.HandleFunc("/groups/{groupId}/stats", h.authMiddleware(h.GetGroupStats)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/storage/tier", h.authMiddleware(h.SetStorageTier)).Methods("PUT")
+}
+
+// GetGroupStats 获取群组成员统计和文件存储用量
+func (h *GroupHandler) GetGroupStats(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	stats, err := h.groupService.GetGroupStats(r.Context(), userID, groupID)
+	if err != nil {
+		h.writeStorageError(w, err, groupID.String())
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, stats)
+}
+
+// SetStorageTier 设置群组存储档位，仅平台管理员可以调用
+func (h *GroupHandler) SetStorageTier(w http.ResponseWriter, r *http.Request) {
+	if h.getRoleFromContext(r) != platformAdminRole {
+		h.writeErrorResponse(w, http.StatusForbidden, "Admin access required")
+		return
+	}
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.SetStorageTierRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	usage, err := h.groupService.SetStorageTier(r.Context(), userID, groupID, &req)
+	if err != nil {
+		h.writeStorageError(w, err, groupID.String())
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, usage)
+}
+
+// CheckStorage 供媒体服务在把文件附加到群聊会话前检查会话所属群组的配额
+func (h *GroupHandler) CheckStorage(w http.ResponseWriter, r *http.Request) {
+	conversationID := mux.Vars(r)["conversationId"]
+	size, err := strconv.ParseInt(r.URL.Query().Get("size"), 10, 64)
+	if err != nil || size < 0 {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid size")
+		return
+	}
+
+	result, err := h.groupService.CheckStorage(r.Context(), conversationID, r.URL.Query().Get("file_id"), size)
+	if err != nil {
+		h.writeStorageError(w, err, conversationID)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// writeStorageError 根据统计和配额相关的错误类型返回对应的状态码
+func (h *GroupHandler) writeStorageError(w http.ResponseWriter, err error, groupID string) {
+	if errs, ok := validation.AsErrors(err); ok {
+		validation.WriteError(w, errs)
+		return
+	}
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "access denied"):
+		h.writeErrorResponse(w, http.StatusForbidden, msg)
+	case strings.Contains(msg, "not found"):
+		h.writeErrorResponse(w, http.StatusNotFound, msg)
+	default:
+		h.logger.Error("Group storage request failed", zap.Error(err), zap.String("group_id", groupID))
+		h.writeErrorResponse(w, http.StatusInternalServerError, msg)
+	}
+}
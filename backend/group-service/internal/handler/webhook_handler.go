@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// registerWebhookRoutes 注册Webhook路由
+func (h *GroupHandler) registerWebhookRoutes(router *mux.Router) {
+	router.HandleFunc("/groups/{groupId}/webhooks", h.authMiddleware(h.CreateWebhook)).Methods("POST")
+	router.HandleFunc("/groups/{groupId}/webhooks", h.authMiddleware(h.GetWebhooks)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/webhooks/{webhookId}", h.authMiddleware(h.DeleteWebhook)).Methods("DELETE")
+}
+
+// CreateWebhook 创建成员同步Webhook
+func (h *GroupHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	webhook, err := h.groupService.CreateWebhook(r.Context(), userID, groupID, &req)
+	if err != nil {
+		h.logger.Error("Failed to create webhook", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeWebhookError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusCreated, webhook)
+}
+
+// GetWebhooks 获取群组Webhook列表
+func (h *GroupHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	webhooks, err := h.groupService.GetWebhooks(r.Context(), userID, groupID)
+	if err != nil {
+		h.logger.Error("Failed to get webhooks", zap.Error(err), zap.String("group_id", groupID.String()))
+		h.writeWebhookError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, webhooks)
+}
+
+// DeleteWebhook 删除Webhook
+func (h *GroupHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserIDFromContext(r)
+	groupID, err := h.getGroupIDFromPath(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+	webhookID, err := uuid.Parse(mux.Vars(r)["webhookId"])
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	if err := h.groupService.DeleteWebhook(r.Context(), userID, groupID, webhookID); err != nil {
+		h.logger.Error("Failed to delete webhook", zap.Error(err), zap.String("webhook_id", webhookID.String()))
+		h.writeWebhookError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Webhook deleted successfully"})
+}
+
+// writeWebhookError 根据Webhook错误写入对应状态码
+func (h *GroupHandler) writeWebhookError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "access denied") || strings.Contains(msg, "not a member of this group"):
+		h.writeErrorResponse(w, http.StatusForbidden, msg)
+	case strings.Contains(msg, "not found"):
+		h.writeErrorResponse(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "invalid") || strings.Contains(msg, "too short"):
+		h.writeErrorResponse(w, http.StatusBadRequest, msg)
+	default:
+		h.writeErrorResponse(w, http.StatusInternalServerError, msg)
+	}
+}
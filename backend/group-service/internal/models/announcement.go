@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 群公告限制
+const (
+	MaxAnnouncementTitleLength   = 100
+	MaxAnnouncementContentLength = 2000
+	MaxPinnedAnnouncements       = 3
+)
+
+// GroupAnnouncement 群公告，置顶公告排在最前，其余按发布时间倒序
+type GroupAnnouncement struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	GroupID   uuid.UUID  `json:"group_id" db:"group_id"`
+	Title     string     `json:"title" db:"title"`
+	Content   string     `json:"content" db:"content"`
+	Pinned    bool       `json:"pinned" db:"pinned"`
+	PinnedBy  *uuid.UUID `json:"pinned_by,omitempty" db:"pinned_by"`
+	PinnedAt  *time.Time `json:"pinned_at,omitempty" db:"pinned_at"`
+	CreatedBy uuid.UUID  `json:"created_by" db:"created_by"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CreateAnnouncementRequest 发布群公告请求，Pinned为true时发布后直接置顶
+type CreateAnnouncementRequest struct {
+	Title   string `json:"title" validate:"required,max=100"`
+	Content string `json:"content" validate:"required,max=2000"`
+	Pinned  bool   `json:"pinned"`
+}
+
+// PinAnnouncementRequest 置顶或取消置顶群公告请求
+type PinAnnouncementRequest struct {
+	Pinned bool `json:"pinned"`
+}
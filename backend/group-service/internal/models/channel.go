@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChannelPermission 频道权限
+type ChannelPermission string
+
+const (
+	ChannelPermissionOpen      ChannelPermission = "open"       // 所有成员可加入、可发言
+	ChannelPermissionReadOnly  ChannelPermission = "read_only"  // 所有成员可加入，仅管理员可发言
+	ChannelPermissionAdminOnly ChannelPermission = "admin_only" // 仅管理员可加入和发言
+)
+
+// DefaultChannelName 创建群组时自动创建的默认频道名称
+const DefaultChannelName = "general"
+
+// GroupChannel 群组频道模型，每个频道对应消息服务中的一个会话
+type GroupChannel struct {
+	ID             uuid.UUID         `json:"id" db:"id"`
+	GroupID        uuid.UUID         `json:"group_id" db:"group_id"`
+	Name           string            `json:"name" db:"name"`
+	Topic          string            `json:"topic" db:"topic"`
+	ConversationID string            `json:"conversation_id" db:"conversation_id"`
+	Permission     ChannelPermission `json:"permission" db:"permission"`
+	IsDefault      bool              `json:"is_default" db:"is_default"`
+	CreatedBy      uuid.UUID         `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// ChannelMember 频道成员模型
+type ChannelMember struct {
+	ChannelID uuid.UUID `json:"channel_id" db:"channel_id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	JoinedAt  time.Time `json:"joined_at" db:"joined_at"`
+}
+
+// CreateChannelRequest 创建频道请求
+type CreateChannelRequest struct {
+	Name       string            `json:"name" validate:"required,min=1,max=50"`
+	Topic      string            `json:"topic" validate:"max=200"`
+	Permission ChannelPermission `json:"permission" validate:"omitempty,oneof=open read_only admin_only"`
+	IsDefault  bool              `json:"is_default"`
+}
+
+// UpdateChannelRequest 更新频道请求
+type UpdateChannelRequest struct {
+	Name       *string            `json:"name,omitempty" validate:"omitempty,min=1,max=50"`
+	Topic      *string            `json:"topic,omitempty" validate:"omitempty,max=200"`
+	Permission *ChannelPermission `json:"permission,omitempty" validate:"omitempty,oneof=open read_only admin_only"`
+	IsDefault  *bool              `json:"is_default,omitempty"`
+}
+
+// IsValid 检查频道权限是否有效
+func (p ChannelPermission) IsValid() bool {
+	switch p {
+	case ChannelPermissionOpen, ChannelPermissionReadOnly, ChannelPermissionAdminOnly:
+		return true
+	}
+	return false
+}
+
+// CanJoin 判断指定角色是否可以加入频道
+func (c *GroupChannel) CanJoin(role GroupMemberRole) bool {
+	if c.Permission == ChannelPermissionAdminOnly {
+		return role.IsAdmin()
+	}
+	return true
+}
+
+// CanPost 判断指定角色是否可以在频道中发言
+func (c *GroupChannel) CanPost(role GroupMemberRole) bool {
+	if c.Permission == ChannelPermissionOpen {
+		return true
+	}
+	return role.IsAdmin()
+}
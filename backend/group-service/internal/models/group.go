@@ -1,22 +1,50 @@
 package models
 
 import (
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+
+	"github.com/neohope/chatapp/group-service/pkg/validation"
+)
+
+// DescriptionFormat 群组简介格式
+type DescriptionFormat string
+
+const (
+	DescriptionFormatPlain    DescriptionFormat = "plain"
+	DescriptionFormatMarkdown DescriptionFormat = "markdown" // 客户端按Markdown渲染，需禁用内嵌HTML
+)
+
+// 群组简介长度限制，Markdown简介允许更长的内容
+const (
+	MaxPlainDescriptionLength    = 200
+	MaxMarkdownDescriptionLength = 4000
 )
 
 // Group 群组模型
 type Group struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name"`
-	Description string    `json:"description" db:"description"`
-	AvatarURL   string    `json:"avatar_url" db:"avatar_url"`
-	OwnerID     uuid.UUID `json:"owner_id" db:"owner_id"`
-	MaxMembers  int       `json:"max_members" db:"max_members"`
-	IsPrivate   bool      `json:"is_private" db:"is_private"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID                uuid.UUID         `json:"id" db:"id"`
+	Name              string            `json:"name" db:"name"`
+	Description       string            `json:"description" db:"description"`
+	DescriptionFormat DescriptionFormat `json:"description_format" db:"description_format"`
+	AvatarURL         string            `json:"avatar_url" db:"avatar_url"`
+	OwnerID           uuid.UUID         `json:"owner_id" db:"owner_id"`
+	MaxMembers        int               `json:"max_members" db:"max_members"`
+	IsPrivate         bool              `json:"is_private" db:"is_private"`
+	MemberCount       int               `json:"member_count" db:"member_count"` // 活跃成员数（冗余字段，随成员变更事务更新）
+	CreatedAt         time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at" db:"updated_at"`
+	// RequireOwnerConfirmation 开启后解散群组、转让群主需要另一位群主在24小时内确认
+	RequireOwnerConfirmation bool `json:"require_owner_confirmation" db:"require_owner_confirmation"`
+	// DeletedAt 解散时间，非空表示群组已解散，保留期内可以恢复，期满后永久删除
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	DeletedBy *uuid.UUID `json:"deleted_by,omitempty" db:"deleted_by"`
+	// Resources 置顶链接/资源，仅在获取群组详情时附带
+	Resources []*GroupResource `json:"resources,omitempty" db:"-"`
 }
 
 // GroupMember 群组成员模型
@@ -34,9 +62,10 @@ type GroupMember struct {
 type GroupMemberRole string
 
 const (
-	RoleOwner     GroupMemberRole = "owner"
-	RoleAdmin     GroupMemberRole = "admin"
-	RoleMember    GroupMemberRole = "member"
+	RoleOwner   GroupMemberRole = "owner"
+	RoleCoOwner GroupMemberRole = "co_owner" // 联合群主，拥有群主权限，但不能任免其他联合群主
+	RoleAdmin   GroupMemberRole = "admin"
+	RoleMember  GroupMemberRole = "member"
 )
 
 // GroupMemberStatus 群组成员状态
@@ -73,20 +102,43 @@ const (
 
 // CreateGroupRequest 创建群组请求
 type CreateGroupRequest struct {
-	Name        string `json:"name" validate:"required,min=1,max=50"`
-	Description string `json:"description" validate:"max=200"`
-	AvatarURL   string `json:"avatar_url" validate:"omitempty,url"`
-	MaxMembers  int    `json:"max_members" validate:"min=2,max=500"`
-	IsPrivate   bool   `json:"is_private"`
+	Name              string            `json:"name" validate:"required,min=1,max=50"`
+	Description       string            `json:"description" validate:"max=4000"`
+	DescriptionFormat DescriptionFormat `json:"description_format" validate:"omitempty,oneof=plain markdown"`
+	AvatarURL         string            `json:"avatar_url" validate:"omitempty,url"`
+	MaxMembers        int               `json:"max_members" validate:"omitempty,min=2,max=500"` // 不填时默认100
+	IsPrivate         bool              `json:"is_private"`
+}
+
+// ValidateStruct 简介长度上限取决于简介格式
+func (req CreateGroupRequest) ValidateStruct() validation.Errors {
+	format := req.DescriptionFormat
+	if format == "" {
+		format = DescriptionFormatPlain
+	}
+	if !format.IsValid() {
+		return nil
+	}
+	limit := format.MaxDescriptionLength()
+	if utf8.RuneCountInString(strings.TrimSpace(req.Description)) > limit {
+		return validation.Errors{{
+			Field:   "description",
+			Rule:    "max",
+			Param:   strconv.Itoa(limit),
+			Message: "description must contain at most " + strconv.Itoa(limit) + " characters for " + string(format) + " format",
+		}}
+	}
+	return nil
 }
 
 // UpdateGroupRequest 更新群组请求
 type UpdateGroupRequest struct {
-	Name        *string `json:"name,omitempty" validate:"omitempty,min=1,max=50"`
-	Description *string `json:"description,omitempty" validate:"omitempty,max=200"`
-	AvatarURL   *string `json:"avatar_url,omitempty" validate:"omitempty,url"`
-	MaxMembers  *int    `json:"max_members,omitempty" validate:"omitempty,min=2,max=500"`
-	IsPrivate   *bool   `json:"is_private,omitempty"`
+	Name              *string            `json:"name,omitempty" validate:"omitempty,min=1,max=50"`
+	Description       *string            `json:"description,omitempty" validate:"omitempty,max=4000"`
+	DescriptionFormat *DescriptionFormat `json:"description_format,omitempty" validate:"omitempty,oneof=plain markdown"`
+	AvatarURL         *string            `json:"avatar_url,omitempty" validate:"omitempty,url"`
+	MaxMembers        *int               `json:"max_members,omitempty" validate:"omitempty,min=2,max=500"`
+	IsPrivate         *bool              `json:"is_private,omitempty"`
 }
 
 // AddMemberRequest 添加成员请求
@@ -98,7 +150,7 @@ type AddMemberRequest struct {
 
 // UpdateMemberRequest 更新成员请求
 type UpdateMemberRequest struct {
-	Role     *GroupMemberRole   `json:"role,omitempty" validate:"omitempty,oneof=admin member"`
+	Role     *GroupMemberRole   `json:"role,omitempty" validate:"omitempty,oneof=co_owner admin member"`
 	Status   *GroupMemberStatus `json:"status,omitempty" validate:"omitempty,oneof=active muted banned"`
 	Nickname *string           `json:"nickname,omitempty" validate:"omitempty,max=30"`
 }
@@ -111,16 +163,22 @@ type InviteRequest struct {
 
 // GroupWithMemberCount 带成员数量的群组
 type GroupWithMemberCount struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name"`
-	Description string    `json:"description" db:"description"`
-	AvatarURL   string    `json:"avatar_url" db:"avatar_url"`
-	OwnerID     uuid.UUID `json:"owner_id" db:"owner_id"`
-	MaxMembers  int       `json:"max_members" db:"max_members"`
-	IsPrivate   bool      `json:"is_private" db:"is_private"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
-	MemberCount int       `json:"member_count" db:"member_count"`
+	ID                uuid.UUID         `json:"id" db:"id"`
+	Name              string            `json:"name" db:"name"`
+	Description       string            `json:"description" db:"description"`
+	DescriptionFormat DescriptionFormat `json:"description_format" db:"description_format"`
+	AvatarURL         string            `json:"avatar_url" db:"avatar_url"`
+	OwnerID           uuid.UUID         `json:"owner_id" db:"owner_id"`
+	MaxMembers        int               `json:"max_members" db:"max_members"`
+	IsPrivate         bool              `json:"is_private" db:"is_private"`
+	MemberCount       int               `json:"member_count" db:"member_count"`
+	CreatedAt         time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at" db:"updated_at"`
+	// RequireOwnerConfirmation 是否开启群主双人确认
+	RequireOwnerConfirmation bool `json:"require_owner_confirmation" db:"require_owner_confirmation"`
+	// 列表只返回未解散的群组，这两个字段始终为空，仅用于接收g.*
+	DeletedAt *time.Time `json:"-" db:"deleted_at"`
+	DeletedBy *uuid.UUID `json:"-" db:"deleted_by"`
 }
 
 // GroupMemberWithUser 带用户信息的群组成员
@@ -128,4 +186,31 @@ type GroupMemberWithUser struct {
 	GroupMember `json:",inline"`
 	Username    string `json:"username"`
 	AvatarURL   string `json:"user_avatar_url"`
+}
+
+// IsValid 检查简介格式是否有效
+func (f DescriptionFormat) IsValid() bool {
+	switch f {
+	case DescriptionFormatPlain, DescriptionFormatMarkdown:
+		return true
+	}
+	return false
+}
+
+// MaxDescriptionLength 返回该格式简介的最大长度
+func (f DescriptionFormat) MaxDescriptionLength() int {
+	if f == DescriptionFormatMarkdown {
+		return MaxMarkdownDescriptionLength
+	}
+	return MaxPlainDescriptionLength
+}
+
+// IsOwner 检查角色是否拥有群主权限（群主或联合群主）
+func (r GroupMemberRole) IsOwner() bool {
+	return r == RoleOwner || r == RoleCoOwner
+}
+
+// IsAdmin 检查角色是否拥有管理员权限（管理员、群主或联合群主）
+func (r GroupMemberRole) IsAdmin() bool {
+	return r.IsOwner() || r == RoleAdmin
 }
\ No newline at end of file
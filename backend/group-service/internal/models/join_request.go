@@ -0,0 +1,154 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/neohope/chatapp/group-service/pkg/validation"
+)
+
+// 入群问题限制
+const (
+	MaxJoinQuestions      = 3
+	MaxJoinQuestionLength = 200
+	MaxJoinAnswerLength   = 500
+)
+
+// GroupJoinQuestion 管理员为入群申请设置的问题，按Position排序
+type GroupJoinQuestion struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	GroupID   uuid.UUID `json:"group_id" db:"group_id"`
+	Position  int       `json:"position" db:"position"`
+	Question  string    `json:"question" db:"question"`
+	Required  bool      `json:"required" db:"required"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// JoinRequestStatus 入群申请状态
+type JoinRequestStatus string
+
+const (
+	JoinRequestPending   JoinRequestStatus = "pending"
+	JoinRequestApproved  JoinRequestStatus = "approved"
+	JoinRequestRejected  JoinRequestStatus = "rejected"
+	JoinRequestCancelled JoinRequestStatus = "cancelled" // 申请人撤回
+)
+
+// JoinAnswer 申请人对入群问题的回答，同时保存提交时的问题文本，问题修改后审核人看到的仍是原问题
+type JoinAnswer struct {
+	QuestionID uuid.UUID `json:"question_id"`
+	Question   string    `json:"question"`
+	Answer     string    `json:"answer"`
+}
+
+// JoinAnswers 以JSONB保存的回答列表
+type JoinAnswers []JoinAnswer
+
+// Value 实现driver.Valuer
+func (a JoinAnswers) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(a)
+}
+
+// Scan 实现sql.Scanner
+func (a *JoinAnswers) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*a = JoinAnswers{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into JoinAnswers", src)
+	}
+	return json.Unmarshal(data, a)
+}
+
+// GroupJoinRequest 入群申请，审核通过后申请人成为普通成员
+type GroupJoinRequest struct {
+	ID         uuid.UUID         `json:"id" db:"id"`
+	GroupID    uuid.UUID         `json:"group_id" db:"group_id"`
+	UserID     uuid.UUID         `json:"user_id" db:"user_id"`
+	Status     JoinRequestStatus `json:"status" db:"status"`
+	Answers    JoinAnswers       `json:"answers" db:"answers"`
+	ReviewedBy *uuid.UUID        `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt *time.Time        `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	CreatedAt  time.Time         `json:"created_at" db:"created_at"`
+}
+
+// JoinQuestionInput 设置入群问题时的单个问题
+type JoinQuestionInput struct {
+	Question string `json:"question" validate:"required,max=200"`
+	Required bool   `json:"required"`
+}
+
+// SetJoinQuestionsRequest 设置入群问题请求，整体替换原有问题，为空表示取消问题
+type SetJoinQuestionsRequest struct {
+	Questions []JoinQuestionInput `json:"questions" validate:"max=3"`
+}
+
+// JoinAnswerInput 提交入群申请时的单个回答
+type JoinAnswerInput struct {
+	QuestionID uuid.UUID `json:"question_id" validate:"required"`
+	Answer     string    `json:"answer" validate:"max=500"`
+}
+
+// CreateJoinRequestRequest 提交入群申请请求
+type CreateJoinRequestRequest struct {
+	Answers []JoinAnswerInput `json:"answers" validate:"max=3"`
+}
+
+// ValidateStruct 同一问题只能回答一次
+func (req CreateJoinRequestRequest) ValidateStruct() validation.Errors {
+	var errs validation.Errors
+	seen := make(map[uuid.UUID]bool, len(req.Answers))
+	for i, answer := range req.Answers {
+		if seen[answer.QuestionID] {
+			field := "answers[" + strconv.Itoa(i) + "].question_id"
+			errs = append(errs, validation.FieldError{
+				Field:   field,
+				Rule:    "unique",
+				Message: field + " is answered more than once",
+			})
+		}
+		seen[answer.QuestionID] = true
+	}
+	return errs
+}
+
+// MatchAnswers 按问题顺序整理回答，选答题的空回答不保存
+// 回答了不存在的问题或必答题未回答时返回错误
+func MatchAnswers(questions []*GroupJoinQuestion, inputs []JoinAnswerInput) (JoinAnswers, error) {
+	byQuestion := make(map[uuid.UUID]string, len(inputs))
+	for _, input := range inputs {
+		byQuestion[input.QuestionID] = strings.TrimSpace(input.Answer)
+	}
+
+	answers := make(JoinAnswers, 0, len(questions))
+	for _, question := range questions {
+		answer := byQuestion[question.ID]
+		delete(byQuestion, question.ID)
+		if answer == "" {
+			if question.Required {
+				return nil, fmt.Errorf("answer required for question: %s", question.Question)
+			}
+			continue
+		}
+		answers = append(answers, JoinAnswer{QuestionID: question.ID, Question: question.Question, Answer: answer})
+	}
+	if len(byQuestion) > 0 {
+		return nil, fmt.Errorf("answers contain unknown join question, questions may have changed")
+	}
+	return answers, nil
+}
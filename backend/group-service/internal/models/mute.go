@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 免打扰时长，off表示取消免打扰
+const (
+	MuteDurationOneHour    = "1h"
+	MuteDurationEightHours = "8h"
+	MuteDurationForever    = "forever"
+	MuteDurationOff        = "off"
+)
+
+// GroupMute 成员对群组的免打扰设置，MutedUntil为nil表示永久免打扰
+type GroupMute struct {
+	GroupID    uuid.UUID  `json:"group_id" db:"group_id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	Muted      bool       `json:"muted" db:"-"`
+	MutedUntil *time.Time `json:"muted_until,omitempty" db:"muted_until"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// SetMuteRequest 设置群组免打扰请求
+type SetMuteRequest struct {
+	Duration string `json:"duration" validate:"required,oneof=1h 8h forever off"`
+}
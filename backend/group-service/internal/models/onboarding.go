@@ -0,0 +1,46 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxWelcomeMessageLength 欢迎消息模板的最大长度
+const MaxWelcomeMessageLength = 1000
+
+// 欢迎消息模板支持的占位符
+const (
+	WelcomePlaceholderUsername  = "{{username}}"
+	WelcomePlaceholderGroupName = "{{group_name}}"
+)
+
+// GroupOnboarding 群组的新成员入群设置，由管理员配置
+// 新成员加入时以DefaultRole作为角色，开启欢迎消息时以群主身份在欢迎频道发送欢迎消息
+type GroupOnboarding struct {
+	GroupID        uuid.UUID `json:"group_id" db:"group_id"`
+	WelcomeEnabled bool      `json:"welcome_enabled" db:"welcome_enabled"`
+	WelcomeMessage string    `json:"welcome_message" db:"welcome_message"`
+	// WelcomeChannelID 发送欢迎消息的频道，为空时使用第一个默认频道
+	WelcomeChannelID *uuid.UUID      `json:"welcome_channel_id,omitempty" db:"welcome_channel_id"`
+	DefaultRole      GroupMemberRole `json:"default_role" db:"default_role"`
+	UpdatedBy        *uuid.UUID      `json:"updated_by,omitempty" db:"updated_by"`
+	UpdatedAt        *time.Time      `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// SetOnboardingRequest 设置入群设置请求
+type SetOnboardingRequest struct {
+	WelcomeEnabled   bool            `json:"welcome_enabled"`
+	WelcomeMessage   string          `json:"welcome_message" validate:"max=1000"`
+	WelcomeChannelID *uuid.UUID      `json:"welcome_channel_id,omitempty"`
+	DefaultRole      GroupMemberRole `json:"default_role" validate:"omitempty,oneof=admin member"` // 不填时为member
+}
+
+// RenderWelcomeMessage 用新成员的用户名和群组名称替换欢迎消息模板中的占位符
+func RenderWelcomeMessage(template, username, groupName string) string {
+	return strings.NewReplacer(
+		WelcomePlaceholderUsername, username,
+		WelcomePlaceholderGroupName, groupName,
+	).Replace(template)
+}
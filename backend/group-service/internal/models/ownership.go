@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PendingActionType 需要第二位群主确认的操作类型
+type PendingActionType string
+
+const (
+	PendingActionDeleteGroup         PendingActionType = "delete_group"
+	PendingActionTransferOwnership   PendingActionType = "transfer_ownership"
+	PendingActionDisableConfirmation PendingActionType = "disable_confirmation" // 关闭双人确认本身也需要确认，避免被绕过
+)
+
+// PendingActionStatus 待确认操作状态，过期由ExpiresAt判断
+type PendingActionStatus string
+
+const (
+	PendingActionPending   PendingActionStatus = "pending"
+	PendingActionConfirmed PendingActionStatus = "confirmed"
+	PendingActionCancelled PendingActionStatus = "cancelled"
+)
+
+// GroupPendingAction 等待另一位群主（群主或联合群主）确认的破坏性操作
+type GroupPendingAction struct {
+	ID           uuid.UUID           `json:"id" db:"id"`
+	GroupID      uuid.UUID           `json:"group_id" db:"group_id"`
+	Action       PendingActionType   `json:"action" db:"action"`
+	TargetUserID *uuid.UUID          `json:"target_user_id,omitempty" db:"target_user_id"` // 转让群主的目标用户
+	RequestedBy  uuid.UUID           `json:"requested_by" db:"requested_by"`
+	Status       PendingActionStatus `json:"status" db:"status"`
+	ResolvedBy   *uuid.UUID          `json:"resolved_by,omitempty" db:"resolved_by"`
+	CreatedAt    time.Time           `json:"created_at" db:"created_at"`
+	ExpiresAt    time.Time           `json:"expires_at" db:"expires_at"`
+	ResolvedAt   *time.Time          `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// IsExpired 检查待确认操作是否已过期
+func (a *GroupPendingAction) IsExpired(now time.Time) bool {
+	return a.Status == PendingActionPending && !now.Before(a.ExpiresAt)
+}
+
+// TransferOwnershipRequest 转让群主请求，原群主转为管理员
+type TransferOwnershipRequest struct {
+	NewOwnerID uuid.UUID `json:"new_owner_id" validate:"required"`
+}
+
+// OwnerConfirmationRequest 开启或关闭群主双人确认
+type OwnerConfirmationRequest struct {
+	Enabled bool `json:"enabled"`
+}
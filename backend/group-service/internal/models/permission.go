@@ -0,0 +1,39 @@
+package models
+
+import "github.com/google/uuid"
+
+// MemberPermission 成员操作权限，由成员角色决定
+// 消息服务等其他服务通过gRPC查询，保证消息级别的权限与群组角色一致
+type MemberPermission string
+
+const (
+	PermissionSendMessages MemberPermission = "send_messages" // 在群聊中发言
+	PermissionPinMessages  MemberPermission = "pin_messages"  // 置顶/取消置顶群聊消息
+)
+
+// MemberPermissions 用户在群组中的成员身份和权限
+type MemberPermissions struct {
+	GroupID     uuid.UUID          `json:"group_id"`
+	UserID      uuid.UUID          `json:"user_id"`
+	IsMember    bool               `json:"is_member"`
+	Role        GroupMemberRole    `json:"role,omitempty"`
+	Status      GroupMemberStatus  `json:"status,omitempty"`
+	Permissions []MemberPermission `json:"permissions"`
+}
+
+// Permissions 角色拥有的权限，置顶消息需要管理员权限
+func (r GroupMemberRole) Permissions() []MemberPermission {
+	permissions := []MemberPermission{PermissionSendMessages}
+	if r.IsAdmin() {
+		permissions = append(permissions, PermissionPinMessages)
+	}
+	return permissions
+}
+
+// PermissionsOf 成员当前拥有的权限，非活跃成员（禁言、封禁、待审核）没有任何权限
+func PermissionsOf(member *GroupMember) []MemberPermission {
+	if member == nil || member.Status != StatusActive {
+		return []MemberPermission{}
+	}
+	return member.Role.Permissions()
+}
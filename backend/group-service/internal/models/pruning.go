@@ -0,0 +1,111 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 不活跃天数限制，未设置时默认30天
+const (
+	DefaultPruneInactiveDays = 30
+	MinPruneInactiveDays     = 7
+	MaxPruneInactiveDays     = 365
+)
+
+// PruneAction 发现不活跃成员后的处理方式
+type PruneAction string
+
+const (
+	PruneActionFlag   PruneAction = "flag"   // 生成清理提议，管理员确认后移除
+	PruneActionRemove PruneAction = "remove" // 直接移除，不需要确认
+)
+
+// GroupPrunePolicy 群组的不活跃成员清理策略，需要管理员主动开启
+type GroupPrunePolicy struct {
+	GroupID      uuid.UUID   `json:"group_id" db:"group_id"`
+	Enabled      bool        `json:"enabled" db:"enabled"`
+	InactiveDays int         `json:"inactive_days" db:"inactive_days"`
+	Action       PruneAction `json:"action" db:"action"`
+	// EnabledAt 最近一次开启的时间，不活跃天数至少从此时开始计算，避免开启前没有活跃记录的成员被立即清理
+	EnabledAt *time.Time `json:"enabled_at,omitempty" db:"enabled_at"`
+	UpdatedBy uuid.UUID  `json:"updated_by" db:"updated_by"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// PruneCandidate 不活跃的成员，LastActiveAt为空表示加入后没有发过消息
+type PruneCandidate struct {
+	UserID       uuid.UUID         `json:"user_id" db:"user_id"`
+	Role         GroupMemberRole   `json:"role" db:"role"`
+	Status       GroupMemberStatus `json:"status" db:"status"`
+	JoinedAt     time.Time         `json:"joined_at" db:"joined_at"`
+	LastActiveAt *time.Time        `json:"last_active_at,omitempty" db:"last_active_at"`
+}
+
+// PruneCandidates 以JSONB保存的清理名单
+type PruneCandidates []PruneCandidate
+
+// Value 实现driver.Valuer
+func (c PruneCandidates) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan 实现sql.Scanner
+func (c *PruneCandidates) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*c = PruneCandidates{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into PruneCandidates", src)
+	}
+	return json.Unmarshal(data, c)
+}
+
+// PruneProposalStatus 清理提议状态
+type PruneProposalStatus string
+
+const (
+	PruneProposalPending  PruneProposalStatus = "pending"
+	PruneProposalApproved PruneProposalStatus = "approved"
+	PruneProposalRejected PruneProposalStatus = "rejected"
+)
+
+// GroupPruneProposal 定时任务按策略生成的清理提议，管理员确认后移除名单中仍不活跃的成员
+type GroupPruneProposal struct {
+	ID           uuid.UUID           `json:"id" db:"id"`
+	GroupID      uuid.UUID           `json:"group_id" db:"group_id"`
+	InactiveDays int                 `json:"inactive_days" db:"inactive_days"`
+	Candidates   PruneCandidates     `json:"candidates" db:"candidates"`
+	Status       PruneProposalStatus `json:"status" db:"status"`
+	RemovedCount int                 `json:"removed_count" db:"removed_count"`
+	ResolvedBy   *uuid.UUID          `json:"resolved_by,omitempty" db:"resolved_by"`
+	CreatedAt    time.Time           `json:"created_at" db:"created_at"`
+	ResolvedAt   *time.Time          `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// PrunePreview 清理预览（dry-run），只列出名单，不做任何修改
+type PrunePreview struct {
+	GroupID      uuid.UUID        `json:"group_id"`
+	InactiveDays int              `json:"inactive_days"`
+	Cutoff       time.Time        `json:"cutoff"` // 此时间之后没有活跃记录的成员视为不活跃
+	Candidates   []PruneCandidate `json:"candidates"`
+}
+
+// SetPrunePolicyRequest 设置清理策略请求
+type SetPrunePolicyRequest struct {
+	Enabled      bool        `json:"enabled"`
+	InactiveDays int         `json:"inactive_days" validate:"omitempty,min=7,max=365"` // 不填时默认30天
+	Action       PruneAction `json:"action" validate:"omitempty,oneof=flag remove"`    // 不填时为flag
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 置顶资源限制
+const (
+	MaxGroupResources            = 20
+	MaxResourceTitleLength       = 100
+	MaxResourceURLLength         = 500
+	MaxResourceDescriptionLength = 200
+)
+
+// GroupResource 群组置顶的链接/资源，按Position升序展示
+type GroupResource struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	GroupID     uuid.UUID `json:"group_id" db:"group_id"`
+	Title       string    `json:"title" db:"title"`
+	URL         string    `json:"url" db:"url"`
+	Description string    `json:"description" db:"description"`
+	Position    int       `json:"position" db:"position"`
+	CreatedBy   uuid.UUID `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateGroupResourceRequest 创建置顶资源请求，未指定Position时追加到末尾
+type CreateGroupResourceRequest struct {
+	Title       string `json:"title" validate:"required,min=1,max=100"`
+	URL         string `json:"url" validate:"required,url,max=500"`
+	Description string `json:"description" validate:"max=200"`
+	Position    *int   `json:"position,omitempty" validate:"omitempty,min=0"`
+}
+
+// UpdateGroupResourceRequest 更新置顶资源请求
+type UpdateGroupResourceRequest struct {
+	Title       *string `json:"title,omitempty" validate:"omitempty,min=1,max=100"`
+	URL         *string `json:"url,omitempty" validate:"omitempty,url,max=500"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=200"`
+	Position    *int    `json:"position,omitempty" validate:"omitempty,min=0"`
+}
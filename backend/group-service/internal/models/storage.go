@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 群组文件存储档位，未设置档位的群组为free
+const (
+	StorageTierFree     = "free"
+	StorageTierStandard = "standard"
+	StorageTierPremium  = "premium"
+)
+
+// StorageWarningPercent 用量达到配额的该百分比时提示即将用满
+const StorageWarningPercent = 90
+
+// GroupMediaFile 附加到群聊会话的文件，同一文件在一个群组中只计一次
+type GroupMediaFile struct {
+	GroupID    uuid.UUID `json:"group_id" db:"group_id"`
+	FileID     string    `json:"file_id" db:"file_id"`
+	OwnerID    uuid.UUID `json:"owner_id" db:"owner_id"`
+	Size       int64     `json:"size" db:"size"`
+	AttachedAt time.Time `json:"attached_at" db:"attached_at"`
+}
+
+// GroupStorageUsage 群组文件存储用量
+type GroupStorageUsage struct {
+	Tier         string  `json:"tier"`
+	UsedBytes    int64   `json:"used_bytes"`
+	QuotaBytes   int64   `json:"quota_bytes"`
+	FileCount    int     `json:"file_count"`
+	UsagePercent float64 `json:"usage_percent"`
+	// Warning 用量达到配额的90%时为true
+	Warning bool `json:"warning"`
+}
+
+// GroupStats 群组统计信息
+type GroupStats struct {
+	GroupID       uuid.UUID          `json:"group_id"`
+	TotalMembers  int                `json:"total_members"`
+	ActiveMembers int                `json:"active_members"`
+	AdminCount    int                `json:"admin_count"`
+	OwnerID       uuid.UUID          `json:"owner_id"`
+	Storage       *GroupStorageUsage `json:"storage"`
+}
+
+// StorageCheckResult 附加文件前的配额检查结果
+type StorageCheckResult struct {
+	Allowed bool               `json:"allowed"`
+	Usage   *GroupStorageUsage `json:"usage"`
+}
+
+// SetStorageTierRequest 设置群组存储档位请求
+type SetStorageTierRequest struct {
+	Tier string `json:"tier" validate:"required,oneof=free standard premium"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// WebhookEvent 成员变更事件类型
+type WebhookEvent string
+
+const (
+	WebhookEventMemberAdded       WebhookEvent = "member.added"
+	WebhookEventMemberRemoved     WebhookEvent = "member.removed"
+	WebhookEventMemberRoleChanged WebhookEvent = "member.role_changed"
+)
+
+// AllWebhookEvents 所有支持的事件类型
+var AllWebhookEvents = []WebhookEvent{
+	WebhookEventMemberAdded,
+	WebhookEventMemberRemoved,
+	WebhookEventMemberRoleChanged,
+}
+
+// GroupWebhook 群组成员同步Webhook
+type GroupWebhook struct {
+	ID        uuid.UUID      `json:"id" db:"id"`
+	GroupID   uuid.UUID      `json:"group_id" db:"group_id"`
+	URL       string         `json:"url" db:"url"`
+	Secret    string         `json:"-" db:"secret"`
+	Events    pq.StringArray `json:"events" db:"events"`
+	IsActive  bool           `json:"is_active" db:"is_active"`
+	CreatedBy uuid.UUID      `json:"created_by" db:"created_by"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// Subscribes 检查Webhook是否订阅了指定事件
+func (w *GroupWebhook) Subscribes(event WebhookEvent) bool {
+	if !w.IsActive {
+		return false
+	}
+	for _, e := range w.Events {
+		if WebhookEvent(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateWebhookRequest 创建Webhook请求
+type CreateWebhookRequest struct {
+	URL    string         `json:"url" validate:"required,url"`
+	Secret string         `json:"secret" validate:"omitempty,min=16"`
+	Events []WebhookEvent `json:"events"`
+}
+
+// CreateWebhookResponse 创建Webhook响应，签名密钥只在创建时返回一次
+type CreateWebhookResponse struct {
+	*GroupWebhook
+	Secret string `json:"secret"`
+}
+
+// MembershipEvent 推送给外部系统的成员变更事件
+type MembershipEvent struct {
+	ID         uuid.UUID       `json:"id"`
+	Event      WebhookEvent    `json:"event"`
+	GroupID    uuid.UUID       `json:"group_id"`
+	UserID     uuid.UUID       `json:"user_id"`
+	Role       GroupMemberRole `json:"role,omitempty"`
+	PrevRole   GroupMemberRole `json:"previous_role,omitempty"`
+	ActorID    uuid.UUID       `json:"actor_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
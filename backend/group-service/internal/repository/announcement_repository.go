@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+)
+
+// CreateAnnouncement 创建群公告
+func (r *PostgreSQLGroupRepository) CreateAnnouncement(ctx context.Context, announcement *models.GroupAnnouncement) error {
+	query := `
+		INSERT INTO group_announcements (id, group_id, title, content, pinned, pinned_by, pinned_at, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		announcement.ID, announcement.GroupID, announcement.Title, announcement.Content, announcement.Pinned,
+		announcement.PinnedBy, announcement.PinnedAt, announcement.CreatedBy, announcement.CreatedAt, announcement.UpdatedAt)
+	return err
+}
+
+// GetAnnouncement 根据ID获取群公告
+func (r *PostgreSQLGroupRepository) GetAnnouncement(ctx context.Context, announcementID uuid.UUID) (*models.GroupAnnouncement, error) {
+	var announcement models.GroupAnnouncement
+	query := `SELECT * FROM group_announcements WHERE id = $1`
+	err := r.db.GetContext(ctx, &announcement, query, announcementID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &announcement, err
+}
+
+// GetGroupAnnouncements 分页获取群公告，置顶公告在前
+func (r *PostgreSQLGroupRepository) GetGroupAnnouncements(ctx context.Context, groupID uuid.UUID, limit, offset int) ([]*models.GroupAnnouncement, error) {
+	var announcements []*models.GroupAnnouncement
+	query := `
+		SELECT * FROM group_announcements
+		WHERE group_id = $1
+		ORDER BY pinned DESC, pinned_at DESC NULLS LAST, created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	err := r.db.SelectContext(ctx, &announcements, query, groupID, limit, offset)
+	return announcements, err
+}
+
+// CountPinnedAnnouncements 统计群组置顶公告数量
+func (r *PostgreSQLGroupRepository) CountPinnedAnnouncements(ctx context.Context, groupID uuid.UUID) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM group_announcements WHERE group_id = $1 AND pinned = true`
+	err := r.db.GetContext(ctx, &count, query, groupID)
+	return count, err
+}
+
+// SetAnnouncementPinned 置顶或取消置顶群公告，取消时清空置顶人和置顶时间
+func (r *PostgreSQLGroupRepository) SetAnnouncementPinned(ctx context.Context, announcementID uuid.UUID, pinned bool, pinnedBy *uuid.UUID, pinnedAt *time.Time) error {
+	query := `UPDATE group_announcements SET pinned = $1, pinned_by = $2, pinned_at = $3 WHERE id = $4`
+	result, err := r.db.ExecContext(ctx, query, pinned, pinnedBy, pinnedAt, announcementID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("announcement not found")
+	}
+	return nil
+}
+
+// DeleteAnnouncement 删除群公告
+func (r *PostgreSQLGroupRepository) DeleteAnnouncement(ctx context.Context, announcementID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM group_announcements WHERE id = $1", announcementID)
+	return err
+}
+
+// CreateAnnouncement 创建群公告
+func (r *MemoryGroupRepository) CreateAnnouncement(ctx context.Context, announcement *models.GroupAnnouncement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.announcements[announcement.ID] = announcement
+	return nil
+}
+
+// GetAnnouncement 根据ID获取群公告
+func (r *MemoryGroupRepository) GetAnnouncement(ctx context.Context, announcementID uuid.UUID) (*models.GroupAnnouncement, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	announcement, exists := r.announcements[announcementID]
+	if !exists {
+		return nil, nil
+	}
+	return announcement, nil
+}
+
+// GetGroupAnnouncements 分页获取群公告，置顶公告在前
+func (r *MemoryGroupRepository) GetGroupAnnouncements(ctx context.Context, groupID uuid.UUID, limit, offset int) ([]*models.GroupAnnouncement, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var announcements []*models.GroupAnnouncement
+	for _, announcement := range r.announcements {
+		if announcement.GroupID == groupID {
+			announcements = append(announcements, announcement)
+		}
+	}
+	sort.Slice(announcements, func(i, j int) bool {
+		a, b := announcements[i], announcements[j]
+		if a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		if a.Pinned && !a.PinnedAt.Equal(*b.PinnedAt) {
+			return a.PinnedAt.After(*b.PinnedAt)
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	})
+
+	if offset >= len(announcements) {
+		return []*models.GroupAnnouncement{}, nil
+	}
+	end := offset + limit
+	if end > len(announcements) {
+		end = len(announcements)
+	}
+	return announcements[offset:end], nil
+}
+
+// CountPinnedAnnouncements 统计群组置顶公告数量
+func (r *MemoryGroupRepository) CountPinnedAnnouncements(ctx context.Context, groupID uuid.UUID) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	count := 0
+	for _, announcement := range r.announcements {
+		if announcement.GroupID == groupID && announcement.Pinned {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// SetAnnouncementPinned 置顶或取消置顶群公告
+func (r *MemoryGroupRepository) SetAnnouncementPinned(ctx context.Context, announcementID uuid.UUID, pinned bool, pinnedBy *uuid.UUID, pinnedAt *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	announcement, exists := r.announcements[announcementID]
+	if !exists {
+		return fmt.Errorf("announcement not found")
+	}
+	announcement.Pinned = pinned
+	announcement.PinnedBy = pinnedBy
+	announcement.PinnedAt = pinnedAt
+	announcement.UpdatedAt = time.Now()
+	return nil
+}
+
+// DeleteAnnouncement 删除群公告
+func (r *MemoryGroupRepository) DeleteAnnouncement(ctx context.Context, announcementID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.announcements, announcementID)
+	return nil
+}
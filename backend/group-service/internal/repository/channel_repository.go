@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+)
+
+// CreateChannel 创建频道
+func (r *PostgreSQLGroupRepository) CreateChannel(ctx context.Context, channel *models.GroupChannel) error {
+	query := `
+		INSERT INTO group_channels (id, group_id, name, topic, conversation_id, permission, is_default, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		channel.ID, channel.GroupID, channel.Name, channel.Topic, channel.ConversationID,
+		channel.Permission, channel.IsDefault, channel.CreatedBy,
+		channel.CreatedAt, channel.UpdatedAt)
+	return err
+}
+
+// GetChannel 根据ID获取频道
+func (r *PostgreSQLGroupRepository) GetChannel(ctx context.Context, channelID uuid.UUID) (*models.GroupChannel, error) {
+	var channel models.GroupChannel
+	query := `SELECT * FROM group_channels WHERE id = $1`
+	err := r.db.GetContext(ctx, &channel, query, channelID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &channel, err
+}
+
+// GetChannelByConversation 根据消息服务中的会话ID获取频道
+func (r *PostgreSQLGroupRepository) GetChannelByConversation(ctx context.Context, conversationID string) (*models.GroupChannel, error) {
+	var channel models.GroupChannel
+	query := `SELECT * FROM group_channels WHERE conversation_id = $1 LIMIT 1`
+	err := r.db.GetContext(ctx, &channel, query, conversationID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &channel, err
+}
+
+// GetGroupChannels 获取群组所有频道
+func (r *PostgreSQLGroupRepository) GetGroupChannels(ctx context.Context, groupID uuid.UUID) ([]*models.GroupChannel, error) {
+	var channels []*models.GroupChannel
+	query := `SELECT * FROM group_channels WHERE group_id = $1 ORDER BY is_default DESC, created_at`
+	err := r.db.SelectContext(ctx, &channels, query, groupID)
+	return channels, err
+}
+
+// UpdateChannel 更新频道
+func (r *PostgreSQLGroupRepository) UpdateChannel(ctx context.Context, channelID uuid.UUID, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	setClause := ""
+	args := []interface{}{}
+	argIndex := 1
+
+	for field, value := range updates {
+		if setClause != "" {
+			setClause += ", "
+		}
+		setClause += fmt.Sprintf("%s = $%d", field, argIndex)
+		args = append(args, value)
+		argIndex++
+	}
+
+	// 添加updated_at字段
+	setClause += fmt.Sprintf(", updated_at = $%d", argIndex)
+	args = append(args, time.Now())
+	argIndex++
+
+	// 添加WHERE条件
+	args = append(args, channelID)
+
+	query := fmt.Sprintf("UPDATE group_channels SET %s WHERE id = $%d", setClause, argIndex)
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// DeleteChannel 删除频道
+func (r *PostgreSQLGroupRepository) DeleteChannel(ctx context.Context, channelID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM group_channels WHERE id = $1", channelID)
+	return err
+}
+
+// AddChannelMember 添加频道成员
+func (r *PostgreSQLGroupRepository) AddChannelMember(ctx context.Context, member *models.ChannelMember) error {
+	query := `
+		INSERT INTO group_channel_members (channel_id, user_id, joined_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (channel_id, user_id) DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query, member.ChannelID, member.UserID, member.JoinedAt)
+	return err
+}
+
+// RemoveChannelMember 移除频道成员
+func (r *PostgreSQLGroupRepository) RemoveChannelMember(ctx context.Context, channelID, userID uuid.UUID) error {
+	query := `DELETE FROM group_channel_members WHERE channel_id = $1 AND user_id = $2`
+	_, err := r.db.ExecContext(ctx, query, channelID, userID)
+	return err
+}
+
+// IsChannelMember 检查用户是否为频道成员
+func (r *PostgreSQLGroupRepository) IsChannelMember(ctx context.Context, channelID, userID uuid.UUID) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM group_channel_members WHERE channel_id = $1 AND user_id = $2`
+	err := r.db.GetContext(ctx, &count, query, channelID, userID)
+	return count > 0, err
+}
+
+// GetUserChannels 获取用户在群组中加入的频道
+func (r *PostgreSQLGroupRepository) GetUserChannels(ctx context.Context, groupID, userID uuid.UUID) ([]*models.GroupChannel, error) {
+	var channels []*models.GroupChannel
+	query := `
+		SELECT c.*
+		FROM group_channels c
+		JOIN group_channel_members cm ON c.id = cm.channel_id
+		WHERE c.group_id = $1 AND cm.user_id = $2
+		ORDER BY c.is_default DESC, c.created_at
+	`
+	err := r.db.SelectContext(ctx, &channels, query, groupID, userID)
+	return channels, err
+}
+
+// RemoveUserFromGroupChannels 将用户从群组的所有频道中移除
+func (r *PostgreSQLGroupRepository) RemoveUserFromGroupChannels(ctx context.Context, groupID, userID uuid.UUID) error {
+	query := `
+		DELETE FROM group_channel_members
+		WHERE user_id = $1 AND channel_id IN (SELECT id FROM group_channels WHERE group_id = $2)
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, groupID)
+	return err
+}
+
+// CreateChannel 创建频道
+func (r *MemoryGroupRepository) CreateChannel(ctx context.Context, channel *models.GroupChannel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.channels[channel.ID] = channel
+	r.channelMembers[channel.ID] = make(map[uuid.UUID]*models.ChannelMember)
+	return nil
+}
+
+// GetChannel 根据ID获取频道
+func (r *MemoryGroupRepository) GetChannel(ctx context.Context, channelID uuid.UUID) (*models.GroupChannel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	channel, exists := r.channels[channelID]
+	if !exists {
+		return nil, nil
+	}
+	return channel, nil
+}
+
+// GetChannelByConversation 根据消息服务中的会话ID获取频道
+func (r *MemoryGroupRepository) GetChannelByConversation(ctx context.Context, conversationID string) (*models.GroupChannel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, channel := range r.channels {
+		if conversationID != "" && channel.ConversationID == conversationID {
+			return channel, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetGroupChannels 获取群组所有频道
+func (r *MemoryGroupRepository) GetGroupChannels(ctx context.Context, groupID uuid.UUID) ([]*models.GroupChannel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var channels []*models.GroupChannel
+	for _, channel := range r.channels {
+		if channel.GroupID == groupID {
+			channels = append(channels, channel)
+		}
+	}
+	sortChannels(channels)
+	return channels, nil
+}
+
+// UpdateChannel 更新频道
+func (r *MemoryGroupRepository) UpdateChannel(ctx context.Context, channelID uuid.UUID, updates map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	channel, exists := r.channels[channelID]
+	if !exists {
+		return fmt.Errorf("channel not found")
+	}
+
+	if name, ok := updates["name"]; ok {
+		channel.Name = name.(string)
+	}
+	if topic, ok := updates["topic"]; ok {
+		channel.Topic = topic.(string)
+	}
+	if permission, ok := updates["permission"]; ok {
+		channel.Permission = permission.(models.ChannelPermission)
+	}
+	if isDefault, ok := updates["is_default"]; ok {
+		channel.IsDefault = isDefault.(bool)
+	}
+	channel.UpdatedAt = time.Now()
+	return nil
+}
+
+// DeleteChannel 删除频道
+func (r *MemoryGroupRepository) DeleteChannel(ctx context.Context, channelID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.channels, channelID)
+	delete(r.channelMembers, channelID)
+	return nil
+}
+
+// AddChannelMember 添加频道成员
+func (r *MemoryGroupRepository) AddChannelMember(ctx context.Context, member *models.ChannelMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.channelMembers[member.ChannelID] == nil {
+		r.channelMembers[member.ChannelID] = make(map[uuid.UUID]*models.ChannelMember)
+	}
+	if _, exists := r.channelMembers[member.ChannelID][member.UserID]; !exists {
+		r.channelMembers[member.ChannelID][member.UserID] = member
+	}
+	return nil
+}
+
+// RemoveChannelMember 移除频道成员
+func (r *MemoryGroupRepository) RemoveChannelMember(ctx context.Context, channelID, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if members, exists := r.channelMembers[channelID]; exists {
+		delete(members, userID)
+	}
+	return nil
+}
+
+// IsChannelMember 检查用户是否为频道成员
+func (r *MemoryGroupRepository) IsChannelMember(ctx context.Context, channelID, userID uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if members, exists := r.channelMembers[channelID]; exists {
+		_, exists = members[userID]
+		return exists, nil
+	}
+	return false, nil
+}
+
+// GetUserChannels 获取用户在群组中加入的频道
+func (r *MemoryGroupRepository) GetUserChannels(ctx context.Context, groupID, userID uuid.UUID) ([]*models.GroupChannel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var channels []*models.GroupChannel
+	for id, channel := range r.channels {
+		if channel.GroupID != groupID {
+			continue
+		}
+		if _, joined := r.channelMembers[id][userID]; joined {
+			channels = append(channels, channel)
+		}
+	}
+	sortChannels(channels)
+	return channels, nil
+}
+
+// RemoveUserFromGroupChannels 将用户从群组的所有频道中移除
+func (r *MemoryGroupRepository) RemoveUserFromGroupChannels(ctx context.Context, groupID, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, channel := range r.channels {
+		if channel.GroupID == groupID {
+			delete(r.channelMembers[id], userID)
+		}
+	}
+	return nil
+}
+
+// sortChannels 默认频道在前，其余按创建时间排序
+func sortChannels(channels []*models.GroupChannel) {
+	sort.Slice(channels, func(i, j int) bool {
+		if channels[i].IsDefault != channels[j].IsDefault {
+			return channels[i].IsDefault
+		}
+		return channels[i].CreatedAt.Before(channels[j].CreatedAt)
+	})
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+)
+
+// SoftDeleteGroup 解散群组：只记录解散时间和操作人，成员、频道等数据保留到永久删除
+func (r *PostgreSQLGroupRepository) SoftDeleteGroup(ctx context.Context, groupID, deletedBy uuid.UUID) error {
+	query := `UPDATE groups SET deleted_at = $1, deleted_by = $2 WHERE id = $3 AND deleted_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, time.Now(), deletedBy, groupID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return fmt.Errorf("group not found")
+	}
+	return nil
+}
+
+// RestoreGroup 恢复已解散的群组
+func (r *PostgreSQLGroupRepository) RestoreGroup(ctx context.Context, groupID uuid.UUID) error {
+	query := `UPDATE groups SET deleted_at = NULL, deleted_by = NULL, updated_at = $1 WHERE id = $2 AND deleted_at IS NOT NULL`
+	result, err := r.db.ExecContext(ctx, query, time.Now(), groupID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return fmt.Errorf("deleted group not found")
+	}
+	return nil
+}
+
+// GetDeletedGroup 获取已解散的群组，群组不存在或未解散时返回nil
+func (r *PostgreSQLGroupRepository) GetDeletedGroup(ctx context.Context, groupID uuid.UUID) (*models.Group, error) {
+	var group models.Group
+	query := `SELECT * FROM groups WHERE id = $1 AND deleted_at IS NOT NULL`
+	err := r.db.GetContext(ctx, &group, query, groupID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &group, err
+}
+
+// GetGroupsDeletedBefore 获取在cutoff之前解散的群组ID，按解散时间排序
+func (r *PostgreSQLGroupRepository) GetGroupsDeletedBefore(ctx context.Context, cutoff time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	query := `SELECT id FROM groups WHERE deleted_at IS NOT NULL AND deleted_at < $1 ORDER BY deleted_at LIMIT $2`
+	err := r.db.SelectContext(ctx, &ids, query, cutoff, limit)
+	return ids, err
+}
+
+// SoftDeleteGroup 解散群组
+func (r *MemoryGroupRepository) SoftDeleteGroup(ctx context.Context, groupID, deletedBy uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	group, exists := r.groups[groupID]
+	if !exists || group.DeletedAt != nil {
+		return fmt.Errorf("group not found")
+	}
+	now := time.Now()
+	group.DeletedAt = &now
+	group.DeletedBy = &deletedBy
+	return nil
+}
+
+// RestoreGroup 恢复已解散的群组
+func (r *MemoryGroupRepository) RestoreGroup(ctx context.Context, groupID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	group, exists := r.groups[groupID]
+	if !exists || group.DeletedAt == nil {
+		return fmt.Errorf("deleted group not found")
+	}
+	group.DeletedAt = nil
+	group.DeletedBy = nil
+	group.UpdatedAt = time.Now()
+	return nil
+}
+
+// GetDeletedGroup 获取已解散的群组
+func (r *MemoryGroupRepository) GetDeletedGroup(ctx context.Context, groupID uuid.UUID) (*models.Group, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	group, exists := r.groups[groupID]
+	if !exists || group.DeletedAt == nil {
+		return nil, nil
+	}
+	return group, nil
+}
+
+// GetGroupsDeletedBefore 获取在cutoff之前解散的群组ID
+func (r *MemoryGroupRepository) GetGroupsDeletedBefore(ctx context.Context, cutoff time.Time, limit int) ([]uuid.UUID, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var groups []*models.Group
+	for _, group := range r.groups {
+		if group.DeletedAt != nil && group.DeletedAt.Before(cutoff) {
+			groups = append(groups, group)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].DeletedAt.Before(*groups[j].DeletedAt) })
+	if len(groups) > limit {
+		groups = groups[:limit]
+	}
+	ids := make([]uuid.UUID, 0, len(groups))
+	for _, group := range groups {
+		ids = append(ids, group.ID)
+	}
+	return ids, nil
+}
+
+// isDeleted 判断群组是否已解散，调用方需持有锁
+func (r *MemoryGroupRepository) isDeleted(groupID uuid.UUID) bool {
+	group, exists := r.groups[groupID]
+	return exists && group.DeletedAt != nil
+}
@@ -19,6 +19,10 @@ type GroupRepository interface {
 	GetGroupByID(ctx context.Context, groupID uuid.UUID) (*models.Group, error)
 	UpdateGroup(ctx context.Context, groupID uuid.UUID, updates map[string]interface{}) error
 	DeleteGroup(ctx context.Context, groupID uuid.UUID) error
+	SoftDeleteGroup(ctx context.Context, groupID, deletedBy uuid.UUID) error
+	RestoreGroup(ctx context.Context, groupID uuid.UUID) error
+	GetDeletedGroup(ctx context.Context, groupID uuid.UUID) (*models.Group, error)
+	GetGroupsDeletedBefore(ctx context.Context, cutoff time.Time, limit int) ([]uuid.UUID, error)
 	GetGroupsByOwner(ctx context.Context, ownerID uuid.UUID) ([]*models.Group, error)
 	SearchGroups(ctx context.Context, query string, limit, offset int) ([]*models.GroupWithMemberCount, error)
 
@@ -31,6 +35,9 @@ type GroupRepository interface {
 	GetUserGroups(ctx context.Context, userID uuid.UUID) ([]*models.GroupWithMemberCount, error)
 	IsMember(ctx context.Context, groupID, userID uuid.UUID) (bool, error)
 	GetMemberCount(ctx context.Context, groupID uuid.UUID) (int, error)
+	ReconcileMemberCounts(ctx context.Context) (int, error)
+	CountMembersByRole(ctx context.Context, groupID uuid.UUID, role models.GroupMemberRole) (int, error)
+	TransferOwnership(ctx context.Context, groupID, fromUserID, toUserID uuid.UUID) error
 
 	// 邀请管理
 	CreateInvitation(ctx context.Context, invitation *models.GroupInvitation) error
@@ -38,6 +45,88 @@ type GroupRepository interface {
 	UpdateInvitationStatus(ctx context.Context, invitationID uuid.UUID, status models.InvitationStatus) error
 	GetPendingInvitations(ctx context.Context, userID uuid.UUID) ([]*models.GroupInvitation, error)
 	GetGroupInvitations(ctx context.Context, groupID uuid.UUID) ([]*models.GroupInvitation, error)
+
+	// 频道管理
+	CreateChannel(ctx context.Context, channel *models.GroupChannel) error
+	GetChannel(ctx context.Context, channelID uuid.UUID) (*models.GroupChannel, error)
+	GetChannelByConversation(ctx context.Context, conversationID string) (*models.GroupChannel, error)
+	GetGroupChannels(ctx context.Context, groupID uuid.UUID) ([]*models.GroupChannel, error)
+	UpdateChannel(ctx context.Context, channelID uuid.UUID, updates map[string]interface{}) error
+	DeleteChannel(ctx context.Context, channelID uuid.UUID) error
+	AddChannelMember(ctx context.Context, member *models.ChannelMember) error
+	RemoveChannelMember(ctx context.Context, channelID, userID uuid.UUID) error
+	IsChannelMember(ctx context.Context, channelID, userID uuid.UUID) (bool, error)
+	GetUserChannels(ctx context.Context, groupID, userID uuid.UUID) ([]*models.GroupChannel, error)
+	RemoveUserFromGroupChannels(ctx context.Context, groupID, userID uuid.UUID) error
+
+	// Webhook管理
+	CreateWebhook(ctx context.Context, webhook *models.GroupWebhook) error
+	GetWebhook(ctx context.Context, webhookID uuid.UUID) (*models.GroupWebhook, error)
+	GetGroupWebhooks(ctx context.Context, groupID uuid.UUID) ([]*models.GroupWebhook, error)
+	DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error
+
+	// 置顶资源管理
+	CreateResource(ctx context.Context, resource *models.GroupResource) error
+	GetResource(ctx context.Context, resourceID uuid.UUID) (*models.GroupResource, error)
+	GetGroupResources(ctx context.Context, groupID uuid.UUID) ([]*models.GroupResource, error)
+	UpdateResource(ctx context.Context, resourceID uuid.UUID, updates map[string]interface{}) error
+	DeleteResource(ctx context.Context, resourceID uuid.UUID) error
+
+	// 群公告管理
+	CreateAnnouncement(ctx context.Context, announcement *models.GroupAnnouncement) error
+	GetAnnouncement(ctx context.Context, announcementID uuid.UUID) (*models.GroupAnnouncement, error)
+	GetGroupAnnouncements(ctx context.Context, groupID uuid.UUID, limit, offset int) ([]*models.GroupAnnouncement, error)
+	CountPinnedAnnouncements(ctx context.Context, groupID uuid.UUID) (int, error)
+	SetAnnouncementPinned(ctx context.Context, announcementID uuid.UUID, pinned bool, pinnedBy *uuid.UUID, pinnedAt *time.Time) error
+	DeleteAnnouncement(ctx context.Context, announcementID uuid.UUID) error
+
+	// 待确认的群主操作
+	CreatePendingAction(ctx context.Context, action *models.GroupPendingAction) error
+	GetPendingAction(ctx context.Context, actionID uuid.UUID) (*models.GroupPendingAction, error)
+	GetGroupPendingActions(ctx context.Context, groupID uuid.UUID) ([]*models.GroupPendingAction, error)
+	ResolvePendingAction(ctx context.Context, actionID uuid.UUID, status models.PendingActionStatus, resolvedBy uuid.UUID) (bool, error)
+
+	// 入群问题与入群申请
+	GetJoinQuestions(ctx context.Context, groupID uuid.UUID) ([]*models.GroupJoinQuestion, error)
+	ReplaceJoinQuestions(ctx context.Context, groupID uuid.UUID, questions []*models.GroupJoinQuestion) error
+	CreateJoinRequest(ctx context.Context, request *models.GroupJoinRequest) error
+	GetJoinRequest(ctx context.Context, requestID uuid.UUID) (*models.GroupJoinRequest, error)
+	GetPendingJoinRequest(ctx context.Context, groupID, userID uuid.UUID) (*models.GroupJoinRequest, error)
+	GetGroupJoinRequests(ctx context.Context, groupID uuid.UUID, status models.JoinRequestStatus, limit, offset int) ([]*models.GroupJoinRequest, error)
+	ResolveJoinRequest(ctx context.Context, requestID uuid.UUID, status models.JoinRequestStatus, reviewedBy uuid.UUID) (bool, error)
+
+	// 成员活跃度与不活跃成员清理
+	RecordMemberActivity(ctx context.Context, groupID, userID uuid.UUID, at time.Time) error
+	GetInactiveMembers(ctx context.Context, groupID uuid.UUID, cutoff time.Time) ([]*models.PruneCandidate, error)
+	GetPrunePolicy(ctx context.Context, groupID uuid.UUID) (*models.GroupPrunePolicy, error)
+	UpsertPrunePolicy(ctx context.Context, policy *models.GroupPrunePolicy) error
+	GetEnabledPrunePolicies(ctx context.Context) ([]*models.GroupPrunePolicy, error)
+	CreatePruneProposal(ctx context.Context, proposal *models.GroupPruneProposal) error
+	GetPruneProposal(ctx context.Context, proposalID uuid.UUID) (*models.GroupPruneProposal, error)
+	GetGroupPruneProposals(ctx context.Context, groupID uuid.UUID, status models.PruneProposalStatus, limit, offset int) ([]*models.GroupPruneProposal, error)
+	ResolvePruneProposal(ctx context.Context, proposalID uuid.UUID, status models.PruneProposalStatus, resolvedBy uuid.UUID, removedCount int) (bool, error)
+
+	// 成员免打扰设置
+	SetMute(ctx context.Context, mute *models.GroupMute) error
+	DeleteMute(ctx context.Context, groupID, userID uuid.UUID) error
+	GetActiveMutes(ctx context.Context, groupID uuid.UUID, now time.Time) ([]*models.GroupMute, error)
+
+	// 群组统计与文件存储用量
+	GetGroupStats(ctx context.Context, groupID uuid.UUID) (*models.GroupStats, error)
+	GetStorageTier(ctx context.Context, groupID uuid.UUID) (string, error)
+	SetStorageTier(ctx context.Context, groupID uuid.UUID, tier string, updatedBy uuid.UUID) error
+	// AddGroupMediaFile 记录附加到群组的文件，已记录时返回false
+	AddGroupMediaFile(ctx context.Context, file *models.GroupMediaFile) (bool, error)
+	RemoveGroupMediaFile(ctx context.Context, groupID uuid.UUID, fileID string) error
+	HasGroupMediaFile(ctx context.Context, groupID uuid.UUID, fileID string) (bool, error)
+	// GetGroupStorageUsage 返回群组文件的总字节数和文件数
+	GetGroupStorageUsage(ctx context.Context, groupID uuid.UUID) (int64, int, error)
+
+	// 新成员入群设置
+	GetOnboarding(ctx context.Context, groupID uuid.UUID) (*models.GroupOnboarding, error)
+	UpsertOnboarding(ctx context.Context, onboarding *models.GroupOnboarding) error
+	// GetUsername 获取用户名，用户不存在时返回空字符串
+	GetUsername(ctx context.Context, userID uuid.UUID) (string, error)
 }
 
 // PostgreSQLGroupRepository PostgreSQL群组仓库实现
@@ -53,20 +142,20 @@ func NewPostgreSQLGroupRepository(db *sqlx.DB) *PostgreSQLGroupRepository {
 // CreateGroup 创建群组
 func (r *PostgreSQLGroupRepository) CreateGroup(ctx context.Context, group *models.Group) error {
 	query := `
-		INSERT INTO groups (id, name, description, avatar_url, owner_id, max_members, is_private, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO groups (id, name, description, description_format, avatar_url, owner_id, max_members, is_private, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
 	_, err := r.db.ExecContext(ctx, query,
-		group.ID, group.Name, group.Description, group.AvatarURL,
+		group.ID, group.Name, group.Description, group.DescriptionFormat, group.AvatarURL,
 		group.OwnerID, group.MaxMembers, group.IsPrivate,
 		group.CreatedAt, group.UpdatedAt)
 	return err
 }
 
-// GetGroupByID 根据ID获取群组
+// GetGroupByID 根据ID获取群组，已解散的群组视为不存在
 func (r *PostgreSQLGroupRepository) GetGroupByID(ctx context.Context, groupID uuid.UUID) (*models.Group, error) {
 	var group models.Group
-	query := `SELECT * FROM groups WHERE id = $1`
+	query := `SELECT * FROM groups WHERE id = $1 AND deleted_at IS NULL`
 	err := r.db.GetContext(ctx, &group, query, groupID)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -106,7 +195,7 @@ func (r *PostgreSQLGroupRepository) UpdateGroup(ctx context.Context, groupID uui
 	return err
 }
 
-// DeleteGroup 删除群组
+// DeleteGroup 永久删除群组及其关联数据，用于清理保留期满的已解散群组
 func (r *PostgreSQLGroupRepository) DeleteGroup(ctx context.Context, groupID uuid.UUID) error {
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
@@ -120,6 +209,24 @@ func (r *PostgreSQLGroupRepository) DeleteGroup(ctx context.Context, groupID uui
 		return err
 	}
 
+	// 删除群组Webhook
+	_, err = tx.ExecContext(ctx, "DELETE FROM group_webhooks WHERE group_id = $1", groupID)
+	if err != nil {
+		return err
+	}
+
+	// 删除待确认操作
+	_, err = tx.ExecContext(ctx, "DELETE FROM group_pending_actions WHERE group_id = $1", groupID)
+	if err != nil {
+		return err
+	}
+
+	// 删除群组频道（频道成员随外键级联删除）
+	_, err = tx.ExecContext(ctx, "DELETE FROM group_channels WHERE group_id = $1", groupID)
+	if err != nil {
+		return err
+	}
+
 	// 删除群组成员
 	_, err = tx.ExecContext(ctx, "DELETE FROM group_members WHERE group_id = $1", groupID)
 	if err != nil {
@@ -138,7 +245,7 @@ func (r *PostgreSQLGroupRepository) DeleteGroup(ctx context.Context, groupID uui
 // GetGroupsByOwner 获取用户拥有的群组
 func (r *PostgreSQLGroupRepository) GetGroupsByOwner(ctx context.Context, ownerID uuid.UUID) ([]*models.Group, error) {
 	var groups []*models.Group
-	query := `SELECT * FROM groups WHERE owner_id = $1 ORDER BY created_at DESC`
+	query := `SELECT * FROM groups WHERE owner_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC`
 	err := r.db.SelectContext(ctx, &groups, query, ownerID)
 	return groups, err
 }
@@ -147,15 +254,9 @@ func (r *PostgreSQLGroupRepository) GetGroupsByOwner(ctx context.Context, ownerI
 func (r *PostgreSQLGroupRepository) SearchGroups(ctx context.Context, query string, limit, offset int) ([]*models.GroupWithMemberCount, error) {
 	var groups []*models.GroupWithMemberCount
 	sql := `
-		SELECT g.*, COALESCE(mc.member_count, 0) as member_count
+		SELECT g.*
 		FROM groups g
-		LEFT JOIN (
-			SELECT group_id, COUNT(*) as member_count
-			FROM group_members
-			WHERE status = 'active'
-			GROUP BY group_id
-		) mc ON g.id = mc.group_id
-		WHERE g.is_private = false AND g.name ILIKE $1
+		WHERE g.is_private = false AND g.deleted_at IS NULL AND g.name ILIKE $1
 		ORDER BY g.created_at DESC
 		LIMIT $2 OFFSET $3
 	`
@@ -163,23 +264,59 @@ func (r *PostgreSQLGroupRepository) SearchGroups(ctx context.Context, query stri
 	return groups, err
 }
 
-// AddMember 添加群组成员
+// AddMember 添加群组成员，并在同一事务中更新成员计数
 func (r *PostgreSQLGroupRepository) AddMember(ctx context.Context, member *models.GroupMember) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO group_members (id, group_id, user_id, role, status, joined_at, nickname)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = tx.ExecContext(ctx, query,
 		member.ID, member.GroupID, member.UserID, member.Role,
 		member.Status, member.JoinedAt, member.Nickname)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if member.Status == models.StatusActive {
+		if err := adjustMemberCount(ctx, tx, member.GroupID, 1); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-// RemoveMember 移除群组成员
+// RemoveMember 移除群组成员，并在同一事务中更新成员计数
 func (r *PostgreSQLGroupRepository) RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error {
-	query := `DELETE FROM group_members WHERE group_id = $1 AND user_id = $2`
-	_, err := r.db.ExecContext(ctx, query, groupID, userID)
-	return err
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status models.GroupMemberStatus
+	query := `DELETE FROM group_members WHERE group_id = $1 AND user_id = $2 RETURNING status`
+	err = tx.GetContext(ctx, &status, query, groupID, userID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if status == models.StatusActive {
+		if err := adjustMemberCount(ctx, tx, groupID, -1); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 // UpdateMember 更新群组成员
@@ -204,15 +341,52 @@ func (r *PostgreSQLGroupRepository) UpdateMember(ctx context.Context, groupID, u
 	// 添加WHERE条件
 	args = append(args, groupID, userID)
 
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 锁定成员行，获取原状态用于维护成员计数
+	var oldStatus models.GroupMemberStatus
+	err = tx.GetContext(ctx, &oldStatus,
+		`SELECT status FROM group_members WHERE group_id = $1 AND user_id = $2 FOR UPDATE`, groupID, userID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
 	query := fmt.Sprintf("UPDATE group_members SET %s WHERE group_id = $%d AND user_id = $%d", setClause, argIndex, argIndex+1)
-	_, err := r.db.ExecContext(ctx, query, args...)
-	return err
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	if newStatus, ok := updates["status"]; ok {
+		wasActive := oldStatus == models.StatusActive
+		isActive := models.GroupMemberStatus(fmt.Sprint(newStatus)) == models.StatusActive
+		if wasActive && !isActive {
+			err = adjustMemberCount(ctx, tx, groupID, -1)
+		} else if !wasActive && isActive {
+			err = adjustMemberCount(ctx, tx, groupID, 1)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 // GetMember 获取群组成员
 func (r *PostgreSQLGroupRepository) GetMember(ctx context.Context, groupID, userID uuid.UUID) (*models.GroupMember, error) {
 	var member models.GroupMember
-	query := `SELECT * FROM group_members WHERE group_id = $1 AND user_id = $2`
+	query := `
+		SELECT gm.* FROM group_members gm
+		JOIN groups g ON g.id = gm.group_id
+		WHERE gm.group_id = $1 AND gm.user_id = $2 AND g.deleted_at IS NULL
+	`
 	err := r.db.GetContext(ctx, &member, query, groupID, userID)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -238,16 +412,10 @@ func (r *PostgreSQLGroupRepository) GetGroupMembers(ctx context.Context, groupID
 func (r *PostgreSQLGroupRepository) GetUserGroups(ctx context.Context, userID uuid.UUID) ([]*models.GroupWithMemberCount, error) {
 	var groups []*models.GroupWithMemberCount
 	query := `
-		SELECT g.*, COALESCE(mc.member_count, 0) as member_count
+		SELECT g.*
 		FROM groups g
 		JOIN group_members gm ON g.id = gm.group_id
-		LEFT JOIN (
-			SELECT group_id, COUNT(*) as member_count
-			FROM group_members
-			WHERE status = 'active'
-			GROUP BY group_id
-		) mc ON g.id = mc.group_id
-		WHERE gm.user_id = $1 AND gm.status = 'active'
+		WHERE gm.user_id = $1 AND gm.status = 'active' AND g.deleted_at IS NULL
 		ORDER BY gm.joined_at DESC
 	`
 	err := r.db.SelectContext(ctx, &groups, query, userID)
@@ -257,19 +425,54 @@ func (r *PostgreSQLGroupRepository) GetUserGroups(ctx context.Context, userID uu
 // IsMember 检查用户是否为群组成员
 func (r *PostgreSQLGroupRepository) IsMember(ctx context.Context, groupID, userID uuid.UUID) (bool, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM group_members WHERE group_id = $1 AND user_id = $2 AND status = 'active'`
+	query := `
+		SELECT COUNT(*) FROM group_members gm
+		JOIN groups g ON g.id = gm.group_id
+		WHERE gm.group_id = $1 AND gm.user_id = $2 AND gm.status = 'active' AND g.deleted_at IS NULL
+	`
 	err := r.db.GetContext(ctx, &count, query, groupID, userID)
 	return count > 0, err
 }
 
-// GetMemberCount 获取群组成员数量
+// GetMemberCount 获取群组成员数量（读取冗余计数字段，避免COUNT(*)）
 func (r *PostgreSQLGroupRepository) GetMemberCount(ctx context.Context, groupID uuid.UUID) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM group_members WHERE group_id = $1 AND status = 'active'`
+	query := `SELECT member_count FROM groups WHERE id = $1`
 	err := r.db.GetContext(ctx, &count, query, groupID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
 	return count, err
 }
 
+// ReconcileMemberCounts 根据成员表校正冗余的成员计数，返回被修正的群组数量
+func (r *PostgreSQLGroupRepository) ReconcileMemberCounts(ctx context.Context) (int, error) {
+	query := `
+		UPDATE groups g
+		SET member_count = actual.cnt
+		FROM (
+			SELECT g2.id, COUNT(gm.id) AS cnt
+			FROM groups g2
+			LEFT JOIN group_members gm ON gm.group_id = g2.id AND gm.status = 'active'
+			GROUP BY g2.id
+		) actual
+		WHERE g.id = actual.id AND g.member_count <> actual.cnt
+	`
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// adjustMemberCount 在事务中调整群组成员计数
+func adjustMemberCount(ctx context.Context, tx *sqlx.Tx, groupID uuid.UUID, delta int) error {
+	query := `UPDATE groups SET member_count = GREATEST(member_count + $1, 0) WHERE id = $2`
+	_, err := tx.ExecContext(ctx, query, delta, groupID)
+	return err
+}
+
 // CreateInvitation 创建邀请
 func (r *PostgreSQLGroupRepository) CreateInvitation(ctx context.Context, invitation *models.GroupInvitation) error {
 	query := `
@@ -322,18 +525,48 @@ func (r *PostgreSQLGroupRepository) GetGroupInvitations(ctx context.Context, gro
 
 // MemoryGroupRepository 内存群组仓库实现（用于测试）
 type MemoryGroupRepository struct {
-	groups      map[uuid.UUID]*models.Group
-	members     map[uuid.UUID]map[uuid.UUID]*models.GroupMember // groupID -> userID -> member
-	invitations map[uuid.UUID]*models.GroupInvitation
-	mu          sync.RWMutex
+	groups         map[uuid.UUID]*models.Group
+	members        map[uuid.UUID]map[uuid.UUID]*models.GroupMember // groupID -> userID -> member
+	invitations    map[uuid.UUID]*models.GroupInvitation
+	channels       map[uuid.UUID]*models.GroupChannel
+	channelMembers map[uuid.UUID]map[uuid.UUID]*models.ChannelMember // channelID -> userID -> member
+	webhooks       map[uuid.UUID]*models.GroupWebhook
+	resources      map[uuid.UUID]*models.GroupResource
+	announcements  map[uuid.UUID]*models.GroupAnnouncement
+	pendingActions map[uuid.UUID]*models.GroupPendingAction
+	joinQuestions  map[uuid.UUID][]*models.GroupJoinQuestion // groupID -> questions
+	joinRequests   map[uuid.UUID]*models.GroupJoinRequest
+	activity       map[uuid.UUID]map[uuid.UUID]time.Time // groupID -> userID -> 最近活跃时间
+	prunePolicies  map[uuid.UUID]*models.GroupPrunePolicy
+	pruneProposals map[uuid.UUID]*models.GroupPruneProposal
+	mutes          map[uuid.UUID]map[uuid.UUID]*models.GroupMute // groupID -> userID -> 免打扰设置
+	storageTiers   map[uuid.UUID]string
+	mediaFiles     map[uuid.UUID]map[string]*models.GroupMediaFile // groupID -> fileID -> 文件
+	onboarding     map[uuid.UUID]*models.GroupOnboarding
+	mu             sync.RWMutex
 }
 
 // NewMemoryGroupRepository 创建内存群组仓库
 func NewMemoryGroupRepository() *MemoryGroupRepository {
 	return &MemoryGroupRepository{
-		groups:      make(map[uuid.UUID]*models.Group),
-		members:     make(map[uuid.UUID]map[uuid.UUID]*models.GroupMember),
-		invitations: make(map[uuid.UUID]*models.GroupInvitation),
+		groups:         make(map[uuid.UUID]*models.Group),
+		members:        make(map[uuid.UUID]map[uuid.UUID]*models.GroupMember),
+		invitations:    make(map[uuid.UUID]*models.GroupInvitation),
+		channels:       make(map[uuid.UUID]*models.GroupChannel),
+		channelMembers: make(map[uuid.UUID]map[uuid.UUID]*models.ChannelMember),
+		webhooks:       make(map[uuid.UUID]*models.GroupWebhook),
+		resources:      make(map[uuid.UUID]*models.GroupResource),
+		announcements:  make(map[uuid.UUID]*models.GroupAnnouncement),
+		pendingActions: make(map[uuid.UUID]*models.GroupPendingAction),
+		joinQuestions:  make(map[uuid.UUID][]*models.GroupJoinQuestion),
+		joinRequests:   make(map[uuid.UUID]*models.GroupJoinRequest),
+		activity:       make(map[uuid.UUID]map[uuid.UUID]time.Time),
+		prunePolicies:  make(map[uuid.UUID]*models.GroupPrunePolicy),
+		pruneProposals: make(map[uuid.UUID]*models.GroupPruneProposal),
+		mutes:          make(map[uuid.UUID]map[uuid.UUID]*models.GroupMute),
+		storageTiers:   make(map[uuid.UUID]string),
+		mediaFiles:     make(map[uuid.UUID]map[string]*models.GroupMediaFile),
+		onboarding:     make(map[uuid.UUID]*models.GroupOnboarding),
 	}
 }
 
@@ -351,7 +584,7 @@ func (r *MemoryGroupRepository) GetGroupByID(ctx context.Context, groupID uuid.U
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	group, exists := r.groups[groupID]
-	if !exists {
+	if !exists || group.DeletedAt != nil {
 		return nil, nil
 	}
 	return group, nil
@@ -376,16 +609,64 @@ func (r *MemoryGroupRepository) UpdateGroup(ctx context.Context, groupID uuid.UU
 	if description, ok := updates["description"]; ok {
 		group.Description = description.(string)
 	}
+	if format, ok := updates["description_format"]; ok {
+		group.DescriptionFormat = format.(models.DescriptionFormat)
+	}
+	if confirm, ok := updates["require_owner_confirmation"]; ok {
+		group.RequireOwnerConfirmation = confirm.(bool)
+	}
 	group.UpdatedAt = time.Now()
 	return nil
 }
 
-// DeleteGroup 删除群组
+// DeleteGroup 永久删除群组及其关联数据
 func (r *MemoryGroupRepository) DeleteGroup(ctx context.Context, groupID uuid.UUID) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	delete(r.groups, groupID)
 	delete(r.members, groupID)
+	for id, channel := range r.channels {
+		if channel.GroupID == groupID {
+			delete(r.channels, id)
+			delete(r.channelMembers, id)
+		}
+	}
+	for id, webhook := range r.webhooks {
+		if webhook.GroupID == groupID {
+			delete(r.webhooks, id)
+		}
+	}
+	for id, resource := range r.resources {
+		if resource.GroupID == groupID {
+			delete(r.resources, id)
+		}
+	}
+	for id, announcement := range r.announcements {
+		if announcement.GroupID == groupID {
+			delete(r.announcements, id)
+		}
+	}
+	for id, action := range r.pendingActions {
+		if action.GroupID == groupID {
+			delete(r.pendingActions, id)
+		}
+	}
+	delete(r.joinQuestions, groupID)
+	for id, request := range r.joinRequests {
+		if request.GroupID == groupID {
+			delete(r.joinRequests, id)
+		}
+	}
+	delete(r.activity, groupID)
+	delete(r.prunePolicies, groupID)
+	for id, proposal := range r.pruneProposals {
+		if proposal.GroupID == groupID {
+			delete(r.pruneProposals, id)
+		}
+	}
+	delete(r.mutes, groupID)
+	delete(r.storageTiers, groupID)
+	delete(r.mediaFiles, groupID)
 	return nil
 }
 
@@ -397,6 +678,7 @@ func (r *MemoryGroupRepository) AddMember(ctx context.Context, member *models.Gr
 		r.members[member.GroupID] = make(map[uuid.UUID]*models.GroupMember)
 	}
 	r.members[member.GroupID][member.UserID] = member
+	r.refreshMemberCount(member.GroupID)
 	return nil
 }
 
@@ -405,7 +687,7 @@ func (r *MemoryGroupRepository) IsMember(ctx context.Context, groupID, userID uu
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	groupMembers, exists := r.members[groupID]
-	if !exists {
+	if !exists || r.isDeleted(groupID) {
 		return false, nil
 	}
 	member, exists := groupMembers[userID]
@@ -418,7 +700,7 @@ func (r *MemoryGroupRepository) GetGroupsByOwner(ctx context.Context, ownerID uu
 	defer r.mu.RUnlock()
 	var groups []*models.Group
 	for _, group := range r.groups {
-		if group.OwnerID == ownerID {
+		if group.OwnerID == ownerID && group.DeletedAt == nil {
 			groups = append(groups, group)
 		}
 	}
@@ -435,6 +717,7 @@ func (r *MemoryGroupRepository) RemoveMember(ctx context.Context, groupID, userI
 	if groupMembers, exists := r.members[groupID]; exists {
 		delete(groupMembers, userID)
 	}
+	r.refreshMemberCount(groupID)
 	return nil
 }
 
@@ -445,7 +728,7 @@ func (r *MemoryGroupRepository) UpdateMember(ctx context.Context, groupID, userI
 func (r *MemoryGroupRepository) GetMember(ctx context.Context, groupID, userID uuid.UUID) (*models.GroupMember, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	if groupMembers, exists := r.members[groupID]; exists {
+	if groupMembers, exists := r.members[groupID]; exists && !r.isDeleted(groupID) {
 		if member, exists := groupMembers[userID]; exists {
 			return member, nil
 		}
@@ -464,16 +747,39 @@ func (r *MemoryGroupRepository) GetUserGroups(ctx context.Context, userID uuid.U
 func (r *MemoryGroupRepository) GetMemberCount(ctx context.Context, groupID uuid.UUID) (int, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	if groupMembers, exists := r.members[groupID]; exists {
-		count := 0
-		for _, member := range groupMembers {
-			if member.Status == models.StatusActive {
-				count++
-			}
+	return r.countActiveMembers(groupID), nil
+}
+
+// ReconcileMemberCounts 内存实现中计数随成员变更实时刷新，只需校正偏差
+func (r *MemoryGroupRepository) ReconcileMemberCounts(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fixed := 0
+	for groupID, group := range r.groups {
+		if count := r.countActiveMembers(groupID); group.MemberCount != count {
+			group.MemberCount = count
+			fixed++
 		}
-		return count, nil
 	}
-	return 0, nil
+	return fixed, nil
+}
+
+// countActiveMembers 统计活跃成员数量，调用方需持有锁
+func (r *MemoryGroupRepository) countActiveMembers(groupID uuid.UUID) int {
+	count := 0
+	for _, member := range r.members[groupID] {
+		if member.Status == models.StatusActive {
+			count++
+		}
+	}
+	return count
+}
+
+// refreshMemberCount 刷新群组的冗余成员计数，调用方需持有写锁
+func (r *MemoryGroupRepository) refreshMemberCount(groupID uuid.UUID) {
+	if group, exists := r.groups[groupID]; exists {
+		group.MemberCount = r.countActiveMembers(groupID)
+	}
 }
 
 func (r *MemoryGroupRepository) CreateInvitation(ctx context.Context, invitation *models.GroupInvitation) error {
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+)
+
+// GetJoinQuestions 获取群组的入群问题，按顺序排列
+func (r *PostgreSQLGroupRepository) GetJoinQuestions(ctx context.Context, groupID uuid.UUID) ([]*models.GroupJoinQuestion, error) {
+	var questions []*models.GroupJoinQuestion
+	query := `SELECT * FROM group_join_questions WHERE group_id = $1 ORDER BY position`
+	err := r.db.SelectContext(ctx, &questions, query, groupID)
+	return questions, err
+}
+
+// ReplaceJoinQuestions 在同一事务中替换群组的全部入群问题
+func (r *PostgreSQLGroupRepository) ReplaceJoinQuestions(ctx context.Context, groupID uuid.UUID, questions []*models.GroupJoinQuestion) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM group_join_questions WHERE group_id = $1`, groupID); err != nil {
+		return err
+	}
+	for _, question := range questions {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO group_join_questions (id, group_id, position, question, required, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, question.ID, question.GroupID, question.Position, question.Question, question.Required, question.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// CreateJoinRequest 创建入群申请，同一用户在同一群组已有待审核申请时返回错误
+func (r *PostgreSQLGroupRepository) CreateJoinRequest(ctx context.Context, request *models.GroupJoinRequest) error {
+	query := `
+		INSERT INTO group_join_requests (id, group_id, user_id, status, answers, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (group_id, user_id) WHERE status = 'pending' DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		request.ID, request.GroupID, request.UserID, request.Status, request.Answers, request.CreatedAt)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("join request already pending")
+	}
+	return nil
+}
+
+// GetJoinRequest 根据ID获取入群申请
+func (r *PostgreSQLGroupRepository) GetJoinRequest(ctx context.Context, requestID uuid.UUID) (*models.GroupJoinRequest, error) {
+	var request models.GroupJoinRequest
+	query := `SELECT * FROM group_join_requests WHERE id = $1`
+	err := r.db.GetContext(ctx, &request, query, requestID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &request, err
+}
+
+// GetPendingJoinRequest 获取用户在群组中待审核的入群申请
+func (r *PostgreSQLGroupRepository) GetPendingJoinRequest(ctx context.Context, groupID, userID uuid.UUID) (*models.GroupJoinRequest, error) {
+	var request models.GroupJoinRequest
+	query := `SELECT * FROM group_join_requests WHERE group_id = $1 AND user_id = $2 AND status = 'pending'`
+	err := r.db.GetContext(ctx, &request, query, groupID, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &request, err
+}
+
+// GetGroupJoinRequests 分页获取群组指定状态的入群申请，先提交的在前
+func (r *PostgreSQLGroupRepository) GetGroupJoinRequests(ctx context.Context, groupID uuid.UUID, status models.JoinRequestStatus, limit, offset int) ([]*models.GroupJoinRequest, error) {
+	var requests []*models.GroupJoinRequest
+	query := `
+		SELECT * FROM group_join_requests
+		WHERE group_id = $1 AND status = $2
+		ORDER BY created_at
+		LIMIT $3 OFFSET $4
+	`
+	err := r.db.SelectContext(ctx, &requests, query, groupID, status, limit, offset)
+	return requests, err
+}
+
+// ResolveJoinRequest 审核或撤回入群申请
+// 只更新仍处于pending的记录，返回false表示已被其他请求处理
+func (r *PostgreSQLGroupRepository) ResolveJoinRequest(ctx context.Context, requestID uuid.UUID, status models.JoinRequestStatus, reviewedBy uuid.UUID) (bool, error) {
+	query := `
+		UPDATE group_join_requests
+		SET status = $1, reviewed_by = $2, reviewed_at = NOW()
+		WHERE id = $3 AND status = 'pending'
+	`
+	result, err := r.db.ExecContext(ctx, query, status, reviewedBy, requestID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// GetJoinQuestions 获取群组的入群问题
+func (r *MemoryGroupRepository) GetJoinQuestions(ctx context.Context, groupID uuid.UUID) ([]*models.GroupJoinQuestion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]*models.GroupJoinQuestion(nil), r.joinQuestions[groupID]...), nil
+}
+
+// ReplaceJoinQuestions 替换群组的全部入群问题
+func (r *MemoryGroupRepository) ReplaceJoinQuestions(ctx context.Context, groupID uuid.UUID, questions []*models.GroupJoinQuestion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sorted := append([]*models.GroupJoinQuestion(nil), questions...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Position < sorted[j].Position
+	})
+	r.joinQuestions[groupID] = sorted
+	return nil
+}
+
+// CreateJoinRequest 创建入群申请
+func (r *MemoryGroupRepository) CreateJoinRequest(ctx context.Context, request *models.GroupJoinRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.joinRequests {
+		if existing.GroupID == request.GroupID && existing.UserID == request.UserID && existing.Status == models.JoinRequestPending {
+			return fmt.Errorf("join request already pending")
+		}
+	}
+	r.joinRequests[request.ID] = request
+	return nil
+}
+
+// GetJoinRequest 根据ID获取入群申请
+func (r *MemoryGroupRepository) GetJoinRequest(ctx context.Context, requestID uuid.UUID) (*models.GroupJoinRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	request, exists := r.joinRequests[requestID]
+	if !exists {
+		return nil, nil
+	}
+	copied := *request
+	return &copied, nil
+}
+
+// GetPendingJoinRequest 获取用户在群组中待审核的入群申请
+func (r *MemoryGroupRepository) GetPendingJoinRequest(ctx context.Context, groupID, userID uuid.UUID) (*models.GroupJoinRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, request := range r.joinRequests {
+		if request.GroupID == groupID && request.UserID == userID && request.Status == models.JoinRequestPending {
+			copied := *request
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetGroupJoinRequests 分页获取群组指定状态的入群申请，先提交的在前
+func (r *MemoryGroupRepository) GetGroupJoinRequests(ctx context.Context, groupID uuid.UUID, status models.JoinRequestStatus, limit, offset int) ([]*models.GroupJoinRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var requests []*models.GroupJoinRequest
+	for _, request := range r.joinRequests {
+		if request.GroupID == groupID && request.Status == status {
+			copied := *request
+			requests = append(requests, &copied)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].CreatedAt.Before(requests[j].CreatedAt)
+	})
+
+	if offset >= len(requests) {
+		return []*models.GroupJoinRequest{}, nil
+	}
+	end := offset + limit
+	if end > len(requests) {
+		end = len(requests)
+	}
+	return requests[offset:end], nil
+}
+
+// ResolveJoinRequest 审核或撤回入群申请
+func (r *MemoryGroupRepository) ResolveJoinRequest(ctx context.Context, requestID uuid.UUID, status models.JoinRequestStatus, reviewedBy uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	request, exists := r.joinRequests[requestID]
+	if !exists || request.Status != models.JoinRequestPending {
+		return false, nil
+	}
+	now := time.Now()
+	request.Status = status
+	request.ReviewedBy = &reviewedBy
+	request.ReviewedAt = &now
+	return true, nil
+}
@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/group-service/internal/models"
+)
+
+const (
+	// membershipKeyPrefix 成员缓存键前缀，完整格式为 group:member:<groupID>:<userID>
+	membershipKeyPrefix = "group:member:"
+	// membershipChannel 成员变更失效事件频道
+	membershipChannel = "group:membership:invalidate"
+	// nonMemberValue 非成员的缓存值，避免反复查询不存在的成员
+	nonMemberValue = "null"
+)
+
+// MembershipCacheMetrics 成员缓存统计
+type MembershipCacheMetrics struct {
+	LocalHits     int64 `json:"local_hits"`
+	RedisHits     int64 `json:"redis_hits"`
+	Misses        int64 `json:"misses"`
+	Errors        int64 `json:"errors"`
+	Invalidations int64 `json:"invalidations"`
+	LocalEntries  int   `json:"local_entries"`
+}
+
+// membershipEvent 成员变更事件，UserID为空表示整个群组失效
+type membershipEvent struct {
+	GroupID uuid.UUID `json:"group_id"`
+	UserID  uuid.UUID `json:"user_id,omitempty"`
+}
+
+// localEntry 进程内缓存项
+type localEntry struct {
+	member    *models.GroupMember
+	expiresAt time.Time
+}
+
+// CachedGroupRepository 为成员查询加缓存的群组仓库
+// IsMember/GetMember先查进程内缓存，再查Redis，最后回源数据库；成员变更时删除Redis键并
+// 通过Redis频道广播失效事件，所有实例收到后清理各自的进程内缓存
+type CachedGroupRepository struct {
+	GroupRepository
+
+	client   *redis.Client
+	ttl      time.Duration
+	localTTL time.Duration
+
+	mu         sync.RWMutex
+	local      map[string]localEntry
+	maxEntries int
+
+	localHits     atomic.Int64
+	redisHits     atomic.Int64
+	misses        atomic.Int64
+	errors        atomic.Int64
+	invalidations atomic.Int64
+
+	logger *zap.Logger
+}
+
+// NewCachedGroupRepository 创建带成员缓存的群组仓库
+func NewCachedGroupRepository(repo GroupRepository, client *redis.Client, ttl, localTTL time.Duration, maxEntries int, logger *zap.Logger) *CachedGroupRepository {
+	return &CachedGroupRepository{
+		GroupRepository: repo,
+		client:          client,
+		ttl:             ttl,
+		localTTL:        localTTL,
+		local:           make(map[string]localEntry),
+		maxEntries:      maxEntries,
+		logger:          logger,
+	}
+}
+
+// Start 订阅成员变更事件，ctx取消时退出
+func (r *CachedGroupRepository) Start(ctx context.Context) {
+	pubsub := r.client.Subscribe(ctx, membershipChannel)
+
+	go func() {
+		defer pubsub.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-pubsub.Channel():
+				if !ok {
+					return
+				}
+				var event membershipEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					r.logger.Warn("Invalid membership event", zap.Error(err))
+					continue
+				}
+				r.dropLocal(event)
+			}
+		}
+	}()
+
+	r.logger.Info("Membership cache invalidation subscriber started")
+}
+
+// Metrics 获取缓存统计
+func (r *CachedGroupRepository) Metrics() MembershipCacheMetrics {
+	r.mu.RLock()
+	entries := len(r.local)
+	r.mu.RUnlock()
+
+	return MembershipCacheMetrics{
+		LocalHits:     r.localHits.Load(),
+		RedisHits:     r.redisHits.Load(),
+		Misses:        r.misses.Load(),
+		Errors:        r.errors.Load(),
+		Invalidations: r.invalidations.Load(),
+		LocalEntries:  entries,
+	}
+}
+
+// GetMember 获取群组成员，优先读缓存
+func (r *CachedGroupRepository) GetMember(ctx context.Context, groupID, userID uuid.UUID) (*models.GroupMember, error) {
+	key := membershipKey(groupID, userID)
+
+	if member, ok := r.getLocal(key); ok {
+		r.localHits.Add(1)
+		return member, nil
+	}
+
+	value, err := r.client.Get(ctx, key).Result()
+	switch {
+	case err == nil:
+		var member *models.GroupMember
+		if value != nonMemberValue {
+			member = &models.GroupMember{}
+			if err := json.Unmarshal([]byte(value), member); err != nil {
+				r.errors.Add(1)
+				r.logger.Warn("Invalid membership cache entry", zap.String("key", key), zap.Error(err))
+				break
+			}
+		}
+		r.redisHits.Add(1)
+		r.setLocal(key, member)
+		return member, nil
+	case err != redis.Nil:
+		// Redis不可用时直接回源
+		r.errors.Add(1)
+		r.logger.Warn("Membership cache lookup failed", zap.String("key", key), zap.Error(err))
+	}
+
+	r.misses.Add(1)
+	member, err := r.GroupRepository.GetMember(ctx, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	value = nonMemberValue
+	if member != nil {
+		encoded, err := json.Marshal(member)
+		if err != nil {
+			return member, nil
+		}
+		value = string(encoded)
+	}
+	if err := r.client.Set(ctx, key, value, r.ttl).Err(); err != nil {
+		r.errors.Add(1)
+		r.logger.Warn("Failed to populate membership cache", zap.String("key", key), zap.Error(err))
+	}
+	r.setLocal(key, member)
+
+	return member, nil
+}
+
+// IsMember 检查用户是否为群组活跃成员，复用GetMember的缓存
+func (r *CachedGroupRepository) IsMember(ctx context.Context, groupID, userID uuid.UUID) (bool, error) {
+	member, err := r.GetMember(ctx, groupID, userID)
+	if err != nil {
+		return false, err
+	}
+	return member != nil && member.Status == models.StatusActive, nil
+}
+
+// AddMember 添加群组成员并使缓存失效
+func (r *CachedGroupRepository) AddMember(ctx context.Context, member *models.GroupMember) error {
+	err := r.GroupRepository.AddMember(ctx, member)
+	r.invalidate(ctx, membershipEvent{GroupID: member.GroupID, UserID: member.UserID})
+	return err
+}
+
+// RemoveMember 移除群组成员并使缓存失效
+func (r *CachedGroupRepository) RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	err := r.GroupRepository.RemoveMember(ctx, groupID, userID)
+	r.invalidate(ctx, membershipEvent{GroupID: groupID, UserID: userID})
+	return err
+}
+
+// UpdateMember 更新群组成员并使缓存失效
+func (r *CachedGroupRepository) UpdateMember(ctx context.Context, groupID, userID uuid.UUID, updates map[string]interface{}) error {
+	err := r.GroupRepository.UpdateMember(ctx, groupID, userID, updates)
+	r.invalidate(ctx, membershipEvent{GroupID: groupID, UserID: userID})
+	return err
+}
+
+// TransferOwnership 转让群主并使新旧群主的成员缓存失效
+func (r *CachedGroupRepository) TransferOwnership(ctx context.Context, groupID, fromUserID, toUserID uuid.UUID) error {
+	err := r.GroupRepository.TransferOwnership(ctx, groupID, fromUserID, toUserID)
+	r.invalidate(ctx, membershipEvent{GroupID: groupID, UserID: fromUserID})
+	r.invalidate(ctx, membershipEvent{GroupID: groupID, UserID: toUserID})
+	return err
+}
+
+// DeleteGroup 删除群组并使该群组的全部成员缓存失效
+func (r *CachedGroupRepository) DeleteGroup(ctx context.Context, groupID uuid.UUID) error {
+	err := r.GroupRepository.DeleteGroup(ctx, groupID)
+	r.invalidate(ctx, membershipEvent{GroupID: groupID})
+	return err
+}
+
+// SoftDeleteGroup 解散群组并使该群组的全部成员缓存失效，解散后成员查询不再命中
+func (r *CachedGroupRepository) SoftDeleteGroup(ctx context.Context, groupID, deletedBy uuid.UUID) error {
+	err := r.GroupRepository.SoftDeleteGroup(ctx, groupID, deletedBy)
+	r.invalidate(ctx, membershipEvent{GroupID: groupID})
+	return err
+}
+
+// RestoreGroup 恢复群组并使缓存中的非成员记录失效
+func (r *CachedGroupRepository) RestoreGroup(ctx context.Context, groupID uuid.UUID) error {
+	err := r.GroupRepository.RestoreGroup(ctx, groupID)
+	r.invalidate(ctx, membershipEvent{GroupID: groupID})
+	return err
+}
+
+// invalidate 删除Redis中的缓存并广播失效事件
+// 写操作失败时也执行，避免事务部分提交后缓存与数据库不一致
+func (r *CachedGroupRepository) invalidate(ctx context.Context, event membershipEvent) {
+	r.invalidations.Add(1)
+	r.dropLocal(event)
+
+	if event.UserID != uuid.Nil {
+		if err := r.client.Del(ctx, membershipKey(event.GroupID, event.UserID)).Err(); err != nil {
+			r.errors.Add(1)
+			r.logger.Warn("Failed to invalidate membership cache", zap.Error(err))
+		}
+	} else {
+		iter := r.client.Scan(ctx, 0, fmt.Sprintf("%s%s:*", membershipKeyPrefix, event.GroupID), 100).Iterator()
+		for iter.Next(ctx) {
+			r.client.Del(ctx, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			r.errors.Add(1)
+			r.logger.Warn("Failed to invalidate group membership cache", zap.Error(err))
+		}
+	}
+
+	payload, _ := json.Marshal(event)
+	if err := r.client.Publish(ctx, membershipChannel, payload).Err(); err != nil {
+		r.errors.Add(1)
+		r.logger.Warn("Failed to publish membership event", zap.Error(err))
+	}
+}
+
+// getLocal 读取进程内缓存
+func (r *CachedGroupRepository) getLocal(key string) (*models.GroupMember, bool) {
+	if r.localTTL <= 0 {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	entry, ok := r.local[key]
+	r.mu.RUnlock()
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.member, true
+}
+
+// setLocal 写入进程内缓存，超过容量时先清理过期项，仍然超出则清空
+func (r *CachedGroupRepository) setLocal(key string, member *models.GroupMember) {
+	if r.localTTL <= 0 {
+		return
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxEntries > 0 && len(r.local) >= r.maxEntries {
+		for k, entry := range r.local {
+			if now.After(entry.expiresAt) {
+				delete(r.local, k)
+			}
+		}
+		if len(r.local) >= r.maxEntries {
+			r.local = make(map[string]localEntry)
+		}
+	}
+	r.local[key] = localEntry{member: member, expiresAt: now.Add(r.localTTL)}
+}
+
+// dropLocal 按事件清理进程内缓存
+func (r *CachedGroupRepository) dropLocal(event membershipEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.UserID != uuid.Nil {
+		delete(r.local, membershipKey(event.GroupID, event.UserID))
+		return
+	}
+
+	prefix := fmt.Sprintf("%s%s:", membershipKeyPrefix, event.GroupID)
+	for key := range r.local {
+		if len(key) > len(prefix) && key[:len(prefix)] == prefix {
+			delete(r.local, key)
+		}
+	}
+}
+
+// membershipKey 成员缓存键
+func membershipKey(groupID, userID uuid.UUID) string {
+	return membershipKeyPrefix + groupID.String() + ":" + userID.String()
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+)
+
+// SetMute 创建或更新成员的免打扰设置
+func (r *PostgreSQLGroupRepository) SetMute(ctx context.Context, mute *models.GroupMute) error {
+	query := `
+		INSERT INTO group_mutes (group_id, user_id, muted_until, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (group_id, user_id) DO UPDATE SET
+			muted_until = EXCLUDED.muted_until,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.ExecContext(ctx, query, mute.GroupID, mute.UserID, mute.MutedUntil, mute.UpdatedAt)
+	return err
+}
+
+// DeleteMute 取消成员的免打扰
+func (r *PostgreSQLGroupRepository) DeleteMute(ctx context.Context, groupID, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM group_mutes WHERE group_id = $1 AND user_id = $2", groupID, userID)
+	return err
+}
+
+// GetActiveMutes 获取群组中在now时仍然有效的免打扰设置
+func (r *PostgreSQLGroupRepository) GetActiveMutes(ctx context.Context, groupID uuid.UUID, now time.Time) ([]*models.GroupMute, error) {
+	mutes := []*models.GroupMute{}
+	query := `
+		SELECT group_id, user_id, muted_until, updated_at FROM group_mutes
+		WHERE group_id = $1 AND (muted_until IS NULL OR muted_until > $2)
+	`
+	if err := r.db.SelectContext(ctx, &mutes, query, groupID, now); err != nil {
+		return nil, err
+	}
+	for _, mute := range mutes {
+		mute.Muted = true
+	}
+	return mutes, nil
+}
+
+// SetMute 创建或更新成员的免打扰设置
+func (r *MemoryGroupRepository) SetMute(ctx context.Context, mute *models.GroupMute) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mutes[mute.GroupID] == nil {
+		r.mutes[mute.GroupID] = make(map[uuid.UUID]*models.GroupMute)
+	}
+	copied := *mute
+	r.mutes[mute.GroupID][mute.UserID] = &copied
+	return nil
+}
+
+// DeleteMute 取消成员的免打扰
+func (r *MemoryGroupRepository) DeleteMute(ctx context.Context, groupID, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.mutes[groupID], userID)
+	return nil
+}
+
+// GetActiveMutes 获取群组中在now时仍然有效的免打扰设置
+func (r *MemoryGroupRepository) GetActiveMutes(ctx context.Context, groupID uuid.UUID, now time.Time) ([]*models.GroupMute, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	mutes := []*models.GroupMute{}
+	for _, mute := range r.mutes[groupID] {
+		if mute.MutedUntil == nil || mute.MutedUntil.After(now) {
+			copied := *mute
+			copied.Muted = true
+			mutes = append(mutes, &copied)
+		}
+	}
+	return mutes, nil
+}
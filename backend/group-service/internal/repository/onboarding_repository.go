@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+)
+
+// GetOnboarding 获取群组的入群设置，未设置时返回nil
+func (r *PostgreSQLGroupRepository) GetOnboarding(ctx context.Context, groupID uuid.UUID) (*models.GroupOnboarding, error) {
+	var onboarding models.GroupOnboarding
+	query := `SELECT * FROM group_onboarding WHERE group_id = $1`
+	err := r.db.GetContext(ctx, &onboarding, query, groupID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &onboarding, err
+}
+
+// UpsertOnboarding 创建或更新群组的入群设置
+func (r *PostgreSQLGroupRepository) UpsertOnboarding(ctx context.Context, onboarding *models.GroupOnboarding) error {
+	query := `
+		INSERT INTO group_onboarding (group_id, welcome_enabled, welcome_message, welcome_channel_id, default_role, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (group_id) DO UPDATE SET
+			welcome_enabled = EXCLUDED.welcome_enabled,
+			welcome_message = EXCLUDED.welcome_message,
+			welcome_channel_id = EXCLUDED.welcome_channel_id,
+			default_role = EXCLUDED.default_role,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		onboarding.GroupID, onboarding.WelcomeEnabled, onboarding.WelcomeMessage, onboarding.WelcomeChannelID,
+		onboarding.DefaultRole, onboarding.UpdatedBy, onboarding.UpdatedAt)
+	return err
+}
+
+// GetUsername 获取用户名，用户不存在时返回空字符串
+func (r *PostgreSQLGroupRepository) GetUsername(ctx context.Context, userID uuid.UUID) (string, error) {
+	var username string
+	err := r.db.GetContext(ctx, &username, "SELECT username FROM users WHERE id = $1", userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return username, err
+}
+
+// GetOnboarding 获取群组的入群设置，未设置时返回nil
+func (r *MemoryGroupRepository) GetOnboarding(ctx context.Context, groupID uuid.UUID) (*models.GroupOnboarding, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	onboarding, exists := r.onboarding[groupID]
+	if !exists {
+		return nil, nil
+	}
+	copied := *onboarding
+	return &copied, nil
+}
+
+// UpsertOnboarding 创建或更新群组的入群设置
+func (r *MemoryGroupRepository) UpsertOnboarding(ctx context.Context, onboarding *models.GroupOnboarding) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *onboarding
+	r.onboarding[onboarding.GroupID] = &copied
+	return nil
+}
+
+// GetUsername 内存实现不保存用户信息，总是返回空字符串
+func (r *MemoryGroupRepository) GetUsername(ctx context.Context, userID uuid.UUID) (string, error) {
+	return "", nil
+}
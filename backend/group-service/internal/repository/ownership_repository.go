@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+)
+
+// CountMembersByRole 统计群组中指定角色的成员数量
+func (r *PostgreSQLGroupRepository) CountMembersByRole(ctx context.Context, groupID uuid.UUID, role models.GroupMemberRole) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM group_members WHERE group_id = $1 AND role = $2 AND status = 'active'`
+	err := r.db.GetContext(ctx, &count, query, groupID, role)
+	return count, err
+}
+
+// TransferOwnership 在同一事务中转让群主：更新群组owner_id，新群主角色设为owner，原群主降为管理员
+func (r *PostgreSQLGroupRepository) TransferOwnership(ctx context.Context, groupID, fromUserID, toUserID uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE groups SET owner_id = $1 WHERE id = $2 AND owner_id = $3`, toUserID, groupID, fromUserID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return fmt.Errorf("group owner has changed")
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE group_members SET role = $1 WHERE group_id = $2 AND user_id = $3`, models.RoleAdmin, groupID, fromUserID)
+	if err != nil {
+		return err
+	}
+
+	result, err = tx.ExecContext(ctx, `UPDATE group_members SET role = $1 WHERE group_id = $2 AND user_id = $3 AND status = 'active'`, models.RoleOwner, groupID, toUserID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return fmt.Errorf("new owner is not an active member")
+	}
+
+	return tx.Commit()
+}
+
+// CreatePendingAction 创建待确认操作
+func (r *PostgreSQLGroupRepository) CreatePendingAction(ctx context.Context, action *models.GroupPendingAction) error {
+	query := `
+		INSERT INTO group_pending_actions (id, group_id, action, target_user_id, requested_by, status, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		action.ID, action.GroupID, action.Action, action.TargetUserID,
+		action.RequestedBy, action.Status, action.CreatedAt, action.ExpiresAt)
+	return err
+}
+
+// GetPendingAction 根据ID获取待确认操作
+func (r *PostgreSQLGroupRepository) GetPendingAction(ctx context.Context, actionID uuid.UUID) (*models.GroupPendingAction, error) {
+	var action models.GroupPendingAction
+	query := `SELECT * FROM group_pending_actions WHERE id = $1`
+	err := r.db.GetContext(ctx, &action, query, actionID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &action, err
+}
+
+// GetGroupPendingActions 获取群组尚未处理且未过期的待确认操作
+func (r *PostgreSQLGroupRepository) GetGroupPendingActions(ctx context.Context, groupID uuid.UUID) ([]*models.GroupPendingAction, error) {
+	var actions []*models.GroupPendingAction
+	query := `
+		SELECT * FROM group_pending_actions
+		WHERE group_id = $1 AND status = 'pending' AND expires_at > NOW()
+		ORDER BY created_at
+	`
+	err := r.db.SelectContext(ctx, &actions, query, groupID)
+	return actions, err
+}
+
+// ResolvePendingAction 将待确认操作标记为已确认或已取消
+// 只更新仍处于pending且未过期的记录，返回false表示已被其他请求处理或已过期
+func (r *PostgreSQLGroupRepository) ResolvePendingAction(ctx context.Context, actionID uuid.UUID, status models.PendingActionStatus, resolvedBy uuid.UUID) (bool, error) {
+	query := `
+		UPDATE group_pending_actions
+		SET status = $1, resolved_by = $2, resolved_at = NOW()
+		WHERE id = $3 AND status = 'pending' AND expires_at > NOW()
+	`
+	result, err := r.db.ExecContext(ctx, query, status, resolvedBy, actionID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// CountMembersByRole 统计群组中指定角色的成员数量
+func (r *MemoryGroupRepository) CountMembersByRole(ctx context.Context, groupID uuid.UUID, role models.GroupMemberRole) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	count := 0
+	for _, member := range r.members[groupID] {
+		if member.Role == role && member.Status == models.StatusActive {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// TransferOwnership 转让群主
+func (r *MemoryGroupRepository) TransferOwnership(ctx context.Context, groupID, fromUserID, toUserID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	group, exists := r.groups[groupID]
+	if !exists || group.OwnerID != fromUserID {
+		return fmt.Errorf("group owner has changed")
+	}
+	newOwner, exists := r.members[groupID][toUserID]
+	if !exists || newOwner.Status != models.StatusActive {
+		return fmt.Errorf("new owner is not an active member")
+	}
+
+	if oldOwner, exists := r.members[groupID][fromUserID]; exists {
+		oldOwner.Role = models.RoleAdmin
+	}
+	newOwner.Role = models.RoleOwner
+	group.OwnerID = toUserID
+	group.UpdatedAt = time.Now()
+	return nil
+}
+
+// CreatePendingAction 创建待确认操作
+func (r *MemoryGroupRepository) CreatePendingAction(ctx context.Context, action *models.GroupPendingAction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pendingActions[action.ID] = action
+	return nil
+}
+
+// GetPendingAction 根据ID获取待确认操作
+func (r *MemoryGroupRepository) GetPendingAction(ctx context.Context, actionID uuid.UUID) (*models.GroupPendingAction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	action, exists := r.pendingActions[actionID]
+	if !exists {
+		return nil, nil
+	}
+	return action, nil
+}
+
+// GetGroupPendingActions 获取群组尚未处理且未过期的待确认操作
+func (r *MemoryGroupRepository) GetGroupPendingActions(ctx context.Context, groupID uuid.UUID) ([]*models.GroupPendingAction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := time.Now()
+	var actions []*models.GroupPendingAction
+	for _, action := range r.pendingActions {
+		if action.GroupID == groupID && action.Status == models.PendingActionPending && !action.IsExpired(now) {
+			actions = append(actions, action)
+		}
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].CreatedAt.Before(actions[j].CreatedAt)
+	})
+	return actions, nil
+}
+
+// ResolvePendingAction 将待确认操作标记为已确认或已取消
+func (r *MemoryGroupRepository) ResolvePendingAction(ctx context.Context, actionID uuid.UUID, status models.PendingActionStatus, resolvedBy uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	action, exists := r.pendingActions[actionID]
+	if !exists || action.Status != models.PendingActionPending || action.IsExpired(now) {
+		return false, nil
+	}
+	action.Status = status
+	action.ResolvedBy = &resolvedBy
+	action.ResolvedAt = &now
+	return true, nil
+}
@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+)
+
+// RecordMemberActivity 记录成员在群组中的最近活跃时间，只会向后推进
+func (r *PostgreSQLGroupRepository) RecordMemberActivity(ctx context.Context, groupID, userID uuid.UUID, at time.Time) error {
+	query := `
+		INSERT INTO group_member_activity (group_id, user_id, last_active_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (group_id, user_id)
+		DO UPDATE SET last_active_at = GREATEST(group_member_activity.last_active_at, EXCLUDED.last_active_at)
+	`
+	_, err := r.db.ExecContext(ctx, query, groupID, userID, at)
+	return err
+}
+
+// GetInactiveMembers 获取cutoff之后没有活跃记录的普通成员，加入时间晚于cutoff的成员不计入
+// 群主、联合群主和管理员不会被清理，被封禁和待审核的成员不在范围内
+func (r *PostgreSQLGroupRepository) GetInactiveMembers(ctx context.Context, groupID uuid.UUID, cutoff time.Time) ([]*models.PruneCandidate, error) {
+	var candidates []*models.PruneCandidate
+	query := `
+		SELECT gm.user_id, gm.role, gm.status, gm.joined_at, a.last_active_at
+		FROM group_members gm
+		LEFT JOIN group_member_activity a ON a.group_id = gm.group_id AND a.user_id = gm.user_id
+		WHERE gm.group_id = $1
+			AND gm.role = 'member'
+			AND gm.status IN ('active', 'muted')
+			AND gm.joined_at < $2
+			AND (a.last_active_at IS NULL OR a.last_active_at < $2)
+		ORDER BY COALESCE(a.last_active_at, gm.joined_at)
+	`
+	err := r.db.SelectContext(ctx, &candidates, query, groupID, cutoff)
+	return candidates, err
+}
+
+// GetPrunePolicy 获取群组的清理策略，未设置时返回nil
+func (r *PostgreSQLGroupRepository) GetPrunePolicy(ctx context.Context, groupID uuid.UUID) (*models.GroupPrunePolicy, error) {
+	var policy models.GroupPrunePolicy
+	query := `SELECT * FROM group_prune_policies WHERE group_id = $1`
+	err := r.db.GetContext(ctx, &policy, query, groupID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &policy, err
+}
+
+// UpsertPrunePolicy 创建或更新群组的清理策略
+func (r *PostgreSQLGroupRepository) UpsertPrunePolicy(ctx context.Context, policy *models.GroupPrunePolicy) error {
+	query := `
+		INSERT INTO group_prune_policies (group_id, enabled, inactive_days, action, enabled_at, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (group_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			inactive_days = EXCLUDED.inactive_days,
+			action = EXCLUDED.action,
+			enabled_at = EXCLUDED.enabled_at,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		policy.GroupID, policy.Enabled, policy.InactiveDays, policy.Action,
+		policy.EnabledAt, policy.UpdatedBy, policy.UpdatedAt)
+	return err
+}
+
+// GetEnabledPrunePolicies 获取所有已开启的清理策略，跳过已解散的群组
+func (r *PostgreSQLGroupRepository) GetEnabledPrunePolicies(ctx context.Context) ([]*models.GroupPrunePolicy, error) {
+	var policies []*models.GroupPrunePolicy
+	query := `
+		SELECT p.* FROM group_prune_policies p
+		JOIN groups g ON g.id = p.group_id
+		WHERE p.enabled = TRUE AND g.deleted_at IS NULL
+		ORDER BY p.group_id
+	`
+	err := r.db.SelectContext(ctx, &policies, query)
+	return policies, err
+}
+
+// CreatePruneProposal 创建清理提议，群组已有待确认的提议时返回错误
+func (r *PostgreSQLGroupRepository) CreatePruneProposal(ctx context.Context, proposal *models.GroupPruneProposal) error {
+	query := `
+		INSERT INTO group_prune_proposals (id, group_id, inactive_days, candidates, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (group_id) WHERE status = 'pending' DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		proposal.ID, proposal.GroupID, proposal.InactiveDays, proposal.Candidates, proposal.Status, proposal.CreatedAt)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("prune proposal already pending")
+	}
+	return nil
+}
+
+// GetPruneProposal 根据ID获取清理提议
+func (r *PostgreSQLGroupRepository) GetPruneProposal(ctx context.Context, proposalID uuid.UUID) (*models.GroupPruneProposal, error) {
+	var proposal models.GroupPruneProposal
+	query := `SELECT * FROM group_prune_proposals WHERE id = $1`
+	err := r.db.GetContext(ctx, &proposal, query, proposalID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &proposal, err
+}
+
+// GetGroupPruneProposals 分页获取群组指定状态的清理提议，新的在前
+func (r *PostgreSQLGroupRepository) GetGroupPruneProposals(ctx context.Context, groupID uuid.UUID, status models.PruneProposalStatus, limit, offset int) ([]*models.GroupPruneProposal, error) {
+	var proposals []*models.GroupPruneProposal
+	query := `
+		SELECT * FROM group_prune_proposals
+		WHERE group_id = $1 AND status = $2
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	err := r.db.SelectContext(ctx, &proposals, query, groupID, status, limit, offset)
+	return proposals, err
+}
+
+// ResolvePruneProposal 确认或驳回清理提议
+// 只更新仍处于pending的记录，返回false表示已被其他请求处理
+func (r *PostgreSQLGroupRepository) ResolvePruneProposal(ctx context.Context, proposalID uuid.UUID, status models.PruneProposalStatus, resolvedBy uuid.UUID, removedCount int) (bool, error) {
+	query := `
+		UPDATE group_prune_proposals
+		SET status = $1, resolved_by = $2, removed_count = $3, resolved_at = NOW()
+		WHERE id = $4 AND status = 'pending'
+	`
+	result, err := r.db.ExecContext(ctx, query, status, resolvedBy, removedCount, proposalID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// RecordMemberActivity 记录成员在群组中的最近活跃时间
+func (r *MemoryGroupRepository) RecordMemberActivity(ctx context.Context, groupID, userID uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.activity[groupID] == nil {
+		r.activity[groupID] = make(map[uuid.UUID]time.Time)
+	}
+	if last, exists := r.activity[groupID][userID]; !exists || at.After(last) {
+		r.activity[groupID][userID] = at
+	}
+	return nil
+}
+
+// GetInactiveMembers 获取cutoff之后没有活跃记录的普通成员
+func (r *MemoryGroupRepository) GetInactiveMembers(ctx context.Context, groupID uuid.UUID, cutoff time.Time) ([]*models.PruneCandidate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var candidates []*models.PruneCandidate
+	for userID, member := range r.members[groupID] {
+		if member.Role != models.RoleMember || (member.Status != models.StatusActive && member.Status != models.StatusMuted) {
+			continue
+		}
+		if !member.JoinedAt.Before(cutoff) {
+			continue
+		}
+		candidate := &models.PruneCandidate{
+			UserID:   userID,
+			Role:     member.Role,
+			Status:   member.Status,
+			JoinedAt: member.JoinedAt,
+		}
+		if last, exists := r.activity[groupID][userID]; exists {
+			if !last.Before(cutoff) {
+				continue
+			}
+			candidate.LastActiveAt = &last
+		}
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return lastSeen(candidates[i]).Before(lastSeen(candidates[j]))
+	})
+	return candidates, nil
+}
+
+// lastSeen 成员最近一次活跃或加入的时间
+func lastSeen(candidate *models.PruneCandidate) time.Time {
+	if candidate.LastActiveAt != nil {
+		return *candidate.LastActiveAt
+	}
+	return candidate.JoinedAt
+}
+
+// GetPrunePolicy 获取群组的清理策略
+func (r *MemoryGroupRepository) GetPrunePolicy(ctx context.Context, groupID uuid.UUID) (*models.GroupPrunePolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	policy, exists := r.prunePolicies[groupID]
+	if !exists {
+		return nil, nil
+	}
+	copied := *policy
+	return &copied, nil
+}
+
+// UpsertPrunePolicy 创建或更新群组的清理策略
+func (r *MemoryGroupRepository) UpsertPrunePolicy(ctx context.Context, policy *models.GroupPrunePolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *policy
+	r.prunePolicies[policy.GroupID] = &copied
+	return nil
+}
+
+// GetEnabledPrunePolicies 获取所有已开启的清理策略，跳过已解散的群组
+func (r *MemoryGroupRepository) GetEnabledPrunePolicies(ctx context.Context) ([]*models.GroupPrunePolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var policies []*models.GroupPrunePolicy
+	for _, policy := range r.prunePolicies {
+		if policy.Enabled && !r.isDeleted(policy.GroupID) {
+			copied := *policy
+			policies = append(policies, &copied)
+		}
+	}
+	return policies, nil
+}
+
+// CreatePruneProposal 创建清理提议
+func (r *MemoryGroupRepository) CreatePruneProposal(ctx context.Context, proposal *models.GroupPruneProposal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.pruneProposals {
+		if existing.GroupID == proposal.GroupID && existing.Status == models.PruneProposalPending {
+			return fmt.Errorf("prune proposal already pending")
+		}
+	}
+	copied := *proposal
+	r.pruneProposals[proposal.ID] = &copied
+	return nil
+}
+
+// GetPruneProposal 根据ID获取清理提议
+func (r *MemoryGroupRepository) GetPruneProposal(ctx context.Context, proposalID uuid.UUID) (*models.GroupPruneProposal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	proposal, exists := r.pruneProposals[proposalID]
+	if !exists {
+		return nil, nil
+	}
+	copied := *proposal
+	return &copied, nil
+}
+
+// GetGroupPruneProposals 分页获取群组指定状态的清理提议，新的在前
+func (r *MemoryGroupRepository) GetGroupPruneProposals(ctx context.Context, groupID uuid.UUID, status models.PruneProposalStatus, limit, offset int) ([]*models.GroupPruneProposal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var proposals []*models.GroupPruneProposal
+	for _, proposal := range r.pruneProposals {
+		if proposal.GroupID == groupID && proposal.Status == status {
+			copied := *proposal
+			proposals = append(proposals, &copied)
+		}
+	}
+	sort.Slice(proposals, func(i, j int) bool {
+		return proposals[i].CreatedAt.After(proposals[j].CreatedAt)
+	})
+
+	if offset >= len(proposals) {
+		return []*models.GroupPruneProposal{}, nil
+	}
+	end := offset + limit
+	if end > len(proposals) {
+		end = len(proposals)
+	}
+	return proposals[offset:end], nil
+}
+
+// ResolvePruneProposal 确认或驳回清理提议
+func (r *MemoryGroupRepository) ResolvePruneProposal(ctx context.Context, proposalID uuid.UUID, status models.PruneProposalStatus, resolvedBy uuid.UUID, removedCount int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	proposal, exists := r.pruneProposals[proposalID]
+	if !exists || proposal.Status != models.PruneProposalPending {
+		return false, nil
+	}
+	now := time.Now()
+	proposal.Status = status
+	proposal.ResolvedBy = &resolvedBy
+	proposal.ResolvedAt = &now
+	proposal.RemovedCount = removedCount
+	return true, nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+)
+
+// CreateResource 创建置顶资源
+func (r *PostgreSQLGroupRepository) CreateResource(ctx context.Context, resource *models.GroupResource) error {
+	query := `
+		INSERT INTO group_resources (id, group_id, title, url, description, position, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		resource.ID, resource.GroupID, resource.Title, resource.URL, resource.Description,
+		resource.Position, resource.CreatedBy, resource.CreatedAt, resource.UpdatedAt)
+	return err
+}
+
+// GetResource 根据ID获取置顶资源
+func (r *PostgreSQLGroupRepository) GetResource(ctx context.Context, resourceID uuid.UUID) (*models.GroupResource, error) {
+	var resource models.GroupResource
+	query := `SELECT * FROM group_resources WHERE id = $1`
+	err := r.db.GetContext(ctx, &resource, query, resourceID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &resource, err
+}
+
+// GetGroupResources 获取群组所有置顶资源
+func (r *PostgreSQLGroupRepository) GetGroupResources(ctx context.Context, groupID uuid.UUID) ([]*models.GroupResource, error) {
+	var resources []*models.GroupResource
+	query := `SELECT * FROM group_resources WHERE group_id = $1 ORDER BY position, created_at`
+	err := r.db.SelectContext(ctx, &resources, query, groupID)
+	return resources, err
+}
+
+// UpdateResource 更新置顶资源
+func (r *PostgreSQLGroupRepository) UpdateResource(ctx context.Context, resourceID uuid.UUID, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	setClause := ""
+	args := []interface{}{}
+	argIndex := 1
+
+	for field, value := range updates {
+		if setClause != "" {
+			setClause += ", "
+		}
+		setClause += fmt.Sprintf("%s = $%d", field, argIndex)
+		args = append(args, value)
+		argIndex++
+	}
+
+	// 添加WHERE条件
+	args = append(args, resourceID)
+
+	query := fmt.Sprintf("UPDATE group_resources SET %s WHERE id = $%d", setClause, argIndex)
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// DeleteResource 删除置顶资源
+func (r *PostgreSQLGroupRepository) DeleteResource(ctx context.Context, resourceID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM group_resources WHERE id = $1", resourceID)
+	return err
+}
+
+// CreateResource 创建置顶资源
+func (r *MemoryGroupRepository) CreateResource(ctx context.Context, resource *models.GroupResource) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resources[resource.ID] = resource
+	return nil
+}
+
+// GetResource 根据ID获取置顶资源
+func (r *MemoryGroupRepository) GetResource(ctx context.Context, resourceID uuid.UUID) (*models.GroupResource, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	resource, exists := r.resources[resourceID]
+	if !exists {
+		return nil, nil
+	}
+	return resource, nil
+}
+
+// GetGroupResources 获取群组所有置顶资源
+func (r *MemoryGroupRepository) GetGroupResources(ctx context.Context, groupID uuid.UUID) ([]*models.GroupResource, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var resources []*models.GroupResource
+	for _, resource := range r.resources {
+		if resource.GroupID == groupID {
+			resources = append(resources, resource)
+		}
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Position != resources[j].Position {
+			return resources[i].Position < resources[j].Position
+		}
+		return resources[i].CreatedAt.Before(resources[j].CreatedAt)
+	})
+	return resources, nil
+}
+
+// UpdateResource 更新置顶资源
+func (r *MemoryGroupRepository) UpdateResource(ctx context.Context, resourceID uuid.UUID, updates map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	resource, exists := r.resources[resourceID]
+	if !exists {
+		return fmt.Errorf("resource not found")
+	}
+
+	if title, ok := updates["title"]; ok {
+		resource.Title = title.(string)
+	}
+	if url, ok := updates["url"]; ok {
+		resource.URL = url.(string)
+	}
+	if description, ok := updates["description"]; ok {
+		resource.Description = description.(string)
+	}
+	if position, ok := updates["position"]; ok {
+		resource.Position = position.(int)
+	}
+	resource.UpdatedAt = time.Now()
+	return nil
+}
+
+// DeleteResource 删除置顶资源
+func (r *MemoryGroupRepository) DeleteResource(ctx context.Context, resourceID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.resources, resourceID)
+	return nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+)
+
+// GetGroupStats 获取群组的成员统计，群组不存在时返回nil
+func (r *PostgreSQLGroupRepository) GetGroupStats(ctx context.Context, groupID uuid.UUID) (*models.GroupStats, error) {
+	var row struct {
+		TotalMembers  int       `db:"total_members"`
+		ActiveMembers int       `db:"active_members"`
+		AdminCount    int       `db:"admin_count"`
+		OwnerID       uuid.UUID `db:"owner_id"`
+	}
+	query := `SELECT total_members, active_members, admin_count, owner_id FROM get_group_stats($1)`
+	if err := r.db.GetContext(ctx, &row, query, groupID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &models.GroupStats{
+		GroupID:       groupID,
+		TotalMembers:  row.TotalMembers,
+		ActiveMembers: row.ActiveMembers,
+		AdminCount:    row.AdminCount,
+		OwnerID:       row.OwnerID,
+	}, nil
+}
+
+// GetStorageTier 获取群组的存储档位，未设置时返回空字符串
+func (r *PostgreSQLGroupRepository) GetStorageTier(ctx context.Context, groupID uuid.UUID) (string, error) {
+	var tier string
+	err := r.db.GetContext(ctx, &tier, "SELECT tier FROM group_storage_tiers WHERE group_id = $1", groupID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return tier, err
+}
+
+// SetStorageTier 设置群组的存储档位
+func (r *PostgreSQLGroupRepository) SetStorageTier(ctx context.Context, groupID uuid.UUID, tier string, updatedBy uuid.UUID) error {
+	query := `
+		INSERT INTO group_storage_tiers (group_id, tier, updated_by, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (group_id) DO UPDATE SET
+			tier = EXCLUDED.tier,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.ExecContext(ctx, query, groupID, tier, updatedBy)
+	return err
+}
+
+// AddGroupMediaFile 记录附加到群组的文件，重复附加同一文件不重复计算
+func (r *PostgreSQLGroupRepository) AddGroupMediaFile(ctx context.Context, file *models.GroupMediaFile) (bool, error) {
+	query := `
+		INSERT INTO group_media_files (group_id, file_id, owner_id, size, attached_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (group_id, file_id) DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query, file.GroupID, file.FileID, file.OwnerID, file.Size, file.AttachedAt)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// RemoveGroupMediaFile 移除群组的文件记录
+func (r *PostgreSQLGroupRepository) RemoveGroupMediaFile(ctx context.Context, groupID uuid.UUID, fileID string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM group_media_files WHERE group_id = $1 AND file_id = $2", groupID, fileID)
+	return err
+}
+
+// HasGroupMediaFile 判断文件是否已计入群组用量
+func (r *PostgreSQLGroupRepository) HasGroupMediaFile(ctx context.Context, groupID uuid.UUID, fileID string) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, "SELECT EXISTS(SELECT 1 FROM group_media_files WHERE group_id = $1 AND file_id = $2)", groupID, fileID)
+	return exists, err
+}
+
+// GetGroupStorageUsage 统计群组文件的总字节数和文件数
+func (r *PostgreSQLGroupRepository) GetGroupStorageUsage(ctx context.Context, groupID uuid.UUID) (int64, int, error) {
+	var row struct {
+		UsedBytes int64 `db:"used_bytes"`
+		FileCount int   `db:"file_count"`
+	}
+	query := `SELECT COALESCE(SUM(size), 0) AS used_bytes, COUNT(*) AS file_count FROM group_media_files WHERE group_id = $1`
+	if err := r.db.GetContext(ctx, &row, query, groupID); err != nil {
+		return 0, 0, err
+	}
+	return row.UsedBytes, row.FileCount, nil
+}
+
+// GetGroupStats 获取群组的成员统计，群组不存在时返回nil
+func (r *MemoryGroupRepository) GetGroupStats(ctx context.Context, groupID uuid.UUID) (*models.GroupStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	group, exists := r.groups[groupID]
+	if !exists {
+		return nil, nil
+	}
+	stats := &models.GroupStats{GroupID: groupID, OwnerID: group.OwnerID}
+	for _, member := range r.members[groupID] {
+		stats.TotalMembers++
+		if member.Status == models.StatusActive {
+			stats.ActiveMembers++
+		}
+		if member.Role == models.RoleAdmin {
+			stats.AdminCount++
+		}
+	}
+	return stats, nil
+}
+
+// GetStorageTier 获取群组的存储档位，未设置时返回空字符串
+func (r *MemoryGroupRepository) GetStorageTier(ctx context.Context, groupID uuid.UUID) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.storageTiers[groupID], nil
+}
+
+// SetStorageTier 设置群组的存储档位
+func (r *MemoryGroupRepository) SetStorageTier(ctx context.Context, groupID uuid.UUID, tier string, updatedBy uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.storageTiers[groupID] = tier
+	return nil
+}
+
+// AddGroupMediaFile 记录附加到群组的文件，重复附加同一文件不重复计算
+func (r *MemoryGroupRepository) AddGroupMediaFile(ctx context.Context, file *models.GroupMediaFile) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mediaFiles[file.GroupID] == nil {
+		r.mediaFiles[file.GroupID] = make(map[string]*models.GroupMediaFile)
+	}
+	if _, exists := r.mediaFiles[file.GroupID][file.FileID]; exists {
+		return false, nil
+	}
+	copied := *file
+	r.mediaFiles[file.GroupID][file.FileID] = &copied
+	return true, nil
+}
+
+// RemoveGroupMediaFile 移除群组的文件记录
+func (r *MemoryGroupRepository) RemoveGroupMediaFile(ctx context.Context, groupID uuid.UUID, fileID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.mediaFiles[groupID], fileID)
+	return nil
+}
+
+// HasGroupMediaFile 判断文件是否已计入群组用量
+func (r *MemoryGroupRepository) HasGroupMediaFile(ctx context.Context, groupID uuid.UUID, fileID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, exists := r.mediaFiles[groupID][fileID]
+	return exists, nil
+}
+
+// GetGroupStorageUsage 统计群组文件的总字节数和文件数
+func (r *MemoryGroupRepository) GetGroupStorageUsage(ctx context.Context, groupID uuid.UUID) (int64, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var used int64
+	for _, file := range r.mediaFiles[groupID] {
+		used += file.Size
+	}
+	return used, len(r.mediaFiles[groupID]), nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+)
+
+// CreateWebhook 创建Webhook
+func (r *PostgreSQLGroupRepository) CreateWebhook(ctx context.Context, webhook *models.GroupWebhook) error {
+	query := `
+		INSERT INTO group_webhooks (id, group_id, url, secret, events, is_active, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		webhook.ID, webhook.GroupID, webhook.URL, webhook.Secret, webhook.Events,
+		webhook.IsActive, webhook.CreatedBy, webhook.CreatedAt)
+	return err
+}
+
+// GetWebhook 根据ID获取Webhook
+func (r *PostgreSQLGroupRepository) GetWebhook(ctx context.Context, webhookID uuid.UUID) (*models.GroupWebhook, error) {
+	var webhook models.GroupWebhook
+	query := `SELECT * FROM group_webhooks WHERE id = $1`
+	err := r.db.GetContext(ctx, &webhook, query, webhookID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &webhook, err
+}
+
+// GetGroupWebhooks 获取群组所有Webhook
+func (r *PostgreSQLGroupRepository) GetGroupWebhooks(ctx context.Context, groupID uuid.UUID) ([]*models.GroupWebhook, error) {
+	var webhooks []*models.GroupWebhook
+	query := `SELECT * FROM group_webhooks WHERE group_id = $1 ORDER BY created_at`
+	err := r.db.SelectContext(ctx, &webhooks, query, groupID)
+	return webhooks, err
+}
+
+// DeleteWebhook 删除Webhook
+func (r *PostgreSQLGroupRepository) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM group_webhooks WHERE id = $1", webhookID)
+	return err
+}
+
+// CreateWebhook 创建Webhook
+func (r *MemoryGroupRepository) CreateWebhook(ctx context.Context, webhook *models.GroupWebhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+// GetWebhook 根据ID获取Webhook
+func (r *MemoryGroupRepository) GetWebhook(ctx context.Context, webhookID uuid.UUID) (*models.GroupWebhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	webhook, exists := r.webhooks[webhookID]
+	if !exists {
+		return nil, nil
+	}
+	return webhook, nil
+}
+
+// GetGroupWebhooks 获取群组所有Webhook
+func (r *MemoryGroupRepository) GetGroupWebhooks(ctx context.Context, groupID uuid.UUID) ([]*models.GroupWebhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var webhooks []*models.GroupWebhook
+	for _, webhook := range r.webhooks {
+		if webhook.GroupID == groupID {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook 删除Webhook
+func (r *MemoryGroupRepository) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.webhooks, webhookID)
+	return nil
+}
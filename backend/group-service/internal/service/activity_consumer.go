@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/pkg/events"
+	"go.uber.org/zap"
+)
+
+// ActivityEventTypes 群组服务订阅的事件，用于记录成员活跃度
+var ActivityEventTypes = []string{
+	events.TypeMessageCreated,
+}
+
+// ActivityConsumer 根据message-service发布的消息事件记录成员在群组中的活跃时间
+type ActivityConsumer struct {
+	groups GroupService
+	logger *zap.Logger
+}
+
+func NewActivityConsumer(groups GroupService, logger *zap.Logger) *ActivityConsumer {
+	return &ActivityConsumer{
+		groups: groups,
+		logger: logger,
+	}
+}
+
+// Handle 记录群聊消息发送者的活跃时间，私聊和非群组会话忽略
+func (c *ActivityConsumer) Handle(ctx context.Context, event *events.Event) error {
+	if event.Type != events.TypeMessageCreated {
+		return nil
+	}
+	var data events.MessageCreated
+	if err := event.Decode(&data); err != nil {
+		return fmt.Errorf("invalid %s event: %w", event.Type, err)
+	}
+	if !data.IsGroupChat {
+		return nil
+	}
+
+	// 群组消息都发在频道会话中，按频道找到所属群组，不属于群组的会话忽略
+	groupID, err := c.groups.GetGroupIDByConversation(ctx, data.ConversationID)
+	if err != nil {
+		return ignoreConversationNotFound(err)
+	}
+	senderID, err := uuid.Parse(data.SenderID)
+	if err != nil {
+		return fmt.Errorf("invalid sender id %q: %w", data.SenderID, err)
+	}
+	return c.groups.RecordMemberActivity(ctx, groupID, senderID, event.OccurredAt)
+}
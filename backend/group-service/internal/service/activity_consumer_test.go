@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/internal/repository"
+	"github.com/neohope/chatapp/group-service/pkg/events"
+	"go.uber.org/zap"
+)
+
+func TestChannelMessageRecordsActivity(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryGroupRepository()
+	groups, group, conversationID := newTestGroup(t, repo, uuid.New())
+
+	memberID := uuid.New()
+	if err := repo.AddMember(ctx, &models.GroupMember{
+		ID:       uuid.New(),
+		GroupID:  group.ID,
+		UserID:   memberID,
+		Role:     models.RoleMember,
+		Status:   models.StatusActive,
+		JoinedAt: time.Now().AddDate(0, 0, -100),
+	}); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -30)
+	sentAt := time.Now().AddDate(0, 0, -40).UTC().Truncate(time.Second)
+	handle := func(at time.Time) {
+		t.Helper()
+		event, err := events.NewEvent("message-service", events.TypeMessageCreated, events.MessageCreated{
+			MessageID:      uuid.New().String(),
+			ConversationID: conversationID,
+			SenderID:       memberID.String(),
+			Type:           "text",
+			IsGroupChat:    true,
+		})
+		if err != nil {
+			t.Fatalf("NewEvent: %v", err)
+		}
+		event.OccurredAt = at
+		if err := NewActivityConsumer(groups, zap.NewNop()).Handle(ctx, event); err != nil {
+			t.Fatalf("Handle: %v", err)
+		}
+	}
+
+	handle(sentAt)
+	candidates, err := repo.GetInactiveMembers(ctx, group.ID, cutoff)
+	if err != nil {
+		t.Fatalf("GetInactiveMembers: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].LastActiveAt == nil || !candidates[0].LastActiveAt.Equal(sentAt) {
+		t.Fatalf("expected channel message to set last_active_at to %v, got %+v", sentAt, candidates)
+	}
+
+	// 最近在频道发过消息的成员不再是清理候选
+	handle(time.Now())
+	candidates, err = repo.GetInactiveMembers(ctx, group.ID, cutoff)
+	if err != nil {
+		t.Fatalf("GetInactiveMembers: %v", err)
+	}
+	if len(candidates) != 0 {
+		t.Fatalf("expected active member not to be a prune candidate, got %+v", candidates)
+	}
+}
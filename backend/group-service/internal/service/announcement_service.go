@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/pkg/validation"
+	"go.uber.org/zap"
+)
+
+// CreateAnnouncement 发布群公告，仅管理员和群主可发布
+func (s *groupService) CreateAnnouncement(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.CreateAnnouncementRequest) (*models.GroupAnnouncement, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	// 验证输入
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	announcement := &models.GroupAnnouncement{
+		ID:        uuid.New(),
+		GroupID:   groupID,
+		Title:     strings.TrimSpace(req.Title),
+		Content:   strings.TrimSpace(req.Content),
+		CreatedBy: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.Pinned {
+		if err := s.checkPinnedAnnouncementLimit(ctx, groupID); err != nil {
+			return nil, err
+		}
+		announcement.Pinned = true
+		announcement.PinnedBy = &userID
+		announcement.PinnedAt = &now
+	}
+
+	if err := s.repo.CreateAnnouncement(ctx, announcement); err != nil {
+		s.logger.Error("Failed to create announcement", zap.Error(err), zap.String("group_id", groupID.String()))
+		return nil, fmt.Errorf("failed to create announcement: %w", err)
+	}
+
+	s.logger.Info("Announcement created successfully", zap.String("group_id", groupID.String()), zap.String("announcement_id", announcement.ID.String()))
+	return announcement, nil
+}
+
+// PinAnnouncement 置顶或取消置顶群公告，仅管理员和群主可操作
+func (s *groupService) PinAnnouncement(ctx context.Context, userID uuid.UUID, groupID, announcementID uuid.UUID, pinned bool) (*models.GroupAnnouncement, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	announcement, err := s.getGroupAnnouncement(ctx, groupID, announcementID)
+	if err != nil {
+		return nil, err
+	}
+	// 状态未变化时直接返回，重复置顶不刷新置顶时间
+	if announcement.Pinned == pinned {
+		return announcement, nil
+	}
+
+	var pinnedBy *uuid.UUID
+	var pinnedAt *time.Time
+	if pinned {
+		if err := s.checkPinnedAnnouncementLimit(ctx, groupID); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		pinnedBy, pinnedAt = &userID, &now
+	}
+
+	if err := s.repo.SetAnnouncementPinned(ctx, announcementID, pinned, pinnedBy, pinnedAt); err != nil {
+		s.logger.Error("Failed to pin announcement", zap.Error(err), zap.String("announcement_id", announcementID.String()))
+		return nil, fmt.Errorf("failed to pin announcement: %w", err)
+	}
+
+	s.logger.Info("Announcement pin state changed", zap.String("group_id", groupID.String()), zap.String("announcement_id", announcementID.String()), zap.Bool("pinned", pinned))
+	return s.repo.GetAnnouncement(ctx, announcementID)
+}
+
+// ListAnnouncements 分页获取群公告，仅群成员可查看，置顶公告在前
+func (s *groupService) ListAnnouncements(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, limit, offset int) ([]*models.GroupAnnouncement, error) {
+	// 检查权限
+	if err := s.checkMemberPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	announcements, err := s.repo.GetGroupAnnouncements(ctx, groupID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get announcements: %w", err)
+	}
+	if announcements == nil {
+		return []*models.GroupAnnouncement{}, nil
+	}
+	return announcements, nil
+}
+
+// DeleteAnnouncement 删除群公告，仅管理员和群主可删除
+func (s *groupService) DeleteAnnouncement(ctx context.Context, userID uuid.UUID, groupID, announcementID uuid.UUID) error {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return err
+	}
+
+	if _, err := s.getGroupAnnouncement(ctx, groupID, announcementID); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteAnnouncement(ctx, announcementID); err != nil {
+		s.logger.Error("Failed to delete announcement", zap.Error(err), zap.String("announcement_id", announcementID.String()))
+		return fmt.Errorf("failed to delete announcement: %w", err)
+	}
+
+	s.logger.Info("Announcement deleted successfully", zap.String("group_id", groupID.String()), zap.String("announcement_id", announcementID.String()))
+	return nil
+}
+
+// getGroupAnnouncement 获取属于指定群组的公告
+func (s *groupService) getGroupAnnouncement(ctx context.Context, groupID, announcementID uuid.UUID) (*models.GroupAnnouncement, error) {
+	announcement, err := s.repo.GetAnnouncement(ctx, announcementID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get announcement: %w", err)
+	}
+	if announcement == nil || announcement.GroupID != groupID {
+		return nil, fmt.Errorf("announcement not found")
+	}
+	return announcement, nil
+}
+
+// checkPinnedAnnouncementLimit 检查置顶公告数量上限
+func (s *groupService) checkPinnedAnnouncementLimit(ctx context.Context, groupID uuid.UUID) error {
+	count, err := s.repo.CountPinnedAnnouncements(ctx, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to count pinned announcements: %w", err)
+	}
+	if count >= models.MaxPinnedAnnouncements {
+		return fmt.Errorf("too many pinned announcements: at most %d announcements can be pinned", models.MaxPinnedAnnouncements)
+	}
+	return nil
+}
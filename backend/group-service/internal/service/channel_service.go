@@ -0,0 +1,416 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// CreateChannel 创建群组频道
+func (s *groupService) CreateChannel(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.CreateChannelRequest) (*models.GroupChannel, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	// 验证输入
+	if err := s.validateCreateChannelRequest(req); err != nil {
+		return nil, err
+	}
+
+	permission := req.Permission
+	if permission == "" {
+		permission = models.ChannelPermissionOpen
+	}
+
+	channel := &models.GroupChannel{
+		ID:         uuid.New(),
+		GroupID:    groupID,
+		Name:       strings.TrimSpace(req.Name),
+		Topic:      strings.TrimSpace(req.Topic),
+		Permission: permission,
+		IsDefault:  req.IsDefault,
+		CreatedBy:  userID,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	if err := s.saveChannel(ctx, channel); err != nil {
+		return nil, err
+	}
+
+	// 默认频道自动加入所有现有成员，否则仅创建者加入
+	if channel.IsDefault {
+		s.joinAllMembers(ctx, channel)
+	} else {
+		s.addChannelMember(ctx, channel, userID)
+	}
+
+	s.logger.Info("Channel created successfully", zap.String("group_id", groupID.String()), zap.String("channel_id", channel.ID.String()))
+	return channel, nil
+}
+
+// GetChannels 获取群组中当前用户可见的频道列表
+func (s *groupService) GetChannels(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) ([]*models.GroupChannel, error) {
+	member, err := s.getActiveMember(ctx, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := s.repo.GetGroupChannels(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channels: %w", err)
+	}
+
+	visible := make([]*models.GroupChannel, 0, len(channels))
+	for _, channel := range channels {
+		if channel.CanJoin(member.Role) {
+			visible = append(visible, channel)
+		}
+	}
+	return visible, nil
+}
+
+// GetMyChannels 获取当前用户已加入的频道
+func (s *groupService) GetMyChannels(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) ([]*models.GroupChannel, error) {
+	if err := s.checkMemberPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	channels, err := s.repo.GetUserChannels(ctx, groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user channels: %w", err)
+	}
+	return channels, nil
+}
+
+// UpdateChannel 更新频道
+func (s *groupService) UpdateChannel(ctx context.Context, userID uuid.UUID, groupID, channelID uuid.UUID, req *models.UpdateChannelRequest) (*models.GroupChannel, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	channel, err := s.getGroupChannel(ctx, groupID, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 构建更新字段
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("channel name cannot be empty")
+		}
+		if len(name) > 50 {
+			return nil, fmt.Errorf("channel name too long")
+		}
+		updates["name"] = name
+	}
+	if req.Topic != nil {
+		if len(*req.Topic) > 200 {
+			return nil, fmt.Errorf("channel topic too long")
+		}
+		updates["topic"] = strings.TrimSpace(*req.Topic)
+	}
+	if req.Permission != nil {
+		if !req.Permission.IsValid() {
+			return nil, fmt.Errorf("invalid channel permission")
+		}
+		updates["permission"] = *req.Permission
+	}
+	if req.IsDefault != nil {
+		updates["is_default"] = *req.IsDefault
+	}
+
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("no fields to update")
+	}
+
+	if err := s.repo.UpdateChannel(ctx, channelID, updates); err != nil {
+		s.logger.Error("Failed to update channel", zap.Error(err), zap.String("channel_id", channelID.String()))
+		return nil, fmt.Errorf("failed to update channel: %w", err)
+	}
+
+	// 频道变为默认频道时，自动加入所有成员
+	if req.IsDefault != nil && *req.IsDefault && !channel.IsDefault {
+		s.joinAllMembers(ctx, channel)
+	}
+
+	return s.repo.GetChannel(ctx, channelID)
+}
+
+// DeleteChannel 删除频道
+func (s *groupService) DeleteChannel(ctx context.Context, userID uuid.UUID, groupID, channelID uuid.UUID) error {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return err
+	}
+
+	channel, err := s.getGroupChannel(ctx, groupID, channelID)
+	if err != nil {
+		return err
+	}
+	if channel.IsDefault {
+		return fmt.Errorf("cannot delete default channel")
+	}
+
+	if err := s.repo.DeleteChannel(ctx, channelID); err != nil {
+		s.logger.Error("Failed to delete channel", zap.Error(err), zap.String("channel_id", channelID.String()))
+		return fmt.Errorf("failed to delete channel: %w", err)
+	}
+
+	s.logger.Info("Channel deleted successfully", zap.String("group_id", groupID.String()), zap.String("channel_id", channelID.String()))
+	return nil
+}
+
+// JoinChannel 加入频道
+func (s *groupService) JoinChannel(ctx context.Context, userID uuid.UUID, groupID, channelID uuid.UUID) error {
+	member, err := s.getActiveMember(ctx, groupID, userID)
+	if err != nil {
+		return err
+	}
+
+	channel, err := s.getGroupChannel(ctx, groupID, channelID)
+	if err != nil {
+		return err
+	}
+	if !channel.CanJoin(member.Role) {
+		return fmt.Errorf("access denied: channel is restricted to admins")
+	}
+
+	joinedMember := &models.ChannelMember{
+		ChannelID: channelID,
+		UserID:    userID,
+		JoinedAt:  time.Now(),
+	}
+	if err := s.repo.AddChannelMember(ctx, joinedMember); err != nil {
+		s.logger.Error("Failed to join channel", zap.Error(err), zap.String("channel_id", channelID.String()))
+		return fmt.Errorf("failed to join channel: %w", err)
+	}
+	s.syncChannelJoin(ctx, channel, userID)
+	return nil
+}
+
+// LeaveChannel 离开频道
+func (s *groupService) LeaveChannel(ctx context.Context, userID uuid.UUID, groupID, channelID uuid.UUID) error {
+	channel, err := s.getGroupChannel(ctx, groupID, channelID)
+	if err != nil {
+		return err
+	}
+
+	isChannelMember, err := s.repo.IsChannelMember(ctx, channelID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check channel membership: %w", err)
+	}
+	if !isChannelMember {
+		return fmt.Errorf("not a member of this channel")
+	}
+
+	if err := s.repo.RemoveChannelMember(ctx, channelID, userID); err != nil {
+		s.logger.Error("Failed to leave channel", zap.Error(err), zap.String("channel_id", channelID.String()))
+		return fmt.Errorf("failed to leave channel: %w", err)
+	}
+	s.syncChannelLeave(ctx, channel, userID)
+	return nil
+}
+
+// 频道辅助方法
+
+// createDefaultChannel 为新群组创建默认频道并加入群主
+func (s *groupService) createDefaultChannel(ctx context.Context, groupID, ownerID uuid.UUID) (*models.GroupChannel, error) {
+	channel := &models.GroupChannel{
+		ID:         uuid.New(),
+		GroupID:    groupID,
+		Name:       models.DefaultChannelName,
+		Permission: models.ChannelPermissionOpen,
+		IsDefault:  true,
+		CreatedBy:  ownerID,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	if err := s.saveChannel(ctx, channel); err != nil {
+		return nil, err
+	}
+
+	s.addChannelMember(ctx, channel, ownerID)
+	return channel, nil
+}
+
+// saveChannel 在消息服务中创建会话并保存频道
+func (s *groupService) saveChannel(ctx context.Context, channel *models.GroupChannel) error {
+	if s.messageClient != nil {
+		conversationID, err := s.messageClient.CreateConversation(ctx, channel.CreatedBy, []uuid.UUID{channel.CreatedBy})
+		if err != nil {
+			// 消息服务不可用时仍然创建频道，会话可在之后补建
+			s.logger.Warn("Failed to create channel conversation", zap.Error(err), zap.String("channel_id", channel.ID.String()))
+		} else {
+			channel.ConversationID = conversationID
+		}
+	}
+
+	if err := s.repo.CreateChannel(ctx, channel); err != nil {
+		s.logger.Error("Failed to create channel", zap.Error(err), zap.String("group_id", channel.GroupID.String()))
+		return fmt.Errorf("failed to create channel: %w", err)
+	}
+	return nil
+}
+
+// joinDefaultChannels 新成员自动加入群组的默认频道
+func (s *groupService) joinDefaultChannels(ctx context.Context, groupID, userID uuid.UUID) {
+	member, err := s.repo.GetMember(ctx, groupID, userID)
+	if err != nil || member == nil {
+		return
+	}
+
+	channels, err := s.repo.GetGroupChannels(ctx, groupID)
+	if err != nil {
+		s.logger.Warn("Failed to get channels for auto-join", zap.Error(err), zap.String("group_id", groupID.String()))
+		return
+	}
+
+	for _, channel := range channels {
+		if channel.IsDefault && channel.CanJoin(member.Role) {
+			s.addChannelMember(ctx, channel, userID)
+		}
+	}
+}
+
+// joinAllMembers 将群组所有活跃成员加入频道
+func (s *groupService) joinAllMembers(ctx context.Context, channel *models.GroupChannel) {
+	members, err := s.repo.GetGroupMembers(ctx, channel.GroupID)
+	if err != nil {
+		s.logger.Warn("Failed to get members for auto-join", zap.Error(err), zap.String("group_id", channel.GroupID.String()))
+		return
+	}
+
+	s.addChannelMember(ctx, channel, channel.CreatedBy)
+	for _, member := range members {
+		if channel.CanJoin(member.Role) {
+			s.addChannelMember(ctx, channel, member.UserID)
+		}
+	}
+}
+
+// leaveAllChannels 成员离开群组时退出所有频道，并移出各频道的会话
+func (s *groupService) leaveAllChannels(ctx context.Context, groupID, userID uuid.UUID) {
+	channels, err := s.repo.GetUserChannels(ctx, groupID, userID)
+	if err != nil {
+		s.logger.Warn("Failed to get user channels", zap.Error(err), zap.String("group_id", groupID.String()), zap.String("user_id", userID.String()))
+	}
+	if err := s.repo.RemoveUserFromGroupChannels(ctx, groupID, userID); err != nil {
+		s.logger.Warn("Failed to remove user from channels", zap.Error(err), zap.String("group_id", groupID.String()), zap.String("user_id", userID.String()))
+		return
+	}
+	for _, channel := range channels {
+		s.syncChannelLeave(ctx, channel, userID)
+	}
+}
+
+// addChannelMember 添加频道成员并加入频道的会话，失败仅记录日志
+func (s *groupService) addChannelMember(ctx context.Context, channel *models.GroupChannel, userID uuid.UUID) {
+	member := &models.ChannelMember{
+		ChannelID: channel.ID,
+		UserID:    userID,
+		JoinedAt:  time.Now(),
+	}
+	if err := s.repo.AddChannelMember(ctx, member); err != nil {
+		s.logger.Warn("Failed to add channel member", zap.Error(err), zap.String("channel_id", channel.ID.String()), zap.String("user_id", userID.String()))
+		return
+	}
+	s.syncChannelJoin(ctx, channel, userID)
+}
+
+// syncChannelJoin 把频道成员添加为消息服务中频道会话的参与者，失败仅记录日志
+func (s *groupService) syncChannelJoin(ctx context.Context, channel *models.GroupChannel, userID uuid.UUID) {
+	if s.messageClient == nil || channel.ConversationID == "" {
+		return
+	}
+	if err := s.messageClient.AddParticipants(ctx, channel.ConversationID, []uuid.UUID{userID}); err != nil {
+		s.logger.Warn("Failed to add channel conversation participant", zap.Error(err), zap.String("channel_id", channel.ID.String()), zap.String("user_id", userID.String()))
+	}
+}
+
+// syncChannelLeave 把离开频道的成员移出消息服务中的频道会话，失败仅记录日志
+// 消息服务按频道成员身份校验发言，同步失败时离开的成员也不能继续发言
+func (s *groupService) syncChannelLeave(ctx context.Context, channel *models.GroupChannel, userID uuid.UUID) {
+	if s.messageClient == nil || channel.ConversationID == "" {
+		return
+	}
+	if err := s.messageClient.RemoveParticipant(ctx, channel.ConversationID, userID); err != nil {
+		s.logger.Warn("Failed to remove channel conversation participant", zap.Error(err), zap.String("channel_id", channel.ID.String()), zap.String("user_id", userID.String()))
+	}
+}
+
+// getGroupChannel 获取属于指定群组的频道
+func (s *groupService) getGroupChannel(ctx context.Context, groupID, channelID uuid.UUID) (*models.GroupChannel, error) {
+	channel, err := s.repo.GetChannel(ctx, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel: %w", err)
+	}
+	if channel == nil || channel.GroupID != groupID {
+		return nil, fmt.Errorf("channel not found")
+	}
+	return channel, nil
+}
+
+// GetGroupIDByConversation 根据消息服务中的会话ID查找所属群组，频道会话返回频道所在的群组
+// 早期数据的群聊会话ID即群组ID，找不到对应频道时按群组ID查找，都不存在时返回conversation not found
+func (s *groupService) GetGroupIDByConversation(ctx context.Context, conversationID string) (uuid.UUID, error) {
+	channel, err := s.repo.GetChannelByConversation(ctx, conversationID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get channel: %w", err)
+	}
+	if channel != nil {
+		return channel.GroupID, nil
+	}
+
+	groupID, err := uuid.Parse(conversationID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("conversation not found")
+	}
+	group, err := s.repo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		return uuid.Nil, fmt.Errorf("conversation not found")
+	}
+	return groupID, nil
+}
+
+// getActiveMember 获取活跃成员，非成员返回访问拒绝
+func (s *groupService) getActiveMember(ctx context.Context, groupID, userID uuid.UUID) (*models.GroupMember, error) {
+	member, err := s.repo.GetMember(ctx, groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member: %w", err)
+	}
+	if member == nil || member.Status != models.StatusActive {
+		return nil, fmt.Errorf("access denied: not a member")
+	}
+	return member, nil
+}
+
+// validateCreateChannelRequest 验证创建频道请求
+func (s *groupService) validateCreateChannelRequest(req *models.CreateChannelRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return fmt.Errorf("channel name is required")
+	}
+	if len(req.Name) > 50 {
+		return fmt.Errorf("channel name too long")
+	}
+	if len(req.Topic) > 200 {
+		return fmt.Errorf("channel topic too long")
+	}
+	if req.Permission != "" && !req.Permission.IsValid() {
+		return fmt.Errorf("invalid channel permission")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// purgeBatchSize 每次永久删除的群组数量上限，剩余的留给下一次定时任务
+const purgeBatchSize = 100
+
+// RestoreGroup 恢复保留期内的已解散群组，群主或发起解散的联合群主可以操作
+func (s *groupService) RestoreGroup(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) (*models.Group, error) {
+	group, err := s.repo.GetDeletedGroup(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		return nil, fmt.Errorf("deleted group not found")
+	}
+	if group.OwnerID != userID && (group.DeletedBy == nil || *group.DeletedBy != userID) {
+		return nil, fmt.Errorf("access denied: only the group owner can restore the group")
+	}
+	if time.Since(*group.DeletedAt) > s.ownership.DeleteRetention {
+		return nil, fmt.Errorf("restore period expired")
+	}
+
+	if err := s.repo.RestoreGroup(ctx, groupID); err != nil {
+		s.logger.Error("Failed to restore group", zap.Error(err), zap.String("group_id", groupID.String()))
+		return nil, fmt.Errorf("failed to restore group: %w", err)
+	}
+	s.logger.Info("Group restored", zap.String("group_id", groupID.String()), zap.String("user_id", userID.String()))
+
+	return s.repo.GetGroupByID(ctx, groupID)
+}
+
+// PurgeDeletedGroups 永久删除超过保留期的已解散群组，返回删除的数量
+func (s *groupService) PurgeDeletedGroups(ctx context.Context) (int, error) {
+	ids, err := s.repo.GetGroupsDeletedBefore(ctx, time.Now().Add(-s.ownership.DeleteRetention), purgeBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get deleted groups: %w", err)
+	}
+
+	purged := 0
+	for _, id := range ids {
+		if err := s.repo.DeleteGroup(ctx, id); err != nil {
+			s.logger.Error("Failed to purge deleted group", zap.Error(err), zap.String("group_id", id.String()))
+			continue
+		}
+		purged++
+	}
+	if purged < len(ids) {
+		return purged, fmt.Errorf("failed to purge %d of %d deleted groups", len(ids)-purged, len(ids))
+	}
+	return purged, nil
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/pkg/events"
+	"go.uber.org/zap"
+)
+
+// publishMemberAdded 向事件总线发布成员加入事件，通知服务据此通知被添加的用户，失败仅记录日志
+func (s *groupService) publishMemberAdded(ctx context.Context, group *models.Group, member *models.GroupMember, actorID uuid.UUID, via string) {
+	err := s.events.Publish(ctx, events.TypeGroupMemberAdded, events.GroupMemberAdded{
+		GroupID:   group.ID.String(),
+		GroupName: group.Name,
+		UserID:    member.UserID.String(),
+		Role:      string(member.Role),
+		ActorID:   actorID.String(),
+		Via:       via,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to publish member added event",
+			zap.Error(err),
+			zap.String("group_id", group.ID.String()),
+			zap.String("user_id", member.UserID.String()),
+		)
+	}
+}
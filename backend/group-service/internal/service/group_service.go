@@ -5,10 +5,15 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/client"
 	"github.com/neohope/chatapp/group-service/internal/models"
 	"github.com/neohope/chatapp/group-service/internal/repository"
+	"github.com/neohope/chatapp/group-service/internal/webhook"
+	"github.com/neohope/chatapp/group-service/pkg/events"
+	"github.com/neohope/chatapp/group-service/pkg/validation"
 	"go.uber.org/zap"
 )
 
@@ -18,7 +23,9 @@ type GroupService interface {
 	CreateGroup(ctx context.Context, userID uuid.UUID, req *models.CreateGroupRequest) (*models.Group, error)
 	GetGroup(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) (*models.Group, error)
 	UpdateGroup(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.UpdateGroupRequest) (*models.Group, error)
-	DeleteGroup(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) error
+	DeleteGroup(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) (*models.GroupPendingAction, error)
+	RestoreGroup(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) (*models.Group, error)
+	PurgeDeletedGroups(ctx context.Context) (int, error)
 	GetUserGroups(ctx context.Context, userID uuid.UUID) ([]*models.GroupWithMemberCount, error)
 	SearchGroups(ctx context.Context, query string, limit, offset int) ([]*models.GroupWithMemberCount, error)
 
@@ -35,19 +42,111 @@ type GroupService interface {
 	RejectInvitation(ctx context.Context, userID uuid.UUID, invitationID uuid.UUID) error
 	GetPendingInvitations(ctx context.Context, userID uuid.UUID) ([]*models.GroupInvitation, error)
 	GetGroupInvitations(ctx context.Context, groupID uuid.UUID) ([]*models.GroupInvitation, error)
+
+	// 频道管理
+	CreateChannel(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.CreateChannelRequest) (*models.GroupChannel, error)
+	GetChannels(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) ([]*models.GroupChannel, error)
+	GetMyChannels(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) ([]*models.GroupChannel, error)
+	UpdateChannel(ctx context.Context, userID uuid.UUID, groupID, channelID uuid.UUID, req *models.UpdateChannelRequest) (*models.GroupChannel, error)
+	DeleteChannel(ctx context.Context, userID uuid.UUID, groupID, channelID uuid.UUID) error
+	JoinChannel(ctx context.Context, userID uuid.UUID, groupID, channelID uuid.UUID) error
+	LeaveChannel(ctx context.Context, userID uuid.UUID, groupID, channelID uuid.UUID) error
+	GetGroupIDByConversation(ctx context.Context, conversationID string) (uuid.UUID, error)
+
+	// Webhook管理
+	CreateWebhook(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.CreateWebhookRequest) (*models.CreateWebhookResponse, error)
+	GetWebhooks(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) ([]*models.GroupWebhook, error)
+	DeleteWebhook(ctx context.Context, userID uuid.UUID, groupID, webhookID uuid.UUID) error
+
+	// 置顶资源管理
+	CreateResource(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.CreateGroupResourceRequest) (*models.GroupResource, error)
+	GetResources(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) ([]*models.GroupResource, error)
+	UpdateResource(ctx context.Context, userID uuid.UUID, groupID, resourceID uuid.UUID, req *models.UpdateGroupResourceRequest) (*models.GroupResource, error)
+	DeleteResource(ctx context.Context, userID uuid.UUID, groupID, resourceID uuid.UUID) error
+
+	// 群公告管理，发布和置顶需要管理员权限，成员可查看
+	CreateAnnouncement(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.CreateAnnouncementRequest) (*models.GroupAnnouncement, error)
+	PinAnnouncement(ctx context.Context, userID uuid.UUID, groupID, announcementID uuid.UUID, pinned bool) (*models.GroupAnnouncement, error)
+	ListAnnouncements(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, limit, offset int) ([]*models.GroupAnnouncement, error)
+	DeleteAnnouncement(ctx context.Context, userID uuid.UUID, groupID, announcementID uuid.UUID) error
+
+	// 群主转让与双人确认，需要确认时返回待确认操作
+	TransferOwnership(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.TransferOwnershipRequest) (*models.GroupPendingAction, error)
+	SetOwnerConfirmation(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, enabled bool) (*models.GroupPendingAction, error)
+	GetPendingActions(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) ([]*models.GroupPendingAction, error)
+	ConfirmPendingAction(ctx context.Context, userID uuid.UUID, groupID, actionID uuid.UUID) (*models.GroupPendingAction, error)
+	CancelPendingAction(ctx context.Context, userID uuid.UUID, groupID, actionID uuid.UUID) (*models.GroupPendingAction, error)
+
+	// 入群问题与入群申请，问题和审核需要管理员权限
+	GetJoinQuestions(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) ([]*models.GroupJoinQuestion, error)
+	SetJoinQuestions(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.SetJoinQuestionsRequest) ([]*models.GroupJoinQuestion, error)
+	CreateJoinRequest(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.CreateJoinRequestRequest) (*models.GroupJoinRequest, error)
+	GetMyJoinRequest(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) (*models.GroupJoinRequest, error)
+	CancelJoinRequest(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) error
+	ListJoinRequests(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, status models.JoinRequestStatus, limit, offset int) ([]*models.GroupJoinRequest, error)
+	ApproveJoinRequest(ctx context.Context, userID uuid.UUID, groupID, requestID uuid.UUID) (*models.GroupJoinRequest, error)
+	RejectJoinRequest(ctx context.Context, userID uuid.UUID, groupID, requestID uuid.UUID) (*models.GroupJoinRequest, error)
+
+	// 不活跃成员清理，策略、预览和提议审核需要管理员权限
+	GetPrunePolicy(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) (*models.GroupPrunePolicy, error)
+	SetPrunePolicy(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.SetPrunePolicyRequest) (*models.GroupPrunePolicy, error)
+	PreviewPrune(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, inactiveDays int) (*models.PrunePreview, error)
+	ListPruneProposals(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, status models.PruneProposalStatus, limit, offset int) ([]*models.GroupPruneProposal, error)
+	ApprovePruneProposal(ctx context.Context, userID uuid.UUID, groupID, proposalID uuid.UUID) (*models.GroupPruneProposal, error)
+	RejectPruneProposal(ctx context.Context, userID uuid.UUID, groupID, proposalID uuid.UUID) (*models.GroupPruneProposal, error)
+	RecordMemberActivity(ctx context.Context, groupID, userID uuid.UUID, at time.Time) error
+	RunPrunePolicies(ctx context.Context) error
+
+	// 成员免打扰设置，通知服务推送前查询
+	SetMute(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.SetMuteRequest) (*models.GroupMute, error)
+	GetActiveMutes(ctx context.Context, groupID uuid.UUID) ([]*models.GroupMute, error)
+	GetConversationMutes(ctx context.Context, conversationID string) ([]*models.GroupMute, error)
+
+	// 群组统计与文件存储配额，用量由媒体服务的文件附加事件维护
+	GetGroupStats(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) (*models.GroupStats, error)
+	GetStorageUsage(ctx context.Context, groupID uuid.UUID) (*models.GroupStorageUsage, error)
+	SetStorageTier(ctx context.Context, adminID uuid.UUID, groupID uuid.UUID, req *models.SetStorageTierRequest) (*models.GroupStorageUsage, error)
+	CheckStorage(ctx context.Context, conversationID string, fileID string, size int64) (*models.StorageCheckResult, error)
+	RecordMediaAttached(ctx context.Context, groupID uuid.UUID, fileID string, ownerID uuid.UUID, size int64, at time.Time) error
+	RecordMediaDetached(ctx context.Context, groupID uuid.UUID, fileID string) error
+
+	// 新成员入群设置：欢迎消息和默认角色，需要管理员权限
+	GetOnboarding(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) (*models.GroupOnboarding, error)
+	SetOnboarding(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.SetOnboardingRequest) (*models.GroupOnboarding, error)
+
+	// 成员权限查询，供其他服务通过gRPC调用
+	GetMemberPermissions(ctx context.Context, groupID, userID uuid.UUID) (*models.MemberPermissions, error)
+	GetConversationPermissions(ctx context.Context, id, userID uuid.UUID) (*models.MemberPermissions, error)
 }
 
 // groupService 群组服务实现
 type groupService struct {
-	repo   repository.GroupRepository
-	logger *zap.Logger
+	repo          repository.GroupRepository
+	messageClient client.MessageClient
+	notifier      client.NotificationClient
+	dispatcher    *webhook.Dispatcher
+	events        events.Publisher
+	ownership     OwnershipConfig
+	storage       StorageConfig
+	logger        *zap.Logger
 }
 
 // NewGroupService 创建群组服务
-func NewGroupService(repo repository.GroupRepository, logger *zap.Logger) GroupService {
+// messageClient为nil时频道不会关联消息服务会话，notifier为nil时不发送入群申请通知，dispatcher为nil时不投递成员变更Webhook，
+// publisher为nil时不发布领域事件
+func NewGroupService(repo repository.GroupRepository, messageClient client.MessageClient, notifier client.NotificationClient, dispatcher *webhook.Dispatcher, publisher events.Publisher, ownership OwnershipConfig, storage StorageConfig, logger *zap.Logger) GroupService {
+	if publisher == nil {
+		publisher = events.NopPublisher()
+	}
 	return &groupService{
-		repo:   repo,
-		logger: logger,
+		repo:          repo,
+		messageClient: messageClient,
+		notifier:      notifier,
+		dispatcher:    dispatcher,
+		events:        publisher,
+		ownership:     ownership,
+		storage:       storage,
+		logger:        logger,
 	}
 }
 
@@ -58,17 +157,23 @@ func (s *groupService) CreateGroup(ctx context.Context, userID uuid.UUID, req *m
 		return nil, err
 	}
 
+	format := req.DescriptionFormat
+	if format == "" {
+		format = models.DescriptionFormatPlain
+	}
+
 	// 创建群组
 	group := &models.Group{
-		ID:          uuid.New(),
-		Name:        strings.TrimSpace(req.Name),
-		Description: strings.TrimSpace(req.Description),
-		AvatarURL:   req.AvatarURL,
-		OwnerID:     userID,
-		MaxMembers:  req.MaxMembers,
-		IsPrivate:   req.IsPrivate,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:                uuid.New(),
+		Name:              strings.TrimSpace(req.Name),
+		Description:       strings.TrimSpace(req.Description),
+		DescriptionFormat: format,
+		AvatarURL:         req.AvatarURL,
+		OwnerID:           userID,
+		MaxMembers:        req.MaxMembers,
+		IsPrivate:         req.IsPrivate,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
 	}
 
 	if group.MaxMembers == 0 {
@@ -98,6 +203,11 @@ func (s *groupService) CreateGroup(ctx context.Context, userID uuid.UUID, req *m
 		return nil, fmt.Errorf("failed to add owner as member: %w", err)
 	}
 
+	// 创建默认频道，失败不影响群组创建
+	if _, err := s.createDefaultChannel(ctx, group.ID, userID); err != nil {
+		s.logger.Warn("Failed to create default channel", zap.Error(err), zap.String("group_id", group.ID.String()))
+	}
+
 	s.logger.Info("Group created successfully", zap.String("group_id", group.ID.String()), zap.String("owner_id", userID.String()))
 	return group, nil
 }
@@ -123,7 +233,14 @@ func (s *groupService) GetGroup(ctx context.Context, userID uuid.UUID, groupID u
 		}
 	}
 
-	return group, nil
+	// 附带置顶资源，返回副本以免修改仓库中的对象
+	resources, err := s.repo.GetGroupResources(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group resources: %w", err)
+	}
+	detail := *group
+	detail.Resources = resources
+	return &detail, nil
 }
 
 // UpdateGroup 更新群组信息
@@ -133,10 +250,15 @@ func (s *groupService) UpdateGroup(ctx context.Context, userID uuid.UUID, groupI
 		return nil, err
 	}
 
-	// 验证输入
+	// 验证输入，简介长度限制取决于更新后的格式
 	if err := s.validateUpdateGroupRequest(req); err != nil {
 		return nil, err
 	}
+	if req.Description != nil || req.DescriptionFormat != nil {
+		if err := s.validateDescriptionUpdate(ctx, groupID, req); err != nil {
+			return nil, err
+		}
+	}
 
 	// 构建更新字段
 	updates := make(map[string]interface{})
@@ -146,6 +268,9 @@ func (s *groupService) UpdateGroup(ctx context.Context, userID uuid.UUID, groupI
 	if req.Description != nil {
 		updates["description"] = strings.TrimSpace(*req.Description)
 	}
+	if req.DescriptionFormat != nil {
+		updates["description_format"] = *req.DescriptionFormat
+	}
 	if req.AvatarURL != nil {
 		updates["avatar_url"] = *req.AvatarURL
 	}
@@ -170,20 +295,16 @@ func (s *groupService) UpdateGroup(ctx context.Context, userID uuid.UUID, groupI
 	return s.repo.GetGroupByID(ctx, groupID)
 }
 
-// DeleteGroup 删除群组
-func (s *groupService) DeleteGroup(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) error {
+// DeleteGroup 解散群组，群主和联合群主可以发起，解散后在保留期内可以恢复
+// 开启双人确认且存在其他群主时返回待确认操作，群组在另一位群主确认后解散
+func (s *groupService) DeleteGroup(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) (*models.GroupPendingAction, error) {
 	// 检查是否为群主
-	if err := s.checkOwnerPermission(ctx, userID, groupID); err != nil {
-		return err
-	}
-
-	if err := s.repo.DeleteGroup(ctx, groupID); err != nil {
-		s.logger.Error("Failed to delete group", zap.Error(err), zap.String("group_id", groupID.String()))
-		return fmt.Errorf("failed to delete group: %w", err)
+	group, err := s.getOwnedGroup(ctx, userID, groupID)
+	if err != nil {
+		return nil, err
 	}
 
-	s.logger.Info("Group deleted successfully", zap.String("group_id", groupID.String()), zap.String("owner_id", userID.String()))
-	return nil
+	return s.requestOwnerAction(ctx, userID, group, models.PendingActionDeleteGroup, nil)
 }
 
 // GetUserGroups 获取用户加入的群组
@@ -245,11 +366,14 @@ func (s *groupService) AddMember(ctx context.Context, userID uuid.UUID, groupID
 		return fmt.Errorf("group has reached maximum member limit")
 	}
 
-	// 添加成员
-	role := models.RoleMember
+	// 添加成员，未指定角色时使用入群设置的默认角色，联合群主只能由群主从现有成员中任命
+	role := s.defaultMemberRole(ctx, groupID)
 	if req.Role != "" {
 		role = req.Role
 	}
+	if role != models.RoleAdmin && role != models.RoleMember {
+		return fmt.Errorf("invalid member role")
+	}
 
 	member := &models.GroupMember{
 		ID:       uuid.New(),
@@ -266,6 +390,17 @@ func (s *groupService) AddMember(ctx context.Context, userID uuid.UUID, groupID
 		return fmt.Errorf("failed to add member: %w", err)
 	}
 
+	s.joinDefaultChannels(ctx, groupID, req.UserID)
+	s.publishMembershipEvent(ctx, &models.MembershipEvent{
+		Event:   models.WebhookEventMemberAdded,
+		GroupID: groupID,
+		UserID:  req.UserID,
+		Role:    role,
+		ActorID: userID,
+	})
+	s.publishMemberAdded(ctx, group, member, userID, events.JoinViaAdded)
+	s.sendWelcomeMessage(group, req.UserID)
+
 	s.logger.Info("Member added successfully", zap.String("group_id", groupID.String()), zap.String("user_id", req.UserID.String()))
 	return nil
 }
@@ -293,6 +428,9 @@ func (s *groupService) RemoveMember(ctx context.Context, userID uuid.UUID, group
 	if targetMember.Role == models.RoleOwner {
 		return fmt.Errorf("cannot remove group owner")
 	}
+	if err := s.checkCoOwnerChange(ctx, userID, groupID, targetMember, nil, true); err != nil {
+		return err
+	}
 
 	// 移除成员
 	if err := s.repo.RemoveMember(ctx, groupID, targetUserID); err != nil {
@@ -300,6 +438,15 @@ func (s *groupService) RemoveMember(ctx context.Context, userID uuid.UUID, group
 		return fmt.Errorf("failed to remove member: %w", err)
 	}
 
+	s.leaveAllChannels(ctx, groupID, targetUserID)
+	s.publishMembershipEvent(ctx, &models.MembershipEvent{
+		Event:    models.WebhookEventMemberRemoved,
+		GroupID:  groupID,
+		UserID:   targetUserID,
+		PrevRole: targetMember.Role,
+		ActorID:  userID,
+	})
+
 	s.logger.Info("Member removed successfully", zap.String("group_id", groupID.String()), zap.String("target_user_id", targetUserID.String()))
 	return nil
 }
@@ -324,6 +471,14 @@ func (s *groupService) UpdateMember(ctx context.Context, userID uuid.UUID, group
 	if targetMember.Role == models.RoleOwner {
 		return fmt.Errorf("cannot modify group owner")
 	}
+	if req.Role != nil && *req.Role == models.RoleOwner {
+		return fmt.Errorf("invalid member role: use ownership transfer instead")
+	}
+	demote := (req.Role != nil && *req.Role != models.RoleCoOwner) ||
+		(req.Status != nil && *req.Status != models.StatusActive)
+	if err := s.checkCoOwnerChange(ctx, userID, groupID, targetMember, req.Role, demote); err != nil {
+		return err
+	}
 
 	// 构建更新字段
 	updates := make(map[string]interface{})
@@ -347,6 +502,17 @@ func (s *groupService) UpdateMember(ctx context.Context, userID uuid.UUID, group
 		return fmt.Errorf("failed to update member: %w", err)
 	}
 
+	if req.Role != nil && *req.Role != targetMember.Role {
+		s.publishMembershipEvent(ctx, &models.MembershipEvent{
+			Event:    models.WebhookEventMemberRoleChanged,
+			GroupID:  groupID,
+			UserID:   targetUserID,
+			Role:     *req.Role,
+			PrevRole: targetMember.Role,
+			ActorID:  userID,
+		})
+	}
+
 	s.logger.Info("Member updated successfully", zap.String("group_id", groupID.String()), zap.String("target_user_id", targetUserID.String()))
 	return nil
 }
@@ -391,6 +557,15 @@ func (s *groupService) LeaveGroup(ctx context.Context, userID uuid.UUID, groupID
 		return fmt.Errorf("failed to leave group: %w", err)
 	}
 
+	s.leaveAllChannels(ctx, groupID, userID)
+	s.publishMembershipEvent(ctx, &models.MembershipEvent{
+		Event:    models.WebhookEventMemberRemoved,
+		GroupID:  groupID,
+		UserID:   userID,
+		PrevRole: member.Role,
+		ActorID:  userID,
+	})
+
 	s.logger.Info("User left group successfully", zap.String("group_id", groupID.String()), zap.String("user_id", userID.String()))
 	return nil
 }
@@ -480,12 +655,12 @@ func (s *groupService) AcceptInvitation(ctx context.Context, userID uuid.UUID, i
 		return fmt.Errorf("group has reached maximum member limit")
 	}
 
-	// 添加成员
+	// 添加成员，角色使用入群设置的默认角色
 	member := &models.GroupMember{
 		ID:       uuid.New(),
 		GroupID:  invitation.GroupID,
 		UserID:   userID,
-		Role:     models.RoleMember,
+		Role:     s.defaultMemberRole(ctx, invitation.GroupID),
 		Status:   models.StatusActive,
 		JoinedAt: time.Now(),
 	}
@@ -495,6 +670,17 @@ func (s *groupService) AcceptInvitation(ctx context.Context, userID uuid.UUID, i
 		return fmt.Errorf("failed to add member: %w", err)
 	}
 
+	s.joinDefaultChannels(ctx, invitation.GroupID, userID)
+	s.publishMembershipEvent(ctx, &models.MembershipEvent{
+		Event:   models.WebhookEventMemberAdded,
+		GroupID: invitation.GroupID,
+		UserID:  userID,
+		Role:    member.Role,
+		ActorID: userID,
+	})
+	s.publishMemberAdded(ctx, group, member, userID, events.JoinViaInvitation)
+	s.sendWelcomeMessage(group, userID)
+
 	// 更新邀请状态
 	if err := s.repo.UpdateInvitationStatus(ctx, invitationID, models.InvitationAccepted); err != nil {
 		s.logger.Error("Failed to update invitation status", zap.Error(err), zap.String("invitation_id", invitationID.String()))
@@ -554,7 +740,7 @@ func (s *groupService) GetGroupInvitations(ctx context.Context, groupID uuid.UUI
 
 // 权限检查方法
 
-// checkOwnerPermission 检查群主权限
+// checkOwnerPermission 检查群主权限，联合群主同样拥有群主权限
 func (s *groupService) checkOwnerPermission(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) error {
 	member, err := s.repo.GetMember(ctx, groupID, userID)
 	if err != nil {
@@ -563,7 +749,7 @@ func (s *groupService) checkOwnerPermission(ctx context.Context, userID uuid.UUI
 	if member == nil {
 		return fmt.Errorf("not a member of this group")
 	}
-	if member.Role != models.RoleOwner {
+	if !member.Role.IsOwner() {
 		return fmt.Errorf("access denied: owner permission required")
 	}
 	return nil
@@ -578,7 +764,7 @@ func (s *groupService) checkAdminPermission(ctx context.Context, userID uuid.UUI
 	if member == nil {
 		return fmt.Errorf("not a member of this group")
 	}
-	if member.Role != models.RoleOwner && member.Role != models.RoleAdmin {
+	if !member.Role.IsAdmin() {
 		return fmt.Errorf("access denied: admin permission required")
 	}
 	return nil
@@ -598,21 +784,9 @@ func (s *groupService) checkMemberPermission(ctx context.Context, userID uuid.UU
 
 // 验证方法
 
-// validateCreateGroupRequest 验证创建群组请求
+// validateCreateGroupRequest 验证创建群组请求，处理函数已校验过，这里保证其他调用方同样受约束
 func (s *groupService) validateCreateGroupRequest(req *models.CreateGroupRequest) error {
-	if strings.TrimSpace(req.Name) == "" {
-		return fmt.Errorf("group name is required")
-	}
-	if len(req.Name) > 50 {
-		return fmt.Errorf("group name too long")
-	}
-	if len(req.Description) > 200 {
-		return fmt.Errorf("group description too long")
-	}
-	if req.MaxMembers < 2 || req.MaxMembers > 500 {
-		return fmt.Errorf("max members must be between 2 and 500")
-	}
-	return nil
+	return validation.Struct(req)
 }
 
 // validateUpdateGroupRequest 验证更新群组请求
@@ -625,11 +799,35 @@ func (s *groupService) validateUpdateGroupRequest(req *models.UpdateGroupRequest
 			return fmt.Errorf("group name too long")
 		}
 	}
-	if req.Description != nil && len(*req.Description) > 200 {
-		return fmt.Errorf("group description too long")
+	if req.DescriptionFormat != nil && !req.DescriptionFormat.IsValid() {
+		return fmt.Errorf("invalid description format")
 	}
 	if req.MaxMembers != nil && (*req.MaxMembers < 2 || *req.MaxMembers > 500) {
 		return fmt.Errorf("max members must be between 2 and 500")
 	}
 	return nil
 }
+
+// validateDescriptionUpdate 按更新后的格式检查简介长度，未修改的一项取群组当前值
+func (s *groupService) validateDescriptionUpdate(ctx context.Context, groupID uuid.UUID, req *models.UpdateGroupRequest) error {
+	group, err := s.repo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		return fmt.Errorf("group not found")
+	}
+
+	description := group.Description
+	if req.Description != nil {
+		description = strings.TrimSpace(*req.Description)
+	}
+	format := group.DescriptionFormat
+	if req.DescriptionFormat != nil {
+		format = *req.DescriptionFormat
+	}
+	if utf8.RuneCountInString(description) > format.MaxDescriptionLength() {
+		return fmt.Errorf("group description too long")
+	}
+	return nil
+}
@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/pkg/events"
+	"github.com/neohope/chatapp/group-service/pkg/validation"
+	"go.uber.org/zap"
+)
+
+// notificationTimeout 发送一批群组通知的超时时间
+const notificationTimeout = 10 * time.Second
+
+// GetJoinQuestions 获取群组的入群问题，申请人提交申请前查看
+func (s *groupService) GetJoinQuestions(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) ([]*models.GroupJoinQuestion, error) {
+	if _, err := s.getExistingGroup(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	questions, err := s.repo.GetJoinQuestions(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get join questions: %w", err)
+	}
+	if questions == nil {
+		return []*models.GroupJoinQuestion{}, nil
+	}
+	return questions, nil
+}
+
+// SetJoinQuestions 整体替换入群问题，仅管理员和群主可设置，已提交的申请保留原问题和回答
+func (s *groupService) SetJoinQuestions(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.SetJoinQuestionsRequest) ([]*models.GroupJoinQuestion, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	// 验证输入
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	questions := make([]*models.GroupJoinQuestion, 0, len(req.Questions))
+	for i, input := range req.Questions {
+		questions = append(questions, &models.GroupJoinQuestion{
+			ID:        uuid.New(),
+			GroupID:   groupID,
+			Position:  i,
+			Question:  strings.TrimSpace(input.Question),
+			Required:  input.Required,
+			CreatedAt: now,
+		})
+	}
+
+	if err := s.repo.ReplaceJoinQuestions(ctx, groupID, questions); err != nil {
+		s.logger.Error("Failed to set join questions", zap.Error(err), zap.String("group_id", groupID.String()))
+		return nil, fmt.Errorf("failed to set join questions: %w", err)
+	}
+
+	s.logger.Info("Join questions updated", zap.String("group_id", groupID.String()), zap.Int("count", len(questions)))
+	return questions, nil
+}
+
+// CreateJoinRequest 提交入群申请，回答按当前入群问题校验，提交后通知群组管理员审核
+func (s *groupService) CreateJoinRequest(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.CreateJoinRequestRequest) (*models.GroupJoinRequest, error) {
+	// 验证输入
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	group, err := s.getExistingGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 已是成员或被封禁的用户不能申请
+	member, err := s.repo.GetMember(ctx, groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member: %w", err)
+	}
+	if member != nil {
+		if member.Status == models.StatusBanned {
+			return nil, fmt.Errorf("access denied: banned from this group")
+		}
+		return nil, fmt.Errorf("already a member of this group")
+	}
+	if err := s.checkMemberLimit(ctx, group); err != nil {
+		return nil, err
+	}
+
+	questions, err := s.repo.GetJoinQuestions(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get join questions: %w", err)
+	}
+	answers, err := models.MatchAnswers(questions, req.Answers)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &models.GroupJoinRequest{
+		ID:        uuid.New(),
+		GroupID:   groupID,
+		UserID:    userID,
+		Status:    models.JoinRequestPending,
+		Answers:   answers,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.CreateJoinRequest(ctx, request); err != nil {
+		if strings.Contains(err.Error(), "already pending") {
+			return nil, err
+		}
+		s.logger.Error("Failed to create join request", zap.Error(err), zap.String("group_id", groupID.String()))
+		return nil, fmt.Errorf("failed to create join request: %w", err)
+	}
+
+	s.notifyJoinRequestReviewers(ctx, group, request)
+
+	s.logger.Info("Join request created", zap.String("group_id", groupID.String()), zap.String("request_id", request.ID.String()), zap.String("user_id", userID.String()))
+	return request, nil
+}
+
+// GetMyJoinRequest 获取自己在群组中待审核的入群申请
+func (s *groupService) GetMyJoinRequest(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) (*models.GroupJoinRequest, error) {
+	request, err := s.repo.GetPendingJoinRequest(ctx, groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get join request: %w", err)
+	}
+	if request == nil {
+		return nil, fmt.Errorf("join request not found")
+	}
+	return request, nil
+}
+
+// CancelJoinRequest 申请人撤回待审核的入群申请
+func (s *groupService) CancelJoinRequest(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) error {
+	request, err := s.GetMyJoinRequest(ctx, userID, groupID)
+	if err != nil {
+		return err
+	}
+
+	resolved, err := s.repo.ResolveJoinRequest(ctx, request.ID, models.JoinRequestCancelled, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel join request: %w", err)
+	}
+	if !resolved {
+		return fmt.Errorf("join request already resolved")
+	}
+
+	s.logger.Info("Join request cancelled", zap.String("group_id", groupID.String()), zap.String("request_id", request.ID.String()))
+	return nil
+}
+
+// ListJoinRequests 分页获取群组的入群申请及回答，仅管理员和群主可查看
+func (s *groupService) ListJoinRequests(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, status models.JoinRequestStatus, limit, offset int) ([]*models.GroupJoinRequest, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	if status == "" {
+		status = models.JoinRequestPending
+	}
+	switch status {
+	case models.JoinRequestPending, models.JoinRequestApproved, models.JoinRequestRejected, models.JoinRequestCancelled:
+	default:
+		return nil, fmt.Errorf("invalid join request status: %s", status)
+	}
+
+	requests, err := s.repo.GetGroupJoinRequests(ctx, groupID, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get join requests: %w", err)
+	}
+	if requests == nil {
+		return []*models.GroupJoinRequest{}, nil
+	}
+	return requests, nil
+}
+
+// ApproveJoinRequest 通过入群申请，申请人以入群设置的默认角色加入并收到通知
+func (s *groupService) ApproveJoinRequest(ctx context.Context, userID uuid.UUID, groupID, requestID uuid.UUID) (*models.GroupJoinRequest, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+	request, err := s.getPendingGroupJoinRequest(ctx, groupID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.getExistingGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := s.repo.GetMember(ctx, groupID, request.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member: %w", err)
+	}
+	if member != nil {
+		return nil, fmt.Errorf("already a member of this group")
+	}
+	if err := s.checkMemberLimit(ctx, group); err != nil {
+		return nil, err
+	}
+
+	// 先抢占状态再添加成员，避免并发审核
+	resolved, err := s.repo.ResolveJoinRequest(ctx, requestID, models.JoinRequestApproved, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve join request: %w", err)
+	}
+	if !resolved {
+		return nil, fmt.Errorf("join request already resolved")
+	}
+
+	newMember := &models.GroupMember{
+		ID:       uuid.New(),
+		GroupID:  groupID,
+		UserID:   request.UserID,
+		Role:     s.defaultMemberRole(ctx, groupID),
+		Status:   models.StatusActive,
+		JoinedAt: time.Now(),
+	}
+	if err := s.repo.AddMember(ctx, newMember); err != nil {
+		s.logger.Error("Failed to add member for approved join request", zap.Error(err), zap.String("request_id", requestID.String()))
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+
+	s.joinDefaultChannels(ctx, groupID, request.UserID)
+	s.publishMembershipEvent(ctx, &models.MembershipEvent{
+		Event:   models.WebhookEventMemberAdded,
+		GroupID: groupID,
+		UserID:  request.UserID,
+		Role:    newMember.Role,
+		ActorID: userID,
+	})
+	s.publishMemberAdded(ctx, group, newMember, userID, events.JoinViaJoinRequest)
+	s.sendWelcomeMessage(group, request.UserID)
+	s.sendGroupNotification([]uuid.UUID{request.UserID}, "Join request approved",
+		fmt.Sprintf("You have joined %s", group.Name),
+		joinRequestNotificationData(request, "join_request_approved"))
+
+	s.logger.Info("Join request approved", zap.String("group_id", groupID.String()), zap.String("request_id", requestID.String()), zap.String("reviewed_by", userID.String()))
+	markJoinRequestResolved(request, models.JoinRequestApproved, userID)
+	return request, nil
+}
+
+// RejectJoinRequest 拒绝入群申请并通知申请人
+func (s *groupService) RejectJoinRequest(ctx context.Context, userID uuid.UUID, groupID, requestID uuid.UUID) (*models.GroupJoinRequest, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+	request, err := s.getPendingGroupJoinRequest(ctx, groupID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.getExistingGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := s.repo.ResolveJoinRequest(ctx, requestID, models.JoinRequestRejected, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reject join request: %w", err)
+	}
+	if !resolved {
+		return nil, fmt.Errorf("join request already resolved")
+	}
+
+	s.sendGroupNotification([]uuid.UUID{request.UserID}, "Join request declined",
+		fmt.Sprintf("Your request to join %s was declined", group.Name),
+		joinRequestNotificationData(request, "join_request_rejected"))
+
+	s.logger.Info("Join request rejected", zap.String("group_id", groupID.String()), zap.String("request_id", requestID.String()), zap.String("reviewed_by", userID.String()))
+	markJoinRequestResolved(request, models.JoinRequestRejected, userID)
+	return request, nil
+}
+
+// getExistingGroup 获取群组，不存在时返回错误
+func (s *groupService) getExistingGroup(ctx context.Context, groupID uuid.UUID) (*models.Group, error) {
+	group, err := s.repo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		return nil, fmt.Errorf("group not found")
+	}
+	return group, nil
+}
+
+// checkMemberLimit 检查群组成员数量限制
+func (s *groupService) checkMemberLimit(ctx context.Context, group *models.Group) error {
+	memberCount, err := s.repo.GetMemberCount(ctx, group.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get member count: %w", err)
+	}
+	if memberCount >= group.MaxMembers {
+		return fmt.Errorf("group has reached maximum member limit")
+	}
+	return nil
+}
+
+// getPendingGroupJoinRequest 获取属于指定群组且待审核的入群申请
+func (s *groupService) getPendingGroupJoinRequest(ctx context.Context, groupID, requestID uuid.UUID) (*models.GroupJoinRequest, error) {
+	request, err := s.repo.GetJoinRequest(ctx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get join request: %w", err)
+	}
+	if request == nil || request.GroupID != groupID {
+		return nil, fmt.Errorf("join request not found")
+	}
+	if request.Status != models.JoinRequestPending {
+		return nil, fmt.Errorf("join request already resolved")
+	}
+	return request, nil
+}
+
+// notifyJoinRequestReviewers 通知群组的管理员和群主有新的入群申请
+func (s *groupService) notifyJoinRequestReviewers(ctx context.Context, group *models.Group, request *models.GroupJoinRequest) {
+	if s.notifier == nil {
+		return
+	}
+	reviewers, err := s.activeAdmins(ctx, group.ID)
+	if err != nil {
+		s.logger.Warn("Failed to load join request reviewers", zap.Error(err), zap.String("group_id", group.ID.String()))
+		return
+	}
+	s.sendGroupNotification(reviewers, "New join request",
+		fmt.Sprintf("Someone asked to join %s", group.Name),
+		joinRequestNotificationData(request, "join_request_created"))
+}
+
+// activeAdmins 获取群组中状态正常的管理员和群主
+func (s *groupService) activeAdmins(ctx context.Context, groupID uuid.UUID) ([]uuid.UUID, error) {
+	members, err := s.repo.GetGroupMembers(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	var admins []uuid.UUID
+	for _, member := range members {
+		if member.Role.IsAdmin() && member.Status == models.StatusActive {
+			admins = append(admins, member.UserID)
+		}
+	}
+	return admins, nil
+}
+
+// sendGroupNotification 异步发送群组通知，失败只记录日志，不影响入群流程
+func (s *groupService) sendGroupNotification(userIDs []uuid.UUID, title, body string, data map[string]interface{}) {
+	if s.notifier == nil || len(userIDs) == 0 {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notificationTimeout)
+		defer cancel()
+		for _, userID := range userIDs {
+			if err := s.notifier.SendGroupNotification(ctx, userID, title, body, data); err != nil {
+				s.logger.Warn("Failed to send group notification", zap.Error(err), zap.String("user_id", userID.String()))
+			}
+		}
+	}()
+}
+
+// joinRequestNotificationData 入群申请通知附带的数据，客户端据此跳转到审核或群组页面
+func joinRequestNotificationData(request *models.GroupJoinRequest, kind string) map[string]interface{} {
+	return map[string]interface{}{
+		"kind":       kind,
+		"group_id":   request.GroupID.String(),
+		"request_id": request.ID.String(),
+		"user_id":    request.UserID.String(),
+	}
+}
+
+// markJoinRequestResolved 更新本地的入群申请状态，与仓库中的更新保持一致
+func markJoinRequestResolved(request *models.GroupJoinRequest, status models.JoinRequestStatus, reviewedBy uuid.UUID) {
+	now := time.Now()
+	request.Status = status
+	request.ReviewedBy = &reviewedBy
+	request.ReviewedAt = &now
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/pkg/events"
+	"go.uber.org/zap"
+)
+
+// MediaUsageEventTypes 群组服务订阅的媒体事件，用于统计群组文件存储用量
+var MediaUsageEventTypes = []string{
+	events.TypeMediaAttached,
+	events.TypeMediaDetached,
+}
+
+// MediaUsageConsumer 根据media-service发布的文件附加/移除事件维护群组的文件存储用量
+type MediaUsageConsumer struct {
+	groups GroupService
+	logger *zap.Logger
+}
+
+func NewMediaUsageConsumer(groups GroupService, logger *zap.Logger) *MediaUsageConsumer {
+	return &MediaUsageConsumer{
+		groups: groups,
+		logger: logger,
+	}
+}
+
+// Handle 按会话所属的群组统计文件，单聊等不属于群组的会话忽略
+func (c *MediaUsageConsumer) Handle(ctx context.Context, event *events.Event) error {
+	switch event.Type {
+	case events.TypeMediaAttached:
+		var data events.MediaAttached
+		if err := event.Decode(&data); err != nil {
+			return fmt.Errorf("invalid %s event: %w", event.Type, err)
+		}
+		groupID, err := c.groups.GetGroupIDByConversation(ctx, data.ConversationID)
+		if err != nil {
+			return ignoreConversationNotFound(err)
+		}
+		ownerID, err := uuid.Parse(data.OwnerID)
+		if err != nil {
+			return fmt.Errorf("invalid owner id %q: %w", data.OwnerID, err)
+		}
+		return c.groups.RecordMediaAttached(ctx, groupID, data.FileID, ownerID, data.Size, event.OccurredAt)
+	case events.TypeMediaDetached:
+		var data events.MediaDetached
+		if err := event.Decode(&data); err != nil {
+			return fmt.Errorf("invalid %s event: %w", event.Type, err)
+		}
+		groupID, err := c.groups.GetGroupIDByConversation(ctx, data.ConversationID)
+		if err != nil {
+			return ignoreConversationNotFound(err)
+		}
+		return c.groups.RecordMediaDetached(ctx, groupID, data.FileID)
+	}
+	return nil
+}
+
+// ignoreConversationNotFound 不属于群组的会话不需要处理，其他错误返回给事件总线记录
+func ignoreConversationNotFound(err error) error {
+	if strings.Contains(err.Error(), "conversation not found") {
+		return nil
+	}
+	return err
+}
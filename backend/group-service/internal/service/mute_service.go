@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/pkg/validation"
+	"go.uber.org/zap"
+)
+
+// muteDurations 可选的限时免打扰时长
+var muteDurations = map[string]time.Duration{
+	models.MuteDurationOneHour:    time.Hour,
+	models.MuteDurationEightHours: 8 * time.Hour,
+}
+
+// SetMute 设置或取消群组免打扰，只影响通知服务的推送，仅活跃成员可以设置
+func (s *groupService) SetMute(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.SetMuteRequest) (*models.GroupMute, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkMemberPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	mute := &models.GroupMute{
+		GroupID:   groupID,
+		UserID:    userID,
+		UpdatedAt: now,
+	}
+	if req.Duration == models.MuteDurationOff {
+		if err := s.repo.DeleteMute(ctx, groupID, userID); err != nil {
+			return nil, fmt.Errorf("failed to unmute group: %w", err)
+		}
+		return mute, nil
+	}
+
+	mute.Muted = true
+	if duration, ok := muteDurations[req.Duration]; ok {
+		until := now.Add(duration)
+		mute.MutedUntil = &until
+	}
+	if err := s.repo.SetMute(ctx, mute); err != nil {
+		return nil, fmt.Errorf("failed to mute group: %w", err)
+	}
+
+	s.logger.Debug("Group muted",
+		zap.String("group_id", groupID.String()),
+		zap.String("user_id", userID.String()),
+		zap.String("duration", req.Duration),
+	)
+	return mute, nil
+}
+
+// GetActiveMutes 获取群组中仍然有效的免打扰设置，供通知服务在推送前查询
+func (s *groupService) GetActiveMutes(ctx context.Context, groupID uuid.UUID) ([]*models.GroupMute, error) {
+	mutes, err := s.repo.GetActiveMutes(ctx, groupID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group mutes: %w", err)
+	}
+	return mutes, nil
+}
+
+// GetConversationMutes 获取会话所属群组中仍然有效的免打扰设置，频道会话按频道所在的群组查询
+// 会话不属于任何群组时返回空列表
+func (s *groupService) GetConversationMutes(ctx context.Context, conversationID string) ([]*models.GroupMute, error) {
+	groupID, err := s.GetGroupIDByConversation(ctx, conversationID)
+	if err != nil {
+		if strings.Contains(err.Error(), "conversation not found") {
+			return []*models.GroupMute{}, nil
+		}
+		return nil, err
+	}
+	return s.GetActiveMutes(ctx, groupID)
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/internal/repository"
+)
+
+func TestChannelConversationMutes(t *testing.T) {
+	ctx := context.Background()
+	ownerID := uuid.New()
+	groups, group, conversationID := newTestGroup(t, repository.NewMemoryGroupRepository(), ownerID)
+
+	if _, err := groups.SetMute(ctx, ownerID, group.ID, &models.SetMuteRequest{Duration: models.MuteDurationForever}); err != nil {
+		t.Fatalf("SetMute: %v", err)
+	}
+
+	mutes, err := groups.GetConversationMutes(ctx, conversationID)
+	if err != nil {
+		t.Fatalf("GetConversationMutes: %v", err)
+	}
+	if len(mutes) != 1 || mutes[0].UserID != ownerID {
+		t.Fatalf("expected group mute to apply to channel conversation, got %+v", mutes)
+	}
+
+	mutes, err = groups.GetConversationMutes(ctx, uuid.New().String())
+	if err != nil {
+		t.Fatalf("GetConversationMutes: %v", err)
+	}
+	if len(mutes) != 0 {
+		t.Fatalf("expected no mutes for conversation outside any group, got %+v", mutes)
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/pkg/validation"
+	"go.uber.org/zap"
+)
+
+// GetOnboarding 获取新成员入群设置，仅管理员和群主可查看
+func (s *groupService) GetOnboarding(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) (*models.GroupOnboarding, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	return s.getOnboarding(ctx, groupID)
+}
+
+// SetOnboarding 设置新成员入群设置，仅管理员和群主可设置
+// 开启欢迎消息时模板不能为空，指定的欢迎频道必须属于该群组且所有成员可以加入
+func (s *groupService) SetOnboarding(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.SetOnboardingRequest) (*models.GroupOnboarding, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	// 验证输入
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+	message := strings.TrimSpace(req.WelcomeMessage)
+	if req.WelcomeEnabled && message == "" {
+		return nil, fmt.Errorf("invalid welcome message: message is required when welcome is enabled")
+	}
+	if req.WelcomeChannelID != nil {
+		channel, err := s.repo.GetChannel(ctx, *req.WelcomeChannelID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get channel: %w", err)
+		}
+		if channel == nil || channel.GroupID != groupID {
+			return nil, fmt.Errorf("channel not found")
+		}
+		if channel.Permission == models.ChannelPermissionAdminOnly {
+			return nil, fmt.Errorf("invalid welcome channel: new members cannot join admin only channels")
+		}
+	}
+
+	now := time.Now()
+	onboarding := &models.GroupOnboarding{
+		GroupID:          groupID,
+		WelcomeEnabled:   req.WelcomeEnabled,
+		WelcomeMessage:   message,
+		WelcomeChannelID: req.WelcomeChannelID,
+		DefaultRole:      req.DefaultRole,
+		UpdatedBy:        &userID,
+		UpdatedAt:        &now,
+	}
+	if onboarding.DefaultRole == "" {
+		onboarding.DefaultRole = models.RoleMember
+	}
+
+	if err := s.repo.UpsertOnboarding(ctx, onboarding); err != nil {
+		s.logger.Error("Failed to set onboarding", zap.Error(err), zap.String("group_id", groupID.String()))
+		return nil, fmt.Errorf("failed to set onboarding: %w", err)
+	}
+
+	s.logger.Info("Onboarding updated",
+		zap.String("group_id", groupID.String()),
+		zap.Bool("welcome_enabled", onboarding.WelcomeEnabled),
+		zap.String("default_role", string(onboarding.DefaultRole)),
+		zap.String("updated_by", userID.String()),
+	)
+	return onboarding, nil
+}
+
+// getOnboarding 获取群组的入群设置，未设置时返回默认设置
+func (s *groupService) getOnboarding(ctx context.Context, groupID uuid.UUID) (*models.GroupOnboarding, error) {
+	onboarding, err := s.repo.GetOnboarding(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get onboarding: %w", err)
+	}
+	if onboarding == nil {
+		return &models.GroupOnboarding{GroupID: groupID, DefaultRole: models.RoleMember}, nil
+	}
+	return onboarding, nil
+}
+
+// defaultMemberRole 新成员加入时的角色，读取设置失败时为member
+func (s *groupService) defaultMemberRole(ctx context.Context, groupID uuid.UUID) models.GroupMemberRole {
+	onboarding, err := s.getOnboarding(ctx, groupID)
+	if err != nil {
+		s.logger.Warn("Failed to get onboarding for default role", zap.Error(err), zap.String("group_id", groupID.String()))
+		return models.RoleMember
+	}
+	return onboarding.DefaultRole
+}
+
+// sendWelcomeMessage 新成员加入后以群主身份在欢迎频道发送欢迎消息，在后台发送，失败仅记录日志
+func (s *groupService) sendWelcomeMessage(group *models.Group, userID uuid.UUID) {
+	if s.messageClient == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notificationTimeout)
+		defer cancel()
+
+		onboarding, err := s.getOnboarding(ctx, group.ID)
+		if err != nil {
+			s.logger.Warn("Failed to get onboarding for welcome message", zap.Error(err), zap.String("group_id", group.ID.String()))
+			return
+		}
+		if !onboarding.WelcomeEnabled || onboarding.WelcomeMessage == "" {
+			return
+		}
+
+		channel, err := s.welcomeChannel(ctx, onboarding)
+		if err != nil {
+			s.logger.Warn("Failed to get welcome channel", zap.Error(err), zap.String("group_id", group.ID.String()))
+			return
+		}
+		if channel == nil || channel.ConversationID == "" {
+			return
+		}
+
+		username, err := s.repo.GetUsername(ctx, userID)
+		if err != nil {
+			s.logger.Warn("Failed to get username for welcome message", zap.Error(err), zap.String("user_id", userID.String()))
+			return
+		}
+		if username == "" {
+			username = userID.String()
+		}
+
+		content := models.RenderWelcomeMessage(onboarding.WelcomeMessage, username, group.Name)
+		metadata := map[string]interface{}{
+			"kind":       "group_welcome",
+			"group_id":   group.ID.String(),
+			"channel_id": channel.ID.String(),
+			"user_id":    userID.String(),
+		}
+		if err := s.messageClient.SendSystemMessage(ctx, group.OwnerID, channel.ConversationID, content, metadata); err != nil {
+			s.logger.Warn("Failed to send welcome message", zap.Error(err), zap.String("group_id", group.ID.String()), zap.String("user_id", userID.String()))
+		}
+	}()
+}
+
+// welcomeChannel 发送欢迎消息的频道，未指定或指定的频道已删除时使用第一个默认频道
+func (s *groupService) welcomeChannel(ctx context.Context, onboarding *models.GroupOnboarding) (*models.GroupChannel, error) {
+	if onboarding.WelcomeChannelID != nil {
+		channel, err := s.repo.GetChannel(ctx, *onboarding.WelcomeChannelID)
+		if err != nil {
+			return nil, err
+		}
+		if channel != nil && channel.GroupID == onboarding.GroupID {
+			return channel, nil
+		}
+	}
+
+	channels, err := s.repo.GetGroupChannels(ctx, onboarding.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	for _, channel := range channels {
+		if channel.IsDefault && channel.Permission != models.ChannelPermissionAdminOnly {
+			return channel, nil
+		}
+	}
+	return nil, nil
+}
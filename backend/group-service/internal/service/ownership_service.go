@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// OwnershipConfig 联合群主和双人确认配置
+type OwnershipConfig struct {
+	MaxCoOwners     int           // 每个群组最多的联合群主数量
+	ConfirmationTTL time.Duration // 待确认操作的有效期
+	DeleteRetention time.Duration // 解散后可以恢复的期限，期满后永久删除
+}
+
+// TransferOwnership 转让群主，仅群主本人可以发起，原群主转为管理员
+// 开启双人确认且存在其他群主时返回待确认操作，操作在另一位群主确认后执行
+func (s *groupService) TransferOwnership(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.TransferOwnershipRequest) (*models.GroupPendingAction, error) {
+	group, err := s.getOwnedGroup(ctx, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.OwnerID != userID {
+		return nil, fmt.Errorf("access denied: only the group owner can transfer ownership")
+	}
+
+	if req.NewOwnerID == uuid.Nil {
+		return nil, fmt.Errorf("new owner is required")
+	}
+	if req.NewOwnerID == userID {
+		return nil, fmt.Errorf("invalid new owner: already the group owner")
+	}
+	isMember, err := s.repo.IsMember(ctx, groupID, req.NewOwnerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check membership: %w", err)
+	}
+	if !isMember {
+		return nil, fmt.Errorf("new owner is not an active member")
+	}
+
+	target := req.NewOwnerID
+	return s.requestOwnerAction(ctx, userID, group, models.PendingActionTransferOwnership, &target)
+}
+
+// SetOwnerConfirmation 开启或关闭群主双人确认，群主和联合群主可操作
+// 开启立即生效；关闭本身也需要另一位群主确认，避免单个群主先关闭再直接解散群组
+func (s *groupService) SetOwnerConfirmation(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, enabled bool) (*models.GroupPendingAction, error) {
+	group, err := s.getOwnedGroup(ctx, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.RequireOwnerConfirmation == enabled {
+		return nil, nil
+	}
+
+	if enabled {
+		return nil, s.updateOwnerConfirmation(ctx, groupID, true)
+	}
+	return s.requestOwnerAction(ctx, userID, group, models.PendingActionDisableConfirmation, nil)
+}
+
+// GetPendingActions 获取群组尚未处理的待确认操作，仅群主和联合群主可见
+func (s *groupService) GetPendingActions(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) ([]*models.GroupPendingAction, error) {
+	if err := s.checkOwnerPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	actions, err := s.repo.GetGroupPendingActions(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending actions: %w", err)
+	}
+	if actions == nil {
+		actions = []*models.GroupPendingAction{}
+	}
+	return actions, nil
+}
+
+// ConfirmPendingAction 确认并执行待确认操作，确认人必须是发起人以外的群主或联合群主
+func (s *groupService) ConfirmPendingAction(ctx context.Context, userID uuid.UUID, groupID, actionID uuid.UUID) (*models.GroupPendingAction, error) {
+	group, err := s.getOwnedGroup(ctx, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	action, err := s.getPendingAction(ctx, groupID, actionID)
+	if err != nil {
+		return nil, err
+	}
+	if action.RequestedBy == userID {
+		return nil, fmt.Errorf("access denied: action must be confirmed by another owner")
+	}
+
+	// 先抢占状态再执行，避免并发确认导致重复执行
+	resolved, err := s.repo.ResolvePendingAction(ctx, actionID, models.PendingActionConfirmed, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm pending action: %w", err)
+	}
+	if !resolved {
+		return nil, fmt.Errorf("pending action already resolved or expired")
+	}
+
+	if err := s.executeOwnerAction(ctx, action.RequestedBy, group, action); err != nil {
+		s.logger.Error("Failed to execute confirmed action", zap.Error(err),
+			zap.String("action_id", actionID.String()), zap.String("action", string(action.Action)))
+		return nil, err
+	}
+
+	s.logger.Info("Pending action confirmed",
+		zap.String("group_id", groupID.String()),
+		zap.String("action", string(action.Action)),
+		zap.String("requested_by", action.RequestedBy.String()),
+		zap.String("confirmed_by", userID.String()),
+	)
+	// 解散群组后待确认记录随群组一并删除，直接返回本地更新后的状态
+	markResolved(action, models.PendingActionConfirmed, userID)
+	return action, nil
+}
+
+// CancelPendingAction 取消待确认操作，发起人和其他群主都可以取消
+func (s *groupService) CancelPendingAction(ctx context.Context, userID uuid.UUID, groupID, actionID uuid.UUID) (*models.GroupPendingAction, error) {
+	if err := s.checkOwnerPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+	action, err := s.getPendingAction(ctx, groupID, actionID)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := s.repo.ResolvePendingAction(ctx, actionID, models.PendingActionCancelled, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel pending action: %w", err)
+	}
+	if !resolved {
+		return nil, fmt.Errorf("pending action already resolved or expired")
+	}
+
+	s.logger.Info("Pending action cancelled", zap.String("group_id", groupID.String()), zap.String("action_id", actionID.String()))
+	markResolved(action, models.PendingActionCancelled, userID)
+	return action, nil
+}
+
+// requestOwnerAction 执行群主级操作；开启双人确认且存在其他群主时改为登记待确认操作
+// 没有其他群主时无法满足双人确认，直接执行
+func (s *groupService) requestOwnerAction(ctx context.Context, userID uuid.UUID, group *models.Group, actionType models.PendingActionType, target *uuid.UUID) (*models.GroupPendingAction, error) {
+	required, err := s.requiresOwnerConfirmation(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	if !required {
+		action := &models.GroupPendingAction{Action: actionType, TargetUserID: target}
+		return nil, s.executeOwnerAction(ctx, userID, group, action)
+	}
+
+	existing, err := s.repo.GetGroupPendingActions(ctx, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending actions: %w", err)
+	}
+	for _, action := range existing {
+		if action.Action == actionType {
+			return nil, fmt.Errorf("pending action already exists")
+		}
+	}
+
+	ttl := s.ownership.ConfirmationTTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	now := time.Now()
+	action := &models.GroupPendingAction{
+		ID:           uuid.New(),
+		GroupID:      group.ID,
+		Action:       actionType,
+		TargetUserID: target,
+		RequestedBy:  userID,
+		Status:       models.PendingActionPending,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(ttl),
+	}
+	if err := s.repo.CreatePendingAction(ctx, action); err != nil {
+		s.logger.Error("Failed to create pending action", zap.Error(err), zap.String("group_id", group.ID.String()))
+		return nil, fmt.Errorf("failed to create pending action: %w", err)
+	}
+
+	s.logger.Info("Owner action awaiting confirmation",
+		zap.String("group_id", group.ID.String()),
+		zap.String("action", string(actionType)),
+		zap.String("requested_by", userID.String()),
+	)
+	return action, nil
+}
+
+// requiresOwnerConfirmation 判断群组的群主级操作是否需要另一位群主确认
+func (s *groupService) requiresOwnerConfirmation(ctx context.Context, group *models.Group) (bool, error) {
+	if !group.RequireOwnerConfirmation {
+		return false, nil
+	}
+	coOwners, err := s.repo.CountMembersByRole(ctx, group.ID, models.RoleCoOwner)
+	if err != nil {
+		return false, fmt.Errorf("failed to count co-owners: %w", err)
+	}
+	return coOwners > 0, nil
+}
+
+// executeOwnerAction 执行群主级操作，actorID为发起人
+func (s *groupService) executeOwnerAction(ctx context.Context, actorID uuid.UUID, group *models.Group, action *models.GroupPendingAction) error {
+	switch action.Action {
+	case models.PendingActionDeleteGroup:
+		if err := s.repo.SoftDeleteGroup(ctx, group.ID, actorID); err != nil {
+			s.logger.Error("Failed to delete group", zap.Error(err), zap.String("group_id", group.ID.String()))
+			return fmt.Errorf("failed to delete group: %w", err)
+		}
+		s.logger.Info("Group deleted successfully",
+			zap.String("group_id", group.ID.String()),
+			zap.String("owner_id", actorID.String()),
+			zap.Duration("restorable_for", s.ownership.DeleteRetention),
+		)
+
+	case models.PendingActionTransferOwnership:
+		if action.TargetUserID == nil {
+			return fmt.Errorf("invalid pending action: missing new owner")
+		}
+		newOwnerID := *action.TargetUserID
+		prevRole := models.RoleMember
+		if member, err := s.repo.GetMember(ctx, group.ID, newOwnerID); err == nil && member != nil {
+			prevRole = member.Role
+		}
+		if err := s.repo.TransferOwnership(ctx, group.ID, group.OwnerID, newOwnerID); err != nil {
+			s.logger.Error("Failed to transfer ownership", zap.Error(err), zap.String("group_id", group.ID.String()))
+			return fmt.Errorf("failed to transfer ownership: %w", err)
+		}
+		s.publishMembershipEvent(ctx, &models.MembershipEvent{
+			Event:    models.WebhookEventMemberRoleChanged,
+			GroupID:  group.ID,
+			UserID:   newOwnerID,
+			Role:     models.RoleOwner,
+			PrevRole: prevRole,
+			ActorID:  actorID,
+		})
+		s.publishMembershipEvent(ctx, &models.MembershipEvent{
+			Event:    models.WebhookEventMemberRoleChanged,
+			GroupID:  group.ID,
+			UserID:   group.OwnerID,
+			Role:     models.RoleAdmin,
+			PrevRole: models.RoleOwner,
+			ActorID:  actorID,
+		})
+		s.logger.Info("Group ownership transferred",
+			zap.String("group_id", group.ID.String()),
+			zap.String("from_user_id", group.OwnerID.String()),
+			zap.String("to_user_id", newOwnerID.String()),
+		)
+
+	case models.PendingActionDisableConfirmation:
+		return s.updateOwnerConfirmation(ctx, group.ID, false)
+
+	default:
+		return fmt.Errorf("invalid pending action: %s", action.Action)
+	}
+	return nil
+}
+
+// updateOwnerConfirmation 更新群组的双人确认开关
+func (s *groupService) updateOwnerConfirmation(ctx context.Context, groupID uuid.UUID, enabled bool) error {
+	if err := s.repo.UpdateGroup(ctx, groupID, map[string]interface{}{"require_owner_confirmation": enabled}); err != nil {
+		s.logger.Error("Failed to update owner confirmation", zap.Error(err), zap.String("group_id", groupID.String()))
+		return fmt.Errorf("failed to update owner confirmation: %w", err)
+	}
+	s.logger.Info("Owner confirmation updated", zap.String("group_id", groupID.String()), zap.Bool("enabled", enabled))
+	return nil
+}
+
+// getOwnedGroup 检查群主权限并获取群组
+func (s *groupService) getOwnedGroup(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) (*models.Group, error) {
+	if err := s.checkOwnerPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+	group, err := s.repo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		return nil, fmt.Errorf("group not found")
+	}
+	return group, nil
+}
+
+// getPendingAction 获取属于指定群组且仍可处理的待确认操作
+func (s *groupService) getPendingAction(ctx context.Context, groupID, actionID uuid.UUID) (*models.GroupPendingAction, error) {
+	action, err := s.repo.GetPendingAction(ctx, actionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending action: %w", err)
+	}
+	if action == nil || action.GroupID != groupID {
+		return nil, fmt.Errorf("pending action not found")
+	}
+	if action.Status != models.PendingActionPending {
+		return nil, fmt.Errorf("pending action already resolved")
+	}
+	if action.IsExpired(time.Now()) {
+		return nil, fmt.Errorf("pending action expired")
+	}
+	return action, nil
+}
+
+// markResolved 更新待确认操作的处理结果
+func markResolved(action *models.GroupPendingAction, status models.PendingActionStatus, resolvedBy uuid.UUID) {
+	now := time.Now()
+	action.Status = status
+	action.ResolvedBy = &resolvedBy
+	action.ResolvedAt = &now
+}
+
+// checkCoOwnerChange 检查联合群主的任免：仅群主本人可以操作，且数量不能超过上限
+// 开启双人确认时不能撤销联合群主，避免群主先撤销其他群主再绕过确认
+func (s *groupService) checkCoOwnerChange(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, target *models.GroupMember, newRole *models.GroupMemberRole, demote bool) error {
+	promote := newRole != nil && *newRole == models.RoleCoOwner && target.Role != models.RoleCoOwner
+	if !promote && target.Role != models.RoleCoOwner {
+		return nil
+	}
+
+	group, err := s.repo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		return fmt.Errorf("group not found")
+	}
+	if group.OwnerID != userID {
+		return fmt.Errorf("access denied: only the group owner can manage co-owners")
+	}
+
+	if promote {
+		count, err := s.repo.CountMembersByRole(ctx, groupID, models.RoleCoOwner)
+		if err != nil {
+			return fmt.Errorf("failed to count co-owners: %w", err)
+		}
+		if s.ownership.MaxCoOwners <= 0 || count >= s.ownership.MaxCoOwners {
+			return fmt.Errorf("too many co-owners: at most %d co-owners are allowed", s.ownership.MaxCoOwners)
+		}
+		return nil
+	}
+
+	if demote && group.RequireOwnerConfirmation {
+		return fmt.Errorf("cannot demote co-owner while owner confirmation is required")
+	}
+	return nil
+}
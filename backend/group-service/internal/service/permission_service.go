@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+)
+
+// GetMemberPermissions 获取用户在群组中的角色和权限，用户不是活跃成员时IsMember为false
+// 供其他服务校验群聊内的操作，不要求调用者是群成员
+func (s *groupService) GetMemberPermissions(ctx context.Context, groupID, userID uuid.UUID) (*models.MemberPermissions, error) {
+	group, err := s.repo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		return nil, fmt.Errorf("group not found")
+	}
+
+	member, err := s.repo.GetMember(ctx, groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member: %w", err)
+	}
+
+	result := &models.MemberPermissions{
+		GroupID:     groupID,
+		UserID:      userID,
+		Permissions: models.PermissionsOf(member),
+	}
+	if member != nil {
+		result.IsMember = member.Status == models.StatusActive
+		result.Role = member.Role
+		result.Status = member.Status
+	}
+	return result, nil
+}
+
+// GetConversationPermissions 获取用户在群组或群组频道会话中的权限，id可以是群组ID或频道在消息服务中的会话ID
+// 频道中只有加入了频道的活跃成员拥有权限，频道不允许该角色发言时不包含发言权限
+func (s *groupService) GetConversationPermissions(ctx context.Context, id, userID uuid.UUID) (*models.MemberPermissions, error) {
+	result, err := s.GetMemberPermissions(ctx, id, userID)
+	if err == nil || !strings.Contains(err.Error(), "group not found") {
+		return result, err
+	}
+
+	channel, channelErr := s.repo.GetChannelByConversation(ctx, id.String())
+	if channelErr != nil {
+		return nil, fmt.Errorf("failed to get channel: %w", channelErr)
+	}
+	if channel == nil {
+		return nil, err
+	}
+
+	result, err = s.GetMemberPermissions(ctx, channel.GroupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !result.IsMember {
+		return result, nil
+	}
+
+	isChannelMember, err := s.repo.IsChannelMember(ctx, channel.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check channel membership: %w", err)
+	}
+	if !isChannelMember {
+		result.IsMember = false
+		result.Permissions = []models.MemberPermission{}
+		return result, nil
+	}
+
+	if !channel.CanPost(result.Role) {
+		permissions := make([]models.MemberPermission, 0, len(result.Permissions))
+		for _, permission := range result.Permissions {
+			if permission != models.PermissionSendMessages {
+				permissions = append(permissions, permission)
+			}
+		}
+		result.Permissions = permissions
+	}
+	return result, nil
+}
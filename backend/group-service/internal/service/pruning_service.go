@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/pkg/validation"
+	"go.uber.org/zap"
+)
+
+// GetPrunePolicy 获取群组的不活跃成员清理策略，未设置时返回关闭状态的默认策略
+func (s *groupService) GetPrunePolicy(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) (*models.GroupPrunePolicy, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	policy, err := s.repo.GetPrunePolicy(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prune policy: %w", err)
+	}
+	if policy == nil {
+		return &models.GroupPrunePolicy{
+			GroupID:      groupID,
+			InactiveDays: models.DefaultPruneInactiveDays,
+			Action:       models.PruneActionFlag,
+		}, nil
+	}
+	return policy, nil
+}
+
+// SetPrunePolicy 设置不活跃成员清理策略，仅管理员和群主可设置
+// 从关闭变为开启时重新记录开启时间，不活跃天数至少从开启时开始计算
+func (s *groupService) SetPrunePolicy(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.SetPrunePolicyRequest) (*models.GroupPrunePolicy, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	// 验证输入
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetPrunePolicy(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prune policy: %w", err)
+	}
+
+	now := time.Now()
+	policy := &models.GroupPrunePolicy{
+		GroupID:      groupID,
+		Enabled:      req.Enabled,
+		InactiveDays: req.InactiveDays,
+		Action:       req.Action,
+		UpdatedBy:    userID,
+		UpdatedAt:    now,
+	}
+	if policy.InactiveDays == 0 {
+		policy.InactiveDays = models.DefaultPruneInactiveDays
+	}
+	if policy.Action == "" {
+		policy.Action = models.PruneActionFlag
+	}
+	if policy.Enabled {
+		if existing != nil && existing.Enabled {
+			policy.EnabledAt = existing.EnabledAt
+		} else {
+			policy.EnabledAt = &now
+		}
+	}
+
+	if err := s.repo.UpsertPrunePolicy(ctx, policy); err != nil {
+		s.logger.Error("Failed to set prune policy", zap.Error(err), zap.String("group_id", groupID.String()))
+		return nil, fmt.Errorf("failed to set prune policy: %w", err)
+	}
+
+	s.logger.Info("Prune policy updated",
+		zap.String("group_id", groupID.String()),
+		zap.Bool("enabled", policy.Enabled),
+		zap.Int("inactive_days", policy.InactiveDays),
+		zap.String("action", string(policy.Action)),
+		zap.String("updated_by", userID.String()))
+	return policy, nil
+}
+
+// PreviewPrune 预览（dry-run）按当前策略会被清理的成员，不做任何修改
+// inactiveDays为0时使用策略中的天数，策略未设置时使用默认天数
+func (s *groupService) PreviewPrune(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, inactiveDays int) (*models.PrunePreview, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	policy, err := s.repo.GetPrunePolicy(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prune policy: %w", err)
+	}
+	if inactiveDays == 0 {
+		inactiveDays = models.DefaultPruneInactiveDays
+		if policy != nil {
+			inactiveDays = policy.InactiveDays
+		}
+	}
+	if inactiveDays < models.MinPruneInactiveDays || inactiveDays > models.MaxPruneInactiveDays {
+		return nil, fmt.Errorf("invalid inactive days: must be between %d and %d", models.MinPruneInactiveDays, models.MaxPruneInactiveDays)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -inactiveDays)
+	candidates, err := s.findInactiveMembers(ctx, groupID, policy, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &models.PrunePreview{
+		GroupID:      groupID,
+		InactiveDays: inactiveDays,
+		Cutoff:       cutoff,
+		Candidates:   make([]models.PruneCandidate, 0, len(candidates)),
+	}
+	for _, candidate := range candidates {
+		preview.Candidates = append(preview.Candidates, *candidate)
+	}
+	return preview, nil
+}
+
+// ListPruneProposals 分页获取群组的清理提议，仅管理员和群主可查看
+func (s *groupService) ListPruneProposals(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, status models.PruneProposalStatus, limit, offset int) ([]*models.GroupPruneProposal, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	if status == "" {
+		status = models.PruneProposalPending
+	}
+	switch status {
+	case models.PruneProposalPending, models.PruneProposalApproved, models.PruneProposalRejected:
+	default:
+		return nil, fmt.Errorf("invalid prune proposal status: %s", status)
+	}
+
+	proposals, err := s.repo.GetGroupPruneProposals(ctx, groupID, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prune proposals: %w", err)
+	}
+	if proposals == nil {
+		return []*models.GroupPruneProposal{}, nil
+	}
+	return proposals, nil
+}
+
+// ApprovePruneProposal 确认清理提议，移除名单中至今仍不活跃的成员并通知被移除的成员
+// 提议生成后重新活跃、已退出或角色变更的成员不会被移除
+func (s *groupService) ApprovePruneProposal(ctx context.Context, userID uuid.UUID, groupID, proposalID uuid.UUID) (*models.GroupPruneProposal, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+	proposal, err := s.getPendingPruneProposal(ctx, groupID, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.getExistingGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.repo.GetPrunePolicy(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prune policy: %w", err)
+	}
+	inactive, err := s.findInactiveMembers(ctx, groupID, policy, time.Now().AddDate(0, 0, -proposal.InactiveDays))
+	if err != nil {
+		return nil, err
+	}
+	stillInactive := make(map[uuid.UUID]*models.PruneCandidate, len(inactive))
+	for _, candidate := range inactive {
+		stillInactive[candidate.UserID] = candidate
+	}
+	var targets []*models.PruneCandidate
+	for _, candidate := range proposal.Candidates {
+		if current, ok := stillInactive[candidate.UserID]; ok {
+			targets = append(targets, current)
+		}
+	}
+
+	// 先抢占状态再移除成员，避免并发确认
+	resolved, err := s.repo.ResolvePruneProposal(ctx, proposalID, models.PruneProposalApproved, userID, len(targets))
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve prune proposal: %w", err)
+	}
+	if !resolved {
+		return nil, fmt.Errorf("prune proposal already resolved")
+	}
+
+	removed := s.removeInactiveMembers(ctx, group, targets, proposal.InactiveDays, userID)
+
+	s.logger.Info("Prune proposal approved",
+		zap.String("group_id", groupID.String()),
+		zap.String("proposal_id", proposalID.String()),
+		zap.Int("removed", removed),
+		zap.String("approved_by", userID.String()))
+	markPruneProposalResolved(proposal, models.PruneProposalApproved, userID, removed)
+	return proposal, nil
+}
+
+// RejectPruneProposal 驳回清理提议，一个不活跃周期内不再为该群组生成新的提议
+func (s *groupService) RejectPruneProposal(ctx context.Context, userID uuid.UUID, groupID, proposalID uuid.UUID) (*models.GroupPruneProposal, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+	proposal, err := s.getPendingPruneProposal(ctx, groupID, proposalID)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := s.repo.ResolvePruneProposal(ctx, proposalID, models.PruneProposalRejected, userID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reject prune proposal: %w", err)
+	}
+	if !resolved {
+		return nil, fmt.Errorf("prune proposal already resolved")
+	}
+
+	s.logger.Info("Prune proposal rejected", zap.String("group_id", groupID.String()), zap.String("proposal_id", proposalID.String()), zap.String("rejected_by", userID.String()))
+	markPruneProposalResolved(proposal, models.PruneProposalRejected, userID, 0)
+	return proposal, nil
+}
+
+// RecordMemberActivity 记录成员在群组中的活跃时间，非群组成员的消息忽略
+func (s *groupService) RecordMemberActivity(ctx context.Context, groupID, userID uuid.UUID, at time.Time) error {
+	member, err := s.repo.GetMember(ctx, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get member: %w", err)
+	}
+	if member == nil {
+		return nil
+	}
+	if err := s.repo.RecordMemberActivity(ctx, groupID, userID, at); err != nil {
+		return fmt.Errorf("failed to record member activity: %w", err)
+	}
+	return nil
+}
+
+// RunPrunePolicies 执行所有已开启的清理策略，由定时任务调用
+// 单个群组失败不影响其他群组，全部执行完后返回失败数量
+func (s *groupService) RunPrunePolicies(ctx context.Context) error {
+	policies, err := s.repo.GetEnabledPrunePolicies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get prune policies: %w", err)
+	}
+
+	var failed int
+	for _, policy := range policies {
+		if err := s.runPrunePolicy(ctx, policy); err != nil {
+			failed++
+			s.logger.Error("Failed to run prune policy", zap.Error(err), zap.String("group_id", policy.GroupID.String()))
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to run %d of %d prune policies", failed, len(policies))
+	}
+	return nil
+}
+
+// runPrunePolicy 按策略处理一个群组：remove直接移除，flag生成清理提议并通知管理员
+func (s *groupService) runPrunePolicy(ctx context.Context, policy *models.GroupPrunePolicy) error {
+	group, err := s.getExistingGroup(ctx, policy.GroupID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	candidates, err := s.findInactiveMembers(ctx, group.ID, policy, now.AddDate(0, 0, -policy.InactiveDays))
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	if policy.Action == models.PruneActionRemove {
+		removed := s.removeInactiveMembers(ctx, group, candidates, policy.InactiveDays, uuid.Nil)
+		s.logger.Info("Inactive members removed by policy", zap.String("group_id", group.ID.String()), zap.Int("removed", removed))
+		return nil
+	}
+
+	// 驳回后一个不活跃周期内不再提议，避免每天重复打扰管理员
+	rejected, err := s.repo.GetGroupPruneProposals(ctx, group.ID, models.PruneProposalRejected, 1, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get prune proposals: %w", err)
+	}
+	if len(rejected) > 0 && rejected[0].ResolvedAt != nil && rejected[0].ResolvedAt.After(now.AddDate(0, 0, -policy.InactiveDays)) {
+		return nil
+	}
+
+	proposal := &models.GroupPruneProposal{
+		ID:           uuid.New(),
+		GroupID:      group.ID,
+		InactiveDays: policy.InactiveDays,
+		Candidates:   make(models.PruneCandidates, 0, len(candidates)),
+		Status:       models.PruneProposalPending,
+		CreatedAt:    now,
+	}
+	for _, candidate := range candidates {
+		proposal.Candidates = append(proposal.Candidates, *candidate)
+	}
+	if err := s.repo.CreatePruneProposal(ctx, proposal); err != nil {
+		if strings.Contains(err.Error(), "already pending") {
+			return nil
+		}
+		return fmt.Errorf("failed to create prune proposal: %w", err)
+	}
+
+	admins, err := s.activeAdmins(ctx, group.ID)
+	if err != nil {
+		s.logger.Warn("Failed to load prune proposal reviewers", zap.Error(err), zap.String("group_id", group.ID.String()))
+	}
+	s.sendGroupNotification(admins, "Inactive members flagged",
+		fmt.Sprintf("%d members of %s have been inactive for %d days", len(candidates), group.Name, policy.InactiveDays),
+		map[string]interface{}{
+			"kind":        "prune_proposal_created",
+			"group_id":    group.ID.String(),
+			"proposal_id": proposal.ID.String(),
+		})
+
+	s.logger.Info("Prune proposal created", zap.String("group_id", group.ID.String()), zap.String("proposal_id", proposal.ID.String()), zap.Int("candidates", len(candidates)))
+	return nil
+}
+
+// findInactiveMembers 查找cutoff之后没有活跃记录的成员
+// 策略开启后未满一个不活跃周期时返回空名单，开启前的活跃记录可能不完整
+func (s *groupService) findInactiveMembers(ctx context.Context, groupID uuid.UUID, policy *models.GroupPrunePolicy, cutoff time.Time) ([]*models.PruneCandidate, error) {
+	if policy != nil && policy.Enabled && policy.EnabledAt != nil && policy.EnabledAt.After(cutoff) {
+		return nil, nil
+	}
+	candidates, err := s.repo.GetInactiveMembers(ctx, groupID, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inactive members: %w", err)
+	}
+	return candidates, nil
+}
+
+// removeInactiveMembers 移除不活跃成员并通知本人，actorID为uuid.Nil表示由策略自动移除
+// 单个成员移除失败只记录日志，返回实际移除的数量
+func (s *groupService) removeInactiveMembers(ctx context.Context, group *models.Group, candidates []*models.PruneCandidate, inactiveDays int, actorID uuid.UUID) int {
+	removed := make([]uuid.UUID, 0, len(candidates))
+	for _, candidate := range candidates {
+		if err := s.repo.RemoveMember(ctx, group.ID, candidate.UserID); err != nil {
+			s.logger.Error("Failed to remove inactive member", zap.Error(err), zap.String("group_id", group.ID.String()), zap.String("user_id", candidate.UserID.String()))
+			continue
+		}
+		s.leaveAllChannels(ctx, group.ID, candidate.UserID)
+		s.publishMembershipEvent(ctx, &models.MembershipEvent{
+			Event:    models.WebhookEventMemberRemoved,
+			GroupID:  group.ID,
+			UserID:   candidate.UserID,
+			PrevRole: candidate.Role,
+			ActorID:  actorID,
+		})
+		removed = append(removed, candidate.UserID)
+	}
+
+	s.sendGroupNotification(removed, "Removed for inactivity",
+		fmt.Sprintf("You were removed from %s after %d days without activity", group.Name, inactiveDays),
+		map[string]interface{}{
+			"kind":     "inactive_member_removed",
+			"group_id": group.ID.String(),
+		})
+	return len(removed)
+}
+
+// getPendingPruneProposal 获取属于指定群组且待确认的清理提议
+func (s *groupService) getPendingPruneProposal(ctx context.Context, groupID, proposalID uuid.UUID) (*models.GroupPruneProposal, error) {
+	proposal, err := s.repo.GetPruneProposal(ctx, proposalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prune proposal: %w", err)
+	}
+	if proposal == nil || proposal.GroupID != groupID {
+		return nil, fmt.Errorf("prune proposal not found")
+	}
+	if proposal.Status != models.PruneProposalPending {
+		return nil, fmt.Errorf("prune proposal already resolved")
+	}
+	return proposal, nil
+}
+
+// markPruneProposalResolved 更新本地的清理提议状态，与仓库中的更新保持一致
+func markPruneProposalResolved(proposal *models.GroupPruneProposal, status models.PruneProposalStatus, resolvedBy uuid.UUID, removedCount int) {
+	now := time.Now()
+	proposal.Status = status
+	proposal.ResolvedBy = &resolvedBy
+	proposal.ResolvedAt = &now
+	proposal.RemovedCount = removedCount
+}
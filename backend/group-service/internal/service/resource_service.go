@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// CreateResource 创建群组置顶资源
+func (s *groupService) CreateResource(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.CreateGroupResourceRequest) (*models.GroupResource, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	// 验证输入
+	title, resourceURL, description, err := s.validateResourceFields(req.Title, req.URL, req.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetGroupResources(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resources: %w", err)
+	}
+	if len(existing) >= models.MaxGroupResources {
+		return nil, fmt.Errorf("too many resources: at most %d resources can be pinned", models.MaxGroupResources)
+	}
+
+	// 未指定位置时追加到末尾
+	position := 0
+	if len(existing) > 0 {
+		position = existing[len(existing)-1].Position + 1
+	}
+	if req.Position != nil {
+		if *req.Position < 0 {
+			return nil, fmt.Errorf("invalid resource position")
+		}
+		position = *req.Position
+	}
+
+	resource := &models.GroupResource{
+		ID:          uuid.New(),
+		GroupID:     groupID,
+		Title:       title,
+		URL:         resourceURL,
+		Description: description,
+		Position:    position,
+		CreatedBy:   userID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := s.repo.CreateResource(ctx, resource); err != nil {
+		s.logger.Error("Failed to create resource", zap.Error(err), zap.String("group_id", groupID.String()))
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	s.logger.Info("Resource pinned successfully", zap.String("group_id", groupID.String()), zap.String("resource_id", resource.ID.String()))
+	return resource, nil
+}
+
+// GetResources 获取群组置顶资源，可见性与群组详情一致：私有群组仅成员可见
+func (s *groupService) GetResources(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) ([]*models.GroupResource, error) {
+	group, err := s.GetGroup(ctx, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.Resources == nil {
+		return []*models.GroupResource{}, nil
+	}
+	return group.Resources, nil
+}
+
+// UpdateResource 更新置顶资源
+func (s *groupService) UpdateResource(ctx context.Context, userID uuid.UUID, groupID, resourceID uuid.UUID, req *models.UpdateGroupResourceRequest) (*models.GroupResource, error) {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	resource, err := s.getGroupResource(ctx, groupID, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	title, resourceURL, description := resource.Title, resource.URL, resource.Description
+	if req.Title != nil {
+		title = *req.Title
+	}
+	if req.URL != nil {
+		resourceURL = *req.URL
+	}
+	if req.Description != nil {
+		description = *req.Description
+	}
+	title, resourceURL, description, err = s.validateResourceFields(title, resourceURL, description)
+	if err != nil {
+		return nil, err
+	}
+
+	// 构建更新字段
+	updates := make(map[string]interface{})
+	if req.Title != nil {
+		updates["title"] = title
+	}
+	if req.URL != nil {
+		updates["url"] = resourceURL
+	}
+	if req.Description != nil {
+		updates["description"] = description
+	}
+	if req.Position != nil {
+		if *req.Position < 0 {
+			return nil, fmt.Errorf("invalid resource position")
+		}
+		updates["position"] = *req.Position
+	}
+
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("no fields to update")
+	}
+
+	if err := s.repo.UpdateResource(ctx, resourceID, updates); err != nil {
+		s.logger.Error("Failed to update resource", zap.Error(err), zap.String("resource_id", resourceID.String()))
+		return nil, fmt.Errorf("failed to update resource: %w", err)
+	}
+
+	return s.repo.GetResource(ctx, resourceID)
+}
+
+// DeleteResource 删除置顶资源
+func (s *groupService) DeleteResource(ctx context.Context, userID uuid.UUID, groupID, resourceID uuid.UUID) error {
+	// 检查权限
+	if err := s.checkAdminPermission(ctx, userID, groupID); err != nil {
+		return err
+	}
+
+	if _, err := s.getGroupResource(ctx, groupID, resourceID); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteResource(ctx, resourceID); err != nil {
+		s.logger.Error("Failed to delete resource", zap.Error(err), zap.String("resource_id", resourceID.String()))
+		return fmt.Errorf("failed to delete resource: %w", err)
+	}
+
+	s.logger.Info("Resource unpinned successfully", zap.String("group_id", groupID.String()), zap.String("resource_id", resourceID.String()))
+	return nil
+}
+
+// getGroupResource 获取属于指定群组的置顶资源
+func (s *groupService) getGroupResource(ctx context.Context, groupID, resourceID uuid.UUID) (*models.GroupResource, error) {
+	resource, err := s.repo.GetResource(ctx, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource: %w", err)
+	}
+	if resource == nil || resource.GroupID != groupID {
+		return nil, fmt.Errorf("resource not found")
+	}
+	return resource, nil
+}
+
+// validateResourceFields 验证并整理置顶资源字段，链接只允许http和https，避免客户端渲染出javascript:等链接
+func (s *groupService) validateResourceFields(title, resourceURL, description string) (string, string, string, error) {
+	title = strings.TrimSpace(title)
+	resourceURL = strings.TrimSpace(resourceURL)
+	description = strings.TrimSpace(description)
+
+	if title == "" {
+		return "", "", "", fmt.Errorf("resource title is required")
+	}
+	if len(title) > models.MaxResourceTitleLength {
+		return "", "", "", fmt.Errorf("resource title too long")
+	}
+	if resourceURL == "" {
+		return "", "", "", fmt.Errorf("resource url is required")
+	}
+	if len(resourceURL) > models.MaxResourceURLLength {
+		return "", "", "", fmt.Errorf("resource url too long")
+	}
+	parsed, err := url.Parse(resourceURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", "", "", fmt.Errorf("invalid resource url")
+	}
+	if len(description) > models.MaxResourceDescriptionLength {
+		return "", "", "", fmt.Errorf("resource description too long")
+	}
+	return title, resourceURL, description, nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/pkg/validation"
+	"go.uber.org/zap"
+)
+
+// StorageConfig 群组文件存储配额配置
+type StorageConfig struct {
+	Quotas map[string]int64 // 存储档位 -> 配额字节数
+}
+
+// GetGroupStats 获取群组的成员统计和文件存储用量，仅成员可以查看
+func (s *groupService) GetGroupStats(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) (*models.GroupStats, error) {
+	if err := s.checkMemberPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.repo.GetGroupStats(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group stats: %w", err)
+	}
+	if stats == nil {
+		return nil, fmt.Errorf("group not found")
+	}
+
+	stats.Storage, err = s.GetStorageUsage(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetStorageUsage 获取群组的文件存储用量
+func (s *groupService) GetStorageUsage(ctx context.Context, groupID uuid.UUID) (*models.GroupStorageUsage, error) {
+	tier, err := s.repo.GetStorageTier(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage tier: %w", err)
+	}
+	if tier == "" {
+		tier = models.StorageTierFree
+	}
+	used, count, err := s.repo.GetGroupStorageUsage(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+	return s.storageUsage(tier, used, count), nil
+}
+
+// SetStorageTier 设置群组的存储档位，只允许平台管理员调用，降档后超出配额的已有文件保留，但不能再附加新文件
+func (s *groupService) SetStorageTier(ctx context.Context, adminID uuid.UUID, groupID uuid.UUID, req *models.SetStorageTierRequest) (*models.GroupStorageUsage, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+	group, err := s.repo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		return nil, fmt.Errorf("group not found")
+	}
+
+	if err := s.repo.SetStorageTier(ctx, groupID, req.Tier, adminID); err != nil {
+		return nil, fmt.Errorf("failed to set storage tier: %w", err)
+	}
+
+	s.logger.Info("Group storage tier changed",
+		zap.String("group_id", groupID.String()),
+		zap.String("tier", req.Tier),
+		zap.String("admin_id", adminID.String()),
+	)
+	return s.GetStorageUsage(ctx, groupID)
+}
+
+// CheckStorage 媒体服务把文件附加到群聊会话前检查所属群组的配额，已计入群组的文件不重复计算
+// 会话不属于任何群组时返回conversation not found，由媒体服务拒绝附加
+func (s *groupService) CheckStorage(ctx context.Context, conversationID string, fileID string, size int64) (*models.StorageCheckResult, error) {
+	groupID, err := s.GetGroupIDByConversation(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := s.GetStorageUsage(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if fileID != "" {
+		counted, err := s.repo.HasGroupMediaFile(ctx, groupID, fileID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check group media file: %w", err)
+		}
+		if counted {
+			return &models.StorageCheckResult{Allowed: true, Usage: usage}, nil
+		}
+	}
+	return &models.StorageCheckResult{Allowed: usage.UsedBytes+size <= usage.QuotaBytes, Usage: usage}, nil
+}
+
+// RecordMediaAttached 把附加到群聊会话的文件计入群组用量，groupID由调用方按会话解析
+func (s *groupService) RecordMediaAttached(ctx context.Context, groupID uuid.UUID, fileID string, ownerID uuid.UUID, size int64, at time.Time) error {
+	added, err := s.repo.AddGroupMediaFile(ctx, &models.GroupMediaFile{
+		GroupID:    groupID,
+		FileID:     fileID,
+		OwnerID:    ownerID,
+		Size:       size,
+		AttachedAt: at,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record group media file: %w", err)
+	}
+	if !added {
+		return nil
+	}
+
+	// 媒体服务检查配额时可能有并发附加，这里只记录超额或接近配额的情况
+	usage, err := s.GetStorageUsage(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	if usage.Warning {
+		s.logger.Warn("Group storage nearly full",
+			zap.String("group_id", groupID.String()),
+			zap.Int64("used_bytes", usage.UsedBytes),
+			zap.Int64("quota_bytes", usage.QuotaBytes),
+		)
+	}
+	return nil
+}
+
+// RecordMediaDetached 文件不再附加到群聊会话时从群组用量中移除
+func (s *groupService) RecordMediaDetached(ctx context.Context, groupID uuid.UUID, fileID string) error {
+	if err := s.repo.RemoveGroupMediaFile(ctx, groupID, fileID); err != nil {
+		return fmt.Errorf("failed to remove group media file: %w", err)
+	}
+	return nil
+}
+
+// storageUsage 根据档位配额计算用量百分比和提示
+func (s *groupService) storageUsage(tier string, used int64, count int) *models.GroupStorageUsage {
+	usage := &models.GroupStorageUsage{
+		Tier:       tier,
+		UsedBytes:  used,
+		QuotaBytes: s.storage.Quotas[tier],
+		FileCount:  count,
+	}
+	if usage.QuotaBytes > 0 {
+		usage.UsagePercent = math.Round(float64(used)*1000/float64(usage.QuotaBytes)) / 10
+		usage.Warning = used*100 >= usage.QuotaBytes*models.StorageWarningPercent
+	} else if used > 0 {
+		usage.UsagePercent = 100
+		usage.Warning = true
+	}
+	return usage
+}
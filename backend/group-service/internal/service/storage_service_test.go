@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/internal/repository"
+	"github.com/neohope/chatapp/group-service/pkg/events"
+	"go.uber.org/zap"
+)
+
+// fakeMessageClient 为每个频道分配新的会话ID，与消息服务一致
+type fakeMessageClient struct{}
+
+func (fakeMessageClient) CreateConversation(ctx context.Context, creatorID uuid.UUID, participants []uuid.UUID) (string, error) {
+	return uuid.New().String(), nil
+}
+
+func (fakeMessageClient) SendSystemMessage(ctx context.Context, senderID uuid.UUID, conversationID, content string, metadata map[string]interface{}) error {
+	return nil
+}
+
+func (fakeMessageClient) AddParticipants(ctx context.Context, conversationID string, userIDs []uuid.UUID) error {
+	return nil
+}
+
+func (fakeMessageClient) RemoveParticipant(ctx context.Context, conversationID string, userID uuid.UUID) error {
+	return nil
+}
+
+// newTestGroup 创建群组并返回默认频道的会话ID
+func newTestGroup(t *testing.T, repo repository.GroupRepository, ownerID uuid.UUID) (GroupService, *models.Group, string) {
+	t.Helper()
+	groups := NewGroupService(repo, fakeMessageClient{}, nil, nil, nil, OwnershipConfig{}, StorageConfig{
+		Quotas: map[string]int64{models.StorageTierFree: 1000},
+	}, zap.NewNop())
+
+	ctx := context.Background()
+	group, err := groups.CreateGroup(ctx, ownerID, &models.CreateGroupRequest{Name: "team"})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	channels, err := groups.GetChannels(ctx, ownerID, group.ID)
+	if err != nil {
+		t.Fatalf("GetChannels: %v", err)
+	}
+	if len(channels) != 1 || channels[0].ConversationID == "" {
+		t.Fatalf("expected default channel with conversation, got %+v", channels)
+	}
+	if channels[0].ConversationID == group.ID.String() {
+		t.Fatal("expected channel conversation id to differ from group id")
+	}
+	return groups, group, channels[0].ConversationID
+}
+
+func TestChannelConversationStorage(t *testing.T) {
+	ctx := context.Background()
+	ownerID := uuid.New()
+	groups, group, conversationID := newTestGroup(t, repository.NewMemoryGroupRepository(), ownerID)
+
+	result, err := groups.CheckStorage(ctx, conversationID, "file-1", 600)
+	if err != nil {
+		t.Fatalf("CheckStorage: %v", err)
+	}
+	if !result.Allowed {
+		t.Fatal("expected first file to fit in quota")
+	}
+
+	event, err := events.NewEvent("media-service", events.TypeMediaAttached, events.MediaAttached{
+		FileID:         "file-1",
+		ConversationID: conversationID,
+		OwnerID:        ownerID.String(),
+		Size:           600,
+	})
+	if err != nil {
+		t.Fatalf("NewEvent: %v", err)
+	}
+	if err := NewMediaUsageConsumer(groups, zap.NewNop()).Handle(ctx, event); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+
+	usage, err := groups.GetStorageUsage(ctx, group.ID)
+	if err != nil {
+		t.Fatalf("GetStorageUsage: %v", err)
+	}
+	if usage.UsedBytes != 600 || usage.FileCount != 1 {
+		t.Fatalf("expected channel file counted in group usage, got %+v", usage)
+	}
+
+	// 已计入的文件再次附加不重复计算，新文件超出配额
+	if result, err = groups.CheckStorage(ctx, conversationID, "file-1", 600); err != nil || !result.Allowed {
+		t.Fatalf("expected counted file to be allowed, got %+v, %v", result, err)
+	}
+	if result, err = groups.CheckStorage(ctx, conversationID, "file-2", 600); err != nil || result.Allowed {
+		t.Fatalf("expected new file to exceed quota, got %+v, %v", result, err)
+	}
+}
+
+func TestCheckStorageUnknownConversation(t *testing.T) {
+	groups, _, _ := newTestGroup(t, repository.NewMemoryGroupRepository(), uuid.New())
+
+	for _, conversationID := range []string{uuid.New().String(), "direct-conversation"} {
+		_, err := groups.CheckStorage(context.Background(), conversationID, "file-1", 1)
+		if err == nil || !strings.Contains(err.Error(), "conversation not found") {
+			t.Fatalf("expected conversation not found for %q, got %v", conversationID, err)
+		}
+	}
+}
+
+func TestMediaAttachedToDirectConversationIgnored(t *testing.T) {
+	ownerID := uuid.New()
+	groups, group, _ := newTestGroup(t, repository.NewMemoryGroupRepository(), ownerID)
+
+	event, err := events.NewEvent("media-service", events.TypeMediaAttached, events.MediaAttached{
+		FileID:         "file-1",
+		ConversationID: uuid.New().String(),
+		OwnerID:        ownerID.String(),
+		Size:           600,
+	})
+	if err != nil {
+		t.Fatalf("NewEvent: %v", err)
+	}
+	if err := NewMediaUsageConsumer(groups, zap.NewNop()).Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+
+	usage, err := groups.GetStorageUsage(context.Background(), group.ID)
+	if err != nil {
+		t.Fatalf("GetStorageUsage: %v", err)
+	}
+	if usage.UsedBytes != 0 {
+		t.Fatalf("expected direct conversation file not counted, got %+v", usage)
+	}
+}
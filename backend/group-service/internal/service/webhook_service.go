@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"github.com/neohope/chatapp/group-service/internal/webhook"
+	"go.uber.org/zap"
+)
+
+// CreateWebhook 为群组创建成员同步Webhook
+func (s *groupService) CreateWebhook(ctx context.Context, userID uuid.UUID, groupID uuid.UUID, req *models.CreateWebhookRequest) (*models.CreateWebhookResponse, error) {
+	// 检查是否为群主
+	if err := s.checkOwnerPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	// 验证输入，未启用投递器时按默认规则拒绝内部地址
+	validate := func(ctx context.Context, rawURL string) error { return webhook.ValidateURL(ctx, rawURL, false) }
+	if s.dispatcher != nil {
+		validate = s.dispatcher.ValidateURL
+	}
+	if err := validate(ctx, req.URL); err != nil {
+		return nil, err
+	}
+	if req.Secret != "" && len(req.Secret) < 16 {
+		return nil, fmt.Errorf("webhook secret too short")
+	}
+
+	events := req.Events
+	if len(events) == 0 {
+		events = models.AllWebhookEvents
+	}
+	eventNames := make([]string, 0, len(events))
+	for _, event := range events {
+		if !isValidWebhookEvent(event) {
+			return nil, fmt.Errorf("invalid webhook event: %s", event)
+		}
+		eventNames = append(eventNames, string(event))
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateWebhookSecret(); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+	}
+
+	webhook := &models.GroupWebhook{
+		ID:        uuid.New(),
+		GroupID:   groupID,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    eventNames,
+		IsActive:  true,
+		CreatedBy: userID,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.repo.CreateWebhook(ctx, webhook); err != nil {
+		s.logger.Error("Failed to create webhook", zap.Error(err), zap.String("group_id", groupID.String()))
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	s.logger.Info("Webhook created successfully", zap.String("group_id", groupID.String()), zap.String("webhook_id", webhook.ID.String()))
+	return &models.CreateWebhookResponse{GroupWebhook: webhook, Secret: secret}, nil
+}
+
+// GetWebhooks 获取群组Webhook列表
+func (s *groupService) GetWebhooks(ctx context.Context, userID uuid.UUID, groupID uuid.UUID) ([]*models.GroupWebhook, error) {
+	if err := s.checkOwnerPermission(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	webhooks, err := s.repo.GetGroupWebhooks(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook 删除Webhook
+func (s *groupService) DeleteWebhook(ctx context.Context, userID uuid.UUID, groupID, webhookID uuid.UUID) error {
+	if err := s.checkOwnerPermission(ctx, userID, groupID); err != nil {
+		return err
+	}
+
+	webhook, err := s.repo.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook: %w", err)
+	}
+	if webhook == nil || webhook.GroupID != groupID {
+		return fmt.Errorf("webhook not found")
+	}
+
+	if err := s.repo.DeleteWebhook(ctx, webhookID); err != nil {
+		s.logger.Error("Failed to delete webhook", zap.Error(err), zap.String("webhook_id", webhookID.String()))
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// publishMembershipEvent 向群组Webhook投递成员变更事件，失败仅记录日志
+func (s *groupService) publishMembershipEvent(ctx context.Context, event *models.MembershipEvent) {
+	if s.dispatcher == nil {
+		return
+	}
+
+	webhooks, err := s.repo.GetGroupWebhooks(ctx, event.GroupID)
+	if err != nil {
+		s.logger.Warn("Failed to load webhooks", zap.Error(err), zap.String("group_id", event.GroupID.String()))
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	event.ID = uuid.New()
+	event.OccurredAt = time.Now().UTC()
+	s.dispatcher.Dispatch(webhooks, event)
+}
+
+// isValidWebhookEvent 检查事件类型是否受支持
+func isValidWebhookEvent(event models.WebhookEvent) bool {
+	for _, e := range models.AllWebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// generateWebhookSecret 生成随机签名密钥
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
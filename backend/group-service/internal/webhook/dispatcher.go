@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/neohope/chatapp/group-service/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// SignatureHeader HMAC-SHA256签名请求头
+	SignatureHeader = "X-Chatapp-Signature"
+	// EventHeader 事件类型请求头
+	EventHeader = "X-Chatapp-Event"
+	// TimestampHeader 签名时间戳请求头
+	TimestampHeader = "X-Chatapp-Timestamp"
+)
+
+// Dispatcher Webhook投递器，异步投递成员变更事件并在失败时重试
+type Dispatcher struct {
+	httpClient   *http.Client
+	allowPrivate bool
+	maxRetries   int
+	retryDelay   time.Duration
+	wg           sync.WaitGroup // 投递中（含重试等待）的Webhook
+	logger       *zap.Logger
+}
+
+// NewDispatcher 创建Webhook投递器，连接时拒绝环回、私有和链路本地地址，不跟随重定向
+// allowPrivate为true时不限制目标地址，仅用于本地开发
+func NewDispatcher(timeout time.Duration, maxRetries int, allowPrivate bool, logger *zap.Logger) *Dispatcher {
+	if maxRetries < 1 {
+		maxRetries = 1
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = dialControl
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// 经过代理时连接的是代理地址，无法检查实际目标
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Dispatcher{
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		allowPrivate: allowPrivate,
+		maxRetries:   maxRetries,
+		retryDelay:   time.Second,
+		logger:       logger,
+	}
+}
+
+// ValidateURL 按投递器的地址限制校验Webhook地址，创建Webhook时调用
+func (d *Dispatcher) ValidateURL(ctx context.Context, rawURL string) error {
+	return ValidateURL(ctx, rawURL, d.allowPrivate)
+}
+
+// Dispatch 将事件异步投递到所有订阅了该事件的Webhook
+func (d *Dispatcher) Dispatch(webhooks []*models.GroupWebhook, event *models.MembershipEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		d.logger.Error("Failed to marshal webhook event", zap.Error(err))
+		return
+	}
+
+	for _, hook := range webhooks {
+		if !hook.Subscribes(event.Event) {
+			continue
+		}
+		d.wg.Add(1)
+		go func(hook *models.GroupWebhook) {
+			defer d.wg.Done()
+			d.deliver(hook, event.Event, payload)
+		}(hook)
+	}
+}
+
+// Wait 等待投递中的Webhook完成（含重试），关闭服务前调用
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// deliver 投递单个Webhook，失败时按指数退避重试
+func (d *Dispatcher) deliver(hook *models.GroupWebhook, event models.WebhookEvent, payload []byte) {
+	delay := d.retryDelay
+	for attempt := 1; attempt <= d.maxRetries; attempt++ {
+		err := d.send(hook, event, payload)
+		if err == nil {
+			return
+		}
+
+		d.logger.Warn("Webhook delivery failed",
+			zap.Error(err),
+			zap.String("webhook_id", hook.ID.String()),
+			zap.String("event", string(event)),
+			zap.Int("attempt", attempt),
+		)
+
+		if attempt < d.maxRetries {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+
+	d.logger.Error("Webhook delivery abandoned",
+		zap.String("webhook_id", hook.ID.String()),
+		zap.String("group_id", hook.GroupID.String()),
+		zap.String("event", string(event)),
+	)
+}
+
+// send 发送一次带签名的Webhook请求
+func (d *Dispatcher) send(hook *models.GroupWebhook, event models.WebhookEvent, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.httpClient.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(event))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(hook.Secret, timestamp, payload))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign 计算 "timestamp.payload" 的HMAC-SHA256签名，接收方应使用相同方式校验
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
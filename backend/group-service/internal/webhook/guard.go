@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// blockedNetworks net.IP方法未覆盖的保留网段：运营商级NAT、本网络、IETF协议分配、基准测试和保留地址
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+)
+
+// ValidateURL 校验Webhook地址：只允许http/https，主机名解析出的所有地址都不能是环回、链路本地（如169.254.169.254）、
+// 私有网段或其他保留地址，防止群主借Webhook访问内部服务（SSRF）。allowPrivate为true时不检查地址，仅用于本地开发
+func ValidateURL(ctx context.Context, rawURL string, allowPrivate bool) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("invalid webhook url")
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("invalid webhook url: credentials are not allowed")
+	}
+	if allowPrivate {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("invalid webhook url: host cannot be resolved")
+	}
+	for _, addr := range addrs {
+		if isBlockedIP(addr.IP) {
+			return fmt.Errorf("invalid webhook url: private or loopback address is not allowed")
+		}
+	}
+	return nil
+}
+
+// isBlockedIP 判断地址是否不允许作为Webhook投递目标
+func isBlockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// dialControl 在建立连接前检查实际连接的地址，防止注册后修改DNS解析（DNS重绑定）或重定向到内部地址
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isBlockedIP(ip) {
+		return fmt.Errorf("webhook destination %s is not allowed", host)
+	}
+	return nil
+}
+
+// mustParseCIDRs 解析网段列表，格式错误时panic
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
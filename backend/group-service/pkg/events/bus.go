@@ -0,0 +1,127 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+// handlerTimeout 单个事件处理的超时时间
+const handlerTimeout = 30 * time.Second
+
+// Publisher 发布领域事件，发布失败由调用方决定是否忽略
+type Publisher interface {
+	Publish(ctx context.Context, eventType string, data interface{}) error
+}
+
+// Handler 处理订阅到的事件，返回错误只记录日志，事件不会重新投递
+type Handler func(ctx context.Context, event *Event) error
+
+// nopPublisher 未配置事件总线时使用，丢弃所有事件
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(ctx context.Context, eventType string, data interface{}) error {
+	return nil
+}
+
+// NopPublisher 返回丢弃所有事件的发布者
+func NopPublisher() Publisher {
+	return nopPublisher{}
+}
+
+// Bus 基于NATS的事件总线，断线后自动重连
+// 事件最多投递一次：订阅方不在线或处理失败时事件丢失，只适合通知这类可丢失的场景
+type Bus struct {
+	conn   *nats.Conn
+	source string
+	logger *zap.Logger
+	closed chan struct{}
+}
+
+// Connect 连接NATS，source为发布事件的服务名
+// NATS暂时不可用时不返回错误，在后台持续重连，期间发布的事件缓存在客户端
+func Connect(url, source string, logger *zap.Logger) (*Bus, error) {
+	closed := make(chan struct{})
+	conn, err := nats.Connect(url,
+		nats.Name(source),
+		nats.MaxReconnects(-1),
+		nats.RetryOnFailedConnect(true),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			// 主动关闭时err为nil
+			if err != nil {
+				logger.Warn("Event bus disconnected", zap.Error(err))
+			}
+		}),
+		nats.ReconnectHandler(func(c *nats.Conn) {
+			logger.Info("Event bus reconnected", zap.String("url", c.ConnectedUrl()))
+		}),
+		nats.ClosedHandler(func(_ *nats.Conn) {
+			close(closed)
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to event bus: %w", err)
+	}
+	return &Bus{conn: conn, source: source, logger: logger, closed: closed}, nil
+}
+
+// Publish 发布事件，断线期间事件缓存在客户端，重连后发送
+func (b *Bus) Publish(ctx context.Context, eventType string, data interface{}) error {
+	event, err := NewEvent(b.source, eventType, data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.conn.Publish(Subject(eventType), payload)
+}
+
+// Subscribe 以队列组订阅事件，同一队列组的多个实例中只有一个收到事件
+func (b *Bus) Subscribe(queue string, handler Handler, eventTypes ...string) error {
+	for _, eventType := range eventTypes {
+		eventType := eventType
+		_, err := b.conn.QueueSubscribe(Subject(eventType), queue, func(msg *nats.Msg) {
+			var event Event
+			if err := json.Unmarshal(msg.Data, &event); err != nil {
+				b.logger.Warn("Dropping malformed event", zap.String("subject", msg.Subject), zap.Error(err))
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), handlerTimeout)
+			defer cancel()
+			if err := handler(ctx, &event); err != nil {
+				b.logger.Error("Failed to handle event",
+					zap.String("type", event.Type),
+					zap.String("event_id", event.ID),
+					zap.String("source", event.Source),
+					zap.Error(err),
+				)
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", eventType, err)
+		}
+	}
+	return nil
+}
+
+// Close 排空连接：停止接收新事件，等待处理中的事件完成并发送缓存的事件
+func (b *Bus) Close(ctx context.Context) error {
+	if err := b.conn.Drain(); err != nil {
+		b.conn.Close()
+		return err
+	}
+	select {
+	case <-b.closed:
+		return nil
+	case <-ctx.Done():
+		b.conn.Close()
+		return ctx.Err()
+	}
+}
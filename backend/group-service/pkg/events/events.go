@@ -0,0 +1,118 @@
+// Package events 服务间的领域事件，通过NATS发布和订阅
+// 各服务保留相同的副本，修改时需要同步到所有服务
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 事件类型，同时作为NATS主题的后缀
+const (
+	TypeMessageCreated    = "message.created"
+	TypeGroupMemberAdded  = "group.member_added"
+	TypeMediaUploaded     = "media.uploaded"
+	TypeMediaAttached     = "media.attached"
+	TypeMediaDetached     = "media.detached"
+	TypeFriendRequestSent = "friend.request_sent"
+)
+
+// SubjectPrefix 事件主题前缀，完整主题如 chatapp.events.message.created
+const SubjectPrefix = "chatapp.events."
+
+// Event 事件信封，Data为具体事件的JSON
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Source     string          `json:"source"` // 发布事件的服务
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// NewEvent 创建事件，data编码为JSON
+func NewEvent(source, eventType string, data interface{}) (*Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		Source:     source,
+		OccurredAt: time.Now().UTC(),
+		Data:       raw,
+	}, nil
+}
+
+// Decode 把事件数据解码到具体事件结构
+func (e *Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// Subject 事件类型对应的NATS主题
+func Subject(eventType string) string {
+	return SubjectPrefix + eventType
+}
+
+// MessageCreated 消息已发送，RecipientIDs为除发送者外的会话参与者
+type MessageCreated struct {
+	MessageID      string   `json:"message_id"`
+	ConversationID string   `json:"conversation_id"`
+	SenderID       string   `json:"sender_id"`
+	RecipientIDs   []string `json:"recipient_ids"`
+	Type           string   `json:"type"`
+	Preview        string   `json:"preview,omitempty"` // 文本消息的内容预览
+	IsGroupChat    bool     `json:"is_group_chat"`
+}
+
+// GroupMemberAdded 用户加入群组，Via区分加入方式
+type GroupMemberAdded struct {
+	GroupID   string `json:"group_id"`
+	GroupName string `json:"group_name"`
+	UserID    string `json:"user_id"`
+	Role      string `json:"role"`
+	ActorID   string `json:"actor_id"`
+	Via       string `json:"via"`
+}
+
+// 用户加入群组的方式
+const (
+	JoinViaAdded       = "added"        // 管理员直接添加
+	JoinViaInvitation  = "invitation"   // 接受邀请
+	JoinViaJoinRequest = "join_request" // 入群申请通过
+)
+
+// MediaUploaded 文件上传完成
+type MediaUploaded struct {
+	FileID   string `json:"file_id"`
+	OwnerID  string `json:"owner_id"`
+	TenantID string `json:"tenant_id,omitempty"`
+	FileName string `json:"file_name"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+}
+
+// MediaAttached 文件附加到会话，群组服务据此统计群组的文件存储用量
+type MediaAttached struct {
+	FileID         string `json:"file_id"`
+	ConversationID string `json:"conversation_id"`
+	OwnerID        string `json:"owner_id"`
+	Size           int64  `json:"size"`
+}
+
+// MediaDetached 文件不再计入会话：撤销会话共享或文件被删除
+type MediaDetached struct {
+	FileID         string `json:"file_id"`
+	ConversationID string `json:"conversation_id"`
+}
+
+// FriendRequestSent 好友请求已发送
+type FriendRequestSent struct {
+	RequestID   string `json:"request_id"`
+	SenderID    string `json:"sender_id"`
+	SenderName  string `json:"sender_name"`
+	RecipientID string `json:"recipient_id"`
+	Message     string `json:"message,omitempty"`
+}
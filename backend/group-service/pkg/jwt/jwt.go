@@ -13,6 +13,9 @@ type Claims struct {
 	UserID   uuid.UUID `json:"user_id"`
 	Username string    `json:"username"`
 	Email    string    `json:"email"`
+	Role     string    `json:"role,omitempty"`
+	// 用户服务签发的刷新令牌token_type为refresh，不能用于访问接口
+	TokenType string `json:"token_type,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -71,6 +74,9 @@ func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	if !ok || !token.Valid {
 		return nil, fmt.Errorf("invalid token")
 	}
+	if claims.TokenType == "refresh" {
+		return nil, fmt.Errorf("refresh token cannot be used as access token")
+	}
 
 	return claims, nil
 }
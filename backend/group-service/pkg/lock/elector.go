@@ -0,0 +1,137 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Elector 主实例选举：取得锁的实例成为主实例并定期续期，续期失败即放弃主实例身份，
+// 其他实例定期尝试取得锁，主实例退出或失联后由其中一个接替
+type Elector struct {
+	locker *Locker
+	name   string
+	ttl    time.Duration
+	logger *zap.Logger
+
+	mu    sync.RWMutex
+	lock  *Lock
+	until time.Time // 本实例确认持有锁的截止时间，Redis不可达时到期即视为失去主实例身份
+
+	wg sync.WaitGroup
+}
+
+// NewElector 创建主实例选举，ttl为锁的有效期，每隔ttl/3续期或尝试取得锁
+func NewElector(locker *Locker, name string, ttl time.Duration, logger *zap.Logger) *Elector {
+	return &Elector{
+		locker: locker,
+		name:   name,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+// Start 立即尝试一次取得锁，之后在后台定期续期或重试；ctx取消后释放锁，使用Wait等待退出
+func (e *Elector) Start(ctx context.Context) {
+	e.tick(ctx)
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		ticker := time.NewTicker(e.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				e.resign()
+				return
+			case <-ticker.C:
+				e.tick(ctx)
+			}
+		}
+	}()
+}
+
+// Wait 等待后台协程退出
+func (e *Elector) Wait() {
+	e.wg.Wait()
+}
+
+// IsLeader 当前实例是否为主实例
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.lock != nil && time.Now().Before(e.until)
+}
+
+// Token 成为主实例时取得的栅栏令牌，不是主实例时返回0
+func (e *Elector) Token() int64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.lock == nil || !time.Now().Before(e.until) {
+		return 0
+	}
+	return e.lock.Token()
+}
+
+// tick 持有锁时续期，否则尝试取得锁
+func (e *Elector) tick(ctx context.Context) {
+	e.mu.RLock()
+	current := e.lock
+	e.mu.RUnlock()
+
+	start := time.Now()
+	if current != nil {
+		err := current.Refresh(ctx, e.ttl)
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		switch {
+		case err == nil:
+			e.until = start.Add(e.ttl)
+		case errors.Is(err, ErrLockLost):
+			e.lock = nil
+			e.logger.Warn("Lost leadership", zap.String("election", e.name), zap.Int64("token", current.Token()))
+		default:
+			// 暂时无法访问Redis，截止时间前仍视为主实例
+			e.logger.Warn("Failed to renew leadership", zap.String("election", e.name), zap.Error(err))
+		}
+		return
+	}
+
+	lock, err := e.locker.Acquire(ctx, e.name, e.ttl)
+	if err != nil {
+		if !errors.Is(err, ErrNotAcquired) && ctx.Err() == nil {
+			e.logger.Warn("Failed to run leader election", zap.String("election", e.name), zap.Error(err))
+		}
+		return
+	}
+
+	e.mu.Lock()
+	e.lock = lock
+	e.until = start.Add(e.ttl)
+	e.mu.Unlock()
+	e.logger.Info("Became leader", zap.String("election", e.name), zap.Int64("token", lock.Token()))
+}
+
+// resign 退出时释放锁，其他实例无需等待锁过期即可接替
+func (e *Elector) resign() {
+	e.mu.Lock()
+	current := e.lock
+	e.lock = nil
+	e.mu.Unlock()
+	if current == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := current.Release(ctx); err != nil && !errors.Is(err, ErrLockLost) {
+		e.logger.Warn("Failed to release leadership", zap.String("election", e.name), zap.Error(err))
+		return
+	}
+	e.logger.Info("Resigned leadership", zap.String("election", e.name))
+}
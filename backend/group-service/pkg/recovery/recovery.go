@@ -0,0 +1,202 @@
+package recovery
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader 请求ID头，网关生成后随代理请求传给下游服务，各服务在响应中原样返回
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 接受的外部请求ID最大长度，超长时重新生成
+const maxRequestIDLength = 128
+
+const defaultReportTimeout = 5 * time.Second
+
+// requestIDKey 请求ID的上下文键
+type requestIDKey struct{}
+
+// Event 一次panic的上报内容
+type Event struct {
+	Service     string
+	RequestID   string
+	Transaction string // HTTP为"方法 路径"，gRPC为完整方法名
+	Method      string
+	URL         string
+	Panic       interface{}
+	Stack       []byte
+	Time        time.Time
+}
+
+// Reporter 错误上报接口，如Sentry
+type Reporter interface {
+	Report(ctx context.Context, event *Event) error
+}
+
+// Options 恢复中间件配置
+type Options struct {
+	// Service 服务名，写入日志和上报事件
+	Service string
+	// Reporter 错误上报，为nil时只记录日志
+	Reporter Reporter
+	// ReportTimeout 单次上报的超时时间，默认5秒
+	ReportTimeout time.Duration
+}
+
+// Recoverer 将处理请求时的panic转换为500响应，记录堆栈并上报
+type Recoverer struct {
+	opts   Options
+	logger *zap.Logger
+}
+
+// New 创建恢复中间件
+func New(opts Options, logger *zap.Logger) *Recoverer {
+	if opts.ReportTimeout <= 0 {
+		opts.ReportTimeout = defaultReportTimeout
+	}
+	return &Recoverer{opts: opts, logger: logger}
+}
+
+// NewRequestID 生成随机请求ID
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID 将请求ID写入上下文
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext 获取中间件写入的请求ID
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// Middleware 为请求分配请求ID（沿用上游传入的X-Request-ID），并在处理函数panic时返回带请求ID的500响应
+// 响应已开始写出时无法再改为500，此时中断连接
+func (rc *Recoverer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = NewRequestID()
+			r.Header.Set(RequestIDHeader, requestID)
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+		r = r.WithContext(WithRequestID(r.Context(), requestID))
+
+		rw := &responseWriter{ResponseWriter: w}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// 标准库用于主动中断响应的panic，不视为错误
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			rc.HandlePanic(r.Context(), &Event{
+				RequestID:   requestID,
+				Transaction: r.Method + " " + r.URL.Path,
+				Method:      r.Method,
+				URL:         r.URL.String(),
+				Panic:       recovered,
+			})
+
+			if rw.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{ // nolint: errcheck
+				"error":      "internal server error",
+				"request_id": requestID,
+			})
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// HandlePanic 记录panic日志和堆栈并异步上报，需在recover所在的defer中调用以获取panic处的堆栈
+// 供HTTP中间件以及gRPC拦截器等其他入口共用
+func (rc *Recoverer) HandlePanic(ctx context.Context, event *Event) {
+	event.Service = rc.opts.Service
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if event.Stack == nil {
+		event.Stack = debug.Stack()
+	}
+
+	rc.logger.Error("Recovered from panic",
+		zap.String("request_id", event.RequestID),
+		zap.String("transaction", event.Transaction),
+		zap.Any("panic", event.Panic),
+		zap.ByteString("stack", event.Stack),
+	)
+
+	if rc.opts.Reporter == nil {
+		return
+	}
+	// 上报不阻塞响应，也不受请求上下文取消的影响
+	go func() {
+		reportCtx, cancel := context.WithTimeout(context.Background(), rc.opts.ReportTimeout)
+		defer cancel()
+		if err := rc.opts.Reporter.Report(reportCtx, event); err != nil {
+			rc.logger.Warn("Failed to report panic", zap.String("request_id", event.RequestID), zap.Error(err))
+		}
+	}()
+}
+
+// responseWriter 记录响应头是否已写出，保留Flush和Hijack以支持流式响应和WebSocket
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	w.wroteHeader = true
+	return hijacker.Hijack()
+}
+
+// Unwrap 供http.ResponseController访问底层ResponseWriter
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
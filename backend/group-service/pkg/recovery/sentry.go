@@ -0,0 +1,130 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// SentryReporter 通过Sentry的store接口上报panic，不依赖Sentry SDK
+type SentryReporter struct {
+	endpoint    string
+	auth        string
+	environment string
+	serverName  string
+	client      *http.Client
+}
+
+// ReporterFromDSN 根据Sentry DSN创建上报，DSN为空时返回nil，只记录日志不上报
+func ReporterFromDSN(dsn, environment string) (Reporter, error) {
+	if dsn == "" {
+		return nil, nil
+	}
+	reporter, err := NewSentryReporter(dsn, environment)
+	if err != nil {
+		return nil, err
+	}
+	return reporter, nil
+}
+
+// NewSentryReporter 根据DSN创建Sentry上报，DSN格式为 https://<key>@<host>[/<path>]/<project_id>
+func NewSentryReporter(dsn, environment string) (*SentryReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry dsn: %w", err)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid sentry dsn: missing public key")
+	}
+
+	path := strings.Trim(u.Path, "/")
+	idx := strings.LastIndex(path, "/")
+	prefix, projectID := "", path
+	if idx >= 0 {
+		prefix, projectID = "/"+path[:idx], path[idx+1:]
+	}
+	if projectID == "" {
+		return nil, fmt.Errorf("invalid sentry dsn: missing project id")
+	}
+
+	hostname, _ := os.Hostname()
+	return &SentryReporter{
+		endpoint:    fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, projectID),
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_client=chatapp-recovery/1.0, sentry_key=%s", u.User.Username()),
+		environment: environment,
+		serverName:  hostname,
+		client:      &http.Client{},
+	}, nil
+}
+
+// sentryEvent Sentry事件中用到的字段
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Logger      string                 `json:"logger"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Transaction string                 `json:"transaction,omitempty"`
+	Message     string                 `json:"message"`
+	Tags        map[string]string      `json:"tags"`
+	Request     map[string]string      `json:"request,omitempty"`
+	Extra       map[string]interface{} `json:"extra"`
+}
+
+// Report 上报panic事件，请求ID作为标签便于与日志关联
+func (s *SentryReporter) Report(ctx context.Context, event *Event) error {
+	message := fmt.Sprintf("panic: %v", event.Panic)
+	payload := sentryEvent{
+		EventID:     NewRequestID(),
+		Timestamp:   event.Time.UTC().Format("2006-01-02T15:04:05.000Z"),
+		Level:       "fatal",
+		Platform:    "go",
+		Logger:      event.Service,
+		ServerName:  s.serverName,
+		Environment: s.environment,
+		Transaction: event.Transaction,
+		Message:     message,
+		Tags: map[string]string{
+			"service":    event.Service,
+			"request_id": event.RequestID,
+		},
+		Extra: map[string]interface{}{
+			"stack": string(event.Stack),
+		},
+	}
+	if event.URL != "" {
+		payload.Request = map[string]string{"method": event.Method, "url": event.URL}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) // nolint: errcheck
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
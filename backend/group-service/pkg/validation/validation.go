@@ -0,0 +1,310 @@
+package validation
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError 单个字段的校验错误，Field为JSON字段名，嵌套字段以"."连接
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Errors 一次校验中全部字段的错误
+type Errors []FieldError
+
+// Error 实现error接口，将字段错误拼接为一行
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// StructValidator 标签无法表达的跨字段规则，由请求结构体实现，在标签规则之后调用
+// 返回的Field和Message以该结构体为起点，嵌套时自动加上上层字段路径
+type StructValidator interface {
+	ValidateStruct() Errors
+}
+
+// RuleFunc 自定义规则，value为字段值（指针已解引用），param为标签中"="后的参数
+type RuleFunc func(value interface{}, param string) bool
+
+type customRule struct {
+	fn      RuleFunc
+	message string
+}
+
+var (
+	customRulesMu sync.RWMutex
+	customRules   = map[string]customRule{}
+)
+
+// Register 注册自定义规则，message为字段名之后的错误描述，如"is not a supported language"
+func Register(name, message string, fn RuleFunc) {
+	customRulesMu.Lock()
+	defer customRulesMu.Unlock()
+	customRules[name] = customRule{fn: fn, message: message}
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Struct 按validate标签校验结构体（或结构体指针），全部通过时返回nil，否则返回Errors
+//
+// 支持的规则：required、omitempty、min、max、len、oneof（空格分隔）、email、url、uuid、base64及Register注册的规则。
+// min/max/len对字符串按字符数、对数字按数值、对切片和map按元素个数比较；required对字符串忽略首尾空白。
+// 结构体字段和结构体切片会递归校验；标签中出现未知规则视为编码错误，直接panic
+func Struct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return Errors{{Rule: "required", Message: "request body is required"}}
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: Struct called with %s", rv.Kind()))
+	}
+
+	var errs Errors
+	validateStruct(rv, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateStruct 校验结构体的各字段，prefix为上层字段路径
+func validateStruct(rv reflect.Value, prefix string, errs *Errors) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("validate")
+		if tag == "-" {
+			continue
+		}
+
+		value := rv.Field(i)
+		// 匿名嵌入的结构体字段提升到当前层级
+		if field.Anonymous && indirect(value).Kind() == reflect.Struct && tag == "" {
+			if embedded := indirect(value); embedded.IsValid() {
+				validateStruct(embedded, prefix, errs)
+			}
+			continue
+		}
+
+		name := joinPath(prefix, fieldName(field))
+		if tag != "" && !validateField(value, name, tag, errs) {
+			continue
+		}
+		validateNested(value, name, errs)
+	}
+
+	// 嵌套结构体同样可以实现跨字段规则，字段名加上路径前缀
+	if sv, ok := rv.Interface().(StructValidator); ok {
+		appendPrefixed(errs, prefix, sv.ValidateStruct())
+	} else if rv.CanAddr() {
+		if sv, ok := rv.Addr().Interface().(StructValidator); ok {
+			appendPrefixed(errs, prefix, sv.ValidateStruct())
+		}
+	}
+}
+
+// validateNested 递归校验结构体字段和结构体切片中的元素
+func validateNested(value reflect.Value, name string, errs *Errors) {
+	value = indirect(value)
+	if !value.IsValid() {
+		return
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		validateStruct(value, name, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			elem := indirect(value.Index(i))
+			if elem.IsValid() && elem.Kind() == reflect.Struct {
+				validateStruct(elem, fmt.Sprintf("%s[%d]", name, i), errs)
+			}
+		}
+	}
+}
+
+// validateField 按标签校验单个字段，字段出错或因omitempty跳过时返回false，不再校验嵌套内容
+func validateField(value reflect.Value, name, tag string, errs *Errors) bool {
+	rules := strings.Split(tag, ",")
+	for _, rule := range rules {
+		if rule == "omitempty" && isEmpty(value) {
+			return false
+		}
+	}
+
+	for _, rule := range rules {
+		rule, param, _ := strings.Cut(rule, "=")
+		if rule == "omitempty" || rule == "" {
+			continue
+		}
+
+		if rule == "required" {
+			if isEmpty(value) {
+				*errs = append(*errs, FieldError{Field: name, Rule: rule, Message: name + " is required"})
+				return false
+			}
+			continue
+		}
+
+		target := indirect(value)
+		if !target.IsValid() {
+			// nil指针只由required处理
+			continue
+		}
+		if message, ok := check(target, rule, param); !ok {
+			*errs = append(*errs, FieldError{Field: name, Rule: rule, Param: param, Message: name + " " + message})
+			return false
+		}
+	}
+	return true
+}
+
+// check 执行单条规则，失败时返回字段名之后的错误描述
+func check(value reflect.Value, rule, param string) (string, bool) {
+	switch rule {
+	case "min", "max", "len":
+		return checkSize(value, rule, param)
+	case "oneof":
+		actual := fmt.Sprint(value.Interface())
+		for _, option := range strings.Fields(param) {
+			if actual == option {
+				return "", true
+			}
+		}
+		return "must be one of: " + strings.Join(strings.Fields(param), ", "), false
+	case "email":
+		s := value.String()
+		addr, err := mail.ParseAddress(s)
+		return "must be a valid email address", err == nil && addr.Address == s
+	case "url":
+		u, err := url.Parse(value.String())
+		return "must be a valid URL", err == nil && u.Scheme != "" && u.Host != ""
+	case "uuid":
+		return "must be a valid UUID", uuidPattern.MatchString(value.String())
+	case "base64":
+		_, err := base64.StdEncoding.DecodeString(value.String())
+		return "must be valid base64", err == nil
+	}
+
+	customRulesMu.RLock()
+	custom, ok := customRules[rule]
+	customRulesMu.RUnlock()
+	if !ok {
+		panic(fmt.Sprintf("validation: unknown rule %q", rule))
+	}
+	return custom.message, custom.fn(value.Interface(), param)
+}
+
+// checkSize 执行min/max/len规则
+func checkSize(value reflect.Value, rule, param string) (string, bool) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation: invalid %s parameter %q", rule, param))
+	}
+
+	var actual float64
+	unit := ""
+	switch value.Kind() {
+	case reflect.String:
+		actual, unit = float64(utf8.RuneCountInString(value.String())), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		actual, unit = float64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		actual = value.Float()
+	default:
+		panic(fmt.Sprintf("validation: %s not supported for %s", rule, value.Kind()))
+	}
+
+	switch rule {
+	case "min":
+		if unit == "" {
+			return "must be at least " + param, actual >= limit
+		}
+		return "must contain at least " + param + unit, actual >= limit
+	case "max":
+		if unit == "" {
+			return "must be at most " + param, actual <= limit
+		}
+		return "must contain at most " + param + unit, actual <= limit
+	default:
+		if unit == "" {
+			return "must be " + param, actual == limit
+		}
+		return "must contain exactly " + param + unit, actual == limit
+	}
+}
+
+// isEmpty 判断字段是否为空值，字符串只含空白也视为空
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	}
+	return value.IsZero()
+}
+
+// indirect 解引用指针和接口，nil时返回无效值
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+// fieldName 取json标签中的字段名，没有时使用Go字段名
+func fieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// appendPrefixed 为跨字段规则返回的错误加上字段路径前缀
+func appendPrefixed(errs *Errors, prefix string, fieldErrs Errors) {
+	for _, fieldErr := range fieldErrs {
+		if prefix != "" {
+			fieldErr.Field = joinPath(prefix, fieldErr.Field)
+			fieldErr.Message = joinPath(prefix, fieldErr.Message)
+		}
+		*errs = append(*errs, fieldErr)
+	}
+}
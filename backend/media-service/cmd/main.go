@@ -2,26 +2,36 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
 	"media-service/config"
+	"media-service/internal/client"
 	"media-service/internal/handlers"
 	"media-service/internal/repository"
+	"media-service/internal/scanner"
 	"media-service/internal/service"
+	"media-service/internal/speech"
 	"media-service/internal/storage"
+	"media-service/internal/vision"
 	"media-service/pkg/auth"
+	"media-service/pkg/events"
+	"media-service/pkg/jobs"
+	"media-service/pkg/lock"
+	"media-service/pkg/metrics"
+	"media-service/pkg/openapi"
+	"media-service/pkg/recovery"
+	"media-service/pkg/shutdown"
+	"media-service/pkg/tracing"
 )
 
 func main() {
@@ -43,9 +53,20 @@ func main() {
 		zap.String("storage_provider", cfg.Storage.Provider),
 	)
 
+	// 链路追踪：从请求的traceparent继续网关开始的trace，按配置导出到OTLP或Jaeger
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Options{
+		Service:       "media-service",
+		Exporter:      cfg.Tracing.Exporter,
+		Endpoint:      cfg.Tracing.Endpoint,
+		Insecure:      cfg.Tracing.Insecure,
+		SamplePercent: cfg.Tracing.SamplePercent,
+	}, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+
 	// 初始化数据库
 	var db *sqlx.DB
-	var err error
 	if cfg.Database.Host != "" {
 		db, err = initDatabase(cfg, logger)
 		if err != nil {
@@ -65,21 +86,120 @@ func main() {
 		logger.Info("Using memory repository")
 	}
 
-	// 初始化存储提供者
-	storageProvider, err := storage.NewStorageProvider(cfg, logger)
+	// 初始化存储提供者，按租户路由到各自的存储桶和前缀
+	storageProvider, err := storage.NewTenantStorage(cfg, logger)
 	if err != nil {
 		logger.Fatal("Failed to initialize storage provider", zap.Error(err))
 	}
-	logger.Info("Storage provider initialized", zap.String("provider", cfg.Storage.Provider))
+
+	// Prometheus指标：HTTP请求、存储操作和数据库连接池
+	var serviceMetrics *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		serviceMetrics = metrics.New("media-service")
+		storageProvider.Instrument(serviceMetrics.ObserveStorage)
+		if db != nil {
+			serviceMetrics.RegisterDB(db.DB, "postgres")
+		}
+	}
+	logger.Info("Storage provider initialized",
+		zap.String("provider", cfg.Storage.Provider),
+		zap.Int("tenant_locations", len(cfg.Tenancy.Storage)),
+	)
+
+	// 初始化文件扫描器
+	fileScanner, err := scanner.NewScanner(cfg, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize file scanner", zap.Error(err))
+	}
+	logger.Info("File scanner initialized", zap.String("provider", cfg.Scan.Provider))
+
+	// 初始化图片内容识别（OCR和图片描述）
+	analyzer, err := vision.NewAnalyzer(cfg, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize image analyzer", zap.Error(err))
+	}
+	logger.Info("Image analyzer initialized",
+		zap.String("ocr_provider", cfg.Vision.OCRProvider),
+		zap.String("caption_provider", cfg.Vision.CaptionProvider),
+	)
+
+	// 初始化语音转写
+	transcriber, err := speech.NewTranscriber(cfg, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize transcriber", zap.Error(err))
+	}
+	logger.Info("Transcriber initialized", zap.String("provider", cfg.Transcription.Provider))
+
+	// 初始化通知服务、消息服务和群组服务客户端
+	notificationClient := client.NewNotificationClient(cfg.External.NotificationServiceURL)
+	messageClient := client.NewMessageClient(cfg.External.MessageServiceURL)
+	groupClient := client.NewGroupClient(cfg.External.GroupServiceURL)
 
 	// 初始化JWT管理器
 	auth.InitJWT(cfg.JWT.SecretKey, time.Duration(cfg.JWT.ExpirationHours)*time.Hour, logger)
 
+	// 初始化后台任务，转写和视频处理耗时较长，租约按30分钟设置；启用主实例选举时只有主实例触发定时任务
+	elector := initLeaderElector(cfg, logger)
+	jobOptions := jobs.Options{Lease: 30 * time.Minute}
+	if elector != nil {
+		jobOptions.Leader = elector
+	}
+	jobRunner := jobs.NewRunner(initJobQueue(db, logger), jobOptions, logger)
+
+	// 上传完成发布到事件总线，未配置时不发布
+	eventBus := initEventBus(cfg, logger)
+
 	// 初始化服务
-	mediaService := service.NewMediaService(mediaRepo, storageProvider, cfg, logger)
+	mediaService := service.NewMediaService(mediaRepo, storageProvider, fileScanner, analyzer, transcriber, notificationClient, messageClient, groupClient, jobRunner, eventPublisher(eventBus), cfg, logger)
+
+	// 本地存储不再提供静态文件目录，旧记录中的文件地址改为下载接口地址
+	if count, err := mediaService.MigrateLocalFileURLs(); err != nil {
+		logger.Error("Failed to migrate local file urls", zap.Error(err))
+	} else if count > 0 {
+		logger.Info("Migrated local file urls", zap.Int("count", count))
+	}
+
+	// 每小时清理过期文件
+	err = jobRunner.Schedule("cleanup_expired_files", "@hourly", func(ctx context.Context, _ json.RawMessage) error {
+		return mediaService.CleanupExpiredFiles()
+	})
+	if err != nil {
+		logger.Fatal("Failed to schedule cleanup job", zap.Error(err))
+	}
+
+	// 每小时永久删除保留期满的已删除文件
+	err = jobRunner.Schedule("purge_deleted_files", "@hourly", func(ctx context.Context, _ json.RawMessage) error {
+		count, err := mediaService.PurgeDeletedFiles()
+		if count > 0 {
+			logger.Info("Purged deleted files", zap.Int("count", count))
+		}
+		return err
+	}, jobs.Timeout(30*time.Minute), jobs.MaxAttempts(1))
+	if err != nil {
+		logger.Fatal("Failed to schedule purge job", zap.Error(err))
+	}
+
+	// 启用备用存储时定期检查并补齐主存储和备用存储中缺失的副本
+	if storageProvider.FailoverEnabled() {
+		interval := cfg.Failover.RepairIntervalMinutes
+		if interval <= 0 {
+			interval = 30
+		}
+		err = jobRunner.Schedule("repair_storage_replicas", fmt.Sprintf("@every %dm", interval), func(ctx context.Context, _ json.RawMessage) error {
+			count, err := mediaService.RepairStorageReplicas()
+			if count > 0 {
+				logger.Info("Repaired storage replicas", zap.Int("count", count))
+			}
+			return err
+		}, jobs.Timeout(30*time.Minute), jobs.MaxAttempts(1))
+		if err != nil {
+			logger.Fatal("Failed to schedule storage repair job", zap.Error(err))
+		}
+	}
 
 	// 初始化处理器
-	mediaHandler := handlers.NewMediaHandler(mediaService, logger)
+	uploadProgress := service.NewUploadProgressTracker(time.Duration(cfg.File.UploadProgressRetention) * time.Minute)
+	mediaHandler := handlers.NewMediaHandler(mediaService, uploadProgress, logger)
 
 	// 初始化路由
 	router := mux.NewRouter()
@@ -88,13 +208,46 @@ func main() {
 	// CORS中间件已移除，由API网关统一处理
 	router.Use(auth.LoggingMiddleware(logger))
 
+	// 后台任务执行统计
+	router.HandleFunc("/internal/jobs/metrics", jobRunner.MetricsHandler).Methods("GET")
+
 	// 注册路由
 	mediaHandler.RegisterRoutes(router)
 
+	// 接口文档：由注册的路由和接口说明生成，两者不一致时记录警告，严格模式下拒绝启动
+	apiDoc, problems := openapi.Build(router, "media-service", handlers.APIOperations)
+	for _, problem := range problems {
+		logger.Warn("OpenAPI document does not match routes", zap.String("problem", problem))
+	}
+	if len(problems) > 0 && cfg.OpenAPI.Strict {
+		logger.Fatal("OpenAPI document does not match routes", zap.Int("problems", len(problems)))
+	}
+	router.HandleFunc("/openapi.json", openapi.Handler(apiDoc)).Methods("GET")
+
+	// 处理请求时的panic转换为带请求ID的500响应，配置了SENTRY_DSN时上报到Sentry
+	reporter, err := recovery.ReporterFromDSN(cfg.ErrorReporting.SentryDSN, cfg.ErrorReporting.Environment)
+	if err != nil {
+		logger.Fatal("Invalid error reporting configuration", zap.Error(err))
+	}
+	recoverer := recovery.New(recovery.Options{Service: "media-service", Reporter: reporter}, logger)
+
+	var httpHandler http.Handler = recoverer.Middleware(router)
+	if serviceMetrics != nil {
+		router.Handle("/metrics", serviceMetrics.Handler(cfg.Metrics.Token)).Methods("GET")
+		httpHandler = serviceMetrics.Instrument(router, httpHandler)
+	}
+	httpHandler = tracing.Middleware("media-service", router, httpHandler)
+
+	// 优雅关闭：排空期间健康检查返回503，负载均衡摘除本实例后再停止接收连接
+	coordinator := shutdown.New(shutdown.Options{
+		DrainDelay: time.Duration(cfg.Server.ShutdownDrainDelay) * time.Second,
+		Timeout:    time.Duration(cfg.Server.ShutdownTimeout) * time.Second,
+	}, logger)
+
 	// 创建HTTP服务器
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
-		Handler:      router,
+		Handler:      coordinator.Middleware(httpHandler),
 		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
 		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
 		IdleTimeout:  time.Duration(cfg.Server.IdleTimeout) * time.Second,
@@ -108,25 +261,33 @@ func main() {
 		}
 	}()
 
-	// 启动清理任务
-	go startCleanupTasks(mediaService, logger)
-
-	// 等待中断信号
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.Info("Shutting down media service...")
-
-	// 优雅关闭
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	// 启动后台任务
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if elector != nil {
+		elector.Start(jobCtx)
+	}
+	jobRunner.Start(jobCtx)
+	mediaService.StartThumbnailWorkers(jobCtx)
 
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Error("Server forced to shutdown", zap.Error(err))
-	} else {
-		logger.Info("Media service stopped gracefully")
+	// 关闭顺序：停止接收请求并等待上传完成 -> 发送缓存的事件 -> 等待执行中的媒体处理任务和缩略图任务 -> 导出剩余的span
+	coordinator.Register("http", srv.Shutdown)
+	if eventBus != nil {
+		coordinator.Register("events", eventBus.Close)
 	}
+	coordinator.Register("jobs", func(ctx context.Context) error {
+		stopJobs()
+		if elector != nil {
+			defer elector.Wait()
+		}
+		if err := shutdown.Await(ctx, jobRunner.Wait); err != nil {
+			return err
+		}
+		return shutdown.Await(ctx, mediaService.WaitThumbnailWorkers)
+	})
+	coordinator.Register("tracing", shutdownTracing)
+	coordinator.Wait()
+	logger.Info("Media service stopped gracefully")
 
 	// 关闭数据库连接
 	if db != nil {
@@ -134,6 +295,28 @@ func main() {
 	}
 }
 
+// initEventBus 连接事件总线，未配置时返回nil
+func initEventBus(cfg *config.Config, logger *zap.Logger) *events.Bus {
+	if cfg.External.EventBusURL == "" {
+		logger.Info("Event bus not configured, domain events disabled")
+		return nil
+	}
+	bus, err := events.Connect(cfg.External.EventBusURL, "media-service", logger)
+	if err != nil {
+		logger.Warn("Failed to connect to event bus, domain events disabled", zap.Error(err))
+		return nil
+	}
+	return bus
+}
+
+// eventPublisher 事件总线不可用时返回丢弃事件的发布者
+func eventPublisher(bus *events.Bus) events.Publisher {
+	if bus == nil {
+		return events.NopPublisher()
+	}
+	return bus
+}
+
 // initLogger 初始化日志
 func initLogger(level string) *zap.Logger {
 	var zapLevel zapcore.Level
@@ -196,10 +379,12 @@ func initDatabase(cfg *config.Config, logger *zap.Logger) (*sqlx.DB, error) {
 		cfg.Database.SSLMode,
 	)
 
-	db, err := sqlx.Connect("postgres", dsn)
+	// 请求中的查询和写入记录为请求span的子span
+	sqlDB, err := tracing.OpenPostgres(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	db := sqlx.NewDb(sqlDB, "postgres")
 
 	// 设置连接池参数
 	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
@@ -269,14 +454,59 @@ func runMigrations(db *sqlx.DB, logger *zap.Logger) error {
 			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 		)`,
 		
+		// 缩略图等后台处理任务的重试次数和退避时间
+		`ALTER TABLE processing_jobs ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE processing_jobs ADD COLUMN IF NOT EXISTS next_run_at TIMESTAMP WITH TIME ZONE`,
+
+		// 多租户：媒体所属租户，历史数据归属默认租户
+		`ALTER TABLE media_files ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default'`,
+
+		// 租户存储配额表
+		`CREATE TABLE IF NOT EXISTS tenant_storage_quotas (
+			tenant_id VARCHAR(64) PRIMARY KEY,
+			total_quota BIGINT NOT NULL,
+			used_quota BIGINT NOT NULL DEFAULT 0,
+			file_count INTEGER NOT NULL DEFAULT 0,
+			max_file_count INTEGER NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		)`,
+
+		// 用户当前头像，头像链接按用户生成
+		`CREATE TABLE IF NOT EXISTS user_avatars (
+			user_id VARCHAR(36) PRIMARY KEY,
+			media_id VARCHAR(36) NOT NULL REFERENCES media_files(id) ON DELETE CASCADE,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		)`,
+
+		// 媒体共享：附加到会话或授权给指定用户
+		`CREATE TABLE IF NOT EXISTS media_shares (
+			id VARCHAR(36) PRIMARY KEY,
+			media_id VARCHAR(36) NOT NULL REFERENCES media_files(id) ON DELETE CASCADE,
+			conversation_id VARCHAR(64),
+			user_id VARCHAR(36),
+			created_by VARCHAR(36) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			CHECK ((conversation_id IS NULL) <> (user_id IS NULL))
+		)`,
+
+		// 软删除：记录删除时间，保留期满后由定时任务删除存储文件和记录；已删除的历史数据以更新时间作为删除时间
+		`ALTER TABLE media_files ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE`,
+		`UPDATE media_files SET deleted_at = updated_at WHERE status = 'deleted' AND deleted_at IS NULL`,
+
 		// 创建索引
 		`CREATE INDEX IF NOT EXISTS idx_media_files_user_id ON media_files(user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_media_files_tenant_id ON media_files(tenant_id, status)`,
 		`CREATE INDEX IF NOT EXISTS idx_media_files_status ON media_files(status)`,
 		`CREATE INDEX IF NOT EXISTS idx_media_files_media_type ON media_files(media_type)`,
 		`CREATE INDEX IF NOT EXISTS idx_media_files_created_at ON media_files(created_at)`,
 		`CREATE INDEX IF NOT EXISTS idx_media_files_expires_at ON media_files(expires_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_media_files_deleted_at ON media_files(deleted_at) WHERE deleted_at IS NOT NULL`,
 		`CREATE INDEX IF NOT EXISTS idx_processing_jobs_status ON processing_jobs(status)`,
+		`CREATE INDEX IF NOT EXISTS idx_processing_jobs_pending ON processing_jobs(job_type, created_at) WHERE status = 'pending'`,
 		`CREATE INDEX IF NOT EXISTS idx_processing_jobs_media_id ON processing_jobs(media_id)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_media_shares_conversation ON media_shares(media_id, conversation_id) WHERE conversation_id IS NOT NULL`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_media_shares_user ON media_shares(media_id, user_id) WHERE user_id IS NOT NULL`,
 	}
 
 	for i, migration := range migrations {
@@ -289,22 +519,41 @@ func runMigrations(db *sqlx.DB, logger *zap.Logger) error {
 	return nil
 }
 
-// startCleanupTasks 启动清理任务
-func startCleanupTasks(mediaService service.MediaService, logger *zap.Logger) {
-	ticker := time.NewTicker(1 * time.Hour) // 每小时运行一次
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			logger.Info("Running cleanup tasks")
-			
-			// 清理过期文件
-			if err := mediaService.CleanupExpiredFiles(); err != nil {
-				logger.Error("Failed to cleanup expired files", zap.Error(err))
-			} else {
-				logger.Info("Expired files cleanup completed")
-			}
+// initLeaderElector 初始化定时任务的主实例选举，未启用或Redis连接失败时返回nil，此时每个实例都触发定时任务
+func initLeaderElector(cfg *config.Config, logger *zap.Logger) *lock.Elector {
+	if !cfg.LeaderElection.Enabled {
+		return nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		logger.Warn("Failed to connect to Redis, leader election disabled", zap.Error(err))
+		client.Close()
+		return nil
+	}
+
+	logger.Info("Leader election enabled for scheduled jobs",
+		zap.String("redis_addr", cfg.Redis.Addr),
+		zap.Int("ttl_seconds", cfg.LeaderElection.TTLSeconds),
+	)
+	return lock.NewElector(lock.NewLocker(client, "media-service"), "jobs", time.Duration(cfg.LeaderElection.TTLSeconds)*time.Second, logger)
+}
+
+// initJobQueue 初始化后台任务队列，有数据库时使用持久化队列
+func initJobQueue(db *sqlx.DB, logger *zap.Logger) jobs.Queue {
+	if db != nil {
+		queue, err := jobs.NewPostgresQueue(db.DB, "media-service")
+		if err == nil {
+			return queue
 		}
+		logger.Warn("Failed to initialize persistent job queue, using memory queue", zap.Error(err))
 	}
-}
\ No newline at end of file
+	return jobs.NewMemoryQueue()
+}
@@ -14,6 +14,10 @@ type ServerConfig struct {
 	ReadTimeout  int `json:"read_timeout"`
 	WriteTimeout int `json:"write_timeout"`
 	IdleTimeout  int `json:"idle_timeout"`
+	// ShutdownDrainDelay 进入排空状态（健康检查返回503）后等待多久再停止接收连接
+	ShutdownDrainDelay int `json:"shutdown_drain_delay"`
+	// ShutdownTimeout 关闭步骤的总超时时间，应覆盖最长的上传请求
+	ShutdownTimeout int `json:"shutdown_timeout"`
 }
 
 // DatabaseConfig 数据库配置
@@ -45,6 +49,34 @@ type StorageConfig struct {
 	Provider  string `json:"provider"`   // local, s3, minio
 	LocalPath string `json:"local_path"` // 本地存储路径
 	BaseURL   string `json:"base_url"`   // 基础URL
+	// ContentCacheSeconds 文件下载响应的缓存时间，只允许客户端缓存
+	ContentCacheSeconds int `json:"content_cache_seconds"`
+	// DeleteRetentionDays 删除的文件保留多少天，期内可以恢复，期满后从存储中永久删除
+	DeleteRetentionDays int `json:"delete_retention_days"`
+}
+
+// TenantStorageConfig 单个租户的存储位置，Bucket为空时使用默认存储桶
+type TenantStorageConfig struct {
+	Bucket string `json:"bucket"`
+	Prefix string `json:"prefix"`
+}
+
+// TenancyConfig 多租户配置
+type TenancyConfig struct {
+	Storage             map[string]TenantStorageConfig `json:"storage"`                // 租户ID -> 存储位置
+	DefaultQuota        int64                          `json:"default_quota"`          // 租户默认总配额（字节）
+	DefaultMaxFileCount int                            `json:"default_max_file_count"` // 租户默认最大文件数量
+}
+
+// FailoverConfig 备用存储配置，Provider为空时不启用
+// 文件写入主存储后异步复制到备用存储，主存储不可用时读写转到备用存储，恢复后由修复任务回填
+type FailoverConfig struct {
+	Provider  string    `json:"provider"`   // local, s3, minio
+	LocalPath string    `json:"local_path"` // 本地存储路径
+	AWS       AWSConfig `json:"aws"`        // S3/MinIO连接配置
+	// RepairIntervalMinutes 修复任务的执行间隔，每次检查RepairBatchSize个文件在两个存储中是否都存在
+	RepairIntervalMinutes int `json:"repair_interval_minutes"`
+	RepairBatchSize       int `json:"repair_batch_size"`
 }
 
 // AWSConfig AWS配置
@@ -65,6 +97,9 @@ type FileConfig struct {
 	AllowedVideoTypes []string `json:"allowed_video_types"`
 	AllowedAudioTypes []string `json:"allowed_audio_types"`
 	AllowedFileTypes  []string `json:"allowed_file_types"`
+
+	// 上传结束后保留进度记录的时间（分钟），供客户端查询最终结果
+	UploadProgressRetention int `json:"upload_progress_retention"`
 }
 
 // ImageConfig 图片处理配置
@@ -72,6 +107,86 @@ type ImageConfig struct {
 	ThumbnailWidth  int `json:"thumbnail_width"`
 	ThumbnailHeight int `json:"thumbnail_height"`
 	ImageQuality    int `json:"image_quality"`
+
+	// 缩略图预设：每张图片按这些最大边长（像素）生成一组缩略图
+	ThumbnailPresets []int `json:"thumbnail_presets"`
+
+	// 缩略图任务工作池：并发数、轮询间隔（秒）、最多执行次数、首次重试的退避时间（秒，之后逐次翻倍）
+	ThumbnailWorkers      int `json:"thumbnail_workers"`
+	ThumbnailPollInterval int `json:"thumbnail_poll_interval"`
+	ThumbnailMaxAttempts  int `json:"thumbnail_max_attempts"`
+	ThumbnailRetryBackoff int `json:"thumbnail_retry_backoff"`
+
+	// 占位图配置
+	BlurHashComponentsX int `json:"blurhash_components_x"`
+	BlurHashComponentsY int `json:"blurhash_components_y"`
+	PlaceholderSize     int `json:"placeholder_size"`
+
+	// 上传时删除EXIF等元数据（包括GPS位置），按EXIF方向旋转图片
+	StripExif            bool `json:"strip_exif"`
+	NormalizeOrientation bool `json:"normalize_orientation"`
+
+	// 解码前按图片头中的尺寸检查，宽×高超过该像素数的图片不做处理
+	MaxPixels int `json:"max_pixels"`
+}
+
+// VideoConfig 视频处理配置
+type VideoConfig struct {
+	FFmpegPath  string `json:"ffmpeg_path"`
+	FFprobePath string `json:"ffprobe_path"`
+
+	// 拖动预览雪碧图配置
+	SpriteEnabled   bool `json:"sprite_enabled"`
+	SpriteInterval  int  `json:"sprite_interval"` // 截帧间隔（秒）
+	SpriteWidth     int  `json:"sprite_width"`    // 单帧宽度（像素）
+	SpriteColumns   int  `json:"sprite_columns"`
+	SpriteMaxFrames int  `json:"sprite_max_frames"`
+
+	// 转码配置：生成H.264/AAC的MP4和封面图，不放大视频
+	TranscodeEnabled      bool   `json:"transcode_enabled"`
+	TranscodeHeights      []int  `json:"transcode_heights"` // 输出高度（像素）
+	TranscodeCRF          int    `json:"transcode_crf"`     // x264质量，越小质量越高
+	TranscodePreset       string `json:"transcode_preset"`  // x264编码速度预设
+	TranscodeAudioBitrate string `json:"transcode_audio_bitrate"`
+	TranscodeTimeout      int    `json:"transcode_timeout"` // 单个转码任务的最长执行时间（分钟），应小于任务租约
+}
+
+// AudioConfig 音频处理配置，解码音频使用视频处理配置中的ffmpeg
+type AudioConfig struct {
+	WaveformEnabled bool `json:"waveform_enabled"`
+	WaveformBuckets int  `json:"waveform_buckets"` // 波形采样点数
+}
+
+// ScanConfig 文件安全扫描配置
+type ScanConfig struct {
+	Provider       string `json:"provider"` // none, clamav
+	ClamAVAddress  string `json:"clamav_address"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+}
+
+// VisionConfig 图片内容识别配置：OCR和图片描述可分别启用
+type VisionConfig struct {
+	OCRProvider     string `json:"ocr_provider"` // none, tesseract
+	TesseractPath   string `json:"tesseract_path"`
+	OCRLanguages    string `json:"ocr_languages"` // tesseract语言，如 eng+chi_sim
+	MaxTextLength   int    `json:"max_text_length"`
+	CaptionProvider string `json:"caption_provider"` // none, openai（OpenAI兼容的多模态对话接口）
+	CaptionBaseURL  string `json:"caption_base_url"`
+	CaptionAPIKey   string `json:"caption_api_key"`
+	CaptionModel    string `json:"caption_model"`
+	TimeoutSeconds  int    `json:"timeout_seconds"`
+}
+
+// TranscriptionConfig 语音转写配置
+type TranscriptionConfig struct {
+	Provider       string `json:"provider"` // none, openai（OpenAI兼容的Whisper接口）, whisper-cpp（本地模型）
+	BaseURL        string `json:"base_url"`
+	APIKey         string `json:"api_key"`
+	Model          string `json:"model"`
+	WhisperCPPPath string `json:"whisper_cpp_path"`
+	WhisperModel   string `json:"whisper_model"` // whisper.cpp模型文件路径
+	Language       string `json:"language"`      // 为空时自动识别
+	TimeoutSeconds int    `json:"timeout_seconds"`
 }
 
 // CDNConfig CDN配置
@@ -80,23 +195,108 @@ type CDNConfig struct {
 	BaseURL string `json:"base_url"`
 }
 
+// AvatarConfig 头像链接配置
+// 链接按用户生成并签名，有效期对齐到URLTTLMinutes的整数倍，同一时间段内链接不变，便于客户端和CDN缓存
+type AvatarConfig struct {
+	SigningKey    string `json:"signing_key"`     // 为空时使用JWT密钥
+	URLTTLMinutes int    `json:"url_ttl_minutes"` // 签名链接的有效期
+	CacheSeconds  int    `json:"cache_seconds"`   // 头像响应的缓存时间，不超过链接剩余有效期
+}
+
+// PublicLinkConfig 公开分享链接配置，链接签名后不需要登录即可下载文件
+type PublicLinkConfig struct {
+	SigningKey      string `json:"signing_key"`       // 为空时使用JWT密钥
+	DefaultTTLHours int    `json:"default_ttl_hours"` // 未指定有效期时使用
+	MaxTTLHours     int    `json:"max_ttl_hours"`
+}
+
+// WatermarkConfig 公开分享图片的水印配置，配置了LogoPath时叠加图标，否则叠加文字
+type WatermarkConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Text     string `json:"text"`      // 支持字母、数字和常用符号，小写字母按大写显示
+	LogoPath string `json:"logo_path"` // PNG或JPEG图标
+	Position string `json:"position"`  // top-left、top-right、bottom-left、bottom-right或center
+	Opacity  int    `json:"opacity"`   // 不透明度百分比
+	Scale    int    `json:"scale"`     // 水印宽度占图片宽度的百分比
+}
+
 // ExternalConfig 外部服务配置
 type ExternalConfig struct {
-	UserServiceURL string `json:"user_service_url"`
+	UserServiceURL         string `json:"user_service_url"`
+	NotificationServiceURL string `json:"notification_service_url"`
+	MessageServiceURL      string `json:"message_service_url"`
+	// GroupServiceURL 为空时分享到群聊会话不检查群组存储配额
+	GroupServiceURL string `json:"group_service_url"`
+	// EventBusURL NATS地址，为空时不发布领域事件
+	EventBusURL string `json:"event_bus_url"`
+}
+
+// ErrorReportingConfig 错误上报配置，SentryDSN为空时panic只记录日志
+type ErrorReportingConfig struct {
+	SentryDSN   string `json:"sentry_dsn"`
+	Environment string `json:"environment"`
+}
+
+// RedisConfig Redis配置，用于定时任务主实例选举
+type RedisConfig struct {
+	Addr     string `json:"addr"`
+	Password string `json:"password"`
+	DB       int    `json:"db"`
+}
+
+// LeaderElectionConfig 定时任务主实例选举配置，启用后多个实例中只有持有Redis锁的实例触发定时任务
+type LeaderElectionConfig struct {
+	Enabled    bool `json:"enabled"`
+	TTLSeconds int  `json:"ttl_seconds"` // 锁的有效期，主实例失联后最长经过这段时间由其他实例接替
+}
+
+// MetricsConfig Prometheus指标配置，Token不为空时抓取请求需携带 Authorization: Bearer <Token>
+type MetricsConfig struct {
+	Enabled bool   `json:"enabled"`
+	Token   string `json:"token"`
+}
+
+// TracingConfig OpenTelemetry链路追踪配置
+type TracingConfig struct {
+	Exporter      string `json:"exporter"`       // none（只生成和传播traceparent）、otlp或jaeger
+	Endpoint      string `json:"endpoint"`       // OTLP collector的gRPC地址或Jaeger collector的HTTP地址，为空时使用导出器默认值
+	Insecure      bool   `json:"insecure"`       // OTLP连接不使用TLS
+	SamplePercent int    `json:"sample_percent"` // 新trace的采样百分比，带traceparent的请求沿用上游的采样决定
+}
+
+// OpenAPIConfig 接口文档配置
+type OpenAPIConfig struct {
+	Strict bool `json:"strict"` // 注册的路由与接口说明不一致时拒绝启动，默认只记录警告
 }
 
 // Config 媒体服务配置
 type Config struct {
-	Server   ServerConfig   `json:"server"`
-	Database DatabaseConfig `json:"database"`
-	Log      LogConfig      `json:"log"`
-	JWT      JWTConfig      `json:"jwt"`
-	Storage  StorageConfig  `json:"storage"`
-	AWS      AWSConfig      `json:"aws"`
-	File     FileConfig     `json:"file"`
-	Image    ImageConfig    `json:"image"`
-	CDN      CDNConfig      `json:"cdn"`
-	External ExternalConfig `json:"external"`
+	Server         ServerConfig         `json:"server"`
+	Database       DatabaseConfig       `json:"database"`
+	Log            LogConfig            `json:"log"`
+	JWT            JWTConfig            `json:"jwt"`
+	Storage        StorageConfig        `json:"storage"`
+	Tenancy        TenancyConfig        `json:"tenancy"`
+	Failover       FailoverConfig       `json:"failover"`
+	AWS            AWSConfig            `json:"aws"`
+	File           FileConfig           `json:"file"`
+	Image          ImageConfig          `json:"image"`
+	Video          VideoConfig          `json:"video"`
+	Audio          AudioConfig          `json:"audio"`
+	Scan           ScanConfig           `json:"scan"`
+	Vision         VisionConfig         `json:"vision"`
+	Transcription  TranscriptionConfig  `json:"transcription"`
+	CDN            CDNConfig            `json:"cdn"`
+	Avatar         AvatarConfig         `json:"avatar"`
+	PublicLink     PublicLinkConfig     `json:"public_link"`
+	Watermark      WatermarkConfig      `json:"watermark"`
+	External       ExternalConfig       `json:"external"`
+	ErrorReporting ErrorReportingConfig `json:"error_reporting"`
+	Metrics        MetricsConfig        `json:"metrics"`
+	Redis          RedisConfig          `json:"redis"`
+	LeaderElection LeaderElectionConfig `json:"leader_election"`
+	Tracing        TracingConfig        `json:"tracing"`
+	OpenAPI        OpenAPIConfig        `json:"openapi"`
 }
 
 // Load 加载配置
@@ -106,10 +306,12 @@ func Load() *Config {
 
 	return &Config{
 		Server: ServerConfig{
-			Port:         getEnvAsInt("SERVER_PORT", 8084),
-			ReadTimeout:  getEnvAsInt("SERVER_READ_TIMEOUT", 30),
-			WriteTimeout: getEnvAsInt("SERVER_WRITE_TIMEOUT", 30),
-			IdleTimeout:  getEnvAsInt("SERVER_IDLE_TIMEOUT", 120),
+			Port:               getEnvAsInt("SERVER_PORT", 8084),
+			ReadTimeout:        getEnvAsInt("SERVER_READ_TIMEOUT", 30),
+			WriteTimeout:       getEnvAsInt("SERVER_WRITE_TIMEOUT", 30),
+			IdleTimeout:        getEnvAsInt("SERVER_IDLE_TIMEOUT", 120),
+			ShutdownDrainDelay: getEnvAsInt("SHUTDOWN_DRAIN_DELAY_SECONDS", 5),
+			ShutdownTimeout:    getEnvAsInt("SHUTDOWN_TIMEOUT_SECONDS", 30),
 		},
 		Database: DatabaseConfig{
 			Host:            getEnv("DB_HOST", "localhost"),
@@ -130,9 +332,29 @@ func Load() *Config {
 			ExpirationHours: getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
 		},
 		Storage: StorageConfig{
-			Provider:  getEnv("STORAGE_PROVIDER", "local"),
-			LocalPath: getEnv("STORAGE_LOCAL_PATH", "./uploads"),
-			BaseURL:   getEnv("STORAGE_BASE_URL", "http://localhost:8084"),
+			Provider:            getEnv("STORAGE_PROVIDER", "local"),
+			LocalPath:           getEnv("STORAGE_LOCAL_PATH", "./uploads"),
+			BaseURL:             getEnv("STORAGE_BASE_URL", "http://localhost:8084"),
+			ContentCacheSeconds: getEnvAsInt("MEDIA_CONTENT_CACHE_SECONDS", 86400),
+			DeleteRetentionDays: getEnvAsInt("SOFT_DELETE_RETENTION_DAYS", 30),
+		},
+		Tenancy: TenancyConfig{
+			Storage:             getEnvAsTenantStorage("TENANT_STORAGE"),
+			DefaultQuota:        getEnvAsInt64("TENANT_DEFAULT_QUOTA", 100*1024*1024*1024),
+			DefaultMaxFileCount: getEnvAsInt("TENANT_DEFAULT_MAX_FILE_COUNT", 1000000),
+		},
+		Failover: FailoverConfig{
+			Provider:  getEnv("STORAGE_SECONDARY_PROVIDER", ""),
+			LocalPath: getEnv("STORAGE_SECONDARY_LOCAL_PATH", "./uploads-secondary"),
+			AWS: AWSConfig{
+				Region:          getEnv("STORAGE_SECONDARY_REGION", getEnv("AWS_REGION", "us-east-1")),
+				AccessKeyID:     getEnv("STORAGE_SECONDARY_ACCESS_KEY_ID", ""),
+				SecretAccessKey: getEnv("STORAGE_SECONDARY_SECRET_ACCESS_KEY", ""),
+				BucketName:      getEnv("STORAGE_SECONDARY_BUCKET", ""),
+				Endpoint:        getEnv("STORAGE_SECONDARY_ENDPOINT", ""),
+			},
+			RepairIntervalMinutes: getEnvAsInt("STORAGE_REPAIR_INTERVAL_MINUTES", 30),
+			RepairBatchSize:       getEnvAsInt("STORAGE_REPAIR_BATCH_SIZE", 200),
 		},
 		AWS: AWSConfig{
 			Region:          getEnv("AWS_REGION", "us-east-1"),
@@ -149,18 +371,130 @@ func Load() *Config {
 			AllowedVideoTypes: getEnvAsSlice("ALLOWED_VIDEO_TYPES", "mp4,avi,mov,wmv,flv,webm"),
 			AllowedAudioTypes: getEnvAsSlice("ALLOWED_AUDIO_TYPES", "mp3,wav,aac,ogg,m4a"),
 			AllowedFileTypes:  getEnvAsSlice("ALLOWED_FILE_TYPES", "pdf,doc,docx,xls,xlsx,ppt,pptx,txt,zip,rar"),
+
+			UploadProgressRetention: getEnvAsInt("UPLOAD_PROGRESS_RETENTION_MINUTES", 10),
 		},
 		Image: ImageConfig{
 			ThumbnailWidth:  getEnvAsInt("THUMBNAIL_WIDTH", 200),
 			ThumbnailHeight: getEnvAsInt("THUMBNAIL_HEIGHT", 200),
 			ImageQuality:    getEnvAsInt("IMAGE_QUALITY", 85),
+
+			ThumbnailPresets: getEnvAsIntSlice("THUMBNAIL_PRESETS", "64,200,800"),
+
+			ThumbnailWorkers:      getEnvAsInt("THUMBNAIL_WORKERS", 2),
+			ThumbnailPollInterval: getEnvAsInt("THUMBNAIL_POLL_INTERVAL_SECONDS", 5),
+			ThumbnailMaxAttempts:  getEnvAsInt("THUMBNAIL_MAX_ATTEMPTS", 5),
+			ThumbnailRetryBackoff: getEnvAsInt("THUMBNAIL_RETRY_BACKOFF_SECONDS", 10),
+
+			BlurHashComponentsX: getEnvAsInt("BLURHASH_COMPONENTS_X", 4),
+			BlurHashComponentsY: getEnvAsInt("BLURHASH_COMPONENTS_Y", 3),
+			PlaceholderSize:     getEnvAsInt("PLACEHOLDER_SIZE", 16),
+
+			StripExif:            getEnvAsBool("IMAGE_STRIP_EXIF", true),
+			NormalizeOrientation: getEnvAsBool("IMAGE_NORMALIZE_ORIENTATION", true),
+
+			MaxPixels: getEnvAsInt("IMAGE_MAX_PIXELS", 50000000),
+		},
+		Video: VideoConfig{
+			FFmpegPath:      getEnv("FFMPEG_PATH", "ffmpeg"),
+			FFprobePath:     getEnv("FFPROBE_PATH", "ffprobe"),
+			SpriteEnabled:   getEnvAsBool("VIDEO_SPRITE_ENABLED", true),
+			SpriteInterval:  getEnvAsInt("VIDEO_SPRITE_INTERVAL", 5),
+			SpriteWidth:     getEnvAsInt("VIDEO_SPRITE_WIDTH", 160),
+			SpriteColumns:   getEnvAsInt("VIDEO_SPRITE_COLUMNS", 10),
+			SpriteMaxFrames: getEnvAsInt("VIDEO_SPRITE_MAX_FRAMES", 100),
+
+			TranscodeEnabled:      getEnvAsBool("VIDEO_TRANSCODE_ENABLED", false),
+			TranscodeHeights:      getEnvAsIntSlice("VIDEO_TRANSCODE_HEIGHTS", "720,360"),
+			TranscodeCRF:          getEnvAsInt("VIDEO_TRANSCODE_CRF", 23),
+			TranscodePreset:       getEnv("VIDEO_TRANSCODE_PRESET", "veryfast"),
+			TranscodeAudioBitrate: getEnv("VIDEO_TRANSCODE_AUDIO_BITRATE", "128k"),
+			TranscodeTimeout:      getEnvAsInt("VIDEO_TRANSCODE_TIMEOUT_MINUTES", 20),
+		},
+		Audio: AudioConfig{
+			WaveformEnabled: getEnvAsBool("AUDIO_WAVEFORM_ENABLED", true),
+			WaveformBuckets: getEnvAsInt("AUDIO_WAVEFORM_BUCKETS", 100),
+		},
+		Scan: ScanConfig{
+			Provider:       getEnv("SCAN_PROVIDER", "none"),
+			ClamAVAddress:  getEnv("CLAMAV_ADDRESS", "localhost:3310"),
+			TimeoutSeconds: getEnvAsInt("SCAN_TIMEOUT_SECONDS", 60),
+		},
+		Vision: VisionConfig{
+			OCRProvider:     getEnv("OCR_PROVIDER", "none"),
+			TesseractPath:   getEnv("TESSERACT_PATH", "tesseract"),
+			OCRLanguages:    getEnv("OCR_LANGUAGES", "eng+chi_sim"),
+			MaxTextLength:   getEnvAsInt("OCR_MAX_TEXT_LENGTH", 10000),
+			CaptionProvider: getEnv("CAPTION_PROVIDER", "none"),
+			CaptionBaseURL:  getEnv("LLM_BASE_URL", ""),
+			CaptionAPIKey:   getEnv("LLM_API_KEY", ""),
+			CaptionModel:    getEnv("CAPTION_MODEL", ""),
+			TimeoutSeconds:  getEnvAsInt("VISION_TIMEOUT_SECONDS", 60),
+		},
+		Transcription: TranscriptionConfig{
+			Provider:       getEnv("TRANSCRIPTION_PROVIDER", "none"),
+			BaseURL:        getEnv("LLM_BASE_URL", ""),
+			APIKey:         getEnv("LLM_API_KEY", ""),
+			Model:          getEnv("TRANSCRIPTION_MODEL", "whisper-1"),
+			WhisperCPPPath: getEnv("WHISPER_CPP_PATH", "whisper-cli"),
+			WhisperModel:   getEnv("WHISPER_MODEL_PATH", ""),
+			Language:       getEnv("TRANSCRIPTION_LANGUAGE", ""),
+			TimeoutSeconds: getEnvAsInt("TRANSCRIPTION_TIMEOUT_SECONDS", 300),
 		},
 		CDN: CDNConfig{
 			Enabled: getEnvAsBool("CDN_ENABLED", false),
 			BaseURL: getEnv("CDN_BASE_URL", ""),
 		},
+		Avatar: AvatarConfig{
+			SigningKey:    getEnv("AVATAR_SIGNING_KEY", ""),
+			URLTTLMinutes: getEnvAsInt("AVATAR_URL_TTL_MINUTES", 60),
+			CacheSeconds:  getEnvAsInt("AVATAR_CACHE_SECONDS", 300),
+		},
+		PublicLink: PublicLinkConfig{
+			SigningKey:      getEnv("PUBLIC_LINK_SIGNING_KEY", ""),
+			DefaultTTLHours: getEnvAsInt("PUBLIC_LINK_DEFAULT_TTL_HOURS", 24),
+			MaxTTLHours:     getEnvAsInt("PUBLIC_LINK_MAX_TTL_HOURS", 720),
+		},
+		Watermark: WatermarkConfig{
+			Enabled:  getEnvAsBool("WATERMARK_ENABLED", false),
+			Text:     getEnv("WATERMARK_TEXT", "CHATAPP"),
+			LogoPath: getEnv("WATERMARK_LOGO_PATH", ""),
+			Position: getEnv("WATERMARK_POSITION", "bottom-right"),
+			Opacity:  getEnvAsInt("WATERMARK_OPACITY", 60),
+			Scale:    getEnvAsInt("WATERMARK_SCALE", 25),
+		},
 		External: ExternalConfig{
-			UserServiceURL: getEnv("USER_SERVICE_URL", "http://localhost:8081"),
+			UserServiceURL:         getEnv("USER_SERVICE_URL", "http://localhost:8081"),
+			NotificationServiceURL: getEnv("NOTIFICATION_SERVICE_URL", "http://localhost:8085"),
+			MessageServiceURL:      getEnv("MESSAGE_SERVICE_URL", "http://localhost:8082"),
+			GroupServiceURL:        getEnv("GROUP_SERVICE_URL", ""),
+			EventBusURL:            getEnv("EVENT_BUS_URL", ""),
+		},
+		ErrorReporting: ErrorReportingConfig{
+			SentryDSN:   getEnv("SENTRY_DSN", ""),
+			Environment: getEnv("SENTRY_ENVIRONMENT", "production"),
+		},
+		Metrics: MetricsConfig{
+			Enabled: getEnvAsBool("METRICS_ENABLED", true),
+			Token:   getEnv("METRICS_TOKEN", ""),
+		},
+		Redis: RedisConfig{
+			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
+			Password: getEnv("REDIS_PASSWORD", ""),
+			DB:       getEnvAsInt("REDIS_DB", 0),
+		},
+		LeaderElection: LeaderElectionConfig{
+			Enabled:    getEnvAsBool("LEADER_ELECTION_ENABLED", false),
+			TTLSeconds: getEnvAsInt("LEADER_ELECTION_TTL_SECONDS", 15),
+		},
+		Tracing: TracingConfig{
+			Exporter:      getEnv("TRACING_EXPORTER", "none"),
+			Endpoint:      getEnv("TRACING_ENDPOINT", ""),
+			Insecure:      getEnvAsBool("TRACING_INSECURE", true),
+			SamplePercent: getEnvAsInt("TRACING_SAMPLE_PERCENT", 100),
+		},
+		OpenAPI: OpenAPIConfig{
+			Strict: getEnvAsBool("OPENAPI_STRICT", false),
 		},
 	}
 }
@@ -213,4 +547,32 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 func getEnvAsSlice(key, defaultValue string) []string {
 	value := getEnv(key, defaultValue)
 	return strings.Split(value, ",")
-}
\ No newline at end of file
+}
+
+func getEnvAsIntSlice(key, defaultValue string) []int {
+	var result []int
+	for _, item := range getEnvAsSlice(key, defaultValue) {
+		if intValue, err := strconv.Atoi(strings.TrimSpace(item)); err == nil && intValue > 0 {
+			result = append(result, intValue)
+		}
+	}
+	return result
+}
+
+// getEnvAsTenantStorage 解析租户存储配置，格式为 tenant=bucket:prefix，多个租户以逗号分隔，
+// 例如 "acme=acme-media:,globex=:globex/"，bucket或prefix可以为空
+func getEnvAsTenantStorage(key string) map[string]TenantStorageConfig {
+	result := make(map[string]TenantStorageConfig)
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		tenantID, location, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || strings.TrimSpace(tenantID) == "" {
+			continue
+		}
+		bucket, prefix, _ := strings.Cut(location, ":")
+		result[strings.TrimSpace(tenantID)] = TenantStorageConfig{
+			Bucket: strings.TrimSpace(bucket),
+			Prefix: strings.TrimSpace(prefix),
+		}
+	}
+	return result
+}
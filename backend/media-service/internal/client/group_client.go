@@ -0,0 +1,72 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"media-service/pkg/tracing"
+)
+
+// GroupClient 群组服务客户端
+type GroupClient interface {
+	// 检查文件附加到群聊会话后是否超出所属群组的存储配额，会话不属于任何群组时返回group not found
+	CheckStorage(conversationID, fileID string, size int64) (bool, error)
+}
+
+// httpGroupClient 基于HTTP的群组服务客户端
+type httpGroupClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewGroupClient 创建群组服务客户端，baseURL为空时不检查群组配额
+func NewGroupClient(baseURL string) GroupClient {
+	if baseURL == "" {
+		return noopGroupClient{}
+	}
+	return &httpGroupClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)},
+	}
+}
+
+// CheckStorage 调用群组服务内部接口检查会话所属群组的配额，群组服务按频道会话查找群组
+func (c *httpGroupClient) CheckStorage(conversationID, fileID string, size int64) (bool, error) {
+	query := url.Values{}
+	query.Set("file_id", fileID)
+	query.Set("size", strconv.FormatInt(size, 10))
+	endpoint := c.baseURL + "/internal/conversations/" + url.PathEscape(conversationID) + "/storage/check?" + query.Encode()
+
+	resp, err := c.httpClient.Get(endpoint)
+	if err != nil {
+		return false, fmt.Errorf("failed to call group service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, fmt.Errorf("group not found for conversation %s", conversationID)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, fmt.Errorf("group service returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Allowed bool `json:"allowed"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode group service response: %w", err)
+	}
+	return result.Allowed, nil
+}
+
+// noopGroupClient 未配置群组服务时使用，不限制群组存储
+type noopGroupClient struct{}
+
+func (noopGroupClient) CheckStorage(conversationID, fileID string, size int64) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"media-service/internal/models"
+	"media-service/pkg/tracing"
+)
+
+// MessageClient 消息服务客户端
+type MessageClient interface {
+	// 推送语音转写文本，返回更新的语音消息数
+	AttachTranscript(mediaID string, transcript *models.Transcript) (int, error)
+
+	// 判断用户是否为会话参与者，用于读取共享到会话的媒体文件
+	IsParticipant(conversationID, userID string) (bool, error)
+
+	// 获取会话类型（private或group），群聊会话附加文件前需要检查群组存储配额
+	GetConversationType(conversationID string) (string, error)
+}
+
+// participantCacheTTL 参与者判断结果的缓存时间，视频分段下载等连续请求不必每次询问消息服务
+const participantCacheTTL = 30 * time.Second
+
+// participantEntry 缓存的参与者判断结果
+type participantEntry struct {
+	participant bool
+	expiresAt   time.Time
+}
+
+// httpMessageClient 基于HTTP的消息服务客户端
+type httpMessageClient struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mu           sync.Mutex
+	participants map[string]participantEntry
+}
+
+// NewMessageClient 创建消息服务客户端
+func NewMessageClient(baseURL string) MessageClient {
+	return &httpMessageClient{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		httpClient:   &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)},
+		participants: make(map[string]participantEntry),
+	}
+}
+
+// AttachTranscript 调用消息服务内部接口，把转写文本写入引用该媒体文件的语音消息
+func (c *httpMessageClient) AttachTranscript(mediaID string, transcript *models.Transcript) (int, error) {
+	payload, err := json.Marshal(transcript)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal transcript: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, c.baseURL+"/internal/media/"+url.PathEscape(mediaID)+"/transcript", bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to call message service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("message service returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Updated int `json:"updated"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode message service response: %w", err)
+	}
+	return result.Updated, nil
+}
+
+// IsParticipant 调用消息服务内部接口判断用户是否为会话参与者，会话不存在时返回false
+func (c *httpMessageClient) IsParticipant(conversationID, userID string) (bool, error) {
+	key := conversationID + "/" + userID
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.participants[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.participant, nil
+	}
+
+	endpoint := c.baseURL + "/internal/conversations/" + url.PathEscape(conversationID) + "/participants/" + url.PathEscape(userID)
+	resp, err := c.httpClient.Get(endpoint)
+	if err != nil {
+		return false, fmt.Errorf("failed to call message service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, fmt.Errorf("message service returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Participant bool `json:"participant"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode message service response: %w", err)
+	}
+
+	c.mu.Lock()
+	// 写入时顺带清理过期项
+	for cached, entry := range c.participants {
+		if now.After(entry.expiresAt) {
+			delete(c.participants, cached)
+		}
+	}
+	c.participants[key] = participantEntry{participant: result.Participant, expiresAt: now.Add(participantCacheTTL)}
+	c.mu.Unlock()
+	return result.Participant, nil
+}
+
+// GetConversationType 调用消息服务内部接口获取会话类型，会话不存在时返回conversation not found
+func (c *httpMessageClient) GetConversationType(conversationID string) (string, error) {
+	resp, err := c.httpClient.Get(c.baseURL + "/internal/conversations/" + url.PathEscape(conversationID))
+	if err != nil {
+		return "", fmt.Errorf("failed to call message service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("conversation not found")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("message service returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Type string `json:"type"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode message service response: %w", err)
+	}
+	return result.Type, nil
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"media-service/pkg/tracing"
+)
+
+// NotificationClient 通知服务客户端
+type NotificationClient interface {
+	// 向用户发送系统通知
+	SendSystemNotification(userID, title, body string, data map[string]interface{}) error
+}
+
+// httpNotificationClient 基于HTTP的通知服务客户端
+type httpNotificationClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewNotificationClient 创建通知服务客户端
+func NewNotificationClient(baseURL string) NotificationClient {
+	return &httpNotificationClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)},
+	}
+}
+
+// SendSystemNotification 调用通知服务创建系统通知
+func (c *httpNotificationClient) SendSystemNotification(userID, title, body string, data map[string]interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"user_id": userID,
+		"type":    "system",
+		"title":   title,
+		"body":    body,
+		"data":    data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	resp, err := c.httpClient.Post(c.baseURL+"/notifications", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to call notification service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+	"media-service/pkg/auth"
+	"media-service/pkg/response"
+	"media-service/pkg/validation"
+)
+
+// registerAvatarRoutes 注册需要认证的头像路由
+func (h *MediaHandler) registerAvatarRoutes(router *mux.Router) {
+	router.HandleFunc("/avatar", h.SetAvatar).Methods("PUT")
+	router.HandleFunc("/avatar", h.ClearAvatar).Methods("DELETE")
+	router.HandleFunc("/avatar/{userId}/url", h.GetAvatarURL).Methods("GET")
+}
+
+// SetAvatar 把自己上传的图片设为头像
+func (h *MediaHandler) SetAvatar(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	var req models.AvatarSetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+	if err := validation.Struct(&req); err != nil {
+		errs, _ := validation.AsErrors(err)
+		response.ValidationError(w, errs)
+		return
+	}
+
+	avatar, err := h.mediaService.SetAvatar(userID, req.MediaID)
+	if err != nil {
+		h.logger.Warn("Failed to set avatar",
+			zap.String("user_id", userID),
+			zap.String("media_id", req.MediaID),
+			zap.Error(err),
+		)
+
+		if strings.Contains(err.Error(), "not found") {
+			response.Error(w, http.StatusNotFound, "Media not found", nil)
+		} else if strings.Contains(err.Error(), "access denied") {
+			response.Error(w, http.StatusForbidden, "Access denied", nil)
+		} else if strings.Contains(err.Error(), "image") || strings.Contains(err.Error(), "encrypted") || strings.Contains(err.Error(), "not available") {
+			response.Error(w, http.StatusBadRequest, err.Error(), nil)
+		} else {
+			response.Error(w, http.StatusInternalServerError, "Failed to set avatar", nil)
+		}
+		return
+	}
+
+	response.Success(w, map[string]interface{}{
+		"avatar": avatar,
+		"url":    h.mediaService.AvatarURL(userID, 0),
+	})
+}
+
+// ClearAvatar 清除头像
+func (h *MediaHandler) ClearAvatar(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	if err := h.mediaService.ClearAvatar(userID); err != nil {
+		h.logger.Error("Failed to clear avatar", zap.String("user_id", userID), zap.Error(err))
+		response.Error(w, http.StatusInternalServerError, "Failed to clear avatar", nil)
+		return
+	}
+
+	response.Success(w, map[string]string{"message": "Avatar cleared successfully"})
+}
+
+// GetAvatarURL 获取任意用户头像的签名链接，size为期望的边长（像素）
+func (h *MediaHandler) GetAvatarURL(w http.ResponseWriter, r *http.Request) {
+	size, err := parseAvatarSize(r.URL.Query().Get("size"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	response.Success(w, h.mediaService.AvatarURL(mux.Vars(r)["userId"], size))
+}
+
+// GetAvatar 按签名链接返回用户当前头像，不需要认证，响应中不包含存储地址
+// 链接按用户生成，更换头像后同一链接返回新头像，ETag随头像变化
+func (h *MediaHandler) GetAvatar(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userId"]
+	query := r.URL.Query()
+
+	size, err := parseAvatarSize(query.Get("size"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || !h.mediaService.VerifyAvatarURL(userID, size, expires, query.Get("sig")) {
+		response.Error(w, http.StatusForbidden, "Invalid or expired avatar link", nil)
+		return
+	}
+
+	content, err := h.mediaService.ResolveAvatar(userID, size)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			response.Error(w, http.StatusNotFound, "Avatar not found", nil)
+			return
+		}
+		h.logger.Error("Failed to resolve avatar", zap.String("user_id", userID), zap.Error(err))
+		response.Error(w, http.StatusInternalServerError, "Failed to get avatar", nil)
+		return
+	}
+
+	// 缓存时间不超过链接剩余有效期
+	maxAge := content.MaxAge
+	if remaining := expires - time.Now().Unix(); remaining < int64(maxAge) {
+		maxAge = int(remaining)
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+	w.Header().Set("ETag", content.ETag)
+	w.Header().Set("Last-Modified", content.UpdatedAt.UTC().Format(http.TimeFormat))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if match := r.Header.Get("If-None-Match"); match != "" && strings.Contains(match, content.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	reader, err := h.mediaService.OpenAvatar(content)
+	if err != nil {
+		h.logger.Error("Failed to read avatar", zap.String("user_id", userID), zap.String("media_id", content.MediaID), zap.Error(err))
+		w.Header().Del("Cache-Control")
+		w.Header().Del("ETag")
+		response.Error(w, http.StatusInternalServerError, "Failed to get avatar", nil)
+		return
+	}
+	defer reader.Close()
+
+	w.Header().Set("Content-Type", content.ContentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, reader); err != nil {
+		h.logger.Warn("Failed to write avatar", zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// parseAvatarSize 解析头像尺寸参数，为空表示原图
+func parseAvatarSize(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 0 || size > 4096 {
+		return 0, fmt.Errorf("size must be between 0 and 4096")
+	}
+	return size, nil
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"media-service/pkg/auth"
+	"media-service/pkg/response"
+)
+
+// errRangeNotSatisfiable 请求的字节范围不在文件内
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// byteRange 单个字节范围，length为读取的字节数
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+func (h *MediaHandler) registerContentRoutes(router *mux.Router) {
+	router.HandleFunc("/files/{id}/content", h.GetMediaContent).Methods("GET", "HEAD")
+}
+
+// GetMediaContent 下载媒体文件，支持单个Range请求（如视频拖动进度条）
+// variant参数指定缩略图、封面图、转码版本等衍生文件，为空时返回原文件
+func (h *MediaHandler) GetMediaContent(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	mediaID := mux.Vars(r)["id"]
+	variant := r.URL.Query().Get("variant")
+
+	content, err := h.mediaService.ResolveMediaContent(userID, mediaID, variant)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			response.Error(w, http.StatusNotFound, "Media not found", nil)
+		case strings.Contains(err.Error(), "access denied"):
+			response.Error(w, http.StatusForbidden, "Access denied", nil)
+		case strings.Contains(err.Error(), "quarantined"):
+			response.Error(w, http.StatusForbidden, "Media is quarantined", nil)
+		default:
+			h.logger.Error("Failed to resolve media content", zap.String("user_id", userID), zap.String("media_id", mediaID), zap.String("variant", variant), zap.Error(err))
+			response.Error(w, http.StatusInternalServerError, "Failed to get media content", nil)
+		}
+		return
+	}
+
+	// 需要认证的内容只允许客户端缓存
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", content.MaxAge))
+	w.Header().Set("ETag", content.ETag)
+	w.Header().Set("Last-Modified", content.UpdatedAt.UTC().Format(http.TimeFormat))
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if content.Filename != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": content.Filename}))
+	}
+
+	if match := r.Header.Get("If-None-Match"); match != "" && strings.Contains(match, content.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// If-Range与当前版本不一致时忽略Range，返回完整文件
+	rangeHeader := r.Header.Get("Range")
+	if ifRange := r.Header.Get("If-Range"); ifRange != "" && ifRange != content.ETag {
+		rangeHeader = ""
+	}
+	requested, err := parseByteRange(rangeHeader, content.Size)
+	if err != nil {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", content.Size))
+		response.Error(w, http.StatusRequestedRangeNotSatisfiable, "Requested range not satisfiable", nil)
+		return
+	}
+
+	status := http.StatusOK
+	offset, length := int64(0), content.Size
+	if requested != nil {
+		status = http.StatusPartialContent
+		offset, length = requested.start, requested.length
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, content.Size))
+	}
+	w.Header().Set("Content-Type", content.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+
+	readLength := length
+	if requested == nil {
+		readLength = -1
+	}
+	reader, err := h.mediaService.OpenMediaContent(content, offset, readLength)
+	if err != nil {
+		h.logger.Error("Failed to read media content", zap.String("media_id", mediaID), zap.Int64("offset", offset), zap.Error(err))
+		for _, header := range []string{"Cache-Control", "ETag", "Last-Modified", "Accept-Ranges", "Content-Disposition", "Content-Range", "Content-Length"} {
+			w.Header().Del(header)
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to get media content", nil)
+		return
+	}
+	defer reader.Close()
+
+	w.WriteHeader(status)
+	if _, err := io.CopyN(w, reader, length); err != nil {
+		// 客户端拖动进度条时经常中途断开，不作为错误
+		h.logger.Debug("Media content transfer ended early", zap.String("media_id", mediaID), zap.Error(err))
+	}
+}
+
+// parseByteRange 解析Range请求头，没有Range、格式无法识别或包含多个范围时返回nil（返回完整文件）
+// 范围超出文件时返回errRangeNotSatisfiable
+func parseByteRange(header string, size int64) (*byteRange, error) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !ok || spec == "" || strings.Contains(spec, ",") {
+		return nil, nil
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, nil
+	}
+
+	// 后缀范围：bytes=-500 表示最后500字节
+	if first == "" {
+		suffix, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || suffix < 0 {
+			return nil, nil
+		}
+		if suffix == 0 || size == 0 {
+			return nil, errRangeNotSatisfiable
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return &byteRange{start: size - suffix, length: suffix}, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return nil, nil
+	}
+	if start >= size {
+		return nil, errRangeNotSatisfiable
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return nil, nil
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return &byteRange{start: start, length: end - start + 1}, nil
+}
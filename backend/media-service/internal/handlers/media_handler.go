@@ -3,7 +3,6 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -13,19 +12,23 @@ import (
 	"media-service/internal/models"
 	"media-service/internal/service"
 	"media-service/pkg/auth"
+	"media-service/pkg/pagination"
 	"media-service/pkg/response"
+	"media-service/pkg/validation"
 )
 
 // MediaHandler 媒体处理器
 type MediaHandler struct {
 	mediaService service.MediaService
+	uploads      *service.UploadProgressTracker
 	logger       *zap.Logger
 }
 
 // NewMediaHandler 创建媒体处理器
-func NewMediaHandler(mediaService service.MediaService, logger *zap.Logger) *MediaHandler {
+func NewMediaHandler(mediaService service.MediaService, uploads *service.UploadProgressTracker, logger *zap.Logger) *MediaHandler {
 	return &MediaHandler{
 		mediaService: mediaService,
+		uploads:      uploads,
 		logger:       logger,
 	}
 }
@@ -38,12 +41,26 @@ func (h *MediaHandler) RegisterRoutes(router *mux.Router) {
 
 	// 文件上传
 	authRouter.HandleFunc("/upload", h.UploadFile).Methods("POST")
+	h.registerUploadProgressRoutes(authRouter)
+
+	// 头像
+	h.registerAvatarRoutes(authRouter)
 
 	// 媒体文件管理
 	authRouter.HandleFunc("/files", h.GetMediaList).Methods("GET")
 	authRouter.HandleFunc("/files/{id}", h.GetMedia).Methods("GET")
 	authRouter.HandleFunc("/files/{id}", h.UpdateMedia).Methods("PUT")
 	authRouter.HandleFunc("/files/{id}", h.DeleteMedia).Methods("DELETE")
+	authRouter.HandleFunc("/files/{id}/restore", h.RestoreMedia).Methods("POST")
+
+	// 文件下载（支持Range请求），替代原来不检查权限的本地文件目录
+	h.registerContentRoutes(authRouter)
+
+	// 共享给会话或指定用户
+	h.registerShareRoutes(authRouter)
+
+	// 公开链接，启用水印时图片通过链接返回带水印的副本
+	h.registerPublicLinkRoutes(authRouter)
 
 	// 缩略图生成
 	authRouter.HandleFunc("/files/{id}/thumbnail", h.GenerateThumbnail).Methods("POST")
@@ -56,7 +73,16 @@ func (h *MediaHandler) RegisterRoutes(router *mux.Router) {
 
 	// 存储统计
 	authRouter.HandleFunc("/stats/user", h.GetUserStorageStats).Methods("GET")
-	authRouter.HandleFunc("/stats/system", h.GetSystemStorageStats).Methods("GET")
+
+	// 隔离区、租户和系统存储统计（仅管理员）
+	adminRouter := router.PathPrefix("/api/v1/media/admin").Subrouter()
+	adminRouter.Use(auth.JWTMiddleware, auth.AdminMiddleware)
+	h.registerQuarantineRoutes(adminRouter)
+	h.registerTenantRoutes(adminRouter)
+	adminRouter.HandleFunc("/stats/system", h.GetSystemStorageStats).Methods("GET")
+
+	// 内部路由，不经过API网关暴露：用户服务导出个人数据时获取文件清单
+	router.HandleFunc("/internal/users/{userId}/media", h.GetUserMediaList).Methods("GET")
 
 	// 公共路由（不需要认证）
 	publicRouter := router.PathPrefix("/api/v1/media").Subrouter()
@@ -64,8 +90,11 @@ func (h *MediaHandler) RegisterRoutes(router *mux.Router) {
 	// 健康检查
 	publicRouter.HandleFunc("/health", h.HealthCheck).Methods("GET")
 
-	// 文件服务（如果使用本地存储）
-	publicRouter.PathPrefix("/files/").Handler(http.StripPrefix("/api/v1/media/files/", http.FileServer(http.Dir("./uploads/"))))
+	// 签名头像链接
+	publicRouter.HandleFunc("/avatar/{userId}", h.GetAvatar).Methods("GET")
+
+	// 签名公开链接
+	publicRouter.HandleFunc("/public/{id}", h.GetPublicMedia).Methods("GET")
 }
 
 // UploadFile 上传文件
@@ -75,26 +104,69 @@ func (h *MediaHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 		response.Error(w, http.StatusUnauthorized, "Unauthorized", nil)
 		return
 	}
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+
+	// 携带上传ID时记录接收进度，客户端可通过 /uploads/{id}/progress 查询
+	progress, ok := h.beginUploadProgress(w, r, userID)
+	if !ok {
+		return
+	}
+	fail := func(message string) {
+		if progress != nil {
+			progress.Fail(message)
+		}
+	}
+	// 已结束的上传不会被覆盖，这里只处理意外退出的情况
+	defer fail("Upload interrupted")
 
 	// 解析multipart表单
 	err := r.ParseMultipartForm(32 << 20) // 32MB
 	if err != nil {
 		h.logger.Error("Failed to parse multipart form", zap.Error(err))
+		fail("Failed to parse form")
 		response.Error(w, http.StatusBadRequest, "Failed to parse form", nil)
 		return
 	}
+	if progress != nil {
+		progress.Processing()
+	}
 
-	// 获取文件
+	// 获取文件，缺少文件时交给表单校验返回422
 	file, header, err := r.FormFile("file")
-	if err != nil {
+	if err != nil && err != http.ErrMissingFile {
 		h.logger.Error("Failed to get file from form", zap.Error(err))
-		response.Error(w, http.StatusBadRequest, "No file provided", nil)
+		fail("Failed to read file")
+		response.Error(w, http.StatusBadRequest, "Failed to read file", nil)
+		return
+	}
+	if file != nil {
+		defer file.Close()
+	}
+
+	// 携带encryption字段时按客户端加密文件处理
+	form := models.UploadForm{File: header}
+	if encryptionJSON := r.FormValue("encryption"); encryptionJSON != "" {
+		form.Encryption = &models.EncryptionInfo{}
+		if err := json.Unmarshal([]byte(encryptionJSON), form.Encryption); err != nil {
+			fail("Invalid encryption info")
+			response.Error(w, http.StatusBadRequest, "Invalid encryption info", nil)
+			return
+		}
+	}
+	if err := validation.Struct(&form); err != nil {
+		errs, _ := validation.AsErrors(err)
+		fail("Validation failed")
+		response.ValidationError(w, errs)
 		return
 	}
-	defer file.Close()
 
 	// 上传文件
-	uploadResponse, err := h.mediaService.UploadFile(userID, file, header)
+	var uploadResponse *models.UploadResponse
+	if form.Encryption != nil {
+		uploadResponse, err = h.mediaService.UploadEncryptedFile(userID, tenantID, file, header, form.Encryption)
+	} else {
+		uploadResponse, err = h.mediaService.UploadFile(userID, tenantID, file, header)
+	}
 	if err != nil {
 		h.logger.Error("Failed to upload file",
 			zap.String("user_id", userID),
@@ -106,14 +178,21 @@ func (h *MediaHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 			response.Error(w, http.StatusPaymentRequired, err.Error(), nil)
 		} else if strings.Contains(err.Error(), "not allowed") {
 			response.Error(w, http.StatusUnsupportedMediaType, err.Error(), nil)
+		} else if strings.Contains(err.Error(), "encryption") || strings.Contains(err.Error(), "tenant id") {
+			response.Error(w, http.StatusBadRequest, err.Error(), nil)
 		} else if strings.Contains(err.Error(), "size") {
 			response.Error(w, http.StatusRequestEntityTooLarge, err.Error(), nil)
 		} else {
 			response.Error(w, http.StatusInternalServerError, "Failed to upload file", nil)
 		}
+		fail(err.Error())
 		return
 	}
 
+	if progress != nil {
+		progress.Complete(uploadResponse.MediaID)
+	}
+
 	response.Success(w, uploadResponse)
 }
 
@@ -126,21 +205,15 @@ func (h *MediaHandler) GetMediaList(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 解析查询参数
-	req := &models.MediaListRequest{
-		Limit:  20,
-		Offset: 0,
-	}
-
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil {
-			req.Limit = limit
-		}
+	page, err := pagination.Parse(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error(), nil)
+		return
 	}
 
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil {
-			req.Offset = offset
-		}
+	req := &models.MediaListRequest{
+		Limit:  page.Limit,
+		Offset: page.Offset,
 	}
 
 	if mediaType := r.URL.Query().Get("media_type"); mediaType != "" {
@@ -167,6 +240,35 @@ func (h *MediaHandler) GetMediaList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	pagination.SetLinkHeader(w, r, page, page.Offset+len(mediaList.Medias) < mediaList.Total)
+	response.Success(w, mediaList)
+}
+
+// GetUserMediaList 分页获取指定用户上传的文件记录，供内部服务调用
+func (h *MediaHandler) GetUserMediaList(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userId"]
+
+	page, err := pagination.Parse(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	mediaList, err := h.mediaService.GetMediaList(userID, &models.MediaListRequest{
+		Limit:     page.Limit,
+		Offset:    page.Offset,
+		SortBy:    "created_at",
+		SortOrder: "asc",
+	})
+	if err != nil {
+		h.logger.Error("Failed to get user media list",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		response.Error(w, http.StatusInternalServerError, "Failed to get media list", nil)
+		return
+	}
+
 	response.Success(w, mediaList)
 }
 
@@ -274,6 +376,42 @@ func (h *MediaHandler) DeleteMedia(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, map[string]string{"message": "Media deleted successfully"})
 }
 
+// RestoreMedia 恢复保留期内删除的媒体文件
+func (h *MediaHandler) RestoreMedia(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	mediaID := mux.Vars(r)["id"]
+
+	media, err := h.mediaService.RestoreMedia(userID, mediaID)
+	if err != nil {
+		h.logger.Error("Failed to restore media",
+			zap.String("user_id", userID),
+			zap.String("media_id", mediaID),
+			zap.Error(err),
+		)
+
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			response.Error(w, http.StatusNotFound, "Media not found", nil)
+		case strings.Contains(err.Error(), "access denied"):
+			response.Error(w, http.StatusForbidden, "Access denied", nil)
+		case strings.Contains(err.Error(), "expired"):
+			response.Error(w, http.StatusGone, err.Error(), nil)
+		case strings.Contains(err.Error(), "quota") || strings.Contains(err.Error(), "limit"):
+			response.Error(w, http.StatusPaymentRequired, err.Error(), nil)
+		default:
+			response.Error(w, http.StatusInternalServerError, "Failed to restore media", nil)
+		}
+		return
+	}
+
+	response.Success(w, media)
+}
+
 // GenerateThumbnail 生成缩略图
 func (h *MediaHandler) GenerateThumbnail(w http.ResponseWriter, r *http.Request) {
 	userID := auth.GetUserIDFromContext(r.Context())
@@ -320,6 +458,8 @@ func (h *MediaHandler) GenerateThumbnail(w http.ResponseWriter, r *http.Request)
 			response.Error(w, http.StatusForbidden, "Access denied", nil)
 		} else if strings.Contains(err.Error(), "only be generated for images") {
 			response.Error(w, http.StatusBadRequest, "Thumbnails can only be generated for images", nil)
+		} else if strings.Contains(err.Error(), "encrypted media") {
+			response.Error(w, http.StatusBadRequest, "Thumbnails cannot be generated for encrypted media", nil)
 		} else {
 			response.Error(w, http.StatusInternalServerError, "Failed to generate thumbnail", nil)
 		}
@@ -369,6 +509,8 @@ func (h *MediaHandler) GetPresignedURL(w http.ResponseWriter, r *http.Request) {
 			response.Error(w, http.StatusNotFound, "Media not found", nil)
 		} else if strings.Contains(err.Error(), "access denied") {
 			response.Error(w, http.StatusForbidden, "Access denied", nil)
+		} else if strings.Contains(err.Error(), "quarantined") {
+			response.Error(w, http.StatusForbidden, "Media is quarantined", nil)
 		} else if strings.Contains(err.Error(), "not supported") {
 			response.Error(w, http.StatusNotImplemented, "Presigned URLs not supported", nil)
 		} else {
@@ -444,19 +586,9 @@ func (h *MediaHandler) GetUserStorageStats(w http.ResponseWriter, r *http.Reques
 	response.Success(w, stats)
 }
 
-// GetSystemStorageStats 获取系统存储统计
+// GetSystemStorageStats 获取系统存储统计，路由注册在管理员子路由下
 func (h *MediaHandler) GetSystemStorageStats(w http.ResponseWriter, r *http.Request) {
 	userID := auth.GetUserIDFromContext(r.Context())
-	if userID == "" {
-		response.Error(w, http.StatusUnauthorized, "Unauthorized", nil)
-		return
-	}
-
-	// 这里可以添加管理员权限检查
-	// if !isAdmin(userID) {
-	//     response.Error(w, http.StatusForbidden, "Admin access required", nil)
-	//     return
-	// }
 
 	stats, err := h.mediaService.GetSystemStorageStats()
 	if err != nil {
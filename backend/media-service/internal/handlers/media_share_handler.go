@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+	"media-service/pkg/auth"
+	"media-service/pkg/response"
+	"media-service/pkg/validation"
+)
+
+func (h *MediaHandler) registerShareRoutes(router *mux.Router) {
+	router.HandleFunc("/files/{id}/shares", h.ShareMedia).Methods("POST")
+	router.HandleFunc("/files/{id}/shares", h.ListMediaShares).Methods("GET")
+	router.HandleFunc("/files/{id}/shares/{shareId}", h.RevokeMediaShare).Methods("DELETE")
+}
+
+// ShareMedia 把媒体文件附加到会话或授权给指定用户
+func (h *MediaHandler) ShareMedia(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	mediaID := mux.Vars(r)["id"]
+
+	var req models.MediaShareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+	if err := validation.Struct(&req); err != nil {
+		errs, _ := validation.AsErrors(err)
+		response.ValidationError(w, errs)
+		return
+	}
+
+	shares, err := h.mediaService.ShareMedia(userID, mediaID, &req)
+	if err != nil {
+		h.writeShareError(w, userID, mediaID, "Failed to share media", err)
+		return
+	}
+
+	response.Success(w, shares)
+}
+
+// ListMediaShares 获取媒体文件的共享记录
+func (h *MediaHandler) ListMediaShares(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	mediaID := mux.Vars(r)["id"]
+
+	shares, err := h.mediaService.ListMediaShares(userID, mediaID)
+	if err != nil {
+		h.writeShareError(w, userID, mediaID, "Failed to list media shares", err)
+		return
+	}
+
+	response.Success(w, shares)
+}
+
+// RevokeMediaShare 撤销共享
+func (h *MediaHandler) RevokeMediaShare(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	vars := mux.Vars(r)
+	mediaID := vars["id"]
+
+	if err := h.mediaService.RevokeMediaShare(userID, mediaID, vars["shareId"]); err != nil {
+		h.writeShareError(w, userID, mediaID, "Failed to revoke media share", err)
+		return
+	}
+
+	response.SuccessWithMessage(w, "Media share revoked", nil)
+}
+
+// writeShareError 按错误类型返回共享接口的错误响应
+func (h *MediaHandler) writeShareError(w http.ResponseWriter, userID, mediaID, message string, err error) {
+	h.logger.Warn(message,
+		zap.String("user_id", userID),
+		zap.String("media_id", mediaID),
+		zap.Error(err),
+	)
+
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		response.Error(w, http.StatusNotFound, err.Error(), nil)
+	case strings.Contains(err.Error(), "access denied"):
+		response.Error(w, http.StatusForbidden, "Access denied", nil)
+	case strings.Contains(err.Error(), "quarantined"):
+		response.Error(w, http.StatusForbidden, "Media is quarantined", nil)
+	case strings.Contains(err.Error(), "quota exceeded"):
+		response.Error(w, http.StatusPaymentRequired, err.Error(), nil)
+	case strings.Contains(err.Error(), "required"):
+		response.Error(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		response.Error(w, http.StatusInternalServerError, message, nil)
+	}
+}
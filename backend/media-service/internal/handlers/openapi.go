@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"time"
+
+	"media-service/internal/models"
+	"media-service/pkg/openapi"
+	"media-service/pkg/response"
+)
+
+// dataResponse 统一响应结构（见pkg/response），Data为接口返回的数据，只用于生成接口文档
+type dataResponse[T any] struct {
+	Success   bool      `json:"success"`
+	Message   string    `json:"message,omitempty"`
+	Data      T         `json:"data"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// APIOperations 媒体服务HTTP接口说明，启动时与注册的路由核对后生成/openapi.json
+var APIOperations = openapi.Operations{
+	"DELETE /api/v1/media/admin/quarantine/{id}":          {Summary: "删除确认为恶意的媒体文件并通知所有者", Response: response.Response{}},
+	"DELETE /api/v1/media/avatar":                         {Summary: "清除头像", Response: response.Response{}},
+	"DELETE /api/v1/media/files/{id}":                     {Summary: "删除媒体文件", Description: "删除后在保留期内可以恢复，期满后永久删除", Response: response.Response{}},
+	"GET /api/v1/media/admin/quarantine":                  {Summary: "列出所有用户中被隔离的媒体文件", Query: []string{"limit", "offset"}, Response: dataResponse[models.QuarantineListResponse]{}},
+	"GET /api/v1/media/admin/quarantine/{id}":             {Summary: "获取被隔离媒体文件的元数据和扫描结果", Response: dataResponse[models.Media]{}},
+	"GET /api/v1/media/admin/tenants/{tenantId}/stats":    {Summary: "获取租户的存储位置、用量和配额", Response: dataResponse[models.TenantStorageStats]{}},
+	"GET /api/v1/media/avatar/{userId}":                   {Summary: "按签名链接返回用户当前头像", Description: "不需要认证，响应体为图片，不包含存储地址", Query: []string{"size", "expires", "sig"}, Public: true},
+	"GET /api/v1/media/avatar/{userId}/url":               {Summary: "获取任意用户头像的签名链接，size为期望的边长（像素）", Query: []string{"size"}, Response: response.Response{}},
+	"GET /api/v1/media/files":                             {Summary: "获取媒体文件列表", Query: []string{"limit", "offset", "media_type", "status", "sort_by", "sort_order"}, Response: dataResponse[models.MediaListResponse]{}},
+	"GET /api/v1/media/files/{id}":                        {Summary: "获取单个媒体文件", Description: "所有者以及共享的用户、会话参与者可以读取", Response: dataResponse[models.Media]{}},
+	"GET /api/v1/media/files/{id}/content":                {Summary: "下载媒体文件", Description: "支持单个Range请求，响应体为文件内容，部分内容返回206；variant参数指定缩略图、封面图、各尺寸缩略图或转码版本"},
+	"GET /api/v1/media/files/{id}/shares":                 {Summary: "获取媒体文件的共享记录，只有所有者可以查看", Response: dataResponse[[]*models.MediaShare]{}},
+	"POST /api/v1/media/files/{id}/restore":               {Summary: "恢复删除的媒体文件", Description: "所有者可以在保留期内恢复，恢复后重新计入存储配额", Response: dataResponse[models.Media]{}},
+	"POST /api/v1/media/files/{id}/shares":                {Summary: "把媒体文件附加到会话或授权给指定用户", Description: "附加到会话时所有者必须是会话参与者，会话的所有参与者都可以读取；返回文件当前的全部共享记录", Request: models.MediaShareRequest{}, Response: dataResponse[[]*models.MediaShare]{}},
+	"POST /api/v1/media/files/{id}/public-link":           {Summary: "生成签名公开链接", Description: "只有所有者可以生成，链接不需要登录即可访问；启用水印时图片通过链接返回带水印的副本，原图不变", Request: models.PublicLinkRequest{}, Response: dataResponse[models.MediaPublicLink]{}},
+	"GET /api/v1/media/public/{id}":                       {Summary: "按签名公开链接返回文件", Description: "不需要认证，响应体为文件内容；带水印的副本尚未生成时返回503和Retry-After", Query: []string{"expires", "sig"}, Public: true},
+	"DELETE /api/v1/media/files/{id}/shares/{shareId}":    {Summary: "撤销共享", Response: response.Response{}},
+	"GET /api/v1/media/files/{id}/presigned-url":          {Summary: "获取预签名URL", Query: []string{"operation", "expiration"}, Response: response.Response{}},
+	"GET /api/v1/media/health":                            {Summary: "健康检查", Response: response.Response{}, Public: true},
+	"GET /api/v1/media/jobs/{id}":                         {Summary: "获取处理任务状态", Response: dataResponse[models.ProcessingJob]{}},
+	"GET /api/v1/media/stats/user":                        {Summary: "获取用户存储统计", Response: dataResponse[models.StorageInfo]{}},
+	"GET /api/v1/media/uploads/{id}/progress":             {Summary: "获取上传进度，Accept为text/event-stream时以SSE推送进度直到上传结束", Response: response.Response{}},
+	"GET /api/v1/media/admin/stats/system":                {Summary: "获取系统存储统计，仅管理员", Response: dataResponse[models.StorageInfo]{}},
+	"POST /api/v1/media/admin/quarantine/{id}/release":    {Summary: "将误报的媒体文件移出隔离区", Response: dataResponse[models.Media]{}},
+	"POST /api/v1/media/admin/tenants/{tenantId}/migrate": {Summary: "把一批已有文件迁移到租户的存储位置，可多次调用直到remaining为false", Request: models.TenantMigrationRequest{}, Response: dataResponse[models.TenantMigrationResult]{}},
+	"POST /api/v1/media/files/{id}/thumbnail":             {Summary: "生成缩略图", Request: models.ThumbnailRequest{}, Response: response.Response{}},
+	"POST /api/v1/media/upload":                           {Summary: "上传文件", Description: "multipart/form-data请求，文件字段为file", Response: dataResponse[models.UploadResponse]{}},
+	"PUT /api/v1/media/admin/tenants/{tenantId}/quota":    {Summary: "调整租户配额上限", Request: models.TenantQuotaUpdateRequest{}, Response: dataResponse[models.TenantStorageQuota]{}},
+	"PUT /api/v1/media/avatar":                            {Summary: "把自己上传的图片设为头像", Request: models.AvatarSetRequest{}, Response: response.Response{}},
+	"PUT /api/v1/media/files/{id}":                        {Summary: "更新媒体文件", Request: models.MediaUpdateRequest{}, Response: response.Response{}},
+}
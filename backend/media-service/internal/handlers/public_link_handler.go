@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+	"media-service/pkg/auth"
+	"media-service/pkg/response"
+	"media-service/pkg/validation"
+)
+
+// registerPublicLinkRoutes 注册需要认证的公开链接路由
+func (h *MediaHandler) registerPublicLinkRoutes(router *mux.Router) {
+	router.HandleFunc("/files/{id}/public-link", h.CreatePublicLink).Methods("POST")
+}
+
+// CreatePublicLink 为自己的文件生成签名公开链接，请求体可以为空
+func (h *MediaHandler) CreatePublicLink(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	mediaID := mux.Vars(r)["id"]
+
+	var req models.PublicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		response.Error(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+	if err := validation.Struct(&req); err != nil {
+		errs, _ := validation.AsErrors(err)
+		response.ValidationError(w, errs)
+		return
+	}
+
+	link, err := h.mediaService.CreatePublicLink(userID, mediaID, &req)
+	if err != nil {
+		h.logger.Warn("Failed to create public link",
+			zap.String("user_id", userID),
+			zap.String("media_id", mediaID),
+			zap.Error(err),
+		)
+
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			response.Error(w, http.StatusNotFound, "Media not found", nil)
+		case strings.Contains(err.Error(), "access denied"):
+			response.Error(w, http.StatusForbidden, "Access denied", nil)
+		case strings.Contains(err.Error(), "quarantined"):
+			response.Error(w, http.StatusForbidden, "Media is quarantined", nil)
+		case strings.Contains(err.Error(), "encrypted") || strings.Contains(err.Error(), "not available") || strings.Contains(err.Error(), "invalid"):
+			response.Error(w, http.StatusBadRequest, err.Error(), nil)
+		default:
+			response.Error(w, http.StatusInternalServerError, "Failed to create public link", nil)
+		}
+		return
+	}
+
+	response.Success(w, link)
+}
+
+// GetPublicMedia 按签名链接返回文件，不需要认证，响应中不包含存储地址
+// 启用水印时图片返回带水印的副本，副本尚未生成时返回503，客户端稍后重试
+func (h *MediaHandler) GetPublicMedia(w http.ResponseWriter, r *http.Request) {
+	mediaID := mux.Vars(r)["id"]
+	query := r.URL.Query()
+
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || !h.mediaService.VerifyPublicLink(mediaID, expires, query.Get("sig")) {
+		response.Error(w, http.StatusForbidden, "Invalid or expired public link", nil)
+		return
+	}
+
+	content, err := h.mediaService.ResolvePublicContent(mediaID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			response.Error(w, http.StatusNotFound, "Media not found", nil)
+		case strings.Contains(err.Error(), "being generated"):
+			w.Header().Set("Retry-After", "5")
+			response.Error(w, http.StatusServiceUnavailable, "Watermark is being generated", nil)
+		default:
+			h.logger.Error("Failed to resolve public media", zap.String("media_id", mediaID), zap.Error(err))
+			response.Error(w, http.StatusInternalServerError, "Failed to get media", nil)
+		}
+		return
+	}
+
+	// 缓存时间不超过链接剩余有效期
+	maxAge := content.MaxAge
+	if remaining := expires - time.Now().Unix(); remaining < int64(maxAge) {
+		maxAge = int(remaining)
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+	w.Header().Set("ETag", content.ETag)
+	w.Header().Set("Last-Modified", content.UpdatedAt.UTC().Format(http.TimeFormat))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if content.Filename != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": content.Filename}))
+	}
+
+	if match := r.Header.Get("If-None-Match"); match != "" && strings.Contains(match, content.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	reader, err := h.mediaService.OpenMediaContent(content, 0, -1)
+	if err != nil {
+		h.logger.Error("Failed to read public media", zap.String("media_id", mediaID), zap.Error(err))
+		for _, header := range []string{"Cache-Control", "ETag", "Last-Modified", "Content-Disposition"} {
+			w.Header().Del(header)
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to get media", nil)
+		return
+	}
+	defer reader.Close()
+
+	w.Header().Set("Content-Type", content.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(content.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, reader); err != nil {
+		h.logger.Warn("Failed to write public media", zap.String("media_id", mediaID), zap.Error(err))
+	}
+}
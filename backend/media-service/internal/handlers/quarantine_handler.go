@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+	"media-service/pkg/auth"
+	"media-service/pkg/pagination"
+	"media-service/pkg/response"
+)
+
+// registerQuarantineRoutes 注册隔离区管理路由
+func (h *MediaHandler) registerQuarantineRoutes(router *mux.Router) {
+	router.HandleFunc("/quarantine", h.ListQuarantinedMedia).Methods("GET")
+	router.HandleFunc("/quarantine/{id}", h.GetQuarantinedMedia).Methods("GET")
+	router.HandleFunc("/quarantine/{id}/release", h.ReleaseQuarantinedMedia).Methods("POST")
+	router.HandleFunc("/quarantine/{id}", h.PurgeQuarantinedMedia).Methods("DELETE")
+}
+
+// ListQuarantinedMedia 列出所有用户中被隔离的媒体文件
+func (h *MediaHandler) ListQuarantinedMedia(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.Parse(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	req := &models.QuarantineListRequest{
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+
+	result, err := h.mediaService.ListQuarantinedMedia(req)
+	if err != nil {
+		h.logger.Error("Failed to list quarantined media", zap.Error(err))
+		response.Error(w, http.StatusInternalServerError, "Failed to list quarantined media", nil)
+		return
+	}
+
+	pagination.SetLinkHeader(w, r, page, page.Offset+len(result.Medias) < result.Total)
+	response.Success(w, result)
+}
+
+// GetQuarantinedMedia 获取被隔离媒体文件的元数据和扫描结果
+func (h *MediaHandler) GetQuarantinedMedia(w http.ResponseWriter, r *http.Request) {
+	mediaID := mux.Vars(r)["id"]
+
+	media, err := h.mediaService.GetQuarantinedMedia(mediaID)
+	if err != nil {
+		h.writeQuarantineError(w, mediaID, err)
+		return
+	}
+
+	response.Success(w, media)
+}
+
+// ReleaseQuarantinedMedia 将误报的媒体文件移出隔离区
+func (h *MediaHandler) ReleaseQuarantinedMedia(w http.ResponseWriter, r *http.Request) {
+	adminID := auth.GetUserIDFromContext(r.Context())
+	mediaID := mux.Vars(r)["id"]
+
+	media, err := h.mediaService.ReleaseQuarantinedMedia(adminID, mediaID)
+	if err != nil {
+		h.writeQuarantineError(w, mediaID, err)
+		return
+	}
+
+	response.Success(w, media)
+}
+
+// PurgeQuarantinedMedia 删除确认为恶意的媒体文件并通知所有者
+func (h *MediaHandler) PurgeQuarantinedMedia(w http.ResponseWriter, r *http.Request) {
+	adminID := auth.GetUserIDFromContext(r.Context())
+	mediaID := mux.Vars(r)["id"]
+
+	if err := h.mediaService.PurgeQuarantinedMedia(adminID, mediaID); err != nil {
+		h.writeQuarantineError(w, mediaID, err)
+		return
+	}
+
+	response.Success(w, map[string]string{"message": "Media purged successfully"})
+}
+
+// writeQuarantineError 根据隔离区操作错误写入对应状态码
+func (h *MediaHandler) writeQuarantineError(w http.ResponseWriter, mediaID string, err error) {
+	h.logger.Error("Quarantine operation failed", zap.String("media_id", mediaID), zap.Error(err))
+
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		response.Error(w, http.StatusNotFound, "Media not found", nil)
+	case strings.Contains(err.Error(), "not quarantined"):
+		response.Error(w, http.StatusConflict, "Media is not quarantined", nil)
+	default:
+		response.Error(w, http.StatusInternalServerError, "Quarantine operation failed", nil)
+	}
+}
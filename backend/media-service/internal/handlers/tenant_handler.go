@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+	"media-service/pkg/auth"
+	"media-service/pkg/response"
+)
+
+// registerTenantRoutes 注册租户存储管理路由
+func (h *MediaHandler) registerTenantRoutes(router *mux.Router) {
+	router.HandleFunc("/tenants/{tenantId}/stats", h.GetTenantStorageStats).Methods("GET")
+	router.HandleFunc("/tenants/{tenantId}/quota", h.UpdateTenantQuota).Methods("PUT")
+	router.HandleFunc("/tenants/{tenantId}/migrate", h.MigrateTenantMedia).Methods("POST")
+}
+
+// GetTenantStorageStats 获取租户的存储位置、用量和配额
+func (h *MediaHandler) GetTenantStorageStats(w http.ResponseWriter, r *http.Request) {
+	tenantID := mux.Vars(r)["tenantId"]
+
+	stats, err := h.mediaService.GetTenantStorageStats(tenantID)
+	if err != nil {
+		h.writeTenantError(w, tenantID, err)
+		return
+	}
+
+	response.Success(w, stats)
+}
+
+// UpdateTenantQuota 调整租户配额上限
+func (h *MediaHandler) UpdateTenantQuota(w http.ResponseWriter, r *http.Request) {
+	tenantID := mux.Vars(r)["tenantId"]
+
+	var req models.TenantQuotaUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	quota, err := h.mediaService.UpdateTenantQuota(tenantID, &req)
+	if err != nil {
+		h.writeTenantError(w, tenantID, err)
+		return
+	}
+
+	response.Success(w, quota)
+}
+
+// MigrateTenantMedia 把一批已有文件迁移到租户的存储位置，可多次调用直到remaining为false
+func (h *MediaHandler) MigrateTenantMedia(w http.ResponseWriter, r *http.Request) {
+	adminID := auth.GetUserIDFromContext(r.Context())
+	tenantID := mux.Vars(r)["tenantId"]
+
+	var req models.TenantMigrationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid request body", nil)
+			return
+		}
+	}
+
+	result, err := h.mediaService.RehomeTenantMedia(adminID, tenantID, &req)
+	if err != nil {
+		h.writeTenantError(w, tenantID, err)
+		return
+	}
+
+	response.Success(w, result)
+}
+
+// writeTenantError 根据租户管理操作错误写入对应状态码
+func (h *MediaHandler) writeTenantError(w http.ResponseWriter, tenantID string, err error) {
+	switch {
+	case strings.Contains(err.Error(), "invalid tenant id"), strings.Contains(err.Error(), "must be positive"):
+		response.Error(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		h.logger.Error("Tenant storage operation failed", zap.String("tenant_id", tenantID), zap.Error(err))
+		response.Error(w, http.StatusInternalServerError, "Tenant storage operation failed", nil)
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+	"media-service/internal/service"
+	"media-service/pkg/auth"
+	"media-service/pkg/response"
+)
+
+const (
+	// progressEventInterval 两次进度事件之间的最小间隔，期间的变化合并为一次
+	progressEventInterval = 250 * time.Millisecond
+	// progressKeepAlive 没有进度变化时发送注释行，避免代理断开空闲连接
+	progressKeepAlive = 15 * time.Second
+	// progressPendingTimeout 订阅后等待上传请求到达的最长时间
+	progressPendingTimeout = 2 * time.Minute
+)
+
+// registerUploadProgressRoutes 注册上传进度路由
+func (h *MediaHandler) registerUploadProgressRoutes(router *mux.Router) {
+	router.HandleFunc("/uploads/{id}/progress", h.GetUploadProgress).Methods("GET")
+}
+
+// uploadIDFromRequest 上传请求携带的上传ID，可通过upload_id查询参数或X-Upload-ID请求头传递
+func uploadIDFromRequest(r *http.Request) string {
+	if id := r.URL.Query().Get("upload_id"); id != "" {
+		return id
+	}
+	return r.Header.Get("X-Upload-ID")
+}
+
+// GetUploadProgress 获取上传进度，Accept为text/event-stream时以SSE推送进度直到上传结束
+func (h *MediaHandler) GetUploadProgress(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	uploadID := mux.Vars(r)["id"]
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		h.streamUploadProgress(w, r, userID, uploadID)
+		return
+	}
+
+	progress, ok := h.uploads.Get(userID, uploadID)
+	if !ok {
+		response.Error(w, http.StatusNotFound, "Upload not found", nil)
+		return
+	}
+	response.Success(w, progress)
+}
+
+// streamUploadProgress 以SSE推送上传进度，每个事件为 event: progress，data为进度JSON，上传结束后关闭连接
+func (h *MediaHandler) streamUploadProgress(w http.ResponseWriter, r *http.Request, userID, uploadID string) {
+	signal, cancel, err := h.uploads.Watch(userID, uploadID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	defer cancel()
+
+	// 进度流的持续时间取决于上传，不受服务器写超时限制
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{}) //nolint:errcheck
+
+	w.Header().Set("X-Accel-Buffering", "no")
+	response.StreamResponse(w, "text/event-stream")
+
+	keepAlive := time.NewTicker(progressKeepAlive)
+	defer keepAlive.Stop()
+	pendingDeadline := time.NewTimer(progressPendingTimeout)
+	defer pendingDeadline.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			controller.Flush() //nolint:errcheck
+		case <-pendingDeadline.C:
+			if progress := h.uploads.Snapshot(userID, uploadID); progress.Status == models.UploadProgressPending {
+				return
+			}
+		case <-signal:
+			progress := h.uploads.Snapshot(userID, uploadID)
+			data, _ := json.Marshal(progress)
+			if _, err := fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			if err := controller.Flush(); err != nil {
+				h.logger.Warn("Failed to flush upload progress", zap.String("upload_id", uploadID), zap.Error(err))
+				return
+			}
+			if progress.Finished() {
+				return
+			}
+
+			// 限制事件频率，等待期间的变化由信号通道合并
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(progressEventInterval):
+			}
+		}
+	}
+}
+
+// beginUploadProgress 请求携带上传ID时开始记录进度并包装请求体，返回nil表示不记录
+// 上传ID无效或同一上传仍在进行时写出错误响应并返回false
+func (h *MediaHandler) beginUploadProgress(w http.ResponseWriter, r *http.Request, userID string) (*service.UploadSession, bool) {
+	uploadID := uploadIDFromRequest(r)
+	if uploadID == "" {
+		return nil, true
+	}
+
+	session, err := h.uploads.Begin(userID, uploadID, r.ContentLength)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err == service.ErrUploadInProgress {
+			status = http.StatusConflict
+		}
+		response.Error(w, status, err.Error(), nil)
+		return nil, false
+	}
+	r.Body = session.Reader(r.Body)
+	return session, true
+}
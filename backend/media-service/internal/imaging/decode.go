@@ -0,0 +1,39 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+)
+
+// ErrImageTooLarge 图片像素数超过限制
+var ErrImageTooLarge = errors.New("image dimensions exceed limit")
+
+// Decode 先读取图片头中的尺寸，宽×高超过maxPixels时拒绝解码，
+// 防止小文件声明超大尺寸导致完整解码时耗尽内存（解压炸弹）。maxPixels为0表示不限制
+func Decode(r io.Reader, maxPixels int) (image.Image, string, error) {
+	var header bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(r, &header))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image config: %w", err)
+	}
+	if err := CheckPixels(config.Width, config.Height, maxPixels); err != nil {
+		return nil, "", err
+	}
+
+	img, format, err := image.Decode(io.MultiReader(&header, r))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, format, nil
+}
+
+// CheckPixels 检查图片尺寸是否超过像素数限制，maxPixels为0表示不限制
+func CheckPixels(width, height, maxPixels int) error {
+	if maxPixels > 0 && int64(width)*int64(height) > int64(maxPixels) {
+		return fmt.Errorf("%w: %dx%d", ErrImageTooLarge, width, height)
+	}
+	return nil
+}
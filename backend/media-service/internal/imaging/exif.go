@@ -0,0 +1,217 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// EXIF中用到的标签
+const (
+	exifTagOrientation = 0x0112
+	exifTagGPSInfo     = 0x8825
+)
+
+var (
+	jpegSignature = []byte{0xFF, 0xD8}
+	pngSignature  = []byte("\x89PNG\r\n\x1a\n")
+	exifHeader    = []byte("Exif\x00\x00")
+)
+
+// errMalformedImage 图片结构不完整，无法安全地修改元数据
+var errMalformedImage = errors.New("malformed image")
+
+// ExifInfo 从EXIF中读取的信息
+type ExifInfo struct {
+	Present     bool // 图片包含EXIF
+	Orientation int  // 1-8，没有方向信息时为1
+	HasGPS      bool // 包含GPS位置
+}
+
+// ReadExif 读取JPEG或PNG（eXIf块）中的EXIF，其他格式或没有EXIF时返回Orientation为1的空信息
+func ReadExif(data []byte) ExifInfo {
+	info := ExifInfo{Orientation: 1}
+	var tiff []byte
+	switch {
+	case bytes.HasPrefix(data, jpegSignature):
+		_ = walkJPEGSegments(data, func(marker byte, segment []byte) bool {
+			if marker == 0xE1 && bytes.HasPrefix(segment, exifHeader) {
+				tiff = segment[len(exifHeader):]
+				return false
+			}
+			return true
+		})
+	case bytes.HasPrefix(data, pngSignature):
+		_ = walkPNGChunks(data, func(chunkType string, chunk, _ []byte) bool {
+			if chunkType == "eXIf" {
+				tiff = chunk
+				return false
+			}
+			return true
+		})
+	}
+	if tiff == nil {
+		return info
+	}
+
+	info.Present = true
+	parseTIFF(tiff, &info)
+	return info
+}
+
+// StripMetadata 无损删除图片中的EXIF、XMP、IPTC和注释等元数据，保留ICC色彩配置
+// 目前支持JPEG和PNG，返回值的第二项表示是否删除了内容
+func StripMetadata(data []byte) ([]byte, bool, error) {
+	switch {
+	case bytes.HasPrefix(data, jpegSignature):
+		return stripJPEGMetadata(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return stripPNGMetadata(data)
+	}
+	return data, false, nil
+}
+
+// stripJPEGMetadata 删除APP1（EXIF/XMP）、APP13（IPTC）和COM段，图像数据原样保留
+func stripJPEGMetadata(data []byte) ([]byte, bool, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, jpegSignature...)
+	stripped := false
+	pos := len(jpegSignature)
+
+	for pos < len(data) {
+		if pos+4 > len(data) || data[pos] != 0xFF {
+			return nil, false, errMalformedImage
+		}
+		marker := data[pos+1]
+		// 段之间可以有0xFF填充字节
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		// SOS之后是压缩数据，原样复制到文件末尾
+		if marker == 0xDA {
+			out = append(out, data[pos:]...)
+			return out, stripped, nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, false, errMalformedImage
+		}
+		if marker == 0xE1 || marker == 0xED || marker == 0xFE {
+			stripped = true
+		} else {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	return nil, false, errMalformedImage
+}
+
+// stripPNGMetadata 删除eXIf和文本块（tEXt/zTXt/iTXt），其余块原样保留
+func stripPNGMetadata(data []byte) ([]byte, bool, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	stripped := false
+
+	err := walkPNGChunks(data, func(chunkType string, _, raw []byte) bool {
+		switch chunkType {
+		case "eXIf", "tEXt", "zTXt", "iTXt":
+			stripped = true
+		default:
+			out = append(out, raw...)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return out, stripped, nil
+}
+
+// walkJPEGSegments 依次访问SOS之前的标记段，visit返回false时停止
+func walkJPEGSegments(data []byte, visit func(marker byte, segment []byte) bool) error {
+	pos := len(jpegSignature)
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return errMalformedImage
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return errMalformedImage
+		}
+		if !visit(marker, data[pos+4:end]) {
+			return nil
+		}
+		pos = end
+	}
+	return errMalformedImage
+}
+
+// walkPNGChunks 依次访问PNG块直到IEND，visit返回false时停止
+// chunk为块数据，raw为包含长度、类型和CRC的完整块
+func walkPNGChunks(data []byte, visit func(chunkType string, chunk, raw []byte) bool) error {
+	pos := len(pngSignature)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		chunkType := string(data[pos+4 : pos+8])
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return errMalformedImage
+		}
+		if !visit(chunkType, data[pos+8:pos+8+length], data[pos:end]) || chunkType == "IEND" {
+			return nil
+		}
+		pos = end
+	}
+	return errMalformedImage
+}
+
+// parseTIFF 从TIFF结构的IFD0中读取方向和GPS标签，结构异常时保留默认值
+func parseTIFF(tiff []byte, info *ExifInfo) {
+	if len(tiff) < 8 {
+		return
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return
+		}
+		switch order.Uint16(tiff[entry:]) {
+		case exifTagOrientation:
+			// SHORT类型，值保存在值字段的前两个字节
+			if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+				info.Orientation = orientation
+			}
+		case exifTagGPSInfo:
+			info.HasGPS = true
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+// NormalizeOptions 上传图片的规范化选项
+type NormalizeOptions struct {
+	StripMetadata    bool // 删除EXIF等元数据，有方向信息时同时按方向旋转，避免删除后显示方向错误
+	ApplyOrientation bool // 按EXIF方向旋转像素，重新编码后原有元数据不再保留
+	Quality          int  // 重新编码JPEG的质量
+	MaxPixels        int  // 需要旋转重新编码时允许解码的最大像素数，0表示不限制
+}
+
+// NormalizeResult 规范化后的图片
+type NormalizeResult struct {
+	Data        []byte
+	Width       int
+	Height      int
+	Orientation int  // 原图的EXIF方向，1表示无需旋转
+	HadGPS      bool // 原图包含GPS位置
+	Rotated     bool // 已按方向旋转并重新编码
+	Stripped    bool // 已删除元数据
+}
+
+// NormalizeImage 按选项删除JPEG/PNG中的元数据并修正方向，并读取规范化后的尺寸
+// 不支持的格式原样返回，无法读取尺寸时返回错误
+func NormalizeImage(data []byte, opts NormalizeOptions) (*NormalizeResult, error) {
+	exif := ReadExif(data)
+	result := &NormalizeResult{
+		Data:        data,
+		Orientation: exif.Orientation,
+		HadGPS:      exif.HasGPS,
+	}
+
+	if exif.Orientation != 1 && (opts.ApplyOrientation || opts.StripMetadata) {
+		rotated, err := reencodeOriented(data, exif.Orientation, opts.Quality, opts.MaxPixels)
+		if err != nil {
+			return nil, err
+		}
+		result.Data = rotated
+		result.Rotated = true
+		result.Stripped = exif.Present
+	} else if opts.StripMetadata {
+		stripped, changed, err := StripMetadata(data)
+		if err != nil {
+			return nil, err
+		}
+		result.Data = stripped
+		result.Stripped = changed
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(result.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image config: %w", err)
+	}
+	result.Width = config.Width
+	result.Height = config.Height
+	return result, nil
+}
+
+// reencodeOriented 解码图片，按EXIF方向旋转后以原格式重新编码
+func reencodeOriented(data []byte, orientation, quality, maxPixels int) ([]byte, error) {
+	img, format, err := Decode(bytes.NewReader(data), maxPixels)
+	if err != nil {
+		return nil, err
+	}
+	oriented := ApplyOrientation(img, orientation)
+
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, oriented, &jpeg.Options{Quality: quality})
+	case "png":
+		err = png.Encode(&buf, oriented)
+	default:
+		return nil, fmt.Errorf("unsupported image format %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", format, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ApplyOrientation 按EXIF方向（1-8）翻转或旋转图片，使其按正常方向显示
+func ApplyOrientation(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	w, h := bounds.Dx(), bounds.Dy()
+	dstW, dstH := w, h
+	// 5-8需要旋转90度，宽高互换
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+
+	for y := 0; y < dstH; y++ {
+		for x := 0; x < dstW; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // 水平翻转
+				sx, sy = w-1-x, y
+			case 3: // 旋转180度
+				sx, sy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				sx, sy = x, h-1-y
+			case 5: // 沿左上-右下对角线翻转
+				sx, sy = y, x
+			case 6: // 顺时针旋转90度
+				sx, sy = y, h-1-x
+			case 7: // 沿右上-左下对角线翻转
+				sx, sy = w-1-y, h-1-x
+			case 8: // 逆时针旋转90度
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,112 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+
+	// 注册标准库支持的图片解码器
+	_ "image/gif"
+	_ "image/png"
+)
+
+// blurHashSampleSize 计算BlurHash前将图片缩小到的最大边长，保证计算量与原图尺寸无关
+const blurHashSampleSize = 32
+
+// PlaceholderOptions 占位图生成选项
+type PlaceholderOptions struct {
+	ComponentsX int // BlurHash横向分量数
+	ComponentsY int // BlurHash纵向分量数
+	PreviewSize int // 内联预览图最大边长（像素），0表示不生成
+	MaxPixels   int // 允许解码的最大像素数，0表示不限制
+}
+
+// Placeholder 图片占位信息
+type Placeholder struct {
+	Width    int
+	Height   int
+	BlurHash string
+	Preview  string // data:image/jpeg;base64,...
+}
+
+// GeneratePlaceholder 解码图片并生成尺寸、BlurHash和内联预览图
+func GeneratePlaceholder(r io.Reader, opts PlaceholderOptions) (*Placeholder, error) {
+	img, _, err := Decode(r, opts.MaxPixels)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	result := &Placeholder{
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+	}
+
+	result.BlurHash, err = EncodeBlurHash(Downscale(img, blurHashSampleSize), opts.ComponentsX, opts.ComponentsY)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode blurhash: %w", err)
+	}
+
+	if opts.PreviewSize > 0 {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, Downscale(img, opts.PreviewSize), &jpeg.Options{Quality: 60}); err != nil {
+			return nil, fmt.Errorf("failed to encode preview: %w", err)
+		}
+		result.Preview = "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+
+	return result, nil
+}
+
+// Downscale 按比例将图片缩小到最大边长不超过maxSide，使用区域平均采样
+func Downscale(img image.Image, maxSide int) image.Image {
+	return Fit(img, maxSide, maxSide)
+}
+
+// Fit 按比例将图片缩小到不超过maxWidth×maxHeight，使用区域平均采样，不放大图片
+func Fit(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW <= maxWidth && srcH <= maxHeight {
+		return img
+	}
+
+	dstW, dstH := maxWidth, srcH*maxWidth/srcW
+	if dstH > maxHeight {
+		dstW, dstH = srcW*maxHeight/srcH, maxHeight
+	}
+	dstW, dstH = max(1, dstW), max(1, dstH)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for dy := 0; dy < dstH; dy++ {
+		y0 := bounds.Min.Y + dy*srcH/dstH
+		y1 := max(y0+1, bounds.Min.Y+(dy+1)*srcH/dstH)
+		for dx := 0; dx < dstW; dx++ {
+			x0 := bounds.Min.X + dx*srcW/dstW
+			x1 := max(x0+1, bounds.Min.X+(dx+1)*srcW/dstW)
+
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, ca := img.At(x, y).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			dst.Set(dx, dy, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
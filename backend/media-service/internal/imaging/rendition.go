@@ -0,0 +1,55 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"sort"
+)
+
+// Rendition 按预设尺寸缩放后的图片
+type Rendition struct {
+	Preset int // 预设的最大边长（像素）
+	Width  int
+	Height int
+	Data   []byte // JPEG编码
+}
+
+// GenerateRenditions 解码图片并按预设最大边长生成JPEG缩略图，
+// 不放大图片：大于等于原图最大边长的预设会被跳过；像素数超过maxPixels的图片不解码
+func GenerateRenditions(r io.Reader, presets []int, quality, maxPixels int) ([]*Rendition, error) {
+	img, _, err := Decode(r, maxPixels)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	longest := max(bounds.Dx(), bounds.Dy())
+
+	sizes := append([]int(nil), presets...)
+	sort.Ints(sizes)
+
+	var renditions []*Rendition
+	for i, size := range sizes {
+		if size <= 0 || size >= longest || (i > 0 && size == sizes[i-1]) {
+			continue
+		}
+
+		scaled := Downscale(img, size)
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("failed to encode %dpx rendition: %w", size, err)
+		}
+
+		scaledBounds := scaled.Bounds()
+		renditions = append(renditions, &Rendition{
+			Preset: size,
+			Width:  scaledBounds.Dx(),
+			Height: scaledBounds.Dy(),
+			Data:   buf.Bytes(),
+		})
+	}
+
+	return renditions, nil
+}
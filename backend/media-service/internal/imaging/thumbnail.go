@@ -0,0 +1,36 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+)
+
+// Thumbnail 将图片按比例缩小到不超过maxWidth×maxHeight并编码为JPEG，不放大图片
+// JPEG不支持透明通道，透明区域以白色填充；返回值的Preset为0
+func Thumbnail(img image.Image, maxWidth, maxHeight, quality int) (*Rendition, error) {
+	if maxWidth <= 0 || maxHeight <= 0 {
+		return nil, fmt.Errorf("invalid thumbnail size %dx%d", maxWidth, maxHeight)
+	}
+
+	scaled := Fit(img, maxWidth, maxHeight)
+	bounds := scaled.Bounds()
+
+	flattened := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flattened, flattened.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flattened, flattened.Bounds(), scaled, bounds.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flattened, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	return &Rendition{
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+		Data:   buf.Bytes(),
+	}, nil
+}
@@ -0,0 +1,197 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// 水印位置
+const (
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right"
+	WatermarkCenter      = "center"
+)
+
+// WatermarkOptions 水印选项，Logo不为空时叠加图标，否则叠加文字
+type WatermarkOptions struct {
+	Text     string
+	Logo     image.Image
+	Position string // 为空或无法识别时放在右下角
+	Opacity  int    // 不透明度百分比，1-100
+	Scale    int    // 水印宽度占图片宽度的百分比，1-100
+	Quality  int    // JPEG编码质量
+}
+
+// glyphWidth、glyphHeight 内置点阵字体的字符尺寸，字符之间留一列空白
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+)
+
+// glyphs 内置5×7点阵字体，每行低5位从左到右表示像素，未收录的字符显示为问号
+var glyphs = map[rune][glyphHeight]byte{
+	' ':  {},
+	'0':  {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1':  {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2':  {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3':  {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4':  {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5':  {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6':  {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7':  {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8':  {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9':  {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'A':  {0x0E, 0x11, 0x11, 0x11, 0x1F, 0x11, 0x11},
+	'B':  {0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E},
+	'C':  {0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E},
+	'D':  {0x1C, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1C},
+	'E':  {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F},
+	'F':  {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10},
+	'G':  {0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F},
+	'H':  {0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'I':  {0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'J':  {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C},
+	'K':  {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'L':  {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F},
+	'M':  {0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'N':  {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O':  {0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'P':  {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
+	'Q':  {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D},
+	'R':  {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'S':  {0x0F, 0x10, 0x10, 0x0E, 0x01, 0x01, 0x1E},
+	'T':  {0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U':  {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'V':  {0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'W':  {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A},
+	'X':  {0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11},
+	'Y':  {0x11, 0x11, 0x11, 0x0A, 0x04, 0x04, 0x04},
+	'Z':  {0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F},
+	'.':  {0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x0C},
+	'-':  {0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00},
+	'_':  {0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x1F},
+	':':  {0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x0C, 0x00},
+	'/':  {0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00},
+	'@':  {0x0E, 0x11, 0x01, 0x0D, 0x15, 0x15, 0x0E},
+	'&':  {0x0C, 0x12, 0x14, 0x08, 0x15, 0x12, 0x0D},
+	'\'': {0x0C, 0x04, 0x08, 0x00, 0x00, 0x00, 0x00},
+	'?':  {0x0E, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04},
+}
+
+// LoadWatermarkLogo 读取PNG或JPEG格式的水印图标
+func LoadWatermarkLogo(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open watermark logo: %w", err)
+	}
+	defer file.Close()
+
+	logo, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode watermark logo: %w", err)
+	}
+	return logo, nil
+}
+
+// ApplyWatermark 在原尺寸图片上叠加水印并编码为JPEG，原图不做修改
+// 透明区域以白色填充；返回值的Preset为0
+func ApplyWatermark(img image.Image, opts WatermarkOptions) (*Rendition, error) {
+	bounds := img.Bounds()
+	canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(canvas, canvas.Bounds(), img, bounds.Min, draw.Over)
+
+	maxWidth := max(1, bounds.Dx()*clampInt(opts.Scale, 1, 100)/100)
+	var mark image.Image
+	if opts.Logo != nil {
+		logoBounds := opts.Logo.Bounds()
+		mark = Fit(opts.Logo, maxWidth, max(1, maxWidth*logoBounds.Dy()/max(1, logoBounds.Dx())))
+	} else if text := strings.TrimSpace(opts.Text); text != "" {
+		mark = renderWatermarkText(text, maxWidth)
+	}
+
+	if mark != nil {
+		markBounds := mark.Bounds()
+		origin := watermarkOrigin(canvas.Bounds(), markBounds.Size(), opts.Position)
+		alpha := uint8(clampInt(opts.Opacity, 1, 100) * 255 / 100)
+		draw.DrawMask(canvas, image.Rectangle{Min: origin, Max: origin.Add(markBounds.Size())},
+			mark, markBounds.Min, image.NewUniform(color.Alpha{A: alpha}), image.Point{}, draw.Over)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, canvas, &jpeg.Options{Quality: opts.Quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode watermark: %w", err)
+	}
+
+	return &Rendition{
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+		Data:   buf.Bytes(),
+	}, nil
+}
+
+// renderWatermarkText 用内置点阵字体渲染白色文字并加深色阴影，文字宽度不超过maxWidth
+// 点阵按整数倍放大，图片过窄时至少按1倍渲染
+func renderWatermarkText(text string, maxWidth int) image.Image {
+	runes := []rune(strings.ToUpper(text))
+	units := len(runes)*(glyphWidth+1) - 1
+	scale := max(1, maxWidth/units)
+	shadow := max(1, scale/2)
+
+	mark := image.NewNRGBA(image.Rect(0, 0, units*scale+shadow, glyphHeight*scale+shadow))
+	shadowColor := image.NewUniform(color.NRGBA{A: 160})
+	textColor := image.NewUniform(color.White)
+	for _, layer := range []struct {
+		src    image.Image
+		offset int
+	}{{shadowColor, shadow}, {textColor, 0}} {
+		for i, r := range runes {
+			glyph, ok := glyphs[r]
+			if !ok && !unicode.IsSpace(r) {
+				glyph = glyphs['?']
+			}
+			for row, bits := range glyph {
+				for col := 0; col < glyphWidth; col++ {
+					if bits&(1<<(glyphWidth-1-col)) == 0 {
+						continue
+					}
+					x := (i*(glyphWidth+1)+col)*scale + layer.offset
+					y := row*scale + layer.offset
+					draw.Draw(mark, image.Rect(x, y, x+scale, y+scale), layer.src, image.Point{}, draw.Src)
+				}
+			}
+		}
+	}
+	return mark
+}
+
+// watermarkOrigin 计算水印左上角的位置，与图片边缘保留短边2%的边距
+func watermarkOrigin(bounds image.Rectangle, size image.Point, position string) image.Point {
+	margin := min(bounds.Dx(), bounds.Dy()) * 2 / 100
+	left := bounds.Min.X + margin
+	top := bounds.Min.Y + margin
+	right := bounds.Max.X - margin - size.X
+	bottom := bounds.Max.Y - margin - size.Y
+
+	switch position {
+	case WatermarkTopLeft:
+		return image.Pt(left, top)
+	case WatermarkTopRight:
+		return image.Pt(right, top)
+	case WatermarkBottomLeft:
+		return image.Pt(left, bottom)
+	case WatermarkCenter:
+		return image.Pt(bounds.Min.X+(bounds.Dx()-size.X)/2, bounds.Min.Y+(bounds.Dy()-size.Y)/2)
+	default:
+		return image.Pt(right, bottom)
+	}
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// ImageAnalysis 图片内容识别结果，供搜索服务索引图片内容（收据、截图等）
+type ImageAnalysis struct {
+	Caption      string    `json:"caption,omitempty"` // 图片描述
+	Text         string    `json:"text,omitempty"`    // OCR识别出的文字
+	CaptionModel string    `json:"caption_model,omitempty"`
+	OCREngine    string    `json:"ocr_engine,omitempty"`
+	AnalyzedAt   time.Time `json:"analyzed_at"`
+}
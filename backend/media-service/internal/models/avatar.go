@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// UserAvatar 用户当前使用的头像，头像链接按用户生成，更换头像后链接不变
+type UserAvatar struct {
+	UserID    string    `json:"user_id" db:"user_id"`
+	MediaID   string    `json:"media_id" db:"media_id"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AvatarSetRequest 设置头像请求，media_id为用户自己上传的图片
+type AvatarSetRequest struct {
+	MediaID string `json:"media_id" validate:"required"`
+}
+
+// AvatarURL 签名的头像链接
+type AvatarURL struct {
+	URL       string    `json:"url"`
+	Size      int       `json:"size,omitempty"` // 实际使用的缩略图尺寸，0表示原图
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AvatarContent 解析后的头像文件，StorageKey不返回给客户端
+type AvatarContent struct {
+	MediaID     string
+	StorageKey  string
+	ContentType string
+	ETag        string
+	UpdatedAt   time.Time
+	MaxAge      int // 响应的缓存时间（秒）
+}
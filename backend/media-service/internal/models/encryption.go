@@ -0,0 +1,53 @@
+package models
+
+import (
+	"fmt"
+
+	"media-service/pkg/validation"
+)
+
+// EncryptedMimeType 客户端加密文件在服务端统一使用的MIME类型
+const EncryptedMimeType = "application/octet-stream"
+
+// SupportedEncryptionAlgorithms 支持的客户端加密算法
+var SupportedEncryptionAlgorithms = []string{
+	"AES-256-GCM",
+	"AES-256-CBC-HMAC-SHA256",
+	"XChaCha20-Poly1305",
+}
+
+func init() {
+	validation.Register("encryption_algorithm", "is not a supported encryption algorithm", func(value interface{}, _ string) bool {
+		algorithm, _ := value.(string)
+		for _, supported := range SupportedEncryptionAlgorithms {
+			if algorithm == supported {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// EncryptionInfo 客户端加密参数
+// 服务端只保存解密所需的公开参数，密钥由客户端通过端到端加密的消息分发，不会上传
+type EncryptionInfo struct {
+	Algorithm         string `json:"algorithm" validate:"required,encryption_algorithm"`
+	IV                string `json:"iv" validate:"required,base64"` // base64编码的初始向量/nonce
+	KeyID             string `json:"key_id,omitempty"`              // 客户端自定义的密钥标识
+	CiphertextHash    string `json:"ciphertext_hash,omitempty"`     // 密文SHA-256，供客户端下载后校验
+	PlaintextMimeType string `json:"plaintext_mime_type,omitempty"` // 明文类型，用于配额统计和列表筛选
+	PlaintextSize     int64  `json:"plaintext_size,omitempty" validate:"min=0"`
+}
+
+// Validate 验证加密参数
+func (e *EncryptionInfo) Validate() error {
+	if e == nil {
+		return fmt.Errorf("encryption info is required")
+	}
+	return validation.Struct(e)
+}
+
+// IsEncrypted 检查是否为客户端加密文件
+func (m *Media) IsEncrypted() bool {
+	return m.Metadata != nil && m.Metadata.Encryption != nil
+}
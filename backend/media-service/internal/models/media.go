@@ -4,9 +4,13 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+
+	"media-service/pkg/validation"
 )
 
 // MediaType 媒体类型枚举
@@ -23,17 +27,19 @@ const (
 type MediaStatus string
 
 const (
-	MediaStatusUploading  MediaStatus = "uploading"
-	MediaStatusProcessing MediaStatus = "processing"
-	MediaStatusReady      MediaStatus = "ready"
-	MediaStatusFailed     MediaStatus = "failed"
-	MediaStatusDeleted    MediaStatus = "deleted"
+	MediaStatusUploading   MediaStatus = "uploading"
+	MediaStatusProcessing  MediaStatus = "processing"
+	MediaStatusReady       MediaStatus = "ready"
+	MediaStatusFailed      MediaStatus = "failed"
+	MediaStatusQuarantined MediaStatus = "quarantined"
+	MediaStatusDeleted     MediaStatus = "deleted"
 )
 
 // Media 媒体文件模型
 type Media struct {
 	ID          string      `json:"id" db:"id"`
 	UserID      string      `json:"user_id" db:"user_id"`
+	TenantID    string      `json:"tenant_id" db:"tenant_id"`
 	Filename    string      `json:"filename" db:"filename"`
 	OriginalName string     `json:"original_name" db:"original_name"`
 	MimeType    string      `json:"mime_type" db:"mime_type"`
@@ -47,6 +53,7 @@ type Media struct {
 	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
 	ExpiresAt   *time.Time  `json:"expires_at,omitempty" db:"expires_at"`
+	DeletedAt   *time.Time  `json:"deleted_at,omitempty" db:"deleted_at"` // 删除时间，只在查询已删除的文件时填充
 }
 
 // MediaMetadata 媒体元数据
@@ -55,18 +62,83 @@ type MediaMetadata struct {
 	Width  *int `json:"width,omitempty"`
 	Height *int `json:"height,omitempty"`
 
+	// 图片占位信息，客户端可在原图加载完成前先渲染
+	BlurHash    string `json:"blurhash,omitempty"`
+	Placeholder string `json:"placeholder,omitempty"` // 内联base64预览图 (data URI)
+
+	// 图片缩略图，键为预设最大边长（如"200"）；Srcset可直接用于<img srcset>
+	Variants map[string]*MediaVariant `json:"variants,omitempty"`
+	Srcset   string                   `json:"srcset,omitempty"`
+
+	// 公开链接使用的带水印图片，原图保持不变
+	Watermark *MediaVariant `json:"watermark,omitempty"`
+
 	// 视频元数据
 	Duration *float64 `json:"duration,omitempty"` // 秒
 	Bitrate  *int     `json:"bitrate,omitempty"`  // bps
 	Codec    *string  `json:"codec,omitempty"`
 
+	// 视频拖动预览：雪碧图及描述各帧位置的WebVTT文件
+	SpriteURL    string `json:"sprite_url,omitempty"`
+	SpriteVTTURL string `json:"sprite_vtt_url,omitempty"`
+
+	// 视频转码：H.264/AAC的MP4版本，键为输出高度（如"720p"），以及封面图
+	Renditions map[string]*VideoRendition `json:"renditions,omitempty"`
+	PosterURL  string                     `json:"poster_url,omitempty"`
+
 	// 音频元数据
 	SampleRate *int `json:"sample_rate,omitempty"` // Hz
 	Channels   *int `json:"channels,omitempty"`
 
+	// 音频波形，按时间均分的各段振幅（0-100，相对最响的一段），客户端无需下载文件即可绘制
+	Waveform []int `json:"waveform,omitempty"`
+
 	// 通用元数据
 	Checksum string            `json:"checksum,omitempty"`
 	Exif     map[string]string `json:"exif,omitempty"`
+
+	// 客户端加密参数，为空表示明文文件
+	Encryption *EncryptionInfo `json:"encryption,omitempty"`
+
+	// 安全扫描结果，为空表示未扫描
+	VirusScan *ScanResult `json:"virus_scan,omitempty"`
+
+	// 图片描述和OCR文字，为空表示未识别
+	Analysis *ImageAnalysis `json:"analysis,omitempty"`
+
+	// 语音转写文本，为空表示未转写
+	Transcript *Transcript `json:"transcript,omitempty"`
+}
+
+// MediaContent 解析后的媒体文件内容，用于下载接口，StorageKey不返回给客户端
+type MediaContent struct {
+	MediaID     string
+	StorageKey  string
+	ContentType string
+	Filename    string
+	Size        int64
+	ETag        string
+	UpdatedAt   time.Time
+	MaxAge      int // 响应的缓存时间（秒）
+}
+
+// MediaVariant 按预设尺寸生成的图片缩略图
+type MediaVariant struct {
+	URL      string `json:"url"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mime_type"`
+}
+
+// VideoRendition 转码生成的视频版本
+type VideoRendition struct {
+	URL      string `json:"url"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Size     int64  `json:"size"`
+	Bitrate  int    `json:"bitrate"` // bps
+	MimeType string `json:"mime_type"`
 }
 
 // UploadRequest 上传请求
@@ -81,6 +153,35 @@ type UploadRequest struct {
 	IsTemporary bool              `json:"is_temporary,omitempty"`
 }
 
+// MaxFilenameLength 上传文件名的最大长度
+const MaxFilenameLength = 255
+
+// UploadForm 上传表单，file为multipart中的文件，encryption为可选的客户端加密参数
+type UploadForm struct {
+	File       *multipart.FileHeader `json:"file" validate:"required"`
+	Encryption *EncryptionInfo       `json:"encryption,omitempty"`
+}
+
+// ValidateStruct 校验文件名和文件大小，大小上限取决于配置，由服务层检查
+func (f UploadForm) ValidateStruct() validation.Errors {
+	if f.File == nil {
+		return nil
+	}
+	var errs validation.Errors
+	if f.File.Size <= 0 {
+		errs = append(errs, validation.FieldError{Field: "file", Rule: "min", Param: "1", Message: "file must not be empty"})
+	}
+	if len([]rune(f.File.Filename)) > MaxFilenameLength {
+		errs = append(errs, validation.FieldError{
+			Field:   "file",
+			Rule:    "max",
+			Param:   strconv.Itoa(MaxFilenameLength),
+			Message: "file name must contain at most " + strconv.Itoa(MaxFilenameLength) + " characters",
+		})
+	}
+	return errs
+}
+
 // UploadResponse 上传响应
 type UploadResponse struct {
 	MediaID   string `json:"media_id"`
@@ -114,6 +215,9 @@ type MediaUpdateRequest struct {
 	Status    *MediaStatus   `json:"status,omitempty"`
 	Metadata  *MediaMetadata `json:"metadata,omitempty"`
 	ExpiresAt *time.Time     `json:"expires_at,omitempty"`
+
+	// ThumbnailURL 由缩略图任务回写，不接受客户端提交
+	ThumbnailURL *string `json:"-"`
 }
 
 // ThumbnailRequest 缩略图请求
@@ -124,11 +228,22 @@ type ThumbnailRequest struct {
 	Quality int    `json:"quality"`
 }
 
+// 处理任务类型
+const (
+	JobTypeThumbnail     = "thumbnail"
+	JobTypeVideoSprite   = "video_sprite"
+	JobTypeImageAnalysis = "image_analysis"
+	JobTypeTranscription = "transcription"
+	JobTypeWatermark     = "watermark"
+	JobTypeTranscode     = "transcode"
+	JobTypeWaveform      = "waveform"
+)
+
 // ProcessingJob 处理任务
 type ProcessingJob struct {
 	ID        string                 `json:"id" db:"id"`
 	MediaID   string                 `json:"media_id" db:"media_id"`
-	JobType   string                 `json:"job_type" db:"job_type"` // thumbnail, compress, convert
+	JobType   string                 `json:"job_type" db:"job_type"` // thumbnail, video_sprite, transcode, waveform, compress, convert
 	Status    string                 `json:"status" db:"status"`     // pending, processing, completed, failed
 	Params    map[string]interface{} `json:"params" db:"params"`
 	Result    map[string]interface{} `json:"result,omitempty" db:"result"`
@@ -137,6 +252,8 @@ type ProcessingJob struct {
 	UpdatedAt time.Time              `json:"updated_at" db:"updated_at"`
 	StartedAt *time.Time             `json:"started_at,omitempty" db:"started_at"`
 	CompletedAt *time.Time           `json:"completed_at,omitempty" db:"completed_at"`
+	Attempts    int                  `json:"attempts" db:"attempts"`             // 已执行次数
+	NextRunAt   *time.Time           `json:"next_run_at,omitempty" db:"next_run_at"` // 失败重试时最早的下次执行时间
 }
 
 // StorageInfo 存储信息
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// ScanVerdict 安全扫描结论
+type ScanVerdict string
+
+const (
+	ScanVerdictClean      ScanVerdict = "clean"
+	ScanVerdictInfected   ScanVerdict = "infected"
+	ScanVerdictSuspicious ScanVerdict = "suspicious"
+)
+
+// ScanResult 文件安全扫描结果
+type ScanResult struct {
+	Verdict   ScanVerdict `json:"verdict"`
+	Signature string      `json:"signature,omitempty"`
+	Engine    string      `json:"engine"`
+	ScannedAt time.Time   `json:"scanned_at"`
+
+	// 管理员复核信息
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// IsThreat 检查扫描结果是否需要隔离
+func (r *ScanResult) IsThreat() bool {
+	return r != nil && (r.Verdict == ScanVerdictInfected || r.Verdict == ScanVerdictSuspicious)
+}
+
+// QuarantineListRequest 隔离文件列表请求
+type QuarantineListRequest struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// QuarantineListResponse 隔离文件列表响应
+type QuarantineListResponse struct {
+	Medias []*Media `json:"medias"`
+	Total  int      `json:"total"`
+	Limit  int      `json:"limit"`
+	Offset int      `json:"offset"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// MediaShare 媒体文件的共享记录，ConversationID和UserID只有一个不为空
+// 附加到会话时会话的所有参与者都可以读取，授权给用户时只有该用户可以读取
+type MediaShare struct {
+	ID             string    `json:"id" db:"id"`
+	MediaID        string    `json:"media_id" db:"media_id"`
+	ConversationID string    `json:"conversation_id,omitempty" db:"conversation_id"`
+	UserID         string    `json:"user_id,omitempty" db:"user_id"`
+	CreatedBy      string    `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// MediaShareRequest 共享请求，conversation_id和user_ids至少提供一个
+type MediaShareRequest struct {
+	ConversationID string   `json:"conversation_id" validate:"omitempty,max=64"`
+	UserIDs        []string `json:"user_ids" validate:"omitempty,max=100,dive,required,max=36"`
+}
+
+// PublicLinkRequest 创建公开链接请求，ttl_hours为0时使用默认有效期
+type PublicLinkRequest struct {
+	TTLHours int `json:"ttl_hours" validate:"omitempty,min=1"`
+}
+
+// MediaPublicLink 签名的公开链接，不需要登录即可访问，到期前无法单独撤销
+// 启用水印时图片通过链接返回带水印的副本
+type MediaPublicLink struct {
+	URL         string    `json:"url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Watermarked bool      `json:"watermarked"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"regexp"
+	"time"
+)
+
+// DefaultTenantID 未启用多租户时所有媒体归属的租户，历史数据迁移前也属于该租户
+const DefaultTenantID = "default"
+
+// tenantIDPattern 租户ID会出现在存储键中，只允许字母、数字、下划线和连字符
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// IsValidTenantID 检查租户ID是否合法
+func IsValidTenantID(tenantID string) bool {
+	return tenantIDPattern.MatchString(tenantID)
+}
+
+// TenantStorageQuota 租户存储配额，在用户配额之外对整个租户的用量做限制
+type TenantStorageQuota struct {
+	TenantID     string    `json:"tenant_id" db:"tenant_id"`
+	TotalQuota   int64     `json:"total_quota" db:"total_quota"`       // 总配额（字节）
+	UsedQuota    int64     `json:"used_quota" db:"used_quota"`         // 已使用配额（字节）
+	FileCount    int       `json:"file_count" db:"file_count"`         // 文件数量
+	MaxFileCount int       `json:"max_file_count" db:"max_file_count"` // 最大文件数量
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TenantQuotaUpdateRequest 调整租户配额上限
+type TenantQuotaUpdateRequest struct {
+	TotalQuota   *int64 `json:"total_quota,omitempty"`
+	MaxFileCount *int   `json:"max_file_count,omitempty"`
+}
+
+// TenantStorageStats 租户存储统计
+type TenantStorageStats struct {
+	TenantID  string `json:"tenant_id"`
+	Bucket    string `json:"bucket,omitempty"`
+	KeyPrefix string `json:"key_prefix"`
+	StorageInfo
+	UserCount int `json:"user_count"`
+}
+
+// TenantMigrationRequest 将已有文件迁移到租户的存储桶和前缀下
+// UserIDs不为空时，把这些用户在其他租户下的文件划归目标租户；
+// 否则只迁移目标租户中仍位于旧位置的文件（如修改了租户的存储配置）
+type TenantMigrationRequest struct {
+	UserIDs []string `json:"user_ids,omitempty"`
+	Limit   int      `json:"limit"`
+	DryRun  bool     `json:"dry_run"`
+}
+
+// TenantMigrationItem 单个文件的迁移结果
+type TenantMigrationItem struct {
+	MediaID    string `json:"media_id"`
+	FromTenant string `json:"from_tenant"`
+	FromKey    string `json:"from_key"`
+	ToKey      string `json:"to_key"`
+	Error      string `json:"error,omitempty"`
+}
+
+// TenantMigrationResult 迁移批次结果，Remaining为true时需要再次调用继续迁移
+type TenantMigrationResult struct {
+	TenantID  string                 `json:"tenant_id"`
+	DryRun    bool                   `json:"dry_run"`
+	Scanned   int                    `json:"scanned"`
+	Migrated  int                    `json:"migrated"`
+	Failed    int                    `json:"failed"`
+	Remaining bool                   `json:"remaining"`
+	Items     []*TenantMigrationItem `json:"items"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Transcript 语音转写结果，用于语音消息搜索和无障碍显示
+type Transcript struct {
+	Text          string    `json:"text"`
+	Language      string    `json:"language,omitempty"`
+	Duration      float64   `json:"duration,omitempty"` // 音频时长（秒）
+	Model         string    `json:"model,omitempty"`
+	TranscribedAt time.Time `json:"transcribed_at"`
+}
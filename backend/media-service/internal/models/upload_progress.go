@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// UploadProgressStatus 上传进度状态
+type UploadProgressStatus string
+
+const (
+	UploadProgressPending    UploadProgressStatus = "pending"    // 已订阅进度，上传请求尚未到达
+	UploadProgressReceiving  UploadProgressStatus = "receiving"  // 正在接收请求体
+	UploadProgressProcessing UploadProgressStatus = "processing" // 请求体已接收完，正在扫描和保存文件
+	UploadProgressCompleted  UploadProgressStatus = "completed"
+	UploadProgressFailed     UploadProgressStatus = "failed"
+)
+
+// UploadProgress 上传进度，TotalBytes为0表示请求未声明Content-Length，此时不计算百分比
+type UploadProgress struct {
+	UploadID      string               `json:"upload_id"`
+	Status        UploadProgressStatus `json:"status"`
+	BytesReceived int64                `json:"bytes_received"`
+	TotalBytes    int64                `json:"total_bytes,omitempty"`
+	Percent       *float64             `json:"percent,omitempty"`
+	MediaID       string               `json:"media_id,omitempty"`
+	Error         string               `json:"error,omitempty"`
+	StartedAt     *time.Time           `json:"started_at,omitempty"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+}
+
+// Finished 上传是否已结束
+func (p *UploadProgress) Finished() bool {
+	return p.Status == UploadProgressCompleted || p.Status == UploadProgressFailed
+}
@@ -0,0 +1,204 @@
+package processing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TranscodeOptions 视频转码选项
+type TranscodeOptions struct {
+	FFmpegPath   string
+	FFprobePath  string
+	Heights      []int  // 输出高度（像素），不超过原视频高度
+	CRF          int    // x264质量
+	Preset       string // x264编码速度预设
+	AudioBitrate string
+}
+
+// VideoInfo ffprobe读取的视频信息
+type VideoInfo struct {
+	Duration float64
+	Bitrate  int // bps
+	Codec    string
+	Width    int
+	Height   int
+	HasAudio bool
+}
+
+// TranscodeOutput 转码输出的MP4文件
+type TranscodeOutput struct {
+	Name    string // 如 "720p"
+	Path    string
+	Width   int
+	Height  int
+	Size    int64
+	Bitrate int // bps
+}
+
+// Transcoder 使用ffmpeg把视频转码为H.264/AAC的MP4并截取封面图
+type Transcoder struct {
+	opts TranscodeOptions
+}
+
+// NewTranscoder 创建视频转码器
+func NewTranscoder(opts TranscodeOptions) *Transcoder {
+	if opts.CRF <= 0 {
+		opts.CRF = 23
+	}
+	if opts.Preset == "" {
+		opts.Preset = "veryfast"
+	}
+	if opts.AudioBitrate == "" {
+		opts.AudioBitrate = "128k"
+	}
+	return &Transcoder{opts: opts}
+}
+
+// Probe 使用ffprobe读取视频的时长、码率、编码和尺寸
+func (t *Transcoder) Probe(ctx context.Context, inputPath string) (*VideoInfo, error) {
+	out, err := exec.CommandContext(ctx, t.opts.FFprobePath,
+		"-v", "error",
+		"-show_entries", "format=duration,bit_rate:stream=codec_type,codec_name,width,height",
+		"-of", "json",
+		inputPath,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	var probe struct {
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			CodecName string `json:"codec_name"`
+			Width     int    `json:"width"`
+			Height    int    `json:"height"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+			BitRate  string `json:"bit_rate"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	info := &VideoInfo{}
+	info.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	info.Bitrate, _ = strconv.Atoi(probe.Format.BitRate)
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "video":
+			if info.Codec == "" {
+				info.Codec = stream.CodecName
+				info.Width = stream.Width
+				info.Height = stream.Height
+			}
+		case "audio":
+			info.HasAudio = true
+		}
+	}
+	if info.Codec == "" || info.Height <= 0 {
+		return nil, fmt.Errorf("no video stream found")
+	}
+	return info, nil
+}
+
+// Transcode 按配置的高度把视频转码为MP4，输出到outputDir
+// 不放大视频：高于原视频的高度被跳过，全部跳过时按原高度输出一个版本
+func (t *Transcoder) Transcode(ctx context.Context, inputPath, outputDir string, info *VideoInfo) ([]*TranscodeOutput, error) {
+	heights := t.targetHeights(info.Height)
+
+	renditions := make([]*TranscodeOutput, 0, len(heights))
+	for _, height := range heights {
+		name := fmt.Sprintf("%dp", height)
+		outputPath := filepath.Join(outputDir, name+".mp4")
+
+		args := []string{
+			"-v", "error", "-y",
+			"-i", inputPath,
+			"-map", "0:v:0", "-map", "0:a:0?",
+			// 宽度按比例计算并取偶数，yuv420p保证浏览器和移动端都能播放
+			"-vf", fmt.Sprintf("scale=-2:%d", height),
+			"-c:v", "libx264", "-preset", t.opts.Preset, "-crf", strconv.Itoa(t.opts.CRF),
+			"-profile:v", "high", "-pix_fmt", "yuv420p",
+			"-c:a", "aac", "-b:a", t.opts.AudioBitrate,
+			// moov放在文件开头，下载未完成时即可开始播放
+			"-movflags", "+faststart",
+			outputPath,
+		}
+		if err := t.run(ctx, args); err != nil {
+			return nil, fmt.Errorf("failed to transcode %s: %w", name, err)
+		}
+
+		output, err := t.Probe(ctx, outputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to probe %s: %w", name, err)
+		}
+		stat, err := os.Stat(outputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", name, err)
+		}
+
+		renditions = append(renditions, &TranscodeOutput{
+			Name:    name,
+			Path:    outputPath,
+			Width:   output.Width,
+			Height:  output.Height,
+			Size:    stat.Size(),
+			Bitrate: output.Bitrate,
+		})
+	}
+	return renditions, nil
+}
+
+// Poster 截取视频开头附近的一帧作为封面图（JPEG），避免截到片头黑场
+func (t *Transcoder) Poster(ctx context.Context, inputPath, outputPath string, duration float64) error {
+	at := math.Min(1, duration/2)
+	return t.run(ctx, []string{
+		"-v", "error", "-y",
+		"-ss", strconv.FormatFloat(at, 'f', 3, 64),
+		"-i", inputPath,
+		"-frames:v", "1",
+		"-q:v", "3",
+		outputPath,
+	})
+}
+
+// targetHeights 去重并从高到低排列不超过原视频高度的输出高度，高度取偶数
+func (t *Transcoder) targetHeights(sourceHeight int) []int {
+	seen := make(map[int]bool)
+	var heights []int
+	for _, height := range t.opts.Heights {
+		height -= height % 2
+		if height <= 0 || height > sourceHeight || seen[height] {
+			continue
+		}
+		seen[height] = true
+		heights = append(heights, height)
+	}
+	if len(heights) == 0 {
+		heights = append(heights, max(2, sourceHeight-sourceHeight%2))
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(heights)))
+	return heights
+}
+
+// run 执行ffmpeg，失败时附带错误输出
+func (t *Transcoder) run(ctx context.Context, args []string) error {
+	cmd := exec.CommandContext(ctx, t.opts.FFmpegPath, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
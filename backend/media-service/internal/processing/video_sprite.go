@@ -0,0 +1,163 @@
+package processing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/jpeg"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SpriteOptions 雪碧图生成选项
+type SpriteOptions struct {
+	FFmpegPath  string
+	FFprobePath string
+	Interval    int // 截帧间隔（秒）
+	Width       int // 单帧宽度（像素）
+	Columns     int
+	MaxFrames   int
+}
+
+// Sprite 生成的视频雪碧图
+type Sprite struct {
+	Path       string
+	Duration   float64
+	Interval   float64
+	Frames     int
+	Columns    int
+	TileWidth  int
+	TileHeight int
+}
+
+// SpriteGenerator 使用ffmpeg从视频中截帧并拼接为雪碧图
+type SpriteGenerator struct {
+	opts SpriteOptions
+}
+
+// NewSpriteGenerator 创建雪碧图生成器
+func NewSpriteGenerator(opts SpriteOptions) *SpriteGenerator {
+	if opts.Interval <= 0 {
+		opts.Interval = 5
+	}
+	if opts.Width <= 0 {
+		opts.Width = 160
+	}
+	if opts.Columns <= 0 {
+		opts.Columns = 10
+	}
+	if opts.MaxFrames <= 0 {
+		opts.MaxFrames = 100
+	}
+	return &SpriteGenerator{opts: opts}
+}
+
+// Generate 为inputPath指定的视频生成雪碧图，输出到outputPath（JPEG）
+func (g *SpriteGenerator) Generate(ctx context.Context, inputPath, outputPath string) (*Sprite, error) {
+	duration, err := g.probeDuration(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 超过最大帧数时拉长截帧间隔，保证覆盖整段视频
+	interval := float64(g.opts.Interval)
+	frames := int(math.Ceil(duration / interval))
+	if frames > g.opts.MaxFrames {
+		frames = g.opts.MaxFrames
+		interval = duration / float64(frames)
+	}
+	if frames < 1 {
+		frames = 1
+	}
+
+	columns := g.opts.Columns
+	if frames < columns {
+		columns = frames
+	}
+	rows := int(math.Ceil(float64(frames) / float64(columns)))
+
+	filter := fmt.Sprintf("fps=1/%s,scale=%d:-2,tile=%dx%d",
+		strconv.FormatFloat(interval, 'f', 3, 64), g.opts.Width, columns, rows)
+	cmd := exec.CommandContext(ctx, g.opts.FFmpegPath,
+		"-v", "error", "-y",
+		"-i", inputPath,
+		"-vf", filter,
+		"-frames:v", "1",
+		"-q:v", "5",
+		outputPath,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	// 读取实际输出尺寸，单帧高度由ffmpeg按比例计算
+	file, err := os.Open(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sprite: %w", err)
+	}
+	defer file.Close()
+	cfg, err := jpeg.DecodeConfig(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sprite dimensions: %w", err)
+	}
+
+	return &Sprite{
+		Path:       outputPath,
+		Duration:   duration,
+		Interval:   interval,
+		Frames:     frames,
+		Columns:    columns,
+		TileWidth:  cfg.Width / columns,
+		TileHeight: cfg.Height / rows,
+	}, nil
+}
+
+// VTT 生成WebVTT文件，每个cue通过媒体片段 #xywh 指向雪碧图中的对应帧
+func (s *Sprite) VTT(spriteURL string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("WEBVTT\n\n")
+	for i := 0; i < s.Frames; i++ {
+		start := float64(i) * s.Interval
+		end := math.Min(start+s.Interval, s.Duration)
+		x := (i % s.Columns) * s.TileWidth
+		y := (i / s.Columns) * s.TileHeight
+		fmt.Fprintf(&buf, "%s --> %s\n%s#xywh=%d,%d,%d,%d\n\n",
+			formatVTTTime(start), formatVTTTime(end), spriteURL, x, y, s.TileWidth, s.TileHeight)
+	}
+	return buf.Bytes()
+}
+
+// probeDuration 使用ffprobe获取视频时长（秒）
+func (g *SpriteGenerator) probeDuration(ctx context.Context, inputPath string) (float64, error) {
+	out, err := exec.CommandContext(ctx, g.opts.FFprobePath,
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		inputPath,
+	).Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	duration, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid video duration: %q", strings.TrimSpace(string(out)))
+	}
+	return duration, nil
+}
+
+// formatVTTTime 将秒数格式化为 HH:MM:SS.mmm
+func formatVTTTime(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second))
+	h := int(d / time.Hour)
+	m := int(d/time.Minute) % 60
+	sec := int(d/time.Second) % 60
+	ms := int(d/time.Millisecond) % 1000
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, sec, ms)
+}
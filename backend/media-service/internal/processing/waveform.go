@@ -0,0 +1,134 @@
+package processing
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strings"
+)
+
+// waveformSampleRate 提取波形时解码的采样率，语音波形不需要更高的精度
+const waveformSampleRate = 8000
+
+// waveformFrameSamples 每帧的采样数（10毫秒），先按帧取峰值再合并为波形段，
+// 解码时不需要保留全部采样
+const waveformFrameSamples = waveformSampleRate / 100
+
+// WaveformOptions 音频波形提取选项
+type WaveformOptions struct {
+	FFmpegPath string
+	Buckets    int // 波形段数
+}
+
+// Waveform 音频波形
+type Waveform struct {
+	Duration float64 // 秒
+	Peaks    []int   // 各段峰值振幅，0-100，按最响的一段归一化
+}
+
+// WaveformExtractor 使用ffmpeg把音频解码为单声道PCM并计算波形
+type WaveformExtractor struct {
+	opts WaveformOptions
+}
+
+// NewWaveformExtractor 创建音频波形提取器
+func NewWaveformExtractor(opts WaveformOptions) *WaveformExtractor {
+	if opts.Buckets <= 0 {
+		opts.Buckets = 100
+	}
+	return &WaveformExtractor{opts: opts}
+}
+
+// Extract 计算inputPath指定的音频的时长和波形
+// 音频短于波形段数对应的帧数时，波形段数按帧数减少
+func (e *WaveformExtractor) Extract(ctx context.Context, inputPath string) (*Waveform, error) {
+	cmd := exec.CommandContext(ctx, e.opts.FFmpegPath,
+		"-v", "error",
+		"-i", inputPath,
+		"-vn", "-ac", "1", "-ar", fmt.Sprint(waveformSampleRate),
+		"-f", "s16le", "-acodec", "pcm_s16le",
+		"-",
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	frames, samples, readErr := readFramePeaks(bufio.NewReader(stdout))
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to read decoded audio: %w", readErr)
+	}
+	if samples == 0 {
+		return nil, fmt.Errorf("no audio stream found")
+	}
+
+	return &Waveform{
+		Duration: float64(samples) / waveformSampleRate,
+		Peaks:    bucketPeaks(frames, e.opts.Buckets),
+	}, nil
+}
+
+// readFramePeaks 读取16位小端PCM，返回每帧的峰值振幅和采样总数
+func readFramePeaks(r io.Reader) ([]int, int, error) {
+	var frames []int
+	samples, peak := 0, 0
+	buf := make([]byte, 2)
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, 0, err
+		}
+		amplitude := int(int16(binary.LittleEndian.Uint16(buf)))
+		if amplitude < 0 {
+			amplitude = -amplitude
+		}
+		peak = max(peak, amplitude)
+		samples++
+		if samples%waveformFrameSamples == 0 {
+			frames = append(frames, peak)
+			peak = 0
+		}
+	}
+	if samples%waveformFrameSamples != 0 {
+		frames = append(frames, peak)
+	}
+	return frames, samples, nil
+}
+
+// bucketPeaks 把帧峰值按时间均分为buckets段，每段取最大值并归一化到0-100
+// 全部静音时各段均为0
+func bucketPeaks(frames []int, buckets int) []int {
+	buckets = min(buckets, len(frames))
+	peaks := make([]int, buckets)
+	loudest := 0
+	for i := range peaks {
+		start := i * len(frames) / buckets
+		end := (i + 1) * len(frames) / buckets
+		for _, peak := range frames[start:end] {
+			peaks[i] = max(peaks[i], peak)
+		}
+		loudest = max(loudest, peaks[i])
+	}
+	if loudest == 0 {
+		return peaks
+	}
+	for i, peak := range peaks {
+		peaks[i] = int(math.Round(float64(peak) * 100 / float64(loudest)))
+	}
+	return peaks
+}
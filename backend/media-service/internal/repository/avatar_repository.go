@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"media-service/internal/models"
+)
+
+// SetUserAvatar 设置用户当前头像，已有头像时替换
+func (r *PostgreSQLMediaRepository) SetUserAvatar(avatar *models.UserAvatar) error {
+	query := `
+		INSERT INTO user_avatars (user_id, media_id, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET media_id = EXCLUDED.media_id, updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.Exec(query, avatar.UserID, avatar.MediaID, avatar.UpdatedAt)
+	return err
+}
+
+// GetUserAvatar 获取用户当前头像
+func (r *PostgreSQLMediaRepository) GetUserAvatar(userID string) (*models.UserAvatar, error) {
+	query := `SELECT user_id, media_id, updated_at FROM user_avatars WHERE user_id = $1`
+
+	avatar := &models.UserAvatar{}
+	err := r.db.QueryRow(query, userID).Scan(&avatar.UserID, &avatar.MediaID, &avatar.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("avatar not found")
+		}
+		return nil, err
+	}
+	return avatar, nil
+}
+
+// DeleteUserAvatar 清除用户头像
+func (r *PostgreSQLMediaRepository) DeleteUserAvatar(userID string) error {
+	_, err := r.db.Exec(`DELETE FROM user_avatars WHERE user_id = $1`, userID)
+	return err
+}
+
+// SetUserAvatar 设置用户当前头像
+func (r *MemoryMediaRepository) SetUserAvatar(avatar *models.UserAvatar) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *avatar
+	r.avatars[avatar.UserID] = &stored
+	return nil
+}
+
+// GetUserAvatar 获取用户当前头像
+func (r *MemoryMediaRepository) GetUserAvatar(userID string) (*models.UserAvatar, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	avatar, exists := r.avatars[userID]
+	if !exists {
+		return nil, fmt.Errorf("avatar not found")
+	}
+	result := *avatar
+	return &result, nil
+}
+
+// DeleteUserAvatar 清除用户头像
+func (r *MemoryMediaRepository) DeleteUserAvatar(userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.avatars, userID)
+	return nil
+}
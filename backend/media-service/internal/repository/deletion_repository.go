@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"media-service/internal/models"
+)
+
+// deletedMediaColumns 查询已删除文件时读取的列，比普通查询多deleted_at
+const deletedMediaColumns = `id, user_id, tenant_id, filename, original_name, mime_type, file_size,
+	media_type, status, storage_path, public_url, thumbnail_url,
+	metadata, created_at, updated_at, expires_at, deleted_at`
+
+func scanDeletedMedia(row interface{ Scan(...interface{}) error }) (*models.Media, error) {
+	media := &models.Media{}
+	var metadataJSON []byte
+
+	err := row.Scan(
+		&media.ID, &media.UserID, &media.TenantID, &media.Filename, &media.OriginalName,
+		&media.MimeType, &media.FileSize, &media.MediaType, &media.Status,
+		&media.StoragePath, &media.PublicURL, &media.ThumbnailURL,
+		&metadataJSON, &media.CreatedAt, &media.UpdatedAt, &media.ExpiresAt, &media.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metadataJSON) > 0 {
+		var metadata models.MediaMetadata
+		if err := json.Unmarshal(metadataJSON, &metadata); err == nil {
+			media.Metadata = &metadata
+		}
+	}
+	return media, nil
+}
+
+// GetDeletedMedia 获取已删除但尚未永久删除的媒体文件
+func (r *PostgreSQLMediaRepository) GetDeletedMedia(id string) (*models.Media, error) {
+	query := `SELECT ` + deletedMediaColumns + ` FROM media_files WHERE id = $1 AND status = 'deleted'`
+	media, err := scanDeletedMedia(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("deleted media not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted media: %w", err)
+	}
+	return media, nil
+}
+
+// RestoreMedia 恢复已删除的媒体文件，恢复后状态为ready
+func (r *PostgreSQLMediaRepository) RestoreMedia(id string) error {
+	query := `UPDATE media_files SET status = 'ready', deleted_at = NULL, updated_at = $1 WHERE id = $2 AND status = 'deleted'`
+	result, err := r.db.Exec(query, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to restore media: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return fmt.Errorf("deleted media not found")
+	}
+	return nil
+}
+
+// GetMediaDeletedBefore 获取在cutoff之前删除的媒体文件，按删除时间排序
+func (r *PostgreSQLMediaRepository) GetMediaDeletedBefore(cutoff time.Time, limit int) ([]*models.Media, error) {
+	query := `SELECT ` + deletedMediaColumns + `
+		FROM media_files
+		WHERE status = 'deleted' AND deleted_at < $1
+		ORDER BY deleted_at
+		LIMIT $2
+	`
+	rows, err := r.db.Query(query, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deleted media: %w", err)
+	}
+	defer rows.Close()
+
+	var medias []*models.Media
+	for rows.Next() {
+		media, err := scanDeletedMedia(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan media: %w", err)
+		}
+		medias = append(medias, media)
+	}
+	return medias, rows.Err()
+}
+
+// PurgeMedia 永久删除已删除的媒体记录，处理任务、头像和共享记录随外键级联删除
+func (r *PostgreSQLMediaRepository) PurgeMedia(id string) error {
+	_, err := r.db.Exec(`DELETE FROM media_files WHERE id = $1 AND status = 'deleted'`, id)
+	if err != nil {
+		return fmt.Errorf("failed to purge media: %w", err)
+	}
+	return nil
+}
+
+// GetDeletedMedia 获取已删除的媒体文件
+func (r *MemoryMediaRepository) GetDeletedMedia(id string) (*models.Media, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	media, exists := r.medias[id]
+	if !exists || media.Status != models.MediaStatusDeleted {
+		return nil, fmt.Errorf("deleted media not found")
+	}
+	return media, nil
+}
+
+// RestoreMedia 恢复已删除的媒体文件
+func (r *MemoryMediaRepository) RestoreMedia(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	media, exists := r.medias[id]
+	if !exists || media.Status != models.MediaStatusDeleted {
+		return fmt.Errorf("deleted media not found")
+	}
+	media.Status = models.MediaStatusReady
+	media.DeletedAt = nil
+	media.UpdatedAt = time.Now()
+	return nil
+}
+
+// GetMediaDeletedBefore 获取在cutoff之前删除的媒体文件
+func (r *MemoryMediaRepository) GetMediaDeletedBefore(cutoff time.Time, limit int) ([]*models.Media, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var medias []*models.Media
+	for _, media := range r.medias {
+		if media.Status == models.MediaStatusDeleted && media.DeletedAt != nil && media.DeletedAt.Before(cutoff) {
+			medias = append(medias, media)
+		}
+	}
+	sort.Slice(medias, func(i, j int) bool { return medias[i].DeletedAt.Before(*medias[j].DeletedAt) })
+	if len(medias) > limit {
+		medias = medias[:limit]
+	}
+	return medias, nil
+}
+
+// PurgeMedia 永久删除已删除的媒体记录及其处理任务、头像和共享记录
+func (r *MemoryMediaRepository) PurgeMedia(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	media, exists := r.medias[id]
+	if !exists || media.Status != models.MediaStatusDeleted {
+		return nil
+	}
+	delete(r.medias, id)
+	delete(r.shares, id)
+	for jobID, job := range r.jobs {
+		if job.MediaID == id {
+			delete(r.jobs, jobID)
+		}
+	}
+	for userID, avatar := range r.avatars {
+		if avatar.MediaID == id {
+			delete(r.avatars, userID)
+		}
+	}
+	return nil
+}
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -18,24 +20,59 @@ type MediaRepository interface {
 	CreateMedia(media *models.Media) error
 	GetMediaByID(id string) (*models.Media, error)
 	GetMediaByUserID(userID string, req *models.MediaListRequest) ([]*models.Media, int, error)
+	GetMediaByStatus(status models.MediaStatus, limit, offset int) ([]*models.Media, int, error)
 	UpdateMedia(id string, updates *models.MediaUpdateRequest) error
 	DeleteMedia(id string) error
 	DeleteExpiredMedia() error
 
+	// 已删除文件的恢复与永久删除
+	GetDeletedMedia(id string) (*models.Media, error)
+	RestoreMedia(id string) error
+	GetMediaDeletedBefore(cutoff time.Time, limit int) ([]*models.Media, error)
+	PurgeMedia(id string) error
+
 	// 处理任务管理
 	CreateProcessingJob(job *models.ProcessingJob) error
 	GetProcessingJob(id string) (*models.ProcessingJob, error)
-	GetPendingJobs(limit int) ([]*models.ProcessingJob, error)
+	GetPendingJobs(jobType string, limit int) ([]*models.ProcessingJob, error)
 	UpdateProcessingJob(id string, status string, result map[string]interface{}, errorMsg *string) error
+	// ClaimProcessingJob 将待处理任务置为处理中并累加执行次数，任务已被其他工作者领取时返回nil
+	ClaimProcessingJob(id string) (*models.ProcessingJob, error)
+	// RetryProcessingJob 将失败的任务放回待处理，nextRunAt之前不会被GetPendingJobs返回
+	RetryProcessingJob(id string, errorMsg string, nextRunAt time.Time) error
 
 	// 存储配额管理
 	GetUserQuota(userID string) (*models.UserStorageQuota, error)
 	UpdateUserQuota(userID string, usedQuota int64, fileCount int) error
 	CreateUserQuota(quota *models.UserStorageQuota) error
 
+	// 租户存储配额管理
+	GetTenantQuota(tenantID string) (*models.TenantStorageQuota, error)
+	CreateTenantQuota(quota *models.TenantStorageQuota) error
+	UpdateTenantQuota(tenantID string, usedQuota int64, fileCount int) error
+	UpdateTenantQuotaLimits(tenantID string, totalQuota int64, maxFileCount int) error
+
+	// 用户头像
+	SetUserAvatar(avatar *models.UserAvatar) error
+	GetUserAvatar(userID string) (*models.UserAvatar, error)
+	DeleteUserAvatar(userID string) error
+
+	// 媒体共享
+	CreateMediaShares(shares []*models.MediaShare) error
+	GetMediaShares(mediaID string) ([]*models.MediaShare, error)
+	DeleteMediaShare(mediaID, shareID string) error
+
+	// 租户文件迁移
+	ListMediaForRehome(tenantID, pathPrefix string, userIDs []string, limit int) ([]*models.Media, error)
+	UpdateMediaLocation(media *models.Media) error
+
+	// 文件地址迁移
+	ListMediaOutsideURLPrefix(urlPrefix string, limit int) ([]*models.Media, error)
+
 	// 统计信息
 	GetStorageStats() (*models.StorageInfo, error)
 	GetUserStorageStats(userID string) (*models.StorageInfo, error)
+	GetTenantStorageStats(tenantID string) (*models.TenantStorageStats, error)
 }
 
 // PostgreSQLMediaRepository PostgreSQL实现
@@ -56,17 +93,20 @@ func NewPostgreSQLMediaRepository(db *sqlx.DB, logger *zap.Logger) MediaReposito
 func (r *PostgreSQLMediaRepository) CreateMedia(media *models.Media) error {
 	query := `
 		INSERT INTO media_files (
-			id, user_id, filename, original_name, mime_type, file_size,
+			id, user_id, tenant_id, filename, original_name, mime_type, file_size,
 			media_type, status, storage_path, public_url, thumbnail_url,
 			metadata, created_at, updated_at, expires_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
 		)`
 
+	if media.TenantID == "" {
+		media.TenantID = models.DefaultTenantID
+	}
 	metadataJSON, _ := json.Marshal(media.Metadata)
 
 	_, err := r.db.Exec(query,
-		media.ID, media.UserID, media.Filename, media.OriginalName,
+		media.ID, media.UserID, media.TenantID, media.Filename, media.OriginalName,
 		media.MimeType, media.FileSize, media.MediaType, media.Status,
 		media.StoragePath, media.PublicURL, media.ThumbnailURL,
 		metadataJSON, media.CreatedAt, media.UpdatedAt, media.ExpiresAt,
@@ -83,7 +123,7 @@ func (r *PostgreSQLMediaRepository) CreateMedia(media *models.Media) error {
 // GetMediaByID 根据ID获取媒体文件
 func (r *PostgreSQLMediaRepository) GetMediaByID(id string) (*models.Media, error) {
 	query := `
-		SELECT id, user_id, filename, original_name, mime_type, file_size,
+		SELECT id, user_id, tenant_id, filename, original_name, mime_type, file_size,
 		       media_type, status, storage_path, public_url, thumbnail_url,
 		       metadata, created_at, updated_at, expires_at
 		FROM media_files
@@ -94,7 +134,7 @@ func (r *PostgreSQLMediaRepository) GetMediaByID(id string) (*models.Media, erro
 	var metadataJSON []byte
 
 	err := r.db.QueryRow(query, id).Scan(
-		&media.ID, &media.UserID, &media.Filename, &media.OriginalName,
+		&media.ID, &media.UserID, &media.TenantID, &media.Filename, &media.OriginalName,
 		&media.MimeType, &media.FileSize, &media.MediaType, &media.Status,
 		&media.StoragePath, &media.PublicURL, &media.ThumbnailURL,
 		&metadataJSON, &media.CreatedAt, &media.UpdatedAt, &media.ExpiresAt,
@@ -156,7 +196,7 @@ func (r *PostgreSQLMediaRepository) GetMediaByUserID(userID string, req *models.
 
 	// 查询数据
 	query := `
-		SELECT id, user_id, filename, original_name, mime_type, file_size,
+		SELECT id, user_id, tenant_id, filename, original_name, mime_type, file_size,
 		       media_type, status, storage_path, public_url, thumbnail_url,
 		       metadata, created_at, updated_at, expires_at
 		FROM media_files
@@ -174,7 +214,59 @@ func (r *PostgreSQLMediaRepository) GetMediaByUserID(userID string, req *models.
 		var metadataJSON []byte
 
 		err := rows.Scan(
-			&media.ID, &media.UserID, &media.Filename, &media.OriginalName,
+			&media.ID, &media.UserID, &media.TenantID, &media.Filename, &media.OriginalName,
+			&media.MimeType, &media.FileSize, &media.MediaType, &media.Status,
+			&media.StoragePath, &media.PublicURL, &media.ThumbnailURL,
+			&metadataJSON, &media.CreatedAt, &media.UpdatedAt, &media.ExpiresAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan media: %w", err)
+		}
+
+		if len(metadataJSON) > 0 {
+			var metadata models.MediaMetadata
+			if err := json.Unmarshal(metadataJSON, &metadata); err == nil {
+				media.Metadata = &metadata
+			}
+		}
+
+		medias = append(medias, media)
+	}
+
+	return medias, total, nil
+}
+
+// GetMediaByStatus 获取所有用户中指定状态的媒体文件
+func (r *PostgreSQLMediaRepository) GetMediaByStatus(status models.MediaStatus, limit, offset int) ([]*models.Media, int, error) {
+	var total int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM media_files WHERE status = $1", status).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count media: %w", err)
+	}
+
+	query := `
+		SELECT id, user_id, tenant_id, filename, original_name, mime_type, file_size,
+		       media_type, status, storage_path, public_url, thumbnail_url,
+		       metadata, created_at, updated_at, expires_at
+		FROM media_files
+		WHERE status = $1
+		ORDER BY updated_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(query, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query media: %w", err)
+	}
+	defer rows.Close()
+
+	var medias []*models.Media
+	for rows.Next() {
+		media := &models.Media{}
+		var metadataJSON []byte
+
+		err := rows.Scan(
+			&media.ID, &media.UserID, &media.TenantID, &media.Filename, &media.OriginalName,
 			&media.MimeType, &media.FileSize, &media.MediaType, &media.Status,
 			&media.StoragePath, &media.PublicURL, &media.ThumbnailURL,
 			&metadataJSON, &media.CreatedAt, &media.UpdatedAt, &media.ExpiresAt,
@@ -227,6 +319,12 @@ func (r *PostgreSQLMediaRepository) UpdateMedia(id string, updates *models.Media
 		argIndex++
 	}
 
+	if updates.ThumbnailURL != nil {
+		setClauses = append(setClauses, fmt.Sprintf("thumbnail_url = $%d", argIndex))
+		args = append(args, *updates.ThumbnailURL)
+		argIndex++
+	}
+
 	if len(setClauses) == 0 {
 		return nil
 	}
@@ -256,7 +354,7 @@ func (r *PostgreSQLMediaRepository) UpdateMedia(id string, updates *models.Media
 
 // DeleteMedia 删除媒体文件（软删除）
 func (r *PostgreSQLMediaRepository) DeleteMedia(id string) error {
-	query := "UPDATE media_files SET status = 'deleted', updated_at = $1 WHERE id = $2"
+	query := "UPDATE media_files SET status = 'deleted', deleted_at = $1, updated_at = $1 WHERE id = $2"
 	_, err := r.db.Exec(query, time.Now(), id)
 	if err != nil {
 		r.logger.Error("Failed to delete media", zap.Error(err), zap.String("media_id", id))
@@ -269,7 +367,7 @@ func (r *PostgreSQLMediaRepository) DeleteMedia(id string) error {
 func (r *PostgreSQLMediaRepository) DeleteExpiredMedia() error {
 	query := `
 		UPDATE media_files 
-		SET status = 'deleted', updated_at = $1 
+		SET status = 'deleted', deleted_at = $1, updated_at = $1
 		WHERE expires_at IS NOT NULL AND expires_at < $1 AND status != 'deleted'
 	`
 	_, err := r.db.Exec(query, time.Now())
@@ -280,38 +378,35 @@ func (r *PostgreSQLMediaRepository) DeleteExpiredMedia() error {
 func (r *PostgreSQLMediaRepository) CreateProcessingJob(job *models.ProcessingJob) error {
 	query := `
 		INSERT INTO processing_jobs (
-			id, media_id, job_type, status, params, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			id, media_id, job_type, status, params, created_at, updated_at, started_at, attempts
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
 	paramsJSON, _ := json.Marshal(job.Params)
 
 	_, err := r.db.Exec(query,
 		job.ID, job.MediaID, job.JobType, job.Status,
-		paramsJSON, job.CreatedAt, job.UpdatedAt,
+		paramsJSON, job.CreatedAt, job.UpdatedAt, job.StartedAt, job.Attempts,
 	)
 
 	return err
 }
 
-// GetProcessingJob 获取处理任务
-func (r *PostgreSQLMediaRepository) GetProcessingJob(id string) (*models.ProcessingJob, error) {
-	query := `
-		SELECT id, media_id, job_type, status, params, result, error,
-		       created_at, updated_at, started_at, completed_at
-		FROM processing_jobs
-		WHERE id = $1
-	`
+// processingJobColumns 处理任务查询列，与scanProcessingJob的顺序一致
+const processingJobColumns = `id, media_id, job_type, status, params, result, error,
+		       created_at, updated_at, started_at, completed_at, attempts, next_run_at`
 
+// scanProcessingJob 扫描一行处理任务
+func scanProcessingJob(row interface{ Scan(...interface{}) error }) (*models.ProcessingJob, error) {
 	job := &models.ProcessingJob{}
 	var paramsJSON, resultJSON []byte
 
-	err := r.db.QueryRow(query, id).Scan(
+	err := row.Scan(
 		&job.ID, &job.MediaID, &job.JobType, &job.Status,
 		&paramsJSON, &resultJSON, &job.Error,
 		&job.CreatedAt, &job.UpdatedAt, &job.StartedAt, &job.CompletedAt,
+		&job.Attempts, &job.NextRunAt,
 	)
-
 	if err != nil {
 		return nil, err
 	}
@@ -327,18 +422,27 @@ func (r *PostgreSQLMediaRepository) GetProcessingJob(id string) (*models.Process
 	return job, nil
 }
 
-// GetPendingJobs 获取待处理任务
-func (r *PostgreSQLMediaRepository) GetPendingJobs(limit int) ([]*models.ProcessingJob, error) {
-	query := `
-		SELECT id, media_id, job_type, status, params, result, error,
-		       created_at, updated_at, started_at, completed_at
+// GetProcessingJob 获取处理任务
+func (r *PostgreSQLMediaRepository) GetProcessingJob(id string) (*models.ProcessingJob, error) {
+	query := `SELECT ` + processingJobColumns + `
+		FROM processing_jobs
+		WHERE id = $1
+	`
+
+	return scanProcessingJob(r.db.QueryRow(query, id))
+}
+
+// GetPendingJobs 获取已到执行时间的待处理任务
+func (r *PostgreSQLMediaRepository) GetPendingJobs(jobType string, limit int) ([]*models.ProcessingJob, error) {
+	query := `SELECT ` + processingJobColumns + `
 		FROM processing_jobs
-		WHERE status = 'pending'
+		WHERE status = 'pending' AND job_type = $1
+		  AND (next_run_at IS NULL OR next_run_at <= $2)
 		ORDER BY created_at ASC
-		LIMIT $1
+		LIMIT $3
 	`
 
-	rows, err := r.db.Query(query, limit)
+	rows, err := r.db.Query(query, jobType, time.Now(), limit)
 	if err != nil {
 		return nil, err
 	}
@@ -346,30 +450,42 @@ func (r *PostgreSQLMediaRepository) GetPendingJobs(limit int) ([]*models.Process
 
 	var jobs []*models.ProcessingJob
 	for rows.Next() {
-		job := &models.ProcessingJob{}
-		var paramsJSON, resultJSON []byte
-
-		err := rows.Scan(
-			&job.ID, &job.MediaID, &job.JobType, &job.Status,
-			&paramsJSON, &resultJSON, &job.Error,
-			&job.CreatedAt, &job.UpdatedAt, &job.StartedAt, &job.CompletedAt,
-		)
+		job, err := scanProcessingJob(rows)
 		if err != nil {
 			return nil, err
 		}
+		jobs = append(jobs, job)
+	}
 
-		if len(paramsJSON) > 0 {
-			json.Unmarshal(paramsJSON, &job.Params)
-		}
+	return jobs, rows.Err()
+}
 
-		if len(resultJSON) > 0 {
-			json.Unmarshal(resultJSON, &job.Result)
-		}
+// ClaimProcessingJob 领取待处理任务，条件更新保证多个实例不会重复执行
+func (r *PostgreSQLMediaRepository) ClaimProcessingJob(id string) (*models.ProcessingJob, error) {
+	query := `
+		UPDATE processing_jobs
+		SET status = 'processing', attempts = attempts + 1, next_run_at = NULL,
+		    started_at = $1, updated_at = $1
+		WHERE id = $2 AND status = 'pending'
+		RETURNING ` + processingJobColumns
 
-		jobs = append(jobs, job)
+	job, err := scanProcessingJob(r.db.QueryRow(query, time.Now(), id))
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
+	return job, err
+}
 
-	return jobs, nil
+// RetryProcessingJob 记录失败原因并将任务放回待处理
+func (r *PostgreSQLMediaRepository) RetryProcessingJob(id string, errorMsg string, nextRunAt time.Time) error {
+	query := `
+		UPDATE processing_jobs
+		SET status = 'pending', error = $1, next_run_at = $2, updated_at = $3
+		WHERE id = $4
+	`
+
+	_, err := r.db.Exec(query, errorMsg, nextRunAt, time.Now(), id)
+	return err
 }
 
 // UpdateProcessingJob 更新处理任务
@@ -500,6 +616,9 @@ type MemoryMediaRepository struct {
 	medias         map[string]*models.Media
 	jobs           map[string]*models.ProcessingJob
 	quotas         map[string]*models.UserStorageQuota
+	tenantQuotas   map[string]*models.TenantStorageQuota
+	avatars        map[string]*models.UserAvatar
+	shares         map[string][]*models.MediaShare
 	mutex          sync.RWMutex
 	logger         *zap.Logger
 }
@@ -507,10 +626,13 @@ type MemoryMediaRepository struct {
 // NewMemoryMediaRepository 创建内存媒体仓库
 func NewMemoryMediaRepository(logger *zap.Logger) MediaRepository {
 	return &MemoryMediaRepository{
-		medias: make(map[string]*models.Media),
-		jobs:   make(map[string]*models.ProcessingJob),
-		quotas: make(map[string]*models.UserStorageQuota),
-		logger: logger,
+		medias:       make(map[string]*models.Media),
+		jobs:         make(map[string]*models.ProcessingJob),
+		quotas:       make(map[string]*models.UserStorageQuota),
+		tenantQuotas: make(map[string]*models.TenantStorageQuota),
+		avatars:      make(map[string]*models.UserAvatar),
+		shares:       make(map[string][]*models.MediaShare),
+		logger:       logger,
 	}
 }
 
@@ -519,6 +641,10 @@ func (r *MemoryMediaRepository) CreateMedia(media *models.Media) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	if media.TenantID == "" {
+		media.TenantID = models.DefaultTenantID
+	}
+
 	r.medias[media.ID] = media
 	return nil
 }
@@ -570,6 +696,36 @@ func (r *MemoryMediaRepository) GetMediaByUserID(userID string, req *models.Medi
 	return result, total, nil
 }
 
+// GetMediaByStatus 获取所有用户中指定状态的媒体文件
+func (r *MemoryMediaRepository) GetMediaByStatus(status models.MediaStatus, limit, offset int) ([]*models.Media, int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var allMedias []*models.Media
+	for _, media := range r.medias {
+		if media.Status == status {
+			allMedias = append(allMedias, media)
+		}
+	}
+	sort.Slice(allMedias, func(i, j int) bool {
+		return allMedias[i].UpdatedAt.After(allMedias[j].UpdatedAt)
+	})
+
+	total := len(allMedias)
+
+	// 简单分页
+	start := offset
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	return allMedias[start:end], total, nil
+}
+
 // UpdateMedia 更新媒体文件
 func (r *MemoryMediaRepository) UpdateMedia(id string, updates *models.MediaUpdateRequest) error {
 	r.mutex.Lock()
@@ -592,6 +748,9 @@ func (r *MemoryMediaRepository) UpdateMedia(id string, updates *models.MediaUpda
 	if updates.ExpiresAt != nil {
 		media.ExpiresAt = updates.ExpiresAt
 	}
+	if updates.ThumbnailURL != nil {
+		media.ThumbnailURL = updates.ThumbnailURL
+	}
 
 	media.UpdatedAt = time.Now()
 	return nil
@@ -607,8 +766,10 @@ func (r *MemoryMediaRepository) DeleteMedia(id string) error {
 		return fmt.Errorf("media not found")
 	}
 
+	now := time.Now()
 	media.Status = models.MediaStatusDeleted
-	media.UpdatedAt = time.Now()
+	media.DeletedAt = &now
+	media.UpdatedAt = now
 	return nil
 }
 
@@ -621,6 +782,7 @@ func (r *MemoryMediaRepository) DeleteExpiredMedia() error {
 	for _, media := range r.medias {
 		if media.ExpiresAt != nil && now.After(*media.ExpiresAt) && media.Status != models.MediaStatusDeleted {
 			media.Status = models.MediaStatusDeleted
+			media.DeletedAt = &now
 			media.UpdatedAt = now
 		}
 	}
@@ -650,24 +812,74 @@ func (r *MemoryMediaRepository) GetProcessingJob(id string) (*models.ProcessingJ
 	return job, nil
 }
 
-// GetPendingJobs 获取待处理任务
-func (r *MemoryMediaRepository) GetPendingJobs(limit int) ([]*models.ProcessingJob, error) {
+// GetPendingJobs 获取已到执行时间的待处理任务
+func (r *MemoryMediaRepository) GetPendingJobs(jobType string, limit int) ([]*models.ProcessingJob, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
+	now := time.Now()
 	var pendingJobs []*models.ProcessingJob
 	for _, job := range r.jobs {
-		if job.Status == "pending" {
-			pendingJobs = append(pendingJobs, job)
-			if len(pendingJobs) >= limit {
-				break
-			}
+		if job.Status != "pending" || job.JobType != jobType {
+			continue
 		}
+		if job.NextRunAt != nil && job.NextRunAt.After(now) {
+			continue
+		}
+		pendingJobs = append(pendingJobs, job)
+	}
+
+	sort.Slice(pendingJobs, func(i, j int) bool {
+		return pendingJobs[i].CreatedAt.Before(pendingJobs[j].CreatedAt)
+	})
+	if len(pendingJobs) > limit {
+		pendingJobs = pendingJobs[:limit]
 	}
 
 	return pendingJobs, nil
 }
 
+// ClaimProcessingJob 领取待处理任务，返回任务副本
+func (r *MemoryMediaRepository) ClaimProcessingJob(id string) (*models.ProcessingJob, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	job, exists := r.jobs[id]
+	if !exists {
+		return nil, fmt.Errorf("job not found")
+	}
+	if job.Status != "pending" {
+		return nil, nil
+	}
+
+	now := time.Now()
+	job.Status = "processing"
+	job.Attempts++
+	job.NextRunAt = nil
+	job.StartedAt = &now
+	job.UpdatedAt = now
+
+	claimed := *job
+	return &claimed, nil
+}
+
+// RetryProcessingJob 记录失败原因并将任务放回待处理
+func (r *MemoryMediaRepository) RetryProcessingJob(id string, errorMsg string, nextRunAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	job, exists := r.jobs[id]
+	if !exists {
+		return fmt.Errorf("job not found")
+	}
+
+	job.Status = "pending"
+	job.Error = &errorMsg
+	job.NextRunAt = &nextRunAt
+	job.UpdatedAt = time.Now()
+	return nil
+}
+
 // UpdateProcessingJob 更新处理任务
 func (r *MemoryMediaRepository) UpdateProcessingJob(id string, status string, result map[string]interface{}, errorMsg *string) error {
 	r.mutex.Lock()
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"media-service/internal/models"
+)
+
+// CreateMediaShares 保存共享记录，已存在的会话或用户共享保持不变
+func (r *PostgreSQLMediaRepository) CreateMediaShares(shares []*models.MediaShare) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO media_shares (id, media_id, conversation_id, user_id, created_by, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6)
+		ON CONFLICT DO NOTHING
+	`
+	for _, share := range shares {
+		if _, err := tx.Exec(query, share.ID, share.MediaID, share.ConversationID, share.UserID, share.CreatedBy, share.CreatedAt); err != nil {
+			return fmt.Errorf("failed to create media share: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetMediaShares 获取媒体文件的所有共享记录，按创建时间排序
+func (r *PostgreSQLMediaRepository) GetMediaShares(mediaID string) ([]*models.MediaShare, error) {
+	query := `
+		SELECT id, media_id, conversation_id, user_id, created_by, created_at
+		FROM media_shares
+		WHERE media_id = $1
+		ORDER BY created_at, id
+	`
+	rows, err := r.db.Query(query, mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media shares: %w", err)
+	}
+	defer rows.Close()
+
+	var shares []*models.MediaShare
+	for rows.Next() {
+		share := &models.MediaShare{}
+		var conversationID, userID sql.NullString
+		if err := rows.Scan(&share.ID, &share.MediaID, &conversationID, &userID, &share.CreatedBy, &share.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan media share: %w", err)
+		}
+		share.ConversationID = conversationID.String
+		share.UserID = userID.String
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+// DeleteMediaShare 撤销共享
+func (r *PostgreSQLMediaRepository) DeleteMediaShare(mediaID, shareID string) error {
+	result, err := r.db.Exec(`DELETE FROM media_shares WHERE media_id = $1 AND id = $2`, mediaID, shareID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("share not found")
+	}
+	return nil
+}
+
+// CreateMediaShares 保存共享记录
+func (r *MemoryMediaRepository) CreateMediaShares(shares []*models.MediaShare) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, share := range shares {
+		duplicate := false
+		for _, existing := range r.shares[share.MediaID] {
+			if existing.ConversationID == share.ConversationID && existing.UserID == share.UserID {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			stored := *share
+			r.shares[share.MediaID] = append(r.shares[share.MediaID], &stored)
+		}
+	}
+	return nil
+}
+
+// GetMediaShares 获取媒体文件的所有共享记录
+func (r *MemoryMediaRepository) GetMediaShares(mediaID string) ([]*models.MediaShare, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	shares := make([]*models.MediaShare, 0, len(r.shares[mediaID]))
+	for _, share := range r.shares[mediaID] {
+		result := *share
+		shares = append(shares, &result)
+	}
+	return shares, nil
+}
+
+// DeleteMediaShare 撤销共享
+func (r *MemoryMediaRepository) DeleteMediaShare(mediaID, shareID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	shares := r.shares[mediaID]
+	for i, share := range shares {
+		if share.ID == shareID {
+			r.shares[mediaID] = append(shares[:i], shares[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("share not found")
+}
@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"media-service/internal/models"
+)
+
+// GetTenantQuota 获取租户存储配额
+func (r *PostgreSQLMediaRepository) GetTenantQuota(tenantID string) (*models.TenantStorageQuota, error) {
+	query := `
+		SELECT tenant_id, total_quota, used_quota, file_count, max_file_count, created_at, updated_at
+		FROM tenant_storage_quotas
+		WHERE tenant_id = $1
+	`
+
+	quota := &models.TenantStorageQuota{}
+	err := r.db.QueryRow(query, tenantID).Scan(
+		&quota.TenantID, &quota.TotalQuota, &quota.UsedQuota, &quota.FileCount,
+		&quota.MaxFileCount, &quota.CreatedAt, &quota.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("tenant quota not found")
+		}
+		return nil, err
+	}
+
+	return quota, nil
+}
+
+// CreateTenantQuota 创建租户存储配额
+func (r *PostgreSQLMediaRepository) CreateTenantQuota(quota *models.TenantStorageQuota) error {
+	query := `
+		INSERT INTO tenant_storage_quotas (
+			tenant_id, total_quota, used_quota, file_count, max_file_count, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tenant_id) DO NOTHING
+	`
+
+	_, err := r.db.Exec(query,
+		quota.TenantID, quota.TotalQuota, quota.UsedQuota, quota.FileCount,
+		quota.MaxFileCount, quota.CreatedAt, quota.UpdatedAt,
+	)
+	return err
+}
+
+// UpdateTenantQuota 更新租户已用配额
+func (r *PostgreSQLMediaRepository) UpdateTenantQuota(tenantID string, usedQuota int64, fileCount int) error {
+	query := `
+		UPDATE tenant_storage_quotas
+		SET used_quota = $1, file_count = $2, updated_at = $3
+		WHERE tenant_id = $4
+	`
+	_, err := r.db.Exec(query, usedQuota, fileCount, time.Now(), tenantID)
+	return err
+}
+
+// UpdateTenantQuotaLimits 更新租户配额上限
+func (r *PostgreSQLMediaRepository) UpdateTenantQuotaLimits(tenantID string, totalQuota int64, maxFileCount int) error {
+	query := `
+		UPDATE tenant_storage_quotas
+		SET total_quota = $1, max_file_count = $2, updated_at = $3
+		WHERE tenant_id = $4
+	`
+	_, err := r.db.Exec(query, totalQuota, maxFileCount, time.Now(), tenantID)
+	return err
+}
+
+// ListMediaForRehome 获取需要迁移到租户存储位置的媒体文件
+// userIDs不为空时返回这些用户在其他租户下的文件，否则返回租户中存储路径不在pathPrefix下的文件
+func (r *PostgreSQLMediaRepository) ListMediaForRehome(tenantID, pathPrefix string, userIDs []string, limit int) ([]*models.Media, error) {
+	var (
+		where string
+		args  []interface{}
+	)
+	if len(userIDs) > 0 {
+		where = "user_id = ANY($1) AND tenant_id != $2"
+		args = []interface{}{pq.Array(userIDs), tenantID}
+	} else {
+		where = "tenant_id = $1 AND storage_path NOT LIKE $2"
+		args = []interface{}{tenantID, escapeLike(pathPrefix) + "%"}
+	}
+
+	query := `
+		SELECT id, user_id, tenant_id, filename, original_name, mime_type, file_size,
+		       media_type, status, storage_path, public_url, thumbnail_url,
+		       metadata, created_at, updated_at, expires_at
+		FROM media_files
+		WHERE status != 'deleted' AND ` + where + `
+		ORDER BY created_at
+		LIMIT ` + fmt.Sprintf("$%d", len(args)+1)
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query media: %w", err)
+	}
+	defer rows.Close()
+
+	var medias []*models.Media
+	for rows.Next() {
+		media := &models.Media{}
+		var metadataJSON []byte
+
+		err := rows.Scan(
+			&media.ID, &media.UserID, &media.TenantID, &media.Filename, &media.OriginalName,
+			&media.MimeType, &media.FileSize, &media.MediaType, &media.Status,
+			&media.StoragePath, &media.PublicURL, &media.ThumbnailURL,
+			&metadataJSON, &media.CreatedAt, &media.UpdatedAt, &media.ExpiresAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan media: %w", err)
+		}
+
+		if len(metadataJSON) > 0 {
+			var metadata models.MediaMetadata
+			if err := json.Unmarshal(metadataJSON, &metadata); err == nil {
+				media.Metadata = &metadata
+			}
+		}
+
+		medias = append(medias, media)
+	}
+
+	return medias, rows.Err()
+}
+
+// UpdateMediaLocation 更新媒体文件的所属租户和存储位置
+func (r *PostgreSQLMediaRepository) UpdateMediaLocation(media *models.Media) error {
+	query := `
+		UPDATE media_files
+		SET tenant_id = $1, storage_path = $2, public_url = $3, thumbnail_url = $4, metadata = $5, updated_at = $6
+		WHERE id = $7
+	`
+
+	metadataJSON, _ := json.Marshal(media.Metadata)
+	media.UpdatedAt = time.Now()
+
+	_, err := r.db.Exec(query,
+		media.TenantID, media.StoragePath, media.PublicURL, media.ThumbnailURL,
+		metadataJSON, media.UpdatedAt, media.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update media location: %w", err)
+	}
+	return nil
+}
+
+// ListMediaOutsideURLPrefix 获取文件地址不在urlPrefix下的媒体文件，包括已删除但仍在保留期内的文件
+func (r *PostgreSQLMediaRepository) ListMediaOutsideURLPrefix(urlPrefix string, limit int) ([]*models.Media, error) {
+	query := `
+		SELECT id, user_id, tenant_id, filename, original_name, mime_type, file_size,
+		       media_type, status, storage_path, public_url, thumbnail_url,
+		       metadata, created_at, updated_at, expires_at
+		FROM media_files
+		WHERE public_url != '' AND public_url NOT LIKE $1
+		ORDER BY created_at
+		LIMIT $2`
+
+	rows, err := r.db.Query(query, escapeLike(urlPrefix)+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query media: %w", err)
+	}
+	defer rows.Close()
+
+	var medias []*models.Media
+	for rows.Next() {
+		media := &models.Media{}
+		var metadataJSON []byte
+
+		err := rows.Scan(
+			&media.ID, &media.UserID, &media.TenantID, &media.Filename, &media.OriginalName,
+			&media.MimeType, &media.FileSize, &media.MediaType, &media.Status,
+			&media.StoragePath, &media.PublicURL, &media.ThumbnailURL,
+			&metadataJSON, &media.CreatedAt, &media.UpdatedAt, &media.ExpiresAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan media: %w", err)
+		}
+
+		if len(metadataJSON) > 0 {
+			var metadata models.MediaMetadata
+			if err := json.Unmarshal(metadataJSON, &metadata); err == nil {
+				media.Metadata = &metadata
+			}
+		}
+
+		medias = append(medias, media)
+	}
+
+	return medias, rows.Err()
+}
+
+// GetTenantStorageStats 获取租户存储统计信息，TotalSize取自租户配额
+func (r *PostgreSQLMediaRepository) GetTenantStorageStats(tenantID string) (*models.TenantStorageStats, error) {
+	query := `
+		SELECT
+			COALESCE(SUM(file_size), 0) as used_size,
+			COUNT(*) as file_count,
+			COUNT(DISTINCT user_id) as user_count
+		FROM media_files
+		WHERE tenant_id = $1 AND status != 'deleted'
+	`
+
+	stats := &models.TenantStorageStats{TenantID: tenantID}
+	err := r.db.QueryRow(query, tenantID).Scan(&stats.UsedSize, &stats.FileCount, &stats.UserCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if quota, err := r.GetTenantQuota(tenantID); err == nil {
+		stats.TotalSize = quota.TotalQuota
+		stats.AvailableSize = quota.TotalQuota - stats.UsedSize
+	}
+
+	return stats, nil
+}
+
+// escapeLike 转义LIKE模式中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// GetTenantQuota 获取租户存储配额
+func (r *MemoryMediaRepository) GetTenantQuota(tenantID string) (*models.TenantStorageQuota, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	quota, exists := r.tenantQuotas[tenantID]
+	if !exists {
+		return nil, fmt.Errorf("tenant quota not found")
+	}
+
+	return quota, nil
+}
+
+// CreateTenantQuota 创建租户存储配额
+func (r *MemoryMediaRepository) CreateTenantQuota(quota *models.TenantStorageQuota) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.tenantQuotas[quota.TenantID]; exists {
+		return nil // 已存在，忽略
+	}
+
+	r.tenantQuotas[quota.TenantID] = quota
+	return nil
+}
+
+// UpdateTenantQuota 更新租户已用配额
+func (r *MemoryMediaRepository) UpdateTenantQuota(tenantID string, usedQuota int64, fileCount int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	quota, exists := r.tenantQuotas[tenantID]
+	if !exists {
+		return fmt.Errorf("tenant quota not found")
+	}
+
+	quota.UsedQuota = usedQuota
+	quota.FileCount = fileCount
+	quota.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateTenantQuotaLimits 更新租户配额上限
+func (r *MemoryMediaRepository) UpdateTenantQuotaLimits(tenantID string, totalQuota int64, maxFileCount int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	quota, exists := r.tenantQuotas[tenantID]
+	if !exists {
+		return fmt.Errorf("tenant quota not found")
+	}
+
+	quota.TotalQuota = totalQuota
+	quota.MaxFileCount = maxFileCount
+	quota.UpdatedAt = time.Now()
+	return nil
+}
+
+// ListMediaForRehome 获取需要迁移到租户存储位置的媒体文件
+func (r *MemoryMediaRepository) ListMediaForRehome(tenantID, pathPrefix string, userIDs []string, limit int) ([]*models.Media, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	users := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		users[userID] = true
+	}
+
+	var medias []*models.Media
+	for _, media := range r.medias {
+		if media.Status == models.MediaStatusDeleted {
+			continue
+		}
+		if len(users) > 0 {
+			if users[media.UserID] && media.TenantID != tenantID {
+				medias = append(medias, media)
+			}
+		} else if media.TenantID == tenantID && !strings.HasPrefix(media.StoragePath, pathPrefix) {
+			medias = append(medias, media)
+		}
+	}
+	sort.Slice(medias, func(i, j int) bool {
+		return medias[i].CreatedAt.Before(medias[j].CreatedAt)
+	})
+
+	if len(medias) > limit {
+		medias = medias[:limit]
+	}
+	return medias, nil
+}
+
+// UpdateMediaLocation 更新媒体文件的所属租户和存储位置
+func (r *MemoryMediaRepository) UpdateMediaLocation(media *models.Media) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.medias[media.ID]
+	if !exists {
+		return fmt.Errorf("media not found")
+	}
+
+	existing.TenantID = media.TenantID
+	existing.StoragePath = media.StoragePath
+	existing.PublicURL = media.PublicURL
+	existing.ThumbnailURL = media.ThumbnailURL
+	existing.Metadata = media.Metadata
+	existing.UpdatedAt = time.Now()
+	return nil
+}
+
+// ListMediaOutsideURLPrefix 获取文件地址不在urlPrefix下的媒体文件
+func (r *MemoryMediaRepository) ListMediaOutsideURLPrefix(urlPrefix string, limit int) ([]*models.Media, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var medias []*models.Media
+	for _, media := range r.medias {
+		if media.PublicURL != "" && !strings.HasPrefix(media.PublicURL, urlPrefix) {
+			medias = append(medias, media)
+		}
+	}
+	sort.Slice(medias, func(i, j int) bool {
+		return medias[i].CreatedAt.Before(medias[j].CreatedAt)
+	})
+
+	if len(medias) > limit {
+		medias = medias[:limit]
+	}
+	return medias, nil
+}
+
+// GetTenantStorageStats 获取租户存储统计信息
+func (r *MemoryMediaRepository) GetTenantStorageStats(tenantID string) (*models.TenantStorageStats, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stats := &models.TenantStorageStats{TenantID: tenantID}
+	users := make(map[string]bool)
+	for _, media := range r.medias {
+		if media.TenantID == tenantID && media.Status != models.MediaStatusDeleted {
+			stats.UsedSize += media.FileSize
+			stats.FileCount++
+			users[media.UserID] = true
+		}
+	}
+	stats.UserCount = len(users)
+
+	if quota, exists := r.tenantQuotas[tenantID]; exists {
+		stats.TotalSize = quota.TotalQuota
+		stats.AvailableSize = quota.TotalQuota - stats.UsedSize
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,126 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/config"
+	"media-service/internal/models"
+)
+
+// Scanner 文件安全扫描钩子，上传时在写入存储前调用
+type Scanner interface {
+	Scan(file io.Reader) (*models.ScanResult, error)
+}
+
+// NewScanner 根据配置创建扫描器
+func NewScanner(cfg *config.Config, logger *zap.Logger) (Scanner, error) {
+	switch strings.ToLower(cfg.Scan.Provider) {
+	case "", "none":
+		return &NoopScanner{}, nil
+	case "clamav":
+		return NewClamAVScanner(cfg.Scan.ClamAVAddress, time.Duration(cfg.Scan.TimeoutSeconds)*time.Second, logger), nil
+	default:
+		return nil, fmt.Errorf("unsupported scan provider: %s", cfg.Scan.Provider)
+	}
+}
+
+// NoopScanner 不做任何扫描，所有文件视为安全
+type NoopScanner struct{}
+
+// Scan 返回空结果表示未扫描
+func (s *NoopScanner) Scan(file io.Reader) (*models.ScanResult, error) {
+	return nil, nil
+}
+
+// clamAVChunkSize INSTREAM 每个数据块的大小
+const clamAVChunkSize = 64 * 1024
+
+// ClamAVScanner 通过clamd的INSTREAM协议扫描文件
+type ClamAVScanner struct {
+	address string
+	timeout time.Duration
+	logger  *zap.Logger
+}
+
+// NewClamAVScanner 创建ClamAV扫描器
+func NewClamAVScanner(address string, timeout time.Duration, logger *zap.Logger) *ClamAVScanner {
+	return &ClamAVScanner{
+		address: address,
+		timeout: timeout,
+		logger:  logger,
+	}
+}
+
+// Scan 将文件流式发送给clamd并解析扫描结果
+func (s *ClamAVScanner) Scan(file io.Reader) (*models.ScanResult, error) {
+	conn, err := net.DialTimeout("tcp", s.address, s.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.timeout))
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("failed to start clamd stream: %w", err)
+	}
+
+	buf := make([]byte, clamAVChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := file.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return nil, fmt.Errorf("failed to stream to clamd: %w", err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return nil, fmt.Errorf("failed to stream to clamd: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read file: %w", readErr)
+		}
+	}
+
+	// 长度为0的数据块表示流结束
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return nil, fmt.Errorf("failed to finish clamd stream: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamAVReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseClamAVReply 解析形如 "stream: OK" 或 "stream: Eicar-Signature FOUND" 的响应
+func parseClamAVReply(reply string) (*models.ScanResult, error) {
+	result := &models.ScanResult{
+		Engine:    "clamav",
+		ScannedAt: time.Now(),
+	}
+
+	status := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case status == "OK":
+		result.Verdict = models.ScanVerdictClean
+	case strings.HasSuffix(status, "FOUND"):
+		result.Verdict = models.ScanVerdictInfected
+		result.Signature = strings.TrimSpace(strings.TrimSuffix(status, "FOUND"))
+	default:
+		return nil, fmt.Errorf("unexpected clamd reply: %s", reply)
+	}
+	return result, nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+)
+
+// waveformTimeout 单个波形提取任务的最长执行时间
+const waveformTimeout = 2 * time.Minute
+
+// processAudio 依次提取波形和转写语音，两者都会改写元数据，在同一任务中执行避免互相覆盖
+// 波形提取很快，先执行使客户端尽早拿到波形
+func (s *mediaService) processAudio(mediaID, storageKey string) {
+	if s.config.Audio.WaveformEnabled {
+		s.extractWaveformAsync(mediaID, storageKey)
+	}
+	if s.transcriber != nil {
+		s.transcribeAudioAsync(mediaID, storageKey)
+	}
+}
+
+// extractWaveformAsync 异步提取音频波形和时长，写入媒体元数据
+func (s *mediaService) extractWaveformAsync(mediaID, storageKey string) {
+	params := map[string]interface{}{
+		"buckets": s.config.Audio.WaveformBuckets,
+	}
+
+	job, err := s.startProcessingJob(mediaID, models.JobTypeWaveform, params)
+	if err != nil {
+		s.logger.Error("Failed to create waveform job", zap.String("media_id", mediaID), zap.Error(err))
+		return
+	}
+
+	result, err := s.runWaveformJob(mediaID, storageKey)
+	if err != nil {
+		errMsg := err.Error()
+		s.repo.UpdateProcessingJob(job.ID, "failed", nil, &errMsg)
+		s.logger.Error("Waveform extraction failed",
+			zap.String("media_id", mediaID),
+			zap.String("job_id", job.ID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	s.repo.UpdateProcessingJob(job.ID, "completed", result, nil)
+	s.logger.Info("Waveform extracted", zap.String("media_id", mediaID), zap.String("job_id", job.ID))
+}
+
+// runWaveformJob 下载音频到临时目录解码，波形和时长写入媒体元数据的waveform、duration
+func (s *mediaService) runWaveformJob(mediaID, storageKey string) (map[string]interface{}, error) {
+	tmpDir, err := os.MkdirTemp("", "media-waveform-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inputPath := filepath.Join(tmpDir, "input"+filepath.Ext(storageKey))
+	if err := s.downloadToFile(storageKey, inputPath); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), waveformTimeout)
+	defer cancel()
+
+	waveform, err := s.waveform.Extract(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	media, err := s.repo.GetMediaByID(mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	metadata := media.Metadata
+	if metadata == nil {
+		metadata = &models.MediaMetadata{}
+	}
+	metadata.Duration = &waveform.Duration
+	metadata.Waveform = waveform.Peaks
+
+	if err := s.repo.UpdateMedia(mediaID, &models.MediaUpdateRequest{Metadata: metadata}); err != nil {
+		return nil, fmt.Errorf("failed to update media metadata: %w", err)
+	}
+
+	return map[string]interface{}{
+		"duration": waveform.Duration,
+		"buckets":  len(waveform.Peaks),
+	}, nil
+}
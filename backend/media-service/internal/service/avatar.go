@@ -0,0 +1,155 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"media-service/internal/models"
+)
+
+// SetAvatar 把用户自己上传的图片设为头像
+func (s *mediaService) SetAvatar(userID, mediaID string) (*models.UserAvatar, error) {
+	media, err := s.getOwnedMedia(userID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if media.MediaType != models.MediaTypeImage {
+		return nil, fmt.Errorf("avatar must be an image")
+	}
+	if media.Metadata != nil && media.Metadata.Encryption != nil {
+		return nil, fmt.Errorf("encrypted media cannot be used as avatar")
+	}
+	if !avatarAvailable(media) {
+		return nil, fmt.Errorf("media is not available")
+	}
+
+	avatar := &models.UserAvatar{UserID: userID, MediaID: mediaID, UpdatedAt: time.Now()}
+	if err := s.repo.SetUserAvatar(avatar); err != nil {
+		return nil, fmt.Errorf("failed to set avatar: %w", err)
+	}
+	return avatar, nil
+}
+
+// ClearAvatar 清除用户头像
+func (s *mediaService) ClearAvatar(userID string) error {
+	if err := s.repo.DeleteUserAvatar(userID); err != nil {
+		return fmt.Errorf("failed to clear avatar: %w", err)
+	}
+	return nil
+}
+
+// AvatarURL 生成用户头像的签名链接，size按缩略图预设向上取整，0表示原图
+// 过期时间对齐到有效期的整数倍，同一时间段内生成的链接相同
+func (s *mediaService) AvatarURL(userID string, size int) *models.AvatarURL {
+	size = s.avatarPreset(size)
+	ttl := s.avatarURLTTL()
+	expiresAt := time.Now().Truncate(ttl).Add(2 * ttl)
+
+	query := url.Values{}
+	query.Set("size", strconv.Itoa(size))
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("sig", s.signAvatar(userID, size, expiresAt.Unix()))
+
+	return &models.AvatarURL{
+		URL:       "/api/v1/media/avatar/" + url.PathEscape(userID) + "?" + query.Encode(),
+		Size:      size,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// VerifyAvatarURL 校验头像链接的签名和有效期
+func (s *mediaService) VerifyAvatarURL(userID string, size int, expires int64, signature string) bool {
+	if time.Now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.signAvatar(userID, size, expires)))
+}
+
+// ResolveAvatar 解析用户当前头像对应的文件，有该尺寸的缩略图时使用缩略图，否则使用原图
+func (s *mediaService) ResolveAvatar(userID string, size int) (*models.AvatarContent, error) {
+	avatar, err := s.repo.GetUserAvatar(userID)
+	if err != nil {
+		return nil, err
+	}
+	media, err := s.repo.GetMediaByID(avatar.MediaID)
+	if err != nil || media.UserID != userID || !avatarAvailable(media) {
+		return nil, fmt.Errorf("avatar not found")
+	}
+
+	content := &models.AvatarContent{
+		MediaID:     media.ID,
+		StorageKey:  s.storageKey(media),
+		ContentType: media.MimeType,
+		UpdatedAt:   avatar.UpdatedAt,
+		MaxAge:      s.config.Avatar.CacheSeconds,
+	}
+	preset := 0
+	if size > 0 && media.Metadata != nil {
+		if variant, ok := media.Metadata.Variants[strconv.Itoa(size)]; ok {
+			preset = size
+			content.StorageKey = s.getVariantKey(content.StorageKey, size)
+			content.ContentType = variant.MimeType
+		}
+	}
+	content.ETag = fmt.Sprintf(`"%s-%d"`, media.ID, preset)
+	return content, nil
+}
+
+// OpenAvatar 读取头像文件
+func (s *mediaService) OpenAvatar(content *models.AvatarContent) (io.ReadCloser, error) {
+	reader, err := s.storageProvider.DownloadFile(content.StorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read avatar: %w", err)
+	}
+	return reader, nil
+}
+
+// avatarPreset 把请求的尺寸向上取整到缩略图预设，超过最大预设时使用最大预设
+func (s *mediaService) avatarPreset(size int) int {
+	presets := append([]int(nil), s.config.Image.ThumbnailPresets...)
+	if size <= 0 || len(presets) == 0 {
+		return 0
+	}
+	sort.Ints(presets)
+	for _, preset := range presets {
+		if preset >= size {
+			return preset
+		}
+	}
+	return presets[len(presets)-1]
+}
+
+// avatarURLTTL 头像链接的有效期
+func (s *mediaService) avatarURLTTL() time.Duration {
+	if s.config.Avatar.URLTTLMinutes <= 0 {
+		return time.Hour
+	}
+	return time.Duration(s.config.Avatar.URLTTLMinutes) * time.Minute
+}
+
+// signAvatar 计算头像链接签名，未配置头像签名密钥时使用JWT密钥
+func (s *mediaService) signAvatar(userID string, size int, expires int64) string {
+	key := s.config.Avatar.SigningKey
+	if key == "" {
+		key = s.config.JWT.SecretKey
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	fmt.Fprintf(mac, "%s\n%d\n%d", userID, size, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// avatarAvailable 媒体文件是否可以作为头像展示
+func avatarAvailable(media *models.Media) bool {
+	switch media.Status {
+	case models.MediaStatusDeleted, models.MediaStatusQuarantined, models.MediaStatusFailed:
+		return false
+	}
+	return media.MediaType == models.MediaTypeImage && (media.Metadata == nil || media.Metadata.Encryption == nil)
+}
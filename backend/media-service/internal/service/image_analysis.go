@@ -0,0 +1,142 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/imaging"
+	"media-service/internal/models"
+)
+
+// captionImageSize 生成图片描述前把图片缩小到的最大边长，减少发送给模型的数据量
+const captionImageSize = 1024
+
+// analyzeImageAsync 异步识别图片文字并生成图片描述，供搜索服务索引图片内容
+func (s *mediaService) analyzeImageAsync(mediaID, storageKey, mimeType string) {
+	params := map[string]interface{}{
+		"ocr":     s.analyzer.OCR != nil,
+		"caption": s.analyzer.Captioner != nil,
+	}
+
+	job, err := s.startProcessingJob(mediaID, models.JobTypeImageAnalysis, params)
+	if err != nil {
+		s.logger.Error("Failed to create image analysis job", zap.String("media_id", mediaID), zap.Error(err))
+		return
+	}
+
+	result, err := s.runImageAnalysisJob(mediaID, storageKey, mimeType)
+	if err != nil {
+		errMsg := err.Error()
+		s.repo.UpdateProcessingJob(job.ID, "failed", nil, &errMsg)
+		s.logger.Error("Image analysis failed",
+			zap.String("media_id", mediaID),
+			zap.String("job_id", job.ID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	s.repo.UpdateProcessingJob(job.ID, "completed", result, nil)
+	s.logger.Info("Image analyzed", zap.String("media_id", mediaID), zap.String("job_id", job.ID))
+}
+
+// runImageAnalysisJob 下载原图进行OCR和图片描述，结果写入媒体元数据的analysis
+// 两项中只要有一项成功就保存结果，另一项的错误记录在任务结果中
+func (s *mediaService) runImageAnalysisJob(mediaID, storageKey, mimeType string) (map[string]interface{}, error) {
+	tmpDir, err := os.MkdirTemp("", "media-analysis-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inputPath := filepath.Join(tmpDir, "input"+filepath.Ext(storageKey))
+	if err := s.downloadToFile(storageKey, inputPath); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.analyzer.Timeout)
+	defer cancel()
+
+	analysis := &models.ImageAnalysis{AnalyzedAt: time.Now()}
+	result := map[string]interface{}{}
+	var errs []error
+
+	if s.analyzer.OCR != nil {
+		text, err := s.analyzer.OCR.ExtractText(ctx, inputPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ocr: %w", err))
+			result["ocr_error"] = err.Error()
+		} else {
+			analysis.Text = truncateText(text, s.analyzer.MaxTextLength)
+			analysis.OCREngine = s.analyzer.OCR.Name()
+			result["text_length"] = utf8.RuneCountInString(analysis.Text)
+		}
+	}
+
+	if s.analyzer.Captioner != nil {
+		caption, err := s.captionImage(ctx, inputPath, mimeType)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("caption: %w", err))
+			result["caption_error"] = err.Error()
+		} else {
+			analysis.Caption = caption
+			analysis.CaptionModel = s.analyzer.Captioner.Model()
+			result["caption"] = caption
+		}
+	}
+
+	if analysis.OCREngine == "" && analysis.CaptionModel == "" {
+		return nil, errors.Join(errs...)
+	}
+
+	media, err := s.repo.GetMediaByID(mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	metadata := media.Metadata
+	if metadata == nil {
+		metadata = &models.MediaMetadata{}
+	}
+	metadata.Analysis = analysis
+
+	if err := s.repo.UpdateMedia(mediaID, &models.MediaUpdateRequest{Metadata: metadata}); err != nil {
+		return nil, fmt.Errorf("failed to update media metadata: %w", err)
+	}
+
+	return result, nil
+}
+
+// captionImage 生成图片描述，大图先缩小为JPEG再发送
+func (s *mediaService) captionImage(ctx context.Context, path, mimeType string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+
+	// 无法解码（如不支持的格式）或原图不超过目标尺寸时直接发送原图
+	renditions, err := imaging.GenerateRenditions(bytes.NewReader(data), []int{captionImageSize}, s.config.Image.ImageQuality, s.config.Image.MaxPixels)
+	if err != nil {
+		s.logger.Debug("Failed to downscale image for caption, sending original", zap.Error(err))
+	} else if len(renditions) > 0 {
+		data = renditions[0].Data
+		mimeType = "image/jpeg"
+	}
+
+	return s.analyzer.Captioner.Caption(ctx, data, mimeType)
+}
+
+// truncateText 按字符数截断文本，maxLength<=0表示不限制
+func truncateText(text string, maxLength int) string {
+	if maxLength <= 0 || utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+	return string([]rune(text)[:maxLength])
+}
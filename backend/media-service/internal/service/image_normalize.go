@@ -0,0 +1,77 @@
+package service
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/imaging"
+	"media-service/internal/models"
+)
+
+// memoryFile 规范化后保存在内存中的文件内容，实现multipart.File
+type memoryFile struct {
+	*bytes.Reader
+}
+
+// Close 内存文件无需释放资源
+func (memoryFile) Close() error { return nil }
+
+// normalizeImage 删除上传图片中的EXIF并按方向旋转，返回规范化后的文件和大小
+// 规范化失败（如格式不支持或文件损坏）时原样返回，不影响上传
+func (s *mediaService) normalizeImage(mediaID string, file multipart.File, size int64) (multipart.File, int64, *imaging.NormalizeResult) {
+	if !s.config.Image.StripExif && !s.config.Image.NormalizeOrientation {
+		return file, size, nil
+	}
+
+	data, err := io.ReadAll(file)
+	file.Seek(0, 0)
+	if err != nil {
+		s.logger.Warn("Failed to read image for normalization", zap.String("media_id", mediaID), zap.Error(err))
+		return file, size, nil
+	}
+
+	result, err := imaging.NormalizeImage(data, imaging.NormalizeOptions{
+		StripMetadata:    s.config.Image.StripExif,
+		ApplyOrientation: s.config.Image.NormalizeOrientation,
+		Quality:          s.config.Image.ImageQuality,
+		MaxPixels:        s.config.Image.MaxPixels,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to normalize image", zap.String("media_id", mediaID), zap.Error(err))
+		return file, size, nil
+	}
+	if !result.Rotated && !result.Stripped {
+		return file, size, result
+	}
+
+	s.logger.Debug("Image normalized",
+		zap.String("media_id", mediaID),
+		zap.Int("orientation", result.Orientation),
+		zap.Bool("had_gps", result.HadGPS),
+		zap.Int64("original_size", size),
+		zap.Int("size", len(result.Data)),
+	)
+	return memoryFile{bytes.NewReader(result.Data)}, int64(len(result.Data)), result
+}
+
+// applyNormalizeResult 记录规范化后的尺寸，以及原图的方向和元数据是否已删除
+func applyNormalizeResult(metadata *models.MediaMetadata, result *imaging.NormalizeResult) {
+	if result == nil {
+		return
+	}
+	width, height := result.Width, result.Height
+	metadata.Width = &width
+	metadata.Height = &height
+
+	if result.Rotated || result.Stripped {
+		metadata.Exif = map[string]string{
+			"orientation": strconv.Itoa(result.Orientation),
+			"rotated":     strconv.FormatBool(result.Rotated),
+			"stripped":    strconv.FormatBool(result.Stripped),
+		}
+	}
+}
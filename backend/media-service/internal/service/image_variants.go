@@ -0,0 +1,160 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/imaging"
+	"media-service/internal/models"
+)
+
+// generateImageVariantsAsync 异步按配置的预设尺寸生成图片缩略图
+func (s *mediaService) generateImageVariantsAsync(mediaID, storageKey string) {
+	params := map[string]interface{}{
+		"presets": s.config.Image.ThumbnailPresets,
+		"quality": s.config.Image.ImageQuality,
+	}
+
+	job, err := s.startProcessingJob(mediaID, models.JobTypeThumbnail, params)
+	if err != nil {
+		s.logger.Error("Failed to create thumbnail job", zap.String("media_id", mediaID), zap.Error(err))
+		return
+	}
+
+	result, err := s.runImageVariantsJob(mediaID, storageKey)
+	if err != nil {
+		errMsg := err.Error()
+		s.repo.UpdateProcessingJob(job.ID, "failed", nil, &errMsg)
+		s.logger.Error("Thumbnail generation failed",
+			zap.String("media_id", mediaID),
+			zap.String("job_id", job.ID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	s.repo.UpdateProcessingJob(job.ID, "completed", result, nil)
+	s.logger.Info("Thumbnails generated", zap.String("media_id", mediaID), zap.String("job_id", job.ID))
+}
+
+// runImageVariantsJob 下载原图生成各尺寸缩略图，与原图存放在一起，并写入媒体元数据的variants和srcset
+func (s *mediaService) runImageVariantsJob(mediaID, storageKey string) (map[string]interface{}, error) {
+	tmpDir, err := os.MkdirTemp("", "media-variants-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inputPath := filepath.Join(tmpDir, "input"+filepath.Ext(storageKey))
+	if err := s.downloadToFile(storageKey, inputPath); err != nil {
+		return nil, err
+	}
+
+	input, err := os.Open(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	renditions, err := imaging.GenerateRenditions(input, s.config.Image.ThumbnailPresets, s.config.Image.ImageQuality, s.config.Image.MaxPixels)
+	input.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make(map[string]*models.MediaVariant, len(renditions))
+	var uploaded []string
+	for _, rendition := range renditions {
+		variantPath := filepath.Join(tmpDir, fmt.Sprintf("%d.jpg", rendition.Preset))
+		if err := os.WriteFile(variantPath, rendition.Data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write thumbnail: %w", err)
+		}
+
+		variantKey := s.getVariantKey(storageKey, rendition.Preset)
+		if _, err := s.uploadLocalFile(variantKey, variantPath, "image/jpeg"); err != nil {
+			for _, key := range uploaded {
+				s.storageProvider.DeleteFile(key)
+			}
+			return nil, err
+		}
+		uploaded = append(uploaded, variantKey)
+
+		variants[strconv.Itoa(rendition.Preset)] = &models.MediaVariant{
+			URL:      s.fileURL(mediaID, variantKey, strconv.Itoa(rendition.Preset)),
+			Width:    rendition.Width,
+			Height:   rendition.Height,
+			Size:     int64(len(rendition.Data)),
+			MimeType: "image/jpeg",
+		}
+	}
+
+	media, err := s.repo.GetMediaByID(mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	metadata := media.Metadata
+	if metadata == nil {
+		metadata = &models.MediaMetadata{}
+	}
+	metadata.Variants = variants
+	metadata.Srcset = buildSrcset(media.PublicURL, metadata)
+
+	if err := s.repo.UpdateMedia(mediaID, &models.MediaUpdateRequest{Metadata: metadata}); err != nil {
+		return nil, fmt.Errorf("failed to update media metadata: %w", err)
+	}
+
+	return map[string]interface{}{
+		"variants": variants,
+		"srcset":   metadata.Srcset,
+	}, nil
+}
+
+// buildSrcset 按宽度从小到大生成srcset，原图尺寸已知时一并列出
+func buildSrcset(originalURL string, metadata *models.MediaMetadata) string {
+	type candidate struct {
+		url   string
+		width int
+	}
+
+	candidates := make([]candidate, 0, len(metadata.Variants)+1)
+	for _, variant := range metadata.Variants {
+		candidates = append(candidates, candidate{url: variant.URL, width: variant.Width})
+	}
+	if metadata.Width != nil && originalURL != "" {
+		candidates = append(candidates, candidate{url: originalURL, width: *metadata.Width})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].width < candidates[j].width
+	})
+
+	parts := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		parts = append(parts, fmt.Sprintf("%s %dw", c.url, c.width))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// getVariantKey 获取预设尺寸缩略图的存储键
+func (s *mediaService) getVariantKey(originalKey string, preset int) string {
+	base := strings.TrimSuffix(originalKey, filepath.Ext(originalKey))
+	return fmt.Sprintf("%s_%d.jpg", base, preset)
+}
+
+// getVariantKeys 获取媒体已生成的全部缩略图存储键
+func (s *mediaService) getVariantKeys(originalKey string, metadata *models.MediaMetadata) []string {
+	if metadata == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(metadata.Variants))
+	for name := range metadata.Variants {
+		if preset, err := strconv.Atoi(name); err == nil {
+			keys = append(keys, s.getVariantKey(originalKey, preset))
+		}
+	}
+	return keys
+}
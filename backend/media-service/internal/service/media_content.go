@@ -0,0 +1,260 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"media-service/internal/models"
+)
+
+// mediaContentPath 下载接口路径，本地存储的文件地址都指向这里
+const mediaContentPath = "/api/v1/media/files/"
+
+// 下载接口的variant参数，为空表示原文件；图片缩略图使用预设名（如"200"），视频转码版本使用输出名（如"720p"）
+const (
+	contentVariantThumbnail = "thumbnail"
+	contentVariantPoster    = "poster"
+	contentVariantSprite    = "sprite"
+	contentVariantSpriteVTT = "sprite_vtt"
+	contentVariantWatermark = "watermark"
+)
+
+// localFileURLBatch 每批改写的旧记录数量
+const localFileURLBatch = 200
+
+// ResolveMediaContent 检查访问权限并解析媒体文件，隔离中的文件拒绝下载，没有可用文件时返回not found
+// variant不为空时返回对应的缩略图、封面图等衍生文件
+func (s *mediaService) ResolveMediaContent(userID, mediaID, variant string) (*models.MediaContent, error) {
+	media, err := s.GetMedia(userID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	switch media.Status {
+	case models.MediaStatusQuarantined:
+		return nil, fmt.Errorf("media is quarantined")
+	case models.MediaStatusUploading, models.MediaStatusFailed, models.MediaStatusDeleted:
+		return nil, fmt.Errorf("media content not found")
+	}
+
+	content := &models.MediaContent{
+		MediaID:     media.ID,
+		StorageKey:  s.storageKey(media),
+		ContentType: media.MimeType,
+		Filename:    media.OriginalName,
+		UpdatedAt:   media.UpdatedAt,
+		MaxAge:      s.config.Storage.ContentCacheSeconds,
+	}
+	// 客户端加密的文件按二进制返回，由客户端解密后识别类型
+	if content.ContentType == "" || (media.Metadata != nil && media.Metadata.Encryption != nil) {
+		content.ContentType = "application/octet-stream"
+	}
+	if variant != "" {
+		if err := s.applyContentVariant(media, content, variant); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.statContent(content); err != nil {
+		return nil, err
+	}
+
+	// 同一媒体ID的文件内容不会改变，校验和缺失时使用媒体ID；衍生文件可能重新生成，带上大小
+	switch {
+	case variant != "":
+		content.ETag = fmt.Sprintf(`"%s-%s-%d"`, media.ID, variant, content.Size)
+	case media.Metadata != nil && media.Metadata.Checksum != "":
+		content.ETag = fmt.Sprintf(`"%s"`, media.Metadata.Checksum)
+	default:
+		content.ETag = fmt.Sprintf(`"%s"`, media.ID)
+	}
+	return content, nil
+}
+
+// applyContentVariant 把下载内容切换为衍生文件，媒体没有生成该文件时返回not found
+func (s *mediaService) applyContentVariant(media *models.Media, content *models.MediaContent, variant string) error {
+	metadata := media.Metadata
+	if metadata == nil {
+		metadata = &models.MediaMetadata{}
+	}
+	key := content.StorageKey
+
+	switch {
+	case variant == contentVariantThumbnail && media.ThumbnailURL != nil && *media.ThumbnailURL != "":
+		content.StorageKey, content.ContentType = s.getThumbnailKey(key), "image/jpeg"
+	case variant == contentVariantPoster && metadata.PosterURL != "":
+		content.StorageKey, content.ContentType = s.getPosterKey(key), "image/jpeg"
+	case variant == contentVariantSprite && metadata.SpriteURL != "":
+		content.StorageKey, _ = s.getSpriteKeys(key)
+		content.ContentType = "image/jpeg"
+	case variant == contentVariantSpriteVTT && metadata.SpriteVTTURL != "":
+		_, content.StorageKey = s.getSpriteKeys(key)
+		content.ContentType = "text/vtt"
+	case variant == contentVariantWatermark && metadata.Watermark != nil:
+		content.StorageKey, content.ContentType = s.getWatermarkKey(key), metadata.Watermark.MimeType
+	case metadata.Variants[variant] != nil:
+		preset, err := strconv.Atoi(variant)
+		if err != nil {
+			return fmt.Errorf("media content not found")
+		}
+		content.StorageKey, content.ContentType = s.getVariantKey(key, preset), metadata.Variants[variant].MimeType
+	case metadata.Renditions[variant] != nil:
+		content.StorageKey, content.ContentType = s.getTranscodeKey(key, variant), metadata.Renditions[variant].MimeType
+	default:
+		return fmt.Errorf("media content not found")
+	}
+
+	content.Filename = strings.TrimSuffix(media.OriginalName, filepath.Ext(media.OriginalName)) + "_" + variant + filepath.Ext(content.StorageKey)
+	return nil
+}
+
+// statContent 按存储中的对象设置文件大小
+// 媒体记录中的file_size是上传时的值，规范化或重新生成的文件大小可能不同，Content-Length必须与实际对象一致
+func (s *mediaService) statContent(content *models.MediaContent) error {
+	info, err := s.storageProvider.GetFileInfo(content.StorageKey)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("media content not found")
+		}
+		return fmt.Errorf("failed to stat media content: %w", err)
+	}
+	content.Size = info.Size
+	return nil
+}
+
+// OpenMediaContent 从offset开始读取length字节，length小于0时读到文件末尾
+func (s *mediaService) OpenMediaContent(content *models.MediaContent, offset, length int64) (io.ReadCloser, error) {
+	if offset == 0 && length < 0 {
+		reader, err := s.storageProvider.DownloadFile(content.StorageKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read media: %w", err)
+		}
+		return reader, nil
+	}
+	reader, err := s.storageProvider.DownloadRange(content.StorageKey, offset, length)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read media range: %w", err)
+	}
+	return reader, nil
+}
+
+// fileURL 生成媒体文件或衍生文件的访问地址
+// 本地存储没有静态文件路由，地址指向需要认证的下载接口；对象存储使用存储按键生成的地址
+func (s *mediaService) fileURL(mediaID, key, variant string) string {
+	if !s.localStorage() {
+		fileURL, _ := s.storageProvider.GetFileURL(key)
+		return fileURL
+	}
+
+	contentURL := mediaContentPath + url.PathEscape(mediaID) + "/content"
+	if variant != "" {
+		contentURL += "?variant=" + url.QueryEscape(variant)
+	}
+	return contentURL
+}
+
+// applyFileURLs 按存储键重新生成媒体记录中的文件地址，包括缩略图、各尺寸缩略图、水印图片、视频转码文件、封面图和雪碧图
+// 元数据先复制再修改，不影响调用方持有的原记录
+func (s *mediaService) applyFileURLs(media *models.Media, key string) {
+	media.PublicURL = s.fileURL(media.ID, key, "")
+	if media.ThumbnailURL != nil && *media.ThumbnailURL != "" {
+		thumbnailURL := s.fileURL(media.ID, s.getThumbnailKey(key), contentVariantThumbnail)
+		media.ThumbnailURL = &thumbnailURL
+	}
+	if media.Metadata == nil {
+		return
+	}
+
+	metadata := *media.Metadata
+	if len(metadata.Variants) > 0 {
+		variants := make(map[string]*models.MediaVariant, len(metadata.Variants))
+		for name, variant := range metadata.Variants {
+			moved := *variant
+			if preset, err := strconv.Atoi(name); err == nil {
+				moved.URL = s.fileURL(media.ID, s.getVariantKey(key, preset), name)
+			}
+			variants[name] = &moved
+		}
+		metadata.Variants = variants
+		metadata.Srcset = buildSrcset(media.PublicURL, &metadata)
+	}
+	if metadata.Watermark != nil {
+		moved := *metadata.Watermark
+		moved.URL = s.fileURL(media.ID, s.getWatermarkKey(key), contentVariantWatermark)
+		metadata.Watermark = &moved
+	}
+	if len(metadata.Renditions) > 0 {
+		renditions := make(map[string]*models.VideoRendition, len(metadata.Renditions))
+		for name, rendition := range metadata.Renditions {
+			moved := *rendition
+			moved.URL = s.fileURL(media.ID, s.getTranscodeKey(key, name), name)
+			renditions[name] = &moved
+		}
+		metadata.Renditions = renditions
+	}
+	if metadata.PosterURL != "" {
+		metadata.PosterURL = s.fileURL(media.ID, s.getPosterKey(key), contentVariantPoster)
+	}
+	if metadata.SpriteURL != "" {
+		spriteKey, vttKey := s.getSpriteKeys(key)
+		metadata.SpriteURL = s.fileURL(media.ID, spriteKey, contentVariantSprite)
+		metadata.SpriteVTTURL = s.fileURL(media.ID, vttKey, contentVariantSpriteVTT)
+	}
+	media.Metadata = &metadata
+}
+
+// localStorage 是否使用本地存储
+func (s *mediaService) localStorage() bool {
+	return strings.EqualFold(s.config.Storage.Provider, "local")
+}
+
+// MigrateLocalFileURLs 本地存储的旧记录保存的是已移除的静态文件地址，改为下载接口地址
+// 视频雪碧图的VTT文件内嵌了雪碧图地址，清空后重新生成。未使用本地存储时不做任何操作
+func (s *mediaService) MigrateLocalFileURLs() (int, error) {
+	if !s.localStorage() {
+		return 0, nil
+	}
+
+	migrated := 0
+	for {
+		medias, err := s.repo.ListMediaOutsideURLPrefix(mediaContentPath, localFileURLBatch)
+		if err != nil {
+			return migrated, fmt.Errorf("failed to list media with local file urls: %w", err)
+		}
+
+		for _, media := range medias {
+			key := s.storageKey(media)
+			updated := *media
+			hadSprite := media.Metadata != nil && media.Metadata.SpriteURL != ""
+			if hadSprite {
+				metadata := *media.Metadata
+				metadata.SpriteURL = ""
+				metadata.SpriteVTTURL = ""
+				updated.Metadata = &metadata
+			}
+			s.applyFileURLs(&updated, key)
+
+			if err := s.repo.UpdateMediaLocation(&updated); err != nil {
+				return migrated, err
+			}
+			migrated++
+
+			if hadSprite && media.Status == models.MediaStatusReady && s.config.Video.SpriteEnabled {
+				mediaID := media.ID
+				s.enqueueProcessing(JobVideoSprite, processingPayload{MediaID: mediaID, StorageKey: key}, func() {
+					s.generateVideoSpriteAsync(mediaID, key)
+				})
+			}
+		}
+
+		if len(medias) < localFileURLBatch {
+			break
+		}
+	}
+
+	return migrated, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+	"media-service/pkg/events"
+)
+
+// purgeBatchSize 每次永久删除的文件数量上限，剩余的留给下一次定时任务
+const purgeBatchSize = 200
+
+// RestoreMedia 恢复保留期内删除的媒体文件，恢复后重新计入配额
+func (s *mediaService) RestoreMedia(userID, mediaID string) (*models.Media, error) {
+	media, err := s.repo.GetDeletedMedia(mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if media.UserID != userID {
+		return nil, fmt.Errorf("access denied")
+	}
+	if media.DeletedAt == nil || time.Since(*media.DeletedAt) > s.deleteRetention() {
+		return nil, fmt.Errorf("restore period expired")
+	}
+	if media.ExpiresAt != nil && time.Now().After(*media.ExpiresAt) {
+		return nil, fmt.Errorf("media expired")
+	}
+
+	// 上传未完成就删除的文件没有可恢复的内容
+	exists, err := s.storageProvider.FileExists(s.storageKey(media))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check stored file: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("media content not found")
+	}
+
+	if err := s.checkUserQuota(userID, media.FileSize); err != nil {
+		return nil, err
+	}
+	if err := s.checkTenantQuota(media.TenantID, media.FileSize); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RestoreMedia(mediaID); err != nil {
+		return nil, err
+	}
+	s.updateUserQuota(userID, media.FileSize, 1)
+	s.updateTenantQuota(media.TenantID, media.FileSize, 1)
+	s.publishConversationShares(media, events.TypeMediaAttached)
+
+	s.logger.Info("Media restored",
+		zap.String("user_id", userID),
+		zap.String("media_id", mediaID),
+	)
+
+	return s.repo.GetMediaByID(mediaID)
+}
+
+// PurgeDeletedFiles 永久删除保留期满的已删除文件：先删除存储中的原文件和衍生文件，再删除记录
+func (s *mediaService) PurgeDeletedFiles() (int, error) {
+	medias, err := s.repo.GetMediaDeletedBefore(time.Now().Add(-s.deleteRetention()), purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, media := range medias {
+		s.deleteStoredFiles(media)
+		if err := s.repo.PurgeMedia(media.ID); err != nil {
+			s.logger.Error("Failed to purge media record", zap.String("media_id", media.ID), zap.Error(err))
+			continue
+		}
+		purged++
+	}
+	if purged < len(medias) {
+		return purged, fmt.Errorf("failed to purge %d of %d deleted files", len(medias)-purged, len(medias))
+	}
+	return purged, nil
+}
+
+// deleteStoredFiles 删除原文件和全部衍生文件
+// 文件可能已被删除（如旧版本删除时立即清理了存储），失败只记录日志，不阻止删除记录
+func (s *mediaService) deleteStoredFiles(media *models.Media) {
+	for _, key := range s.storedFileKeys(media) {
+		if err := s.storageProvider.DeleteFile(key); err != nil {
+			s.logger.Warn("Failed to delete file from storage",
+				zap.String("media_id", media.ID),
+				zap.String("key", key),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// storedFileKeys 媒体在存储中的全部文件：原文件、缩略图、预设尺寸缩略图、水印图片、视频拖动预览文件和转码文件
+func (s *mediaService) storedFileKeys(media *models.Media) []string {
+	storageKey := s.storageKey(media)
+	keys := []string{storageKey}
+	if media.ThumbnailURL != nil && *media.ThumbnailURL != "" {
+		keys = append(keys, s.getThumbnailKey(storageKey))
+	}
+	keys = append(keys, s.getVariantKeys(storageKey, media.Metadata)...)
+	if media.Metadata != nil && media.Metadata.SpriteURL != "" {
+		spriteKey, vttKey := s.getSpriteKeys(storageKey)
+		keys = append(keys, spriteKey, vttKey)
+	}
+	if media.Metadata != nil && media.Metadata.Watermark != nil {
+		keys = append(keys, s.getWatermarkKey(storageKey))
+	}
+	if media.Metadata != nil {
+		for name := range media.Metadata.Renditions {
+			keys = append(keys, s.getTranscodeKey(storageKey, name))
+		}
+		if media.Metadata.PosterURL != "" {
+			keys = append(keys, s.getPosterKey(storageKey))
+		}
+	}
+	return keys
+}
+
+// deleteRetention 删除的文件可以恢复的期限
+func (s *mediaService) deleteRetention() time.Duration {
+	return time.Duration(s.config.Storage.DeleteRetentionDays) * 24 * time.Hour
+}
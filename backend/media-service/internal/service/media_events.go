@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+	"media-service/pkg/events"
+)
+
+// publishTimeout 发布事件的超时时间，上传接口没有请求上下文
+const publishTimeout = 5 * time.Second
+
+// publishMediaUploaded 发布media.uploaded事件，发布失败只记录日志
+func (s *mediaService) publishMediaUploaded(media *models.Media) {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	err := s.events.Publish(ctx, events.TypeMediaUploaded, events.MediaUploaded{
+		FileID:   media.ID,
+		OwnerID:  media.UserID,
+		TenantID: media.TenantID,
+		FileName: media.OriginalName,
+		MimeType: media.MimeType,
+		Size:     media.FileSize,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to publish media event",
+			zap.String("media_id", media.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// publishMediaAttached 发布media.attached事件，群组服务据此统计群组文件用量
+func (s *mediaService) publishMediaAttached(media *models.Media, conversationID string) {
+	s.publishMediaEvent(media.ID, events.TypeMediaAttached, events.MediaAttached{
+		FileID:         media.ID,
+		ConversationID: conversationID,
+		OwnerID:        media.UserID,
+		Size:           media.FileSize,
+	})
+}
+
+// publishMediaDetached 发布media.detached事件，文件不再附加到该会话
+func (s *mediaService) publishMediaDetached(media *models.Media, conversationID string) {
+	s.publishMediaEvent(media.ID, events.TypeMediaDetached, events.MediaDetached{
+		FileID:         media.ID,
+		ConversationID: conversationID,
+	})
+}
+
+// publishConversationShares 删除或恢复文件时，为每个附加的会话发布附加/移除事件
+func (s *mediaService) publishConversationShares(media *models.Media, eventType string) {
+	shares, err := s.repo.GetMediaShares(media.ID)
+	if err != nil {
+		s.logger.Warn("Failed to get media shares", zap.String("media_id", media.ID), zap.Error(err))
+		return
+	}
+	for _, share := range shares {
+		if share.ConversationID == "" {
+			continue
+		}
+		if eventType == events.TypeMediaAttached {
+			s.publishMediaAttached(media, share.ConversationID)
+		} else {
+			s.publishMediaDetached(media, share.ConversationID)
+		}
+	}
+}
+
+func (s *mediaService) publishMediaEvent(mediaID, eventType string, data interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	if err := s.events.Publish(ctx, eventType, data); err != nil {
+		s.logger.Warn("Failed to publish media event",
+			zap.String("media_id", mediaID),
+			zap.String("type", eventType),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/pkg/jobs"
+)
+
+// 媒体处理后台任务名
+const (
+	JobImageProcessing    = "image_processing"
+	JobAudioProcessing    = "audio_processing"
+	JobAudioTranscription = "audio_transcription"
+	JobVideoSprite        = "video_sprite"
+	JobVideoTranscode     = "video_transcode"
+	JobImageWatermark     = "image_watermark"
+	JobStorageReplicate   = "storage_replicate"
+)
+
+// processingPayload 媒体处理任务参数
+type processingPayload struct {
+	MediaID    string `json:"media_id"`
+	StorageKey string `json:"storage_key"`
+	MimeType   string `json:"mime_type,omitempty"`
+}
+
+// registerJobs 注册媒体处理任务。处理结果和失败原因记录在处理任务表中，队列层面不再重试
+func (s *mediaService) registerJobs() {
+	// 缩略图和图片识别都会改写元数据，在同一任务中依次执行避免互相覆盖
+	s.jobs.Register(JobImageProcessing, s.processingHandler(func(p processingPayload) {
+		if len(s.config.Image.ThumbnailPresets) > 0 {
+			s.generateImageVariantsAsync(p.MediaID, p.StorageKey)
+		}
+		if s.analyzer.Enabled() {
+			s.analyzeImageAsync(p.MediaID, p.StorageKey, p.MimeType)
+		}
+	}), jobs.MaxAttempts(1))
+
+	s.jobs.Register(JobAudioProcessing, s.processingHandler(func(p processingPayload) {
+		s.processAudio(p.MediaID, p.StorageKey)
+	}), jobs.MaxAttempts(1))
+
+	// 仅转写语音的旧任务名，处理升级前已写入队列的任务
+	s.jobs.Register(JobAudioTranscription, s.processingHandler(func(p processingPayload) {
+		if s.transcriber != nil {
+			s.transcribeAudioAsync(p.MediaID, p.StorageKey)
+		}
+	}), jobs.MaxAttempts(1))
+
+	s.jobs.Register(JobVideoSprite, s.processingHandler(func(p processingPayload) {
+		s.generateVideoSpriteAsync(p.MediaID, p.StorageKey)
+	}), jobs.MaxAttempts(1))
+
+	s.jobs.Register(JobVideoTranscode, s.processingHandler(func(p processingPayload) {
+		s.transcodeVideoAsync(p.MediaID, p.StorageKey)
+	}), jobs.MaxAttempts(1))
+
+	s.jobs.Register(JobImageWatermark, s.processingHandler(func(p processingPayload) {
+		s.generateWatermarkAsync(p.MediaID, p.StorageKey)
+	}), jobs.MaxAttempts(1))
+
+	// 复制失败通常是存储暂时不可用，按较长的间隔多次重试，仍失败的由修复任务补齐
+	if s.tenantStorage.FailoverEnabled() {
+		s.jobs.Register(JobStorageReplicate, func(ctx context.Context, payload json.RawMessage) error {
+			var p replicationPayload
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return err
+			}
+			return s.tenantStorage.Replicate(p.Key, p.Target)
+		}, jobs.MaxAttempts(8), jobs.Backoff(time.Minute))
+		s.tenantStorage.OnReplicate(s.scheduleReplication)
+	}
+}
+
+// processingHandler 解析任务参数后执行处理函数
+func (s *mediaService) processingHandler(process func(processingPayload)) jobs.Handler {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var p processingPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return err
+		}
+		process(p)
+		return nil
+	}
+}
+
+// enqueueProcessing 将媒体处理写入任务队列，服务重启后未执行的任务继续执行
+// 写入队列失败时退回为直接在后台执行，避免处理任务丢失
+func (s *mediaService) enqueueProcessing(name string, payload processingPayload, fallback func()) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.jobs.Enqueue(ctx, name, payload); err != nil {
+		s.logger.Warn("Failed to enqueue media processing job, running in background",
+			zap.String("job", name),
+			zap.String("media_id", payload.MediaID),
+			zap.Error(err),
+		)
+		go fallback()
+	}
+}
@@ -1,10 +1,13 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,15 +15,26 @@ import (
 	"go.uber.org/zap"
 
 	"media-service/config"
+	"media-service/internal/client"
+	"media-service/internal/imaging"
 	"media-service/internal/models"
+	"media-service/internal/processing"
 	"media-service/internal/repository"
+	"media-service/internal/scanner"
+	"media-service/internal/speech"
 	"media-service/internal/storage"
+	"media-service/internal/vision"
+	"media-service/pkg/events"
+	"media-service/pkg/jobs"
 )
 
 // MediaService 媒体服务接口
 type MediaService interface {
-	// 文件上传
-	UploadFile(userID string, file multipart.File, header *multipart.FileHeader) (*models.UploadResponse, error)
+	// 文件上传，tenantID为空时归属默认租户
+	UploadFile(userID, tenantID string, file multipart.File, header *multipart.FileHeader) (*models.UploadResponse, error)
+
+	// 上传客户端加密文件
+	UploadEncryptedFile(userID, tenantID string, file multipart.File, header *multipart.FileHeader, encryption *models.EncryptionInfo) (*models.UploadResponse, error)
 	
 	// 获取媒体文件
 	GetMedia(userID, mediaID string) (*models.Media, error)
@@ -31,14 +45,29 @@ type MediaService interface {
 	// 更新媒体文件
 	UpdateMedia(userID, mediaID string, req *models.MediaUpdateRequest) error
 	
-	// 删除媒体文件
+	// 删除媒体文件，保留期内可以恢复
 	DeleteMedia(userID, mediaID string) error
+	RestoreMedia(userID, mediaID string) (*models.Media, error)
 	
 	// 生成缩略图
 	GenerateThumbnail(userID, mediaID string, req *models.ThumbnailRequest) (*models.Media, error)
 	
 	// 获取预签名URL
 	GetPresignedURL(userID, mediaID, operation string, expiration time.Duration) (string, error)
+
+	// 媒体共享：附加到会话或授权给指定用户，只有所有者可以管理
+	ShareMedia(userID, mediaID string, req *models.MediaShareRequest) ([]*models.MediaShare, error)
+	ListMediaShares(userID, mediaID string) ([]*models.MediaShare, error)
+	RevokeMediaShare(userID, mediaID, shareID string) error
+
+	// 公开链接：签名链接不需要登录即可下载，启用水印时图片返回带水印的副本
+	CreatePublicLink(userID, mediaID string, req *models.PublicLinkRequest) (*models.MediaPublicLink, error)
+	VerifyPublicLink(mediaID string, expires int64, signature string) bool
+	ResolvePublicContent(mediaID string) (*models.MediaContent, error)
+
+	// 文件下载：检查权限后解析文件，按字节范围读取
+	ResolveMediaContent(userID, mediaID, variant string) (*models.MediaContent, error)
+	OpenMediaContent(content *models.MediaContent, offset, length int64) (io.ReadCloser, error)
 	
 	// 获取用户存储统计
 	GetUserStorageStats(userID string) (*models.StorageInfo, error)
@@ -46,112 +75,265 @@ type MediaService interface {
 	// 获取系统存储统计
 	GetSystemStorageStats() (*models.StorageInfo, error)
 	
-	// 清理过期文件
+	// 清理过期文件，永久删除保留期满的已删除文件
 	CleanupExpiredFiles() error
+	PurgeDeletedFiles() (int, error)
+
+	// 检查一批文件在主存储和备用存储中的副本并补齐缺失的副本，未启用备用存储时不做任何操作
+	RepairStorageReplicas() (int, error)
+
+	// 本地存储的旧记录改用下载接口地址，未使用本地存储时不做任何操作
+	MigrateLocalFileURLs() (int, error)
 	
 	// 处理媒体文件（异步）
 	ProcessMedia(mediaID string, jobType string, params map[string]interface{}) (*models.ProcessingJob, error)
 	
 	// 获取处理任务状态
 	GetProcessingJobStatus(jobID string) (*models.ProcessingJob, error)
+
+	// 隔离区管理（管理员）
+	ListQuarantinedMedia(req *models.QuarantineListRequest) (*models.QuarantineListResponse, error)
+	GetQuarantinedMedia(mediaID string) (*models.Media, error)
+	ReleaseQuarantinedMedia(adminID, mediaID string) (*models.Media, error)
+	PurgeQuarantinedMedia(adminID, mediaID string) error
+
+	// 用户头像：链接按用户签名生成，更换头像后链接不变，不暴露存储地址
+	SetAvatar(userID, mediaID string) (*models.UserAvatar, error)
+	ClearAvatar(userID string) error
+	AvatarURL(userID string, size int) *models.AvatarURL
+	VerifyAvatarURL(userID string, size int, expires int64, signature string) bool
+	ResolveAvatar(userID string, size int) (*models.AvatarContent, error)
+	OpenAvatar(content *models.AvatarContent) (io.ReadCloser, error)
+
+	// 多租户存储管理（管理员）
+	GetTenantStorageStats(tenantID string) (*models.TenantStorageStats, error)
+	UpdateTenantQuota(tenantID string, req *models.TenantQuotaUpdateRequest) (*models.TenantStorageQuota, error)
+	RehomeTenantMedia(adminID, tenantID string, req *models.TenantMigrationRequest) (*models.TenantMigrationResult, error)
+
+	// 缩略图工作池
+	StartThumbnailWorkers(ctx context.Context)
+	WaitThumbnailWorkers()
 }
 
 // mediaService 媒体服务实现
 type mediaService struct {
 	repo           repository.MediaRepository
 	storageProvider storage.StorageProvider
+	tenantStorage   *storage.TenantStorage
 	config         *config.Config
 	logger         *zap.Logger
+	spriteGenerator *processing.SpriteGenerator
+	transcoder      *processing.Transcoder
+	waveform        *processing.WaveformExtractor
+	scanner         scanner.Scanner
+	analyzer        *vision.Analyzer
+	transcriber     speech.Transcriber
+	notifier        client.NotificationClient
+	messageClient   client.MessageClient
+	groupClient     client.GroupClient
+	jobs            *jobs.Runner
+	events          events.Publisher
+
+	thumbnailWorkers sync.WaitGroup
+
+	// 副本修复任务的游标，每次从上次检查到的位置继续
+	repairMu     sync.Mutex
+	repairOffset int
 }
 
 // NewMediaService 创建媒体服务
 func NewMediaService(
 	repo repository.MediaRepository,
-	storageProvider storage.StorageProvider,
+	storageProvider *storage.TenantStorage,
+	fileScanner scanner.Scanner,
+	analyzer *vision.Analyzer,
+	transcriber speech.Transcriber,
+	notifier client.NotificationClient,
+	messageClient client.MessageClient,
+	groupClient client.GroupClient,
+	jobRunner *jobs.Runner,
+	publisher events.Publisher,
 	config *config.Config,
 	logger *zap.Logger,
 ) MediaService {
-	return &mediaService{
+	if publisher == nil {
+		publisher = events.NopPublisher()
+	}
+	s := &mediaService{
 		repo:           repo,
 		storageProvider: storageProvider,
+		tenantStorage:   storageProvider,
 		config:         config,
 		logger:         logger,
-	}
+		spriteGenerator: processing.NewSpriteGenerator(processing.SpriteOptions{
+			FFmpegPath:  config.Video.FFmpegPath,
+			FFprobePath: config.Video.FFprobePath,
+			Interval:    config.Video.SpriteInterval,
+			Width:       config.Video.SpriteWidth,
+			Columns:     config.Video.SpriteColumns,
+			MaxFrames:   config.Video.SpriteMaxFrames,
+		}),
+		transcoder: processing.NewTranscoder(processing.TranscodeOptions{
+			FFmpegPath:   config.Video.FFmpegPath,
+			FFprobePath:  config.Video.FFprobePath,
+			Heights:      config.Video.TranscodeHeights,
+			CRF:          config.Video.TranscodeCRF,
+			Preset:       config.Video.TranscodePreset,
+			AudioBitrate: config.Video.TranscodeAudioBitrate,
+		}),
+		waveform: processing.NewWaveformExtractor(processing.WaveformOptions{
+			FFmpegPath: config.Video.FFmpegPath,
+			Buckets:    config.Audio.WaveformBuckets,
+		}),
+		scanner:       fileScanner,
+		analyzer:      analyzer,
+		transcriber:   transcriber,
+		notifier:      notifier,
+		messageClient: messageClient,
+		groupClient:   groupClient,
+		jobs:          jobRunner,
+		events:        publisher,
+	}
+	s.registerJobs()
+	return s
 }
 
 // UploadFile 上传文件
-func (s *mediaService) UploadFile(userID string, file multipart.File, header *multipart.FileHeader) (*models.UploadResponse, error) {
-	// 验证文件大小
-	if header.Size > s.config.File.MaxFileSize {
-		return nil, fmt.Errorf("file size %d exceeds maximum allowed size %d", header.Size, s.config.File.MaxFileSize)
+func (s *mediaService) UploadFile(userID, tenantID string, file multipart.File, header *multipart.FileHeader) (*models.UploadResponse, error) {
+	return s.uploadFile(userID, tenantID, file, header, nil)
+}
+
+// UploadEncryptedFile 上传客户端加密的文件
+// 服务端只保存密文和加密参数，不做类型嗅探、缩略图等处理，但同样计入存储配额
+func (s *mediaService) UploadEncryptedFile(userID, tenantID string, file multipart.File, header *multipart.FileHeader, encryption *models.EncryptionInfo) (*models.UploadResponse, error) {
+	if err := encryption.Validate(); err != nil {
+		return nil, err
 	}
+	return s.uploadFile(userID, tenantID, file, header, encryption)
+}
 
-	// 检测文件类型
-	fileBytes := make([]byte, 512)
-	n, err := file.Read(fileBytes)
+// uploadFile 上传文件，encryption不为空时按客户端加密文件处理
+func (s *mediaService) uploadFile(userID, tenantID string, file multipart.File, header *multipart.FileHeader, encryption *models.EncryptionInfo) (*models.UploadResponse, error) {
+	tenantID, err := s.resolveTenantID(tenantID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file for type detection: %w", err)
+		return nil, err
 	}
-	
-	// 重置文件指针
-	file.Seek(0, 0)
 
-	// 检测MIME类型
-	kind, _ := filetype.Match(fileBytes[:n])
+	// 验证文件大小
+	if header.Size > s.config.File.MaxFileSize {
+		return nil, fmt.Errorf("file size %d exceeds maximum allowed size %d", header.Size, s.config.File.MaxFileSize)
+	}
 
+	kind := filetype.Unknown
 	mimeType := ""
-	if kind != filetype.Unknown {
-		mimeType = kind.MIME.Value
-	}
-	if mimeType == "" {
-		mimeType = header.Header.Get("Content-Type")
+	if encryption != nil {
+		// 密文无法嗅探类型，按客户端声明的明文类型校验
+		mimeType = models.EncryptedMimeType
+		if encryption.PlaintextMimeType != "" && !s.isAllowedFileType(encryption.PlaintextMimeType) {
+			return nil, fmt.Errorf("file type %s is not allowed", encryption.PlaintextMimeType)
+		}
+	} else {
+		// 检测文件类型
+		fileBytes := make([]byte, 512)
+		n, err := file.Read(fileBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file for type detection: %w", err)
+		}
+
+		// 重置文件指针
+		file.Seek(0, 0)
+
+		// 检测MIME类型
+		kind, _ = filetype.Match(fileBytes[:n])
+
+		if kind != filetype.Unknown {
+			mimeType = kind.MIME.Value
+		}
 		if mimeType == "" {
-			mimeType = "application/octet-stream"
+			mimeType = header.Header.Get("Content-Type")
+			if mimeType == "" {
+				mimeType = "application/octet-stream"
+			}
+		}
+
+		// 验证文件类型
+		if !s.isAllowedFileType(mimeType) {
+			return nil, fmt.Errorf("file type %s is not allowed", mimeType)
 		}
 	}
 
-	// 验证文件类型
-	if !s.isAllowedFileType(mimeType) {
-		return nil, fmt.Errorf("file type %s is not allowed", mimeType)
+	// 生成文件ID
+	mediaID := uuid.New().String()
+
+	// 图片删除EXIF并修正方向，之后按规范化后的内容存储和计算配额
+	fileSize := header.Size
+	var normalized *imaging.NormalizeResult
+	if encryption == nil && s.getMediaType(mimeType) == models.MediaTypeImage {
+		file, fileSize, normalized = s.normalizeImage(mediaID, file, fileSize)
 	}
 
-	// 检查用户存储配额
-	if err = s.checkUserQuota(userID, header.Size); err != nil {
+	// 检查用户和租户存储配额
+	if err := s.checkUserQuota(userID, fileSize); err != nil {
+		return nil, err
+	}
+	if err := s.checkTenantQuota(tenantID, fileSize); err != nil {
 		return nil, err
 	}
 
-	// 生成文件ID和存储路径
-	mediaID := uuid.New().String()
+	// 生成存储路径
 	fileExt := filepath.Ext(header.Filename)
 	if fileExt == "" && kind.Extension != "" {
 		fileExt = "." + kind.Extension
 	}
 	
 	filename := fmt.Sprintf("%s%s", mediaID, fileExt)
-	storageKey := s.generateStorageKey(userID, filename)
+	storageKey := s.tenantStorage.TenantKey(tenantID, s.generateStorageKey(userID, filename))
+
+	// 确定媒体类型
+	mediaType := s.getMediaType(mimeType)
+	if encryption != nil {
+		mediaType = s.getMediaType(encryption.PlaintextMimeType)
+	}
+
+	// 提取元数据，图片同时生成占位图
+	metadata := s.extractMetadata(header, mimeType)
+	metadata.Encryption = encryption
+	applyNormalizeResult(metadata, normalized)
+	if encryption == nil && mediaType == models.MediaTypeImage {
+		s.extractImagePlaceholder(mediaID, file, metadata)
+	}
+
+	// 安全扫描，发现威胁的文件进入隔离区等待管理员复核；密文无法扫描
+	status := models.MediaStatusReady
+	if encryption == nil {
+		metadata.VirusScan = s.scanFile(mediaID, file)
+		if metadata.VirusScan.IsThreat() {
+			status = models.MediaStatusQuarantined
+		}
+	}
 
 	// 上传到存储
-	uploadResult, err := s.storageProvider.UploadFile(storageKey, file, header.Size, mimeType)
-	if err != nil {
+	if _, err := s.storageProvider.UploadFile(storageKey, file, fileSize, mimeType); err != nil {
 		return nil, fmt.Errorf("failed to upload file: %w", err)
 	}
 
-	// 确定媒体类型
-	mediaType := s.getMediaType(mimeType)
+	// 租户可能使用独立的存储桶，公开地址由存储按键生成；本地存储指向下载接口
+	publicURL := s.fileURL(mediaID, storageKey, "")
 
 	// 创建媒体记录
 	media := &models.Media{
 		ID:           mediaID,
 		UserID:       userID,
+		TenantID:     tenantID,
 		Filename:     filename,
 		OriginalName: header.Filename,
 		MimeType:     mimeType,
-		FileSize:     header.Size,
+		FileSize:     fileSize,
 		MediaType:    mediaType,
-		Status:       models.MediaStatusReady,
+		Status:       status,
 		StoragePath:  s.config.Storage.LocalPath + "/" + storageKey,
-		PublicURL:    s.config.Storage.BaseURL + "/" + storageKey,
-		Metadata:     s.extractMetadata(header, mimeType),
+		PublicURL:    publicURL,
+		Metadata:     metadata,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
@@ -167,30 +349,70 @@ func (s *mediaService) UploadFile(userID string, file multipart.File, header *mu
 		return nil, fmt.Errorf("failed to save media record: %w", err)
 	}
 
-	// 更新用户配额
-	s.updateUserQuota(userID, header.Size, 1)
+	// 更新用户和租户配额
+	s.updateUserQuota(userID, fileSize, 1)
+	s.updateTenantQuota(tenantID, fileSize, 1)
+
+	// 客户端加密的文件服务端无法解密，被隔离的文件等待复核，均跳过处理任务
+	if encryption == nil && status == models.MediaStatusReady {
+		payload := processingPayload{MediaID: mediaID, StorageKey: storageKey, MimeType: mimeType}
+
+		// 如果是图片，通过后台任务按预设尺寸生成缩略图，并识别图片文字和生成描述
+		if mediaType == models.MediaTypeImage && (len(s.config.Image.ThumbnailPresets) > 0 || s.analyzer.Enabled()) {
+			s.enqueueProcessing(JobImageProcessing, payload, func() {
+				if len(s.config.Image.ThumbnailPresets) > 0 {
+					s.generateImageVariantsAsync(mediaID, storageKey)
+				}
+				if s.analyzer.Enabled() {
+					s.analyzeImageAsync(mediaID, storageKey, mimeType)
+				}
+			})
+		}
+
+		// 如果是音频，通过后台任务提取波形，并转写语音供搜索和无障碍显示
+		if mediaType == models.MediaTypeAudio && (s.config.Audio.WaveformEnabled || s.transcriber != nil) {
+			s.enqueueProcessing(JobAudioProcessing, payload, func() {
+				s.processAudio(mediaID, storageKey)
+			})
+		}
+
+		// 如果是视频，通过后台任务生成拖动预览雪碧图
+		if mediaType == models.MediaTypeVideo && s.config.Video.SpriteEnabled {
+			s.enqueueProcessing(JobVideoSprite, payload, func() {
+				s.generateVideoSpriteAsync(mediaID, storageKey)
+			})
+		}
 
-	// 如果是图片，异步生成缩略图
-	if mediaType == models.MediaTypeImage {
-		go s.generateThumbnailAsync(mediaID)
+		// 如果是视频，通过后台任务转码为H.264/AAC的MP4并截取封面图
+		if mediaType == models.MediaTypeVideo && s.config.Video.TranscodeEnabled {
+			s.enqueueProcessing(JobVideoTranscode, payload, func() {
+				s.transcodeVideoAsync(mediaID, storageKey)
+			})
+		}
 	}
 
 	s.logger.Info("File uploaded successfully",
 		zap.String("user_id", userID),
 		zap.String("media_id", mediaID),
 		zap.String("filename", header.Filename),
-		zap.Int64("size", header.Size),
+		zap.Int64("size", fileSize),
 	)
 
+	// 被隔离的文件等待复核，不对外发布
+	if status == models.MediaStatusReady {
+		s.publishMediaUploaded(media)
+	}
+
 	return &models.UploadResponse{
 		MediaID:   mediaID,
-		UploadURL: uploadResult.URL,
-		PublicURL: uploadResult.URL,
+		UploadURL: publicURL,
+		PublicURL: publicURL,
 		ExpiresAt: media.CreatedAt.Unix() + 3600, // 1小时后过期
 	}, nil
 }
 
 // GetMedia 获取媒体文件
+// 所有者之外，文件共享给用户或用户所在的会话时也可以读取；隔离中的文件只有所有者可见，且不返回文件地址
 func (s *mediaService) GetMedia(userID, mediaID string) (*models.Media, error) {
 	media, err := s.repo.GetMediaByID(mediaID)
 	if err != nil {
@@ -198,6 +420,26 @@ func (s *mediaService) GetMedia(userID, mediaID string) (*models.Media, error) {
 	}
 
 	// 检查权限
+	if media.UserID != userID {
+		if media.Status == models.MediaStatusQuarantined {
+			return nil, fmt.Errorf("media not found")
+		}
+		if err := s.checkSharedAccess(userID, media); err != nil {
+			return nil, err
+		}
+	}
+
+	redactQuarantined(media)
+	return media, nil
+}
+
+// getOwnedMedia 获取用户自己的媒体文件，修改、删除和共享管理只允许所有者操作
+func (s *mediaService) getOwnedMedia(userID, mediaID string) (*models.Media, error) {
+	media, err := s.repo.GetMediaByID(mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+
 	if media.UserID != userID {
 		return nil, fmt.Errorf("access denied")
 	}
@@ -226,6 +468,7 @@ func (s *mediaService) GetMediaList(userID string, req *models.MediaListRequest)
 	// 转换指针切片为值切片
 	mediaList := make([]models.Media, len(medias))
 	for i, media := range medias {
+		redactQuarantined(media)
 		mediaList[i] = *media
 	}
 
@@ -240,7 +483,7 @@ func (s *mediaService) GetMediaList(userID string, req *models.MediaListRequest)
 // UpdateMedia 更新媒体文件
 func (s *mediaService) UpdateMedia(userID, mediaID string, req *models.MediaUpdateRequest) error {
 	// 检查权限
-	media, err := s.GetMedia(userID, mediaID)
+	media, err := s.getOwnedMedia(userID, mediaID)
 	if err != nil {
 		return err
 	}
@@ -258,35 +501,20 @@ func (s *mediaService) UpdateMedia(userID, mediaID string, req *models.MediaUpda
 // DeleteMedia 删除媒体文件
 func (s *mediaService) DeleteMedia(userID, mediaID string) error {
 	// 检查权限
-	media, err := s.GetMedia(userID, mediaID)
+	media, err := s.getOwnedMedia(userID, mediaID)
 	if err != nil {
 		return err
 	}
 
-	// 软删除数据库记录
+	// 软删除数据库记录，存储文件保留到保留期满后由定时任务删除，期间可以恢复
 	if err := s.repo.DeleteMedia(mediaID); err != nil {
 		return fmt.Errorf("failed to delete media record: %w", err)
 	}
 
-	// 异步删除存储文件
-	go func() {
-		if err := s.storageProvider.DeleteFile(media.StoragePath); err != nil {
-			s.logger.Error("Failed to delete file from storage",
-				zap.String("media_id", mediaID),
-				zap.String("storage_path", media.StoragePath),
-				zap.Error(err),
-			)
-		}
-
-		// 删除缩略图
-		if media.ThumbnailURL != nil && *media.ThumbnailURL != "" {
-			thumbnailKey := s.getThumbnailKey(media.StoragePath)
-			s.storageProvider.DeleteFile(thumbnailKey)
-		}
-	}()
-
-	// 更新用户配额
+	// 更新用户和租户配额
 	s.updateUserQuota(userID, -media.FileSize, -1)
+	s.updateTenantQuota(media.TenantID, -media.FileSize, -1)
+	s.publishConversationShares(media, events.TypeMediaDetached)
 
 	s.logger.Info("Media deleted",
 		zap.String("user_id", userID),
@@ -299,7 +527,7 @@ func (s *mediaService) DeleteMedia(userID, mediaID string) error {
 // GenerateThumbnail 生成缩略图
 func (s *mediaService) GenerateThumbnail(userID, mediaID string, req *models.ThumbnailRequest) (*models.Media, error) {
 	// 检查权限
-	media, err := s.GetMedia(userID, mediaID)
+	media, err := s.getOwnedMedia(userID, mediaID)
 	if err != nil {
 		return nil, err
 	}
@@ -308,6 +536,9 @@ func (s *mediaService) GenerateThumbnail(userID, mediaID string, req *models.Thu
 	if media.MediaType != models.MediaTypeImage {
 		return nil, fmt.Errorf("thumbnails can only be generated for images")
 	}
+	if media.IsEncrypted() {
+		return nil, fmt.Errorf("thumbnails cannot be generated for encrypted media")
+	}
 
 	// 创建处理任务
 	jobParams := map[string]interface{}{
@@ -316,7 +547,7 @@ func (s *mediaService) GenerateThumbnail(userID, mediaID string, req *models.Thu
 		"quality": req.Quality,
 	}
 
-	job, err := s.ProcessMedia(mediaID, "thumbnail", jobParams)
+	job, err := s.ProcessMedia(mediaID, models.JobTypeThumbnail, jobParams)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create thumbnail job: %w", err)
 	}
@@ -333,13 +564,20 @@ func (s *mediaService) GenerateThumbnail(userID, mediaID string, req *models.Thu
 
 // GetPresignedURL 获取预签名URL
 func (s *mediaService) GetPresignedURL(userID, mediaID, operation string, expiration time.Duration) (string, error) {
-	// 检查权限
-	media, err := s.GetMedia(userID, mediaID)
+	// 检查权限，共享的文件只能获取下载链接
+	getMedia := s.GetMedia
+	if !strings.EqualFold(operation, "GET") {
+		getMedia = s.getOwnedMedia
+	}
+	media, err := getMedia(userID, mediaID)
 	if err != nil {
 		return "", err
 	}
+	if media.Status == models.MediaStatusQuarantined {
+		return "", fmt.Errorf("media is quarantined")
+	}
 
-	return s.storageProvider.GetPresignedURL(media.StoragePath, operation, expiration)
+	return s.storageProvider.GetPresignedURL(s.storageKey(media), operation, expiration)
 }
 
 // GetUserStorageStats 获取用户存储统计
@@ -376,6 +614,28 @@ func (s *mediaService) ProcessMedia(mediaID string, jobType string, params map[s
 	return job, nil
 }
 
+// startProcessingJob 记录在当前协程中直接执行的处理任务，创建时即为处理中，不会被缩略图工作池领取
+func (s *mediaService) startProcessingJob(mediaID string, jobType string, params map[string]interface{}) (*models.ProcessingJob, error) {
+	now := time.Now()
+	job := &models.ProcessingJob{
+		ID:        uuid.New().String(),
+		MediaID:   mediaID,
+		JobType:   jobType,
+		Status:    "processing",
+		Params:    params,
+		Attempts:  1,
+		CreatedAt: now,
+		UpdatedAt: now,
+		StartedAt: &now,
+	}
+
+	if err := s.repo.CreateProcessingJob(job); err != nil {
+		return nil, fmt.Errorf("failed to create processing job: %w", err)
+	}
+
+	return job, nil
+}
+
 // GetProcessingJobStatus 获取处理任务状态
 func (s *mediaService) GetProcessingJobStatus(jobID string) (*models.ProcessingJob, error) {
 	return s.repo.GetProcessingJob(jobID)
@@ -435,6 +695,28 @@ func (s *mediaService) extractMetadata(header *multipart.FileHeader, mimeType st
 	return metadata
 }
 
+// extractImagePlaceholder 解码图片，填充尺寸、BlurHash和内联预览图
+// 解码失败（如不支持的格式）不影响上传，仅记录日志
+func (s *mediaService) extractImagePlaceholder(mediaID string, file multipart.File, metadata *models.MediaMetadata) {
+	defer file.Seek(0, 0)
+
+	placeholder, err := imaging.GeneratePlaceholder(file, imaging.PlaceholderOptions{
+		ComponentsX: s.config.Image.BlurHashComponentsX,
+		ComponentsY: s.config.Image.BlurHashComponentsY,
+		PreviewSize: s.config.Image.PlaceholderSize,
+		MaxPixels:   s.config.Image.MaxPixels,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to generate image placeholder", zap.String("media_id", mediaID), zap.Error(err))
+		return
+	}
+
+	metadata.Width = &placeholder.Width
+	metadata.Height = &placeholder.Height
+	metadata.BlurHash = placeholder.BlurHash
+	metadata.Placeholder = placeholder.Preview
+}
+
 // checkUserQuota 检查用户配额
 func (s *mediaService) checkUserQuota(userID string, fileSize int64) error {
 	quota, err := s.repo.GetUserQuota(userID)
@@ -519,28 +801,8 @@ func (s *mediaService) isValidStatusTransition(from, to models.MediaStatus) bool
 	return false
 }
 
-// generateThumbnailAsync 异步生成缩略图
-func (s *mediaService) generateThumbnailAsync(mediaID string) {
-	// 这里应该实现实际的缩略图生成逻辑
-	// 可以使用图像处理库如imaging
-	s.logger.Info("Generating thumbnail", zap.String("media_id", mediaID))
-	
-	// 创建缩略图生成任务
-	params := map[string]interface{}{
-		"width":   200,
-		"height":  200,
-		"quality": 80,
-	}
-	
-	_, err := s.ProcessMedia(mediaID, "thumbnail", params)
-	if err != nil {
-		s.logger.Error("Failed to create thumbnail job", zap.String("media_id", mediaID), zap.Error(err))
-	}
-}
-
-// getThumbnailKey 获取缩略图存储键
+// getThumbnailKey 获取缩略图存储键，缩略图统一编码为JPEG
 func (s *mediaService) getThumbnailKey(originalKey string) string {
-	ext := filepath.Ext(originalKey)
-	base := strings.TrimSuffix(originalKey, ext)
-	return fmt.Sprintf("%s_thumb%s", base, ext)
+	base := strings.TrimSuffix(originalKey, filepath.Ext(originalKey))
+	return base + "_thumb.jpg"
 }
\ No newline at end of file
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+)
+
+// conversationTypeGroup 消息服务中群聊会话的类型，群组频道的会话都是群聊会话
+const conversationTypeGroup = "group"
+
+// ShareMedia 把文件附加到会话或授权给指定用户，附加到会话时所有者必须是会话参与者
+// 返回文件当前的全部共享记录，重复共享不会产生新记录
+func (s *mediaService) ShareMedia(userID, mediaID string, req *models.MediaShareRequest) ([]*models.MediaShare, error) {
+	if req.ConversationID == "" && len(req.UserIDs) == 0 {
+		return nil, fmt.Errorf("conversation_id or user_ids is required")
+	}
+	media, err := s.getOwnedMedia(userID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if media.Status == models.MediaStatusQuarantined {
+		return nil, fmt.Errorf("media is quarantined")
+	}
+
+	now := time.Now()
+	var shares []*models.MediaShare
+	if req.ConversationID != "" {
+		participant, err := s.messageClient.IsParticipant(req.ConversationID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check conversation participant: %w", err)
+		}
+		if !participant {
+			return nil, fmt.Errorf("access denied: not a participant of the conversation")
+		}
+		if err := s.checkGroupStorage(req.ConversationID, media); err != nil {
+			return nil, err
+		}
+		shares = append(shares, &models.MediaShare{ID: uuid.New().String(), MediaID: mediaID, ConversationID: req.ConversationID, CreatedBy: userID, CreatedAt: now})
+	}
+	for _, grantee := range req.UserIDs {
+		if grantee == userID {
+			continue
+		}
+		shares = append(shares, &models.MediaShare{ID: uuid.New().String(), MediaID: mediaID, UserID: grantee, CreatedBy: userID, CreatedAt: now})
+	}
+
+	if len(shares) > 0 {
+		if err := s.repo.CreateMediaShares(shares); err != nil {
+			return nil, err
+		}
+		s.logger.Info("Media shared",
+			zap.String("user_id", userID),
+			zap.String("media_id", mediaID),
+			zap.String("conversation_id", req.ConversationID),
+			zap.Int("users", len(req.UserIDs)),
+		)
+		if req.ConversationID != "" {
+			s.publishMediaAttached(media, req.ConversationID)
+		}
+	}
+	return s.repo.GetMediaShares(mediaID)
+}
+
+// checkGroupStorage 群聊会话检查所属群组的存储配额，单聊会话不检查
+// 群组服务找不到会话所属的群组时拒绝附加；群组服务不可用时不阻止分享，用量仍通过事件计入
+func (s *mediaService) checkGroupStorage(conversationID string, media *models.Media) error {
+	conversationType, err := s.messageClient.GetConversationType(conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation type: %w", err)
+	}
+	if conversationType != conversationTypeGroup {
+		return nil
+	}
+
+	allowed, err := s.groupClient.CheckStorage(conversationID, media.ID, media.FileSize)
+	switch {
+	case err != nil && strings.Contains(err.Error(), "group not found"):
+		return fmt.Errorf("access denied: conversation does not belong to a group")
+	case err != nil:
+		s.logger.Warn("Failed to check group storage quota",
+			zap.String("conversation_id", conversationID),
+			zap.String("media_id", media.ID),
+			zap.Error(err),
+		)
+	case !allowed:
+		return fmt.Errorf("group storage quota exceeded")
+	}
+	return nil
+}
+
+// ListMediaShares 获取文件的共享记录
+func (s *mediaService) ListMediaShares(userID, mediaID string) ([]*models.MediaShare, error) {
+	if _, err := s.getOwnedMedia(userID, mediaID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetMediaShares(mediaID)
+}
+
+// RevokeMediaShare 撤销共享，已下载的内容不受影响
+func (s *mediaService) RevokeMediaShare(userID, mediaID, shareID string) error {
+	media, err := s.getOwnedMedia(userID, mediaID)
+	if err != nil {
+		return err
+	}
+	shares, err := s.repo.GetMediaShares(mediaID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteMediaShare(mediaID, shareID); err != nil {
+		return err
+	}
+	for _, share := range shares {
+		if share.ID == shareID && share.ConversationID != "" {
+			s.publishMediaDetached(media, share.ConversationID)
+		}
+	}
+	s.logger.Info("Media share revoked", zap.String("user_id", userID), zap.String("media_id", mediaID), zap.String("share_id", shareID))
+	return nil
+}
+
+// checkSharedAccess 判断非所有者能否读取文件：直接授权给该用户，或附加到该用户参与的会话
+// 消息服务不可用且没有其他授权时返回错误而不是拒绝访问
+func (s *mediaService) checkSharedAccess(userID string, media *models.Media) error {
+	shares, err := s.repo.GetMediaShares(media.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get media shares: %w", err)
+	}
+
+	var conversations []string
+	for _, share := range shares {
+		if share.UserID == userID {
+			return nil
+		}
+		if share.ConversationID != "" {
+			conversations = append(conversations, share.ConversationID)
+		}
+	}
+
+	var lookupErr error
+	for _, conversationID := range conversations {
+		participant, err := s.messageClient.IsParticipant(conversationID, userID)
+		if err != nil {
+			s.logger.Warn("Failed to check conversation participant",
+				zap.String("conversation_id", conversationID),
+				zap.String("media_id", media.ID),
+				zap.Error(err),
+			)
+			lookupErr = err
+			continue
+		}
+		if participant {
+			return nil
+		}
+	}
+	if lookupErr != nil {
+		return fmt.Errorf("failed to check conversation participant: %w", lookupErr)
+	}
+	return fmt.Errorf("access denied")
+}
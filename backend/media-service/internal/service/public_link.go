@@ -0,0 +1,233 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/imaging"
+	"media-service/internal/models"
+)
+
+// CreatePublicLink 为自己的文件生成签名公开链接，任何人在有效期内都可以通过链接下载
+// 启用水印时图片通过链接返回带水印的副本，首次分享时在后台生成
+func (s *mediaService) CreatePublicLink(userID, mediaID string, req *models.PublicLinkRequest) (*models.MediaPublicLink, error) {
+	media, err := s.getOwnedMedia(userID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if media.Metadata != nil && media.Metadata.Encryption != nil {
+		return nil, fmt.Errorf("encrypted media cannot be shared publicly")
+	}
+	switch media.Status {
+	case models.MediaStatusQuarantined:
+		return nil, fmt.Errorf("media is quarantined")
+	case models.MediaStatusUploading, models.MediaStatusFailed, models.MediaStatusDeleted:
+		return nil, fmt.Errorf("media is not available")
+	}
+
+	ttl := s.config.PublicLink.DefaultTTLHours
+	if req != nil && req.TTLHours > 0 {
+		ttl = req.TTLHours
+	}
+	if maxTTL := s.config.PublicLink.MaxTTLHours; maxTTL > 0 && ttl > maxTTL {
+		return nil, fmt.Errorf("invalid ttl_hours: must not exceed %d", maxTTL)
+	}
+	expiresAt := time.Now().Add(time.Duration(ttl) * time.Hour).Truncate(time.Second)
+
+	watermarked := s.watermarkApplies(media)
+	if watermarked && (media.Metadata == nil || media.Metadata.Watermark == nil) {
+		storageKey := s.storageKey(media)
+		s.enqueueProcessing(JobImageWatermark, processingPayload{MediaID: media.ID, StorageKey: storageKey}, func() {
+			s.generateWatermarkAsync(media.ID, storageKey)
+		})
+	}
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("sig", s.signPublicLink(media.ID, expiresAt.Unix()))
+
+	s.logger.Info("Public link created",
+		zap.String("user_id", userID),
+		zap.String("media_id", media.ID),
+		zap.Time("expires_at", expiresAt),
+		zap.Bool("watermarked", watermarked),
+	)
+	return &models.MediaPublicLink{
+		URL:         "/api/v1/media/public/" + url.PathEscape(media.ID) + "?" + query.Encode(),
+		ExpiresAt:   expiresAt,
+		Watermarked: watermarked,
+	}, nil
+}
+
+// VerifyPublicLink 校验公开链接的签名和有效期
+func (s *mediaService) VerifyPublicLink(mediaID string, expires int64, signature string) bool {
+	if time.Now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.signPublicLink(mediaID, expires)))
+}
+
+// ResolvePublicContent 解析公开链接对应的文件，启用水印的图片只返回带水印的副本
+// 文件已删除、被隔离或不可用时统一返回not found，不向匿名访问者透露原因
+func (s *mediaService) ResolvePublicContent(mediaID string) (*models.MediaContent, error) {
+	media, err := s.repo.GetMediaByID(mediaID)
+	if err != nil || media.Status != models.MediaStatusReady || (media.Metadata != nil && media.Metadata.Encryption != nil) {
+		return nil, fmt.Errorf("media not found")
+	}
+
+	content := &models.MediaContent{
+		MediaID:     media.ID,
+		StorageKey:  s.storageKey(media),
+		ContentType: media.MimeType,
+		Filename:    media.OriginalName,
+		UpdatedAt:   media.UpdatedAt,
+		MaxAge:      s.config.Storage.ContentCacheSeconds,
+		ETag:        fmt.Sprintf(`"%s"`, media.ID),
+	}
+	if media.Metadata != nil && media.Metadata.Checksum != "" {
+		content.ETag = fmt.Sprintf(`"%s"`, media.Metadata.Checksum)
+	}
+
+	watermarked := s.watermarkApplies(media)
+	if watermarked {
+		if media.Metadata == nil || media.Metadata.Watermark == nil {
+			return nil, fmt.Errorf("watermark is being generated")
+		}
+		content.StorageKey = s.getWatermarkKey(content.StorageKey)
+		content.ContentType = media.Metadata.Watermark.MimeType
+		content.Filename = strings.TrimSuffix(media.OriginalName, filepath.Ext(media.OriginalName)) + ".jpg"
+	}
+	if err := s.statContent(content); err != nil {
+		return nil, err
+	}
+	if watermarked {
+		// 修改水印配置后重新生成的副本大小通常不同
+		content.ETag = fmt.Sprintf(`"%s-watermark-%d"`, media.ID, content.Size)
+	}
+	return content, nil
+}
+
+// generateWatermarkAsync 异步生成带水印的图片副本
+func (s *mediaService) generateWatermarkAsync(mediaID, storageKey string) {
+	params := map[string]interface{}{
+		"text":     s.config.Watermark.Text,
+		"logo":     s.config.Watermark.LogoPath,
+		"position": s.config.Watermark.Position,
+		"opacity":  s.config.Watermark.Opacity,
+		"scale":    s.config.Watermark.Scale,
+	}
+
+	job, err := s.startProcessingJob(mediaID, models.JobTypeWatermark, params)
+	if err != nil {
+		s.logger.Error("Failed to create watermark job", zap.String("media_id", mediaID), zap.Error(err))
+		return
+	}
+
+	result, err := s.runWatermarkJob(mediaID, storageKey)
+	if err != nil {
+		errMsg := err.Error()
+		s.repo.UpdateProcessingJob(job.ID, "failed", nil, &errMsg)
+		s.logger.Error("Watermark generation failed",
+			zap.String("media_id", mediaID),
+			zap.String("job_id", job.ID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	s.repo.UpdateProcessingJob(job.ID, "completed", result, nil)
+	s.logger.Info("Watermark generated", zap.String("media_id", mediaID), zap.String("job_id", job.ID))
+}
+
+// runWatermarkJob 读取原图叠加水印，副本与原图存放在一起并写入媒体元数据，原图不做修改
+func (s *mediaService) runWatermarkJob(mediaID, storageKey string) (map[string]interface{}, error) {
+	opts := imaging.WatermarkOptions{
+		Text:     s.config.Watermark.Text,
+		Position: s.config.Watermark.Position,
+		Opacity:  s.config.Watermark.Opacity,
+		Scale:    s.config.Watermark.Scale,
+		Quality:  s.config.Image.ImageQuality,
+	}
+	if s.config.Watermark.LogoPath != "" {
+		logo, err := imaging.LoadWatermarkLogo(s.config.Watermark.LogoPath)
+		if err != nil {
+			return nil, err
+		}
+		opts.Logo = logo
+	}
+
+	reader, err := s.storageProvider.DownloadFile(storageKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	img, _, err := imaging.Decode(reader, s.config.Image.MaxPixels)
+	reader.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rendition, err := imaging.ApplyWatermark(img, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	watermarkKey := s.getWatermarkKey(storageKey)
+	if _, err := s.storageProvider.UploadFile(watermarkKey, memoryFile{bytes.NewReader(rendition.Data)}, int64(len(rendition.Data)), "image/jpeg"); err != nil {
+		return nil, fmt.Errorf("failed to upload watermark: %w", err)
+	}
+
+	media, err := s.repo.GetMediaByID(mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	metadata := media.Metadata
+	if metadata == nil {
+		metadata = &models.MediaMetadata{}
+	}
+	metadata.Watermark = &models.MediaVariant{
+		URL:      s.fileURL(mediaID, watermarkKey, contentVariantWatermark),
+		Width:    rendition.Width,
+		Height:   rendition.Height,
+		Size:     int64(len(rendition.Data)),
+		MimeType: "image/jpeg",
+	}
+
+	if err := s.repo.UpdateMedia(mediaID, &models.MediaUpdateRequest{Metadata: metadata}); err != nil {
+		return nil, fmt.Errorf("failed to update media metadata: %w", err)
+	}
+
+	return map[string]interface{}{
+		"watermark": metadata.Watermark,
+	}, nil
+}
+
+// watermarkApplies 公开链接是否返回带水印的副本，只处理图片
+func (s *mediaService) watermarkApplies(media *models.Media) bool {
+	return s.config.Watermark.Enabled && media.MediaType == models.MediaTypeImage
+}
+
+// getWatermarkKey 获取带水印图片的存储键
+func (s *mediaService) getWatermarkKey(originalKey string) string {
+	return strings.TrimSuffix(originalKey, filepath.Ext(originalKey)) + "_watermark.jpg"
+}
+
+// signPublicLink 计算公开链接签名，未配置公开链接签名密钥时使用JWT密钥
+func (s *mediaService) signPublicLink(mediaID string, expires int64) string {
+	key := s.config.PublicLink.SigningKey
+	if key == "" {
+		key = s.config.JWT.SecretKey
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	fmt.Fprintf(mac, "public\n%s\n%d", mediaID, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"fmt"
+	"mime/multipart"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+)
+
+// scanFile 调用扫描钩子检查上传文件，扫描失败时放行并记录日志
+func (s *mediaService) scanFile(mediaID string, file multipart.File) *models.ScanResult {
+	if s.scanner == nil {
+		return nil
+	}
+	defer file.Seek(0, 0)
+
+	result, err := s.scanner.Scan(file)
+	if err != nil {
+		s.logger.Warn("Failed to scan file", zap.String("media_id", mediaID), zap.Error(err))
+		return nil
+	}
+	if result.IsThreat() {
+		s.logger.Warn("Threat detected, media quarantined",
+			zap.String("media_id", mediaID),
+			zap.String("verdict", string(result.Verdict)),
+			zap.String("signature", result.Signature),
+		)
+	}
+	return result
+}
+
+// redactQuarantined 隐藏隔离中媒体的文件地址和预览，所有者只能看到隔离状态和扫描结果
+func redactQuarantined(media *models.Media) {
+	if media.Status != models.MediaStatusQuarantined {
+		return
+	}
+	media.PublicURL = ""
+	media.ThumbnailURL = nil
+	if media.Metadata == nil {
+		return
+	}
+	metadata := *media.Metadata
+	metadata.Placeholder = ""
+	metadata.Variants = nil
+	metadata.Srcset = ""
+	metadata.Watermark = nil
+	metadata.SpriteURL = ""
+	metadata.SpriteVTTURL = ""
+	metadata.Renditions = nil
+	metadata.PosterURL = ""
+	media.Metadata = &metadata
+}
+
+// ListQuarantinedMedia 列出所有用户中被隔离的媒体文件
+func (s *mediaService) ListQuarantinedMedia(req *models.QuarantineListRequest) (*models.QuarantineListResponse, error) {
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 20
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	medias, total, err := s.repo.GetMediaByStatus(models.MediaStatusQuarantined, req.Limit, req.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined media: %w", err)
+	}
+
+	return &models.QuarantineListResponse{
+		Medias: medias,
+		Total:  total,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}, nil
+}
+
+// GetQuarantinedMedia 获取被隔离的媒体文件详情
+func (s *mediaService) GetQuarantinedMedia(mediaID string) (*models.Media, error) {
+	media, err := s.repo.GetMediaByID(mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if media.Status != models.MediaStatusQuarantined {
+		return nil, fmt.Errorf("media is not quarantined")
+	}
+	return media, nil
+}
+
+// ReleaseQuarantinedMedia 将误报的媒体文件移出隔离区
+func (s *mediaService) ReleaseQuarantinedMedia(adminID, mediaID string) (*models.Media, error) {
+	media, err := s.GetQuarantinedMedia(mediaID)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := media.Metadata
+	if metadata == nil {
+		metadata = &models.MediaMetadata{}
+	}
+	if metadata.VirusScan != nil {
+		now := time.Now()
+		metadata.VirusScan.ReviewedBy = adminID
+		metadata.VirusScan.ReviewedAt = &now
+	}
+
+	status := models.MediaStatusReady
+	if err := s.repo.UpdateMedia(mediaID, &models.MediaUpdateRequest{Status: &status, Metadata: metadata}); err != nil {
+		return nil, fmt.Errorf("failed to release media: %w", err)
+	}
+	media.Status = status
+	media.Metadata = metadata
+
+	s.logger.Info("Quarantined media released",
+		zap.String("admin_id", adminID),
+		zap.String("media_id", mediaID),
+	)
+	return media, nil
+}
+
+// PurgeQuarantinedMedia 删除确认为恶意的媒体文件并通知文件所有者
+func (s *mediaService) PurgeQuarantinedMedia(adminID, mediaID string) error {
+	media, err := s.GetQuarantinedMedia(mediaID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteMedia(mediaID); err != nil {
+		return fmt.Errorf("failed to delete media record: %w", err)
+	}
+	for _, key := range s.storedFileKeys(media) {
+		if err := s.storageProvider.DeleteFile(key); err != nil {
+			s.logger.Error("Failed to delete file from storage",
+				zap.String("media_id", mediaID),
+				zap.String("key", key),
+				zap.Error(err),
+			)
+		}
+	}
+	s.updateUserQuota(media.UserID, -media.FileSize, -1)
+	s.updateTenantQuota(media.TenantID, -media.FileSize, -1)
+
+	s.logger.Info("Quarantined media purged",
+		zap.String("admin_id", adminID),
+		zap.String("media_id", mediaID),
+		zap.String("user_id", media.UserID),
+	)
+
+	go s.notifyMediaPurged(media)
+	return nil
+}
+
+// notifyMediaPurged 通知文件所有者其文件因安全原因被删除
+func (s *mediaService) notifyMediaPurged(media *models.Media) {
+	if s.notifier == nil {
+		return
+	}
+
+	data := map[string]interface{}{
+		"media_id":      media.ID,
+		"original_name": media.OriginalName,
+	}
+	if media.Metadata != nil && media.Metadata.VirusScan != nil {
+		data["signature"] = media.Metadata.VirusScan.Signature
+	}
+
+	body := fmt.Sprintf("Your file %q was removed because it was identified as malicious.", media.OriginalName)
+	if err := s.notifier.SendSystemNotification(media.UserID, "File removed", body, data); err != nil {
+		s.logger.Warn("Failed to notify media owner",
+			zap.String("media_id", media.ID),
+			zap.String("user_id", media.UserID),
+			zap.Error(err),
+		)
+	}
+}
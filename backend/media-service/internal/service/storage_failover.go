@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+)
+
+// replicationPayload 存储复制任务参数
+type replicationPayload struct {
+	Key    string `json:"key"`
+	Target string `json:"target"`
+}
+
+// scheduleReplication 将文件复制写入任务队列，服务重启后未执行的复制继续执行
+// 写入队列失败时退回为直接在后台复制，仍失败的由修复任务补齐
+func (s *mediaService) scheduleReplication(key, target string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	payload := replicationPayload{Key: key, Target: target}
+	if err := s.jobs.Enqueue(ctx, JobStorageReplicate, payload); err != nil {
+		s.logger.Warn("Failed to enqueue storage replication job, running in background",
+			zap.String("key", key),
+			zap.String("target", target),
+			zap.Error(err),
+		)
+		go func() {
+			if err := s.tenantStorage.Replicate(key, target); err != nil {
+				s.logger.Warn("Failed to replicate file", zap.String("key", key), zap.String("target", target), zap.Error(err))
+			}
+		}()
+	}
+}
+
+// RepairStorageReplicas 检查一批可用文件（含衍生文件）是否同时存在于主存储和备用存储，缺失时从另一个存储复制
+// 用于补齐启用备用存储前上传的文件，以及多次重试仍失败的复制；检查完全部文件后从头开始，返回修复的文件数
+func (s *mediaService) RepairStorageReplicas() (int, error) {
+	if !s.tenantStorage.FailoverEnabled() {
+		return 0, nil
+	}
+
+	s.repairMu.Lock()
+	defer s.repairMu.Unlock()
+
+	batchSize := s.config.Failover.RepairBatchSize
+	if batchSize <= 0 {
+		batchSize = purgeBatchSize
+	}
+	medias, total, err := s.repo.GetMediaByStatus(models.MediaStatusReady, batchSize, s.repairOffset)
+	if err != nil {
+		return 0, err
+	}
+	s.repairOffset += len(medias)
+	if len(medias) == 0 || s.repairOffset >= total {
+		s.repairOffset = 0
+	}
+
+	repaired := 0
+	for _, media := range medias {
+		for _, key := range s.storedFileKeys(media) {
+			target, err := s.tenantStorage.Repair(key)
+			if err != nil {
+				s.logger.Warn("Failed to repair storage replica",
+					zap.String("media_id", media.ID),
+					zap.String("key", key),
+					zap.Error(err),
+				)
+				continue
+			}
+			if target != "" {
+				repaired++
+				s.logger.Info("Storage replica repaired",
+					zap.String("media_id", media.ID),
+					zap.String("key", key),
+					zap.String("target", target),
+				)
+			}
+		}
+	}
+	return repaired, nil
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+)
+
+const (
+	// defaultMigrationBatch 单次迁移默认处理的文件数
+	defaultMigrationBatch = 100
+	// maxMigrationBatch 单次迁移最多处理的文件数
+	maxMigrationBatch = 1000
+)
+
+// resolveTenantID 校验租户ID，为空时使用默认租户
+func (s *mediaService) resolveTenantID(tenantID string) (string, error) {
+	if tenantID == "" {
+		return models.DefaultTenantID, nil
+	}
+	if !models.IsValidTenantID(tenantID) {
+		return "", fmt.Errorf("invalid tenant id")
+	}
+	return tenantID, nil
+}
+
+// storageKey 获取媒体文件的存储键，兼容storage_path中带有本地存储目录的记录
+func (s *mediaService) storageKey(media *models.Media) string {
+	return strings.TrimPrefix(media.StoragePath, s.config.Storage.LocalPath+"/")
+}
+
+// storagePath 根据存储键生成媒体记录中的storage_path
+func (s *mediaService) storagePath(key string) string {
+	return s.config.Storage.LocalPath + "/" + key
+}
+
+// getTenantQuota 获取租户配额，不存在时按默认值创建
+func (s *mediaService) getTenantQuota(tenantID string) (*models.TenantStorageQuota, error) {
+	quota, err := s.repo.GetTenantQuota(tenantID)
+	if err == nil {
+		return quota, nil
+	}
+
+	quota = &models.TenantStorageQuota{
+		TenantID:     tenantID,
+		TotalQuota:   s.config.Tenancy.DefaultQuota,
+		MaxFileCount: s.config.Tenancy.DefaultMaxFileCount,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := s.repo.CreateTenantQuota(quota); err != nil {
+		return nil, fmt.Errorf("failed to create tenant quota: %w", err)
+	}
+	// 并发创建时以已存在的记录为准
+	if existing, err := s.repo.GetTenantQuota(tenantID); err == nil {
+		return existing, nil
+	}
+	return quota, nil
+}
+
+// checkTenantQuota 检查租户配额
+func (s *mediaService) checkTenantQuota(tenantID string, fileSize int64) error {
+	quota, err := s.getTenantQuota(tenantID)
+	if err != nil {
+		return err
+	}
+
+	if quota.UsedQuota+fileSize > quota.TotalQuota {
+		return fmt.Errorf("tenant storage quota exceeded: used %d + %d > %d", quota.UsedQuota, fileSize, quota.TotalQuota)
+	}
+	if quota.FileCount >= quota.MaxFileCount {
+		return fmt.Errorf("tenant file count limit exceeded: %d >= %d", quota.FileCount, quota.MaxFileCount)
+	}
+
+	return nil
+}
+
+// updateTenantQuota 更新租户配额
+func (s *mediaService) updateTenantQuota(tenantID string, sizeChange int64, countChange int) {
+	if tenantID == "" {
+		tenantID = models.DefaultTenantID
+	}
+
+	quota, err := s.getTenantQuota(tenantID)
+	if err != nil {
+		s.logger.Error("Failed to get tenant quota for update", zap.String("tenant_id", tenantID), zap.Error(err))
+		return
+	}
+
+	newUsedQuota := quota.UsedQuota + sizeChange
+	newFileCount := quota.FileCount + countChange
+
+	if newUsedQuota < 0 {
+		newUsedQuota = 0
+	}
+	if newFileCount < 0 {
+		newFileCount = 0
+	}
+
+	if err := s.repo.UpdateTenantQuota(tenantID, newUsedQuota, newFileCount); err != nil {
+		s.logger.Error("Failed to update tenant quota", zap.String("tenant_id", tenantID), zap.Error(err))
+	}
+}
+
+// GetTenantStorageStats 获取租户存储统计
+func (s *mediaService) GetTenantStorageStats(tenantID string) (*models.TenantStorageStats, error) {
+	tenantID, err := s.resolveTenantID(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.getTenantQuota(tenantID); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.repo.GetTenantStorageStats(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant storage stats: %w", err)
+	}
+	stats.Bucket = s.tenantStorage.Bucket(tenantID)
+	stats.KeyPrefix = s.tenantStorage.KeyPrefix(tenantID)
+
+	return stats, nil
+}
+
+// UpdateTenantQuota 调整租户配额上限
+func (s *mediaService) UpdateTenantQuota(tenantID string, req *models.TenantQuotaUpdateRequest) (*models.TenantStorageQuota, error) {
+	tenantID, err := s.resolveTenantID(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	quota, err := s.getTenantQuota(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TotalQuota != nil {
+		if *req.TotalQuota <= 0 {
+			return nil, fmt.Errorf("total_quota must be positive")
+		}
+		quota.TotalQuota = *req.TotalQuota
+	}
+	if req.MaxFileCount != nil {
+		if *req.MaxFileCount <= 0 {
+			return nil, fmt.Errorf("max_file_count must be positive")
+		}
+		quota.MaxFileCount = *req.MaxFileCount
+	}
+
+	if err := s.repo.UpdateTenantQuotaLimits(tenantID, quota.TotalQuota, quota.MaxFileCount); err != nil {
+		return nil, fmt.Errorf("failed to update tenant quota: %w", err)
+	}
+	quota.UpdatedAt = time.Now()
+
+	return quota, nil
+}
+
+// RehomeTenantMedia 把文件迁移到租户当前配置的存储桶和前缀下
+// 每次处理一批，文件先复制到新位置，记录更新成功后再删除旧文件，失败的文件保持原样可重试
+func (s *mediaService) RehomeTenantMedia(adminID, tenantID string, req *models.TenantMigrationRequest) (*models.TenantMigrationResult, error) {
+	tenantID, err := s.resolveTenantID(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultMigrationBatch
+	}
+	if limit > maxMigrationBatch {
+		limit = maxMigrationBatch
+	}
+
+	pathPrefix := s.storagePath(s.tenantStorage.KeyPrefix(tenantID))
+	medias, err := s.repo.ListMediaForRehome(tenantID, pathPrefix, req.UserIDs, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list media for migration: %w", err)
+	}
+
+	result := &models.TenantMigrationResult{
+		TenantID:  tenantID,
+		DryRun:    req.DryRun,
+		Scanned:   len(medias),
+		Remaining: len(medias) == limit,
+		Items:     make([]*models.TenantMigrationItem, 0, len(medias)),
+	}
+
+	for _, media := range medias {
+		fromKey := s.storageKey(media)
+		item := &models.TenantMigrationItem{
+			MediaID:    media.ID,
+			FromTenant: media.TenantID,
+			FromKey:    fromKey,
+			ToKey:      s.rehomedKey(tenantID, media.UserID, fromKey),
+		}
+		result.Items = append(result.Items, item)
+
+		if req.DryRun {
+			continue
+		}
+
+		if err := s.rehomeMedia(media, tenantID, item.ToKey); err != nil {
+			item.Error = err.Error()
+			result.Failed++
+			s.logger.Error("Failed to migrate media to tenant storage",
+				zap.String("media_id", media.ID),
+				zap.String("tenant_id", tenantID),
+				zap.Error(err),
+			)
+			continue
+		}
+		result.Migrated++
+	}
+
+	s.logger.Info("Tenant media migration batch finished",
+		zap.String("admin_id", adminID),
+		zap.String("tenant_id", tenantID),
+		zap.Bool("dry_run", req.DryRun),
+		zap.Int("scanned", result.Scanned),
+		zap.Int("migrated", result.Migrated),
+		zap.Int("failed", result.Failed),
+	)
+
+	return result, nil
+}
+
+// rehomedKey 计算文件在租户存储中的新键，保留 users/<userID>/ 之后的路径
+func (s *mediaService) rehomedKey(tenantID, userID, key string) string {
+	relative := key
+	if idx := strings.Index(key, "users/"+userID+"/"); idx >= 0 {
+		relative = key[idx:]
+	}
+	return s.tenantStorage.TenantKey(tenantID, relative)
+}
+
+// rehomeMedia 迁移单个文件及其各尺寸缩略图、水印图片和视频转码文件，视频雪碧图在新位置重新生成
+func (s *mediaService) rehomeMedia(media *models.Media, tenantID, toKey string) error {
+	fromKey := s.storageKey(media)
+	fromTenant := media.TenantID
+	moves := map[string]string{fromKey: toKey}
+	if media.ThumbnailURL != nil && *media.ThumbnailURL != "" {
+		moves[s.getThumbnailKey(fromKey)] = s.getThumbnailKey(toKey)
+	}
+	if media.Metadata != nil {
+		for name := range media.Metadata.Variants {
+			if preset, err := strconv.Atoi(name); err == nil {
+				moves[s.getVariantKey(fromKey, preset)] = s.getVariantKey(toKey, preset)
+			}
+		}
+		if media.Metadata.Watermark != nil {
+			moves[s.getWatermarkKey(fromKey)] = s.getWatermarkKey(toKey)
+		}
+		for name := range media.Metadata.Renditions {
+			moves[s.getTranscodeKey(fromKey, name)] = s.getTranscodeKey(toKey, name)
+		}
+		if media.Metadata.PosterURL != "" {
+			moves[s.getPosterKey(fromKey)] = s.getPosterKey(toKey)
+		}
+	}
+
+	var copied []string
+	rollback := func() {
+		for _, key := range copied {
+			s.storageProvider.DeleteFile(key)
+		}
+	}
+	if fromKey != toKey {
+		for from, to := range moves {
+			if err := s.storageProvider.CopyFile(from, to); err != nil {
+				rollback()
+				return fmt.Errorf("failed to copy %s: %w", from, err)
+			}
+			copied = append(copied, to)
+		}
+	}
+
+	updated := *media
+	updated.TenantID = tenantID
+	updated.StoragePath = s.storagePath(toKey)
+	// VTT文件内嵌雪碧图地址，不能直接复制，清空后重新生成
+	hadSprite := media.Metadata != nil && media.Metadata.SpriteURL != ""
+	if hadSprite && fromKey != toKey {
+		metadata := *media.Metadata
+		metadata.SpriteURL = ""
+		metadata.SpriteVTTURL = ""
+		updated.Metadata = &metadata
+	}
+	s.applyFileURLs(&updated, toKey)
+
+	if err := s.repo.UpdateMediaLocation(&updated); err != nil {
+		rollback()
+		return err
+	}
+
+	if fromKey != toKey {
+		for from := range moves {
+			if err := s.storageProvider.DeleteFile(from); err != nil {
+				s.logger.Warn("Failed to delete migrated file from old location",
+					zap.String("media_id", media.ID),
+					zap.String("key", from),
+					zap.Error(err),
+				)
+			}
+		}
+		if hadSprite {
+			spriteKey, vttKey := s.getSpriteKeys(fromKey)
+			s.storageProvider.DeleteFile(spriteKey)
+			s.storageProvider.DeleteFile(vttKey)
+			if media.Status == models.MediaStatusReady && s.config.Video.SpriteEnabled {
+				s.enqueueProcessing(JobVideoSprite, processingPayload{MediaID: media.ID, StorageKey: toKey}, func() {
+					s.generateVideoSpriteAsync(media.ID, toKey)
+				})
+			}
+		}
+	}
+
+	if fromTenant != tenantID {
+		s.updateTenantQuota(fromTenant, -media.FileSize, -1)
+		s.updateTenantQuota(tenantID, media.FileSize, 1)
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/imaging"
+	"media-service/internal/models"
+)
+
+// maxThumbnailBackoff 缩略图任务失败重试的最长退避时间
+const maxThumbnailBackoff = time.Hour
+
+// permanentError 重试也无法成功的错误，如原图无法解码或媒体已删除，任务直接标记为失败
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// permanent 将错误标记为不可重试
+func permanent(format string, args ...interface{}) error {
+	return &permanentError{err: fmt.Errorf(format, args...)}
+}
+
+// StartThumbnailWorkers 启动缩略图工作池：轮询ProcessMedia创建的待处理缩略图任务，
+// 生成缩略图上传到存储并回写媒体的thumbnail_url；ctx取消后停止领取新任务
+func (s *mediaService) StartThumbnailWorkers(ctx context.Context) {
+	workers := max(1, s.config.Image.ThumbnailWorkers)
+	interval := time.Duration(max(1, s.config.Image.ThumbnailPollInterval)) * time.Second
+	queue := make(chan *models.ProcessingJob)
+
+	for i := 0; i < workers; i++ {
+		s.thumbnailWorkers.Add(1)
+		go func() {
+			defer s.thumbnailWorkers.Done()
+			for job := range queue {
+				s.runThumbnailJob(job)
+			}
+		}()
+	}
+
+	s.thumbnailWorkers.Add(1)
+	go func() {
+		defer s.thumbnailWorkers.Done()
+		defer close(queue)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			// 取满一批说明还有积压，不等待下一次轮询
+			for s.pollThumbnailJobs(ctx, queue, workers) {
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	s.logger.Info("Thumbnail workers started", zap.Int("workers", workers), zap.Duration("poll_interval", interval))
+}
+
+// WaitThumbnailWorkers 等待工作池退出，执行中的任务会先完成
+func (s *mediaService) WaitThumbnailWorkers() {
+	s.thumbnailWorkers.Wait()
+}
+
+// pollThumbnailJobs 获取一批到期的待处理任务交给工作者，返回是否取满一批
+func (s *mediaService) pollThumbnailJobs(ctx context.Context, queue chan<- *models.ProcessingJob, limit int) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	jobs, err := s.repo.GetPendingJobs(models.JobTypeThumbnail, limit)
+	if err != nil {
+		s.logger.Error("Failed to get pending thumbnail jobs", zap.Error(err))
+		return false
+	}
+
+	for _, job := range jobs {
+		select {
+		case queue <- job:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return len(jobs) == limit
+}
+
+// runThumbnailJob 领取并执行缩略图任务，失败时按指数退避放回待处理，达到最多执行次数后标记为失败
+func (s *mediaService) runThumbnailJob(pending *models.ProcessingJob) {
+	job, err := s.repo.ClaimProcessingJob(pending.ID)
+	if err != nil {
+		s.logger.Error("Failed to claim thumbnail job", zap.String("job_id", pending.ID), zap.Error(err))
+		return
+	}
+	if job == nil {
+		// 已被其他实例领取
+		return
+	}
+
+	result, err := s.generateThumbnail(job)
+	if err == nil {
+		s.repo.UpdateProcessingJob(job.ID, "completed", result, nil)
+		s.logger.Info("Thumbnail generated",
+			zap.String("media_id", job.MediaID),
+			zap.String("job_id", job.ID),
+			zap.Int("attempts", job.Attempts),
+		)
+		return
+	}
+
+	errMsg := err.Error()
+	var perm *permanentError
+	if errors.As(err, &perm) || job.Attempts >= max(1, s.config.Image.ThumbnailMaxAttempts) {
+		s.repo.UpdateProcessingJob(job.ID, "failed", nil, &errMsg)
+		s.logger.Error("Thumbnail generation failed",
+			zap.String("media_id", job.MediaID),
+			zap.String("job_id", job.ID),
+			zap.Int("attempts", job.Attempts),
+			zap.Error(err),
+		)
+		return
+	}
+
+	backoff := s.thumbnailBackoff(job.Attempts)
+	if err := s.repo.RetryProcessingJob(job.ID, errMsg, time.Now().Add(backoff)); err != nil {
+		s.logger.Error("Failed to reschedule thumbnail job", zap.String("job_id", job.ID), zap.Error(err))
+		return
+	}
+	s.logger.Warn("Thumbnail generation failed, retrying",
+		zap.String("media_id", job.MediaID),
+		zap.String("job_id", job.ID),
+		zap.Int("attempts", job.Attempts),
+		zap.Duration("backoff", backoff),
+		zap.Error(err),
+	)
+}
+
+// thumbnailBackoff 第attempts次失败后的退避时间：首次为配置值，之后逐次翻倍，不超过maxThumbnailBackoff
+func (s *mediaService) thumbnailBackoff(attempts int) time.Duration {
+	backoff := time.Duration(max(1, s.config.Image.ThumbnailRetryBackoff)) * time.Second
+	for i := 1; i < attempts && backoff < maxThumbnailBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxThumbnailBackoff)
+}
+
+// generateThumbnail 下载原图按任务参数生成JPEG缩略图，与原图存放在一起并回写媒体的thumbnail_url
+func (s *mediaService) generateThumbnail(job *models.ProcessingJob) (map[string]interface{}, error) {
+	media, err := s.repo.GetMediaByID(job.MediaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	switch {
+	case media.Status == models.MediaStatusDeleted || media.Status == models.MediaStatusQuarantined:
+		return nil, permanent("media is %s", media.Status)
+	case media.MediaType != models.MediaTypeImage:
+		return nil, permanent("thumbnails can only be generated for images")
+	case media.IsEncrypted():
+		return nil, permanent("thumbnails cannot be generated for encrypted media")
+	}
+
+	width := jobParamInt(job.Params, "width", s.config.Image.ThumbnailWidth)
+	height := jobParamInt(job.Params, "height", s.config.Image.ThumbnailHeight)
+	quality := jobParamInt(job.Params, "quality", s.config.Image.ImageQuality)
+
+	tmpDir, err := os.MkdirTemp("", "media-thumbnail-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// 先完整下载再解码，区分存储读取失败（可重试）和图片本身无法解码
+	storageKey := s.storageKey(media)
+	inputPath := filepath.Join(tmpDir, "input"+filepath.Ext(storageKey))
+	if err := s.downloadToFile(storageKey, inputPath); err != nil {
+		return nil, err
+	}
+
+	input, err := os.Open(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	img, _, err := imaging.Decode(input, s.config.Image.MaxPixels)
+	input.Close()
+	if err != nil {
+		return nil, permanent("%w", err)
+	}
+	thumbnail, err := imaging.Thumbnail(img, width, height, quality)
+	if err != nil {
+		return nil, permanent("%w", err)
+	}
+
+	thumbnailPath := filepath.Join(tmpDir, "thumbnail.jpg")
+	if err := os.WriteFile(thumbnailPath, thumbnail.Data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write thumbnail: %w", err)
+	}
+	thumbnailKey := s.getThumbnailKey(storageKey)
+	if _, err := s.uploadLocalFile(thumbnailKey, thumbnailPath, "image/jpeg"); err != nil {
+		return nil, err
+	}
+
+	thumbnailURL := s.fileURL(media.ID, thumbnailKey, contentVariantThumbnail)
+	if err := s.repo.UpdateMedia(media.ID, &models.MediaUpdateRequest{ThumbnailURL: &thumbnailURL}); err != nil {
+		return nil, fmt.Errorf("failed to update media thumbnail: %w", err)
+	}
+
+	return map[string]interface{}{
+		"thumbnail_url": thumbnailURL,
+		"width":         thumbnail.Width,
+		"height":        thumbnail.Height,
+		"size":          len(thumbnail.Data),
+	}, nil
+}
+
+// jobParamInt 读取任务参数中的正整数，经过JSON往返后数字为float64；缺失或非正数时使用默认值
+func jobParamInt(params map[string]interface{}, key string, fallback int) int {
+	var value int
+	switch v := params[key].(type) {
+	case int:
+		value = v
+	case float64:
+		value = int(v)
+	}
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+)
+
+// transcriptionTimeout 单个转写任务的默认最长执行时间
+const transcriptionTimeout = 5 * time.Minute
+
+// transcriptPushDelays 推送转写文本到消息服务的重试间隔
+// 语音消息可能在转写完成之后才发送，此时消息服务没有可更新的消息，稍后重试
+var transcriptPushDelays = []time.Duration{0, 30 * time.Second, 2 * time.Minute}
+
+// transcribeAudioAsync 异步转写语音文件，结果写入媒体元数据并推送到消息服务
+func (s *mediaService) transcribeAudioAsync(mediaID, storageKey string) {
+	params := map[string]interface{}{
+		"provider": s.config.Transcription.Provider,
+		"language": s.config.Transcription.Language,
+	}
+
+	job, err := s.startProcessingJob(mediaID, models.JobTypeTranscription, params)
+	if err != nil {
+		s.logger.Error("Failed to create transcription job", zap.String("media_id", mediaID), zap.Error(err))
+		return
+	}
+
+	transcript, err := s.runTranscriptionJob(mediaID, storageKey)
+	if err != nil {
+		errMsg := err.Error()
+		s.repo.UpdateProcessingJob(job.ID, "failed", nil, &errMsg)
+		s.logger.Error("Transcription failed",
+			zap.String("media_id", mediaID),
+			zap.String("job_id", job.ID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	s.repo.UpdateProcessingJob(job.ID, "completed", map[string]interface{}{
+		"language": transcript.Language,
+		"duration": transcript.Duration,
+		"length":   len([]rune(transcript.Text)),
+	}, nil)
+	s.logger.Info("Audio transcribed", zap.String("media_id", mediaID), zap.String("job_id", job.ID))
+
+	if transcript.Text != "" {
+		s.pushTranscript(mediaID, transcript)
+	}
+}
+
+// runTranscriptionJob 下载音频到临时目录进行转写，并写入媒体元数据的transcript
+func (s *mediaService) runTranscriptionJob(mediaID, storageKey string) (*models.Transcript, error) {
+	tmpDir, err := os.MkdirTemp("", "media-transcribe-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inputPath := filepath.Join(tmpDir, "input"+filepath.Ext(storageKey))
+	if err := s.downloadToFile(storageKey, inputPath); err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(s.config.Transcription.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = transcriptionTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	transcript, err := s.transcriber.Transcribe(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	media, err := s.repo.GetMediaByID(mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	metadata := media.Metadata
+	if metadata == nil {
+		metadata = &models.MediaMetadata{}
+	}
+	if metadata.Duration == nil && transcript.Duration > 0 {
+		metadata.Duration = &transcript.Duration
+	}
+	metadata.Transcript = transcript
+
+	if err := s.repo.UpdateMedia(mediaID, &models.MediaUpdateRequest{Metadata: metadata}); err != nil {
+		return nil, fmt.Errorf("failed to update media metadata: %w", err)
+	}
+
+	return transcript, nil
+}
+
+// pushTranscript 把转写文本推送到消息服务，用于语音消息搜索和无障碍显示
+func (s *mediaService) pushTranscript(mediaID string, transcript *models.Transcript) {
+	for attempt, delay := range transcriptPushDelays {
+		time.Sleep(delay)
+
+		updated, err := s.messageClient.AttachTranscript(mediaID, transcript)
+		if err != nil {
+			s.logger.Warn("Failed to push transcript to message service",
+				zap.String("media_id", mediaID),
+				zap.Int("attempt", attempt+1),
+				zap.Error(err),
+			)
+			continue
+		}
+		if updated > 0 {
+			s.logger.Info("Transcript pushed to message service", zap.String("media_id", mediaID), zap.Int("messages", updated))
+			return
+		}
+	}
+
+	s.logger.Warn("No voice message references transcribed media", zap.String("media_id", mediaID))
+}
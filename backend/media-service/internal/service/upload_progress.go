@@ -0,0 +1,249 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"media-service/internal/models"
+)
+
+var (
+	// ErrInvalidUploadID 上传ID格式不正确
+	ErrInvalidUploadID = errors.New("upload id must be 1-64 letters, digits, '-' or '_'")
+	// ErrUploadInProgress 同一上传ID的上传尚未结束
+	ErrUploadInProgress = errors.New("upload with this id is already in progress")
+)
+
+// uploadIDPattern 客户端生成的上传ID，通常为UUID
+var uploadIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// uploadEntry 单个上传的进度和订阅者
+type uploadEntry struct {
+	progress models.UploadProgress
+	watchers map[chan struct{}]struct{}
+}
+
+// UploadProgressTracker 按用户和客户端生成的上传ID记录上传进度，仅保存在本实例内存中
+// 客户端可以在上传请求到达前订阅进度，结束的上传在retention后清理
+type UploadProgressTracker struct {
+	retention time.Duration
+	entries   map[string]*uploadEntry
+	mutex     sync.Mutex
+}
+
+// NewUploadProgressTracker 创建上传进度跟踪器
+func NewUploadProgressTracker(retention time.Duration) *UploadProgressTracker {
+	if retention <= 0 {
+		retention = 10 * time.Minute
+	}
+	return &UploadProgressTracker{
+		retention: retention,
+		entries:   make(map[string]*uploadEntry),
+	}
+}
+
+// Begin 开始记录上传，totalBytes为请求的Content-Length，未知时传0或负数
+// 已结束的上传可以用同一ID重新开始
+func (t *UploadProgressTracker) Begin(userID, uploadID string, totalBytes int64) (*UploadSession, error) {
+	if !uploadIDPattern.MatchString(uploadID) {
+		return nil, ErrInvalidUploadID
+	}
+	if totalBytes < 0 {
+		totalBytes = 0
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	t.prune(now)
+
+	key := uploadKey(userID, uploadID)
+	entry, ok := t.entries[key]
+	if ok && (entry.progress.Status == models.UploadProgressReceiving || entry.progress.Status == models.UploadProgressProcessing) {
+		return nil, ErrUploadInProgress
+	}
+	if !ok {
+		entry = &uploadEntry{watchers: make(map[chan struct{}]struct{})}
+		t.entries[key] = entry
+	}
+	entry.progress = models.UploadProgress{
+		UploadID:   uploadID,
+		Status:     models.UploadProgressReceiving,
+		TotalBytes: totalBytes,
+		StartedAt:  &now,
+		UpdatedAt:  now,
+	}
+	entry.notify()
+
+	return &UploadSession{tracker: t, entry: entry}, nil
+}
+
+// Get 获取上传进度，上传请求尚未到达或已被清理时返回false
+func (t *UploadProgressTracker) Get(userID, uploadID string) (*models.UploadProgress, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	entry, ok := t.entries[uploadKey(userID, uploadID)]
+	if !ok || entry.progress.Status == models.UploadProgressPending {
+		return nil, false
+	}
+	return entry.snapshot(), true
+}
+
+// Watch 订阅上传进度变化，进度变化时向返回的通道发送信号（多次变化可能合并为一次）
+// 上传请求尚未到达时先登记为pending；调用方结束订阅时必须调用返回的cancel
+func (t *UploadProgressTracker) Watch(userID, uploadID string) (<-chan struct{}, func(), error) {
+	if !uploadIDPattern.MatchString(uploadID) {
+		return nil, nil, ErrInvalidUploadID
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	t.prune(now)
+
+	key := uploadKey(userID, uploadID)
+	entry, ok := t.entries[key]
+	if !ok {
+		entry = &uploadEntry{
+			progress: models.UploadProgress{UploadID: uploadID, Status: models.UploadProgressPending, UpdatedAt: now},
+			watchers: make(map[chan struct{}]struct{}),
+		}
+		t.entries[key] = entry
+	}
+
+	signal := make(chan struct{}, 1)
+	signal <- struct{}{}
+	entry.watchers[signal] = struct{}{}
+
+	cancel := func() {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		delete(entry.watchers, signal)
+	}
+	return signal, cancel, nil
+}
+
+// Snapshot 获取订阅中的上传进度，包括尚未开始的上传
+func (t *UploadProgressTracker) Snapshot(userID, uploadID string) *models.UploadProgress {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	entry, ok := t.entries[uploadKey(userID, uploadID)]
+	if !ok {
+		return &models.UploadProgress{UploadID: uploadID, Status: models.UploadProgressPending, UpdatedAt: time.Now()}
+	}
+	return entry.snapshot()
+}
+
+// prune 清理已结束或一直没有开始且无人订阅的上传，调用方需持有锁
+func (t *UploadProgressTracker) prune(now time.Time) {
+	for key, entry := range t.entries {
+		if now.Sub(entry.progress.UpdatedAt) < t.retention {
+			continue
+		}
+		switch entry.progress.Status {
+		case models.UploadProgressCompleted, models.UploadProgressFailed:
+			delete(t.entries, key)
+		case models.UploadProgressPending:
+			if len(entry.watchers) == 0 {
+				delete(t.entries, key)
+			}
+		}
+	}
+}
+
+// update 修改进度并通知订阅者
+func (t *UploadProgressTracker) update(entry *uploadEntry, apply func(progress *models.UploadProgress)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if entry.progress.Finished() {
+		return
+	}
+	apply(&entry.progress)
+	entry.progress.UpdatedAt = time.Now()
+	entry.notify()
+}
+
+// snapshot 复制当前进度并计算百分比，调用方需持有锁
+func (e *uploadEntry) snapshot() *models.UploadProgress {
+	progress := e.progress
+	if progress.TotalBytes > 0 {
+		percent := float64(progress.BytesReceived) * 100 / float64(progress.TotalBytes)
+		if percent > 100 {
+			percent = 100
+		}
+		progress.Percent = &percent
+	}
+	return &progress
+}
+
+// notify 非阻塞地通知所有订阅者，调用方需持有锁
+func (e *uploadEntry) notify() {
+	for signal := range e.watchers {
+		select {
+		case signal <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func uploadKey(userID, uploadID string) string {
+	return userID + ":" + uploadID
+}
+
+// UploadSession 一次上传的进度记录
+type UploadSession struct {
+	tracker *UploadProgressTracker
+	entry   *uploadEntry
+}
+
+// Reader 包装请求体，读取时累计已接收的字节数
+func (s *UploadSession) Reader(body io.ReadCloser) io.ReadCloser {
+	return &progressReader{ReadCloser: body, session: s}
+}
+
+// Processing 请求体已接收完，开始扫描和保存文件
+func (s *UploadSession) Processing() {
+	s.tracker.update(s.entry, func(progress *models.UploadProgress) {
+		progress.Status = models.UploadProgressProcessing
+	})
+}
+
+// Complete 上传成功
+func (s *UploadSession) Complete(mediaID string) {
+	s.tracker.update(s.entry, func(progress *models.UploadProgress) {
+		progress.Status = models.UploadProgressCompleted
+		progress.MediaID = mediaID
+	})
+}
+
+// Fail 上传失败，message返回给订阅进度的客户端
+func (s *UploadSession) Fail(message string) {
+	s.tracker.update(s.entry, func(progress *models.UploadProgress) {
+		progress.Status = models.UploadProgressFailed
+		progress.Error = message
+	})
+}
+
+// progressReader 统计读取字节数的请求体
+type progressReader struct {
+	io.ReadCloser
+	session *UploadSession
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.session.tracker.update(r.session.entry, func(progress *models.UploadProgress) {
+			progress.BytesReceived += int64(n)
+		})
+	}
+	return n, err
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+	"media-service/internal/storage"
+)
+
+// videoSpriteTimeout 单个雪碧图任务的最长执行时间
+const videoSpriteTimeout = 10 * time.Minute
+
+// generateVideoSpriteAsync 异步生成视频拖动预览雪碧图和VTT文件
+func (s *mediaService) generateVideoSpriteAsync(mediaID, storageKey string) {
+	params := map[string]interface{}{
+		"interval": s.config.Video.SpriteInterval,
+		"width":    s.config.Video.SpriteWidth,
+		"columns":  s.config.Video.SpriteColumns,
+	}
+
+	job, err := s.startProcessingJob(mediaID, models.JobTypeVideoSprite, params)
+	if err != nil {
+		s.logger.Error("Failed to create video sprite job", zap.String("media_id", mediaID), zap.Error(err))
+		return
+	}
+
+	result, err := s.runVideoSpriteJob(mediaID, storageKey)
+	if err != nil {
+		errMsg := err.Error()
+		s.repo.UpdateProcessingJob(job.ID, "failed", nil, &errMsg)
+		s.logger.Error("Video sprite generation failed",
+			zap.String("media_id", mediaID),
+			zap.String("job_id", job.ID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	s.repo.UpdateProcessingJob(job.ID, "completed", result, nil)
+	s.logger.Info("Video sprite generated", zap.String("media_id", mediaID), zap.String("job_id", job.ID))
+}
+
+// runVideoSpriteJob 下载视频到临时目录，生成雪碧图和VTT后与原文件存放在一起，并写入媒体元数据
+func (s *mediaService) runVideoSpriteJob(mediaID, storageKey string) (map[string]interface{}, error) {
+	tmpDir, err := os.MkdirTemp("", "media-sprite-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inputPath := filepath.Join(tmpDir, "input"+filepath.Ext(storageKey))
+	if err := s.downloadToFile(storageKey, inputPath); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), videoSpriteTimeout)
+	defer cancel()
+
+	sprite, err := s.spriteGenerator.Generate(ctx, inputPath, filepath.Join(tmpDir, "sprite.jpg"))
+	if err != nil {
+		return nil, err
+	}
+
+	spriteKey, vttKey := s.getSpriteKeys(storageKey)
+	if _, err := s.uploadLocalFile(spriteKey, sprite.Path, "image/jpeg"); err != nil {
+		return nil, err
+	}
+	spriteURL := s.fileURL(mediaID, spriteKey, contentVariantSprite)
+
+	vttPath := filepath.Join(tmpDir, "sprite.vtt")
+	if err := os.WriteFile(vttPath, sprite.VTT(spriteURL), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write vtt: %w", err)
+	}
+	if _, err := s.uploadLocalFile(vttKey, vttPath, "text/vtt"); err != nil {
+		s.storageProvider.DeleteFile(spriteKey)
+		return nil, err
+	}
+
+	media, err := s.repo.GetMediaByID(mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	metadata := media.Metadata
+	if metadata == nil {
+		metadata = &models.MediaMetadata{}
+	}
+	if metadata.Duration == nil {
+		metadata.Duration = &sprite.Duration
+	}
+	metadata.SpriteURL = spriteURL
+	metadata.SpriteVTTURL = s.fileURL(mediaID, vttKey, contentVariantSpriteVTT)
+
+	if err := s.repo.UpdateMedia(mediaID, &models.MediaUpdateRequest{Metadata: metadata}); err != nil {
+		return nil, fmt.Errorf("failed to update media metadata: %w", err)
+	}
+
+	return map[string]interface{}{
+		"sprite_url":     metadata.SpriteURL,
+		"sprite_vtt_url": metadata.SpriteVTTURL,
+		"frames":         sprite.Frames,
+		"tile_width":     sprite.TileWidth,
+		"tile_height":    sprite.TileHeight,
+	}, nil
+}
+
+// downloadToFile 将存储中的文件下载到本地路径
+func (s *mediaService) downloadToFile(key, path string) error {
+	reader, err := s.storageProvider.DownloadFile(key)
+	if err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	defer reader.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, reader); err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	return nil
+}
+
+// uploadLocalFile 将本地文件上传到存储
+func (s *mediaService) uploadLocalFile(key, path, contentType string) (*storage.UploadResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", filepath.Base(path), err)
+	}
+
+	result, err := s.storageProvider.UploadFile(key, file, info.Size(), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload %s: %w", filepath.Base(path), err)
+	}
+	return result, nil
+}
+
+// getSpriteKeys 获取雪碧图和VTT文件的存储键
+func (s *mediaService) getSpriteKeys(originalKey string) (string, string) {
+	base := strings.TrimSuffix(originalKey, filepath.Ext(originalKey))
+	return base + "_sprite.jpg", base + "_sprite.vtt"
+}
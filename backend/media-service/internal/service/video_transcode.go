@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+)
+
+// transcodeVideoAsync 异步把视频转码为各高度的H.264/AAC MP4并截取封面图
+func (s *mediaService) transcodeVideoAsync(mediaID, storageKey string) {
+	params := map[string]interface{}{
+		"heights":       s.config.Video.TranscodeHeights,
+		"crf":           s.config.Video.TranscodeCRF,
+		"preset":        s.config.Video.TranscodePreset,
+		"audio_bitrate": s.config.Video.TranscodeAudioBitrate,
+	}
+
+	job, err := s.startProcessingJob(mediaID, models.JobTypeTranscode, params)
+	if err != nil {
+		s.logger.Error("Failed to create transcode job", zap.String("media_id", mediaID), zap.Error(err))
+		return
+	}
+
+	result, err := s.runTranscodeJob(mediaID, storageKey)
+	if err != nil {
+		errMsg := err.Error()
+		s.repo.UpdateProcessingJob(job.ID, "failed", nil, &errMsg)
+		s.logger.Error("Video transcoding failed",
+			zap.String("media_id", mediaID),
+			zap.String("job_id", job.ID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	s.repo.UpdateProcessingJob(job.ID, "completed", result, nil)
+	s.logger.Info("Video transcoded", zap.String("media_id", mediaID), zap.String("job_id", job.ID))
+}
+
+// runTranscodeJob 下载视频到临时目录转码，输出文件与原文件存放在一起，
+// 并把原视频的时长、码率、编码以及各转码版本和封面图写入媒体元数据
+func (s *mediaService) runTranscodeJob(mediaID, storageKey string) (map[string]interface{}, error) {
+	tmpDir, err := os.MkdirTemp("", "media-transcode-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inputPath := filepath.Join(tmpDir, "input"+filepath.Ext(storageKey))
+	if err := s.downloadToFile(storageKey, inputPath); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.transcodeTimeout())
+	defer cancel()
+
+	info, err := s.transcoder.Probe(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := s.transcoder.Transcode(ctx, inputPath, tmpDir, info)
+	if err != nil {
+		return nil, err
+	}
+	posterPath := filepath.Join(tmpDir, "poster.jpg")
+	if err := s.transcoder.Poster(ctx, inputPath, posterPath, info.Duration); err != nil {
+		return nil, err
+	}
+
+	var uploaded []string
+	rollback := func() {
+		for _, key := range uploaded {
+			s.storageProvider.DeleteFile(key)
+		}
+	}
+
+	renditions := make(map[string]*models.VideoRendition, len(outputs))
+	for _, output := range outputs {
+		key := s.getTranscodeKey(storageKey, output.Name)
+		if _, err := s.uploadLocalFile(key, output.Path, "video/mp4"); err != nil {
+			rollback()
+			return nil, err
+		}
+		uploaded = append(uploaded, key)
+
+		renditions[output.Name] = &models.VideoRendition{
+			URL:      s.fileURL(mediaID, key, output.Name),
+			Width:    output.Width,
+			Height:   output.Height,
+			Size:     output.Size,
+			Bitrate:  output.Bitrate,
+			MimeType: "video/mp4",
+		}
+	}
+
+	posterKey := s.getPosterKey(storageKey)
+	if _, err := s.uploadLocalFile(posterKey, posterPath, "image/jpeg"); err != nil {
+		rollback()
+		return nil, err
+	}
+	uploaded = append(uploaded, posterKey)
+
+	media, err := s.repo.GetMediaByID(mediaID)
+	if err != nil {
+		rollback()
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	metadata := media.Metadata
+	if metadata == nil {
+		metadata = &models.MediaMetadata{}
+	}
+	if info.Duration > 0 {
+		metadata.Duration = &info.Duration
+	}
+	if info.Bitrate > 0 {
+		metadata.Bitrate = &info.Bitrate
+	}
+	metadata.Codec = &info.Codec
+	metadata.Width = &info.Width
+	metadata.Height = &info.Height
+	metadata.Renditions = renditions
+	metadata.PosterURL = s.fileURL(mediaID, posterKey, contentVariantPoster)
+
+	if err := s.repo.UpdateMedia(mediaID, &models.MediaUpdateRequest{Metadata: metadata}); err != nil {
+		rollback()
+		return nil, fmt.Errorf("failed to update media metadata: %w", err)
+	}
+
+	return map[string]interface{}{
+		"duration":   info.Duration,
+		"bitrate":    info.Bitrate,
+		"codec":      info.Codec,
+		"renditions": renditions,
+		"poster_url": metadata.PosterURL,
+	}, nil
+}
+
+// transcodeTimeout 单个转码任务的最长执行时间
+func (s *mediaService) transcodeTimeout() time.Duration {
+	if s.config.Video.TranscodeTimeout <= 0 {
+		return 20 * time.Minute
+	}
+	return time.Duration(s.config.Video.TranscodeTimeout) * time.Minute
+}
+
+// getTranscodeKey 获取转码版本的存储键
+func (s *mediaService) getTranscodeKey(originalKey, name string) string {
+	return strings.TrimSuffix(originalKey, filepath.Ext(originalKey)) + "_" + name + ".mp4"
+}
+
+// getPosterKey 获取视频封面图的存储键
+func (s *mediaService) getPosterKey(originalKey string) string {
+	return strings.TrimSuffix(originalKey, filepath.Ext(originalKey)) + "_poster.jpg"
+}
@@ -0,0 +1,120 @@
+package speech
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"media-service/internal/models"
+)
+
+// OpenAITranscriber 通过OpenAI兼容的 /audio/transcriptions 接口转写，
+// 也适用于提供相同接口的本地服务（如faster-whisper-server、LocalAI）
+type OpenAITranscriber struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	language   string
+	httpClient *http.Client
+}
+
+// NewOpenAITranscriber 创建OpenAI兼容的语音转写器，language为空时由模型自动识别
+func NewOpenAITranscriber(baseURL, apiKey, model, language string, timeout time.Duration) *OpenAITranscriber {
+	return &OpenAITranscriber{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		language:   language,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Transcribe 上传音频文件并返回转写文本
+func (t *OpenAITranscriber) Transcribe(ctx context.Context, audioPath string) (*models.Transcript, error) {
+	body, contentType, err := t.buildRequestBody(audioPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+"/audio/transcriptions", body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("transcription request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("transcription request returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var result struct {
+		Text     string  `json:"text"`
+		Language string  `json:"language"`
+		Duration float64 `json:"duration"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode transcription response: %w", err)
+	}
+
+	return &models.Transcript{
+		Text:          strings.TrimSpace(result.Text),
+		Language:      result.Language,
+		Duration:      result.Duration,
+		Model:         t.model,
+		TranscribedAt: time.Now(),
+	}, nil
+}
+
+// buildRequestBody 构造multipart请求体，verbose_json格式会额外返回识别的语言和时长
+func (t *OpenAITranscriber) buildRequestBody(audioPath string) (io.Reader, string, error) {
+	file, err := os.Open(audioPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open audio: %w", err)
+	}
+	defer file.Close()
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, err := writer.CreateFormFile("file", filepath.Base(audioPath))
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, "", fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	fields := map[string]string{
+		"model":           t.model,
+		"response_format": "verbose_json",
+	}
+	if t.language != "" {
+		fields["language"] = t.language
+	}
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return &buf, writer.FormDataContentType(), nil
+}
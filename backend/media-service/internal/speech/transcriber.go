@@ -0,0 +1,41 @@
+package speech
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/config"
+	"media-service/internal/models"
+)
+
+// Transcriber 语音转写接口，转写audioPath指定的本地音频文件
+type Transcriber interface {
+	Transcribe(ctx context.Context, audioPath string) (*models.Transcript, error)
+}
+
+// NewTranscriber 根据配置创建语音转写器，未启用时返回nil
+func NewTranscriber(cfg *config.Config, logger *zap.Logger) (Transcriber, error) {
+	tc := cfg.Transcription
+	timeout := time.Duration(tc.TimeoutSeconds) * time.Second
+
+	switch strings.ToLower(tc.Provider) {
+	case "", "none":
+		return nil, nil
+	case "openai":
+		if tc.BaseURL == "" {
+			return nil, fmt.Errorf("transcription provider openai requires LLM_BASE_URL")
+		}
+		return NewOpenAITranscriber(tc.BaseURL, tc.APIKey, tc.Model, tc.Language, timeout), nil
+	case "whisper-cpp":
+		if tc.WhisperModel == "" {
+			return nil, fmt.Errorf("transcription provider whisper-cpp requires WHISPER_MODEL_PATH")
+		}
+		return NewWhisperCPPTranscriber(tc.WhisperCPPPath, tc.WhisperModel, cfg.Video.FFmpegPath, tc.Language, logger), nil
+	default:
+		return nil, fmt.Errorf("unsupported transcription provider: %s", tc.Provider)
+	}
+}
@@ -0,0 +1,119 @@
+package speech
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/internal/models"
+)
+
+// wavBytesPerSecond 16kHz单声道16位PCM每秒的字节数
+const wavBytesPerSecond = 16000 * 2
+
+// WhisperCPPTranscriber 使用本地whisper.cpp模型转写，音频先用ffmpeg转换为whisper.cpp要求的16kHz单声道WAV
+type WhisperCPPTranscriber struct {
+	binaryPath string
+	modelPath  string
+	ffmpegPath string
+	language   string
+	logger     *zap.Logger
+}
+
+// NewWhisperCPPTranscriber 创建whisper.cpp语音转写器，language为空时自动识别
+func NewWhisperCPPTranscriber(binaryPath, modelPath, ffmpegPath, language string, logger *zap.Logger) *WhisperCPPTranscriber {
+	if binaryPath == "" {
+		binaryPath = "whisper-cli"
+	}
+	if ffmpegPath == "" {
+		ffmpegPath = "ffmpeg"
+	}
+	if language == "" {
+		language = "auto"
+	}
+	return &WhisperCPPTranscriber{
+		binaryPath: binaryPath,
+		modelPath:  modelPath,
+		ffmpegPath: ffmpegPath,
+		language:   language,
+		logger:     logger,
+	}
+}
+
+// Transcribe 转换音频格式后调用whisper.cpp，读取其JSON输出
+func (t *WhisperCPPTranscriber) Transcribe(ctx context.Context, audioPath string) (*models.Transcript, error) {
+	tmpDir, err := os.MkdirTemp("", "media-whisper-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	wavPath := filepath.Join(tmpDir, "audio.wav")
+	if err := t.run(ctx, t.ffmpegPath, "-y", "-i", audioPath, "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", wavPath); err != nil {
+		return nil, fmt.Errorf("failed to convert audio: %w", err)
+	}
+
+	outputBase := filepath.Join(tmpDir, "transcript")
+	if err := t.run(ctx, t.binaryPath, "-m", t.modelPath, "-f", wavPath, "-l", t.language, "-np", "-oj", "-of", outputBase); err != nil {
+		return nil, fmt.Errorf("whisper.cpp failed: %w", err)
+	}
+
+	data, err := os.ReadFile(outputBase + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read whisper.cpp output: %w", err)
+	}
+	var output struct {
+		Result struct {
+			Language string `json:"language"`
+		} `json:"result"`
+		Transcription []struct {
+			Text string `json:"text"`
+		} `json:"transcription"`
+	}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("failed to decode whisper.cpp output: %w", err)
+	}
+
+	var segments []string
+	for _, segment := range output.Transcription {
+		if text := strings.TrimSpace(segment.Text); text != "" {
+			segments = append(segments, text)
+		}
+	}
+
+	transcript := &models.Transcript{
+		Text:          strings.Join(segments, " "),
+		Language:      output.Result.Language,
+		Model:         filepath.Base(t.modelPath),
+		TranscribedAt: time.Now(),
+	}
+	if info, err := os.Stat(wavPath); err == nil && info.Size() > 44 {
+		// 减去44字节的WAV文件头
+		transcript.Duration = float64(info.Size()-44) / wavBytesPerSecond
+	}
+	return transcript, nil
+}
+
+// run 执行外部命令，失败时附带stderr末尾的输出
+func (t *WhisperCPPTranscriber) run(ctx context.Context, name string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		output := strings.TrimSpace(stderr.String())
+		if len(output) > 500 {
+			output = output[len(output)-500:]
+		}
+		t.logger.Debug("Command failed", zap.String("command", name), zap.String("stderr", output))
+		return fmt.Errorf("%w: %s", err, output)
+	}
+	return nil
+}
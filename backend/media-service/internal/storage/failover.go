@@ -0,0 +1,279 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/config"
+)
+
+// 复制的目标存储
+const (
+	ReplicaPrimary   = "primary"
+	ReplicaSecondary = "secondary"
+)
+
+// ReplicationScheduler 安排把文件复制到目标存储，由服务层写入任务队列，保证服务重启后继续复制
+type ReplicationScheduler func(key, target string)
+
+// FailoverStorage 主备双写存储，实现StorageProvider接口
+// 写入主存储成功后安排复制到备用存储；主存储写入失败时直接写入备用存储，再安排回填主存储，
+// 保证主存储故障期间上传的文件不丢失。读取时主存储失败则从备用存储读取。
+// 文件地址和预签名URL始终按主存储生成，回填后地址仍然有效
+type FailoverStorage struct {
+	primary   StorageProvider
+	secondary StorageProvider
+	schedule  ReplicationScheduler
+	logger    *zap.Logger
+}
+
+// NewFailoverStorage 创建主备双写存储，未设置复制调度时在后台直接复制
+func NewFailoverStorage(primary, secondary StorageProvider, logger *zap.Logger) *FailoverStorage {
+	return &FailoverStorage{
+		primary:   primary,
+		secondary: secondary,
+		logger:    logger,
+	}
+}
+
+// newSecondaryProvider 根据备用存储配置创建存储提供者
+func newSecondaryProvider(cfg *config.Config, logger *zap.Logger) (StorageProvider, error) {
+	secondaryCfg := *cfg
+	secondaryCfg.Storage.Provider = cfg.Failover.Provider
+	secondaryCfg.Storage.LocalPath = cfg.Failover.LocalPath
+	// 文件地址按主存储生成，备用存储使用默认地址规则
+	secondaryCfg.Storage.BaseURL = ""
+	secondaryCfg.AWS = cfg.Failover.AWS
+	provider, err := NewStorageProvider(&secondaryCfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secondary storage: %w", err)
+	}
+	return provider, nil
+}
+
+// OnReplicate 设置复制调度，应在开始处理请求前调用
+func (f *FailoverStorage) OnReplicate(schedule ReplicationScheduler) {
+	f.schedule = schedule
+}
+
+// scheduleReplication 安排复制文件，未设置调度时在后台直接复制
+func (f *FailoverStorage) scheduleReplication(key, target string) {
+	if f.schedule != nil {
+		f.schedule(key, target)
+		return
+	}
+	go func() {
+		if err := f.Replicate(key, target); err != nil {
+			f.logger.Warn("Failed to replicate file", zap.String("key", key), zap.String("target", target), zap.Error(err))
+		}
+	}()
+}
+
+// UploadFile 上传到主存储后安排复制到备用存储，主存储失败时写入备用存储并安排回填
+func (f *FailoverStorage) UploadFile(key string, file multipart.File, fileSize int64, contentType string) (*UploadResult, error) {
+	result, err := f.primary.UploadFile(key, file, fileSize, contentType)
+	if err == nil {
+		f.scheduleReplication(key, ReplicaSecondary)
+		return result, nil
+	}
+
+	f.logger.Warn("Primary storage upload failed, writing to secondary storage", zap.String("key", key), zap.Error(err))
+	if _, seekErr := file.Seek(0, io.SeekStart); seekErr != nil {
+		return nil, err
+	}
+	result, secondaryErr := f.secondary.UploadFile(key, file, fileSize, contentType)
+	if secondaryErr != nil {
+		f.logger.Error("Secondary storage upload failed", zap.String("key", key), zap.Error(secondaryErr))
+		return nil, err
+	}
+	f.scheduleReplication(key, ReplicaPrimary)
+
+	if url, urlErr := f.primary.GetFileURL(key); urlErr == nil {
+		result.URL = url
+	}
+	return result, nil
+}
+
+// DownloadFile 下载文件，主存储失败时从备用存储读取
+func (f *FailoverStorage) DownloadFile(key string) (io.ReadCloser, error) {
+	reader, err := f.primary.DownloadFile(key)
+	if err == nil {
+		return reader, nil
+	}
+	reader, secondaryErr := f.secondary.DownloadFile(key)
+	if secondaryErr != nil {
+		return nil, err
+	}
+	f.logger.Warn("Read file from secondary storage", zap.String("key", key), zap.Error(err))
+	return reader, nil
+}
+
+// DownloadRange 分段下载文件，主存储失败时从备用存储读取
+func (f *FailoverStorage) DownloadRange(key string, offset, length int64) (io.ReadCloser, error) {
+	reader, err := f.primary.DownloadRange(key, offset, length)
+	if err == nil {
+		return reader, nil
+	}
+	reader, secondaryErr := f.secondary.DownloadRange(key, offset, length)
+	if secondaryErr != nil {
+		return nil, err
+	}
+	f.logger.Warn("Read file range from secondary storage", zap.String("key", key), zap.Error(err))
+	return reader, nil
+}
+
+// GetFileURL 获取主存储中的文件URL
+func (f *FailoverStorage) GetFileURL(key string) (string, error) {
+	return f.primary.GetFileURL(key)
+}
+
+// GetPresignedURL 获取主存储的预签名URL
+func (f *FailoverStorage) GetPresignedURL(key string, operation string, expiration time.Duration) (string, error) {
+	return f.primary.GetPresignedURL(key, operation, expiration)
+}
+
+// DeleteFile 从两个存储中删除文件，备用存储删除失败只记录日志
+func (f *FailoverStorage) DeleteFile(key string) error {
+	if err := f.secondary.DeleteFile(key); err != nil {
+		f.logger.Warn("Failed to delete file from secondary storage", zap.String("key", key), zap.Error(err))
+	}
+	return f.primary.DeleteFile(key)
+}
+
+// FileExists 检查文件是否存在于任一存储
+func (f *FailoverStorage) FileExists(key string) (bool, error) {
+	exists, err := f.primary.FileExists(key)
+	if err == nil && exists {
+		return true, nil
+	}
+	secondaryExists, secondaryErr := f.secondary.FileExists(key)
+	if secondaryErr == nil && secondaryExists {
+		return true, nil
+	}
+	return false, err
+}
+
+// GetFileInfo 获取文件信息，主存储失败时从备用存储获取
+func (f *FailoverStorage) GetFileInfo(key string) (*FileInfo, error) {
+	info, err := f.primary.GetFileInfo(key)
+	if err == nil {
+		return info, nil
+	}
+	info, secondaryErr := f.secondary.GetFileInfo(key)
+	if secondaryErr != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// ListFiles 列出主存储中的文件，主存储失败时列出备用存储
+func (f *FailoverStorage) ListFiles(prefix string, maxKeys int) ([]*FileInfo, error) {
+	files, err := f.primary.ListFiles(prefix, maxKeys)
+	if err == nil {
+		return files, nil
+	}
+	files, secondaryErr := f.secondary.ListFiles(prefix, maxKeys)
+	if secondaryErr != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// CopyFile 在主存储中复制后安排复制到备用存储，主存储失败时在备用存储中复制并安排回填
+func (f *FailoverStorage) CopyFile(sourceKey, destKey string) error {
+	err := f.primary.CopyFile(sourceKey, destKey)
+	if err == nil {
+		f.scheduleReplication(destKey, ReplicaSecondary)
+		return nil
+	}
+	if secondaryErr := f.secondary.CopyFile(sourceKey, destKey); secondaryErr != nil {
+		return err
+	}
+	f.logger.Warn("Copied file in secondary storage", zap.String("source", sourceKey), zap.String("dest", destKey), zap.Error(err))
+	f.scheduleReplication(destKey, ReplicaPrimary)
+	return nil
+}
+
+// Replicate 把文件从另一个存储复制到目标存储，源文件已不存在（如已删除）时跳过
+func (f *FailoverStorage) Replicate(key, target string) error {
+	source, dest := f.primary, f.secondary
+	switch target {
+	case ReplicaPrimary:
+		source, dest = f.secondary, f.primary
+	case ReplicaSecondary:
+	default:
+		return fmt.Errorf("invalid replica target %q", target)
+	}
+
+	exists, err := source.FileExists(key)
+	if err != nil {
+		return fmt.Errorf("failed to check source file: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+	return copyBetween(source, dest, key, key)
+}
+
+// Repair 检查文件是否同时存在于两个存储，缺失时从另一个存储复制，返回修复的目标存储
+// 两个存储中都不存在时返回错误
+func (f *FailoverStorage) Repair(key string) (string, error) {
+	inPrimary, err := f.primary.FileExists(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to check primary storage: %w", err)
+	}
+	inSecondary, err := f.secondary.FileExists(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to check secondary storage: %w", err)
+	}
+
+	switch {
+	case inPrimary && inSecondary:
+		return "", nil
+	case inPrimary:
+		return ReplicaSecondary, copyBetween(f.primary, f.secondary, key, key)
+	case inSecondary:
+		return ReplicaPrimary, copyBetween(f.secondary, f.primary, key, key)
+	default:
+		return "", fmt.Errorf("file not found in any storage")
+	}
+}
+
+// copyBetween 经由临时文件把文件从一个存储复制到另一个存储
+func copyBetween(source, dest StorageProvider, sourceKey, destKey string) error {
+	info, err := source.GetFileInfo(sourceKey)
+	if err != nil {
+		return fmt.Errorf("failed to get source file info: %w", err)
+	}
+
+	reader, err := source.DownloadFile(sourceKey)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	tmpFile, err := os.CreateTemp("", "media-storage-copy-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	size, err := io.Copy(tmpFile, reader)
+	if err != nil {
+		return fmt.Errorf("failed to download source file: %w", err)
+	}
+	if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := dest.UploadFile(destKey, tmpFile, size, info.ContentType); err != nil {
+		return fmt.Errorf("failed to upload destination file: %w", err)
+	}
+	return nil
+}
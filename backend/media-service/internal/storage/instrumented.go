@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"io"
+	"mime/multipart"
+	"time"
+)
+
+// StorageObserver 存储操作观察者，每次操作完成后调用，err为nil表示成功
+type StorageObserver func(backend, operation string, start time.Time, err error)
+
+// instrumentedStorage 记录每次存储操作的结果和耗时
+// GetFileURL和GetPresignedURL只在本地生成地址，不访问存储，因此不记录
+type instrumentedStorage struct {
+	StorageProvider
+	backend string
+	observe StorageObserver
+}
+
+func (s *instrumentedStorage) UploadFile(key string, file multipart.File, fileSize int64, contentType string) (*UploadResult, error) {
+	start := time.Now()
+	result, err := s.StorageProvider.UploadFile(key, file, fileSize, contentType)
+	s.observe(s.backend, "upload", start, err)
+	return result, err
+}
+
+func (s *instrumentedStorage) DownloadFile(key string) (io.ReadCloser, error) {
+	start := time.Now()
+	reader, err := s.StorageProvider.DownloadFile(key)
+	s.observe(s.backend, "download", start, err)
+	return reader, err
+}
+
+func (s *instrumentedStorage) DownloadRange(key string, offset, length int64) (io.ReadCloser, error) {
+	start := time.Now()
+	reader, err := s.StorageProvider.DownloadRange(key, offset, length)
+	s.observe(s.backend, "download_range", start, err)
+	return reader, err
+}
+
+func (s *instrumentedStorage) DeleteFile(key string) error {
+	start := time.Now()
+	err := s.StorageProvider.DeleteFile(key)
+	s.observe(s.backend, "delete", start, err)
+	return err
+}
+
+func (s *instrumentedStorage) FileExists(key string) (bool, error) {
+	start := time.Now()
+	exists, err := s.StorageProvider.FileExists(key)
+	s.observe(s.backend, "exists", start, err)
+	return exists, err
+}
+
+func (s *instrumentedStorage) GetFileInfo(key string) (*FileInfo, error) {
+	start := time.Now()
+	info, err := s.StorageProvider.GetFileInfo(key)
+	s.observe(s.backend, "stat", start, err)
+	return info, err
+}
+
+func (s *instrumentedStorage) ListFiles(prefix string, maxKeys int) ([]*FileInfo, error) {
+	start := time.Now()
+	files, err := s.StorageProvider.ListFiles(prefix, maxKeys)
+	s.observe(s.backend, "list", start, err)
+	return files, err
+}
+
+func (s *instrumentedStorage) CopyFile(sourceKey, destKey string) error {
+	start := time.Now()
+	err := s.StorageProvider.CopyFile(sourceKey, destKey)
+	s.observe(s.backend, "copy", start, err)
+	return err
+}
@@ -27,6 +27,9 @@ type StorageProvider interface {
 	
 	// 文件下载
 	DownloadFile(key string) (io.ReadCloser, error)
+
+	// 分段下载，从offset开始读取length字节，length小于0时读到文件末尾
+	DownloadRange(key string, offset, length int64) (io.ReadCloser, error)
 	
 	// 获取文件URL
 	GetFileURL(key string) (string, error)
@@ -157,7 +160,30 @@ func (s *LocalStorage) DownloadFile(key string) (io.ReadCloser, error) {
 	return os.Open(filePath)
 }
 
+// DownloadRange 分段读取本地文件
+func (s *LocalStorage) DownloadRange(key string, offset, length int64) (io.ReadCloser, error) {
+	file, err := os.Open(filepath.Join(s.basePath, key))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if length < 0 {
+		return file, nil
+	}
+	return limitedReadCloser{Reader: io.LimitReader(file, length), Closer: file}, nil
+}
+
+// limitedReadCloser 只读取文件的一部分，关闭时关闭文件
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
 // GetFileURL 获取文件URL
+// 本地存储没有静态文件路由，这个地址不能直接访问，媒体记录中的地址由服务层指向下载接口
 func (s *LocalStorage) GetFileURL(key string) (string, error) {
 	return fmt.Sprintf("%s/%s", strings.TrimRight(s.baseURL, "/"), key), nil
 }
@@ -363,6 +389,24 @@ func (s *S3Storage) DownloadFile(key string) (io.ReadCloser, error) {
 	return result.Body, nil
 }
 
+// DownloadRange 通过Range请求分段下载S3对象
+func (s *S3Storage) DownloadRange(key string, offset, length int64) (io.ReadCloser, error) {
+	byteRange := fmt.Sprintf("bytes=%d-", offset)
+	if length >= 0 {
+		byteRange = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+	result, err := s.s3Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(byteRange),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download range from S3: %w", err)
+	}
+
+	return result.Body, nil
+}
+
 // GetFileURL 获取S3文件URL
 func (s *S3Storage) GetFileURL(key string) (string, error) {
 	return fmt.Sprintf("%s/%s", strings.TrimRight(s.baseURL, "/"), key), nil
@@ -0,0 +1,324 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/config"
+	"media-service/internal/models"
+)
+
+// tenantLocation 租户的存储位置
+type tenantLocation struct {
+	tenantID string
+	bucket   string
+	prefix   string
+	provider StorageProvider
+	// failover 启用备用存储时包装provider的主备双写存储
+	failover *FailoverStorage
+}
+
+// TenantStorage 按租户隔离的存储，实现StorageProvider接口
+// 每个租户的文件都位于自己的键前缀下，可选地放在独立的存储桶中；
+// 操作按存储键的前缀路由到对应租户的存储桶，因此调用方只需使用KeyPrefix生成的键
+type TenantStorage struct {
+	defaultProvider StorageProvider
+	defaultBucket   string
+	backend         string
+	defaultFailover *FailoverStorage
+	locations       map[string]*tenantLocation
+	// routes 按前缀长度倒序排列，保证最长前缀优先匹配
+	routes []*tenantLocation
+	logger *zap.Logger
+}
+
+// NewTenantStorage 根据配置创建默认存储和各租户的存储
+func NewTenantStorage(cfg *config.Config, logger *zap.Logger) (*TenantStorage, error) {
+	defaultProvider, err := NewStorageProvider(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := &TenantStorage{
+		defaultProvider: defaultProvider,
+		defaultBucket:   defaultBucketName(cfg),
+		backend:         strings.ToLower(cfg.Storage.Provider),
+		locations:       make(map[string]*tenantLocation),
+		logger:          logger,
+	}
+
+	// 启用备用存储时所有存储桶都复制到同一个备用存储，存储键包含租户前缀，不会冲突
+	var secondary StorageProvider
+	if cfg.Failover.Provider != "" {
+		secondary, err = newSecondaryProvider(cfg, logger)
+		if err != nil {
+			return nil, err
+		}
+		ts.defaultFailover = NewFailoverStorage(defaultProvider, secondary, logger)
+		ts.defaultProvider = ts.defaultFailover
+		logger.Info("Secondary storage configured", zap.String("provider", cfg.Failover.Provider))
+	}
+
+	prefixOwners := make(map[string]string)
+	for tenantID, tenantCfg := range cfg.Tenancy.Storage {
+		if tenantID == models.DefaultTenantID {
+			return nil, fmt.Errorf("storage location of tenant %q cannot be overridden", tenantID)
+		}
+
+		prefix, err := normalizeTenantPrefix(tenantID, tenantCfg.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		for existing, owner := range prefixOwners {
+			if strings.HasPrefix(prefix, existing) || strings.HasPrefix(existing, prefix) {
+				return nil, fmt.Errorf("storage prefix %q of tenant %q overlaps with tenant %q", prefix, tenantID, owner)
+			}
+		}
+		prefixOwners[prefix] = tenantID
+
+		location := &tenantLocation{
+			tenantID: tenantID,
+			bucket:   ts.defaultBucket,
+			prefix:   prefix,
+			provider: ts.defaultProvider,
+			failover: ts.defaultFailover,
+		}
+		if tenantCfg.Bucket != "" && tenantCfg.Bucket != ts.defaultBucket {
+			provider, err := newBucketProvider(cfg, tenantCfg.Bucket, logger)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create storage for tenant %q: %w", tenantID, err)
+			}
+			location.bucket = tenantCfg.Bucket
+			location.provider = provider
+			if secondary != nil {
+				location.failover = NewFailoverStorage(provider, secondary, logger)
+				location.provider = location.failover
+			}
+		}
+
+		ts.locations[tenantID] = location
+		ts.routes = append(ts.routes, location)
+		logger.Info("Tenant storage configured",
+			zap.String("tenant_id", tenantID),
+			zap.String("bucket", location.bucket),
+			zap.String("prefix", prefix),
+		)
+	}
+
+	sort.Slice(ts.routes, func(i, j int) bool {
+		return len(ts.routes[i].prefix) > len(ts.routes[j].prefix)
+	})
+
+	return ts, nil
+}
+
+// normalizeTenantPrefix 规范化租户前缀，未配置时使用 tenants/<tenantID>/
+func normalizeTenantPrefix(tenantID, prefix string) (string, error) {
+	if !models.IsValidTenantID(tenantID) {
+		return "", fmt.Errorf("invalid tenant id %q", tenantID)
+	}
+
+	prefix = strings.Trim(strings.TrimSpace(prefix), "/")
+	if prefix == "" {
+		prefix = path.Join("tenants", tenantID)
+	}
+	if strings.Contains(prefix, "..") {
+		return "", fmt.Errorf("invalid storage prefix %q for tenant %q", prefix, tenantID)
+	}
+	// tenants/下是未单独配置的租户的默认位置，只能使用自己的目录
+	if (prefix == "tenants" || strings.HasPrefix(prefix, "tenants/")) && prefix != path.Join("tenants", tenantID) {
+		return "", fmt.Errorf("storage prefix %q of tenant %q conflicts with default tenant prefixes", prefix, tenantID)
+	}
+	// 默认租户的文件直接位于users/下，租户前缀不能与之冲突
+	if prefix == "users" || strings.HasPrefix(prefix, "users/") {
+		return "", fmt.Errorf("storage prefix %q of tenant %q conflicts with default tenant", prefix, tenantID)
+	}
+	return prefix + "/", nil
+}
+
+// defaultBucketName 默认存储桶名称，本地存储没有存储桶
+func defaultBucketName(cfg *config.Config) string {
+	if strings.ToLower(cfg.Storage.Provider) == "local" {
+		return ""
+	}
+	return cfg.AWS.BucketName
+}
+
+// newBucketProvider 创建使用指定存储桶的存储提供者，本地存储中存储桶对应子目录
+func newBucketProvider(cfg *config.Config, bucket string, logger *zap.Logger) (StorageProvider, error) {
+	bucketCfg := *cfg
+	bucketCfg.AWS.BucketName = bucket
+	if strings.ToLower(cfg.Storage.Provider) == "local" {
+		bucketCfg.Storage.LocalPath = filepath.Join(cfg.Storage.LocalPath, bucket)
+		if cfg.Storage.BaseURL != "" {
+			bucketCfg.Storage.BaseURL = strings.TrimRight(cfg.Storage.BaseURL, "/") + "/" + bucket
+		}
+	} else {
+		// 使用默认地址规则，避免沿用默认存储桶的BaseURL
+		bucketCfg.Storage.BaseURL = ""
+	}
+	return NewStorageProvider(&bucketCfg, logger)
+}
+
+// Instrument 为默认存储和各租户的存储添加操作观察者，应在开始处理请求前调用
+// 共用同一存储的租户包装为同一个实例，跨存储桶复制的判断不受影响
+func (t *TenantStorage) Instrument(observe StorageObserver) {
+	wrapped := make(map[StorageProvider]StorageProvider)
+	wrap := func(provider StorageProvider) StorageProvider {
+		if instrumented, ok := wrapped[provider]; ok {
+			return instrumented
+		}
+		instrumented := &instrumentedStorage{StorageProvider: provider, backend: t.backend, observe: observe}
+		wrapped[provider] = instrumented
+		return instrumented
+	}
+
+	t.defaultProvider = wrap(t.defaultProvider)
+	for _, location := range t.locations {
+		location.provider = wrap(location.provider)
+	}
+}
+
+// FailoverEnabled 是否启用了备用存储
+func (t *TenantStorage) FailoverEnabled() bool {
+	return t.defaultFailover != nil
+}
+
+// OnReplicate 设置所有存储的复制调度，应在开始处理请求前调用
+func (t *TenantStorage) OnReplicate(schedule ReplicationScheduler) {
+	if t.defaultFailover != nil {
+		t.defaultFailover.OnReplicate(schedule)
+	}
+	for _, location := range t.locations {
+		if location.failover != nil {
+			location.failover.OnReplicate(schedule)
+		}
+	}
+}
+
+// Replicate 把文件复制到键所属存储的主存储或备用存储，未启用备用存储时不做任何操作
+func (t *TenantStorage) Replicate(key, target string) error {
+	if failover := t.routeFailover(key); failover != nil {
+		return failover.Replicate(key, target)
+	}
+	return nil
+}
+
+// Repair 检查文件是否同时存在于主存储和备用存储，缺失时补齐，返回修复的目标存储
+func (t *TenantStorage) Repair(key string) (string, error) {
+	if failover := t.routeFailover(key); failover != nil {
+		return failover.Repair(key)
+	}
+	return "", nil
+}
+
+// KeyPrefix 获取租户的存储键前缀，默认租户没有前缀；
+// 未单独配置的租户使用默认存储桶中的 tenants/<tenantID>/ 前缀
+func (t *TenantStorage) KeyPrefix(tenantID string) string {
+	if tenantID == "" || tenantID == models.DefaultTenantID {
+		return ""
+	}
+	if location, ok := t.locations[tenantID]; ok {
+		return location.prefix
+	}
+	return path.Join("tenants", tenantID) + "/"
+}
+
+// Bucket 获取租户使用的存储桶
+func (t *TenantStorage) Bucket(tenantID string) string {
+	if location, ok := t.locations[tenantID]; ok {
+		return location.bucket
+	}
+	return t.defaultBucket
+}
+
+// TenantKey 生成租户存储键
+func (t *TenantStorage) TenantKey(tenantID, key string) string {
+	return t.KeyPrefix(tenantID) + strings.TrimLeft(key, "/")
+}
+
+// route 根据存储键找到所在的存储
+func (t *TenantStorage) route(key string) StorageProvider {
+	key = strings.TrimLeft(key, "/")
+	for _, location := range t.routes {
+		if strings.HasPrefix(key, location.prefix) {
+			return location.provider
+		}
+	}
+	return t.defaultProvider
+}
+
+// routeFailover 根据存储键找到所在存储的主备双写存储，未启用备用存储时返回nil
+func (t *TenantStorage) routeFailover(key string) *FailoverStorage {
+	key = strings.TrimLeft(key, "/")
+	for _, location := range t.routes {
+		if strings.HasPrefix(key, location.prefix) {
+			return location.failover
+		}
+	}
+	return t.defaultFailover
+}
+
+// UploadFile 上传文件到键所属租户的存储
+func (t *TenantStorage) UploadFile(key string, file multipart.File, fileSize int64, contentType string) (*UploadResult, error) {
+	return t.route(key).UploadFile(key, file, fileSize, contentType)
+}
+
+// DownloadFile 下载文件
+func (t *TenantStorage) DownloadFile(key string) (io.ReadCloser, error) {
+	return t.route(key).DownloadFile(key)
+}
+
+// DownloadRange 分段下载文件
+func (t *TenantStorage) DownloadRange(key string, offset, length int64) (io.ReadCloser, error) {
+	return t.route(key).DownloadRange(key, offset, length)
+}
+
+// GetFileURL 获取文件URL
+func (t *TenantStorage) GetFileURL(key string) (string, error) {
+	return t.route(key).GetFileURL(key)
+}
+
+// GetPresignedURL 获取预签名URL
+func (t *TenantStorage) GetPresignedURL(key string, operation string, expiration time.Duration) (string, error) {
+	return t.route(key).GetPresignedURL(key, operation, expiration)
+}
+
+// DeleteFile 删除文件
+func (t *TenantStorage) DeleteFile(key string) error {
+	return t.route(key).DeleteFile(key)
+}
+
+// FileExists 检查文件是否存在
+func (t *TenantStorage) FileExists(key string) (bool, error) {
+	return t.route(key).FileExists(key)
+}
+
+// GetFileInfo 获取文件信息
+func (t *TenantStorage) GetFileInfo(key string) (*FileInfo, error) {
+	return t.route(key).GetFileInfo(key)
+}
+
+// ListFiles 列出文件，前缀需位于单个租户内
+func (t *TenantStorage) ListFiles(prefix string, maxKeys int) ([]*FileInfo, error) {
+	return t.route(prefix).ListFiles(prefix, maxKeys)
+}
+
+// CopyFile 复制文件，源和目标位于不同存储桶时经由临时文件中转
+func (t *TenantStorage) CopyFile(sourceKey, destKey string) error {
+	source := t.route(sourceKey)
+	dest := t.route(destKey)
+	if source == dest {
+		return source.CopyFile(sourceKey, destKey)
+	}
+
+	return copyBetween(source, dest, sourceKey, destKey)
+}
@@ -0,0 +1,102 @@
+package vision
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// captionPrompt 图片描述提示词，描述和图中文字一起供搜索索引使用
+const captionPrompt = "Describe this image in one concise sentence for search indexing. " +
+	"Mention the kind of image (photo, screenshot, receipt, document, etc.) and its main subject. " +
+	"Do not transcribe text in the image."
+
+// OpenAICaptioner 通过OpenAI兼容的多模态对话接口（/chat/completions）生成图片描述
+type OpenAICaptioner struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+	logger     *zap.Logger
+}
+
+// NewOpenAICaptioner 创建OpenAI兼容的图片描述生成器
+func NewOpenAICaptioner(baseURL, apiKey, model string, timeout time.Duration, logger *zap.Logger) *OpenAICaptioner {
+	return &OpenAICaptioner{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: &http.Client{Timeout: timeout},
+		logger:     logger,
+	}
+}
+
+// Model 返回使用的模型
+func (c *OpenAICaptioner) Model() string {
+	return c.model
+}
+
+// Caption 以data URI的形式发送图片并返回模型生成的描述
+func (c *OpenAICaptioner) Caption(ctx context.Context, image []byte, mimeType string) (string, error) {
+	dataURI := "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(image)
+	payload, err := json.Marshal(map[string]interface{}{
+		"model": c.model,
+		"messages": []map[string]interface{}{
+			{
+				"role": "user",
+				"content": []map[string]interface{}{
+					{"type": "text", "text": captionPrompt},
+					{"type": "image_url", "image_url": map[string]string{"url": dataURI}},
+				},
+			},
+		},
+		"max_tokens": 100,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("caption request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("caption request returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode caption response: %w", err)
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("caption response has no choices")
+	}
+
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
@@ -0,0 +1,58 @@
+package vision
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// TesseractExtractor 调用tesseract命令行识别图片文字
+type TesseractExtractor struct {
+	path      string
+	languages string
+}
+
+// NewTesseractExtractor 创建tesseract文字识别器，languages如 eng+chi_sim
+func NewTesseractExtractor(path, languages string) *TesseractExtractor {
+	if path == "" {
+		path = "tesseract"
+	}
+	return &TesseractExtractor{path: path, languages: languages}
+}
+
+// Name 返回识别引擎名称
+func (t *TesseractExtractor) Name() string {
+	return "tesseract"
+}
+
+// ExtractText 识别图片文字，合并多余的空行
+func (t *TesseractExtractor) ExtractText(ctx context.Context, imagePath string) (string, error) {
+	args := []string{imagePath, "stdout"}
+	if t.languages != "" {
+		args = append(args, "-l", t.languages)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.path, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return normalizeText(stdout.String()), nil
+}
+
+// normalizeText 去除每行首尾空白和空行，tesseract输出的换页符一并去掉
+func normalizeText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.ReplaceAll(line, "\f", ""))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,73 @@
+package vision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"media-service/config"
+)
+
+// TextExtractor 从图片中识别文字（OCR）
+type TextExtractor interface {
+	// Name 识别引擎名称，记录在媒体元数据中
+	Name() string
+	// ExtractText 识别imagePath指定图片中的文字，无文字时返回空字符串
+	ExtractText(ctx context.Context, imagePath string) (string, error)
+}
+
+// Captioner 生成图片的文字描述
+type Captioner interface {
+	// Model 生成描述使用的模型，记录在媒体元数据中
+	Model() string
+	// Caption 为图片生成一句话描述，image为图片内容，mimeType如image/jpeg
+	Caption(ctx context.Context, image []byte, mimeType string) (string, error)
+}
+
+// Analyzer 图片内容识别，OCR和图片描述可分别启用，未启用的一项为nil
+type Analyzer struct {
+	OCR           TextExtractor
+	Captioner     Captioner
+	Timeout       time.Duration
+	MaxTextLength int
+}
+
+// NewAnalyzer 根据配置创建图片内容识别器
+func NewAnalyzer(cfg *config.Config, logger *zap.Logger) (*Analyzer, error) {
+	analyzer := &Analyzer{
+		Timeout:       time.Duration(cfg.Vision.TimeoutSeconds) * time.Second,
+		MaxTextLength: cfg.Vision.MaxTextLength,
+	}
+	if analyzer.Timeout <= 0 {
+		analyzer.Timeout = 60 * time.Second
+	}
+
+	switch strings.ToLower(cfg.Vision.OCRProvider) {
+	case "", "none":
+	case "tesseract":
+		analyzer.OCR = NewTesseractExtractor(cfg.Vision.TesseractPath, cfg.Vision.OCRLanguages)
+	default:
+		return nil, fmt.Errorf("unsupported ocr provider: %s", cfg.Vision.OCRProvider)
+	}
+
+	switch strings.ToLower(cfg.Vision.CaptionProvider) {
+	case "", "none":
+	case "openai":
+		if cfg.Vision.CaptionBaseURL == "" || cfg.Vision.CaptionModel == "" {
+			return nil, fmt.Errorf("caption provider openai requires LLM_BASE_URL and CAPTION_MODEL")
+		}
+		analyzer.Captioner = NewOpenAICaptioner(cfg.Vision.CaptionBaseURL, cfg.Vision.CaptionAPIKey, cfg.Vision.CaptionModel, analyzer.Timeout, logger)
+	default:
+		return nil, fmt.Errorf("unsupported caption provider: %s", cfg.Vision.CaptionProvider)
+	}
+
+	return analyzer, nil
+}
+
+// Enabled 是否启用了OCR或图片描述
+func (a *Analyzer) Enabled() bool {
+	return a != nil && (a.OCR != nil || a.Captioner != nil)
+}
@@ -19,6 +19,9 @@ type Claims struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Role     string `json:"role"`
+	TenantID string `json:"tenant_id,omitempty"` // 多租户部署中用户所属的租户
+	// 用户服务签发的刷新令牌token_type为refresh，不能用于访问接口
+	TokenType string `json:"token_type,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -75,6 +78,9 @@ func (manager *JWTManager) VerifyToken(tokenString string) (*Claims, error) {
 	if !ok || !token.Valid {
 		return nil, fmt.Errorf("invalid token")
 	}
+	if claims.TokenType == "refresh" {
+		return nil, fmt.Errorf("refresh token cannot be used as access token")
+	}
 
 	return claims, nil
 }
@@ -240,6 +246,18 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Flush 支持流式响应
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap 供http.ResponseController访问底层ResponseWriter
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // 上下文辅助函数
 
 // GetUserIDFromContext 从上下文获取用户ID
@@ -274,6 +292,14 @@ func GetRoleFromContext(ctx context.Context) string {
 	return ""
 }
 
+// GetTenantIDFromContext 从上下文获取租户ID，令牌中没有租户时返回空字符串
+func GetTenantIDFromContext(ctx context.Context) string {
+	if claims := GetClaimsFromContext(ctx); claims != nil {
+		return claims.TenantID
+	}
+	return ""
+}
+
 // GetClaimsFromContext 从上下文获取JWT声明
 func GetClaimsFromContext(ctx context.Context) *Claims {
 	if claims, ok := ctx.Value("claims").(*Claims); ok {
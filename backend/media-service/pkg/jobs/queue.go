@@ -0,0 +1,148 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 任务状态
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Job 队列中的一个任务
+type Job struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LastError   string          `json:"last_error,omitempty"`
+}
+
+// Queue 任务队列。Claim领取到期任务并加租约，租约过期仍未完成的任务（如实例崩溃）会被重新领取
+type Queue interface {
+	// Enqueue 入队，相同ID的任务已存在时忽略，用于定时任务在多个实例间去重
+	Enqueue(ctx context.Context, job *Job) error
+	// Claim 领取最多limit个到期任务，领取时执行次数加一
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Job, error)
+	Complete(ctx context.Context, id string) error
+	// Retry 任务执行失败，在runAt之后重新执行
+	Retry(ctx context.Context, id string, runAt time.Time, lastError string) error
+	// Fail 任务达到最大执行次数，不再重试
+	Fail(ctx context.Context, id string, lastError string) error
+	// Prune 删除早于before结束的已完成和失败任务
+	Prune(ctx context.Context, before time.Time) error
+}
+
+// memoryEntry 内存队列中的任务及其状态
+type memoryEntry struct {
+	job         Job
+	status      string
+	lockedUntil time.Time
+	updatedAt   time.Time
+}
+
+// MemoryQueue 内存任务队列，不持久化，用于没有数据库的服务和本地开发
+type MemoryQueue struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+// NewMemoryQueue 创建内存任务队列
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{entries: make(map[string]*memoryEntry)}
+}
+
+func (q *MemoryQueue) Enqueue(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, exists := q.entries[job.ID]; exists {
+		return nil
+	}
+	q.entries[job.ID] = &memoryEntry{job: *job, status: StatusPending, updatedAt: time.Now()}
+	return nil
+}
+
+func (q *MemoryQueue) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var due []*memoryEntry
+	for _, entry := range q.entries {
+		if (entry.status == StatusPending && !entry.job.RunAt.After(now)) ||
+			(entry.status == StatusRunning && entry.lockedUntil.Before(now)) {
+			due = append(due, entry)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].job.RunAt.Before(due[j].job.RunAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]*Job, 0, len(due))
+	for _, entry := range due {
+		entry.status = StatusRunning
+		entry.lockedUntil = now.Add(lease)
+		entry.updatedAt = now
+		entry.job.Attempts++
+		job := entry.job
+		claimed = append(claimed, &job)
+	}
+	return claimed, nil
+}
+
+func (q *MemoryQueue) Complete(ctx context.Context, id string) error {
+	q.update(id, StatusCompleted, nil, "")
+	return nil
+}
+
+func (q *MemoryQueue) Retry(ctx context.Context, id string, runAt time.Time, lastError string) error {
+	q.update(id, StatusPending, &runAt, lastError)
+	return nil
+}
+
+func (q *MemoryQueue) Fail(ctx context.Context, id string, lastError string) error {
+	q.update(id, StatusFailed, nil, lastError)
+	return nil
+}
+
+func (q *MemoryQueue) Prune(ctx context.Context, before time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for id, entry := range q.entries {
+		if (entry.status == StatusCompleted || entry.status == StatusFailed) && entry.updatedAt.Before(before) {
+			delete(q.entries, id)
+		}
+	}
+	return nil
+}
+
+// update 更新任务状态
+func (q *MemoryQueue) update(id, status string, runAt *time.Time, lastError string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.entries[id]
+	if !ok {
+		return
+	}
+	entry.status = status
+	entry.lockedUntil = time.Time{}
+	entry.updatedAt = time.Now()
+	if runAt != nil {
+		entry.job.RunAt = *runAt
+	}
+	if lastError != "" {
+		entry.job.LastError = lastError
+	}
+}
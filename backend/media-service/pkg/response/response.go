@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"media-service/pkg/validation"
 )
 
 // Response 统一响应结构
@@ -198,9 +200,9 @@ func ServiceUnavailable(w http.ResponseWriter, message string) {
 	Error(w, http.StatusServiceUnavailable, message, nil)
 }
 
-// ValidationError 验证错误响应
-func ValidationError(w http.ResponseWriter, errors map[string]string) {
-	ErrorWithCode(w, http.StatusBadRequest, "VALIDATION_ERROR", "Validation failed", errors)
+// ValidationError 验证错误响应，details为各字段的错误列表
+func ValidationError(w http.ResponseWriter, errs validation.Errors) {
+	ErrorWithCode(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Validation failed", errs)
 }
 
 // writeJSON 写入JSON响应
@@ -0,0 +1,43 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ErrorResponse 校验失败的响应体
+type ErrorResponse struct {
+	Error  string `json:"error"`
+	Fields Errors `json:"fields"`
+}
+
+// AsErrors 从错误链中取出字段校验错误
+func AsErrors(err error) (Errors, bool) {
+	var errs Errors
+	if errors.As(err, &errs) {
+		return errs, true
+	}
+	return nil, false
+}
+
+// WriteError 以422返回全部字段错误
+func WriteError(w http.ResponseWriter, errs Errors) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(ErrorResponse{ // nolint: errcheck
+		Error:  "validation failed",
+		Fields: errs,
+	})
+}
+
+// Request 校验请求结构体，失败时写出422响应并返回false，供处理函数在解码请求体后调用
+func Request(w http.ResponseWriter, v interface{}) bool {
+	err := Struct(v)
+	if err == nil {
+		return true
+	}
+	errs, _ := AsErrors(err)
+	WriteError(w, errs)
+	return false
+}
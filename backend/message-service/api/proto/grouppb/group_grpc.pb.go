@@ -0,0 +1,115 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: group.proto
+
+// 群组服务gRPC接口，供消息服务等内部服务查询群成员角色和权限，不经过API网关
+// 从group-service/api/proto/group.proto复制，修改时两处保持一致
+
+package grouppb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	GroupMembershipService_GetMembership_FullMethodName = "/chatapp.group.v1.GroupMembershipService/GetMembership"
+)
+
+// GroupMembershipServiceClient is the client API for GroupMembershipService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GroupMembershipServiceClient interface {
+	// GetMembership 获取用户在群组中的角色和权限，用户不是成员时is_member为false
+	GetMembership(ctx context.Context, in *GetMembershipRequest, opts ...grpc.CallOption) (*GetMembershipResponse, error)
+}
+
+type groupMembershipServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGroupMembershipServiceClient(cc grpc.ClientConnInterface) GroupMembershipServiceClient {
+	return &groupMembershipServiceClient{cc}
+}
+
+func (c *groupMembershipServiceClient) GetMembership(ctx context.Context, in *GetMembershipRequest, opts ...grpc.CallOption) (*GetMembershipResponse, error) {
+	out := new(GetMembershipResponse)
+	err := c.cc.Invoke(ctx, GroupMembershipService_GetMembership_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GroupMembershipServiceServer is the server API for GroupMembershipService service.
+// All implementations must embed UnimplementedGroupMembershipServiceServer
+// for forward compatibility
+type GroupMembershipServiceServer interface {
+	// GetMembership 获取用户在群组中的角色和权限，用户不是成员时is_member为false
+	GetMembership(context.Context, *GetMembershipRequest) (*GetMembershipResponse, error)
+	mustEmbedUnimplementedGroupMembershipServiceServer()
+}
+
+// UnimplementedGroupMembershipServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedGroupMembershipServiceServer struct {
+}
+
+func (UnimplementedGroupMembershipServiceServer) GetMembership(context.Context, *GetMembershipRequest) (*GetMembershipResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMembership not implemented")
+}
+func (UnimplementedGroupMembershipServiceServer) mustEmbedUnimplementedGroupMembershipServiceServer() {
+}
+
+// UnsafeGroupMembershipServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GroupMembershipServiceServer will
+// result in compilation errors.
+type UnsafeGroupMembershipServiceServer interface {
+	mustEmbedUnimplementedGroupMembershipServiceServer()
+}
+
+func RegisterGroupMembershipServiceServer(s grpc.ServiceRegistrar, srv GroupMembershipServiceServer) {
+	s.RegisterService(&GroupMembershipService_ServiceDesc, srv)
+}
+
+func _GroupMembershipService_GetMembership_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMembershipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GroupMembershipServiceServer).GetMembership(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GroupMembershipService_GetMembership_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GroupMembershipServiceServer).GetMembership(ctx, req.(*GetMembershipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// GroupMembershipService_ServiceDesc is the grpc.ServiceDesc for GroupMembershipService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GroupMembershipService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chatapp.group.v1.GroupMembershipService",
+	HandlerType: (*GroupMembershipServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetMembership",
+			Handler:    _GroupMembershipService_GetMembership_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "group.proto",
+}
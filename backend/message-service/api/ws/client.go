@@ -2,7 +2,10 @@ package ws
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -34,21 +37,40 @@ var (
 // Client 客户端连接
 type Client struct {
 	manager *ClientManager // 客户端管理器
+	fanout  *FanoutPool     // 消息分发工作池
 	conn    *websocket.Conn // WebSocket连接
 	userID  string          // 用户ID
+	session *Session        // 会话信息
 	send    chan []byte     // 发送通道
 	logger  *zap.Logger     // 日志记录器
+	closing chan struct{}   // 关闭前排空时关闭，写入泵写出缓冲中的消息后以1012关闭连接
+	done    chan struct{}   // 写入泵退出时关闭
+
+	protocol             atomic.Pointer[clientProtocol] // 握手协商的协议，未握手时为旧版协议
+	compressionAvailable bool                           // 升级连接时是否协商了permessage-deflate
 }
 
-// NewClient 创建客户端
-func NewClient(manager *ClientManager, conn *websocket.Conn, userID string, logger *zap.Logger) *Client {
-	return &Client{
-		manager: manager,
-		conn:    conn,
-		userID:  userID,
-		send:    make(chan []byte, 256),
-		logger:  logger,
+// NewClient 创建客户端，客户端发送hello之前按旧版协议推送事件且不压缩
+func NewClient(manager *ClientManager, fanout *FanoutPool, conn *websocket.Conn, session *Session, compressionAvailable bool, logger *zap.Logger) *Client {
+	client := &Client{
+		manager:              manager,
+		fanout:               fanout,
+		conn:                 conn,
+		userID:               session.UserID,
+		session:              session,
+		send:                 make(chan []byte, 256),
+		logger:               logger,
+		closing:              make(chan struct{}),
+		done:                 make(chan struct{}),
+		compressionAvailable: compressionAvailable,
 	}
+	client.protocol.Store(legacyProtocol)
+	return client
+}
+
+// accepts 判断连接是否接收该类型的事件
+func (c *Client) accepts(event WebSocketMessageType) bool {
+	return c.protocol.Load().accepts(event)
 }
 
 // ReadPump 读取泵，从WebSocket连接读取消息
@@ -75,6 +97,9 @@ func (c *Client) ReadPump() {
 			break
 		}
 
+		// 收到任何消息都说明连接存活，客户端可以用应用层的pong消息代替协议层的Pong帧
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+
 		// 处理消息
 		message = bytes.TrimSpace(bytes.Replace(message, newline, space, -1))
 		c.handleMessage(message)
@@ -87,6 +112,7 @@ func (c *Client) WritePump() {
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
+		close(c.done)
 	}()
 
 	for {
@@ -99,6 +125,10 @@ func (c *Client) WritePump() {
 				return
 			}
 
+			// 压缩只能在写入泵中切换，握手后的第一条消息开始生效
+			if c.compressionAvailable {
+				c.conn.EnableWriteCompression(c.protocol.Load().compression)
+			}
 			w, err := c.conn.NextWriter(websocket.TextMessage)
 			if err != nil {
 				return
@@ -116,6 +146,12 @@ func (c *Client) WritePump() {
 			if err := w.Close(); err != nil {
 				return
 			}
+		case <-c.closing:
+			// 写出缓冲中已有的消息，然后通知客户端服务重启
+			c.flushPending()
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseServiceRestart, "server shutting down"))
+			return
 		case <-ticker.C:
 			// 发送心跳
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
@@ -134,6 +170,24 @@ func (c *Client) WritePump() {
 	}
 }
 
+// flushPending 写出发送通道中已缓冲的消息，不等待新消息
+func (c *Client) flushPending() {
+	for {
+		select {
+		case message, ok := <-c.send:
+			if !ok {
+				return
+			}
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
 // handleMessage 处理接收到的消息
 func (c *Client) handleMessage(message []byte) {
 	// 解析消息
@@ -145,15 +199,23 @@ func (c *Client) handleMessage(message []byte) {
 
 	// 根据消息类型处理
 	switch wsMessage.Type {
+	case WebSocketMessageTypeHello:
+		// 协议握手
+		c.handleHello(wsMessage)
 	case WebSocketMessageTypeMessage:
 		// 处理聊天消息
 		c.handleChatMessage(wsMessage)
 	case WebSocketMessageTypePing:
 		// 处理心跳消息
 		c.handlePingMessage(wsMessage)
+	case WebSocketMessageTypePong:
+		// 心跳响应，读取超时已在收到消息时延长
 	case WebSocketMessageTypeSystem:
 		// 处理系统消息
 		c.handleSystemMessage(wsMessage)
+	case WebSocketMessageTypePresenceSub:
+		// 订阅在线状态
+		c.handlePresenceSubscribe(wsMessage)
 	default:
 		c.logger.Warn("Unknown message type", zap.String("type", string(wsMessage.Type)))
 	}
@@ -203,12 +265,11 @@ func (c *Client) handleDirectMessage(message Message) {
 	}
 	responseBytes, _ := json.Marshal(responseMsg)
 
-	// 发送给接收者
+	// 通过分发工作池发送给接收者，不阻塞读取泵
 	if message.ReceiverID != nil {
 		receiverID := *message.ReceiverID
-		if sent := c.manager.SendToUser(receiverID, responseBytes); sent {
-			// 消息已发送给接收者，更新状态为已送达
-			message.Status = MessageStatusDelivered
+		if err := c.fanout.Submit(directConversationKey(c.userID, receiverID), WebSocketMessageTypeMessage, []string{receiverID}, responseBytes); err != nil {
+			c.logger.Warn("Failed to dispatch direct message", zap.String("receiverID", receiverID), zap.Error(err))
 		}
 	}
 
@@ -234,8 +295,18 @@ func (c *Client) handleGroupMessage(message Message) {
 	// 这里需要调用群组服务获取群组成员列表
 	// 然后将消息发送给所有群组成员
 
-	// 暂时使用广播方式发送给所有连接的客户端
-	c.manager.Broadcast(responseBytes)
+	// 暂时发送给所有连接的客户端，按群组ID排序保证群内消息顺序
+	if err := c.fanout.Submit(*message.GroupID, WebSocketMessageTypeMessage, nil, responseBytes); err != nil {
+		c.logger.Warn("Failed to dispatch group message", zap.String("groupID", *message.GroupID), zap.Error(err))
+	}
+}
+
+// directConversationKey 单聊的排序键，与收发方向无关
+func directConversationKey(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return a + ":" + b
 }
 
 // handlePingMessage 处理心跳消息
@@ -251,6 +322,56 @@ func (c *Client) handlePingMessage(wsMessage WebSocketMessage) {
 	c.send <- pongBytes
 }
 
+// handlePresenceSubscribe 处理在线状态订阅，回复订阅用户的当前状态，之后推送状态变化
+func (c *Client) handlePresenceSubscribe(wsMessage WebSocketMessage) {
+	if c.manager.presence == nil {
+		c.sendError("presence tracking is not enabled")
+		return
+	}
+
+	data, err := json.Marshal(wsMessage.Data)
+	if err != nil {
+		c.logger.Error("Failed to marshal presence subscription", zap.Error(err))
+		return
+	}
+	var request PresenceSubscribeRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		c.sendError("invalid presence subscription")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), presenceTimeout)
+	defer cancel()
+	presences, err := c.manager.presence.Subscribe(ctx, c, request.UserIDs)
+	if err != nil {
+		if errors.Is(err, ErrTooManyPresenceUsers) {
+			c.sendError(err.Error())
+			return
+		}
+		c.logger.Warn("Failed to query presence", zap.String("userID", c.userID), zap.Error(err))
+		c.sendError("failed to query presence")
+		return
+	}
+
+	snapshot, _ := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypePresenceSnap,
+		Data: presences,
+	})
+	c.send <- snapshot
+}
+
+// sendError 以系统消息的形式回复错误
+func (c *Client) sendError(message string) {
+	errorMsg, _ := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeSystem,
+		Data: SystemMessage{
+			Type:    "error",
+			Content: message,
+		},
+	})
+	c.send <- errorMsg
+}
+
 // handleSystemMessage 处理系统消息
 func (c *Client) handleSystemMessage(wsMessage WebSocketMessage) {
 	// 处理系统消息
@@ -1,32 +1,48 @@
 package ws
 
 import (
+	"context"
 	"encoding/json"
+	"math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+// registryTimeout 单次会话登记操作的超时时间
+const registryTimeout = 3 * time.Second
+
 // ClientManager 客户端管理器
 type ClientManager struct {
-	clients    map[string]*Client // 客户端映射表，键为用户ID，值为客户端
-	register   chan *Client       // 注册通道
-	unregister chan *Client       // 注销通道
-	broadcast  chan []byte        // 广播通道
-	mutex      sync.RWMutex       // 读写锁
-	logger     *zap.Logger        // 日志记录器
-}
-
-// NewClientManager 创建客户端管理器
-func NewClientManager(logger *zap.Logger) *ClientManager {
-	return &ClientManager{
-		clients:    make(map[string]*Client),
+	clients    map[string]map[*Client]bool // 客户端映射表，键为用户ID，同一用户可在多个设备上同时连接
+	register   chan *Client                // 注册通道
+	unregister chan *Client                // 注销通道
+	broadcast  chan []byte                 // 广播通道
+	registry   *SessionRegistry            // 会话登记表，为nil时仅维护本实例的连接
+	presence   *PresenceTracker            // 在线状态跟踪器，为nil时不记录在线状态
+	draining   atomic.Bool                 // 关闭前排空中，不再接受新连接
+	mutex      sync.RWMutex                // 读写锁
+	logger     *zap.Logger                 // 日志记录器
+}
+
+// NewClientManager 创建客户端管理器，registry和presence可以为nil
+func NewClientManager(registry *SessionRegistry, presence *PresenceTracker, logger *zap.Logger) *ClientManager {
+	manager := &ClientManager{
+		clients:    make(map[string]map[*Client]bool),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		broadcast:  make(chan []byte),
+		registry:   registry,
+		presence:   presence,
 		logger:     logger,
 	}
+	if presence != nil {
+		presence.manager = manager
+	}
+	return manager
 }
 
 // Start 启动客户端管理器
@@ -35,11 +51,28 @@ func (manager *ClientManager) Start() {
 	for {
 		select {
 		case client := <-manager.register:
+			// 排空期间建立的连接直接关闭，客户端重连到其他实例
+			if manager.draining.Load() {
+				client.conn.Close()
+				continue
+			}
+
 			// 注册客户端
 			manager.mutex.Lock()
-			manager.clients[client.userID] = client
+			if manager.clients[client.userID] == nil {
+				manager.clients[client.userID] = make(map[*Client]bool)
+			}
+			manager.clients[client.userID][client] = true
 			manager.mutex.Unlock()
-			manager.logger.Info("Client registered", zap.String("userID", client.userID))
+			manager.logger.Info("Client registered",
+				zap.String("userID", client.userID),
+				zap.String("sessionID", client.session.ID),
+				zap.String("device", client.session.Device),
+			)
+			manager.addSession(client.session)
+			if manager.presence != nil {
+				manager.presence.connected(client)
+			}
 
 			// 发送系统消息通知客户端连接成功
 			systemMsg := WebSocketMessage{
@@ -47,7 +80,7 @@ func (manager *ClientManager) Start() {
 				Data: SystemMessage{
 					Type:    "connected",
 					Content: "Connected to WebSocket server",
-					Data:    map[string]interface{}{"timestamp": time.Now().Unix()},
+					Data:    map[string]interface{}{"timestamp": time.Now().Unix(), "session_id": client.session.ID},
 				},
 			}
 			msgBytes, _ := json.Marshal(systemMsg)
@@ -56,31 +89,66 @@ func (manager *ClientManager) Start() {
 		case client := <-manager.unregister:
 			// 注销客户端
 			manager.mutex.Lock()
-			if _, ok := manager.clients[client.userID]; ok {
-				delete(manager.clients, client.userID)
-				close(client.send)
-				manager.logger.Info("Client unregistered", zap.String("userID", client.userID))
-			}
+			removed := manager.removeClient(client)
 			manager.mutex.Unlock()
+			if removed {
+				manager.logger.Info("Client unregistered",
+					zap.String("userID", client.userID),
+					zap.String("sessionID", client.session.ID),
+				)
+			}
 
 		case message := <-manager.broadcast:
 			// 广播消息给所有客户端
-			manager.mutex.RLock()
-			for _, client := range manager.clients {
-				select {
-				case client.send <- message:
-					// 消息发送成功
-				default:
-					// 消息发送失败，关闭客户端连接
-					close(client.send)
-					delete(manager.clients, client.userID)
+			manager.mutex.Lock()
+			for _, clients := range manager.clients {
+				for client := range clients {
+					select {
+					case client.send <- message:
+						// 消息发送成功
+					default:
+						// 消息发送失败，关闭客户端连接
+						manager.removeClient(client)
+					}
 				}
 			}
-			manager.mutex.RUnlock()
+			manager.mutex.Unlock()
 		}
 	}
 }
 
+// SyncSessions 启动会话登记表同步：定期为本实例的会话续期，并订阅其他实例发出的踢下线事件
+// 未配置会话登记表时直接返回，ctx取消时停止
+func (manager *ClientManager) SyncSessions(ctx context.Context) {
+	if manager.registry == nil {
+		return
+	}
+
+	manager.registry.Subscribe(ctx, func(userID string) {
+		if manager.Disconnect(userID) {
+			manager.logger.Info("User disconnected by cluster event", zap.String("userID", userID))
+		}
+	})
+
+	go func() {
+		ticker := time.NewTicker(manager.registry.TTL() / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refreshCtx, cancel := context.WithTimeout(ctx, registryTimeout)
+				if err := manager.registry.Refresh(refreshCtx, manager.localSessionIDs()); err != nil {
+					manager.logger.Warn("Failed to refresh WebSocket sessions", zap.Error(err))
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
 // Register 注册客户端
 func (manager *ClientManager) Register(client *Client) {
 	manager.register <- client
@@ -96,25 +164,171 @@ func (manager *ClientManager) Broadcast(message []byte) {
 	manager.broadcast <- message
 }
 
-// SendToUser 发送消息给指定用户
+// SendToUser 发送消息给指定用户的所有连接
 func (manager *ClientManager) SendToUser(userID string, message []byte) bool {
 	manager.mutex.RLock()
 	defer manager.mutex.RUnlock()
 
-	if client, ok := manager.clients[userID]; ok {
+	clients, ok := manager.clients[userID]
+	if !ok {
+		return false
+	}
+	for client := range clients {
 		client.send <- message
-		return true
 	}
-	return false
+	return true
 }
 
-// GetClient 获取指定用户的客户端
-func (manager *ClientManager) GetClient(userID string) (*Client, bool) {
+// TrySendToUser 非阻塞地发送事件给指定用户协商了该事件的连接，用户离线、没有连接接收该事件或发送缓冲区已满时返回false
+func (manager *ClientManager) TrySendToUser(userID string, event WebSocketMessageType, message []byte) bool {
 	manager.mutex.RLock()
 	defer manager.mutex.RUnlock()
 
-	client, ok := manager.clients[userID]
-	return client, ok
+	clients, ok := manager.clients[userID]
+	if !ok {
+		return false
+	}
+
+	sent := false
+	for client := range clients {
+		if !client.accepts(event) {
+			continue
+		}
+		select {
+		case client.send <- message:
+			sent = true
+		default:
+			manager.logger.Warn("Client send buffer full, message skipped",
+				zap.String("userID", userID),
+				zap.String("sessionID", client.session.ID),
+			)
+		}
+	}
+	return sent
+}
+
+// Disconnect 关闭指定用户在本实例上的所有连接，读取泵退出后会自动注销客户端
+func (manager *ClientManager) Disconnect(userID string) bool {
+	clients := manager.GetClients(userID)
+	for _, client := range clients {
+		client.conn.Close()
+	}
+	return len(clients) > 0
+}
+
+// DisconnectEverywhere 关闭指定用户在所有实例上的连接
+// 返回本实例是否有连接以及登记表中该用户的会话数
+func (manager *ClientManager) DisconnectEverywhere(ctx context.Context, userID string) (bool, int, error) {
+	local := manager.Disconnect(userID)
+	if manager.registry == nil {
+		return local, 0, nil
+	}
+
+	sessions, err := manager.registry.List(ctx, userID)
+	if err != nil {
+		return local, 0, err
+	}
+	if err := manager.registry.PublishDisconnect(ctx, userID); err != nil {
+		return local, len(sessions), err
+	}
+	return local, len(sessions), nil
+}
+
+// Sessions 列出WebSocket会话，userID为空时列出全部
+// 配置了会话登记表时返回所有实例的会话，否则仅返回本实例的会话
+func (manager *ClientManager) Sessions(ctx context.Context, userID string) ([]*Session, error) {
+	if manager.registry != nil {
+		return manager.registry.List(ctx, userID)
+	}
+
+	manager.mutex.RLock()
+	sessions := make([]*Session, 0)
+	for id, clients := range manager.clients {
+		if userID != "" && id != userID {
+			continue
+		}
+		for client := range clients {
+			sessions = append(sessions, client.session)
+		}
+	}
+	manager.mutex.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ConnectedAt.After(sessions[j].ConnectedAt)
+	})
+	return sessions, nil
+}
+
+// Drain 关闭前排空本实例的所有连接：不再接受新连接，给每个客户端发送reconnect系统消息，
+// 写出发送缓冲中已有的消息后以1012（服务重启）关闭连接，并删除会话登记
+// 连接关闭后读取泵退出时照常注销客户端
+// 重连等待时间在[0, reconnectWindow)内随机，避免所有客户端同时重连到其他实例
+// ctx取消时不再等待尚未关闭的连接
+func (manager *ClientManager) Drain(ctx context.Context, reconnectWindow time.Duration) error {
+	if !manager.draining.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	manager.mutex.RLock()
+	clients := make([]*Client, 0)
+	for _, userClients := range manager.clients {
+		for client := range userClients {
+			clients = append(clients, client)
+		}
+	}
+	for _, client := range clients {
+		var delay int64
+		if reconnectWindow > 0 {
+			delay = rand.Int63n(int64(reconnectWindow))
+		}
+		notice, _ := json.Marshal(WebSocketMessage{
+			Type: WebSocketMessageTypeSystem,
+			Data: SystemMessage{
+				Type:    "reconnect",
+				Content: "Server is shutting down, please reconnect",
+				Data:    map[string]interface{}{"reconnect_after_ms": time.Duration(delay).Milliseconds()},
+			},
+		})
+		select {
+		case client.send <- notice:
+		default:
+			// 发送缓冲区已满，客户端仍会收到关闭帧
+		}
+		close(client.closing)
+	}
+	manager.mutex.RUnlock()
+
+	manager.logger.Info("Draining WebSocket connections", zap.Int("connections", len(clients)))
+
+	// 同步删除会话登记，进程退出后异步删除可能来不及执行
+	if manager.registry != nil {
+		for _, client := range clients {
+			if err := manager.registry.Remove(ctx, client.session); err != nil {
+				manager.logger.Warn("Failed to remove WebSocket session", zap.String("sessionID", client.session.ID), zap.Error(err))
+			}
+		}
+	}
+
+	for _, client := range clients {
+		select {
+		case <-client.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// GetClients 获取指定用户在本实例上的所有客户端
+func (manager *ClientManager) GetClients(userID string) []*Client {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	clients := make([]*Client, 0, len(manager.clients[userID]))
+	for client := range manager.clients[userID] {
+		clients = append(clients, client)
+	}
+	return clients
 }
 
 // GetConnectedUsers 获取所有已连接的用户ID
@@ -129,10 +343,95 @@ func (manager *ClientManager) GetConnectedUsers() []string {
 	return userIDs
 }
 
-// GetClientCount 获取客户端数量
+// GetClientCount 获取客户端连接数量
 func (manager *ClientManager) GetClientCount() int {
 	manager.mutex.RLock()
 	defer manager.mutex.RUnlock()
 
-	return len(manager.clients)
-}
\ No newline at end of file
+	count := 0
+	for _, clients := range manager.clients {
+		count += len(clients)
+	}
+	return count
+}
+
+// removeClient 移除客户端并关闭发送通道，调用方需持有写锁
+func (manager *ClientManager) removeClient(client *Client) bool {
+	clients, ok := manager.clients[client.userID]
+	if !ok || !clients[client] {
+		return false
+	}
+
+	delete(clients, client)
+	if len(clients) == 0 {
+		delete(manager.clients, client.userID)
+	}
+	close(client.send)
+	manager.removeSession(client.session)
+	if manager.presence != nil {
+		manager.presence.disconnected(client)
+	}
+	return true
+}
+
+// trySend 非阻塞地发送事件给指定的客户端，跳过已注销和不接收该事件的客户端
+func (manager *ClientManager) trySend(clients []*Client, event WebSocketMessageType, message []byte) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	for _, client := range clients {
+		if !manager.clients[client.userID][client] || !client.accepts(event) {
+			continue
+		}
+		select {
+		case client.send <- message:
+		default:
+			manager.logger.Warn("Client send buffer full, message skipped",
+				zap.String("userID", client.userID),
+				zap.String("sessionID", client.session.ID),
+			)
+		}
+	}
+}
+
+// localSessionIDs 本实例上所有会话的ID
+func (manager *ClientManager) localSessionIDs() []string {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	ids := make([]string, 0)
+	for _, clients := range manager.clients {
+		for client := range clients {
+			ids = append(ids, client.session.ID)
+		}
+	}
+	return ids
+}
+
+// addSession 异步登记会话，不阻塞管理器主循环
+func (manager *ClientManager) addSession(session *Session) {
+	if manager.registry == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), registryTimeout)
+		defer cancel()
+		if err := manager.registry.Add(ctx, session); err != nil {
+			manager.logger.Warn("Failed to register WebSocket session", zap.String("sessionID", session.ID), zap.Error(err))
+		}
+	}()
+}
+
+// removeSession 异步删除会话登记
+func (manager *ClientManager) removeSession(session *Session) {
+	if manager.registry == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), registryTimeout)
+		defer cancel()
+		if err := manager.registry.Remove(ctx, session); err != nil {
+			manager.logger.Warn("Failed to remove WebSocket session", zap.String("sessionID", session.ID), zap.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,272 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/config"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// ErrFanoutQueueFull 分发队列已满
+var ErrFanoutQueueFull = errors.New("fan-out queue is full")
+
+// deliveryRecordTimeout 记录送达回执的超时时间，避免数据库变慢时长时间占用worker
+const deliveryRecordTimeout = 5 * time.Second
+
+// fanoutJob 一次消息分发任务
+type fanoutJob struct {
+	key        string               // 排序键，通常为会话ID
+	event      WebSocketMessageType // 事件类型，只推送给协商了该事件的连接
+	recipients []string             // 接收者，为空时发给所有在线用户
+	payload    []byte
+	message    *domain.Message // 聊天消息分发时设置，推送成功后记录送达
+}
+
+// FanoutMetrics 分发统计快照
+type FanoutMetrics struct {
+	Workers    int   `json:"workers"`
+	QueueDepth int64 `json:"queue_depth"`
+	QueueSize  int   `json:"queue_size"`
+	Enqueued   int64 `json:"enqueued"`
+	Dropped    int64 `json:"dropped"`
+	Delivered  int64 `json:"delivered"`
+	Skipped    int64 `json:"skipped"` // 接收者离线或发送缓冲区已满
+}
+
+// FanoutPool 有界的消息分发工作池
+// 同一排序键的任务总是进入同一个worker的队列，从而保证同一会话内消息的投递顺序
+type FanoutPool struct {
+	manager  *ClientManager
+	recorder domain.DeliveryRecorder
+	queues   []chan *fanoutJob
+	size     int
+	wg       sync.WaitGroup
+	// mu 防止Stop关闭队列时仍有任务提交
+	mu     sync.RWMutex
+	closed bool
+
+	depth     atomic.Int64
+	enqueued  atomic.Int64
+	dropped   atomic.Int64
+	delivered atomic.Int64
+	skipped   atomic.Int64
+
+	logger *zap.Logger
+}
+
+// NewFanoutPool 创建分发工作池
+func NewFanoutPool(manager *ClientManager, cfg config.FanoutConfig, logger *zap.Logger) *FanoutPool {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	size := cfg.QueueSize
+	if size <= 0 {
+		size = 1
+	}
+
+	queues := make([]chan *fanoutJob, workers)
+	for i := range queues {
+		queues[i] = make(chan *fanoutJob, size)
+	}
+
+	return &FanoutPool{
+		manager: manager,
+		queues:  queues,
+		size:    size,
+		logger:  logger,
+	}
+}
+
+// SetDeliveryRecorder 设置送达回执记录器，必须在Start之前调用
+func (p *FanoutPool) SetDeliveryRecorder(recorder domain.DeliveryRecorder) {
+	p.recorder = recorder
+}
+
+// Start 启动所有worker
+func (p *FanoutPool) Start() {
+	for i, queue := range p.queues {
+		p.wg.Add(1)
+		go p.work(i, queue)
+	}
+	p.logger.Info("Fan-out pool started", zap.Int("workers", len(p.queues)), zap.Int("queue_size", p.size))
+}
+
+// Stop 停止接收任务并等待队列中的任务处理完
+func (p *FanoutPool) Stop() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.wg.Wait()
+}
+
+// Submit 提交分发任务，不阻塞调用方；队列已满时返回ErrFanoutQueueFull
+func (p *FanoutPool) Submit(key string, event WebSocketMessageType, recipients []string, payload []byte) error {
+	return p.submit(&fanoutJob{key: key, event: event, recipients: recipients, payload: payload})
+}
+
+func (p *FanoutPool) submit(job *fanoutJob) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		p.dropped.Add(1)
+		return ErrFanoutQueueFull
+	}
+
+	p.depth.Add(1)
+	select {
+	case p.queues[p.shard(job.key)] <- job:
+		p.enqueued.Add(1)
+		return nil
+	default:
+		p.depth.Add(-1)
+		p.dropped.Add(1)
+		p.logger.Warn("Fan-out queue full, dropping message", zap.String("key", job.key), zap.Int("recipients", len(job.recipients)))
+		return ErrFanoutQueueFull
+	}
+}
+
+// Dispatch 实现domain.MessageDispatcher，把已保存的消息推送给在线的会话参与者
+func (p *FanoutPool) Dispatch(message *domain.Message, recipients []string) error {
+	// 空接收者在工作池中表示广播，这里没有需要推送的人时直接返回
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeMessage,
+		Data: message,
+	})
+	if err != nil {
+		return err
+	}
+	return p.submit(&fanoutJob{key: message.Conversation, event: WebSocketMessageTypeMessage, recipients: recipients, payload: payload, message: message})
+}
+
+// DispatchEvent 实现domain.MessageDispatcher，把消息的编辑、撤回和置顶推送给在线的会话参与者
+// 与消息使用同一排序键，参与者总是先收到消息再收到它的变更
+func (p *FanoutPool) DispatchEvent(event domain.MessageEventType, message *domain.Message, recipients []string) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	var messageType WebSocketMessageType
+	switch event {
+	case domain.MessageEventEdited:
+		messageType = WebSocketMessageTypeEdited
+	case domain.MessageEventDeleted:
+		messageType = WebSocketMessageTypeDeleted
+	case domain.MessageEventPinned:
+		messageType = WebSocketMessageTypePinned
+	case domain.MessageEventUnpinned:
+		messageType = WebSocketMessageTypeUnpinned
+	default:
+		return fmt.Errorf("unknown message event: %s", event)
+	}
+
+	payload, err := json.Marshal(WebSocketMessage{
+		Type: messageType,
+		Data: message,
+	})
+	if err != nil {
+		return err
+	}
+	return p.Submit(message.Conversation, messageType, recipients, payload)
+}
+
+// NotifyReceipt 实现domain.ReceiptNotifier，把回执变更推送给在线的消息发送者
+// 与消息使用同一排序键，发送者总是先收到消息再收到它的回执
+func (p *FanoutPool) NotifyReceipt(senderID string, update *domain.ReceiptUpdate) error {
+	payload, err := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeReceipt,
+		Data: update,
+	})
+	if err != nil {
+		return err
+	}
+	return p.Submit(update.ConversationID, WebSocketMessageTypeReceipt, []string{senderID}, payload)
+}
+
+// Metrics 获取分发统计
+func (p *FanoutPool) Metrics() FanoutMetrics {
+	return FanoutMetrics{
+		Workers:    len(p.queues),
+		QueueDepth: p.depth.Load(),
+		QueueSize:  p.size,
+		Enqueued:   p.enqueued.Load(),
+		Dropped:    p.dropped.Load(),
+		Delivered:  p.delivered.Load(),
+		Skipped:    p.skipped.Load(),
+	}
+}
+
+// work 顺序处理一个队列中的任务
+func (p *FanoutPool) work(id int, queue chan *fanoutJob) {
+	defer p.wg.Done()
+
+	for job := range queue {
+		p.depth.Add(-1)
+
+		recipients := job.recipients
+		if len(recipients) == 0 {
+			recipients = p.manager.GetConnectedUsers()
+		}
+
+		var delivered, skipped int64
+		var deliveredTo []string
+		for _, userID := range recipients {
+			if p.manager.TrySendToUser(userID, job.event, job.payload) {
+				delivered++
+				deliveredTo = append(deliveredTo, userID)
+			} else {
+				skipped++
+			}
+		}
+		p.delivered.Add(delivered)
+		p.skipped.Add(skipped)
+
+		if job.message != nil && p.recorder != nil && len(deliveredTo) > 0 {
+			p.recordDelivered(job.message, deliveredTo)
+		}
+
+		p.logger.Debug("Fan-out job processed",
+			zap.Int("worker", id),
+			zap.String("key", job.key),
+			zap.Int64("delivered", delivered),
+			zap.Int64("skipped", skipped),
+		)
+	}
+}
+
+// recordDelivered 记录送达回执，失败只记录日志
+func (p *FanoutPool) recordDelivered(message *domain.Message, userIDs []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryRecordTimeout)
+	defer cancel()
+
+	if err := p.recorder.RecordDelivered(ctx, message, userIDs); err != nil {
+		p.logger.Warn("Failed to record message delivery",
+			zap.Error(err),
+			zap.String("message_id", message.ID),
+			zap.Int("recipients", len(userIDs)),
+		)
+	}
+}
+
+// shard 根据排序键选择队列
+func (p *FanoutPool) shard(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key)) // nolint: errcheck
+	return int(h.Sum32() % uint32(len(p.queues)))
+}
@@ -33,11 +33,21 @@ type WebSocketMessageType string
 
 // WebSocket消息类型常量
 const (
-	WebSocketMessageTypeMessage      WebSocketMessageType = "message"      // 聊天消息
-	WebSocketMessageTypeNotification WebSocketMessageType = "notification" // 通知消息
-	WebSocketMessageTypeSystem       WebSocketMessageType = "system"       // 系统消息
-	WebSocketMessageTypePing         WebSocketMessageType = "ping"         // 心跳消息
-	WebSocketMessageTypePong         WebSocketMessageType = "pong"         // 心跳响应
+	WebSocketMessageTypeMessage      WebSocketMessageType = "message"            // 聊天消息
+	WebSocketMessageTypeNotification WebSocketMessageType = "notification"       // 通知消息
+	WebSocketMessageTypeSystem       WebSocketMessageType = "system"             // 系统消息
+	WebSocketMessageTypePing         WebSocketMessageType = "ping"               // 心跳消息
+	WebSocketMessageTypePong         WebSocketMessageType = "pong"               // 心跳响应
+	WebSocketMessageTypeReceipt      WebSocketMessageType = "receipt"            // 送达/已读回执
+	WebSocketMessageTypeEdited       WebSocketMessageType = "message.edited"     // 消息被编辑
+	WebSocketMessageTypeDeleted      WebSocketMessageType = "message.deleted"    // 消息被撤回
+	WebSocketMessageTypePinned       WebSocketMessageType = "message.pinned"     // 消息被置顶
+	WebSocketMessageTypeUnpinned     WebSocketMessageType = "message.unpinned"   // 消息被取消置顶
+	WebSocketMessageTypePresenceSub  WebSocketMessageType = "presence.subscribe" // 订阅用户在线状态
+	WebSocketMessageTypePresenceSnap WebSocketMessageType = "presence.snapshot"  // 订阅用户的当前在线状态
+	WebSocketMessageTypePresence     WebSocketMessageType = "presence"           // 在线状态变化
+	WebSocketMessageTypeHello        WebSocketMessageType = "hello"              // 客户端握手，声明协议版本和支持的事件
+	WebSocketMessageTypeCapabilities WebSocketMessageType = "capabilities"       // 握手回复，协商后的协议版本和事件
 )
 
 // WebSocketMessage WebSocket消息
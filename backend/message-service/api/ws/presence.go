@@ -0,0 +1,318 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// presenceChannel 跨实例在线状态事件频道
+	presenceChannel = "presence:events"
+	// presenceTimeout 单次在线状态读写的超时时间
+	presenceTimeout = 3 * time.Second
+	// presenceQueueSize 连接变化队列长度，队列满时丢弃，由心跳超时兜底
+	presenceQueueSize = 4096
+	// MaxPresenceSubscriptions 单个连接最多订阅的用户数，也是批量查询的上限
+	MaxPresenceSubscriptions = 200
+)
+
+// ErrTooManyPresenceUsers 订阅或查询的用户数超过上限
+var ErrTooManyPresenceUsers = errors.New("too many users in presence request")
+
+// presenceOp 连接变化
+type presenceOp struct {
+	connected bool
+	userID    string
+	sessionID string
+	at        time.Time
+}
+
+// presenceEnvelope 跨实例发布的在线状态事件，订阅方忽略本实例发出的事件
+type presenceEnvelope struct {
+	InstanceID string               `json:"instance_id"`
+	Event      domain.PresenceEvent `json:"event"`
+}
+
+// PresenceSubscribeRequest 客户端订阅在线状态的请求，每次订阅替换该连接之前的订阅
+type PresenceSubscribeRequest struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// PresenceTracker 在线状态跟踪器
+// 客户端注册和注销时记录连接，用户的第一个连接建立时发布上线事件，最后一个连接断开时发布下线事件
+// 定期为本实例的连接刷新心跳，实例异常退出后连接在心跳超时后视为已断开
+// client为nil时事件只推送给本实例上订阅了该用户的连接
+type PresenceTracker struct {
+	repo       domain.PresenceRepository
+	client     *redis.Client
+	instanceID string
+	timeout    time.Duration
+	manager    *ClientManager
+	ops        chan presenceOp
+	watchers   map[string]map[*Client]bool // 被订阅的用户ID -> 订阅的连接
+	watching   map[*Client][]string        // 连接 -> 订阅的用户ID
+	mutex      sync.RWMutex
+	logger     *zap.Logger
+}
+
+// NewPresenceTracker 创建在线状态跟踪器，client可以为nil
+func NewPresenceTracker(repo domain.PresenceRepository, client *redis.Client, timeout time.Duration, instanceID string, logger *zap.Logger) *PresenceTracker {
+	if timeout <= 0 {
+		timeout = 90 * time.Second
+	}
+	return &PresenceTracker{
+		repo:       repo,
+		client:     client,
+		instanceID: instanceID,
+		timeout:    timeout,
+		ops:        make(chan presenceOp, presenceQueueSize),
+		watchers:   make(map[string]map[*Client]bool),
+		watching:   make(map[*Client][]string),
+		logger:     logger,
+	}
+}
+
+// Run 处理连接变化并定期刷新心跳，订阅其他实例发布的在线状态事件，ctx取消时退出
+func (t *PresenceTracker) Run(ctx context.Context) {
+	if t.client != nil {
+		pubsub := t.client.Subscribe(ctx, presenceChannel)
+		go t.receive(ctx, pubsub)
+	}
+
+	go func() {
+		ticker := time.NewTicker(t.timeout / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case op := <-t.ops:
+				t.apply(ctx, op)
+			case <-ticker.C:
+				t.heartbeat(ctx)
+			}
+		}
+	}()
+}
+
+// GetPresence 查询单个用户的在线状态
+func (t *PresenceTracker) GetPresence(ctx context.Context, userID string) (*domain.Presence, error) {
+	presences, err := t.repo.GetPresence(ctx, []string{userID}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return presences[0], nil
+}
+
+// QueryPresence 批量查询在线状态，结果顺序与userIDs一致
+func (t *PresenceTracker) QueryPresence(ctx context.Context, userIDs []string) ([]*domain.Presence, error) {
+	if len(userIDs) > MaxPresenceSubscriptions {
+		return nil, ErrTooManyPresenceUsers
+	}
+	if len(userIDs) == 0 {
+		return []*domain.Presence{}, nil
+	}
+	return t.repo.GetPresence(ctx, userIDs, time.Now())
+}
+
+// Subscribe 替换连接订阅的用户，返回这些用户当前的在线状态
+func (t *PresenceTracker) Subscribe(ctx context.Context, client *Client, userIDs []string) ([]*domain.Presence, error) {
+	userIDs = uniqueStrings(userIDs)
+	if len(userIDs) > MaxPresenceSubscriptions {
+		return nil, ErrTooManyPresenceUsers
+	}
+
+	t.mutex.Lock()
+	t.unwatchLocked(client)
+	for _, userID := range userIDs {
+		if t.watchers[userID] == nil {
+			t.watchers[userID] = make(map[*Client]bool)
+		}
+		t.watchers[userID][client] = true
+	}
+	if len(userIDs) > 0 {
+		t.watching[client] = userIDs
+	}
+	t.mutex.Unlock()
+
+	return t.QueryPresence(ctx, userIDs)
+}
+
+// connected 记录连接建立，不阻塞客户端管理器主循环
+func (t *PresenceTracker) connected(client *Client) {
+	t.enqueue(presenceOp{connected: true, userID: client.userID, sessionID: client.session.ID, at: time.Now()})
+}
+
+// disconnected 记录连接断开并移除该连接的订阅，调用方可能持有客户端管理器的写锁
+func (t *PresenceTracker) disconnected(client *Client) {
+	t.mutex.Lock()
+	t.unwatchLocked(client)
+	t.mutex.Unlock()
+
+	t.enqueue(presenceOp{userID: client.userID, sessionID: client.session.ID, at: time.Now()})
+}
+
+// enqueue 非阻塞地提交连接变化
+func (t *PresenceTracker) enqueue(op presenceOp) {
+	select {
+	case t.ops <- op:
+	default:
+		t.logger.Warn("Presence queue full, connection change dropped",
+			zap.String("userID", op.userID),
+			zap.String("sessionID", op.sessionID),
+			zap.Bool("connected", op.connected),
+		)
+	}
+}
+
+// apply 记录连接变化，用户状态改变时发布事件
+func (t *PresenceTracker) apply(ctx context.Context, op presenceOp) {
+	opCtx, cancel := context.WithTimeout(ctx, presenceTimeout)
+	defer cancel()
+
+	event := domain.PresenceEvent{UserID: op.userID, At: op.at}
+	if op.connected {
+		wasOffline, err := t.repo.Connect(opCtx, op.userID, op.sessionID, op.at)
+		if err != nil {
+			t.logger.Warn("Failed to record connection", zap.String("userID", op.userID), zap.Error(err))
+			return
+		}
+		if !wasOffline {
+			return
+		}
+		event.Status = domain.PresenceOnline
+	} else {
+		nowOffline, err := t.repo.Disconnect(opCtx, op.userID, op.sessionID, op.at)
+		if err != nil {
+			t.logger.Warn("Failed to record disconnection", zap.String("userID", op.userID), zap.Error(err))
+			return
+		}
+		if !nowOffline {
+			return
+		}
+		lastSeen := op.at
+		event.Status = domain.PresenceOffline
+		event.LastSeen = &lastSeen
+	}
+
+	t.deliver(event)
+	t.publish(opCtx, event)
+}
+
+// heartbeat 刷新本实例所有连接的心跳
+func (t *PresenceTracker) heartbeat(ctx context.Context) {
+	if t.manager == nil {
+		return
+	}
+
+	sessions := make(map[string][]string)
+	t.manager.mutex.RLock()
+	for userID, clients := range t.manager.clients {
+		for client := range clients {
+			sessions[userID] = append(sessions[userID], client.session.ID)
+		}
+	}
+	t.manager.mutex.RUnlock()
+
+	now := time.Now()
+	for userID, ids := range sessions {
+		beatCtx, cancel := context.WithTimeout(ctx, presenceTimeout)
+		err := t.repo.Heartbeat(beatCtx, userID, ids, now)
+		cancel()
+		if err != nil {
+			t.logger.Warn("Failed to refresh presence heartbeat", zap.String("userID", userID), zap.Error(err))
+		}
+	}
+}
+
+// publish 把事件发布给其他实例
+func (t *PresenceTracker) publish(ctx context.Context, event domain.PresenceEvent) {
+	if t.client == nil {
+		return
+	}
+	payload, err := json.Marshal(presenceEnvelope{InstanceID: t.instanceID, Event: event})
+	if err != nil {
+		return
+	}
+	if err := t.client.Publish(ctx, presenceChannel, payload).Err(); err != nil {
+		t.logger.Warn("Failed to publish presence event", zap.String("userID", event.UserID), zap.Error(err))
+	}
+}
+
+// receive 处理其他实例发布的事件
+func (t *PresenceTracker) receive(ctx context.Context, pubsub *redis.PubSub) {
+	defer pubsub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-pubsub.Channel():
+			if !ok {
+				return
+			}
+			var envelope presenceEnvelope
+			if err := json.Unmarshal([]byte(msg.Payload), &envelope); err != nil {
+				t.logger.Warn("Invalid presence event", zap.Error(err))
+				continue
+			}
+			if envelope.InstanceID == t.instanceID {
+				continue
+			}
+			t.deliver(envelope.Event)
+		}
+	}
+}
+
+// deliver 把事件推送给本实例上订阅了该用户的连接
+func (t *PresenceTracker) deliver(event domain.PresenceEvent) {
+	t.mutex.RLock()
+	clients := make([]*Client, 0, len(t.watchers[event.UserID]))
+	for client := range t.watchers[event.UserID] {
+		clients = append(clients, client)
+	}
+	t.mutex.RUnlock()
+
+	if len(clients) == 0 || t.manager == nil {
+		return
+	}
+
+	message, _ := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypePresence,
+		Data: event,
+	})
+	t.manager.trySend(clients, WebSocketMessageTypePresence, message)
+}
+
+// unwatchLocked 移除连接的全部订阅，调用方需持有写锁
+func (t *PresenceTracker) unwatchLocked(client *Client) {
+	for _, userID := range t.watching[client] {
+		delete(t.watchers[userID], client)
+		if len(t.watchers[userID]) == 0 {
+			delete(t.watchers, userID)
+		}
+	}
+	delete(t.watching, client)
+}
+
+// uniqueStrings 去除空值和重复值，保持原有顺序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		result = append(result, value)
+	}
+	return result
+}
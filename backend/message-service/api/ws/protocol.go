@@ -0,0 +1,146 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	// ProtocolVersion 服务端支持的最新协议版本
+	ProtocolVersion = 2
+	// LegacyProtocolVersion 没有发送hello的客户端按此版本处理，只收到该版本已有的事件类型
+	LegacyProtocolVersion = 1
+)
+
+// eventVersions 服务端推送的事件类型及引入该事件的协议版本
+// 新增推送事件时在这里登记，旧版本客户端不会收到比自己协议版本新的事件
+var eventVersions = map[WebSocketMessageType]int{
+	WebSocketMessageTypeMessage:      1,
+	WebSocketMessageTypeNotification: 1,
+	WebSocketMessageTypeReceipt:      1,
+	WebSocketMessageTypeEdited:       1,
+	WebSocketMessageTypeDeleted:      1,
+	WebSocketMessageTypePinned:       1,
+	WebSocketMessageTypeUnpinned:     1,
+	WebSocketMessageTypePresenceSnap: 1,
+	WebSocketMessageTypePresence:     1,
+}
+
+// HelloMessage 客户端连接后发送的握手消息
+// Events为空时接收协议版本内的全部事件，否则只接收列出的事件；Compression表示客户端能处理压缩帧
+type HelloMessage struct {
+	ProtocolVersion int                    `json:"protocol_version"`
+	Events          []WebSocketMessageType `json:"events,omitempty"`
+	Compression     bool                   `json:"compression"`
+}
+
+// CapabilitiesMessage 服务端对hello的回复，说明协商后的协议版本、会推送的事件类型和是否压缩
+type CapabilitiesMessage struct {
+	ProtocolVersion       int                    `json:"protocol_version"`
+	LatestProtocolVersion int                    `json:"latest_protocol_version"`
+	Events                []WebSocketMessageType `json:"events"`
+	Compression           bool                   `json:"compression"`
+}
+
+// clientProtocol 连接协商后的协议，events为nil时接收协议版本内的全部事件
+type clientProtocol struct {
+	version     int
+	events      map[WebSocketMessageType]bool
+	compression bool
+}
+
+// legacyProtocol 未握手连接使用的协议，不压缩
+var legacyProtocol = &clientProtocol{version: LegacyProtocolVersion}
+
+// negotiate 根据客户端的hello协商协议，compressionAvailable表示升级连接时已协商permessage-deflate
+func negotiate(hello HelloMessage, compressionAvailable bool) *clientProtocol {
+	version := hello.ProtocolVersion
+	if version < LegacyProtocolVersion {
+		version = LegacyProtocolVersion
+	}
+	if version > ProtocolVersion {
+		version = ProtocolVersion
+	}
+
+	protocol := &clientProtocol{
+		version:     version,
+		compression: hello.Compression && compressionAvailable,
+	}
+	if len(hello.Events) > 0 {
+		protocol.events = make(map[WebSocketMessageType]bool, len(hello.Events))
+		for _, event := range hello.Events {
+			protocol.events[event] = true
+		}
+	}
+	return protocol
+}
+
+// accepts 判断连接是否接收该类型的事件，系统、心跳和握手消息总是发送
+func (p *clientProtocol) accepts(event WebSocketMessageType) bool {
+	switch event {
+	case WebSocketMessageTypeSystem, WebSocketMessageTypePing, WebSocketMessageTypePong, WebSocketMessageTypeCapabilities:
+		return true
+	}
+	version, known := eventVersions[event]
+	if !known || version > p.version {
+		return false
+	}
+	return p.events == nil || p.events[event]
+}
+
+// capabilities 生成对hello的回复，事件类型按名称排序
+func (p *clientProtocol) capabilities() CapabilitiesMessage {
+	events := make([]WebSocketMessageType, 0, len(eventVersions))
+	for event := range eventVersions {
+		if p.accepts(event) {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i] < events[j] })
+
+	return CapabilitiesMessage{
+		ProtocolVersion:       p.version,
+		LatestProtocolVersion: ProtocolVersion,
+		Events:                events,
+		Compression:           p.compression,
+	}
+}
+
+// handleHello 处理握手消息，更新连接的协议并回复capabilities
+// 可以重复发送，以最后一次为准
+func (c *Client) handleHello(wsMessage WebSocketMessage) {
+	data, err := json.Marshal(wsMessage.Data)
+	if err != nil {
+		c.sendError("invalid hello")
+		return
+	}
+	var hello HelloMessage
+	if err := json.Unmarshal(data, &hello); err != nil {
+		c.sendError("invalid hello")
+		return
+	}
+
+	protocol := negotiate(hello, c.compressionAvailable)
+	c.protocol.Store(protocol)
+
+	reply, _ := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeCapabilities,
+		Data: protocol.capabilities(),
+	})
+	c.send <- reply
+}
+
+// compressionRequested 判断升级请求是否协商了permessage-deflate，与Upgrader的判断一致
+func compressionRequested(r *http.Request) bool {
+	for _, header := range r.Header.Values("Sec-WebSocket-Extensions") {
+		for _, extension := range strings.Split(header, ",") {
+			name := strings.TrimSpace(strings.Split(extension, ";")[0])
+			if strings.EqualFold(name, "permessage-deflate") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -8,12 +8,17 @@ import (
 )
 
 // RegisterRoutes 注册WebSocket路由
-func RegisterRoutes(router *mux.Router, messageService domain.MessageService, jwtManager *auth.JWTManager, logger *zap.Logger) {
+func RegisterRoutes(router *mux.Router, clientManager *ClientManager, fanout *FanoutPool, messageService domain.MessageService, jwtManager *auth.JWTManager, instanceID string, compression bool, logger *zap.Logger) {
 	// 创建WebSocket处理器
-	websocketHandler := NewWebSocketHandler(messageService, jwtManager, logger)
+	websocketHandler := NewWebSocketHandler(clientManager, fanout, messageService, jwtManager, instanceID, compression, logger)
 
 	// 注册WebSocket路由
 	router.HandleFunc("/ws", websocketHandler.ServeWS)
 
+	// 内部路由，不经过API网关暴露
+	router.HandleFunc("/internal/users/{id}/disconnect", websocketHandler.DisconnectUser).Methods("POST")
+	router.HandleFunc("/internal/ws/sessions", websocketHandler.ListSessions).Methods("GET")
+	router.HandleFunc("/internal/fanout/metrics", websocketHandler.FanoutMetrics).Methods("GET")
+
 	logger.Info("WebSocket routes registered")
 }
@@ -0,0 +1,219 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// sessionKeyPrefix 会话记录键前缀，完整格式为 ws:session:<sessionID>
+	sessionKeyPrefix = "ws:session:"
+	// userSessionsKeyPrefix 用户会话集合键前缀，完整格式为 ws:user:<userID>:sessions
+	userSessionsKeyPrefix = "ws:user:"
+	// allSessionsKey 全部会话ID集合，用于管理员列表
+	allSessionsKey = "ws:sessions"
+	// disconnectChannel 跨实例踢下线事件频道
+	disconnectChannel = "ws:disconnect"
+)
+
+// Session WebSocket会话信息
+type Session struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	Device      string    `json:"device"`
+	RemoteAddr  string    `json:"remote_addr"`
+	InstanceID  string    `json:"instance_id"`
+	ConnectedAt time.Time `json:"connected_at"`
+}
+
+// disconnectEvent 踢下线事件，断开该用户在所有实例上的全部会话
+type disconnectEvent struct {
+	UserID string `json:"user_id"`
+}
+
+// SessionRegistry 基于Redis的WebSocket会话登记表，多个消息服务实例共享
+// 会话记录带有效期，连接存活期间由客户端管理器定期续期，实例异常退出后记录自动过期
+type SessionRegistry struct {
+	client     *redis.Client
+	ttl        time.Duration
+	instanceID string
+	logger     *zap.Logger
+}
+
+// NewSessionRegistry 创建会话登记表
+func NewSessionRegistry(client *redis.Client, ttl time.Duration, instanceID string, logger *zap.Logger) *SessionRegistry {
+	if ttl <= 0 {
+		ttl = 90 * time.Second
+	}
+	return &SessionRegistry{client: client, ttl: ttl, instanceID: instanceID, logger: logger}
+}
+
+// InstanceID 当前实例标识
+func (r *SessionRegistry) InstanceID() string {
+	return r.instanceID
+}
+
+// TTL 会话记录有效期
+func (r *SessionRegistry) TTL() time.Duration {
+	return r.ttl
+}
+
+// Add 登记会话
+func (r *SessionRegistry) Add(ctx context.Context, session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, sessionKey(session.ID), data, r.ttl)
+	pipe.SAdd(ctx, userSessionsKey(session.UserID), session.ID)
+	pipe.SAdd(ctx, allSessionsKey, session.ID)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Refresh 为仍然在线的会话续期
+func (r *SessionRegistry) Refresh(ctx context.Context, sessionIDs []string) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+
+	pipe := r.client.Pipeline()
+	for _, id := range sessionIDs {
+		pipe.Expire(ctx, sessionKey(id), r.ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Remove 删除会话
+func (r *SessionRegistry) Remove(ctx context.Context, session *Session) error {
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, sessionKey(session.ID))
+	pipe.SRem(ctx, userSessionsKey(session.UserID), session.ID)
+	pipe.SRem(ctx, allSessionsKey, session.ID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// List 列出会话，userID为空时列出全部会话，按连接时间倒序
+// 集合中已过期的会话ID会被顺带清理
+func (r *SessionRegistry) List(ctx context.Context, userID string) ([]*Session, error) {
+	setKey := allSessionsKey
+	if userID != "" {
+		setKey = userSessionsKey(userID)
+	}
+
+	ids, err := r.client.SMembers(ctx, setKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(ids))
+	if len(ids) == 0 {
+		return sessions, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = sessionKey(id)
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			stale = append(stale, ids[i])
+			continue
+		}
+		var session Session
+		if err := json.Unmarshal([]byte(data), &session); err != nil {
+			r.logger.Warn("Invalid session record", zap.String("session_id", ids[i]), zap.Error(err))
+			continue
+		}
+		sessions = append(sessions, &session)
+	}
+	if len(stale) > 0 {
+		r.removeStale(ctx, userID, stale)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ConnectedAt.After(sessions[j].ConnectedAt)
+	})
+	return sessions, nil
+}
+
+// PublishDisconnect 广播踢下线事件，所有实例收到后断开对应的本地连接
+func (r *SessionRegistry) PublishDisconnect(ctx context.Context, userID string) error {
+	data, err := json.Marshal(disconnectEvent{UserID: userID})
+	if err != nil {
+		return err
+	}
+	return r.client.Publish(ctx, disconnectChannel, data).Err()
+}
+
+// Subscribe 订阅踢下线事件，ctx取消时退出
+func (r *SessionRegistry) Subscribe(ctx context.Context, handle func(userID string)) {
+	pubsub := r.client.Subscribe(ctx, disconnectChannel)
+
+	go func() {
+		defer pubsub.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-pubsub.Channel():
+				if !ok {
+					return
+				}
+				var event disconnectEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					r.logger.Warn("Invalid disconnect event", zap.Error(err))
+					continue
+				}
+				handle(event.UserID)
+			}
+		}
+	}()
+
+	r.logger.Info("WebSocket disconnect subscriber started")
+}
+
+// removeStale 从集合中移除已过期的会话ID
+func (r *SessionRegistry) removeStale(ctx context.Context, userID string, ids []string) {
+	members := make([]interface{}, len(ids))
+	for i, id := range ids {
+		members[i] = id
+	}
+
+	pipe := r.client.Pipeline()
+	pipe.SRem(ctx, allSessionsKey, members...)
+	if userID != "" {
+		pipe.SRem(ctx, userSessionsKey(userID), members...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Warn("Failed to remove stale sessions", zap.Error(err))
+	}
+}
+
+// sessionKey 会话记录键
+func sessionKey(sessionID string) string {
+	return sessionKeyPrefix + sessionID
+}
+
+// userSessionsKey 用户会话集合键
+func userSessionsKey(userID string) string {
+	return fmt.Sprintf("%s%s:sessions", userSessionsKeyPrefix, userID)
+}
@@ -3,7 +3,11 @@ package ws
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/neohope/chatapp/message-service/internal/domain"
 	"github.com/neohope/chatapp/message-service/pkg/auth"
@@ -13,37 +17,36 @@ import (
 // WebSocketHandler WebSocket处理器
 type WebSocketHandler struct {
 	clientManager  *ClientManager
+	fanout         *FanoutPool
 	messageService domain.MessageService
 	jwtManager     *auth.JWTManager
+	instanceID     string
+	upgrader       websocket.Upgrader
 	logger         *zap.Logger
 }
 
-// 升级HTTP连接为WebSocket的配置
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		// 在生产环境中应该检查Origin
-		return true
-	},
-}
-
 // NewWebSocketHandler 创建一个新的WebSocket处理器
-func NewWebSocketHandler(messageService domain.MessageService, jwtManager *auth.JWTManager, logger *zap.Logger) *WebSocketHandler {
-	// 创建客户端管理器
-	clientManager := NewClientManager(logger)
-
-	handler := &WebSocketHandler{
+// 客户端管理器和分发工作池由调用方创建并启动，与HTTP发送路径共用
+// compression为true时升级连接时协商permessage-deflate，客户端在hello中确认后才压缩
+func NewWebSocketHandler(clientManager *ClientManager, fanout *FanoutPool, messageService domain.MessageService, jwtManager *auth.JWTManager, instanceID string, compression bool, logger *zap.Logger) *WebSocketHandler {
+	return &WebSocketHandler{
 		clientManager:  clientManager,
+		fanout:         fanout,
 		messageService: messageService,
 		jwtManager:     jwtManager,
-		logger:         logger,
+		instanceID:     instanceID,
+		// 升级HTTP连接为WebSocket的配置
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:    1024,
+			WriteBufferSize:   1024,
+			EnableCompression: compression,
+			CheckOrigin: func(r *http.Request) bool {
+				// 在生产环境中应该检查Origin
+				return true
+			},
+		},
+		logger: logger,
 	}
-
-	// 启动客户端管理器
-	go clientManager.Start()
-
-	return handler
 }
 
 // SendToUser 发送消息给特定用户
@@ -68,6 +71,45 @@ func (h *WebSocketHandler) BroadcastToAll(message interface{}) error {
 	return nil
 }
 
+// DisconnectUser 断开指定用户在所有实例上的WebSocket连接（内部接口，供用户服务在停用账户和管理员踢下线时调用）
+func (h *WebSocketHandler) DisconnectUser(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+
+	local, sessions, err := h.clientManager.DisconnectEverywhere(r.Context(), userID)
+	if err != nil {
+		// 本实例的连接已经断开，其他实例的会话会在过期后失效
+		h.logger.Warn("Failed to publish disconnect event", zap.String("userID", userID), zap.Error(err))
+	}
+	disconnected := local || sessions > 0
+	if disconnected {
+		h.logger.Info("User disconnected", zap.String("userID", userID), zap.Int("sessions", sessions))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"disconnected": disconnected, "sessions": sessions})
+}
+
+// ListSessions 列出WebSocket会话（内部接口，供网关管理接口调用），可按user_id过滤
+func (h *WebSocketHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+
+	sessions, err := h.clientManager.Sessions(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to list sessions", zap.Error(err))
+		http.Error(w, "Failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"sessions": sessions, "total": len(sessions)})
+}
+
+// FanoutMetrics 输出分发工作池统计（内部接口）
+func (h *WebSocketHandler) FanoutMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.fanout.Metrics())
+}
+
 // GetConnectedUsers 获取所有已连接的用户ID
 func (h *WebSocketHandler) GetConnectedUsers() []string {
 	return h.clientManager.GetConnectedUsers()
@@ -97,14 +139,26 @@ func (h *WebSocketHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 升级HTTP连接为WebSocket
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		h.logger.Error("Failed to upgrade connection to WebSocket", zap.Error(err))
 		return
 	}
 
-	// 创建新客户端
-	client := NewClient(h.clientManager, conn, claims.UserID, h.logger)
+	// 创建新客户端，设备标识由客户端通过device参数传入，缺省时使用User-Agent
+	session := &Session{
+		ID:          uuid.New().String(),
+		UserID:      claims.UserID,
+		Device:      r.URL.Query().Get("device"),
+		RemoteAddr:  clientAddr(r),
+		InstanceID:  h.instanceID,
+		ConnectedAt: time.Now(),
+	}
+	if session.Device == "" {
+		session.Device = r.UserAgent()
+	}
+	compressionAvailable := h.upgrader.EnableCompression && compressionRequested(r)
+	client := NewClient(h.clientManager, h.fanout, conn, session, compressionAvailable, h.logger)
 
 	// 注册客户端
 	h.clientManager.Register(client)
@@ -113,3 +167,11 @@ func (h *WebSocketHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
 	go client.ReadPump()
 	go client.WritePump()
 }
+
+// clientAddr 获取客户端地址，经过代理时优先使用X-Forwarded-For中的第一个地址
+func clientAddr(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	return r.RemoteAddr
+}
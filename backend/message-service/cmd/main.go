@@ -5,19 +5,29 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/neohope/chatapp/message-service/api/ws"
 	"github.com/neohope/chatapp/message-service/config"
+	"github.com/neohope/chatapp/message-service/internal/analytics"
+	"github.com/neohope/chatapp/message-service/internal/client"
 	httpdelivery "github.com/neohope/chatapp/message-service/internal/delivery/http"
 	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/internal/llm"
 	"github.com/neohope/chatapp/message-service/internal/repository"
 	"github.com/neohope/chatapp/message-service/internal/service"
 	"github.com/neohope/chatapp/message-service/pkg/auth"
+	"github.com/neohope/chatapp/message-service/pkg/events"
+	"github.com/neohope/chatapp/message-service/pkg/jobs"
+	"github.com/neohope/chatapp/message-service/pkg/lock"
 	"github.com/neohope/chatapp/message-service/pkg/logger"
+	"github.com/neohope/chatapp/message-service/pkg/metrics"
+	"github.com/neohope/chatapp/message-service/pkg/openapi"
+	"github.com/neohope/chatapp/message-service/pkg/recovery"
+	"github.com/neohope/chatapp/message-service/pkg/shutdown"
+	"github.com/neohope/chatapp/message-service/pkg/tracing"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
@@ -43,42 +53,308 @@ func main() {
 		zap.String("log_level", cfg.Service.LogLevel),
 	)
 
-	// 尝试连接数据库，如果失败则使用内存存储
-	db, err := repository.NewPostgresDB(cfg.GetPostgresConnString(), log)
+	// 链路追踪：从请求的traceparent继续网关开始的trace，按配置导出到OTLP或Jaeger
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Options{
+		Service:       "message-service",
+		Exporter:      cfg.Tracing.Exporter,
+		Endpoint:      cfg.Tracing.Endpoint,
+		Insecure:      cfg.Tracing.Insecure,
+		SamplePercent: cfg.Tracing.SamplePercent,
+	}, log)
 	if err != nil {
-		log.Warn("Failed to connect to database, using in-memory storage for WebSocket testing", zap.Error(err))
-		db = nil
+		log.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+
+	// Prometheus指标：HTTP请求、WebSocket连接、数据库连接池和归档冷存储
+	var serviceMetrics *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		serviceMetrics = metrics.New("message-service")
 	}
 
 	// 初始化JWT管理器
 	jwtManager := auth.NewJWTManager(cfg.JWT.SecretKey, cfg.JWT.ExpirationHours)
 
-	// 初始化仓库
+	// Redis用于WebSocket会话登记、Redis消息存储、分析事件导出和定时任务主实例选举，不可用时相关功能退化
+	var redisClient *redis.Client
+	if cfg.Sessions.RegistryEnabled || cfg.Storage.MessageStore == config.MessageStoreRedis || cfg.Analytics.Enabled || cfg.LeaderElection.Enabled {
+		redisClient = initRedis(cfg, log)
+		if redisClient != nil {
+			defer redisClient.Close()
+		}
+	}
+
+	// 初始化仓库，存储不可用时使用内存存储
 	var messageRepo domain.MessageRepository
-	if db != nil {
-		messageRepo = repository.NewMessageRepository(db, log)
+	var archiveRepo domain.ArchiveRepository
+	var interactionRepo domain.InteractionRepository
+	var receiptRepo domain.ReceiptRepository
+	var pinRepo domain.PinRepository
+	var muteRepo domain.MuteRepository
+	var starRepo domain.StarRepository
+	// 后台任务队列，使用PostgreSQL存储时持久化
+	var jobQueue jobs.Queue = jobs.NewMemoryQueue()
+	persistentStore := cfg.Storage.MessageStore
+	if persistentStore == config.MessageStoreRedis {
+		persistentStore = cfg.Storage.RedisBackingStore
+	}
+	switch persistentStore {
+	case config.MessageStoreMongoDB:
+		mongoDB, err := repository.NewMongoDB(cfg.MongoDB.URI, cfg.MongoDB.Database, log)
+		if err != nil {
+			log.Warn("Failed to connect to MongoDB, using in-memory storage for WebSocket testing", zap.Error(err))
+			messageRepo = repository.NewInMemoryMessageRepository(log)
+			interactionRepo = repository.NewInMemoryInteractionRepository(log)
+			receiptRepo = repository.NewInMemoryReceiptRepository(log)
+			pinRepo = repository.NewInMemoryPinRepository(log)
+			muteRepo = repository.NewInMemoryMuteRepository(log)
+			starRepo = repository.NewInMemoryStarRepository(log)
+		} else {
+			messageRepo = repository.NewMongoMessageRepository(mongoDB, log)
+			interactionRepo = repository.NewMongoInteractionRepository(mongoDB, log)
+			receiptRepo = repository.NewMongoReceiptRepository(mongoDB, log)
+			pinRepo = repository.NewMongoPinRepository(mongoDB, log)
+			muteRepo = repository.NewMongoMuteRepository(mongoDB, log)
+			starRepo = repository.NewMongoStarRepository(mongoDB, log)
+		}
+	default:
+		db, err := repository.NewPostgresDB(cfg.GetPostgresConnString(), log)
+		if err != nil {
+			log.Warn("Failed to connect to database, using in-memory storage for WebSocket testing", zap.Error(err))
+			messageRepo = repository.NewInMemoryMessageRepository(log)
+			interactionRepo = repository.NewInMemoryInteractionRepository(log)
+			receiptRepo = repository.NewInMemoryReceiptRepository(log)
+			pinRepo = repository.NewInMemoryPinRepository(log)
+			muteRepo = repository.NewInMemoryMuteRepository(log)
+			starRepo = repository.NewInMemoryStarRepository(log)
+		} else {
+			messageRepo = repository.NewMessageRepository(db, log)
+			archiveRepo = repository.NewArchiveRepository(db, log)
+			interactionRepo = repository.NewInteractionRepository(db, log)
+			receiptRepo = repository.NewReceiptRepository(db, log)
+			pinRepo = repository.NewPinRepository(db, log)
+			muteRepo = repository.NewMuteRepository(db, log)
+			starRepo = repository.NewStarRepository(db, log)
+			if serviceMetrics != nil {
+				serviceMetrics.RegisterDB(db.DB, "postgres")
+			}
+			if pgQueue, err := jobs.NewPostgresQueue(db.DB, "message-service"); err != nil {
+				log.Warn("Failed to initialize persistent job queue, using memory queue", zap.Error(err))
+			} else {
+				jobQueue = pgQueue
+			}
+		}
+	}
+	if cfg.Storage.MessageStore == config.MessageStoreRedis {
+		if redisClient == nil {
+			log.Warn("Redis unavailable, serving message history from the backing store", zap.String("backing_store", persistentStore))
+		} else {
+			messageRepo = repository.NewRedisMessageRepository(
+				redisClient,
+				messageRepo,
+				time.Duration(cfg.Storage.RedisRecentTTLSeconds)*time.Second,
+				cfg.Storage.RedisRecentMessages,
+				log,
+			)
+		}
+	}
+	log.Info("Message store initialized", zap.String("store", cfg.Storage.MessageStore), zap.String("persistent_store", persistentStore))
+
+	// 初始化WebSocket客户端管理器和分发工作池，发送消息时不在请求路径上逐个推送
+	// 会话登记表保存在Redis中，供管理员查看会话和跨实例踢下线
+	var sessionRegistry *ws.SessionRegistry
+	if cfg.Sessions.RegistryEnabled && redisClient != nil {
+		sessionRegistry = ws.NewSessionRegistry(redisClient, time.Duration(cfg.Sessions.TTLSeconds)*time.Second, cfg.Sessions.InstanceID, log)
+		log.Info("WebSocket session registry enabled",
+			zap.String("instance_id", cfg.Sessions.InstanceID),
+			zap.Int("ttl_seconds", cfg.Sessions.TTLSeconds),
+		)
+	}
+	// 在线状态按连接记录心跳，有Redis时跨实例共享并通过Redis发布状态变化事件
+	presenceTimeout := time.Duration(cfg.Presence.TimeoutSeconds) * time.Second
+	var presenceRepo domain.PresenceRepository
+	if redisClient != nil {
+		presenceRepo = repository.NewRedisPresenceRepository(redisClient, presenceTimeout)
+	} else {
+		presenceRepo = repository.NewInMemoryPresenceRepository(presenceTimeout)
+	}
+	presenceTracker := ws.NewPresenceTracker(presenceRepo, redisClient, presenceTimeout, cfg.Sessions.InstanceID, log)
+	clientManager := ws.NewClientManager(sessionRegistry, presenceTracker, log)
+	go clientManager.Start()
+	fanout := ws.NewFanoutPool(clientManager, cfg.Fanout, log)
+
+	// 送达和已读状态事件导出到分析管道，只包含会话和用户维度
+	var readStateExporter domain.ReadStateExporter
+	var analyticsExporter *analytics.RedisStreamExporter
+	if cfg.Analytics.Enabled {
+		if redisClient == nil {
+			log.Warn("Redis unavailable, read state analytics export disabled")
+		} else {
+			analyticsExporter = analytics.NewRedisStreamExporter(redisClient, analytics.Options{
+				Stream:     cfg.Analytics.Stream,
+				MaxLen:     int64(cfg.Analytics.MaxLen),
+				BufferSize: cfg.Analytics.BufferSize,
+			}, log)
+			analyticsExporter.Start()
+			readStateExporter = analyticsExporter
+		}
+	}
+
+	// 新消息发布到事件总线，由通知服务生成离线通知
+	eventBus := initEventBus(cfg, log)
+
+	// 群聊发言和置顶权限由群组服务的角色决定，未配置群组服务gRPC端口时只校验会话参与者
+	var groupPermissions domain.GroupPermissionChecker
+	if addr := cfg.GetGroupServiceGRPCEndpoint(); addr != "" {
+		groupClient, err := client.NewGroupClient(addr, time.Duration(cfg.Pin.GroupRoleCacheSeconds)*time.Second)
+		if err != nil {
+			log.Fatal("Failed to create group service client", zap.Error(err))
+		}
+		defer groupClient.Close()
+		groupPermissions = groupClient
+		log.Info("Group permissions enabled", zap.String("group_service", addr))
 	} else {
-		// 使用内存存储进行WebSocket测试
-		messageRepo = repository.NewInMemoryMessageRepository(log)
+		log.Warn("Group service gRPC endpoint not configured, group permissions fall back to conversation participants")
 	}
 
-	// 初始化服务
-	messageService := service.NewMessageService(messageRepo, log)
+	// 初始化服务，回执变更通过分发工作池推送给消息发送者
+	messageService := service.NewMessageService(messageRepo, interactionRepo, fanout, groupPermissions, eventPublisher(eventBus), cfg.Expiry, log)
+	interactionService := service.NewInteractionService(interactionRepo, receiptRepo, messageRepo, fanout, readStateExporter, cfg.Aggregate, log)
+	pinService := service.NewPinService(pinRepo, messageRepo, groupPermissions, fanout, log)
+
+	// 推送成功的聊天消息记录送达回执
+	fanout.SetDeliveryRecorder(interactionService)
+	fanout.Start()
+
+	// 大模型配置有误时退化为noop，AI功能返回不可用而不影响服务启动
+	llmProvider, err := llm.NewProvider(cfg.LLM)
+	if err != nil {
+		log.Warn("Invalid LLM configuration, assistant features disabled", zap.Error(err))
+		llmProvider = llm.NewNoopProvider()
+	}
+	log.Info("LLM provider initialized", zap.String("provider", llmProvider.Name()), zap.String("model", llmProvider.Model()))
+	assistantService := service.NewAssistantService(messageRepo, llmProvider, cfg.Assistant, log)
 
 	// 初始化HTTP处理器
 	messageHandler := httpdelivery.NewMessageHandler(messageService, jwtManager, log)
 
 	// 创建路由
 	router := mux.NewRouter()
+
+	// 后台任务执行器，启用主实例选举时只有主实例触发定时任务
+	var elector *lock.Elector
+	jobOptions := jobs.Options{}
+	if cfg.LeaderElection.Enabled {
+		if redisClient != nil {
+			elector = lock.NewElector(lock.NewLocker(redisClient, "message-service"), "jobs", time.Duration(cfg.LeaderElection.TTLSeconds)*time.Second, log)
+			jobOptions.Leader = elector
+		} else {
+			log.Warn("Redis unavailable, leader election disabled")
+		}
+	}
+	jobRunner := jobs.NewRunner(jobQueue, jobOptions, log)
+	router.HandleFunc("/internal/jobs/metrics", jobRunner.MetricsHandler).Methods("GET")
+	if analyticsExporter != nil {
+		router.HandleFunc("/internal/analytics/metrics", analyticsExporter.MetricsHandler).Methods("GET")
+	}
+
+	// 分区归档仅适用于PostgreSQL存储
+	apiOperations := httpdelivery.APIOperations
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	clientManager.SyncSessions(jobCtx)
+	presenceTracker.Run(jobCtx)
+	if archiveRepo != nil && cfg.Archive.Enabled {
+		coldStorage, err := repository.NewS3ColdStorage(cfg.Archive, log)
+		if err != nil {
+			log.Warn("Message archiving disabled", zap.Error(err))
+		} else {
+			if serviceMetrics != nil {
+				coldStorage = repository.InstrumentColdStorage(coldStorage, serviceMetrics.ObserveStorage)
+			}
+			archiveService := service.NewArchiveService(archiveRepo, coldStorage, cfg.Archive, log)
+			if err := archiveService.ScheduleJobs(jobRunner); err != nil {
+				log.Fatal("Failed to schedule message archival", zap.Error(err))
+			}
+			archiveHandler := httpdelivery.NewArchiveHandler(archiveService, messageService, cfg.Archive.AdminUserIDs, log)
+			archiveHandler.RegisterRoutes(router, messageHandler.AuthMiddleware)
+			apiOperations = apiOperations.Merge(httpdelivery.ArchiveAPIOperations)
+			log.Info("Message archiving enabled",
+				zap.Int("after_months", cfg.Archive.AfterMonths),
+				zap.String("bucket", cfg.Archive.Bucket),
+			)
+		}
+	}
+
+	// 回应和已读统计在写入时维护，后台定期按明细对账
+	if err := interactionService.ScheduleJobs(jobRunner); err != nil {
+		log.Fatal("Failed to schedule aggregate reconciliation", zap.Error(err))
+	}
+	// 到期的自毁消息由后台任务撤回并推送撤回事件
+	if err := messageService.ScheduleJobs(jobRunner); err != nil {
+		log.Fatal("Failed to schedule message expiry", zap.Error(err))
+	}
+	if elector != nil {
+		elector.Start(jobCtx)
+	}
+	jobRunner.Start(jobCtx)
+	interactionHandler := httpdelivery.NewInteractionHandler(interactionService, cfg.Archive.AdminUserIDs, log)
+	interactionHandler.RegisterRoutes(router, messageHandler.AuthMiddleware)
+
+	pinHandler := httpdelivery.NewPinHandler(pinService, log)
+	pinHandler.RegisterRoutes(router, messageHandler.AuthMiddleware)
+
+	muteHandler := httpdelivery.NewMuteHandler(service.NewMuteService(muteRepo, messageRepo, log), log)
+	muteHandler.RegisterRoutes(router, messageHandler.AuthMiddleware)
+
+	starHandler := httpdelivery.NewStarHandler(service.NewStarService(starRepo, messageRepo, log), log)
+	starHandler.RegisterRoutes(router, messageHandler.AuthMiddleware)
+
+	presenceHandler := httpdelivery.NewPresenceHandler(presenceTracker, log)
+	presenceHandler.RegisterRoutes(router, messageHandler.AuthMiddleware)
+
+	assistantHandler := httpdelivery.NewAssistantHandler(assistantService, log)
+	assistantHandler.RegisterRoutes(router, messageHandler.AuthMiddleware)
+
 	messageHandler.RegisterRoutes(router)
 
 	// 注册WebSocket路由
-	ws.RegisterRoutes(router, messageService, jwtManager, log)
+	ws.RegisterRoutes(router, clientManager, fanout, messageService, jwtManager, cfg.Sessions.InstanceID, cfg.Sessions.Compression, log)
+
+	// 接口文档：由注册的路由和接口说明生成，两者不一致时记录警告，严格模式下拒绝启动
+	apiDoc, problems := openapi.Build(router, "message-service", apiOperations)
+	for _, problem := range problems {
+		log.Warn("OpenAPI document does not match routes", zap.String("problem", problem))
+	}
+	if len(problems) > 0 && cfg.OpenAPI.Strict {
+		log.Fatal("OpenAPI document does not match routes", zap.Int("problems", len(problems)))
+	}
+	router.HandleFunc("/openapi.json", openapi.Handler(apiDoc)).Methods("GET")
+
+	// 处理请求时的panic转换为带请求ID的500响应，配置了SENTRY_DSN时上报到Sentry
+	reporter, err := recovery.ReporterFromDSN(cfg.ErrorReporting.SentryDSN, cfg.ErrorReporting.Environment)
+	if err != nil {
+		log.Fatal("Invalid error reporting configuration", zap.Error(err))
+	}
+	recoverer := recovery.New(recovery.Options{Service: "message-service", Reporter: reporter}, log)
+
+	var httpHandler http.Handler = recoverer.Middleware(router)
+	if serviceMetrics != nil {
+		serviceMetrics.WebSocketConnections("client", clientManager.GetClientCount)
+		router.Handle("/metrics", serviceMetrics.Handler(cfg.Metrics.Token)).Methods("GET")
+		httpHandler = serviceMetrics.Instrument(router, httpHandler)
+	}
+	httpHandler = tracing.Middleware("message-service", router, httpHandler)
+
+	// 优雅关闭：排空期间健康检查返回503、拒绝新的WebSocket连接
+	coordinator := shutdown.New(shutdown.Options{
+		DrainDelay: time.Duration(cfg.Shutdown.DrainDelaySeconds) * time.Second,
+		Timeout:    time.Duration(cfg.Shutdown.TimeoutSeconds) * time.Second,
+	}, log)
 
 	// 创建HTTP服务器
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Service.HTTPPort),
-		Handler:      router,
+		Handler:      coordinator.Middleware(httpHandler),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -92,20 +368,75 @@ func main() {
 		}
 	}()
 
-	// 优雅关闭
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	sig := <-sigChan
-	log.Info("Received signal", zap.String("signal", sig.String()))
+	// 关闭顺序：停止接收请求并等待处理中的请求 -> 清空分发队列 -> 发送缓存的事件 -> 通知WebSocket客户端重连并断开 -> 等待后台任务 -> 写完分析事件 -> 导出剩余的span
+	coordinator.Register("http", server.Shutdown)
+	coordinator.Register("fanout", func(ctx context.Context) error {
+		return shutdown.Await(ctx, fanout.Stop)
+	})
+	if eventBus != nil {
+		coordinator.Register("events", eventBus.Close)
+	}
+	coordinator.Register("websocket", func(ctx context.Context) error {
+		return clientManager.Drain(ctx, time.Duration(cfg.Shutdown.ReconnectWindowSeconds)*time.Second)
+	})
+	coordinator.Register("jobs", func(ctx context.Context) error {
+		stopJobs()
+		if elector != nil {
+			defer elector.Wait()
+		}
+		return shutdown.Await(ctx, jobRunner.Wait)
+	})
+	if analyticsExporter != nil {
+		coordinator.Register("analytics", func(ctx context.Context) error {
+			return shutdown.Await(ctx, analyticsExporter.Stop)
+		})
+	}
+	coordinator.Register("tracing", shutdownTracing)
+	coordinator.Wait()
 
-	// 创建关闭上下文
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	log.Info("Server gracefully stopped")
+}
 
-	// 关闭HTTP服务器
-	if err := server.Shutdown(ctx); err != nil {
-		log.Error("Server shutdown failed", zap.Error(err))
+// initEventBus 连接事件总线，未配置时返回nil
+func initEventBus(cfg *config.Config, log *zap.Logger) *events.Bus {
+	if cfg.EventBusURL == "" {
+		log.Info("Event bus not configured, domain events disabled")
+		return nil
+	}
+	bus, err := events.Connect(cfg.EventBusURL, "message-service", log)
+	if err != nil {
+		log.Warn("Failed to connect to event bus, domain events disabled", zap.Error(err))
+		return nil
 	}
+	return bus
+}
 
-	log.Info("Server gracefully stopped")
+// eventPublisher 事件总线不可用时返回丢弃事件的发布者
+func eventPublisher(bus *events.Bus) events.Publisher {
+	if bus == nil {
+		return events.NopPublisher()
+	}
+	return bus
+}
+
+// initRedis 连接Redis，连接失败时返回nil
+func initRedis(cfg *config.Config, log *zap.Logger) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	// Redis仓储（最近消息缓存、在线状态）在请求中执行的命令记录为子span
+	client.AddHook(tracing.RedisHook())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		log.Warn("Failed to connect to Redis", zap.String("redis_addr", cfg.Redis.Addr), zap.Error(err))
+		client.Close()
+		return nil
+	}
+
+	log.Info("Connected to Redis", zap.String("redis_addr", cfg.Redis.Addr))
+	return client
 }
@@ -4,21 +4,42 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 // Config 应用配置结构体
 type Config struct {
-	Service   ServiceConfig
-	Database  DatabaseConfig
-	JWT       JWTConfig
-	Kafka     KafkaConfig
-	Redis     RedisConfig
-	UserSvc   ServiceEndpoint
-	GroupSvc  ServiceEndpoint
-	MediaSvc  ServiceEndpoint
-	NotifySvc ServiceEndpoint
+	Service        ServiceConfig
+	Database       DatabaseConfig
+	Storage        StorageConfig
+	MongoDB        MongoDBConfig
+	Archive        ArchiveConfig
+	Fanout         FanoutConfig
+	Sessions       SessionConfig
+	Presence       PresenceConfig
+	Shutdown       ShutdownConfig
+	ErrorReporting ErrorReportingConfig
+	Metrics        MetricsConfig
+	Tracing        TracingConfig
+	OpenAPI        OpenAPIConfig
+	LeaderElection LeaderElectionConfig
+	Aggregate      AggregateConfig
+	LLM            LLMConfig
+	Assistant      AssistantConfig
+	Pin            PinConfig
+	Expiry         ExpiryConfig
+	Analytics      AnalyticsConfig
+	JWT            JWTConfig
+	Kafka          KafkaConfig
+	Redis          RedisConfig
+	UserSvc        ServiceEndpoint
+	GroupSvc       ServiceEndpoint
+	MediaSvc       ServiceEndpoint
+	NotifySvc      ServiceEndpoint
+	// EventBusURL NATS地址，为空时不发布领域事件
+	EventBusURL string
 }
 
 // ServiceConfig 服务配置
@@ -38,6 +59,160 @@ type DatabaseConfig struct {
 	SSLMode  string
 }
 
+// 消息存储后端
+const (
+	MessageStorePostgres = "postgres"
+	MessageStoreMongoDB  = "mongodb"
+	// MessageStoreRedis 最近消息保存在Redis中，持久化仍使用RedisBackingStore
+	MessageStoreRedis = "redis"
+)
+
+// StorageConfig 消息存储配置
+type StorageConfig struct {
+	MessageStore string // postgres、mongodb 或 redis
+	// Redis存储配置（MessageStore为redis时生效）
+	RedisBackingStore     string // 持久化存储：postgres 或 mongodb
+	RedisRecentTTLSeconds int    // 会话最近消息在Redis中的有效期，读写时续期
+	RedisRecentMessages   int    // 每个会话在Redis中保留的最近消息数
+}
+
+// MongoDBConfig MongoDB配置
+type MongoDBConfig struct {
+	URI      string
+	Database string
+}
+
+// ArchiveConfig 消息分区归档配置
+type ArchiveConfig struct {
+	Enabled          bool
+	AfterMonths      int // 早于该月数的分区移到冷存储
+	PremakeMonths    int // 提前创建的未来分区数
+	IntervalHours    int
+	RestoreRetention int // 恢复后的分区保留天数，到期后重新归档
+	Bucket           string
+	Prefix           string
+	Region           string
+	AccessKeyID      string
+	SecretAccessKey  string
+	Endpoint         string
+	StorageClass     string
+	AdminUserIDs     []string
+}
+
+// FanoutConfig 实时消息分发工作池配置
+type FanoutConfig struct {
+	Workers   int
+	QueueSize int // 每个worker的队列长度
+}
+
+// ShutdownConfig 优雅关闭配置
+type ShutdownConfig struct {
+	DrainDelaySeconds      int // 进入排空状态（健康检查返回503）后等待多久再停止接收连接
+	TimeoutSeconds         int // 关闭步骤的总超时时间
+	ReconnectWindowSeconds int // 通知WebSocket客户端重连时随机等待时间的上限
+}
+
+// ErrorReportingConfig 错误上报配置，SentryDSN为空时panic只记录日志
+type ErrorReportingConfig struct {
+	SentryDSN   string // Sentry项目DSN
+	Environment string // 上报事件的环境标识
+}
+
+// MetricsConfig Prometheus指标配置
+type MetricsConfig struct {
+	Enabled bool   // 是否提供/metrics端点
+	Token   string // 不为空时抓取请求需携带 Authorization: Bearer <Token>
+}
+
+// TracingConfig OpenTelemetry链路追踪配置
+type TracingConfig struct {
+	Exporter      string // none（只生成和传播traceparent）、otlp或jaeger
+	Endpoint      string // OTLP collector的gRPC地址或Jaeger collector的HTTP地址，为空时使用导出器默认值
+	Insecure      bool   // OTLP连接不使用TLS
+	SamplePercent int    // 新trace的采样百分比，带traceparent的请求沿用上游的采样决定
+}
+
+// OpenAPIConfig 接口文档配置
+type OpenAPIConfig struct {
+	Strict bool // 注册的路由与接口说明不一致时拒绝启动，默认只记录警告
+}
+
+// LeaderElectionConfig 定时任务主实例选举配置，启用后多个实例中只有持有Redis锁的实例触发定时任务
+type LeaderElectionConfig struct {
+	Enabled    bool
+	TTLSeconds int // 锁的有效期，主实例失联后最长经过这段时间由其他实例接替
+}
+
+// SessionConfig WebSocket会话配置，会话保存在Redis中供管理员查看和跨实例踢下线
+type SessionConfig struct {
+	RegistryEnabled bool
+	TTLSeconds      int    // 会话记录有效期，连接存活期间定期续期
+	InstanceID      string // 当前实例标识，默认取主机名
+	Compression     bool   // 是否协商permessage-deflate压缩，客户端在hello中确认后才压缩
+}
+
+// PresenceConfig 在线状态配置，连接超过TimeoutSeconds没有心跳即视为已断开
+type PresenceConfig struct {
+	TimeoutSeconds int
+}
+
+// AggregateConfig 回应和已读统计对账配置
+type AggregateConfig struct {
+	ReconcileIntervalMinutes int
+	ReconcileWindowMinutes   int // 对账最近该时间范围内有变更的消息
+	ReconcileBatch           int // 每次对账最多处理的消息数
+}
+
+// 大模型提供方
+const (
+	LLMProviderNoop   = "noop"   // 不接入模型，AI功能返回不可用
+	LLMProviderOpenAI = "openai" // OpenAI兼容的 /chat/completions 接口
+	LLMProviderLocal  = "local"  // 本地模型（Ollama /api/chat 接口）
+)
+
+// LLMConfig 大模型提供方配置
+type LLMConfig struct {
+	Provider       string
+	BaseURL        string
+	APIKey         string
+	Model          string
+	TimeoutSeconds int
+	MaxTokens      int
+}
+
+// AssistantConfig 会话摘要、智能回复等AI功能配置
+type AssistantConfig struct {
+	SummaryMaxMessages  int // 默认摘要最近的消息数
+	SummaryCacheMinutes int
+	SummaryRateLimit    int // 每个用户每小时可生成的摘要数，<=0表示不限
+
+	SmartReplyEnabled         bool
+	SmartReplyContextMessages int    // 生成建议回复时参考的最近消息数
+	SmartReplyPromptFile      string // 自定义提示词模板文件（text/template），为空使用内置模板
+	SmartReplyRateLimit       int    // 每个用户每小时可请求的建议回复数，<=0表示不限
+}
+
+// PinConfig 置顶消息配置
+type PinConfig struct {
+	GroupRoleCacheSeconds int // 群组服务返回的置顶权限缓存时间，群组角色变更最多延迟该时间生效，<=0表示不缓存
+}
+
+// ExpiryConfig 自毁消息配置，到期的消息由后台任务撤回
+type ExpiryConfig struct {
+	MaxTTLSeconds   int // 单条消息保留时间的上限
+	ViewOnceSeconds int // 阅后即焚消息未指定保留时间时，第一次被已读后保留的时间
+	IntervalSeconds int // 清理任务的执行间隔，决定自毁时间的精度
+	Batch           int // 每次清理最多撤回的消息数
+}
+
+// AnalyticsConfig 已读状态事件导出配置，事件写入Redis Stream供分析管道消费
+type AnalyticsConfig struct {
+	Enabled    bool
+	Stream     string
+	MaxLen     int // Stream保留的大致事件数，<=0表示不裁剪，由消费方负责清理
+	BufferSize int // 内存缓冲的事件数，Redis写入跟不上时丢弃新事件
+}
+
 // JWTConfig JWT配置
 type JWTConfig struct {
 	SecretKey       string
@@ -59,8 +234,9 @@ type RedisConfig struct {
 
 // ServiceEndpoint 微服务端点配置
 type ServiceEndpoint struct {
-	Host string
-	Port int
+	Host     string
+	Port     int
+	GRPCPort int // 内部gRPC接口端口，0表示未提供
 }
 
 // LoadConfig 从环境变量或.env文件加载配置
@@ -82,6 +258,108 @@ func LoadConfig() (*Config, error) {
 			DBName:   getEnv("DB_NAME", "chatapp"),
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
+		Storage: StorageConfig{
+			MessageStore:          getEnv("MESSAGE_STORE", MessageStorePostgres),
+			RedisBackingStore:     getEnv("REDIS_BACKING_STORE", MessageStorePostgres),
+			RedisRecentTTLSeconds: getEnvAsInt("REDIS_RECENT_TTL_SECONDS", 3600),
+			RedisRecentMessages:   getEnvAsInt("REDIS_RECENT_MESSAGES", 200),
+		},
+		MongoDB: MongoDBConfig{
+			URI:      getEnv("MONGODB_URI", "mongodb://localhost:27017"),
+			Database: getEnv("MONGODB_DATABASE", "chatapp"),
+		},
+		Archive: ArchiveConfig{
+			Enabled:          getEnv("ARCHIVE_ENABLED", "false") == "true",
+			AfterMonths:      getEnvAsInt("ARCHIVE_AFTER_MONTHS", 6),
+			PremakeMonths:    getEnvAsInt("PARTITION_PREMAKE_MONTHS", 2),
+			IntervalHours:    getEnvAsInt("ARCHIVE_INTERVAL_HOURS", 24),
+			RestoreRetention: getEnvAsInt("ARCHIVE_RESTORE_RETENTION_DAYS", 7),
+			Bucket:           getEnv("ARCHIVE_S3_BUCKET", ""),
+			Prefix:           getEnv("ARCHIVE_S3_PREFIX", "message-archives"),
+			Region:           getEnv("AWS_REGION", "us-east-1"),
+			AccessKeyID:      getEnv("AWS_ACCESS_KEY_ID", ""),
+			SecretAccessKey:  getEnv("AWS_SECRET_ACCESS_KEY", ""),
+			Endpoint:         getEnv("AWS_ENDPOINT", ""),
+			StorageClass:     getEnv("ARCHIVE_STORAGE_CLASS", "STANDARD_IA"),
+			AdminUserIDs:     splitList(getEnv("ADMIN_USER_IDS", "")),
+		},
+		Fanout: FanoutConfig{
+			Workers:   getEnvAsInt("FANOUT_WORKERS", 8),
+			QueueSize: getEnvAsInt("FANOUT_QUEUE_SIZE", 1024),
+		},
+		Sessions: SessionConfig{
+			RegistryEnabled: getEnv("WS_SESSION_REGISTRY_ENABLED", "true") == "true",
+			TTLSeconds:      getEnvAsInt("WS_SESSION_TTL_SECONDS", 90),
+			InstanceID:      getEnv("INSTANCE_ID", hostname()),
+			Compression:     getEnv("WS_COMPRESSION_ENABLED", "true") == "true",
+		},
+		Presence: PresenceConfig{
+			TimeoutSeconds: getEnvAsInt("PRESENCE_TIMEOUT_SECONDS", 90),
+		},
+		Shutdown: ShutdownConfig{
+			DrainDelaySeconds:      getEnvAsInt("SHUTDOWN_DRAIN_DELAY_SECONDS", 5),
+			TimeoutSeconds:         getEnvAsInt("SHUTDOWN_TIMEOUT_SECONDS", 30),
+			ReconnectWindowSeconds: getEnvAsInt("WS_RECONNECT_WINDOW_SECONDS", 10),
+		},
+		ErrorReporting: ErrorReportingConfig{
+			SentryDSN:   getEnv("SENTRY_DSN", ""),
+			Environment: getEnv("SENTRY_ENVIRONMENT", "production"),
+		},
+		LeaderElection: LeaderElectionConfig{
+			Enabled:    getEnv("LEADER_ELECTION_ENABLED", "false") == "true",
+			TTLSeconds: getEnvAsInt("LEADER_ELECTION_TTL_SECONDS", 15),
+		},
+		Metrics: MetricsConfig{
+			Enabled: getEnv("METRICS_ENABLED", "true") == "true",
+			Token:   getEnv("METRICS_TOKEN", ""),
+		},
+		Tracing: TracingConfig{
+			Exporter:      getEnv("TRACING_EXPORTER", "none"),
+			Endpoint:      getEnv("TRACING_ENDPOINT", ""),
+			Insecure:      getEnv("TRACING_INSECURE", "true") == "true",
+			SamplePercent: getEnvAsInt("TRACING_SAMPLE_PERCENT", 100),
+		},
+		OpenAPI: OpenAPIConfig{
+			Strict: getEnv("OPENAPI_STRICT", "false") == "true",
+		},
+		Aggregate: AggregateConfig{
+			ReconcileIntervalMinutes: getEnvAsInt("AGGREGATE_RECONCILE_INTERVAL_MINUTES", 10),
+			ReconcileWindowMinutes:   getEnvAsInt("AGGREGATE_RECONCILE_WINDOW_MINUTES", 60),
+			ReconcileBatch:           getEnvAsInt("AGGREGATE_RECONCILE_BATCH", 1000),
+		},
+		LLM: LLMConfig{
+			Provider:       getEnv("LLM_PROVIDER", LLMProviderNoop),
+			BaseURL:        getEnv("LLM_BASE_URL", ""),
+			APIKey:         getEnv("LLM_API_KEY", ""),
+			Model:          getEnv("LLM_MODEL", ""),
+			TimeoutSeconds: getEnvAsInt("LLM_TIMEOUT_SECONDS", 30),
+			MaxTokens:      getEnvAsInt("LLM_MAX_TOKENS", 512),
+		},
+		Assistant: AssistantConfig{
+			SummaryMaxMessages:  getEnvAsInt("SUMMARY_MAX_MESSAGES", 50),
+			SummaryCacheMinutes: getEnvAsInt("SUMMARY_CACHE_TTL_MINUTES", 30),
+			SummaryRateLimit:    getEnvAsInt("SUMMARY_RATE_LIMIT_PER_HOUR", 20),
+
+			SmartReplyEnabled:         getEnv("SMART_REPLY_ENABLED", "false") == "true",
+			SmartReplyContextMessages: getEnvAsInt("SMART_REPLY_CONTEXT_MESSAGES", 10),
+			SmartReplyPromptFile:      getEnv("SMART_REPLY_PROMPT_FILE", ""),
+			SmartReplyRateLimit:       getEnvAsInt("SMART_REPLY_RATE_LIMIT_PER_HOUR", 60),
+		},
+		Pin: PinConfig{
+			GroupRoleCacheSeconds: getEnvAsInt("PIN_GROUP_ROLE_CACHE_SECONDS", 30),
+		},
+		Expiry: ExpiryConfig{
+			MaxTTLSeconds:   getEnvAsInt("MESSAGE_TTL_MAX_SECONDS", 604800),
+			ViewOnceSeconds: getEnvAsInt("VIEW_ONCE_SECONDS", 30),
+			IntervalSeconds: getEnvAsInt("MESSAGE_EXPIRY_INTERVAL_SECONDS", 15),
+			Batch:           getEnvAsInt("MESSAGE_EXPIRY_BATCH", 500),
+		},
+		Analytics: AnalyticsConfig{
+			Enabled:    getEnv("ANALYTICS_EXPORT_ENABLED", "false") == "true",
+			Stream:     getEnv("ANALYTICS_READ_STATE_STREAM", "analytics:read_state"),
+			MaxLen:     getEnvAsInt("ANALYTICS_STREAM_MAXLEN", 1000000),
+			BufferSize: getEnvAsInt("ANALYTICS_BUFFER_SIZE", 10000),
+		},
 		JWT: JWTConfig{
 			SecretKey:       getEnv("JWT_SECRET_KEY", "your_super_secret_key_change_in_production"),
 			ExpirationHours: getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
@@ -100,8 +378,9 @@ func LoadConfig() (*Config, error) {
 			Port: getEnvAsInt("USER_SVC_PORT", 8081),
 		},
 		GroupSvc: ServiceEndpoint{
-			Host: getEnv("GROUP_SVC_HOST", "localhost"),
-			Port: getEnvAsInt("GROUP_SVC_PORT", 8083),
+			Host:     getEnv("GROUP_SVC_HOST", "localhost"),
+			Port:     getEnvAsInt("GROUP_SVC_PORT", 8083),
+			GRPCPort: getEnvAsInt("GROUP_SVC_GRPC_PORT", 9083),
 		},
 		MediaSvc: ServiceEndpoint{
 			Host: getEnv("MEDIA_SVC_HOST", "localhost"),
@@ -111,6 +390,7 @@ func LoadConfig() (*Config, error) {
 			Host: getEnv("NOTIFY_SVC_HOST", "localhost"),
 			Port: getEnvAsInt("NOTIFY_SVC_PORT", 8085),
 		},
+		EventBusURL: getEnv("EVENT_BUS_URL", ""),
 	}, nil
 }
 
@@ -123,6 +403,17 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// splitList 解析逗号分隔的列表，忽略空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // 获取环境变量并转换为整数，如果不存在或转换失败则返回默认值
 func getEnvAsInt(key string, defaultValue int) int {
 	valueStr := getEnv(key, "")
@@ -161,6 +452,14 @@ func (c *Config) GetGroupServiceEndpoint() string {
 	return fmt.Sprintf("%s:%d", c.GroupSvc.Host, c.GroupSvc.Port)
 }
 
+// GetGroupServiceGRPCEndpoint 获取群组服务gRPC端点，未配置gRPC端口时返回空字符串
+func (c *Config) GetGroupServiceGRPCEndpoint() string {
+	if c.GroupSvc.GRPCPort <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", c.GroupSvc.Host, c.GroupSvc.GRPCPort)
+}
+
 // GetMediaServiceEndpoint 获取媒体服务端点
 func (c *Config) GetMediaServiceEndpoint() string {
 	return fmt.Sprintf("%s:%d", c.MediaSvc.Host, c.MediaSvc.Port)
@@ -169,4 +468,10 @@ func (c *Config) GetMediaServiceEndpoint() string {
 // GetNotificationServiceEndpoint 获取通知服务端点
 func (c *Config) GetNotificationServiceEndpoint() string {
 	return fmt.Sprintf("%s:%d", c.NotifySvc.Host, c.NotifySvc.Port)
-}
\ No newline at end of file
+}
+
+// hostname 获取主机名，失败时返回空字符串
+func hostname() string {
+	name, _ := os.Hostname()
+	return name
+}
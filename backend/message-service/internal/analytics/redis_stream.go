@@ -0,0 +1,204 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// flushBatchSize 每次写入Redis的最大事件数
+	flushBatchSize = 200
+	// flushInterval 事件不足一批时的最长等待时间
+	flushInterval = time.Second
+	// writeTimeout 每批写入的超时时间
+	writeTimeout = 5 * time.Second
+)
+
+// Options Redis Stream导出配置
+type Options struct {
+	Stream     string // 事件写入的Stream
+	MaxLen     int64  // Stream保留的大致事件数，<=0表示不裁剪
+	BufferSize int    // 内存缓冲的事件数，缓冲已满时丢弃新事件
+}
+
+// ExporterMetrics 导出统计快照
+type ExporterMetrics struct {
+	Stream   string `json:"stream"`
+	Buffered int    `json:"buffered"`
+	Exported int64  `json:"exported"`
+	Dropped  int64  `json:"dropped"`
+	Failed   int64  `json:"failed"` // 写入Redis失败而丢弃的事件数
+}
+
+// RedisStreamExporter 把已读状态事件批量写入Redis Stream，由分析管道以消费组方式读取
+// 每条记录包含type字段和JSON格式的event字段，不经过数据库，写入失败时丢弃不重试
+type RedisStreamExporter struct {
+	client *redis.Client
+	opts   Options
+	events chan *domain.ReadStateEvent
+	done   chan struct{}
+	// mu 防止Stop关闭缓冲时仍有事件写入
+	mu     sync.RWMutex
+	closed bool
+
+	exported atomic.Int64
+	dropped  atomic.Int64
+	failed   atomic.Int64
+
+	logger *zap.Logger
+}
+
+// NewRedisStreamExporter 创建Redis Stream导出器，调用Start后开始写入
+func NewRedisStreamExporter(client *redis.Client, opts Options, logger *zap.Logger) *RedisStreamExporter {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1
+	}
+	return &RedisStreamExporter{
+		client: client,
+		opts:   opts,
+		events: make(chan *domain.ReadStateEvent, opts.BufferSize),
+		done:   make(chan struct{}),
+		logger: logger,
+	}
+}
+
+// Start 启动后台写入
+func (e *RedisStreamExporter) Start() {
+	go e.run()
+	e.logger.Info("Read state analytics export started", zap.String("stream", e.opts.Stream), zap.Int("buffer_size", e.opts.BufferSize))
+}
+
+// Stop 停止接收事件并等待缓冲中的事件写完
+func (e *RedisStreamExporter) Stop() {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return
+	}
+	e.closed = true
+	close(e.events)
+	e.mu.Unlock()
+
+	<-e.done
+}
+
+// Export 实现domain.ReadStateExporter，缓冲已满时丢弃事件
+func (e *RedisStreamExporter) Export(events ...*domain.ReadStateEvent) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		e.dropped.Add(int64(len(events)))
+		return
+	}
+
+	for i, event := range events {
+		select {
+		case e.events <- event:
+		default:
+			dropped := len(events) - i
+			e.dropped.Add(int64(dropped))
+			e.logger.Warn("Analytics buffer full, dropping read state events", zap.Int("dropped", dropped))
+			return
+		}
+	}
+}
+
+// Metrics 返回导出统计快照
+func (e *RedisStreamExporter) Metrics() ExporterMetrics {
+	return ExporterMetrics{
+		Stream:   e.opts.Stream,
+		Buffered: len(e.events),
+		Exported: e.exported.Load(),
+		Dropped:  e.dropped.Load(),
+		Failed:   e.failed.Load(),
+	}
+}
+
+// MetricsHandler 以JSON返回导出统计，供内部监控抓取
+func (e *RedisStreamExporter) MetricsHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e.Metrics()) // nolint: errcheck
+}
+
+// run 攒批写入，缓冲关闭后写完剩余事件退出
+func (e *RedisStreamExporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*domain.ReadStateEvent, 0, flushBatchSize)
+	for {
+		select {
+		case event, ok := <-e.events:
+			if !ok {
+				e.flush(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= flushBatchSize {
+				e.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			e.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush 使用管道把一批事件写入Stream
+func (e *RedisStreamExporter) flush(batch []*domain.ReadStateEvent) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	pipe := e.client.Pipeline()
+	for _, event := range batch {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			e.failed.Add(1)
+			continue
+		}
+		args := &redis.XAddArgs{
+			Stream: e.opts.Stream,
+			Values: map[string]interface{}{"type": string(event.Type), "event": payload},
+		}
+		if e.opts.MaxLen > 0 {
+			args.MaxLen = e.opts.MaxLen
+			args.Approx = true
+		}
+		pipe.XAdd(ctx, args)
+	}
+
+	cmds, err := pipe.Exec(ctx)
+	if err == nil {
+		e.exported.Add(int64(len(cmds)))
+		return
+	}
+
+	// 连接失败时命令上不设置错误，整批视为失败
+	var failed int64
+	for _, cmd := range cmds {
+		if cmd.Err() != nil {
+			failed++
+		}
+	}
+	if failed == 0 {
+		failed = int64(len(cmds))
+	}
+	e.exported.Add(int64(len(cmds)) - failed)
+	e.failed.Add(failed)
+	e.logger.Warn("Failed to export read state events", zap.Error(err), zap.Int64("failed", failed))
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/api/proto/grouppb"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// 群组服务中的权限名
+const (
+	permissionSendMessages = "send_messages"
+	permissionPinMessages  = "pin_messages"
+)
+
+// groupCacheMaxEntries 权限缓存的最大条目数，超过时先清理过期条目，仍然超过则清空
+const groupCacheMaxEntries = 10000
+
+// groupPermissionEntry 缓存的用户群组权限，群组不存在的结果同样缓存
+type groupPermissionEntry struct {
+	canSend   bool
+	canPin    bool
+	notFound  bool
+	expiresAt time.Time
+}
+
+// GroupClient 通过gRPC查询群组服务中的成员权限，结果按群组和用户缓存ttl时间
+// 角色变更最多延迟ttl生效，ttl<=0时不缓存
+type GroupClient struct {
+	conn    *grpc.ClientConn
+	client  grouppb.GroupMembershipServiceClient
+	ttl     time.Duration
+	timeout time.Duration
+
+	mutex sync.Mutex
+	cache map[string]*groupPermissionEntry
+}
+
+// NewGroupClient 创建群组服务gRPC客户端，连接在首次调用时建立
+func NewGroupClient(addr string, ttl time.Duration) (*GroupClient, error) {
+	conn, err := grpc.Dial(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		// 调用在当前请求的trace下创建client span，并通过metadata传递traceparent
+		grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial group service: %w", err)
+	}
+
+	return &GroupClient{
+		conn:    conn,
+		client:  grouppb.NewGroupMembershipServiceClient(conn),
+		ttl:     ttl,
+		timeout: 3 * time.Second,
+		cache:   make(map[string]*groupPermissionEntry),
+	}, nil
+}
+
+// CanPinMessages 实现domain.GroupPermissionChecker，群组服务不可用时返回错误且不缓存
+func (c *GroupClient) CanPinMessages(ctx context.Context, groupID, userID string) (bool, error) {
+	entry, err := c.membership(ctx, groupID, userID)
+	if err != nil {
+		return false, err
+	}
+	return entry.canPin, nil
+}
+
+// CanSendMessages 实现domain.GroupPermissionChecker，群组服务不可用时返回错误且不缓存
+func (c *GroupClient) CanSendMessages(ctx context.Context, groupID, userID string) (bool, error) {
+	entry, err := c.membership(ctx, groupID, userID)
+	if err != nil {
+		return false, err
+	}
+	return entry.canSend, nil
+}
+
+// membership 查询并缓存用户在群组中的权限，群组不存在时返回ErrGroupNotFound
+func (c *GroupClient) membership(ctx context.Context, groupID, userID string) (*groupPermissionEntry, error) {
+	key := groupID + ":" + userID
+	if entry := c.cached(key); entry != nil {
+		if entry.notFound {
+			return nil, domain.ErrGroupNotFound
+		}
+		return entry, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.GetMembership(ctx, &grouppb.GetMembershipRequest{GroupId: groupID, UserId: userID})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.NotFound, codes.InvalidArgument:
+		// 群组服务只管理UUID标识的群组和频道，其他会话ID视为群组不存在
+		c.store(key, &groupPermissionEntry{notFound: true})
+		return nil, domain.ErrGroupNotFound
+	default:
+		return nil, fmt.Errorf("failed to get group membership: %w", err)
+	}
+
+	entry := &groupPermissionEntry{}
+	membership := resp.GetMembership()
+	if membership.GetIsMember() {
+		for _, permission := range membership.GetPermissions() {
+			switch permission {
+			case permissionSendMessages:
+				entry.canSend = true
+			case permissionPinMessages:
+				entry.canPin = true
+			}
+		}
+	}
+	c.store(key, entry)
+	return entry, nil
+}
+
+// Close 关闭gRPC连接
+func (c *GroupClient) Close() error {
+	return c.conn.Close()
+}
+
+// cached 获取未过期的缓存条目
+func (c *GroupClient) cached(key string) *groupPermissionEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.cache[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.cache, key)
+		return nil
+	}
+	return entry
+}
+
+// store 写入缓存条目
+func (c *GroupClient) store(key string, entry *groupPermissionEntry) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	if len(c.cache) >= groupCacheMaxEntries {
+		for k, e := range c.cache {
+			if now.After(e.expiresAt) {
+				delete(c.cache, k)
+			}
+		}
+		if len(c.cache) >= groupCacheMaxEntries {
+			c.cache = make(map[string]*groupPermissionEntry)
+		}
+	}
+	entry.expiresAt = now.Add(c.ttl)
+	c.cache[key] = entry
+}
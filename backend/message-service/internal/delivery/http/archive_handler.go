@@ -0,0 +1,144 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// ArchiveHandler 消息归档处理器
+type ArchiveHandler struct {
+	archiveService domain.ArchiveService
+	messageService domain.MessageService
+	adminUserIDs   map[string]bool
+	logger         *zap.Logger
+}
+
+// NewArchiveHandler 创建一个新的归档处理器
+func NewArchiveHandler(archiveService domain.ArchiveService, messageService domain.MessageService, adminUserIDs []string, logger *zap.Logger) *ArchiveHandler {
+	admins := make(map[string]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = true
+	}
+
+	return &ArchiveHandler{
+		archiveService: archiveService,
+		messageService: messageService,
+		adminUserIDs:   admins,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *ArchiveHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/conversations/{id}/archives", authMiddleware(http.HandlerFunc(h.GetConversationArchives))).Methods("GET")
+
+	// 管理员路由
+	router.Handle("/api/v1/admin/archives", authMiddleware(h.adminOnly(h.ListArchives))).Methods("GET")
+	router.Handle("/api/v1/admin/archives/run", authMiddleware(h.adminOnly(h.RunArchival))).Methods("POST")
+	router.Handle("/api/v1/admin/archives/{id}/restore", authMiddleware(h.adminOnly(h.RestoreArchive))).Methods("POST")
+}
+
+// GetConversationArchives 获取会话已归档的历史消息占位信息，仅会话参与者可查看
+func (h *ArchiveHandler) GetConversationArchives(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	conversationID := mux.Vars(r)["id"]
+
+	conversation, err := h.messageService.GetConversation(r.Context(), conversationID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "conversation not found")
+		return
+	}
+	if !containsString(conversation.Participants, userID) && !isAdmin(r, h.adminUserIDs) {
+		respondError(w, http.StatusForbidden, "not a participant of this conversation")
+		return
+	}
+
+	stubs, err := h.archiveService.GetConversationArchives(r.Context(), conversationID)
+	if err != nil {
+		h.logger.Error("Failed to get conversation archives", zap.Error(err), zap.String("conversation_id", conversationID))
+		respondError(w, http.StatusInternalServerError, "failed to get conversation archives")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, stubs)
+}
+
+// ListArchives 获取全部归档（管理员）
+func (h *ArchiveHandler) ListArchives(w http.ResponseWriter, r *http.Request) {
+	archives, err := h.archiveService.ListArchives(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to list archives", zap.Error(err))
+		respondError(w, http.StatusInternalServerError, "failed to list archives")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, archives)
+}
+
+// RunArchival 立即执行一次归档（管理员）
+func (h *ArchiveHandler) RunArchival(w http.ResponseWriter, r *http.Request) {
+	archives, err := h.archiveService.RunArchival(r.Context())
+	if err != nil {
+		h.logger.Error("Manual archival run failed", zap.Error(err))
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"archived": archives})
+}
+
+// RestoreArchive 按需把归档恢复到数据库（管理员）
+func (h *ArchiveHandler) RestoreArchive(w http.ResponseWriter, r *http.Request) {
+	archiveID := mux.Vars(r)["id"]
+
+	archive, err := h.archiveService.RestoreArchive(r.Context(), archiveID)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "not found"):
+			respondError(w, http.StatusNotFound, msg)
+		case strings.Contains(msg, "already restored"):
+			respondError(w, http.StatusConflict, msg)
+		default:
+			h.logger.Error("Failed to restore archive", zap.Error(err), zap.String("archive_id", archiveID))
+			respondError(w, http.StatusInternalServerError, "failed to restore archive")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, archive)
+}
+
+// adminOnly 仅允许管理员访问
+func (h *ArchiveHandler) adminOnly(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(r, h.adminUserIDs) {
+			respondError(w, http.StatusForbidden, "admin access required")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// isAdmin 令牌角色为admin或在配置的管理员列表中
+func isAdmin(r *http.Request, adminUserIDs map[string]bool) bool {
+	if role, _ := r.Context().Value("role").(string); role == "admin" {
+		return true
+	}
+	userID, _ := r.Context().Value("user_id").(string)
+	return adminUserIDs[userID]
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(items []string, target string) bool {
+	for _, item := range items {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
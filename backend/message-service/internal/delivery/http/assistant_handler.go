@@ -0,0 +1,92 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// AssistantHandler 会话摘要等AI辅助功能处理器
+type AssistantHandler struct {
+	assistantService domain.AssistantService
+	logger           *zap.Logger
+}
+
+// NewAssistantHandler 创建一个新的AI辅助功能处理器
+func NewAssistantHandler(assistantService domain.AssistantService, logger *zap.Logger) *AssistantHandler {
+	return &AssistantHandler{
+		assistantService: assistantService,
+		logger:           logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *AssistantHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/conversations/{id}/summary", authMiddleware(http.HandlerFunc(h.GetConversationSummary))).Methods("GET")
+	router.Handle("/api/v1/conversations/{id}/smart-replies", authMiddleware(http.HandlerFunc(h.GetSmartReplies))).Methods("GET")
+}
+
+// GetConversationSummary 获取会话最近消息的摘要，limit为参与摘要的消息数
+func (h *AssistantHandler) GetConversationSummary(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	conversationID := mux.Vars(r)["id"]
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			respondError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	summary, err := h.assistantService.SummarizeConversation(r.Context(), userID, conversationID, limit)
+	if err != nil {
+		h.respondAssistantError(w, err, conversationID, "failed to summarize conversation")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, summary)
+}
+
+// GetSmartReplies 获取针对会话最新消息的建议回复
+func (h *AssistantHandler) GetSmartReplies(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	conversationID := mux.Vars(r)["id"]
+
+	replies, err := h.assistantService.SuggestReplies(r.Context(), userID, conversationID)
+	if err != nil {
+		h.respondAssistantError(w, err, conversationID, "failed to suggest replies")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, replies)
+}
+
+// respondAssistantError 根据错误类型返回对应的状态码
+func (h *AssistantHandler) respondAssistantError(w http.ResponseWriter, err error, conversationID, message string) {
+	var rateLimitErr *service.RateLimitError
+	switch {
+	case errors.As(err, &rateLimitErr):
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))))
+		respondError(w, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, service.ErrNotParticipant):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrAssistantUnavailable), errors.Is(err, service.ErrSmartReplyDisabled):
+		respondError(w, http.StatusServiceUnavailable, err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, "conversation not found")
+	default:
+		h.logger.Error("Assistant request failed", zap.Error(err), zap.String("conversation_id", conversationID))
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,166 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/internal/service"
+	"github.com/neohope/chatapp/message-service/pkg/validation"
+	"go.uber.org/zap"
+)
+
+// RenameConversation 修改群聊会话名称
+func (h *MessageHandler) RenameConversation(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req domain.RenameConversationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	conversationID := mux.Vars(r)["id"]
+	conversation, err := h.service.RenameConversation(r.Context(), userID, conversationID, req.Name)
+	if err != nil {
+		h.respondConversationError(w, err, conversationID, "failed to rename conversation")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, conversation)
+}
+
+// AddParticipants 向群聊会话添加参与者
+func (h *MessageHandler) AddParticipants(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req domain.AddParticipantsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	conversationID := mux.Vars(r)["id"]
+	conversation, err := h.service.AddParticipants(r.Context(), userID, conversationID, req.UserIDs)
+	if err != nil {
+		h.respondConversationError(w, err, conversationID, "failed to add participants")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, conversation)
+}
+
+// RemoveParticipant 从群聊会话移除参与者，移除自己即退出会话
+func (h *MessageHandler) RemoveParticipant(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	conversation, err := h.service.RemoveParticipant(r.Context(), userID, vars["id"], vars["userId"])
+	if err != nil {
+		h.respondConversationError(w, err, vars["id"], "failed to remove participant")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, conversation)
+}
+
+// SetParticipantRole 任命或撤销群聊会话的管理员
+func (h *MessageHandler) SetParticipantRole(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req domain.SetParticipantRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	vars := mux.Vars(r)
+	conversation, err := h.service.SetParticipantRole(r.Context(), userID, vars["id"], vars["userId"], req.Role)
+	if err != nil {
+		h.respondConversationError(w, err, vars["id"], "failed to set participant role")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, conversation)
+}
+
+// SyncAddParticipants 群组服务同步频道成员，成员加入频道时添加为会话参与者
+func (h *MessageHandler) SyncAddParticipants(w http.ResponseWriter, r *http.Request) {
+	var req domain.AddParticipantsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	conversationID := mux.Vars(r)["id"]
+	conversation, err := h.service.SyncAddParticipants(r.Context(), conversationID, req.UserIDs)
+	if err != nil {
+		h.respondConversationError(w, err, conversationID, "failed to add participants")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, conversation)
+}
+
+// SyncRemoveParticipant 群组服务同步频道成员，成员离开频道或群组时移出会话
+func (h *MessageHandler) SyncRemoveParticipant(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	conversation, err := h.service.SyncRemoveParticipant(r.Context(), vars["id"], vars["userId"])
+	if err != nil {
+		h.respondConversationError(w, err, vars["id"], "failed to remove participant")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, conversation)
+}
+
+// respondConversationError 根据会话管理的错误类型返回对应的状态码
+func (h *MessageHandler) respondConversationError(w http.ResponseWriter, err error, conversationID, message string) {
+	switch {
+	case errors.Is(err, service.ErrNotConversationAdmin), errors.Is(err, service.ErrNotParticipant):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrLastConversationAdmin):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrNotGroupConversation), errors.Is(err, service.ErrConversationNameTooLong),
+		strings.Contains(err.Error(), "required"), strings.Contains(err.Error(), "invalid"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrParticipantNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, "conversation not found")
+	default:
+		h.logger.Error("Failed to manage conversation", zap.Error(err), zap.String("conversation_id", conversationID))
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,211 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// InteractionHandler 消息回应和已读回执处理器
+type InteractionHandler struct {
+	interactionService domain.InteractionService
+	adminUserIDs       map[string]bool
+	logger             *zap.Logger
+}
+
+// NewInteractionHandler 创建一个新的回应和已读回执处理器
+func NewInteractionHandler(interactionService domain.InteractionService, adminUserIDs []string, logger *zap.Logger) *InteractionHandler {
+	admins := make(map[string]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = true
+	}
+
+	return &InteractionHandler{
+		interactionService: interactionService,
+		adminUserIDs:       admins,
+		logger:             logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *InteractionHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/messages/{id}/reactions", authMiddleware(http.HandlerFunc(h.GetReactions))).Methods("GET")
+	router.Handle("/api/v1/messages/{id}/reactions", authMiddleware(http.HandlerFunc(h.AddReaction))).Methods("POST")
+	router.Handle("/api/v1/messages/{id}/reactions", authMiddleware(http.HandlerFunc(h.RemoveReaction))).Methods("DELETE")
+	router.Handle("/api/v1/messages/{id}/read", authMiddleware(http.HandlerFunc(h.MarkRead))).Methods("POST")
+	router.Handle("/api/v1/messages/{id}/receipts", authMiddleware(http.HandlerFunc(h.GetReceipts))).Methods("GET")
+	router.Handle("/api/v1/conversations/{id}/read", authMiddleware(http.HandlerFunc(h.MarkConversationRead))).Methods("PUT")
+	router.Handle("/api/v1/conversations/{id}/read", authMiddleware(http.HandlerFunc(h.GetReadCursors))).Methods("GET")
+
+	// 管理员路由
+	router.Handle("/api/v1/admin/aggregates/reconcile", authMiddleware(h.adminOnly(h.ReconcileAggregates))).Methods("POST")
+}
+
+// AddReaction 添加回应，请求体为 {"emoji": "👍"}
+func (h *InteractionHandler) AddReaction(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	messageID := mux.Vars(r)["id"]
+
+	var req struct {
+		Emoji string `json:"emoji"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	aggregates, err := h.interactionService.AddReaction(r.Context(), userID, messageID, req.Emoji)
+	if err != nil {
+		h.respondInteractionError(w, err, messageID, "failed to add reaction")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, aggregates)
+}
+
+// RemoveReaction 取消回应，表情通过查询参数emoji传递
+func (h *InteractionHandler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	messageID := mux.Vars(r)["id"]
+
+	aggregates, err := h.interactionService.RemoveReaction(r.Context(), userID, messageID, r.URL.Query().Get("emoji"))
+	if err != nil {
+		h.respondInteractionError(w, err, messageID, "failed to remove reaction")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, aggregates)
+}
+
+// GetReactions 获取消息的回应明细
+func (h *InteractionHandler) GetReactions(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	messageID := mux.Vars(r)["id"]
+
+	reactions, err := h.interactionService.GetReactions(r.Context(), userID, messageID)
+	if err != nil {
+		h.respondInteractionError(w, err, messageID, "failed to get reactions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, reactions)
+}
+
+// MarkRead 把消息标记为当前用户已读
+func (h *InteractionHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	messageID := mux.Vars(r)["id"]
+
+	aggregates, err := h.interactionService.MarkRead(r.Context(), userID, messageID)
+	if err != nil {
+		h.respondInteractionError(w, err, messageID, "failed to mark message read")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, aggregates)
+}
+
+// GetReceipts 获取消息每个接收者的送达和已读状态
+func (h *InteractionHandler) GetReceipts(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	messageID := mux.Vars(r)["id"]
+
+	receipts, err := h.interactionService.GetReceipts(r.Context(), userID, messageID)
+	if err != nil {
+		h.respondInteractionError(w, err, messageID, "failed to get receipts")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, receipts)
+}
+
+// MarkConversationRead 推进当前用户在会话中的已读位置，请求体 {"message_id": "..."} 可选，省略时推进到最新消息
+func (h *InteractionHandler) MarkConversationRead(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	conversationID := mux.Vars(r)["id"]
+
+	var req struct {
+		MessageID string `json:"message_id"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	cursor, err := h.interactionService.MarkConversationRead(r.Context(), userID, conversationID, req.MessageID)
+	if err != nil {
+		h.respondInteractionError(w, err, conversationID, "failed to mark conversation read")
+		return
+	}
+	if cursor == nil {
+		// 会话中还没有消息
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, cursor)
+}
+
+// GetReadCursors 获取会话所有参与者的已读位置
+func (h *InteractionHandler) GetReadCursors(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	conversationID := mux.Vars(r)["id"]
+
+	cursors, err := h.interactionService.GetReadCursors(r.Context(), userID, conversationID)
+	if err != nil {
+		h.respondInteractionError(w, err, conversationID, "failed to get read cursors")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, cursors)
+}
+
+// ReconcileAggregates 立即执行一次统计对账（管理员）
+func (h *InteractionHandler) ReconcileAggregates(w http.ResponseWriter, r *http.Request) {
+	fixed, err := h.interactionService.ReconcileAggregates(r.Context())
+	if err != nil {
+		h.logger.Error("Manual aggregate reconciliation failed", zap.Error(err))
+		respondError(w, http.StatusInternalServerError, "failed to reconcile aggregates")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]int{"fixed": fixed})
+}
+
+// respondInteractionError 根据错误类型返回对应的状态码
+// id为请求路径中的消息或会话ID
+func (h *InteractionHandler) respondInteractionError(w http.ResponseWriter, err error, id, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidEmoji), errors.Is(err, service.ErrMessageNotInConversation):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrNotParticipant):
+		respondError(w, http.StatusForbidden, err.Error())
+	case strings.Contains(err.Error(), "conversation not found"):
+		respondError(w, http.StatusNotFound, "conversation not found")
+	case strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, "message not found")
+	default:
+		h.logger.Error("Message interaction failed", zap.Error(err), zap.String("id", id))
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+// adminOnly 仅允许管理员访问
+func (h *InteractionHandler) adminOnly(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(r, h.adminUserIDs) {
+			respondError(w, http.StatusForbidden, "admin access required")
+			return
+		}
+		next(w, r)
+	})
+}
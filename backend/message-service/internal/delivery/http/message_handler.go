@@ -6,12 +6,16 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/internal/service"
 	"github.com/neohope/chatapp/message-service/pkg/auth"
+	"github.com/neohope/chatapp/message-service/pkg/pagination"
+	"github.com/neohope/chatapp/message-service/pkg/validation"
 	"go.uber.org/zap"
 )
 
@@ -36,6 +40,13 @@ func (h *MessageHandler) RegisterRoutes(router *mux.Router) {
 	// 公共API
 	router.HandleFunc("/health", h.HealthCheck).Methods("GET")
 
+	// 内部路由，不经过API网关暴露
+	router.HandleFunc("/internal/media/{id}/transcript", h.AttachTranscript).Methods("PUT")
+	router.HandleFunc("/internal/conversations/{id}", h.GetConversationType).Methods("GET")
+	router.HandleFunc("/internal/conversations/{id}/participants/{userId}", h.CheckParticipant).Methods("GET")
+	router.HandleFunc("/internal/conversations/{id}/participants", h.SyncAddParticipants).Methods("POST")
+	router.HandleFunc("/internal/conversations/{id}/participants/{userId}", h.SyncRemoveParticipant).Methods("DELETE")
+
 	// 需要认证的API
 	apiRouter := router.PathPrefix("/api/v1").Subrouter()
 	apiRouter.Use(h.AuthMiddleware)
@@ -43,13 +54,22 @@ func (h *MessageHandler) RegisterRoutes(router *mux.Router) {
 	// 消息相关API
 	apiRouter.HandleFunc("/messages", h.SendMessage).Methods("POST")
 	apiRouter.HandleFunc("/messages/{id}", h.GetMessage).Methods("GET")
+	apiRouter.HandleFunc("/messages/{id}", h.EditMessage).Methods("PUT")
+	apiRouter.HandleFunc("/messages/{id}", h.DeleteMessage).Methods("DELETE")
+	apiRouter.HandleFunc("/messages/{id}/edits", h.GetMessageEdits).Methods("GET")
+	apiRouter.HandleFunc("/messages/{id}/thread", h.GetThread).Methods("GET")
 	apiRouter.HandleFunc("/messages/{id}/status", h.UpdateMessageStatus).Methods("PUT")
 	apiRouter.HandleFunc("/conversations/{id}/messages", h.GetConversationMessages).Methods("GET")
+	apiRouter.HandleFunc("/conversations/{id}/messages/around", h.GetMessagesAround).Methods("GET")
 
 	// 会话相关API
 	apiRouter.HandleFunc("/conversations", h.CreateConversation).Methods("POST")
 	apiRouter.HandleFunc("/conversations", h.GetUserConversations).Methods("GET")
 	apiRouter.HandleFunc("/conversations/{id}", h.GetConversation).Methods("GET")
+	apiRouter.HandleFunc("/conversations/{id}", h.RenameConversation).Methods("PUT")
+	apiRouter.HandleFunc("/conversations/{id}/participants", h.AddParticipants).Methods("POST")
+	apiRouter.HandleFunc("/conversations/{id}/participants/{userId}", h.RemoveParticipant).Methods("DELETE")
+	apiRouter.HandleFunc("/conversations/{id}/participants/{userId}/role", h.SetParticipantRole).Methods("PUT")
 }
 
 // HealthCheck 健康检查
@@ -57,6 +77,74 @@ func (h *MessageHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"status": "ok", "service": "message-service"})
 }
 
+// AttachTranscript 接收媒体服务推送的语音转写文本，写入引用该媒体文件的语音消息
+func (h *MessageHandler) AttachTranscript(w http.ResponseWriter, r *http.Request) {
+	mediaID := mux.Vars(r)["id"]
+
+	var transcript domain.MediaTranscript
+	if err := json.NewDecoder(r.Body).Decode(&transcript); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	count, err := h.service.AttachTranscript(r.Context(), mediaID, &transcript)
+	if err != nil {
+		if strings.Contains(err.Error(), "required") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.Error("Failed to attach transcript", zap.Error(err), zap.String("media_id", mediaID))
+		respondError(w, http.StatusInternalServerError, "failed to attach transcript")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]int{"updated": count})
+}
+
+// GetConversationType 供媒体服务判断会话类型，群聊会话附加文件前需要检查群组存储配额
+func (h *MessageHandler) GetConversationType(w http.ResponseWriter, r *http.Request) {
+	conversationID := mux.Vars(r)["id"]
+
+	conversation, err := h.service.GetConversation(r.Context(), conversationID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			respondError(w, http.StatusNotFound, "conversation not found")
+			return
+		}
+		h.logger.Error("Failed to get conversation", zap.Error(err), zap.String("conversation_id", conversationID))
+		respondError(w, http.StatusInternalServerError, "failed to get conversation")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"id": conversation.ID, "type": conversation.Type})
+}
+
+// CheckParticipant 供媒体服务判断用户是否为会话参与者，会话不存在时返回false
+func (h *MessageHandler) CheckParticipant(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	conversationID, userID := vars["id"], vars["userId"]
+
+	conversation, err := h.service.GetConversation(r.Context(), conversationID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			respondJSON(w, http.StatusOK, map[string]bool{"participant": false})
+			return
+		}
+		h.logger.Error("Failed to get conversation", zap.Error(err), zap.String("conversation_id", conversationID))
+		respondError(w, http.StatusInternalServerError, "failed to get conversation")
+		return
+	}
+
+	participant := false
+	for _, id := range conversation.Participants {
+		if id == userID {
+			participant = true
+			break
+		}
+	}
+	respondJSON(w, http.StatusOK, map[string]bool{"participant": participant})
+}
+
 // SendMessage 发送消息
 func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	userID, err := h.getUserIDFromContext(r.Context())
@@ -72,13 +160,7 @@ func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 验证请求
-	if req.Content == "" {
-		respondError(w, http.StatusBadRequest, "message content is required")
-		return
-	}
-
-	if req.ConversationID == "" {
-		respondError(w, http.StatusBadRequest, "conversation ID is required")
+	if !validation.Request(w, &req) {
 		return
 	}
 
@@ -94,10 +176,30 @@ func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		CreatedAt:    time.Now().UTC(),
 		UpdatedAt:    time.Now().UTC(),
 		IsGroupChat:  req.IsGroupChat,
+		TTLSeconds:   req.TTLSeconds,
+		ViewOnce:     req.ViewOnce,
+
+		ReplyToMessageID: req.ReplyTo,
 	}
 
 	// 发送消息
 	if err := h.service.SendMessage(r.Context(), message); err != nil {
+		if errors.Is(err, service.ErrInvalidTTL) || errors.Is(err, service.ErrInvalidReply) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrMessageDeleted) {
+			respondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrSendNotPermitted) {
+			respondError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrGroupServiceUnavailable) {
+			respondError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
 		h.logger.Error("Failed to send message", zap.Error(err), zap.String("user_id", userID))
 		respondError(w, http.StatusInternalServerError, "failed to send message")
 		return
@@ -133,6 +235,112 @@ func (h *MessageHandler) GetMessage(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, message)
 }
 
+// EditMessage 编辑消息内容，仅发送者可以编辑
+func (h *MessageHandler) EditMessage(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	messageID := mux.Vars(r)["id"]
+
+	var req domain.EditMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	message, err := h.service.EditMessage(r.Context(), userID, messageID, req.Content)
+	if err != nil {
+		h.respondModifyError(w, err, messageID, "failed to edit message")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, message)
+}
+
+// DeleteMessage 撤回消息，仅发送者可以撤回
+func (h *MessageHandler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	messageID := mux.Vars(r)["id"]
+	message, err := h.service.DeleteMessage(r.Context(), userID, messageID)
+	if err != nil {
+		h.respondModifyError(w, err, messageID, "failed to delete message")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, message)
+}
+
+// GetMessageEdits 获取消息的编辑历史
+func (h *MessageHandler) GetMessageEdits(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	messageID := mux.Vars(r)["id"]
+	edits, err := h.service.GetMessageEdits(r.Context(), userID, messageID)
+	if err != nil {
+		h.respondModifyError(w, err, messageID, "failed to get message edits")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, edits)
+}
+
+// GetThread 获取消息所在的话题
+func (h *MessageHandler) GetThread(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.Parse(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	messageID := mux.Vars(r)["id"]
+	messages, err := h.service.GetThread(r.Context(), userID, messageID, page.Limit, page.Offset)
+	if err != nil {
+		h.respondModifyError(w, err, messageID, "failed to get thread")
+		return
+	}
+
+	pagination.SetLinkHeader(w, r, page, page.HasMore(len(messages)))
+	respondJSON(w, http.StatusOK, messages)
+}
+
+// respondModifyError 根据消息编辑、撤回的错误类型返回对应的状态码
+func (h *MessageHandler) respondModifyError(w http.ResponseWriter, err error, messageID, message string) {
+	switch {
+	case errors.Is(err, service.ErrNotMessageSender), errors.Is(err, service.ErrNotParticipant):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrMessageDeleted):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrMessageNotEditable), strings.Contains(err.Error(), "required"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, "message not found")
+	default:
+		h.logger.Error("Failed to modify message", zap.Error(err), zap.String("message_id", messageID))
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
 // UpdateMessageStatus 更新消息状态
 func (h *MessageHandler) UpdateMessageStatus(w http.ResponseWriter, r *http.Request) {
 	_, err := h.getUserIDFromContext(r.Context())
@@ -195,10 +403,14 @@ func (h *MessageHandler) GetConversationMessages(w http.ResponseWriter, r *http.
 	}
 
 	// 获取分页参数
-	limit, offset := h.getPaginationParams(r)
+	page, err := pagination.Parse(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	// 获取消息
-	messages, err := h.service.GetConversationMessages(r.Context(), conversationID, limit, offset)
+	messages, err := h.service.GetConversationMessages(r.Context(), conversationID, page.Limit, page.Offset)
 	if err != nil {
 		h.logger.Error("Failed to get conversation messages",
 			zap.Error(err),
@@ -208,9 +420,70 @@ func (h *MessageHandler) GetConversationMessages(w http.ResponseWriter, r *http.
 		return
 	}
 
+	pagination.SetLinkHeader(w, r, page, page.HasMore(len(messages)))
 	respondJSON(w, http.StatusOK, messages)
 }
 
+// GetMessagesAround 获取目标消息或时间点前后的消息，用于从搜索结果或置顶消息跳转
+func (h *MessageHandler) GetMessagesAround(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+	messageID := query.Get("message_id")
+	var at time.Time
+	if value := query.Get("date"); value != "" {
+		if messageID != "" {
+			respondError(w, http.StatusBadRequest, "message_id and date are mutually exclusive")
+			return
+		}
+		if at, err = time.Parse(time.RFC3339, value); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid date, expected RFC3339")
+			return
+		}
+	} else if messageID == "" {
+		respondError(w, http.StatusBadRequest, "message_id or date is required")
+		return
+	}
+
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			respondError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	conversationID := mux.Vars(r)["id"]
+	window, err := h.service.GetMessagesAround(r.Context(), userID, conversationID, messageID, at, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotParticipant):
+			respondError(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, service.ErrMessageNotInConversation):
+			respondError(w, http.StatusNotFound, "message not found")
+		case strings.Contains(err.Error(), "message not found"):
+			respondError(w, http.StatusNotFound, "message not found")
+		case strings.Contains(err.Error(), "not found"):
+			respondError(w, http.StatusNotFound, "conversation not found")
+		default:
+			h.logger.Error("Failed to get messages around",
+				zap.Error(err),
+				zap.String("conversation_id", conversationID),
+			)
+			respondError(w, http.StatusInternalServerError, "failed to get messages around")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, window)
+}
+
 // CreateConversation 创建会话
 func (h *MessageHandler) CreateConversation(w http.ResponseWriter, r *http.Request) {
 	userID, err := h.getUserIDFromContext(r.Context())
@@ -253,12 +526,22 @@ func (h *MessageHandler) CreateConversation(w http.ResponseWriter, r *http.Reque
 	conversation := &domain.Conversation{
 		ID:           uuid.New().String(),
 		Type:         req.Type,
+		Name:         req.Name,
 		Participants: req.Participants,
 		CreatedAt:    time.Now().UTC(),
 		UpdatedAt:    time.Now().UTC(),
 	}
 
+	// 群聊会话的创建者成为管理员
+	if req.Type == domain.ConversationTypeGroup {
+		conversation.Admins = []string{userID}
+	}
+
 	if err := h.service.CreateConversation(r.Context(), conversation); err != nil {
+		if errors.Is(err, service.ErrConversationNameTooLong) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		h.logger.Error("Failed to create conversation", zap.Error(err), zap.String("user_id", userID))
 		respondError(w, http.StatusInternalServerError, "failed to create conversation")
 		return
@@ -276,16 +559,21 @@ func (h *MessageHandler) GetUserConversations(w http.ResponseWriter, r *http.Req
 	}
 
 	// 获取分页参数
-	limit, offset := h.getPaginationParams(r)
+	page, err := pagination.Parse(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	// 获取会话列表
-	conversations, err := h.service.GetUserConversations(r.Context(), userID, limit, offset)
+	conversations, err := h.service.GetUserConversations(r.Context(), userID, page.Limit, page.Offset)
 	if err != nil {
 		h.logger.Error("Failed to get user conversations", zap.Error(err), zap.String("user_id", userID))
 		respondError(w, http.StatusInternalServerError, "failed to get user conversations")
 		return
 	}
 
+	pagination.SetLinkHeader(w, r, page, page.HasMore(len(conversations)))
 	respondJSON(w, http.StatusOK, conversations)
 }
 
@@ -324,6 +612,7 @@ func (h *MessageHandler) AuthMiddleware(next http.Handler) http.Handler {
 		if userID != "" {
 			// 信任API网关的认证结果
 			ctx := context.WithValue(r.Context(), "user_id", userID)
+			ctx = context.WithValue(ctx, "role", h.verifiedRole(r, userID))
 			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
@@ -349,10 +638,25 @@ func (h *MessageHandler) AuthMiddleware(next http.Handler) http.Handler {
 
 		// 将用户ID添加到请求上下文
 		ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
+		ctx = context.WithValue(ctx, "role", claims.Role)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// verifiedRole 角色只来自校验过的令牌，不使用客户端可以伪造的X-User-Role头部
+// API网关转发请求时保留Authorization，令牌缺失、无效或不属于该用户时返回空角色
+func (h *MessageHandler) verifiedRole(r *http.Request, userID string) string {
+	tokenString := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if tokenString == "" {
+		return ""
+	}
+	claims, err := h.jwtManager.VerifyToken(tokenString)
+	if err != nil || claims.UserID != userID {
+		return ""
+	}
+	return claims.Role
+}
+
 // 从上下文获取用户ID
 func (h *MessageHandler) getUserIDFromContext(ctx context.Context) (string, error) {
 	userID, ok := ctx.Value("user_id").(string)
@@ -362,31 +666,6 @@ func (h *MessageHandler) getUserIDFromContext(ctx context.Context) (string, erro
 	return userID, nil
 }
 
-// 获取分页参数
-func (h *MessageHandler) getPaginationParams(r *http.Request) (int, int) {
-	// 默认值
-	limit := 20
-	offset := 0
-
-	// 从查询参数获取
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-			if limit > 100 {
-				limit = 100 // 限制最大获取数量
-			}
-		}
-	}
-
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
-		}
-	}
-
-	return limit, offset
-}
-
 // respondJSON 响应JSON数据
 func respondJSON(w http.ResponseWriter, status int, payload interface{}) {
 	response, err := json.Marshal(payload)
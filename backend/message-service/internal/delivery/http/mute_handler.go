@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// MuteHandler 会话免打扰处理器
+type MuteHandler struct {
+	muteService domain.MuteService
+	logger      *zap.Logger
+}
+
+// NewMuteHandler 创建一个新的会话免打扰处理器
+func NewMuteHandler(muteService domain.MuteService, logger *zap.Logger) *MuteHandler {
+	return &MuteHandler{
+		muteService: muteService,
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *MuteHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/conversations/{id}/mute", authMiddleware(http.HandlerFunc(h.MuteConversation))).Methods("PUT")
+	router.HandleFunc("/internal/conversations/{id}/mutes", h.ListActiveMutes).Methods("GET")
+}
+
+// MuteConversation 设置或取消会话免打扰
+func (h *MuteHandler) MuteConversation(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	conversationID := mux.Vars(r)["id"]
+
+	var req domain.MuteConversationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	mute, err := h.muteService.MuteConversation(r.Context(), userID, conversationID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidMuteDuration):
+			respondError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrNotParticipant):
+			respondError(w, http.StatusForbidden, err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			respondError(w, http.StatusNotFound, "conversation not found")
+		default:
+			h.logger.Error("Failed to mute conversation", zap.Error(err), zap.String("conversation_id", conversationID))
+			respondError(w, http.StatusInternalServerError, "failed to mute conversation")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, mute)
+}
+
+// ListActiveMutes 供通知服务在推送前查询会话中开启了免打扰的用户
+func (h *MuteHandler) ListActiveMutes(w http.ResponseWriter, r *http.Request) {
+	conversationID := mux.Vars(r)["id"]
+
+	mutes, err := h.muteService.ListActiveMutes(r.Context(), conversationID)
+	if err != nil {
+		h.logger.Error("Failed to list conversation mutes", zap.Error(err), zap.String("conversation_id", conversationID))
+		respondError(w, http.StatusInternalServerError, "failed to list conversation mutes")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, mutes)
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/pkg/openapi"
+)
+
+// 以下请求和响应类型只用于生成接口文档，与对应处理函数中的匿名结构体保持一致
+type reactionRequest struct {
+	Emoji string `json:"emoji"`
+}
+
+type readCursorRequest struct {
+	MessageID string `json:"message_id"`
+}
+
+type messageStatusRequest struct {
+	Status domain.MessageStatus `json:"status"`
+}
+
+type presenceQueryRequest struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+type presenceQueryResponse struct {
+	Presences []*domain.Presence `json:"presences"`
+}
+
+// APIOperations 消息服务HTTP接口说明，启动时与注册的路由核对后生成/openapi.json
+var APIOperations = openapi.Operations{
+	"GET /ws": {Summary: "建立WebSocket连接", Description: "令牌通过token查询参数传递，升级成功返回101", Query: []string{"token", "device"}, Status: http.StatusSwitchingProtocols, Public: true},
+	"DELETE /api/v1/conversations/{id}/participants/{userId}":   {Summary: "移除群聊会话的参与者（管理员），参与者也可以移除自己退出会话", Description: "还有其他参与者时不能移除最后一个管理员，返回409", Response: domain.Conversation{}},
+	"DELETE /api/v1/messages/{id}":                              {Summary: "撤回消息，发送者和群聊会话的管理员可以撤回", Response: domain.Message{}},
+	"DELETE /api/v1/messages/{id}/pin":                          {Summary: "取消置顶", Status: http.StatusNoContent},
+	"DELETE /api/v1/messages/{id}/star":                         {Summary: "取消收藏，未收藏时同样返回204", Status: http.StatusNoContent},
+	"DELETE /api/v1/messages/{id}/reactions":                    {Summary: "取消回应", Query: []string{"emoji"}, Response: domain.MessageAggregates{}},
+	"GET /api/v1/conversations":                                 {Summary: "获取用户会话列表", Query: []string{"limit", "offset"}, Response: []*domain.Conversation{}},
+	"GET /api/v1/conversations/{id}":                            {Summary: "获取会话", Response: domain.Conversation{}},
+	"GET /api/v1/conversations/{id}/messages":                   {Summary: "获取会话消息", Query: []string{"limit", "offset"}, Response: []*domain.Message{}},
+	"GET /api/v1/conversations/{id}/messages/around":            {Summary: "获取目标消息或时间点前后的消息", Description: "message_id和date（RFC3339）二选一，limit为前后各返回的消息数，默认20，最多50", Query: []string{"message_id", "date", "limit"}, Response: domain.MessageWindow{}},
+	"GET /api/v1/conversations/{id}/pins":                       {Summary: "获取会话中的置顶消息", Response: []*domain.MessagePin{}},
+	"GET /api/v1/conversations/{id}/read":                       {Summary: "获取会话所有参与者的已读位置", Response: []*domain.ReadCursor{}},
+	"GET /api/v1/conversations/{id}/smart-replies":              {Summary: "获取针对会话最新消息的建议回复", Response: domain.SmartReplies{}},
+	"GET /api/v1/conversations/{id}/summary":                    {Summary: "获取会话最近消息的摘要，limit为参与摘要的消息数", Query: []string{"limit"}, Response: domain.ConversationSummary{}},
+	"GET /api/v1/messages/starred":                              {Summary: "获取当前用户跨会话收藏的消息，按收藏时间倒序", Query: []string{"limit", "offset"}, Response: []*domain.StarredMessage{}},
+	"GET /api/v1/messages/{id}":                                 {Summary: "获取消息", Response: domain.Message{}},
+	"GET /api/v1/messages/{id}/edits":                           {Summary: "获取消息的编辑历史", Response: []*domain.MessageEdit{}},
+	"GET /api/v1/messages/{id}/reactions":                       {Summary: "获取消息的回应明细", Response: []*domain.Reaction{}},
+	"GET /api/v1/messages/{id}/thread":                          {Summary: "获取消息所在的话题", Description: "按创建时间顺序返回根消息和所有回复，回复的回复归入同一话题", Query: []string{"limit", "offset"}, Response: []*domain.Message{}},
+	"GET /api/v1/messages/{id}/receipts":                        {Summary: "获取消息每个接收者的送达和已读状态", Response: []*domain.MessageReceipt{}},
+	"GET /api/v1/presence/{userId}":                             {Summary: "获取用户的在线状态", Response: domain.Presence{}},
+	"POST /api/v1/admin/aggregates/reconcile":                   {Summary: "立即执行一次统计对账（管理员）", Response: map[string]int{}},
+	"POST /api/v1/conversations":                                {Summary: "创建会话，群聊会话的创建者成为管理员", Request: domain.CreateConversationRequest{}, Response: domain.Conversation{}, Status: http.StatusCreated},
+	"POST /api/v1/conversations/{id}/participants":              {Summary: "向群聊会话添加参与者（管理员）", Description: "新参与者为普通成员，已在会话中的用户忽略", Request: domain.AddParticipantsRequest{}, Response: domain.Conversation{}},
+	"POST /api/v1/messages":                                     {Summary: "发送消息", Description: "reply_to为同一会话中被回复的消息ID", Request: domain.SendMessageRequest{}, Response: domain.Message{}, Status: http.StatusCreated},
+	"POST /api/v1/messages/{id}/pin":                            {Summary: "置顶消息", Response: domain.MessagePin{}},
+	"POST /api/v1/messages/{id}/reactions":                      {Summary: "添加回应", Request: reactionRequest{}, Response: domain.MessageAggregates{}},
+	"POST /api/v1/messages/{id}/read":                           {Summary: "把消息标记为当前用户已读", Response: domain.MessageAggregates{}},
+	"POST /api/v1/presence/query":                               {Summary: "批量获取用户的在线状态", Description: "结果顺序与请求中的user_ids一致", Request: presenceQueryRequest{}, Response: presenceQueryResponse{}},
+	"PUT /api/v1/conversations/{id}":                            {Summary: "修改群聊会话名称（管理员）", Request: domain.RenameConversationRequest{}, Response: domain.Conversation{}},
+	"PUT /api/v1/conversations/{id}/mute":                       {Summary: "设置或取消会话免打扰", Description: "duration可选1h、8h、forever，off取消免打扰；免打扰期间通知服务不再推送该会话的新消息", Request: domain.MuteConversationRequest{}, Response: domain.ConversationMute{}},
+	"PUT /api/v1/conversations/{id}/read":                       {Summary: "推进当前用户在会话中的已读位置", Description: "message_id省略时推进到最新消息，已读位置没有变化时返回204", Request: readCursorRequest{}, Response: domain.ReadCursor{}},
+	"PUT /api/v1/conversations/{id}/participants/{userId}/role": {Summary: "任命或撤销群聊会话的管理员（管理员）", Description: "role可选admin或member，不能撤销最后一个管理员", Request: domain.SetParticipantRoleRequest{}, Response: domain.Conversation{}},
+	"PUT /api/v1/messages/{id}":                                 {Summary: "编辑消息内容，仅发送者可以编辑", Request: domain.EditMessageRequest{}, Response: domain.Message{}},
+	"PUT /api/v1/messages/{id}/star":                            {Summary: "收藏消息，仅会话参与者可以收藏", Description: "重复收藏不修改收藏时间", Status: http.StatusNoContent},
+	"PUT /api/v1/messages/{id}/status":                          {Summary: "更新消息状态", Request: messageStatusRequest{}},
+}
+
+// ArchiveAPIOperations 消息归档的接口说明，只在启用归档时注册
+var ArchiveAPIOperations = openapi.Operations{
+	"GET /api/v1/admin/archives":               {Summary: "获取全部归档（管理员）", Response: []*domain.MessageArchive{}},
+	"GET /api/v1/conversations/{id}/archives":  {Summary: "获取会话已归档的历史消息占位信息，仅会话参与者可查看", Response: []*domain.ArchiveStub{}},
+	"POST /api/v1/admin/archives/run":          {Summary: "立即执行一次归档（管理员）"},
+	"POST /api/v1/admin/archives/{id}/restore": {Summary: "按需把归档恢复到数据库（管理员）", Response: domain.MessageArchive{}},
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// PinHandler 置顶消息处理器
+type PinHandler struct {
+	pinService domain.PinService
+	logger     *zap.Logger
+}
+
+// NewPinHandler 创建一个新的置顶消息处理器
+func NewPinHandler(pinService domain.PinService, logger *zap.Logger) *PinHandler {
+	return &PinHandler{
+		pinService: pinService,
+		logger:     logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *PinHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/messages/{id}/pin", authMiddleware(http.HandlerFunc(h.PinMessage))).Methods("POST")
+	router.Handle("/api/v1/messages/{id}/pin", authMiddleware(http.HandlerFunc(h.UnpinMessage))).Methods("DELETE")
+	router.Handle("/api/v1/conversations/{id}/pins", authMiddleware(http.HandlerFunc(h.ListPins))).Methods("GET")
+}
+
+// PinMessage 置顶消息
+func (h *PinHandler) PinMessage(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	messageID := mux.Vars(r)["id"]
+
+	pin, err := h.pinService.PinMessage(r.Context(), userID, messageID)
+	if err != nil {
+		h.respondPinError(w, err, messageID, "failed to pin message")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, pin)
+}
+
+// UnpinMessage 取消置顶
+func (h *PinHandler) UnpinMessage(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	messageID := mux.Vars(r)["id"]
+
+	if err := h.pinService.UnpinMessage(r.Context(), userID, messageID); err != nil {
+		h.respondPinError(w, err, messageID, "failed to unpin message")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListPins 获取会话中的置顶消息
+func (h *PinHandler) ListPins(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	conversationID := mux.Vars(r)["id"]
+
+	pins, err := h.pinService.ListPins(r.Context(), userID, conversationID)
+	if err != nil {
+		h.respondPinError(w, err, conversationID, "failed to list pinned messages")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, pins)
+}
+
+// respondPinError 把置顶服务的错误转换为HTTP响应
+func (h *PinHandler) respondPinError(w http.ResponseWriter, err error, id, message string) {
+	switch {
+	case errors.Is(err, service.ErrNotParticipant), errors.Is(err, service.ErrPinNotPermitted):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrMessageDeleted):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrGroupServiceUnavailable):
+		respondError(w, http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, service.ErrMessageNotPinned):
+		respondError(w, http.StatusNotFound, err.Error())
+	case strings.Contains(err.Error(), "conversation not found"):
+		respondError(w, http.StatusNotFound, "conversation not found")
+	case strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, "message not found")
+	default:
+		h.logger.Error("Message pin operation failed", zap.Error(err), zap.String("id", id))
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
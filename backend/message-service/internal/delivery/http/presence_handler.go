@@ -0,0 +1,72 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// maxPresenceQuery 批量查询在线状态时最多包含的用户数
+const maxPresenceQuery = 200
+
+// PresenceHandler 在线状态处理器
+type PresenceHandler struct {
+	presenceService domain.PresenceService
+	logger          *zap.Logger
+}
+
+// NewPresenceHandler 创建一个新的在线状态处理器
+func NewPresenceHandler(presenceService domain.PresenceService, logger *zap.Logger) *PresenceHandler {
+	return &PresenceHandler{
+		presenceService: presenceService,
+		logger:          logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *PresenceHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/presence/query", authMiddleware(http.HandlerFunc(h.QueryPresence))).Methods("POST")
+	router.Handle("/api/v1/presence/{userId}", authMiddleware(http.HandlerFunc(h.GetPresence))).Methods("GET")
+}
+
+// GetPresence 获取用户的在线状态
+func (h *PresenceHandler) GetPresence(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userId"]
+
+	presence, err := h.presenceService.GetPresence(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get presence", zap.String("userID", userID), zap.Error(err))
+		respondError(w, http.StatusInternalServerError, "failed to get presence")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, presence)
+}
+
+// QueryPresence 批量获取用户的在线状态，结果顺序与请求中的user_ids一致
+func (h *PresenceHandler) QueryPresence(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		UserIDs []string `json:"user_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(request.UserIDs) > maxPresenceQuery {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("at most %d user_ids per query", maxPresenceQuery))
+		return
+	}
+
+	presences, err := h.presenceService.QueryPresence(r.Context(), request.UserIDs)
+	if err != nil {
+		h.logger.Error("Failed to query presence", zap.Int("users", len(request.UserIDs)), zap.Error(err))
+		respondError(w, http.StatusInternalServerError, "failed to query presence")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"presences": presences})
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/internal/service"
+	"github.com/neohope/chatapp/message-service/pkg/pagination"
+	"go.uber.org/zap"
+)
+
+// StarHandler 收藏消息处理器
+type StarHandler struct {
+	starService domain.StarService
+	logger      *zap.Logger
+}
+
+// NewStarHandler 创建一个新的收藏消息处理器
+func NewStarHandler(starService domain.StarService, logger *zap.Logger) *StarHandler {
+	return &StarHandler{
+		starService: starService,
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+// 需要在消息处理器之前注册，否则/messages/starred会被/messages/{id}匹配
+func (h *StarHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/messages/starred", authMiddleware(http.HandlerFunc(h.ListStarred))).Methods("GET")
+	router.Handle("/api/v1/messages/{id}/star", authMiddleware(http.HandlerFunc(h.StarMessage))).Methods("PUT")
+	router.Handle("/api/v1/messages/{id}/star", authMiddleware(http.HandlerFunc(h.UnstarMessage))).Methods("DELETE")
+}
+
+// StarMessage 收藏消息
+func (h *StarHandler) StarMessage(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	messageID := mux.Vars(r)["id"]
+
+	if err := h.starService.StarMessage(r.Context(), userID, messageID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotParticipant):
+			respondError(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, domain.ErrMessageDeleted):
+			respondError(w, http.StatusConflict, err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			respondError(w, http.StatusNotFound, "message not found")
+		default:
+			h.logger.Error("Failed to star message", zap.Error(err), zap.String("message_id", messageID))
+			respondError(w, http.StatusInternalServerError, "failed to star message")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnstarMessage 取消收藏
+func (h *StarHandler) UnstarMessage(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	messageID := mux.Vars(r)["id"]
+
+	if err := h.starService.UnstarMessage(r.Context(), userID, messageID); err != nil {
+		h.logger.Error("Failed to unstar message", zap.Error(err), zap.String("message_id", messageID))
+		respondError(w, http.StatusInternalServerError, "failed to unstar message")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListStarred 分页获取当前用户收藏的消息
+func (h *StarHandler) ListStarred(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+
+	page, err := pagination.Parse(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stars, err := h.starService.ListStarred(r.Context(), userID, page.Limit, page.Offset)
+	if err != nil {
+		h.logger.Error("Failed to list starred messages", zap.Error(err), zap.String("user_id", userID))
+		respondError(w, http.StatusInternalServerError, "failed to list starred messages")
+		return
+	}
+
+	pagination.SetLinkHeader(w, r, page, page.HasMore(len(stars)))
+	respondJSON(w, http.StatusOK, stars)
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// ReadStateEventType 导出到分析管道的已读状态事件类型
+type ReadStateEventType string
+
+const (
+	ReadStateEventDelivered   ReadStateEventType = "message.delivered"        // 消息送达接收者
+	ReadStateEventRead        ReadStateEventType = "message.read"             // 接收者已读消息
+	ReadStateEventCursorMoved ReadStateEventType = "conversation.read_cursor" // 用户推进会话已读位置
+)
+
+// ReadStateEvent 送达、已读状态变更事件，只包含会话和用户维度，不包含消息内容
+type ReadStateEvent struct {
+	Type             ReadStateEventType `json:"type"`
+	ConversationID   string             `json:"conversation_id"`
+	GroupChat        bool               `json:"group_chat"`
+	UserID           string             `json:"user_id"`             // 送达或已读的用户
+	SenderID         string             `json:"sender_id,omitempty"` // 消息发送者，已读位置事件为空
+	MessageID        string             `json:"message_id"`
+	MessageCreatedAt time.Time          `json:"message_created_at"` // 与At之差即送达或已读耗时
+	At               time.Time          `json:"at"`
+}
+
+// ReadStateExporter 把已读状态事件导出到分析管道，实现方不得阻塞调用方
+type ReadStateExporter interface {
+	Export(events ...*ReadStateEvent)
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/pkg/jobs"
+)
+
+// ArchiveStatus 归档状态
+type ArchiveStatus string
+
+const (
+	ArchiveStatusArchived ArchiveStatus = "archived" // 分区已移出数据库，仅保留冷存储副本
+	ArchiveStatusRestored ArchiveStatus = "restored" // 已按需恢复回数据库
+)
+
+// MessagePartition 按月划分的消息分区
+type MessagePartition struct {
+	Name        string    `json:"name"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+}
+
+// MessageArchive 已归档分区的元数据
+type MessageArchive struct {
+	ID            string        `json:"id"`
+	PartitionName string        `json:"partition_name"`
+	PeriodStart   time.Time     `json:"period_start"`
+	PeriodEnd     time.Time     `json:"period_end"`
+	Bucket        string        `json:"bucket"`
+	ObjectKey     string        `json:"object_key"`
+	Format        string        `json:"format"`
+	MessageCount  int64         `json:"message_count"`
+	SizeBytes     int64         `json:"size_bytes"`
+	Status        ArchiveStatus `json:"status"`
+	ArchivedAt    time.Time     `json:"archived_at"`
+	RestoredAt    *time.Time    `json:"restored_at,omitempty"`
+}
+
+// ArchiveStub 会话在某个归档中的占位信息，用于提示客户端存在可按需恢复的历史消息
+type ArchiveStub struct {
+	ArchiveID      string        `json:"archive_id"`
+	ConversationID string        `json:"conversation_id"`
+	PeriodStart    time.Time     `json:"period_start"`
+	PeriodEnd      time.Time     `json:"period_end"`
+	MessageCount   int64         `json:"message_count"`
+	FirstMessageAt time.Time     `json:"first_message_at"`
+	LastMessageAt  time.Time     `json:"last_message_at"`
+	Status         ArchiveStatus `json:"status"`
+}
+
+// PartitionSnapshot 导出时分区内容的摘要，删除分区前据此确认导出后分区没有新的写入
+type PartitionSnapshot struct {
+	MessageCount  int64
+	LastUpdatedAt time.Time
+}
+
+// PartitionReader 在导出快照中按会话和时间顺序逐条读取分区内的消息
+type PartitionReader func(fn func(*Message) error) error
+
+// ArchiveRepository 消息分区与归档元数据仓库接口
+type ArchiveRepository interface {
+	EnsurePartition(ctx context.Context, month time.Time) error
+	ListPartitions(ctx context.Context) ([]*MessagePartition, error)
+	// ExportPartition 在同一个只读快照中统计分区的会话占位信息并调用export读取消息，返回快照摘要
+	ExportPartition(ctx context.Context, partition string, export func(stubs []*ArchiveStub, read PartitionReader) error) (*PartitionSnapshot, error)
+	// FinalizeArchive 在同一事务中锁定消息表，确认分区与导出快照一致后写入归档元数据并删除分区
+	FinalizeArchive(ctx context.Context, archive *MessageArchive, stubs []*ArchiveStub, snapshot *PartitionSnapshot) error
+	RestoreMessages(ctx context.Context, archive *MessageArchive, messages []*Message) error
+	MarkRestored(ctx context.Context, archiveID string, restoredAt time.Time) error
+	GetArchive(ctx context.Context, id string) (*MessageArchive, error)
+	GetArchiveByPartition(ctx context.Context, partition string) (*MessageArchive, error)
+	ListArchives(ctx context.Context) ([]*MessageArchive, error)
+	GetConversationStubs(ctx context.Context, conversationID string) ([]*ArchiveStub, error)
+}
+
+// ColdStorage 归档文件的冷存储
+type ColdStorage interface {
+	Bucket() string
+	Upload(ctx context.Context, key string, body io.ReadSeeker) error
+	Download(ctx context.Context, key string, w io.WriterAt) (int64, error)
+}
+
+// ArchiveService 消息归档服务接口
+type ArchiveService interface {
+	ScheduleJobs(runner *jobs.Runner) error
+	RunArchival(ctx context.Context) ([]*MessageArchive, error)
+	ListArchives(ctx context.Context) ([]*MessageArchive, error)
+	GetConversationArchives(ctx context.Context, conversationID string) ([]*ArchiveStub, error)
+	RestoreArchive(ctx context.Context, archiveID string) (*MessageArchive, error)
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ConversationSummary 会话最近消息的摘要
+type ConversationSummary struct {
+	ConversationID string    `json:"conversation_id"`
+	Summary        string    `json:"summary"`
+	MessageCount   int       `json:"message_count"` // 参与摘要的消息数
+	LastMessageID  string    `json:"last_message_id,omitempty"`
+	Provider       string    `json:"provider"`
+	Model          string    `json:"model,omitempty"`
+	Cached         bool      `json:"cached"`
+	GeneratedAt    time.Time `json:"generated_at"`
+}
+
+// SmartReplies 针对会话最新消息的建议回复
+type SmartReplies struct {
+	ConversationID string    `json:"conversation_id"`
+	MessageID      string    `json:"message_id,omitempty"` // 建议回复所针对的消息
+	Suggestions    []string  `json:"suggestions"`
+	Provider       string    `json:"provider"`
+	Model          string    `json:"model,omitempty"`
+	Cached         bool      `json:"cached"`
+	GeneratedAt    time.Time `json:"generated_at"`
+}
+
+// AssistantService 基于大模型的会话辅助功能接口
+type AssistantService interface {
+	SummarizeConversation(ctx context.Context, userID, conversationID string, limit int) (*ConversationSummary, error)
+	SuggestReplies(ctx context.Context, userID, conversationID string) (*SmartReplies, error)
+}
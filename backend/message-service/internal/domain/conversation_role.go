@@ -0,0 +1,37 @@
+package domain
+
+// ConversationTypeGroup 群聊会话，只有群聊会话区分管理员和普通成员
+const ConversationTypeGroup = "group"
+
+// ParticipantRole 参与者在群聊会话中的角色，与群组服务的成员角色相互独立
+type ParticipantRole string
+
+const (
+	ParticipantRoleAdmin  ParticipantRole = "admin"
+	ParticipantRoleMember ParticipantRole = "member"
+)
+
+// IsAdmin 判断用户是否是会话的管理员
+func (c *Conversation) IsAdmin(userID string) bool {
+	for _, admin := range c.Admins {
+		if admin == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// RenameConversationRequest 修改会话名称请求
+type RenameConversationRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// AddParticipantsRequest 添加会话参与者请求
+type AddParticipantsRequest struct {
+	UserIDs []string `json:"user_ids" validate:"required,min=1"`
+}
+
+// SetParticipantRoleRequest 设置参与者角色请求
+type SetParticipantRoleRequest struct {
+	Role ParticipantRole `json:"role" validate:"required,oneof=admin member"`
+}
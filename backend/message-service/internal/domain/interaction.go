@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/pkg/jobs"
+)
+
+// Reaction 用户对消息的表情回应
+type Reaction struct {
+	MessageID string    `json:"message_id"`
+	UserID    string    `json:"user_id"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReadReceipt 消息已读回执
+type ReadReceipt struct {
+	MessageID string    `json:"message_id"`
+	UserID    string    `json:"user_id"`
+	ReadAt    time.Time `json:"read_at"`
+}
+
+// MessageAggregates 消息的回应和已读统计，写入回应/回执时同步维护，读取消息列表时直接返回
+type MessageAggregates struct {
+	Reactions map[string]int `json:"reactions,omitempty"` // 表情 -> 回应人数
+	ReadBy    []string       `json:"read_by,omitempty"`   // 按已读时间排序
+	ReadCount int            `json:"read_count"`
+}
+
+// InteractionRepository 消息回应、已读回执及其统计仓库接口
+// 写入明细时在同一操作中更新统计，统计与明细不一致时由ReconcileAggregates按明细重建
+type InteractionRepository interface {
+	// AddReaction 添加回应，已存在时返回false
+	AddReaction(ctx context.Context, reaction *Reaction) (bool, error)
+	// RemoveReaction 删除回应，不存在时返回false
+	RemoveReaction(ctx context.Context, messageID, userID, emoji string) (bool, error)
+	ListReactions(ctx context.Context, messageID string) ([]*Reaction, error)
+	// MarkRead 记录已读回执，已读过时返回false
+	MarkRead(ctx context.Context, receipt *ReadReceipt) (bool, error)
+	ListReadReceipts(ctx context.Context, messageID string) ([]*ReadReceipt, error)
+	// GetAggregates 批量获取统计，没有任何回应和回执的消息不在结果中
+	GetAggregates(ctx context.Context, messageIDs []string) (map[string]*MessageAggregates, error)
+	// ListActiveMessages 列出since之后有回应、回执或统计变更的消息
+	ListActiveMessages(ctx context.Context, since time.Time, limit int) ([]string, error)
+	// ReconcileAggregates 按明细重建统计，返回被修正的消息数
+	ReconcileAggregates(ctx context.Context, messageIDs []string) (int, error)
+}
+
+// InteractionService 消息回应、送达和已读回执服务接口
+type InteractionService interface {
+	DeliveryRecorder
+	ScheduleJobs(runner *jobs.Runner) error
+	AddReaction(ctx context.Context, userID, messageID, emoji string) (*MessageAggregates, error)
+	RemoveReaction(ctx context.Context, userID, messageID, emoji string) (*MessageAggregates, error)
+	GetReactions(ctx context.Context, userID, messageID string) ([]*Reaction, error)
+	MarkRead(ctx context.Context, userID, messageID string) (*MessageAggregates, error)
+	GetReceipts(ctx context.Context, userID, messageID string) ([]*MessageReceipt, error)
+	// MarkConversationRead 推进用户在会话中的已读位置，messageID为空时推进到最新消息
+	MarkConversationRead(ctx context.Context, userID, conversationID, messageID string) (*ReadCursor, error)
+	GetReadCursors(ctx context.Context, userID, conversationID string) ([]*ReadCursor, error)
+	ReconcileAggregates(ctx context.Context) (int, error)
+}
@@ -2,7 +2,10 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
+
+	"github.com/neohope/chatapp/message-service/pkg/jobs"
 )
 
 // MessageType 消息类型枚举
@@ -40,13 +43,93 @@ type Message struct {
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	IsGroupChat  bool           `json:"is_group_chat"`
+	// EditedAt 最近一次编辑时间，未编辑过为空
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+	// DeletedAt 撤回时间，撤回后内容和元数据被清空，只保留占位记录
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// TTLSeconds 消息自身的保留时间，阅后即焚消息从第一次被接收者已读开始计算
+	TTLSeconds int `json:"ttl_seconds,omitempty"`
+	// ViewOnce 阅后即焚
+	ViewOnce bool `json:"view_once,omitempty"`
+	// ExpiresAt 自毁时间，到期后由清理任务撤回；阅后即焚消息在被已读前为空
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// ReplyToMessageID 被回复（引用）的消息
+	ReplyToMessageID string `json:"reply_to_message_id,omitempty"`
+	// ThreadRootID 所在话题的根消息，回复的回复也归入同一话题
+	ThreadRootID string `json:"thread_root_id,omitempty"`
+	// ReplyCount 以该消息为根的话题中未撤回的回复数，仅在读取消息时附带
+	ReplyCount int `json:"reply_count,omitempty"`
+	// Aggregates 回应和已读统计，仅在读取消息时附带
+	Aggregates *MessageAggregates `json:"aggregates,omitempty"`
+}
+
+// ErrMessageDeleted 消息已撤回，不能再编辑或撤回
+var ErrMessageDeleted = errors.New("message has been deleted")
+
+// Expired 消息是否已到自毁时间
+func (m *Message) Expired(now time.Time) bool {
+	return m.ExpiresAt != nil && !now.Before(*m.ExpiresAt)
+}
+
+// MessageEdit 消息编辑历史，每次编辑保存编辑前的内容
+type MessageEdit struct {
+	ID              string    `json:"id"`
+	MessageID       string    `json:"message_id"`
+	EditorID        string    `json:"editor_id"`
+	PreviousContent string    `json:"previous_content"`
+	EditedAt        time.Time `json:"edited_at"`
+}
+
+// MessageEventType 推送给会话参与者的消息变更事件类型
+type MessageEventType string
+
+const (
+	MessageEventEdited   MessageEventType = "message.edited"
+	MessageEventDeleted  MessageEventType = "message.deleted"
+	MessageEventPinned   MessageEventType = "message.pinned"
+	MessageEventUnpinned MessageEventType = "message.unpinned"
+)
+
+// MetadataMediaID 消息元数据中引用媒体服务文件ID的键
+const MetadataMediaID = "media_id"
+
+// MetadataTranscript 消息元数据中保存语音转写文本的键
+const MetadataTranscript = "transcript"
+
+// MediaTranscript 语音消息的转写文本，由媒体服务转写完成后推送
+type MediaTranscript struct {
+	Text          string    `json:"text"`
+	Language      string    `json:"language,omitempty"`
+	Duration      float64   `json:"duration,omitempty"` // 音频时长（秒）
+	Model         string    `json:"model,omitempty"`
+	TranscribedAt time.Time `json:"transcribed_at"`
+}
+
+// ToMetadata 转换为写入消息元数据的键值，各存储实现使用相同的字段名
+func (t *MediaTranscript) ToMetadata() map[string]any {
+	value := map[string]any{
+		"text":           t.Text,
+		"transcribed_at": t.TranscribedAt,
+	}
+	if t.Language != "" {
+		value["language"] = t.Language
+	}
+	if t.Duration > 0 {
+		value["duration"] = t.Duration
+	}
+	if t.Model != "" {
+		value["model"] = t.Model
+	}
+	return value
 }
 
 // Conversation 会话实体
 type Conversation struct {
 	ID           string    `json:"id"`
 	Type         string    `json:"type"` // "private" 或 "group"
+	Name         string    `json:"name,omitempty"`
 	Participants []string  `json:"participants"`
+	Admins       []string  `json:"admins,omitempty"` // 群聊会话的管理员，私聊会话为空
 	LastMessage  *Message  `json:"last_message,omitempty"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
@@ -62,6 +145,39 @@ type MessageRepository interface {
 	CreateConversation(ctx context.Context, conversation *Conversation) error
 	GetConversation(ctx context.Context, id string) (*Conversation, error)
 	UpdateConversationLastMessage(ctx context.Context, conversationID string, message *Message) error
+	// SetMediaTranscript 把转写文本写入引用该媒体文件的语音消息，返回更新的消息数
+	SetMediaTranscript(ctx context.Context, mediaID string, transcript *MediaTranscript) (int, error)
+	// EditContent 修改消息内容并写入编辑历史，edit.PreviousContent由仓库填充；消息已撤回时返回ErrMessageDeleted
+	EditContent(ctx context.Context, edit *MessageEdit, content string) error
+	// SoftDelete 撤回消息，清空内容和元数据并记录撤回时间；消息已撤回时返回ErrMessageDeleted
+	SoftDelete(ctx context.Context, id string, deletedAt time.Time) error
+	// ListEdits 按编辑时间顺序列出消息的编辑历史
+	ListEdits(ctx context.Context, messageID string) ([]*MessageEdit, error)
+	// SetExpiry 设置消息的自毁时间，已有更早的自毁时间或消息已撤回时不修改
+	SetExpiry(ctx context.Context, id string, expiresAt time.Time) error
+	// ListExpired 按自毁时间顺序列出到期但尚未撤回的消息
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*Message, error)
+	// ListThread 按创建时间顺序分页列出话题中的消息，根消息排在最前
+	ListThread(ctx context.Context, rootID string, limit, offset int) ([]*Message, error)
+	// CountReplies 批量统计以这些消息为根的话题中未撤回的回复数，没有回复的消息不在结果中
+	CountReplies(ctx context.Context, messageIDs []string) (map[string]int, error)
+	// GetMessagesAround 按创建时间顺序返回会话中早于at的最后before条消息，以及不早于at的前after条消息
+	GetMessagesAround(ctx context.Context, conversationID string, at time.Time, before, after int) ([]*Message, error)
+	// RenameConversation 修改会话名称
+	RenameConversation(ctx context.Context, id, name string) error
+	// AddParticipants 以普通成员身份添加参与者，已在会话中的用户忽略
+	AddParticipants(ctx context.Context, id string, userIDs []string) error
+	// RemoveParticipant 移除参与者及其角色
+	RemoveParticipant(ctx context.Context, id, userID string) error
+	// SetParticipantRole 设置参与者在会话中的角色
+	SetParticipantRole(ctx context.Context, id, userID string, role ParticipantRole) error
+}
+
+// MessageDispatcher 把已保存的消息实时推送给在线接收者，实现方不得阻塞调用方
+type MessageDispatcher interface {
+	Dispatch(message *Message, recipients []string) error
+	// DispatchEvent 推送消息的编辑、撤回等变更事件
+	DispatchEvent(event MessageEventType, message *Message, recipients []string) error
 }
 
 // MessageService 消息服务接口
@@ -73,19 +189,57 @@ type MessageService interface {
 	GetUserConversations(ctx context.Context, userID string, limit, offset int) ([]*Conversation, error)
 	CreateConversation(ctx context.Context, conversation *Conversation) error
 	GetConversation(ctx context.Context, id string) (*Conversation, error)
+	AttachTranscript(ctx context.Context, mediaID string, transcript *MediaTranscript) (int, error)
+	// EditMessage 发送者修改文本消息内容
+	EditMessage(ctx context.Context, userID, id, content string) (*Message, error)
+	// DeleteMessage 发送者撤回消息，群聊会话的管理员可以撤回其他人的消息
+	DeleteMessage(ctx context.Context, userID, id string) (*Message, error)
+	// GetMessageEdits 会话参与者查看消息的编辑历史
+	GetMessageEdits(ctx context.Context, userID, id string) ([]*MessageEdit, error)
+	// GetThread 会话参与者查看消息所在的话题
+	GetThread(ctx context.Context, userID, id string, limit, offset int) ([]*Message, error)
+	// GetMessagesAround 会话参与者获取目标消息（messageID非空时）或时间点前后各limit条消息
+	GetMessagesAround(ctx context.Context, userID, conversationID, messageID string, at time.Time, limit int) (*MessageWindow, error)
+	// ExpireMessages 撤回到期的自毁消息，返回撤回的数量
+	ExpireMessages(ctx context.Context) (int, error)
+	// ScheduleJobs 注册定期清理到期自毁消息的后台任务
+	ScheduleJobs(runner *jobs.Runner) error
+	// RenameConversation 群聊会话的管理员修改会话名称
+	RenameConversation(ctx context.Context, userID, id, name string) (*Conversation, error)
+	// AddParticipants 群聊会话的管理员添加参与者
+	AddParticipants(ctx context.Context, userID, id string, userIDs []string) (*Conversation, error)
+	// RemoveParticipant 群聊会话的管理员移除参与者，参与者也可以移除自己退出会话
+	RemoveParticipant(ctx context.Context, userID, id, participantID string) (*Conversation, error)
+	// SetParticipantRole 群聊会话的管理员设置参与者的角色
+	SetParticipantRole(ctx context.Context, userID, id, participantID string, role ParticipantRole) (*Conversation, error)
+	// SyncAddParticipants 群组服务同步频道成员时添加参与者
+	SyncAddParticipants(ctx context.Context, id string, userIDs []string) (*Conversation, error)
+	// SyncRemoveParticipant 群组服务同步频道成员时移除参与者
+	SyncRemoveParticipant(ctx context.Context, id, participantID string) (*Conversation, error)
 }
 
 // SendMessageRequest 发送消息请求
 type SendMessageRequest struct {
-	ConversationID string         `json:"conversation_id"`
-	Type           MessageType    `json:"type" validate:"required"`
+	ConversationID string         `json:"conversation_id" validate:"required"`
+	Type           MessageType    `json:"type" validate:"required,oneof=text image video audio file location system"`
 	Content        string         `json:"content" validate:"required"`
 	Metadata       map[string]any `json:"metadata,omitempty"`
 	IsGroupChat    bool           `json:"is_group_chat"`
+	// TTLSeconds 消息的保留时间，为0时不自毁；阅后即焚消息为0时使用默认的查看时间
+	TTLSeconds int  `json:"ttl_seconds,omitempty" validate:"min=0"`
+	ViewOnce   bool `json:"view_once,omitempty"`
+	// ReplyTo 回复同一会话中的消息，新消息归入该消息所在的话题
+	ReplyTo string `json:"reply_to,omitempty"`
+}
+
+// EditMessageRequest 编辑消息请求
+type EditMessageRequest struct {
+	Content string `json:"content" validate:"required"`
 }
 
 // CreateConversationRequest 创建会话请求
 type CreateConversationRequest struct {
 	Type         string   `json:"type" validate:"required,oneof=private group"`
+	Name         string   `json:"name,omitempty"`
 	Participants []string `json:"participants" validate:"required,min=1"`
 }
\ No newline at end of file
@@ -0,0 +1,11 @@
+package domain
+
+// MessageWindow 目标消息或时间点前后的一段消息，按创建时间顺序排列
+// 客户端从搜索结果或置顶消息跳转时用它定位，不需要从最新消息开始翻页
+type MessageWindow struct {
+	Messages []*Message `json:"messages"`
+	// AnchorID 定位到的消息，按时间定位时为该时间及之后的第一条消息，之后没有消息时为空
+	AnchorID      string `json:"anchor_id,omitempty"`
+	HasMoreBefore bool   `json:"has_more_before"`
+	HasMoreAfter  bool   `json:"has_more_after"`
+}
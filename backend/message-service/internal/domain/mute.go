@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// 免打扰时长，off表示取消免打扰
+const (
+	MuteDurationOneHour    = "1h"
+	MuteDurationEightHours = "8h"
+	MuteDurationForever    = "forever"
+	MuteDurationOff        = "off"
+)
+
+// ConversationMute 用户对会话的免打扰设置，MutedUntil为nil表示永久免打扰
+type ConversationMute struct {
+	ConversationID string     `json:"conversation_id"`
+	UserID         string     `json:"user_id"`
+	Muted          bool       `json:"muted"`
+	MutedUntil     *time.Time `json:"muted_until,omitempty"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Active 判断免打扰在now时是否仍然有效
+func (m *ConversationMute) Active(now time.Time) bool {
+	return m.Muted && (m.MutedUntil == nil || now.Before(*m.MutedUntil))
+}
+
+// MuteConversationRequest 设置会话免打扰的请求
+type MuteConversationRequest struct {
+	Duration string `json:"duration"` // 1h、8h、forever或off
+}
+
+// MuteRepository 会话免打扰设置仓库接口
+type MuteRepository interface {
+	// SetMute 保存用户对会话的免打扰设置，已有设置时覆盖
+	SetMute(ctx context.Context, mute *ConversationMute) error
+	// DeleteMute 取消用户对会话的免打扰
+	DeleteMute(ctx context.Context, conversationID, userID string) error
+	// ListActiveMutes 列出会话中在now时仍然有效的免打扰设置
+	ListActiveMutes(ctx context.Context, conversationID string, now time.Time) ([]*ConversationMute, error)
+}
+
+// MuteService 会话免打扰服务接口
+type MuteService interface {
+	MuteConversation(ctx context.Context, userID, conversationID string, req *MuteConversationRequest) (*ConversationMute, error)
+	// ListActiveMutes 供通知服务在推送前查询，不校验调用者
+	ListActiveMutes(ctx context.Context, conversationID string) ([]*ConversationMute, error)
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrGroupNotFound 群组服务中不存在该群组或群组频道，即不由群组服务管理的多人会话
+var ErrGroupNotFound = errors.New("group not found")
+
+// MessagePin 会话中被置顶的消息
+type MessagePin struct {
+	ConversationID string    `json:"conversation_id"`
+	MessageID      string    `json:"message_id"`
+	PinnedBy       string    `json:"pinned_by"`
+	PinnedAt       time.Time `json:"pinned_at"`
+	// Message 置顶的消息内容，仅在列出置顶消息时附带
+	Message *Message `json:"message,omitempty"`
+}
+
+// PinRepository 置顶消息仓库接口
+type PinRepository interface {
+	// Pin 置顶消息，已置顶时返回false
+	Pin(ctx context.Context, pin *MessagePin) (bool, error)
+	// Unpin 取消置顶，未置顶时返回false
+	Unpin(ctx context.Context, conversationID, messageID string) (bool, error)
+	// ListPins 按置顶时间倒序列出会话中的置顶消息
+	ListPins(ctx context.Context, conversationID string) ([]*MessagePin, error)
+}
+
+// GroupPermissionChecker 查询用户在群组中的权限，群组角色由群组服务维护
+// groupID可以是群组ID，也可以是群组频道对应的会话ID
+type GroupPermissionChecker interface {
+	// CanPinMessages 用户是否可以在群组中置顶消息，群组不存在时返回ErrGroupNotFound
+	CanPinMessages(ctx context.Context, groupID, userID string) (bool, error)
+	// CanSendMessages 用户是否可以在群组中发言，群组不存在时返回ErrGroupNotFound
+	CanSendMessages(ctx context.Context, groupID, userID string) (bool, error)
+}
+
+// PinService 置顶消息服务接口
+type PinService interface {
+	PinMessage(ctx context.Context, userID, messageID string) (*MessagePin, error)
+	UnpinMessage(ctx context.Context, userID, messageID string) error
+	ListPins(ctx context.Context, userID, conversationID string) ([]*MessagePin, error)
+}
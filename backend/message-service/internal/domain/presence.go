@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PresenceStatus 用户在线状态
+type PresenceStatus string
+
+const (
+	PresenceOnline  PresenceStatus = "online"
+	PresenceOffline PresenceStatus = "offline"
+)
+
+// Presence 用户在线状态，离线时LastSeen为最后一个连接断开（或最后一次心跳）的时间
+type Presence struct {
+	UserID   string         `json:"user_id"`
+	Status   PresenceStatus `json:"status"`
+	LastSeen *time.Time     `json:"last_seen,omitempty"`
+}
+
+// PresenceEvent 在线状态变化事件，用户的第一个连接建立时上线，最后一个连接断开时下线
+type PresenceEvent struct {
+	UserID   string         `json:"user_id"`
+	Status   PresenceStatus `json:"status"`
+	LastSeen *time.Time     `json:"last_seen,omitempty"`
+	At       time.Time      `json:"at"`
+}
+
+// PresenceRepository 在线状态仓库接口，按连接记录心跳，心跳超时的连接视为已断开
+type PresenceRepository interface {
+	// Connect 记录连接，返回用户此前是否没有存活的连接
+	Connect(ctx context.Context, userID, connectionID string, at time.Time) (bool, error)
+	// Disconnect 删除连接并记录最后在线时间，返回用户是否已没有存活的连接
+	Disconnect(ctx context.Context, userID, connectionID string, at time.Time) (bool, error)
+	// Heartbeat 刷新用户连接的心跳时间
+	Heartbeat(ctx context.Context, userID string, connectionIDs []string, at time.Time) error
+	// GetPresence 批量查询在线状态，结果顺序与userIDs一致
+	GetPresence(ctx context.Context, userIDs []string, now time.Time) ([]*Presence, error)
+}
+
+// PresenceService 在线状态查询接口
+type PresenceService interface {
+	GetPresence(ctx context.Context, userID string) (*Presence, error)
+	QueryPresence(ctx context.Context, userIDs []string) ([]*Presence, error)
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ReceiptStatus 接收者的消息回执状态
+type ReceiptStatus string
+
+const (
+	ReceiptStatusDelivered ReceiptStatus = "delivered"
+	ReceiptStatusRead      ReceiptStatus = "read"
+)
+
+// MessageReceipt 单个接收者对一条消息的送达和已读状态
+type MessageReceipt struct {
+	MessageID   string        `json:"message_id"`
+	UserID      string        `json:"user_id"`
+	Status      ReceiptStatus `json:"status"`
+	DeliveredAt *time.Time    `json:"delivered_at,omitempty"`
+	ReadAt      *time.Time    `json:"read_at,omitempty"`
+}
+
+// ReadCursor 用户在会话中的已读位置，只向前推进
+type ReadCursor struct {
+	ConversationID   string    `json:"conversation_id"`
+	UserID           string    `json:"user_id"`
+	MessageID        string    `json:"message_id"`
+	MessageCreatedAt time.Time `json:"message_created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ReceiptUpdate 推送给消息发送者的回执变更：UserIDs中的接收者送达或已读了MessageIDs中的消息
+type ReceiptUpdate struct {
+	ConversationID string        `json:"conversation_id"`
+	Status         ReceiptStatus `json:"status"`
+	MessageIDs     []string      `json:"message_ids"`
+	UserIDs        []string      `json:"user_ids"`
+	At             time.Time     `json:"at"`
+}
+
+// ReceiptRepository 消息送达、已读回执和会话已读位置仓库接口
+type ReceiptRepository interface {
+	// MarkDelivered 记录消息已送达给userIDs，返回此前未送达的用户
+	MarkDelivered(ctx context.Context, messageID string, userIDs []string, at time.Time) ([]string, error)
+	// MarkRead 记录用户已读messageIDs，未送达的同时记为送达，返回此前未读的消息
+	MarkRead(ctx context.Context, userID string, messageIDs []string, at time.Time) ([]string, error)
+	// ListReceipts 获取消息的全部接收者回执
+	ListReceipts(ctx context.Context, messageID string) ([]*MessageReceipt, error)
+	// GetReadCursor 获取用户在会话中的已读位置，没有时返回nil
+	GetReadCursor(ctx context.Context, conversationID, userID string) (*ReadCursor, error)
+	// AdvanceReadCursor 保存已读位置，仅当新位置晚于已保存的位置时更新，返回是否更新
+	AdvanceReadCursor(ctx context.Context, cursor *ReadCursor) (bool, error)
+	// ListReadCursors 获取会话所有参与者的已读位置
+	ListReadCursors(ctx context.Context, conversationID string) ([]*ReadCursor, error)
+}
+
+// ReceiptNotifier 把回执变更实时推送给消息发送者，实现方不得阻塞调用方
+type ReceiptNotifier interface {
+	NotifyReceipt(senderID string, update *ReceiptUpdate) error
+}
+
+// DeliveryRecorder 消息实时推送成功后记录送达
+type DeliveryRecorder interface {
+	RecordDelivered(ctx context.Context, message *Message, userIDs []string) error
+}
+
+// NewMessageReceipt 创建回执，有已读时间时状态为已读
+func NewMessageReceipt(messageID, userID string, deliveredAt, readAt *time.Time) *MessageReceipt {
+	status := ReceiptStatusDelivered
+	if readAt != nil {
+		status = ReceiptStatusRead
+	}
+	return &MessageReceipt{
+		MessageID:   messageID,
+		UserID:      userID,
+		Status:      status,
+		DeliveredAt: deliveredAt,
+		ReadAt:      readAt,
+	}
+}
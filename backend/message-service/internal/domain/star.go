@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// StarredMessage 用户收藏的消息，收藏只对收藏者本人可见
+type StarredMessage struct {
+	UserID         string    `json:"user_id"`
+	MessageID      string    `json:"message_id"`
+	ConversationID string    `json:"conversation_id"`
+	StarredAt      time.Time `json:"starred_at"`
+	// Message 收藏的消息内容，仅在列出收藏时附带
+	Message *Message `json:"message,omitempty"`
+}
+
+// StarRepository 收藏消息仓库接口
+type StarRepository interface {
+	// Star 收藏消息，已收藏时不修改收藏时间
+	Star(ctx context.Context, star *StarredMessage) error
+	// Unstar 取消收藏，未收藏时不做任何操作
+	Unstar(ctx context.Context, userID, messageID string) error
+	// ListStars 按收藏时间倒序分页列出用户收藏的消息
+	ListStars(ctx context.Context, userID string, limit, offset int) ([]*StarredMessage, error)
+}
+
+// StarService 收藏消息服务接口
+type StarService interface {
+	StarMessage(ctx context.Context, userID, messageID string) error
+	UnstarMessage(ctx context.Context, userID, messageID string) error
+	ListStarred(ctx context.Context, userID string, limit, offset int) ([]*StarredMessage, error)
+}
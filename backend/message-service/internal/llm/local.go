@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// LocalProvider 本地模型服务（Ollama /api/chat 接口）
+type LocalProvider struct {
+	baseURL   string
+	model     string
+	maxTokens int
+	client    *http.Client
+}
+
+type localRequest struct {
+	Model    string       `json:"model"`
+	Messages []Message    `json:"messages"`
+	Stream   bool         `json:"stream"`
+	Options  localOptions `json:"options"`
+}
+
+type localOptions struct {
+	NumPredict  int     `json:"num_predict,omitempty"`
+	Temperature float64 `json:"temperature"`
+}
+
+type localResponse struct {
+	Message Message `json:"message"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// NewLocalProvider 创建本地模型提供方，baseURL形如 http://localhost:11434
+func NewLocalProvider(baseURL, model string, maxTokens int, client *http.Client) *LocalProvider {
+	return &LocalProvider{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		model:     model,
+		maxTokens: maxTokens,
+		client:    client,
+	}
+}
+
+// Name 提供方名称
+func (p *LocalProvider) Name() string {
+	return "local"
+}
+
+// Model 模型名称
+func (p *LocalProvider) Model() string {
+	return p.model
+}
+
+// Complete 以非流式方式调用本地模型
+func (p *LocalProvider) Complete(ctx context.Context, req *CompletionRequest) (string, error) {
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = p.maxTokens
+	}
+
+	body, err := json.Marshal(localRequest{
+		Model:    p.model,
+		Messages: req.Messages,
+		Options: localOptions{
+			NumPredict:  maxTokens,
+			Temperature: req.Temperature,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal completion request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create completion request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("completion request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read completion response: %w", err)
+	}
+
+	var result localResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("invalid completion response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != "" {
+			return "", fmt.Errorf("completion request failed with status %d: %s", resp.StatusCode, result.Error)
+		}
+		return "", fmt.Errorf("completion request failed with status %d", resp.StatusCode)
+	}
+
+	return strings.TrimSpace(result.Message.Content), nil
+}
@@ -0,0 +1,26 @@
+package llm
+
+import "context"
+
+// NoopProvider 未接入模型时使用，所有请求返回ErrDisabled
+type NoopProvider struct{}
+
+// NewNoopProvider 创建一个空的大模型提供方
+func NewNoopProvider() *NoopProvider {
+	return &NoopProvider{}
+}
+
+// Name 提供方名称
+func (p *NoopProvider) Name() string {
+	return "noop"
+}
+
+// Model 模型名称
+func (p *NoopProvider) Model() string {
+	return ""
+}
+
+// Complete 始终返回ErrDisabled
+func (p *NoopProvider) Complete(ctx context.Context, req *CompletionRequest) (string, error) {
+	return "", ErrDisabled
+}
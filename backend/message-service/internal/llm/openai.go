@@ -0,0 +1,111 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OpenAIProvider OpenAI兼容的 /chat/completions 接口，也适用于vLLM、LM Studio等兼容服务
+type OpenAIProvider struct {
+	baseURL   string
+	apiKey    string
+	model     string
+	maxTokens int
+	client    *http.Client
+}
+
+type openAIRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Temperature float64   `json:"temperature"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// NewOpenAIProvider 创建OpenAI兼容的提供方，baseURL形如 https://api.openai.com/v1
+func NewOpenAIProvider(baseURL, apiKey, model string, maxTokens int, client *http.Client) *OpenAIProvider {
+	return &OpenAIProvider{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		apiKey:    apiKey,
+		model:     model,
+		maxTokens: maxTokens,
+		client:    client,
+	}
+}
+
+// Name 提供方名称
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+// Model 模型名称
+func (p *OpenAIProvider) Model() string {
+	return p.model
+}
+
+// Complete 调用对话补全接口，返回第一条候选回复
+func (p *OpenAIProvider) Complete(ctx context.Context, req *CompletionRequest) (string, error) {
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = p.maxTokens
+	}
+
+	body, err := json.Marshal(openAIRequest{
+		Model:       p.model,
+		Messages:    req.Messages,
+		MaxTokens:   maxTokens,
+		Temperature: req.Temperature,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal completion request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create completion request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("completion request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read completion response: %w", err)
+	}
+
+	var result openAIResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("invalid completion response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != nil {
+			return "", fmt.Errorf("completion request failed with status %d: %s", resp.StatusCode, result.Error.Message)
+		}
+		return "", fmt.Errorf("completion request failed with status %d", resp.StatusCode)
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("completion response has no choices")
+	}
+
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/config"
+)
+
+// ErrDisabled 未配置大模型提供方
+var ErrDisabled = errors.New("llm provider is not configured")
+
+// 对话角色
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message 对话中的一条消息
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// CompletionRequest 对话补全请求
+type CompletionRequest struct {
+	Messages    []Message
+	MaxTokens   int
+	Temperature float64
+}
+
+// Provider 大模型提供方，实现方需保证并发安全
+type Provider interface {
+	Name() string
+	Model() string
+	Complete(ctx context.Context, req *CompletionRequest) (string, error)
+}
+
+// NewProvider 根据配置创建大模型提供方
+func NewProvider(cfg config.LLMConfig) (Provider, error) {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	switch cfg.Provider {
+	case "", config.LLMProviderNoop:
+		return NewNoopProvider(), nil
+	case config.LLMProviderOpenAI:
+		if cfg.BaseURL == "" || cfg.Model == "" {
+			return nil, fmt.Errorf("openai provider requires LLM_BASE_URL and LLM_MODEL")
+		}
+		return NewOpenAIProvider(cfg.BaseURL, cfg.APIKey, cfg.Model, cfg.MaxTokens, client), nil
+	case config.LLMProviderLocal:
+		if cfg.BaseURL == "" || cfg.Model == "" {
+			return nil, fmt.Errorf("local provider requires LLM_BASE_URL and LLM_MODEL")
+		}
+		return NewLocalProvider(cfg.BaseURL, cfg.Model, cfg.MaxTokens, client), nil
+	default:
+		return nil, fmt.Errorf("unsupported llm provider: %s", cfg.Provider)
+	}
+}
@@ -0,0 +1,465 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// restoreBatchSize 恢复归档时每批写入的消息数
+const restoreBatchSize = 500
+
+// ArchiveRepository 消息分区与归档元数据仓库实现
+type ArchiveRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewArchiveRepository 创建一个新的归档仓库
+func NewArchiveRepository(db *sqlx.DB, logger *zap.Logger) domain.ArchiveRepository {
+	return &ArchiveRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// archiveRow 归档元数据行
+type archiveRow struct {
+	ID            string       `db:"id"`
+	PartitionName string       `db:"partition_name"`
+	PeriodStart   time.Time    `db:"period_start"`
+	PeriodEnd     time.Time    `db:"period_end"`
+	Bucket        string       `db:"bucket"`
+	ObjectKey     string       `db:"object_key"`
+	Format        string       `db:"format"`
+	MessageCount  int64        `db:"message_count"`
+	SizeBytes     int64        `db:"size_bytes"`
+	Status        string       `db:"status"`
+	ArchivedAt    time.Time    `db:"archived_at"`
+	RestoredAt    sql.NullTime `db:"restored_at"`
+}
+
+func (a *archiveRow) toDomain() *domain.MessageArchive {
+	archive := &domain.MessageArchive{
+		ID:            a.ID,
+		PartitionName: a.PartitionName,
+		PeriodStart:   a.PeriodStart.UTC(),
+		PeriodEnd:     a.PeriodEnd.UTC(),
+		Bucket:        a.Bucket,
+		ObjectKey:     a.ObjectKey,
+		Format:        a.Format,
+		MessageCount:  a.MessageCount,
+		SizeBytes:     a.SizeBytes,
+		Status:        domain.ArchiveStatus(a.Status),
+		ArchivedAt:    a.ArchivedAt.UTC(),
+	}
+	if a.RestoredAt.Valid {
+		restoredAt := a.RestoredAt.Time.UTC()
+		archive.RestoredAt = &restoredAt
+	}
+	return archive
+}
+
+const archiveColumns = `id, partition_name, period_start, period_end, bucket, object_key, format, message_count, size_bytes, status, archived_at, restored_at`
+
+// EnsurePartition 创建month所在月份的分区
+func (r *ArchiveRepository) EnsurePartition(ctx context.Context, month time.Time) error {
+	return ensureMonthlyPartition(ctx, r.db, month)
+}
+
+// ListPartitions 获取消息表的全部月分区，按时间升序
+func (r *ArchiveRepository) ListPartitions(ctx context.Context) ([]*domain.MessagePartition, error) {
+	query := `
+	SELECT c.relname
+	FROM pg_inherits i
+	JOIN pg_class c ON c.oid = i.inhrelid
+	WHERE i.inhparent = 'messages'::regclass
+	`
+
+	var names []string
+	if err := r.db.SelectContext(ctx, &names, query); err != nil {
+		return nil, fmt.Errorf("failed to list message partitions: %w", err)
+	}
+
+	partitions := make([]*domain.MessagePartition, 0, len(names))
+	for _, name := range names {
+		month, err := time.Parse(partitionNameLayout, name)
+		if err != nil {
+			// 非本服务创建的分区不参与归档
+			r.logger.Warn("Skipping unrecognized message partition", zap.String("partition", name))
+			continue
+		}
+		start, end := monthBounds(month)
+		partitions = append(partitions, &domain.MessagePartition{
+			Name:        name,
+			PeriodStart: start,
+			PeriodEnd:   end,
+		})
+	}
+
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].PeriodStart.Before(partitions[j].PeriodStart)
+	})
+	return partitions, nil
+}
+
+// ExportPartition 在可重复读的只读事务中统计占位信息、记录快照摘要并导出消息，三者来自同一快照
+func (r *ArchiveRepository) ExportPartition(ctx context.Context, partition string, export func(stubs []*domain.ArchiveStub, read domain.PartitionReader) error) (*domain.PartitionSnapshot, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	snapshot, err := partitionSnapshot(ctx, tx, partition)
+	if err != nil {
+		return nil, err
+	}
+	stubs, err := r.partitionStubs(ctx, tx, partition)
+	if err != nil {
+		return nil, err
+	}
+
+	read := func(fn func(*domain.Message) error) error {
+		return r.readPartition(ctx, tx, partition, fn)
+	}
+	if err := export(stubs, read); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return snapshot, nil
+}
+
+// partitionSnapshot 统计分区的消息数和最后更新时间，消息的写入、编辑、撤回和状态变更都会更新updated_at
+func partitionSnapshot(ctx context.Context, q sqlx.QueryerContext, partition string) (*domain.PartitionSnapshot, error) {
+	query := fmt.Sprintf(`
+	SELECT COUNT(*) AS message_count, COALESCE(MAX(updated_at), 'epoch') AS last_updated_at
+	FROM %s
+	`, pq.QuoteIdentifier(partition))
+
+	var row struct {
+		MessageCount  int64     `db:"message_count"`
+		LastUpdatedAt time.Time `db:"last_updated_at"`
+	}
+	if err := sqlx.GetContext(ctx, q, &row, query); err != nil {
+		return nil, fmt.Errorf("failed to snapshot partition %s: %w", partition, err)
+	}
+	return &domain.PartitionSnapshot{
+		MessageCount:  row.MessageCount,
+		LastUpdatedAt: row.LastUpdatedAt.UTC(),
+	}, nil
+}
+
+// partitionStubs 按会话统计分区内的消息，作为归档后的占位信息
+func (r *ArchiveRepository) partitionStubs(ctx context.Context, q sqlx.QueryerContext, partition string) ([]*domain.ArchiveStub, error) {
+	query := fmt.Sprintf(`
+	SELECT conversation_id, COUNT(*) AS message_count, MIN(created_at) AS first_message_at, MAX(created_at) AS last_message_at
+	FROM %s
+	GROUP BY conversation_id
+	`, pq.QuoteIdentifier(partition))
+
+	var rows []struct {
+		ConversationID string    `db:"conversation_id"`
+		MessageCount   int64     `db:"message_count"`
+		FirstMessageAt time.Time `db:"first_message_at"`
+		LastMessageAt  time.Time `db:"last_message_at"`
+	}
+	if err := sqlx.SelectContext(ctx, q, &rows, query); err != nil {
+		return nil, fmt.Errorf("failed to summarize partition %s: %w", partition, err)
+	}
+
+	stubs := make([]*domain.ArchiveStub, 0, len(rows))
+	for _, row := range rows {
+		stubs = append(stubs, &domain.ArchiveStub{
+			ConversationID: row.ConversationID,
+			MessageCount:   row.MessageCount,
+			FirstMessageAt: row.FirstMessageAt.UTC(),
+			LastMessageAt:  row.LastMessageAt.UTC(),
+		})
+	}
+	return stubs, nil
+}
+
+// readPartition 按会话和时间顺序逐条读取分区内的消息
+func (r *ArchiveRepository) readPartition(ctx context.Context, q sqlx.QueryerContext, partition string, fn func(*domain.Message) error) error {
+	query := fmt.Sprintf(`
+	SELECT `+messageColumns+`
+	FROM %s
+	ORDER BY conversation_id, created_at
+	`, pq.QuoteIdentifier(partition))
+
+	rows, err := q.QueryxContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to read partition %s: %w", partition, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row messageRow
+		if scanErr := rows.StructScan(&row); scanErr != nil {
+			return fmt.Errorf("failed to scan message: %w", scanErr)
+		}
+
+		message := row.toDomain(r.logger)
+		message.CreatedAt = message.CreatedAt.UTC()
+		message.UpdatedAt = message.UpdatedAt.UTC()
+		if err := fn(message); err != nil {
+			return err
+		}
+	}
+
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return fmt.Errorf("error iterating over partition %s: %w", partition, rowsErr)
+	}
+	return nil
+}
+
+// FinalizeArchive 锁定消息表并确认分区与导出快照一致，然后写入归档元数据和会话占位信息，摘除并删除分区
+// 导出后分区有新的写入时放弃本次归档，分区保留到下次重新导出
+func (r *ArchiveRepository) FinalizeArchive(ctx context.Context, archive *domain.MessageArchive, stubs []*domain.ArchiveStub, snapshot *domain.PartitionSnapshot) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	// 摘除分区本身就需要消息表的排他锁，提前获取以免与写入交叉等待；拿不到锁时放弃，避免长时间阻塞其他请求
+	if _, err := tx.ExecContext(ctx, `SET LOCAL lock_timeout = '5s'`); err != nil {
+		return fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE messages IN ACCESS EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("failed to lock messages: %w", err)
+	}
+	current, err := partitionSnapshot(ctx, tx, archive.PartitionName)
+	if err != nil {
+		return err
+	}
+	if current.MessageCount != snapshot.MessageCount || !current.LastUpdatedAt.Equal(snapshot.LastUpdatedAt) {
+		return fmt.Errorf("partition %s changed during export", archive.PartitionName)
+	}
+
+	// 同一分区恢复后再次归档时覆盖原记录
+	query := `
+	INSERT INTO message_archives (` + archiveColumns + `)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULL)
+	ON CONFLICT (partition_name) DO UPDATE SET
+		bucket = EXCLUDED.bucket,
+		object_key = EXCLUDED.object_key,
+		format = EXCLUDED.format,
+		message_count = EXCLUDED.message_count,
+		size_bytes = EXCLUDED.size_bytes,
+		status = EXCLUDED.status,
+		archived_at = EXCLUDED.archived_at,
+		restored_at = NULL
+	RETURNING id
+	`
+	err = tx.GetContext(ctx, &archive.ID, query,
+		archive.ID,
+		archive.PartitionName,
+		archive.PeriodStart,
+		archive.PeriodEnd,
+		archive.Bucket,
+		archive.ObjectKey,
+		archive.Format,
+		archive.MessageCount,
+		archive.SizeBytes,
+		archive.Status,
+		archive.ArchivedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save archive: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM message_archive_stubs WHERE archive_id = $1`, archive.ID); err != nil {
+		return fmt.Errorf("failed to clear archive stubs: %w", err)
+	}
+	for _, stub := range stubs {
+		stub.ArchiveID = archive.ID
+		_, err := tx.ExecContext(ctx, `
+		INSERT INTO message_archive_stubs (archive_id, conversation_id, message_count, first_message_at, last_message_at)
+		VALUES ($1, $2, $3, $4, $5)
+		`, stub.ArchiveID, stub.ConversationID, stub.MessageCount, stub.FirstMessageAt, stub.LastMessageAt)
+		if err != nil {
+			return fmt.Errorf("failed to save archive stub: %w", err)
+		}
+	}
+
+	identifier := pq.QuoteIdentifier(archive.PartitionName)
+	if _, err := tx.ExecContext(ctx, `ALTER TABLE messages DETACH PARTITION `+identifier); err != nil {
+		return fmt.Errorf("failed to detach partition %s: %w", archive.PartitionName, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DROP TABLE `+identifier); err != nil {
+		return fmt.Errorf("failed to drop partition %s: %w", archive.PartitionName, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	// 分区已删除，之后写入该月份的消息需要重新创建分区
+	knownPartitions.Delete(archive.PartitionName)
+	return nil
+}
+
+// RestoreMessages 把归档中的消息写回分区，已存在的消息忽略
+func (r *ArchiveRepository) RestoreMessages(ctx context.Context, archive *domain.MessageArchive, messages []*domain.Message) error {
+	if err := ensureMonthlyPartition(ctx, r.db, archive.PeriodStart); err != nil {
+		return err
+	}
+
+	for start := 0; start < len(messages); start += restoreBatchSize {
+		end := start + restoreBatchSize
+		if end > len(messages) {
+			end = len(messages)
+		}
+		if err := r.insertBatch(ctx, messages[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertBatch 批量写入消息
+func (r *ArchiveRepository) insertBatch(ctx context.Context, messages []*domain.Message) error {
+	rows := make([]map[string]interface{}, 0, len(messages))
+	for _, message := range messages {
+		metadataJSON, err := json.Marshal(message.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		rows = append(rows, map[string]interface{}{
+			"id":                  message.ID,
+			"conversation_id":     message.Conversation,
+			"sender_id":           message.SenderID,
+			"type":                message.Type,
+			"content":             message.Content,
+			"metadata":            metadataJSON,
+			"status":              message.Status,
+			"created_at":          message.CreatedAt,
+			"updated_at":          message.UpdatedAt,
+			"is_group_chat":       message.IsGroupChat,
+			"edited_at":           message.EditedAt,
+			"deleted_at":          message.DeletedAt,
+			"ttl_seconds":         message.TTLSeconds,
+			"view_once":           message.ViewOnce,
+			"expires_at":          message.ExpiresAt,
+			"reply_to_message_id": nullableID(message.ReplyToMessageID),
+			"thread_root_id":      nullableID(message.ThreadRootID),
+		})
+	}
+
+	query := `
+	INSERT INTO messages (id, conversation_id, sender_id, type, content, metadata, status, created_at, updated_at, is_group_chat, edited_at, deleted_at,
+		ttl_seconds, view_once, expires_at, reply_to_message_id, thread_root_id)
+	VALUES (:id, :conversation_id, :sender_id, :type, :content, :metadata, :status, :created_at, :updated_at, :is_group_chat, :edited_at, :deleted_at,
+		:ttl_seconds, :view_once, :expires_at, :reply_to_message_id, :thread_root_id)
+	ON CONFLICT DO NOTHING
+	`
+	if _, err := r.db.NamedExecContext(ctx, query, rows); err != nil {
+		return fmt.Errorf("failed to restore messages: %w", err)
+	}
+	return nil
+}
+
+// MarkRestored 标记归档已恢复
+func (r *ArchiveRepository) MarkRestored(ctx context.Context, archiveID string, restoredAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+	UPDATE message_archives
+	SET status = $1, restored_at = $2
+	WHERE id = $3
+	`, domain.ArchiveStatusRestored, restoredAt, archiveID)
+	if err != nil {
+		return fmt.Errorf("failed to mark archive restored: %w", err)
+	}
+	return nil
+}
+
+// GetArchive 根据ID获取归档
+func (r *ArchiveRepository) GetArchive(ctx context.Context, id string) (*domain.MessageArchive, error) {
+	var row archiveRow
+	err := r.db.GetContext(ctx, &row, `SELECT `+archiveColumns+` FROM message_archives WHERE id = $1`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("archive not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get archive: %w", err)
+	}
+	return row.toDomain(), nil
+}
+
+// GetArchiveByPartition 根据分区名获取归档，不存在时返回nil
+func (r *ArchiveRepository) GetArchiveByPartition(ctx context.Context, partition string) (*domain.MessageArchive, error) {
+	var row archiveRow
+	err := r.db.GetContext(ctx, &row, `SELECT `+archiveColumns+` FROM message_archives WHERE partition_name = $1`, partition)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get archive: %w", err)
+	}
+	return row.toDomain(), nil
+}
+
+// ListArchives 获取全部归档，按时间倒序
+func (r *ArchiveRepository) ListArchives(ctx context.Context) ([]*domain.MessageArchive, error) {
+	var rows []archiveRow
+	if err := r.db.SelectContext(ctx, &rows, `SELECT `+archiveColumns+` FROM message_archives ORDER BY period_start DESC`); err != nil {
+		return nil, fmt.Errorf("failed to list archives: %w", err)
+	}
+
+	archives := make([]*domain.MessageArchive, 0, len(rows))
+	for i := range rows {
+		archives = append(archives, rows[i].toDomain())
+	}
+	return archives, nil
+}
+
+// GetConversationStubs 获取会话在各归档中的占位信息，按时间倒序
+func (r *ArchiveRepository) GetConversationStubs(ctx context.Context, conversationID string) ([]*domain.ArchiveStub, error) {
+	query := `
+	SELECT s.archive_id, s.conversation_id, a.period_start, a.period_end, s.message_count, s.first_message_at, s.last_message_at, a.status
+	FROM message_archive_stubs s
+	JOIN message_archives a ON a.id = s.archive_id
+	WHERE s.conversation_id = $1
+	ORDER BY a.period_start DESC
+	`
+
+	var rows []struct {
+		ArchiveID      string    `db:"archive_id"`
+		ConversationID string    `db:"conversation_id"`
+		PeriodStart    time.Time `db:"period_start"`
+		PeriodEnd      time.Time `db:"period_end"`
+		MessageCount   int64     `db:"message_count"`
+		FirstMessageAt time.Time `db:"first_message_at"`
+		LastMessageAt  time.Time `db:"last_message_at"`
+		Status         string    `db:"status"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, conversationID); err != nil {
+		return nil, fmt.Errorf("failed to get conversation archives: %w", err)
+	}
+
+	stubs := make([]*domain.ArchiveStub, 0, len(rows))
+	for _, row := range rows {
+		stubs = append(stubs, &domain.ArchiveStub{
+			ArchiveID:      row.ArchiveID,
+			ConversationID: row.ConversationID,
+			PeriodStart:    row.PeriodStart.UTC(),
+			PeriodEnd:      row.PeriodEnd.UTC(),
+			MessageCount:   row.MessageCount,
+			FirstMessageAt: row.FirstMessageAt.UTC(),
+			LastMessageAt:  row.LastMessageAt.UTC(),
+			Status:         domain.ArchiveStatus(row.Status),
+		})
+	}
+	return stubs, nil
+}
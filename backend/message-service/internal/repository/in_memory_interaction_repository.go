@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// memoryAggregate 内存中的消息统计及其最后变更时间
+type memoryAggregate struct {
+	aggregates *domain.MessageAggregates
+	updatedAt  time.Time
+}
+
+// InMemoryInteractionRepository 内存回应和已读回执仓库实现
+type InMemoryInteractionRepository struct {
+	reactions  map[string][]*domain.Reaction    // messageID -> 回应
+	receipts   map[string][]*domain.ReadReceipt // messageID -> 已读回执
+	aggregates map[string]*memoryAggregate
+	mutex      sync.RWMutex
+	logger     *zap.Logger
+}
+
+// NewInMemoryInteractionRepository 创建新的内存回应和已读回执仓库
+func NewInMemoryInteractionRepository(logger *zap.Logger) domain.InteractionRepository {
+	return &InMemoryInteractionRepository{
+		reactions:  make(map[string][]*domain.Reaction),
+		receipts:   make(map[string][]*domain.ReadReceipt),
+		aggregates: make(map[string]*memoryAggregate),
+		logger:     logger,
+	}
+}
+
+// AddReaction 添加回应
+func (r *InMemoryInteractionRepository) AddReaction(ctx context.Context, reaction *domain.Reaction) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.reactions[reaction.MessageID] {
+		if existing.UserID == reaction.UserID && existing.Emoji == reaction.Emoji {
+			return false, nil
+		}
+	}
+
+	if reaction.CreatedAt.IsZero() {
+		reaction.CreatedAt = time.Now().UTC()
+	}
+	stored := *reaction
+	r.reactions[reaction.MessageID] = append(r.reactions[reaction.MessageID], &stored)
+
+	aggregate := r.aggregate(reaction.MessageID, reaction.CreatedAt)
+	if aggregate.Reactions == nil {
+		aggregate.Reactions = make(map[string]int)
+	}
+	aggregate.Reactions[reaction.Emoji]++
+	return true, nil
+}
+
+// RemoveReaction 删除回应
+func (r *InMemoryInteractionRepository) RemoveReaction(ctx context.Context, messageID, userID, emoji string) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	reactions := r.reactions[messageID]
+	for i, existing := range reactions {
+		if existing.UserID != userID || existing.Emoji != emoji {
+			continue
+		}
+		r.reactions[messageID] = append(reactions[:i:i], reactions[i+1:]...)
+
+		aggregate := r.aggregate(messageID, time.Now().UTC())
+		if aggregate.Reactions[emoji] <= 1 {
+			delete(aggregate.Reactions, emoji)
+		} else {
+			aggregate.Reactions[emoji]--
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// ListReactions 获取消息的全部回应
+func (r *InMemoryInteractionRepository) ListReactions(ctx context.Context, messageID string) ([]*domain.Reaction, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	reactions := make([]*domain.Reaction, 0, len(r.reactions[messageID]))
+	for _, reaction := range r.reactions[messageID] {
+		copied := *reaction
+		reactions = append(reactions, &copied)
+	}
+	return reactions, nil
+}
+
+// MarkRead 记录已读回执
+func (r *InMemoryInteractionRepository) MarkRead(ctx context.Context, receipt *domain.ReadReceipt) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.receipts[receipt.MessageID] {
+		if existing.UserID == receipt.UserID {
+			return false, nil
+		}
+	}
+
+	if receipt.ReadAt.IsZero() {
+		receipt.ReadAt = time.Now().UTC()
+	}
+	stored := *receipt
+	r.receipts[receipt.MessageID] = append(r.receipts[receipt.MessageID], &stored)
+
+	aggregate := r.aggregate(receipt.MessageID, receipt.ReadAt)
+	aggregate.ReadBy = append(aggregate.ReadBy, receipt.UserID)
+	aggregate.ReadCount++
+	return true, nil
+}
+
+// ListReadReceipts 获取消息的已读回执
+func (r *InMemoryInteractionRepository) ListReadReceipts(ctx context.Context, messageID string) ([]*domain.ReadReceipt, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	receipts := make([]*domain.ReadReceipt, 0, len(r.receipts[messageID]))
+	for _, receipt := range r.receipts[messageID] {
+		copied := *receipt
+		receipts = append(receipts, &copied)
+	}
+	return receipts, nil
+}
+
+// GetAggregates 批量获取消息统计，返回副本
+func (r *InMemoryInteractionRepository) GetAggregates(ctx context.Context, messageIDs []string) (map[string]*domain.MessageAggregates, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := make(map[string]*domain.MessageAggregates, len(messageIDs))
+	for _, id := range messageIDs {
+		entry, exists := r.aggregates[id]
+		if !exists || (len(entry.aggregates.Reactions) == 0 && entry.aggregates.ReadCount == 0) {
+			continue
+		}
+		result[id] = copyAggregates(entry.aggregates)
+	}
+	return result, nil
+}
+
+// ListActiveMessages 列出since之后统计有变更的消息
+func (r *InMemoryInteractionRepository) ListActiveMessages(ctx context.Context, since time.Time, limit int) ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var messageIDs []string
+	for id, entry := range r.aggregates {
+		if len(messageIDs) >= limit {
+			break
+		}
+		if !entry.updatedAt.Before(since) {
+			messageIDs = append(messageIDs, id)
+		}
+	}
+	return messageIDs, nil
+}
+
+// ReconcileAggregates 按明细重建统计
+func (r *InMemoryInteractionRepository) ReconcileAggregates(ctx context.Context, messageIDs []string) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	fixed := 0
+	for _, id := range messageIDs {
+		expected := &domain.MessageAggregates{}
+		for _, reaction := range r.reactions[id] {
+			if expected.Reactions == nil {
+				expected.Reactions = make(map[string]int)
+			}
+			expected.Reactions[reaction.Emoji]++
+		}
+		receipts := append([]*domain.ReadReceipt(nil), r.receipts[id]...)
+		sort.SliceStable(receipts, func(i, j int) bool {
+			return receipts[i].ReadAt.Before(receipts[j].ReadAt)
+		})
+		for _, receipt := range receipts {
+			expected.ReadBy = append(expected.ReadBy, receipt.UserID)
+		}
+		expected.ReadCount = len(expected.ReadBy)
+
+		entry, exists := r.aggregates[id]
+		if exists && aggregatesEqual(entry.aggregates, expected) {
+			continue
+		}
+		if !exists {
+			if len(expected.Reactions) == 0 && expected.ReadCount == 0 {
+				continue
+			}
+			entry = &memoryAggregate{updatedAt: time.Now().UTC()}
+			r.aggregates[id] = entry
+		}
+		entry.aggregates = expected
+		fixed++
+	}
+	return fixed, nil
+}
+
+// aggregate 获取消息统计，不存在时创建，并记录变更时间；调用方需持有写锁
+func (r *InMemoryInteractionRepository) aggregate(messageID string, updatedAt time.Time) *domain.MessageAggregates {
+	entry, exists := r.aggregates[messageID]
+	if !exists {
+		entry = &memoryAggregate{aggregates: &domain.MessageAggregates{}}
+		r.aggregates[messageID] = entry
+	}
+	entry.updatedAt = updatedAt
+	return entry.aggregates
+}
+
+// copyAggregates 复制统计，避免调用方修改仓库内的数据
+func copyAggregates(aggregates *domain.MessageAggregates) *domain.MessageAggregates {
+	copied := &domain.MessageAggregates{
+		ReadBy:    append([]string(nil), aggregates.ReadBy...),
+		ReadCount: aggregates.ReadCount,
+	}
+	if len(aggregates.Reactions) > 0 {
+		copied.Reactions = make(map[string]int, len(aggregates.Reactions))
+		for emoji, count := range aggregates.Reactions {
+			copied.Reactions[emoji] = count
+		}
+	}
+	return copied
+}
+
+// aggregatesEqual 比较两份统计，已读列表只比较成员不比较顺序
+func aggregatesEqual(a, b *domain.MessageAggregates) bool {
+	if a.ReadCount != b.ReadCount || len(a.ReadBy) != len(b.ReadBy) || len(a.Reactions) != len(b.Reactions) {
+		return false
+	}
+	for emoji, count := range a.Reactions {
+		if b.Reactions[emoji] != count {
+			return false
+		}
+	}
+
+	readers := make(map[string]bool, len(a.ReadBy))
+	for _, userID := range a.ReadBy {
+		readers[userID] = true
+	}
+	for _, userID := range b.ReadBy {
+		if !readers[userID] {
+			return false
+		}
+	}
+	return true
+}
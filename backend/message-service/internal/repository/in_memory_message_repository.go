@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"sort"
 	"sync"
 	"time"
 
@@ -20,6 +21,7 @@ var (
 type InMemoryMessageRepository struct {
 	messages      map[string]*domain.Message
 	conversations map[string]*domain.Conversation
+	edits         map[string][]*domain.MessageEdit // 消息ID -> 编辑历史
 	mutex         sync.RWMutex
 	logger        *zap.Logger
 }
@@ -29,6 +31,7 @@ func NewInMemoryMessageRepository(logger *zap.Logger) domain.MessageRepository {
 	return &InMemoryMessageRepository{
 		messages:      make(map[string]*domain.Message),
 		conversations: make(map[string]*domain.Conversation),
+		edits:         make(map[string][]*domain.MessageEdit),
 		logger:        logger,
 	}
 }
@@ -96,6 +99,119 @@ func (r *InMemoryMessageRepository) UpdateStatus(ctx context.Context, id string,
 	return nil
 }
 
+// SetMediaTranscript 把转写文本写入引用该媒体文件的语音消息的元数据
+func (r *InMemoryMessageRepository) SetMediaTranscript(ctx context.Context, mediaID string, transcript *domain.MediaTranscript) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	count := 0
+	for _, message := range r.messages {
+		if message.Type != domain.MessageTypeAudio || message.Metadata[domain.MetadataMediaID] != mediaID {
+			continue
+		}
+		message.Metadata[domain.MetadataTranscript] = transcript.ToMetadata()
+		message.UpdatedAt = time.Now()
+		count++
+	}
+
+	return count, nil
+}
+
+// EditContent 修改消息内容并记录编辑历史
+func (r *InMemoryMessageRepository) EditContent(ctx context.Context, edit *domain.MessageEdit, content string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	message, exists := r.messages[edit.MessageID]
+	if !exists {
+		return ErrMessageNotFound
+	}
+	if message.DeletedAt != nil {
+		return domain.ErrMessageDeleted
+	}
+
+	if edit.ID == "" {
+		edit.ID = uuid.New().String()
+	}
+	edit.PreviousContent = message.Content
+	r.edits[edit.MessageID] = append(r.edits[edit.MessageID], edit)
+
+	editedAt := edit.EditedAt
+	message.Content = content
+	message.EditedAt = &editedAt
+	message.UpdatedAt = editedAt
+
+	return nil
+}
+
+// SoftDelete 撤回消息
+func (r *InMemoryMessageRepository) SoftDelete(ctx context.Context, id string, deletedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	message, exists := r.messages[id]
+	if !exists {
+		return ErrMessageNotFound
+	}
+	if message.DeletedAt != nil {
+		return domain.ErrMessageDeleted
+	}
+
+	message.Content = ""
+	message.Metadata = nil
+	message.DeletedAt = &deletedAt
+	message.UpdatedAt = deletedAt
+
+	return nil
+}
+
+// SetExpiry 设置消息的自毁时间
+func (r *InMemoryMessageRepository) SetExpiry(ctx context.Context, id string, expiresAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	message, exists := r.messages[id]
+	if !exists {
+		return ErrMessageNotFound
+	}
+	if message.DeletedAt != nil || (message.ExpiresAt != nil && !message.ExpiresAt.After(expiresAt)) {
+		return nil
+	}
+	message.ExpiresAt = &expiresAt
+
+	return nil
+}
+
+// ListExpired 获取到期但尚未撤回的消息
+func (r *InMemoryMessageRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.Message, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var messages []*domain.Message
+	for _, message := range r.messages {
+		if message.DeletedAt == nil && message.Expired(before) {
+			copied := *message
+			messages = append(messages, &copied)
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ExpiresAt.Before(*messages[j].ExpiresAt) })
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	return messages, nil
+}
+
+// ListEdits 获取消息的编辑历史
+func (r *InMemoryMessageRepository) ListEdits(ctx context.Context, messageID string) ([]*domain.MessageEdit, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	edits := make([]*domain.MessageEdit, len(r.edits[messageID]))
+	copy(edits, r.edits[messageID])
+	return edits, nil
+}
+
 // GetConversationMessages 获取会话消息
 func (r *InMemoryMessageRepository) GetConversationMessages(ctx context.Context, conversationID string, limit, offset int) ([]*domain.Message, error) {
 	r.mutex.RLock()
@@ -122,6 +238,86 @@ func (r *InMemoryMessageRepository) GetConversationMessages(ctx context.Context,
 	return messages[start:end], nil
 }
 
+// ListThread 按创建时间顺序获取话题中的消息，根消息排在最前
+func (r *InMemoryMessageRepository) ListThread(ctx context.Context, rootID string, limit, offset int) ([]*domain.Message, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var messages []*domain.Message
+	for _, msg := range r.messages {
+		if msg.ID == rootID || msg.ThreadRootID == rootID {
+			messages = append(messages, msg)
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].CreatedAt.Equal(messages[j].CreatedAt) {
+			return messages[i].ID < messages[j].ID
+		}
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+
+	if offset >= len(messages) {
+		return []*domain.Message{}, nil
+	}
+	end := offset + limit
+	if end > len(messages) {
+		end = len(messages)
+	}
+	return messages[offset:end], nil
+}
+
+// CountReplies 批量统计以这些消息为根的话题中未撤回的回复数
+func (r *InMemoryMessageRepository) CountReplies(ctx context.Context, messageIDs []string) (map[string]int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	roots := make(map[string]bool, len(messageIDs))
+	for _, id := range messageIDs {
+		roots[id] = true
+	}
+
+	counts := make(map[string]int)
+	for _, msg := range r.messages {
+		if msg.ThreadRootID != "" && roots[msg.ThreadRootID] && msg.DeletedAt == nil {
+			counts[msg.ThreadRootID]++
+		}
+	}
+	return counts, nil
+}
+
+// GetMessagesAround 按创建时间顺序返回会话中早于at的最后before条消息，以及不早于at的前after条消息
+func (r *InMemoryMessageRepository) GetMessagesAround(ctx context.Context, conversationID string, at time.Time, before, after int) ([]*domain.Message, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var messages []*domain.Message
+	for _, msg := range r.messages {
+		if msg.Conversation == conversationID {
+			messages = append(messages, msg)
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].CreatedAt.Equal(messages[j].CreatedAt) {
+			return messages[i].ID < messages[j].ID
+		}
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+
+	split := sort.Search(len(messages), func(i int) bool {
+		return !messages[i].CreatedAt.Before(at)
+	})
+	start := split - before
+	if start < 0 {
+		start = 0
+	}
+	end := split + after
+	if end > len(messages) {
+		end = len(messages)
+	}
+
+	return messages[start:end], nil
+}
+
 // GetUserConversations 获取用户会话列表
 func (r *InMemoryMessageRepository) GetUserConversations(ctx context.Context, userID string, limit, offset int) ([]*domain.Conversation, error) {
 	r.mutex.RLock()
@@ -210,3 +406,96 @@ func (r *InMemoryMessageRepository) UpdateConversationLastMessage(ctx context.Co
 
 	return nil
 }
+
+// RenameConversation 修改会话名称
+func (r *InMemoryMessageRepository) RenameConversation(ctx context.Context, id, name string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	conversation, exists := r.conversations[id]
+	if !exists {
+		return ErrConversationNotFound
+	}
+
+	conversation.Name = name
+	conversation.UpdatedAt = time.Now()
+	return nil
+}
+
+// AddParticipants 以普通成员身份添加参与者，已在会话中的用户忽略
+func (r *InMemoryMessageRepository) AddParticipants(ctx context.Context, id string, userIDs []string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	conversation, exists := r.conversations[id]
+	if !exists {
+		return ErrConversationNotFound
+	}
+
+	// 复制后再修改，避免影响调用方已经读取到的参与者列表
+	participants := append([]string(nil), conversation.Participants...)
+	for _, userID := range userIDs {
+		if !containsString(participants, userID) {
+			participants = append(participants, userID)
+		}
+	}
+	conversation.Participants = participants
+	conversation.UpdatedAt = time.Now()
+	return nil
+}
+
+// RemoveParticipant 移除参与者及其角色
+func (r *InMemoryMessageRepository) RemoveParticipant(ctx context.Context, id, userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	conversation, exists := r.conversations[id]
+	if !exists {
+		return ErrConversationNotFound
+	}
+
+	conversation.Participants = withoutString(conversation.Participants, userID)
+	conversation.Admins = withoutString(conversation.Admins, userID)
+	conversation.UpdatedAt = time.Now()
+	return nil
+}
+
+// SetParticipantRole 设置参与者在会话中的角色
+func (r *InMemoryMessageRepository) SetParticipantRole(ctx context.Context, id, userID string, role domain.ParticipantRole) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	conversation, exists := r.conversations[id]
+	if !exists {
+		return ErrConversationNotFound
+	}
+
+	admins := withoutString(conversation.Admins, userID)
+	if role == domain.ParticipantRoleAdmin {
+		admins = append(admins, userID)
+	}
+	conversation.Admins = admins
+	conversation.UpdatedAt = time.Now()
+	return nil
+}
+
+// containsString 判断字符串是否在列表中
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutString 返回去掉指定字符串的新列表，不修改原列表
+func withoutString(values []string, value string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
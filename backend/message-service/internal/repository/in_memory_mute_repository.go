@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// InMemoryMuteRepository 内存会话免打扰仓库实现
+type InMemoryMuteRepository struct {
+	mutes  map[string]map[string]*domain.ConversationMute // conversationID -> userID -> 免打扰设置
+	mutex  sync.RWMutex
+	logger *zap.Logger
+}
+
+// NewInMemoryMuteRepository 创建新的内存会话免打扰仓库
+func NewInMemoryMuteRepository(logger *zap.Logger) domain.MuteRepository {
+	return &InMemoryMuteRepository{
+		mutes:  make(map[string]map[string]*domain.ConversationMute),
+		logger: logger,
+	}
+}
+
+// SetMute 保存免打扰设置
+func (r *InMemoryMuteRepository) SetMute(ctx context.Context, mute *domain.ConversationMute) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	mutes, ok := r.mutes[mute.ConversationID]
+	if !ok {
+		mutes = make(map[string]*domain.ConversationMute)
+		r.mutes[mute.ConversationID] = mutes
+	}
+	muteCopy := *mute
+	mutes[mute.UserID] = &muteCopy
+	return nil
+}
+
+// DeleteMute 取消免打扰
+func (r *InMemoryMuteRepository) DeleteMute(ctx context.Context, conversationID, userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.mutes[conversationID], userID)
+	return nil
+}
+
+// ListActiveMutes 列出会话中仍然有效的免打扰设置
+func (r *InMemoryMuteRepository) ListActiveMutes(ctx context.Context, conversationID string, now time.Time) ([]*domain.ConversationMute, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	mutes := make([]*domain.ConversationMute, 0, len(r.mutes[conversationID]))
+	for _, mute := range r.mutes[conversationID] {
+		if mute.Active(now) {
+			muteCopy := *mute
+			mutes = append(mutes, &muteCopy)
+		}
+	}
+	return mutes, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// InMemoryPinRepository 内存置顶消息仓库实现
+type InMemoryPinRepository struct {
+	pins   map[string]map[string]*domain.MessagePin // conversationID -> messageID -> 置顶记录
+	mutex  sync.RWMutex
+	logger *zap.Logger
+}
+
+// NewInMemoryPinRepository 创建新的内存置顶消息仓库
+func NewInMemoryPinRepository(logger *zap.Logger) domain.PinRepository {
+	return &InMemoryPinRepository{
+		pins:   make(map[string]map[string]*domain.MessagePin),
+		logger: logger,
+	}
+}
+
+// Pin 置顶消息
+func (r *InMemoryPinRepository) Pin(ctx context.Context, pin *domain.MessagePin) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	pins, ok := r.pins[pin.ConversationID]
+	if !ok {
+		pins = make(map[string]*domain.MessagePin)
+		r.pins[pin.ConversationID] = pins
+	}
+	if _, exists := pins[pin.MessageID]; exists {
+		return false, nil
+	}
+	pinCopy := *pin
+	pins[pin.MessageID] = &pinCopy
+	return true, nil
+}
+
+// Unpin 取消置顶
+func (r *InMemoryPinRepository) Unpin(ctx context.Context, conversationID, messageID string) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	pins := r.pins[conversationID]
+	if _, exists := pins[messageID]; !exists {
+		return false, nil
+	}
+	delete(pins, messageID)
+	return true, nil
+}
+
+// ListPins 按置顶时间倒序列出会话中的置顶消息
+func (r *InMemoryPinRepository) ListPins(ctx context.Context, conversationID string) ([]*domain.MessagePin, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	pins := make([]*domain.MessagePin, 0, len(r.pins[conversationID]))
+	for _, pin := range r.pins[conversationID] {
+		pinCopy := *pin
+		pins = append(pins, &pinCopy)
+	}
+	sort.Slice(pins, func(i, j int) bool {
+		return pins[i].PinnedAt.After(pins[j].PinnedAt)
+	})
+	return pins, nil
+}
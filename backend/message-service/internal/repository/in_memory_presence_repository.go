@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+)
+
+// InMemoryPresenceRepository 内存在线状态仓库实现，仅反映本实例的连接
+type InMemoryPresenceRepository struct {
+	timeout     time.Duration
+	connections map[string]map[string]time.Time // userID -> connectionID -> 最后心跳时间
+	lastSeen    map[string]time.Time
+	mutex       sync.Mutex
+}
+
+// NewInMemoryPresenceRepository 创建新的内存在线状态仓库，timeout为心跳超时时间
+func NewInMemoryPresenceRepository(timeout time.Duration) domain.PresenceRepository {
+	return &InMemoryPresenceRepository{
+		timeout:     timeout,
+		connections: make(map[string]map[string]time.Time),
+		lastSeen:    make(map[string]time.Time),
+	}
+}
+
+// Connect 记录连接
+func (r *InMemoryPresenceRepository) Connect(ctx context.Context, userID, connectionID string, at time.Time) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.expire(userID, at)
+	connections, ok := r.connections[userID]
+	if !ok {
+		connections = make(map[string]time.Time)
+		r.connections[userID] = connections
+	}
+	wasOffline := len(connections) == 0
+	connections[connectionID] = at
+	return wasOffline, nil
+}
+
+// Disconnect 删除连接
+func (r *InMemoryPresenceRepository) Disconnect(ctx context.Context, userID, connectionID string, at time.Time) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.connections[userID], connectionID)
+	r.lastSeen[userID] = at
+	r.expire(userID, at)
+	_, online := r.connections[userID]
+	return !online, nil
+}
+
+// Heartbeat 刷新连接的心跳时间
+func (r *InMemoryPresenceRepository) Heartbeat(ctx context.Context, userID string, connectionIDs []string, at time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	connections := r.connections[userID]
+	for _, id := range connectionIDs {
+		if _, ok := connections[id]; ok {
+			connections[id] = at
+		}
+	}
+	return nil
+}
+
+// GetPresence 批量查询在线状态
+func (r *InMemoryPresenceRepository) GetPresence(ctx context.Context, userIDs []string, now time.Time) ([]*domain.Presence, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	result := make([]*domain.Presence, 0, len(userIDs))
+	for _, userID := range userIDs {
+		r.expire(userID, now)
+		presence := &domain.Presence{UserID: userID, Status: domain.PresenceOffline}
+		if _, online := r.connections[userID]; online {
+			presence.Status = domain.PresenceOnline
+		} else if lastSeen, ok := r.lastSeen[userID]; ok {
+			presence.LastSeen = &lastSeen
+		}
+		result = append(result, presence)
+	}
+	return result, nil
+}
+
+// expire 删除心跳超时的连接，调用方需持有锁
+func (r *InMemoryPresenceRepository) expire(userID string, now time.Time) {
+	connections, ok := r.connections[userID]
+	if !ok {
+		return
+	}
+	for id, heartbeat := range connections {
+		if now.Sub(heartbeat) > r.timeout {
+			delete(connections, id)
+			if heartbeat.After(r.lastSeen[userID]) {
+				r.lastSeen[userID] = heartbeat
+			}
+		}
+	}
+	if len(connections) == 0 {
+		delete(r.connections, userID)
+	}
+}
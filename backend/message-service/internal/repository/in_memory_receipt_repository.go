@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// memoryReceipt 内存中单个接收者的回执
+type memoryReceipt struct {
+	deliveredAt *time.Time
+	readAt      *time.Time
+}
+
+// InMemoryReceiptRepository 内存回执仓库实现
+type InMemoryReceiptRepository struct {
+	receipts map[string]map[string]*memoryReceipt     // messageID -> userID -> 回执
+	cursors  map[string]map[string]*domain.ReadCursor // conversationID -> userID -> 已读位置
+	mutex    sync.RWMutex
+	logger   *zap.Logger
+}
+
+// NewInMemoryReceiptRepository 创建新的内存回执仓库
+func NewInMemoryReceiptRepository(logger *zap.Logger) domain.ReceiptRepository {
+	return &InMemoryReceiptRepository{
+		receipts: make(map[string]map[string]*memoryReceipt),
+		cursors:  make(map[string]map[string]*domain.ReadCursor),
+		logger:   logger,
+	}
+}
+
+// MarkDelivered 记录送达
+func (r *InMemoryReceiptRepository) MarkDelivered(ctx context.Context, messageID string, userIDs []string, at time.Time) ([]string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var delivered []string
+	for _, userID := range userIDs {
+		receipt := r.receipt(messageID, userID)
+		if receipt.deliveredAt != nil {
+			continue
+		}
+		deliveredAt := at
+		receipt.deliveredAt = &deliveredAt
+		delivered = append(delivered, userID)
+	}
+	return delivered, nil
+}
+
+// MarkRead 记录已读
+func (r *InMemoryReceiptRepository) MarkRead(ctx context.Context, userID string, messageIDs []string, at time.Time) ([]string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var read []string
+	for _, messageID := range messageIDs {
+		receipt := r.receipt(messageID, userID)
+		if receipt.readAt != nil {
+			continue
+		}
+		readAt := at
+		receipt.readAt = &readAt
+		if receipt.deliveredAt == nil {
+			receipt.deliveredAt = &readAt
+		}
+		read = append(read, messageID)
+	}
+	return read, nil
+}
+
+// ListReceipts 获取消息的全部接收者回执
+func (r *InMemoryReceiptRepository) ListReceipts(ctx context.Context, messageID string) ([]*domain.MessageReceipt, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	receipts := make([]*domain.MessageReceipt, 0, len(r.receipts[messageID]))
+	for userID, receipt := range r.receipts[messageID] {
+		receipts = append(receipts, domain.NewMessageReceipt(messageID, userID, receipt.deliveredAt, receipt.readAt))
+	}
+	sort.Slice(receipts, func(i, j int) bool {
+		return receiptTime(receipts[i]).Before(receiptTime(receipts[j]))
+	})
+	return receipts, nil
+}
+
+// GetReadCursor 获取用户在会话中的已读位置
+func (r *InMemoryReceiptRepository) GetReadCursor(ctx context.Context, conversationID, userID string) (*domain.ReadCursor, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	cursor, ok := r.cursors[conversationID][userID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *cursor
+	return &copied, nil
+}
+
+// AdvanceReadCursor 保存已读位置
+func (r *InMemoryReceiptRepository) AdvanceReadCursor(ctx context.Context, cursor *domain.ReadCursor) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	cursors, ok := r.cursors[cursor.ConversationID]
+	if !ok {
+		cursors = make(map[string]*domain.ReadCursor)
+		r.cursors[cursor.ConversationID] = cursors
+	}
+	if existing, ok := cursors[cursor.UserID]; ok && !cursor.MessageCreatedAt.After(existing.MessageCreatedAt) {
+		return false, nil
+	}
+	stored := *cursor
+	cursors[cursor.UserID] = &stored
+	return true, nil
+}
+
+// ListReadCursors 获取会话所有参与者的已读位置
+func (r *InMemoryReceiptRepository) ListReadCursors(ctx context.Context, conversationID string) ([]*domain.ReadCursor, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	cursors := make([]*domain.ReadCursor, 0, len(r.cursors[conversationID]))
+	for _, cursor := range r.cursors[conversationID] {
+		copied := *cursor
+		cursors = append(cursors, &copied)
+	}
+	sort.Slice(cursors, func(i, j int) bool {
+		return cursors[i].MessageCreatedAt.After(cursors[j].MessageCreatedAt)
+	})
+	return cursors, nil
+}
+
+// receipt 获取或创建回执，调用方需持有写锁
+func (r *InMemoryReceiptRepository) receipt(messageID, userID string) *memoryReceipt {
+	users, ok := r.receipts[messageID]
+	if !ok {
+		users = make(map[string]*memoryReceipt)
+		r.receipts[messageID] = users
+	}
+	receipt, ok := users[userID]
+	if !ok {
+		receipt = &memoryReceipt{}
+		users[userID] = receipt
+	}
+	return receipt
+}
+
+// receiptTime 回执的最近状态时间，用于排序
+func receiptTime(receipt *domain.MessageReceipt) time.Time {
+	if receipt.ReadAt != nil {
+		return *receipt.ReadAt
+	}
+	if receipt.DeliveredAt != nil {
+		return *receipt.DeliveredAt
+	}
+	return time.Time{}
+}
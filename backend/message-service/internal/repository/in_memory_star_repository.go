@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// InMemoryStarRepository 内存收藏消息仓库实现
+type InMemoryStarRepository struct {
+	stars  map[string]map[string]*domain.StarredMessage // userID -> messageID -> 收藏记录
+	mutex  sync.RWMutex
+	logger *zap.Logger
+}
+
+// NewInMemoryStarRepository 创建新的内存收藏消息仓库
+func NewInMemoryStarRepository(logger *zap.Logger) domain.StarRepository {
+	return &InMemoryStarRepository{
+		stars:  make(map[string]map[string]*domain.StarredMessage),
+		logger: logger,
+	}
+}
+
+// Star 收藏消息，已收藏时不修改收藏时间
+func (r *InMemoryStarRepository) Star(ctx context.Context, star *domain.StarredMessage) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stars, ok := r.stars[star.UserID]
+	if !ok {
+		stars = make(map[string]*domain.StarredMessage)
+		r.stars[star.UserID] = stars
+	}
+	if _, exists := stars[star.MessageID]; exists {
+		return nil
+	}
+	starCopy := *star
+	stars[star.MessageID] = &starCopy
+	return nil
+}
+
+// Unstar 取消收藏
+func (r *InMemoryStarRepository) Unstar(ctx context.Context, userID, messageID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.stars[userID], messageID)
+	return nil
+}
+
+// ListStars 按收藏时间倒序分页列出用户收藏的消息
+func (r *InMemoryStarRepository) ListStars(ctx context.Context, userID string, limit, offset int) ([]*domain.StarredMessage, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stars := make([]*domain.StarredMessage, 0, len(r.stars[userID]))
+	for _, star := range r.stars[userID] {
+		starCopy := *star
+		stars = append(stars, &starCopy)
+	}
+	sort.Slice(stars, func(i, j int) bool {
+		if stars[i].StarredAt.Equal(stars[j].StarredAt) {
+			return stars[i].MessageID < stars[j].MessageID
+		}
+		return stars[i].StarredAt.After(stars[j].StarredAt)
+	})
+
+	if offset >= len(stars) {
+		return []*domain.StarredMessage{}, nil
+	}
+	end := offset + limit
+	if end > len(stars) {
+		end = len(stars)
+	}
+	return stars[offset:end], nil
+}
@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// InteractionRepository 基于PostgreSQL的回应和已读回执仓库实现
+type InteractionRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewInteractionRepository 创建一个新的回应和已读回执仓库
+func NewInteractionRepository(db *sqlx.DB, logger *zap.Logger) domain.InteractionRepository {
+	return &InteractionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// AddReaction 添加回应，并在同一事务中增加统计计数
+func (r *InteractionRepository) AddReaction(ctx context.Context, reaction *domain.Reaction) (bool, error) {
+	if reaction.CreatedAt.IsZero() {
+		reaction.CreatedAt = time.Now().UTC()
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	result, err := tx.ExecContext(ctx, `
+	INSERT INTO message_reactions (message_id, user_id, emoji, created_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT DO NOTHING
+	`, reaction.MessageID, reaction.UserID, reaction.Emoji, reaction.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to add reaction: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO message_aggregates (message_id, reaction_counts, updated_at)
+	VALUES ($1, jsonb_build_object($2::text, 1), $3)
+	ON CONFLICT (message_id) DO UPDATE SET
+		reaction_counts = jsonb_set(
+			message_aggregates.reaction_counts,
+			ARRAY[$2::text],
+			to_jsonb(COALESCE((message_aggregates.reaction_counts->>$2::text)::int, 0) + 1)
+		),
+		updated_at = EXCLUDED.updated_at
+	`, reaction.MessageID, reaction.Emoji, reaction.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to update reaction aggregates: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// RemoveReaction 删除回应，并在同一事务中减少统计计数，计数归零时移除该表情
+func (r *InteractionRepository) RemoveReaction(ctx context.Context, messageID, userID, emoji string) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	result, err := tx.ExecContext(ctx, `
+	DELETE FROM message_reactions
+	WHERE message_id = $1 AND user_id = $2 AND emoji = $3
+	`, messageID, userID, emoji)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove reaction: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE message_aggregates SET
+		reaction_counts = CASE
+			WHEN COALESCE((reaction_counts->>$2::text)::int, 0) <= 1 THEN reaction_counts - $2::text
+			ELSE jsonb_set(reaction_counts, ARRAY[$2::text], to_jsonb((reaction_counts->>$2::text)::int - 1))
+		END,
+		updated_at = $3
+	WHERE message_id = $1
+	`, messageID, emoji, time.Now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to update reaction aggregates: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// ListReactions 获取消息的全部回应
+func (r *InteractionRepository) ListReactions(ctx context.Context, messageID string) ([]*domain.Reaction, error) {
+	var rows []struct {
+		MessageID string    `db:"message_id"`
+		UserID    string    `db:"user_id"`
+		Emoji     string    `db:"emoji"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+	SELECT message_id, user_id, emoji, created_at
+	FROM message_reactions
+	WHERE message_id = $1
+	ORDER BY created_at
+	`, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reactions: %w", err)
+	}
+
+	reactions := make([]*domain.Reaction, 0, len(rows))
+	for _, row := range rows {
+		reactions = append(reactions, &domain.Reaction{
+			MessageID: row.MessageID,
+			UserID:    row.UserID,
+			Emoji:     row.Emoji,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+	return reactions, nil
+}
+
+// MarkRead 记录已读回执，并在同一事务中追加到已读列表
+func (r *InteractionRepository) MarkRead(ctx context.Context, receipt *domain.ReadReceipt) (bool, error) {
+	if receipt.ReadAt.IsZero() {
+		receipt.ReadAt = time.Now().UTC()
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	result, err := tx.ExecContext(ctx, `
+	INSERT INTO message_reads (message_id, user_id, read_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT DO NOTHING
+	`, receipt.MessageID, receipt.UserID, receipt.ReadAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark message read: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO message_aggregates (message_id, read_by, read_count, updated_at)
+	VALUES ($1, ARRAY[$2::text], 1, $3)
+	ON CONFLICT (message_id) DO UPDATE SET
+		read_by = array_append(message_aggregates.read_by, $2::text),
+		read_count = message_aggregates.read_count + 1,
+		updated_at = EXCLUDED.updated_at
+	`, receipt.MessageID, receipt.UserID, receipt.ReadAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to update read aggregates: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// ListReadReceipts 获取消息的已读回执
+func (r *InteractionRepository) ListReadReceipts(ctx context.Context, messageID string) ([]*domain.ReadReceipt, error) {
+	var rows []struct {
+		MessageID string    `db:"message_id"`
+		UserID    string    `db:"user_id"`
+		ReadAt    time.Time `db:"read_at"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+	SELECT message_id, user_id, read_at
+	FROM message_reads
+	WHERE message_id = $1
+	ORDER BY read_at
+	`, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list read receipts: %w", err)
+	}
+
+	receipts := make([]*domain.ReadReceipt, 0, len(rows))
+	for _, row := range rows {
+		receipts = append(receipts, &domain.ReadReceipt{
+			MessageID: row.MessageID,
+			UserID:    row.UserID,
+			ReadAt:    row.ReadAt,
+		})
+	}
+	return receipts, nil
+}
+
+// GetAggregates 批量获取消息统计
+func (r *InteractionRepository) GetAggregates(ctx context.Context, messageIDs []string) (map[string]*domain.MessageAggregates, error) {
+	aggregates := make(map[string]*domain.MessageAggregates, len(messageIDs))
+	if len(messageIDs) == 0 {
+		return aggregates, nil
+	}
+
+	rows, err := r.db.QueryxContext(ctx, `
+	SELECT message_id, reaction_counts, read_by, read_count
+	FROM message_aggregates
+	WHERE message_id = ANY($1::uuid[])
+	`, pq.Array(messageIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message aggregates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			messageID      string
+			reactionCounts []byte
+			readBy         pq.StringArray
+			readCount      int
+		)
+		if err := rows.Scan(&messageID, &reactionCounts, &readBy, &readCount); err != nil {
+			return nil, fmt.Errorf("failed to scan message aggregates: %w", err)
+		}
+
+		aggregate := &domain.MessageAggregates{
+			ReadBy:    []string(readBy),
+			ReadCount: readCount,
+		}
+		if len(reactionCounts) > 0 {
+			if err := json.Unmarshal(reactionCounts, &aggregate.Reactions); err != nil {
+				r.logger.Warn("Failed to unmarshal reaction counts", zap.Error(err), zap.String("message_id", messageID))
+			}
+		}
+		if len(aggregate.Reactions) == 0 && aggregate.ReadCount == 0 {
+			continue
+		}
+		aggregates[messageID] = aggregate
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over message aggregates: %w", err)
+	}
+	return aggregates, nil
+}
+
+// ListActiveMessages 列出since之后有回应、回执或统计变更的消息
+func (r *InteractionRepository) ListActiveMessages(ctx context.Context, since time.Time, limit int) ([]string, error) {
+	var messageIDs []string
+	err := r.db.SelectContext(ctx, &messageIDs, `
+	SELECT message_id FROM message_aggregates WHERE updated_at >= $1
+	UNION
+	SELECT message_id FROM message_reactions WHERE created_at >= $1
+	UNION
+	SELECT message_id FROM message_reads WHERE read_at >= $1
+	LIMIT $2
+	`, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active messages: %w", err)
+	}
+	return messageIDs, nil
+}
+
+// ReconcileAggregates 按明细表重建统计，只改写与明细不一致的记录，不改变其updated_at
+func (r *InteractionRepository) ReconcileAggregates(ctx context.Context, messageIDs []string) (int, error) {
+	if len(messageIDs) == 0 {
+		return 0, nil
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+	WITH ids AS (
+		SELECT DISTINCT unnest($1::uuid[]) AS message_id
+	),
+	reactions AS (
+		SELECT message_id, jsonb_object_agg(emoji, total) AS counts
+		FROM (
+			SELECT message_id, emoji, COUNT(*) AS total
+			FROM message_reactions
+			WHERE message_id = ANY($1::uuid[])
+			GROUP BY message_id, emoji
+		) grouped
+		GROUP BY message_id
+	),
+	reads AS (
+		SELECT message_id, array_agg(user_id::text ORDER BY read_at, user_id) AS users
+		FROM message_reads
+		WHERE message_id = ANY($1::uuid[])
+		GROUP BY message_id
+	)
+	INSERT INTO message_aggregates (message_id, reaction_counts, read_by, read_count, updated_at)
+	SELECT ids.message_id,
+		COALESCE(reactions.counts, '{}'::jsonb),
+		COALESCE(reads.users, '{}'::text[]),
+		COALESCE(cardinality(reads.users), 0),
+		$2
+	FROM ids
+	LEFT JOIN reactions ON reactions.message_id = ids.message_id
+	LEFT JOIN reads ON reads.message_id = ids.message_id
+	ON CONFLICT (message_id) DO UPDATE SET
+		reaction_counts = EXCLUDED.reaction_counts,
+		read_by = EXCLUDED.read_by,
+		read_count = EXCLUDED.read_count
+	WHERE message_aggregates.reaction_counts IS DISTINCT FROM EXCLUDED.reaction_counts
+		OR message_aggregates.read_count IS DISTINCT FROM EXCLUDED.read_count
+		OR NOT (message_aggregates.read_by @> EXCLUDED.read_by AND message_aggregates.read_by <@ EXCLUDED.read_by)
+	`, pq.Array(messageIDs), time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to reconcile message aggregates: %w", err)
+	}
+
+	fixed, _ := result.RowsAffected()
+	return int(fixed), nil
+}
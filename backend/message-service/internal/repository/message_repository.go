@@ -9,6 +9,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/neohope/chatapp/message-service/internal/domain"
 	"go.uber.org/zap"
 )
@@ -27,6 +28,73 @@ func NewMessageRepository(db *sqlx.DB, logger *zap.Logger) domain.MessageReposit
 	}
 }
 
+// messageColumns 读取完整消息时查询的列，与messageRow的字段对应
+const messageColumns = `id, conversation_id, sender_id, type, content, metadata, status, created_at, updated_at, is_group_chat, edited_at, deleted_at,
+		ttl_seconds, view_once, expires_at, reply_to_message_id, thread_root_id`
+
+// messageRow 消息表的一行
+type messageRow struct {
+	ID           string               `db:"id"`
+	Conversation string               `db:"conversation_id"`
+	SenderID     string               `db:"sender_id"`
+	Type         domain.MessageType   `db:"type"`
+	Content      string               `db:"content"`
+	Metadata     []byte               `db:"metadata"`
+	Status       domain.MessageStatus `db:"status"`
+	CreatedAt    time.Time            `db:"created_at"`
+	UpdatedAt    time.Time            `db:"updated_at"`
+	IsGroupChat  bool                 `db:"is_group_chat"`
+	EditedAt     *time.Time           `db:"edited_at"`
+	DeletedAt    *time.Time           `db:"deleted_at"`
+	TTLSeconds   int                  `db:"ttl_seconds"`
+	ViewOnce     bool                 `db:"view_once"`
+	ExpiresAt    *time.Time           `db:"expires_at"`
+	ReplyTo      *string              `db:"reply_to_message_id"`
+	ThreadRootID *string              `db:"thread_root_id"`
+}
+
+// toDomain 转换为领域消息，元数据解析失败时记录警告并返回空元数据
+func (row *messageRow) toDomain(logger *zap.Logger) *domain.Message {
+	message := &domain.Message{
+		ID:           row.ID,
+		Conversation: row.Conversation,
+		SenderID:     row.SenderID,
+		Type:         row.Type,
+		Content:      row.Content,
+		Status:       row.Status,
+		CreatedAt:    row.CreatedAt,
+		UpdatedAt:    row.UpdatedAt,
+		IsGroupChat:  row.IsGroupChat,
+		EditedAt:     row.EditedAt,
+		DeletedAt:    row.DeletedAt,
+		TTLSeconds:   row.TTLSeconds,
+		ViewOnce:     row.ViewOnce,
+		ExpiresAt:    row.ExpiresAt,
+		Metadata:     make(map[string]any),
+	}
+	if row.ReplyTo != nil {
+		message.ReplyToMessageID = *row.ReplyTo
+	}
+	if row.ThreadRootID != nil {
+		message.ThreadRootID = *row.ThreadRootID
+	}
+
+	if len(row.Metadata) > 0 {
+		if err := json.Unmarshal(row.Metadata, &message.Metadata); err != nil {
+			logger.Warn("Failed to unmarshal message metadata", zap.Error(err), zap.String("message_id", row.ID))
+		}
+	}
+	return message
+}
+
+// nullableID 空字符串写入为NULL
+func nullableID(id string) *string {
+	if id == "" {
+		return nil
+	}
+	return &id
+}
+
 // Create 创建一条新消息
 func (r *MessageRepository) Create(ctx context.Context, message *domain.Message) error {
 	if message.ID == "" {
@@ -44,9 +112,15 @@ func (r *MessageRepository) Create(ctx context.Context, message *domain.Message)
 		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
+	// 消息表按月分区，写入前确保目标分区存在
+	if err := r.ensurePartition(ctx, message.CreatedAt); err != nil {
+		return err
+	}
+
 	query := `
-	INSERT INTO messages (id, conversation_id, sender_id, type, content, metadata, status, created_at, updated_at, is_group_chat)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	INSERT INTO messages (id, conversation_id, sender_id, type, content, metadata, status, created_at, updated_at, is_group_chat, ttl_seconds, view_once, expires_at,
+		reply_to_message_id, thread_root_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`
 
 	_, err = r.db.ExecContext(
@@ -62,6 +136,11 @@ func (r *MessageRepository) Create(ctx context.Context, message *domain.Message)
 		message.CreatedAt,
 		message.UpdatedAt,
 		message.IsGroupChat,
+		message.TTLSeconds,
+		message.ViewOnce,
+		message.ExpiresAt,
+		nullableID(message.ReplyToMessageID),
+		nullableID(message.ThreadRootID),
 	)
 
 	if err != nil {
@@ -71,28 +150,28 @@ func (r *MessageRepository) Create(ctx context.Context, message *domain.Message)
 	return nil
 }
 
+// ensurePartition 确保消息时间所在月份的分区存在，结果在进程内缓存
+func (r *MessageRepository) ensurePartition(ctx context.Context, createdAt time.Time) error {
+	name := partitionName(createdAt)
+	if _, ok := knownPartitions.Load(name); ok {
+		return nil
+	}
+	if err := ensureMonthlyPartition(ctx, r.db, createdAt); err != nil {
+		return err
+	}
+	knownPartitions.Store(name, true)
+	return nil
+}
+
 // GetByID 根据ID获取消息
 func (r *MessageRepository) GetByID(ctx context.Context, id string) (*domain.Message, error) {
-	query := `
-	SELECT id, conversation_id, sender_id, type, content, metadata, status, created_at, updated_at, is_group_chat
+	query := `SELECT ` + messageColumns + `
 	FROM messages
 	WHERE id = $1
 	`
 
-	var message struct {
-		ID           string               `db:"id"`
-		Conversation string               `db:"conversation_id"`
-		SenderID     string               `db:"sender_id"`
-		Type         domain.MessageType   `db:"type"`
-		Content      string               `db:"content"`
-		Metadata     []byte               `db:"metadata"`
-		Status       domain.MessageStatus `db:"status"`
-		CreatedAt    time.Time            `db:"created_at"`
-		UpdatedAt    time.Time            `db:"updated_at"`
-		IsGroupChat  bool                 `db:"is_group_chat"`
-	}
-
-	err := r.db.GetContext(ctx, &message, query, id)
+	var row messageRow
+	err := r.db.GetContext(ctx, &row, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("message not found: %s", id)
@@ -100,26 +179,7 @@ func (r *MessageRepository) GetByID(ctx context.Context, id string) (*domain.Mes
 		return nil, fmt.Errorf("failed to get message: %w", err)
 	}
 
-	result := &domain.Message{
-		ID:           message.ID,
-		Conversation: message.Conversation,
-		SenderID:     message.SenderID,
-		Type:         message.Type,
-		Content:      message.Content,
-		Status:       message.Status,
-		CreatedAt:    message.CreatedAt,
-		UpdatedAt:    message.UpdatedAt,
-		IsGroupChat:  message.IsGroupChat,
-		Metadata:     make(map[string]any),
-	}
-
-	if len(message.Metadata) > 0 {
-		if unmarshalErr := json.Unmarshal(message.Metadata, &result.Metadata); unmarshalErr != nil {
-			r.logger.Warn("Failed to unmarshal message metadata", zap.Error(unmarshalErr), zap.String("message_id", id))
-		}
-	}
-
-	return result, nil
+	return row.toDomain(r.logger), nil
 }
 
 // UpdateStatus 更新消息状态
@@ -140,8 +200,7 @@ func (r *MessageRepository) UpdateStatus(ctx context.Context, id string, status
 
 // GetConversationMessages 获取会话消息
 func (r *MessageRepository) GetConversationMessages(ctx context.Context, conversationID string, limit, offset int) ([]*domain.Message, error) {
-	query := `
-	SELECT id, conversation_id, sender_id, type, content, metadata, status, created_at, updated_at, is_group_chat
+	query := `SELECT ` + messageColumns + `
 	FROM messages
 	WHERE conversation_id = $1
 	ORDER BY created_at DESC
@@ -156,49 +215,89 @@ func (r *MessageRepository) GetConversationMessages(ctx context.Context, convers
 
 	var messages []*domain.Message
 	for rows.Next() {
-		var msg struct {
-			ID           string               `db:"id"`
-			Conversation string               `db:"conversation_id"`
-			SenderID     string               `db:"sender_id"`
-			Type         domain.MessageType   `db:"type"`
-			Content      string               `db:"content"`
-			Metadata     []byte               `db:"metadata"`
-			Status       domain.MessageStatus `db:"status"`
-			CreatedAt    time.Time            `db:"created_at"`
-			UpdatedAt    time.Time            `db:"updated_at"`
-			IsGroupChat  bool                 `db:"is_group_chat"`
-		}
-
-		if scanErr := rows.StructScan(&msg); scanErr != nil {
+		var row messageRow
+		if scanErr := rows.StructScan(&row); scanErr != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", scanErr)
 		}
+		messages = append(messages, row.toDomain(r.logger))
+	}
 
-		message := &domain.Message{
-			ID:           msg.ID,
-			Conversation: msg.Conversation,
-			SenderID:     msg.SenderID,
-			Type:         msg.Type,
-			Content:      msg.Content,
-			Status:       msg.Status,
-			CreatedAt:    msg.CreatedAt,
-			UpdatedAt:    msg.UpdatedAt,
-			IsGroupChat:  msg.IsGroupChat,
-			Metadata:     make(map[string]any),
-		}
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, fmt.Errorf("error iterating over messages: %w", rowsErr)
+	}
 
-		if len(msg.Metadata) > 0 {
-			if unmarshalErr := json.Unmarshal(msg.Metadata, &message.Metadata); unmarshalErr != nil {
-				r.logger.Warn("Failed to unmarshal message metadata", zap.Error(unmarshalErr), zap.String("message_id", msg.ID))
-			}
-		}
+	return messages, nil
+}
 
-		messages = append(messages, message)
+// ListThread 按创建时间顺序获取话题中的消息，根消息排在最前
+func (r *MessageRepository) ListThread(ctx context.Context, rootID string, limit, offset int) ([]*domain.Message, error) {
+	query := `SELECT ` + messageColumns + `
+	FROM messages
+	WHERE id = $1 OR thread_root_id = $1
+	ORDER BY created_at, id
+	LIMIT $2 OFFSET $3
+	`
+
+	var rows []messageRow
+	if err := r.db.SelectContext(ctx, &rows, query, rootID, limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to list thread messages: %w", err)
 	}
 
-	if rowsErr := rows.Err(); rowsErr != nil {
-		return nil, fmt.Errorf("error iterating over messages: %w", rowsErr)
+	messages := make([]*domain.Message, 0, len(rows))
+	for i := range rows {
+		messages = append(messages, rows[i].toDomain(r.logger))
+	}
+	return messages, nil
+}
+
+// CountReplies 批量统计以这些消息为根的话题中未撤回的回复数
+func (r *MessageRepository) CountReplies(ctx context.Context, messageIDs []string) (map[string]int, error) {
+	var rows []struct {
+		ThreadRootID string `db:"thread_root_id"`
+		Count        int    `db:"count"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+	SELECT thread_root_id, COUNT(*) AS count
+	FROM messages
+	WHERE thread_root_id = ANY($1::uuid[]) AND deleted_at IS NULL
+	GROUP BY thread_root_id
+	`, pq.Array(messageIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count replies: %w", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.ThreadRootID] = row.Count
 	}
+	return counts, nil
+}
+
+// GetMessagesAround 按创建时间顺序返回会话中早于at的最后before条消息，以及不早于at的前after条消息
+func (r *MessageRepository) GetMessagesAround(ctx context.Context, conversationID string, at time.Time, before, after int) ([]*domain.Message, error) {
+	query := `SELECT * FROM (
+		(SELECT ` + messageColumns + ` FROM messages
+		WHERE conversation_id = $1 AND created_at < $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3)
+		UNION ALL
+		(SELECT ` + messageColumns + ` FROM messages
+		WHERE conversation_id = $1 AND created_at >= $2
+		ORDER BY created_at, id
+		LIMIT $4)
+	) AS window_messages
+	ORDER BY created_at, id
+	`
 
+	var rows []messageRow
+	if err := r.db.SelectContext(ctx, &rows, query, conversationID, at, before, after); err != nil {
+		return nil, fmt.Errorf("failed to get messages around: %w", err)
+	}
+
+	messages := make([]*domain.Message, 0, len(rows))
+	for i := range rows {
+		messages = append(messages, rows[i].toDomain(r.logger))
+	}
 	return messages, nil
 }
 
@@ -221,8 +320,8 @@ func (r *MessageRepository) CreateConversation(ctx context.Context, conversation
 
 	// 创建会话
 	query := `
-	INSERT INTO conversations (id, type, created_at, updated_at)
-	VALUES ($1, $2, $3, $4)
+	INSERT INTO conversations (id, type, name, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5)
 	`
 
 	_, err = tx.ExecContext(
@@ -230,6 +329,7 @@ func (r *MessageRepository) CreateConversation(ctx context.Context, conversation
 		query,
 		conversation.ID,
 		conversation.Type,
+		conversation.Name,
 		conversation.CreatedAt,
 		conversation.UpdatedAt,
 	)
@@ -242,11 +342,15 @@ func (r *MessageRepository) CreateConversation(ctx context.Context, conversation
 	// 添加参与者
 	for _, userID := range conversation.Participants {
 		query := `
-		INSERT INTO conversation_participants (conversation_id, user_id, joined_at)
-		VALUES ($1, $2, $3)
+		INSERT INTO conversation_participants (conversation_id, user_id, joined_at, role)
+		VALUES ($1, $2, $3, $4)
 		`
 
-		_, err = tx.ExecContext(ctx, query, conversation.ID, userID, now)
+		role := domain.ParticipantRoleMember
+		if conversation.IsAdmin(userID) {
+			role = domain.ParticipantRoleAdmin
+		}
+		_, err = tx.ExecContext(ctx, query, conversation.ID, userID, now, role)
 		if err != nil {
 			tx.Rollback()
 			return fmt.Errorf("failed to add participant: %w", err)
@@ -264,7 +368,7 @@ func (r *MessageRepository) CreateConversation(ctx context.Context, conversation
 func (r *MessageRepository) GetConversation(ctx context.Context, id string) (*domain.Conversation, error) {
 	// 获取会话基本信息
 	convQuery := `
-	SELECT id, type, created_at, updated_at
+	SELECT id, type, name, created_at, updated_at
 	FROM conversations
 	WHERE id = $1
 	`
@@ -272,6 +376,7 @@ func (r *MessageRepository) GetConversation(ctx context.Context, id string) (*do
 	var conv struct {
 		ID        string    `db:"id"`
 		Type      string    `db:"type"`
+		Name      string    `db:"name"`
 		CreatedAt time.Time `db:"created_at"`
 		UpdatedAt time.Time `db:"updated_at"`
 	}
@@ -286,69 +391,52 @@ func (r *MessageRepository) GetConversation(ctx context.Context, id string) (*do
 
 	// 获取参与者
 	participantsQuery := `
-	SELECT user_id
+	SELECT user_id, role
 	FROM conversation_participants
 	WHERE conversation_id = $1
 	`
 
-	var participants []string
-	if selectErr := r.db.SelectContext(ctx, &participants, participantsQuery, id); selectErr != nil {
+	var rows []struct {
+		UserID string `db:"user_id"`
+		Role   string `db:"role"`
+	}
+	if selectErr := r.db.SelectContext(ctx, &rows, participantsQuery, id); selectErr != nil {
 		return nil, fmt.Errorf("failed to get conversation participants: %w", selectErr)
 	}
 
+	participants := make([]string, 0, len(rows))
+	var admins []string
+	for _, row := range rows {
+		participants = append(participants, row.UserID)
+		if domain.ParticipantRole(row.Role) == domain.ParticipantRoleAdmin {
+			admins = append(admins, row.UserID)
+		}
+	}
+
 	// 获取最后一条消息
-	lastMsgQuery := `
-	SELECT id, conversation_id, sender_id, type, content, metadata, status, created_at, updated_at, is_group_chat
+	lastMsgQuery := `SELECT ` + messageColumns + `
 	FROM messages
 	WHERE conversation_id = $1
 	ORDER BY created_at DESC
 	LIMIT 1
 	`
 
-	var lastMsg struct {
-		ID           string               `db:"id"`
-		Conversation string               `db:"conversation_id"`
-		SenderID     string               `db:"sender_id"`
-		Type         domain.MessageType   `db:"type"`
-		Content      string               `db:"content"`
-		Metadata     []byte               `db:"metadata"`
-		Status       domain.MessageStatus `db:"status"`
-		CreatedAt    time.Time            `db:"created_at"`
-		UpdatedAt    time.Time            `db:"updated_at"`
-		IsGroupChat  bool                 `db:"is_group_chat"`
-	}
-
+	var lastMsg messageRow
 	var lastMessage *domain.Message
 	err = r.db.GetContext(ctx, &lastMsg, lastMsgQuery, id)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, fmt.Errorf("failed to get last message: %w", err)
 	}
-
 	if err != sql.ErrNoRows {
-		lastMessage = &domain.Message{
-			ID:           lastMsg.ID,
-			Conversation: lastMsg.Conversation,
-			SenderID:     lastMsg.SenderID,
-			Type:         lastMsg.Type,
-			Content:      lastMsg.Content,
-			Status:       lastMsg.Status,
-			CreatedAt:    lastMsg.CreatedAt,
-			UpdatedAt:    lastMsg.UpdatedAt,
-			IsGroupChat:  lastMsg.IsGroupChat,
-			Metadata:     make(map[string]any),
-		}
-
-		if len(lastMsg.Metadata) > 0 {
-			if unmarshalErr := json.Unmarshal(lastMsg.Metadata, &lastMessage.Metadata); unmarshalErr != nil {
-				r.logger.Warn("Failed to unmarshal message metadata", zap.Error(unmarshalErr), zap.String("message_id", lastMsg.ID))
-			}
-		}
+		lastMessage = lastMsg.toDomain(r.logger)
 	}
 
 	return &domain.Conversation{
 		ID:           conv.ID,
 		Type:         conv.Type,
+		Name:         conv.Name,
 		Participants: participants,
+		Admins:       admins,
 		LastMessage:  lastMessage,
 		CreatedAt:    conv.CreatedAt,
 		UpdatedAt:    conv.UpdatedAt,
@@ -417,3 +505,282 @@ func (r *MessageRepository) UpdateConversationLastMessage(ctx context.Context, c
 
 	return nil
 }
+
+// SetMediaTranscript 把转写文本写入引用该媒体文件的语音消息的元数据
+func (r *MessageRepository) SetMediaTranscript(ctx context.Context, mediaID string, transcript *domain.MediaTranscript) (int, error) {
+	value, err := json.Marshal(transcript.ToMetadata())
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal transcript: %w", err)
+	}
+
+	query := `
+	UPDATE messages
+	SET metadata = jsonb_set(COALESCE(metadata, '{}'::jsonb), '{transcript}', $1::jsonb), updated_at = $2
+	WHERE type = 'audio' AND metadata->>'media_id' = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, string(value), time.Now().UTC(), mediaID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set media transcript: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to set media transcript: %w", err)
+	}
+	return int(count), nil
+}
+
+// EditContent 修改消息内容，在同一事务中把编辑前的内容写入编辑历史
+func (r *MessageRepository) EditContent(ctx context.Context, edit *domain.MessageEdit, content string) error {
+	if edit.ID == "" {
+		edit.ID = uuid.New().String()
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	// 锁定消息行，并发编辑时按顺序记录历史
+	var current struct {
+		Content   string     `db:"content"`
+		DeletedAt *time.Time `db:"deleted_at"`
+	}
+	err = tx.GetContext(ctx, &current, `SELECT content, deleted_at FROM messages WHERE id = $1 FOR UPDATE`, edit.MessageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("message not found: %s", edit.MessageID)
+		}
+		return fmt.Errorf("failed to get message: %w", err)
+	}
+	if current.DeletedAt != nil {
+		return domain.ErrMessageDeleted
+	}
+	edit.PreviousContent = current.Content
+
+	if _, err := tx.ExecContext(ctx, `
+	INSERT INTO message_edits (id, message_id, editor_id, previous_content, edited_at)
+	VALUES ($1, $2, $3, $4, $5)
+	`, edit.ID, edit.MessageID, edit.EditorID, edit.PreviousContent, edit.EditedAt); err != nil {
+		return fmt.Errorf("failed to record message edit: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE messages
+	SET content = $1, edited_at = $2, updated_at = $2
+	WHERE id = $3
+	`, content, edit.EditedAt, edit.MessageID); err != nil {
+		return fmt.Errorf("failed to edit message: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SoftDelete 撤回消息，清空内容和元数据，保留消息行作为占位
+func (r *MessageRepository) SoftDelete(ctx context.Context, id string, deletedAt time.Time) error {
+	query := `
+	UPDATE messages
+	SET content = '', metadata = NULL, deleted_at = $1, updated_at = $1
+	WHERE id = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, deletedAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM messages WHERE id = $1)`, id); err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("message not found: %s", id)
+	}
+	return domain.ErrMessageDeleted
+}
+
+// SetExpiry 设置消息的自毁时间，已有更早的自毁时间或消息已撤回时不修改
+func (r *MessageRepository) SetExpiry(ctx context.Context, id string, expiresAt time.Time) error {
+	query := `
+	UPDATE messages
+	SET expires_at = $1, updated_at = $2
+	WHERE id = $3 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > $1)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, expiresAt, time.Now().UTC(), id); err != nil {
+		return fmt.Errorf("failed to set message expiry: %w", err)
+	}
+	return nil
+}
+
+// ListExpired 按自毁时间顺序获取到期但尚未撤回的消息
+func (r *MessageRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.Message, error) {
+	query := `
+	SELECT id, conversation_id, sender_id, type, status, created_at, updated_at, is_group_chat, ttl_seconds, view_once, expires_at
+	FROM messages
+	WHERE expires_at <= $1 AND deleted_at IS NULL
+	ORDER BY expires_at
+	LIMIT $2
+	`
+
+	var rows []struct {
+		ID           string               `db:"id"`
+		Conversation string               `db:"conversation_id"`
+		SenderID     string               `db:"sender_id"`
+		Type         domain.MessageType   `db:"type"`
+		Status       domain.MessageStatus `db:"status"`
+		CreatedAt    time.Time            `db:"created_at"`
+		UpdatedAt    time.Time            `db:"updated_at"`
+		IsGroupChat  bool                 `db:"is_group_chat"`
+		TTLSeconds   int                  `db:"ttl_seconds"`
+		ViewOnce     bool                 `db:"view_once"`
+		ExpiresAt    *time.Time           `db:"expires_at"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, before, limit); err != nil {
+		return nil, fmt.Errorf("failed to list expired messages: %w", err)
+	}
+
+	// 到期的消息即将被撤回，不读取内容和元数据
+	messages := make([]*domain.Message, 0, len(rows))
+	for _, row := range rows {
+		messages = append(messages, &domain.Message{
+			ID:           row.ID,
+			Conversation: row.Conversation,
+			SenderID:     row.SenderID,
+			Type:         row.Type,
+			Status:       row.Status,
+			CreatedAt:    row.CreatedAt,
+			UpdatedAt:    row.UpdatedAt,
+			IsGroupChat:  row.IsGroupChat,
+			TTLSeconds:   row.TTLSeconds,
+			ViewOnce:     row.ViewOnce,
+			ExpiresAt:    row.ExpiresAt,
+		})
+	}
+	return messages, nil
+}
+
+// ListEdits 按编辑时间顺序获取消息的编辑历史
+func (r *MessageRepository) ListEdits(ctx context.Context, messageID string) ([]*domain.MessageEdit, error) {
+	query := `
+	SELECT id, message_id, editor_id, previous_content, edited_at
+	FROM message_edits
+	WHERE message_id = $1
+	ORDER BY edited_at
+	`
+
+	var rows []struct {
+		ID              string    `db:"id"`
+		MessageID       string    `db:"message_id"`
+		EditorID        string    `db:"editor_id"`
+		PreviousContent string    `db:"previous_content"`
+		EditedAt        time.Time `db:"edited_at"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, messageID); err != nil {
+		return nil, fmt.Errorf("failed to list message edits: %w", err)
+	}
+
+	edits := make([]*domain.MessageEdit, 0, len(rows))
+	for _, row := range rows {
+		edits = append(edits, &domain.MessageEdit{
+			ID:              row.ID,
+			MessageID:       row.MessageID,
+			EditorID:        row.EditorID,
+			PreviousContent: row.PreviousContent,
+			EditedAt:        row.EditedAt.UTC(),
+		})
+	}
+	return edits, nil
+}
+
+// RenameConversation 修改会话名称
+func (r *MessageRepository) RenameConversation(ctx context.Context, id, name string) error {
+	query := `
+	UPDATE conversations
+	SET name = $1, updated_at = $2
+	WHERE id = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, name, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to rename conversation: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("conversation not found: %s", id)
+	}
+	return nil
+}
+
+// AddParticipants 以普通成员身份添加参与者，已在会话中的用户忽略
+func (r *MessageRepository) AddParticipants(ctx context.Context, id string, userIDs []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	for _, userID := range userIDs {
+		query := `
+		INSERT INTO conversation_participants (conversation_id, user_id, joined_at, role)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (conversation_id, user_id) DO NOTHING
+		`
+		if _, err := tx.ExecContext(ctx, query, id, userID, now, domain.ParticipantRoleMember); err != nil {
+			return fmt.Errorf("failed to add participant: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE conversations SET updated_at = $1 WHERE id = $2", now, id); err != nil {
+		return fmt.Errorf("failed to update conversation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RemoveParticipant 移除参与者及其角色
+func (r *MessageRepository) RemoveParticipant(ctx context.Context, id, userID string) error {
+	query := `
+	DELETE FROM conversation_participants
+	WHERE conversation_id = $1 AND user_id = $2
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, userID); err != nil {
+		return fmt.Errorf("failed to remove participant: %w", err)
+	}
+	return nil
+}
+
+// SetParticipantRole 设置参与者在会话中的角色
+func (r *MessageRepository) SetParticipantRole(ctx context.Context, id, userID string, role domain.ParticipantRole) error {
+	query := `
+	UPDATE conversation_participants
+	SET role = $1
+	WHERE conversation_id = $2 AND user_id = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, role, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set participant role: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("participant not found: %s", userID)
+	}
+	return nil
+}
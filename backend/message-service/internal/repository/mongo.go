@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/message-service/pkg/tracing"
+)
+
+// MongoDB集合名称
+const (
+	mongoMessagesCollection          = "messages"
+	mongoConversationsCollection     = "conversations"
+	mongoReactionsCollection         = "message_reactions"
+	mongoReadReceiptsCollection      = "message_reads"
+	mongoAggregatesCollection        = "message_aggregates"
+	mongoMessageReceiptsCollection   = "message_receipts"
+	mongoReadCursorsCollection       = "conversation_read_cursors"
+	mongoMessageEditsCollection      = "message_edits"
+	mongoPinnedMessagesCollection    = "pinned_messages"
+	mongoConversationMutesCollection = "conversation_mutes"
+	mongoStarredMessagesCollection   = "starred_messages"
+)
+
+// NewMongoDB 创建一个新的MongoDB连接并返回消息库
+func NewMongoDB(uri, database string, logger *zap.Logger) (*mongo.Database, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().
+		ApplyURI(uri).
+		SetMaxPoolSize(50).
+		SetRetryWrites(true).
+		// 请求中执行的命令记录为请求span的子span
+		SetMonitor(tracing.MongoMonitor()).
+		// 元数据中的嵌套文档解码为map，保持与JSON序列化一致
+		SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mongodb: %w", err)
+	}
+
+	// 测试连接
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, fmt.Errorf("failed to ping mongodb: %w", err)
+	}
+
+	db := client.Database(database)
+
+	// 初始化索引
+	if err := initMongoIndexes(ctx, db, logger); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, fmt.Errorf("failed to initialize mongodb: %w", err)
+	}
+
+	logger.Info("Successfully connected to MongoDB", zap.String("database", database))
+	return db, nil
+}
+
+// initMongoIndexes 初始化集合索引
+// 消息按会话分区：{conversation_id, created_at} 复合索引覆盖按会话倒序翻页，
+// 分片部署时建议以 conversation_id 作为分片键，使同一会话的消息落在同一分片上顺序追加
+func initMongoIndexes(ctx context.Context, db *mongo.Database, logger *zap.Logger) error {
+	_, err := db.Collection(mongoMessagesCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "conversation_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_messages_conversation_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "sender_id", Value: 1}},
+			Options: options.Index().SetName("idx_messages_sender_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "metadata.media_id", Value: 1}},
+			Options: options.Index().SetName("idx_messages_media_id").SetSparse(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create message indexes: %w", err)
+	}
+
+	// 参与者直接内嵌在会话文档中，多键索引支持按用户查询会话列表
+	_, err = db.Collection(mongoConversationsCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "participants", Value: 1}, {Key: "updated_at", Value: -1}},
+		Options: options.Index().SetName("idx_conversations_participants_updated_at"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create conversation indexes: %w", err)
+	}
+
+	// 回应和回执按消息查询明细，按时间查询供统计对账任务使用
+	for _, collection := range []struct {
+		name      string
+		timeField string
+	}{
+		{mongoReactionsCollection, "created_at"},
+		{mongoReadReceiptsCollection, "read_at"},
+	} {
+		_, err = db.Collection(collection.name).Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "message_id", Value: 1}, {Key: collection.timeField, Value: 1}},
+				Options: options.Index().SetName("idx_" + collection.name + "_message_id"),
+			},
+			{
+				Keys:    bson.D{{Key: collection.timeField, Value: 1}},
+				Options: options.Index().SetName("idx_" + collection.name + "_" + collection.timeField),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create %s indexes: %w", collection.name, err)
+		}
+	}
+
+	_, err = db.Collection(mongoAggregatesCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "updated_at", Value: 1}},
+		Options: options.Index().SetName("idx_message_aggregates_updated_at"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create message aggregate indexes: %w", err)
+	}
+
+	// 接收者回执按消息查询，已读位置按会话查询
+	_, err = db.Collection(mongoMessageReceiptsCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "message_id", Value: 1}},
+		Options: options.Index().SetName("idx_message_receipts_message_id"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create message receipt indexes: %w", err)
+	}
+	_, err = db.Collection(mongoReadCursorsCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "conversation_id", Value: 1}},
+		Options: options.Index().SetName("idx_conversation_read_cursors_conversation_id"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create read cursor indexes: %w", err)
+	}
+
+	// 编辑历史按消息查询并按编辑时间排序
+	_, err = db.Collection(mongoMessageEditsCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "message_id", Value: 1}, {Key: "edited_at", Value: 1}},
+		Options: options.Index().SetName("idx_message_edits_message_id_edited_at"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create message edit indexes: %w", err)
+	}
+
+	// 清理任务按自毁时间查询到期的消息，只索引设置了自毁时间的消息
+	_, err = db.Collection(mongoMessagesCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetName("idx_messages_expires_at").
+			SetPartialFilterExpression(bson.M{"expires_at": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create message expiry indexes: %w", err)
+	}
+
+	// 话题按根消息查询回复并统计回复数
+	_, err = db.Collection(mongoMessagesCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "thread_root_id", Value: 1}, {Key: "created_at", Value: 1}},
+		Options: options.Index().SetName("idx_messages_thread_root_id").
+			SetPartialFilterExpression(bson.M{"thread_root_id": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create message thread indexes: %w", err)
+	}
+
+	// 置顶消息按会话查询并按置顶时间倒序
+	_, err = db.Collection(mongoPinnedMessagesCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "conversation_id", Value: 1}, {Key: "pinned_at", Value: -1}},
+		Options: options.Index().SetName("idx_pinned_messages_conversation_id_pinned_at"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create pinned message indexes: %w", err)
+	}
+
+	// 通知服务推送前按会话查询免打扰设置
+	_, err = db.Collection(mongoConversationMutesCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "conversation_id", Value: 1}},
+		Options: options.Index().SetName("idx_conversation_mutes_conversation_id"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create conversation mute indexes: %w", err)
+	}
+
+	// 收藏按用户查询并按收藏时间倒序
+	_, err = db.Collection(mongoStarredMessagesCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "starred_at", Value: -1}},
+		Options: options.Index().SetName("idx_starred_messages_user_id_starred_at"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create starred message indexes: %w", err)
+	}
+
+	logger.Info("MongoDB indexes initialized successfully")
+	return nil
+}
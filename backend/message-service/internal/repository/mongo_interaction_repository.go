@@ -0,0 +1,353 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// mongoReaction 回应文档，_id由消息、用户和表情组成，保证同一用户对同一表情只回应一次
+type mongoReaction struct {
+	ID        string    `bson:"_id"`
+	MessageID string    `bson:"message_id"`
+	UserID    string    `bson:"user_id"`
+	Emoji     string    `bson:"emoji"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// mongoReadReceipt 已读回执文档，_id由消息和用户组成
+type mongoReadReceipt struct {
+	ID        string    `bson:"_id"`
+	MessageID string    `bson:"message_id"`
+	UserID    string    `bson:"user_id"`
+	ReadAt    time.Time `bson:"read_at"`
+}
+
+// mongoAggregate 消息统计文档，_id为消息ID
+type mongoAggregate struct {
+	ID        string         `bson:"_id"`
+	Reactions map[string]int `bson:"reactions,omitempty"`
+	ReadBy    []string       `bson:"read_by,omitempty"`
+	ReadCount int            `bson:"read_count"`
+	UpdatedAt time.Time      `bson:"updated_at"`
+}
+
+// MongoInteractionRepository 基于MongoDB的回应和已读回执仓库实现
+// 明细和统计分两步写入，中途失败造成的不一致由ReconcileAggregates修正
+type MongoInteractionRepository struct {
+	reactions  *mongo.Collection
+	receipts   *mongo.Collection
+	aggregates *mongo.Collection
+	logger     *zap.Logger
+}
+
+// NewMongoInteractionRepository 创建一个新的MongoDB回应和已读回执仓库
+func NewMongoInteractionRepository(db *mongo.Database, logger *zap.Logger) domain.InteractionRepository {
+	return &MongoInteractionRepository{
+		reactions:  db.Collection(mongoReactionsCollection),
+		receipts:   db.Collection(mongoReadReceiptsCollection),
+		aggregates: db.Collection(mongoAggregatesCollection),
+		logger:     logger,
+	}
+}
+
+// AddReaction 添加回应
+func (r *MongoInteractionRepository) AddReaction(ctx context.Context, reaction *domain.Reaction) (bool, error) {
+	if reaction.CreatedAt.IsZero() {
+		reaction.CreatedAt = time.Now().UTC()
+	}
+
+	_, err := r.reactions.InsertOne(ctx, mongoReaction{
+		ID:        reaction.MessageID + ":" + reaction.UserID + ":" + reaction.Emoji,
+		MessageID: reaction.MessageID,
+		UserID:    reaction.UserID,
+		Emoji:     reaction.Emoji,
+		CreatedAt: reaction.CreatedAt,
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to add reaction: %w", err)
+	}
+
+	_, err = r.aggregates.UpdateOne(ctx, bson.M{"_id": reaction.MessageID}, bson.M{
+		"$inc": bson.M{"reactions." + reaction.Emoji: 1},
+		"$set": bson.M{"updated_at": reaction.CreatedAt},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		return false, fmt.Errorf("failed to update reaction aggregates: %w", err)
+	}
+	return true, nil
+}
+
+// RemoveReaction 删除回应，计数归零时移除该表情
+func (r *MongoInteractionRepository) RemoveReaction(ctx context.Context, messageID, userID, emoji string) (bool, error) {
+	result, err := r.reactions.DeleteOne(ctx, bson.M{"_id": messageID + ":" + userID + ":" + emoji})
+	if err != nil {
+		return false, fmt.Errorf("failed to remove reaction: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return false, nil
+	}
+
+	field := "reactions." + emoji
+	now := time.Now().UTC()
+	_, err = r.aggregates.UpdateOne(ctx, bson.M{"_id": messageID}, bson.M{
+		"$inc": bson.M{field: -1},
+		"$set": bson.M{"updated_at": now},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update reaction aggregates: %w", err)
+	}
+	_, err = r.aggregates.UpdateOne(ctx, bson.M{"_id": messageID, field: bson.M{"$lte": 0}}, bson.M{
+		"$unset": bson.M{field: ""},
+	})
+	if err != nil {
+		r.logger.Warn("Failed to remove empty reaction count", zap.Error(err), zap.String("message_id", messageID))
+	}
+	return true, nil
+}
+
+// ListReactions 获取消息的全部回应
+func (r *MongoInteractionRepository) ListReactions(ctx context.Context, messageID string) ([]*domain.Reaction, error) {
+	docs, err := r.findReactions(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	reactions := make([]*domain.Reaction, 0, len(docs))
+	for _, doc := range docs {
+		reactions = append(reactions, &domain.Reaction{
+			MessageID: doc.MessageID,
+			UserID:    doc.UserID,
+			Emoji:     doc.Emoji,
+			CreatedAt: doc.CreatedAt.UTC(),
+		})
+	}
+	return reactions, nil
+}
+
+// MarkRead 记录已读回执
+func (r *MongoInteractionRepository) MarkRead(ctx context.Context, receipt *domain.ReadReceipt) (bool, error) {
+	if receipt.ReadAt.IsZero() {
+		receipt.ReadAt = time.Now().UTC()
+	}
+
+	_, err := r.receipts.InsertOne(ctx, mongoReadReceipt{
+		ID:        receipt.MessageID + ":" + receipt.UserID,
+		MessageID: receipt.MessageID,
+		UserID:    receipt.UserID,
+		ReadAt:    receipt.ReadAt,
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to mark message read: %w", err)
+	}
+
+	_, err = r.aggregates.UpdateOne(ctx, bson.M{"_id": receipt.MessageID}, bson.M{
+		"$push": bson.M{"read_by": receipt.UserID},
+		"$inc":  bson.M{"read_count": 1},
+		"$set":  bson.M{"updated_at": receipt.ReadAt},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		return false, fmt.Errorf("failed to update read aggregates: %w", err)
+	}
+	return true, nil
+}
+
+// ListReadReceipts 获取消息的已读回执
+func (r *MongoInteractionRepository) ListReadReceipts(ctx context.Context, messageID string) ([]*domain.ReadReceipt, error) {
+	docs, err := r.findReceipts(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	receipts := make([]*domain.ReadReceipt, 0, len(docs))
+	for _, doc := range docs {
+		receipts = append(receipts, &domain.ReadReceipt{
+			MessageID: doc.MessageID,
+			UserID:    doc.UserID,
+			ReadAt:    doc.ReadAt.UTC(),
+		})
+	}
+	return receipts, nil
+}
+
+// GetAggregates 批量获取消息统计
+func (r *MongoInteractionRepository) GetAggregates(ctx context.Context, messageIDs []string) (map[string]*domain.MessageAggregates, error) {
+	result := make(map[string]*domain.MessageAggregates, len(messageIDs))
+	if len(messageIDs) == 0 {
+		return result, nil
+	}
+
+	cursor, err := r.aggregates.Find(ctx, bson.M{"_id": bson.M{"$in": messageIDs}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message aggregates: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc mongoAggregate
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode message aggregates: %w", err)
+		}
+		aggregates := doc.toDomain()
+		if len(aggregates.Reactions) == 0 && aggregates.ReadCount == 0 {
+			continue
+		}
+		result[doc.ID] = aggregates
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over message aggregates: %w", err)
+	}
+	return result, nil
+}
+
+// ListActiveMessages 列出since之后有回应、回执或统计变更的消息
+func (r *MongoInteractionRepository) ListActiveMessages(ctx context.Context, since time.Time, limit int) ([]string, error) {
+	seen := make(map[string]bool)
+	var messageIDs []string
+
+	sources := []struct {
+		collection *mongo.Collection
+		idField    string
+		timeField  string
+	}{
+		{r.aggregates, "_id", "updated_at"},
+		{r.reactions, "message_id", "created_at"},
+		{r.receipts, "message_id", "read_at"},
+	}
+	for _, source := range sources {
+		if len(messageIDs) >= limit {
+			break
+		}
+
+		ids, err := source.collection.Distinct(ctx, source.idField, bson.M{source.timeField: bson.M{"$gte": since}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list active messages: %w", err)
+		}
+		for _, id := range ids {
+			messageID, ok := id.(string)
+			if !ok || seen[messageID] {
+				continue
+			}
+			seen[messageID] = true
+			messageIDs = append(messageIDs, messageID)
+			if len(messageIDs) >= limit {
+				break
+			}
+		}
+	}
+	return messageIDs, nil
+}
+
+// ReconcileAggregates 按明细重建统计
+func (r *MongoInteractionRepository) ReconcileAggregates(ctx context.Context, messageIDs []string) (int, error) {
+	fixed := 0
+	for _, messageID := range messageIDs {
+		reactions, err := r.findReactions(ctx, messageID)
+		if err != nil {
+			return fixed, err
+		}
+		receipts, err := r.findReceipts(ctx, messageID)
+		if err != nil {
+			return fixed, err
+		}
+
+		expected := &domain.MessageAggregates{}
+		for _, reaction := range reactions {
+			if expected.Reactions == nil {
+				expected.Reactions = make(map[string]int)
+			}
+			expected.Reactions[reaction.Emoji]++
+		}
+		for _, receipt := range receipts {
+			expected.ReadBy = append(expected.ReadBy, receipt.UserID)
+		}
+		expected.ReadCount = len(expected.ReadBy)
+
+		var current mongoAggregate
+		err = r.aggregates.FindOne(ctx, bson.M{"_id": messageID}).Decode(&current)
+		switch {
+		case err == mongo.ErrNoDocuments:
+			if len(expected.Reactions) == 0 && expected.ReadCount == 0 {
+				continue
+			}
+			current = mongoAggregate{ID: messageID, UpdatedAt: time.Now().UTC()}
+		case err != nil:
+			return fixed, fmt.Errorf("failed to get message aggregates: %w", err)
+		case aggregatesEqual(current.toDomain(), expected):
+			continue
+		}
+
+		current.Reactions = expected.Reactions
+		current.ReadBy = expected.ReadBy
+		current.ReadCount = expected.ReadCount
+		_, err = r.aggregates.ReplaceOne(ctx, bson.M{"_id": messageID}, current, options.Replace().SetUpsert(true))
+		if err != nil {
+			return fixed, fmt.Errorf("failed to reconcile message aggregates: %w", err)
+		}
+		fixed++
+	}
+	return fixed, nil
+}
+
+// findReactions 按时间顺序查询消息的回应文档
+func (r *MongoInteractionRepository) findReactions(ctx context.Context, messageID string) ([]*mongoReaction, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.reactions.Find(ctx, bson.M{"message_id": messageID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []*mongoReaction
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode reactions: %w", err)
+	}
+	return docs, nil
+}
+
+// findReceipts 按已读时间顺序查询消息的回执文档
+func (r *MongoInteractionRepository) findReceipts(ctx context.Context, messageID string) ([]*mongoReadReceipt, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "read_at", Value: 1}})
+	cursor, err := r.receipts.Find(ctx, bson.M{"message_id": messageID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list read receipts: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []*mongoReadReceipt
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode read receipts: %w", err)
+	}
+	return docs, nil
+}
+
+// toDomain 文档转换为领域统计，去掉计数已归零的表情
+func (a *mongoAggregate) toDomain() *domain.MessageAggregates {
+	aggregates := &domain.MessageAggregates{
+		ReadBy:    a.ReadBy,
+		ReadCount: a.ReadCount,
+	}
+	for emoji, count := range a.Reactions {
+		if count <= 0 {
+			continue
+		}
+		if aggregates.Reactions == nil {
+			aggregates.Reactions = make(map[string]int)
+		}
+		aggregates.Reactions[emoji] = count
+	}
+	return aggregates
+}
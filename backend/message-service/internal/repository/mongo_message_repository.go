@@ -0,0 +1,655 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// mongoMessage 消息文档
+type mongoMessage struct {
+	ID           string               `bson:"_id"`
+	Conversation string               `bson:"conversation_id"`
+	SenderID     string               `bson:"sender_id"`
+	Type         domain.MessageType   `bson:"type"`
+	Content      string               `bson:"content"`
+	Metadata     bson.M               `bson:"metadata,omitempty"`
+	Status       domain.MessageStatus `bson:"status"`
+	CreatedAt    time.Time            `bson:"created_at"`
+	UpdatedAt    time.Time            `bson:"updated_at"`
+	IsGroupChat  bool                 `bson:"is_group_chat"`
+	EditedAt     *time.Time           `bson:"edited_at,omitempty"`
+	DeletedAt    *time.Time           `bson:"deleted_at,omitempty"`
+	TTLSeconds   int                  `bson:"ttl_seconds,omitempty"`
+	ViewOnce     bool                 `bson:"view_once,omitempty"`
+	ExpiresAt    *time.Time           `bson:"expires_at,omitempty"`
+	ReplyTo      string               `bson:"reply_to_message_id,omitempty"`
+	ThreadRootID string               `bson:"thread_root_id,omitempty"`
+}
+
+// mongoMessageEdit 消息编辑历史文档
+type mongoMessageEdit struct {
+	ID              string    `bson:"_id"`
+	MessageID       string    `bson:"message_id"`
+	EditorID        string    `bson:"editor_id"`
+	PreviousContent string    `bson:"previous_content"`
+	EditedAt        time.Time `bson:"edited_at"`
+}
+
+// mongoConversation 会话文档，参与者和最后一条消息内嵌存储，读取会话列表时无需关联查询
+type mongoConversation struct {
+	ID           string        `bson:"_id"`
+	Type         string        `bson:"type"`
+	Name         string        `bson:"name,omitempty"`
+	Participants []string      `bson:"participants"`
+	Admins       []string      `bson:"admins,omitempty"`
+	LastMessage  *mongoMessage `bson:"last_message,omitempty"`
+	CreatedAt    time.Time     `bson:"created_at"`
+	UpdatedAt    time.Time     `bson:"updated_at"`
+}
+
+// MongoMessageRepository 基于MongoDB的消息仓库实现，适合按会话分区的追加写场景
+type MongoMessageRepository struct {
+	messages      *mongo.Collection
+	conversations *mongo.Collection
+	edits         *mongo.Collection
+	logger        *zap.Logger
+}
+
+// NewMongoMessageRepository 创建一个新的MongoDB消息仓库
+func NewMongoMessageRepository(db *mongo.Database, logger *zap.Logger) domain.MessageRepository {
+	return &MongoMessageRepository{
+		messages:      db.Collection(mongoMessagesCollection),
+		conversations: db.Collection(mongoConversationsCollection),
+		edits:         db.Collection(mongoMessageEditsCollection),
+		logger:        logger,
+	}
+}
+
+// Create 创建一条新消息
+func (r *MongoMessageRepository) Create(ctx context.Context, message *domain.Message) error {
+	if message.ID == "" {
+		message.ID = uuid.New().String()
+	}
+
+	now := time.Now().UTC()
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = now
+	}
+	message.UpdatedAt = now
+
+	if _, err := r.messages.InsertOne(ctx, toMongoMessage(message)); err != nil {
+		return fmt.Errorf("failed to create message: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID 根据ID获取消息
+func (r *MongoMessageRepository) GetByID(ctx context.Context, id string) (*domain.Message, error) {
+	var doc mongoMessage
+	err := r.messages.FindOne(ctx, bson.M{"_id": id}).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("message not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	return doc.toDomain(), nil
+}
+
+// UpdateStatus 更新消息状态
+func (r *MongoMessageRepository) UpdateStatus(ctx context.Context, id string, status domain.MessageStatus) error {
+	now := time.Now().UTC()
+	_, err := r.messages.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"status": status, "updated_at": now},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update message status: %w", err)
+	}
+
+	// 同步会话中内嵌的最后一条消息
+	_, err = r.conversations.UpdateOne(ctx, bson.M{"last_message._id": id}, bson.M{
+		"$set": bson.M{"last_message.status": status, "last_message.updated_at": now},
+	})
+	if err != nil {
+		r.logger.Warn("Failed to update last message status", zap.Error(err), zap.String("message_id", id))
+	}
+
+	return nil
+}
+
+// GetConversationMessages 获取会话消息
+func (r *MongoMessageRepository) GetConversationMessages(ctx context.Context, conversationID string, limit, offset int) ([]*domain.Message, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	cursor, err := r.messages.Find(ctx, bson.M{"conversation_id": conversationID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation messages: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var messages []*domain.Message
+	for cursor.Next(ctx) {
+		var doc mongoMessage
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode message: %w", err)
+		}
+		messages = append(messages, doc.toDomain())
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// ListThread 按创建时间顺序获取话题中的消息，根消息排在最前
+func (r *MongoMessageRepository) ListThread(ctx context.Context, rootID string, limit, offset int) ([]*domain.Message, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	filter := bson.M{"$or": bson.A{bson.M{"_id": rootID}, bson.M{"thread_root_id": rootID}}}
+	cursor, err := r.messages.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list thread messages: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	messages := []*domain.Message{}
+	for cursor.Next(ctx) {
+		var doc mongoMessage
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode message: %w", err)
+		}
+		messages = append(messages, doc.toDomain())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over thread messages: %w", err)
+	}
+	return messages, nil
+}
+
+// CountReplies 批量统计以这些消息为根的话题中未撤回的回复数
+func (r *MongoMessageRepository) CountReplies(ctx context.Context, messageIDs []string) (map[string]int, error) {
+	cursor, err := r.messages.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"thread_root_id": bson.M{"$in": messageIDs}, "deleted_at": nil}}},
+		{{Key: "$group", Value: bson.M{"_id": "$thread_root_id", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count replies: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID    string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode reply counts: %w", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.ID] = row.Count
+	}
+	return counts, nil
+}
+
+// GetMessagesAround 按创建时间顺序返回会话中早于at的最后before条消息，以及不早于at的前after条消息
+func (r *MongoMessageRepository) GetMessagesAround(ctx context.Context, conversationID string, at time.Time, before, after int) ([]*domain.Message, error) {
+	earlier, err := r.findMessages(ctx,
+		bson.M{"conversation_id": conversationID, "created_at": bson.M{"$lt": at}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(int64(before)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages before: %w", err)
+	}
+	later, err := r.findMessages(ctx,
+		bson.M{"conversation_id": conversationID, "created_at": bson.M{"$gte": at}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(int64(after)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages after: %w", err)
+	}
+
+	messages := make([]*domain.Message, 0, len(earlier)+len(later))
+	for i := len(earlier) - 1; i >= 0; i-- {
+		messages = append(messages, earlier[i])
+	}
+	return append(messages, later...), nil
+}
+
+// findMessages 按条件查询消息
+func (r *MongoMessageRepository) findMessages(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*domain.Message, error) {
+	cursor, err := r.messages.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []mongoMessage
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	messages := make([]*domain.Message, 0, len(docs))
+	for i := range docs {
+		messages = append(messages, docs[i].toDomain())
+	}
+	return messages, nil
+}
+
+// CreateConversation 创建会话
+func (r *MongoMessageRepository) CreateConversation(ctx context.Context, conversation *domain.Conversation) error {
+	if conversation.ID == "" {
+		conversation.ID = uuid.New().String()
+	}
+
+	now := time.Now().UTC()
+	if conversation.CreatedAt.IsZero() {
+		conversation.CreatedAt = now
+	}
+	conversation.UpdatedAt = now
+
+	participants := conversation.Participants
+	if participants == nil {
+		participants = []string{}
+	}
+
+	doc := mongoConversation{
+		ID:           conversation.ID,
+		Type:         conversation.Type,
+		Name:         conversation.Name,
+		Participants: participants,
+		Admins:       conversation.Admins,
+		CreatedAt:    conversation.CreatedAt,
+		UpdatedAt:    conversation.UpdatedAt,
+	}
+	if conversation.LastMessage != nil {
+		doc.LastMessage = toMongoMessage(conversation.LastMessage)
+	}
+
+	if _, err := r.conversations.InsertOne(ctx, doc); err != nil {
+		return fmt.Errorf("failed to create conversation: %w", err)
+	}
+
+	return nil
+}
+
+// GetConversation 获取会话
+func (r *MongoMessageRepository) GetConversation(ctx context.Context, id string) (*domain.Conversation, error) {
+	var doc mongoConversation
+	err := r.conversations.FindOne(ctx, bson.M{"_id": id}).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("conversation not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	return doc.toDomain(), nil
+}
+
+// GetUserConversations 获取用户的会话列表
+func (r *MongoMessageRepository) GetUserConversations(ctx context.Context, userID string, limit, offset int) ([]*domain.Conversation, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	cursor, err := r.conversations.Find(ctx, bson.M{"participants": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user conversations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var conversations []*domain.Conversation
+	for cursor.Next(ctx) {
+		var doc mongoConversation
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode conversation: %w", err)
+		}
+		conversations = append(conversations, doc.toDomain())
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over conversations: %w", err)
+	}
+
+	return conversations, nil
+}
+
+// UpdateConversationLastMessage 更新会话的最后一条消息
+func (r *MongoMessageRepository) UpdateConversationLastMessage(ctx context.Context, conversationID string, message *domain.Message) error {
+	set := bson.M{"updated_at": time.Now().UTC()}
+	if message != nil {
+		set["last_message"] = toMongoMessage(message)
+	}
+
+	_, err := r.conversations.UpdateOne(ctx, bson.M{"_id": conversationID}, bson.M{"$set": set})
+	if err != nil {
+		return fmt.Errorf("failed to update conversation last message: %w", err)
+	}
+
+	return nil
+}
+
+// RenameConversation 修改会话名称
+func (r *MongoMessageRepository) RenameConversation(ctx context.Context, id, name string) error {
+	return r.updateConversation(ctx, id, bson.M{"$set": bson.M{"name": name, "updated_at": time.Now().UTC()}})
+}
+
+// AddParticipants 以普通成员身份添加参与者，已在会话中的用户忽略
+func (r *MongoMessageRepository) AddParticipants(ctx context.Context, id string, userIDs []string) error {
+	return r.updateConversation(ctx, id, bson.M{
+		"$addToSet": bson.M{"participants": bson.M{"$each": userIDs}},
+		"$set":      bson.M{"updated_at": time.Now().UTC()},
+	})
+}
+
+// RemoveParticipant 移除参与者及其角色
+func (r *MongoMessageRepository) RemoveParticipant(ctx context.Context, id, userID string) error {
+	return r.updateConversation(ctx, id, bson.M{
+		"$pull": bson.M{"participants": userID, "admins": userID},
+		"$set":  bson.M{"updated_at": time.Now().UTC()},
+	})
+}
+
+// SetParticipantRole 设置参与者在会话中的角色，管理员列表内嵌在会话文档中
+func (r *MongoMessageRepository) SetParticipantRole(ctx context.Context, id, userID string, role domain.ParticipantRole) error {
+	update := bson.M{"$pull": bson.M{"admins": userID}}
+	if role == domain.ParticipantRoleAdmin {
+		update = bson.M{"$addToSet": bson.M{"admins": userID}}
+	}
+	update["$set"] = bson.M{"updated_at": time.Now().UTC()}
+	return r.updateConversation(ctx, id, update)
+}
+
+// updateConversation 更新会话文档，会话不存在时返回错误
+func (r *MongoMessageRepository) updateConversation(ctx context.Context, id string, update bson.M) error {
+	result, err := r.conversations.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update conversation: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("conversation not found: %s", id)
+	}
+	return nil
+}
+
+// toMongoMessage 领域消息转换为文档
+func toMongoMessage(message *domain.Message) *mongoMessage {
+	doc := &mongoMessage{
+		ID:           message.ID,
+		Conversation: message.Conversation,
+		SenderID:     message.SenderID,
+		Type:         message.Type,
+		Content:      message.Content,
+		Status:       message.Status,
+		CreatedAt:    message.CreatedAt,
+		UpdatedAt:    message.UpdatedAt,
+		IsGroupChat:  message.IsGroupChat,
+		EditedAt:     message.EditedAt,
+		DeletedAt:    message.DeletedAt,
+		TTLSeconds:   message.TTLSeconds,
+		ViewOnce:     message.ViewOnce,
+		ExpiresAt:    message.ExpiresAt,
+		ReplyTo:      message.ReplyToMessageID,
+		ThreadRootID: message.ThreadRootID,
+	}
+	if len(message.Metadata) > 0 {
+		doc.Metadata = bson.M(message.Metadata)
+	}
+	return doc
+}
+
+// toDomain 文档转换为领域消息
+func (m *mongoMessage) toDomain() *domain.Message {
+	message := &domain.Message{
+		ID:           m.ID,
+		Conversation: m.Conversation,
+		SenderID:     m.SenderID,
+		Type:         m.Type,
+		Content:      m.Content,
+		Status:       m.Status,
+		CreatedAt:    m.CreatedAt.UTC(),
+		UpdatedAt:    m.UpdatedAt.UTC(),
+		IsGroupChat:  m.IsGroupChat,
+		TTLSeconds:   m.TTLSeconds,
+		ViewOnce:     m.ViewOnce,
+		Metadata:     make(map[string]any),
+	}
+	message.ReplyToMessageID = m.ReplyTo
+	message.ThreadRootID = m.ThreadRootID
+	if m.EditedAt != nil {
+		editedAt := m.EditedAt.UTC()
+		message.EditedAt = &editedAt
+	}
+	if m.DeletedAt != nil {
+		deletedAt := m.DeletedAt.UTC()
+		message.DeletedAt = &deletedAt
+	}
+	if m.ExpiresAt != nil {
+		expiresAt := m.ExpiresAt.UTC()
+		message.ExpiresAt = &expiresAt
+	}
+	for k, v := range m.Metadata {
+		message.Metadata[k] = v
+	}
+	return message
+}
+
+// toDomain 文档转换为领域会话
+func (c *mongoConversation) toDomain() *domain.Conversation {
+	conversation := &domain.Conversation{
+		ID:           c.ID,
+		Type:         c.Type,
+		Name:         c.Name,
+		Participants: c.Participants,
+		Admins:       c.Admins,
+		CreatedAt:    c.CreatedAt.UTC(),
+		UpdatedAt:    c.UpdatedAt.UTC(),
+	}
+	if c.LastMessage != nil {
+		conversation.LastMessage = c.LastMessage.toDomain()
+	}
+	return conversation
+}
+
+// SetMediaTranscript 把转写文本写入引用该媒体文件的语音消息的元数据
+func (r *MongoMessageRepository) SetMediaTranscript(ctx context.Context, mediaID string, transcript *domain.MediaTranscript) (int, error) {
+	now := time.Now().UTC()
+	value := bson.M(transcript.ToMetadata())
+	result, err := r.messages.UpdateMany(ctx, bson.M{"type": domain.MessageTypeAudio, "metadata.media_id": mediaID}, bson.M{
+		"$set": bson.M{"metadata.transcript": value, "updated_at": now},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to set media transcript: %w", err)
+	}
+
+	// 同步会话中内嵌的最后一条消息
+	_, err = r.conversations.UpdateMany(ctx, bson.M{"last_message.type": domain.MessageTypeAudio, "last_message.metadata.media_id": mediaID}, bson.M{
+		"$set": bson.M{"last_message.metadata.transcript": value, "last_message.updated_at": now},
+	})
+	if err != nil {
+		r.logger.Warn("Failed to update last message transcript", zap.Error(err), zap.String("media_id", mediaID))
+	}
+
+	return int(result.ModifiedCount), nil
+}
+
+// EditContent 修改消息内容并写入编辑历史
+// 先以未撤回为条件更新消息并取回编辑前的内容，再写入历史
+func (r *MongoMessageRepository) EditContent(ctx context.Context, edit *domain.MessageEdit, content string) error {
+	if edit.ID == "" {
+		edit.ID = uuid.New().String()
+	}
+
+	var before mongoMessage
+	err := r.messages.FindOneAndUpdate(ctx,
+		bson.M{"_id": edit.MessageID, "deleted_at": nil},
+		bson.M{"$set": bson.M{"content": content, "edited_at": edit.EditedAt, "updated_at": edit.EditedAt}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&before)
+	if err == mongo.ErrNoDocuments {
+		return r.missingOrDeleted(ctx, edit.MessageID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to edit message: %w", err)
+	}
+	edit.PreviousContent = before.Content
+
+	_, err = r.edits.InsertOne(ctx, mongoMessageEdit{
+		ID:              edit.ID,
+		MessageID:       edit.MessageID,
+		EditorID:        edit.EditorID,
+		PreviousContent: edit.PreviousContent,
+		EditedAt:        edit.EditedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record message edit: %w", err)
+	}
+
+	// 同步会话中内嵌的最后一条消息
+	_, err = r.conversations.UpdateOne(ctx, bson.M{"last_message._id": edit.MessageID}, bson.M{
+		"$set": bson.M{"last_message.content": content, "last_message.edited_at": edit.EditedAt, "last_message.updated_at": edit.EditedAt},
+	})
+	if err != nil {
+		r.logger.Warn("Failed to update edited last message", zap.Error(err), zap.String("message_id", edit.MessageID))
+	}
+
+	return nil
+}
+
+// SoftDelete 撤回消息，清空内容和元数据，保留文档作为占位
+func (r *MongoMessageRepository) SoftDelete(ctx context.Context, id string, deletedAt time.Time) error {
+	result, err := r.messages.UpdateOne(ctx, bson.M{"_id": id, "deleted_at": nil}, bson.M{
+		"$set":   bson.M{"content": "", "deleted_at": deletedAt, "updated_at": deletedAt},
+		"$unset": bson.M{"metadata": ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return r.missingOrDeleted(ctx, id)
+	}
+
+	// 同步会话中内嵌的最后一条消息
+	_, err = r.conversations.UpdateOne(ctx, bson.M{"last_message._id": id}, bson.M{
+		"$set":   bson.M{"last_message.content": "", "last_message.deleted_at": deletedAt, "last_message.updated_at": deletedAt},
+		"$unset": bson.M{"last_message.metadata": ""},
+	})
+	if err != nil {
+		r.logger.Warn("Failed to update deleted last message", zap.Error(err), zap.String("message_id", id))
+	}
+
+	return nil
+}
+
+// SetExpiry 设置消息的自毁时间，已有更早的自毁时间时不修改
+func (r *MongoMessageRepository) SetExpiry(ctx context.Context, id string, expiresAt time.Time) error {
+	filter := bson.M{
+		"_id":        id,
+		"deleted_at": nil,
+		"$or":        bson.A{bson.M{"expires_at": nil}, bson.M{"expires_at": bson.M{"$gt": expiresAt}}},
+	}
+	if _, err := r.messages.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"expires_at": expiresAt}}); err != nil {
+		return fmt.Errorf("failed to set message expiry: %w", err)
+	}
+
+	// 同步会话中内嵌的最后一条消息
+	_, err := r.conversations.UpdateOne(ctx, bson.M{"last_message._id": id, "last_message.expires_at": nil}, bson.M{
+		"$set": bson.M{"last_message.expires_at": expiresAt},
+	})
+	if err != nil {
+		r.logger.Warn("Failed to update last message expiry", zap.Error(err), zap.String("message_id", id))
+	}
+
+	return nil
+}
+
+// ListExpired 按自毁时间顺序获取到期但尚未撤回的消息
+func (r *MongoMessageRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.Message, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "expires_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.messages.Find(ctx, bson.M{"expires_at": bson.M{"$lte": before}, "deleted_at": nil}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired messages: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var messages []*domain.Message
+	for cursor.Next(ctx) {
+		var doc mongoMessage
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode message: %w", err)
+		}
+		messages = append(messages, doc.toDomain())
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over expired messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// ListEdits 按编辑时间顺序获取消息的编辑历史
+func (r *MongoMessageRepository) ListEdits(ctx context.Context, messageID string) ([]*domain.MessageEdit, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "edited_at", Value: 1}})
+	cursor, err := r.edits.Find(ctx, bson.M{"message_id": messageID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message edits: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	edits := []*domain.MessageEdit{}
+	for cursor.Next(ctx) {
+		var doc mongoMessageEdit
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode message edit: %w", err)
+		}
+		edits = append(edits, &domain.MessageEdit{
+			ID:              doc.ID,
+			MessageID:       doc.MessageID,
+			EditorID:        doc.EditorID,
+			PreviousContent: doc.PreviousContent,
+			EditedAt:        doc.EditedAt.UTC(),
+		})
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over message edits: %w", err)
+	}
+
+	return edits, nil
+}
+
+// missingOrDeleted 条件更新未命中时区分消息不存在和已撤回
+func (r *MongoMessageRepository) missingOrDeleted(ctx context.Context, id string) error {
+	count, err := r.messages.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to get message: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("message not found: %s", id)
+	}
+	return domain.ErrMessageDeleted
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// mongoConversationMute 会话免打扰文档，_id由会话和用户组成，muted_until缺省表示永久免打扰
+type mongoConversationMute struct {
+	ID             string     `bson:"_id"`
+	ConversationID string     `bson:"conversation_id"`
+	UserID         string     `bson:"user_id"`
+	MutedUntil     *time.Time `bson:"muted_until,omitempty"`
+	UpdatedAt      time.Time  `bson:"updated_at"`
+}
+
+// MongoMuteRepository 基于MongoDB的会话免打扰仓库实现
+type MongoMuteRepository struct {
+	mutes  *mongo.Collection
+	logger *zap.Logger
+}
+
+// NewMongoMuteRepository 创建一个新的MongoDB会话免打扰仓库
+func NewMongoMuteRepository(db *mongo.Database, logger *zap.Logger) domain.MuteRepository {
+	return &MongoMuteRepository{
+		mutes:  db.Collection(mongoConversationMutesCollection),
+		logger: logger,
+	}
+}
+
+// SetMute 保存免打扰设置，已有设置时整体替换
+func (r *MongoMuteRepository) SetMute(ctx context.Context, mute *domain.ConversationMute) error {
+	id := mute.ConversationID + ":" + mute.UserID
+	_, err := r.mutes.ReplaceOne(ctx, bson.M{"_id": id}, &mongoConversationMute{
+		ID:             id,
+		ConversationID: mute.ConversationID,
+		UserID:         mute.UserID,
+		MutedUntil:     mute.MutedUntil,
+		UpdatedAt:      mute.UpdatedAt,
+	}, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to mute conversation: %w", err)
+	}
+	return nil
+}
+
+// DeleteMute 取消免打扰
+func (r *MongoMuteRepository) DeleteMute(ctx context.Context, conversationID, userID string) error {
+	if _, err := r.mutes.DeleteOne(ctx, bson.M{"_id": conversationID + ":" + userID}); err != nil {
+		return fmt.Errorf("failed to unmute conversation: %w", err)
+	}
+	return nil
+}
+
+// ListActiveMutes 列出会话中仍然有效的免打扰设置
+func (r *MongoMuteRepository) ListActiveMutes(ctx context.Context, conversationID string, now time.Time) ([]*domain.ConversationMute, error) {
+	filter := bson.M{
+		"conversation_id": conversationID,
+		"$or": bson.A{
+			bson.M{"muted_until": bson.M{"$exists": false}},
+			bson.M{"muted_until": bson.M{"$gt": now}},
+		},
+	}
+	cursor, err := r.mutes.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversation mutes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []*mongoConversationMute
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation mutes: %w", err)
+	}
+
+	mutes := make([]*domain.ConversationMute, 0, len(docs))
+	for _, doc := range docs {
+		mute := &domain.ConversationMute{
+			ConversationID: doc.ConversationID,
+			UserID:         doc.UserID,
+			Muted:          true,
+			UpdatedAt:      doc.UpdatedAt.UTC(),
+		}
+		if doc.MutedUntil != nil {
+			until := doc.MutedUntil.UTC()
+			mute.MutedUntil = &until
+		}
+		mutes = append(mutes, mute)
+	}
+	return mutes, nil
+}
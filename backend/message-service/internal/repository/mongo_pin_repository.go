@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// mongoMessagePin 置顶消息文档，_id由会话和消息组成
+type mongoMessagePin struct {
+	ID             string    `bson:"_id"`
+	ConversationID string    `bson:"conversation_id"`
+	MessageID      string    `bson:"message_id"`
+	PinnedBy       string    `bson:"pinned_by"`
+	PinnedAt       time.Time `bson:"pinned_at"`
+}
+
+// MongoPinRepository 基于MongoDB的置顶消息仓库实现
+type MongoPinRepository struct {
+	pins   *mongo.Collection
+	logger *zap.Logger
+}
+
+// NewMongoPinRepository 创建一个新的MongoDB置顶消息仓库
+func NewMongoPinRepository(db *mongo.Database, logger *zap.Logger) domain.PinRepository {
+	return &MongoPinRepository{
+		pins:   db.Collection(mongoPinnedMessagesCollection),
+		logger: logger,
+	}
+}
+
+// Pin 置顶消息，已置顶时插入因_id重复失败
+func (r *MongoPinRepository) Pin(ctx context.Context, pin *domain.MessagePin) (bool, error) {
+	_, err := r.pins.InsertOne(ctx, &mongoMessagePin{
+		ID:             pin.ConversationID + ":" + pin.MessageID,
+		ConversationID: pin.ConversationID,
+		MessageID:      pin.MessageID,
+		PinnedBy:       pin.PinnedBy,
+		PinnedAt:       pin.PinnedAt,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to pin message: %w", err)
+	}
+	return true, nil
+}
+
+// Unpin 取消置顶
+func (r *MongoPinRepository) Unpin(ctx context.Context, conversationID, messageID string) (bool, error) {
+	result, err := r.pins.DeleteOne(ctx, bson.M{"_id": conversationID + ":" + messageID})
+	if err != nil {
+		return false, fmt.Errorf("failed to unpin message: %w", err)
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// ListPins 按置顶时间倒序列出会话中的置顶消息
+func (r *MongoPinRepository) ListPins(ctx context.Context, conversationID string) ([]*domain.MessagePin, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "pinned_at", Value: -1}})
+	cursor, err := r.pins.Find(ctx, bson.M{"conversation_id": conversationID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pinned messages: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []*mongoMessagePin
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode pinned messages: %w", err)
+	}
+
+	pins := make([]*domain.MessagePin, 0, len(docs))
+	for _, doc := range docs {
+		pins = append(pins, &domain.MessagePin{
+			ConversationID: doc.ConversationID,
+			MessageID:      doc.MessageID,
+			PinnedBy:       doc.PinnedBy,
+			PinnedAt:       doc.PinnedAt.UTC(),
+		})
+	}
+	return pins, nil
+}
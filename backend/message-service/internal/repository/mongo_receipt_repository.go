@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// mongoMessageReceipt 接收者回执文档，_id由消息和用户组成
+type mongoMessageReceipt struct {
+	ID          string     `bson:"_id"`
+	MessageID   string     `bson:"message_id"`
+	UserID      string     `bson:"user_id"`
+	DeliveredAt *time.Time `bson:"delivered_at,omitempty"`
+	ReadAt      *time.Time `bson:"read_at,omitempty"`
+}
+
+// mongoReadCursor 已读位置文档，_id由会话和用户组成
+type mongoReadCursor struct {
+	ID               string    `bson:"_id"`
+	ConversationID   string    `bson:"conversation_id"`
+	UserID           string    `bson:"user_id"`
+	MessageID        string    `bson:"message_id"`
+	MessageCreatedAt time.Time `bson:"message_created_at"`
+	UpdatedAt        time.Time `bson:"updated_at"`
+}
+
+// MongoReceiptRepository 基于MongoDB的回执仓库实现
+// 条件更新与upsert组合：条件不满足时upsert因_id重复失败，表示状态已存在无需更新
+type MongoReceiptRepository struct {
+	receipts *mongo.Collection
+	cursors  *mongo.Collection
+	logger   *zap.Logger
+}
+
+// NewMongoReceiptRepository 创建一个新的MongoDB回执仓库
+func NewMongoReceiptRepository(db *mongo.Database, logger *zap.Logger) domain.ReceiptRepository {
+	return &MongoReceiptRepository{
+		receipts: db.Collection(mongoMessageReceiptsCollection),
+		cursors:  db.Collection(mongoReadCursorsCollection),
+		logger:   logger,
+	}
+}
+
+// MarkDelivered 记录送达
+func (r *MongoReceiptRepository) MarkDelivered(ctx context.Context, messageID string, userIDs []string, at time.Time) ([]string, error) {
+	var delivered []string
+	for _, userID := range userIDs {
+		updated, err := r.upsertIf(ctx, r.receipts,
+			bson.M{"_id": messageID + ":" + userID, "delivered_at": nil},
+			bson.M{
+				"$set":         bson.M{"delivered_at": at},
+				"$setOnInsert": bson.M{"message_id": messageID, "user_id": userID},
+			},
+		)
+		if err != nil {
+			return delivered, fmt.Errorf("failed to mark message delivered: %w", err)
+		}
+		if updated {
+			delivered = append(delivered, userID)
+		}
+	}
+	return delivered, nil
+}
+
+// MarkRead 记录已读，使用管道更新在同一操作中补齐送达时间
+func (r *MongoReceiptRepository) MarkRead(ctx context.Context, userID string, messageIDs []string, at time.Time) ([]string, error) {
+	var read []string
+	for _, messageID := range messageIDs {
+		updated, err := r.upsertIf(ctx, r.receipts,
+			bson.M{"_id": messageID + ":" + userID, "read_at": nil},
+			bson.A{bson.M{"$set": bson.M{
+				"message_id":   messageID,
+				"user_id":      userID,
+				"read_at":      at,
+				"delivered_at": bson.M{"$ifNull": bson.A{"$delivered_at", at}},
+			}}},
+		)
+		if err != nil {
+			return read, fmt.Errorf("failed to mark message read: %w", err)
+		}
+		if updated {
+			read = append(read, messageID)
+		}
+	}
+	return read, nil
+}
+
+// ListReceipts 获取消息的全部接收者回执
+func (r *MongoReceiptRepository) ListReceipts(ctx context.Context, messageID string) ([]*domain.MessageReceipt, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "delivered_at", Value: 1}})
+	cursor, err := r.receipts.Find(ctx, bson.M{"message_id": messageID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message receipts: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []*mongoMessageReceipt
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode message receipts: %w", err)
+	}
+
+	receipts := make([]*domain.MessageReceipt, 0, len(docs))
+	for _, doc := range docs {
+		receipts = append(receipts, domain.NewMessageReceipt(doc.MessageID, doc.UserID, utcTime(doc.DeliveredAt), utcTime(doc.ReadAt)))
+	}
+	return receipts, nil
+}
+
+// GetReadCursor 获取用户在会话中的已读位置
+func (r *MongoReceiptRepository) GetReadCursor(ctx context.Context, conversationID, userID string) (*domain.ReadCursor, error) {
+	var doc mongoReadCursor
+	err := r.cursors.FindOne(ctx, bson.M{"_id": conversationID + ":" + userID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get read cursor: %w", err)
+	}
+	return doc.toDomain(), nil
+}
+
+// AdvanceReadCursor 保存已读位置，仅当新位置晚于已保存的位置时更新
+func (r *MongoReceiptRepository) AdvanceReadCursor(ctx context.Context, cursor *domain.ReadCursor) (bool, error) {
+	updated, err := r.upsertIf(ctx, r.cursors,
+		bson.M{
+			"_id":                cursor.ConversationID + ":" + cursor.UserID,
+			"message_created_at": bson.M{"$lt": cursor.MessageCreatedAt},
+		},
+		bson.M{"$set": bson.M{
+			"conversation_id":    cursor.ConversationID,
+			"user_id":            cursor.UserID,
+			"message_id":         cursor.MessageID,
+			"message_created_at": cursor.MessageCreatedAt,
+			"updated_at":         cursor.UpdatedAt,
+		}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to advance read cursor: %w", err)
+	}
+	return updated, nil
+}
+
+// ListReadCursors 获取会话所有参与者的已读位置
+func (r *MongoReceiptRepository) ListReadCursors(ctx context.Context, conversationID string) ([]*domain.ReadCursor, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "message_created_at", Value: -1}})
+	cursor, err := r.cursors.Find(ctx, bson.M{"conversation_id": conversationID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list read cursors: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []*mongoReadCursor
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode read cursors: %w", err)
+	}
+
+	cursors := make([]*domain.ReadCursor, 0, len(docs))
+	for _, doc := range docs {
+		cursors = append(cursors, doc.toDomain())
+	}
+	return cursors, nil
+}
+
+// upsertIf 满足filter时更新，文档不存在时插入；文档存在但不满足条件时返回false
+func (r *MongoReceiptRepository) upsertIf(ctx context.Context, collection *mongo.Collection, filter bson.M, update interface{}) (bool, error) {
+	result, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return result.ModifiedCount > 0 || result.UpsertedCount > 0, nil
+}
+
+func (doc *mongoReadCursor) toDomain() *domain.ReadCursor {
+	return &domain.ReadCursor{
+		ConversationID:   doc.ConversationID,
+		UserID:           doc.UserID,
+		MessageID:        doc.MessageID,
+		MessageCreatedAt: doc.MessageCreatedAt.UTC(),
+		UpdatedAt:        doc.UpdatedAt.UTC(),
+	}
+}
+
+// utcTime 可选时间转换为UTC
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
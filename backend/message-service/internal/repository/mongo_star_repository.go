@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// mongoStarredMessage 收藏消息文档，_id由用户和消息组成
+type mongoStarredMessage struct {
+	ID             string    `bson:"_id"`
+	UserID         string    `bson:"user_id"`
+	MessageID      string    `bson:"message_id"`
+	ConversationID string    `bson:"conversation_id"`
+	StarredAt      time.Time `bson:"starred_at"`
+}
+
+// MongoStarRepository 基于MongoDB的收藏消息仓库实现
+type MongoStarRepository struct {
+	stars  *mongo.Collection
+	logger *zap.Logger
+}
+
+// NewMongoStarRepository 创建一个新的MongoDB收藏消息仓库
+func NewMongoStarRepository(db *mongo.Database, logger *zap.Logger) domain.StarRepository {
+	return &MongoStarRepository{
+		stars:  db.Collection(mongoStarredMessagesCollection),
+		logger: logger,
+	}
+}
+
+// Star 收藏消息，已收藏时插入因_id重复失败，保留原来的收藏时间
+func (r *MongoStarRepository) Star(ctx context.Context, star *domain.StarredMessage) error {
+	_, err := r.stars.InsertOne(ctx, &mongoStarredMessage{
+		ID:             star.UserID + ":" + star.MessageID,
+		UserID:         star.UserID,
+		MessageID:      star.MessageID,
+		ConversationID: star.ConversationID,
+		StarredAt:      star.StarredAt,
+	})
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("failed to star message: %w", err)
+	}
+	return nil
+}
+
+// Unstar 取消收藏
+func (r *MongoStarRepository) Unstar(ctx context.Context, userID, messageID string) error {
+	if _, err := r.stars.DeleteOne(ctx, bson.M{"_id": userID + ":" + messageID}); err != nil {
+		return fmt.Errorf("failed to unstar message: %w", err)
+	}
+	return nil
+}
+
+// ListStars 按收藏时间倒序分页列出用户收藏的消息
+func (r *MongoStarRepository) ListStars(ctx context.Context, userID string, limit, offset int) ([]*domain.StarredMessage, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "starred_at", Value: -1}, {Key: "message_id", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+	cursor, err := r.stars.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list starred messages: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []*mongoStarredMessage
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode starred messages: %w", err)
+	}
+
+	stars := make([]*domain.StarredMessage, 0, len(docs))
+	for _, doc := range docs {
+		stars = append(stars, &domain.StarredMessage{
+			UserID:         doc.UserID,
+			MessageID:      doc.MessageID,
+			ConversationID: doc.ConversationID,
+			StarredAt:      doc.StarredAt.UTC(),
+		})
+	}
+	return stars, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// MuteRepository 基于PostgreSQL的会话免打扰仓库实现
+type MuteRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewMuteRepository 创建一个新的会话免打扰仓库
+func NewMuteRepository(db *sqlx.DB, logger *zap.Logger) domain.MuteRepository {
+	return &MuteRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SetMute 保存免打扰设置，muted_until为NULL表示永久免打扰
+func (r *MuteRepository) SetMute(ctx context.Context, mute *domain.ConversationMute) error {
+	_, err := r.db.ExecContext(ctx, `
+	INSERT INTO conversation_mutes (conversation_id, user_id, muted_until, updated_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (conversation_id, user_id) DO UPDATE SET
+		muted_until = EXCLUDED.muted_until,
+		updated_at = EXCLUDED.updated_at
+	`, mute.ConversationID, mute.UserID, mute.MutedUntil, mute.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to mute conversation: %w", err)
+	}
+	return nil
+}
+
+// DeleteMute 取消免打扰
+func (r *MuteRepository) DeleteMute(ctx context.Context, conversationID, userID string) error {
+	_, err := r.db.ExecContext(ctx, `
+	DELETE FROM conversation_mutes WHERE conversation_id = $1 AND user_id = $2
+	`, conversationID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to unmute conversation: %w", err)
+	}
+	return nil
+}
+
+// ListActiveMutes 列出会话中仍然有效的免打扰设置
+func (r *MuteRepository) ListActiveMutes(ctx context.Context, conversationID string, now time.Time) ([]*domain.ConversationMute, error) {
+	var rows []struct {
+		ConversationID string     `db:"conversation_id"`
+		UserID         string     `db:"user_id"`
+		MutedUntil     *time.Time `db:"muted_until"`
+		UpdatedAt      time.Time  `db:"updated_at"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+	SELECT conversation_id, user_id, muted_until, updated_at
+	FROM conversation_mutes
+	WHERE conversation_id = $1 AND (muted_until IS NULL OR muted_until > $2)
+	`, conversationID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversation mutes: %w", err)
+	}
+
+	mutes := make([]*domain.ConversationMute, 0, len(rows))
+	for _, row := range rows {
+		mutes = append(mutes, &domain.ConversationMute{
+			ConversationID: row.ConversationID,
+			UserID:         row.UserID,
+			Muted:          true,
+			MutedUntil:     row.MutedUntil,
+			UpdatedAt:      row.UpdatedAt,
+		})
+	}
+	return mutes, nil
+}
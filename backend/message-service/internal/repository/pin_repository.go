@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// PinRepository 基于PostgreSQL的置顶消息仓库实现
+type PinRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewPinRepository 创建一个新的置顶消息仓库
+func NewPinRepository(db *sqlx.DB, logger *zap.Logger) domain.PinRepository {
+	return &PinRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Pin 置顶消息，已置顶时不更新置顶人和时间
+func (r *PinRepository) Pin(ctx context.Context, pin *domain.MessagePin) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+	INSERT INTO pinned_messages (conversation_id, message_id, pinned_by, pinned_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (conversation_id, message_id) DO NOTHING
+	`, pin.ConversationID, pin.MessageID, pin.PinnedBy, pin.PinnedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to pin message: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// Unpin 取消置顶
+func (r *PinRepository) Unpin(ctx context.Context, conversationID, messageID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+	DELETE FROM pinned_messages WHERE conversation_id = $1 AND message_id = $2
+	`, conversationID, messageID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unpin message: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// ListPins 按置顶时间倒序列出会话中的置顶消息
+func (r *PinRepository) ListPins(ctx context.Context, conversationID string) ([]*domain.MessagePin, error) {
+	var rows []struct {
+		ConversationID string    `db:"conversation_id"`
+		MessageID      string    `db:"message_id"`
+		PinnedBy       string    `db:"pinned_by"`
+		PinnedAt       time.Time `db:"pinned_at"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+	SELECT conversation_id, message_id, pinned_by, pinned_at
+	FROM pinned_messages
+	WHERE conversation_id = $1
+	ORDER BY pinned_at DESC
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pinned messages: %w", err)
+	}
+
+	pins := make([]*domain.MessagePin, 0, len(rows))
+	for _, row := range rows {
+		pins = append(pins, &domain.MessagePin{
+			ConversationID: row.ConversationID,
+			MessageID:      row.MessageID,
+			PinnedBy:       row.PinnedBy,
+			PinnedAt:       row.PinnedAt,
+		})
+	}
+	return pins, nil
+}
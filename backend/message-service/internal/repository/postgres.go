@@ -2,20 +2,26 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // PostgreSQL驱动
+	"github.com/lib/pq" // PostgreSQL驱动
 	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/message-service/pkg/tracing"
 )
 
 // NewPostgresDB 创建一个新的PostgreSQL数据库连接
 func NewPostgresDB(connStr string, logger *zap.Logger) (*sqlx.DB, error) {
-	db, err := sqlx.Connect("postgres", connStr)
+	// 请求中的查询和写入记录为请求span的子span
+	sqlDB, err := tracing.OpenPostgres(connStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	db := sqlx.NewDb(sqlDB, "postgres")
 
 	// 设置连接池参数
 	db.SetMaxOpenConns(25)
@@ -41,10 +47,15 @@ func initDB(db *sqlx.DB, logger *zap.Logger) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// 创建消息表
+	// 已存在未分区的旧消息表时先迁移
+	if err := migrateMessagesToPartitioned(ctx, db, logger); err != nil {
+		return err
+	}
+
+	// 创建消息表，按created_at每月一个分区，分区键必须包含在主键中
 	messagesTable := `
 	CREATE TABLE IF NOT EXISTS messages (
-		id UUID PRIMARY KEY,
+		id UUID NOT NULL,
 		conversation_id UUID NOT NULL,
 		sender_id UUID NOT NULL,
 		type VARCHAR(20) NOT NULL,
@@ -53,11 +64,34 @@ func initDB(db *sqlx.DB, logger *zap.Logger) error {
 		status VARCHAR(20) NOT NULL,
 		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
 		updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
-		is_group_chat BOOLEAN NOT NULL DEFAULT FALSE
-	);
-	CREATE INDEX IF NOT EXISTS idx_messages_conversation_id ON messages(conversation_id);
+		is_group_chat BOOLEAN NOT NULL DEFAULT FALSE,
+		PRIMARY KEY (id, created_at)
+	) PARTITION BY RANGE (created_at);
+	CREATE INDEX IF NOT EXISTS idx_messages_id ON messages(id);
+	CREATE INDEX IF NOT EXISTS idx_messages_conversation_id ON messages(conversation_id, created_at DESC);
 	CREATE INDEX IF NOT EXISTS idx_messages_sender_id ON messages(sender_id);
-	CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages(created_at);
+	CREATE INDEX IF NOT EXISTS idx_messages_media_id ON messages((metadata->>'media_id')) WHERE type = 'audio';
+	ALTER TABLE messages ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP WITH TIME ZONE;
+	ALTER TABLE messages ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+	ALTER TABLE messages ADD COLUMN IF NOT EXISTS ttl_seconds INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE messages ADD COLUMN IF NOT EXISTS view_once BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE messages ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+	CREATE INDEX IF NOT EXISTS idx_messages_expires_at ON messages(expires_at) WHERE expires_at IS NOT NULL AND deleted_at IS NULL;
+	ALTER TABLE messages ADD COLUMN IF NOT EXISTS reply_to_message_id UUID;
+	ALTER TABLE messages ADD COLUMN IF NOT EXISTS thread_root_id UUID;
+	CREATE INDEX IF NOT EXISTS idx_messages_thread_root_id ON messages(thread_root_id, created_at) WHERE thread_root_id IS NOT NULL;
+	`
+
+	// 创建消息编辑历史表，每次编辑保存编辑前的内容
+	editsTable := `
+	CREATE TABLE IF NOT EXISTS message_edits (
+		id UUID PRIMARY KEY,
+		message_id UUID NOT NULL,
+		editor_id UUID NOT NULL,
+		previous_content TEXT NOT NULL,
+		edited_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_message_edits_message_id ON message_edits(message_id, edited_at);
 	`
 
 	// 创建会话表
@@ -68,6 +102,7 @@ func initDB(db *sqlx.DB, logger *zap.Logger) error {
 		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
 		updated_at TIMESTAMP WITH TIME ZONE NOT NULL
 	);
+	ALTER TABLE conversations ADD COLUMN IF NOT EXISTS name VARCHAR(100) NOT NULL DEFAULT '';
 	`
 
 	// 创建会话参与者表
@@ -80,10 +115,119 @@ func initDB(db *sqlx.DB, logger *zap.Logger) error {
 		FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE
 	);
 	CREATE INDEX IF NOT EXISTS idx_participants_user_id ON conversation_participants(user_id);
+	ALTER TABLE conversation_participants ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'member';
+	`
+
+	// 创建归档元数据表，分区移到冷存储后保留的占位信息
+	archivesTable := `
+	CREATE TABLE IF NOT EXISTS message_archives (
+		id UUID PRIMARY KEY,
+		partition_name VARCHAR(64) NOT NULL UNIQUE,
+		period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+		period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+		bucket VARCHAR(255) NOT NULL,
+		object_key TEXT NOT NULL,
+		format VARCHAR(20) NOT NULL,
+		message_count BIGINT NOT NULL,
+		size_bytes BIGINT NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		archived_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		restored_at TIMESTAMP WITH TIME ZONE
+	);
+	CREATE TABLE IF NOT EXISTS message_archive_stubs (
+		archive_id UUID NOT NULL REFERENCES message_archives(id) ON DELETE CASCADE,
+		conversation_id UUID NOT NULL,
+		message_count BIGINT NOT NULL,
+		first_message_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		last_message_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		PRIMARY KEY (archive_id, conversation_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_archive_stubs_conversation_id ON message_archive_stubs(conversation_id);
+	`
+
+	// 创建回应、已读回执明细表和按消息汇总的统计表
+	// 统计表在写入明细时同步更新，读取消息列表时无需实时聚合
+	interactionsTable := `
+	CREATE TABLE IF NOT EXISTS message_reactions (
+		message_id UUID NOT NULL,
+		user_id UUID NOT NULL,
+		emoji VARCHAR(64) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		PRIMARY KEY (message_id, user_id, emoji)
+	);
+	CREATE INDEX IF NOT EXISTS idx_message_reactions_created_at ON message_reactions(created_at);
+	CREATE TABLE IF NOT EXISTS message_reads (
+		message_id UUID NOT NULL,
+		user_id UUID NOT NULL,
+		read_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		PRIMARY KEY (message_id, user_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_message_reads_read_at ON message_reads(read_at);
+	CREATE TABLE IF NOT EXISTS message_aggregates (
+		message_id UUID PRIMARY KEY,
+		reaction_counts JSONB NOT NULL DEFAULT '{}',
+		read_by TEXT[] NOT NULL DEFAULT '{}',
+		read_count INTEGER NOT NULL DEFAULT 0,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_message_aggregates_updated_at ON message_aggregates(updated_at);
+	`
+
+	// 创建每个接收者的送达/已读回执表和会话已读位置表
+	receiptsTable := `
+	CREATE TABLE IF NOT EXISTS message_receipts (
+		message_id UUID NOT NULL,
+		user_id UUID NOT NULL,
+		delivered_at TIMESTAMP WITH TIME ZONE,
+		read_at TIMESTAMP WITH TIME ZONE,
+		PRIMARY KEY (message_id, user_id)
+	);
+	CREATE TABLE IF NOT EXISTS conversation_read_cursors (
+		conversation_id UUID NOT NULL,
+		user_id UUID NOT NULL,
+		message_id UUID NOT NULL,
+		message_created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		PRIMARY KEY (conversation_id, user_id)
+	);
+	`
+
+	// 创建会话置顶消息表
+	pinsTable := `
+	CREATE TABLE IF NOT EXISTS pinned_messages (
+		conversation_id UUID NOT NULL,
+		message_id UUID NOT NULL,
+		pinned_by UUID NOT NULL,
+		pinned_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		PRIMARY KEY (conversation_id, message_id)
+	);
+	`
+
+	// 创建会话免打扰表，muted_until为NULL表示永久免打扰
+	mutesTable := `
+	CREATE TABLE IF NOT EXISTS conversation_mutes (
+		conversation_id UUID NOT NULL,
+		user_id UUID NOT NULL,
+		muted_until TIMESTAMP WITH TIME ZONE,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		PRIMARY KEY (conversation_id, user_id)
+	);
+	`
+
+	// 创建收藏消息表，按用户分页列出收藏
+	starsTable := `
+	CREATE TABLE IF NOT EXISTS starred_messages (
+		user_id UUID NOT NULL,
+		message_id UUID NOT NULL,
+		conversation_id UUID NOT NULL,
+		starred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		PRIMARY KEY (user_id, message_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_starred_messages_user_id ON starred_messages(user_id, starred_at DESC);
 	`
 
 	// 执行SQL语句
-	queries := []string{messagesTable, conversationsTable, participantsTable}
+	queries := []string{messagesTable, editsTable, conversationsTable, participantsTable, archivesTable, interactionsTable, receiptsTable, pinsTable, mutesTable, starsTable}
 	for _, query := range queries {
 		_, err := db.ExecContext(ctx, query)
 		if err != nil {
@@ -91,6 +235,108 @@ func initDB(db *sqlx.DB, logger *zap.Logger) error {
 		}
 	}
 
+	// 预先创建当月分区，之后的分区由归档任务和写入时按需创建
+	if err := ensureMonthlyPartition(ctx, db, time.Now().UTC()); err != nil {
+		return err
+	}
+
 	logger.Info("Database tables initialized successfully")
 	return nil
-}
\ No newline at end of file
+}
+
+// migrateMessagesToPartitioned 把未分区的旧消息表迁移为按月分区表，整个过程在一个事务内完成
+func migrateMessagesToPartitioned(ctx context.Context, db *sqlx.DB, logger *zap.Logger) error {
+	var relkind string
+	err := db.GetContext(ctx, &relkind, `SELECT relkind::text FROM pg_class WHERE oid = to_regclass('messages')`)
+	if err == sql.ErrNoRows || (err == nil && relkind != "r") {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to inspect messages table: %w", err)
+	}
+
+	logger.Info("Migrating messages table to monthly partitions")
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	// 旧表的主键和索引名会与新表冲突，先释放
+	statements := []string{
+		`ALTER TABLE messages RENAME TO messages_unpartitioned`,
+		`ALTER TABLE messages_unpartitioned DROP CONSTRAINT IF EXISTS messages_pkey`,
+		`DROP INDEX IF EXISTS idx_messages_conversation_id`,
+		`DROP INDEX IF EXISTS idx_messages_sender_id`,
+		`DROP INDEX IF EXISTS idx_messages_created_at`,
+		`CREATE TABLE messages (LIKE messages_unpartitioned INCLUDING DEFAULTS, PRIMARY KEY (id, created_at)) PARTITION BY RANGE (created_at)`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to migrate messages table: %w\nQuery: %s", err, stmt)
+		}
+	}
+
+	var months []time.Time
+	if err := tx.SelectContext(ctx, &months, `
+	SELECT DISTINCT date_trunc('month', created_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+	FROM messages_unpartitioned
+	`); err != nil {
+		return fmt.Errorf("failed to list message months: %w", err)
+	}
+	for _, month := range months {
+		if err := ensureMonthlyPartition(ctx, tx, month); err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `INSERT INTO messages SELECT * FROM messages_unpartitioned`)
+	if err != nil {
+		return fmt.Errorf("failed to copy messages into partitions: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DROP TABLE messages_unpartitioned`); err != nil {
+		return fmt.Errorf("failed to drop unpartitioned messages table: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	copied, _ := result.RowsAffected()
+	logger.Info("Messages table migrated to monthly partitions", zap.Int64("messages", copied), zap.Int("partitions", len(months)))
+	return nil
+}
+
+// knownPartitions 进程内已确认存在的分区名，消息仓库写入前据此跳过建表，归档删除分区后移除
+var knownPartitions sync.Map
+
+// ensureMonthlyPartition 创建month所在月份的消息分区（已存在时忽略）
+func ensureMonthlyPartition(ctx context.Context, db sqlx.ExecerContext, month time.Time) error {
+	start, end := monthBounds(month)
+	query := fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s PARTITION OF messages FOR VALUES FROM ('%s') TO ('%s')`,
+		pq.QuoteIdentifier(partitionName(start)),
+		start.Format(time.RFC3339),
+		end.Format(time.RFC3339),
+	)
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create message partition %s: %w", partitionName(start), err)
+	}
+	return nil
+}
+
+// partitionNameLayout 分区表名格式，如 messages_p2024_01
+const partitionNameLayout = "messages_p2006_01"
+
+// partitionName 月份对应的分区表名
+func partitionName(month time.Time) string {
+	return month.UTC().Format(partitionNameLayout)
+}
+
+// monthBounds 返回t所在月份的起止时间（UTC，左闭右开）
+func monthBounds(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// ReceiptRepository 基于PostgreSQL的送达、已读回执和会话已读位置仓库实现
+type ReceiptRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewReceiptRepository 创建一个新的回执仓库
+func NewReceiptRepository(db *sqlx.DB, logger *zap.Logger) domain.ReceiptRepository {
+	return &ReceiptRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// MarkDelivered 记录送达，已送达的用户不更新
+func (r *ReceiptRepository) MarkDelivered(ctx context.Context, messageID string, userIDs []string, at time.Time) ([]string, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	var delivered []string
+	err := r.db.SelectContext(ctx, &delivered, `
+	INSERT INTO message_receipts (message_id, user_id, delivered_at)
+	SELECT $1::uuid, user_id, $3::timestamptz FROM unnest($2::uuid[]) AS user_id
+	ON CONFLICT (message_id, user_id) DO UPDATE SET delivered_at = EXCLUDED.delivered_at
+	WHERE message_receipts.delivered_at IS NULL
+	RETURNING user_id
+	`, messageID, pq.Array(userIDs), at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark messages delivered: %w", err)
+	}
+	return delivered, nil
+}
+
+// MarkRead 记录已读，已读的消息不更新
+func (r *ReceiptRepository) MarkRead(ctx context.Context, userID string, messageIDs []string, at time.Time) ([]string, error) {
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
+
+	var read []string
+	err := r.db.SelectContext(ctx, &read, `
+	INSERT INTO message_receipts (message_id, user_id, delivered_at, read_at)
+	SELECT message_id, $2::uuid, $3::timestamptz, $3::timestamptz FROM unnest($1::uuid[]) AS message_id
+	ON CONFLICT (message_id, user_id) DO UPDATE SET
+		delivered_at = COALESCE(message_receipts.delivered_at, EXCLUDED.delivered_at),
+		read_at = EXCLUDED.read_at
+	WHERE message_receipts.read_at IS NULL
+	RETURNING message_id
+	`, pq.Array(messageIDs), userID, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark messages read: %w", err)
+	}
+	return read, nil
+}
+
+// ListReceipts 获取消息的全部接收者回执，按最近状态时间排序
+func (r *ReceiptRepository) ListReceipts(ctx context.Context, messageID string) ([]*domain.MessageReceipt, error) {
+	var rows []struct {
+		MessageID   string     `db:"message_id"`
+		UserID      string     `db:"user_id"`
+		DeliveredAt *time.Time `db:"delivered_at"`
+		ReadAt      *time.Time `db:"read_at"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+	SELECT message_id, user_id, delivered_at, read_at
+	FROM message_receipts
+	WHERE message_id = $1
+	ORDER BY COALESCE(read_at, delivered_at)
+	`, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message receipts: %w", err)
+	}
+
+	receipts := make([]*domain.MessageReceipt, 0, len(rows))
+	for _, row := range rows {
+		receipts = append(receipts, domain.NewMessageReceipt(row.MessageID, row.UserID, row.DeliveredAt, row.ReadAt))
+	}
+	return receipts, nil
+}
+
+// GetReadCursor 获取用户在会话中的已读位置
+func (r *ReceiptRepository) GetReadCursor(ctx context.Context, conversationID, userID string) (*domain.ReadCursor, error) {
+	var cursor readCursorRow
+	err := r.db.GetContext(ctx, &cursor, `
+	SELECT conversation_id, user_id, message_id, message_created_at, updated_at
+	FROM conversation_read_cursors
+	WHERE conversation_id = $1 AND user_id = $2
+	`, conversationID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get read cursor: %w", err)
+	}
+	return cursor.toDomain(), nil
+}
+
+// AdvanceReadCursor 保存已读位置，并发推进时以消息时间较晚的为准
+func (r *ReceiptRepository) AdvanceReadCursor(ctx context.Context, cursor *domain.ReadCursor) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+	INSERT INTO conversation_read_cursors (conversation_id, user_id, message_id, message_created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (conversation_id, user_id) DO UPDATE SET
+		message_id = EXCLUDED.message_id,
+		message_created_at = EXCLUDED.message_created_at,
+		updated_at = EXCLUDED.updated_at
+	WHERE conversation_read_cursors.message_created_at < EXCLUDED.message_created_at
+	`, cursor.ConversationID, cursor.UserID, cursor.MessageID, cursor.MessageCreatedAt, cursor.UpdatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to advance read cursor: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// ListReadCursors 获取会话所有参与者的已读位置
+func (r *ReceiptRepository) ListReadCursors(ctx context.Context, conversationID string) ([]*domain.ReadCursor, error) {
+	var rows []readCursorRow
+	err := r.db.SelectContext(ctx, &rows, `
+	SELECT conversation_id, user_id, message_id, message_created_at, updated_at
+	FROM conversation_read_cursors
+	WHERE conversation_id = $1
+	ORDER BY message_created_at DESC
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list read cursors: %w", err)
+	}
+
+	cursors := make([]*domain.ReadCursor, 0, len(rows))
+	for _, row := range rows {
+		cursors = append(cursors, row.toDomain())
+	}
+	return cursors, nil
+}
+
+// readCursorRow 已读位置表的行
+type readCursorRow struct {
+	ConversationID   string    `db:"conversation_id"`
+	UserID           string    `db:"user_id"`
+	MessageID        string    `db:"message_id"`
+	MessageCreatedAt time.Time `db:"message_created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
+
+func (row readCursorRow) toDomain() *domain.ReadCursor {
+	return &domain.ReadCursor{
+		ConversationID:   row.ConversationID,
+		UserID:           row.UserID,
+		MessageID:        row.MessageID,
+		MessageCreatedAt: row.MessageCreatedAt,
+		UpdatedAt:        row.UpdatedAt,
+	}
+}
@@ -0,0 +1,412 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// redisMessageKeyPrefix 最近消息缓存键前缀
+	redisMessageKeyPrefix = "msgstore:"
+	// loadedField 会话消息哈希中的标记字段，存在表示该会话的最近消息已完整加载（会话可能没有消息）
+	loadedField = "_loaded"
+)
+
+// appendScript 递增会话版本，并向已加载的会话追加消息、裁剪到上限
+// 会话未加载时不写入，避免缓存只有部分最近消息
+// KEYS[1]=有序集合 KEYS[2]=消息哈希 KEYS[3]=会话版本 ARGV: 分数, 消息ID, 消息JSON, 上限, 有效期(毫秒)
+var appendScript = redis.NewScript(`
+redis.call('INCR', KEYS[3])
+redis.call('PEXPIRE', KEYS[3], ARGV[5])
+if redis.call('HEXISTS', KEYS[2], '` + loadedField + `') == 0 then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])
+redis.call('HSET', KEYS[2], ARGV[2], ARGV[3])
+local excess = redis.call('ZCARD', KEYS[1]) - tonumber(ARGV[4])
+if excess > 0 then
+	local old = redis.call('ZRANGE', KEYS[1], 0, excess - 1)
+	redis.call('ZREMRANGEBYRANK', KEYS[1], 0, excess - 1)
+	redis.call('HDEL', KEYS[2], unpack(old))
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+redis.call('PEXPIRE', KEYS[2], ARGV[5])
+return 1
+`)
+
+// RedisMessageRepository 在持久化存储之上用Redis保存每个会话的最近消息
+// 最近的RecentLimit条以内的历史查询直接由Redis返回，不访问PostgreSQL/MongoDB；写入先落持久化存储再更新Redis
+// 会话相关方法直接使用持久化存储
+type RedisMessageRepository struct {
+	domain.MessageRepository
+	client *redis.Client
+	ttl    time.Duration
+	limit  int
+	logger *zap.Logger
+}
+
+// NewRedisMessageRepository 创建Redis最近消息仓库，store为持久化存储
+func NewRedisMessageRepository(client *redis.Client, store domain.MessageRepository, ttl time.Duration, limit int, logger *zap.Logger) domain.MessageRepository {
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	if limit <= 0 {
+		limit = 200
+	}
+	return &RedisMessageRepository{
+		MessageRepository: store,
+		client:            client,
+		ttl:               ttl,
+		limit:             limit,
+		logger:            logger,
+	}
+}
+
+// Create 创建消息，持久化成功后追加到已加载会话的最近消息中
+func (r *RedisMessageRepository) Create(ctx context.Context, message *domain.Message) error {
+	if err := r.MessageRepository.Create(ctx, message); err != nil {
+		return err
+	}
+
+	data, err := marshalCachedMessage(message)
+	if err != nil {
+		r.logger.Warn("Failed to marshal message for redis", zap.String("message_id", message.ID), zap.Error(err))
+		r.invalidate(ctx, message.Conversation)
+		return nil
+	}
+
+	keys := []string{recentKey(message.Conversation), messagesKey(message.Conversation), versionKey(message.Conversation)}
+	args := []interface{}{message.CreatedAt.UnixMicro(), message.ID, data, r.limit, r.ttl.Milliseconds()}
+	if err := appendScript.Run(ctx, r.client, keys, args...).Err(); err != nil {
+		// 追加失败时删除该会话的缓存，下次读取重新加载
+		r.logger.Warn("Failed to append message to redis", zap.String("message_id", message.ID), zap.Error(err))
+		r.invalidate(ctx, message.Conversation)
+		return nil
+	}
+	r.indexMessage(ctx, message)
+	return nil
+}
+
+// GetConversationMessages 获取会话消息，按创建时间倒序
+// 请求范围在最近消息以内时由Redis返回，未加载的会话先从持久化存储加载最近消息
+func (r *RedisMessageRepository) GetConversationMessages(ctx context.Context, conversationID string, limit, offset int) ([]*domain.Message, error) {
+	if offset < 0 || limit <= 0 || offset+limit > r.limit {
+		return r.MessageRepository.GetConversationMessages(ctx, conversationID, limit, offset)
+	}
+
+	messages, ok := r.readRecent(ctx, conversationID, limit, offset)
+	if ok {
+		return messages, nil
+	}
+
+	// 读取持久化存储前记录会话版本，期间有新的写入时放弃加载
+	version, err := r.client.Get(ctx, versionKey(conversationID)).Result()
+	if err != nil && err != redis.Nil {
+		r.logger.Warn("Failed to read conversation version from redis", zap.String("conversation_id", conversationID), zap.Error(err))
+		return r.MessageRepository.GetConversationMessages(ctx, conversationID, limit, offset)
+	}
+
+	recent, err := r.MessageRepository.GetConversationMessages(ctx, conversationID, r.limit, 0)
+	if err != nil {
+		return nil, err
+	}
+	r.load(ctx, conversationID, version, recent)
+
+	if offset >= len(recent) {
+		return []*domain.Message{}, nil
+	}
+	end := offset + limit
+	if end > len(recent) {
+		end = len(recent)
+	}
+	return recent[offset:end], nil
+}
+
+// UpdateStatus 更新消息状态，同步修改Redis中的副本
+func (r *RedisMessageRepository) UpdateStatus(ctx context.Context, id string, status domain.MessageStatus) error {
+	if err := r.MessageRepository.UpdateStatus(ctx, id, status); err != nil {
+		return err
+	}
+
+	r.updateMessage(ctx, id, func(message *domain.Message) {
+		message.Status = status
+		message.UpdatedAt = time.Now()
+	})
+	return nil
+}
+
+// EditContent 修改消息内容，同步修改Redis中的副本
+func (r *RedisMessageRepository) EditContent(ctx context.Context, edit *domain.MessageEdit, content string) error {
+	if err := r.MessageRepository.EditContent(ctx, edit, content); err != nil {
+		return err
+	}
+
+	editedAt := edit.EditedAt
+	r.updateMessage(ctx, edit.MessageID, func(message *domain.Message) {
+		message.Content = content
+		message.EditedAt = &editedAt
+		message.UpdatedAt = editedAt
+	})
+	return nil
+}
+
+// SoftDelete 撤回消息，同步清空Redis中副本的内容
+func (r *RedisMessageRepository) SoftDelete(ctx context.Context, id string, deletedAt time.Time) error {
+	if err := r.MessageRepository.SoftDelete(ctx, id, deletedAt); err != nil {
+		return err
+	}
+
+	r.updateMessage(ctx, id, func(message *domain.Message) {
+		message.Content = ""
+		message.Metadata = nil
+		message.DeletedAt = &deletedAt
+		message.UpdatedAt = deletedAt
+	})
+	return nil
+}
+
+// SetExpiry 设置消息的自毁时间，同步修改Redis中的副本
+func (r *RedisMessageRepository) SetExpiry(ctx context.Context, id string, expiresAt time.Time) error {
+	if err := r.MessageRepository.SetExpiry(ctx, id, expiresAt); err != nil {
+		return err
+	}
+
+	r.updateMessage(ctx, id, func(message *domain.Message) {
+		if message.DeletedAt == nil && (message.ExpiresAt == nil || message.ExpiresAt.After(expiresAt)) {
+			message.ExpiresAt = &expiresAt
+		}
+	})
+	return nil
+}
+
+// SetMediaTranscript 写入语音消息转写文本，并删除引用该媒体文件的会话缓存
+func (r *RedisMessageRepository) SetMediaTranscript(ctx context.Context, mediaID string, transcript *domain.MediaTranscript) (int, error) {
+	count, err := r.MessageRepository.SetMediaTranscript(ctx, mediaID, transcript)
+	if err != nil || count == 0 {
+		return count, err
+	}
+
+	conversationIDs, err := r.client.SMembers(ctx, mediaIndexKey(mediaID)).Result()
+	if err != nil {
+		r.logger.Warn("Failed to read media index from redis", zap.String("media_id", mediaID), zap.Error(err))
+		return count, nil
+	}
+	for _, conversationID := range conversationIDs {
+		r.invalidate(ctx, conversationID)
+	}
+	return count, nil
+}
+
+// readRecent 从Redis读取最近消息，会话未加载或数据不完整时返回false
+func (r *RedisMessageRepository) readRecent(ctx context.Context, conversationID string, limit, offset int) ([]*domain.Message, bool) {
+	hashKey := messagesKey(conversationID)
+
+	pipe := r.client.Pipeline()
+	loaded := pipe.HExists(ctx, hashKey, loadedField)
+	ids := pipe.ZRevRange(ctx, recentKey(conversationID), int64(offset), int64(offset+limit-1))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		r.logger.Warn("Failed to read recent messages from redis", zap.String("conversation_id", conversationID), zap.Error(err))
+		return nil, false
+	}
+	if !loaded.Val() {
+		return nil, false
+	}
+
+	messages := make([]*domain.Message, 0, len(ids.Val()))
+	if len(ids.Val()) == 0 {
+		return messages, true
+	}
+
+	values, err := r.client.HMGet(ctx, hashKey, ids.Val()...).Result()
+	if err != nil {
+		r.logger.Warn("Failed to read recent messages from redis", zap.String("conversation_id", conversationID), zap.Error(err))
+		return nil, false
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		var message domain.Message
+		if err := json.Unmarshal([]byte(data), &message); err != nil {
+			return nil, false
+		}
+		messages = append(messages, &message)
+	}
+
+	// 活跃会话在被读取时续期
+	pipe = r.client.Pipeline()
+	pipe.PExpire(ctx, recentKey(conversationID), r.ttl)
+	pipe.PExpire(ctx, hashKey, r.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Debug("Failed to refresh recent messages ttl", zap.String("conversation_id", conversationID), zap.Error(err))
+	}
+	return messages, true
+}
+
+// load 用持久化存储中的最近消息（倒序）重建会话缓存，会话版本与读取前不一致时放弃
+func (r *RedisMessageRepository) load(ctx context.Context, conversationID, version string, messages []*domain.Message) {
+	zsetKey := recentKey(conversationID)
+	hashKey := messagesKey(conversationID)
+
+	entries := make(map[string]string, len(messages))
+	for _, message := range messages {
+		data, err := marshalCachedMessage(message)
+		if err != nil {
+			r.logger.Warn("Failed to marshal message for redis", zap.String("message_id", message.ID), zap.Error(err))
+			return
+		}
+		entries[message.ID] = data
+	}
+
+	err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, versionKey(conversationID)).Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if current != version {
+			return redis.TxFailedErr
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, zsetKey, hashKey)
+			pipe.HSet(ctx, hashKey, loadedField, "1")
+			for _, message := range messages {
+				pipe.ZAdd(ctx, zsetKey, redis.Z{Score: float64(message.CreatedAt.UnixMicro()), Member: message.ID})
+				pipe.HSet(ctx, hashKey, message.ID, entries[message.ID])
+			}
+			pipe.PExpire(ctx, zsetKey, r.ttl)
+			pipe.PExpire(ctx, hashKey, r.ttl)
+			return nil
+		})
+		return err
+	}, versionKey(conversationID))
+	if err == redis.TxFailedErr {
+		// 加载期间会话有新的写入，下次读取再加载
+		return
+	}
+	if err != nil {
+		r.logger.Warn("Failed to load recent messages into redis", zap.String("conversation_id", conversationID), zap.Error(err))
+		return
+	}
+
+	for _, message := range messages {
+		r.indexMessage(ctx, message)
+	}
+}
+
+// updateMessage 持久化存储更新成功后修改消息所在会话缓存中的副本，失败时删除该会话的缓存
+func (r *RedisMessageRepository) updateMessage(ctx context.Context, id string, update func(message *domain.Message)) {
+	conversationID, err := r.client.Get(ctx, messageIndexKey(id)).Result()
+	if err == redis.Nil {
+		return
+	}
+	if err != nil {
+		r.logger.Warn("Failed to read message index from redis", zap.String("message_id", id), zap.Error(err))
+		return
+	}
+
+	if err := r.bumpVersion(ctx, conversationID); err != nil {
+		r.logger.Warn("Failed to bump conversation version in redis", zap.String("conversation_id", conversationID), zap.Error(err))
+	}
+	if err := r.updateCached(ctx, conversationID, id, update); err != nil {
+		r.logger.Warn("Failed to update message in redis", zap.String("message_id", id), zap.Error(err))
+		r.invalidate(ctx, conversationID)
+	}
+}
+
+// updateCached 修改Redis中的消息副本，消息不在缓存中时忽略
+func (r *RedisMessageRepository) updateCached(ctx context.Context, conversationID, id string, update func(message *domain.Message)) error {
+	hashKey := messagesKey(conversationID)
+
+	data, err := r.client.HGet(ctx, hashKey, id).Result()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var message domain.Message
+	if err := json.Unmarshal([]byte(data), &message); err != nil {
+		return err
+	}
+	update(&message)
+
+	updated, err := marshalCachedMessage(&message)
+	if err != nil {
+		return err
+	}
+	return r.client.HSet(ctx, hashKey, id, updated).Err()
+}
+
+// indexMessage 记录消息所属会话，语音消息同时记录媒体文件所在的会话，用于更新和失效
+func (r *RedisMessageRepository) indexMessage(ctx context.Context, message *domain.Message) {
+	pipe := r.client.Pipeline()
+	pipe.Set(ctx, messageIndexKey(message.ID), message.Conversation, r.ttl)
+	if mediaID, ok := message.Metadata[domain.MetadataMediaID].(string); ok && mediaID != "" {
+		pipe.SAdd(ctx, mediaIndexKey(mediaID), message.Conversation)
+		pipe.PExpire(ctx, mediaIndexKey(mediaID), r.ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Debug("Failed to index message in redis", zap.String("message_id", message.ID), zap.Error(err))
+	}
+}
+
+// bumpVersion 递增会话版本，使进行中的加载失效
+func (r *RedisMessageRepository) bumpVersion(ctx context.Context, conversationID string) error {
+	pipe := r.client.Pipeline()
+	pipe.Incr(ctx, versionKey(conversationID))
+	pipe.PExpire(ctx, versionKey(conversationID), r.ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// invalidate 删除会话的最近消息缓存
+func (r *RedisMessageRepository) invalidate(ctx context.Context, conversationID string) {
+	if err := r.client.Del(ctx, recentKey(conversationID), messagesKey(conversationID)).Err(); err != nil {
+		r.logger.Warn("Failed to invalidate recent messages in redis", zap.String("conversation_id", conversationID), zap.Error(err))
+	}
+}
+
+// marshalCachedMessage 序列化消息，统计数据在读取时另行附加，不写入缓存
+func marshalCachedMessage(message *domain.Message) (string, error) {
+	copied := *message
+	copied.Aggregates = nil
+	data, err := json.Marshal(&copied)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// recentKey 会话最近消息ID有序集合，分数为创建时间
+func recentKey(conversationID string) string {
+	return fmt.Sprintf("%sconv:%s:recent", redisMessageKeyPrefix, conversationID)
+}
+
+// messagesKey 会话最近消息内容哈希，字段为消息ID
+func messagesKey(conversationID string) string {
+	return fmt.Sprintf("%sconv:%s:messages", redisMessageKeyPrefix, conversationID)
+}
+
+// versionKey 会话写入版本
+func versionKey(conversationID string) string {
+	return fmt.Sprintf("%sconv:%s:version", redisMessageKeyPrefix, conversationID)
+}
+
+// messageIndexKey 消息所属会话
+func messageIndexKey(messageID string) string {
+	return fmt.Sprintf("%smsg:%s", redisMessageKeyPrefix, messageID)
+}
+
+// mediaIndexKey 引用媒体文件的会话集合
+func mediaIndexKey(mediaID string) string {
+	return fmt.Sprintf("%smedia:%s", redisMessageKeyPrefix, mediaID)
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// presenceKeyPrefix 在线状态键前缀，presence:<userID>:conns 为连接有序集合（分数为最后心跳毫秒时间戳），
+	// presence:<userID>:last_seen 为最后断开连接的毫秒时间戳
+	presenceKeyPrefix = "presence:"
+)
+
+// presenceConnectScript 清理超时连接后登记新连接，返回登记前的存活连接数
+// KEYS[1]=连接有序集合 ARGV: 当前时间, 超时界限, 连接ID, 有效期(毫秒)
+var presenceConnectScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[2])
+local before = redis.call('ZCARD', KEYS[1])
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return before
+`)
+
+// presenceDisconnectScript 删除连接和超时连接并记录最后在线时间，返回剩余的存活连接数
+// KEYS[1]=连接有序集合 KEYS[2]=最后在线时间 ARGV: 当前时间, 超时界限, 连接ID
+var presenceDisconnectScript = redis.NewScript(`
+redis.call('ZREM', KEYS[1], ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[2])
+redis.call('SET', KEYS[2], ARGV[1])
+return redis.call('ZCARD', KEYS[1])
+`)
+
+// RedisPresenceRepository 基于Redis的在线状态仓库，多个消息服务实例共享
+// 实例异常退出时连接不会被删除，心跳超时后自动视为离线，最后心跳时间作为最后在线时间
+type RedisPresenceRepository struct {
+	client  *redis.Client
+	timeout time.Duration
+}
+
+// NewRedisPresenceRepository 创建Redis在线状态仓库，timeout为心跳超时时间
+func NewRedisPresenceRepository(client *redis.Client, timeout time.Duration) domain.PresenceRepository {
+	return &RedisPresenceRepository{client: client, timeout: timeout}
+}
+
+// Connect 记录连接
+func (r *RedisPresenceRepository) Connect(ctx context.Context, userID, connectionID string, at time.Time) (bool, error) {
+	before, err := presenceConnectScript.Run(ctx, r.client,
+		[]string{presenceConnsKey(userID)},
+		at.UnixMilli(), at.Add(-r.timeout).UnixMilli(), connectionID, (2 * r.timeout).Milliseconds(),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return before == 0, nil
+}
+
+// Disconnect 删除连接
+func (r *RedisPresenceRepository) Disconnect(ctx context.Context, userID, connectionID string, at time.Time) (bool, error) {
+	remaining, err := presenceDisconnectScript.Run(ctx, r.client,
+		[]string{presenceConnsKey(userID), presenceLastSeenKey(userID)},
+		at.UnixMilli(), at.Add(-r.timeout).UnixMilli(), connectionID,
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return remaining == 0, nil
+}
+
+// Heartbeat 刷新连接的心跳时间，已被清理的连接不会重新登记
+func (r *RedisPresenceRepository) Heartbeat(ctx context.Context, userID string, connectionIDs []string, at time.Time) error {
+	if len(connectionIDs) == 0 {
+		return nil
+	}
+
+	members := make([]redis.Z, 0, len(connectionIDs))
+	for _, id := range connectionIDs {
+		members = append(members, redis.Z{Score: float64(at.UnixMilli()), Member: id})
+	}
+
+	key := presenceConnsKey(userID)
+	pipe := r.client.Pipeline()
+	pipe.ZAddXX(ctx, key, members...)
+	pipe.PExpire(ctx, key, 2*r.timeout)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetPresence 批量查询在线状态
+func (r *RedisPresenceRepository) GetPresence(ctx context.Context, userIDs []string, now time.Time) ([]*domain.Presence, error) {
+	if len(userIDs) == 0 {
+		return []*domain.Presence{}, nil
+	}
+
+	pipe := r.client.Pipeline()
+	latest := make([]*redis.ZSliceCmd, len(userIDs))
+	lastSeen := make([]*redis.StringCmd, len(userIDs))
+	for i, userID := range userIDs {
+		latest[i] = pipe.ZRevRangeWithScores(ctx, presenceConnsKey(userID), 0, 0)
+		lastSeen[i] = pipe.Get(ctx, presenceLastSeenKey(userID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	staleBefore := now.Add(-r.timeout).UnixMilli()
+	result := make([]*domain.Presence, 0, len(userIDs))
+	for i, userID := range userIDs {
+		presence := &domain.Presence{UserID: userID, Status: domain.PresenceOffline}
+
+		var seenAt int64
+		if connections, err := latest[i].Result(); err == nil && len(connections) > 0 {
+			heartbeat := int64(connections[0].Score)
+			if heartbeat >= staleBefore {
+				presence.Status = domain.PresenceOnline
+				result = append(result, presence)
+				continue
+			}
+			seenAt = heartbeat
+		}
+		if value, err := lastSeen[i].Result(); err == nil {
+			if disconnected, err := strconv.ParseInt(value, 10, 64); err == nil && disconnected > seenAt {
+				seenAt = disconnected
+			}
+		}
+		if seenAt > 0 {
+			t := time.UnixMilli(seenAt)
+			presence.LastSeen = &t
+		}
+		result = append(result, presence)
+	}
+	return result, nil
+}
+
+func presenceConnsKey(userID string) string {
+	return presenceKeyPrefix + userID + ":conns"
+}
+
+func presenceLastSeenKey(userID string) string {
+	return presenceKeyPrefix + userID + ":last_seen"
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/neohope/chatapp/message-service/config"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// S3ColdStorage 基于S3的归档冷存储，兼容MinIO等S3协议存储
+type S3ColdStorage struct {
+	bucket       string
+	storageClass string
+	uploader     *s3manager.Uploader
+	downloader   *s3manager.Downloader
+	logger       *zap.Logger
+}
+
+// NewS3ColdStorage 创建S3冷存储
+func NewS3ColdStorage(cfg config.ArchiveConfig, logger *zap.Logger) (domain.ColdStorage, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("archive bucket is not configured")
+	}
+
+	awsConfig := &aws.Config{
+		Region: aws.String(cfg.Region),
+	}
+	if cfg.AccessKeyID != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.SecretAccessKey, "")
+	}
+	if cfg.Endpoint != "" {
+		awsConfig.Endpoint = aws.String(cfg.Endpoint)
+		awsConfig.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %w", err)
+	}
+
+	return &S3ColdStorage{
+		bucket:       cfg.Bucket,
+		storageClass: cfg.StorageClass,
+		uploader:     s3manager.NewUploader(sess),
+		downloader:   s3manager.NewDownloader(sess),
+		logger:       logger,
+	}, nil
+}
+
+// Bucket 返回存储桶名称
+func (s *S3ColdStorage) Bucket() string {
+	return s.bucket
+}
+
+// Upload 上传归档文件，大文件自动分片上传
+func (s *S3ColdStorage) Upload(ctx context.Context, key string, body io.ReadSeeker) error {
+	input := &s3manager.UploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String("application/vnd.apache.parquet"),
+	}
+	if s.storageClass != "" {
+		input.StorageClass = aws.String(s.storageClass)
+	}
+
+	if _, err := s.uploader.UploadWithContext(ctx, input); err != nil {
+		return fmt.Errorf("failed to upload archive %s: %w", key, err)
+	}
+
+	s.logger.Info("Archive uploaded", zap.String("bucket", s.bucket), zap.String("key", key))
+	return nil
+}
+
+// Download 下载归档文件
+func (s *S3ColdStorage) Download(ctx context.Context, key string, w io.WriterAt) (int64, error) {
+	n, err := s.downloader.DownloadWithContext(ctx, w, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to download archive %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// ColdStorageObserver 冷存储操作观察者，每次上传或下载完成后调用，err为nil表示成功
+type ColdStorageObserver func(backend, operation string, start time.Time, err error)
+
+// instrumentedColdStorage 记录每次冷存储操作的结果和耗时
+type instrumentedColdStorage struct {
+	domain.ColdStorage
+	observe ColdStorageObserver
+}
+
+// InstrumentColdStorage 为冷存储添加操作观察者
+func InstrumentColdStorage(storage domain.ColdStorage, observe ColdStorageObserver) domain.ColdStorage {
+	return &instrumentedColdStorage{ColdStorage: storage, observe: observe}
+}
+
+// Upload 上传归档文件并记录结果
+func (s *instrumentedColdStorage) Upload(ctx context.Context, key string, body io.ReadSeeker) error {
+	start := time.Now()
+	err := s.ColdStorage.Upload(ctx, key, body)
+	s.observe("s3", "upload", start, err)
+	return err
+}
+
+// Download 下载归档文件并记录结果
+func (s *instrumentedColdStorage) Download(ctx context.Context, key string, w io.WriterAt) (int64, error) {
+	start := time.Now()
+	n, err := s.ColdStorage.Download(ctx, key, w)
+	s.observe("s3", "download", start, err)
+	return n, err
+}
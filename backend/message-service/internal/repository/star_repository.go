@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// StarRepository 基于PostgreSQL的收藏消息仓库实现
+type StarRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewStarRepository 创建一个新的收藏消息仓库
+func NewStarRepository(db *sqlx.DB, logger *zap.Logger) domain.StarRepository {
+	return &StarRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Star 收藏消息，已收藏时不修改收藏时间
+func (r *StarRepository) Star(ctx context.Context, star *domain.StarredMessage) error {
+	_, err := r.db.ExecContext(ctx, `
+	INSERT INTO starred_messages (user_id, message_id, conversation_id, starred_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (user_id, message_id) DO NOTHING
+	`, star.UserID, star.MessageID, star.ConversationID, star.StarredAt)
+	if err != nil {
+		return fmt.Errorf("failed to star message: %w", err)
+	}
+	return nil
+}
+
+// Unstar 取消收藏
+func (r *StarRepository) Unstar(ctx context.Context, userID, messageID string) error {
+	_, err := r.db.ExecContext(ctx, `
+	DELETE FROM starred_messages WHERE user_id = $1 AND message_id = $2
+	`, userID, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to unstar message: %w", err)
+	}
+	return nil
+}
+
+// ListStars 按收藏时间倒序分页列出用户收藏的消息
+func (r *StarRepository) ListStars(ctx context.Context, userID string, limit, offset int) ([]*domain.StarredMessage, error) {
+	var rows []struct {
+		UserID         string    `db:"user_id"`
+		MessageID      string    `db:"message_id"`
+		ConversationID string    `db:"conversation_id"`
+		StarredAt      time.Time `db:"starred_at"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+	SELECT user_id, message_id, conversation_id, starred_at
+	FROM starred_messages
+	WHERE user_id = $1
+	ORDER BY starred_at DESC, message_id
+	LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list starred messages: %w", err)
+	}
+
+	stars := make([]*domain.StarredMessage, 0, len(rows))
+	for _, row := range rows {
+		stars = append(stars, &domain.StarredMessage{
+			UserID:         row.UserID,
+			MessageID:      row.MessageID,
+			ConversationID: row.ConversationID,
+			StarredAt:      row.StarredAt,
+		})
+	}
+	return stars, nil
+}
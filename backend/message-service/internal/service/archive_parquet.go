@@ -0,0 +1,249 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// parquetRowGroupSize 每个行组的大小，按会话排序后同一会话的消息集中在少数行组中
+const parquetRowGroupSize = 64 * 1024 * 1024
+
+// parquetMessage 归档文件中的消息行，空值的时间列为NULL，空的消息ID列为空字符串
+type parquetMessage struct {
+	ID               string `parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ConversationID   string `parquet:"name=conversation_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SenderID         string `parquet:"name=sender_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Type             string `parquet:"name=type, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Content          string `parquet:"name=content, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Metadata         string `parquet:"name=metadata, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Status           string `parquet:"name=status, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	CreatedAt        int64  `parquet:"name=created_at, type=INT64, convertedtype=TIMESTAMP_MICROS"`
+	UpdatedAt        int64  `parquet:"name=updated_at, type=INT64, convertedtype=TIMESTAMP_MICROS"`
+	IsGroupChat      bool   `parquet:"name=is_group_chat, type=BOOLEAN"`
+	EditedAt         *int64 `parquet:"name=edited_at, type=INT64, convertedtype=TIMESTAMP_MICROS, repetitiontype=OPTIONAL"`
+	DeletedAt        *int64 `parquet:"name=deleted_at, type=INT64, convertedtype=TIMESTAMP_MICROS, repetitiontype=OPTIONAL"`
+	TTLSeconds       int32  `parquet:"name=ttl_seconds, type=INT32"`
+	ViewOnce         bool   `parquet:"name=view_once, type=BOOLEAN"`
+	ExpiresAt        *int64 `parquet:"name=expires_at, type=INT64, convertedtype=TIMESTAMP_MICROS, repetitiontype=OPTIONAL"`
+	ReplyToMessageID string `parquet:"name=reply_to_message_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ThreadRootID     string `parquet:"name=thread_root_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+// legacyParquetMessage 早期归档文件中的消息行，只有基础列
+type legacyParquetMessage struct {
+	ID             string `parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ConversationID string `parquet:"name=conversation_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SenderID       string `parquet:"name=sender_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Type           string `parquet:"name=type, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Content        string `parquet:"name=content, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Metadata       string `parquet:"name=metadata, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Status         string `parquet:"name=status, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	CreatedAt      int64  `parquet:"name=created_at, type=INT64, convertedtype=TIMESTAMP_MICROS"`
+	UpdatedAt      int64  `parquet:"name=updated_at, type=INT64, convertedtype=TIMESTAMP_MICROS"`
+	IsGroupChat    bool   `parquet:"name=is_group_chat, type=BOOLEAN"`
+}
+
+// upgrade 转换为当前格式，早期文件没有的列取零值
+func (m *legacyParquetMessage) upgrade() parquetMessage {
+	return parquetMessage{
+		ID:             m.ID,
+		ConversationID: m.ConversationID,
+		SenderID:       m.SenderID,
+		Type:           m.Type,
+		Content:        m.Content,
+		Metadata:       m.Metadata,
+		Status:         m.Status,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+		IsGroupChat:    m.IsGroupChat,
+	}
+}
+
+// timeToMicros 可空时间转换为微秒时间戳
+func timeToMicros(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	micros := t.UnixMicro()
+	return &micros
+}
+
+// microsToTime 可空微秒时间戳转换为UTC时间
+func microsToTime(micros *int64) *time.Time {
+	if micros == nil {
+		return nil
+	}
+	t := time.UnixMicro(*micros).UTC()
+	return &t
+}
+
+// parquetMessageWriter 把消息逐条写入parquet文件
+type parquetMessageWriter struct {
+	pw *writer.ParquetWriter
+}
+
+func newParquetMessageWriter(w io.Writer) (*parquetMessageWriter, error) {
+	pw, err := writer.NewParquetWriterFromWriter(w, new(parquetMessage), 4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parquet writer: %w", err)
+	}
+	pw.RowGroupSize = parquetRowGroupSize
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	return &parquetMessageWriter{pw: pw}, nil
+}
+
+// Write 写入一条消息
+func (w *parquetMessageWriter) Write(message *domain.Message) error {
+	metadata := ""
+	if len(message.Metadata) > 0 {
+		encoded, err := json.Marshal(message.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		metadata = string(encoded)
+	}
+
+	return w.pw.Write(parquetMessage{
+		ID:               message.ID,
+		ConversationID:   message.Conversation,
+		SenderID:         message.SenderID,
+		Type:             string(message.Type),
+		Content:          message.Content,
+		Metadata:         metadata,
+		Status:           string(message.Status),
+		CreatedAt:        message.CreatedAt.UnixMicro(),
+		UpdatedAt:        message.UpdatedAt.UnixMicro(),
+		IsGroupChat:      message.IsGroupChat,
+		EditedAt:         timeToMicros(message.EditedAt),
+		DeletedAt:        timeToMicros(message.DeletedAt),
+		TTLSeconds:       int32(message.TTLSeconds),
+		ViewOnce:         message.ViewOnce,
+		ExpiresAt:        timeToMicros(message.ExpiresAt),
+		ReplyToMessageID: message.ReplyToMessageID,
+		ThreadRootID:     message.ThreadRootID,
+	})
+}
+
+// Close 写入文件尾
+func (w *parquetMessageWriter) Close() error {
+	if err := w.pw.WriteStop(); err != nil {
+		return fmt.Errorf("failed to finish parquet file: %w", err)
+	}
+	return nil
+}
+
+// readParquetMessages 按批读取parquet文件中的消息
+func readParquetMessages(path string, batchSize int, fn func([]*domain.Message) error) error {
+	file, err := local.NewLocalFileReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open parquet file: %w", err)
+	}
+	defer file.Close()
+
+	// 早期归档文件没有编辑、撤回、自毁和回复相关的列，按旧格式读取
+	legacy, err := isLegacyParquetFile(file)
+	if err != nil {
+		return err
+	}
+	var schema interface{} = new(parquetMessage)
+	if legacy {
+		schema = new(legacyParquetMessage)
+	}
+
+	pr, err := reader.NewParquetReader(file, schema, 4)
+	if err != nil {
+		return fmt.Errorf("failed to create parquet reader: %w", err)
+	}
+	defer pr.ReadStop()
+
+	remaining := int(pr.GetNumRows())
+	for remaining > 0 {
+		n := batchSize
+		if n > remaining {
+			n = remaining
+		}
+		rows, err := readParquetRows(pr, n, legacy)
+		if err != nil {
+			return err
+		}
+		remaining -= n
+
+		messages := make([]*domain.Message, 0, len(rows))
+		for _, row := range rows {
+			message := &domain.Message{
+				ID:               row.ID,
+				Conversation:     row.ConversationID,
+				SenderID:         row.SenderID,
+				Type:             domain.MessageType(row.Type),
+				Content:          row.Content,
+				Status:           domain.MessageStatus(row.Status),
+				CreatedAt:        time.UnixMicro(row.CreatedAt).UTC(),
+				UpdatedAt:        time.UnixMicro(row.UpdatedAt).UTC(),
+				IsGroupChat:      row.IsGroupChat,
+				EditedAt:         microsToTime(row.EditedAt),
+				DeletedAt:        microsToTime(row.DeletedAt),
+				TTLSeconds:       int(row.TTLSeconds),
+				ViewOnce:         row.ViewOnce,
+				ExpiresAt:        microsToTime(row.ExpiresAt),
+				ReplyToMessageID: row.ReplyToMessageID,
+				ThreadRootID:     row.ThreadRootID,
+				Metadata:         make(map[string]any),
+			}
+			if row.Metadata != "" {
+				if err := json.Unmarshal([]byte(row.Metadata), &message.Metadata); err != nil {
+					return fmt.Errorf("failed to unmarshal metadata of message %s: %w", row.ID, err)
+				}
+			}
+			messages = append(messages, message)
+		}
+
+		if err := fn(messages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readParquetRows 读取n行，早期格式的行转换为当前格式
+func readParquetRows(pr *reader.ParquetReader, n int, legacy bool) ([]parquetMessage, error) {
+	if !legacy {
+		rows := make([]parquetMessage, n)
+		if err := pr.Read(&rows); err != nil {
+			return nil, fmt.Errorf("failed to read parquet rows: %w", err)
+		}
+		return rows, nil
+	}
+
+	legacyRows := make([]legacyParquetMessage, n)
+	if err := pr.Read(&legacyRows); err != nil {
+		return nil, fmt.Errorf("failed to read parquet rows: %w", err)
+	}
+	rows := make([]parquetMessage, 0, n)
+	for i := range legacyRows {
+		rows = append(rows, legacyRows[i].upgrade())
+	}
+	return rows, nil
+}
+
+// isLegacyParquetFile 根据文件尾中的schema判断是否为只有基础列的早期归档文件
+func isLegacyParquetFile(file source.ParquetFile) (bool, error) {
+	footer := &reader.ParquetReader{PFile: file}
+	if err := footer.ReadFooter(); err != nil {
+		return false, fmt.Errorf("failed to read parquet footer: %w", err)
+	}
+	for _, element := range footer.Footer.GetSchema() {
+		if element.GetName() == "thread_root_id" {
+			return false, nil
+		}
+	}
+	return true, nil
+}
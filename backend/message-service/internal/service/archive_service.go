@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neohope/chatapp/message-service/config"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/pkg/jobs"
+	"go.uber.org/zap"
+)
+
+// restoreReadBatch 恢复归档时每次从文件读取的行数
+const restoreReadBatch = 1000
+
+// ArchiveService 消息分区维护和冷存储归档服务实现
+type ArchiveService struct {
+	repo    domain.ArchiveRepository
+	storage domain.ColdStorage
+	cfg     config.ArchiveConfig
+	// mu 保证同一时间只有一个归档或恢复任务在操作分区
+	mu     sync.Mutex
+	logger *zap.Logger
+}
+
+// NewArchiveService 创建一个新的归档服务
+func NewArchiveService(repo domain.ArchiveRepository, storage domain.ColdStorage, cfg config.ArchiveConfig, logger *zap.Logger) domain.ArchiveService {
+	return &ArchiveService{
+		repo:    repo,
+		storage: storage,
+		cfg:     cfg,
+		logger:  logger,
+	}
+}
+
+// ScheduleJobs 注册定时任务：启动时及之后定期预建分区并归档过期分区
+func (s *ArchiveService) ScheduleJobs(runner *jobs.Runner) error {
+	interval := time.Duration(s.cfg.IntervalHours) * time.Hour
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	return runner.Schedule("message_archival", fmt.Sprintf("@every %s", interval), func(ctx context.Context, _ json.RawMessage) error {
+		_, err := s.RunArchival(ctx)
+		return err
+	}, jobs.RunOnStart())
+}
+
+// RunArchival 执行一次分区维护：创建未来分区，把早于保留期的分区归档到冷存储
+func (s *ArchiveService) RunArchival(ctx context.Context) ([]*domain.MessageArchive, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for i := 0; i <= s.cfg.PremakeMonths; i++ {
+		if err := s.repo.EnsurePartition(ctx, now.AddDate(0, i, 0)); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.cfg.AfterMonths <= 0 {
+		return nil, nil
+	}
+
+	partitions, err := s.repo.ListPartitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 分区结束时间不晚于截止月份起点的才归档
+	cutoff := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -s.cfg.AfterMonths, 0)
+
+	var archives []*domain.MessageArchive
+	for _, partition := range partitions {
+		if partition.PeriodEnd.After(cutoff) {
+			break
+		}
+
+		existing, err := s.repo.GetArchiveByPartition(ctx, partition.Name)
+		if err != nil {
+			return archives, err
+		}
+		// 按需恢复的分区在保留期内不重新归档
+		if existing != nil && existing.RestoredAt != nil &&
+			now.Before(existing.RestoredAt.AddDate(0, 0, s.cfg.RestoreRetention)) {
+			continue
+		}
+
+		archive, err := s.archivePartition(ctx, partition)
+		if err != nil {
+			return archives, fmt.Errorf("failed to archive partition %s: %w", partition.Name, err)
+		}
+		if archive != nil {
+			archives = append(archives, archive)
+		}
+	}
+
+	return archives, nil
+}
+
+// archivePartition 把单个分区导出为parquet上传到冷存储，再删除分区
+func (s *ArchiveService) archivePartition(ctx context.Context, partition *domain.MessagePartition) (*domain.MessageArchive, error) {
+	archive := &domain.MessageArchive{
+		ID:            uuid.New().String(),
+		PartitionName: partition.Name,
+		PeriodStart:   partition.PeriodStart,
+		PeriodEnd:     partition.PeriodEnd,
+		Bucket:        s.storage.Bucket(),
+		ObjectKey:     s.objectKey(partition),
+		Format:        "parquet",
+		Status:        domain.ArchiveStatusArchived,
+		ArchivedAt:    time.Now().UTC(),
+	}
+
+	// 先在导出快照中写入临时文件，快照事务结束后再上传，避免上传期间占用数据库事务
+	file, err := os.CreateTemp("", partition.Name+"-*.parquet")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	var stubs []*domain.ArchiveStub
+	snapshot, err := s.repo.ExportPartition(ctx, partition.Name, func(partitionStubs []*domain.ArchiveStub, read domain.PartitionReader) error {
+		stubs = partitionStubs
+		if len(stubs) == 0 {
+			return nil
+		}
+		return writeParquetMessages(file, read)
+	})
+	if err != nil {
+		return nil, err
+	}
+	archive.MessageCount = snapshot.MessageCount
+
+	if archive.MessageCount > 0 {
+		size, err := s.uploadFile(ctx, file, archive.ObjectKey)
+		if err != nil {
+			return nil, err
+		}
+		archive.SizeBytes = size
+	}
+
+	// 上传成功后再删除分区，元数据写入和删除在同一事务中；导出后分区有新的写入时本次不删除
+	if err := s.repo.FinalizeArchive(ctx, archive, stubs, snapshot); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Message partition archived",
+		zap.String("partition", partition.Name),
+		zap.String("object_key", archive.ObjectKey),
+		zap.Int64("messages", archive.MessageCount),
+		zap.Int64("size_bytes", archive.SizeBytes),
+		zap.Int("conversations", len(stubs)),
+	)
+	return archive, nil
+}
+
+// writeParquetMessages 把分区内的消息写入parquet文件
+func writeParquetMessages(file *os.File, read domain.PartitionReader) error {
+	pw, err := newParquetMessageWriter(file)
+	if err != nil {
+		return err
+	}
+	if err := read(pw.Write); err != nil {
+		return err
+	}
+	return pw.Close()
+}
+
+// uploadFile 把导出的临时文件上传到冷存储，返回文件大小
+func (s *ArchiveService) uploadFile(ctx context.Context, file *os.File, key string) (int64, error) {
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat temp file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to rewind temp file: %w", err)
+	}
+
+	if err := s.storage.Upload(ctx, key, file); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// ListArchives 获取全部归档
+func (s *ArchiveService) ListArchives(ctx context.Context) ([]*domain.MessageArchive, error) {
+	return s.repo.ListArchives(ctx)
+}
+
+// GetConversationArchives 获取会话已归档的历史消息占位信息
+func (s *ArchiveService) GetConversationArchives(ctx context.Context, conversationID string) ([]*domain.ArchiveStub, error) {
+	if conversationID == "" {
+		return nil, errors.New("conversation ID is required")
+	}
+	return s.repo.GetConversationStubs(ctx, conversationID)
+}
+
+// RestoreArchive 从冷存储下载归档并写回数据库分区
+func (s *ArchiveService) RestoreArchive(ctx context.Context, archiveID string) (*domain.MessageArchive, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	archive, err := s.repo.GetArchive(ctx, archiveID)
+	if err != nil {
+		return nil, err
+	}
+	if archive.Status == domain.ArchiveStatusRestored {
+		return nil, errors.New("archive is already restored")
+	}
+
+	if archive.MessageCount > 0 {
+		if err := s.importArchive(ctx, archive); err != nil {
+			return nil, err
+		}
+	} else if err := s.repo.EnsurePartition(ctx, archive.PeriodStart); err != nil {
+		return nil, err
+	}
+
+	restoredAt := time.Now().UTC()
+	if err := s.repo.MarkRestored(ctx, archive.ID, restoredAt); err != nil {
+		return nil, err
+	}
+	archive.Status = domain.ArchiveStatusRestored
+	archive.RestoredAt = &restoredAt
+
+	s.logger.Info("Message archive restored",
+		zap.String("archive_id", archive.ID),
+		zap.String("partition", archive.PartitionName),
+		zap.Int64("messages", archive.MessageCount),
+	)
+	return archive, nil
+}
+
+// importArchive 下载parquet文件并分批写回
+func (s *ArchiveService) importArchive(ctx context.Context, archive *domain.MessageArchive) error {
+	file, err := os.CreateTemp("", archive.PartitionName+"-*.parquet")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if _, err := s.storage.Download(ctx, archive.ObjectKey, file); err != nil {
+		return err
+	}
+
+	return readParquetMessages(file.Name(), restoreReadBatch, func(messages []*domain.Message) error {
+		return s.repo.RestoreMessages(ctx, archive, messages)
+	})
+}
+
+// objectKey 归档文件路径，如 message-archives/2024/01/messages_p2024_01.parquet
+func (s *ArchiveService) objectKey(partition *domain.MessagePartition) string {
+	return path.Join(s.cfg.Prefix, partition.PeriodStart.Format("2006/01"), partition.Name+".parquet")
+}
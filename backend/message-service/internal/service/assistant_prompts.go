@@ -0,0 +1,52 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"text/template"
+)
+
+// defaultSmartReplyPrompt 内置的智能回复提示词模板
+const defaultSmartReplyPrompt = `You suggest short replies in a chat app.
+Below is the recent conversation; you are writing as participant {{.UserID}}.
+Suggest exactly {{.Count}} different short replies (at most {{.MaxLength}} characters each) to the last message from {{.SenderID}}.
+Reply in the same language as the conversation. Output one reply per line, without numbering, quotes or explanations.
+
+{{.Transcript}}`
+
+// smartReplyPromptData 智能回复提示词模板可用的字段
+type smartReplyPromptData struct {
+	UserID     string // 请求建议回复的用户
+	SenderID   string // 最新消息的发送者
+	Count      int    // 需要的建议数
+	MaxLength  int    // 每条建议的最大字符数
+	Transcript string // 按时间顺序的最近消息记录
+}
+
+// loadPromptTemplate 加载提示词模板，path为空时使用内置模板
+func loadPromptTemplate(name, path, fallback string) (*template.Template, error) {
+	text := fallback
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt template %s: %w", path, err)
+		}
+		text = string(content)
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// renderPrompt 渲染提示词模板
+func renderPrompt(tmpl *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/neohope/chatapp/message-service/config"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/internal/llm"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxSummaryMessages 单次摘要最多读取的消息数
+	maxSummaryMessages = 200
+	// maxTranscriptMessageLength 摘要时每条消息保留的最大字符数
+	maxTranscriptMessageLength = 500
+	// smartReplyCount 每次返回的建议回复数
+	smartReplyCount = 3
+	// maxSmartReplyLength 每条建议回复的最大字符数
+	maxSmartReplyLength = 60
+	// smartReplyCacheTTL 建议回复的缓存时间，最新消息变化后自动失效
+	smartReplyCacheTTL = 10 * time.Minute
+)
+
+// summaryPrompt 会话摘要的系统提示词
+const summaryPrompt = `You summarize group and private chat conversations.
+Write a concise summary (at most 5 bullet points) of the key topics, decisions and open questions.
+Refer to participants by the IDs shown in the transcript. Reply in the same language as the conversation.`
+
+// listMarkerPattern 模型输出行首的列表符号或编号，如 "- "、"1. "、"2)"
+var listMarkerPattern = regexp.MustCompile(`^(?:[-*•]|\d+[.)、])\s*`)
+
+var (
+	// ErrAssistantUnavailable 未配置大模型，AI功能不可用
+	ErrAssistantUnavailable = errors.New("assistant is not available")
+	// ErrSmartReplyDisabled 智能回复功能未开启
+	ErrSmartReplyDisabled = errors.New("smart replies are disabled")
+)
+
+// cachedResult 缓存的生成结果及过期时间
+type cachedResult struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// AssistantService 基于大模型的会话辅助功能实现
+type AssistantService struct {
+	repo     domain.MessageRepository
+	provider llm.Provider
+	cfg      config.AssistantConfig
+	logger   *zap.Logger
+
+	summaryLimiter    *userRateLimiter
+	smartReplyLimiter *userRateLimiter
+	smartReplyPrompt  *template.Template
+
+	mu    sync.Mutex
+	cache map[string]cachedResult
+}
+
+// NewAssistantService 创建一个新的会话辅助服务，自定义提示词模板无效时使用内置模板
+func NewAssistantService(repo domain.MessageRepository, provider llm.Provider, cfg config.AssistantConfig, logger *zap.Logger) domain.AssistantService {
+	smartReplyPrompt, err := loadPromptTemplate("smart_reply", cfg.SmartReplyPromptFile, defaultSmartReplyPrompt)
+	if err != nil {
+		logger.Warn("Invalid smart reply prompt template, using built-in template", zap.Error(err))
+		smartReplyPrompt, _ = loadPromptTemplate("smart_reply", "", defaultSmartReplyPrompt)
+	}
+
+	return &AssistantService{
+		repo:              repo,
+		provider:          provider,
+		cfg:               cfg,
+		logger:            logger,
+		summaryLimiter:    newUserRateLimiter(cfg.SummaryRateLimit, time.Hour),
+		smartReplyLimiter: newUserRateLimiter(cfg.SmartReplyRateLimit, time.Hour),
+		smartReplyPrompt:  smartReplyPrompt,
+		cache:             make(map[string]cachedResult),
+	}
+}
+
+// SummarizeConversation 摘要会话最近limit条消息
+// 摘要按会话、消息数和最后一条消息缓存，会话有新消息时自动失效；命中缓存不计入用户限流
+func (s *AssistantService) SummarizeConversation(ctx context.Context, userID, conversationID string, limit int) (*domain.ConversationSummary, error) {
+	if conversationID == "" {
+		return nil, errors.New("conversation ID is required")
+	}
+	if limit <= 0 {
+		limit = s.cfg.SummaryMaxMessages
+	}
+	if limit <= 0 || limit > maxSummaryMessages {
+		limit = maxSummaryMessages
+	}
+
+	if err := s.requireParticipant(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	messages, err := s.recentMessages(ctx, conversationID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &domain.ConversationSummary{
+		ConversationID: conversationID,
+		MessageCount:   len(messages),
+		Provider:       s.provider.Name(),
+		Model:          s.provider.Model(),
+		GeneratedAt:    time.Now().UTC(),
+	}
+	if len(messages) == 0 {
+		return summary, nil
+	}
+	summary.LastMessageID = messages[0].ID
+
+	key := fmt.Sprintf("summary|%s|%d|%s", conversationID, limit, summary.LastMessageID)
+	if value, ok := s.cached(key); ok {
+		cached := value.(domain.ConversationSummary)
+		cached.Cached = true
+		return &cached, nil
+	}
+
+	if err := s.summaryLimiter.Allow(userID); err != nil {
+		return nil, err
+	}
+
+	text, err := s.provider.Complete(ctx, &llm.CompletionRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: summaryPrompt},
+			{Role: llm.RoleUser, Content: buildTranscript(messages)},
+		},
+		Temperature: 0.3,
+	})
+	if err != nil {
+		if errors.Is(err, llm.ErrDisabled) {
+			return nil, ErrAssistantUnavailable
+		}
+		return nil, fmt.Errorf("failed to generate summary: %w", err)
+	}
+	summary.Summary = text
+
+	s.store(key, *summary, time.Duration(s.cfg.SummaryCacheMinutes)*time.Minute)
+
+	s.logger.Info("Conversation summary generated",
+		zap.String("conversation_id", conversationID),
+		zap.String("user_id", userID),
+		zap.Int("messages", summary.MessageCount),
+		zap.String("provider", summary.Provider),
+	)
+	return summary, nil
+}
+
+// SuggestReplies 针对会话最新消息生成建议回复
+// 最新消息由请求者本人发送时没有可回复的内容，返回空列表；结果按用户和最新消息缓存
+func (s *AssistantService) SuggestReplies(ctx context.Context, userID, conversationID string) (*domain.SmartReplies, error) {
+	if !s.cfg.SmartReplyEnabled {
+		return nil, ErrSmartReplyDisabled
+	}
+	if conversationID == "" {
+		return nil, errors.New("conversation ID is required")
+	}
+
+	if err := s.requireParticipant(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	limit := s.cfg.SmartReplyContextMessages
+	if limit <= 0 || limit > maxSummaryMessages {
+		limit = 10
+	}
+	messages, err := s.recentMessages(ctx, conversationID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	replies := &domain.SmartReplies{
+		ConversationID: conversationID,
+		Suggestions:    []string{},
+		Provider:       s.provider.Name(),
+		Model:          s.provider.Model(),
+		GeneratedAt:    time.Now().UTC(),
+	}
+	if len(messages) == 0 {
+		return replies, nil
+	}
+	latest := messages[0]
+	replies.MessageID = latest.ID
+	if latest.SenderID == userID || latest.Type == domain.MessageTypeSystem {
+		return replies, nil
+	}
+
+	key := fmt.Sprintf("smart_reply|%s|%s|%s", conversationID, userID, latest.ID)
+	if value, ok := s.cached(key); ok {
+		cached := value.(domain.SmartReplies)
+		cached.Suggestions = append([]string(nil), cached.Suggestions...)
+		cached.Cached = true
+		return &cached, nil
+	}
+
+	if err := s.smartReplyLimiter.Allow(userID); err != nil {
+		return nil, err
+	}
+
+	prompt, err := renderPrompt(s.smartReplyPrompt, smartReplyPromptData{
+		UserID:     userID,
+		SenderID:   latest.SenderID,
+		Count:      smartReplyCount,
+		MaxLength:  maxSmartReplyLength,
+		Transcript: buildTranscript(messages),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := s.provider.Complete(ctx, &llm.CompletionRequest{
+		Messages:    []llm.Message{{Role: llm.RoleUser, Content: prompt}},
+		MaxTokens:   200,
+		Temperature: 0.7,
+	})
+	if err != nil {
+		if errors.Is(err, llm.ErrDisabled) {
+			return nil, ErrAssistantUnavailable
+		}
+		return nil, fmt.Errorf("failed to generate smart replies: %w", err)
+	}
+
+	replies.Suggestions = parseSuggestions(text, smartReplyCount)
+	if len(replies.Suggestions) == 0 {
+		return nil, errors.New("failed to generate smart replies: empty model output")
+	}
+
+	cached := *replies
+	cached.Suggestions = append([]string(nil), replies.Suggestions...)
+	s.store(key, cached, smartReplyCacheTTL)
+	return replies, nil
+}
+
+// requireParticipant 确认用户是会话参与者
+func (s *AssistantService) requireParticipant(ctx context.Context, userID, conversationID string) error {
+	conversation, err := s.repo.GetConversation(ctx, conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation: %w", err)
+	}
+	for _, participant := range conversation.Participants {
+		if participant == userID {
+			return nil
+		}
+	}
+	return ErrNotParticipant
+}
+
+// recentMessages 获取会话最近limit条消息，按时间倒序
+func (s *AssistantService) recentMessages(ctx context.Context, conversationID string, limit int) ([]*domain.Message, error) {
+	messages, err := s.repo.GetConversationMessages(ctx, conversationID, limit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation messages: %w", err)
+	}
+	// 数据库按时间倒序返回，内存存储无序，统一排为倒序
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.After(messages[j].CreatedAt)
+	})
+	return messages, nil
+}
+
+// cached 读取未过期的缓存结果
+func (s *AssistantService) cached(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// store 缓存结果，写入时顺带清理过期项；ttl<=0表示不缓存
+func (s *AssistantService) store(key string, value interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range s.cache {
+		if now.After(entry.expiresAt) {
+			delete(s.cache, k)
+		}
+	}
+	s.cache[key] = cachedResult{value: value, expiresAt: now.Add(ttl)}
+}
+
+// parseSuggestions 从模型输出中解析建议回复：每行一条，去掉编号、列表符号和引号，去重后最多取count条
+func parseSuggestions(text string, count int) []string {
+	suggestions := make([]string, 0, count)
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(text, "\n") {
+		line = listMarkerPattern.ReplaceAllString(strings.TrimSpace(line), "")
+		line = strings.TrimSpace(strings.Trim(line, "\"'“”「」"))
+		if line == "" || seen[line] {
+			continue
+		}
+		if utf8.RuneCountInString(line) > maxSmartReplyLength {
+			line = string([]rune(line)[:maxSmartReplyLength])
+		}
+		seen[line] = true
+		suggestions = append(suggestions, line)
+		if len(suggestions) == count {
+			break
+		}
+	}
+	return suggestions
+}
+
+// buildTranscript 把倒序的消息整理为按时间顺序的文本记录，非文本消息以类型占位
+func buildTranscript(messages []*domain.Message) string {
+	var b strings.Builder
+	for i := len(messages) - 1; i >= 0; i-- {
+		message := messages[i]
+
+		content := message.Content
+		if message.Type != domain.MessageTypeText {
+			content = "[" + string(message.Type) + "]"
+		} else if utf8.RuneCountInString(content) > maxTranscriptMessageLength {
+			content = string([]rune(content)[:maxTranscriptMessageLength]) + "…"
+		}
+
+		fmt.Fprintf(&b, "[%s] %s: %s\n", message.CreatedAt.UTC().Format("2006-01-02 15:04"), message.SenderID, content)
+	}
+	return b.String()
+}
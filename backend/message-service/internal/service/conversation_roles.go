@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// maxConversationNameLength 会话名称的最大字符数
+const maxConversationNameLength = 100
+
+var (
+	// ErrNotGroupConversation 角色只适用于群聊会话，私聊会话不能改名或增减参与者
+	ErrNotGroupConversation = errors.New("participant roles only apply to group conversations")
+	// ErrNotConversationAdmin 只有会话管理员可以执行该操作
+	ErrNotConversationAdmin = errors.New("only conversation admins can manage the conversation")
+	// ErrLastConversationAdmin 会话中还有其他参与者时至少要保留一个管理员
+	ErrLastConversationAdmin = errors.New("conversation must keep at least one admin")
+	// ErrParticipantNotFound 目标用户不在会话中
+	ErrParticipantNotFound = errors.New("participant not found")
+	// ErrConversationNameTooLong 会话名称超过长度限制
+	ErrConversationNameTooLong = fmt.Errorf("conversation name must be at most %d characters", maxConversationNameLength)
+)
+
+// RenameConversation 群聊会话的管理员修改会话名称
+func (s *MessageService) RenameConversation(ctx context.Context, userID, id, name string) (*domain.Conversation, error) {
+	if name == "" {
+		return nil, errors.New("conversation name is required")
+	}
+	if utf8.RuneCountInString(name) > maxConversationNameLength {
+		return nil, ErrConversationNameTooLong
+	}
+	if _, err := s.managedConversation(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RenameConversation(ctx, id, name); err != nil {
+		return nil, fmt.Errorf("failed to rename conversation: %w", err)
+	}
+
+	s.logger.Debug("Conversation renamed", zap.String("conversation_id", id), zap.String("user_id", userID))
+	return s.GetConversation(ctx, id)
+}
+
+// AddParticipants 群聊会话的管理员添加参与者，新参与者默认为普通成员
+func (s *MessageService) AddParticipants(ctx context.Context, userID, id string, userIDs []string) (*domain.Conversation, error) {
+	if len(userIDs) == 0 {
+		return nil, errors.New("at least one participant is required")
+	}
+	if _, err := s.managedConversation(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddParticipants(ctx, id, userIDs); err != nil {
+		return nil, fmt.Errorf("failed to add participants: %w", err)
+	}
+
+	s.logger.Debug("Conversation participants added",
+		zap.String("conversation_id", id),
+		zap.String("user_id", userID),
+		zap.Int("count", len(userIDs)),
+	)
+	return s.GetConversation(ctx, id)
+}
+
+// RemoveParticipant 群聊会话的管理员移除参与者，参与者也可以移除自己退出会话
+// 还有其他参与者时不能移除最后一个管理员，需要先任命新的管理员
+func (s *MessageService) RemoveParticipant(ctx context.Context, userID, id, participantID string) (*domain.Conversation, error) {
+	if participantID == "" {
+		return nil, errors.New("participant ID is required")
+	}
+
+	var conversation *domain.Conversation
+	var err error
+	if participantID == userID {
+		conversation, err = s.groupConversation(ctx, userID, id)
+	} else {
+		conversation, err = s.managedConversation(ctx, userID, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !containsUser(conversation.Participants, participantID) {
+		return nil, ErrParticipantNotFound
+	}
+	if conversation.IsAdmin(participantID) && len(conversation.Admins) == 1 && len(conversation.Participants) > 1 {
+		return nil, ErrLastConversationAdmin
+	}
+
+	if err := s.repo.RemoveParticipant(ctx, id, participantID); err != nil {
+		return nil, fmt.Errorf("failed to remove participant: %w", err)
+	}
+
+	s.logger.Debug("Conversation participant removed",
+		zap.String("conversation_id", id),
+		zap.String("user_id", userID),
+		zap.String("participant_id", participantID),
+	)
+	return s.GetConversation(ctx, id)
+}
+
+// SetParticipantRole 群聊会话的管理员任命或撤销管理员，不能撤销最后一个管理员
+func (s *MessageService) SetParticipantRole(ctx context.Context, userID, id, participantID string, role domain.ParticipantRole) (*domain.Conversation, error) {
+	if role != domain.ParticipantRoleAdmin && role != domain.ParticipantRoleMember {
+		return nil, fmt.Errorf("invalid participant role: %s", role)
+	}
+
+	conversation, err := s.managedConversation(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !containsUser(conversation.Participants, participantID) {
+		return nil, ErrParticipantNotFound
+	}
+	if role == domain.ParticipantRoleMember && conversation.IsAdmin(participantID) && len(conversation.Admins) == 1 {
+		return nil, ErrLastConversationAdmin
+	}
+
+	if err := s.repo.SetParticipantRole(ctx, id, participantID, role); err != nil {
+		return nil, fmt.Errorf("failed to set participant role: %w", err)
+	}
+
+	s.logger.Debug("Conversation participant role changed",
+		zap.String("conversation_id", id),
+		zap.String("user_id", userID),
+		zap.String("participant_id", participantID),
+		zap.String("role", string(role)),
+	)
+	return s.GetConversation(ctx, id)
+}
+
+// SyncAddParticipants 群组服务同步频道成员时添加参与者，不校验操作者
+func (s *MessageService) SyncAddParticipants(ctx context.Context, id string, userIDs []string) (*domain.Conversation, error) {
+	if len(userIDs) == 0 {
+		return nil, errors.New("at least one participant is required")
+	}
+	conversation, err := s.GetConversation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if conversation.Type != domain.ConversationTypeGroup {
+		return nil, ErrNotGroupConversation
+	}
+
+	if err := s.repo.AddParticipants(ctx, id, userIDs); err != nil {
+		return nil, fmt.Errorf("failed to add participants: %w", err)
+	}
+
+	s.logger.Debug("Conversation participants synced", zap.String("conversation_id", id), zap.Int("count", len(userIDs)))
+	return s.GetConversation(ctx, id)
+}
+
+// SyncRemoveParticipant 群组服务同步频道成员时移除参与者，不校验操作者，也不要求保留管理员
+// 用户不在会话中时直接返回，重复同步不会失败
+func (s *MessageService) SyncRemoveParticipant(ctx context.Context, id, participantID string) (*domain.Conversation, error) {
+	if participantID == "" {
+		return nil, errors.New("participant ID is required")
+	}
+	conversation, err := s.GetConversation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if conversation.Type != domain.ConversationTypeGroup {
+		return nil, ErrNotGroupConversation
+	}
+	if !containsUser(conversation.Participants, participantID) {
+		return conversation, nil
+	}
+
+	if err := s.repo.RemoveParticipant(ctx, id, participantID); err != nil {
+		return nil, fmt.Errorf("failed to remove participant: %w", err)
+	}
+
+	s.logger.Debug("Conversation participant removed by sync", zap.String("conversation_id", id), zap.String("participant_id", participantID))
+	return s.GetConversation(ctx, id)
+}
+
+// groupConversation 获取群聊会话并校验用户是参与者
+func (s *MessageService) groupConversation(ctx context.Context, userID, id string) (*domain.Conversation, error) {
+	conversation, err := s.GetConversation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if conversation.Type != domain.ConversationTypeGroup {
+		return nil, ErrNotGroupConversation
+	}
+	if !containsUser(conversation.Participants, userID) {
+		return nil, ErrNotParticipant
+	}
+	return conversation, nil
+}
+
+// managedConversation 获取群聊会话并校验用户是管理员
+func (s *MessageService) managedConversation(ctx context.Context, userID, id string) (*domain.Conversation, error) {
+	conversation, err := s.groupConversation(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !conversation.IsAdmin(userID) {
+		return nil, ErrNotConversationAdmin
+	}
+	return conversation, nil
+}
+
+// isConversationAdmin 判断用户是否是消息所在群聊会话的管理员，会话不存在时返回false
+func (s *MessageService) isConversationAdmin(ctx context.Context, conversationID, userID string) bool {
+	conversation, err := s.repo.GetConversation(ctx, conversationID)
+	if err != nil {
+		return false
+	}
+	return conversation.Type == domain.ConversationTypeGroup && conversation.IsAdmin(userID)
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/pkg/events"
+	"go.uber.org/zap"
+)
+
+// previewMaxRunes 事件中消息预览的最大字符数
+const previewMaxRunes = 100
+
+// publishMessageCreated 发布message.created事件，只有文本消息附带内容预览，自毁消息不带预览以免内容留在推送通知中
+// 发布失败只记录日志
+func (s *MessageService) publishMessageCreated(ctx context.Context, message *domain.Message, recipients []string) {
+	if len(recipients) == 0 {
+		return
+	}
+
+	data := events.MessageCreated{
+		MessageID:      message.ID,
+		ConversationID: message.Conversation,
+		SenderID:       message.SenderID,
+		RecipientIDs:   recipients,
+		Type:           string(message.Type),
+		IsGroupChat:    message.IsGroupChat,
+	}
+	if message.Type == domain.MessageTypeText && !message.ViewOnce && message.TTLSeconds == 0 {
+		data.Preview = messagePreview(message.Content)
+	}
+
+	if err := s.events.Publish(ctx, events.TypeMessageCreated, data); err != nil {
+		s.logger.Warn("Failed to publish message event",
+			zap.Error(err),
+			zap.String("conversation_id", message.Conversation),
+			zap.String("message_id", message.ID),
+		)
+	}
+}
+
+// messagePreview 截取消息内容的前previewMaxRunes个字符
+func messagePreview(content string) string {
+	runes := []rune(content)
+	if len(runes) <= previewMaxRunes {
+		return content
+	}
+	return string(runes[:previewMaxRunes]) + "…"
+}
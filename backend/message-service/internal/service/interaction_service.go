@@ -0,0 +1,478 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/neohope/chatapp/message-service/config"
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/pkg/jobs"
+	"go.uber.org/zap"
+)
+
+// maxEmojiLength 回应表情的最大字符数，允许组合表情和 :shortcode: 形式的自定义表情
+const maxEmojiLength = 32
+
+const (
+	// readScanPageSize 推进已读位置时每次读取的消息数
+	readScanPageSize = 100
+	// maxReadScan 推进已读位置时最多扫描的消息数，更早的未读消息只推进位置不逐条记录回执
+	maxReadScan = 500
+)
+
+var (
+	ErrNotParticipant           = errors.New("not a participant of this conversation")
+	ErrInvalidEmoji             = errors.New("invalid reaction emoji")
+	ErrMessageNotInConversation = errors.New("message does not belong to this conversation")
+)
+
+// InteractionService 消息回应和已读回执服务实现
+type InteractionService struct {
+	repo     domain.InteractionRepository
+	receipts domain.ReceiptRepository
+	messages domain.MessageRepository
+	notifier domain.ReceiptNotifier
+	exporter domain.ReadStateExporter
+	cfg      config.AggregateConfig
+	logger   *zap.Logger
+}
+
+// NewInteractionService 创建一个新的回应和已读回执服务，notifier为nil时不推送回执变更，exporter为nil时不导出已读状态事件
+func NewInteractionService(repo domain.InteractionRepository, receipts domain.ReceiptRepository, messages domain.MessageRepository, notifier domain.ReceiptNotifier, exporter domain.ReadStateExporter, cfg config.AggregateConfig, logger *zap.Logger) domain.InteractionService {
+	return &InteractionService{
+		repo:     repo,
+		receipts: receipts,
+		messages: messages,
+		notifier: notifier,
+		exporter: exporter,
+		cfg:      cfg,
+		logger:   logger,
+	}
+}
+
+// ScheduleJobs 注册后台对账任务，定期按明细修正最近有变更的消息统计
+func (s *InteractionService) ScheduleJobs(runner *jobs.Runner) error {
+	interval := time.Duration(s.cfg.ReconcileIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+
+	return runner.Schedule("reconcile_message_aggregates", fmt.Sprintf("@every %s", interval), func(ctx context.Context, _ json.RawMessage) error {
+		_, err := s.ReconcileAggregates(ctx)
+		return err
+	}, jobs.MaxAttempts(1))
+}
+
+// AddReaction 添加回应，重复回应不报错，返回最新统计
+func (s *InteractionService) AddReaction(ctx context.Context, userID, messageID, emoji string) (*domain.MessageAggregates, error) {
+	if err := validateEmoji(emoji); err != nil {
+		return nil, err
+	}
+	if _, err := s.authorize(ctx, userID, messageID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.AddReaction(ctx, &domain.Reaction{
+		MessageID: messageID,
+		UserID:    userID,
+		Emoji:     emoji,
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.aggregates(ctx, messageID)
+}
+
+// RemoveReaction 取消回应，返回最新统计
+func (s *InteractionService) RemoveReaction(ctx context.Context, userID, messageID, emoji string) (*domain.MessageAggregates, error) {
+	if err := validateEmoji(emoji); err != nil {
+		return nil, err
+	}
+	if _, err := s.authorize(ctx, userID, messageID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.RemoveReaction(ctx, messageID, userID, emoji); err != nil {
+		return nil, err
+	}
+
+	return s.aggregates(ctx, messageID)
+}
+
+// GetReactions 获取消息的回应明细
+func (s *InteractionService) GetReactions(ctx context.Context, userID, messageID string) ([]*domain.Reaction, error) {
+	if _, err := s.authorize(ctx, userID, messageID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListReactions(ctx, messageID)
+}
+
+// MarkRead 记录已读回执，发送者读自己的消息不计入，返回最新统计
+func (s *InteractionService) MarkRead(ctx context.Context, userID, messageID string) (*domain.MessageAggregates, error) {
+	message, err := s.authorize(ctx, userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.SenderID != userID {
+		if err := s.markRead(ctx, userID, message.Conversation, []*domain.Message{message}, time.Now().UTC()); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.aggregates(ctx, messageID)
+}
+
+// GetReceipts 获取消息每个接收者的送达和已读状态
+// 引入送达回执之前只记录在已读明细中的已读状态一并返回
+func (s *InteractionService) GetReceipts(ctx context.Context, userID, messageID string) ([]*domain.MessageReceipt, error) {
+	if _, err := s.authorize(ctx, userID, messageID); err != nil {
+		return nil, err
+	}
+
+	receipts, err := s.receipts.ListReceipts(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := s.repo.ListReadReceipts(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(receipts))
+	for _, receipt := range receipts {
+		seen[receipt.UserID] = true
+	}
+	for _, read := range legacy {
+		if seen[read.UserID] {
+			continue
+		}
+		readAt := read.ReadAt
+		receipts = append(receipts, domain.NewMessageReceipt(messageID, read.UserID, &readAt, &readAt))
+	}
+	return receipts, nil
+}
+
+// MarkConversationRead 把会话已读位置推进到messageID（为空时为最新消息），
+// 记录其间他人消息的已读回执并通知各自的发送者，返回推进后的已读位置
+func (s *InteractionService) MarkConversationRead(ctx context.Context, userID, conversationID, messageID string) (*domain.ReadCursor, error) {
+	if err := s.authorizeConversation(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	var target *domain.Message
+	if messageID != "" {
+		message, err := s.messages.GetByID(ctx, messageID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get message: %w", err)
+		}
+		if message.Conversation != conversationID {
+			return nil, ErrMessageNotInConversation
+		}
+		target = message
+	}
+
+	previous, err := s.receipts.GetReadCursor(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 仓库不保证返回顺序，按时间窗口 (previous, target] 过滤
+	var unread []*domain.Message
+	for offset := 0; offset < maxReadScan; offset += readScanPageSize {
+		page, err := s.messages.GetConversationMessages(ctx, conversationID, readScanPageSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get conversation messages: %w", err)
+		}
+		for _, message := range page {
+			if messageID == "" && (target == nil || message.CreatedAt.After(target.CreatedAt)) {
+				target = message
+			}
+			if previous != nil && !message.CreatedAt.After(previous.MessageCreatedAt) {
+				continue
+			}
+			unread = append(unread, message)
+		}
+		if len(page) < readScanPageSize {
+			break
+		}
+	}
+
+	// 会话没有消息，或目标不晚于已保存的位置
+	if target == nil || (previous != nil && !target.CreatedAt.After(previous.MessageCreatedAt)) {
+		return previous, nil
+	}
+
+	var read []*domain.Message
+	for _, message := range unread {
+		if message.SenderID != userID && !message.CreatedAt.After(target.CreatedAt) {
+			read = append(read, message)
+		}
+	}
+
+	now := time.Now().UTC()
+	if err := s.markRead(ctx, userID, conversationID, read, now); err != nil {
+		return nil, err
+	}
+
+	cursor := &domain.ReadCursor{
+		ConversationID:   conversationID,
+		UserID:           userID,
+		MessageID:        target.ID,
+		MessageCreatedAt: target.CreatedAt,
+		UpdatedAt:        now,
+	}
+	advanced, err := s.receipts.AdvanceReadCursor(ctx, cursor)
+	if err != nil {
+		return nil, err
+	}
+	if !advanced {
+		// 并发请求已推进到更晚的位置
+		return s.receipts.GetReadCursor(ctx, conversationID, userID)
+	}
+
+	s.export(&domain.ReadStateEvent{
+		Type:             domain.ReadStateEventCursorMoved,
+		ConversationID:   conversationID,
+		GroupChat:        target.IsGroupChat,
+		UserID:           userID,
+		MessageID:        target.ID,
+		MessageCreatedAt: target.CreatedAt,
+		At:               now,
+	})
+	return cursor, nil
+}
+
+// GetReadCursors 获取会话所有参与者的已读位置
+func (s *InteractionService) GetReadCursors(ctx context.Context, userID, conversationID string) ([]*domain.ReadCursor, error) {
+	if err := s.authorizeConversation(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+	return s.receipts.ListReadCursors(ctx, conversationID)
+}
+
+// RecordDelivered 实现domain.DeliveryRecorder，记录实时推送成功的接收者并通知发送者
+func (s *InteractionService) RecordDelivered(ctx context.Context, message *domain.Message, userIDs []string) error {
+	now := time.Now().UTC()
+	delivered, err := s.receipts.MarkDelivered(ctx, message.ID, userIDs, now)
+	if err != nil {
+		return err
+	}
+	if len(delivered) > 0 {
+		events := make([]*domain.ReadStateEvent, 0, len(delivered))
+		for _, userID := range delivered {
+			events = append(events, readStateEvent(domain.ReadStateEventDelivered, message, userID, now))
+		}
+		s.export(events...)
+
+		s.notify(message.SenderID, &domain.ReceiptUpdate{
+			ConversationID: message.Conversation,
+			Status:         domain.ReceiptStatusDelivered,
+			MessageIDs:     []string{message.ID},
+			UserIDs:        delivered,
+			At:             now,
+		})
+	}
+	return nil
+}
+
+// ReconcileAggregates 对最近有变更的消息按明细重建统计，返回被修正的消息数
+func (s *InteractionService) ReconcileAggregates(ctx context.Context) (int, error) {
+	window := time.Duration(s.cfg.ReconcileWindowMinutes) * time.Minute
+	if window <= 0 {
+		window = time.Hour
+	}
+	batch := s.cfg.ReconcileBatch
+	if batch <= 0 {
+		batch = 1000
+	}
+
+	messageIDs, err := s.repo.ListActiveMessages(ctx, time.Now().UTC().Add(-window), batch)
+	if err != nil {
+		return 0, err
+	}
+
+	fixed, err := s.repo.ReconcileAggregates(ctx, messageIDs)
+	if err != nil {
+		return fixed, err
+	}
+
+	if fixed > 0 {
+		s.logger.Warn("Message aggregates drifted from detail records and were rebuilt",
+			zap.Int("checked", len(messageIDs)),
+			zap.Int("fixed", fixed),
+		)
+	} else {
+		s.logger.Debug("Message aggregates reconciled", zap.Int("checked", len(messageIDs)))
+	}
+	return fixed, nil
+}
+
+// markRead 记录用户对messages的已读回执，同步更新已读统计，并按发送者分组推送回执变更
+func (s *InteractionService) markRead(ctx context.Context, userID, conversationID string, messages []*domain.Message, at time.Time) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*domain.Message, len(messages))
+	messageIDs := make([]string, 0, len(messages))
+	for _, message := range messages {
+		byID[message.ID] = message
+		messageIDs = append(messageIDs, message.ID)
+	}
+
+	read, err := s.receipts.MarkRead(ctx, userID, messageIDs, at)
+	if err != nil {
+		return err
+	}
+
+	bySender := make(map[string][]string)
+	events := make([]*domain.ReadStateEvent, 0, len(read))
+	for _, messageID := range read {
+		if _, err := s.repo.MarkRead(ctx, &domain.ReadReceipt{
+			MessageID: messageID,
+			UserID:    userID,
+			ReadAt:    at,
+		}); err != nil {
+			return err
+		}
+		message := byID[messageID]
+		bySender[message.SenderID] = append(bySender[message.SenderID], messageID)
+		events = append(events, readStateEvent(domain.ReadStateEventRead, message, userID, at))
+		s.startViewOnce(ctx, message, at)
+	}
+	s.export(events...)
+
+	for senderID, ids := range bySender {
+		s.notify(senderID, &domain.ReceiptUpdate{
+			ConversationID: conversationID,
+			Status:         domain.ReceiptStatusRead,
+			MessageIDs:     ids,
+			UserIDs:        []string{userID},
+			At:             at,
+		})
+	}
+	return nil
+}
+
+// startViewOnce 阅后即焚消息第一次被接收者已读时开始计时，到期后由清理任务撤回
+// 群聊中同样从第一个已读的成员开始计时，失败只记录日志，消息保留到下一次已读
+func (s *InteractionService) startViewOnce(ctx context.Context, message *domain.Message, at time.Time) {
+	if !message.ViewOnce || message.ExpiresAt != nil {
+		return
+	}
+	expiresAt := at.Add(time.Duration(message.TTLSeconds) * time.Second)
+	if err := s.messages.SetExpiry(ctx, message.ID, expiresAt); err != nil {
+		s.logger.Warn("Failed to start view once countdown", zap.Error(err), zap.String("message_id", message.ID))
+	}
+}
+
+// notify 推送回执变更，失败只记录日志
+func (s *InteractionService) notify(senderID string, update *domain.ReceiptUpdate) {
+	if s.notifier == nil {
+		return
+	}
+	if err := s.notifier.NotifyReceipt(senderID, update); err != nil {
+		s.logger.Warn("Failed to push receipt update",
+			zap.Error(err),
+			zap.String("sender_id", senderID),
+			zap.String("conversation_id", update.ConversationID),
+			zap.String("status", string(update.Status)),
+		)
+	}
+}
+
+// export 导出已读状态事件，供分析管道计算参与度漏斗
+func (s *InteractionService) export(events ...*domain.ReadStateEvent) {
+	if s.exporter == nil || len(events) == 0 {
+		return
+	}
+	s.exporter.Export(events...)
+}
+
+// readStateEvent 创建单条消息的送达或已读事件，不包含消息内容
+func readStateEvent(eventType domain.ReadStateEventType, message *domain.Message, userID string, at time.Time) *domain.ReadStateEvent {
+	return &domain.ReadStateEvent{
+		Type:             eventType,
+		ConversationID:   message.Conversation,
+		GroupChat:        message.IsGroupChat,
+		UserID:           userID,
+		SenderID:         message.SenderID,
+		MessageID:        message.ID,
+		MessageCreatedAt: message.CreatedAt,
+		At:               at,
+	}
+}
+
+// authorizeConversation 确认用户是会话的参与者
+func (s *InteractionService) authorizeConversation(ctx context.Context, userID, conversationID string) error {
+	if conversationID == "" {
+		return errors.New("conversation ID is required")
+	}
+
+	conversation, err := s.messages.GetConversation(ctx, conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation: %w", err)
+	}
+	for _, participant := range conversation.Participants {
+		if participant == userID {
+			return nil
+		}
+	}
+	return ErrNotParticipant
+}
+
+// authorize 确认消息存在且用户是所在会话的参与者
+func (s *InteractionService) authorize(ctx context.Context, userID, messageID string) (*domain.Message, error) {
+	if messageID == "" {
+		return nil, errors.New("message ID is required")
+	}
+
+	message, err := s.messages.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	conversation, err := s.messages.GetConversation(ctx, message.Conversation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	for _, participant := range conversation.Participants {
+		if participant == userID {
+			return message, nil
+		}
+	}
+	return nil, ErrNotParticipant
+}
+
+// aggregates 获取单条消息的统计，没有任何回应和回执时返回空统计
+func (s *InteractionService) aggregates(ctx context.Context, messageID string) (*domain.MessageAggregates, error) {
+	aggregates, err := s.repo.GetAggregates(ctx, []string{messageID})
+	if err != nil {
+		return nil, err
+	}
+	if aggregate, ok := aggregates[messageID]; ok {
+		return aggregate, nil
+	}
+	return &domain.MessageAggregates{}, nil
+}
+
+// validateEmoji 校验回应表情：非空、长度受限，不含空白和 '.'、'$'（统计中以表情作为字段名）
+func validateEmoji(emoji string) error {
+	if emoji == "" || utf8.RuneCountInString(emoji) > maxEmojiLength || strings.ContainsAny(emoji, ".$") {
+		return ErrInvalidEmoji
+	}
+	for _, r := range emoji {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return ErrInvalidEmoji
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/pkg/jobs"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidTTL 消息保留时间超出范围
+var ErrInvalidTTL = errors.New("invalid message ttl")
+
+// ScheduleJobs 注册自毁消息清理任务，定期撤回到期的消息并推送撤回事件
+func (s *MessageService) ScheduleJobs(runner *jobs.Runner) error {
+	interval := time.Duration(s.expiry.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+
+	return runner.Schedule("expire_messages", fmt.Sprintf("@every %s", interval), func(ctx context.Context, _ json.RawMessage) error {
+		_, err := s.ExpireMessages(ctx)
+		return err
+	}, jobs.MaxAttempts(1))
+}
+
+// ExpireMessages 撤回到期的自毁消息，撤回事件中的消息带有expires_at，客户端据此区分自毁和发送者撤回
+// 已被发送者撤回的消息跳过，剩余的留给下一次任务
+func (s *MessageService) ExpireMessages(ctx context.Context) (int, error) {
+	batch := s.expiry.Batch
+	if batch <= 0 {
+		batch = 500
+	}
+
+	now := time.Now().UTC()
+	messages, err := s.repo.ListExpired(ctx, now, batch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired messages: %w", err)
+	}
+
+	expired := 0
+	for _, message := range messages {
+		if err := s.repo.SoftDelete(ctx, message.ID, now); err != nil {
+			if errors.Is(err, domain.ErrMessageDeleted) {
+				continue
+			}
+			s.logger.Warn("Failed to expire message", zap.Error(err), zap.String("message_id", message.ID))
+			continue
+		}
+		expired++
+
+		s.dispatchEvent(ctx, domain.MessageEventDeleted, tombstone(message, now))
+	}
+
+	if expired > 0 {
+		s.logger.Info("Expired self-destructing messages", zap.Int("messages", expired))
+	}
+	return expired, nil
+}
+
+// validateTTL 检查消息的保留时间，阅后即焚只适用于非系统消息
+func (s *MessageService) validateTTL(message *domain.Message) error {
+	if message.TTLSeconds < 0 || (s.expiry.MaxTTLSeconds > 0 && message.TTLSeconds > s.expiry.MaxTTLSeconds) {
+		return fmt.Errorf("%w: ttl_seconds must be between 0 and %d", ErrInvalidTTL, s.expiry.MaxTTLSeconds)
+	}
+	if message.ViewOnce && message.Type == domain.MessageTypeSystem {
+		return fmt.Errorf("%w: system messages cannot be view once", ErrInvalidTTL)
+	}
+	return nil
+}
+
+// applyTTL 计算自毁时间：普通自毁消息从发送时开始计时，阅后即焚消息在第一次被已读时才设置自毁时间
+func (s *MessageService) applyTTL(message *domain.Message) {
+	message.ExpiresAt = nil
+	if message.ViewOnce {
+		if message.TTLSeconds == 0 {
+			message.TTLSeconds = s.expiry.ViewOnceSeconds
+		}
+		return
+	}
+	if message.TTLSeconds > 0 {
+		expiresAt := message.CreatedAt.Add(time.Duration(message.TTLSeconds) * time.Second)
+		message.ExpiresAt = &expiresAt
+	}
+}
+
+// tombstone 生成撤回后的消息副本
+func tombstone(message *domain.Message, deletedAt time.Time) *domain.Message {
+	deleted := *message
+	deleted.Content = ""
+	deleted.Metadata = nil
+	deleted.DeletedAt = &deletedAt
+	deleted.UpdatedAt = deletedAt
+	return &deleted
+}
+
+// hideExpired 清理任务执行前已到期的消息按撤回后的样子返回，返回副本以免修改仓库中的对象
+func hideExpired(messages []*domain.Message) []*domain.Message {
+	now := time.Now()
+	result := make([]*domain.Message, 0, len(messages))
+	for _, message := range messages {
+		if message.DeletedAt == nil && message.Expired(now) {
+			message = tombstone(message, *message.ExpiresAt)
+		}
+		result = append(result, message)
+	}
+	return result
+}
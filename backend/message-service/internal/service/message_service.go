@@ -4,24 +4,49 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/neohope/chatapp/message-service/config"
 	"github.com/neohope/chatapp/message-service/internal/domain"
+	"github.com/neohope/chatapp/message-service/pkg/events"
 	"go.uber.org/zap"
 )
 
+var (
+	ErrNotMessageSender   = errors.New("only the sender can modify this message")
+	ErrMessageNotEditable = errors.New("only text messages can be edited")
+	// ErrSendNotPermitted 群组角色或成员状态不允许在该群组或频道中发言，如只读频道的普通成员
+	ErrSendNotPermitted = errors.New("group role does not allow sending messages")
+)
+
 // MessageService 消息服务实现
 type MessageService struct {
-	repo   domain.MessageRepository
-	logger *zap.Logger
+	repo         domain.MessageRepository
+	interactions domain.InteractionRepository
+	dispatcher   domain.MessageDispatcher
+	groups       domain.GroupPermissionChecker
+	events       events.Publisher
+	expiry       config.ExpiryConfig
+	logger       *zap.Logger
 }
 
-// NewMessageService 创建一个新的消息服务
-func NewMessageService(repo domain.MessageRepository, logger *zap.Logger) domain.MessageService {
+// NewMessageService 创建一个新的消息服务，dispatcher为nil时不做实时推送，interactions为nil时不附带回应和已读统计，
+// groups为nil时不校验群聊发言权限，publisher为nil时不发布message.created事件
+func NewMessageService(repo domain.MessageRepository, interactions domain.InteractionRepository, dispatcher domain.MessageDispatcher, groups domain.GroupPermissionChecker, publisher events.Publisher, expiry config.ExpiryConfig, logger *zap.Logger) domain.MessageService {
+	if publisher == nil {
+		publisher = events.NopPublisher()
+	}
 	return &MessageService{
-		repo:   repo,
-		logger: logger,
+		repo:         repo,
+		interactions: interactions,
+		dispatcher:   dispatcher,
+		groups:       groups,
+		events:       publisher,
+		expiry:       expiry,
+		logger:       logger,
 	}
 }
 
@@ -44,6 +69,18 @@ func (s *MessageService) SendMessage(ctx context.Context, message *domain.Messag
 		return errors.New("message content is required")
 	}
 
+	if err := s.validateTTL(message); err != nil {
+		return err
+	}
+
+	if err := s.checkSendPermission(ctx, message); err != nil {
+		return err
+	}
+
+	if err := s.resolveReply(ctx, message); err != nil {
+		return err
+	}
+
 	// 设置消息ID和时间
 	if message.ID == "" {
 		message.ID = uuid.New().String()
@@ -54,6 +91,7 @@ func (s *MessageService) SendMessage(ctx context.Context, message *domain.Messag
 		message.CreatedAt = now
 	}
 	message.UpdatedAt = now
+	s.applyTTL(message)
 
 	// 设置初始状态
 	if message.Status == "" {
@@ -74,9 +112,100 @@ func (s *MessageService) SendMessage(ctx context.Context, message *domain.Messag
 		)
 	}
 
+	s.dispatch(ctx, message)
+
+	return nil
+}
+
+// checkSendPermission 群组和频道的会话按群组服务的权限校验发言，如只读频道仅管理员可以发言
+// 群组服务不可用时拒绝发送，避免被禁言的成员在故障期间继续发言；不由群组服务管理的会话不做限制
+func (s *MessageService) checkSendPermission(ctx context.Context, message *domain.Message) error {
+	if s.groups == nil {
+		return nil
+	}
+	conversation, err := s.repo.GetConversation(ctx, message.Conversation)
+	if err != nil || (conversation.Type != domain.ConversationTypeGroup && !message.IsGroupChat) {
+		return nil
+	}
+
+	canSend, err := s.groups.CanSendMessages(ctx, conversation.ID, message.SenderID)
+	switch {
+	case errors.Is(err, domain.ErrGroupNotFound):
+		return nil
+	case err != nil:
+		s.logger.Warn("Failed to check group send permission",
+			zap.Error(err),
+			zap.String("conversation_id", conversation.ID),
+			zap.String("user_id", message.SenderID),
+		)
+		return ErrGroupServiceUnavailable
+	case !canSend:
+		return ErrSendNotPermitted
+	}
 	return nil
 }
 
+// dispatch 把消息交给分发工作池推送给其他参与者，并发布message.created事件供通知服务生成离线通知，失败不影响发送结果
+func (s *MessageService) dispatch(ctx context.Context, message *domain.Message) {
+	participants, ok := s.participants(ctx, message)
+	if !ok {
+		return
+	}
+
+	recipients := make([]string, 0, len(participants))
+	for _, participant := range participants {
+		if participant != message.SenderID {
+			recipients = append(recipients, participant)
+		}
+	}
+
+	if s.dispatcher != nil {
+		if err := s.dispatcher.Dispatch(message, recipients); err != nil {
+			s.logger.Warn("Failed to dispatch message",
+				zap.Error(err),
+				zap.String("conversation_id", message.Conversation),
+				zap.String("message_id", message.ID),
+			)
+		}
+	}
+
+	s.publishMessageCreated(ctx, message, recipients)
+}
+
+// dispatchEvent 把消息变更推送给所有参与者，发送者的其他设备也需要同步，失败不影响操作结果
+func (s *MessageService) dispatchEvent(ctx context.Context, event domain.MessageEventType, message *domain.Message) {
+	if s.dispatcher == nil {
+		return
+	}
+
+	participants, ok := s.participants(ctx, message)
+	if !ok {
+		return
+	}
+
+	if err := s.dispatcher.DispatchEvent(event, message, participants); err != nil {
+		s.logger.Warn("Failed to dispatch message event",
+			zap.Error(err),
+			zap.String("event", string(event)),
+			zap.String("conversation_id", message.Conversation),
+			zap.String("message_id", message.ID),
+		)
+	}
+}
+
+// participants 获取消息所在会话的参与者，会话不存在时跳过推送
+func (s *MessageService) participants(ctx context.Context, message *domain.Message) ([]string, bool) {
+	conversation, err := s.repo.GetConversation(ctx, message.Conversation)
+	if err != nil {
+		s.logger.Debug("Skipping realtime dispatch, conversation not found",
+			zap.String("conversation_id", message.Conversation),
+			zap.Error(err),
+		)
+		return nil, false
+	}
+	return conversation.Participants, true
+}
+
 // GetMessage 获取消息
 func (s *MessageService) GetMessage(ctx context.Context, id string) (*domain.Message, error) {
 	if id == "" {
@@ -88,7 +217,7 @@ func (s *MessageService) GetMessage(ctx context.Context, id string) (*domain.Mes
 		return nil, fmt.Errorf("failed to get message: %w", err)
 	}
 
-	return message, nil
+	return s.withReplyCounts(ctx, s.withAggregates(ctx, hideExpired([]*domain.Message{message})))[0], nil
 }
 
 // UpdateMessageStatus 更新消息状态
@@ -149,7 +278,7 @@ func (s *MessageService) GetConversationMessages(ctx context.Context, conversati
 		return nil, fmt.Errorf("failed to get conversation messages: %w", err)
 	}
 
-	return messages, nil
+	return s.withReplyCounts(ctx, s.withAggregates(ctx, hideExpired(messages))), nil
 }
 
 // GetUserConversations 获取用户会话列表
@@ -188,6 +317,10 @@ func (s *MessageService) CreateConversation(ctx context.Context, conversation *d
 		return errors.New("conversation must have at least one participant")
 	}
 
+	if utf8.RuneCountInString(conversation.Name) > maxConversationNameLength {
+		return ErrConversationNameTooLong
+	}
+
 	// 设置会话ID和时间
 	if conversation.ID == "" {
 		conversation.ID = uuid.New().String()
@@ -220,3 +353,167 @@ func (s *MessageService) GetConversation(ctx context.Context, id string) (*domai
 
 	return conversation, nil
 }
+
+// AttachTranscript 保存媒体服务推送的语音转写文本，返回更新的消息数
+// 消息可能晚于转写完成才发送，此时返回0，由媒体服务稍后重试
+func (s *MessageService) AttachTranscript(ctx context.Context, mediaID string, transcript *domain.MediaTranscript) (int, error) {
+	if mediaID == "" {
+		return 0, errors.New("media ID is required")
+	}
+	if transcript == nil || strings.TrimSpace(transcript.Text) == "" {
+		return 0, errors.New("transcript text is required")
+	}
+	if transcript.TranscribedAt.IsZero() {
+		transcript.TranscribedAt = time.Now().UTC()
+	}
+
+	count, err := s.repo.SetMediaTranscript(ctx, mediaID, transcript)
+	if err != nil {
+		return 0, fmt.Errorf("failed to attach transcript: %w", err)
+	}
+
+	s.logger.Info("Transcript attached to voice messages",
+		zap.String("media_id", mediaID),
+		zap.Int("messages", count),
+	)
+	return count, nil
+}
+
+// EditMessage 发送者修改文本消息内容，编辑前的内容写入编辑历史，并把修改后的消息推送给参与者
+func (s *MessageService) EditMessage(ctx context.Context, userID, id, content string) (*domain.Message, error) {
+	if id == "" {
+		return nil, errors.New("message ID is required")
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.New("message content is required")
+	}
+
+	message, err := s.modifiable(ctx, userID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if message.Type != domain.MessageTypeText {
+		return nil, ErrMessageNotEditable
+	}
+	if message.Content == content {
+		return message, nil
+	}
+
+	edit := &domain.MessageEdit{
+		MessageID: id,
+		EditorID:  userID,
+		EditedAt:  time.Now().UTC(),
+	}
+	if err := s.repo.EditContent(ctx, edit, content); err != nil {
+		return nil, fmt.Errorf("failed to edit message: %w", err)
+	}
+
+	edited := *message
+	edited.Content = content
+	edited.EditedAt = &edit.EditedAt
+	edited.UpdatedAt = edit.EditedAt
+
+	s.dispatchEvent(ctx, domain.MessageEventEdited, &edited)
+
+	return &edited, nil
+}
+
+// DeleteMessage 发送者撤回消息，消息保留为清空内容的占位记录，并把撤回事件推送给参与者
+func (s *MessageService) DeleteMessage(ctx context.Context, userID, id string) (*domain.Message, error) {
+	if id == "" {
+		return nil, errors.New("message ID is required")
+	}
+
+	message, err := s.modifiable(ctx, userID, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedAt := time.Now().UTC()
+	if err := s.repo.SoftDelete(ctx, id, deletedAt); err != nil {
+		return nil, fmt.Errorf("failed to delete message: %w", err)
+	}
+
+	deleted := tombstone(message, deletedAt)
+	s.dispatchEvent(ctx, domain.MessageEventDeleted, deleted)
+
+	return deleted, nil
+}
+
+// GetMessageEdits 获取消息的编辑历史，仅会话参与者可以查看，撤回后不再返回
+func (s *MessageService) GetMessageEdits(ctx context.Context, userID, id string) ([]*domain.MessageEdit, error) {
+	if id == "" {
+		return nil, errors.New("message ID is required")
+	}
+
+	message, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if message.DeletedAt != nil {
+		return nil, domain.ErrMessageDeleted
+	}
+
+	participants, ok := s.participants(ctx, message)
+	if !ok || !containsUser(participants, userID) {
+		return nil, ErrNotParticipant
+	}
+
+	edits, err := s.repo.ListEdits(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message edits: %w", err)
+	}
+	return edits, nil
+}
+
+// modifiable 获取发送者可以编辑或撤回的消息，allowAdmin为true时群聊会话的管理员也可以操作其他人的消息
+func (s *MessageService) modifiable(ctx context.Context, userID, id string, allowAdmin bool) (*domain.Message, error) {
+	message, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if message.SenderID != userID && !(allowAdmin && s.isConversationAdmin(ctx, message.Conversation, userID)) {
+		return nil, ErrNotMessageSender
+	}
+	if message.DeletedAt != nil || message.Expired(time.Now()) {
+		return nil, domain.ErrMessageDeleted
+	}
+	return message, nil
+}
+
+// containsUser 判断用户是否在列表中
+func containsUser(userIDs []string, userID string) bool {
+	for _, id := range userIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// withAggregates 批量读取预先维护的回应和已读统计附加到消息上，返回副本以免修改仓库中的对象
+// 统计读取失败时照常返回消息
+func (s *MessageService) withAggregates(ctx context.Context, messages []*domain.Message) []*domain.Message {
+	if s.interactions == nil || len(messages) == 0 {
+		return messages
+	}
+
+	ids := make([]string, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+
+	aggregates, err := s.interactions.GetAggregates(ctx, ids)
+	if err != nil {
+		s.logger.Warn("Failed to get message aggregates", zap.Error(err), zap.Int("messages", len(ids)))
+		return messages
+	}
+
+	result := make([]*domain.Message, 0, len(messages))
+	for _, message := range messages {
+		copied := *message
+		copied.Aggregates = aggregates[message.ID]
+		result = append(result, &copied)
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidReply 回复的消息不存在或不在同一会话中
+var ErrInvalidReply = errors.New("reply target must be an existing message in the same conversation")
+
+// resolveReply 校验被回复的消息并设置话题根消息，回复的回复归入同一话题
+func (s *MessageService) resolveReply(ctx context.Context, message *domain.Message) error {
+	if message.ReplyToMessageID == "" {
+		message.ThreadRootID = ""
+		return nil
+	}
+
+	parent, err := s.repo.GetByID(ctx, message.ReplyToMessageID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrInvalidReply
+		}
+		return fmt.Errorf("failed to get reply target: %w", err)
+	}
+	if parent.Conversation != message.Conversation {
+		return ErrInvalidReply
+	}
+	if parent.DeletedAt != nil || parent.Expired(time.Now()) {
+		return domain.ErrMessageDeleted
+	}
+
+	message.ThreadRootID = parent.ThreadRootID
+	if message.ThreadRootID == "" {
+		message.ThreadRootID = parent.ID
+	}
+	return nil
+}
+
+// GetThread 获取消息所在的话题，按创建时间顺序返回根消息和所有回复，仅会话参与者可以查看
+func (s *MessageService) GetThread(ctx context.Context, userID, id string, limit, offset int) ([]*domain.Message, error) {
+	if id == "" {
+		return nil, errors.New("message ID is required")
+	}
+	if limit <= 0 {
+		limit = 20
+	} else if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	message, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	participants, ok := s.participants(ctx, message)
+	if !ok || !containsUser(participants, userID) {
+		return nil, ErrNotParticipant
+	}
+
+	rootID := message.ThreadRootID
+	if rootID == "" {
+		rootID = message.ID
+	}
+	messages, err := s.repo.ListThread(ctx, rootID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+
+	return s.withReplyCounts(ctx, s.withAggregates(ctx, hideExpired(messages))), nil
+}
+
+// withReplyCounts 附加以消息为根的话题回复数，只复制有回复的消息以免修改仓库中的对象
+// 统计失败时照常返回消息
+func (s *MessageService) withReplyCounts(ctx context.Context, messages []*domain.Message) []*domain.Message {
+	if len(messages) == 0 {
+		return messages
+	}
+
+	ids := make([]string, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+
+	counts, err := s.repo.CountReplies(ctx, ids)
+	if err != nil {
+		s.logger.Warn("Failed to count message replies", zap.Error(err), zap.Int("messages", len(ids)))
+		return messages
+	}
+
+	result := make([]*domain.Message, 0, len(messages))
+	for _, message := range messages {
+		if count := counts[message.ID]; count > 0 {
+			copied := *message
+			copied.ReplyCount = count
+			message = &copied
+		}
+		result = append(result, message)
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+)
+
+const (
+	// defaultWindowSize 目标前后默认各返回的消息数
+	defaultWindowSize = 20
+	// maxWindowSize 目标前后各返回的消息数上限
+	maxWindowSize = 50
+)
+
+// GetMessagesAround 获取目标消息或时间点前后各limit条消息，仅会话参与者可以查看
+// messageID非空时以该消息为中心，否则以at为中心；目标消息本身不计入limit
+func (s *MessageService) GetMessagesAround(ctx context.Context, userID, conversationID, messageID string, at time.Time, limit int) (*domain.MessageWindow, error) {
+	if conversationID == "" {
+		return nil, errors.New("conversation ID is required")
+	}
+	if messageID == "" && at.IsZero() {
+		return nil, errors.New("message ID or date is required")
+	}
+	if limit <= 0 {
+		limit = defaultWindowSize
+	} else if limit > maxWindowSize {
+		limit = maxWindowSize
+	}
+
+	conversation, err := s.repo.GetConversation(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if !containsUser(conversation.Participants, userID) {
+		return nil, ErrNotParticipant
+	}
+
+	after := limit
+	if messageID != "" {
+		target, err := s.repo.GetByID(ctx, messageID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get message: %w", err)
+		}
+		if target.Conversation != conversationID {
+			return nil, ErrMessageNotInConversation
+		}
+		at = target.CreatedAt
+		after++
+	}
+
+	// 两侧各多取一条，用于判断是否还有更多消息
+	messages, err := s.repo.GetMessagesAround(ctx, conversationID, at, limit+1, after+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages around: %w", err)
+	}
+
+	split := 0
+	for split < len(messages) && messages[split].CreatedAt.Before(at) {
+		split++
+	}
+	window := &domain.MessageWindow{AnchorID: messageID}
+	if split > limit {
+		window.HasMoreBefore = true
+		messages = messages[1:]
+		split--
+	}
+	if len(messages)-split > after {
+		window.HasMoreAfter = true
+		messages = messages[:split+after]
+	}
+	if window.AnchorID == "" && split < len(messages) {
+		window.AnchorID = messages[split].ID
+	}
+
+	window.Messages = s.withReplyCounts(ctx, s.withAggregates(ctx, hideExpired(messages)))
+	return window, nil
+}
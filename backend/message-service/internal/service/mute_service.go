@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidMuteDuration 免打扰时长只能是1h、8h、forever或off
+var ErrInvalidMuteDuration = errors.New("invalid mute duration: must be one of 1h, 8h, forever, off")
+
+// muteDurations 可选的限时免打扰时长
+var muteDurations = map[string]time.Duration{
+	domain.MuteDurationOneHour:    time.Hour,
+	domain.MuteDurationEightHours: 8 * time.Hour,
+}
+
+// MuteService 会话免打扰服务实现
+// 免打扰只影响通知服务的推送，消息和WebSocket实时推送照常进行
+type MuteService struct {
+	mutes    domain.MuteRepository
+	messages domain.MessageRepository
+	logger   *zap.Logger
+}
+
+// NewMuteService 创建一个新的会话免打扰服务
+func NewMuteService(mutes domain.MuteRepository, messages domain.MessageRepository, logger *zap.Logger) domain.MuteService {
+	return &MuteService{
+		mutes:    mutes,
+		messages: messages,
+		logger:   logger,
+	}
+}
+
+// MuteConversation 设置或取消会话免打扰，仅会话参与者可以设置
+func (s *MuteService) MuteConversation(ctx context.Context, userID, conversationID string, req *domain.MuteConversationRequest) (*domain.ConversationMute, error) {
+	if conversationID == "" {
+		return nil, errors.New("conversation ID is required")
+	}
+
+	now := time.Now().UTC()
+	mute := &domain.ConversationMute{
+		ConversationID: conversationID,
+		UserID:         userID,
+		Muted:          true,
+		UpdatedAt:      now,
+	}
+	switch req.Duration {
+	case domain.MuteDurationOff:
+		mute.Muted = false
+	case domain.MuteDurationForever:
+	default:
+		duration, ok := muteDurations[req.Duration]
+		if !ok {
+			return nil, ErrInvalidMuteDuration
+		}
+		until := now.Add(duration)
+		mute.MutedUntil = &until
+	}
+
+	conversation, err := s.messages.GetConversation(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if !containsUser(conversation.Participants, userID) {
+		return nil, ErrNotParticipant
+	}
+
+	if !mute.Muted {
+		if err := s.mutes.DeleteMute(ctx, conversationID, userID); err != nil {
+			return nil, err
+		}
+		return mute, nil
+	}
+	if err := s.mutes.SetMute(ctx, mute); err != nil {
+		return nil, err
+	}
+
+	s.logger.Debug("Conversation muted",
+		zap.String("conversation_id", conversationID),
+		zap.String("user_id", userID),
+		zap.String("duration", req.Duration),
+	)
+	return mute, nil
+}
+
+// ListActiveMutes 列出会话中仍然有效的免打扰设置
+func (s *MuteService) ListActiveMutes(ctx context.Context, conversationID string) ([]*domain.ConversationMute, error) {
+	if conversationID == "" {
+		return nil, errors.New("conversation ID is required")
+	}
+	return s.mutes.ListActiveMutes(ctx, conversationID, time.Now().UTC())
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrPinNotPermitted         = errors.New("group role does not allow pinning messages")
+	ErrMessageNotPinned        = errors.New("message is not pinned")
+	ErrGroupServiceUnavailable = errors.New("group service unavailable")
+)
+
+// PinService 置顶消息服务实现
+// 群聊中置顶和取消置顶需要群组服务授予pin_messages权限，与群组角色保持一致；私聊参与者都可以置顶
+type PinService struct {
+	pins       domain.PinRepository
+	messages   domain.MessageRepository
+	groups     domain.GroupPermissionChecker
+	dispatcher domain.MessageDispatcher
+	logger     *zap.Logger
+}
+
+// NewPinService 创建一个新的置顶消息服务，groups为nil时群聊也只校验会话参与者，dispatcher为nil时不做实时推送
+func NewPinService(pins domain.PinRepository, messages domain.MessageRepository, groups domain.GroupPermissionChecker, dispatcher domain.MessageDispatcher, logger *zap.Logger) domain.PinService {
+	return &PinService{
+		pins:       pins,
+		messages:   messages,
+		groups:     groups,
+		dispatcher: dispatcher,
+		logger:     logger,
+	}
+}
+
+// PinMessage 置顶消息，重复置顶返回已有的置顶记录
+func (s *PinService) PinMessage(ctx context.Context, userID, messageID string) (*domain.MessagePin, error) {
+	message, err := s.authorize(ctx, userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.DeletedAt != nil {
+		return nil, domain.ErrMessageDeleted
+	}
+
+	pin := &domain.MessagePin{
+		ConversationID: message.Conversation,
+		MessageID:      message.ID,
+		PinnedBy:       userID,
+		PinnedAt:       time.Now().UTC(),
+	}
+	created, err := s.pins.Pin(ctx, pin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pin message: %w", err)
+	}
+	if !created {
+		return s.existingPin(ctx, message.Conversation, message.ID, pin)
+	}
+
+	s.dispatchEvent(ctx, domain.MessageEventPinned, message)
+	return pin, nil
+}
+
+// UnpinMessage 取消置顶，与置顶使用相同的权限
+func (s *PinService) UnpinMessage(ctx context.Context, userID, messageID string) error {
+	message, err := s.authorize(ctx, userID, messageID)
+	if err != nil {
+		return err
+	}
+
+	removed, err := s.pins.Unpin(ctx, message.Conversation, message.ID)
+	if err != nil {
+		return fmt.Errorf("failed to unpin message: %w", err)
+	}
+	if !removed {
+		return ErrMessageNotPinned
+	}
+
+	s.dispatchEvent(ctx, domain.MessageEventUnpinned, message)
+	return nil
+}
+
+// ListPins 列出会话中的置顶消息并附带消息内容，仅会话参与者可以查看
+func (s *PinService) ListPins(ctx context.Context, userID, conversationID string) ([]*domain.MessagePin, error) {
+	if conversationID == "" {
+		return nil, errors.New("conversation ID is required")
+	}
+
+	conversation, err := s.messages.GetConversation(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if !containsUser(conversation.Participants, userID) {
+		return nil, ErrNotParticipant
+	}
+
+	pins, err := s.pins.ListPins(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pinned messages: %w", err)
+	}
+	for _, pin := range pins {
+		message, err := s.messages.GetByID(ctx, pin.MessageID)
+		if err != nil {
+			// 消息所在分区已归档时只返回置顶记录
+			s.logger.Debug("Pinned message unavailable", zap.String("message_id", pin.MessageID), zap.Error(err))
+			continue
+		}
+		pin.Message = message
+	}
+	return pins, nil
+}
+
+// authorize 确认消息存在、用户是会话参与者，群聊中还需要群组角色允许置顶
+func (s *PinService) authorize(ctx context.Context, userID, messageID string) (*domain.Message, error) {
+	if messageID == "" {
+		return nil, errors.New("message ID is required")
+	}
+
+	message, err := s.messages.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	conversation, err := s.messages.GetConversation(ctx, message.Conversation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if !containsUser(conversation.Participants, userID) {
+		return nil, ErrNotParticipant
+	}
+	if (conversation.Type != "group" && !message.IsGroupChat) || s.groups == nil {
+		return message, nil
+	}
+
+	// 群组服务不可用时拒绝操作，避免被移除管理员角色的成员在故障期间继续置顶
+	canPin, err := s.groups.CanPinMessages(ctx, conversation.ID, userID)
+	switch {
+	case errors.Is(err, domain.ErrGroupNotFound):
+		// 不由群组服务管理的多人会话沿用参与者规则
+		return message, nil
+	case err != nil:
+		s.logger.Warn("Failed to check group pin permission",
+			zap.Error(err),
+			zap.String("conversation_id", conversation.ID),
+			zap.String("user_id", userID),
+		)
+		return nil, ErrGroupServiceUnavailable
+	case !canPin:
+		return nil, ErrPinNotPermitted
+	}
+	return message, nil
+}
+
+// existingPin 获取已存在的置顶记录，并发取消置顶导致找不到时返回本次的记录
+func (s *PinService) existingPin(ctx context.Context, conversationID, messageID string, fallback *domain.MessagePin) (*domain.MessagePin, error) {
+	pins, err := s.pins.ListPins(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pinned messages: %w", err)
+	}
+	for _, pin := range pins {
+		if pin.MessageID == messageID {
+			return pin, nil
+		}
+	}
+	return fallback, nil
+}
+
+// dispatchEvent 把置顶变更推送给所有参与者，失败不影响操作结果
+func (s *PinService) dispatchEvent(ctx context.Context, event domain.MessageEventType, message *domain.Message) {
+	if s.dispatcher == nil {
+		return
+	}
+
+	conversation, err := s.messages.GetConversation(ctx, message.Conversation)
+	if err != nil {
+		return
+	}
+	if err := s.dispatcher.DispatchEvent(event, message, conversation.Participants); err != nil {
+		s.logger.Warn("Failed to dispatch pin event",
+			zap.Error(err),
+			zap.String("event", string(event)),
+			zap.String("conversation_id", message.Conversation),
+			zap.String("message_id", message.ID),
+		)
+	}
+}
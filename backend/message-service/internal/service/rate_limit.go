@@ -0,0 +1,72 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimitError 请求超出限流，RetryAfter为距离窗口重置的时间
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// userRateLimiter 按用户的固定窗口限流器
+type userRateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	users     map[string]*userWindow
+	lastSweep time.Time
+}
+
+// userWindow 单个用户在当前窗口内的请求计数
+type userWindow struct {
+	start time.Time
+	count int
+}
+
+func newUserRateLimiter(limit int, window time.Duration) *userRateLimiter {
+	return &userRateLimiter{
+		limit:     limit,
+		window:    window,
+		users:     make(map[string]*userWindow),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow 判断请求是否允许，拒绝时返回RateLimitError；limit<=0表示不限流
+func (l *userRateLimiter) Allow(userID string) error {
+	if l.limit <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	// 定期清理过期窗口
+	if now.Sub(l.lastSweep) > l.window {
+		for key, w := range l.users {
+			if now.Sub(w.start) >= l.window {
+				delete(l.users, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	w, ok := l.users[userID]
+	if !ok || now.Sub(w.start) >= l.window {
+		l.users[userID] = &userWindow{start: now, count: 1}
+		return nil
+	}
+	if w.count >= l.limit {
+		return &RateLimitError{RetryAfter: w.start.Add(l.window).Sub(now)}
+	}
+	w.count++
+	return nil
+}
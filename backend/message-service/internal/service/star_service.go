@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neohope/chatapp/message-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// StarService 收藏消息服务实现，收藏按用户保存，跨会话列出
+type StarService struct {
+	stars    domain.StarRepository
+	messages domain.MessageRepository
+	logger   *zap.Logger
+}
+
+// NewStarService 创建一个新的收藏消息服务
+func NewStarService(stars domain.StarRepository, messages domain.MessageRepository, logger *zap.Logger) domain.StarService {
+	return &StarService{
+		stars:    stars,
+		messages: messages,
+		logger:   logger,
+	}
+}
+
+// StarMessage 收藏消息，仅会话参与者可以收藏，重复收藏不修改收藏时间
+func (s *StarService) StarMessage(ctx context.Context, userID, messageID string) error {
+	if messageID == "" {
+		return errors.New("message ID is required")
+	}
+
+	message, err := s.messages.GetByID(ctx, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to get message: %w", err)
+	}
+	if message.DeletedAt != nil || message.Expired(time.Now()) {
+		return domain.ErrMessageDeleted
+	}
+
+	conversation, err := s.messages.GetConversation(ctx, message.Conversation)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if !containsUser(conversation.Participants, userID) {
+		return ErrNotParticipant
+	}
+
+	return s.stars.Star(ctx, &domain.StarredMessage{
+		UserID:         userID,
+		MessageID:      message.ID,
+		ConversationID: message.Conversation,
+		StarredAt:      time.Now().UTC(),
+	})
+}
+
+// UnstarMessage 取消收藏，未收藏时同样视为成功
+func (s *StarService) UnstarMessage(ctx context.Context, userID, messageID string) error {
+	if messageID == "" {
+		return errors.New("message ID is required")
+	}
+	return s.stars.Unstar(ctx, userID, messageID)
+}
+
+// ListStarred 按收藏时间倒序列出用户收藏的消息并附带消息内容
+// 收藏后被撤回或自毁的消息返回撤回后的占位，所在分区已归档时只返回收藏记录
+func (s *StarService) ListStarred(ctx context.Context, userID string, limit, offset int) ([]*domain.StarredMessage, error) {
+	stars, err := s.stars.ListStars(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, star := range stars {
+		message, err := s.messages.GetByID(ctx, star.MessageID)
+		if err != nil {
+			s.logger.Debug("Starred message unavailable", zap.String("message_id", star.MessageID), zap.Error(err))
+			continue
+		}
+		star.Message = hideExpired([]*domain.Message{message})[0]
+	}
+	return stars, nil
+}
@@ -19,6 +19,9 @@ type CustomClaims struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	Email    string `json:"email"`
+	Role     string `json:"role,omitempty"`
+	// 用户服务签发的刷新令牌token_type为refresh，不能用于访问接口
+	TokenType string `json:"token_type,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -73,6 +76,9 @@ func (m *JWTManager) VerifyToken(tokenString string) (*CustomClaims, error) {
 	if !ok || !token.Valid {
 		return nil, errors.New("invalid token")
 	}
+	if claims.TokenType == "refresh" {
+		return nil, errors.New("refresh token cannot be used as access token")
+	}
 
 	return claims, nil
 }
\ No newline at end of file
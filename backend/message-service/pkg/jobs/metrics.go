@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Metrics 单个任务的执行统计，仅统计当前实例
+type Metrics struct {
+	Runs            int64      `json:"runs"`
+	Succeeded       int64      `json:"succeeded"`
+	Failed          int64      `json:"failed"`
+	Panics          int64      `json:"panics"`
+	TotalDurationMs int64      `json:"total_duration_ms"`
+	LastDurationMs  int64      `json:"last_duration_ms"`
+	LastRunAt       *time.Time `json:"last_run_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+}
+
+// record 记录一次执行结果
+func (r *Runner) record(name string, start time.Time, err error) {
+	duration := time.Since(start).Milliseconds()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.metrics[name]
+	if !ok {
+		m = &Metrics{}
+		r.metrics[name] = m
+	}
+	m.Runs++
+	m.TotalDurationMs += duration
+	m.LastDurationMs = duration
+	m.LastRunAt = &start
+	if err == nil {
+		m.Succeeded++
+		return
+	}
+
+	m.Failed++
+	m.LastError = err.Error()
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		m.Panics++
+	}
+}
+
+// Metrics 返回各任务执行统计的快照
+func (r *Runner) Metrics() map[string]Metrics {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot := make(map[string]Metrics, len(r.metrics))
+	for name, m := range r.metrics {
+		snapshot[name] = *m
+	}
+	return snapshot
+}
+
+// MetricsHandler 以JSON返回各任务执行统计，供内部监控抓取
+func (r *Runner) MetricsHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{ // nolint: errcheck
+		"jobs": r.Metrics(),
+	})
+}
@@ -0,0 +1,157 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultLimit 未指定limit时的默认每页数量
+	DefaultLimit = 20
+	// MaxLimit 允许的最大每页数量，超过时截断
+	MaxLimit = 100
+
+	// cursorPrefix 游标编码前缀，便于以后扩展其他游标类型
+	cursorPrefix = "o:"
+	// NextCursorHeader 下一页游标响应头，供不解析Link头的客户端使用
+	NextCursorHeader = "X-Next-Cursor"
+)
+
+var (
+	// ErrInvalidLimit limit参数不是正整数
+	ErrInvalidLimit = errors.New("invalid limit")
+	// ErrInvalidOffset offset参数不是非负整数
+	ErrInvalidOffset = errors.New("invalid offset")
+	// ErrInvalidCursor cursor参数无法解码
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Options 分页参数解析选项，零值字段使用包级默认值
+type Options struct {
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// Page 解析后的分页参数
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// Parse 使用默认选项解析请求中的分页参数
+func Parse(r *http.Request) (Page, error) {
+	return ParseWithOptions(r, Options{})
+}
+
+// ParseWithOptions 解析请求中的 limit、offset 和 cursor 参数
+// cursor 优先于 offset；limit 超过上限时截断为上限，非法值返回错误
+func ParseWithOptions(r *http.Request, opts Options) (Page, error) {
+	if opts.DefaultLimit <= 0 {
+		opts.DefaultLimit = DefaultLimit
+	}
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = MaxLimit
+	}
+	if opts.DefaultLimit > opts.MaxLimit {
+		opts.DefaultLimit = opts.MaxLimit
+	}
+
+	query := r.URL.Query()
+	page := Page{Limit: opts.DefaultLimit}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return Page{}, ErrInvalidLimit
+		}
+		if limit > opts.MaxLimit {
+			limit = opts.MaxLimit
+		}
+		page.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		offset, err := DecodeCursor(value)
+		if err != nil {
+			return Page{}, err
+		}
+		page.Offset = offset
+	} else if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return Page{}, ErrInvalidOffset
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
+
+// HasMore 根据本页返回的条数判断是否可能还有下一页
+func (p Page) HasMore(count int) bool {
+	return count >= p.Limit
+}
+
+// NextCursor 下一页的游标
+func (p Page) NextCursor() string {
+	return EncodeCursor(p.Offset + p.Limit)
+}
+
+// EncodeCursor 将偏移量编码为不透明游标
+func EncodeCursor(offset int) string {
+	if offset < 0 {
+		offset = 0
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+// DecodeCursor 解码游标得到偏移量
+func DecodeCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	value := string(data)
+	if !strings.HasPrefix(value, cursorPrefix) {
+		return 0, ErrInvalidCursor
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(value, cursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return offset, nil
+}
+
+// SetLinkHeader 按RFC 5988写入分页Link响应头（first、prev、next），hasMore为true时同时写入下一页游标头
+// 链接保留原请求的其他查询参数，并以cursor代替offset
+func SetLinkHeader(w http.ResponseWriter, r *http.Request, page Page, hasMore bool) {
+	links := []string{link(r, page.Limit, 0, "first")}
+	if page.Offset > 0 {
+		prev := page.Offset - page.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		links = append(links, link(r, page.Limit, prev, "prev"))
+	}
+	if hasMore {
+		links = append(links, link(r, page.Limit, page.Offset+page.Limit, "next"))
+		w.Header().Set(NextCursorHeader, page.NextCursor())
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// link 生成单个Link头条目
+func link(r *http.Request, limit, offset int, rel string) string {
+	query := r.URL.Query()
+	query.Del("offset")
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("cursor", EncodeCursor(offset))
+
+	target := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf("<%s>; rel=%q", target.String(), rel)
+}
@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MongoMonitor 为请求上下文中执行的MongoDB命令创建子span，span名称为命令名（如mongodb find）
+// 只记录集合和命令名，不记录命令内容
+func MongoMonitor() *event.CommandMonitor {
+	var spans sync.Map // RequestID -> trace.Span
+	tracer := otel.Tracer(instrumentationName)
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			if !trace.SpanFromContext(ctx).IsRecording() {
+				return
+			}
+			attrs := []attribute.KeyValue{
+				semconv.DBSystemMongoDB,
+				semconv.DBName(evt.DatabaseName),
+				semconv.DBOperation(evt.CommandName),
+			}
+			// 大多数命令的第一个字段为集合名，如 {find: "messages", ...}
+			if elem, err := evt.Command.IndexErr(0); err == nil {
+				if collection, ok := elem.Value().StringValueOK(); ok {
+					attrs = append(attrs, semconv.DBMongoDBCollection(collection))
+				}
+			}
+			_, span := tracer.Start(ctx, "mongodb "+evt.CommandName,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+			spans.Store(evt.RequestID, span)
+		},
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			if span, ok := spans.LoadAndDelete(evt.RequestID); ok {
+				span.(trace.Span).End()
+			}
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			if value, ok := spans.LoadAndDelete(evt.RequestID); ok {
+				span := value.(trace.Span)
+				span.RecordError(errors.New(evt.Failure))
+				span.SetStatus(codes.Error, evt.Failure)
+				span.End()
+			}
+		},
+	}
+}
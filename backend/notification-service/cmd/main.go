@@ -2,21 +2,34 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
-	"os"
-	"os/signal"
 	"strconv"
-	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 
 	"github.com/neohope/chatapp/notification-service/config"
+	"github.com/neohope/chatapp/notification-service/internal/client"
 	handlers "github.com/neohope/chatapp/notification-service/internal/delivery/http"
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+	"github.com/neohope/chatapp/notification-service/internal/i18n"
+	"github.com/neohope/chatapp/notification-service/internal/push"
 	"github.com/neohope/chatapp/notification-service/internal/repository"
 	"github.com/neohope/chatapp/notification-service/internal/service"
+	"github.com/neohope/chatapp/notification-service/pkg/events"
+	"github.com/neohope/chatapp/notification-service/pkg/jobs"
+	"github.com/neohope/chatapp/notification-service/pkg/lock"
 	"github.com/neohope/chatapp/notification-service/pkg/logger"
+	"github.com/neohope/chatapp/notification-service/pkg/metrics"
+	"github.com/neohope/chatapp/notification-service/pkg/openapi"
+	"github.com/neohope/chatapp/notification-service/pkg/recovery"
+	"github.com/neohope/chatapp/notification-service/pkg/shutdown"
+	"github.com/neohope/chatapp/notification-service/pkg/tracing"
 )
 
 func main() {
@@ -35,45 +48,204 @@ func main() {
 
 	log.Info("Starting notification service", zap.Int("port", cfg.HTTPPort))
 
-	// 初始化存储库
-	notificationRepo := repository.NewMemoryNotificationRepository()
-	userDeviceRepo := repository.NewMemoryUserDeviceRepository()
-	notificationPreferenceRepo := repository.NewMemoryNotificationPreferenceRepository()
+	// 链路追踪：从请求的traceparent继续网关开始的trace，按配置导出到OTLP或Jaeger
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Options{
+		Service:       "notification-service",
+		Exporter:      cfg.Tracing.Exporter,
+		Endpoint:      cfg.Tracing.Endpoint,
+		Insecure:      cfg.Tracing.Insecure,
+		SamplePercent: cfg.Tracing.SamplePercent,
+	}, log)
+	if err != nil {
+		log.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+
+	// 初始化存储库，STORAGE_BACKEND=postgres时通知、设备和偏好保存到数据库
+	var (
+		db                         *sqlx.DB
+		notificationRepo           domain.NotificationRepository
+		userDeviceRepo             domain.UserDeviceRepository
+		notificationPreferenceRepo domain.NotificationPreferenceRepository
+	)
+	switch cfg.StorageBackend {
+	case "postgres":
+		db, err = initDatabase(cfg, log)
+		if err != nil {
+			log.Fatal("Failed to connect to database", zap.Error(err))
+		}
+		defer db.Close()
+		notificationRepo = repository.NewPostgresNotificationRepository(db)
+		userDeviceRepo = repository.NewPostgresUserDeviceRepository(db)
+		notificationPreferenceRepo = repository.NewPostgresNotificationPreferenceRepository(db)
+		log.Info("Using PostgreSQL repositories")
+	case "memory":
+		notificationRepo = repository.NewMemoryNotificationRepository()
+		userDeviceRepo = repository.NewMemoryUserDeviceRepository()
+		notificationPreferenceRepo = repository.NewMemoryNotificationPreferenceRepository()
+		log.Info("Using memory repositories")
+	default:
+		log.Fatal("Unsupported storage backend", zap.String("backend", cfg.StorageBackend))
+	}
+	replyTokenRepo := repository.NewMemoryReplyTokenRepository()
+	experimentRepo := repository.NewMemoryExperimentRepository()
 
-	// 初始化推送服务
+	// 初始化推送服务，按设备平台选择FCM或APNs
+	pushProviders, err := push.NewProviders(&cfg.PushNotification, log)
+	if err != nil {
+		log.Fatal("Failed to init push providers", zap.Error(err))
+	}
 	pushService := service.NewPushService(
 		userDeviceRepo,
+		pushProviders,
 		&cfg.PushNotification,
 		log,
 	)
 
+	// 初始化通知模板本地化
+	catalog, err := i18n.NewCatalog(cfg.Locale.DefaultLocale, i18n.DefaultTemplates)
+	if err != nil {
+		log.Fatal("Failed to load notification templates", zap.Error(err))
+	}
+	userClient := client.NewUserClient(cfg.UserServiceURL)
+	localeResolver := i18n.NewLocaleResolver(
+		userClient,
+		cfg.Locale.DefaultLocale,
+		cfg.Locale.CacheTTL,
+		log,
+	)
+	localizer := i18n.NewLocalizer(catalog, localeResolver)
+
+	// 初始化邮件回复服务
+	messageClient := client.NewMessageClient(cfg.MessageServiceURL)
+	inboundEmailService := service.NewInboundEmailService(
+		replyTokenRepo,
+		messageClient,
+		cfg.InboundEmail.ReplyDomain,
+		cfg.InboundEmail.TokenTTL,
+		log,
+	)
+
+	// 初始化通知文案实验
+	experimentService := service.NewExperimentService(
+		experimentRepo,
+		notificationRepo,
+		localizer,
+		cfg.Experiment.AttributionWindow,
+		log,
+	)
+
+	// 初始化后台任务，有数据库时使用持久化队列，延后推送的通知在重启后继续执行
+	// 启用主实例选举时只有主实例触发定时任务
+	elector := initLeaderElector(cfg, log)
+	jobOptions := jobs.Options{}
+	if elector != nil {
+		jobOptions.Leader = elector
+	}
+	jobRunner := jobs.NewRunner(initJobQueue(db, log), jobOptions, log)
+	err = jobRunner.Schedule("cleanup_reply_tokens", "@hourly", func(ctx context.Context, _ json.RawMessage) error {
+		return replyTokenRepo.DeleteExpired()
+	}, jobs.MaxAttempts(1))
+	if err != nil {
+		log.Fatal("Failed to schedule reply token cleanup", zap.Error(err))
+	}
+
+	// 初始化用户通知频率限制
+	rateLimiter := service.NewRateLimiter(cfg.RateLimit.HourlyCap, cfg.RateLimit.DailyCap)
+	// 频率限制计数保存在进程内，每个实例都需要清理
+	err = jobRunner.Schedule("prune_rate_limits", "@hourly", func(ctx context.Context, _ json.RawMessage) error {
+		rateLimiter.Prune()
+		return nil
+	}, jobs.Local())
+	if err != nil {
+		log.Fatal("Failed to schedule rate limit pruning", zap.Error(err))
+	}
+
 	// 初始化通知服务
 	notificationService := service.NewNotificationService(
 		notificationRepo,
 		userDeviceRepo,
 		notificationPreferenceRepo,
 		pushService,
+		localizer,
+		inboundEmailService,
+		service.NewRecipientFilter(userClient, cfg.Locale.CacheTTL, log),
+		experimentService,
+		rateLimiter,
+		jobRunner,
 		log,
 	)
 
+	// 订阅其他服务的领域事件并转换为通知，多个实例在同一队列组中分担事件
+	// 新消息通知跳过对会话或群组开启了免打扰的接收者
+	muteFilter := service.NewMuteFilter(messageClient, client.NewGroupClient(cfg.GroupServiceURL), cfg.Mute.CacheTTL, log)
+	eventBus := initEventBus(cfg, service.NewEventConsumer(notificationService, muteFilter, log), log)
+
 	// 初始化HTTP处理器
 	handler := handlers.NewHandler(notificationService, log)
+	inboundEmailHandler := handlers.NewInboundEmailHandler(inboundEmailService, &cfg.InboundEmail, log)
+	experimentHandler := handlers.NewExperimentHandler(experimentService, cfg.Experiment.AdminToken, log)
 
 	// 设置路由
 	router := mux.NewRouter()
 	handler.RegisterRoutes(router)
+	inboundEmailHandler.RegisterRoutes(router)
+	experimentHandler.RegisterRoutes(router)
+	router.HandleFunc("/internal/jobs/metrics", jobRunner.MetricsHandler).Methods("GET")
+
+	// 接口文档：由注册的路由和接口说明生成，两者不一致时记录警告，严格模式下拒绝启动
+	apiDoc, problems := openapi.Build(router, "notification-service", handlers.APIOperations)
+	for _, problem := range problems {
+		log.Warn("OpenAPI document does not match routes", zap.String("problem", problem))
+	}
+	if len(problems) > 0 && cfg.OpenAPI.Strict {
+		log.Fatal("OpenAPI document does not match routes", zap.Int("problems", len(problems)))
+	}
+	router.HandleFunc("/openapi.json", openapi.Handler(apiDoc)).Methods("GET")
 
 	// CORS中间件已移除，由API网关统一处理
 
+	// 处理请求时的panic转换为带请求ID的500响应，配置了SENTRY_DSN时上报到Sentry
+	reporter, err := recovery.ReporterFromDSN(cfg.ErrorReporting.SentryDSN, cfg.ErrorReporting.Environment)
+	if err != nil {
+		log.Fatal("Invalid error reporting configuration", zap.Error(err))
+	}
+	recoverer := recovery.New(recovery.Options{Service: "notification-service", Reporter: reporter}, log)
+
+	// Prometheus指标：HTTP请求和数据库连接池
+	var httpHandler http.Handler = recoverer.Middleware(router)
+	if cfg.Metrics.Enabled {
+		serviceMetrics := metrics.New("notification-service")
+		if db != nil {
+			serviceMetrics.RegisterDB(db.DB, "postgres")
+		}
+		router.Handle("/metrics", serviceMetrics.Handler(cfg.Metrics.Token)).Methods("GET")
+		httpHandler = serviceMetrics.Instrument(router, httpHandler)
+	}
+	httpHandler = tracing.Middleware("notification-service", router, httpHandler)
+
+	// 优雅关闭：排空期间健康检查返回503，负载均衡摘除本实例后再停止接收连接
+	coordinator := shutdown.New(shutdown.Options{
+		DrainDelay: cfg.Shutdown.DrainDelay,
+		Timeout:    cfg.Shutdown.Timeout,
+	}, log)
+
 	// 创建HTTP服务器
 	srv := &http.Server{
 		Addr:         ":" + strconv.Itoa(cfg.HTTPPort),
-		Handler:      router,
+		Handler:      coordinator.Middleware(httpHandler),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// 启动后台任务
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if elector != nil {
+		elector.Start(jobCtx)
+	}
+	jobRunner.Start(jobCtx)
+
 	// 启动服务器
 	go func() {
 		log.Info("Server starting", zap.String("address", srv.Addr))
@@ -82,22 +254,182 @@ func main() {
 		}
 	}()
 
-	// 等待中断信号
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	// 关闭顺序：停止接收请求并等待处理中的请求 -> 处理完已收到的事件 -> 等待后台任务 -> 导出剩余的span
+	coordinator.Register("http", srv.Shutdown)
+	if eventBus != nil {
+		coordinator.Register("events", eventBus.Close)
+	}
+	coordinator.Register("jobs", func(ctx context.Context) error {
+		stopJobs()
+		if elector != nil {
+			defer elector.Wait()
+		}
+		return shutdown.Await(ctx, jobRunner.Wait)
+	})
+	coordinator.Register("tracing", shutdownTracing)
+	coordinator.Wait()
+
+	log.Info("Server exited")
+}
 
-	log.Info("Server shutting down...")
+// initLeaderElector 初始化定时任务的主实例选举，未启用或Redis连接失败时返回nil，此时每个实例都触发定时任务
+func initLeaderElector(cfg *config.Config, log *zap.Logger) *lock.Elector {
+	if !cfg.LeaderElection.Enabled {
+		return nil
+	}
+
+	addr := cfg.Redis.Host + ":" + strconv.Itoa(cfg.Redis.Port)
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
 
-	// 优雅关闭
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		log.Warn("Failed to connect to Redis, leader election disabled", zap.Error(err))
+		client.Close()
+		return nil
+	}
+
+	log.Info("Leader election enabled for scheduled jobs",
+		zap.String("redis_addr", addr),
+		zap.Duration("ttl", cfg.LeaderElection.TTL),
+	)
+	return lock.NewElector(lock.NewLocker(client, "notification-service"), "jobs", cfg.LeaderElection.TTL, log)
+}
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown", zap.Error(err))
+// initEventBus 连接事件总线并订阅通知相关事件，未配置或连接失败时返回nil，
+// 此时只能通过HTTP接口创建通知
+func initEventBus(cfg *config.Config, consumer *service.EventConsumer, log *zap.Logger) *events.Bus {
+	if cfg.EventBusURL == "" {
+		log.Info("Event bus not configured, domain events disabled")
+		return nil
+	}
+	bus, err := events.Connect(cfg.EventBusURL, "notification-service", log)
+	if err != nil {
+		log.Warn("Failed to connect to event bus, domain events disabled", zap.Error(err))
+		return nil
 	}
+	if err := bus.Subscribe("notification-service", consumer.Handle, service.ConsumedEventTypes...); err != nil {
+		log.Warn("Failed to subscribe to domain events", zap.Error(err))
+		return bus
+	}
+	log.Info("Subscribed to domain events", zap.Strings("types", service.ConsumedEventTypes))
+	return bus
+}
 
-	log.Info("Server exited")
+// initDatabase 初始化数据库连接并运行迁移
+func initDatabase(cfg *config.Config, log *zap.Logger) (*sqlx.DB, error) {
+	dsn := fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Database.Host,
+		cfg.Database.Port,
+		cfg.Database.User,
+		cfg.Database.Password,
+		cfg.Database.DBName,
+		cfg.Database.SSLMode,
+	)
+
+	// 请求中的查询和写入记录为请求span的子span
+	sqlDB, err := tracing.OpenPostgres(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	db := sqlx.NewDb(sqlDB, "postgres")
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	// 设置连接池参数
+	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+
+	// 运行数据库迁移，表结构不完整时无法保存通知，直接返回错误
+	if err := runMigrations(db, log); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log.Info("Database connected successfully")
+	return db, nil
+}
+
+// runMigrations 运行数据库迁移
+func runMigrations(db *sqlx.DB, log *zap.Logger) error {
+	migrations := []string{
+		// 通知表
+		`CREATE TABLE IF NOT EXISTS notifications (
+			id VARCHAR(64) PRIMARY KEY,
+			user_id VARCHAR(36) NOT NULL,
+			type VARCHAR(32) NOT NULL,
+			title TEXT NOT NULL,
+			body TEXT NOT NULL,
+			data JSONB,
+			template VARCHAR(128) NOT NULL DEFAULT '',
+			params JSONB,
+			locale VARCHAR(16) NOT NULL DEFAULT '',
+			experiment_id VARCHAR(36) NOT NULL DEFAULT '',
+			variant TEXT NOT NULL DEFAULT '',
+			status VARCHAR(16) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			sent_at TIMESTAMP WITH TIME ZONE,
+			read_at TIMESTAMP WITH TIME ZONE
+		)`,
+
+		// 用户设备表，设备令牌全局唯一
+		`CREATE TABLE IF NOT EXISTS user_devices (
+			device_token VARCHAR(512) PRIMARY KEY,
+			user_id VARCHAR(36) NOT NULL,
+			platform VARCHAR(16) NOT NULL,
+			device_type VARCHAR(16) NOT NULL,
+			categories TEXT[],
+			is_active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)`,
+
+		// 通知偏好表
+		`CREATE TABLE IF NOT EXISTS notification_preferences (
+			user_id VARCHAR(36) PRIMARY KEY,
+			push_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			email_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			message_notifications BOOLEAN NOT NULL DEFAULT TRUE,
+			group_notifications BOOLEAN NOT NULL DEFAULT TRUE,
+			system_notifications BOOLEAN NOT NULL DEFAULT TRUE,
+			preview_mode VARCHAR(16) NOT NULL DEFAULT 'full',
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)`,
+
+		// 创建索引
+		`CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications(user_id, created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications(user_id) WHERE status != 'read'`,
+		`CREATE INDEX IF NOT EXISTS idx_user_devices_user_id ON user_devices(user_id)`,
+	}
+
+	for i, migration := range migrations {
+		if _, err := db.Exec(migration); err != nil {
+			return fmt.Errorf("failed to run migration %d: %w", i+1, err)
+		}
+	}
+
+	log.Info("Database migrations completed successfully")
+	return nil
+}
+
+// initJobQueue 初始化后台任务队列，有数据库时使用持久化队列
+func initJobQueue(db *sqlx.DB, log *zap.Logger) jobs.Queue {
+	if db != nil {
+		queue, err := jobs.NewPostgresQueue(db.DB, "notification-service")
+		if err == nil {
+			return queue
+		}
+		log.Warn("Failed to initialize persistent job queue, using memory queue", zap.Error(err))
+	}
+	return jobs.NewMemoryQueue()
 }
 
 // CORS中间件 - 已移除，由API网关统一处理CORS
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -10,9 +11,41 @@ import (
 type Config struct {
 	HTTPPort     int
 	LogLevel     string
+	// 通知、设备和偏好的存储方式：memory（默认，重启后丢失）或 postgres
+	StorageBackend string
+	Database     DatabaseConfig
 	Redis        RedisConfig
 	WebSocket    WebSocketConfig
 	PushNotification PushConfig
+	UserServiceURL    string
+	MessageServiceURL string
+	GroupServiceURL   string
+	// NATS地址，为空时不订阅其他服务的领域事件
+	EventBusURL       string
+	Locale            LocaleConfig
+	InboundEmail      InboundEmailConfig
+	Shutdown          ShutdownConfig
+	ErrorReporting    ErrorReportingConfig
+	Experiment        ExperimentConfig
+	RateLimit         RateLimitConfig
+	Mute              MuteConfig
+	Metrics           MetricsConfig
+	Tracing           TracingConfig
+	OpenAPI           OpenAPIConfig
+	LeaderElection    LeaderElectionConfig
+}
+
+// DatabaseConfig 数据库配置，StorageBackend为postgres时使用
+type DatabaseConfig struct {
+	Host            string
+	Port            int
+	User            string
+	Password        string
+	DBName          string
+	SSLMode         string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
 }
 
 type RedisConfig struct {
@@ -28,11 +61,92 @@ type WebSocketConfig struct {
 	MaxConnections  int
 }
 
+type LocaleConfig struct {
+	DefaultLocale string
+	CacheTTL      time.Duration
+}
+
+type InboundEmailConfig struct {
+	ReplyDomain       string // 回复地址域名，为空时不生成回复地址
+	TokenTTL          time.Duration
+	MailgunSigningKey string
+	SESWebhookSecret  string
+}
+
+// ShutdownConfig 优雅关闭配置
+type ShutdownConfig struct {
+	DrainDelay time.Duration // 进入排空状态（健康检查返回503）后等待多久再停止接收连接
+	Timeout    time.Duration // 关闭步骤的总超时时间
+}
+
+// ExperimentConfig 通知文案实验配置
+type ExperimentConfig struct {
+	AttributionWindow time.Duration // 通知发出后多久内的转化计入实验
+	AdminToken        string        // 实验管理接口要求携带 Authorization: Bearer <AdminToken>，为空时拒绝所有管理请求
+}
+
+// RateLimitConfig 每个用户的通知推送上限，0表示不限制
+// 超限时普通消息只保存不推送，@提及等延后到窗口重置后推送，系统通知不受限制
+type RateLimitConfig struct {
+	HourlyCap int
+	DailyCap  int
+}
+
+// MuteConfig 免打扰设置缓存，会话或群组的免打扰变更最多延迟CacheTTL生效
+type MuteConfig struct {
+	CacheTTL time.Duration
+}
+
+// ErrorReportingConfig 错误上报配置，SentryDSN为空时panic只记录日志
+type ErrorReportingConfig struct {
+	SentryDSN   string
+	Environment string
+}
+
+// LeaderElectionConfig 定时任务主实例选举配置，启用后多个实例中只有持有Redis锁的实例触发定时任务
+type LeaderElectionConfig struct {
+	Enabled bool
+	TTL     time.Duration // 锁的有效期，主实例失联后最长经过这段时间由其他实例接替
+}
+
+// MetricsConfig Prometheus指标配置
+type MetricsConfig struct {
+	Enabled bool   // 是否提供/metrics端点
+	Token   string // 不为空时抓取请求需携带 Authorization: Bearer <Token>
+}
+
+// TracingConfig OpenTelemetry链路追踪配置
+type TracingConfig struct {
+	Exporter      string // none（只生成和传播traceparent）、otlp或jaeger
+	Endpoint      string // OTLP collector的gRPC地址或Jaeger collector的HTTP地址，为空时使用导出器默认值
+	Insecure      bool   // OTLP连接不使用TLS
+	SamplePercent int    // 新trace的采样百分比，带traceparent的请求沿用上游的采样决定
+}
+
+// OpenAPIConfig 接口文档配置
+type OpenAPIConfig struct {
+	Strict bool // 注册的路由与接口说明不一致时拒绝启动，默认只记录警告
+}
+
+// PushConfig 推送服务商配置，未配置凭据的平台不推送
 type PushConfig struct {
-	FCMServerKey string
-	APNSKeyFile  string
-	APNSKeyID    string
-	APNSTeamID   string
+	// FCM HTTP v1，使用Google服务账号凭据文件，项目ID为空时取凭据中的project_id
+	FCMCredentialsFile string
+	FCMProjectID       string
+	FCMMaxAttempts     int
+	FCMRetryDelay      time.Duration
+
+	// APNs基于令牌的认证，APNSTopic为应用的Bundle ID
+	APNSKeyFile     string
+	APNSKeyID       string
+	APNSTeamID      string
+	APNSTopic       string
+	APNSProduction  bool
+	APNSMaxAttempts int
+	APNSRetryDelay  time.Duration
+
+	RetryMaxDelay time.Duration // 重试等待的上限，服务商要求的Retry-After同样受此限制
+	BatchSize     int           // 每批并发发送的设备令牌数
 }
 
 func LoadConfig() (*Config, error) {
@@ -40,15 +154,47 @@ func LoadConfig() (*Config, error) {
 	godotenv.Load()
 
 	httpPort, _ := strconv.Atoi(getEnv("HTTP_PORT", "8085"))
+	dbPort, _ := strconv.Atoi(getEnv("DB_PORT", "5432"))
+	dbMaxOpenConns, _ := strconv.Atoi(getEnv("DB_MAX_OPEN_CONNS", "25"))
+	dbMaxIdleConns, _ := strconv.Atoi(getEnv("DB_MAX_IDLE_CONNS", "25"))
+	dbConnMaxLifetime, _ := strconv.Atoi(getEnv("DB_CONN_MAX_LIFETIME", "5"))
 	redisPort, _ := strconv.Atoi(getEnv("REDIS_PORT", "6379"))
 	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
 	readBufferSize, _ := strconv.Atoi(getEnv("WS_READ_BUFFER_SIZE", "1024"))
 	writeBufferSize, _ := strconv.Atoi(getEnv("WS_WRITE_BUFFER_SIZE", "1024"))
 	maxConnections, _ := strconv.Atoi(getEnv("WS_MAX_CONNECTIONS", "1000"))
+	localeCacheMinutes, _ := strconv.Atoi(getEnv("LOCALE_CACHE_TTL_MINUTES", "30"))
+	replyTokenHours, _ := strconv.Atoi(getEnv("REPLY_TOKEN_TTL_HOURS", "168"))
+	shutdownDrainDelay, _ := strconv.Atoi(getEnv("SHUTDOWN_DRAIN_DELAY_SECONDS", "5"))
+	shutdownTimeout, _ := strconv.Atoi(getEnv("SHUTDOWN_TIMEOUT_SECONDS", "30"))
+	attributionHours, _ := strconv.Atoi(getEnv("EXPERIMENT_ATTRIBUTION_WINDOW_HOURS", "72"))
+	hourlyCap, _ := strconv.Atoi(getEnv("NOTIFICATION_HOURLY_CAP", "30"))
+	dailyCap, _ := strconv.Atoi(getEnv("NOTIFICATION_DAILY_CAP", "200"))
+	muteCacheSeconds, _ := strconv.Atoi(getEnv("MUTE_CACHE_TTL_SECONDS", "30"))
+	fcmMaxAttempts, _ := strconv.Atoi(getEnv("FCM_MAX_ATTEMPTS", "3"))
+	fcmRetryDelayMs, _ := strconv.Atoi(getEnv("FCM_RETRY_DELAY_MS", "1000"))
+	apnsMaxAttempts, _ := strconv.Atoi(getEnv("APNS_MAX_ATTEMPTS", "3"))
+	apnsRetryDelayMs, _ := strconv.Atoi(getEnv("APNS_RETRY_DELAY_MS", "500"))
+	pushRetryMaxDelay, _ := strconv.Atoi(getEnv("PUSH_RETRY_MAX_DELAY_SECONDS", "30"))
+	pushBatchSize, _ := strconv.Atoi(getEnv("PUSH_BATCH_SIZE", "100"))
+	leaderElectionTTL, _ := strconv.Atoi(getEnv("LEADER_ELECTION_TTL_SECONDS", "15"))
+	tracingSamplePercent, _ := strconv.Atoi(getEnv("TRACING_SAMPLE_PERCENT", "100"))
 
 	return &Config{
 		HTTPPort: httpPort,
 		LogLevel: getEnv("LOG_LEVEL", "info"),
+		StorageBackend: getEnv("STORAGE_BACKEND", "memory"),
+		Database: DatabaseConfig{
+			Host:            getEnv("DB_HOST", "localhost"),
+			Port:            dbPort,
+			User:            getEnv("DB_USER", "postgres"),
+			Password:        getEnv("DB_PASSWORD", "postgres"),
+			DBName:          getEnv("DB_NAME", "chatapp"),
+			SSLMode:         getEnv("DB_SSLMODE", "disable"),
+			MaxOpenConns:    dbMaxOpenConns,
+			MaxIdleConns:    dbMaxIdleConns,
+			ConnMaxLifetime: time.Duration(dbConnMaxLifetime) * time.Minute,
+		},
 		Redis: RedisConfig{
 			Host:     getEnv("REDIS_HOST", "localhost"),
 			Port:     redisPort,
@@ -61,10 +207,69 @@ func LoadConfig() (*Config, error) {
 			MaxConnections:  maxConnections,
 		},
 		PushNotification: PushConfig{
-			FCMServerKey: getEnv("FCM_SERVER_KEY", ""),
-			APNSKeyFile:  getEnv("APNS_KEY_FILE", ""),
-			APNSKeyID:    getEnv("APNS_KEY_ID", ""),
-			APNSTeamID:   getEnv("APNS_TEAM_ID", ""),
+			FCMCredentialsFile: getEnv("FCM_CREDENTIALS_FILE", ""),
+			FCMProjectID:       getEnv("FCM_PROJECT_ID", ""),
+			FCMMaxAttempts:     fcmMaxAttempts,
+			FCMRetryDelay:      time.Duration(fcmRetryDelayMs) * time.Millisecond,
+			APNSKeyFile:        getEnv("APNS_KEY_FILE", ""),
+			APNSKeyID:          getEnv("APNS_KEY_ID", ""),
+			APNSTeamID:         getEnv("APNS_TEAM_ID", ""),
+			APNSTopic:          getEnv("APNS_TOPIC", ""),
+			APNSProduction:     getEnv("APNS_PRODUCTION", "false") == "true",
+			APNSMaxAttempts:    apnsMaxAttempts,
+			APNSRetryDelay:     time.Duration(apnsRetryDelayMs) * time.Millisecond,
+			RetryMaxDelay:      time.Duration(pushRetryMaxDelay) * time.Second,
+			BatchSize:          pushBatchSize,
+		},
+		UserServiceURL:    getEnv("USER_SERVICE_URL", "http://localhost:8081"),
+		MessageServiceURL: getEnv("MESSAGE_SERVICE_URL", "http://localhost:8082"),
+		GroupServiceURL:   getEnv("GROUP_SERVICE_URL", "http://localhost:8083"),
+		EventBusURL:       getEnv("EVENT_BUS_URL", ""),
+		Locale: LocaleConfig{
+			DefaultLocale: getEnv("DEFAULT_LOCALE", "zh-CN"),
+			CacheTTL:      time.Duration(localeCacheMinutes) * time.Minute,
+		},
+		InboundEmail: InboundEmailConfig{
+			ReplyDomain:       getEnv("INBOUND_EMAIL_DOMAIN", ""),
+			TokenTTL:          time.Duration(replyTokenHours) * time.Hour,
+			MailgunSigningKey: getEnv("MAILGUN_WEBHOOK_SIGNING_KEY", ""),
+			SESWebhookSecret:  getEnv("SES_WEBHOOK_SECRET", ""),
+		},
+		Shutdown: ShutdownConfig{
+			DrainDelay: time.Duration(shutdownDrainDelay) * time.Second,
+			Timeout:    time.Duration(shutdownTimeout) * time.Second,
+		},
+		ErrorReporting: ErrorReportingConfig{
+			SentryDSN:   getEnv("SENTRY_DSN", ""),
+			Environment: getEnv("SENTRY_ENVIRONMENT", "production"),
+		},
+		Experiment: ExperimentConfig{
+			AttributionWindow: time.Duration(attributionHours) * time.Hour,
+			AdminToken:        getEnv("EXPERIMENT_ADMIN_TOKEN", ""),
+		},
+		RateLimit: RateLimitConfig{
+			HourlyCap: hourlyCap,
+			DailyCap:  dailyCap,
+		},
+		Mute: MuteConfig{
+			CacheTTL: time.Duration(muteCacheSeconds) * time.Second,
+		},
+		LeaderElection: LeaderElectionConfig{
+			Enabled: getEnv("LEADER_ELECTION_ENABLED", "false") == "true",
+			TTL:     time.Duration(leaderElectionTTL) * time.Second,
+		},
+		Metrics: MetricsConfig{
+			Enabled: getEnv("METRICS_ENABLED", "true") == "true",
+			Token:   getEnv("METRICS_TOKEN", ""),
+		},
+		Tracing: TracingConfig{
+			Exporter:      getEnv("TRACING_EXPORTER", "none"),
+			Endpoint:      getEnv("TRACING_ENDPOINT", ""),
+			Insecure:      getEnv("TRACING_INSECURE", "true") == "true",
+			SamplePercent: tracingSamplePercent,
+		},
+		OpenAPI: OpenAPIConfig{
+			Strict: getEnv("OPENAPI_STRICT", "false") == "true",
 		},
 	}, nil
 }
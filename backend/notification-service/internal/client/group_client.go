@@ -0,0 +1,48 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/neohope/chatapp/notification-service/pkg/tracing"
+)
+
+// GroupClient 群组服务客户端
+type GroupClient interface {
+	GetGroupMutes(conversationID string) ([]Mute, error)
+}
+
+type httpGroupClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewGroupClient(baseURL string) GroupClient {
+	return &httpGroupClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second, Transport: tracing.Transport(nil)},
+	}
+}
+
+// GetGroupMutes 通过群组服务内部接口获取会话所属群组中仍然有效的免打扰设置，频道会话由群组服务映射到所在群组
+func (c *httpGroupClient) GetGroupMutes(conversationID string) ([]Mute, error) {
+	resp, err := c.httpClient.Get(c.baseURL + "/internal/conversations/" + url.PathEscape(conversationID) + "/mutes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to call group service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("group service returned status %d", resp.StatusCode)
+	}
+
+	var mutes []Mute
+	if err := json.NewDecoder(resp.Body).Decode(&mutes); err != nil {
+		return nil, fmt.Errorf("failed to decode group mutes: %w", err)
+	}
+	return mutes, nil
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/neohope/chatapp/notification-service/pkg/tracing"
+)
+
+// MessageClient 消息服务客户端
+type MessageClient interface {
+	SendTextMessage(userID, conversationID, content string, metadata map[string]interface{}) error
+	GetConversationMutes(conversationID string) ([]Mute, error)
+}
+
+// Mute 会话或群组中开启了免打扰的用户，MutedUntil为nil表示永久免打扰
+type Mute struct {
+	UserID     string     `json:"user_id"`
+	MutedUntil *time.Time `json:"muted_until,omitempty"`
+}
+
+type httpMessageClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewMessageClient(baseURL string) MessageClient {
+	return &httpMessageClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)},
+	}
+}
+
+// SendTextMessage 以用户身份向会话发送文本消息
+func (c *httpMessageClient) SendTextMessage(userID, conversationID, content string, metadata map[string]interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"conversation_id": conversationID,
+		"type":            "text",
+		"content":         content,
+		"metadata":        metadata,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/api/v1/messages", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", userID)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call message service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("message service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// GetConversationMutes 通过消息服务内部接口获取会话中仍然有效的免打扰设置
+func (c *httpMessageClient) GetConversationMutes(conversationID string) ([]Mute, error) {
+	resp, err := c.httpClient.Get(c.baseURL + "/internal/conversations/" + url.PathEscape(conversationID) + "/mutes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to call message service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("message service returned status %d", resp.StatusCode)
+	}
+
+	var mutes []Mute
+	if err := json.NewDecoder(resp.Body).Decode(&mutes); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation mutes: %w", err)
+	}
+	return mutes, nil
+}
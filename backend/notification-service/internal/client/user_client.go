@@ -0,0 +1,94 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/neohope/chatapp/notification-service/pkg/tracing"
+)
+
+// UserClient 用户服务客户端
+type UserClient interface {
+	GetLanguage(userID string) (string, error)
+	GetStatus(userID string) (string, error)
+	GetNotificationEmail(userID string) (string, error)
+}
+
+type httpUserClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewUserClient(baseURL string) UserClient {
+	return &httpUserClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second, Transport: tracing.Transport(nil)},
+	}
+}
+
+// GetLanguage 通过用户服务内部接口获取用户的语言设置
+func (c *httpUserClient) GetLanguage(userID string) (string, error) {
+	resp, err := c.httpClient.Get(c.baseURL + "/internal/users/" + url.PathEscape(userID) + "/locale")
+	if err != nil {
+		return "", fmt.Errorf("failed to call user service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("user service returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Language string `json:"language"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode user locale: %w", err)
+	}
+	return result.Language, nil
+}
+
+// GetStatus 通过用户服务内部接口获取用户账户状态
+func (c *httpUserClient) GetStatus(userID string) (string, error) {
+	resp, err := c.httpClient.Get(c.baseURL + "/internal/users/" + url.PathEscape(userID) + "/status")
+	if err != nil {
+		return "", fmt.Errorf("failed to call user service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("user service returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode user status: %w", err)
+	}
+	return result.Status, nil
+}
+
+// GetNotificationEmail 通过用户服务内部接口获取发送通知邮件的地址，已验证的通知邮箱优先于登录邮箱
+func (c *httpUserClient) GetNotificationEmail(userID string) (string, error) {
+	resp, err := c.httpClient.Get(c.baseURL + "/internal/users/" + url.PathEscape(userID) + "/notification-email")
+	if err != nil {
+		return "", fmt.Errorf("failed to call user service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("user service returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode notification email: %w", err)
+	}
+	return result.Email, nil
+}
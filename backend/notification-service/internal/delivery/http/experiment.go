@@ -0,0 +1,174 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+	"github.com/neohope/chatapp/notification-service/internal/service"
+)
+
+// ExperimentHandler 通知文案A/B实验的管理和转化上报
+type ExperimentHandler struct {
+	experimentService domain.ExperimentService
+	adminToken        string
+	logger            *zap.Logger
+}
+
+type CreateExperimentRequest struct {
+	Name     string                     `json:"name"`
+	Template string                     `json:"template"`
+	Variants []domain.ExperimentVariant `json:"variants"` // 不设置copy的变体使用模板原文案，作为对照组
+}
+
+func NewExperimentHandler(experimentService domain.ExperimentService, adminToken string, logger *zap.Logger) *ExperimentHandler {
+	return &ExperimentHandler{
+		experimentService: experimentService,
+		adminToken:        adminToken,
+		logger:            logger,
+	}
+}
+
+func (h *ExperimentHandler) RegisterRoutes(router *mux.Router) {
+	// 实验管理只供内部调用，不经过API网关暴露，还要求携带管理令牌
+	router.HandleFunc("/internal/experiments", h.requireAdminToken(h.CreateExperiment)).Methods("POST")
+	router.HandleFunc("/internal/experiments", h.requireAdminToken(h.ListExperiments)).Methods("GET")
+	router.HandleFunc("/internal/experiments/{id}", h.requireAdminToken(h.GetExperiment)).Methods("GET")
+	router.HandleFunc("/internal/experiments/{id}/stop", h.requireAdminToken(h.StopExperiment)).Methods("POST")
+	router.HandleFunc("/internal/experiments/{id}/results", h.requireAdminToken(h.GetResults)).Methods("GET")
+
+	// 客户端在用户完成通知引导的操作后上报转化
+	router.HandleFunc("/notifications/{id}/conversion", h.RecordConversion).Methods("POST")
+}
+
+// requireAdminToken 校验 Authorization: Bearer <EXPERIMENT_ADMIN_TOKEN>，未配置令牌时拒绝所有请求
+func (h *ExperimentHandler) requireAdminToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		expected := []byte("Bearer " + h.adminToken)
+		if h.adminToken == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			h.respondError(w, http.StatusUnauthorized, "Invalid admin token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (h *ExperimentHandler) CreateExperiment(w http.ResponseWriter, r *http.Request) {
+	var req CreateExperimentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Name == "" || req.Template == "" {
+		h.respondError(w, http.StatusBadRequest, "Missing required fields")
+		return
+	}
+
+	experiment := &domain.Experiment{
+		Name:     req.Name,
+		Template: req.Template,
+		Variants: req.Variants,
+	}
+	if err := h.experimentService.CreateExperiment(experiment); err != nil {
+		if errors.Is(err, service.ErrInvalidExperiment) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.Error("Failed to create experiment", zap.Error(err))
+		h.respondError(w, http.StatusInternalServerError, "Failed to create experiment")
+		return
+	}
+
+	h.respondSuccess(w, experiment, "Experiment started")
+}
+
+func (h *ExperimentHandler) ListExperiments(w http.ResponseWriter, r *http.Request) {
+	experiments, err := h.experimentService.ListExperiments()
+	if err != nil {
+		h.logger.Error("Failed to list experiments", zap.Error(err))
+		h.respondError(w, http.StatusInternalServerError, "Failed to list experiments")
+		return
+	}
+
+	h.respondSuccess(w, experiments, "")
+}
+
+func (h *ExperimentHandler) GetExperiment(w http.ResponseWriter, r *http.Request) {
+	experiment, err := h.experimentService.GetExperiment(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, "Experiment not found")
+		return
+	}
+
+	h.respondSuccess(w, experiment, "")
+}
+
+func (h *ExperimentHandler) StopExperiment(w http.ResponseWriter, r *http.Request) {
+	experiment, err := h.experimentService.StopExperiment(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, service.ErrExperimentNotFound) {
+			h.respondError(w, http.StatusNotFound, "Experiment not found")
+			return
+		}
+		h.logger.Error("Failed to stop experiment", zap.Error(err))
+		h.respondError(w, http.StatusInternalServerError, "Failed to stop experiment")
+		return
+	}
+
+	h.respondSuccess(w, experiment, "Experiment stopped")
+}
+
+func (h *ExperimentHandler) GetResults(w http.ResponseWriter, r *http.Request) {
+	results, err := h.experimentService.GetResults(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, service.ErrExperimentNotFound) {
+			h.respondError(w, http.StatusNotFound, "Experiment not found")
+			return
+		}
+		h.logger.Error("Failed to get experiment results", zap.Error(err))
+		h.respondError(w, http.StatusInternalServerError, "Failed to get experiment results")
+		return
+	}
+
+	h.respondSuccess(w, results, "")
+}
+
+func (h *ExperimentHandler) RecordConversion(w http.ResponseWriter, r *http.Request) {
+	// 从请求头中获取用户ID（由API Gateway注入）
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.respondError(w, http.StatusUnauthorized, "User ID required")
+		return
+	}
+
+	attributed, err := h.experimentService.RecordConversion(userID, mux.Vars(r)["id"])
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, "Notification not found")
+		return
+	}
+
+	h.respondSuccess(w, map[string]bool{"attributed": attributed}, "")
+}
+
+func (h *ExperimentHandler) respondSuccess(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Message: message,
+		Data:    data,
+	})
+}
+
+func (h *ExperimentHandler) respondError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(Response{
+		Success: false,
+		Error:   message,
+	})
+}
@@ -3,12 +3,12 @@ package http
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
 
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 
 	"github.com/neohope/chatapp/notification-service/internal/domain"
+	"github.com/neohope/chatapp/notification-service/pkg/pagination"
 )
 
 type Handler struct {
@@ -17,11 +17,13 @@ type Handler struct {
 }
 
 type CreateNotificationRequest struct {
-	UserID string                 `json:"user_id"`
-	Type   string                 `json:"type"`
-	Title  string                 `json:"title"`
-	Body   string                 `json:"body"`
-	Data   map[string]interface{} `json:"data,omitempty"`
+	UserID   string                 `json:"user_id"`
+	Type     string                 `json:"type"`
+	Title    string                 `json:"title"`
+	Body     string                 `json:"body"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+	Template string                 `json:"template,omitempty"` // 设置后按接收者语言渲染，忽略title/body
+	Params   map[string]interface{} `json:"params,omitempty"`
 }
 
 type SendPushRequest struct {
@@ -32,20 +34,32 @@ type SendPushRequest struct {
 	Data        map[string]interface{} `json:"data,omitempty"`
 	Badge       int                    `json:"badge,omitempty"`
 	Sound       string                 `json:"sound,omitempty"`
+	ThreadID    string                 `json:"thread_id,omitempty"`
+	CollapseKey string                 `json:"collapse_key,omitempty"`
+	Category    string                 `json:"category,omitempty"`
+	ChannelID   string                 `json:"channel_id,omitempty"`
 }
 
 type RegisterDeviceRequest struct {
-	UserID      string `json:"user_id"`
-	DeviceToken string `json:"device_token"`
-	Platform    string `json:"platform"`
+	UserID      string   `json:"user_id"`
+	DeviceToken string   `json:"device_token"`
+	Platform    string   `json:"platform"`
+	DeviceType  string   `json:"device_type,omitempty"` // phone, tablet, desktop，默认phone
+	Categories  []string `json:"categories,omitempty"`  // 为空时接收全部类别
+}
+
+type UpdateDeviceRoutingRequest struct {
+	DeviceToken string   `json:"device_token"`
+	Categories  []string `json:"categories"` // messages, mentions, groups, social, system
 }
 
 type UpdatePreferencesRequest struct {
-	PushEnabled          bool `json:"push_enabled"`
-	EmailEnabled         bool `json:"email_enabled"`
-	MessageNotifications bool `json:"message_notifications"`
-	GroupNotifications   bool `json:"group_notifications"`
-	SystemNotifications  bool `json:"system_notifications"`
+	PushEnabled          bool   `json:"push_enabled"`
+	EmailEnabled         bool   `json:"email_enabled"`
+	MessageNotifications bool   `json:"message_notifications"`
+	GroupNotifications   bool   `json:"group_notifications"`
+	SystemNotifications  bool   `json:"system_notifications"`
+	PreviewMode          string `json:"preview_mode,omitempty"` // full, sender_only, generic
 }
 
 type Response struct {
@@ -78,6 +92,8 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 	// 设备管理路由
 	router.HandleFunc("/devices", h.RegisterDevice).Methods("POST")
 	router.HandleFunc("/devices", h.UnregisterDevice).Methods("DELETE")
+	router.HandleFunc("/devices", h.GetDevices).Methods("GET")
+	router.HandleFunc("/devices/routing", h.UpdateDeviceRouting).Methods("PUT")
 
 	// 偏好设置路由
 	router.HandleFunc("/preferences", h.GetPreferences).Methods("GET")
@@ -100,17 +116,19 @@ func (h *Handler) CreateNotification(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 验证必填字段
-	if req.UserID == "" || req.Title == "" || req.Body == "" {
+	if req.UserID == "" || (req.Template == "" && (req.Title == "" || req.Body == "")) {
 		h.respondError(w, http.StatusBadRequest, "Missing required fields")
 		return
 	}
 
 	notification := &domain.Notification{
-		UserID: req.UserID,
-		Type:   domain.NotificationType(req.Type),
-		Title:  req.Title,
-		Body:   req.Body,
-		Data:   req.Data,
+		UserID:   req.UserID,
+		Type:     domain.NotificationType(req.Type),
+		Title:    req.Title,
+		Body:     req.Body,
+		Data:     req.Data,
+		Template: req.Template,
+		Params:   req.Params,
 	}
 
 	if err := h.notificationService.SendNotification(notification); err != nil {
@@ -130,23 +148,20 @@ func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 解析分页参数
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-
-	if limit <= 0 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
+	page, err := pagination.Parse(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	notifications, err := h.notificationService.GetNotifications(userID, limit, offset)
+	notifications, err := h.notificationService.GetNotifications(userID, page.Limit, page.Offset)
 	if err != nil {
 		h.logger.Error("Failed to get notifications", zap.Error(err))
 		h.respondError(w, http.StatusInternalServerError, "Failed to get notifications")
 		return
 	}
 
+	pagination.SetLinkHeader(w, r, page, page.HasMore(len(notifications)))
 	h.respondSuccess(w, notifications, "")
 }
 
@@ -199,6 +214,10 @@ func (h *Handler) SendPushNotification(w http.ResponseWriter, r *http.Request) {
 		Data:        req.Data,
 		Badge:       req.Badge,
 		Sound:       req.Sound,
+		ThreadID:    req.ThreadID,
+		CollapseKey: req.CollapseKey,
+		Category:    req.Category,
+		ChannelID:   req.ChannelID,
 	}
 
 	if req.Sound == "" {
@@ -226,7 +245,18 @@ func (h *Handler) RegisterDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.notificationService.RegisterDevice(req.UserID, req.DeviceToken, req.Platform); err != nil {
+	if req.DeviceType != "" && !domain.IsValidDeviceType(req.DeviceType) {
+		h.respondError(w, http.StatusBadRequest, "Invalid device_type")
+		return
+	}
+	for _, category := range req.Categories {
+		if !domain.IsValidRouteCategory(category) {
+			h.respondError(w, http.StatusBadRequest, "Invalid category: "+category)
+			return
+		}
+	}
+
+	if err := h.notificationService.RegisterDevice(req.UserID, req.DeviceToken, req.Platform, req.DeviceType, req.Categories); err != nil {
 		h.logger.Error("Failed to register device", zap.Error(err))
 		h.respondError(w, http.StatusInternalServerError, "Failed to register device")
 		return
@@ -253,6 +283,61 @@ func (h *Handler) UnregisterDevice(w http.ResponseWriter, r *http.Request) {
 	h.respondSuccess(w, nil, "Device unregistered successfully")
 }
 
+func (h *Handler) GetDevices(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserID(r)
+	if userID == "" {
+		h.respondError(w, http.StatusUnauthorized, "User ID required")
+		return
+	}
+
+	devices, err := h.notificationService.GetDevices(userID)
+	if err != nil {
+		h.logger.Error("Failed to get devices", zap.Error(err))
+		h.respondError(w, http.StatusInternalServerError, "Failed to get devices")
+		return
+	}
+
+	h.respondSuccess(w, devices, "")
+}
+
+func (h *Handler) UpdateDeviceRouting(w http.ResponseWriter, r *http.Request) {
+	userID := h.getUserID(r)
+	if userID == "" {
+		h.respondError(w, http.StatusUnauthorized, "User ID required")
+		return
+	}
+
+	var req UpdateDeviceRoutingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.DeviceToken == "" {
+		h.respondError(w, http.StatusBadRequest, "Missing device_token")
+		return
+	}
+	for _, category := range req.Categories {
+		if !domain.IsValidRouteCategory(category) {
+			h.respondError(w, http.StatusBadRequest, "Invalid category: "+category)
+			return
+		}
+	}
+
+	device, err := h.notificationService.UpdateDeviceRouting(userID, req.DeviceToken, req.Categories)
+	if err != nil {
+		if err.Error() == "device not found" {
+			h.respondError(w, http.StatusNotFound, "Device not found")
+			return
+		}
+		h.logger.Error("Failed to update device routing", zap.Error(err))
+		h.respondError(w, http.StatusInternalServerError, "Failed to update device routing")
+		return
+	}
+
+	h.respondSuccess(w, device, "Device routing updated successfully")
+}
+
 func (h *Handler) GetPreferences(w http.ResponseWriter, r *http.Request) {
 	userID := h.getUserID(r)
 	if userID == "" {
@@ -290,6 +375,12 @@ func (h *Handler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
 		MessageNotifications: req.MessageNotifications,
 		GroupNotifications:   req.GroupNotifications,
 		SystemNotifications:  req.SystemNotifications,
+		PreviewMode:          domain.PreviewMode(req.PreviewMode),
+	}
+
+	if preferences.PreviewMode != "" && !preferences.PreviewMode.IsValid() {
+		h.respondError(w, http.StatusBadRequest, "Invalid preview_mode")
+		return
 	}
 
 	if err := h.notificationService.UpdatePreferences(userID, preferences); err != nil {
@@ -0,0 +1,220 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/notification-service/config"
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+	"github.com/neohope/chatapp/notification-service/internal/email"
+)
+
+// mailgunSignatureMaxAge Mailgun签名的最大有效时间，防止重放
+const mailgunSignatureMaxAge = 5 * time.Minute
+
+// InboundEmailHandler 处理邮件服务商推送的入站邮件
+type InboundEmailHandler struct {
+	inboundEmailService domain.InboundEmailService
+	config              *config.InboundEmailConfig
+	httpClient          *http.Client
+	logger              *zap.Logger
+}
+
+type snsEnvelope struct {
+	Type         string `json:"Type"`
+	Message      string `json:"Message"`
+	SubscribeURL string `json:"SubscribeURL"`
+}
+
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	Receipt          struct {
+		Action struct {
+			Encoding string `json:"encoding"`
+		} `json:"action"`
+	} `json:"receipt"`
+	Content string `json:"content"`
+}
+
+func NewInboundEmailHandler(inboundEmailService domain.InboundEmailService, cfg *config.InboundEmailConfig, logger *zap.Logger) *InboundEmailHandler {
+	return &InboundEmailHandler{
+		inboundEmailService: inboundEmailService,
+		config:              cfg,
+		httpClient:          &http.Client{Timeout: 10 * time.Second},
+		logger:              logger,
+	}
+}
+
+func (h *InboundEmailHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/inbound/email/mailgun", h.HandleMailgun).Methods("POST")
+	router.HandleFunc("/inbound/email/ses", h.HandleSES).Methods("POST")
+}
+
+// HandleMailgun 处理Mailgun的入站路由回调
+func (h *InboundEmailHandler) HandleMailgun(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(10 << 20); err != nil && err != http.ErrNotMultipart {
+		h.respondError(w, http.StatusBadRequest, "Invalid form")
+		return
+	}
+
+	if !h.verifyMailgunSignature(r.FormValue("timestamp"), r.FormValue("token"), r.FormValue("signature")) {
+		h.respondError(w, http.StatusUnauthorized, "Invalid signature")
+		return
+	}
+
+	// stripped-text 已由Mailgun去掉引用内容，缺失时退回完整正文
+	text := r.FormValue("stripped-text")
+	if text == "" {
+		text = r.FormValue("body-plain")
+	}
+
+	h.handleReply(w, &domain.InboundEmail{
+		Provider:   "mailgun",
+		Sender:     r.FormValue("sender"),
+		Recipients: strings.Split(r.FormValue("recipient"), ","),
+		Subject:    r.FormValue("subject"),
+		Text:       text,
+	})
+}
+
+// HandleSES 处理SES经由SNS推送的入站邮件
+func (h *InboundEmailHandler) HandleSES(w http.ResponseWriter, r *http.Request) {
+	secret := r.URL.Query().Get("secret")
+	if h.config.SESWebhookSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(h.config.SESWebhookSecret)) != 1 {
+		h.respondError(w, http.StatusUnauthorized, "Invalid secret")
+		return
+	}
+
+	var envelope snsEnvelope
+	if err := json.NewDecoder(r.Body).Decode(&envelope); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	switch envelope.Type {
+	case "SubscriptionConfirmation":
+		h.confirmSubscription(w, envelope.SubscribeURL)
+		return
+	case "Notification":
+	default:
+		h.respondSuccess(w, nil, "Ignored")
+		return
+	}
+
+	var notification sesNotification
+	if err := json.Unmarshal([]byte(envelope.Message), &notification); err != nil || notification.NotificationType != "Received" {
+		h.respondError(w, http.StatusBadRequest, "Unsupported SES notification")
+		return
+	}
+
+	raw := []byte(notification.Content)
+	if strings.EqualFold(notification.Receipt.Action.Encoding, "BASE64") {
+		decoded, err := base64.StdEncoding.DecodeString(notification.Content)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "Invalid email content")
+			return
+		}
+		raw = decoded
+	}
+
+	sender, recipients, subject, text, err := email.ParseMIME(raw)
+	if err != nil {
+		h.logger.Warn("Failed to parse inbound email", zap.Error(err))
+		h.respondError(w, http.StatusBadRequest, "Invalid email content")
+		return
+	}
+
+	h.handleReply(w, &domain.InboundEmail{
+		Provider:   "ses",
+		Sender:     sender,
+		Recipients: recipients,
+		Subject:    subject,
+		Text:       text,
+	})
+}
+
+func (h *InboundEmailHandler) handleReply(w http.ResponseWriter, inbound *domain.InboundEmail) {
+	if err := h.inboundEmailService.HandleReply(inbound); err != nil {
+		h.logger.Warn("Failed to handle email reply",
+			zap.String("provider", inbound.Provider),
+			zap.String("sender", inbound.Sender),
+			zap.Error(err),
+		)
+
+		// 令牌无效或内容为空时返回2xx，避免服务商反复重试
+		if strings.Contains(err.Error(), "failed to post reply") {
+			h.respondError(w, http.StatusBadGateway, "Failed to post reply")
+			return
+		}
+		h.respondSuccess(w, nil, "Reply discarded")
+		return
+	}
+
+	h.respondSuccess(w, nil, "Reply posted")
+}
+
+func (h *InboundEmailHandler) verifyMailgunSignature(timestamp, token, signature string) bool {
+	if h.config.MailgunSigningKey == "" || timestamp == "" || token == "" || signature == "" {
+		return false
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(ts, 0)) > mailgunSignatureMaxAge {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(h.config.MailgunSigningKey))
+	mac.Write([]byte(timestamp + token))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// confirmSubscription 确认SNS订阅，只访问AWS域名
+func (h *InboundEmailHandler) confirmSubscription(w http.ResponseWriter, subscribeURL string) {
+	parsed, err := url.Parse(subscribeURL)
+	if err != nil || parsed.Scheme != "https" || !strings.HasSuffix(parsed.Hostname(), ".amazonaws.com") {
+		h.respondError(w, http.StatusBadRequest, "Invalid subscribe URL")
+		return
+	}
+
+	resp, err := h.httpClient.Get(subscribeURL)
+	if err != nil {
+		h.logger.Error("Failed to confirm SNS subscription", zap.Error(err))
+		h.respondError(w, http.StatusBadGateway, "Failed to confirm subscription")
+		return
+	}
+	resp.Body.Close()
+
+	h.logger.Info("SNS subscription confirmed")
+	h.respondSuccess(w, nil, "Subscription confirmed")
+}
+
+func (h *InboundEmailHandler) respondSuccess(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Message: message,
+		Data:    data,
+	})
+}
+
+func (h *InboundEmailHandler) respondError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(Response{
+		Success: false,
+		Error:   message,
+	})
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+	"github.com/neohope/chatapp/notification-service/pkg/openapi"
+)
+
+// dataResponse 统一响应结构（见Response），Data为接口返回的数据，只用于生成接口文档
+type dataResponse[T any] struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+	Data    T      `json:"data"`
+}
+
+// APIOperations 通知服务HTTP接口说明，启动时与注册的路由核对后生成/openapi.json
+// 用户身份取自API网关注入的X-User-ID头
+var APIOperations = openapi.Operations{
+	"POST /notifications":                 {Summary: "发送通知", Description: "指定template时按模板渲染标题和正文", Request: CreateNotificationRequest{}, Response: dataResponse[domain.Notification]{}},
+	"GET /notifications":                  {Summary: "获取当前用户的通知列表", Query: []string{"limit", "offset"}, Response: dataResponse[[]*domain.Notification]{}},
+	"PUT /notifications/{id}/read":        {Summary: "把通知标记为已读", Response: Response{}},
+	"GET /notifications/unread-count":     {Summary: "获取当前用户的未读通知数", Response: dataResponse[map[string]int]{}},
+	"POST /notifications/{id}/conversion": {Summary: "上报用户完成了通知引导的操作", Description: "通知属于实验分组时计入该分组的转化", Response: dataResponse[map[string]bool]{}},
+	"POST /push":                          {Summary: "向用户的设备发送推送通知", Request: SendPushRequest{}, Response: Response{}},
+	"POST /devices":                       {Summary: "注册推送设备", Request: RegisterDeviceRequest{}, Response: Response{}},
+	"DELETE /devices":                     {Summary: "注销推送设备", Query: []string{"user_id", "device_token"}, Response: Response{}},
+	"GET /devices":                        {Summary: "获取当前用户的推送设备", Response: dataResponse[[]*domain.UserDevice]{}},
+	"PUT /devices/routing":                {Summary: "设置设备接收的通知类别", Request: UpdateDeviceRoutingRequest{}, Response: dataResponse[domain.UserDevice]{}},
+	"GET /preferences":                    {Summary: "获取当前用户的通知偏好", Response: dataResponse[domain.NotificationPreference]{}},
+	"PUT /preferences":                    {Summary: "更新当前用户的通知偏好", Request: UpdatePreferencesRequest{}, Response: dataResponse[domain.NotificationPreference]{}},
+	"POST /inbound/email/mailgun":         {Summary: "接收Mailgun的入站邮件回调", Description: "multipart/form-data请求，按Mailgun签名校验来源", Public: true},
+	"POST /inbound/email/ses":             {Summary: "接收SES经由SNS推送的入站邮件", Description: "secret需与配置的共享密钥一致", Query: []string{"secret"}, Request: snsEnvelope{}, Public: true},
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"time"
+)
+
+type ExperimentStatus string
+
+const (
+	ExperimentStatusRunning ExperimentStatus = "running"
+	ExperimentStatusStopped ExperimentStatus = "stopped"
+)
+
+// VariantCopy 变体在某个语言下的推送文案，支持与通知模板相同的参数
+type VariantCopy struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// ExperimentVariant 通知文案实验的一个变体
+type ExperimentVariant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+	// 各语言的文案，为空时使用模板目录中的原文案（对照组）
+	Copy map[string]VariantCopy `json:"copy,omitempty"`
+}
+
+// Experiment 通知文案A/B实验，同一模板同时只有一个进行中的实验
+// 用户按实验ID和用户ID哈希分配到变体，同一实验中的分配保持不变
+type Experiment struct {
+	ID        string              `json:"id"`
+	Name      string              `json:"name"`
+	Template  string              `json:"template"`
+	Variants  []ExperimentVariant `json:"variants"`
+	Status    ExperimentStatus    `json:"status"`
+	CreatedAt time.Time           `json:"created_at"`
+	StoppedAt *time.Time          `json:"stopped_at,omitempty"`
+}
+
+// ExperimentEvent 实验漏斗中的事件
+type ExperimentEvent string
+
+const (
+	ExperimentEventSent      ExperimentEvent = "sent"
+	ExperimentEventOpened    ExperimentEvent = "opened"
+	ExperimentEventConverted ExperimentEvent = "converted"
+)
+
+// VariantResult 单个变体的投放和转化统计
+type VariantResult struct {
+	Variant        string  `json:"variant"`
+	Weight         int     `json:"weight"`
+	Sent           int     `json:"sent"`
+	Opened         int     `json:"opened"`
+	Converted      int     `json:"converted"`
+	OpenRate       float64 `json:"open_rate"`
+	ConversionRate float64 `json:"conversion_rate"`
+}
+
+// ExperimentResults 实验结果，转化按通知归因到发送时分配的变体
+type ExperimentResults struct {
+	Experiment *Experiment      `json:"experiment"`
+	Variants   []*VariantResult `json:"variants"`
+}
+
+type ExperimentRepository interface {
+	Create(experiment *Experiment) error
+	GetByID(id string) (*Experiment, error)
+	List() ([]*Experiment, error)
+	// GetRunningByTemplate 获取模板进行中的实验，没有时返回nil
+	GetRunningByTemplate(template string) (*Experiment, error)
+	Update(experiment *Experiment) error
+	// RecordEvent 记录通知的漏斗事件，同一通知的同一事件只计一次，返回是否新记录
+	RecordEvent(experimentID, variant, notificationID string, event ExperimentEvent) (bool, error)
+	// CountEvents 统计实验各变体的事件数，variant -> event -> 数量
+	CountEvents(experimentID string) (map[string]map[ExperimentEvent]int, error)
+}
+
+type ExperimentService interface {
+	CreateExperiment(experiment *Experiment) error
+	GetExperiment(id string) (*Experiment, error)
+	ListExperiments() ([]*Experiment, error)
+	StopExperiment(id string) (*Experiment, error)
+	GetResults(id string) (*ExperimentResults, error)
+	// RecordConversion 用户完成通知引导的操作，归因到该通知的实验变体，返回是否计入实验
+	RecordConversion(userID, notificationID string) (bool, error)
+}
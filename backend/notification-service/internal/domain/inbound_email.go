@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// ReplyToken 邮件回复令牌，编码在通知邮件的Reply-To地址中
+type ReplyToken struct {
+	Token          string    `json:"token"`
+	UserID         string    `json:"user_id"`
+	ConversationID string    `json:"conversation_id"`
+	Email          string    `json:"email"` // 通知邮件的收件地址，只接受从该地址发出的回复
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// InboundEmail 解析后的入站邮件
+type InboundEmail struct {
+	Provider   string   `json:"provider"`
+	Sender     string   `json:"sender"`
+	Recipients []string `json:"recipients"`
+	Subject    string   `json:"subject"`
+	Text       string   `json:"text"` // 纯文本正文（可能包含引用的原邮件）
+}
+
+type ReplyTokenRepository interface {
+	Create(token *ReplyToken) error
+	Get(token string) (*ReplyToken, error)
+	DeleteExpired() error
+}
+
+type InboundEmailService interface {
+	// 为发送给emailAddress的会话邮件通知生成回复地址
+	ReplyAddress(userID, conversationID, emailAddress string) (string, error)
+	// 处理入站回复邮件，发件人与通知邮件的收件地址一致时将回复内容发布到原会话
+	HandleReply(email *InboundEmail) error
+}
@@ -20,6 +20,8 @@ const (
 	NotificationStatusSent    NotificationStatus = "sent"
 	NotificationStatusRead    NotificationStatus = "read"
 	NotificationStatusFailed  NotificationStatus = "failed"
+	// 超出用户通知频率上限，只保存不推送
+	NotificationStatusThrottled NotificationStatus = "throttled"
 )
 
 type Notification struct {
@@ -29,6 +31,13 @@ type Notification struct {
 	Title     string             `json:"title"`
 	Body      string             `json:"body"`
 	Data      map[string]interface{} `json:"data,omitempty"`
+	// 模板通知：按接收者语言渲染Title/Body
+	Template  string                 `json:"template,omitempty"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+	Locale    string                 `json:"locale,omitempty"`
+	// A/B实验：发送时分配的文案变体，用于投放和转化统计
+	ExperimentID string `json:"experiment_id,omitempty"`
+	Variant      string `json:"variant,omitempty"`
 	Status    NotificationStatus `json:"status"`
 	CreatedAt time.Time          `json:"created_at"`
 	SentAt    *time.Time         `json:"sent_at,omitempty"`
@@ -42,15 +51,88 @@ type PushNotification struct {
 	Data        map[string]interface{} `json:"data,omitempty"`
 	Badge       int                    `json:"badge,omitempty"`
 	Sound       string                 `json:"sound,omitempty"`
+	// 通知分组：APNs thread-id / FCM collapse_key / Android渠道
+	ThreadID    string                 `json:"thread_id,omitempty"`
+	CollapseKey string                 `json:"collapse_key,omitempty"`
+	Category    string                 `json:"category,omitempty"`
+	ChannelID   string                 `json:"channel_id,omitempty"`
+}
+
+// 设备类型，与推送平台无关（如macOS设备同样通过APNs推送）
+const (
+	DeviceTypePhone   = "phone"
+	DeviceTypeTablet  = "tablet"
+	DeviceTypeDesktop = "desktop"
+)
+
+// 设备路由类别，用户可按设备选择接收哪些类别的通知
+const (
+	RouteCategoryMessages = "messages" // 全部消息，包含@提及
+	RouteCategoryMentions = "mentions" // 仅@提及自己的消息
+	RouteCategoryGroups   = "groups"
+	RouteCategorySocial   = "social"
+	RouteCategorySystem   = "system"
+)
+
+// IsValidDeviceType 判断是否为支持的设备类型
+func IsValidDeviceType(deviceType string) bool {
+	switch deviceType {
+	case DeviceTypePhone, DeviceTypeTablet, DeviceTypeDesktop:
+		return true
+	}
+	return false
+}
+
+// IsValidRouteCategory 判断是否为支持的路由类别
+func IsValidRouteCategory(category string) bool {
+	switch category {
+	case RouteCategoryMessages, RouteCategoryMentions, RouteCategoryGroups, RouteCategorySocial, RouteCategorySystem:
+		return true
+	}
+	return false
 }
 
 type UserDevice struct {
-	UserID      string    `json:"user_id"`
-	DeviceToken string    `json:"device_token"`
-	Platform    string    `json:"platform"` // ios, android
-	IsActive    bool      `json:"is_active"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	UserID      string `json:"user_id"`
+	DeviceToken string `json:"device_token"`
+	Platform    string `json:"platform"` // ios, android
+	DeviceType  string `json:"device_type"`
+	// 该设备接收的通知类别，为空时接收全部
+	Categories []string  `json:"categories,omitempty"`
+	IsActive   bool      `json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Accepts 判断设备是否接收指定路由类别的通知，订阅全部消息的设备同样接收@提及
+func (d *UserDevice) Accepts(category string) bool {
+	if len(d.Categories) == 0 {
+		return true
+	}
+	for _, c := range d.Categories {
+		if c == category || (c == RouteCategoryMessages && category == RouteCategoryMentions) {
+			return true
+		}
+	}
+	return false
+}
+
+// PreviewMode 消息推送在锁屏上展示的内容
+type PreviewMode string
+
+const (
+	PreviewModeFull       PreviewMode = "full"        // 发送者和消息预览
+	PreviewModeSenderOnly PreviewMode = "sender_only" // 仅显示发送者
+	PreviewModeGeneric    PreviewMode = "generic"     // 仅显示“新消息”
+)
+
+// IsValid 判断是否为支持的预览模式
+func (m PreviewMode) IsValid() bool {
+	switch m {
+	case PreviewModeFull, PreviewModeSenderOnly, PreviewModeGeneric:
+		return true
+	}
+	return false
 }
 
 type NotificationPreference struct {
@@ -60,6 +142,20 @@ type NotificationPreference struct {
 	MessageNotifications bool   `json:"message_notifications"`
 	GroupNotifications  bool   `json:"group_notifications"`
 	SystemNotifications bool   `json:"system_notifications"`
+	PreviewMode         PreviewMode `json:"preview_mode"`
+}
+
+// DefaultNotificationPreference 用户未设置时的默认偏好，全部开启并显示完整预览
+func DefaultNotificationPreference(userID string) *NotificationPreference {
+	return &NotificationPreference{
+		UserID:               userID,
+		PushEnabled:          true,
+		EmailEnabled:         true,
+		MessageNotifications: true,
+		GroupNotifications:   true,
+		SystemNotifications:  true,
+		PreviewMode:          PreviewModeFull,
+	}
 }
 
 // Repository interfaces
@@ -96,8 +192,10 @@ type NotificationService interface {
 	GetNotifications(userID string, limit, offset int) ([]*Notification, error)
 	MarkAsRead(notificationID string) error
 	GetUnreadCount(userID string) (int, error)
-	RegisterDevice(userID, deviceToken, platform string) error
+	RegisterDevice(userID, deviceToken, platform, deviceType string, categories []string) error
 	UnregisterDevice(userID, deviceToken string) error
+	GetDevices(userID string) ([]*UserDevice, error)
+	UpdateDeviceRouting(userID, deviceToken string, categories []string) (*UserDevice, error)
 	UpdatePreferences(userID string, preferences *NotificationPreference) error
 	GetPreferences(userID string) (*NotificationPreference, error)
 }
@@ -0,0 +1,155 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// ReplyLocalPrefix 回复地址本地部分前缀，格式为 reply+<token>@domain
+const ReplyLocalPrefix = "reply+"
+
+// 引用原邮件的起始行，之后的内容都会被丢弃
+var quoteHeaderPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^On .+ wrote:\s*$`),
+	regexp.MustCompile(`^在.+写道[:：]\s*$`),
+	regexp.MustCompile(`(?i)^-+\s*Original Message\s*-+$`),
+	regexp.MustCompile(`^-+\s*原始邮件\s*-+$`),
+	regexp.MustCompile(`(?i)^From:\s.+`),
+	regexp.MustCompile(`^发件人[:：]\s*.+`),
+}
+
+// NormalizeAddress 取出邮件地址（去掉显示名）并转为小写，无法解析时返回空
+func NormalizeAddress(address string) string {
+	parsed, err := mail.ParseAddress(strings.TrimSpace(address))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Address)
+}
+
+// ExtractReplyToken 从收件人列表中找出回复令牌
+func ExtractReplyToken(recipients []string) (string, bool) {
+	for _, recipient := range recipients {
+		address := strings.TrimSpace(recipient)
+		if parsed, err := mail.ParseAddress(address); err == nil {
+			address = parsed.Address
+		}
+
+		local := strings.SplitN(address, "@", 2)[0]
+		if strings.HasPrefix(strings.ToLower(local), ReplyLocalPrefix) {
+			token := strings.ToLower(local[len(ReplyLocalPrefix):])
+			if token != "" {
+				return token, true
+			}
+		}
+	}
+	return "", false
+}
+
+// StripQuotedReply 去掉回复邮件中引用的原文和签名，只保留用户新写的内容
+func StripQuotedReply(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "--" || strings.HasPrefix(trimmed, ">") || matchesQuoteHeader(trimmed) {
+			break
+		}
+		kept = append(kept, line)
+	}
+
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+func matchesQuoteHeader(line string) bool {
+	for _, pattern := range quoteHeaderPatterns {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseMIME 解析原始MIME邮件，返回发件人、收件人、主题和纯文本正文
+func ParseMIME(raw []byte) (sender string, recipients []string, subject string, text string, err error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return "", nil, "", "", fmt.Errorf("failed to parse email: %w", err)
+	}
+
+	sender = msg.Header.Get("From")
+	if from, err := mail.ParseAddress(sender); err == nil {
+		sender = from.Address
+	}
+	for _, field := range []string{"To", "Cc", "Delivered-To"} {
+		if list, err := msg.Header.AddressList(field); err == nil {
+			for _, addr := range list {
+				recipients = append(recipients, addr.Address)
+			}
+		}
+	}
+
+	decoder := new(mime.WordDecoder)
+	subject, err = decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	text, err = extractPlainText(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return "", nil, "", "", err
+	}
+	return sender, recipients, subject, text, nil
+}
+
+// extractPlainText 递归查找 text/plain 正文
+func extractPlainText(contentType, transferEncoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return "", fmt.Errorf("no text/plain part found")
+			}
+			if err != nil {
+				return "", fmt.Errorf("failed to read mime part: %w", err)
+			}
+
+			text, err := extractPlainText(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err == nil {
+				return text, nil
+			}
+		}
+	}
+
+	if mediaType != "text/plain" {
+		return "", fmt.Errorf("unsupported content type: %s", mediaType)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(transferEncoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read email body: %w", err)
+	}
+	return string(content), nil
+}
@@ -0,0 +1,115 @@
+package i18n
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Template 单个语言的通知模板
+type Template struct {
+	Title string
+	Body  string
+}
+
+// Catalog 通知模板目录，key -> locale -> 模板
+type Catalog struct {
+	defaultLocale string
+	templates     map[string]TemplateSet
+}
+
+// TemplateSet 编译后的单个模板的多语言版本，locale -> 模板
+type TemplateSet map[string]*compiledTemplate
+
+type compiledTemplate struct {
+	title *template.Template
+	body  *template.Template
+}
+
+// NewCatalog 创建模板目录并编译所有模板
+func NewCatalog(defaultLocale string, templates map[string]map[string]Template) (*Catalog, error) {
+	c := &Catalog{
+		defaultLocale: defaultLocale,
+		templates:     make(map[string]TemplateSet),
+	}
+
+	for key, variants := range templates {
+		set, err := CompileTemplateSet(key, variants)
+		if err != nil {
+			return nil, err
+		}
+		c.templates[key] = set
+	}
+
+	return c, nil
+}
+
+// CompileTemplateSet 编译一个模板的多语言版本，供模板目录之外的文案（如A/B测试变体）使用
+func CompileTemplateSet(key string, variants map[string]Template) (TemplateSet, error) {
+	set := make(TemplateSet, len(variants))
+	for locale, tpl := range variants {
+		title, err := template.New(key + ".title").Option("missingkey=zero").Parse(tpl.Title)
+		if err != nil {
+			return nil, fmt.Errorf("invalid title template %s/%s: %w", key, locale, err)
+		}
+		body, err := template.New(key + ".body").Option("missingkey=zero").Parse(tpl.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body template %s/%s: %w", key, locale, err)
+		}
+		set[locale] = &compiledTemplate{title: title, body: body}
+	}
+	return set, nil
+}
+
+// Has 判断模板是否存在
+func (c *Catalog) Has(key string) bool {
+	_, ok := c.templates[key]
+	return ok
+}
+
+// Render 按语言渲染模板，返回标题、正文和实际使用的语言
+// 语言匹配顺序：完全匹配 -> 同语种（如 en-GB 匹配 en-US）-> 默认语言
+func (c *Catalog) Render(key, locale string, params map[string]interface{}) (string, string, string, error) {
+	variants, ok := c.templates[key]
+	if !ok {
+		return "", "", "", fmt.Errorf("template not found: %s", key)
+	}
+	return c.RenderSet(key, variants, locale, params)
+}
+
+// RenderSet 按语言渲染已编译的模板，语言匹配规则与Render相同
+func (c *Catalog) RenderSet(key string, variants TemplateSet, locale string, params map[string]interface{}) (string, string, string, error) {
+	resolved := c.resolve(variants, locale)
+	tpl, ok := variants[resolved]
+	if !ok {
+		return "", "", "", fmt.Errorf("template %s has no variant for locale %s", key, locale)
+	}
+
+	var title, body bytes.Buffer
+	if err := tpl.title.Execute(&title, params); err != nil {
+		return "", "", "", fmt.Errorf("failed to render title: %w", err)
+	}
+	if err := tpl.body.Execute(&body, params); err != nil {
+		return "", "", "", fmt.Errorf("failed to render body: %w", err)
+	}
+
+	return title.String(), body.String(), resolved, nil
+}
+
+func (c *Catalog) resolve(variants TemplateSet, locale string) string {
+	if _, ok := variants[locale]; ok {
+		return locale
+	}
+
+	language := strings.ToLower(strings.SplitN(locale, "-", 2)[0])
+	if language != "" {
+		for candidate := range variants {
+			if strings.ToLower(strings.SplitN(candidate, "-", 2)[0]) == language {
+				return candidate
+			}
+		}
+	}
+
+	return c.defaultLocale
+}
@@ -0,0 +1,123 @@
+package i18n
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/notification-service/internal/client"
+)
+
+// localeCacheMaxEntries 缓存的用户数量上限，超出时先清理过期项
+const localeCacheMaxEntries = 10000
+
+type cachedLocale struct {
+	locale    string
+	expiresAt time.Time
+}
+
+// LocaleResolver 查询并缓存用户的语言设置
+type LocaleResolver struct {
+	userClient    client.UserClient
+	defaultLocale string
+	ttl           time.Duration
+	logger        *zap.Logger
+
+	mu    sync.RWMutex
+	cache map[string]cachedLocale
+}
+
+func NewLocaleResolver(userClient client.UserClient, defaultLocale string, ttl time.Duration, logger *zap.Logger) *LocaleResolver {
+	return &LocaleResolver{
+		userClient:    userClient,
+		defaultLocale: defaultLocale,
+		ttl:           ttl,
+		logger:        logger,
+		cache:         make(map[string]cachedLocale),
+	}
+}
+
+// Resolve 获取用户语言，用户服务不可用时回退到默认语言（不缓存回退结果）
+func (r *LocaleResolver) Resolve(userID string) string {
+	r.mu.RLock()
+	entry, ok := r.cache[userID]
+	r.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.locale
+	}
+
+	locale, err := r.userClient.GetLanguage(userID)
+	if err != nil {
+		r.logger.Warn("Failed to get user locale, using default",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return r.defaultLocale
+	}
+	if locale == "" {
+		locale = r.defaultLocale
+	}
+
+	r.store(userID, cachedLocale{locale: locale, expiresAt: time.Now().Add(r.ttl)})
+	return locale
+}
+
+// store 写入缓存，达到上限时清理过期项，仍然超出时清空缓存
+func (r *LocaleResolver) store(userID string, entry cachedLocale) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.cache) >= localeCacheMaxEntries {
+		for k, cached := range r.cache {
+			if !now.Before(cached.expiresAt) {
+				delete(r.cache, k)
+			}
+		}
+		if len(r.cache) >= localeCacheMaxEntries {
+			r.cache = make(map[string]cachedLocale)
+		}
+	}
+	r.cache[userID] = entry
+}
+
+// Invalidate 清除用户的语言缓存
+func (r *LocaleResolver) Invalidate(userID string) {
+	r.mu.Lock()
+	delete(r.cache, userID)
+	r.mu.Unlock()
+}
+
+// Localizer 根据接收者语言渲染通知模板
+type Localizer struct {
+	catalog  *Catalog
+	resolver *LocaleResolver
+}
+
+func NewLocalizer(catalog *Catalog, resolver *LocaleResolver) *Localizer {
+	return &Localizer{
+		catalog:  catalog,
+		resolver: resolver,
+	}
+}
+
+// Render 为指定用户渲染模板，返回标题、正文和实际使用的语言
+func (l *Localizer) Render(userID, key string, params map[string]interface{}) (string, string, string, error) {
+	return l.catalog.Render(key, l.resolver.Resolve(userID), params)
+}
+
+// RenderSet 为指定用户渲染模板目录之外的已编译模板
+func (l *Localizer) RenderSet(userID, key string, set TemplateSet, params map[string]interface{}) (string, string, string, error) {
+	return l.catalog.RenderSet(key, set, l.resolver.Resolve(userID), params)
+}
+
+// DefaultLocale 无法匹配接收者语言时使用的语言
+func (l *Localizer) DefaultLocale() string {
+	return l.catalog.defaultLocale
+}
+
+// HasTemplate 判断模板目录中是否存在模板
+func (l *Localizer) HasTemplate(key string) bool {
+	return l.catalog.Has(key)
+}
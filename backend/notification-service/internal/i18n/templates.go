@@ -0,0 +1,51 @@
+package i18n
+
+// DefaultTemplates 内置通知模板
+var DefaultTemplates = map[string]map[string]Template{
+	"message.new": {
+		"zh-CN": {Title: "{{.sender_name}}", Body: "{{.preview}}"},
+		"en-US": {Title: "{{.sender_name}}", Body: "{{.preview}}"},
+	},
+	"message.group": {
+		"zh-CN": {Title: "{{.group_name}}", Body: "{{.sender_name}}: {{.preview}}"},
+		"en-US": {Title: "{{.group_name}}", Body: "{{.sender_name}}: {{.preview}}"},
+	},
+	// 锁屏隐私模式下的消息推送
+	"message.sender_only": {
+		"zh-CN": {Title: "{{.sender_name}}", Body: "发来一条新消息"},
+		"en-US": {Title: "{{.sender_name}}", Body: "Sent you a message"},
+	},
+	"message.group.sender_only": {
+		"zh-CN": {Title: "{{.group_name}}", Body: "{{.sender_name}} 发来一条新消息"},
+		"en-US": {Title: "{{.group_name}}", Body: "New message from {{.sender_name}}"},
+	},
+	// 事件总线上的消息事件不带发送者名称，只显示内容预览
+	"message.preview": {
+		"zh-CN": {Title: "新消息", Body: "{{.preview}}"},
+		"en-US": {Title: "New message", Body: "{{.preview}}"},
+	},
+	"message.generic": {
+		"zh-CN": {Title: "新消息", Body: "你收到一条新消息"},
+		"en-US": {Title: "New message", Body: "You have a new message"},
+	},
+	"group.invite": {
+		"zh-CN": {Title: "群组邀请", Body: "{{.inviter_name}} 邀请你加入群组「{{.group_name}}」"},
+		"en-US": {Title: "Group invitation", Body: "{{.inviter_name}} invited you to join \"{{.group_name}}\""},
+	},
+	"group.member_added": {
+		"zh-CN": {Title: "已加入群组", Body: "你已被加入群组「{{.group_name}}」"},
+		"en-US": {Title: "Added to group", Body: "You were added to \"{{.group_name}}\""},
+	},
+	"friend.request": {
+		"zh-CN": {Title: "好友请求", Body: "{{.sender_name}} 请求添加你为好友"},
+		"en-US": {Title: "Friend request", Body: "{{.sender_name}} wants to be your friend"},
+	},
+	"friend.accepted": {
+		"zh-CN": {Title: "好友请求已通过", Body: "{{.sender_name}} 已通过你的好友请求"},
+		"en-US": {Title: "Friend request accepted", Body: "{{.sender_name}} accepted your friend request"},
+	},
+	"media.removed": {
+		"zh-CN": {Title: "文件已删除", Body: "你的文件「{{.original_name}}」被识别为恶意文件，已被删除"},
+		"en-US": {Title: "File removed", Body: "Your file \"{{.original_name}}\" was removed because it was identified as malicious"},
+	},
+}
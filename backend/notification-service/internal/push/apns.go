@@ -0,0 +1,213 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+)
+
+const (
+	apnsProductionHost  = "https://api.push.apple.com"
+	apnsDevelopmentHost = "https://api.sandbox.push.apple.com"
+	// Apple要求提供者令牌在20到60分钟之间刷新
+	apnsTokenTTL = 50 * time.Minute
+	// apns-collapse-id 请求头最长64字节
+	apnsMaxCollapseID = 64
+)
+
+// APNsOptions APNs基于令牌的认证配置
+type APNsOptions struct {
+	KeyFile    string // .p8私钥文件
+	KeyID      string
+	TeamID     string
+	Topic      string // 应用的Bundle ID
+	Production bool
+}
+
+// APNsProvider 通过APNs HTTP/2接口推送，使用.p8密钥签发的提供者令牌认证
+type APNsProvider struct {
+	key    *ecdsa.PrivateKey
+	opts   APNsOptions
+	host   string
+	policy RetryPolicy
+	client *http.Client
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+type apnsPayload struct {
+	APS  apnsAps                `json:"aps"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+type apnsAps struct {
+	Alert    apnsAlert `json:"alert"`
+	Sound    string    `json:"sound,omitempty"`
+	Badge    int       `json:"badge,omitempty"`
+	ThreadID string    `json:"thread-id,omitempty"`
+	Category string    `json:"category,omitempty"`
+}
+
+type apnsAlert struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// NewAPNsProvider 加载.p8私钥并创建APNs推送
+func NewAPNsProvider(opts APNsOptions, policy RetryPolicy, logger *zap.Logger) (*APNsProvider, error) {
+	if opts.KeyID == "" || opts.TeamID == "" || opts.Topic == "" {
+		return nil, fmt.Errorf("APNs key id, team id and topic are required")
+	}
+	data, err := os.ReadFile(opts.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid APNs key: %w", err)
+	}
+
+	host := apnsDevelopmentHost
+	if opts.Production {
+		host = apnsProductionHost
+	}
+	return &APNsProvider{
+		key:    key,
+		opts:   opts,
+		host:   host,
+		policy: policy,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{ForceAttemptHTTP2: true},
+		},
+		logger: logger,
+	}, nil
+}
+
+func (p *APNsProvider) Name() string { return "apns" }
+
+// Send 并发向每个令牌发送通知，请求在同一HTTP/2连接上复用
+func (p *APNsProvider) Send(ctx context.Context, tokens []string, notification *domain.PushNotification) []Result {
+	body, err := json.Marshal(apnsPayload{
+		APS: apnsAps{
+			Alert: apnsAlert{
+				Title: notification.Title,
+				Body:  notification.Body,
+			},
+			Sound:    notification.Sound,
+			Badge:    notification.Badge,
+			ThreadID: notification.ThreadID,
+			Category: notification.Category,
+		},
+		Data: notification.Data,
+	})
+
+	collapseID := notification.CollapseKey
+	if len(collapseID) > apnsMaxCollapseID {
+		collapseID = collapseID[:apnsMaxCollapseID]
+	}
+
+	return sendConcurrently(tokens, func(token string) Result {
+		result := Result{Token: token}
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		result.Err = p.policy.do(ctx, func() error {
+			unregistered, err := p.post(ctx, token, collapseID, body)
+			result.Unregistered = unregistered
+			return err
+		})
+		return result
+	})
+}
+
+// post 发送一次请求，返回令牌是否已失效
+func (p *APNsProvider) post(ctx context.Context, token, collapseID string, body []byte) (bool, error) {
+	providerToken, err := p.providerToken()
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.host+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("authorization", "bearer "+providerToken)
+	req.Header.Set("apns-topic", p.opts.Topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+	if collapseID != "" {
+		req.Header.Set("apns-collapse-id", collapseID)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false, &retryableError{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return false, nil
+	}
+
+	var errResp struct {
+		Reason string `json:"reason"`
+	}
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	err = fmt.Errorf("APNs request failed with status %d: %s", resp.StatusCode, errResp.Reason)
+
+	switch {
+	case resp.StatusCode == http.StatusGone || errResp.Reason == "Unregistered" || errResp.Reason == "BadDeviceToken":
+		return true, err
+	case errResp.Reason == "ExpiredProviderToken":
+		p.invalidateToken()
+		return false, &retryableError{err: err}
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return false, &retryableError{err: err, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+	return false, err
+}
+
+// providerToken 返回缓存的提供者令牌，超过有效期时重新签发
+func (p *APNsProvider) providerToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Since(p.issuedAt) < apnsTokenTTL {
+		return p.token, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": p.opts.TeamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = p.opts.KeyID
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+	p.token = signed
+	p.issuedAt = now
+	return signed, nil
+}
+
+func (p *APNsProvider) invalidateToken() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = ""
+}
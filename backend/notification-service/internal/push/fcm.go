@@ -0,0 +1,292 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+)
+
+const (
+	fcmScope       = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmEndpoint    = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+	googleTokenURI = "https://oauth2.googleapis.com/token"
+)
+
+// serviceAccount Google服务账号凭据文件中用到的字段
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMProvider 通过FCM HTTP v1 API推送，使用服务账号换取OAuth2访问令牌
+type FCMProvider struct {
+	account  serviceAccount
+	endpoint string
+	policy   RetryPolicy
+	client   *http.Client
+	logger   *zap.Logger
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// fcmRequest FCM v1消息请求
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+	Android      fcmAndroidConfig  `json:"android"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmAndroidConfig struct {
+	Priority     string                 `json:"priority"`
+	CollapseKey  string                 `json:"collapse_key,omitempty"`
+	Notification fcmAndroidNotification `json:"notification"`
+}
+
+type fcmAndroidNotification struct {
+	Sound             string `json:"sound,omitempty"`
+	Tag               string `json:"tag,omitempty"` // 相同tag的通知在Android通知栏中合并显示
+	ChannelID         string `json:"channel_id,omitempty"`
+	NotificationCount int    `json:"notification_count,omitempty"`
+}
+
+// fcmErrorResponse FCM v1错误响应，失效令牌的errorCode为UNREGISTERED
+type fcmErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// NewFCMProvider 从服务账号凭据文件创建FCM推送，projectID为空时使用凭据中的项目
+func NewFCMProvider(credentialsFile, projectID string, policy RetryPolicy, logger *zap.Logger) (*FCMProvider, error) {
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	var account serviceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("invalid service account file: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, fmt.Errorf("service account file missing client_email or private_key")
+	}
+	if _, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey)); err != nil {
+		return nil, fmt.Errorf("invalid service account private key: %w", err)
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = googleTokenURI
+	}
+	if projectID == "" {
+		projectID = account.ProjectID
+	}
+	if projectID == "" {
+		return nil, fmt.Errorf("FCM project id not configured")
+	}
+
+	return &FCMProvider{
+		account:  account,
+		endpoint: fmt.Sprintf(fcmEndpoint, projectID),
+		policy:   policy,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		logger:   logger,
+	}, nil
+}
+
+func (p *FCMProvider) Name() string { return "fcm" }
+
+// Send 并发向每个令牌发送通知
+func (p *FCMProvider) Send(ctx context.Context, tokens []string, notification *domain.PushNotification) []Result {
+	return sendConcurrently(tokens, func(token string) Result {
+		result := Result{Token: token}
+		body, err := json.Marshal(fcmRequest{Message: p.buildMessage(token, notification)})
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		result.Err = p.policy.do(ctx, func() error {
+			unregistered, err := p.post(ctx, body)
+			result.Unregistered = unregistered
+			return err
+		})
+		return result
+	})
+}
+
+func (p *FCMProvider) buildMessage(token string, notification *domain.PushNotification) fcmMessage {
+	return fcmMessage{
+		Token: token,
+		Notification: fcmNotification{
+			Title: notification.Title,
+			Body:  notification.Body,
+		},
+		Data: stringifyData(notification.Data),
+		Android: fcmAndroidConfig{
+			Priority:    "high",
+			CollapseKey: notification.CollapseKey,
+			Notification: fcmAndroidNotification{
+				Sound:             notification.Sound,
+				Tag:               notification.ThreadID,
+				ChannelID:         notification.ChannelID,
+				NotificationCount: notification.Badge,
+			},
+		},
+	}
+}
+
+// post 发送一次请求，返回令牌是否已失效
+func (p *FCMProvider) post(ctx context.Context, body []byte) (bool, error) {
+	accessToken, err := p.token(ctx)
+	if err != nil {
+		return false, &retryableError{err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false, &retryableError{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return false, nil
+	}
+
+	var errResp fcmErrorResponse
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	errorCode := errResp.Error.Status
+	for _, detail := range errResp.Error.Details {
+		if detail.ErrorCode != "" {
+			errorCode = detail.ErrorCode
+		}
+	}
+	err = fmt.Errorf("FCM request failed with status %d: %s %s", resp.StatusCode, errorCode, errResp.Error.Message)
+
+	switch {
+	case errorCode == "UNREGISTERED":
+		return true, err
+	case resp.StatusCode == http.StatusUnauthorized:
+		// 访问令牌被提前吊销，清除缓存后重试
+		p.invalidateToken()
+		return false, &retryableError{err: err}
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return false, &retryableError{err: err, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+	return false, err
+}
+
+// token 返回缓存的OAuth2访问令牌，过期前一分钟刷新
+func (p *FCMProvider) token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.accessToken != "" && time.Now().Before(p.expiresAt.Add(-time.Minute)) {
+		return p.accessToken, nil
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(p.account.PrivateKey))
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   p.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   p.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(key)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("FCM token request failed with status: %d", resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", err
+	}
+	p.accessToken = tokenResp.AccessToken
+	p.expiresAt = now.Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	return p.accessToken, nil
+}
+
+func (p *FCMProvider) invalidateToken() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.accessToken = ""
+}
+
+// stringifyData FCM v1的data字段只接受字符串值，非字符串值编码为JSON
+func stringifyData(data map[string]interface{}) map[string]string {
+	if len(data) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(data))
+	for key, value := range data {
+		if s, ok := value.(string); ok {
+			result[key] = s
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		result[key] = string(encoded)
+	}
+	return result
+}
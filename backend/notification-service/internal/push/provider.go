@@ -0,0 +1,150 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/notification-service/config"
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+)
+
+// 设备平台，决定通过哪个推送服务商发送
+const (
+	PlatformAndroid = "android"
+	PlatformIOS     = "ios"
+)
+
+// Provider 推送服务商，FCM和APNs都是一次请求发送一个设备令牌，批量发送时并发请求
+type Provider interface {
+	Name() string
+	// Send 向一批设备令牌发送通知，返回每个令牌的发送结果，顺序与tokens一致
+	Send(ctx context.Context, tokens []string, notification *domain.PushNotification) []Result
+}
+
+// Result 单个设备令牌的发送结果
+type Result struct {
+	Token string
+	Err   error
+	// Unregistered 令牌已失效（应用卸载或令牌无效），需要注销对应设备
+	Unregistered bool
+}
+
+// RetryPolicy 推送请求的重试策略，只重试限流和服务端错误
+type RetryPolicy struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+// retryableError 可重试的错误，retryAfter为服务商要求的等待时间
+type retryableError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+
+func (e *retryableError) Unwrap() error { return e.err }
+
+// do 按重试策略执行请求，fn返回retryableError时等待后重试
+func (p RetryPolicy) do(ctx context.Context, fn func() error) error {
+	attempts := p.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := p.InitialDelay
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		var retryable *retryableError
+		if err == nil || !errors.As(err, &retryable) || attempt >= attempts {
+			return err
+		}
+
+		wait := delay
+		if retryable.retryAfter > wait {
+			wait = retryable.retryAfter
+		}
+		if p.MaxDelay > 0 && wait > p.MaxDelay {
+			wait = p.MaxDelay
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// parseRetryAfter 解析Retry-After响应头（秒数）
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// sendConcurrently 并发向每个令牌发送，send返回该令牌的结果
+func sendConcurrently(tokens []string, send func(token string) Result) []Result {
+	results := make([]Result, len(tokens))
+	var wg sync.WaitGroup
+	for i, token := range tokens {
+		wg.Add(1)
+		go func(i int, token string) {
+			defer wg.Done()
+			results[i] = send(token)
+		}(i, token)
+	}
+	wg.Wait()
+	return results
+}
+
+// NewProviders 根据配置创建推送服务商，按设备平台索引，未配置凭据的服务商不创建
+func NewProviders(cfg *config.PushConfig, logger *zap.Logger) (map[string]Provider, error) {
+	providers := make(map[string]Provider)
+
+	if cfg.FCMCredentialsFile != "" {
+		fcm, err := NewFCMProvider(cfg.FCMCredentialsFile, cfg.FCMProjectID, RetryPolicy{
+			MaxAttempts:  cfg.FCMMaxAttempts,
+			InitialDelay: cfg.FCMRetryDelay,
+			MaxDelay:     cfg.RetryMaxDelay,
+		}, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init FCM provider: %w", err)
+		}
+		providers[PlatformAndroid] = fcm
+	} else {
+		logger.Warn("FCM credentials not configured, Android push disabled")
+	}
+
+	if cfg.APNSKeyFile != "" {
+		apns, err := NewAPNsProvider(APNsOptions{
+			KeyFile:    cfg.APNSKeyFile,
+			KeyID:      cfg.APNSKeyID,
+			TeamID:     cfg.APNSTeamID,
+			Topic:      cfg.APNSTopic,
+			Production: cfg.APNSProduction,
+		}, RetryPolicy{
+			MaxAttempts:  cfg.APNSMaxAttempts,
+			InitialDelay: cfg.APNSRetryDelay,
+			MaxDelay:     cfg.RetryMaxDelay,
+		}, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init APNs provider: %w", err)
+		}
+		providers[PlatformIOS] = apns
+	} else {
+		logger.Warn("APNs key not configured, iOS push disabled")
+	}
+
+	return providers, nil
+}
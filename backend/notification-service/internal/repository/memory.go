@@ -280,14 +280,7 @@ func (r *MemoryNotificationPreferenceRepository) GetByUserID(userID string) (*do
 	preference, exists := r.preferences[userID]
 	if !exists {
 		// 返回默认偏好设置
-		return &domain.NotificationPreference{
-			UserID:               userID,
-			PushEnabled:          true,
-			EmailEnabled:         true,
-			MessageNotifications: true,
-			GroupNotifications:   true,
-			SystemNotifications:  true,
-		}, nil
+		return domain.DefaultNotificationPreference(userID), nil
 	}
 	return preference, nil
 }
@@ -307,3 +300,168 @@ func (r *MemoryNotificationPreferenceRepository) Delete(userID string) error {
 	delete(r.preferences, userID)
 	return nil
 }
+
+type MemoryReplyTokenRepository struct {
+	mu     sync.RWMutex
+	tokens map[string]*domain.ReplyToken
+}
+
+func NewMemoryReplyTokenRepository() *MemoryReplyTokenRepository {
+	return &MemoryReplyTokenRepository{
+		tokens: make(map[string]*domain.ReplyToken),
+	}
+}
+
+// ReplyTokenRepository implementation
+func (r *MemoryReplyTokenRepository) Create(token *domain.ReplyToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[token.Token] = token
+	return nil
+}
+
+func (r *MemoryReplyTokenRepository) Get(token string) (*domain.ReplyToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	replyToken, exists := r.tokens[token]
+	if !exists {
+		return nil, errors.New("reply token not found")
+	}
+	return replyToken, nil
+}
+
+func (r *MemoryReplyTokenRepository) DeleteExpired() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for token, replyToken := range r.tokens {
+		if now.After(replyToken.ExpiresAt) {
+			delete(r.tokens, token)
+		}
+	}
+	return nil
+}
+
+type MemoryExperimentRepository struct {
+	mu          sync.RWMutex
+	experiments map[string]*domain.Experiment
+	// experimentID -> variant -> event -> notificationID集合
+	events map[string]map[string]map[domain.ExperimentEvent]map[string]bool
+}
+
+func NewMemoryExperimentRepository() *MemoryExperimentRepository {
+	return &MemoryExperimentRepository{
+		experiments: make(map[string]*domain.Experiment),
+		events:      make(map[string]map[string]map[domain.ExperimentEvent]map[string]bool),
+	}
+}
+
+// ExperimentRepository implementation
+func (r *MemoryExperimentRepository) Create(experiment *domain.Experiment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if experiment.Status == domain.ExperimentStatusRunning {
+		for _, existing := range r.experiments {
+			if existing.Template == experiment.Template && existing.Status == domain.ExperimentStatusRunning {
+				return errors.New("template already has a running experiment")
+			}
+		}
+	}
+	r.experiments[experiment.ID] = experiment
+	return nil
+}
+
+func (r *MemoryExperimentRepository) GetByID(id string) (*domain.Experiment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	experiment, exists := r.experiments[id]
+	if !exists {
+		return nil, errors.New("experiment not found")
+	}
+	return experiment, nil
+}
+
+func (r *MemoryExperimentRepository) List() ([]*domain.Experiment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	experiments := make([]*domain.Experiment, 0, len(r.experiments))
+	for _, experiment := range r.experiments {
+		experiments = append(experiments, experiment)
+	}
+
+	// 按创建时间倒序排序
+	sort.Slice(experiments, func(i, j int) bool {
+		return experiments[i].CreatedAt.After(experiments[j].CreatedAt)
+	})
+	return experiments, nil
+}
+
+func (r *MemoryExperimentRepository) GetRunningByTemplate(template string) (*domain.Experiment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, experiment := range r.experiments {
+		if experiment.Template == template && experiment.Status == domain.ExperimentStatusRunning {
+			return experiment, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryExperimentRepository) Update(experiment *domain.Experiment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.experiments[experiment.ID]; !exists {
+		return errors.New("experiment not found")
+	}
+	r.experiments[experiment.ID] = experiment
+	return nil
+}
+
+func (r *MemoryExperimentRepository) RecordEvent(experimentID, variant, notificationID string, event domain.ExperimentEvent) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	variants, exists := r.events[experimentID]
+	if !exists {
+		variants = make(map[string]map[domain.ExperimentEvent]map[string]bool)
+		r.events[experimentID] = variants
+	}
+	events, exists := variants[variant]
+	if !exists {
+		events = make(map[domain.ExperimentEvent]map[string]bool)
+		variants[variant] = events
+	}
+	notifications, exists := events[event]
+	if !exists {
+		notifications = make(map[string]bool)
+		events[event] = notifications
+	}
+
+	if notifications[notificationID] {
+		return false, nil
+	}
+	notifications[notificationID] = true
+	return true, nil
+}
+
+func (r *MemoryExperimentRepository) CountEvents(experimentID string) (map[string]map[domain.ExperimentEvent]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]map[domain.ExperimentEvent]int)
+	for variant, events := range r.events[experimentID] {
+		counts[variant] = make(map[domain.ExperimentEvent]int, len(events))
+		for event, notifications := range events {
+			counts[variant][event] = len(notifications)
+		}
+	}
+	return counts, nil
+}
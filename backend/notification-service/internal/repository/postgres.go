@@ -0,0 +1,376 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+)
+
+// PostgresNotificationRepository 基于PostgreSQL的通知存储，服务重启后通知不丢失
+type PostgresNotificationRepository struct {
+	db *sqlx.DB
+}
+
+// PostgresUserDeviceRepository 基于PostgreSQL的用户设备存储
+type PostgresUserDeviceRepository struct {
+	db *sqlx.DB
+}
+
+// PostgresNotificationPreferenceRepository 基于PostgreSQL的通知偏好存储
+type PostgresNotificationPreferenceRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresNotificationRepository(db *sqlx.DB) *PostgresNotificationRepository {
+	return &PostgresNotificationRepository{db: db}
+}
+
+func NewPostgresUserDeviceRepository(db *sqlx.DB) *PostgresUserDeviceRepository {
+	return &PostgresUserDeviceRepository{db: db}
+}
+
+func NewPostgresNotificationPreferenceRepository(db *sqlx.DB) *PostgresNotificationPreferenceRepository {
+	return &PostgresNotificationPreferenceRepository{db: db}
+}
+
+// rowScanner *sql.Row 和 *sql.Rows 共同的读取方法
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+const notificationColumns = `id, user_id, type, title, body, data, template, params, locale,
+	experiment_id, variant, status, created_at, sent_at, read_at`
+
+// NotificationRepository implementation
+func (r *PostgresNotificationRepository) Create(notification *domain.Notification) error {
+	data, err := marshalJSONMap(notification.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification data: %w", err)
+	}
+	params, err := marshalJSONMap(notification.Params)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification params: %w", err)
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO notifications (`+notificationColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		notification.ID, notification.UserID, notification.Type, notification.Title, notification.Body,
+		data, notification.Template, params, notification.Locale,
+		notification.ExperimentID, notification.Variant, notification.Status,
+		notification.CreatedAt, notification.SentAt, notification.ReadAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresNotificationRepository) GetByID(id string) (*domain.Notification, error) {
+	row := r.db.QueryRow(`SELECT `+notificationColumns+` FROM notifications WHERE id = $1`, id)
+	notification, err := scanNotification(row)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("notification not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification: %w", err)
+	}
+	return notification, nil
+}
+
+func (r *PostgresNotificationRepository) GetByUserID(userID string, limit, offset int) ([]*domain.Notification, error) {
+	rows, err := r.db.Query(`
+		SELECT `+notificationColumns+`
+		FROM notifications
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []*domain.Notification{}
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, rows.Err()
+}
+
+func (r *PostgresNotificationRepository) UpdateStatus(id string, status domain.NotificationStatus) error {
+	// 标记为已发送时记录发送时间，其他状态保留原值
+	result, err := r.db.Exec(`
+		UPDATE notifications
+		SET status = $2, sent_at = CASE WHEN $3 THEN NOW() ELSE sent_at END
+		WHERE id = $1`,
+		id, status, status == domain.NotificationStatusSent,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update notification status: %w", err)
+	}
+	return expectAffected(result, "notification not found")
+}
+
+func (r *PostgresNotificationRepository) MarkAsRead(id string) error {
+	result, err := r.db.Exec(`
+		UPDATE notifications SET status = $2, read_at = NOW() WHERE id = $1`,
+		id, domain.NotificationStatusRead,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification as read: %w", err)
+	}
+	return expectAffected(result, "notification not found")
+}
+
+func (r *PostgresNotificationRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM notifications WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification: %w", err)
+	}
+	return expectAffected(result, "notification not found")
+}
+
+func (r *PostgresNotificationRepository) GetUnreadCount(userID string) (int, error) {
+	var count int
+	err := r.db.Get(&count, `
+		SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND status != $2`,
+		userID, domain.NotificationStatusRead,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// scanNotification 读取一行通知，列顺序与notificationColumns一致
+func scanNotification(row rowScanner) (*domain.Notification, error) {
+	var (
+		notification domain.Notification
+		data, params []byte
+	)
+	err := row.Scan(
+		&notification.ID, &notification.UserID, &notification.Type, &notification.Title, &notification.Body,
+		&data, &notification.Template, &params, &notification.Locale,
+		&notification.ExperimentID, &notification.Variant, &notification.Status,
+		&notification.CreatedAt, &notification.SentAt, &notification.ReadAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if notification.Data, err = unmarshalJSONMap(data); err != nil {
+		return nil, err
+	}
+	if notification.Params, err = unmarshalJSONMap(params); err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+const deviceColumns = `user_id, device_token, platform, device_type, categories, is_active, created_at, updated_at`
+
+// UserDeviceRepository implementation
+func (r *PostgresUserDeviceRepository) Create(device *domain.UserDevice) error {
+	// 同一设备令牌只对应一台设备，重复注册时覆盖
+	_, err := r.db.Exec(`
+		INSERT INTO user_devices (`+deviceColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (device_token) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			platform = EXCLUDED.platform,
+			device_type = EXCLUDED.device_type,
+			categories = EXCLUDED.categories,
+			is_active = EXCLUDED.is_active,
+			updated_at = EXCLUDED.updated_at`,
+		device.UserID, device.DeviceToken, device.Platform, device.DeviceType,
+		pq.Array(device.Categories), device.IsActive, device.CreatedAt, device.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create device: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresUserDeviceRepository) GetByUserID(userID string) ([]*domain.UserDevice, error) {
+	rows, err := r.db.Query(`
+		SELECT `+deviceColumns+`
+		FROM user_devices
+		WHERE user_id = $1 AND is_active
+		ORDER BY created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+	defer rows.Close()
+
+	devices := []*domain.UserDevice{}
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan device: %w", err)
+		}
+		devices = append(devices, device)
+	}
+	return devices, rows.Err()
+}
+
+func (r *PostgresUserDeviceRepository) GetByDeviceToken(deviceToken string) (*domain.UserDevice, error) {
+	row := r.db.QueryRow(`SELECT `+deviceColumns+` FROM user_devices WHERE device_token = $1`, deviceToken)
+	device, err := scanDevice(row)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("device not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	return device, nil
+}
+
+func (r *PostgresUserDeviceRepository) Update(device *domain.UserDevice) error {
+	device.UpdatedAt = time.Now()
+	result, err := r.db.Exec(`
+		UPDATE user_devices
+		SET user_id = $2, platform = $3, device_type = $4, categories = $5, is_active = $6, updated_at = $7
+		WHERE device_token = $1`,
+		device.DeviceToken, device.UserID, device.Platform, device.DeviceType,
+		pq.Array(device.Categories), device.IsActive, device.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update device: %w", err)
+	}
+	return expectAffected(result, "device not found")
+}
+
+func (r *PostgresUserDeviceRepository) Delete(userID, deviceToken string) error {
+	_, err := r.db.Exec(`DELETE FROM user_devices WHERE user_id = $1 AND device_token = $2`, userID, deviceToken)
+	if err != nil {
+		return fmt.Errorf("failed to delete device: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresUserDeviceRepository) DeactivateDevice(deviceToken string) error {
+	result, err := r.db.Exec(`
+		UPDATE user_devices SET is_active = FALSE, updated_at = NOW() WHERE device_token = $1`,
+		deviceToken,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate device: %w", err)
+	}
+	return expectAffected(result, "device not found")
+}
+
+// scanDevice 读取一行设备，列顺序与deviceColumns一致
+func scanDevice(row rowScanner) (*domain.UserDevice, error) {
+	var device domain.UserDevice
+	err := row.Scan(
+		&device.UserID, &device.DeviceToken, &device.Platform, &device.DeviceType,
+		pq.Array(&device.Categories), &device.IsActive, &device.CreatedAt, &device.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// NotificationPreferenceRepository implementation
+func (r *PostgresNotificationPreferenceRepository) Create(preference *domain.NotificationPreference) error {
+	return r.Update(preference)
+}
+
+func (r *PostgresNotificationPreferenceRepository) GetByUserID(userID string) (*domain.NotificationPreference, error) {
+	var preference domain.NotificationPreference
+	err := r.db.QueryRow(`
+		SELECT user_id, push_enabled, email_enabled, message_notifications,
+		       group_notifications, system_notifications, preview_mode
+		FROM notification_preferences
+		WHERE user_id = $1`,
+		userID,
+	).Scan(
+		&preference.UserID, &preference.PushEnabled, &preference.EmailEnabled, &preference.MessageNotifications,
+		&preference.GroupNotifications, &preference.SystemNotifications, &preference.PreviewMode,
+	)
+	if err == sql.ErrNoRows {
+		// 返回默认偏好设置
+		return domain.DefaultNotificationPreference(userID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	return &preference, nil
+}
+
+func (r *PostgresNotificationPreferenceRepository) Update(preference *domain.NotificationPreference) error {
+	_, err := r.db.Exec(`
+		INSERT INTO notification_preferences (
+			user_id, push_enabled, email_enabled, message_notifications,
+			group_notifications, system_notifications, preview_mode, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			push_enabled = EXCLUDED.push_enabled,
+			email_enabled = EXCLUDED.email_enabled,
+			message_notifications = EXCLUDED.message_notifications,
+			group_notifications = EXCLUDED.group_notifications,
+			system_notifications = EXCLUDED.system_notifications,
+			preview_mode = EXCLUDED.preview_mode,
+			updated_at = NOW()`,
+		preference.UserID, preference.PushEnabled, preference.EmailEnabled, preference.MessageNotifications,
+		preference.GroupNotifications, preference.SystemNotifications, preference.PreviewMode,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresNotificationPreferenceRepository) Delete(userID string) error {
+	_, err := r.db.Exec(`DELETE FROM notification_preferences WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification preferences: %w", err)
+	}
+	return nil
+}
+
+// expectAffected 更新或删除没有命中任何行时返回notFound错误
+func expectAffected(result sql.Result, notFound string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New(notFound)
+	}
+	return nil
+}
+
+// marshalJSONMap 把map编码为JSONB，nil保存为NULL
+func marshalJSONMap(m map[string]interface{}) ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
+
+func unmarshalJSONMap(data []byte) (map[string]interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
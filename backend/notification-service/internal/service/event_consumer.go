@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+	"github.com/neohope/chatapp/notification-service/pkg/events"
+)
+
+// ConsumedEventTypes 通知服务订阅的事件，media.uploaded等其他事件不产生通知
+var ConsumedEventTypes = []string{
+	events.TypeMessageCreated,
+	events.TypeGroupMemberAdded,
+	events.TypeFriendRequestSent,
+}
+
+// EventConsumer 把其他服务发布的领域事件转换为通知，代替服务间的同步通知调用
+type EventConsumer struct {
+	notifications domain.NotificationService
+	mutes         *MuteFilter
+	logger        *zap.Logger
+}
+
+// NewEventConsumer 创建事件消费者，mutes为nil时不检查免打扰设置
+func NewEventConsumer(notifications domain.NotificationService, mutes *MuteFilter, logger *zap.Logger) *EventConsumer {
+	return &EventConsumer{
+		notifications: notifications,
+		mutes:         mutes,
+		logger:        logger,
+	}
+}
+
+// Handle 按事件类型生成通知
+func (c *EventConsumer) Handle(ctx context.Context, event *events.Event) error {
+	switch event.Type {
+	case events.TypeMessageCreated:
+		var data events.MessageCreated
+		if err := event.Decode(&data); err != nil {
+			return fmt.Errorf("invalid %s event: %w", event.Type, err)
+		}
+		return c.handleMessageCreated(&data)
+	case events.TypeGroupMemberAdded:
+		var data events.GroupMemberAdded
+		if err := event.Decode(&data); err != nil {
+			return fmt.Errorf("invalid %s event: %w", event.Type, err)
+		}
+		return c.handleGroupMemberAdded(&data)
+	case events.TypeFriendRequestSent:
+		var data events.FriendRequestSent
+		if err := event.Decode(&data); err != nil {
+			return fmt.Errorf("invalid %s event: %w", event.Type, err)
+		}
+		return c.handleFriendRequestSent(&data)
+	}
+	return nil
+}
+
+// handleMessageCreated 通知会话中除发送者和开启免打扰的用户外的参与者，单个接收者失败不影响其他接收者
+func (c *EventConsumer) handleMessageCreated(data *events.MessageCreated) error {
+	template := "message.preview"
+	if data.Preview == "" {
+		template = "message.generic"
+	}
+
+	var failed int
+	for _, recipientID := range data.RecipientIDs {
+		if recipientID == data.SenderID {
+			continue
+		}
+		if c.mutes != nil && c.mutes.IsMuted(data.ConversationID, data.IsGroupChat, recipientID) {
+			c.logger.Debug("Message notification skipped for muted conversation",
+				zap.String("user_id", recipientID),
+				zap.String("conversation_id", data.ConversationID),
+			)
+			continue
+		}
+		notification := &domain.Notification{
+			UserID:   recipientID,
+			Type:     domain.NotificationTypeMessage,
+			Template: template,
+			Params:   map[string]interface{}{"preview": data.Preview},
+			Data: map[string]interface{}{
+				"conversation_id": data.ConversationID,
+				"message_id":      data.MessageID,
+				"sender_id":       data.SenderID,
+				"preview":         data.Preview,
+			},
+		}
+		if data.IsGroupChat {
+			notification.Data["group_id"] = data.ConversationID
+		}
+		if err := c.notifications.SendNotification(notification); err != nil {
+			failed++
+			c.logger.Warn("Failed to notify message recipient",
+				zap.String("user_id", recipientID),
+				zap.String("message_id", data.MessageID),
+				zap.Error(err),
+			)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to notify %d of %d recipients", failed, len(data.RecipientIDs))
+	}
+	return nil
+}
+
+// handleGroupMemberAdded 只通知被管理员直接添加的成员，接受邀请和入群申请通过时用户已知情或已单独通知
+func (c *EventConsumer) handleGroupMemberAdded(data *events.GroupMemberAdded) error {
+	if data.Via != events.JoinViaAdded || data.ActorID == data.UserID {
+		return nil
+	}
+	return c.notifications.SendNotification(&domain.Notification{
+		UserID:   data.UserID,
+		Type:     domain.NotificationTypeGroupInvite,
+		Template: "group.member_added",
+		Params:   map[string]interface{}{"group_name": data.GroupName},
+		Data: map[string]interface{}{
+			"group_id": data.GroupID,
+			"actor_id": data.ActorID,
+			"role":     data.Role,
+		},
+	})
+}
+
+// handleFriendRequestSent 通知好友请求的接收者
+func (c *EventConsumer) handleFriendRequestSent(data *events.FriendRequestSent) error {
+	return c.notifications.SendNotification(&domain.Notification{
+		UserID:   data.RecipientID,
+		Type:     domain.NotificationTypeFriendRequest,
+		Template: "friend.request",
+		Params:   map[string]interface{}{"sender_name": data.SenderName},
+		Data: map[string]interface{}{
+			"request_id": data.RequestID,
+			"sender_id":  data.SenderID,
+			"message":    data.Message,
+		},
+	})
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+	"github.com/neohope/chatapp/notification-service/internal/i18n"
+)
+
+var (
+	ErrInvalidExperiment  = errors.New("invalid experiment")
+	ErrExperimentNotFound = errors.New("experiment not found")
+)
+
+// Assignment 用户在实验中分配到的变体，templates为nil时使用模板目录中的原文案
+type Assignment struct {
+	ExperimentID string
+	Variant      string
+	templates    i18n.TemplateSet
+}
+
+// ExperimentService 通知文案A/B实验：按权重把用户稳定地分配到文案变体，记录投放、打开和转化
+type ExperimentService struct {
+	repo              domain.ExperimentRepository
+	notificationRepo  domain.NotificationRepository
+	localizer         *i18n.Localizer
+	attributionWindow time.Duration
+	logger            *zap.Logger
+
+	// 编译后的变体文案，experimentID -> variant -> 模板
+	mu       sync.RWMutex
+	compiled map[string]map[string]i18n.TemplateSet
+}
+
+func NewExperimentService(
+	repo domain.ExperimentRepository,
+	notificationRepo domain.NotificationRepository,
+	localizer *i18n.Localizer,
+	attributionWindow time.Duration,
+	logger *zap.Logger,
+) *ExperimentService {
+	return &ExperimentService{
+		repo:              repo,
+		notificationRepo:  notificationRepo,
+		localizer:         localizer,
+		attributionWindow: attributionWindow,
+		logger:            logger,
+		compiled:          make(map[string]map[string]i18n.TemplateSet),
+	}
+}
+
+// CreateExperiment 校验并启动实验，模板已有进行中的实验时返回错误
+func (s *ExperimentService) CreateExperiment(experiment *domain.Experiment) error {
+	if s.localizer == nil || !s.localizer.HasTemplate(experiment.Template) {
+		return fmt.Errorf("%w: unknown template %q", ErrInvalidExperiment, experiment.Template)
+	}
+	if len(experiment.Variants) < 2 {
+		return fmt.Errorf("%w: at least two variants are required", ErrInvalidExperiment)
+	}
+
+	experiment.ID = uuid.New().String()
+	compiled, err := s.compile(experiment)
+	if err != nil {
+		return err
+	}
+
+	experiment.Status = domain.ExperimentStatusRunning
+	experiment.CreatedAt = time.Now()
+	experiment.StoppedAt = nil
+	if err := s.repo.Create(experiment); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidExperiment, err)
+	}
+
+	s.mu.Lock()
+	s.compiled[experiment.ID] = compiled
+	s.mu.Unlock()
+
+	s.logger.Info("Notification experiment started",
+		zap.String("experiment_id", experiment.ID),
+		zap.String("template", experiment.Template),
+		zap.Int("variants", len(experiment.Variants)),
+	)
+	return nil
+}
+
+func (s *ExperimentService) GetExperiment(id string) (*domain.Experiment, error) {
+	experiment, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrExperimentNotFound
+	}
+	return experiment, nil
+}
+
+func (s *ExperimentService) ListExperiments() ([]*domain.Experiment, error) {
+	return s.repo.List()
+}
+
+// StopExperiment 停止实验，之后的通知使用原文案，已有统计保留
+func (s *ExperimentService) StopExperiment(id string) (*domain.Experiment, error) {
+	experiment, err := s.GetExperiment(id)
+	if err != nil {
+		return nil, err
+	}
+	if experiment.Status == domain.ExperimentStatusStopped {
+		return experiment, nil
+	}
+
+	stopped := *experiment
+	now := time.Now()
+	stopped.Status = domain.ExperimentStatusStopped
+	stopped.StoppedAt = &now
+	if err := s.repo.Update(&stopped); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.compiled, id)
+	s.mu.Unlock()
+	return &stopped, nil
+}
+
+// GetResults 按变体汇总投放、打开和转化
+func (s *ExperimentService) GetResults(id string) (*domain.ExperimentResults, error) {
+	experiment, err := s.GetExperiment(id)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.repo.CountEvents(id)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &domain.ExperimentResults{Experiment: experiment}
+	for _, variant := range experiment.Variants {
+		result := &domain.VariantResult{
+			Variant:   variant.Name,
+			Weight:    variant.Weight,
+			Sent:      counts[variant.Name][domain.ExperimentEventSent],
+			Opened:    counts[variant.Name][domain.ExperimentEventOpened],
+			Converted: counts[variant.Name][domain.ExperimentEventConverted],
+		}
+		if result.Sent > 0 {
+			result.OpenRate = float64(result.Opened) / float64(result.Sent)
+			result.ConversionRate = float64(result.Converted) / float64(result.Sent)
+		}
+		results.Variants = append(results.Variants, result)
+	}
+	return results, nil
+}
+
+// RecordConversion 把转化归因到通知发送时分配的变体，超出归因窗口或不属于实验的通知返回false
+func (s *ExperimentService) RecordConversion(userID, notificationID string) (bool, error) {
+	notification, err := s.notificationRepo.GetByID(notificationID)
+	if err != nil || notification.UserID != userID {
+		return false, fmt.Errorf("notification not found")
+	}
+	if notification.ExperimentID == "" {
+		return false, nil
+	}
+
+	sentAt := notification.CreatedAt
+	if notification.SentAt != nil {
+		sentAt = *notification.SentAt
+	}
+	if s.attributionWindow > 0 && time.Since(sentAt) > s.attributionWindow {
+		return false, nil
+	}
+
+	// 转化的通知必然已被打开，保持漏斗单调
+	s.RecordEvent(notification, domain.ExperimentEventOpened)
+	return s.RecordEvent(notification, domain.ExperimentEventConverted), nil
+}
+
+// Assign 获取用户在模板进行中实验里的变体，没有实验时返回nil
+// 同一实验中按实验ID和用户ID哈希分配，用户每次收到的都是同一变体
+func (s *ExperimentService) Assign(userID, template string) *Assignment {
+	experiment, err := s.repo.GetRunningByTemplate(template)
+	if err != nil {
+		s.logger.Warn("Failed to get running experiment", zap.String("template", template), zap.Error(err))
+		return nil
+	}
+	if experiment == nil {
+		return nil
+	}
+
+	compiled, err := s.compiledVariants(experiment)
+	if err != nil {
+		s.logger.Error("Failed to compile experiment variants", zap.String("experiment_id", experiment.ID), zap.Error(err))
+		return nil
+	}
+
+	variant := pickVariant(experiment, userID)
+	return &Assignment{
+		ExperimentID: experiment.ID,
+		Variant:      variant.Name,
+		templates:    compiled[variant.Name],
+	}
+}
+
+// RecordEvent 记录通知的漏斗事件，失败只记录日志
+func (s *ExperimentService) RecordEvent(notification *domain.Notification, event domain.ExperimentEvent) bool {
+	if notification.ExperimentID == "" {
+		return false
+	}
+
+	recorded, err := s.repo.RecordEvent(notification.ExperimentID, notification.Variant, notification.ID, event)
+	if err != nil {
+		s.logger.Warn("Failed to record experiment event",
+			zap.String("experiment_id", notification.ExperimentID),
+			zap.String("notification_id", notification.ID),
+			zap.String("event", string(event)),
+			zap.Error(err),
+		)
+		return false
+	}
+	return recorded
+}
+
+// compiledVariants 获取实验编译后的变体文案，服务重启后按需重新编译
+func (s *ExperimentService) compiledVariants(experiment *domain.Experiment) (map[string]i18n.TemplateSet, error) {
+	s.mu.RLock()
+	compiled, ok := s.compiled[experiment.ID]
+	s.mu.RUnlock()
+	if ok {
+		return compiled, nil
+	}
+
+	compiled, err := s.compile(experiment)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.compiled[experiment.ID] = compiled
+	s.mu.Unlock()
+	return compiled, nil
+}
+
+// compile 校验变体并编译文案，有文案的变体必须提供默认语言版本
+func (s *ExperimentService) compile(experiment *domain.Experiment) (map[string]i18n.TemplateSet, error) {
+	compiled := make(map[string]i18n.TemplateSet, len(experiment.Variants))
+	for _, variant := range experiment.Variants {
+		name := strings.TrimSpace(variant.Name)
+		if name == "" || name != variant.Name {
+			return nil, fmt.Errorf("%w: invalid variant name %q", ErrInvalidExperiment, variant.Name)
+		}
+		if _, exists := compiled[name]; exists {
+			return nil, fmt.Errorf("%w: duplicate variant %q", ErrInvalidExperiment, name)
+		}
+		if variant.Weight <= 0 {
+			return nil, fmt.Errorf("%w: variant %q must have a positive weight", ErrInvalidExperiment, name)
+		}
+
+		if len(variant.Copy) == 0 {
+			compiled[name] = nil
+			continue
+		}
+		if _, ok := variant.Copy[s.localizer.DefaultLocale()]; !ok {
+			return nil, fmt.Errorf("%w: variant %q has no copy for default locale %s", ErrInvalidExperiment, name, s.localizer.DefaultLocale())
+		}
+
+		templates := make(map[string]i18n.Template, len(variant.Copy))
+		for locale, variantCopy := range variant.Copy {
+			if variantCopy.Title == "" || variantCopy.Body == "" {
+				return nil, fmt.Errorf("%w: variant %q copy for %s requires title and body", ErrInvalidExperiment, name, locale)
+			}
+			templates[locale] = i18n.Template{Title: variantCopy.Title, Body: variantCopy.Body}
+		}
+		set, err := i18n.CompileTemplateSet(experiment.Template+"#"+name, templates)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExperiment, err)
+		}
+		compiled[name] = set
+	}
+	return compiled, nil
+}
+
+// pickVariant 按权重稳定地为用户选择变体
+func pickVariant(experiment *domain.Experiment, userID string) domain.ExperimentVariant {
+	total := 0
+	for _, variant := range experiment.Variants {
+		total += variant.Weight
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(experiment.ID + ":" + userID))
+	point := int(h.Sum32() % uint32(total))
+	for _, variant := range experiment.Variants {
+		if point < variant.Weight {
+			return variant
+		}
+		point -= variant.Weight
+	}
+	return experiment.Variants[len(experiment.Variants)-1]
+}
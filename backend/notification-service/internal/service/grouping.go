@@ -0,0 +1,72 @@
+package service
+
+import (
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+)
+
+// Android通知渠道，客户端需预先创建同名渠道
+const (
+	ChannelDirectMessages = "direct_messages"
+	ChannelGroupMessages  = "group_messages"
+	ChannelGroups         = "groups"
+	ChannelSocial         = "social"
+	ChannelSystem         = "system"
+)
+
+// iOS通知类别，对应客户端注册的UNNotificationCategory
+const (
+	CategoryMessage       = "MESSAGE"
+	CategoryGroupInvite   = "GROUP_INVITE"
+	CategoryFriendRequest = "FRIEND_REQUEST"
+	CategorySystem        = "SYSTEM"
+)
+
+// applyPushGrouping 根据会话/群组上下文补全推送的分组字段，调用方已指定的字段保持不变
+func applyPushGrouping(push *domain.PushNotification, notificationType domain.NotificationType) {
+	conversationID, _ := push.Data["conversation_id"].(string)
+	groupID, _ := push.Data["group_id"].(string)
+
+	// 同一会话（或群组）的通知归为一组
+	threadID := ""
+	switch {
+	case conversationID != "":
+		threadID = "conversation:" + conversationID
+	case groupID != "":
+		threadID = "group:" + groupID
+	}
+
+	var category, channelID string
+	switch notificationType {
+	case domain.NotificationTypeMessage:
+		category = CategoryMessage
+		channelID = ChannelDirectMessages
+		if groupID != "" {
+			channelID = ChannelGroupMessages
+		}
+	case domain.NotificationTypeGroupInvite:
+		category = CategoryGroupInvite
+		channelID = ChannelGroups
+	case domain.NotificationTypeFriendRequest:
+		category = CategoryFriendRequest
+		channelID = ChannelSocial
+		if threadID == "" {
+			threadID = "friend_requests"
+		}
+	default:
+		category = CategorySystem
+		channelID = ChannelSystem
+	}
+
+	if push.ThreadID == "" {
+		push.ThreadID = threadID
+	}
+	if push.CollapseKey == "" {
+		push.CollapseKey = push.ThreadID
+	}
+	if push.Category == "" {
+		push.Category = category
+	}
+	if push.ChannelID == "" {
+		push.ChannelID = channelID
+	}
+}
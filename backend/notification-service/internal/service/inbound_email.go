@@ -0,0 +1,117 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/notification-service/internal/client"
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+	"github.com/neohope/chatapp/notification-service/internal/email"
+)
+
+// maxReplyLength 通过邮件回复的消息最大长度
+const maxReplyLength = 4000
+
+var replyTokenEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type inboundEmailService struct {
+	tokenRepo     domain.ReplyTokenRepository
+	messageClient client.MessageClient
+	replyDomain   string
+	tokenTTL      time.Duration
+	logger        *zap.Logger
+}
+
+func NewInboundEmailService(
+	tokenRepo domain.ReplyTokenRepository,
+	messageClient client.MessageClient,
+	replyDomain string,
+	tokenTTL time.Duration,
+	logger *zap.Logger,
+) domain.InboundEmailService {
+	return &inboundEmailService{
+		tokenRepo:     tokenRepo,
+		messageClient: messageClient,
+		replyDomain:   replyDomain,
+		tokenTTL:      tokenTTL,
+		logger:        logger,
+	}
+}
+
+func (s *inboundEmailService) ReplyAddress(userID, conversationID, emailAddress string) (string, error) {
+	if s.replyDomain == "" {
+		return "", errors.New("reply domain not configured")
+	}
+	recipient := email.NormalizeAddress(emailAddress)
+	if recipient == "" {
+		return "", errors.New("email address required")
+	}
+
+	// 令牌只含小写字母和数字，避免邮件系统改写大小写导致失效
+	buf := make([]byte, 15)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	token := &domain.ReplyToken{
+		Token:          strings.ToLower(replyTokenEncoding.EncodeToString(buf)),
+		UserID:         userID,
+		ConversationID: conversationID,
+		Email:          recipient,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(s.tokenTTL),
+	}
+	if err := s.tokenRepo.Create(token); err != nil {
+		return "", err
+	}
+
+	return email.ReplyLocalPrefix + token.Token + "@" + s.replyDomain, nil
+}
+
+func (s *inboundEmailService) HandleReply(inbound *domain.InboundEmail) error {
+	tokenValue, ok := email.ExtractReplyToken(inbound.Recipients)
+	if !ok {
+		return errors.New("reply token not found in recipients")
+	}
+
+	token, err := s.tokenRepo.Get(tokenValue)
+	if err != nil {
+		return err
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return errors.New("reply token expired")
+	}
+	// 回复地址可能被转发或泄露，只接受通知邮件收件人本人的回复
+	if token.Email == "" || email.NormalizeAddress(inbound.Sender) != token.Email {
+		return errors.New("reply sender does not match recipient")
+	}
+
+	content := email.StripQuotedReply(inbound.Text)
+	if content == "" {
+		return errors.New("empty reply")
+	}
+	if len([]rune(content)) > maxReplyLength {
+		content = string([]rune(content)[:maxReplyLength])
+	}
+
+	metadata := map[string]interface{}{
+		"source":         "email",
+		"email_provider": inbound.Provider,
+	}
+	if err := s.messageClient.SendTextMessage(token.UserID, token.ConversationID, content, metadata); err != nil {
+		return fmt.Errorf("failed to post reply: %w", err)
+	}
+
+	s.logger.Info("Email reply posted",
+		zap.String("user_id", token.UserID),
+		zap.String("conversation_id", token.ConversationID),
+		zap.String("provider", inbound.Provider),
+	)
+	return nil
+}
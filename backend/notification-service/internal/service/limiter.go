@@ -0,0 +1,129 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+)
+
+// NotificationPriority 通知优先级，超出频率上限时先限制低优先级的通知
+type NotificationPriority int
+
+const (
+	PriorityGeneric NotificationPriority = iota // 普通消息，超限时丢弃推送
+	PriorityMention                             // @提及和直接发给用户的邀请/好友请求，超限时延后推送
+	PrioritySystem                              // 系统通知，不受上限限制
+)
+
+// LimitDecision 频率限制的处理结果
+type LimitDecision int
+
+const (
+	LimitAllow LimitDecision = iota
+	LimitDefer
+	LimitDrop
+)
+
+func (p NotificationPriority) String() string {
+	switch p {
+	case PrioritySystem:
+		return "system"
+	case PriorityMention:
+		return "mention"
+	default:
+		return "generic"
+	}
+}
+
+// notificationPriority 根据通知类型确定优先级，消息数据中mentioned为true时视为@提及
+func notificationPriority(notification *domain.Notification) NotificationPriority {
+	switch notification.Type {
+	case domain.NotificationTypeSystem:
+		return PrioritySystem
+	case domain.NotificationTypeGroupInvite, domain.NotificationTypeFriendRequest:
+		return PriorityMention
+	}
+	if mentioned, _ := notification.Data["mentioned"].(bool); mentioned {
+		return PriorityMention
+	}
+	return PriorityGeneric
+}
+
+type userUsage struct {
+	hourStart time.Time
+	hourCount int
+	dayStart  time.Time
+	dayCount  int
+}
+
+// RateLimiter 按用户限制每小时/每天推送的通知数量，防止消息风暴时打扰用户
+// 计数按自然小时/自然日（UTC）窗口，保存在内存中
+type RateLimiter struct {
+	hourlyCap int
+	dailyCap  int
+
+	mu    sync.Mutex
+	usage map[string]*userUsage
+}
+
+// NewRateLimiter 创建频率限制器，上限为0表示不限制该窗口
+func NewRateLimiter(hourlyCap, dailyCap int) *RateLimiter {
+	return &RateLimiter{
+		hourlyCap: hourlyCap,
+		dailyCap:  dailyCap,
+		usage:     make(map[string]*userUsage),
+	}
+}
+
+// Admit 判断是否可以立即推送，允许时计入用量；延后时返回窗口重置的时间
+func (l *RateLimiter) Admit(userID string, priority NotificationPriority) (LimitDecision, time.Time) {
+	now := time.Now().UTC()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	usage := l.usage[userID]
+	if usage == nil {
+		usage = &userUsage{}
+		l.usage[userID] = usage
+	}
+	if hour := now.Truncate(time.Hour); !usage.hourStart.Equal(hour) {
+		usage.hourStart = hour
+		usage.hourCount = 0
+	}
+	if day := now.Truncate(24 * time.Hour); !usage.dayStart.Equal(day) {
+		usage.dayStart = day
+		usage.dayCount = 0
+	}
+
+	overDaily := l.dailyCap > 0 && usage.dayCount >= l.dailyCap
+	overHourly := l.hourlyCap > 0 && usage.hourCount >= l.hourlyCap
+	if priority != PrioritySystem && (overDaily || overHourly) {
+		if priority == PriorityGeneric {
+			return LimitDrop, time.Time{}
+		}
+		if overDaily {
+			return LimitDefer, usage.dayStart.Add(24 * time.Hour)
+		}
+		return LimitDefer, usage.hourStart.Add(time.Hour)
+	}
+
+	// 系统通知同样计入用量
+	usage.hourCount++
+	usage.dayCount++
+	return LimitAllow, time.Time{}
+}
+
+// Prune 清理已经过了当日窗口的用户计数
+func (l *RateLimiter) Prune() {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for userID, usage := range l.usage {
+		if usage.dayStart.Before(today) {
+			delete(l.usage, userID)
+		}
+	}
+}
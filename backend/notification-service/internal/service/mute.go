@@ -0,0 +1,99 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/notification-service/internal/client"
+)
+
+// muteCacheMaxEntries 缓存的会话和群组数量上限，超出时先清理过期项
+const muteCacheMaxEntries = 10000
+
+type cachedMutes struct {
+	until     map[string]*time.Time // userID -> 免打扰截止时间，nil表示永久
+	expiresAt time.Time
+}
+
+// MuteFilter 查询并缓存会话和群组的免打扰设置，开启免打扰的用户不再收到新消息通知
+// 会话免打扰由消息服务维护，群组免打扰由群组服务维护，群聊中任一处开启都视为免打扰
+type MuteFilter struct {
+	messageClient client.MessageClient
+	groupClient   client.GroupClient
+	ttl           time.Duration
+	logger        *zap.Logger
+
+	mu    sync.RWMutex
+	cache map[string]cachedMutes
+}
+
+// NewMuteFilter 创建免打扰过滤器，groupClient为nil时只检查会话免打扰
+func NewMuteFilter(messageClient client.MessageClient, groupClient client.GroupClient, ttl time.Duration, logger *zap.Logger) *MuteFilter {
+	return &MuteFilter{
+		messageClient: messageClient,
+		groupClient:   groupClient,
+		ttl:           ttl,
+		logger:        logger,
+		cache:         make(map[string]cachedMutes),
+	}
+}
+
+// IsMuted 用户对会话开启了免打扰时返回true，群聊还检查对应群组；查询失败时照常推送
+func (f *MuteFilter) IsMuted(conversationID string, isGroupChat bool, userID string) bool {
+	now := time.Now()
+	if f.muted("conversation:"+conversationID, userID, now, func() ([]client.Mute, error) {
+		return f.messageClient.GetConversationMutes(conversationID)
+	}) {
+		return true
+	}
+	// 群聊消息发在频道会话中，由群组服务按会话找到所属群组
+	if isGroupChat && f.groupClient != nil {
+		return f.muted("group:"+conversationID, userID, now, func() ([]client.Mute, error) {
+			return f.groupClient.GetGroupMutes(conversationID)
+		})
+	}
+	return false
+}
+
+// muted 从缓存或服务查询key对应的免打扰设置，判断用户在now时是否免打扰
+func (f *MuteFilter) muted(key, userID string, now time.Time, load func() ([]client.Mute, error)) bool {
+	f.mu.RLock()
+	entry, ok := f.cache[key]
+	f.mu.RUnlock()
+
+	if !ok || !now.Before(entry.expiresAt) {
+		mutes, err := load()
+		if err != nil {
+			f.logger.Warn("Failed to get mute settings", zap.String("key", key), zap.Error(err))
+			return false
+		}
+		entry = cachedMutes{until: make(map[string]*time.Time, len(mutes)), expiresAt: now.Add(f.ttl)}
+		for _, mute := range mutes {
+			entry.until[mute.UserID] = mute.MutedUntil
+		}
+		f.store(key, entry, now)
+	}
+
+	until, muted := entry.until[userID]
+	return muted && (until == nil || now.Before(*until))
+}
+
+// store 写入缓存，超过容量时先清理过期项，仍然超出则清空
+func (f *MuteFilter) store(key string, entry cachedMutes, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.cache) >= muteCacheMaxEntries {
+		for k, cached := range f.cache {
+			if !now.Before(cached.expiresAt) {
+				delete(f.cache, k)
+			}
+		}
+		if len(f.cache) >= muteCacheMaxEntries {
+			f.cache = make(map[string]cachedMutes)
+		}
+	}
+	f.cache[key] = entry
+}
@@ -1,19 +1,37 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
 	"github.com/neohope/chatapp/notification-service/internal/domain"
+	"github.com/neohope/chatapp/notification-service/internal/i18n"
+	"github.com/neohope/chatapp/notification-service/pkg/jobs"
 )
 
+// jobDeliverDeferred 超出频率上限而延后的通知，在窗口重置后重新投递
+const jobDeliverDeferred = "deliver_deferred_notification"
+
+type deferredPayload struct {
+	NotificationID string `json:"notification_id"`
+}
+
 type notificationService struct {
 	notificationRepo domain.NotificationRepository
 	deviceRepo       domain.UserDeviceRepository
 	preferenceRepo   domain.NotificationPreferenceRepository
 	pushService      domain.PushService
+	localizer        *i18n.Localizer
+	inboundEmail     domain.InboundEmailService
+	recipients       *RecipientFilter
+	experiments      *ExperimentService
+	limiter          *RateLimiter
+	jobRunner        *jobs.Runner
 	logger           *zap.Logger
 }
 
@@ -22,18 +40,45 @@ func NewNotificationService(
 	deviceRepo domain.UserDeviceRepository,
 	preferenceRepo domain.NotificationPreferenceRepository,
 	pushService domain.PushService,
+	localizer *i18n.Localizer,
+	inboundEmail domain.InboundEmailService,
+	recipients *RecipientFilter,
+	experiments *ExperimentService,
+	limiter *RateLimiter,
+	jobRunner *jobs.Runner,
 	logger *zap.Logger,
 ) domain.NotificationService {
-	return &notificationService{
+	s := &notificationService{
 		notificationRepo: notificationRepo,
 		deviceRepo:       deviceRepo,
 		preferenceRepo:   preferenceRepo,
 		pushService:      pushService,
+		localizer:        localizer,
+		inboundEmail:     inboundEmail,
+		recipients:       recipients,
+		experiments:      experiments,
+		limiter:          limiter,
+		jobRunner:        jobRunner,
 		logger:           logger,
 	}
+
+	// 没有任务执行器时无法延后投递，超限的通知一律丢弃推送
+	if jobRunner != nil {
+		jobRunner.Register(jobDeliverDeferred, s.deliverDeferred, jobs.MaxAttempts(3))
+	}
+	return s
 }
 
 func (s *notificationService) SendNotification(notification *domain.Notification) error {
+	// 已停用的账户不再接收通知
+	if s.recipients != nil && s.recipients.IsSuppressed(notification.UserID) {
+		s.logger.Info("Notification suppressed for deactivated user",
+			zap.String("user_id", notification.UserID),
+			zap.String("type", string(notification.Type)),
+		)
+		return nil
+	}
+
 	// 生成ID和时间戳
 	if notification.ID == "" {
 		notification.ID = uuid.New().String()
@@ -41,6 +86,17 @@ func (s *notificationService) SendNotification(notification *domain.Notification
 	notification.CreatedAt = time.Now()
 	notification.Status = domain.NotificationStatusPending
 
+	// 模板通知按接收者语言渲染
+	if notification.Template != "" {
+		if err := s.localize(notification); err != nil {
+			s.logger.Error("Failed to render notification template",
+				zap.String("template", notification.Template),
+				zap.Error(err),
+			)
+			return err
+		}
+	}
+
 	// 检查用户通知偏好
 	preferences, err := s.preferenceRepo.GetByUserID(notification.UserID)
 	if err != nil {
@@ -57,20 +113,39 @@ func (s *notificationService) SendNotification(notification *domain.Notification
 		return nil
 	}
 
+	// 邮件通知附带收件地址；发送邮件的会话通知附带回复地址，支持直接回复邮件
+	if preferences != nil && preferences.EmailEnabled {
+		s.attachEmailAddress(notification)
+		s.attachReplyAddress(notification)
+	}
+
 	// 保存通知到数据库
 	if err := s.notificationRepo.Create(notification); err != nil {
 		s.logger.Error("Failed to create notification", zap.Error(err))
 		return err
 	}
 
+	return s.deliver(notification, preferences)
+}
+
+// deliver 推送已保存的通知并更新状态，超出用户频率上限时按优先级延后或丢弃
+func (s *notificationService) deliver(notification *domain.Notification, preferences *domain.NotificationPreference) error {
 	// 发送推送通知
 	if preferences.PushEnabled {
+		if !s.admit(notification) {
+			return nil
+		}
+
 		pushNotification := &domain.PushNotification{
 			Title: notification.Title,
 			Body:  notification.Body,
 			Data:  notification.Data,
 			Sound: "default",
 		}
+		applyPushGrouping(pushNotification, notification.Type)
+		if notification.Type == domain.NotificationTypeMessage {
+			s.applyPreviewMode(notification.UserID, pushNotification, notification.Params, previewMode(preferences))
+		}
 
 		if err := s.pushService.SendToUser(notification.UserID, pushNotification); err != nil {
 			s.logger.Error("Failed to send push notification",
@@ -85,6 +160,9 @@ func (s *notificationService) SendNotification(notification *domain.Notification
 
 	// 更新状态为已发送
 	s.notificationRepo.UpdateStatus(notification.ID, domain.NotificationStatusSent)
+	if s.experiments != nil {
+		s.experiments.RecordEvent(notification, domain.ExperimentEventSent)
+	}
 
 	s.logger.Info("Notification sent successfully",
 		zap.String("notification_id", notification.ID),
@@ -95,7 +173,78 @@ func (s *notificationService) SendNotification(notification *domain.Notification
 	return nil
 }
 
+// admit 检查用户通知频率上限，返回false时通知已被延后或丢弃
+func (s *notificationService) admit(notification *domain.Notification) bool {
+	if s.limiter == nil {
+		return true
+	}
+
+	priority := notificationPriority(notification)
+	decision, retryAt := s.limiter.Admit(notification.UserID, priority)
+	if decision == LimitAllow {
+		return true
+	}
+
+	if decision == LimitDefer && s.jobRunner != nil {
+		err := s.jobRunner.EnqueueAt(context.Background(), jobDeliverDeferred, deferredPayload{NotificationID: notification.ID}, retryAt)
+		if err == nil {
+			s.logger.Info("Notification deferred by rate limit",
+				zap.String("notification_id", notification.ID),
+				zap.String("user_id", notification.UserID),
+				zap.String("priority", priority.String()),
+				zap.Time("retry_at", retryAt),
+			)
+			return false
+		}
+		s.logger.Error("Failed to defer notification", zap.String("notification_id", notification.ID), zap.Error(err))
+	}
+
+	s.notificationRepo.UpdateStatus(notification.ID, domain.NotificationStatusThrottled)
+	s.logger.Info("Notification push dropped by rate limit",
+		zap.String("notification_id", notification.ID),
+		zap.String("user_id", notification.UserID),
+		zap.String("priority", priority.String()),
+	)
+	return false
+}
+
+// deliverDeferred 投递延后的通知，已读或已处理的通知不再推送
+func (s *notificationService) deliverDeferred(ctx context.Context, payload json.RawMessage) error {
+	var p deferredPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+
+	notification, err := s.notificationRepo.GetByID(p.NotificationID)
+	if err != nil || notification.Status != domain.NotificationStatusPending {
+		return nil
+	}
+
+	preferences, err := s.preferenceRepo.GetByUserID(notification.UserID)
+	if err != nil {
+		return err
+	}
+	return s.deliver(notification, preferences)
+}
+
 func (s *notificationService) SendPushNotification(userID string, push *domain.PushNotification) error {
+	if s.recipients != nil && s.recipients.IsSuppressed(userID) {
+		s.logger.Info("Push notification suppressed for deactivated user", zap.String("user_id", userID))
+		return nil
+	}
+
+	notificationType, _ := push.Data["type"].(string)
+	applyPushGrouping(push, domain.NotificationType(notificationType))
+
+	// 消息推送按用户的锁屏预览设置隐藏内容
+	if domain.NotificationType(notificationType) == domain.NotificationTypeMessage {
+		preferences, err := s.preferenceRepo.GetByUserID(userID)
+		if err != nil {
+			s.logger.Error("Failed to get user preferences", zap.Error(err))
+		}
+		s.applyPreviewMode(userID, push, nil, previewMode(preferences))
+	}
+
 	return s.pushService.SendToUser(userID, push)
 }
 
@@ -104,19 +253,42 @@ func (s *notificationService) GetNotifications(userID string, limit, offset int)
 }
 
 func (s *notificationService) MarkAsRead(notificationID string) error {
-	return s.notificationRepo.MarkAsRead(notificationID)
+	if err := s.notificationRepo.MarkAsRead(notificationID); err != nil {
+		return err
+	}
+
+	// 实验中的通知记为已打开
+	if s.experiments != nil {
+		if notification, err := s.notificationRepo.GetByID(notificationID); err == nil {
+			s.experiments.RecordEvent(notification, domain.ExperimentEventOpened)
+		}
+	}
+	return nil
 }
 
 func (s *notificationService) GetUnreadCount(userID string) (int, error) {
 	return s.notificationRepo.GetUnreadCount(userID)
 }
 
-func (s *notificationService) RegisterDevice(userID, deviceToken, platform string) error {
+func (s *notificationService) RegisterDevice(userID, deviceToken, platform, deviceType string, categories []string) error {
+	if deviceType == "" {
+		deviceType = domain.DeviceTypePhone
+	}
+	if !domain.IsValidDeviceType(deviceType) {
+		return fmt.Errorf("invalid device type: %s", deviceType)
+	}
+	categories, err := normalizeCategories(categories)
+	if err != nil {
+		return err
+	}
+
 	// 检查设备是否已存在
 	existingDevice, err := s.deviceRepo.GetByDeviceToken(deviceToken)
 	if err == nil {
-		// 设备已存在，更新用户ID和激活状态
+		// 设备已存在，更新用户ID、激活状态和路由规则
 		existingDevice.UserID = userID
+		existingDevice.DeviceType = deviceType
+		existingDevice.Categories = categories
 		existingDevice.IsActive = true
 		existingDevice.UpdatedAt = time.Now()
 		return s.deviceRepo.Update(existingDevice)
@@ -127,6 +299,8 @@ func (s *notificationService) RegisterDevice(userID, deviceToken, platform strin
 		UserID:      userID,
 		DeviceToken: deviceToken,
 		Platform:    platform,
+		DeviceType:  deviceType,
+		Categories:  categories,
 		IsActive:    true,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
@@ -139,8 +313,38 @@ func (s *notificationService) UnregisterDevice(userID, deviceToken string) error
 	return s.deviceRepo.Delete(userID, deviceToken)
 }
 
+func (s *notificationService) GetDevices(userID string) ([]*domain.UserDevice, error) {
+	return s.deviceRepo.GetByUserID(userID)
+}
+
+// UpdateDeviceRouting 设置设备接收的通知类别，categories为空时恢复接收全部
+func (s *notificationService) UpdateDeviceRouting(userID, deviceToken string, categories []string) (*domain.UserDevice, error) {
+	categories, err := normalizeCategories(categories)
+	if err != nil {
+		return nil, err
+	}
+
+	device, err := s.deviceRepo.GetByDeviceToken(deviceToken)
+	if err != nil || device.UserID != userID {
+		return nil, fmt.Errorf("device not found")
+	}
+
+	updated := *device
+	updated.Categories = categories
+	if err := s.deviceRepo.Update(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
 func (s *notificationService) UpdatePreferences(userID string, preferences *domain.NotificationPreference) error {
 	preferences.UserID = userID
+	if preferences.PreviewMode == "" {
+		preferences.PreviewMode = domain.PreviewModeFull
+	}
+	if !preferences.PreviewMode.IsValid() {
+		return fmt.Errorf("invalid preview mode: %s", preferences.PreviewMode)
+	}
 	return s.preferenceRepo.Update(preferences)
 }
 
@@ -148,6 +352,80 @@ func (s *notificationService) GetPreferences(userID string) (*domain.Notificatio
 	return s.preferenceRepo.GetByUserID(userID)
 }
 
+func (s *notificationService) localize(notification *domain.Notification) error {
+	if s.localizer == nil {
+		return fmt.Errorf("notification templates are not configured")
+	}
+
+	// 模板有进行中的文案实验时按分配的变体渲染
+	var assignment *Assignment
+	if s.experiments != nil {
+		assignment = s.experiments.Assign(notification.UserID, notification.Template)
+	}
+
+	var title, body, locale string
+	var err error
+	if assignment != nil && assignment.templates != nil {
+		title, body, locale, err = s.localizer.RenderSet(notification.UserID, notification.Template, assignment.templates, notification.Params)
+	} else {
+		title, body, locale, err = s.localizer.Render(notification.UserID, notification.Template, notification.Params)
+	}
+	if err != nil {
+		return err
+	}
+
+	notification.Title = title
+	notification.Body = body
+	notification.Locale = locale
+
+	// 变体写入通知和推送数据，客户端上报转化时带回通知ID
+	if assignment != nil {
+		notification.ExperimentID = assignment.ExperimentID
+		notification.Variant = assignment.Variant
+		if notification.Data == nil {
+			notification.Data = make(map[string]interface{})
+		}
+		notification.Data["notification_id"] = notification.ID
+		notification.Data["experiment_id"] = assignment.ExperimentID
+		notification.Data["variant"] = assignment.Variant
+	}
+	return nil
+}
+
+// attachEmailAddress 写入发送通知邮件的收件地址，由用户服务决定使用通知邮箱还是登录邮箱
+func (s *notificationService) attachEmailAddress(notification *domain.Notification) {
+	if s.recipients == nil {
+		return
+	}
+	address := s.recipients.EmailAddress(notification.UserID)
+	if address == "" {
+		return
+	}
+	if notification.Data == nil {
+		notification.Data = make(map[string]interface{})
+	}
+	notification.Data["email_to"] = address
+}
+
+func (s *notificationService) attachReplyAddress(notification *domain.Notification) {
+	if s.inboundEmail == nil {
+		return
+	}
+	// 没有收件地址时不会发送邮件，也就不需要回复令牌
+	recipient, _ := notification.Data["email_to"].(string)
+	conversationID, ok := notification.Data["conversation_id"].(string)
+	if recipient == "" || !ok || conversationID == "" {
+		return
+	}
+
+	address, err := s.inboundEmail.ReplyAddress(notification.UserID, conversationID, recipient)
+	if err != nil {
+		s.logger.Debug("Reply address not generated", zap.Error(err))
+		return
+	}
+	notification.Data["reply_to"] = address
+}
+
 func (s *notificationService) shouldSendNotification(notification *domain.Notification, preferences *domain.NotificationPreference) bool {
 	if preferences == nil {
 		return true // 默认发送
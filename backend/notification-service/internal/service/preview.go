@@ -0,0 +1,85 @@
+package service
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+)
+
+// 推送数据中包含消息内容的字段，非完整预览模式下移除
+var previewContentKeys = []string{"preview", "content", "body"}
+
+// 推送数据中标识发送者的字段，通用模式下一并移除
+var previewSenderKeys = []string{"sender_name", "group_name"}
+
+// previewMode 获取用户的消息预览模式，未设置时完整显示
+func previewMode(preferences *domain.NotificationPreference) domain.PreviewMode {
+	if preferences == nil || !preferences.PreviewMode.IsValid() {
+		return domain.PreviewModeFull
+	}
+	return preferences.PreviewMode
+}
+
+// applyPreviewMode 按用户的预览模式改写消息推送的标题、正文和附带数据，
+// 通知记录本身保持完整，仅影响锁屏上可见的推送内容
+func (s *notificationService) applyPreviewMode(userID string, push *domain.PushNotification, params map[string]interface{}, mode domain.PreviewMode) {
+	if mode == domain.PreviewModeFull {
+		return
+	}
+
+	senderName := previewParam("sender_name", params, push.Data)
+	groupName := previewParam("group_name", params, push.Data)
+
+	// 缺少发送者信息时无法只显示发送者，退化为通用提示
+	if mode == domain.PreviewModeSenderOnly && senderName == "" {
+		mode = domain.PreviewModeGeneric
+	}
+
+	// 模板无法渲染时使用的默认内容
+	key := "message.generic"
+	title, body := "New message", ""
+	removed := append(append([]string(nil), previewContentKeys...), previewSenderKeys...)
+	if mode == domain.PreviewModeSenderOnly {
+		key = "message.sender_only"
+		if groupName != "" {
+			key = "message.group.sender_only"
+		}
+		title, body = senderName, "New message"
+		removed = previewContentKeys
+	}
+
+	if s.localizer != nil {
+		renderParams := map[string]interface{}{
+			"sender_name": senderName,
+			"group_name":  groupName,
+		}
+		renderedTitle, renderedBody, _, err := s.localizer.Render(userID, key, renderParams)
+		if err != nil {
+			s.logger.Warn("Failed to render push preview", zap.String("template", key), zap.Error(err))
+		} else {
+			title, body = renderedTitle, renderedBody
+		}
+	}
+	push.Title = title
+	push.Body = body
+
+	if push.Data != nil {
+		data := make(map[string]interface{}, len(push.Data))
+		for k, v := range push.Data {
+			data[k] = v
+		}
+		for _, k := range removed {
+			delete(data, k)
+		}
+		push.Data = data
+	}
+}
+
+// previewParam 从模板参数或推送数据中读取字符串字段
+func previewParam(name string, params, data map[string]interface{}) string {
+	if value, ok := params[name].(string); ok && value != "" {
+		return value
+	}
+	value, _ := data[name].(string)
+	return value
+}
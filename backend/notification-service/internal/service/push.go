@@ -1,66 +1,42 @@
 package service
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/neohope/chatapp/notification-service/config"
 	"github.com/neohope/chatapp/notification-service/internal/domain"
+	"github.com/neohope/chatapp/notification-service/internal/push"
 )
 
+// pushBatchTimeout 每批推送（含重试）的超时时间
+const pushBatchTimeout = 2 * time.Minute
+
 type pushService struct {
 	deviceRepo domain.UserDeviceRepository
-	config     *config.PushConfig
-	client     *http.Client
+	providers  map[string]push.Provider // 按设备平台选择推送服务商
+	batchSize  int
 	logger     *zap.Logger
 }
 
-type FCMMessage struct {
-	To              string                 `json:"to,omitempty"`
-	RegistrationIDs []string               `json:"registration_ids,omitempty"`
-	Notification    FCMNotification        `json:"notification"`
-	Data            map[string]interface{} `json:"data,omitempty"`
-	Priority        string                 `json:"priority"`
-}
-
-type FCMNotification struct {
-	Title string `json:"title"`
-	Body  string `json:"body"`
-	Sound string `json:"sound,omitempty"`
-	Badge int    `json:"badge,omitempty"`
-}
-
-type FCMResponse struct {
-	MulticastID  int64       `json:"multicast_id"`
-	Success      int         `json:"success"`
-	Failure      int         `json:"failure"`
-	CanonicalIDs int         `json:"canonical_ids"`
-	Results      []FCMResult `json:"results"`
-}
-
-type FCMResult struct {
-	MessageID      string `json:"message_id,omitempty"`
-	RegistrationID string `json:"registration_id,omitempty"`
-	Error          string `json:"error,omitempty"`
-}
-
 func NewPushService(
 	deviceRepo domain.UserDeviceRepository,
+	providers map[string]push.Provider,
 	config *config.PushConfig,
 	logger *zap.Logger,
 ) domain.PushService {
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
 	return &pushService{
 		deviceRepo: deviceRepo,
-		config:     config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		logger: logger,
+		providers:  providers,
+		batchSize:  batchSize,
+		logger:     logger,
 	}
 }
 
@@ -77,14 +53,13 @@ func (s *pushService) SendToDevice(deviceToken string, notification *domain.Push
 		return fmt.Errorf("device is inactive")
 	}
 
-	switch device.Platform {
-	case "android":
-		return s.sendFCM([]string{deviceToken}, notification)
-	case "ios":
-		return s.sendAPNS(deviceToken, notification)
-	default:
+	provider, ok := s.providers[device.Platform]
+	if !ok {
 		return fmt.Errorf("unsupported platform: %s", device.Platform)
 	}
+
+	results := s.send(provider, []*domain.UserDevice{device}, notification)
+	return results[0].Err
 }
 
 func (s *pushService) SendToUser(userID string, notification *domain.PushNotification) error {
@@ -100,35 +75,26 @@ func (s *pushService) SendToUser(userID string, notification *domain.PushNotific
 		return nil
 	}
 
-	// 按平台分组设备
-	androidTokens := make([]string, 0)
-	iosTokens := make([]string, 0)
-
+	// 按平台分组设备，跳过未订阅该类别的设备
+	category := routeCategory(notification)
+	byPlatform := make(map[string][]*domain.UserDevice)
 	for _, device := range devices {
-		if !device.IsActive {
+		if !device.IsActive || !device.Accepts(category) {
 			continue
 		}
-
-		switch device.Platform {
-		case "android":
-			androidTokens = append(androidTokens, device.DeviceToken)
-		case "ios":
-			iosTokens = append(iosTokens, device.DeviceToken)
-		}
-	}
-
-	// 发送到Android设备
-	if len(androidTokens) > 0 {
-		if err := s.sendFCM(androidTokens, notification); err != nil {
-			s.logger.Error("Failed to send FCM notification", zap.Error(err))
-		}
+		byPlatform[device.Platform] = append(byPlatform[device.Platform], device)
 	}
 
-	// 发送到iOS设备
-	for _, token := range iosTokens {
-		if err := s.sendAPNS(token, notification); err != nil {
-			s.logger.Error("Failed to send APNS notification", zap.String("token", token), zap.Error(err))
+	for platform, platformDevices := range byPlatform {
+		provider, ok := s.providers[platform]
+		if !ok {
+			s.logger.Warn("No push provider for platform",
+				zap.String("platform", platform),
+				zap.Int("devices", len(platformDevices)),
+			)
+			continue
 		}
+		s.send(provider, platformDevices, notification)
 	}
 
 	return nil
@@ -146,85 +112,61 @@ func (s *pushService) SendToMultipleUsers(userIDs []string, notification *domain
 	return nil
 }
 
-func (s *pushService) sendFCM(deviceTokens []string, notification *domain.PushNotification) error {
-	if s.config.FCMServerKey == "" {
-		s.logger.Warn("FCM server key not configured")
-		return fmt.Errorf("FCM server key not configured")
-	}
-
-	message := FCMMessage{
-		RegistrationIDs: deviceTokens,
-		Notification: FCMNotification{
-			Title: notification.Title,
-			Body:  notification.Body,
-			Sound: notification.Sound,
-			Badge: notification.Badge,
-		},
-		Data:     notification.Data,
-		Priority: "high",
-	}
-
-	jsonData, err := json.Marshal(message)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequest("POST", "https://fcm.googleapis.com/fcm/send", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "key="+s.config.FCMServerKey)
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("FCM request failed with status: %d", resp.StatusCode)
-	}
+// send 分批通过服务商发送，注销令牌已失效的设备
+func (s *pushService) send(provider push.Provider, devices []*domain.UserDevice, notification *domain.PushNotification) []push.Result {
+	results := make([]push.Result, 0, len(devices))
+	for start := 0; start < len(devices); start += s.batchSize {
+		end := start + s.batchSize
+		if end > len(devices) {
+			end = len(devices)
+		}
+		batch := devices[start:end]
+		tokens := make([]string, len(batch))
+		for i, device := range batch {
+			tokens[i] = device.DeviceToken
+		}
 
-	var fcmResponse FCMResponse
-	if err := json.NewDecoder(resp.Body).Decode(&fcmResponse); err != nil {
-		return err
-	}
+		ctx, cancel := context.WithTimeout(context.Background(), pushBatchTimeout)
+		batchResults := provider.Send(ctx, tokens, notification)
+		cancel()
 
-	// 处理失败的设备令牌
-	for i, result := range fcmResponse.Results {
-		if result.Error != "" {
-			s.logger.Warn("FCM delivery failed",
-				zap.String("device_token", deviceTokens[i]),
-				zap.String("error", result.Error),
+		failed := 0
+		for i, result := range batchResults {
+			if result.Err == nil {
+				continue
+			}
+			failed++
+			s.logger.Warn("Push delivery failed",
+				zap.String("provider", provider.Name()),
+				zap.String("device_token", result.Token),
+				zap.Error(result.Err),
 			)
-
-			// 如果是无效的令牌，停用设备
-			if result.Error == "NotRegistered" || result.Error == "InvalidRegistration" {
-				s.deviceRepo.DeactivateDevice(deviceTokens[i])
+			if result.Unregistered {
+				s.unregisterDevice(batch[i])
 			}
 		}
-	}
-
-	s.logger.Info("FCM notification sent",
-		zap.Int("success", fcmResponse.Success),
-		zap.Int("failure", fcmResponse.Failure),
-	)
-
-	return nil
+		s.logger.Info("Push batch sent",
+			zap.String("provider", provider.Name()),
+			zap.Int("success", len(batch)-failed),
+			zap.Int("failure", failed),
+		)
+		results = append(results, batchResults...)
+	}
+	return results
 }
 
-func (s *pushService) sendAPNS(deviceToken string, notification *domain.PushNotification) error {
-	// 简化的APNS实现
-	// 在实际项目中，应该使用官方的APNS库
-	s.logger.Info("APNS notification would be sent",
-		zap.String("device_token", deviceToken),
-		zap.String("title", notification.Title),
-		zap.String("body", notification.Body),
+// unregisterDevice 服务商报告令牌失效（应用卸载或令牌无效）时注销设备
+func (s *pushService) unregisterDevice(device *domain.UserDevice) {
+	if err := s.deviceRepo.Delete(device.UserID, device.DeviceToken); err != nil {
+		s.logger.Error("Failed to unregister invalid device",
+			zap.String("user_id", device.UserID),
+			zap.String("device_token", device.DeviceToken),
+			zap.Error(err),
+		)
+		return
+	}
+	s.logger.Info("Unregistered invalid device",
+		zap.String("user_id", device.UserID),
+		zap.String("device_token", device.DeviceToken),
 	)
-
-	// TODO: 实现真正的APNS推送
-	// 这里只是模拟成功
-	return nil
 }
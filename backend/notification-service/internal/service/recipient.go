@@ -0,0 +1,88 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/notification-service/internal/client"
+)
+
+// userStatusDeactivated 用户服务中主动停用账户的状态
+const userStatusDeactivated = "deactivated"
+
+type cachedStatus struct {
+	status    string
+	expiresAt time.Time
+}
+
+type cachedEmail struct {
+	address   string
+	expiresAt time.Time
+}
+
+// RecipientFilter 查询并缓存接收者账户状态和通知邮箱，停用账户不再接收通知
+type RecipientFilter struct {
+	userClient client.UserClient
+	ttl        time.Duration
+	logger     *zap.Logger
+
+	mu     sync.RWMutex
+	cache  map[string]cachedStatus
+	emails map[string]cachedEmail
+}
+
+func NewRecipientFilter(userClient client.UserClient, ttl time.Duration, logger *zap.Logger) *RecipientFilter {
+	return &RecipientFilter{
+		userClient: userClient,
+		ttl:        ttl,
+		logger:     logger,
+		cache:      make(map[string]cachedStatus),
+		emails:     make(map[string]cachedEmail),
+	}
+}
+
+// IsSuppressed 用户账户已停用时返回true；用户服务不可用时照常投递
+func (f *RecipientFilter) IsSuppressed(userID string) bool {
+	f.mu.RLock()
+	entry, ok := f.cache[userID]
+	f.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.status == userStatusDeactivated
+	}
+
+	status, err := f.userClient.GetStatus(userID)
+	if err != nil {
+		f.logger.Warn("Failed to get user status", zap.String("user_id", userID), zap.Error(err))
+		return false
+	}
+
+	f.mu.Lock()
+	f.cache[userID] = cachedStatus{status: status, expiresAt: time.Now().Add(f.ttl)}
+	f.mu.Unlock()
+
+	return status == userStatusDeactivated
+}
+
+// EmailAddress 返回发送通知邮件的地址，用户设置并验证了通知邮箱时不使用登录邮箱；查询失败时返回空
+func (f *RecipientFilter) EmailAddress(userID string) string {
+	f.mu.RLock()
+	entry, ok := f.emails[userID]
+	f.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.address
+	}
+
+	address, err := f.userClient.GetNotificationEmail(userID)
+	if err != nil {
+		f.logger.Warn("Failed to get notification email", zap.String("user_id", userID), zap.Error(err))
+		return ""
+	}
+
+	f.mu.Lock()
+	f.emails[userID] = cachedEmail{address: address, expiresAt: time.Now().Add(f.ttl)}
+	f.mu.Unlock()
+
+	return address
+}
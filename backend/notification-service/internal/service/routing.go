@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/neohope/chatapp/notification-service/internal/domain"
+)
+
+// routeCategory 根据推送的iOS类别确定设备路由类别，消息数据中mentioned为true时视为@提及
+func routeCategory(push *domain.PushNotification) string {
+	switch push.Category {
+	case CategoryMessage:
+		if mentioned, _ := push.Data["mentioned"].(bool); mentioned {
+			return domain.RouteCategoryMentions
+		}
+		return domain.RouteCategoryMessages
+	case CategoryGroupInvite:
+		return domain.RouteCategoryGroups
+	case CategoryFriendRequest:
+		return domain.RouteCategorySocial
+	default:
+		return domain.RouteCategorySystem
+	}
+}
+
+// normalizeCategories 校验并去重设备路由类别，为空表示接收全部
+func normalizeCategories(categories []string) ([]string, error) {
+	if len(categories) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(categories))
+	normalized := make([]string, 0, len(categories))
+	for _, category := range categories {
+		if !domain.IsValidRouteCategory(category) {
+			return nil, fmt.Errorf("invalid notification category: %s", category)
+		}
+		if seen[category] {
+			continue
+		}
+		seen[category] = true
+		normalized = append(normalized, category)
+	}
+	return normalized, nil
+}
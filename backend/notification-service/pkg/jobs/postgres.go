@@ -0,0 +1,121 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PostgresQueue 基于PostgreSQL的持久化任务队列，服务重启后未完成的任务继续执行
+// 多个服务共用 background_jobs 表，按queue列区分；领取任务使用 FOR UPDATE SKIP LOCKED，多实例互不阻塞
+type PostgresQueue struct {
+	db    *sql.DB
+	queue string
+}
+
+// NewPostgresQueue 创建持久化任务队列，表不存在时自动创建
+func NewPostgresQueue(db *sql.DB, queue string) (*PostgresQueue, error) {
+	schema := `
+		CREATE TABLE IF NOT EXISTS background_jobs (
+			queue VARCHAR(64) NOT NULL,
+			id VARCHAR(255) NOT NULL,
+			name VARCHAR(128) NOT NULL,
+			payload TEXT,
+			status VARCHAR(16) NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			max_attempts INTEGER NOT NULL DEFAULT 1,
+			run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			locked_until TIMESTAMP WITH TIME ZONE,
+			last_error TEXT,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (queue, id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_background_jobs_due ON background_jobs(queue, status, run_at);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		return nil, err
+	}
+	return &PostgresQueue{db: db, queue: queue}, nil
+}
+
+func (q *PostgresQueue) Enqueue(ctx context.Context, job *Job) error {
+	_, err := q.db.ExecContext(ctx, `
+		INSERT INTO background_jobs (queue, id, name, payload, max_attempts, run_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (queue, id) DO NOTHING`,
+		q.queue, job.ID, job.Name, string(job.Payload), job.MaxAttempts, job.RunAt,
+	)
+	return err
+}
+
+func (q *PostgresQueue) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Job, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		UPDATE background_jobs
+		SET status = $2, attempts = attempts + 1, locked_until = $4, updated_at = NOW()
+		WHERE queue = $1 AND id IN (
+			SELECT id FROM background_jobs
+			WHERE queue = $1
+			  AND ((status = $5 AND run_at <= $3) OR (status = $2 AND locked_until < $3))
+			ORDER BY run_at
+			LIMIT $6
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, name, COALESCE(payload, ''), attempts, max_attempts, run_at, COALESCE(last_error, '')`,
+		q.queue, StatusRunning, now, now.Add(lease), StatusPending, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claimed []*Job
+	for rows.Next() {
+		job := &Job{}
+		var payload string
+		if err := rows.Scan(&job.ID, &job.Name, &payload, &job.Attempts, &job.MaxAttempts, &job.RunAt, &job.LastError); err != nil {
+			return nil, err
+		}
+		if payload != "" {
+			job.Payload = []byte(payload)
+		}
+		claimed = append(claimed, job)
+	}
+	return claimed, rows.Err()
+}
+
+func (q *PostgresQueue) Complete(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE background_jobs SET status = $3, locked_until = NULL, updated_at = NOW()
+		WHERE queue = $1 AND id = $2`,
+		q.queue, id, StatusCompleted,
+	)
+	return err
+}
+
+func (q *PostgresQueue) Retry(ctx context.Context, id string, runAt time.Time, lastError string) error {
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE background_jobs SET status = $3, run_at = $4, last_error = $5, locked_until = NULL, updated_at = NOW()
+		WHERE queue = $1 AND id = $2`,
+		q.queue, id, StatusPending, runAt, lastError,
+	)
+	return err
+}
+
+func (q *PostgresQueue) Fail(ctx context.Context, id string, lastError string) error {
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE background_jobs SET status = $3, last_error = $4, locked_until = NULL, updated_at = NOW()
+		WHERE queue = $1 AND id = $2`,
+		q.queue, id, StatusFailed, lastError,
+	)
+	return err
+}
+
+func (q *PostgresQueue) Prune(ctx context.Context, before time.Time) error {
+	_, err := q.db.ExecContext(ctx, `
+		DELETE FROM background_jobs
+		WHERE queue = $1 AND status IN ($2, $3) AND updated_at < $4`,
+		q.queue, StatusCompleted, StatusFailed, before,
+	)
+	return err
+}
@@ -0,0 +1,449 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultWorkers      = 4
+	defaultPollInterval = time.Second
+	defaultLease        = 10 * time.Minute
+	defaultRetention    = 7 * 24 * time.Hour
+	defaultMaxAttempts  = 3
+	defaultBackoff      = 30 * time.Second
+	maxBackoff          = time.Hour
+)
+
+// Handler 任务处理函数，payload为入队时的JSON参数；返回错误时按重试策略重试
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Options 执行器配置，零值字段使用默认值
+type Options struct {
+	// Workers 同时执行的任务数
+	Workers int
+	// PollInterval 轮询队列的间隔
+	PollInterval time.Duration
+	// Lease 任务租约，执行时间超过租约的任务会被其他实例重新领取，应大于最长任务的执行时间
+	Lease time.Duration
+	// Retention 已完成和失败任务的保留时间
+	Retention time.Duration
+	// Leader 多实例部署时的主实例选举，设置后只有主实例触发定时任务；为nil时每个实例都触发
+	Leader Leader
+}
+
+// Leader 主实例判断，由pkg/lock的Elector实现
+type Leader interface {
+	IsLeader() bool
+}
+
+// Option 任务选项
+type Option func(*definition)
+
+// MaxAttempts 最大执行次数（含首次），默认3次
+func MaxAttempts(n int) Option {
+	return func(d *definition) {
+		if n > 0 {
+			d.maxAttempts = n
+		}
+	}
+}
+
+// Backoff 首次重试的等待时间，之后每次翻倍，最长1小时，默认30秒
+func Backoff(base time.Duration) Option {
+	return func(d *definition) {
+		if base > 0 {
+			d.backoff = base
+		}
+	}
+}
+
+// Timeout 单次执行的超时时间，默认不限制
+func Timeout(timeout time.Duration) Option {
+	return func(d *definition) {
+		d.timeout = timeout
+	}
+}
+
+// RunOnStart 定时任务在启动时立即执行一次
+func RunOnStart() Option {
+	return func(d *definition) {
+		d.runOnStart = true
+	}
+}
+
+// Local 本实例的定时任务，用于清理进程内状态等每个实例都需要执行的任务：
+// 触发时直接在本实例执行，不写入队列，也不受主实例选举限制，失败不重试
+func Local() Option {
+	return func(d *definition) {
+		d.local = true
+	}
+}
+
+// definition 已注册的任务
+type definition struct {
+	name        string
+	handler     Handler
+	maxAttempts int
+	backoff     time.Duration
+	timeout     time.Duration
+	runOnStart  bool
+	local       bool
+	schedule    Schedule
+}
+
+// Runner 后台任务执行器：按cron规则把定时任务写入队列，从队列领取任务执行，
+// 失败按指数退避重试，处理函数panic时记录错误而不影响进程，并按任务名统计执行情况
+type Runner struct {
+	queue  Queue
+	opts   Options
+	logger *zap.Logger
+
+	mu          sync.RWMutex
+	definitions map[string]*definition
+	metrics     map[string]*Metrics
+
+	wake chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewRunner 创建任务执行器
+func NewRunner(queue Queue, opts Options, logger *zap.Logger) *Runner {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = defaultLease
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = defaultRetention
+	}
+
+	return &Runner{
+		queue:       queue,
+		opts:        opts,
+		logger:      logger,
+		definitions: make(map[string]*definition),
+		metrics:     make(map[string]*Metrics),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Register 注册任务处理函数，需要在Start之前调用
+func (r *Runner) Register(name string, handler Handler, opts ...Option) {
+	def := &definition{
+		name:        name,
+		handler:     handler,
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(def)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.definitions[name] = def
+	if _, ok := r.metrics[name]; !ok {
+		r.metrics[name] = &Metrics{}
+	}
+}
+
+// Schedule 注册定时任务，spec格式见ParseSchedule。每次触发时写入队列，
+// 任务ID由任务名和触发时间组成，多个实例同时触发时只执行一次
+func (r *Runner) Schedule(name, spec string, handler Handler, opts ...Option) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+
+	r.Register(name, handler, opts...)
+	r.mu.Lock()
+	r.definitions[name].schedule = schedule
+	r.mu.Unlock()
+	return nil
+}
+
+// Enqueue 写入一个立即执行的任务，payload编码为JSON
+func (r *Runner) Enqueue(ctx context.Context, name string, payload interface{}) error {
+	return r.EnqueueAt(ctx, name, payload, time.Now())
+}
+
+// EnqueueAt 写入一个在指定时间之后执行的任务
+func (r *Runner) EnqueueAt(ctx context.Context, name string, payload interface{}, runAt time.Time) error {
+	def := r.definition(name)
+	if def == nil {
+		return fmt.Errorf("job %q is not registered", name)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload of job %q: %w", name, err)
+	}
+	if err := r.enqueue(ctx, def, uuid.New().String(), data, runAt); err != nil {
+		return err
+	}
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start 启动定时触发和任务执行，ctx取消后停止领取新任务，使用Wait等待执行中的任务结束
+func (r *Runner) Start(ctx context.Context) {
+	r.mu.RLock()
+	for _, def := range r.definitions {
+		if def.schedule != nil {
+			r.wg.Add(1)
+			go r.runSchedule(ctx, def)
+		}
+	}
+	r.mu.RUnlock()
+
+	r.wg.Add(2)
+	go r.poll(ctx)
+	go r.prune(ctx)
+
+	r.logger.Info("Background job runner started", zap.Int("workers", r.opts.Workers))
+}
+
+// Wait 等待所有后台协程退出
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// enqueue 写入队列
+func (r *Runner) enqueue(ctx context.Context, def *definition, id string, payload []byte, runAt time.Time) error {
+	return r.queue.Enqueue(ctx, &Job{
+		ID:          id,
+		Name:        def.name,
+		Payload:     payload,
+		MaxAttempts: def.maxAttempts,
+		RunAt:       runAt,
+	})
+}
+
+// runSchedule 按定时规则触发任务
+func (r *Runner) runSchedule(ctx context.Context, def *definition) {
+	defer r.wg.Done()
+
+	if def.runOnStart {
+		r.trigger(ctx, def, "start:"+time.Now().UTC().Truncate(time.Minute).Format(time.RFC3339), time.Now())
+	}
+
+	for {
+		next := def.schedule.Next(time.Now())
+		if next.IsZero() {
+			r.logger.Warn("Job schedule has no upcoming run", zap.String("job", def.name))
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		r.trigger(ctx, def, next.UTC().Format(time.RFC3339), next)
+	}
+}
+
+// trigger 写入一次定时触发的任务，不是主实例时跳过；本实例任务直接执行
+func (r *Runner) trigger(ctx context.Context, def *definition, key string, runAt time.Time) {
+	if def.local {
+		r.runLocal(ctx, def, def.name+"@"+key)
+		return
+	}
+	if r.opts.Leader != nil && !r.opts.Leader.IsLeader() {
+		r.logger.Debug("Not the leader, skipping scheduled job", zap.String("job", def.name))
+		return
+	}
+
+	if err := r.enqueue(ctx, def, def.name+"@"+key, nil, runAt); err != nil && ctx.Err() == nil {
+		r.logger.Error("Failed to enqueue scheduled job", zap.String("job", def.name), zap.Error(err))
+		return
+	}
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// poll 轮询领取到期任务，并发数不超过Workers
+func (r *Runner) poll(ctx context.Context) {
+	defer r.wg.Done()
+
+	slots := make(chan struct{}, r.opts.Workers)
+	ticker := time.NewTicker(r.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if free := cap(slots) - len(slots); free > 0 {
+			claimed, err := r.queue.Claim(ctx, time.Now(), r.opts.Lease, free)
+			if err != nil && ctx.Err() == nil {
+				r.logger.Warn("Failed to claim background jobs", zap.Error(err))
+			}
+			for _, job := range claimed {
+				slots <- struct{}{}
+				r.wg.Add(1)
+				go func(job *Job) {
+					defer func() {
+						<-slots
+						r.wg.Done()
+					}()
+					r.execute(ctx, job)
+				}(job)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+	}
+}
+
+// execute 执行一个任务并更新队列状态
+func (r *Runner) execute(ctx context.Context, job *Job) {
+	// 进程退出时上下文已取消，队列状态使用独立的上下文更新
+	updateCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	def := r.definition(job.Name)
+	if def == nil {
+		r.logger.Error("No handler registered for job", zap.String("job", job.Name), zap.String("id", job.ID))
+		r.queue.Fail(updateCtx, job.ID, "no handler registered") // nolint: errcheck
+		return
+	}
+
+	start := time.Now()
+	err := r.call(ctx, def, job)
+	r.record(job.Name, start, err)
+
+	switch {
+	case err == nil:
+		if err := r.queue.Complete(updateCtx, job.ID); err != nil {
+			r.logger.Error("Failed to mark job completed", zap.String("job", job.Name), zap.String("id", job.ID), zap.Error(err))
+		}
+	case ctx.Err() != nil:
+		// 因停止而中断的任务不计入失败，下次启动后重新执行
+		r.queue.Retry(updateCtx, job.ID, time.Now(), err.Error()) // nolint: errcheck
+	case job.Attempts < job.MaxAttempts:
+		delay := retryDelay(def.backoff, job.Attempts)
+		r.logger.Warn("Job failed, will retry",
+			zap.String("job", job.Name),
+			zap.String("id", job.ID),
+			zap.Int("attempt", job.Attempts),
+			zap.Duration("retry_in", delay),
+			zap.Error(err),
+		)
+		r.queue.Retry(updateCtx, job.ID, time.Now().Add(delay), err.Error()) // nolint: errcheck
+	default:
+		r.logger.Error("Job failed",
+			zap.String("job", job.Name),
+			zap.String("id", job.ID),
+			zap.Int("attempts", job.Attempts),
+			zap.Error(err),
+		)
+		r.queue.Fail(updateCtx, job.ID, err.Error()) // nolint: errcheck
+	}
+}
+
+// runLocal 在本实例直接执行一次本实例任务
+func (r *Runner) runLocal(ctx context.Context, def *definition, id string) {
+	job := &Job{ID: id, Name: def.name, Attempts: 1, MaxAttempts: 1}
+	start := time.Now()
+	err := r.call(ctx, def, job)
+	r.record(def.name, start, err)
+	if err != nil && ctx.Err() == nil {
+		r.logger.Error("Job failed", zap.String("job", def.name), zap.String("id", id), zap.Error(err))
+	}
+}
+
+// call 调用处理函数，panic转换为错误
+func (r *Runner) call(ctx context.Context, def *definition, job *Job) (err error) {
+	if def.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, def.timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			r.logger.Error("Job panicked",
+				zap.String("job", job.Name),
+				zap.String("id", job.ID),
+				zap.Any("panic", recovered),
+				zap.ByteString("stack", debug.Stack()),
+			)
+			err = &PanicError{Value: recovered}
+		}
+	}()
+
+	return def.handler(ctx, job.Payload)
+}
+
+// prune 定期清理过期的已完成和失败任务
+func (r *Runner) prune(ctx context.Context) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.queue.Prune(ctx, time.Now().Add(-r.opts.Retention)); err != nil && ctx.Err() == nil {
+			r.logger.Warn("Failed to prune finished jobs", zap.Error(err))
+		}
+	}
+}
+
+// definition 按任务名查找注册信息
+func (r *Runner) definition(name string) *definition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.definitions[name]
+}
+
+// retryDelay 第attempt次执行失败后的等待时间
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+// PanicError 处理函数panic时返回的错误
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("job panicked: %v", e.Value)
+}
@@ -0,0 +1,120 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrNotAcquired 锁已被其他实例持有
+	ErrNotAcquired = errors.New("lock is held by another instance")
+	// ErrLockLost 锁已过期或被其他实例取得
+	ErrLockLost = errors.New("lock is no longer held")
+)
+
+// acquireScript 锁不存在时写入持有者并递增栅栏令牌，返回令牌；锁已被持有时返回0
+// 栅栏令牌计数不过期，每次取得锁得到的令牌严格递增
+var acquireScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+  return redis.call('INCR', KEYS[2])
+end
+return 0
+`)
+
+// refreshScript 仍由自己持有时延长有效期
+var refreshScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript 仍由自己持有时删除锁，避免删除其他实例在过期后取得的锁
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Locker 基于Redis SET NX的分布式锁，锁的键为 <service>:lock:<name>
+type Locker struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewLocker 创建分布式锁，service用于区分不同服务的锁
+func NewLocker(client *redis.Client, service string) *Locker {
+	return &Locker{client: client, prefix: service + ":lock:"}
+}
+
+// Lock 已取得的锁
+type Lock struct {
+	locker *Locker
+	key    string
+	owner  string
+	token  int64
+}
+
+// Acquire 尝试取得锁，锁已被持有时返回ErrNotAcquired；ttl到期后锁自动释放
+func (l *Locker) Acquire(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	owner, err := newOwnerID()
+	if err != nil {
+		return nil, err
+	}
+
+	key := l.prefix + name
+	token, err := acquireScript.Run(ctx, l.client, []string{key, key + ":fence"}, owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	if token == 0 {
+		return nil, ErrNotAcquired
+	}
+	return &Lock{locker: l, key: key, owner: owner, token: token}, nil
+}
+
+// Token 栅栏令牌，后取得锁的持有者令牌更大；写入共享资源时带上令牌，
+// 资源拒绝比已见过的令牌更小的写入，可以排除锁过期后仍在执行的旧持有者
+func (l *Lock) Token() int64 {
+	return l.token
+}
+
+// Refresh 延长锁的有效期，锁已过期或被其他实例取得时返回ErrLockLost
+func (l *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	ok, err := refreshScript.Run(ctx, l.locker.client, []string{l.key}, l.owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to refresh lock %s: %w", l.key, err)
+	}
+	if ok == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// Release 释放锁，锁已过期或被其他实例取得时返回ErrLockLost
+func (l *Lock) Release(ctx context.Context) error {
+	ok, err := releaseScript.Run(ctx, l.locker.client, []string{l.key}, l.owner).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", l.key, err)
+	}
+	if ok == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// newOwnerID 生成锁持有者标识
+func newOwnerID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate lock owner: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -2,23 +2,38 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 
 	"github.com/neohope/chatapp/user-service/config"
+	"github.com/neohope/chatapp/user-service/internal/client"
+	grpcdelivery "github.com/neohope/chatapp/user-service/internal/delivery/grpc"
 	httpdelivery "github.com/neohope/chatapp/user-service/internal/delivery/http"
+	"github.com/neohope/chatapp/user-service/internal/domain"
 	"github.com/neohope/chatapp/user-service/internal/repository"
 	"github.com/neohope/chatapp/user-service/internal/service"
+	"github.com/neohope/chatapp/user-service/pkg/ai"
 	"github.com/neohope/chatapp/user-service/pkg/auth"
+	"github.com/neohope/chatapp/user-service/pkg/events"
+	"github.com/neohope/chatapp/user-service/pkg/jobs"
+	"github.com/neohope/chatapp/user-service/pkg/lock"
 	"github.com/neohope/chatapp/user-service/pkg/logger"
+	"github.com/neohope/chatapp/user-service/pkg/mail"
+	"github.com/neohope/chatapp/user-service/pkg/metrics"
+	"github.com/neohope/chatapp/user-service/pkg/openapi"
+	"github.com/neohope/chatapp/user-service/pkg/recovery"
+	"github.com/neohope/chatapp/user-service/pkg/shutdown"
+	"github.com/neohope/chatapp/user-service/pkg/sms"
+	"github.com/neohope/chatapp/user-service/pkg/tracing"
 )
 
 func main() {
@@ -35,6 +50,18 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// 链路追踪：从请求的traceparent继续网关开始的trace，按配置导出到OTLP或Jaeger
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Options{
+		Service:       "user-service",
+		Exporter:      cfg.Tracing.Exporter,
+		Endpoint:      cfg.Tracing.Endpoint,
+		Insecure:      cfg.Tracing.Insecure,
+		SamplePercent: cfg.Tracing.SamplePercent,
+	}, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+
 	// 初始化数据库连接
 	db, err := repository.NewPostgresDB(cfg.DB)
 	if err != nil {
@@ -45,25 +72,290 @@ func main() {
 	// 初始化仓库
 	userRepo := repository.NewUserRepository(db)
 	friendRepo := repository.NewFriendRepository(db)
+	consentRepo := repository.NewConsentRepository(db)
+	privacyRepo := repository.NewPrivacyRepository(db)
+	contactRepo := repository.NewContactRepository(db)
+	mentionRepo := repository.NewMentionRepository(db)
+	deactivationRepo := repository.NewDeactivationRepository(db)
+	ssoRepo := repository.NewSSORepository(db)
+	importRepo := repository.NewUserImportRepository(db)
+	notificationEmailRepo := repository.NewNotificationEmailRepository(db)
+	settingsRepo := repository.NewSettingsRepository(db)
+	loginIdentityRepo := repository.NewLoginIdentityRepository(db)
+	adminUserRepo := repository.NewAdminUserRepository(db)
+	interestRepo := repository.NewInterestRepository(db)
+	identityLinkRepo := repository.NewIdentityLinkRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	dataExportRepo := repository.NewDataExportRepository(db)
 
 	// 初始化JWT管理器
 	jwtManager := auth.NewJWTManager(cfg.JWT.SecretKey, cfg.JWT.ExpirationHours)
 
+	// 设置密码哈希参数
+	if err := auth.SetPasswordParams(auth.PasswordParams{
+		Version:     cfg.PasswordHash.Version,
+		Memory:      uint32(cfg.PasswordHash.MemoryKB),
+		Iterations:  uint32(cfg.PasswordHash.Iterations),
+		Parallelism: uint8(cfg.PasswordHash.Parallelism),
+		SaltLength:  uint32(cfg.PasswordHash.SaltLength),
+		KeyLength:   uint32(cfg.PasswordHash.KeyLength),
+	}); err != nil {
+		logger.Fatal("Invalid password hash configuration", zap.Error(err))
+	}
+
 	// 初始化服务
-	userService := service.NewUserService(userRepo, jwtManager, logger)
-	friendService := service.NewFriendService(friendRepo, userRepo, logger)
+	// 初始化目录认证（可选）
+	var directory *service.DirectoryLogin
+	if cfg.Auth.Provider == "ldap" {
+		directory = &service.DirectoryLogin{
+			Authenticator: auth.NewLDAPAuthenticator(auth.LDAPConfig{
+				URL:                cfg.Auth.LDAP.URL,
+				StartTLS:           cfg.Auth.LDAP.StartTLS,
+				InsecureSkipVerify: cfg.Auth.LDAP.InsecureSkipVerify,
+				BindDN:             cfg.Auth.LDAP.BindDN,
+				BindPassword:       cfg.Auth.LDAP.BindPassword,
+				BaseDN:             cfg.Auth.LDAP.BaseDN,
+				UserFilter:         cfg.Auth.LDAP.UserFilter,
+				UsernameAttribute:  cfg.Auth.LDAP.UsernameAttribute,
+				EmailAttribute:     cfg.Auth.LDAP.EmailAttribute,
+				FullNameAttribute:  cfg.Auth.LDAP.FullNameAttribute,
+				GroupAttribute:     cfg.Auth.LDAP.GroupAttribute,
+				Timeout:            time.Duration(cfg.Auth.LDAP.TimeoutSeconds) * time.Second,
+			}),
+			GroupRoles:      cfg.Auth.LDAP.GroupRoleMapping,
+			AllowLocalLogin: cfg.Auth.AllowLocalLogin,
+		}
+		logger.Info("LDAP authentication enabled", zap.String("url", cfg.Auth.LDAP.URL))
+	}
+
+	refreshTokenService := service.NewRefreshTokenService(refreshTokenRepo, userRepo, jwtManager, time.Duration(cfg.JWT.RefreshTTLDays)*24*time.Hour, logger)
+	userService := service.NewUserService(userRepo, deactivationRepo, loginIdentityRepo, directory, refreshTokenService, logger)
+	// 好友请求发布到事件总线，由通知服务通知接收者
+	var eventBus *events.Bus
+	var publisher events.Publisher = events.NopPublisher()
+	if cfg.EventBusURL != "" {
+		eventBus, err = events.Connect(cfg.EventBusURL, "user-service", logger)
+		if err != nil {
+			logger.Warn("Failed to connect to event bus, domain events disabled", zap.Error(err))
+		} else {
+			publisher = eventBus
+		}
+	}
+	friendService := service.NewFriendService(friendRepo, userRepo, publisher, logger)
+	consentService := service.NewConsentService(consentRepo, logger)
+	profileService := service.NewProfileService(userRepo, privacyRepo, friendService, logger)
+	contactService := service.NewContactService(contactRepo, friendService, logger)
+	// 初始化单点登录（SAML SP证书可选）
+	ssoConfig := service.SSOConfig{
+		BaseURL:  cfg.Auth.SSO.BaseURL,
+		StateTTL: time.Duration(cfg.Auth.SSO.StateTTLMinutes) * time.Minute,
+	}
+	if cfg.Auth.SSO.SPCertFile != "" && cfg.Auth.SSO.SPKeyFile != "" {
+		ssoConfig.Certificate, ssoConfig.Key, err = auth.LoadRSAKeyPair(cfg.Auth.SSO.SPCertFile, cfg.Auth.SSO.SPKeyFile)
+		if err != nil {
+			logger.Fatal("Failed to load SAML SP key pair", zap.Error(err))
+		}
+	}
+	ssoService := service.NewSSOService(ssoRepo, userRepo, refreshTokenService, ssoConfig, logger)
+	identityLinkService := service.NewIdentityLinkService(identityLinkRepo, userRepo, logger)
+	// 初始化邮件发送（未配置SMTP时只记录日志）
+	var mailSender mail.Mailer
+	if cfg.SMTP.Host != "" {
+		mailSender = mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+		})
+	} else {
+		mailSender = mail.NewLogMailer(logger)
+		logger.Warn("SMTP not configured, invitation and verification emails will only be logged")
+	}
+
+	// 服务重启会中断正在进行的导入任务
+	if count, err := importRepo.FailInterruptedJobs(context.Background()); err != nil {
+		logger.Warn("Failed to mark interrupted import jobs", zap.Error(err))
+	} else if count > 0 {
+		logger.Warn("Marked interrupted import jobs as failed", zap.Int("count", count))
+	}
+	importService := service.NewUserImportService(userRepo, importRepo, mailSender, refreshTokenService, service.UserImportConfig{
+		InvitationURL: cfg.UserImport.InvitationURL,
+		InvitationTTL: time.Duration(cfg.UserImport.InvitationTTLHours) * time.Hour,
+		MaxRows:       cfg.UserImport.MaxRows,
+	}, logger)
+	notificationEmailService := service.NewNotificationEmailService(notificationEmailRepo, userRepo, mailSender, service.NotificationEmailConfig{
+		VerificationURL: cfg.NotificationEmail.VerificationURL,
+		VerificationTTL: time.Duration(cfg.NotificationEmail.VerificationTTLHours) * time.Hour,
+	}, logger)
+	settingsService := service.NewSettingsService(settingsRepo, logger)
+	// 尚未接入短信网关，手机号验证码只写入日志
+	oauthVerifier := auth.NewOAuthVerifier(auth.OAuthConfig{
+		GoogleClientIDs: cfg.Auth.OAuth.GoogleClientIDs,
+		GitHubEnabled:   cfg.Auth.OAuth.GitHubEnabled,
+		GitHubAPIURL:    cfg.Auth.OAuth.GitHubAPIURL,
+	})
+	loginIdentityService := service.NewLoginIdentityService(loginIdentityRepo, userRepo, mailSender, sms.NewLogSender(logger), oauthVerifier, refreshTokenService, service.LoginIdentityConfig{
+		CodeTTL:     time.Duration(cfg.LoginIdentity.CodeTTLMinutes) * time.Minute,
+		MaxAttempts: cfg.LoginIdentity.MaxAttempts,
+	}, logger)
+	messageClient := client.NewMessageClient(cfg.MessageServiceURL)
+	accountService := service.NewAccountService(userRepo, deactivationRepo, refreshTokenService, messageClient, logger)
+	adminService := service.NewAdminService(adminUserRepo, userRepo, refreshTokenService, messageClient, logger)
+	mentionService := service.NewMentionService(mentionRepo, messageClient, logger)
+	// 初始化推荐（可选），向量存储或AI提供方不可用时推荐列表为空
+	var embeddingRepo domain.EmbeddingRepository
+	embedder, err := ai.NewEmbedder(ai.Config{
+		Provider: cfg.Recommendation.Provider,
+		BaseURL:  cfg.Recommendation.BaseURL,
+		APIKey:   cfg.Recommendation.APIKey,
+		Model:    cfg.Recommendation.EmbeddingModel,
+		Timeout:  time.Duration(cfg.Recommendation.TimeoutSeconds) * time.Second,
+	})
+	if err != nil {
+		logger.Warn("Invalid AI provider configuration, embedding recommendations disabled", zap.Error(err))
+		embedder = ai.NewNoopEmbedder()
+	}
+	if cfg.Recommendation.Mode == config.RecommendationModeEmbedding {
+		if embedder.Name() == ai.ProviderNoop {
+			logger.Warn("Embedding recommendations require an AI provider, set LLM_PROVIDER and EMBEDDING_MODEL")
+		} else if embeddingRepo, err = repository.NewEmbeddingRepository(db, cfg.Recommendation.EmbeddingDimensions); err != nil {
+			logger.Warn("Embedding store unavailable, recommendations disabled", zap.Error(err))
+		} else {
+			logger.Info("Embedding recommendations enabled",
+				zap.String("provider", embedder.Name()),
+				zap.String("model", embedder.Model()),
+			)
+		}
+	}
+	recommendationService := service.NewRecommendationService(interestRepo, embeddingRepo, embedder, service.RecommendationConfig{
+		EmbeddingEnabled: embeddingRepo != nil,
+		RefreshInterval:  time.Duration(cfg.Recommendation.RefreshIntervalMinutes) * time.Minute,
+		RefreshBatch:     cfg.Recommendation.RefreshBatch,
+	}, logger)
+
+	// 初始化后台任务
+	jobQueue, err := jobs.NewPostgresQueue(db.DB, "user-service")
+	if err != nil {
+		logger.Fatal("Failed to initialize job queue", zap.Error(err))
+	}
+	// 启用主实例选举时只有主实例触发定时任务
+	elector := initLeaderElector(cfg, logger)
+	jobOptions := jobs.Options{}
+	if elector != nil {
+		jobOptions.Leader = elector
+	}
+	jobRunner := jobs.NewRunner(jobQueue, jobOptions, logger)
+	if err := recommendationService.ScheduleJobs(jobRunner); err != nil {
+		logger.Fatal("Failed to schedule embedding refresh", zap.Error(err))
+	}
+	// 个人数据导出：通过消息服务和媒体服务汇总消息和文件清单，归档在后台任务中生成
+	dataExportService := service.NewDataExportService(dataExportRepo, userRepo, friendRepo, privacyRepo, consentRepo, settingsRepo,
+		messageClient, client.NewMediaClient(cfg.MediaServiceURL), jobRunner, service.DataExportConfig{
+			Retention:   time.Duration(cfg.DataExport.RetentionHours) * time.Hour,
+			MaxMessages: cfg.DataExport.MaxMessages,
+		}, logger)
+	if err := dataExportService.ScheduleJobs(jobRunner); err != nil {
+		logger.Fatal("Failed to schedule data export jobs", zap.Error(err))
+	}
+	err = jobRunner.Schedule("cleanup_refresh_tokens", "@hourly", func(ctx context.Context, _ json.RawMessage) error {
+		count, err := refreshTokenRepo.DeleteExpired(ctx)
+		if err == nil && count > 0 {
+			logger.Info("Deleted expired refresh tokens", zap.Int64("count", count))
+		}
+		return err
+	}, jobs.MaxAttempts(1))
+	if err != nil {
+		logger.Fatal("Failed to schedule refresh token cleanup", zap.Error(err))
+	}
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if elector != nil {
+		elector.Start(jobCtx)
+	}
+	jobRunner.Start(jobCtx)
 
 	// 初始化HTTP处理器
-	userHandler := httpdelivery.NewUserHandler(userService, friendService, jwtManager, logger)
+	userHandler := httpdelivery.NewUserHandler(userService, friendService, recommendationService, refreshTokenService, jwtManager, logger)
+	consentHandler := httpdelivery.NewConsentHandler(consentService, jwtManager, cfg.AdminUserIDs, logger)
+	profileHandler := httpdelivery.NewProfileHandler(profileService, logger)
+	contactHandler := httpdelivery.NewContactHandler(contactService, logger)
+	mentionHandler := httpdelivery.NewMentionHandler(mentionService, logger)
+	accountHandler := httpdelivery.NewAccountHandler(accountService, logger)
+	dataExportHandler := httpdelivery.NewDataExportHandler(dataExportService, logger)
+	ssoHandler := httpdelivery.NewSSOHandler(ssoService, cfg.Auth.SSO.SuccessRedirectURL, cfg.AdminUserIDs, logger)
+	identityLinkHandler := httpdelivery.NewIdentityLinkHandler(identityLinkService, cfg.AdminUserIDs, logger)
+	importHandler := httpdelivery.NewUserImportHandler(importService, cfg.AdminUserIDs, logger)
+	adminUserHandler := httpdelivery.NewAdminUserHandler(adminService, cfg.AdminUserIDs, logger)
+	notificationEmailHandler := httpdelivery.NewNotificationEmailHandler(notificationEmailService, logger)
+	settingsHandler := httpdelivery.NewSettingsHandler(settingsService, logger)
+	loginIdentityHandler := httpdelivery.NewLoginIdentityHandler(loginIdentityService, logger)
+	availabilityHandler := httpdelivery.NewAvailabilityHandler(userService, cfg.AvailabilityRateLimit, logger)
+	recommendationHandler := httpdelivery.NewRecommendationHandler(recommendationService, logger)
 
 	// 初始化路由
 	router := mux.NewRouter()
+	router.Use(consentHandler.RequireConsent)
+	consentHandler.RegisterRoutes(router, userHandler.AuthMiddleware)
+	profileHandler.RegisterRoutes(router, userHandler.AuthMiddleware)
+	contactHandler.RegisterRoutes(router, userHandler.AuthMiddleware)
+	mentionHandler.RegisterRoutes(router, userHandler.AuthMiddleware)
+	accountHandler.RegisterRoutes(router, userHandler.AuthMiddleware)
+	dataExportHandler.RegisterRoutes(router, userHandler.AuthMiddleware)
+	ssoHandler.RegisterRoutes(router, userHandler.AuthMiddleware)
+	identityLinkHandler.RegisterRoutes(router, userHandler.AuthMiddleware)
+	importHandler.RegisterRoutes(router, userHandler.AuthMiddleware)
+	adminUserHandler.RegisterRoutes(router, userHandler.AuthMiddleware)
+	notificationEmailHandler.RegisterRoutes(router, userHandler.AuthMiddleware)
+	settingsHandler.RegisterRoutes(router, userHandler.AuthMiddleware)
+	loginIdentityHandler.RegisterRoutes(router, userHandler.AuthMiddleware)
+	recommendationHandler.RegisterRoutes(router, userHandler.AuthMiddleware)
+	// 必须在 /api/v1/users/{id} 之前注册
+	availabilityHandler.RegisterRoutes(router)
 	userHandler.RegisterRoutes(router)
 
+	internalRouter := router.PathPrefix("/internal").Subrouter()
+	userHandler.RegisterInternalRoutes(internalRouter)
+	consentHandler.RegisterInternalRoutes(internalRouter)
+	internalRouter.HandleFunc("/jobs/metrics", jobRunner.MetricsHandler).Methods("GET")
+
+	// 接口文档：由注册的路由和接口说明生成，两者不一致时记录警告，严格模式下拒绝启动
+	apiDoc, problems := openapi.Build(router, "user-service", httpdelivery.APIOperations)
+	for _, problem := range problems {
+		logger.Warn("OpenAPI document does not match routes", zap.String("problem", problem))
+	}
+	if len(problems) > 0 && cfg.OpenAPI.Strict {
+		logger.Fatal("OpenAPI document does not match routes", zap.Int("problems", len(problems)))
+	}
+	router.HandleFunc("/openapi.json", openapi.Handler(apiDoc)).Methods("GET")
+
+	// 处理请求时的panic转换为带请求ID的500响应，配置了SENTRY_DSN时上报到Sentry
+	reporter, err := recovery.ReporterFromDSN(cfg.ErrorReporting.SentryDSN, cfg.ErrorReporting.Environment)
+	if err != nil {
+		logger.Fatal("Invalid error reporting configuration", zap.Error(err))
+	}
+	recoverer := recovery.New(recovery.Options{Service: "user-service", Reporter: reporter}, logger)
+
+	// Prometheus指标：按路由模板统计请求数和延迟，以及数据库连接池状态
+	var handler http.Handler = recoverer.Middleware(router)
+	if cfg.Metrics.Enabled {
+		serviceMetrics := metrics.New("user-service")
+		serviceMetrics.RegisterDB(db.DB, "postgres")
+		router.Handle("/metrics", serviceMetrics.Handler(cfg.Metrics.Token)).Methods("GET")
+		handler = serviceMetrics.Instrument(router, handler)
+	}
+	handler = tracing.Middleware("user-service", router, handler)
+
+	// 优雅关闭：排空期间健康检查返回503，负载均衡摘除本实例后再停止接收连接
+	coordinator := shutdown.New(shutdown.Options{
+		DrainDelay: time.Duration(cfg.Shutdown.DrainDelaySeconds) * time.Second,
+		Timeout:    time.Duration(cfg.Shutdown.TimeoutSeconds) * time.Second,
+	}, logger)
+
 	// 创建HTTP服务器
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.HTTPPort),
-		Handler:      router,
+		Handler:      coordinator.Middleware(handler),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 	}
@@ -76,19 +368,75 @@ func main() {
 		}
 	}()
 
-	// 优雅关闭
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	// 启动gRPC服务器，供内部服务直接调用
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort > 0 {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+		if err != nil {
+			logger.Fatal("Failed to listen on gRPC port", zap.Int("port", cfg.GRPCPort), zap.Error(err))
+		}
+		grpcServer = grpcdelivery.NewServer(grpcdelivery.NewUserServer(userService, logger), jwtManager, recoverer, logger)
+		go func() {
+			logger.Info("Starting gRPC server", zap.Int("port", cfg.GRPCPort))
+			if err := grpcServer.Serve(listener); err != nil {
+				logger.Fatal("Failed to start gRPC server", zap.Error(err))
+			}
+		}()
+	}
 
-	logger.Info("Shutting down server...")
+	// 关闭顺序：停止接收HTTP和gRPC请求并等待处理中的请求 -> 发送缓存的事件 -> 等待导入任务 -> 等待后台任务 -> 导出剩余的span
+	coordinator.Register("http", srv.Shutdown)
+	if grpcServer != nil {
+		coordinator.Register("grpc", func(ctx context.Context) error {
+			if err := shutdown.Await(ctx, grpcServer.GracefulStop); err != nil {
+				grpcServer.Stop()
+				return err
+			}
+			return nil
+		})
+	}
+	if eventBus != nil {
+		coordinator.Register("events", eventBus.Close)
+	}
+	coordinator.Register("imports", func(ctx context.Context) error {
+		return shutdown.Await(ctx, importService.Wait)
+	})
+	coordinator.Register("jobs", func(ctx context.Context) error {
+		stopJobs()
+		if elector != nil {
+			defer elector.Wait()
+		}
+		return shutdown.Await(ctx, jobRunner.Wait)
+	})
+	coordinator.Register("tracing", shutdownTracing)
+	coordinator.Wait()
+
+	logger.Info("Server exited properly")
+}
+
+// initLeaderElector 初始化定时任务的主实例选举，未启用或Redis连接失败时返回nil，此时每个实例都触发定时任务
+func initLeaderElector(cfg *config.Config, logger *zap.Logger) *lock.Elector {
+	if !cfg.LeaderElection.Enabled {
+		return nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal("Server forced to shutdown", zap.Error(err))
+	if err := client.Ping(ctx).Err(); err != nil {
+		logger.Warn("Failed to connect to Redis, leader election disabled", zap.Error(err))
+		client.Close()
+		return nil
 	}
 
-	logger.Info("Server exited properly")
+	logger.Info("Leader election enabled for scheduled jobs",
+		zap.String("redis_addr", cfg.Redis.Addr),
+		zap.Int("ttl_seconds", cfg.LeaderElection.TTLSeconds),
+	)
+	return lock.NewElector(lock.NewLocker(client, "user-service"), "jobs", time.Duration(cfg.LeaderElection.TTLSeconds)*time.Second, logger)
 }
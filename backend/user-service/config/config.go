@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +13,8 @@ import (
 type Config struct {
 	// 服务配置
 	HTTPPort int
+	// GRPCPort 供内部服务调用的gRPC端口，0表示不启动gRPC服务
+	GRPCPort int
 	LogLevel string
 
 	// 数据库配置
@@ -19,6 +22,214 @@ type Config struct {
 
 	// JWT配置
 	JWT JWTConfig
+
+	// 管理员用户ID列表
+	AdminUserIDs []string
+
+	// 依赖服务地址
+	MessageServiceURL string
+	MediaServiceURL   string
+
+	// NATS地址，为空时不发布领域事件
+	EventBusURL string
+
+	// 注册可用性检查每个IP每分钟允许的请求数，0表示不限流
+	AvailabilityRateLimit int
+
+	// 认证配置
+	Auth AuthConfig
+
+	// 密码哈希配置
+	PasswordHash PasswordHashConfig
+
+	// 邮件配置
+	SMTP SMTPConfig
+
+	// 用户批量导入配置
+	UserImport UserImportConfig
+
+	// 个人数据导出配置
+	DataExport DataExportConfig
+
+	// 通知邮箱验证配置
+	NotificationEmail NotificationEmailConfig
+
+	// 登录身份关联配置
+	LoginIdentity LoginIdentityConfig
+
+	// 推荐用户配置
+	Recommendation RecommendationConfig
+
+	// 优雅关闭配置
+	Shutdown ShutdownConfig
+
+	// 错误上报配置
+	ErrorReporting ErrorReportingConfig
+
+	// Prometheus指标配置
+	Metrics MetricsConfig
+
+	// Redis配置，用于定时任务主实例选举
+	Redis RedisConfig
+
+	// 定时任务主实例选举配置
+	LeaderElection LeaderElectionConfig
+
+	// 链路追踪配置
+	Tracing TracingConfig
+
+	// 接口文档配置
+	OpenAPI OpenAPIConfig
+}
+
+// 推荐模式
+const (
+	RecommendationModeNone      = "none"      // 不推荐
+	RecommendationModeEmbedding = "embedding" // 按简介/兴趣向量的近邻推荐，需要pgvector扩展
+)
+
+// RecommendationConfig 推荐用户配置，AI提供方与消息服务共用 LLM_* 配置
+type RecommendationConfig struct {
+	Mode                   string
+	Provider               string
+	BaseURL                string
+	APIKey                 string
+	TimeoutSeconds         int
+	EmbeddingModel         string
+	EmbeddingDimensions    int // 向量维度，须与模型一致，修改后需重建 user_embeddings 表
+	RefreshIntervalMinutes int // 后台补算过期向量的间隔
+	RefreshBatch           int
+}
+
+// ShutdownConfig 优雅关闭配置
+type ShutdownConfig struct {
+	DrainDelaySeconds int // 进入排空状态（健康检查返回503）后等待多久再停止接收连接
+	TimeoutSeconds    int // 关闭步骤的总超时时间
+}
+
+// ErrorReportingConfig 错误上报配置，SentryDSN为空时panic只记录日志
+type ErrorReportingConfig struct {
+	SentryDSN   string // Sentry项目DSN
+	Environment string // 上报事件的环境标识
+}
+
+// MetricsConfig Prometheus指标配置
+type MetricsConfig struct {
+	Enabled bool   // 是否提供/metrics端点
+	Token   string // 不为空时抓取请求需携带 Authorization: Bearer <Token>
+}
+
+// RedisConfig Redis配置
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// LeaderElectionConfig 定时任务主实例选举配置，启用后多个实例中只有持有Redis锁的实例触发定时任务
+type LeaderElectionConfig struct {
+	Enabled    bool
+	TTLSeconds int // 锁的有效期，主实例失联后最长经过这段时间由其他实例接替
+}
+
+// TracingConfig OpenTelemetry链路追踪配置
+type TracingConfig struct {
+	Exporter      string // none（只生成和传播traceparent）、otlp或jaeger
+	Endpoint      string // OTLP collector的gRPC地址或Jaeger collector的HTTP地址，为空时使用导出器默认值
+	Insecure      bool   // OTLP连接不使用TLS
+	SamplePercent int    // 新trace的采样百分比，带traceparent的请求沿用上游的采样决定
+}
+
+// OpenAPIConfig 接口文档配置
+type OpenAPIConfig struct {
+	Strict bool // 注册的路由与接口说明不一致时拒绝启动，默认只记录警告
+}
+
+// SMTPConfig 外发邮件配置，Host为空时只记录日志不发送
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// UserImportConfig 用户批量导入与邀请配置
+type UserImportConfig struct {
+	InvitationURL      string // 前端接受邀请页面地址
+	InvitationTTLHours int
+	MaxRows            int
+}
+
+// DataExportConfig 个人数据导出配置
+type DataExportConfig struct {
+	RetentionHours int // 导出文件保留多久，过期后删除
+	MaxMessages    int // 导出的消息数量上限，超出部分不导出
+}
+
+// NotificationEmailConfig 通知邮箱验证配置
+type NotificationEmailConfig struct {
+	VerificationURL      string // 前端验证通知邮箱页面地址
+	VerificationTTLHours int
+}
+
+// LoginIdentityConfig 关联邮箱/手机号登录身份的验证码配置
+type LoginIdentityConfig struct {
+	CodeTTLMinutes int
+	MaxAttempts    int
+}
+
+// PasswordHashConfig Argon2id密码哈希参数
+// 修改参数时需要同时递增Version，已有账户在下次登录时自动升级
+type PasswordHashConfig struct {
+	Version     int
+	MemoryKB    int
+	Iterations  int
+	Parallelism int
+	SaltLength  int
+	KeyLength   int
+}
+
+// AuthConfig 登录认证配置
+type AuthConfig struct {
+	Provider        string // local 或 ldap
+	AllowLocalLogin bool   // ldap模式下目录中不存在的用户是否可使用本地账户登录
+	LDAP            LDAPConfig
+	SSO             SSOConfig
+	OAuth           OAuthConfig
+}
+
+// OAuthConfig 个人用户关联和登录Google/GitHub账号的配置
+type OAuthConfig struct {
+	GoogleClientIDs []string // 允许的Google客户端ID，为空时不支持Google
+	GitHubEnabled   bool
+	GitHubAPIURL    string // GitHub企业版的API地址，为空时使用api.github.com
+}
+
+// SSOConfig 企业租户单点登录配置，IdP按租户保存在数据库中
+type SSOConfig struct {
+	BaseURL            string // 对外访问地址，用于生成回调URL和SAML实体ID
+	SuccessRedirectURL string // 登录完成后跳转的前端地址，为空时回调直接返回JSON
+	SPCertFile         string // SAML SP证书（PEM），可选
+	SPKeyFile          string // SAML SP私钥（PEM），可选
+	StateTTLMinutes    int
+}
+
+// LDAPConfig LDAP/AD配置
+type LDAPConfig struct {
+	URL                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+	BindDN             string
+	BindPassword       string
+	BaseDN             string
+	UserFilter         string
+	UsernameAttribute  string
+	EmailAttribute     string
+	FullNameAttribute  string
+	GroupAttribute     string
+	GroupRoleMapping   map[string]string // 组DN（小写） -> 角色
+	TimeoutSeconds     int
 }
 
 // DatabaseConfig 数据库配置
@@ -35,6 +246,7 @@ type DatabaseConfig struct {
 type JWTConfig struct {
 	SecretKey       string
 	ExpirationHours int
+	RefreshTTLDays  int // 刷新令牌有效期，每次刷新后重新计算
 }
 
 // LoadConfig 从环境变量加载配置
@@ -51,6 +263,11 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("invalid HTTP_PORT: %w", err)
 	}
 
+	grpcPort, err := strconv.Atoi(getEnv("GRPC_PORT", "9081"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid GRPC_PORT: %w", err)
+	}
+
 	// 数据库配置
 	dbPort, err := strconv.Atoi(getEnv("DB_PORT", "5432"))
 	if err != nil {
@@ -62,9 +279,163 @@ func LoadConfig() (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid JWT_EXPIRATION_HOURS: %w", err)
 	}
+	refreshTTLDays, err := strconv.Atoi(getEnv("REFRESH_TOKEN_TTL_DAYS", "30"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REFRESH_TOKEN_TTL_DAYS: %w", err)
+	}
+
+	// 认证配置
+	ldapTimeout, err := strconv.Atoi(getEnv("LDAP_TIMEOUT_SECONDS", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDAP_TIMEOUT_SECONDS: %w", err)
+	}
+	ssoStateTTL, err := strconv.Atoi(getEnv("SSO_STATE_TTL_MINUTES", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSO_STATE_TTL_MINUTES: %w", err)
+	}
+	groupRoleMapping, err := parseGroupRoleMapping(getEnv("LDAP_GROUP_ROLE_MAPPING", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	availabilityRateLimit, err := strconv.Atoi(getEnv("AVAILABILITY_RATE_LIMIT_PER_MINUTE", "20"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AVAILABILITY_RATE_LIMIT_PER_MINUTE: %w", err)
+	}
+
+	smtpPort, err := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP_PORT: %w", err)
+	}
+	invitationTTL, err := strconv.Atoi(getEnv("INVITATION_TTL_HOURS", "72"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid INVITATION_TTL_HOURS: %w", err)
+	}
+	importMaxRows, err := strconv.Atoi(getEnv("USER_IMPORT_MAX_ROWS", "5000"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid USER_IMPORT_MAX_ROWS: %w", err)
+	}
+	exportRetention, err := strconv.Atoi(getEnv("DATA_EXPORT_RETENTION_HOURS", "168"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DATA_EXPORT_RETENTION_HOURS: %w", err)
+	}
+	exportMaxMessages, err := strconv.Atoi(getEnv("DATA_EXPORT_MAX_MESSAGES", "100000"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DATA_EXPORT_MAX_MESSAGES: %w", err)
+	}
+	notificationEmailTTL, err := strconv.Atoi(getEnv("NOTIFICATION_EMAIL_VERIFICATION_TTL_HOURS", "24"))
+	if err != nil || notificationEmailTTL <= 0 {
+		return nil, fmt.Errorf("invalid NOTIFICATION_EMAIL_VERIFICATION_TTL_HOURS: %s", getEnv("NOTIFICATION_EMAIL_VERIFICATION_TTL_HOURS", "24"))
+	}
+
+	identityCodeTTL, err := strconv.Atoi(getEnv("IDENTITY_CODE_TTL_MINUTES", "10"))
+	if err != nil || identityCodeTTL <= 0 {
+		return nil, fmt.Errorf("invalid IDENTITY_CODE_TTL_MINUTES: %s", getEnv("IDENTITY_CODE_TTL_MINUTES", "10"))
+	}
+	identityCodeAttempts, err := strconv.Atoi(getEnv("IDENTITY_CODE_MAX_ATTEMPTS", "5"))
+	if err != nil || identityCodeAttempts <= 0 {
+		return nil, fmt.Errorf("invalid IDENTITY_CODE_MAX_ATTEMPTS: %s", getEnv("IDENTITY_CODE_MAX_ATTEMPTS", "5"))
+	}
+
+	metricsEnabled, err := strconv.ParseBool(getEnv("METRICS_ENABLED", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid METRICS_ENABLED: %w", err)
+	}
+
+	redisDB, err := strconv.Atoi(getEnv("REDIS_DB", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_DB: %w", err)
+	}
+	leaderElectionEnabled, err := strconv.ParseBool(getEnv("LEADER_ELECTION_ENABLED", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LEADER_ELECTION_ENABLED: %w", err)
+	}
+	leaderElectionTTL, err := strconv.Atoi(getEnv("LEADER_ELECTION_TTL_SECONDS", "15"))
+	if err != nil || leaderElectionTTL <= 0 {
+		return nil, fmt.Errorf("invalid LEADER_ELECTION_TTL_SECONDS: %s", getEnv("LEADER_ELECTION_TTL_SECONDS", "15"))
+	}
+	tracingInsecure, err := strconv.ParseBool(getEnv("TRACING_INSECURE", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRACING_INSECURE: %w", err)
+	}
+	tracingSamplePercent, err := strconv.Atoi(getEnv("TRACING_SAMPLE_PERCENT", "100"))
+	if err != nil || tracingSamplePercent < 0 || tracingSamplePercent > 100 {
+		return nil, fmt.Errorf("invalid TRACING_SAMPLE_PERCENT: %s", getEnv("TRACING_SAMPLE_PERCENT", "100"))
+	}
+	openAPIStrict, err := strconv.ParseBool(getEnv("OPENAPI_STRICT", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OPENAPI_STRICT: %w", err)
+	}
+
+	// 关闭配置
+	shutdown := ShutdownConfig{}
+	for _, item := range []struct {
+		key          string
+		defaultValue string
+		target       *int
+	}{
+		{"SHUTDOWN_DRAIN_DELAY_SECONDS", "5", &shutdown.DrainDelaySeconds},
+		{"SHUTDOWN_TIMEOUT_SECONDS", "30", &shutdown.TimeoutSeconds},
+	} {
+		value, err := strconv.Atoi(getEnv(item.key, item.defaultValue))
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid %s: %q", item.key, getEnv(item.key, item.defaultValue))
+		}
+		*item.target = value
+	}
+
+	// 推荐配置
+	recommendation := RecommendationConfig{
+		Mode:           getEnv("RECOMMENDATION_MODE", RecommendationModeNone),
+		Provider:       getEnv("LLM_PROVIDER", "noop"),
+		BaseURL:        getEnv("LLM_BASE_URL", ""),
+		APIKey:         getEnv("LLM_API_KEY", ""),
+		EmbeddingModel: getEnv("EMBEDDING_MODEL", ""),
+	}
+	for _, item := range []struct {
+		key          string
+		defaultValue string
+		target       *int
+	}{
+		{"LLM_TIMEOUT_SECONDS", "30", &recommendation.TimeoutSeconds},
+		{"EMBEDDING_DIMENSIONS", "1536", &recommendation.EmbeddingDimensions},
+		{"EMBEDDING_REFRESH_INTERVAL_MINUTES", "10", &recommendation.RefreshIntervalMinutes},
+		{"EMBEDDING_REFRESH_BATCH", "100", &recommendation.RefreshBatch},
+	} {
+		value, err := strconv.Atoi(getEnv(item.key, item.defaultValue))
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid %s: %q", item.key, getEnv(item.key, item.defaultValue))
+		}
+		*item.target = value
+	}
+	if recommendation.Mode != RecommendationModeNone && recommendation.Mode != RecommendationModeEmbedding {
+		return nil, fmt.Errorf("invalid RECOMMENDATION_MODE: %q", recommendation.Mode)
+	}
+
+	// 密码哈希配置
+	passwordHash := PasswordHashConfig{}
+	for _, item := range []struct {
+		key          string
+		defaultValue string
+		target       *int
+	}{
+		{"PASSWORD_HASH_VERSION", "2", &passwordHash.Version},
+		{"ARGON2_MEMORY_KB", "65536", &passwordHash.MemoryKB},
+		{"ARGON2_ITERATIONS", "3", &passwordHash.Iterations},
+		{"ARGON2_PARALLELISM", "2", &passwordHash.Parallelism},
+		{"ARGON2_SALT_LENGTH", "16", &passwordHash.SaltLength},
+		{"ARGON2_KEY_LENGTH", "32", &passwordHash.KeyLength},
+	} {
+		value, err := strconv.Atoi(getEnv(item.key, item.defaultValue))
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid %s: %q", item.key, getEnv(item.key, item.defaultValue))
+		}
+		*item.target = value
+	}
 
 	return &Config{
 		HTTPPort: httpPort,
+		GRPCPort: grpcPort,
 		LogLevel: getEnv("LOG_LEVEL", "info"),
 		DB: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -77,6 +448,96 @@ func LoadConfig() (*Config, error) {
 		JWT: JWTConfig{
 			SecretKey:       getEnv("JWT_SECRET_KEY", "your-secret-key"),
 			ExpirationHours: jwtExpiration,
+			RefreshTTLDays:  refreshTTLDays,
+		},
+		AdminUserIDs:          splitList(getEnv("ADMIN_USER_IDS", "")),
+		MessageServiceURL:     getEnv("MESSAGE_SERVICE_URL", "http://localhost:8082"),
+		MediaServiceURL:       getEnv("MEDIA_SERVICE_URL", "http://localhost:8084"),
+		EventBusURL:           getEnv("EVENT_BUS_URL", ""),
+		AvailabilityRateLimit: availabilityRateLimit,
+		Auth: AuthConfig{
+			Provider:        getEnv("AUTH_PROVIDER", "local"),
+			AllowLocalLogin: getEnv("LDAP_ALLOW_LOCAL_LOGIN", "true") == "true",
+			LDAP: LDAPConfig{
+				URL:                getEnv("LDAP_URL", "ldap://localhost:389"),
+				StartTLS:           getEnv("LDAP_START_TLS", "false") == "true",
+				InsecureSkipVerify: getEnv("LDAP_INSECURE_SKIP_VERIFY", "false") == "true",
+				BindDN:             getEnv("LDAP_BIND_DN", ""),
+				BindPassword:       getEnv("LDAP_BIND_PASSWORD", ""),
+				BaseDN:             getEnv("LDAP_BASE_DN", ""),
+				UserFilter:         getEnv("LDAP_USER_FILTER", ""),
+				UsernameAttribute:  getEnv("LDAP_USERNAME_ATTRIBUTE", "uid"),
+				EmailAttribute:     getEnv("LDAP_EMAIL_ATTRIBUTE", "mail"),
+				FullNameAttribute:  getEnv("LDAP_FULLNAME_ATTRIBUTE", "cn"),
+				GroupAttribute:     getEnv("LDAP_GROUP_ATTRIBUTE", "memberOf"),
+				GroupRoleMapping:   groupRoleMapping,
+				TimeoutSeconds:     ldapTimeout,
+			},
+			SSO: SSOConfig{
+				BaseURL:            getEnv("SSO_BASE_URL", "http://localhost:8080"),
+				SuccessRedirectURL: getEnv("SSO_SUCCESS_REDIRECT_URL", ""),
+				SPCertFile:         getEnv("SSO_SP_CERT_FILE", ""),
+				SPKeyFile:          getEnv("SSO_SP_KEY_FILE", ""),
+				StateTTLMinutes:    ssoStateTTL,
+			},
+			OAuth: OAuthConfig{
+				GoogleClientIDs: splitList(getEnv("GOOGLE_CLIENT_IDS", "")),
+				GitHubEnabled:   getEnv("GITHUB_OAUTH_ENABLED", "false") == "true",
+				GitHubAPIURL:    getEnv("GITHUB_API_URL", ""),
+			},
+		},
+		PasswordHash: passwordHash,
+		SMTP: SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     smtpPort,
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "ChatApp <no-reply@chatapp.local>"),
+		},
+		UserImport: UserImportConfig{
+			InvitationURL:      getEnv("INVITATION_URL", "http://localhost:3000/invitations/accept"),
+			InvitationTTLHours: invitationTTL,
+			MaxRows:            importMaxRows,
+		},
+		DataExport: DataExportConfig{
+			RetentionHours: exportRetention,
+			MaxMessages:    exportMaxMessages,
+		},
+		NotificationEmail: NotificationEmailConfig{
+			VerificationURL:      getEnv("NOTIFICATION_EMAIL_VERIFICATION_URL", "http://localhost:3000/notification-email/verify"),
+			VerificationTTLHours: notificationEmailTTL,
+		},
+		LoginIdentity: LoginIdentityConfig{
+			CodeTTLMinutes: identityCodeTTL,
+			MaxAttempts:    identityCodeAttempts,
+		},
+		Recommendation: recommendation,
+		Shutdown:       shutdown,
+		ErrorReporting: ErrorReportingConfig{
+			SentryDSN:   getEnv("SENTRY_DSN", ""),
+			Environment: getEnv("SENTRY_ENVIRONMENT", "production"),
+		},
+		Metrics: MetricsConfig{
+			Enabled: metricsEnabled,
+			Token:   getEnv("METRICS_TOKEN", ""),
+		},
+		Redis: RedisConfig{
+			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
+			Password: getEnv("REDIS_PASSWORD", ""),
+			DB:       redisDB,
+		},
+		LeaderElection: LeaderElectionConfig{
+			Enabled:    leaderElectionEnabled,
+			TTLSeconds: leaderElectionTTL,
+		},
+		Tracing: TracingConfig{
+			Exporter:      getEnv("TRACING_EXPORTER", "none"),
+			Endpoint:      getEnv("TRACING_ENDPOINT", ""),
+			Insecure:      tracingInsecure,
+			SamplePercent: tracingSamplePercent,
+		},
+		OpenAPI: OpenAPIConfig{
+			Strict: openAPIStrict,
 		},
 	}, nil
 }
@@ -88,4 +549,32 @@ func getEnv(key, defaultValue string) string {
 		return defaultValue
 	}
 	return value
-}
\ No newline at end of file
+}
+
+// splitList 解析逗号分隔的列表，忽略空项
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseGroupRoleMapping 解析 "组DN=角色;组DN=角色" 格式的映射，组DN中可包含逗号和等号
+func parseGroupRoleMapping(value string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		idx := strings.LastIndex(item, "=")
+		if idx <= 0 || idx == len(item)-1 {
+			return nil, fmt.Errorf("invalid LDAP_GROUP_ROLE_MAPPING entry: %q", item)
+		}
+		mapping[strings.ToLower(strings.TrimSpace(item[:idx]))] = strings.TrimSpace(item[idx+1:])
+	}
+	return mapping, nil
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/neohope/chatapp/user-service/pkg/tracing"
+)
+
+// MediaClient 媒体服务客户端
+type MediaClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewMediaClient 创建媒体服务客户端
+func NewMediaClient(baseURL string) *MediaClient {
+	return &MediaClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)},
+	}
+}
+
+// GetUserMedia 调用媒体服务内部接口分页获取用户上传的文件记录，返回当前页和总数
+func (c *MediaClient) GetUserMedia(ctx context.Context, userID string, limit, offset int) ([]json.RawMessage, int, error) {
+	endpoint := fmt.Sprintf("%s/internal/users/%s/media?limit=%d&offset=%d", c.baseURL, url.PathEscape(userID), limit, offset)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to call media service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("media service returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data struct {
+			Medias []json.RawMessage `json:"medias"`
+			Total  int               `json:"total"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode media service response: %w", err)
+	}
+	return result.Data.Medias, result.Data.Total, nil
+}
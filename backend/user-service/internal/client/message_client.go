@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/neohope/chatapp/user-service/pkg/tracing"
+)
+
+// MessageClient 消息服务客户端
+type MessageClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewMessageClient 创建消息服务客户端
+func NewMessageClient(baseURL string) *MessageClient {
+	return &MessageClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second, Transport: tracing.Transport(nil)},
+	}
+}
+
+// DisconnectUser 断开用户的WebSocket连接，使其显示为离线
+func (c *MessageClient) DisconnectUser(userID string) error {
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/internal/users/"+url.PathEscape(userID)+"/disconnect", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call message service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("message service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ErrConversationNotFound 消息服务中不存在该会话
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// GetConversationParticipants 获取会话成员ID列表，userID作为调用方身份传递给消息服务
+func (c *MessageClient) GetConversationParticipants(ctx context.Context, userID, conversationID string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/conversations/"+url.PathEscape(conversationID), nil)
+	if err != nil {
+		return nil, err
+	}
+	// 消息服务信任内部调用传递的用户ID
+	req.Header.Set("X-User-ID", userID)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call message service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrConversationNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("message service returned status %d", resp.StatusCode)
+	}
+
+	var conversation struct {
+		Participants []string `json:"participants"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&conversation); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation: %w", err)
+	}
+	return conversation.Participants, nil
+}
+
+// GetUserConversationIDs 分页获取用户参与的会话ID
+func (c *MessageClient) GetUserConversationIDs(ctx context.Context, userID string, limit, offset int) ([]string, error) {
+	var conversations []struct {
+		ID string `json:"id"`
+	}
+	endpoint := fmt.Sprintf("%s/api/v1/conversations?limit=%d&offset=%d", c.baseURL, limit, offset)
+	if err := c.getAsUser(ctx, userID, endpoint, &conversations); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(conversations))
+	for _, conversation := range conversations {
+		ids = append(ids, conversation.ID)
+	}
+	return ids, nil
+}
+
+// GetConversationMessages 分页获取会话消息，消息保持消息服务返回的原始JSON
+func (c *MessageClient) GetConversationMessages(ctx context.Context, userID, conversationID string, limit, offset int) ([]json.RawMessage, error) {
+	var messages []json.RawMessage
+	endpoint := fmt.Sprintf("%s/api/v1/conversations/%s/messages?limit=%d&offset=%d", c.baseURL, url.PathEscape(conversationID), limit, offset)
+	if err := c.getAsUser(ctx, userID, endpoint, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// getAsUser 以指定用户身份调用消息服务的GET接口并解码响应
+func (c *MessageClient) getAsUser(ctx context.Context, userID, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	// 消息服务信任内部调用传递的用户ID
+	req.Header.Set("X-User-ID", userID)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call message service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("message service returned status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode message service response: %w", err)
+	}
+	return nil
+}
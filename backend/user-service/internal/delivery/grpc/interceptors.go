@@ -0,0 +1,140 @@
+package grpcdelivery
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/neohope/chatapp/user-service/api/proto/userpb"
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/auth"
+	"github.com/neohope/chatapp/user-service/pkg/recovery"
+)
+
+// contextKey 上下文键类型，避免与其他包冲突
+type contextKey string
+
+const (
+	userIDKey   contextKey = "user_id"
+	usernameKey contextKey = "username"
+	emailKey    contextKey = "email"
+)
+
+// publicMethods 不需要认证的方法
+var publicMethods = map[string]bool{
+	userpb.UserService_Register_FullMethodName: true,
+	userpb.UserService_Login_FullMethodName:    true,
+}
+
+// UserIDFromContext 获取认证拦截器写入的用户ID
+func UserIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDKey).(string)
+	return userID
+}
+
+// AuthInterceptor JWT认证拦截器，令牌通过 authorization 元数据以 "Bearer {token}" 格式传入
+// 与HTTP接口一致，按数据库中的当前状态只允许活跃用户访问，令牌版本不一致视为已吊销
+func AuthInterceptor(jwtManager *auth.JWTManager, userService domain.UserService, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "authorization metadata is required")
+		}
+
+		parts := strings.Split(values[0], " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			return nil, status.Error(codes.Unauthenticated, "authorization metadata format must be Bearer {token}")
+		}
+
+		claims, err := jwtManager.ValidateToken(parts[1])
+		if err != nil {
+			logger.Info("Invalid token", zap.String("method", info.FullMethod), zap.Error(err))
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		}
+		user, err := userService.GetUserByID(ctx, claims.UserID)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		}
+		if user.Status != domain.UserStatusActive {
+			return nil, status.Error(codes.PermissionDenied, "account is not active")
+		}
+		if claims.TokenVersion != user.TokenVersion {
+			return nil, status.Error(codes.Unauthenticated, "token has been revoked")
+		}
+
+		ctx = context.WithValue(ctx, userIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, usernameKey, claims.Username)
+		ctx = context.WithValue(ctx, emailKey, claims.Email)
+		return handler(ctx, req)
+	}
+}
+
+// LoggingInterceptor 记录每次调用的方法、状态码和耗时
+func LoggingInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		fields := []zap.Field{
+			zap.String("method", info.FullMethod),
+			zap.String("code", status.Code(err).String()),
+			zap.Duration("duration", time.Since(start)),
+		}
+		if err != nil {
+			logger.Info("gRPC request failed", append(fields, zap.Error(err))...)
+		} else {
+			logger.Info("gRPC request", fields...)
+		}
+		return resp, err
+	}
+}
+
+// RecoveryInterceptor 将处理函数的panic转换为Internal错误，与HTTP接口一样记录堆栈并上报
+// 请求ID取自x-request-id元数据，没有时生成新的
+func RecoveryInterceptor(recoverer *recovery.Recoverer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		requestID := recovery.NewRequestID()
+		if values := md.Get(strings.ToLower(recovery.RequestIDHeader)); len(values) > 0 && values[0] != "" {
+			requestID = values[0]
+		}
+		ctx = recovery.WithRequestID(ctx, requestID)
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				recoverer.HandlePanic(ctx, &recovery.Event{
+					RequestID:   requestID,
+					Transaction: info.FullMethod,
+					Panic:       recovered,
+				})
+				err = status.Errorf(codes.Internal, "internal server error (request_id: %s)", requestID)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// NewServer 创建gRPC服务器并注册用户服务，拦截器按链路追踪、恢复、日志、认证的顺序执行
+func NewServer(userServer *UserServer, jwtManager *auth.JWTManager, recoverer *recovery.Recoverer, logger *zap.Logger) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		otelgrpc.UnaryServerInterceptor(),
+		RecoveryInterceptor(recoverer),
+		LoggingInterceptor(logger),
+		AuthInterceptor(jwtManager, userServer.userService, logger),
+	))
+	userpb.RegisterUserServiceServer(server, userServer)
+	return server
+}
@@ -0,0 +1,154 @@
+package grpcdelivery
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/neohope/chatapp/user-service/api/proto/userpb"
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/pagination"
+)
+
+// UserServer 用户服务gRPC接口实现，与HTTP接口共用服务层
+type UserServer struct {
+	userpb.UnimplementedUserServiceServer
+	userService domain.UserService
+	logger      *zap.Logger
+}
+
+// NewUserServer 创建用户服务gRPC接口
+func NewUserServer(userService domain.UserService, logger *zap.Logger) *UserServer {
+	return &UserServer{
+		userService: userService,
+		logger:      logger,
+	}
+}
+
+// Register 注册本地账户
+func (s *UserServer) Register(ctx context.Context, req *userpb.RegisterRequest) (*userpb.RegisterResponse, error) {
+	registerReq := domain.RegisterRequest{
+		Username: req.GetUsername(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+		FullName: req.GetFullName(),
+		Language: req.GetLanguage(),
+	}
+	if err := registerReq.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	user := &domain.User{
+		Username: registerReq.Username,
+		Email:    registerReq.Email,
+		FullName: registerReq.FullName,
+		Language: registerReq.Language,
+	}
+	if err := s.userService.Register(ctx, user, registerReq.Password); err != nil {
+		s.logger.Error("Failed to register user", zap.Error(err))
+		if strings.Contains(err.Error(), "already exists") {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &userpb.RegisterResponse{User: toProtoUser(user)}, nil
+}
+
+// Login 使用邮箱或用户名登录
+func (s *UserServer) Login(ctx context.Context, req *userpb.LoginRequest) (*userpb.LoginResponse, error) {
+	if req.GetIdentifier() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "username/email and password are required")
+	}
+
+	response, err := s.userService.Login(ctx, req.GetIdentifier(), req.GetPassword())
+	if err != nil {
+		s.logger.Info("Login failed", zap.String("identifier", req.GetIdentifier()), zap.Error(err))
+		if err.Error() == "account is deactivated" {
+			return nil, status.Error(codes.PermissionDenied, "account is deactivated")
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+
+	return &userpb.LoginResponse{Token: response.Token, User: toProtoUser(response.User)}, nil
+}
+
+// GetUser 按ID获取用户
+func (s *UserServer) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*userpb.GetUserResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user ID is required")
+	}
+
+	user, err := s.userService.GetUserByID(ctx, req.GetId())
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		s.logger.Error("Failed to get user", zap.String("id", req.GetId()), zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to retrieve user")
+	}
+
+	return &userpb.GetUserResponse{User: toProtoUser(user)}, nil
+}
+
+// SearchUsers 搜索活跃用户，分页参数与HTTP接口使用相同的默认值和上限
+func (s *UserServer) SearchUsers(ctx context.Context, req *userpb.SearchUsersRequest) (*userpb.SearchUsersResponse, error) {
+	query := strings.TrimSpace(req.GetQuery())
+	if query == "" {
+		return nil, status.Error(codes.InvalidArgument, "search term is required")
+	}
+	if req.GetLimit() < 0 || req.GetOffset() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit and offset must not be negative")
+	}
+
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = pagination.DefaultLimit
+	}
+	if limit > pagination.MaxLimit {
+		limit = pagination.MaxLimit
+	}
+
+	users, err := s.userService.SearchUsers(ctx, query, limit, int(req.GetOffset()))
+	if err != nil {
+		s.logger.Error("Failed to search users", zap.String("query", query), zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to search users")
+	}
+
+	resp := &userpb.SearchUsersResponse{Users: make([]*userpb.User, 0, len(users))}
+	for _, user := range users {
+		resp.Users = append(resp.Users, toProtoUser(user))
+	}
+	return resp, nil
+}
+
+// toProtoUser 转换为gRPC用户消息，不包含密码
+func toProtoUser(user *domain.User) *userpb.User {
+	return &userpb.User{
+		Id:         user.ID,
+		Username:   user.Username,
+		Email:      user.Email,
+		FullName:   user.FullName,
+		AvatarUrl:  user.AvatarURL,
+		Phone:      user.Phone,
+		Status:     string(user.Status),
+		Role:       user.Role,
+		Language:   user.Language,
+		LastSeenAt: toTimestamp(user.LastSeenAt),
+		CreatedAt:  toTimestamp(&user.CreatedAt),
+		UpdatedAt:  toTimestamp(&user.UpdatedAt),
+	}
+}
+
+// toTimestamp 空时间转换为nil
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,114 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// AccountHandler 处理账户停用/重新启用相关的HTTP请求
+type AccountHandler struct {
+	accountService domain.AccountService
+	logger         *zap.Logger
+}
+
+// NewAccountHandler 创建一个新的账户处理器
+func NewAccountHandler(accountService domain.AccountService, logger *zap.Logger) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *AccountHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	// 停用时递增令牌版本并吊销刷新令牌，已签发的令牌随即失效，重新启用只能通过账号密码认证
+	router.HandleFunc("/api/v1/users/reactivate", h.Reactivate).Methods("POST")
+	router.Handle("/api/v1/users/me/deactivate", authMiddleware(http.HandlerFunc(h.Deactivate))).Methods("POST")
+}
+
+// Deactivate 临时停用当前账户
+func (h *AccountHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	var req domain.DeactivateAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.Password == "" {
+		h.respondError(w, http.StatusBadRequest, "Password is required")
+		return
+	}
+
+	deactivation, err := h.accountService.Deactivate(r.Context(), userID, &req)
+	if err != nil {
+		h.logger.Info("Failed to deactivate account", zap.String("id", userID), zap.Error(err))
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "invalid password"):
+			h.respondError(w, http.StatusUnauthorized, msg)
+		case strings.Contains(msg, "not found"):
+			h.respondError(w, http.StatusNotFound, msg)
+		case strings.Contains(msg, "not active"):
+			h.respondError(w, http.StatusConflict, msg)
+		default:
+			h.respondError(w, http.StatusInternalServerError, msg)
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, deactivation)
+}
+
+// Reactivate 重新启用已停用的账户
+func (h *AccountHandler) Reactivate(w http.ResponseWriter, r *http.Request) {
+	var req domain.ReactivateAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.Identifier == "" || req.Password == "" {
+		h.respondError(w, http.StatusBadRequest, "Username/email and password are required")
+		return
+	}
+
+	response, err := h.accountService.Reactivate(r.Context(), req.Identifier, req.Password)
+	if err != nil {
+		h.logger.Info("Failed to reactivate account", zap.String("identifier", req.Identifier), zap.Error(err))
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "invalid credentials"):
+			h.respondError(w, http.StatusUnauthorized, "Invalid credentials")
+		case strings.Contains(msg, "not deactivated"):
+			h.respondError(w, http.StatusConflict, msg)
+		default:
+			h.respondError(w, http.StatusInternalServerError, msg)
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// respondJSON 发送JSON响应
+func (h *AccountHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// respondError 发送错误响应
+func (h *AccountHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,194 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/pagination"
+	"github.com/neohope/chatapp/user-service/pkg/validation"
+)
+
+// isAdmin 检查当前用户是否为管理员：认证中间件按数据库写入的当前角色为admin，或在ADMIN_USER_IDS中（用于指定第一个管理员）
+func isAdmin(r *http.Request, adminUserIDs map[string]bool) bool {
+	if role, _ := r.Context().Value(roleKey).(string); role == domain.RoleAdmin {
+		return true
+	}
+	userID, _ := r.Context().Value(userIDKey).(string)
+	return adminUserIDs[userID]
+}
+
+// AdminUserHandler 处理管理员用户管理相关的HTTP请求
+type AdminUserHandler struct {
+	adminService domain.AdminService
+	adminUserIDs map[string]bool
+	logger       *zap.Logger
+}
+
+// NewAdminUserHandler 创建一个新的管理员用户管理处理器
+func NewAdminUserHandler(adminService domain.AdminService, adminUserIDs []string, logger *zap.Logger) *AdminUserHandler {
+	admins := make(map[string]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = true
+	}
+
+	return &AdminUserHandler{
+		adminService: adminService,
+		adminUserIDs: admins,
+		logger:       logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *AdminUserHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/users/admin/users", authMiddleware(h.adminOnly(h.ListUsers))).Methods("GET")
+	router.Handle("/api/v1/users/admin/users/{id}/suspend", authMiddleware(h.adminOnly(h.SuspendUser))).Methods("POST")
+	router.Handle("/api/v1/users/admin/users/{id}/reactivate", authMiddleware(h.adminOnly(h.ReactivateUser))).Methods("POST")
+	router.Handle("/api/v1/users/admin/users/{id}/role", authMiddleware(h.adminOnly(h.SetRole))).Methods("PUT")
+	router.Handle("/api/v1/users/admin/users/{id}", authMiddleware(h.adminOnly(h.DeleteUser))).Methods("DELETE")
+}
+
+// ListUsers 按状态、角色和关键字列出用户
+func (h *AdminUserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.Parse(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	filter := domain.AdminUserFilter{
+		Status: domain.UserStatus(query.Get("status")),
+		Role:   query.Get("role"),
+		Query:  query.Get("q"),
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+
+	result, err := h.adminService.ListUsers(r.Context(), filter)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+// SuspendUser 暂停账户
+func (h *AdminUserHandler) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(userIDKey).(string)
+
+	var req domain.SuspendUserRequest
+	// 请求体可以为空
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	user, err := h.adminService.SuspendUser(r.Context(), adminID, mux.Vars(r)["id"], &req)
+	if err != nil {
+		h.respondAdminError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, user)
+}
+
+// ReactivateUser 恢复被暂停的账户
+func (h *AdminUserHandler) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(userIDKey).(string)
+
+	user, err := h.adminService.ReactivateUser(r.Context(), adminID, mux.Vars(r)["id"])
+	if err != nil {
+		h.respondAdminError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, user)
+}
+
+// DeleteUser 删除账户
+func (h *AdminUserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(userIDKey).(string)
+
+	if err := h.adminService.DeleteUser(r.Context(), adminID, mux.Vars(r)["id"]); err != nil {
+		h.respondAdminError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetRole 修改用户角色
+func (h *AdminUserHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(userIDKey).(string)
+
+	var req domain.SetUserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	user, err := h.adminService.SetRole(r.Context(), adminID, mux.Vars(r)["id"], req.Role)
+	if err != nil {
+		h.respondAdminError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, user)
+}
+
+// respondAdminError 根据管理操作的错误类型返回对应的状态码
+func (h *AdminUserHandler) respondAdminError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		h.respondError(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "cannot"), strings.Contains(msg, "already"), strings.Contains(msg, "not suspended"):
+		h.respondError(w, http.StatusConflict, msg)
+	case strings.Contains(msg, "invalid"):
+		h.respondError(w, http.StatusBadRequest, msg)
+	default:
+		h.respondError(w, http.StatusInternalServerError, msg)
+	}
+}
+
+// adminOnly 仅允许管理员访问
+func (h *AdminUserHandler) adminOnly(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(r, h.adminUserIDs) {
+			h.respondError(w, http.StatusForbidden, "Admin access required")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// respondJSON 发送JSON响应
+func (h *AdminUserHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// respondError 发送错误响应
+func (h *AdminUserHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,157 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// availabilityWindow 可用性检查限流的时间窗口
+const availabilityWindow = time.Minute
+
+// AvailabilityHandler 处理注册表单的用户名/邮箱可用性检查
+type AvailabilityHandler struct {
+	userService domain.UserService
+	limiter     *ipRateLimiter
+	logger      *zap.Logger
+}
+
+// NewAvailabilityHandler 创建一个新的可用性检查处理器，limit为每个IP每分钟允许的请求数
+func NewAvailabilityHandler(userService domain.UserService, limit int, logger *zap.Logger) *AvailabilityHandler {
+	return &AvailabilityHandler{
+		userService: userService,
+		limiter:     newIPRateLimiter(limit, availabilityWindow),
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes 注册路由，注册前调用，无需认证
+func (h *AvailabilityHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/users/availability", h.CheckAvailability).Methods("GET")
+}
+
+// CheckAvailability 检查用户名和邮箱是否可注册
+func (h *AvailabilityHandler) CheckAvailability(w http.ResponseWriter, r *http.Request) {
+	ip := rateLimitIP(r)
+	if allowed, retryAfter := h.limiter.Allow(ip); !allowed {
+		h.logger.Warn("Availability check rate limited", zap.String("client_ip", ip))
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		h.respondError(w, http.StatusTooManyRequests, "Too many availability checks, please try again later")
+		return
+	}
+
+	username := r.URL.Query().Get("username")
+	email := r.URL.Query().Get("email")
+	if strings.TrimSpace(username) == "" && strings.TrimSpace(email) == "" {
+		h.respondError(w, http.StatusBadRequest, "username or email is required")
+		return
+	}
+
+	result, err := h.userService.CheckAvailability(r.Context(), username, email)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// 结果随时可能变化，不允许缓存
+	w.Header().Set("Cache-Control", "no-store")
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+func (h *AvailabilityHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+func (h *AvailabilityHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
+
+// ipRateLimiter 按客户端IP的固定窗口限流器
+type ipRateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	clients   map[string]*ipWindow
+	lastSweep time.Time
+}
+
+// ipWindow 单个IP在当前窗口内的请求计数
+type ipWindow struct {
+	start time.Time
+	count int
+}
+
+func newIPRateLimiter(limit int, window time.Duration) *ipRateLimiter {
+	return &ipRateLimiter{
+		limit:     limit,
+		window:    window,
+		clients:   make(map[string]*ipWindow),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow 判断请求是否允许，拒绝时返回距离窗口重置的时间；limit<=0表示不限流
+func (l *ipRateLimiter) Allow(ip string) (bool, time.Duration) {
+	if l.limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	// 定期清理过期窗口，防止大量不同IP撑大内存
+	if now.Sub(l.lastSweep) > l.window {
+		for key, w := range l.clients {
+			if now.Sub(w.start) >= l.window {
+				delete(l.clients, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	w, ok := l.clients[ip]
+	if !ok || now.Sub(w.start) >= l.window {
+		l.clients[ip] = &ipWindow{start: now, count: 1}
+		return true, 0
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// rateLimitIP 获取用于限流的客户端IP；与clientIP不同，这里取网关追加在X-Forwarded-For末尾的地址，
+// 该地址由网关根据连接写入，客户端无法通过伪造请求头绕过限流
+func rateLimitIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		parts := strings.Split(forwarded, ",")
+		if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+			return ip
+		}
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
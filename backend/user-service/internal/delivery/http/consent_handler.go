@@ -0,0 +1,260 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/auth"
+)
+
+// consentExemptPaths 未同意最新协议时仍可访问的路径
+var consentExemptPaths = []string{
+	"/api/v1/users/register",
+	"/api/v1/users/login",
+	"/api/v1/users/login/oauth",
+	"/api/v1/users/me",
+	"/api/v1/users/me/consents",
+	"/api/v1/users/policies",
+	"/api/v1/users/admin/policies",
+}
+
+// ConsentHandler 处理服务条款/隐私政策同意相关的HTTP请求
+type ConsentHandler struct {
+	consentService domain.ConsentService
+	jwtManager     *auth.JWTManager
+	adminUserIDs   map[string]bool
+	logger         *zap.Logger
+}
+
+// NewConsentHandler 创建一个新的协议同意处理器
+func NewConsentHandler(consentService domain.ConsentService, jwtManager *auth.JWTManager, adminUserIDs []string, logger *zap.Logger) *ConsentHandler {
+	admins := make(map[string]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = true
+	}
+
+	return &ConsentHandler{
+		consentService: consentService,
+		jwtManager:     jwtManager,
+		adminUserIDs:   admins,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *ConsentHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/users/policies", authMiddleware(http.HandlerFunc(h.GetLatestPolicies))).Methods("GET")
+	router.Handle("/api/v1/users/me/consents", authMiddleware(http.HandlerFunc(h.GetConsentStatus))).Methods("GET")
+	router.Handle("/api/v1/users/me/consents", authMiddleware(http.HandlerFunc(h.AcceptConsents))).Methods("POST")
+
+	// 管理员路由
+	router.Handle("/api/v1/users/admin/policies", authMiddleware(h.adminOnly(h.ListPolicyVersions))).Methods("GET")
+	router.Handle("/api/v1/users/admin/policies", authMiddleware(h.adminOnly(h.PublishPolicyVersion))).Methods("POST")
+}
+
+// RequireConsent 发布新的强制协议版本后，拦截未同意用户的API请求（同意相关接口除外）
+func (h *ConsentHandler) RequireConsent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/v1/") || isConsentExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// 未携带有效令牌的请求交给认证中间件处理
+		parts := strings.Split(r.Header.Get("Authorization"), " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		claims, err := h.jwtManager.ValidateToken(parts[1])
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		pending, err := h.consentService.HasPendingConsents(r.Context(), claims.UserID)
+		if err != nil {
+			// 无法确认是否已同意时拒绝请求，不能因查询故障绕过强制协议
+			h.logger.Warn("Failed to check consent status", zap.String("user_id", claims.UserID), zap.Error(err))
+			h.respondError(w, http.StatusServiceUnavailable, "Consent status unavailable")
+			return
+		}
+		if pending {
+			h.respondJSON(w, http.StatusForbidden, map[string]string{
+				"error": "You must accept the latest terms before continuing",
+				"code":  "consent_required",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RegisterInternalRoutes 注册供API网关调用的内部路由，router为/internal前缀的子路由
+func (h *ConsentHandler) RegisterInternalRoutes(router *mux.Router) {
+	router.HandleFunc("/users/{id}/consents/pending", h.GetPendingConsents).Methods("GET")
+}
+
+// GetPendingConsents 返回用户是否有尚未同意的强制协议版本，网关据此拦截其他服务的请求
+func (h *ConsentHandler) GetPendingConsents(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+
+	pending, err := h.consentService.HasPendingConsents(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to check consent status", zap.String("user_id", userID), zap.Error(err))
+		h.respondError(w, http.StatusInternalServerError, "Failed to check consent status")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]bool{"pending": pending})
+}
+
+// GetLatestPolicies 获取各类协议的最新版本
+func (h *ConsentHandler) GetLatestPolicies(w http.ResponseWriter, r *http.Request) {
+	versions, err := h.consentService.GetLatestVersions(r.Context())
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, versions)
+}
+
+// GetConsentStatus 获取当前用户的协议同意状态
+func (h *ConsentHandler) GetConsentStatus(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	status, err := h.consentService.GetConsentStatus(r.Context(), userID)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, status)
+}
+
+// AcceptConsents 同意协议版本
+func (h *ConsentHandler) AcceptConsents(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	var req domain.AcceptConsentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	status, err := h.consentService.AcceptVersions(r.Context(), userID, &req, clientIP(r), r.UserAgent())
+	if err != nil {
+		h.logger.Info("Failed to accept consents", zap.String("user_id", userID), zap.Error(err))
+		h.respondConsentError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, status)
+}
+
+// ListPolicyVersions 获取某类协议的全部版本（管理员）
+func (h *ConsentHandler) ListPolicyVersions(w http.ResponseWriter, r *http.Request) {
+	policyType := domain.PolicyType(r.URL.Query().Get("policy_type"))
+
+	versions, err := h.consentService.ListVersions(r.Context(), policyType)
+	if err != nil {
+		h.respondConsentError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, versions)
+}
+
+// PublishPolicyVersion 发布新的协议版本（管理员）
+func (h *ConsentHandler) PublishPolicyVersion(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(userIDKey).(string)
+
+	var req domain.PublishPolicyVersionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	version, err := h.consentService.PublishVersion(r.Context(), adminID, &req)
+	if err != nil {
+		h.logger.Info("Failed to publish policy version", zap.String("admin_id", adminID), zap.Error(err))
+		h.respondConsentError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, version)
+}
+
+// adminOnly 仅允许管理员访问
+func (h *ConsentHandler) adminOnly(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(r, h.adminUserIDs) {
+			h.respondError(w, http.StatusForbidden, "Admin access required")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// respondConsentError 根据错误信息写入对应状态码
+func (h *ConsentHandler) respondConsentError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		h.respondError(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "already exists"):
+		h.respondError(w, http.StatusConflict, msg)
+	case strings.Contains(msg, "invalid") || strings.Contains(msg, "no consents"):
+		h.respondError(w, http.StatusBadRequest, msg)
+	default:
+		h.respondError(w, http.StatusInternalServerError, msg)
+	}
+}
+
+// respondJSON 发送JSON响应
+func (h *ConsentHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// respondError 发送错误响应
+func (h *ConsentHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
+
+// isConsentExempt 检查路径是否无需同意协议即可访问
+func isConsentExempt(path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	for _, p := range consentExemptPaths {
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP 获取客户端IP，优先使用网关转发的X-Forwarded-For
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
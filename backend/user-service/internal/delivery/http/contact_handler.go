@@ -0,0 +1,74 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/validation"
+)
+
+// maxContactImportBodySize 通讯录导入请求体上限，足够容纳两组各1000个摘要
+const maxContactImportBodySize = 256 << 10
+
+// ContactHandler 处理通讯录导入相关的HTTP请求
+type ContactHandler struct {
+	contactService domain.ContactService
+	logger         *zap.Logger
+}
+
+// NewContactHandler 创建一个新的通讯录导入处理器
+func NewContactHandler(contactService domain.ContactService, logger *zap.Logger) *ContactHandler {
+	return &ContactHandler{
+		contactService: contactService,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *ContactHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/users/contacts/import", authMiddleware(http.HandlerFunc(h.ImportContacts))).Methods("POST")
+}
+
+// ImportContacts 上传通讯录中手机号/邮箱的摘要，返回已注册且允许被发现的用户
+func (h *ContactHandler) ImportContacts(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxContactImportBodySize)
+	var req domain.ContactImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	result, err := h.contactService.ImportContacts(r.Context(), userID, &req)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+// respondJSON 发送JSON响应
+func (h *ContactHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// respondError 发送错误响应
+func (h *ContactHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,113 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// DataExportHandler 处理个人数据导出相关的HTTP请求
+type DataExportHandler struct {
+	exportService domain.DataExportService
+	logger        *zap.Logger
+}
+
+// NewDataExportHandler 创建一个新的个人数据导出处理器
+func NewDataExportHandler(exportService domain.DataExportService, logger *zap.Logger) *DataExportHandler {
+	return &DataExportHandler{
+		exportService: exportService,
+		logger:        logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *DataExportHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/users/me/export", authMiddleware(http.HandlerFunc(h.RequestExport))).Methods("POST")
+	router.Handle("/api/v1/users/me/export", authMiddleware(http.HandlerFunc(h.GetExport))).Methods("GET")
+	router.Handle("/api/v1/users/me/export/download", authMiddleware(http.HandlerFunc(h.DownloadExport))).Methods("GET")
+}
+
+// RequestExport 发起个人数据导出，归档在后台生成
+func (h *DataExportHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	export, err := h.exportService.RequestExport(r.Context(), userID)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, export)
+}
+
+// GetExport 获取最近一次导出的状态，客户端轮询直到completed或failed
+func (h *DataExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	export, err := h.exportService.GetLatestExport(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, export)
+}
+
+// DownloadExport 下载最近一次已完成的导出归档
+func (h *DataExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	export, archive, err := h.exportService.DownloadExport(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="chatapp-export-%s.zip"`, export.CreatedAt.Format("20060102")))
+	w.Header().Set("Content-Length", strconv.Itoa(len(archive)))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(archive); err != nil {
+		h.logger.Warn("Failed to write data export", zap.String("export_id", export.ID), zap.Error(err))
+	}
+}
+
+// writeError 根据错误类型返回对应的状态码
+func (h *DataExportHandler) writeError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		h.respondError(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "expired"):
+		h.respondError(w, http.StatusGone, msg)
+	case strings.HasPrefix(msg, "export is "):
+		h.respondError(w, http.StatusConflict, msg)
+	default:
+		h.respondError(w, http.StatusInternalServerError, msg)
+	}
+}
+
+// respondJSON 发送JSON响应
+func (h *DataExportHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// respondError 发送错误响应
+func (h *DataExportHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
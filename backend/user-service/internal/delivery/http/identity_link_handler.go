@@ -0,0 +1,145 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// IdentityLinkHandler 处理外部身份映射相关的HTTP请求
+type IdentityLinkHandler struct {
+	linkService  domain.IdentityLinkService
+	adminUserIDs map[string]bool
+	logger       *zap.Logger
+}
+
+// NewIdentityLinkHandler 创建一个新的外部身份映射处理器
+func NewIdentityLinkHandler(linkService domain.IdentityLinkService, adminUserIDs []string, logger *zap.Logger) *IdentityLinkHandler {
+	admins := make(map[string]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = true
+	}
+
+	return &IdentityLinkHandler{
+		linkService:  linkService,
+		adminUserIDs: admins,
+		logger:       logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *IdentityLinkHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	// 内部路由，供API网关在令牌内省后解析用户，不经过API网关暴露
+	router.HandleFunc("/internal/identity-links/resolve", h.Resolve).Methods("GET")
+
+	// 管理员路由
+	router.Handle("/api/v1/users/admin/identity-links", authMiddleware(h.adminOnly(h.ListLinks))).Methods("GET")
+	router.Handle("/api/v1/users/admin/identity-links", authMiddleware(h.adminOnly(h.CreateLink))).Methods("POST")
+	router.Handle("/api/v1/users/admin/identity-links/{id}", authMiddleware(h.adminOnly(h.DeleteLink))).Methods("DELETE")
+}
+
+// Resolve 根据外部IdP和主体解析内部用户（内部接口）
+func (h *IdentityLinkHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	identity, err := h.linkService.Resolve(r.Context(), query.Get("issuer"), query.Get("subject"))
+	if err != nil {
+		h.respondLinkError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, identity)
+}
+
+// ListLinks 获取外部身份映射列表，支持 ?user_id= 过滤（管理员）
+func (h *IdentityLinkHandler) ListLinks(w http.ResponseWriter, r *http.Request) {
+	links, err := h.linkService.ListLinks(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		h.respondLinkError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, links)
+}
+
+// CreateLink 创建外部身份映射（管理员）
+func (h *IdentityLinkHandler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(userIDKey).(string)
+
+	var req domain.CreateIdentityLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	link, err := h.linkService.CreateLink(r.Context(), adminID, &req)
+	if err != nil {
+		h.logger.Info("Failed to create identity link", zap.String("admin_id", adminID), zap.Error(err))
+		h.respondLinkError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, link)
+}
+
+// DeleteLink 删除外部身份映射（管理员）
+func (h *IdentityLinkHandler) DeleteLink(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if err := h.linkService.DeleteLink(r.Context(), id); err != nil {
+		h.respondLinkError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]string{"message": "Identity link deleted successfully"})
+}
+
+// adminOnly 仅允许管理员访问
+func (h *IdentityLinkHandler) adminOnly(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(r, h.adminUserIDs) {
+			h.respondError(w, http.StatusForbidden, "Admin access required")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// respondLinkError 根据错误信息写入对应状态码
+func (h *IdentityLinkHandler) respondLinkError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		h.respondError(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "not active"):
+		h.respondError(w, http.StatusForbidden, msg)
+	case strings.Contains(msg, "already exists"):
+		h.respondError(w, http.StatusConflict, msg)
+	case strings.Contains(msg, "invalid"):
+		h.respondError(w, http.StatusBadRequest, msg)
+	default:
+		h.respondError(w, http.StatusInternalServerError, msg)
+	}
+}
+
+// respondJSON 发送JSON响应
+func (h *IdentityLinkHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// respondError 发送错误响应
+func (h *IdentityLinkHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,200 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/validation"
+)
+
+// LoginIdentityHandler 处理登录身份关联相关的HTTP请求
+type LoginIdentityHandler struct {
+	identityService domain.LoginIdentityService
+	logger          *zap.Logger
+}
+
+// NewLoginIdentityHandler 创建一个新的登录身份处理器
+func NewLoginIdentityHandler(identityService domain.LoginIdentityService, logger *zap.Logger) *LoginIdentityHandler {
+	return &LoginIdentityHandler{
+		identityService: identityService,
+		logger:          logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *LoginIdentityHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/users/me/identities", authMiddleware(http.HandlerFunc(h.ListIdentities))).Methods("GET")
+	router.Handle("/api/v1/users/me/identities", authMiddleware(http.HandlerFunc(h.StartVerification))).Methods("POST")
+	router.Handle("/api/v1/users/me/identities/verify", authMiddleware(http.HandlerFunc(h.VerifyIdentity))).Methods("POST")
+	router.Handle("/api/v1/users/me/identities/oauth", authMiddleware(http.HandlerFunc(h.LinkOAuth))).Methods("POST")
+	router.Handle("/api/v1/users/me/identities/{id}", authMiddleware(http.HandlerFunc(h.UnlinkIdentity))).Methods("DELETE")
+	router.HandleFunc("/api/v1/users/login/oauth", h.LoginWithOAuth).Methods("POST")
+}
+
+// ListIdentities 获取当前用户已关联的登录身份
+func (h *LoginIdentityHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	identities, err := h.identityService.ListIdentities(r.Context(), userID)
+	if err != nil {
+		h.respondIdentityError(w, err, userID)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, identities)
+}
+
+// StartVerification 向待关联的邮箱或手机号发送验证码
+func (h *LoginIdentityHandler) StartVerification(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	var req domain.LinkIdentityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	if err := h.identityService.StartVerification(r.Context(), userID, &req); err != nil {
+		h.respondIdentityError(w, err, userID)
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, map[string]string{"message": "Verification code sent"})
+}
+
+// VerifyIdentity 提交验证码完成关联
+func (h *LoginIdentityHandler) VerifyIdentity(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	var req domain.VerifyIdentityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	identity, err := h.identityService.VerifyIdentity(r.Context(), userID, &req)
+	if err != nil {
+		h.respondIdentityError(w, err, userID)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, identity)
+}
+
+// LinkOAuth 关联Google/GitHub账号
+func (h *LoginIdentityHandler) LinkOAuth(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	var req domain.OAuthIdentityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	identity, err := h.identityService.LinkOAuth(r.Context(), userID, &req)
+	if err != nil {
+		h.respondIdentityError(w, err, userID)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, identity)
+}
+
+// UnlinkIdentity 取消关联登录身份
+func (h *LoginIdentityHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	if err := h.identityService.UnlinkIdentity(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		h.respondIdentityError(w, err, userID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LoginWithOAuth 使用已关联的Google/GitHub账号登录
+func (h *LoginIdentityHandler) LoginWithOAuth(w http.ResponseWriter, r *http.Request) {
+	var req domain.OAuthIdentityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	response, err := h.identityService.LoginWithOAuth(r.Context(), &req)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "deactivated"):
+			h.respondError(w, http.StatusForbidden, "Account is deactivated")
+		case strings.Contains(msg, "suspended"):
+			h.respondError(w, http.StatusForbidden, "Account is suspended")
+		case strings.Contains(msg, "invalid credentials"), strings.Contains(msg, "invalid oauth token"), strings.Contains(msg, "not active"):
+			h.respondError(w, http.StatusUnauthorized, "Invalid credentials")
+		default:
+			h.respondIdentityError(w, err, "")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// respondIdentityError 根据登录身份相关的错误类型返回对应的状态码
+func (h *LoginIdentityHandler) respondIdentityError(w http.ResponseWriter, err error, userID string) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "invalid oauth token"):
+		h.respondError(w, http.StatusUnauthorized, msg)
+	case strings.Contains(msg, "already"), strings.Contains(msg, "last login identity"), strings.Contains(msg, "too many login identities"):
+		h.respondError(w, http.StatusConflict, msg)
+	case strings.Contains(msg, "recently sent"), strings.Contains(msg, "too many verification attempts"):
+		h.respondError(w, http.StatusTooManyRequests, msg)
+	case strings.Contains(msg, "expired"):
+		h.respondError(w, http.StatusGone, msg)
+	case strings.Contains(msg, "not configured"):
+		h.respondError(w, http.StatusNotImplemented, msg)
+	case strings.Contains(msg, "invalid"), strings.Contains(msg, "unsupported"):
+		h.respondError(w, http.StatusBadRequest, msg)
+	case strings.Contains(msg, "not found"):
+		h.respondError(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "oauth provider"):
+		h.respondError(w, http.StatusBadGateway, msg)
+	default:
+		h.logger.Error("Login identity request failed", zap.String("user_id", userID), zap.Error(err))
+		h.respondError(w, http.StatusInternalServerError, msg)
+	}
+}
+
+// respondJSON 发送JSON响应
+func (h *LoginIdentityHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// respondError 发送错误响应
+func (h *LoginIdentityHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,83 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/client"
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// MentionHandler 处理@提及自动补全相关的HTTP请求
+type MentionHandler struct {
+	mentionService domain.MentionService
+	logger         *zap.Logger
+}
+
+// NewMentionHandler 创建一个新的@提及处理器
+func NewMentionHandler(mentionService domain.MentionService, logger *zap.Logger) *MentionHandler {
+	return &MentionHandler{
+		mentionService: mentionService,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态，必须在 /api/v1/users/{id} 之前注册
+func (h *MentionHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/users/autocomplete", authMiddleware(http.HandlerFunc(h.Autocomplete))).Methods("GET")
+}
+
+// Autocomplete 按用户名前缀返回可@的会话成员和好友
+func (h *MentionHandler) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+	query := r.URL.Query()
+
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			h.respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	candidates, err := h.mentionService.Autocomplete(r.Context(), userID, query.Get("prefix"), query.Get("conversation_id"), limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, client.ErrConversationNotFound):
+			h.respondError(w, http.StatusNotFound, err.Error())
+		case strings.Contains(err.Error(), "access denied"):
+			h.respondError(w, http.StatusForbidden, err.Error())
+		case strings.Contains(err.Error(), "invalid"):
+			h.respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			h.respondError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, candidates)
+}
+
+// respondJSON 发送JSON响应
+func (h *MentionHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// respondError 发送错误响应
+func (h *MentionHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,161 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/validation"
+)
+
+// NotificationEmailHandler 处理通知邮箱相关的HTTP请求
+type NotificationEmailHandler struct {
+	emailService domain.NotificationEmailService
+	logger       *zap.Logger
+}
+
+// NewNotificationEmailHandler 创建一个新的通知邮箱处理器
+func NewNotificationEmailHandler(emailService domain.NotificationEmailService, logger *zap.Logger) *NotificationEmailHandler {
+	return &NotificationEmailHandler{
+		emailService: emailService,
+		logger:       logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *NotificationEmailHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/users/me/notification-email", authMiddleware(http.HandlerFunc(h.GetNotificationEmail))).Methods("GET")
+	router.Handle("/api/v1/users/me/notification-email", authMiddleware(http.HandlerFunc(h.SetNotificationEmail))).Methods("PUT")
+	router.Handle("/api/v1/users/me/notification-email", authMiddleware(http.HandlerFunc(h.RemoveNotificationEmail))).Methods("DELETE")
+	// 验证链接可能在未登录的设备上打开，使用邮件中的令牌验证
+	router.HandleFunc("/api/v1/users/notification-email/verify", h.VerifyNotificationEmail).Methods("POST")
+
+	// 内部路由，供通知服务发送邮件前查询收件地址
+	router.HandleFunc("/internal/users/{id}/notification-email", h.GetNotificationAddress).Methods("GET")
+}
+
+// GetNotificationEmail 获取当前用户的通知邮箱及验证状态
+func (h *NotificationEmailHandler) GetNotificationEmail(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	email, err := h.emailService.GetNotificationEmail(r.Context(), userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			h.respondError(w, http.StatusNotFound, "Notification email not set")
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, email)
+}
+
+// SetNotificationEmail 设置通知邮箱并发送验证邮件
+func (h *NotificationEmailHandler) SetNotificationEmail(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	var req domain.SetNotificationEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	email, err := h.emailService.SetNotificationEmail(r.Context(), userID, req.Email)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "must differ"):
+			h.respondError(w, http.StatusBadRequest, msg)
+		case strings.Contains(msg, "user not found"):
+			h.respondError(w, http.StatusNotFound, "User not found")
+		default:
+			h.respondError(w, http.StatusInternalServerError, msg)
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, email)
+}
+
+// RemoveNotificationEmail 删除通知邮箱，之后通知邮件发送到登录邮箱
+func (h *NotificationEmailHandler) RemoveNotificationEmail(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	if err := h.emailService.RemoveNotificationEmail(r.Context(), userID); err != nil {
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyNotificationEmail 使用验证邮件中的令牌确认通知邮箱
+func (h *NotificationEmailHandler) VerifyNotificationEmail(w http.ResponseWriter, r *http.Request) {
+	var req domain.VerifyNotificationEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	email, err := h.emailService.VerifyNotificationEmail(r.Context(), req.Token)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "invalid verification token"):
+			h.respondError(w, http.StatusNotFound, msg)
+		case strings.Contains(msg, "expired"), strings.Contains(msg, "already used"):
+			h.respondError(w, http.StatusGone, msg)
+		default:
+			h.respondError(w, http.StatusInternalServerError, msg)
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, email)
+}
+
+// GetNotificationAddress 获取发送通知邮件时使用的地址（内部接口）
+func (h *NotificationEmailHandler) GetNotificationAddress(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+
+	address, err := h.emailService.ResolveAddress(r.Context(), userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			h.respondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		h.logger.Error("Failed to resolve notification address", zap.String("user_id", userID), zap.Error(err))
+		h.respondError(w, http.StatusInternalServerError, "Failed to resolve notification address")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, address)
+}
+
+// respondJSON 发送JSON响应
+func (h *NotificationEmailHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// respondError 发送错误响应
+func (h *NotificationEmailHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,83 @@
+package httpdelivery
+
+import (
+	"net/http"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/openapi"
+)
+
+// APIOperations 用户服务HTTP接口说明，启动时与注册的路由核对后生成/openapi.json
+var APIOperations = openapi.Operations{
+	"DELETE /api/v1/users/admin/identity-links/{id}":    {Summary: "删除外部身份映射（管理员）"},
+	"DELETE /api/v1/users/admin/sso/providers/{tenant}": {Summary: "删除租户IdP配置（管理员）"},
+	"DELETE /api/v1/users/admin/users/{id}":             {Summary: "删除用户（管理员）", Description: "不能删除自己或最后一个管理员", Status: http.StatusNoContent},
+	"DELETE /api/v1/users/contacts/{contactId}":         {Summary: "删除联系人"},
+	"DELETE /api/v1/users/me/identities/{id}":           {Summary: "取消关联登录身份", Description: "不能取消最后一个登录身份", Status: http.StatusNoContent},
+	"DELETE /api/v1/users/me/notification-email":        {Summary: "删除通知邮箱", Description: "之后通知邮件发送到登录邮箱", Status: http.StatusNoContent},
+	"DELETE /api/v1/users/{id}":                         {Summary: "删除用户"},
+	"GET /api/v1/friends":                               {Summary: "获取好友列表", Response: []*domain.User{}},
+	"GET /api/v1/friends/pending":                       {Summary: "获取待处理的好友请求", Response: []*domain.FriendRequest{}},
+	"GET /api/v1/friends/sent":                          {Summary: "获取已发送的好友请求", Response: []*domain.FriendRequest{}},
+	"GET /api/v1/users":                                 {Summary: "获取用户列表", Description: "只包含公开资料", Query: []string{"limit", "offset"}, Response: []*domain.PublicProfile{}},
+	"GET /api/v1/users/admin/identity-links":            {Summary: "获取外部身份映射列表（管理员）", Query: []string{"user_id"}, Response: []*domain.IdentityLink{}},
+	"GET /api/v1/users/admin/imports":                   {Summary: "获取导入任务列表（管理员）", Query: []string{"limit", "offset"}, Response: []*domain.UserImportJob{}},
+	"GET /api/v1/users/admin/imports/{id}":              {Summary: "获取导入任务状态（管理员）", Description: "完成后包含逐行错误报告", Response: domain.UserImportJob{}},
+	"GET /api/v1/users/admin/policies":                  {Summary: "获取某类协议的全部版本（管理员）", Query: []string{"policy_type"}, Response: []*domain.PolicyVersion{}},
+	"GET /api/v1/users/admin/sso/providers":             {Summary: "获取全部IdP配置（管理员）", Response: []*domain.SSOProvider{}},
+	"GET /api/v1/users/admin/users":                     {Summary: "按状态、角色和关键字列出用户（管理员）", Description: "包括已停用和被暂停的账户，q按用户名、全名或邮箱模糊匹配", Query: []string{"status", "role", "q", "limit", "offset"}, Response: domain.AdminUserPage{}},
+	"GET /api/v1/users/autocomplete":                    {Summary: "@提及候选", Description: "按用户名前缀返回可@的会话成员和好友", Query: []string{"prefix", "conversation_id", "limit"}, Response: []*domain.MentionCandidate{}},
+	"GET /api/v1/users/availability":                    {Summary: "检查用户名和邮箱是否可注册", Query: []string{"username", "email"}, Response: domain.AvailabilityResult{}, Public: true},
+	"GET /api/v1/users/contacts":                        {Summary: "获取联系人列表"},
+	"GET /api/v1/users/me":                              {Summary: "获取当前登录用户信息", Response: domain.User{}},
+	"GET /api/v1/users/me/consents":                     {Summary: "获取当前用户的协议同意状态", Response: domain.ConsentStatus{}},
+	"GET /api/v1/users/me/export":                       {Summary: "获取最近一次个人数据导出的状态", Description: "status为completed后通过/export/download下载", Response: domain.DataExport{}},
+	"GET /api/v1/users/me/export/download":              {Summary: "下载个人数据导出归档", Description: "zip归档包含profile.json、friends.json、friend_requests.json、messages.json和media.json，过期后返回410"},
+	"GET /api/v1/users/me/identities":                   {Summary: "获取当前用户已关联的登录身份", Response: []*domain.LoginIdentity{}},
+	"GET /api/v1/users/me/interests":                    {Summary: "获取当前用户的简介和兴趣", Response: domain.InterestProfile{}},
+	"GET /api/v1/users/me/notification-email":           {Summary: "获取当前用户的通知邮箱及验证状态", Response: domain.NotificationEmail{}},
+	"GET /api/v1/users/me/privacy":                      {Summary: "获取当前用户的隐私设置", Response: domain.PrivacySettings{}},
+	"GET /api/v1/users/me/settings":                     {Summary: "获取当前用户的同步设置", Description: "每项设置带有向量时间戳clock", Response: domain.UserSettings{}},
+	"GET /api/v1/users/policies":                        {Summary: "获取各类协议的最新版本", Response: []*domain.PolicyVersion{}},
+	"GET /api/v1/users/recommended":                     {Summary: "获取推荐用户", Query: []string{"limit", "offset"}},
+	"GET /api/v1/users/search":                          {Summary: "搜索用户", Description: "只包含公开资料", Query: []string{"q", "keyword", "limit", "offset"}, Response: []*domain.PublicProfile{}},
+	"GET /api/v1/users/sso/{tenant}/login":              {Summary: "发起SP端登录，重定向到租户IdP", Public: true},
+	"GET /api/v1/users/sso/{tenant}/oidc/callback":      {Summary: "处理OIDC授权码回调", Query: []string{"error", "error_description", "state", "code"}, Public: true},
+	"GET /api/v1/users/sso/{tenant}/saml/metadata":      {Summary: "输出租户的SP元数据", Public: true},
+	"GET /api/v1/users/{id}":                            {Summary: "获取指定用户信息", Description: "他人的手机号、邮箱和最后在线时间不返回，按隐私设置查看请使用/profile", Response: domain.PublicProfile{}},
+	"GET /api/v1/users/{id}/profile":                    {Summary: "获取用户公开资料", Description: "按资料主人的隐私设置隐藏字段", Response: domain.PublicProfile{}},
+	"POST /api/v1/friends/accept":                       {Summary: "接受好友请求", Request: domain.AcceptFriendRequestRequest{}},
+	"POST /api/v1/friends/reject":                       {Summary: "拒绝好友请求", Request: domain.RejectFriendRequestRequest{}},
+	"POST /api/v1/friends/request":                      {Summary: "发送好友请求", Request: domain.SendFriendRequestRequest{}},
+	"POST /api/v1/users/admin/identity-links":           {Summary: "创建外部身份映射（管理员）", Request: domain.CreateIdentityLinkRequest{}, Response: domain.IdentityLink{}, Status: http.StatusCreated},
+	"POST /api/v1/users/admin/imports":                  {Summary: "上传CSV创建导入任务（管理员）", Description: "支持multipart表单的file字段或text/csv请求体，通过Location头返回的地址轮询任务状态", Response: domain.UserImportJob{}, Status: http.StatusAccepted},
+	"POST /api/v1/users/admin/policies":                 {Summary: "发布新的协议版本（管理员）", Request: domain.PublishPolicyVersionRequest{}, Response: domain.PolicyVersion{}, Status: http.StatusCreated},
+	"POST /api/v1/users/admin/users/{id}/reactivate":    {Summary: "恢复被暂停的账户（管理员）", Response: domain.User{}},
+	"POST /api/v1/users/admin/users/{id}/suspend":       {Summary: "暂停账户（管理员）", Description: "已签发的访问令牌和刷新令牌立即失效并断开实时连接，暂停期间不能登录", Request: domain.SuspendUserRequest{}, Response: domain.User{}},
+	"POST /api/v1/users/change-password":                {Summary: "修改密码", Request: domain.ChangePasswordRequest{}},
+	"POST /api/v1/users/contacts":                       {Summary: "添加联系人"},
+	"POST /api/v1/users/contacts/import":                {Summary: "通讯录匹配", Description: "上传通讯录中手机号/邮箱的摘要，返回已注册且允许被发现的用户", Request: domain.ContactImportRequest{}, Response: domain.ContactImportResponse{}},
+	"POST /api/v1/users/contacts/{contactId}/favorite":  {Summary: "切换联系人收藏状态"},
+	"POST /api/v1/users/invitations/accept":             {Summary: "接受邀请", Description: "设置密码并激活账户，返回登录令牌", Request: domain.AcceptInvitationRequest{}, Response: domain.LoginResponse{}, Public: true},
+	"POST /api/v1/users/login":                          {Summary: "使用用户名、邮箱或手机号登录", Description: "邮箱和手机号（E.164格式）按已关联的登录身份查找", Request: domain.LoginRequest{}, Response: domain.LoginResponse{}, Public: true},
+	"POST /api/v1/users/login/oauth":                    {Summary: "使用已关联的Google/GitHub账号登录", Description: "不会自动创建账户", Request: domain.OAuthIdentityRequest{}, Response: domain.LoginResponse{}, Public: true},
+	"POST /api/v1/users/logout":                         {Summary: "吊销刷新令牌", Description: "已签发的访问令牌在过期前仍然有效", Request: domain.RefreshTokenRequest{}, Public: true},
+	"POST /api/v1/users/me/consents":                    {Summary: "同意协议版本", Request: domain.AcceptConsentRequest{}, Response: domain.ConsentStatus{}},
+	"POST /api/v1/users/me/deactivate":                  {Summary: "临时停用当前账户", Request: domain.DeactivateAccountRequest{}, Response: domain.AccountDeactivation{}},
+	"POST /api/v1/users/me/export":                      {Summary: "发起个人数据导出", Description: "归档在后台生成，已有进行中的导出时返回该导出", Response: domain.DataExport{}, Status: http.StatusAccepted},
+	"POST /api/v1/users/me/identities":                  {Summary: "向待关联的邮箱或手机号发送验证码", Description: "同一地址一分钟内只能发送一次", Request: domain.LinkIdentityRequest{}, Status: http.StatusAccepted},
+	"POST /api/v1/users/me/identities/oauth":            {Summary: "关联Google/GitHub账号", Description: "Google使用ID令牌，GitHub使用访问令牌", Request: domain.OAuthIdentityRequest{}, Response: domain.LoginIdentity{}, Status: http.StatusCreated},
+	"POST /api/v1/users/me/identities/verify":           {Summary: "提交验证码关联邮箱或手机号", Request: domain.VerifyIdentityRequest{}, Response: domain.LoginIdentity{}, Status: http.StatusCreated},
+	"POST /api/v1/users/notification-email/verify":      {Summary: "验证通知邮箱", Description: "使用验证邮件中的令牌，令牌只能使用一次", Request: domain.VerifyNotificationEmailRequest{}, Response: domain.NotificationEmail{}, Public: true},
+	"POST /api/v1/users/reactivate":                     {Summary: "重新启用已停用的账户", Request: domain.ReactivateAccountRequest{}, Response: domain.LoginResponse{}, Public: true},
+	"POST /api/v1/users/refresh":                        {Summary: "用刷新令牌换取新的访问令牌", Description: "刷新令牌同时轮换", Request: domain.RefreshTokenRequest{}, Response: domain.LoginResponse{}, Public: true},
+	"POST /api/v1/users/register":                       {Summary: "注册用户", Request: domain.RegisterRequest{}, Response: domain.User{}, Status: http.StatusCreated, Public: true},
+	"POST /api/v1/users/sso/{tenant}/saml/acs":          {Summary: "处理IdP通过HTTP-POST绑定提交的SAML响应", Public: true},
+	"PUT /api/v1/users/admin/sso/providers":             {Summary: "创建或更新租户IdP配置（管理员）", Request: domain.SSOProviderRequest{}, Response: domain.SSOProvider{}},
+	"PUT /api/v1/users/admin/users/{id}/role":           {Summary: "修改用户角色（管理员）", Description: "立即生效，管理员权限按当前角色判断；降级会使该用户已签发的访问令牌和刷新令牌失效", Request: domain.SetUserRoleRequest{}, Response: domain.User{}},
+	"PUT /api/v1/users/me/interests":                    {Summary: "更新当前用户的简介和兴趣", Request: domain.UpdateInterestsRequest{}, Response: domain.InterestProfile{}},
+	"PUT /api/v1/users/me/notification-email":           {Summary: "设置通知邮箱并发送验证邮件", Description: "验证通过前通知邮件仍发送到登录邮箱，重复设置会重新发送验证邮件", Request: domain.SetNotificationEmailRequest{}, Response: domain.NotificationEmail{}, Status: http.StatusAccepted},
+	"PUT /api/v1/users/me/privacy":                      {Summary: "更新当前用户的隐私设置", Request: domain.UpdatePrivacySettingsRequest{}, Response: domain.PrivacySettings{}},
+	"PUT /api/v1/users/me/settings":                     {Summary: "同步本设备的设置修改", Description: "按向量时间戳逐项合并，并发修改时修改时间较晚者胜出；响应中每项的setting为合并后服务端的状态", Request: domain.SyncSettingsRequest{}, Response: map[string][]*domain.SettingResult{}},
+	"PUT /api/v1/users/{id}":                            {Summary: "更新用户信息", Request: domain.UpdateUserRequest{}, Response: domain.User{}},
+}
@@ -0,0 +1,107 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// ProfileHandler 处理用户资料与隐私设置相关的HTTP请求
+type ProfileHandler struct {
+	profileService domain.ProfileService
+	logger         *zap.Logger
+}
+
+// NewProfileHandler 创建一个新的用户资料处理器
+func NewProfileHandler(profileService domain.ProfileService, logger *zap.Logger) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: profileService,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *ProfileHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/users/me/privacy", authMiddleware(http.HandlerFunc(h.GetPrivacySettings))).Methods("GET")
+	router.Handle("/api/v1/users/me/privacy", authMiddleware(http.HandlerFunc(h.UpdatePrivacySettings))).Methods("PUT")
+	router.Handle("/api/v1/users/{id}/profile", authMiddleware(http.HandlerFunc(h.GetProfile))).Methods("GET")
+}
+
+// GetProfile 获取用户公开资料，按资料主人的隐私设置隐藏字段
+func (h *ProfileHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	viewerID := r.Context().Value(userIDKey).(string)
+	userID := mux.Vars(r)["id"]
+	if userID == "me" {
+		userID = viewerID
+	}
+
+	profile, err := h.profileService.GetProfile(r.Context(), viewerID, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			h.respondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, profile)
+}
+
+// GetPrivacySettings 获取当前用户的隐私设置
+func (h *ProfileHandler) GetPrivacySettings(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	settings, err := h.profileService.GetPrivacySettings(r.Context(), userID)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, settings)
+}
+
+// UpdatePrivacySettings 更新当前用户的隐私设置
+func (h *ProfileHandler) UpdatePrivacySettings(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	var req domain.UpdatePrivacySettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	settings, err := h.profileService.UpdatePrivacySettings(r.Context(), userID, &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, settings)
+}
+
+// respondJSON 发送JSON响应
+func (h *ProfileHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// respondError 发送错误响应
+func (h *ProfileHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
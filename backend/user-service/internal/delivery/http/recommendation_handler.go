@@ -0,0 +1,85 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// RecommendationHandler 处理用户简介和兴趣相关的HTTP请求，推荐列表由UserHandler.GetRecommendedUsers提供
+type RecommendationHandler struct {
+	recommendationService domain.RecommendationService
+	logger                *zap.Logger
+}
+
+// NewRecommendationHandler 创建一个新的兴趣资料处理器
+func NewRecommendationHandler(recommendationService domain.RecommendationService, logger *zap.Logger) *RecommendationHandler {
+	return &RecommendationHandler{
+		recommendationService: recommendationService,
+		logger:                logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *RecommendationHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/users/me/interests", authMiddleware(http.HandlerFunc(h.GetInterests))).Methods("GET")
+	router.Handle("/api/v1/users/me/interests", authMiddleware(http.HandlerFunc(h.UpdateInterests))).Methods("PUT")
+}
+
+// GetInterests 获取当前用户的简介和兴趣
+func (h *RecommendationHandler) GetInterests(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	profile, err := h.recommendationService.GetInterests(r.Context(), userID)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, profile)
+}
+
+// UpdateInterests 更新当前用户的简介和兴趣
+func (h *RecommendationHandler) UpdateInterests(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	var req domain.UpdateInterestsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	profile, err := h.recommendationService.UpdateInterests(r.Context(), userID, &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, profile)
+}
+
+// respondJSON 发送JSON响应
+func (h *RecommendationHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// respondError 发送错误响应
+func (h *RecommendationHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,100 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/validation"
+)
+
+// maxSettingsBodySize 同步设置请求体上限，足够容纳50项各4KB的值
+const maxSettingsBodySize = 256 << 10
+
+// SettingsHandler 处理跨设备设置同步相关的HTTP请求
+type SettingsHandler struct {
+	settingsService domain.SettingsService
+	logger          *zap.Logger
+}
+
+// NewSettingsHandler 创建一个新的设置同步处理器
+func NewSettingsHandler(settingsService domain.SettingsService, logger *zap.Logger) *SettingsHandler {
+	return &SettingsHandler{
+		settingsService: settingsService,
+		logger:          logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *SettingsHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	router.Handle("/api/v1/users/me/settings", authMiddleware(http.HandlerFunc(h.GetSettings))).Methods("GET")
+	router.Handle("/api/v1/users/me/settings", authMiddleware(http.HandlerFunc(h.SyncSettings))).Methods("PUT")
+}
+
+// GetSettings 获取当前用户的全部设置
+func (h *SettingsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	settings, err := h.settingsService.GetSettings(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get settings", zap.String("user_id", userID), zap.Error(err))
+		h.respondError(w, http.StatusInternalServerError, "Failed to get settings")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, settings)
+}
+
+// SyncSettings 提交本设备的设置修改，返回每项合并后的结果
+func (h *SettingsHandler) SyncSettings(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(userIDKey).(string)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSettingsBodySize)
+	var req domain.SyncSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !validation.Request(w, &req) {
+		return
+	}
+
+	results, err := h.settingsService.SyncSettings(r.Context(), userID, &req)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "invalid"), strings.Contains(msg, "too many settings"):
+			h.respondError(w, http.StatusBadRequest, msg)
+		case errors.Is(err, domain.ErrSettingModified):
+			h.respondError(w, http.StatusConflict, msg)
+		default:
+			h.logger.Error("Failed to sync settings", zap.String("user_id", userID), zap.Error(err))
+			h.respondError(w, http.StatusInternalServerError, "Failed to sync settings")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{"results": results})
+}
+
+// respondJSON 发送JSON响应
+func (h *SettingsHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// respondError 发送错误响应
+func (h *SettingsHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
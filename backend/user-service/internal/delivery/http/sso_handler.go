@@ -0,0 +1,237 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// SSOHandler 处理企业租户单点登录相关的HTTP请求
+type SSOHandler struct {
+	ssoService domain.SSOService
+	// successRedirectURL 登录完成后跳转的前端地址，令牌放在URL片段中；为空时直接返回JSON
+	successRedirectURL string
+	adminUserIDs       map[string]bool
+	logger             *zap.Logger
+}
+
+// NewSSOHandler 创建一个新的单点登录处理器
+func NewSSOHandler(ssoService domain.SSOService, successRedirectURL string, adminUserIDs []string, logger *zap.Logger) *SSOHandler {
+	admins := make(map[string]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = true
+	}
+
+	return &SSOHandler{
+		ssoService:         ssoService,
+		successRedirectURL: successRedirectURL,
+		adminUserIDs:       admins,
+		logger:             logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *SSOHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	// 浏览器跳转流程，无需登录
+	router.HandleFunc("/api/v1/users/sso/{tenant}/login", h.BeginLogin).Methods("GET")
+	router.HandleFunc("/api/v1/users/sso/{tenant}/oidc/callback", h.OIDCCallback).Methods("GET")
+	router.HandleFunc("/api/v1/users/sso/{tenant}/saml/acs", h.SAMLAssertionConsumer).Methods("POST")
+	router.HandleFunc("/api/v1/users/sso/{tenant}/saml/metadata", h.SAMLMetadata).Methods("GET")
+
+	// 管理员路由
+	router.Handle("/api/v1/users/admin/sso/providers", authMiddleware(h.adminOnly(h.ListProviders))).Methods("GET")
+	router.Handle("/api/v1/users/admin/sso/providers", authMiddleware(h.adminOnly(h.SaveProvider))).Methods("PUT")
+	router.Handle("/api/v1/users/admin/sso/providers/{tenant}", authMiddleware(h.adminOnly(h.DeleteProvider))).Methods("DELETE")
+}
+
+// BeginLogin 发起SP端登录，重定向到租户IdP
+func (h *SSOHandler) BeginLogin(w http.ResponseWriter, r *http.Request) {
+	tenant := mux.Vars(r)["tenant"]
+
+	location, err := h.ssoService.BeginLogin(r.Context(), tenant)
+	if err != nil {
+		h.logger.Info("Failed to begin sso login", zap.String("tenant", tenant), zap.Error(err))
+		h.respondSSOError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, location, http.StatusFound)
+}
+
+// OIDCCallback 处理OIDC授权码回调
+func (h *SSOHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	tenant := mux.Vars(r)["tenant"]
+	query := r.URL.Query()
+
+	if idpError := query.Get("error"); idpError != "" {
+		h.logger.Info("IdP returned error", zap.String("tenant", tenant), zap.String("error", idpError), zap.String("description", query.Get("error_description")))
+		h.respondError(w, http.StatusUnauthorized, "SSO login was rejected by the identity provider")
+		return
+	}
+	if query.Get("state") == "" || query.Get("code") == "" {
+		h.respondError(w, http.StatusBadRequest, "State and code are required")
+		return
+	}
+
+	response, err := h.ssoService.CompleteOIDCLogin(r.Context(), tenant, query.Get("state"), query.Get("code"))
+	if err != nil {
+		h.respondSSOError(w, err)
+		return
+	}
+
+	h.respondLogin(w, r, response)
+}
+
+// SAMLAssertionConsumer 处理IdP通过HTTP-POST绑定提交的SAML响应
+func (h *SSOHandler) SAMLAssertionConsumer(w http.ResponseWriter, r *http.Request) {
+	tenant := mux.Vars(r)["tenant"]
+
+	if err := r.ParseForm(); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid form payload")
+		return
+	}
+	samlResponse := r.PostForm.Get("SAMLResponse")
+	relayState := r.PostForm.Get("RelayState")
+	if samlResponse == "" || relayState == "" {
+		h.respondError(w, http.StatusBadRequest, "SAMLResponse and RelayState are required")
+		return
+	}
+
+	response, err := h.ssoService.CompleteSAMLLogin(r.Context(), tenant, samlResponse, relayState)
+	if err != nil {
+		h.respondSSOError(w, err)
+		return
+	}
+
+	h.respondLogin(w, r, response)
+}
+
+// SAMLMetadata 输出租户的SP元数据
+func (h *SSOHandler) SAMLMetadata(w http.ResponseWriter, r *http.Request) {
+	tenant := mux.Vars(r)["tenant"]
+
+	metadata, err := h.ssoService.SAMLMetadata(r.Context(), tenant)
+	if err != nil {
+		h.respondSSOError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(metadata); err != nil {
+		h.logger.Error("Failed to write metadata", zap.Error(err))
+	}
+}
+
+// ListProviders 获取全部IdP配置（管理员）
+func (h *SSOHandler) ListProviders(w http.ResponseWriter, r *http.Request) {
+	providers, err := h.ssoService.ListProviders(r.Context())
+	if err != nil {
+		h.respondSSOError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, providers)
+}
+
+// SaveProvider 创建或更新租户IdP配置（管理员）
+func (h *SSOHandler) SaveProvider(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(userIDKey).(string)
+
+	var req domain.SSOProviderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	provider, err := h.ssoService.SaveProvider(r.Context(), &req)
+	if err != nil {
+		h.logger.Info("Failed to save sso provider", zap.String("admin_id", adminID), zap.Error(err))
+		h.respondSSOError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, provider)
+}
+
+// DeleteProvider 删除租户IdP配置（管理员）
+func (h *SSOHandler) DeleteProvider(w http.ResponseWriter, r *http.Request) {
+	tenant := mux.Vars(r)["tenant"]
+
+	if err := h.ssoService.DeleteProvider(r.Context(), tenant); err != nil {
+		h.respondSSOError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]string{"message": "SSO provider deleted successfully"})
+}
+
+// respondLogin 登录成功：配置了前端地址时携带令牌重定向，否则返回JSON
+func (h *SSOHandler) respondLogin(w http.ResponseWriter, r *http.Request, response *domain.LoginResponse) {
+	if h.successRedirectURL == "" {
+		h.respondJSON(w, http.StatusOK, response)
+		return
+	}
+
+	// 令牌放在URL片段中，不会出现在服务端访问日志和Referer里
+	fragment := url.Values{
+		"token":         {response.Token},
+		"refresh_token": {response.RefreshToken},
+	}.Encode()
+	http.Redirect(w, r, h.successRedirectURL+"#"+fragment, http.StatusFound)
+}
+
+// adminOnly 仅允许管理员访问
+func (h *SSOHandler) adminOnly(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(r, h.adminUserIDs) {
+			h.respondError(w, http.StatusForbidden, "Admin access required")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// respondSSOError 根据错误信息写入对应状态码
+func (h *SSOHandler) respondSSOError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		h.respondError(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "disabled") || strings.Contains(msg, "not allowed") || strings.Contains(msg, "deactivated") || strings.Contains(msg, "not active"):
+		h.respondError(w, http.StatusForbidden, msg)
+	case strings.Contains(msg, "different sign-in method"):
+		h.respondError(w, http.StatusConflict, msg)
+	case strings.Contains(msg, "sso assertion") || strings.Contains(msg, "sso state"):
+		h.respondError(w, http.StatusUnauthorized, msg)
+	case strings.Contains(msg, "invalid"):
+		h.respondError(w, http.StatusBadRequest, msg)
+	case strings.Contains(msg, "unavailable"):
+		h.respondError(w, http.StatusBadGateway, msg)
+	default:
+		h.respondError(w, http.StatusInternalServerError, msg)
+	}
+}
+
+// respondJSON 发送JSON响应
+func (h *SSOHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// respondError 发送错误响应
+func (h *SSOHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
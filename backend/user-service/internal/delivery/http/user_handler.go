@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
@@ -13,6 +12,8 @@ import (
 
 	"github.com/neohope/chatapp/user-service/internal/domain"
 	"github.com/neohope/chatapp/user-service/pkg/auth"
+	"github.com/neohope/chatapp/user-service/pkg/pagination"
+	"github.com/neohope/chatapp/user-service/pkg/validation"
 )
 
 // Context key types to avoid collisions
@@ -22,31 +23,44 @@ const (
 	userIDKey    contextKey = "user_id"
 	usernameKey  contextKey = "username"
 	emailKey     contextKey = "email"
+	roleKey      contextKey = "role"
 )
 
 // UserHandler 处理用户相关的HTTP请求
 type UserHandler struct {
-	userService   domain.UserService
-	friendService domain.FriendService
-	jwtManager    *auth.JWTManager
-	logger        *zap.Logger
+	userService           domain.UserService
+	friendService         domain.FriendService
+	recommendationService domain.RecommendationService
+	refreshTokenService   domain.RefreshTokenService
+	jwtManager            *auth.JWTManager
+	logger                *zap.Logger
 }
 
-// NewUserHandler 创建一个新的用户处理器
-func NewUserHandler(userService domain.UserService, friendService domain.FriendService, jwtManager *auth.JWTManager, logger *zap.Logger) *UserHandler {
+// NewUserHandler 创建一个新的用户处理器，recommendationService为nil时推荐列表为空
+func NewUserHandler(userService domain.UserService, friendService domain.FriendService, recommendationService domain.RecommendationService, refreshTokenService domain.RefreshTokenService, jwtManager *auth.JWTManager, logger *zap.Logger) *UserHandler {
 	return &UserHandler{
-		userService:   userService,
-		friendService: friendService,
-		jwtManager:    jwtManager,
-		logger:        logger,
+		userService:           userService,
+		friendService:         friendService,
+		recommendationService: recommendationService,
+		refreshTokenService:   refreshTokenService,
+		jwtManager:            jwtManager,
+		logger:                logger,
 	}
 }
 
+// RegisterInternalRoutes 注册供其他服务调用的内部路由，router为/internal前缀的子路由，不经过API网关暴露
+func (h *UserHandler) RegisterInternalRoutes(router *mux.Router) {
+	router.HandleFunc("/users/{id}/locale", h.GetUserLocale).Methods("GET")
+	router.HandleFunc("/users/{id}/status", h.GetUserStatus).Methods("GET")
+}
+
 // RegisterRoutes 注册路由
 func (h *UserHandler) RegisterRoutes(router *mux.Router) {
 	// 公共路由
 	router.HandleFunc("/api/v1/users/register", h.Register).Methods("POST")
 	router.HandleFunc("/api/v1/users/login", h.Login).Methods("POST")
+	router.HandleFunc("/api/v1/users/refresh", h.RefreshToken).Methods("POST")
+	router.HandleFunc("/api/v1/users/logout", h.Logout).Methods("POST")
 
 	// 受保护的路由
 	authRouter := router.PathPrefix("/api/v1").Subrouter()
@@ -88,8 +102,7 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 验证请求
-	if err := validateRegisterRequest(req); err != nil {
-		h.respondError(w, http.StatusBadRequest, err.Error())
+	if !validation.Request(w, &req) {
 		return
 	}
 
@@ -98,6 +111,7 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 		Username: req.Username,
 		Email:    req.Email,
 		FullName: req.FullName,
+		Language: req.Language,
 	}
 
 	// 注册用户
@@ -130,33 +144,27 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 登录
-	token, err := h.userService.Login(r.Context(), req.Identifier, req.Password)
+	response, err := h.userService.Login(r.Context(), req.Identifier, req.Password)
 	if err != nil {
 		h.logger.Info("Login failed", zap.String("identifier", req.Identifier), zap.Error(err))
+		if err.Error() == "account is deactivated" {
+			h.respondError(w, http.StatusForbidden, "Account is deactivated")
+			return
+		}
+		if err.Error() == "account is suspended" {
+			h.respondError(w, http.StatusForbidden, "Account is suspended")
+			return
+		}
+		if err.Error() == "failed to issue refresh token" {
+			h.respondError(w, http.StatusInternalServerError, "Failed to issue refresh token")
+			return
+		}
 		h.respondError(w, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
-	// 获取用户信息
-	claims, err := h.jwtManager.ValidateToken(token)
-	if err != nil {
-		h.logger.Error("Failed to validate token", zap.Error(err))
-		h.respondError(w, http.StatusInternalServerError, "Authentication error")
-		return
-	}
-
-	user, err := h.userService.GetUserByID(r.Context(), claims.UserID)
-	if err != nil {
-		h.logger.Error("Failed to get user after login", zap.Error(err))
-		h.respondError(w, http.StatusInternalServerError, "Failed to retrieve user information")
-		return
-	}
-
 	// 返回成功响应
-	h.respondJSON(w, http.StatusOK, domain.LoginResponse{
-		Token: token,
-		User:  user,
-	})
+	h.respondJSON(w, http.StatusOK, response)
 }
 
 // GetCurrentUser 获取当前登录用户信息
@@ -176,14 +184,18 @@ func (h *UserHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 	h.respondJSON(w, http.StatusOK, user)
 }
 
-// GetUser 获取指定用户信息
+// GetUser 获取指定用户信息，他人只能看到不含手机号、邮箱和最后在线时间的公开资料
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	// 获取路径参数
 	vars := mux.Vars(r)
 	userID := vars["id"]
+	viewerID := r.Context().Value(userIDKey).(string)
 
-	// 获取用户信息
+	// 获取用户信息，停用或封禁的账户对他人隐藏
 	user, err := h.userService.GetUserByID(r.Context(), userID)
+	if err == nil && userID != viewerID && user.Status != domain.UserStatusActive {
+		err = errors.New("user not found")
+	}
 	if err != nil {
 		h.logger.Info("User not found", zap.String("id", userID), zap.Error(err))
 		h.respondError(w, http.StatusNotFound, "User not found")
@@ -191,7 +203,47 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 返回用户信息
-	h.respondJSON(w, http.StatusOK, user)
+	h.respondJSON(w, http.StatusOK, domain.NewPublicProfile(user, viewerID))
+}
+
+// GetUserLocale 获取用户语言设置（内部接口）
+func (h *UserHandler) GetUserLocale(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+
+	user, err := h.userService.GetUserByID(r.Context(), userID)
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	language := user.Language
+	if language == "" {
+		language = domain.DefaultLanguage
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]string{
+		"user_id":  user.ID,
+		"language": language,
+	})
+}
+
+// GetUserStatus 获取用户账户状态（内部接口）
+func (h *UserHandler) GetUserStatus(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+
+	user, err := h.userService.GetUserByID(r.Context(), userID)
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	// 网关据此校验访问令牌的版本并使用当前角色
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"user_id":       user.ID,
+		"status":        string(user.Status),
+		"role":          user.Role,
+		"token_version": user.TokenVersion,
+	})
 }
 
 // UpdateUser 更新用户信息
@@ -231,6 +283,16 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	if req.AvatarURL != "" {
 		user.AvatarURL = req.AvatarURL
 	}
+	if req.Phone != "" {
+		user.Phone = strings.TrimSpace(req.Phone)
+	}
+	if req.Language != "" {
+		if !domain.IsSupportedLanguage(req.Language) {
+			h.respondError(w, http.StatusBadRequest, "Unsupported language")
+			return
+		}
+		user.Language = req.Language
+	}
 
 	// 保存更新
 	if err := h.userService.UpdateUser(r.Context(), user); err != nil {
@@ -271,28 +333,24 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 
 // ListUsers 获取用户列表
 func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
-	// 获取查询参数
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-
-	// 设置默认值
-	if limit <= 0 {
-		limit = 10
-	}
-	if offset < 0 {
-		offset = 0
+	// 获取分页参数
+	page, err := pagination.Parse(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	// 获取用户列表
-	users, err := h.userService.ListUsers(r.Context(), limit, offset)
+	users, err := h.userService.ListUsers(r.Context(), page.Limit, page.Offset)
 	if err != nil {
 		h.logger.Error("Failed to list users", zap.Error(err))
 		h.respondError(w, http.StatusInternalServerError, "Failed to retrieve users")
 		return
 	}
 
-	// 返回用户列表
-	h.respondJSON(w, http.StatusOK, users)
+	// 返回用户列表，只包含公开资料
+	pagination.SetLinkHeader(w, r, page, page.HasMore(len(users)))
+	h.respondJSON(w, http.StatusOK, domain.NewPublicProfiles(users, r.Context().Value(userIDKey).(string)))
 }
 
 // ChangePassword 修改密码
@@ -320,10 +378,68 @@ func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 修改密码后其他设备需要重新登录
+	if err := h.refreshTokenService.RevokeAll(r.Context(), userID); err != nil {
+		h.logger.Error("Failed to revoke refresh tokens", zap.String("id", userID), zap.Error(err))
+	}
+
 	// 返回成功响应
 	h.respondJSON(w, http.StatusOK, map[string]string{"message": "Password changed successfully"})
 }
 
+// RefreshToken 用刷新令牌换取新的访问令牌，刷新令牌同时轮换
+func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req domain.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.RefreshToken == "" {
+		h.respondError(w, http.StatusBadRequest, "Refresh token is required")
+		return
+	}
+
+	response, err := h.refreshTokenService.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRefreshTokenReused):
+			h.respondError(w, http.StatusUnauthorized, "Refresh token has already been used, please log in again")
+		case errors.Is(err, domain.ErrInvalidRefreshToken):
+			h.respondError(w, http.StatusUnauthorized, "Invalid refresh token")
+		default:
+			h.respondError(w, http.StatusInternalServerError, "Failed to refresh token")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// Logout 吊销刷新令牌，已签发的访问令牌在过期前仍然有效
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req domain.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.RefreshToken == "" {
+		h.respondError(w, http.StatusBadRequest, "Refresh token is required")
+		return
+	}
+
+	if err := h.refreshTokenService.Revoke(r.Context(), req.RefreshToken); err != nil {
+		if errors.Is(err, domain.ErrInvalidRefreshToken) {
+			h.respondError(w, http.StatusUnauthorized, "Invalid refresh token")
+			return
+		}
+		h.logger.Error("Failed to revoke refresh token", zap.Error(err))
+		h.respondError(w, http.StatusInternalServerError, "Failed to log out")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]string{"message": "Logged out successfully"})
+}
+
 // AuthMiddleware 认证中间件
 func (h *UserHandler) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -351,16 +467,27 @@ func (h *UserHandler) AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// 检查用户状态
-		if claims.Status != domain.UserStatusActive {
+		// 令牌中的状态和角色是签发时的快照，以数据库中的当前值为准，
+		// 停用、封禁或角色变更后递增令牌版本，已签发的令牌立即失效
+		user, err := h.userService.GetUserByID(r.Context(), claims.UserID)
+		if err != nil {
+			h.respondError(w, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+		if user.Status != domain.UserStatusActive {
 			h.respondError(w, http.StatusForbidden, "Account is not active")
 			return
 		}
+		if claims.TokenVersion != user.TokenVersion {
+			h.respondError(w, http.StatusUnauthorized, "Token has been revoked")
+			return
+		}
 
 		// 将用户信息添加到请求上下文
 		ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
 		ctx = context.WithValue(ctx, usernameKey, claims.Username)
 		ctx = context.WithValue(ctx, emailKey, claims.Email)
+		ctx = context.WithValue(ctx, roleKey, user.Role)
 
 		// 继续处理请求
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -413,8 +540,6 @@ func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 	// 获取查询参数
 	query := r.URL.Query().Get("q")
 	keyword := r.URL.Query().Get("keyword")
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
 	
 	// 支持两种查询参数格式
 	searchTerm := query
@@ -428,48 +553,48 @@ func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 	}
 	
 	// 解析分页参数
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
-	
-	// 设置默认值
-	if limit <= 0 {
-		limit = 10
-	}
-	if offset < 0 {
-		offset = 0
+	page, err := pagination.Parse(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 	
 	// 调用服务层搜索用户
-	users, err := h.userService.SearchUsers(r.Context(), searchTerm, limit, offset)
+	users, err := h.userService.SearchUsers(r.Context(), searchTerm, page.Limit, page.Offset)
 	if err != nil {
 		h.logger.Error("Failed to search users", zap.String("query", searchTerm), zap.Error(err))
 		h.respondError(w, http.StatusInternalServerError, "Failed to search users")
 		return
 	}
 	
-	// 返回搜索结果
-	h.respondJSON(w, http.StatusOK, users)
+	// 返回搜索结果，只包含公开资料
+	pagination.SetLinkHeader(w, r, page, page.HasMore(len(users)))
+	h.respondJSON(w, http.StatusOK, domain.NewPublicProfiles(users, r.Context().Value(userIDKey).(string)))
 }
 
 // GetRecommendedUsers 获取推荐用户
 func (h *UserHandler) GetRecommendedUsers(w http.ResponseWriter, r *http.Request) {
-	// 获取查询参数
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-	
-	// 设置默认值
-	if limit <= 0 {
-		limit = 10
-	}
-	if offset < 0 {
-		offset = 0
+	// 获取分页参数，向量推荐开销较大，每页最多50条
+	page, err := pagination.ParseWithOptions(r, pagination.Options{MaxLimit: 50})
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 	
-	// 暂时返回空推荐用户列表
-	// 在实际实现中，这里应该包含推荐算法逻辑
-	recommendedUsers := []interface{}{}
+	// 开启向量推荐时按简介/兴趣的相似度推荐，否则返回空列表
+	recommendedUsers := []*domain.RecommendedUser{}
+	if h.recommendationService != nil {
+		currentUserID := r.Context().Value(userIDKey).(string)
+		users, err := h.recommendationService.GetRecommendedUsers(r.Context(), currentUserID, page.Limit, page.Offset)
+		if err != nil {
+			h.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		recommendedUsers = users
+	}
 	
 	// 返回推荐用户列表
+	pagination.SetLinkHeader(w, r, page, page.HasMore(len(recommendedUsers)))
 	h.respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"data":    recommendedUsers,
@@ -622,24 +747,3 @@ func (h *UserHandler) GetFriends(w http.ResponseWriter, r *http.Request) {
 	
 	h.respondJSON(w, http.StatusOK, friends)
 }
-
-// validateRegisterRequest 验证注册请求
-func validateRegisterRequest(req domain.RegisterRequest) error {
-	if strings.TrimSpace(req.Username) == "" || len(req.Username) < 3 {
-		return errors.New("username must be at least 3 characters long")
-	}
-
-	if strings.TrimSpace(req.Email) == "" || !strings.Contains(req.Email, "@") {
-		return errors.New("valid email is required")
-	}
-
-	if strings.TrimSpace(req.Password) == "" || len(req.Password) < 8 {
-		return errors.New("password must be at least 8 characters long")
-	}
-
-	if strings.TrimSpace(req.FullName) == "" {
-		return errors.New("full name is required")
-	}
-
-	return nil
-}
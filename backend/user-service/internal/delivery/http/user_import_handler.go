@@ -0,0 +1,184 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/pagination"
+)
+
+// maxImportFileSize 导入CSV文件的大小上限
+const maxImportFileSize = 5 << 20
+
+// UserImportHandler 处理用户批量导入和邀请相关的HTTP请求
+type UserImportHandler struct {
+	importService domain.UserImportService
+	adminUserIDs  map[string]bool
+	logger        *zap.Logger
+}
+
+// NewUserImportHandler 创建一个新的批量导入处理器
+func NewUserImportHandler(importService domain.UserImportService, adminUserIDs []string, logger *zap.Logger) *UserImportHandler {
+	admins := make(map[string]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = true
+	}
+
+	return &UserImportHandler{
+		importService: importService,
+		adminUserIDs:  admins,
+		logger:        logger,
+	}
+}
+
+// RegisterRoutes 注册路由，authMiddleware用于校验登录状态
+func (h *UserImportHandler) RegisterRoutes(router *mux.Router, authMiddleware mux.MiddlewareFunc) {
+	// 被邀请用户尚无密码，使用邮件中的令牌激活
+	router.HandleFunc("/api/v1/users/invitations/accept", h.AcceptInvitation).Methods("POST")
+
+	// 管理员路由
+	router.Handle("/api/v1/users/admin/imports", authMiddleware(h.adminOnly(h.StartImport))).Methods("POST")
+	router.Handle("/api/v1/users/admin/imports", authMiddleware(h.adminOnly(h.ListImports))).Methods("GET")
+	router.Handle("/api/v1/users/admin/imports/{id}", authMiddleware(h.adminOnly(h.GetImport))).Methods("GET")
+}
+
+// StartImport 上传CSV创建导入任务，支持multipart表单的file字段或text/csv请求体
+func (h *UserImportHandler) StartImport(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(userIDKey).(string)
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize)
+
+	var (
+		data     []byte
+		fileName string
+		err      error
+	)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, formErr := r.FormFile("file")
+		if formErr != nil {
+			h.respondError(w, http.StatusBadRequest, "CSV file is required in the 'file' field")
+			return
+		}
+		defer file.Close()
+		fileName = header.Filename
+		data, err = io.ReadAll(file)
+	} else {
+		data, err = io.ReadAll(r.Body)
+	}
+	if err != nil {
+		h.respondError(w, http.StatusRequestEntityTooLarge, "CSV file is too large")
+		return
+	}
+
+	job, err := h.importService.StartImport(r.Context(), adminID, fileName, data)
+	if err != nil {
+		if strings.Contains(err.Error(), "failed to create") {
+			h.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/users/admin/imports/"+job.ID)
+	h.respondJSON(w, http.StatusAccepted, job)
+}
+
+// ListImports 获取导入任务列表
+func (h *UserImportHandler) ListImports(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.Parse(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	jobs, err := h.importService.ListJobs(r.Context(), page.Limit, page.Offset)
+	if err != nil {
+		h.logger.Error("Failed to list import jobs", zap.Error(err))
+		h.respondError(w, http.StatusInternalServerError, "Failed to list import jobs")
+		return
+	}
+
+	pagination.SetLinkHeader(w, r, page, page.HasMore(len(jobs)))
+	h.respondJSON(w, http.StatusOK, jobs)
+}
+
+// GetImport 轮询导入任务状态，完成后包含逐行错误报告
+func (h *UserImportHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	job, err := h.importService.GetJob(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			h.respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		h.logger.Error("Failed to get import job", zap.Error(err))
+		h.respondError(w, http.StatusInternalServerError, "Failed to get import job")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, job)
+}
+
+// AcceptInvitation 接受邀请：设置密码并激活账户，返回登录令牌
+func (h *UserImportHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req domain.AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.Token == "" {
+		h.respondError(w, http.StatusBadRequest, "Invitation token is required")
+		return
+	}
+
+	response, err := h.importService.AcceptInvitation(r.Context(), req.Token, req.Password)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "password must"):
+			h.respondError(w, http.StatusBadRequest, msg)
+		case strings.Contains(msg, "invalid invitation"):
+			h.respondError(w, http.StatusNotFound, msg)
+		case strings.Contains(msg, "already accepted"), strings.Contains(msg, "expired"), strings.Contains(msg, "not awaiting"):
+			h.respondError(w, http.StatusGone, msg)
+		default:
+			h.respondError(w, http.StatusInternalServerError, msg)
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// adminOnly 仅允许管理员访问
+func (h *UserImportHandler) adminOnly(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(r, h.adminUserIDs) {
+			h.respondError(w, http.StatusForbidden, "Admin access required")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// respondJSON 发送JSON响应
+func (h *UserImportHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
+
+// respondError 发送错误响应
+func (h *UserImportHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,50 @@
+package domain
+
+import "context"
+
+// AdminUserFilter 管理员用户列表的筛选条件，空字段不参与筛选
+type AdminUserFilter struct {
+	Status UserStatus
+	Role   string
+	// Query 按用户名、全名或邮箱模糊匹配
+	Query  string
+	Limit  int
+	Offset int
+}
+
+// AdminUserPage 管理员用户列表分页结果
+type AdminUserPage struct {
+	Users []*User `json:"users"`
+	Total int     `json:"total"`
+}
+
+// AdminUserRepository 管理员用户管理仓库接口
+type AdminUserRepository interface {
+	// ListUsers 按条件列出用户（包括非活跃用户），返回当前页和总数
+	ListUsers(ctx context.Context, filter AdminUserFilter) ([]*User, int, error)
+	// CountAdmins 统计状态为active的管理员数量
+	CountAdmins(ctx context.Context) (int, error)
+}
+
+// AdminService 管理员用户管理服务接口，adminID为执行操作的管理员
+type AdminService interface {
+	ListUsers(ctx context.Context, filter AdminUserFilter) (*AdminUserPage, error)
+	// SuspendUser 暂停账户，吊销刷新令牌并断开实时连接
+	SuspendUser(ctx context.Context, adminID, userID string, req *SuspendUserRequest) (*User, error)
+	// ReactivateUser 恢复被暂停的账户
+	ReactivateUser(ctx context.Context, adminID, userID string) (*User, error)
+	// DeleteUser 删除账户，不能删除自己或最后一个管理员
+	DeleteUser(ctx context.Context, adminID, userID string) error
+	// SetRole 修改用户角色，新角色在用户下次获取令牌时生效
+	SetRole(ctx context.Context, adminID, userID, role string) (*User, error)
+}
+
+// SuspendUserRequest 暂停账户请求
+type SuspendUserRequest struct {
+	Reason string `json:"reason,omitempty" validate:"max=500"`
+}
+
+// SetUserRoleRequest 修改用户角色请求
+type SetUserRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin"`
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PolicyType 需要用户同意的协议类型
+type PolicyType string
+
+const (
+	PolicyTypeTermsOfService PolicyType = "terms_of_service"
+	PolicyTypePrivacyPolicy  PolicyType = "privacy_policy"
+)
+
+// IsValidPolicyType 检查协议类型是否受支持
+func IsValidPolicyType(policyType PolicyType) bool {
+	return policyType == PolicyTypeTermsOfService || policyType == PolicyTypePrivacyPolicy
+}
+
+// PolicyVersion 已发布的协议版本
+type PolicyVersion struct {
+	ID          string     `json:"id" db:"id"`
+	PolicyType  PolicyType `json:"policy_type" db:"policy_type"`
+	Version     string     `json:"version" db:"version"`
+	URL         string     `json:"url" db:"url"`
+	Summary     string     `json:"summary" db:"summary"`
+	Mandatory   bool       `json:"mandatory" db:"mandatory"` // 强制版本发布后，未同意的用户将被限制使用API
+	PublishedBy string     `json:"published_by" db:"published_by"`
+	PublishedAt time.Time  `json:"published_at" db:"published_at"`
+}
+
+// UserConsent 用户对某个协议版本的同意记录
+type UserConsent struct {
+	UserID     string     `json:"user_id" db:"user_id"`
+	PolicyType PolicyType `json:"policy_type" db:"policy_type"`
+	Version    string     `json:"version" db:"version"`
+	IPAddress  string     `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent  string     `json:"user_agent,omitempty" db:"user_agent"`
+	AcceptedAt time.Time  `json:"accepted_at" db:"accepted_at"`
+}
+
+// ConsentStatus 用户的协议同意状态
+type ConsentStatus struct {
+	Accepted []*UserConsent   `json:"accepted"`
+	Pending  []*PolicyVersion `json:"pending"` // 尚未同意的强制版本
+	Latest   []*PolicyVersion `json:"latest"`
+	Blocked  bool             `json:"blocked"`
+}
+
+// ConsentRepository 协议同意仓库接口
+type ConsentRepository interface {
+	CreateVersion(ctx context.Context, version *PolicyVersion) error
+	GetVersion(ctx context.Context, policyType PolicyType, version string) (*PolicyVersion, error)
+	ListVersions(ctx context.Context, policyType PolicyType) ([]*PolicyVersion, error)
+	GetLatestVersions(ctx context.Context) ([]*PolicyVersion, error)
+	GetLatestMandatoryVersions(ctx context.Context) ([]*PolicyVersion, error)
+	CreateConsent(ctx context.Context, consent *UserConsent) error
+	GetUserConsents(ctx context.Context, userID string) ([]*UserConsent, error)
+	// HasAcceptedSince 用户是否同意过指定时间之后（含）发布的该类协议版本
+	HasAcceptedSince(ctx context.Context, userID string, policyType PolicyType, since time.Time) (bool, error)
+}
+
+// ConsentService 协议同意服务接口
+type ConsentService interface {
+	PublishVersion(ctx context.Context, adminID string, req *PublishPolicyVersionRequest) (*PolicyVersion, error)
+	ListVersions(ctx context.Context, policyType PolicyType) ([]*PolicyVersion, error)
+	GetLatestVersions(ctx context.Context) ([]*PolicyVersion, error)
+	GetConsentStatus(ctx context.Context, userID string) (*ConsentStatus, error)
+	AcceptVersions(ctx context.Context, userID string, req *AcceptConsentRequest, ipAddress, userAgent string) (*ConsentStatus, error)
+	HasPendingConsents(ctx context.Context, userID string) (bool, error)
+}
+
+// PublishPolicyVersionRequest 发布协议版本请求
+type PublishPolicyVersionRequest struct {
+	PolicyType PolicyType `json:"policy_type"`
+	Version    string     `json:"version"`
+	URL        string     `json:"url"`
+	Summary    string     `json:"summary"`
+	Mandatory  bool       `json:"mandatory"`
+}
+
+// AcceptConsentRequest 同意协议请求
+type AcceptConsentRequest struct {
+	Consents []ConsentItem `json:"consents"`
+}
+
+// ConsentItem 单个协议版本
+type ConsentItem struct {
+	PolicyType PolicyType `json:"policy_type"`
+	Version    string     `json:"version"`
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/neohope/chatapp/user-service/pkg/validation"
+)
+
+// MaxContactImportHashes 单次通讯录导入最多提交的哈希数量，手机号和邮箱分别计算
+const MaxContactImportHashes = 1000
+
+// 通讯录匹配方式
+const (
+	ContactMatchedByPhone = "phone"
+	ContactMatchedByEmail = "email"
+)
+
+// NormalizeContactPhone 规范化手机号，只保留数字（国家码不带+号），客户端哈希前必须使用相同规则
+func NormalizeContactPhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+}
+
+// NormalizeContactEmail 规范化邮箱，去除首尾空白并转为小写
+func NormalizeContactEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// HashContact 计算规范化后的手机号或邮箱的SHA-256十六进制摘要
+func HashContact(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// ContactHashes 计算用户邮箱和手机号的摘要，字段为空时返回空字符串，避免空值互相匹配
+func ContactHashes(user *User) (emailHash, phoneHash string) {
+	if email := NormalizeContactEmail(user.Email); email != "" {
+		emailHash = HashContact(email)
+	}
+	if phone := NormalizeContactPhone(user.Phone); phone != "" {
+		phoneHash = HashContact(phone)
+	}
+	return emailHash, phoneHash
+}
+
+// ContactImportRequest 通讯录导入请求，只包含规范化后手机号/邮箱的SHA-256摘要，服务端不接收明文
+type ContactImportRequest struct {
+	PhoneHashes []string `json:"phone_hashes" validate:"max=1000"`
+	EmailHashes []string `json:"email_hashes" validate:"max=1000"`
+}
+
+// ValidateStruct 至少提交一个哈希，且每个哈希都是64位十六进制字符串
+func (req *ContactImportRequest) ValidateStruct() validation.Errors {
+	var errs validation.Errors
+	if len(req.PhoneHashes) == 0 && len(req.EmailHashes) == 0 {
+		errs = append(errs, validation.FieldError{
+			Field:   "phone_hashes",
+			Rule:    "required",
+			Message: "phone_hashes or email_hashes is required",
+		})
+	}
+	errs = append(errs, validateHashes("phone_hashes", req.PhoneHashes)...)
+	errs = append(errs, validateHashes("email_hashes", req.EmailHashes)...)
+	return errs
+}
+
+// validateHashes 校验一组哈希的格式
+func validateHashes(field string, hashes []string) validation.Errors {
+	var errs validation.Errors
+	for i, hash := range hashes {
+		if !isSHA256Hex(hash) {
+			name := fmt.Sprintf("%s[%d]", field, i)
+			errs = append(errs, validation.FieldError{
+				Field:   name,
+				Rule:    "sha256",
+				Message: name + " must be a hex encoded SHA-256 digest",
+			})
+		}
+	}
+	return errs
+}
+
+// isSHA256Hex 检查字符串是否为64位十六进制摘要
+func isSHA256Hex(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.Is(unicode.ASCII_Hex_Digit, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContactCandidate 通讯录哈希命中的可被发现用户，附带用户手机号/邮箱的摘要用于对应匹配项
+type ContactCandidate struct {
+	ID        string `db:"id"`
+	Username  string `db:"username"`
+	FullName  string `db:"full_name"`
+	AvatarURL string `db:"avatar_url"`
+	EmailHash string `db:"email_hash"`
+	PhoneHash string `db:"phone_hash"`
+}
+
+// ContactMatch 通讯录匹配结果，Hash为命中的客户端摘要，便于客户端对应到本地联系人
+type ContactMatch struct {
+	Hash      string         `json:"hash"`
+	MatchedBy string         `json:"matched_by"`
+	Profile   *PublicProfile `json:"profile"`
+}
+
+// ContactImportResponse 通讯录导入响应
+type ContactImportResponse struct {
+	Matches []*ContactMatch `json:"matches"`
+}
+
+// ContactRepository 通讯录匹配仓库接口，只返回状态正常且未关闭"可通过手机号/邮箱找到我"的用户
+type ContactRepository interface {
+	FindDiscoverable(ctx context.Context, phoneHashes, emailHashes []string) ([]*ContactCandidate, error)
+}
+
+// ContactService 通讯录导入服务接口
+type ContactService interface {
+	ImportContacts(ctx context.Context, userID string, req *ContactImportRequest) (*ContactImportResponse, error)
+}
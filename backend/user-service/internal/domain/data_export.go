@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/neohope/chatapp/user-service/pkg/jobs"
+)
+
+// DataExportStatus 个人数据导出任务状态
+type DataExportStatus string
+
+const (
+	DataExportStatusPending   DataExportStatus = "pending"
+	DataExportStatusRunning   DataExportStatus = "running"
+	DataExportStatusCompleted DataExportStatus = "completed"
+	DataExportStatusFailed    DataExportStatus = "failed"
+)
+
+// DataExport 个人数据导出任务，完成后可以下载zip归档，过期后归档被删除
+type DataExport struct {
+	ID           string           `json:"id" db:"id"`
+	UserID       string           `json:"user_id" db:"user_id"`
+	Status       DataExportStatus `json:"status" db:"status"`
+	SizeBytes    int64            `json:"size_bytes" db:"size_bytes"`
+	MessageCount int              `json:"message_count" db:"message_count"`
+	MediaCount   int              `json:"media_count" db:"media_count"`
+	// Truncated 消息数量超过上限，超出部分没有导出
+	Truncated  bool       `json:"truncated" db:"truncated"`
+	Message    string     `json:"message,omitempty" db:"message"` // 任务失败的原因
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty" db:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+}
+
+// DataExportRepository 个人数据导出仓库接口
+type DataExportRepository interface {
+	Create(ctx context.Context, export *DataExport) error
+	Update(ctx context.Context, export *DataExport) error
+	Get(ctx context.Context, id string) (*DataExport, error)
+	// GetLatest 获取用户最近一次导出，没有时返回nil
+	GetLatest(ctx context.Context, userID string) (*DataExport, error)
+	// Complete 保存归档并把任务标记为完成
+	Complete(ctx context.Context, export *DataExport, archive []byte) error
+	GetArchive(ctx context.Context, id string) ([]byte, error)
+	// DeleteExpired 删除过期的导出任务和归档
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// DataExportService 个人数据导出服务接口
+type DataExportService interface {
+	// RequestExport 创建导出任务并在后台生成归档，已有进行中的任务时直接返回该任务
+	RequestExport(ctx context.Context, userID string) (*DataExport, error)
+	// GetLatestExport 获取用户最近一次导出的状态，供客户端轮询
+	GetLatestExport(ctx context.Context, userID string) (*DataExport, error)
+	// DownloadExport 获取已完成的导出归档
+	DownloadExport(ctx context.Context, userID string) (*DataExport, []byte, error)
+	// ScheduleJobs 注册生成归档和清理过期归档的后台任务
+	ScheduleJobs(runner *jobs.Runner) error
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// AccountDeactivation 账户停用记录
+type AccountDeactivation struct {
+	UserID            string    `json:"user_id" db:"user_id"`
+	Reason            string    `json:"reason,omitempty" db:"reason"`
+	ReactivateOnLogin bool      `json:"reactivate_on_login" db:"reactivate_on_login"` // 下次登录时自动重新启用
+	DeactivatedAt     time.Time `json:"deactivated_at" db:"deactivated_at"`
+}
+
+// DeactivationRepository 账户停用仓库接口
+type DeactivationRepository interface {
+	Create(ctx context.Context, deactivation *AccountDeactivation) error
+	Get(ctx context.Context, userID string) (*AccountDeactivation, error)
+	Delete(ctx context.Context, userID string) error
+}
+
+// AccountService 账户停用/启用服务接口
+type AccountService interface {
+	Deactivate(ctx context.Context, userID string, req *DeactivateAccountRequest) (*AccountDeactivation, error)
+	Reactivate(ctx context.Context, identifier, password string) (*LoginResponse, error)
+}
+
+// DeactivateAccountRequest 停用账户请求
+type DeactivateAccountRequest struct {
+	Password          string `json:"password"`
+	Reason            string `json:"reason,omitempty"`
+	ReactivateOnLogin bool   `json:"reactivate_on_login"`
+}
+
+// ReactivateAccountRequest 重新启用账户请求
+type ReactivateAccountRequest struct {
+	Identifier string `json:"identifier"`
+	Password   string `json:"password"`
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// IdentityLink 外部IdP主体到内部用户的映射，供网关对第三方客户端的不透明令牌做内省后识别用户
+type IdentityLink struct {
+	ID        string    `json:"id" db:"id"`
+	Issuer    string    `json:"issuer" db:"issuer"`   // 外部IdP标识，与内省结果中的iss一致
+	Subject   string    `json:"subject" db:"subject"` // 外部IdP中的主体标识（sub）
+	UserID    string    `json:"user_id" db:"user_id"`
+	CreatedBy string    `json:"created_by" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ResolvedIdentity 外部主体解析出的内部用户身份
+type ResolvedIdentity struct {
+	UserID   string `json:"user_id"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+// IdentityLinkRepository 外部身份映射仓库接口
+type IdentityLinkRepository interface {
+	Create(ctx context.Context, link *IdentityLink) error
+	GetBySubject(ctx context.Context, issuer, subject string) (*IdentityLink, error)
+	List(ctx context.Context, userID string) ([]*IdentityLink, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// IdentityLinkService 外部身份映射服务接口
+type IdentityLinkService interface {
+	CreateLink(ctx context.Context, adminID string, req *CreateIdentityLinkRequest) (*IdentityLink, error)
+	ListLinks(ctx context.Context, userID string) ([]*IdentityLink, error)
+	DeleteLink(ctx context.Context, id string) error
+	// Resolve 解析外部主体对应的内部用户，未映射或用户不可用时返回错误
+	Resolve(ctx context.Context, issuer, subject string) (*ResolvedIdentity, error)
+}
+
+// CreateIdentityLinkRequest 创建外部身份映射请求
+type CreateIdentityLinkRequest struct {
+	Issuer  string `json:"issuer"`
+	Subject string `json:"subject"`
+	UserID  string `json:"user_id"`
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// LoginIdentityType 登录身份类型
+type LoginIdentityType string
+
+const (
+	LoginIdentityEmail  LoginIdentityType = "email"
+	LoginIdentityPhone  LoginIdentityType = "phone"
+	LoginIdentityGoogle LoginIdentityType = "google"
+	LoginIdentityGitHub LoginIdentityType = "github"
+)
+
+// LoginIdentity 账户上一个已验证的登录身份，同一账户可以关联多个，每个身份只能属于一个账户
+type LoginIdentity struct {
+	ID     string            `json:"id" db:"id"`
+	UserID string            `json:"user_id" db:"user_id"`
+	Type   LoginIdentityType `json:"type" db:"type"`
+	// Identifier 邮箱（小写）、E.164格式手机号或第三方账号的稳定标识
+	Identifier string `json:"identifier" db:"identifier"`
+	// DisplayName 第三方账号的展示名（Google邮箱、GitHub登录名）
+	DisplayName string    `json:"display_name,omitempty" db:"display_name"`
+	VerifiedAt  time.Time `json:"verified_at" db:"verified_at"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// IdentityVerification 关联邮箱或手机号时发出的验证码，只保存哈希
+type IdentityVerification struct {
+	UserID     string            `db:"user_id"`
+	Type       LoginIdentityType `db:"type"`
+	Identifier string            `db:"identifier"`
+	CodeHash   string            `db:"code_hash"`
+	Attempts   int               `db:"attempts"`
+	ExpiresAt  time.Time         `db:"expires_at"`
+	CreatedAt  time.Time         `db:"created_at"`
+}
+
+// LoginIdentityRepository 登录身份仓库接口
+type LoginIdentityRepository interface {
+	// Create 创建登录身份，身份已被关联时返回错误
+	Create(ctx context.Context, identity *LoginIdentity) error
+	GetByIdentifier(ctx context.Context, identityType LoginIdentityType, identifier string) (*LoginIdentity, error)
+	List(ctx context.Context, userID string) ([]*LoginIdentity, error)
+	Count(ctx context.Context, userID string) (int, error)
+	// Delete 删除用户的登录身份，是用户最后一个登录身份时返回错误
+	Delete(ctx context.Context, userID, id string) error
+
+	// SaveVerification 保存验证码，覆盖同一身份之前的验证码并重置尝试次数
+	SaveVerification(ctx context.Context, verification *IdentityVerification) error
+	GetVerification(ctx context.Context, userID string, identityType LoginIdentityType, identifier string) (*IdentityVerification, error)
+	IncrementVerificationAttempts(ctx context.Context, userID string, identityType LoginIdentityType, identifier string) error
+	DeleteVerification(ctx context.Context, userID string, identityType LoginIdentityType, identifier string) error
+}
+
+// LoginIdentityService 登录身份服务接口
+type LoginIdentityService interface {
+	ListIdentities(ctx context.Context, userID string) ([]*LoginIdentity, error)
+	// StartVerification 向待关联的邮箱或手机号发送验证码
+	StartVerification(ctx context.Context, userID string, req *LinkIdentityRequest) error
+	// VerifyIdentity 校验验证码并关联邮箱或手机号
+	VerifyIdentity(ctx context.Context, userID string, req *VerifyIdentityRequest) (*LoginIdentity, error)
+	// LinkOAuth 校验第三方令牌并关联Google/GitHub账号
+	LinkOAuth(ctx context.Context, userID string, req *OAuthIdentityRequest) (*LoginIdentity, error)
+	// UnlinkIdentity 取消关联，不能取消最后一个登录身份
+	UnlinkIdentity(ctx context.Context, userID, id string) error
+	// LoginWithOAuth 使用已关联的Google/GitHub账号登录
+	LoginWithOAuth(ctx context.Context, req *OAuthIdentityRequest) (*LoginResponse, error)
+}
+
+// LinkIdentityRequest 关联邮箱或手机号请求
+type LinkIdentityRequest struct {
+	Type       LoginIdentityType `json:"type" validate:"required,oneof=email phone"`
+	Identifier string            `json:"identifier" validate:"required,max=100"`
+}
+
+// VerifyIdentityRequest 提交验证码请求
+type VerifyIdentityRequest struct {
+	Type       LoginIdentityType `json:"type" validate:"required,oneof=email phone"`
+	Identifier string            `json:"identifier" validate:"required,max=100"`
+	Code       string            `json:"code" validate:"required,len=6"`
+}
+
+// OAuthIdentityRequest 关联或登录Google/GitHub账号请求，Google为ID令牌，GitHub为访问令牌
+type OAuthIdentityRequest struct {
+	Provider LoginIdentityType `json:"provider" validate:"required,oneof=google github"`
+	Token    string            `json:"token" validate:"required"`
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "context"
+
+// @提及自动补全的返回数量
+const (
+	DefaultMentionLimit = 10
+	MaxMentionLimit     = 20
+)
+
+// MentionCandidate @提及候选用户，IsParticipant表示是会话成员，否则为好友
+type MentionCandidate struct {
+	ID            string `json:"id" db:"id"`
+	Username      string `json:"username" db:"username"`
+	FullName      string `json:"full_name" db:"full_name"`
+	AvatarURL     string `json:"avatar_url" db:"avatar_url"`
+	IsParticipant bool   `json:"is_participant" db:"is_participant"`
+}
+
+// MentionRepository @提及候选仓库接口
+type MentionRepository interface {
+	// FindByUsernamePrefix 在participantIDs和userID的好友中按用户名前缀（不区分大小写）查找活跃用户，不含userID本人
+	FindByUsernamePrefix(ctx context.Context, userID, prefix string, participantIDs []string, limit int) ([]*MentionCandidate, error)
+}
+
+// MentionService @提及自动补全服务接口
+type MentionService interface {
+	// Autocomplete conversationID为空时只在好友中查找
+	Autocomplete(ctx context.Context, userID, prefix, conversationID string, limit int) ([]*MentionCandidate, error)
+}
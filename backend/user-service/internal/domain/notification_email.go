@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// 通知邮箱地址的来源
+const (
+	NotificationEmailSourceNotification = "notification"
+	NotificationEmailSourceAccount      = "account"
+)
+
+// NotificationEmail 用户单独设置的通知邮箱，与登录邮箱相互独立，验证通过后才用于发送通知邮件
+type NotificationEmail struct {
+	UserID         string     `json:"user_id" db:"user_id"`
+	Email          string     `json:"email" db:"email"`
+	Verified       bool       `json:"verified" db:"-"`
+	VerifiedAt     *time.Time `json:"verified_at,omitempty" db:"verified_at"`
+	TokenHash      string     `json:"-" db:"token_hash"`
+	TokenExpiresAt *time.Time `json:"-" db:"token_expires_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationAddress 通知服务发送邮件时使用的地址，未设置或未验证通知邮箱时为登录邮箱
+type NotificationAddress struct {
+	Email  string `json:"email"`
+	Source string `json:"source"`
+}
+
+// NotificationEmailRepository 通知邮箱仓库接口
+type NotificationEmailRepository interface {
+	// Upsert 设置通知邮箱并重置验证状态
+	Upsert(ctx context.Context, email *NotificationEmail) error
+	Get(ctx context.Context, userID string) (*NotificationEmail, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*NotificationEmail, error)
+	// MarkVerified 标记通知邮箱已验证并作废验证令牌，令牌已被使用时返回错误
+	MarkVerified(ctx context.Context, userID, tokenHash string, verifiedAt time.Time) error
+	Delete(ctx context.Context, userID string) error
+}
+
+// NotificationEmailService 通知邮箱服务接口
+type NotificationEmailService interface {
+	GetNotificationEmail(ctx context.Context, userID string) (*NotificationEmail, error)
+	// SetNotificationEmail 设置通知邮箱并发送验证邮件，重复设置时重新发送
+	SetNotificationEmail(ctx context.Context, userID, email string) (*NotificationEmail, error)
+	// VerifyNotificationEmail 使用验证邮件中的令牌确认通知邮箱
+	VerifyNotificationEmail(ctx context.Context, token string) (*NotificationEmail, error)
+	RemoveNotificationEmail(ctx context.Context, userID string) error
+	// ResolveAddress 返回发送通知邮件时应使用的地址
+	ResolveAddress(ctx context.Context, userID string) (*NotificationAddress, error)
+}
+
+// SetNotificationEmailRequest 设置通知邮箱请求
+type SetNotificationEmailRequest struct {
+	Email string `json:"email" validate:"required,email,max=100"`
+}
+
+// VerifyNotificationEmailRequest 验证通知邮箱请求
+type VerifyNotificationEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Visibility 资料字段可见范围
+type Visibility string
+
+const (
+	VisibilityEveryone Visibility = "everyone"
+	VisibilityFriends  Visibility = "friends"
+	VisibilityNobody   Visibility = "nobody"
+)
+
+// IsValidVisibility 检查可见范围是否有效
+func IsValidVisibility(v Visibility) bool {
+	return v == VisibilityEveryone || v == VisibilityFriends || v == VisibilityNobody
+}
+
+// PrivacySettings 用户资料字段的可见性设置
+type PrivacySettings struct {
+	UserID             string     `json:"user_id" db:"user_id"`
+	EmailVisibility    Visibility `json:"email_visibility" db:"email_visibility"`
+	PhoneVisibility    Visibility `json:"phone_visibility" db:"phone_visibility"`
+	LastSeenVisibility Visibility `json:"last_seen_visibility" db:"last_seen_visibility"`
+	Discoverable       bool       `json:"discoverable" db:"discoverable"` // 是否允许他人通过通讯录中的手机号/邮箱找到自己
+	UpdatedAt          time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// DefaultPrivacySettings 未设置时的默认隐私设置，敏感字段仅好友可见，默认可通过通讯录被找到
+func DefaultPrivacySettings(userID string) *PrivacySettings {
+	return &PrivacySettings{
+		UserID:             userID,
+		EmailVisibility:    VisibilityFriends,
+		PhoneVisibility:    VisibilityFriends,
+		LastSeenVisibility: VisibilityFriends,
+		Discoverable:       true,
+	}
+}
+
+// PublicProfile 按隐私设置过滤后的用户资料，不可见的字段为空
+type PublicProfile struct {
+	ID         string     `json:"id"`
+	Username   string     `json:"username"`
+	FullName   string     `json:"full_name"`
+	AvatarURL  string     `json:"avatar_url"`
+	Email      string     `json:"email,omitempty"`
+	Phone      string     `json:"phone,omitempty"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	IsFriend   bool       `json:"is_friend"`
+	IsSelf     bool       `json:"is_self"`
+}
+
+// NewPublicProfile 生成不含隐私字段的资料，用于列表和搜索结果；本人可见全部字段
+func NewPublicProfile(user *User, viewerID string) *PublicProfile {
+	profile := &PublicProfile{
+		ID:        user.ID,
+		Username:  user.Username,
+		FullName:  user.FullName,
+		AvatarURL: user.AvatarURL,
+		IsSelf:    user.ID == viewerID,
+	}
+	if profile.IsSelf {
+		profile.Email = user.Email
+		profile.Phone = user.Phone
+		profile.LastSeenAt = user.LastSeenAt
+	}
+	return profile
+}
+
+// NewPublicProfiles 批量生成不含隐私字段的资料
+func NewPublicProfiles(users []*User, viewerID string) []*PublicProfile {
+	profiles := make([]*PublicProfile, 0, len(users))
+	for _, user := range users {
+		profiles = append(profiles, NewPublicProfile(user, viewerID))
+	}
+	return profiles
+}
+
+// PrivacyRepository 隐私设置仓库接口
+type PrivacyRepository interface {
+	Get(ctx context.Context, userID string) (*PrivacySettings, error)
+	Upsert(ctx context.Context, settings *PrivacySettings) error
+}
+
+// ProfileService 用户资料服务接口
+type ProfileService interface {
+	GetProfile(ctx context.Context, viewerID, userID string) (*PublicProfile, error)
+	GetPrivacySettings(ctx context.Context, userID string) (*PrivacySettings, error)
+	UpdatePrivacySettings(ctx context.Context, userID string, req *UpdatePrivacySettingsRequest) (*PrivacySettings, error)
+}
+
+// UpdatePrivacySettingsRequest 更新隐私设置请求，空字段保持不变
+type UpdatePrivacySettingsRequest struct {
+	EmailVisibility    Visibility `json:"email_visibility,omitempty"`
+	PhoneVisibility    Visibility `json:"phone_visibility,omitempty"`
+	LastSeenVisibility Visibility `json:"last_seen_visibility,omitempty"`
+	Discoverable       *bool      `json:"discoverable,omitempty"`
+}
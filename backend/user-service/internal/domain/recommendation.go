@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/neohope/chatapp/user-service/pkg/jobs"
+)
+
+// 兴趣资料限制
+const (
+	MaxBioLength      = 500
+	MaxInterests      = 20
+	MaxInterestLength = 32
+)
+
+// InterestProfile 用户简介和兴趣，用于推荐可能认识的人
+type InterestProfile struct {
+	UserID    string    `json:"user_id" db:"user_id"`
+	Bio       string    `json:"bio" db:"bio"`
+	Interests []string  `json:"interests" db:"-"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RecommendedUser 推荐的用户，Score为与当前用户的相似度（0~1）
+type RecommendedUser struct {
+	ID              string   `json:"id" db:"id"`
+	Username        string   `json:"username" db:"username"`
+	FullName        string   `json:"full_name" db:"full_name"`
+	AvatarURL       string   `json:"avatar_url" db:"avatar_url"`
+	Score           float64  `json:"score" db:"score"`
+	Interests       []string `json:"-" db:"-"`
+	SharedInterests []string `json:"shared_interests"`
+}
+
+// InterestRepository 用户兴趣资料仓库接口
+type InterestRepository interface {
+	Get(ctx context.Context, userID string) (*InterestProfile, error)
+	Upsert(ctx context.Context, profile *InterestProfile) error
+}
+
+// EmbeddingRepository 用户向量仓库接口，向量按模型区分
+type EmbeddingRepository interface {
+	Get(ctx context.Context, userID, model string) ([]float32, error)
+	Save(ctx context.Context, userID, model string, embedding []float32, sourceUpdatedAt time.Time) error
+	Delete(ctx context.Context, userID string) error
+	// ListStale 列出兴趣资料在向量生成之后有更新（或尚无向量）的用户
+	ListStale(ctx context.Context, model string, limit int) ([]*InterestProfile, error)
+	// NearestNeighbors 按余弦距离查找最相近的活跃用户，排除本人、好友和待处理的好友请求双方
+	NearestNeighbors(ctx context.Context, userID, model string, embedding []float32, limit, offset int) ([]*RecommendedUser, error)
+}
+
+// RecommendationService 推荐用户服务接口
+type RecommendationService interface {
+	GetInterests(ctx context.Context, userID string) (*InterestProfile, error)
+	UpdateInterests(ctx context.Context, userID string, req *UpdateInterestsRequest) (*InterestProfile, error)
+	GetRecommendedUsers(ctx context.Context, userID string, limit, offset int) ([]*RecommendedUser, error)
+	// ScheduleJobs 注册后台向量补算任务
+	ScheduleJobs(runner *jobs.Runner) error
+}
+
+// UpdateInterestsRequest 更新简介和兴趣请求
+type UpdateInterestsRequest struct {
+	Bio       string   `json:"bio"`
+	Interests []string `json:"interests"`
+}
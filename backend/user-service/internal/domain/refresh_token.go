@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused 已轮换的刷新令牌被再次使用，视为泄露，整个令牌族已被吊销
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// RefreshToken 刷新令牌记录，同一次登录轮换出的令牌属于同一令牌族
+type RefreshToken struct {
+	ID         string     `json:"id" db:"id"`
+	UserID     string     `json:"user_id" db:"user_id"`
+	FamilyID   string     `json:"family_id" db:"family_id"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	ReplacedBy *string    `json:"replaced_by,omitempty" db:"replaced_by"`
+}
+
+// RefreshTokenRepository 刷新令牌仓库接口
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *RefreshToken) error
+	GetByID(ctx context.Context, id string) (*RefreshToken, error)
+	// Rotate 吊销旧令牌并保存新令牌，旧令牌已被吊销时返回false
+	Rotate(ctx context.Context, oldID string, next *RefreshToken) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// RefreshTokenService 刷新令牌服务接口
+type RefreshTokenService interface {
+	// IssueSession 登录成功后签发访问令牌和新令牌族的刷新令牌，所有登录入口都通过它签发
+	IssueSession(ctx context.Context, user *User) (*LoginResponse, error)
+	// Refresh 用刷新令牌换取新的访问令牌和刷新令牌，旧刷新令牌随即失效
+	Refresh(ctx context.Context, refreshToken string) (*LoginResponse, error)
+	// Revoke 吊销刷新令牌所在的令牌族（退出登录）
+	Revoke(ctx context.Context, refreshToken string) error
+	// RevokeAll 吊销用户的所有刷新令牌（修改密码等）
+	RevokeAll(ctx context.Context, userID string) error
+}
+
+// RefreshTokenRequest 刷新令牌请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/neohope/chatapp/user-service/pkg/validation"
+)
+
+// ErrSettingModified 保存设置时发现已被其他请求修改，需要重新读取后再合并
+var ErrSettingModified = errors.New("setting modified concurrently")
+
+// settingKeyPattern 设置键只允许小写字母、数字和._-，如theme、chat.wallpaper、send_on_enter
+var settingKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+func init() {
+	validation.Register("setting_key", "must be 1-64 lowercase letters, digits or ._-", func(value interface{}, _ string) bool {
+		key, ok := value.(string)
+		return ok && settingKeyPattern.MatchString(key)
+	})
+}
+
+// VectorClock 设置的向量时间戳，记录每台设备对该设置的修改次数
+type VectorClock map[string]uint64
+
+// ClockOrder 两个向量时间戳的先后关系
+type ClockOrder int
+
+const (
+	ClockEqual ClockOrder = iota
+	ClockBefore
+	ClockAfter
+	// ClockConcurrent 两台设备在互不知情的情况下各自修改，需要按最后写入时间决定取值
+	ClockConcurrent
+)
+
+// Compare 比较c与other的先后关系
+func (c VectorClock) Compare(other VectorClock) ClockOrder {
+	before, after := false, false
+	for device, n := range c {
+		if m := other[device]; n > m {
+			after = true
+		} else if n < m {
+			before = true
+		}
+	}
+	for device, m := range other {
+		if _, ok := c[device]; !ok && m > 0 {
+			before = true
+		}
+	}
+
+	switch {
+	case before && after:
+		return ClockConcurrent
+	case before:
+		return ClockBefore
+	case after:
+		return ClockAfter
+	default:
+		return ClockEqual
+	}
+}
+
+// Merge 返回逐项取最大值的向量时间戳，结果不早于c和other
+func (c VectorClock) Merge(other VectorClock) VectorClock {
+	merged := make(VectorClock, len(c)+len(other))
+	for device, n := range c {
+		merged[device] = n
+	}
+	for device, m := range other {
+		if m > merged[device] {
+			merged[device] = m
+		}
+	}
+	return merged
+}
+
+// UserSetting 用户的一项跨设备同步设置，值为任意JSON，null表示恢复默认
+type UserSetting struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+	Clock VectorClock     `json:"clock"`
+	// DeviceID 最后写入该值的设备
+	DeviceID string `json:"device_id"`
+	// ModifiedAt 客户端修改的时间，并发修改时较晚者胜出
+	ModifiedAt time.Time `json:"modified_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// Revision 服务端修订号，用于保存时检测并发修改
+	Revision int64 `json:"-"`
+}
+
+// 设置同步结果
+const (
+	// SettingApplied 客户端的修改晚于服务端，直接采用
+	SettingApplied = "applied"
+	// SettingStale 客户端的修改不晚于服务端，忽略
+	SettingStale = "stale"
+	// SettingConflictWon 并发修改，客户端的修改时间较晚而胜出
+	SettingConflictWon = "conflict_won"
+	// SettingConflictLost 并发修改，服务端的值较晚而保留，时间戳已合并
+	SettingConflictLost = "conflict_lost"
+)
+
+// SettingResult 单项设置的同步结果，Setting为同步后服务端的状态
+type SettingResult struct {
+	Key     string       `json:"key"`
+	Status  string       `json:"status"`
+	Setting *UserSetting `json:"setting"`
+}
+
+// UserSettings 用户的全部同步设置
+type UserSettings struct {
+	Settings []*UserSetting `json:"settings"`
+}
+
+// SettingsRepository 用户设置仓库接口
+type SettingsRepository interface {
+	List(ctx context.Context, userID string) ([]*UserSetting, error)
+	Get(ctx context.Context, userID, key string) (*UserSetting, error)
+	Count(ctx context.Context, userID string) (int, error)
+	// Save 按setting.Revision保存，修订号为0时新建；期间已被修改时返回ErrSettingModified，成功后递增修订号
+	Save(ctx context.Context, userID string, setting *UserSetting) error
+}
+
+// SettingsService 用户设置同步服务接口
+type SettingsService interface {
+	GetSettings(ctx context.Context, userID string) (*UserSettings, error)
+	// SyncSettings 按向量时间戳合并客户端的修改，并发修改时最后写入者胜出
+	SyncSettings(ctx context.Context, userID string, req *SyncSettingsRequest) ([]*SettingResult, error)
+}
+
+// SyncSettingsRequest 同步设置请求
+type SyncSettingsRequest struct {
+	DeviceID string               `json:"device_id" validate:"required,max=64"`
+	Settings []SettingChangeInput `json:"settings" validate:"required,min=1,max=50"`
+}
+
+// SettingChangeInput 客户端对一项设置的修改，Clock为客户端已知的时间戳并已递增本设备的计数
+type SettingChangeInput struct {
+	Key        string          `json:"key" validate:"required,setting_key"`
+	Value      json.RawMessage `json:"value" validate:"max=4096"`
+	Clock      VectorClock     `json:"clock" validate:"required"`
+	ModifiedAt time.Time       `json:"modified_at"`
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// SSOProtocol 单点登录协议
+type SSOProtocol string
+
+const (
+	SSOProtocolOIDC SSOProtocol = "oidc"
+	SSOProtocolSAML SSOProtocol = "saml"
+)
+
+// SSOAttributeMapping IdP声明/属性到用户字段的映射
+type SSOAttributeMapping struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	FullName string `json:"full_name"`
+	Groups   string `json:"groups"`
+}
+
+// SSOProvider 企业租户的身份提供方（IdP）配置
+type SSOProvider struct {
+	ID       string      `json:"id"`
+	Tenant   string      `json:"tenant"` // 租户标识，用于登录URL
+	Name     string      `json:"name"`
+	Protocol SSOProtocol `json:"protocol"`
+	Enabled  bool        `json:"enabled"`
+
+	// OIDC配置
+	Issuer       string   `json:"issuer,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"-"`
+	Scopes       []string `json:"scopes,omitempty"`
+
+	// SAML配置，元数据URL与元数据XML二选一
+	IdPMetadataURL string `json:"idp_metadata_url,omitempty"`
+	IdPMetadataXML string `json:"idp_metadata_xml,omitempty"`
+
+	AttributeMapping SSOAttributeMapping `json:"attribute_mapping"`
+	// GroupRoles IdP组名到角色的映射，键不区分大小写
+	GroupRoles map[string]string `json:"group_roles,omitempty"`
+	// AllowedDomains 允许登录的邮箱域名，为空表示不限制
+	AllowedDomains []string `json:"allowed_domains,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SSOIdentity IdP断言校验通过后得到的用户身份
+type SSOIdentity struct {
+	Issuer   string
+	Subject  string
+	Username string
+	Email    string
+	FullName string
+	Groups   []string
+}
+
+// SSOIdentityLink 租户IdP主体与本地用户的关联，SSO登录只通过关联匹配用户
+type SSOIdentityLink struct {
+	ID        string    `json:"id" db:"id"`
+	Tenant    string    `json:"tenant" db:"tenant"`
+	Issuer    string    `json:"issuer" db:"issuer"`
+	Subject   string    `json:"subject" db:"subject"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// SSORepository 单点登录配置仓库接口
+type SSORepository interface {
+	CreateProvider(ctx context.Context, provider *SSOProvider) error
+	GetProviderByTenant(ctx context.Context, tenant string) (*SSOProvider, error)
+	ListProviders(ctx context.Context) ([]*SSOProvider, error)
+	UpdateProvider(ctx context.Context, provider *SSOProvider) error
+	DeleteProvider(ctx context.Context, tenant string) error
+	// GetIdentityLink 按租户、IdP标识和主体查找关联的本地用户
+	GetIdentityLink(ctx context.Context, tenant, issuer, subject string) (*SSOIdentityLink, error)
+	CreateIdentityLink(ctx context.Context, link *SSOIdentityLink) error
+}
+
+// SSOService 单点登录服务接口
+type SSOService interface {
+	SaveProvider(ctx context.Context, req *SSOProviderRequest) (*SSOProvider, error)
+	ListProviders(ctx context.Context) ([]*SSOProvider, error)
+	DeleteProvider(ctx context.Context, tenant string) error
+	// BeginLogin 发起SP端登录，返回跳转到IdP的地址
+	BeginLogin(ctx context.Context, tenant string) (string, error)
+	CompleteOIDCLogin(ctx context.Context, tenant, state, code string) (*LoginResponse, error)
+	CompleteSAMLLogin(ctx context.Context, tenant, samlResponse, relayState string) (*LoginResponse, error)
+	SAMLMetadata(ctx context.Context, tenant string) ([]byte, error)
+}
+
+// SSOProviderRequest 创建/更新IdP配置请求
+type SSOProviderRequest struct {
+	Tenant           string              `json:"tenant"`
+	Name             string              `json:"name"`
+	Protocol         SSOProtocol         `json:"protocol"`
+	Enabled          bool                `json:"enabled"`
+	Issuer           string              `json:"issuer"`
+	ClientID         string              `json:"client_id"`
+	ClientSecret     string              `json:"client_secret"` // 更新时为空表示保留原值
+	Scopes           []string            `json:"scopes"`
+	IdPMetadataURL   string              `json:"idp_metadata_url"`
+	IdPMetadataXML   string              `json:"idp_metadata_xml"`
+	AttributeMapping SSOAttributeMapping `json:"attribute_mapping"`
+	GroupRoles       map[string]string   `json:"group_roles"`
+	AllowedDomains   []string            `json:"allowed_domains"`
+}
@@ -3,6 +3,8 @@ package domain
 import (
 	"context"
 	"time"
+
+	"github.com/neohope/chatapp/user-service/pkg/validation"
 )
 
 // UserStatus 用户状态枚举
@@ -12,19 +14,66 @@ const (
 	UserStatusActive   UserStatus = "active"
 	UserStatusInactive UserStatus = "inactive"
 	UserStatusBlocked  UserStatus = "blocked"
+	// UserStatusDeactivated 用户主动停用，数据保留，可重新启用
+	UserStatusDeactivated UserStatus = "deactivated"
+	// UserStatusSuspended 被管理员暂停，不能登录，只能由管理员恢复
+	UserStatusSuspended UserStatus = "suspended"
+)
+
+// 用户角色
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// 账户认证来源
+const (
+	AuthProviderLocal = "local"
+	AuthProviderLDAP  = "ldap"
+	AuthProviderSSO   = "sso"
 )
 
+// DefaultLanguage 默认界面/通知语言
+const DefaultLanguage = "zh-CN"
+
+// SupportedLanguages 支持的语言
+var SupportedLanguages = []string{"zh-CN", "en-US"}
+
+// IsSupportedLanguage 检查语言是否受支持
+func IsSupportedLanguage(language string) bool {
+	for _, l := range SupportedLanguages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	validation.Register("language", "is not a supported language", func(value interface{}, _ string) bool {
+		language, ok := value.(string)
+		return ok && IsSupportedLanguage(language)
+	})
+}
+
 // User 用户实体
 type User struct {
-	ID        string     `json:"id" db:"id"`
-	Username  string     `json:"username" db:"username"`
-	Email     string     `json:"email" db:"email"`
-	Password  string     `json:"-" db:"password"` // 不在JSON中暴露密码
-	FullName  string     `json:"full_name" db:"full_name"`
-	AvatarURL string     `json:"avatar_url" db:"avatar_url"`
-	Status    UserStatus `json:"status" db:"status"`
-	CreatedAt time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	ID              string     `json:"id" db:"id"`
+	Username        string     `json:"username" db:"username"`
+	Email           string     `json:"email" db:"email"`
+	Password        string     `json:"-" db:"password"`         // 不在JSON中暴露密码
+	PasswordVersion int        `json:"-" db:"password_version"` // 密码哈希参数版本
+	TokenVersion    int        `json:"-" db:"token_version"`    // 令牌版本，递增后已签发的令牌全部失效
+	FullName        string     `json:"full_name" db:"full_name"`
+	AvatarURL       string     `json:"avatar_url" db:"avatar_url"`
+	Phone           string     `json:"phone" db:"phone"`
+	Status          UserStatus `json:"status" db:"status"`
+	Role            string     `json:"role" db:"role"`
+	AuthProvider    string     `json:"auth_provider" db:"auth_provider"`
+	Language        string     `json:"language" db:"language"`
+	LastSeenAt      *time.Time `json:"last_seen_at,omitempty" db:"last_seen_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // UserRepository 用户仓库接口
@@ -33,7 +82,12 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id string) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	GetByUsername(ctx context.Context, username string) (*User, error)
+	ExistsByUsername(ctx context.Context, username string) (bool, error)
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	Update(ctx context.Context, user *User) error
+	UpdatePassword(ctx context.Context, id, hashedPassword string, version int) error
+	UpdateLastSeen(ctx context.Context, id string, lastSeen time.Time) error
+	IncrementTokenVersion(ctx context.Context, id string) (int, error)
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, limit, offset int) ([]*User, error)
 	SearchUsers(ctx context.Context, query string, limit, offset int) ([]*User, error)
@@ -42,13 +96,33 @@ type UserRepository interface {
 // UserService 用户服务接口
 type UserService interface {
 	Register(ctx context.Context, user *User, password string) error
-	Login(ctx context.Context, identifier, password string) (string, error) // 返回JWT令牌，identifier可以是邮箱或用户名
+	Login(ctx context.Context, identifier, password string) (*LoginResponse, error) // 返回访问令牌和刷新令牌，identifier可以是邮箱、手机号或用户名
 	GetUserByID(ctx context.Context, id string) (*User, error)
 	UpdateUser(ctx context.Context, user *User) error
 	DeleteUser(ctx context.Context, id string) error
 	ListUsers(ctx context.Context, limit, offset int) ([]*User, error)
 	ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) error
 	SearchUsers(ctx context.Context, query string, limit, offset int) ([]*User, error)
+	CheckAvailability(ctx context.Context, username, email string) (*AvailabilityResult, error)
+}
+
+// 用户名/邮箱不可用的原因
+const (
+	AvailabilityReasonInvalid = "invalid"
+	AvailabilityReasonTaken   = "taken"
+)
+
+// FieldAvailability 单个字段的可用性
+type FieldAvailability struct {
+	Value     string `json:"value"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// AvailabilityResult 注册表单用户名/邮箱可用性检查结果，未查询的字段为空
+type AvailabilityResult struct {
+	Username *FieldAvailability `json:"username,omitempty"`
+	Email    *FieldAvailability `json:"email,omitempty"`
 }
 
 // RegisterRequest 注册请求
@@ -57,6 +131,12 @@ type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
 	FullName string `json:"full_name" validate:"required"`
+	Language string `json:"language,omitempty" validate:"omitempty,language"`
+}
+
+// Validate 按validate标签验证注册请求，失败时返回validation.Errors
+func (req RegisterRequest) Validate() error {
+	return validation.Struct(req)
 }
 
 // LoginRequest 登录请求
@@ -67,18 +147,21 @@ type LoginRequest struct {
 
 // LoginResponse 登录响应
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  *User  `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	User         *User  `json:"user"`
 }
 
 // UpdateUserRequest 更新用户请求
 type UpdateUserRequest struct {
 	FullName  string `json:"full_name"`
 	AvatarURL string `json:"avatar_url"`
+	Phone     string `json:"phone"`
+	Language  string `json:"language"`
 }
 
 // ChangePasswordRequest 修改密码请求
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" validate:"required"`
 	NewPassword string `json:"new_password" validate:"required,min=8"`
-}
\ No newline at end of file
+}
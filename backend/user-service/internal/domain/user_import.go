@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ImportJobStatus 批量导入任务状态
+type ImportJobStatus string
+
+const (
+	ImportJobStatusPending   ImportJobStatus = "pending"
+	ImportJobStatusRunning   ImportJobStatus = "running"
+	ImportJobStatusCompleted ImportJobStatus = "completed"
+	ImportJobStatusFailed    ImportJobStatus = "failed"
+)
+
+// ImportRowError 导入失败行的错误信息，Row为CSV中的行号（表头为第1行）
+type ImportRowError struct {
+	Row      int    `json:"row"`
+	Username string `json:"username,omitempty"`
+	Email    string `json:"email,omitempty"`
+	UserID   string `json:"user_id,omitempty"` // 账户已创建但邀请邮件发送失败时返回
+	Error    string `json:"error"`
+}
+
+// UserImportJob 用户批量导入任务
+type UserImportJob struct {
+	ID            string           `json:"id"`
+	CreatedBy     string           `json:"created_by"`
+	FileName      string           `json:"file_name,omitempty"`
+	Status        ImportJobStatus  `json:"status"`
+	TotalRows     int              `json:"total_rows"`
+	ProcessedRows int              `json:"processed_rows"`
+	CreatedCount  int              `json:"created_count"`
+	FailedCount   int              `json:"failed_count"`
+	Errors        []ImportRowError `json:"errors"`
+	Message       string           `json:"message,omitempty"` // 整个任务失败的原因
+	CreatedAt     time.Time        `json:"created_at"`
+	StartedAt     *time.Time       `json:"started_at,omitempty"`
+	FinishedAt    *time.Time       `json:"finished_at,omitempty"`
+}
+
+// UserInvitation 批量导入账户的邀请，只保存令牌哈希
+type UserInvitation struct {
+	TokenHash  string     `db:"token_hash"`
+	UserID     string     `db:"user_id"`
+	JobID      string     `db:"job_id"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	AcceptedAt *time.Time `db:"accepted_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+// UserImportRepository 批量导入任务和邀请仓库接口
+type UserImportRepository interface {
+	CreateJob(ctx context.Context, job *UserImportJob) error
+	UpdateJob(ctx context.Context, job *UserImportJob) error
+	GetJob(ctx context.Context, id string) (*UserImportJob, error)
+	ListJobs(ctx context.Context, limit, offset int) ([]*UserImportJob, error)
+	// FailInterruptedJobs 把服务重启前未完成的任务标记为失败
+	FailInterruptedJobs(ctx context.Context) (int, error)
+
+	CreateInvitation(ctx context.Context, invitation *UserInvitation) error
+	GetInvitation(ctx context.Context, tokenHash string) (*UserInvitation, error)
+	AcceptInvitation(ctx context.Context, tokenHash string, acceptedAt time.Time) error
+}
+
+// UserImportService 用户批量导入服务接口
+type UserImportService interface {
+	// StartImport 校验CSV表头并创建任务，行在后台异步处理
+	StartImport(ctx context.Context, adminID, fileName string, data []byte) (*UserImportJob, error)
+	GetJob(ctx context.Context, id string) (*UserImportJob, error)
+	ListJobs(ctx context.Context, limit, offset int) ([]*UserImportJob, error)
+	// AcceptInvitation 被邀请用户设置密码并激活账户，返回访问令牌和刷新令牌
+	AcceptInvitation(ctx context.Context, token, password string) (*LoginResponse, error)
+	// Wait 等待后台处理中的导入任务结束，关闭服务前调用
+	Wait()
+}
+
+// AcceptInvitationRequest 接受邀请请求
+type AcceptInvitationRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// AdminUserRepository 实现domain.AdminUserRepository接口
+type AdminUserRepository struct {
+	db *sqlx.DB
+}
+
+// NewAdminUserRepository 创建一个新的管理员用户仓库
+func NewAdminUserRepository(db *sqlx.DB) domain.AdminUserRepository {
+	return &AdminUserRepository{db: db}
+}
+
+// ListUsers 按状态、角色和关键字筛选用户，按注册时间倒序
+func (r *AdminUserRepository) ListUsers(ctx context.Context, filter domain.AdminUserFilter) ([]*domain.User, int, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Role != "" {
+		args = append(args, filter.Role)
+		conditions = append(conditions, fmt.Sprintf("role = $%d", len(args)))
+	}
+	if filter.Query != "" {
+		args = append(args, "%"+filter.Query+"%")
+		conditions = append(conditions, fmt.Sprintf("(username ILIKE $%d OR full_name ILIKE $%d OR email ILIKE $%d)", len(args), len(args), len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM users `+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	users := []*domain.User{}
+	query := fmt.Sprintf(`
+	SELECT id, username, email, password, password_version, token_version, full_name, avatar_url, phone, status, role, auth_provider, language, last_seen_at, created_at, updated_at
+	FROM users
+	%s
+	ORDER BY created_at DESC, id
+	LIMIT $%d OFFSET $%d
+	`, where, len(args)+1, len(args)+2)
+	args = append(args, filter.Limit, filter.Offset)
+	if err := r.db.SelectContext(ctx, &users, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// CountAdmins 统计状态为active的管理员数量
+func (r *AdminUserRepository) CountAdmins(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM users WHERE role = $1 AND status = $2`, domain.RoleAdmin, domain.UserStatusActive)
+	return count, err
+}
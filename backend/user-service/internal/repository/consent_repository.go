@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// ConsentRepository 实现domain.ConsentRepository接口
+type ConsentRepository struct {
+	db *sqlx.DB
+}
+
+// NewConsentRepository 创建一个新的协议同意仓库
+func NewConsentRepository(db *sqlx.DB) domain.ConsentRepository {
+	return &ConsentRepository{db: db}
+}
+
+// CreateVersion 发布协议版本
+func (r *ConsentRepository) CreateVersion(ctx context.Context, version *domain.PolicyVersion) error {
+	if version.ID == "" {
+		version.ID = uuid.New().String()
+	}
+	version.PublishedAt = time.Now()
+
+	query := `
+	INSERT INTO policy_versions (id, policy_type, version, url, summary, mandatory, published_by, published_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		version.ID,
+		version.PolicyType,
+		version.Version,
+		version.URL,
+		version.Summary,
+		version.Mandatory,
+		version.PublishedBy,
+		version.PublishedAt,
+	)
+	return err
+}
+
+// GetVersion 获取指定协议版本
+func (r *ConsentRepository) GetVersion(ctx context.Context, policyType domain.PolicyType, version string) (*domain.PolicyVersion, error) {
+	var v domain.PolicyVersion
+
+	query := `
+	SELECT id, policy_type, version, url, summary, mandatory, published_by, published_at
+	FROM policy_versions
+	WHERE policy_type = $1 AND version = $2
+	`
+
+	err := r.db.GetContext(ctx, &v, query, policyType, version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("policy version not found")
+		}
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// ListVersions 获取某类协议的全部版本，按发布时间倒序
+func (r *ConsentRepository) ListVersions(ctx context.Context, policyType domain.PolicyType) ([]*domain.PolicyVersion, error) {
+	versions := []*domain.PolicyVersion{}
+
+	query := `
+	SELECT id, policy_type, version, url, summary, mandatory, published_by, published_at
+	FROM policy_versions
+	WHERE policy_type = $1
+	ORDER BY published_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &versions, query, policyType)
+	return versions, err
+}
+
+// GetLatestVersions 获取每类协议的最新版本
+func (r *ConsentRepository) GetLatestVersions(ctx context.Context) ([]*domain.PolicyVersion, error) {
+	versions := []*domain.PolicyVersion{}
+
+	query := `
+	SELECT DISTINCT ON (policy_type) id, policy_type, version, url, summary, mandatory, published_by, published_at
+	FROM policy_versions
+	ORDER BY policy_type, published_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &versions, query)
+	return versions, err
+}
+
+// GetLatestMandatoryVersions 获取每类协议最新的强制版本
+func (r *ConsentRepository) GetLatestMandatoryVersions(ctx context.Context) ([]*domain.PolicyVersion, error) {
+	versions := []*domain.PolicyVersion{}
+
+	query := `
+	SELECT DISTINCT ON (policy_type) id, policy_type, version, url, summary, mandatory, published_by, published_at
+	FROM policy_versions
+	WHERE mandatory = TRUE
+	ORDER BY policy_type, published_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &versions, query)
+	return versions, err
+}
+
+// CreateConsent 记录用户同意，重复同意同一版本时保留首次记录
+func (r *ConsentRepository) CreateConsent(ctx context.Context, consent *domain.UserConsent) error {
+	consent.AcceptedAt = time.Now()
+
+	query := `
+	INSERT INTO user_consents (user_id, policy_type, version, ip_address, user_agent, accepted_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (user_id, policy_type, version) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		consent.UserID,
+		consent.PolicyType,
+		consent.Version,
+		consent.IPAddress,
+		consent.UserAgent,
+		consent.AcceptedAt,
+	)
+	return err
+}
+
+// GetUserConsents 获取用户的同意记录
+func (r *ConsentRepository) GetUserConsents(ctx context.Context, userID string) ([]*domain.UserConsent, error) {
+	consents := []*domain.UserConsent{}
+
+	query := `
+	SELECT user_id, policy_type, version, ip_address, user_agent, accepted_at
+	FROM user_consents
+	WHERE user_id = $1
+	ORDER BY accepted_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &consents, query, userID)
+	return consents, err
+}
+
+// HasAcceptedSince 用户是否同意过指定时间之后（含）发布的该类协议版本
+func (r *ConsentRepository) HasAcceptedSince(ctx context.Context, userID string, policyType domain.PolicyType, since time.Time) (bool, error) {
+	var exists bool
+
+	query := `
+	SELECT EXISTS (
+		SELECT 1
+		FROM user_consents c
+		JOIN policy_versions v ON v.policy_type = c.policy_type AND v.version = c.version
+		WHERE c.user_id = $1 AND c.policy_type = $2 AND v.published_at >= $3
+	)
+	`
+
+	err := r.db.GetContext(ctx, &exists, query, userID, policyType, since)
+	return exists, err
+}
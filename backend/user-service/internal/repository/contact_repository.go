@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// ContactRepository 实现domain.ContactRepository接口
+type ContactRepository struct {
+	db *sqlx.DB
+}
+
+// NewContactRepository 创建一个新的通讯录匹配仓库
+func NewContactRepository(db *sqlx.DB) domain.ContactRepository {
+	return &ContactRepository{db: db}
+}
+
+// FindDiscoverable 按手机号/邮箱摘要查找可被发现的活跃用户，未保存隐私设置的用户默认可被发现
+func (r *ContactRepository) FindDiscoverable(ctx context.Context, phoneHashes, emailHashes []string) ([]*domain.ContactCandidate, error) {
+	candidates := []*domain.ContactCandidate{}
+
+	query := `
+	SELECT u.id, u.username, u.full_name, u.avatar_url, u.email_hash, u.phone_hash
+	FROM users u
+	LEFT JOIN user_privacy_settings p ON p.user_id = u.id
+	WHERE u.status = $1
+		AND COALESCE(p.discoverable, TRUE)
+		AND ((u.phone_hash <> '' AND u.phone_hash = ANY($2)) OR (u.email_hash <> '' AND u.email_hash = ANY($3)))
+	`
+
+	err := r.db.SelectContext(ctx, &candidates, query, domain.UserStatusActive, pq.Array(phoneHashes), pq.Array(emailHashes))
+	if err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// dataExportColumns user_data_exports表的查询列，不包含归档内容
+const dataExportColumns = `id, user_id, status, size_bytes, message_count, media_count, truncated, message,
+	created_at, started_at, finished_at, expires_at`
+
+// DataExportRepository 实现domain.DataExportRepository接口
+type DataExportRepository struct {
+	db *sqlx.DB
+}
+
+// NewDataExportRepository 创建一个新的个人数据导出仓库
+func NewDataExportRepository(db *sqlx.DB) domain.DataExportRepository {
+	return &DataExportRepository{db: db}
+}
+
+// Create 创建导出任务
+func (r *DataExportRepository) Create(ctx context.Context, export *domain.DataExport) error {
+	if export.ID == "" {
+		export.ID = uuid.New().String()
+	}
+	export.CreatedAt = time.Now()
+
+	query := `
+	INSERT INTO user_data_exports (id, user_id, status, created_at)
+	VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, export.ID, export.UserID, export.Status, export.CreatedAt)
+	return err
+}
+
+// Update 更新导出任务状态
+func (r *DataExportRepository) Update(ctx context.Context, export *domain.DataExport) error {
+	query := `
+	UPDATE user_data_exports
+	SET status = $1, message = $2, started_at = $3, finished_at = $4
+	WHERE id = $5
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		export.Status,
+		export.Message,
+		export.StartedAt,
+		export.FinishedAt,
+		export.ID,
+	)
+	return err
+}
+
+// Get 获取导出任务
+func (r *DataExportRepository) Get(ctx context.Context, id string) (*domain.DataExport, error) {
+	var export domain.DataExport
+
+	query := `SELECT ` + dataExportColumns + ` FROM user_data_exports WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &export, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("export not found")
+		}
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+// GetLatest 获取用户最近一次导出，没有时返回nil
+func (r *DataExportRepository) GetLatest(ctx context.Context, userID string) (*domain.DataExport, error) {
+	var export domain.DataExport
+
+	query := `SELECT ` + dataExportColumns + ` FROM user_data_exports WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`
+
+	err := r.db.GetContext(ctx, &export, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+// Complete 保存归档并把任务标记为完成
+func (r *DataExportRepository) Complete(ctx context.Context, export *domain.DataExport, archive []byte) error {
+	query := `
+	UPDATE user_data_exports
+	SET status = $1, size_bytes = $2, message_count = $3, media_count = $4, truncated = $5, message = '',
+		archive = $6, finished_at = $7, expires_at = $8
+	WHERE id = $9
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		export.Status,
+		export.SizeBytes,
+		export.MessageCount,
+		export.MediaCount,
+		export.Truncated,
+		archive,
+		export.FinishedAt,
+		export.ExpiresAt,
+		export.ID,
+	)
+	return err
+}
+
+// GetArchive 获取导出归档
+func (r *DataExportRepository) GetArchive(ctx context.Context, id string) ([]byte, error) {
+	var archive []byte
+
+	err := r.db.GetContext(ctx, &archive, `SELECT archive FROM user_data_exports WHERE id = $1 AND archive IS NOT NULL`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("export archive not found")
+		}
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// DeleteExpired 删除过期的导出任务和归档
+func (r *DataExportRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM user_data_exports WHERE expires_at < $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// DeactivationRepository 实现domain.DeactivationRepository接口
+type DeactivationRepository struct {
+	db *sqlx.DB
+}
+
+// NewDeactivationRepository 创建一个新的账户停用仓库
+func NewDeactivationRepository(db *sqlx.DB) domain.DeactivationRepository {
+	return &DeactivationRepository{db: db}
+}
+
+// Create 记录账户停用，已有记录时覆盖
+func (r *DeactivationRepository) Create(ctx context.Context, deactivation *domain.AccountDeactivation) error {
+	deactivation.DeactivatedAt = time.Now()
+
+	query := `
+	INSERT INTO account_deactivations (user_id, reason, reactivate_on_login, deactivated_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (user_id) DO UPDATE SET
+		reason = EXCLUDED.reason,
+		reactivate_on_login = EXCLUDED.reactivate_on_login,
+		deactivated_at = EXCLUDED.deactivated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		deactivation.UserID,
+		deactivation.Reason,
+		deactivation.ReactivateOnLogin,
+		deactivation.DeactivatedAt,
+	)
+	return err
+}
+
+// Get 获取账户停用记录
+func (r *DeactivationRepository) Get(ctx context.Context, userID string) (*domain.AccountDeactivation, error) {
+	var deactivation domain.AccountDeactivation
+
+	query := `
+	SELECT user_id, reason, reactivate_on_login, deactivated_at
+	FROM account_deactivations
+	WHERE user_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &deactivation, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("deactivation not found")
+		}
+		return nil, err
+	}
+
+	return &deactivation, nil
+}
+
+// Delete 删除账户停用记录
+func (r *DeactivationRepository) Delete(ctx context.Context, userID string) error {
+	query := `DELETE FROM account_deactivations WHERE user_id = $1`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
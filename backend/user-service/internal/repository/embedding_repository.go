@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// EmbeddingRepository 基于pgvector的用户向量仓库，实现domain.EmbeddingRepository接口
+type EmbeddingRepository struct {
+	db         *sqlx.DB
+	dimensions int
+}
+
+// NewEmbeddingRepository 创建用户向量仓库，并初始化pgvector扩展、向量表和HNSW索引
+// 每个用户只保存一份向量，更换模型后旧向量会被视为过期并由后台任务重新生成
+func NewEmbeddingRepository(db *sqlx.DB, dimensions int) (domain.EmbeddingRepository, error) {
+	if dimensions <= 0 {
+		return nil, fmt.Errorf("invalid embedding dimensions: %d", dimensions)
+	}
+
+	queries := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS user_embeddings (
+			user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			model VARCHAR(100) NOT NULL,
+			embedding vector(%d) NOT NULL,
+			source_updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)`, dimensions),
+		`CREATE INDEX IF NOT EXISTS idx_user_embeddings_hnsw ON user_embeddings USING hnsw (embedding vector_cosine_ops)`,
+	}
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return nil, fmt.Errorf("failed to initialize embedding store: %w", err)
+		}
+	}
+
+	return &EmbeddingRepository{db: db, dimensions: dimensions}, nil
+}
+
+// Get 获取用户在指定模型下的向量，不存在时返回nil
+func (r *EmbeddingRepository) Get(ctx context.Context, userID, model string) ([]float32, error) {
+	var value string
+
+	query := `
+	SELECT embedding::text
+	FROM user_embeddings
+	WHERE user_id = $1 AND model = $2
+	`
+
+	err := r.db.GetContext(ctx, &value, query, userID, model)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return parseVector(value)
+}
+
+// Save 保存用户向量，sourceUpdatedAt为生成向量时兴趣资料的更新时间
+func (r *EmbeddingRepository) Save(ctx context.Context, userID, model string, embedding []float32, sourceUpdatedAt time.Time) error {
+	if len(embedding) != r.dimensions {
+		return fmt.Errorf("embedding has %d dimensions, expected %d", len(embedding), r.dimensions)
+	}
+
+	query := `
+	INSERT INTO user_embeddings (user_id, model, embedding, source_updated_at, updated_at)
+	VALUES ($1, $2, $3::vector, $4, NOW())
+	ON CONFLICT (user_id) DO UPDATE SET
+		model = EXCLUDED.model,
+		embedding = EXCLUDED.embedding,
+		source_updated_at = EXCLUDED.source_updated_at,
+		updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query, userID, model, formatVector(embedding), sourceUpdatedAt)
+	return err
+}
+
+// Delete 删除用户向量
+func (r *EmbeddingRepository) Delete(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM user_embeddings WHERE user_id = $1`, userID)
+	return err
+}
+
+// ListStale 列出需要重新生成向量的活跃用户，跳过简介和兴趣都为空的资料
+func (r *EmbeddingRepository) ListStale(ctx context.Context, model string, limit int) ([]*domain.InterestProfile, error) {
+	var rows []interestRow
+
+	query := `
+	SELECT p.user_id, p.bio, p.interests, p.updated_at
+	FROM user_interest_profiles p
+	JOIN users u ON u.id = p.user_id
+	LEFT JOIN user_embeddings e ON e.user_id = p.user_id AND e.model = $1
+	WHERE u.status = 'active'
+		AND (p.bio <> '' OR cardinality(p.interests) > 0)
+		AND (e.user_id IS NULL OR e.source_updated_at < p.updated_at)
+	ORDER BY p.updated_at
+	LIMIT $2
+	`
+
+	if err := r.db.SelectContext(ctx, &rows, query, model, limit); err != nil {
+		return nil, err
+	}
+
+	profiles := make([]*domain.InterestProfile, 0, len(rows))
+	for i := range rows {
+		profiles = append(profiles, rows[i].toDomain())
+	}
+	return profiles, nil
+}
+
+// NearestNeighbors 按余弦距离查找最相近的活跃用户，排除本人、好友和待处理的好友请求双方
+func (r *EmbeddingRepository) NearestNeighbors(ctx context.Context, userID, model string, embedding []float32, limit, offset int) ([]*domain.RecommendedUser, error) {
+	var rows []struct {
+		ID        string         `db:"id"`
+		Username  string         `db:"username"`
+		FullName  string         `db:"full_name"`
+		AvatarURL sql.NullString `db:"avatar_url"`
+		Distance  float64        `db:"distance"`
+		Interests pq.StringArray `db:"interests"`
+	}
+
+	query := `
+	SELECT u.id, u.username, u.full_name, u.avatar_url,
+		e.embedding <=> $1::vector AS distance,
+		COALESCE(p.interests, '{}') AS interests
+	FROM user_embeddings e
+	JOIN users u ON u.id = e.user_id
+	LEFT JOIN user_interest_profiles p ON p.user_id = e.user_id
+	WHERE e.model = $2
+		AND e.user_id <> $3
+		AND u.status = 'active'
+		AND NOT EXISTS (
+			SELECT 1 FROM friendships f
+			WHERE (f.user1_id = $3 AND f.user2_id = e.user_id) OR (f.user2_id = $3 AND f.user1_id = e.user_id)
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM friend_requests fr
+			WHERE fr.status = 'pending'
+				AND ((fr.from_user_id = $3 AND fr.to_user_id = e.user_id) OR (fr.to_user_id = $3 AND fr.from_user_id = e.user_id))
+		)
+	ORDER BY e.embedding <=> $1::vector
+	LIMIT $4 OFFSET $5
+	`
+
+	if err := r.db.SelectContext(ctx, &rows, query, formatVector(embedding), model, userID, limit, offset); err != nil {
+		return nil, err
+	}
+
+	users := make([]*domain.RecommendedUser, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, &domain.RecommendedUser{
+			ID:        row.ID,
+			Username:  row.Username,
+			FullName:  row.FullName,
+			AvatarURL: row.AvatarURL.String,
+			// 余弦距离取值0~2，换算为0~1的相似度
+			Score:     1 - row.Distance/2,
+			Interests: []string(row.Interests),
+		})
+	}
+	return users, nil
+}
+
+// formatVector 把向量编码为pgvector的文本格式 [1,2,3]
+func formatVector(embedding []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, v := range embedding {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// parseVector 解析pgvector的文本格式
+func parseVector(value string) ([]float32, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || value[0] != '[' || value[len(value)-1] != ']' {
+		return nil, fmt.Errorf("invalid vector value: %q", value)
+	}
+	value = value[1 : len(value)-1]
+	if value == "" {
+		return []float32{}, nil
+	}
+
+	parts := strings.Split(value, ",")
+	embedding := make([]float32, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector value: %w", err)
+		}
+		embedding[i] = float32(v)
+	}
+	return embedding, nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// IdentityLinkRepository 实现domain.IdentityLinkRepository接口
+type IdentityLinkRepository struct {
+	db *sqlx.DB
+}
+
+// NewIdentityLinkRepository 创建一个新的外部身份映射仓库
+func NewIdentityLinkRepository(db *sqlx.DB) domain.IdentityLinkRepository {
+	return &IdentityLinkRepository{db: db}
+}
+
+// Create 创建外部身份映射
+func (r *IdentityLinkRepository) Create(ctx context.Context, link *domain.IdentityLink) error {
+	if link.ID == "" {
+		link.ID = uuid.New().String()
+	}
+	link.CreatedAt = time.Now()
+
+	query := `
+	INSERT INTO external_identity_links (id, issuer, subject, user_id, created_by, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, link.ID, link.Issuer, link.Subject, link.UserID, link.CreatedBy, link.CreatedAt)
+	return err
+}
+
+// GetBySubject 根据外部IdP和主体获取映射
+func (r *IdentityLinkRepository) GetBySubject(ctx context.Context, issuer, subject string) (*domain.IdentityLink, error) {
+	var link domain.IdentityLink
+
+	query := `
+	SELECT id, issuer, subject, user_id, created_by, created_at
+	FROM external_identity_links
+	WHERE issuer = $1 AND subject = $2
+	`
+
+	err := r.db.GetContext(ctx, &link, query, issuer, subject)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("identity link not found")
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+// List 获取映射列表，userID为空时返回全部
+func (r *IdentityLinkRepository) List(ctx context.Context, userID string) ([]*domain.IdentityLink, error) {
+	links := []*domain.IdentityLink{}
+
+	query := `
+	SELECT id, issuer, subject, user_id, created_by, created_at
+	FROM external_identity_links
+	WHERE $1 = '' OR user_id::text = $1
+	ORDER BY created_at DESC
+	`
+
+	if err := r.db.SelectContext(ctx, &links, query, userID); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// Delete 删除外部身份映射
+func (r *IdentityLinkRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM external_identity_links WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("identity link not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// interestRow user_interest_profiles表的行
+type interestRow struct {
+	UserID    string         `db:"user_id"`
+	Bio       string         `db:"bio"`
+	Interests pq.StringArray `db:"interests"`
+	UpdatedAt time.Time      `db:"updated_at"`
+}
+
+func (row *interestRow) toDomain() *domain.InterestProfile {
+	return &domain.InterestProfile{
+		UserID:    row.UserID,
+		Bio:       row.Bio,
+		Interests: []string(row.Interests),
+		UpdatedAt: row.UpdatedAt,
+	}
+}
+
+// InterestRepository 实现domain.InterestRepository接口
+type InterestRepository struct {
+	db *sqlx.DB
+}
+
+// NewInterestRepository 创建一个新的用户兴趣资料仓库
+func NewInterestRepository(db *sqlx.DB) domain.InterestRepository {
+	return &InterestRepository{db: db}
+}
+
+// Get 获取用户兴趣资料，未设置时返回空资料
+func (r *InterestRepository) Get(ctx context.Context, userID string) (*domain.InterestProfile, error) {
+	var row interestRow
+
+	query := `
+	SELECT user_id, bio, interests, updated_at
+	FROM user_interest_profiles
+	WHERE user_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &row, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &domain.InterestProfile{UserID: userID, Interests: []string{}}, nil
+		}
+		return nil, err
+	}
+
+	return row.toDomain(), nil
+}
+
+// Upsert 保存用户兴趣资料
+func (r *InterestRepository) Upsert(ctx context.Context, profile *domain.InterestProfile) error {
+	profile.UpdatedAt = time.Now()
+
+	query := `
+	INSERT INTO user_interest_profiles (user_id, bio, interests, updated_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (user_id) DO UPDATE SET
+		bio = EXCLUDED.bio,
+		interests = EXCLUDED.interests,
+		updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		profile.UserID,
+		profile.Bio,
+		pq.StringArray(profile.Interests),
+		profile.UpdatedAt,
+	)
+	return err
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// loginIdentityColumns user_identities表的查询列
+const loginIdentityColumns = `id, user_id, type, identifier, display_name, verified_at, created_at`
+
+// LoginIdentityRepository 实现domain.LoginIdentityRepository接口
+type LoginIdentityRepository struct {
+	db *sqlx.DB
+}
+
+// NewLoginIdentityRepository 创建一个新的登录身份仓库
+func NewLoginIdentityRepository(db *sqlx.DB) domain.LoginIdentityRepository {
+	return &LoginIdentityRepository{db: db}
+}
+
+// Create 创建登录身份，(type, identifier)唯一，已被关联时返回错误
+func (r *LoginIdentityRepository) Create(ctx context.Context, identity *domain.LoginIdentity) error {
+	if identity.ID == "" {
+		identity.ID = uuid.New().String()
+	}
+	identity.CreatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, `
+	INSERT INTO user_identities (id, user_id, type, identifier, display_name, verified_at, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (type, identifier) DO NOTHING
+	`,
+		identity.ID,
+		identity.UserID,
+		identity.Type,
+		identity.Identifier,
+		identity.DisplayName,
+		identity.VerifiedAt,
+		identity.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("login identity already exists")
+	}
+	return nil
+}
+
+// GetByIdentifier 根据类型和标识获取登录身份
+func (r *LoginIdentityRepository) GetByIdentifier(ctx context.Context, identityType domain.LoginIdentityType, identifier string) (*domain.LoginIdentity, error) {
+	var identity domain.LoginIdentity
+	query := `SELECT ` + loginIdentityColumns + ` FROM user_identities WHERE type = $1 AND identifier = $2`
+	if err := r.db.GetContext(ctx, &identity, query, identityType, identifier); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("login identity not found")
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// List 获取用户的全部登录身份，按关联时间排序
+func (r *LoginIdentityRepository) List(ctx context.Context, userID string) ([]*domain.LoginIdentity, error) {
+	identities := []*domain.LoginIdentity{}
+	query := `SELECT ` + loginIdentityColumns + ` FROM user_identities WHERE user_id = $1 ORDER BY created_at, id`
+	if err := r.db.SelectContext(ctx, &identities, query, userID); err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// Count 统计用户的登录身份数量
+func (r *LoginIdentityRepository) Count(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM user_identities WHERE user_id = $1`, userID)
+	return count, err
+}
+
+// Delete 删除登录身份，锁定用户行使并发的取消关联串行执行，避免同时删掉最后两个身份
+func (r *LoginIdentityRepository) Delete(ctx context.Context, userID, id string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var lockedID string
+	if err := tx.GetContext(ctx, &lockedID, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("user not found")
+		}
+		return err
+	}
+
+	var exists bool
+	if err := tx.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM user_identities WHERE id = $1 AND user_id = $2)`, id, userID); err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("login identity not found")
+	}
+
+	var count int
+	if err := tx.GetContext(ctx, &count, `SELECT COUNT(*) FROM user_identities WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if count <= 1 {
+		return errors.New("cannot remove the last login identity")
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_identities WHERE id = $1 AND user_id = $2`, id, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SaveVerification 保存验证码，覆盖之前未使用的验证码
+func (r *LoginIdentityRepository) SaveVerification(ctx context.Context, verification *domain.IdentityVerification) error {
+	verification.CreatedAt = time.Now()
+	verification.Attempts = 0
+
+	_, err := r.db.ExecContext(ctx, `
+	INSERT INTO identity_verifications (user_id, type, identifier, code_hash, attempts, expires_at, created_at)
+	VALUES ($1, $2, $3, $4, 0, $5, $6)
+	ON CONFLICT (user_id, type, identifier) DO UPDATE SET
+		code_hash = EXCLUDED.code_hash,
+		attempts = 0,
+		expires_at = EXCLUDED.expires_at,
+		created_at = EXCLUDED.created_at
+	`,
+		verification.UserID,
+		verification.Type,
+		verification.Identifier,
+		verification.CodeHash,
+		verification.ExpiresAt,
+		verification.CreatedAt,
+	)
+	return err
+}
+
+// GetVerification 获取待验证的验证码
+func (r *LoginIdentityRepository) GetVerification(ctx context.Context, userID string, identityType domain.LoginIdentityType, identifier string) (*domain.IdentityVerification, error) {
+	var verification domain.IdentityVerification
+	query := `
+	SELECT user_id, type, identifier, code_hash, attempts, expires_at, created_at
+	FROM identity_verifications
+	WHERE user_id = $1 AND type = $2 AND identifier = $3
+	`
+	if err := r.db.GetContext(ctx, &verification, query, userID, identityType, identifier); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("verification not found")
+		}
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// IncrementVerificationAttempts 记录一次验证码错误
+func (r *LoginIdentityRepository) IncrementVerificationAttempts(ctx context.Context, userID string, identityType domain.LoginIdentityType, identifier string) error {
+	_, err := r.db.ExecContext(ctx, `
+	UPDATE identity_verifications SET attempts = attempts + 1
+	WHERE user_id = $1 AND type = $2 AND identifier = $3
+	`, userID, identityType, identifier)
+	return err
+}
+
+// DeleteVerification 删除验证码
+func (r *LoginIdentityRepository) DeleteVerification(ctx context.Context, userID string, identityType domain.LoginIdentityType, identifier string) error {
+	_, err := r.db.ExecContext(ctx, `
+	DELETE FROM identity_verifications WHERE user_id = $1 AND type = $2 AND identifier = $3
+	`, userID, identityType, identifier)
+	return err
+}
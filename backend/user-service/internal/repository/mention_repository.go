@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// likeEscaper 转义LIKE模式中的通配符，用户输入的前缀按字面匹配
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// MentionRepository 实现domain.MentionRepository接口
+type MentionRepository struct {
+	db *sqlx.DB
+}
+
+// NewMentionRepository 创建一个新的@提及候选仓库
+func NewMentionRepository(db *sqlx.DB) domain.MentionRepository {
+	return &MentionRepository{db: db}
+}
+
+// FindByUsernamePrefix 按用户名前缀查找会话成员和好友，会话成员排在前面
+// 前缀条件使用lower(username) text_pattern_ops索引，候选范围限定在会话成员和好友内
+func (r *MentionRepository) FindByUsernamePrefix(ctx context.Context, userID, prefix string, participantIDs []string, limit int) ([]*domain.MentionCandidate, error) {
+	candidates := []*domain.MentionCandidate{}
+
+	query := `
+	SELECT u.id, u.username, u.full_name, u.avatar_url, u.id::text = ANY($3) AS is_participant
+	FROM users u
+	WHERE lower(u.username) LIKE $1 ESCAPE '\'
+		AND u.status = $2
+		AND u.id <> $4
+		AND (
+			u.id::text = ANY($3)
+			OR u.id IN (
+				SELECT user2_id FROM friendships WHERE user1_id = $4
+				UNION ALL
+				SELECT user1_id FROM friendships WHERE user2_id = $4
+			)
+		)
+	ORDER BY is_participant DESC, lower(u.username)
+	LIMIT $5
+	`
+
+	pattern := likeEscaper.Replace(strings.ToLower(prefix)) + "%"
+	err := r.db.SelectContext(ctx, &candidates, query, pattern, domain.UserStatusActive, pq.Array(participantIDs), userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
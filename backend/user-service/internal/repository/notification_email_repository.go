@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// notificationEmailColumns notification_emails表的查询列
+const notificationEmailColumns = `user_id, email, verified_at, COALESCE(token_hash, '') AS token_hash, token_expires_at, created_at, updated_at`
+
+// NotificationEmailRepository 实现domain.NotificationEmailRepository接口
+type NotificationEmailRepository struct {
+	db *sqlx.DB
+}
+
+// NewNotificationEmailRepository 创建一个新的通知邮箱仓库
+func NewNotificationEmailRepository(db *sqlx.DB) domain.NotificationEmailRepository {
+	return &NotificationEmailRepository{db: db}
+}
+
+// Upsert 设置通知邮箱，写入新的验证令牌并清除验证时间
+func (r *NotificationEmailRepository) Upsert(ctx context.Context, email *domain.NotificationEmail) error {
+	now := time.Now()
+	email.UpdatedAt = now
+	email.VerifiedAt = nil
+	email.Verified = false
+
+	query := `
+	INSERT INTO notification_emails (user_id, email, token_hash, token_expires_at, verified_at, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, NULL, $5, $5)
+	ON CONFLICT (user_id) DO UPDATE SET
+		email = EXCLUDED.email,
+		token_hash = EXCLUDED.token_hash,
+		token_expires_at = EXCLUDED.token_expires_at,
+		verified_at = NULL,
+		updated_at = EXCLUDED.updated_at
+	RETURNING created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		email.UserID,
+		email.Email,
+		email.TokenHash,
+		email.TokenExpiresAt,
+		now,
+	).Scan(&email.CreatedAt)
+}
+
+// Get 获取用户的通知邮箱
+func (r *NotificationEmailRepository) Get(ctx context.Context, userID string) (*domain.NotificationEmail, error) {
+	return r.get(ctx, `SELECT `+notificationEmailColumns+` FROM notification_emails WHERE user_id = $1`, userID)
+}
+
+// GetByTokenHash 根据验证令牌哈希获取通知邮箱
+func (r *NotificationEmailRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.NotificationEmail, error) {
+	return r.get(ctx, `SELECT `+notificationEmailColumns+` FROM notification_emails WHERE token_hash = $1`, tokenHash)
+}
+
+// MarkVerified 标记已验证，只有令牌仍然有效的记录才会更新
+func (r *NotificationEmailRepository) MarkVerified(ctx context.Context, userID, tokenHash string, verifiedAt time.Time) error {
+	result, err := r.db.ExecContext(ctx, `
+	UPDATE notification_emails
+	SET verified_at = $1, token_hash = NULL, token_expires_at = NULL, updated_at = $1
+	WHERE user_id = $2 AND token_hash = $3
+	`, verifiedAt, userID, tokenHash)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("verification token already used")
+	}
+	return nil
+}
+
+// Delete 删除用户的通知邮箱
+func (r *NotificationEmailRepository) Delete(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM notification_emails WHERE user_id = $1`, userID)
+	return err
+}
+
+func (r *NotificationEmailRepository) get(ctx context.Context, query string, arg string) (*domain.NotificationEmail, error) {
+	var email domain.NotificationEmail
+	if err := r.db.GetContext(ctx, &email, query, arg); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("notification email not found")
+		}
+		return nil, err
+	}
+
+	email.Verified = email.VerifiedAt != nil
+	return &email, nil
+}
@@ -5,9 +5,9 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // PostgreSQL驱动
 
 	"github.com/neohope/chatapp/user-service/config"
+	"github.com/neohope/chatapp/user-service/pkg/tracing"
 )
 
 // NewPostgresDB 创建一个新的PostgreSQL数据库连接
@@ -15,10 +15,12 @@ func NewPostgresDB(cfg config.DatabaseConfig) (*sqlx.DB, error) {
 	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.DBName, cfg.SSLMode)
 
-	db, err := sqlx.Open("postgres", dsn)
+	// 请求中的查询和写入记录为请求span的子span
+	sqlDB, err := tracing.OpenPostgres(dsn)
 	if err != nil {
 		return nil, err
 	}
+	db := sqlx.NewDb(sqlDB, "postgres")
 
 	// 设置连接池参数
 	db.SetMaxOpenConns(25)
@@ -46,10 +48,17 @@ func initDB(db *sqlx.DB) error {
 		id UUID PRIMARY KEY,
 		username VARCHAR(50) UNIQUE NOT NULL,
 		email VARCHAR(100) UNIQUE NOT NULL,
-		password VARCHAR(100) NOT NULL,
+		password VARCHAR(255) NOT NULL,
+		password_version INT NOT NULL DEFAULT 1,
+		token_version INT NOT NULL DEFAULT 1,
 		full_name VARCHAR(100) NOT NULL,
 		avatar_url TEXT,
+		phone VARCHAR(32) NOT NULL DEFAULT '',
 		status VARCHAR(20) NOT NULL DEFAULT 'active',
+		role VARCHAR(20) NOT NULL DEFAULT 'user',
+		auth_provider VARCHAR(20) NOT NULL DEFAULT 'local',
+		language VARCHAR(16) NOT NULL DEFAULT 'zh-CN',
+		last_seen_at TIMESTAMP WITH TIME ZONE,
 		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
 		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 	);
@@ -60,6 +69,30 @@ func initDB(db *sqlx.DB) error {
 		return err
 	}
 
+	// 为已有的用户表补充新增字段
+	alterQueries := []string{
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS language VARCHAR(16) NOT NULL DEFAULT 'zh-CN'`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS phone VARCHAR(32) NOT NULL DEFAULT ''`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP WITH TIME ZONE`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user'`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS auth_provider VARCHAR(20) NOT NULL DEFAULT 'local'`,
+		// 已有账户均为bcrypt哈希，版本为1，登录时升级为Argon2id
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS password_version INT NOT NULL DEFAULT 1`,
+		// 令牌版本写入JWT，停用/封禁/角色变更时递增，使已签发的令牌立即失效
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 1`,
+		`ALTER TABLE users ALTER COLUMN password TYPE VARCHAR(255)`,
+		// 通讯录匹配使用的手机号/邮箱摘要，规则与domain.ContactHashes一致，新增列时为已有用户回填
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS email_hash VARCHAR(64) NOT NULL DEFAULT ''`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_hash VARCHAR(64) NOT NULL DEFAULT ''`,
+		`UPDATE users SET email_hash = encode(sha256(convert_to(lower(btrim(email)), 'UTF8')), 'hex') WHERE email_hash = '' AND btrim(email) <> ''`,
+		`UPDATE users SET phone_hash = encode(sha256(convert_to(regexp_replace(phone, '[^0-9]', '', 'g'), 'UTF8')), 'hex') WHERE phone_hash = '' AND regexp_replace(phone, '[^0-9]', '', 'g') <> ''`,
+	}
+	for _, alterQuery := range alterQueries {
+		if _, err = db.Exec(alterQuery); err != nil {
+			return err
+		}
+	}
+
 	// 创建好友请求表
 	friendRequestQuery := `
 	CREATE TABLE IF NOT EXISTS friend_requests (
@@ -96,6 +129,302 @@ func initDB(db *sqlx.DB) error {
 		return err
 	}
 
+	// 创建协议版本表和用户同意记录表
+	consentQuery := `
+	CREATE TABLE IF NOT EXISTS policy_versions (
+		id UUID PRIMARY KEY,
+		policy_type VARCHAR(32) NOT NULL,
+		version VARCHAR(32) NOT NULL,
+		url TEXT NOT NULL,
+		summary TEXT NOT NULL DEFAULT '',
+		mandatory BOOLEAN NOT NULL DEFAULT FALSE,
+		published_by UUID NOT NULL,
+		published_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		UNIQUE(policy_type, version)
+	);
+
+	CREATE TABLE IF NOT EXISTS user_consents (
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		policy_type VARCHAR(32) NOT NULL,
+		version VARCHAR(32) NOT NULL,
+		ip_address VARCHAR(64) NOT NULL DEFAULT '',
+		user_agent TEXT NOT NULL DEFAULT '',
+		accepted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		PRIMARY KEY(user_id, policy_type, version)
+	);
+	`
+
+	_, err = db.Exec(consentQuery)
+	if err != nil {
+		return err
+	}
+
+	// 创建用户隐私设置表
+	privacyQuery := `
+	CREATE TABLE IF NOT EXISTS user_privacy_settings (
+		user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		email_visibility VARCHAR(16) NOT NULL DEFAULT 'friends',
+		phone_visibility VARCHAR(16) NOT NULL DEFAULT 'friends',
+		last_seen_visibility VARCHAR(16) NOT NULL DEFAULT 'friends',
+		discoverable BOOLEAN NOT NULL DEFAULT TRUE,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+	`
+
+	_, err = db.Exec(privacyQuery)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE user_privacy_settings ADD COLUMN IF NOT EXISTS discoverable BOOLEAN NOT NULL DEFAULT TRUE`)
+	if err != nil {
+		return err
+	}
+
+	// 创建账户停用记录表
+	deactivationQuery := `
+	CREATE TABLE IF NOT EXISTS account_deactivations (
+		user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		reason TEXT NOT NULL DEFAULT '',
+		reactivate_on_login BOOLEAN NOT NULL DEFAULT FALSE,
+		deactivated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+	`
+
+	_, err = db.Exec(deactivationQuery)
+	if err != nil {
+		return err
+	}
+
+	// 创建刷新令牌表，replaced_by记录轮换后的新令牌
+	refreshTokenQuery := `
+	CREATE TABLE IF NOT EXISTS refresh_tokens (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		family_id UUID NOT NULL,
+		expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		revoked_at TIMESTAMP WITH TIME ZONE,
+		replaced_by UUID
+	);
+	`
+
+	_, err = db.Exec(refreshTokenQuery)
+	if err != nil {
+		return err
+	}
+
+	// 创建单点登录IdP配置表
+	ssoQuery := `
+	CREATE TABLE IF NOT EXISTS sso_providers (
+		id UUID PRIMARY KEY,
+		tenant VARCHAR(64) UNIQUE NOT NULL,
+		name VARCHAR(100) NOT NULL DEFAULT '',
+		protocol VARCHAR(16) NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT TRUE,
+		issuer TEXT NOT NULL DEFAULT '',
+		client_id TEXT NOT NULL DEFAULT '',
+		client_secret TEXT NOT NULL DEFAULT '',
+		scopes TEXT[] NOT NULL DEFAULT '{}',
+		idp_metadata_url TEXT NOT NULL DEFAULT '',
+		idp_metadata_xml TEXT NOT NULL DEFAULT '',
+		attribute_mapping JSONB NOT NULL DEFAULT '{}',
+		group_roles JSONB NOT NULL DEFAULT '{}',
+		allowed_domains TEXT[] NOT NULL DEFAULT '{}',
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+	`
+
+	_, err = db.Exec(ssoQuery)
+	if err != nil {
+		return err
+	}
+
+	// 创建SSO身份关联表，SSO登录只按(tenant, issuer, subject)匹配本地用户，不按用户名或邮箱匹配
+	ssoIdentityLinkQuery := `
+	CREATE TABLE IF NOT EXISTS sso_identity_links (
+		id UUID PRIMARY KEY,
+		tenant VARCHAR(64) NOT NULL,
+		issuer TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		UNIQUE (tenant, issuer, subject)
+	);
+	`
+
+	_, err = db.Exec(ssoIdentityLinkQuery)
+	if err != nil {
+		return err
+	}
+
+	// 创建外部身份映射表，网关内省第三方令牌后按(issuer, subject)查找内部用户
+	identityLinkQuery := `
+	CREATE TABLE IF NOT EXISTS external_identity_links (
+		id UUID PRIMARY KEY,
+		issuer TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		created_by UUID NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		UNIQUE (issuer, subject)
+	);
+	`
+
+	_, err = db.Exec(identityLinkQuery)
+	if err != nil {
+		return err
+	}
+
+	// 创建用户批量导入任务表和邀请表
+	importQuery := `
+	CREATE TABLE IF NOT EXISTS user_import_jobs (
+		id UUID PRIMARY KEY,
+		created_by UUID NOT NULL,
+		file_name VARCHAR(255) NOT NULL DEFAULT '',
+		status VARCHAR(20) NOT NULL,
+		total_rows INT NOT NULL DEFAULT 0,
+		processed_rows INT NOT NULL DEFAULT 0,
+		created_count INT NOT NULL DEFAULT 0,
+		failed_count INT NOT NULL DEFAULT 0,
+		errors JSONB NOT NULL DEFAULT '[]',
+		message TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		started_at TIMESTAMP WITH TIME ZONE,
+		finished_at TIMESTAMP WITH TIME ZONE
+	);
+
+	CREATE TABLE IF NOT EXISTS user_invitations (
+		token_hash VARCHAR(64) PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		job_id UUID REFERENCES user_import_jobs(id) ON DELETE SET NULL,
+		expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		accepted_at TIMESTAMP WITH TIME ZONE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+	`
+
+	_, err = db.Exec(importQuery)
+	if err != nil {
+		return err
+	}
+
+	// 创建通知邮箱表，令牌只保存哈希，验证后清空
+	notificationEmailQuery := `
+	CREATE TABLE IF NOT EXISTS notification_emails (
+		user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		email VARCHAR(100) NOT NULL,
+		token_hash VARCHAR(64),
+		token_expires_at TIMESTAMP WITH TIME ZONE,
+		verified_at TIMESTAMP WITH TIME ZONE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+	`
+
+	_, err = db.Exec(notificationEmailQuery)
+	if err != nil {
+		return err
+	}
+
+	// 创建跨设备同步的用户设置表，clock为各设备修改次数组成的向量时间戳
+	settingsQuery := `
+	CREATE TABLE IF NOT EXISTS user_settings (
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		key VARCHAR(64) NOT NULL,
+		value JSONB NOT NULL,
+		clock JSONB NOT NULL DEFAULT '{}',
+		device_id VARCHAR(64) NOT NULL DEFAULT '',
+		modified_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		revision BIGINT NOT NULL DEFAULT 1,
+		PRIMARY KEY (user_id, key)
+	);
+	`
+
+	_, err = db.Exec(settingsQuery)
+	if err != nil {
+		return err
+	}
+
+	// 创建登录身份表，每个邮箱、手机号或第三方账号只能关联一个账户
+	// 没有任何登录身份的旧账户在首次使用时以账户邮箱补写一条（见service.ensureAccountIdentity）
+	loginIdentityQuery := `
+	CREATE TABLE IF NOT EXISTS user_identities (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		type VARCHAR(20) NOT NULL,
+		identifier VARCHAR(255) NOT NULL,
+		display_name VARCHAR(255) NOT NULL DEFAULT '',
+		verified_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		UNIQUE (type, identifier)
+	);
+	`
+
+	_, err = db.Exec(loginIdentityQuery)
+	if err != nil {
+		return err
+	}
+
+	// 创建关联邮箱或手机号时的验证码表，验证码只保存哈希
+	identityVerificationQuery := `
+	CREATE TABLE IF NOT EXISTS identity_verifications (
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		type VARCHAR(20) NOT NULL,
+		identifier VARCHAR(255) NOT NULL,
+		code_hash VARCHAR(64) NOT NULL,
+		attempts INT NOT NULL DEFAULT 0,
+		expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (user_id, type, identifier)
+	);
+	`
+
+	_, err = db.Exec(identityVerificationQuery)
+	if err != nil {
+		return err
+	}
+
+	// 创建用户兴趣资料表，向量表仅在开启向量推荐时创建（见NewEmbeddingRepository）
+	interestQuery := `
+	CREATE TABLE IF NOT EXISTS user_interest_profiles (
+		user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		bio TEXT NOT NULL DEFAULT '',
+		interests TEXT[] NOT NULL DEFAULT '{}',
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+	`
+
+	_, err = db.Exec(interestQuery)
+	if err != nil {
+		return err
+	}
+
+	// 创建个人数据导出表，归档完成后写入archive列，过期后整行删除
+	dataExportQuery := `
+	CREATE TABLE IF NOT EXISTS user_data_exports (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		status VARCHAR(20) NOT NULL,
+		size_bytes BIGINT NOT NULL DEFAULT 0,
+		message_count INT NOT NULL DEFAULT 0,
+		media_count INT NOT NULL DEFAULT 0,
+		truncated BOOLEAN NOT NULL DEFAULT FALSE,
+		message TEXT NOT NULL DEFAULT '',
+		archive BYTEA,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		started_at TIMESTAMP WITH TIME ZONE,
+		finished_at TIMESTAMP WITH TIME ZONE,
+		expires_at TIMESTAMP WITH TIME ZONE
+	);
+	`
+
+	_, err = db.Exec(dataExportQuery)
+	if err != nil {
+		return err
+	}
+
 	// 创建索引以提高查询性能
 	indexQueries := []string{
 		`CREATE INDEX IF NOT EXISTS idx_friend_requests_from_user ON friend_requests(from_user_id);`,
@@ -103,6 +432,20 @@ func initDB(db *sqlx.DB) error {
 		`CREATE INDEX IF NOT EXISTS idx_friend_requests_status ON friend_requests(status);`,
 		`CREATE INDEX IF NOT EXISTS idx_friendships_user1 ON friendships(user1_id);`,
 		`CREATE INDEX IF NOT EXISTS idx_friendships_user2 ON friendships(user2_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_policy_versions_type_published ON policy_versions(policy_type, published_at DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_user_import_jobs_created_at ON user_import_jobs(created_at DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_user_invitations_user ON user_invitations(user_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_user_data_exports_user ON user_data_exports(user_id, created_at DESC);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_emails_token_hash ON notification_emails(token_hash) WHERE token_hash IS NOT NULL;`,
+		`CREATE INDEX IF NOT EXISTS idx_external_identity_links_user ON external_identity_links(user_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities(user_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user ON refresh_tokens(user_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_users_email_hash ON users(email_hash) WHERE email_hash <> '';`,
+		`CREATE INDEX IF NOT EXISTS idx_users_phone_hash ON users(phone_hash) WHERE phone_hash <> '';`,
+		// @提及自动补全按用户名前缀查找
+		`CREATE INDEX IF NOT EXISTS idx_users_username_prefix ON users(lower(username) text_pattern_ops);`,
 	}
 
 	for _, indexQuery := range indexQueries {
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// PrivacyRepository 实现domain.PrivacyRepository接口
+type PrivacyRepository struct {
+	db *sqlx.DB
+}
+
+// NewPrivacyRepository 创建一个新的隐私设置仓库
+func NewPrivacyRepository(db *sqlx.DB) domain.PrivacyRepository {
+	return &PrivacyRepository{db: db}
+}
+
+// Get 获取用户隐私设置，未设置时返回默认值
+func (r *PrivacyRepository) Get(ctx context.Context, userID string) (*domain.PrivacySettings, error) {
+	var settings domain.PrivacySettings
+
+	query := `
+	SELECT user_id, email_visibility, phone_visibility, last_seen_visibility, discoverable, updated_at
+	FROM user_privacy_settings
+	WHERE user_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &settings, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.DefaultPrivacySettings(userID), nil
+		}
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// Upsert 保存用户隐私设置
+func (r *PrivacyRepository) Upsert(ctx context.Context, settings *domain.PrivacySettings) error {
+	settings.UpdatedAt = time.Now()
+
+	query := `
+	INSERT INTO user_privacy_settings (user_id, email_visibility, phone_visibility, last_seen_visibility, discoverable, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (user_id) DO UPDATE SET
+		email_visibility = EXCLUDED.email_visibility,
+		phone_visibility = EXCLUDED.phone_visibility,
+		last_seen_visibility = EXCLUDED.last_seen_visibility,
+		discoverable = EXCLUDED.discoverable,
+		updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		settings.UserID,
+		settings.EmailVisibility,
+		settings.PhoneVisibility,
+		settings.LastSeenVisibility,
+		settings.Discoverable,
+		settings.UpdatedAt,
+	)
+	return err
+}
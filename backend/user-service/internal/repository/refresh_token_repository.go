@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// RefreshTokenRepository 实现domain.RefreshTokenRepository接口
+type RefreshTokenRepository struct {
+	db *sqlx.DB
+}
+
+// NewRefreshTokenRepository 创建一个新的刷新令牌仓库
+func NewRefreshTokenRepository(db *sqlx.DB) domain.RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// Create 保存刷新令牌
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	token.CreatedAt = time.Now()
+
+	query := `
+	INSERT INTO refresh_tokens (id, user_id, family_id, expires_at, created_at)
+	VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, token.ID, token.UserID, token.FamilyID, token.ExpiresAt, token.CreatedAt)
+	return err
+}
+
+// GetByID 获取刷新令牌
+func (r *RefreshTokenRepository) GetByID(ctx context.Context, id string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+
+	query := `
+	SELECT id, user_id, family_id, expires_at, created_at, revoked_at, replaced_by
+	FROM refresh_tokens
+	WHERE id = $1
+	`
+
+	err := r.db.GetContext(ctx, &token, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("refresh token not found")
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// Rotate 在同一条语句中吊销旧令牌并插入新令牌，并发使用同一令牌时只有一个请求成功
+func (r *RefreshTokenRepository) Rotate(ctx context.Context, oldID string, next *domain.RefreshToken) (bool, error) {
+	next.CreatedAt = time.Now()
+
+	query := `
+	WITH revoked AS (
+		UPDATE refresh_tokens SET revoked_at = $5, replaced_by = $2
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING id
+	)
+	INSERT INTO refresh_tokens (id, user_id, family_id, expires_at, created_at)
+	SELECT $2, $3, $4, $6, $5 FROM revoked
+	`
+
+	result, err := r.db.ExecContext(ctx, query, oldID, next.ID, next.UserID, next.FamilyID, next.CreatedAt, next.ExpiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// RevokeFamily 吊销令牌族中所有未吊销的令牌
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, familyID)
+	return err
+}
+
+// RevokeAllForUser 吊销用户所有未吊销的令牌
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// DeleteExpired 删除已过期的令牌，返回删除数量
+// 已吊销但未过期的令牌保留到过期，用于识别重放
+func (r *RefreshTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
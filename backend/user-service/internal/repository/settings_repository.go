@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// settingColumns user_settings表的查询列
+const settingColumns = `key, value, clock, device_id, modified_at, updated_at, revision`
+
+// settingRow user_settings表的行结构，值和时间戳为JSON
+type settingRow struct {
+	Key        string    `db:"key"`
+	Value      []byte    `db:"value"`
+	Clock      []byte    `db:"clock"`
+	DeviceID   string    `db:"device_id"`
+	ModifiedAt time.Time `db:"modified_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+	Revision   int64     `db:"revision"`
+}
+
+// toDomain 转换为领域对象
+func (row *settingRow) toDomain() (*domain.UserSetting, error) {
+	setting := &domain.UserSetting{
+		Key:        row.Key,
+		Value:      json.RawMessage(row.Value),
+		DeviceID:   row.DeviceID,
+		ModifiedAt: row.ModifiedAt,
+		UpdatedAt:  row.UpdatedAt,
+		Revision:   row.Revision,
+	}
+	if err := json.Unmarshal(row.Clock, &setting.Clock); err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+// SettingsRepository 实现domain.SettingsRepository接口
+type SettingsRepository struct {
+	db *sqlx.DB
+}
+
+// NewSettingsRepository 创建一个新的用户设置仓库
+func NewSettingsRepository(db *sqlx.DB) domain.SettingsRepository {
+	return &SettingsRepository{db: db}
+}
+
+// List 按键名顺序获取用户的全部设置
+func (r *SettingsRepository) List(ctx context.Context, userID string) ([]*domain.UserSetting, error) {
+	var rows []settingRow
+	query := `SELECT ` + settingColumns + ` FROM user_settings WHERE user_id = $1 ORDER BY key`
+	if err := r.db.SelectContext(ctx, &rows, query, userID); err != nil {
+		return nil, err
+	}
+
+	settings := make([]*domain.UserSetting, 0, len(rows))
+	for i := range rows {
+		setting, err := rows[i].toDomain()
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// Get 获取用户的一项设置
+func (r *SettingsRepository) Get(ctx context.Context, userID, key string) (*domain.UserSetting, error) {
+	var row settingRow
+	query := `SELECT ` + settingColumns + ` FROM user_settings WHERE user_id = $1 AND key = $2`
+	if err := r.db.GetContext(ctx, &row, query, userID, key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("setting not found")
+		}
+		return nil, err
+	}
+	return row.toDomain()
+}
+
+// Count 统计用户的设置数量
+func (r *SettingsRepository) Count(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM user_settings WHERE user_id = $1`, userID)
+	return count, err
+}
+
+// Save 按修订号保存设置，修订号不一致说明期间已被其他请求修改
+func (r *SettingsRepository) Save(ctx context.Context, userID string, setting *domain.UserSetting) error {
+	clock, err := json.Marshal(setting.Clock)
+	if err != nil {
+		return err
+	}
+	value := []byte(setting.Value)
+	if len(value) == 0 {
+		value = []byte("null")
+	}
+	setting.UpdatedAt = time.Now()
+
+	var result sql.Result
+	if setting.Revision == 0 {
+		result, err = r.db.ExecContext(ctx, `
+		INSERT INTO user_settings (user_id, key, value, clock, device_id, modified_at, updated_at, revision)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 1)
+		ON CONFLICT (user_id, key) DO NOTHING
+		`, userID, setting.Key, value, clock, setting.DeviceID, setting.ModifiedAt, setting.UpdatedAt)
+	} else {
+		result, err = r.db.ExecContext(ctx, `
+		UPDATE user_settings
+		SET value = $3, clock = $4, device_id = $5, modified_at = $6, updated_at = $7, revision = revision + 1
+		WHERE user_id = $1 AND key = $2 AND revision = $8
+		`, userID, setting.Key, value, clock, setting.DeviceID, setting.ModifiedAt, setting.UpdatedAt, setting.Revision)
+	}
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return domain.ErrSettingModified
+	}
+	setting.Revision++
+	return nil
+}
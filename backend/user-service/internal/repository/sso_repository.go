@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// ssoProviderColumns sso_providers表的查询列
+const ssoProviderColumns = `id, tenant, name, protocol, enabled, issuer, client_id, client_secret, scopes,
+	idp_metadata_url, idp_metadata_xml, attribute_mapping, group_roles, allowed_domains, created_at, updated_at`
+
+// ssoProviderRow sso_providers表的行结构，数组和JSON列在此转换
+type ssoProviderRow struct {
+	ID               string         `db:"id"`
+	Tenant           string         `db:"tenant"`
+	Name             string         `db:"name"`
+	Protocol         string         `db:"protocol"`
+	Enabled          bool           `db:"enabled"`
+	Issuer           string         `db:"issuer"`
+	ClientID         string         `db:"client_id"`
+	ClientSecret     string         `db:"client_secret"`
+	Scopes           pq.StringArray `db:"scopes"`
+	IdPMetadataURL   string         `db:"idp_metadata_url"`
+	IdPMetadataXML   string         `db:"idp_metadata_xml"`
+	AttributeMapping []byte         `db:"attribute_mapping"`
+	GroupRoles       []byte         `db:"group_roles"`
+	AllowedDomains   pq.StringArray `db:"allowed_domains"`
+	CreatedAt        time.Time      `db:"created_at"`
+	UpdatedAt        time.Time      `db:"updated_at"`
+}
+
+// toDomain 转换为领域对象
+func (row *ssoProviderRow) toDomain() (*domain.SSOProvider, error) {
+	provider := &domain.SSOProvider{
+		ID:             row.ID,
+		Tenant:         row.Tenant,
+		Name:           row.Name,
+		Protocol:       domain.SSOProtocol(row.Protocol),
+		Enabled:        row.Enabled,
+		Issuer:         row.Issuer,
+		ClientID:       row.ClientID,
+		ClientSecret:   row.ClientSecret,
+		Scopes:         []string(row.Scopes),
+		IdPMetadataURL: row.IdPMetadataURL,
+		IdPMetadataXML: row.IdPMetadataXML,
+		AllowedDomains: []string(row.AllowedDomains),
+		CreatedAt:      row.CreatedAt,
+		UpdatedAt:      row.UpdatedAt,
+	}
+	if err := json.Unmarshal(row.AttributeMapping, &provider.AttributeMapping); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(row.GroupRoles, &provider.GroupRoles); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// SSORepository 实现domain.SSORepository接口
+type SSORepository struct {
+	db *sqlx.DB
+}
+
+// NewSSORepository 创建一个新的单点登录配置仓库
+func NewSSORepository(db *sqlx.DB) domain.SSORepository {
+	return &SSORepository{db: db}
+}
+
+// CreateProvider 创建IdP配置
+func (r *SSORepository) CreateProvider(ctx context.Context, provider *domain.SSOProvider) error {
+	if provider.ID == "" {
+		provider.ID = uuid.New().String()
+	}
+	now := time.Now()
+	provider.CreatedAt = now
+	provider.UpdatedAt = now
+
+	mapping, groupRoles, err := marshalSSOJSON(provider)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO sso_providers (id, tenant, name, protocol, enabled, issuer, client_id, client_secret, scopes,
+		idp_metadata_url, idp_metadata_xml, attribute_mapping, group_roles, allowed_domains, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		provider.ID,
+		provider.Tenant,
+		provider.Name,
+		provider.Protocol,
+		provider.Enabled,
+		provider.Issuer,
+		provider.ClientID,
+		provider.ClientSecret,
+		pq.StringArray(provider.Scopes),
+		provider.IdPMetadataURL,
+		provider.IdPMetadataXML,
+		mapping,
+		groupRoles,
+		pq.StringArray(provider.AllowedDomains),
+		provider.CreatedAt,
+		provider.UpdatedAt,
+	)
+	return err
+}
+
+// GetProviderByTenant 根据租户获取IdP配置
+func (r *SSORepository) GetProviderByTenant(ctx context.Context, tenant string) (*domain.SSOProvider, error) {
+	var row ssoProviderRow
+
+	query := `SELECT ` + ssoProviderColumns + ` FROM sso_providers WHERE tenant = $1`
+
+	err := r.db.GetContext(ctx, &row, query, tenant)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("sso provider not found")
+		}
+		return nil, err
+	}
+
+	return row.toDomain()
+}
+
+// ListProviders 获取全部IdP配置
+func (r *SSORepository) ListProviders(ctx context.Context) ([]*domain.SSOProvider, error) {
+	var rows []ssoProviderRow
+
+	query := `SELECT ` + ssoProviderColumns + ` FROM sso_providers ORDER BY tenant`
+
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, err
+	}
+
+	providers := make([]*domain.SSOProvider, 0, len(rows))
+	for i := range rows {
+		provider, err := rows[i].toDomain()
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// UpdateProvider 更新IdP配置
+func (r *SSORepository) UpdateProvider(ctx context.Context, provider *domain.SSOProvider) error {
+	provider.UpdatedAt = time.Now()
+
+	mapping, groupRoles, err := marshalSSOJSON(provider)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE sso_providers
+	SET name = $1, protocol = $2, enabled = $3, issuer = $4, client_id = $5, client_secret = $6, scopes = $7,
+		idp_metadata_url = $8, idp_metadata_xml = $9, attribute_mapping = $10, group_roles = $11,
+		allowed_domains = $12, updated_at = $13
+	WHERE tenant = $14
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		provider.Name,
+		provider.Protocol,
+		provider.Enabled,
+		provider.Issuer,
+		provider.ClientID,
+		provider.ClientSecret,
+		pq.StringArray(provider.Scopes),
+		provider.IdPMetadataURL,
+		provider.IdPMetadataXML,
+		mapping,
+		groupRoles,
+		pq.StringArray(provider.AllowedDomains),
+		provider.UpdatedAt,
+		provider.Tenant,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("sso provider not found")
+	}
+
+	return nil
+}
+
+// DeleteProvider 删除IdP配置
+func (r *SSORepository) DeleteProvider(ctx context.Context, tenant string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sso_providers WHERE tenant = $1`, tenant)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("sso provider not found")
+	}
+
+	return nil
+}
+
+// GetIdentityLink 按租户、IdP标识和主体获取SSO身份关联
+func (r *SSORepository) GetIdentityLink(ctx context.Context, tenant, issuer, subject string) (*domain.SSOIdentityLink, error) {
+	var link domain.SSOIdentityLink
+
+	query := `
+	SELECT id, tenant, issuer, subject, user_id, created_at
+	FROM sso_identity_links
+	WHERE tenant = $1 AND issuer = $2 AND subject = $3
+	`
+
+	err := r.db.GetContext(ctx, &link, query, tenant, issuer, subject)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("sso identity link not found")
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+// CreateIdentityLink 创建SSO身份关联，同一租户IdP主体只能关联一个用户
+func (r *SSORepository) CreateIdentityLink(ctx context.Context, link *domain.SSOIdentityLink) error {
+	if link.ID == "" {
+		link.ID = uuid.New().String()
+	}
+	link.CreatedAt = time.Now()
+
+	query := `
+	INSERT INTO sso_identity_links (id, tenant, issuer, subject, user_id, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, link.ID, link.Tenant, link.Issuer, link.Subject, link.UserID, link.CreatedAt)
+	return err
+}
+
+// marshalSSOJSON 序列化属性映射和组角色映射
+func marshalSSOJSON(provider *domain.SSOProvider) ([]byte, []byte, error) {
+	mapping, err := json.Marshal(provider.AttributeMapping)
+	if err != nil {
+		return nil, nil, err
+	}
+	groupRoles := provider.GroupRoles
+	if groupRoles == nil {
+		groupRoles = map[string]string{}
+	}
+	roles, err := json.Marshal(groupRoles)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mapping, roles, nil
+}
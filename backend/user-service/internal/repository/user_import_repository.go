@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// importJobColumns user_import_jobs表的查询列
+const importJobColumns = `id, created_by, file_name, status, total_rows, processed_rows, created_count, failed_count,
+	errors, message, created_at, started_at, finished_at`
+
+// importJobRow user_import_jobs表的行结构，errors列为JSON
+type importJobRow struct {
+	ID            string     `db:"id"`
+	CreatedBy     string     `db:"created_by"`
+	FileName      string     `db:"file_name"`
+	Status        string     `db:"status"`
+	TotalRows     int        `db:"total_rows"`
+	ProcessedRows int        `db:"processed_rows"`
+	CreatedCount  int        `db:"created_count"`
+	FailedCount   int        `db:"failed_count"`
+	Errors        []byte     `db:"errors"`
+	Message       string     `db:"message"`
+	CreatedAt     time.Time  `db:"created_at"`
+	StartedAt     *time.Time `db:"started_at"`
+	FinishedAt    *time.Time `db:"finished_at"`
+}
+
+// toDomain 转换为领域对象
+func (row *importJobRow) toDomain() (*domain.UserImportJob, error) {
+	job := &domain.UserImportJob{
+		ID:            row.ID,
+		CreatedBy:     row.CreatedBy,
+		FileName:      row.FileName,
+		Status:        domain.ImportJobStatus(row.Status),
+		TotalRows:     row.TotalRows,
+		ProcessedRows: row.ProcessedRows,
+		CreatedCount:  row.CreatedCount,
+		FailedCount:   row.FailedCount,
+		Message:       row.Message,
+		CreatedAt:     row.CreatedAt,
+		StartedAt:     row.StartedAt,
+		FinishedAt:    row.FinishedAt,
+	}
+	if err := json.Unmarshal(row.Errors, &job.Errors); err != nil {
+		return nil, err
+	}
+	if job.Errors == nil {
+		job.Errors = []domain.ImportRowError{}
+	}
+	return job, nil
+}
+
+// UserImportRepository 实现domain.UserImportRepository接口
+type UserImportRepository struct {
+	db *sqlx.DB
+}
+
+// NewUserImportRepository 创建一个新的批量导入仓库
+func NewUserImportRepository(db *sqlx.DB) domain.UserImportRepository {
+	return &UserImportRepository{db: db}
+}
+
+// CreateJob 创建导入任务
+func (r *UserImportRepository) CreateJob(ctx context.Context, job *domain.UserImportJob) error {
+	if job.ID == "" {
+		job.ID = uuid.New().String()
+	}
+	job.CreatedAt = time.Now()
+	if job.Errors == nil {
+		job.Errors = []domain.ImportRowError{}
+	}
+
+	rowErrors, err := json.Marshal(job.Errors)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO user_import_jobs (id, created_by, file_name, status, total_rows, processed_rows, created_count, failed_count,
+		errors, message, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		job.ID,
+		job.CreatedBy,
+		job.FileName,
+		job.Status,
+		job.TotalRows,
+		job.ProcessedRows,
+		job.CreatedCount,
+		job.FailedCount,
+		rowErrors,
+		job.Message,
+		job.CreatedAt,
+	)
+	return err
+}
+
+// UpdateJob 更新导入任务进度和结果
+func (r *UserImportRepository) UpdateJob(ctx context.Context, job *domain.UserImportJob) error {
+	rowErrors, err := json.Marshal(job.Errors)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE user_import_jobs
+	SET status = $1, total_rows = $2, processed_rows = $3, created_count = $4, failed_count = $5,
+		errors = $6, message = $7, started_at = $8, finished_at = $9
+	WHERE id = $10
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		job.Status,
+		job.TotalRows,
+		job.ProcessedRows,
+		job.CreatedCount,
+		job.FailedCount,
+		rowErrors,
+		job.Message,
+		job.StartedAt,
+		job.FinishedAt,
+		job.ID,
+	)
+	return err
+}
+
+// GetJob 获取导入任务
+func (r *UserImportRepository) GetJob(ctx context.Context, id string) (*domain.UserImportJob, error) {
+	var row importJobRow
+
+	query := `SELECT ` + importJobColumns + ` FROM user_import_jobs WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &row, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("import job not found")
+		}
+		return nil, err
+	}
+
+	return row.toDomain()
+}
+
+// ListJobs 按创建时间倒序获取导入任务
+func (r *UserImportRepository) ListJobs(ctx context.Context, limit, offset int) ([]*domain.UserImportJob, error) {
+	var rows []importJobRow
+
+	query := `SELECT ` + importJobColumns + ` FROM user_import_jobs ORDER BY created_at DESC LIMIT $1 OFFSET $2`
+
+	if err := r.db.SelectContext(ctx, &rows, query, limit, offset); err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*domain.UserImportJob, 0, len(rows))
+	for i := range rows {
+		job, err := rows[i].toDomain()
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// FailInterruptedJobs 把服务重启前未完成的任务标记为失败
+func (r *UserImportRepository) FailInterruptedJobs(ctx context.Context) (int, error) {
+	query := `
+	UPDATE user_import_jobs
+	SET status = $1, message = 'import interrupted by service restart', finished_at = NOW()
+	WHERE status IN ($2, $3)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		domain.ImportJobStatusFailed,
+		domain.ImportJobStatusPending,
+		domain.ImportJobStatusRunning,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return int(rowsAffected), err
+}
+
+// CreateInvitation 创建账户邀请
+func (r *UserImportRepository) CreateInvitation(ctx context.Context, invitation *domain.UserInvitation) error {
+	invitation.CreatedAt = time.Now()
+
+	query := `
+	INSERT INTO user_invitations (token_hash, user_id, job_id, expires_at, created_at)
+	VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		invitation.TokenHash,
+		invitation.UserID,
+		invitation.JobID,
+		invitation.ExpiresAt,
+		invitation.CreatedAt,
+	)
+	return err
+}
+
+// GetInvitation 根据令牌哈希获取邀请
+func (r *UserImportRepository) GetInvitation(ctx context.Context, tokenHash string) (*domain.UserInvitation, error) {
+	var invitation domain.UserInvitation
+
+	query := `
+	SELECT token_hash, user_id, COALESCE(job_id::text, '') AS job_id, expires_at, accepted_at, created_at
+	FROM user_invitations
+	WHERE token_hash = $1
+	`
+
+	err := r.db.GetContext(ctx, &invitation, query, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("invitation not found")
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// AcceptInvitation 标记邀请已接受，只有未接受的邀请才会更新
+func (r *UserImportRepository) AcceptInvitation(ctx context.Context, tokenHash string, acceptedAt time.Time) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE user_invitations SET accepted_at = $1 WHERE token_hash = $2 AND accepted_at IS NULL`,
+		acceptedAt, tokenHash)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("invitation already accepted")
+	}
+	return nil
+}
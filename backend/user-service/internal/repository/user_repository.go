@@ -34,11 +34,22 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	user.CreatedAt = now
 	user.UpdatedAt = now
 
+	if user.Role == "" {
+		user.Role = domain.RoleUser
+	}
+	if user.AuthProvider == "" {
+		user.AuthProvider = domain.AuthProviderLocal
+	}
+	if user.TokenVersion == 0 {
+		user.TokenVersion = 1
+	}
+
 	// 插入用户记录
 	query := `
-	INSERT INTO users (id, username, email, password, full_name, avatar_url, status, created_at, updated_at)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	INSERT INTO users (id, username, email, password, password_version, token_version, full_name, avatar_url, phone, status, role, auth_provider, language, created_at, updated_at, email_hash, phone_hash)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 	`
+	emailHash, phoneHash := domain.ContactHashes(user)
 
 	_, err := r.db.ExecContext(
 		ctx,
@@ -47,11 +58,19 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 		user.Username,
 		user.Email,
 		user.Password,
+		user.PasswordVersion,
+		user.TokenVersion,
 		user.FullName,
 		user.AvatarURL,
+		user.Phone,
 		user.Status,
+		user.Role,
+		user.AuthProvider,
+		user.Language,
 		user.CreatedAt,
 		user.UpdatedAt,
+		emailHash,
+		phoneHash,
 	)
 
 	return err
@@ -62,7 +81,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User,
 	var user domain.User
 
 	query := `
-	SELECT id, username, email, password, full_name, avatar_url, status, created_at, updated_at
+	SELECT id, username, email, password, password_version, token_version, full_name, avatar_url, phone, status, role, auth_provider, language, last_seen_at, created_at, updated_at
 	FROM users
 	WHERE id = $1
 	`
@@ -83,7 +102,7 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.
 	var user domain.User
 
 	query := `
-	SELECT id, username, email, password, full_name, avatar_url, status, created_at, updated_at
+	SELECT id, username, email, password, password_version, token_version, full_name, avatar_url, phone, status, role, auth_provider, language, last_seen_at, created_at, updated_at
 	FROM users
 	WHERE email = $1
 	`
@@ -109,7 +128,7 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*d
 	var user domain.User
 
 	query := `
-	SELECT id, username, email, password, full_name, avatar_url, status, created_at, updated_at
+	SELECT id, username, email, password, password_version, token_version, full_name, avatar_url, phone, status, role, auth_provider, language, last_seen_at, created_at, updated_at
 	FROM users
 	WHERE username = $1
 	`
@@ -125,6 +144,20 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*d
 	return &user, nil
 }
 
+// ExistsByUsername 检查用户名是否已被使用，走username唯一索引
+func (r *UserRepository) ExistsByUsername(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)`, username)
+	return exists, err
+}
+
+// ExistsByEmail 检查邮箱是否已被使用，走email唯一索引
+func (r *UserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`, email)
+	return exists, err
+}
+
 // Update 更新用户信息
 func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 	// 更新时间戳
@@ -132,9 +165,11 @@ func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 
 	query := `
 	UPDATE users
-	SET username = $1, email = $2, password = $3, full_name = $4, avatar_url = $5, status = $6, updated_at = $7
-	WHERE id = $8
+	SET username = $1, email = $2, password = $3, password_version = $4, full_name = $5, avatar_url = $6, phone = $7, status = $8, role = $9, language = $10, updated_at = $11,
+		email_hash = $12, phone_hash = $13
+	WHERE id = $14
 	`
+	emailHash, phoneHash := domain.ContactHashes(user)
 
 	_, err := r.db.ExecContext(
 		ctx,
@@ -142,16 +177,49 @@ func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 		user.Username,
 		user.Email,
 		user.Password,
+		user.PasswordVersion,
 		user.FullName,
 		user.AvatarURL,
+		user.Phone,
 		user.Status,
+		user.Role,
+		user.Language,
 		user.UpdatedAt,
+		emailHash,
+		phoneHash,
 		user.ID,
 	)
 
 	return err
 }
 
+// UpdatePassword 只更新密码哈希及其参数版本，用于登录时的透明重新哈希
+func (r *UserRepository) UpdatePassword(ctx context.Context, id, hashedPassword string, version int) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE users SET password = $1, password_version = $2, updated_at = $3 WHERE id = $4`,
+		hashedPassword, version, time.Now(), id)
+	return err
+}
+
+// UpdateLastSeen 更新用户最后在线时间
+func (r *UserRepository) UpdateLastSeen(ctx context.Context, id string, lastSeen time.Time) error {
+	query := `UPDATE users SET last_seen_at = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, lastSeen, id)
+	return err
+}
+
+// IncrementTokenVersion 递增令牌版本，使该用户已签发的令牌全部失效，返回新版本
+func (r *UserRepository) IncrementTokenVersion(ctx context.Context, id string) (int, error) {
+	var version int
+	err := r.db.GetContext(ctx, &version,
+		`UPDATE users SET token_version = token_version + 1, updated_at = $1 WHERE id = $2 RETURNING token_version`,
+		time.Now(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errors.New("user not found")
+	}
+	return version, err
+}
+
 // Delete 删除用户
 func (r *UserRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM users WHERE id = $1`
@@ -164,7 +232,7 @@ func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]*domain
 	var users []*domain.User
 
 	query := `
-	SELECT id, username, email, password, full_name, avatar_url, status, created_at, updated_at
+	SELECT id, username, email, password, password_version, token_version, full_name, avatar_url, phone, status, role, auth_provider, language, last_seen_at, created_at, updated_at
 	FROM users
 	ORDER BY created_at DESC
 	LIMIT $1 OFFSET $2
@@ -184,7 +252,7 @@ func (r *UserRepository) SearchUsers(ctx context.Context, query string, limit, o
 
 	// 构建搜索查询，支持按用户名、全名和邮箱搜索
 	sqlQuery := `
-	SELECT id, username, email, password, full_name, avatar_url, status, created_at, updated_at
+	SELECT id, username, email, password, password_version, token_version, full_name, avatar_url, phone, status, role, auth_provider, language, last_seen_at, created_at, updated_at
 	FROM users
 	WHERE (username ILIKE $1 OR full_name ILIKE $1 OR email ILIKE $1)
 	  AND status = 'active'
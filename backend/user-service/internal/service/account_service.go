@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/client"
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/auth"
+)
+
+// AccountService 实现domain.AccountService接口
+type AccountService struct {
+	userRepo            domain.UserRepository
+	deactivationRepo    domain.DeactivationRepository
+	refreshTokenService domain.RefreshTokenService
+	messageClient       *client.MessageClient
+	logger              *zap.Logger
+}
+
+// NewAccountService 创建一个新的账户服务
+func NewAccountService(userRepo domain.UserRepository, deactivationRepo domain.DeactivationRepository, refreshTokenService domain.RefreshTokenService, messageClient *client.MessageClient, logger *zap.Logger) domain.AccountService {
+	return &AccountService{
+		userRepo:            userRepo,
+		deactivationRepo:    deactivationRepo,
+		refreshTokenService: refreshTokenService,
+		messageClient:       messageClient,
+		logger:              logger,
+	}
+}
+
+// Deactivate 临时停用账户：隐藏资料、停止通知并下线，数据保留
+func (s *AccountService) Deactivate(ctx context.Context, userID string, req *domain.DeactivateAccountRequest) (*domain.AccountDeactivation, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Status != domain.UserStatusActive {
+		return nil, errors.New("account is not active")
+	}
+
+	// 停用前再次确认密码
+	if err := auth.CheckPassword(req.Password, user.Password); err != nil {
+		return nil, errors.New("invalid password")
+	}
+
+	deactivation := &domain.AccountDeactivation{
+		UserID:            userID,
+		Reason:            strings.TrimSpace(req.Reason),
+		ReactivateOnLogin: req.ReactivateOnLogin,
+	}
+	if err := s.deactivationRepo.Create(ctx, deactivation); err != nil {
+		s.logger.Error("Failed to record deactivation", zap.String("id", userID), zap.Error(err))
+		return nil, errors.New("failed to deactivate account")
+	}
+
+	user.Status = domain.UserStatusDeactivated
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("Failed to deactivate user", zap.String("id", userID), zap.Error(err))
+		return nil, errors.New("failed to deactivate account")
+	}
+
+	// 递增令牌版本使已签发的访问令牌立即失效，并吊销刷新令牌
+	if _, err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+		s.logger.Error("Failed to revoke access tokens", zap.String("id", userID), zap.Error(err))
+		return nil, errors.New("failed to deactivate account")
+	}
+	if err := s.refreshTokenService.RevokeAll(ctx, userID); err != nil {
+		s.logger.Warn("Failed to revoke refresh tokens", zap.String("id", userID), zap.Error(err))
+	}
+
+	// 断开实时连接，失败不影响停用
+	if s.messageClient != nil {
+		if err := s.messageClient.DisconnectUser(userID); err != nil {
+			s.logger.Warn("Failed to disconnect deactivated user", zap.String("id", userID), zap.Error(err))
+		}
+	}
+
+	s.logger.Info("Account deactivated", zap.String("id", userID), zap.Bool("reactivate_on_login", req.ReactivateOnLogin))
+	return deactivation, nil
+}
+
+// Reactivate 使用账号密码重新启用已停用的账户，返回新的访问令牌和刷新令牌
+func (s *AccountService) Reactivate(ctx context.Context, identifier, password string) (*domain.LoginResponse, error) {
+	var user *domain.User
+	var err error
+	if strings.Contains(identifier, "@") {
+		user, err = s.userRepo.GetByEmail(ctx, identifier)
+	} else {
+		user, err = s.userRepo.GetByUsername(ctx, identifier)
+	}
+	if err != nil {
+		return nil, errors.New("invalid credentials")
+	}
+
+	if err := auth.CheckPassword(password, user.Password); err != nil {
+		return nil, errors.New("invalid credentials")
+	}
+	if user.Status != domain.UserStatusDeactivated {
+		return nil, errors.New("account is not deactivated")
+	}
+
+	// 先升级密码哈希，避免reactivateUser用旧哈希覆盖
+	rehashPassword(ctx, s.userRepo, user, password, s.logger)
+
+	if err := reactivateUser(ctx, s.userRepo, s.deactivationRepo, user); err != nil {
+		s.logger.Error("Failed to reactivate user", zap.String("id", user.ID), zap.Error(err))
+		return nil, errors.New("failed to reactivate account")
+	}
+
+	s.logger.Info("Account reactivated", zap.String("id", user.ID))
+	return s.refreshTokenService.IssueSession(ctx, user)
+}
+
+// reactivateUser 恢复账户为活跃状态并删除停用记录
+func reactivateUser(ctx context.Context, userRepo domain.UserRepository, deactivationRepo domain.DeactivationRepository, user *domain.User) error {
+	user.Status = domain.UserStatusActive
+	if err := userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	return deactivationRepo.Delete(ctx, user.ID)
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/client"
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// AdminService 实现domain.AdminService接口
+type AdminService struct {
+	adminRepo           domain.AdminUserRepository
+	userRepo            domain.UserRepository
+	refreshTokenService domain.RefreshTokenService
+	messageClient       *client.MessageClient
+	logger              *zap.Logger
+}
+
+// NewAdminService 创建一个新的管理员用户管理服务
+func NewAdminService(adminRepo domain.AdminUserRepository, userRepo domain.UserRepository, refreshTokenService domain.RefreshTokenService, messageClient *client.MessageClient, logger *zap.Logger) domain.AdminService {
+	return &AdminService{
+		adminRepo:           adminRepo,
+		userRepo:            userRepo,
+		refreshTokenService: refreshTokenService,
+		messageClient:       messageClient,
+		logger:              logger,
+	}
+}
+
+// ListUsers 按条件列出用户
+func (s *AdminService) ListUsers(ctx context.Context, filter domain.AdminUserFilter) (*domain.AdminUserPage, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	users, total, err := s.adminRepo.ListUsers(ctx, filter)
+	if err != nil {
+		s.logger.Error("Failed to list users for admin", zap.Error(err))
+		return nil, errors.New("failed to retrieve users")
+	}
+
+	for _, user := range users {
+		user.Password = ""
+	}
+	return &domain.AdminUserPage{Users: users, Total: total}, nil
+}
+
+// SuspendUser 暂停账户，已签发的访问令牌和刷新令牌随即失效
+func (s *AdminService) SuspendUser(ctx context.Context, adminID, userID string, req *domain.SuspendUserRequest) (*domain.User, error) {
+	if adminID == userID {
+		return nil, errors.New("cannot suspend your own account")
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Status == domain.UserStatusSuspended {
+		return nil, errors.New("user is already suspended")
+	}
+	if err := s.ensureNotLastAdmin(ctx, user); err != nil {
+		return nil, err
+	}
+
+	user.Status = domain.UserStatusSuspended
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("Failed to suspend user", zap.String("id", userID), zap.Error(err))
+		return nil, errors.New("failed to suspend user")
+	}
+	s.signOut(ctx, userID)
+
+	s.logger.Info("User suspended",
+		zap.String("id", userID),
+		zap.String("admin_id", adminID),
+		zap.String("reason", strings.TrimSpace(req.Reason)),
+	)
+	user.Password = ""
+	return user, nil
+}
+
+// ReactivateUser 恢复被暂停的账户，其他状态的账户不受影响
+func (s *AdminService) ReactivateUser(ctx context.Context, adminID, userID string) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Status != domain.UserStatusSuspended {
+		return nil, errors.New("user is not suspended")
+	}
+
+	user.Status = domain.UserStatusActive
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("Failed to reactivate user", zap.String("id", userID), zap.Error(err))
+		return nil, errors.New("failed to reactivate user")
+	}
+
+	s.logger.Info("User reactivated by admin", zap.String("id", userID), zap.String("admin_id", adminID))
+	user.Password = ""
+	return user, nil
+}
+
+// DeleteUser 删除账户
+func (s *AdminService) DeleteUser(ctx context.Context, adminID, userID string) error {
+	if adminID == userID {
+		return errors.New("cannot delete your own account")
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if err := s.ensureNotLastAdmin(ctx, user); err != nil {
+		return err
+	}
+
+	// 先下线再删除，删除后刷新令牌随用户记录一起失效
+	s.signOut(ctx, userID)
+	if err := s.userRepo.Delete(ctx, userID); err != nil {
+		s.logger.Error("Failed to delete user", zap.String("id", userID), zap.Error(err))
+		return errors.New("failed to delete user")
+	}
+
+	s.logger.Info("User deleted by admin", zap.String("id", userID), zap.String("admin_id", adminID))
+	return nil
+}
+
+// SetRole 修改用户角色
+func (s *AdminService) SetRole(ctx context.Context, adminID, userID, role string) (*domain.User, error) {
+	if role != domain.RoleUser && role != domain.RoleAdmin {
+		return nil, errors.New("invalid role")
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Role == role {
+		user.Password = ""
+		return user, nil
+	}
+	if role != domain.RoleAdmin {
+		if adminID == userID {
+			return nil, errors.New("cannot change your own role")
+		}
+		if err := s.ensureNotLastAdmin(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	user.Role = role
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("Failed to update user role", zap.String("id", userID), zap.Error(err))
+		return nil, errors.New("failed to update user role")
+	}
+	// 降级后带admin角色的访问令牌立即失效，旧的刷新令牌也不能再换出新令牌
+	if role != domain.RoleAdmin {
+		if _, err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+			s.logger.Warn("Failed to revoke access tokens after role change", zap.String("id", userID), zap.Error(err))
+		}
+		if err := s.refreshTokenService.RevokeAll(ctx, userID); err != nil {
+			s.logger.Warn("Failed to revoke refresh tokens after role change", zap.String("id", userID), zap.Error(err))
+		}
+	}
+
+	s.logger.Info("User role changed", zap.String("id", userID), zap.String("role", role), zap.String("admin_id", adminID))
+	user.Password = ""
+	return user, nil
+}
+
+// ensureNotLastAdmin 目标是唯一的活跃管理员时拒绝操作，避免系统失去管理员
+func (s *AdminService) ensureNotLastAdmin(ctx context.Context, user *domain.User) error {
+	if user.Role != domain.RoleAdmin || user.Status != domain.UserStatusActive {
+		return nil
+	}
+	count, err := s.adminRepo.CountAdmins(ctx)
+	if err != nil {
+		s.logger.Error("Failed to count admins", zap.Error(err))
+		return errors.New("failed to count admins")
+	}
+	if count <= 1 {
+		return errors.New("cannot remove the last admin")
+	}
+	return nil
+}
+
+// signOut 递增令牌版本使访问令牌失效，吊销刷新令牌并断开实时连接，失败只记录日志
+// 认证时还会检查数据库中的账户状态，令牌版本未能递增时暂停仍然生效
+func (s *AdminService) signOut(ctx context.Context, userID string) {
+	if _, err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+		s.logger.Warn("Failed to revoke access tokens", zap.String("id", userID), zap.Error(err))
+	}
+	if err := s.refreshTokenService.RevokeAll(ctx, userID); err != nil {
+		s.logger.Warn("Failed to revoke refresh tokens", zap.String("id", userID), zap.Error(err))
+	}
+	if s.messageClient != nil {
+		if err := s.messageClient.DisconnectUser(userID); err != nil {
+			s.logger.Warn("Failed to disconnect user", zap.String("id", userID), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// mandatoryCacheTTL 强制版本列表的缓存时间，每个受保护请求都会用到
+const mandatoryCacheTTL = time.Minute
+
+// ConsentService 实现domain.ConsentService接口
+type ConsentService struct {
+	consentRepo domain.ConsentRepository
+	logger      *zap.Logger
+
+	mu              sync.RWMutex
+	mandatory       []*domain.PolicyVersion
+	mandatoryLoaded time.Time
+}
+
+// NewConsentService 创建一个新的协议同意服务
+func NewConsentService(consentRepo domain.ConsentRepository, logger *zap.Logger) domain.ConsentService {
+	return &ConsentService{
+		consentRepo: consentRepo,
+		logger:      logger,
+	}
+}
+
+// PublishVersion 发布新的协议版本
+func (s *ConsentService) PublishVersion(ctx context.Context, adminID string, req *domain.PublishPolicyVersionRequest) (*domain.PolicyVersion, error) {
+	if !domain.IsValidPolicyType(req.PolicyType) {
+		return nil, errors.New("invalid policy type")
+	}
+	req.Version = strings.TrimSpace(req.Version)
+	if req.Version == "" || len(req.Version) > 32 {
+		return nil, errors.New("invalid policy version")
+	}
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, errors.New("invalid policy url")
+	}
+
+	if existing, err := s.consentRepo.GetVersion(ctx, req.PolicyType, req.Version); err == nil && existing != nil {
+		return nil, errors.New("policy version already exists")
+	}
+
+	version := &domain.PolicyVersion{
+		PolicyType:  req.PolicyType,
+		Version:     req.Version,
+		URL:         req.URL,
+		Summary:     req.Summary,
+		Mandatory:   req.Mandatory,
+		PublishedBy: adminID,
+	}
+	if err := s.consentRepo.CreateVersion(ctx, version); err != nil {
+		s.logger.Error("Failed to publish policy version", zap.String("policy_type", string(req.PolicyType)), zap.Error(err))
+		return nil, errors.New("failed to publish policy version")
+	}
+
+	// 新的强制版本需要立即生效
+	if version.Mandatory {
+		s.invalidateMandatory()
+	}
+
+	s.logger.Info("Policy version published",
+		zap.String("policy_type", string(version.PolicyType)),
+		zap.String("version", version.Version),
+		zap.Bool("mandatory", version.Mandatory),
+		zap.String("admin_id", adminID),
+	)
+	return version, nil
+}
+
+// ListVersions 获取某类协议的全部版本
+func (s *ConsentService) ListVersions(ctx context.Context, policyType domain.PolicyType) ([]*domain.PolicyVersion, error) {
+	if !domain.IsValidPolicyType(policyType) {
+		return nil, errors.New("invalid policy type")
+	}
+
+	versions, err := s.consentRepo.ListVersions(ctx, policyType)
+	if err != nil {
+		s.logger.Error("Failed to list policy versions", zap.Error(err))
+		return nil, errors.New("failed to list policy versions")
+	}
+	return versions, nil
+}
+
+// GetLatestVersions 获取每类协议的最新版本
+func (s *ConsentService) GetLatestVersions(ctx context.Context) ([]*domain.PolicyVersion, error) {
+	versions, err := s.consentRepo.GetLatestVersions(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get latest policy versions", zap.Error(err))
+		return nil, errors.New("failed to get policy versions")
+	}
+	return versions, nil
+}
+
+// GetConsentStatus 获取用户的协议同意状态
+func (s *ConsentService) GetConsentStatus(ctx context.Context, userID string) (*domain.ConsentStatus, error) {
+	accepted, err := s.consentRepo.GetUserConsents(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get user consents", zap.String("user_id", userID), zap.Error(err))
+		return nil, errors.New("failed to get consent status")
+	}
+
+	latest, err := s.GetLatestVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := s.pendingVersions(ctx, userID)
+	if err != nil {
+		return nil, errors.New("failed to get consent status")
+	}
+
+	return &domain.ConsentStatus{
+		Accepted: accepted,
+		Pending:  pending,
+		Latest:   latest,
+		Blocked:  len(pending) > 0,
+	}, nil
+}
+
+// AcceptVersions 记录用户同意的协议版本
+func (s *ConsentService) AcceptVersions(ctx context.Context, userID string, req *domain.AcceptConsentRequest, ipAddress, userAgent string) (*domain.ConsentStatus, error) {
+	if len(req.Consents) == 0 {
+		return nil, errors.New("no consents provided")
+	}
+
+	for _, item := range req.Consents {
+		if !domain.IsValidPolicyType(item.PolicyType) {
+			return nil, errors.New("invalid policy type")
+		}
+		if _, err := s.consentRepo.GetVersion(ctx, item.PolicyType, item.Version); err != nil {
+			return nil, errors.New("policy version not found")
+		}
+	}
+
+	for _, item := range req.Consents {
+		consent := &domain.UserConsent{
+			UserID:     userID,
+			PolicyType: item.PolicyType,
+			Version:    item.Version,
+			IPAddress:  ipAddress,
+			UserAgent:  userAgent,
+		}
+		if err := s.consentRepo.CreateConsent(ctx, consent); err != nil {
+			s.logger.Error("Failed to record consent",
+				zap.String("user_id", userID),
+				zap.String("policy_type", string(item.PolicyType)),
+				zap.String("version", item.Version),
+				zap.Error(err),
+			)
+			return nil, errors.New("failed to record consent")
+		}
+	}
+
+	return s.GetConsentStatus(ctx, userID)
+}
+
+// HasPendingConsents 用户是否有尚未同意的强制协议版本
+func (s *ConsentService) HasPendingConsents(ctx context.Context, userID string) (bool, error) {
+	pending, err := s.pendingVersions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return len(pending) > 0, nil
+}
+
+// pendingVersions 计算用户尚未同意的强制版本，同意过更新的版本也视为已同意
+func (s *ConsentService) pendingVersions(ctx context.Context, userID string) ([]*domain.PolicyVersion, error) {
+	mandatory, err := s.mandatoryVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []*domain.PolicyVersion{}
+	for _, version := range mandatory {
+		accepted, err := s.consentRepo.HasAcceptedSince(ctx, userID, version.PolicyType, version.PublishedAt)
+		if err != nil {
+			s.logger.Error("Failed to check consent", zap.String("user_id", userID), zap.Error(err))
+			return nil, err
+		}
+		if !accepted {
+			pending = append(pending, version)
+		}
+	}
+	return pending, nil
+}
+
+// mandatoryVersions 获取各类协议最新的强制版本（带缓存）
+func (s *ConsentService) mandatoryVersions(ctx context.Context) ([]*domain.PolicyVersion, error) {
+	s.mu.RLock()
+	if !s.mandatoryLoaded.IsZero() && time.Since(s.mandatoryLoaded) < mandatoryCacheTTL {
+		versions := s.mandatory
+		s.mu.RUnlock()
+		return versions, nil
+	}
+	s.mu.RUnlock()
+
+	versions, err := s.consentRepo.GetLatestMandatoryVersions(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get mandatory policy versions", zap.Error(err))
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.mandatory = versions
+	s.mandatoryLoaded = time.Now()
+	s.mu.Unlock()
+
+	return versions, nil
+}
+
+// invalidateMandatory 清除强制版本缓存
+func (s *ConsentService) invalidateMandatory() {
+	s.mu.Lock()
+	s.mandatoryLoaded = time.Time{}
+	s.mu.Unlock()
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/validation"
+)
+
+// ContactService 实现domain.ContactService接口
+type ContactService struct {
+	contactRepo   domain.ContactRepository
+	friendService domain.FriendService
+	logger        *zap.Logger
+}
+
+// NewContactService 创建一个新的通讯录导入服务
+func NewContactService(contactRepo domain.ContactRepository, friendService domain.FriendService, logger *zap.Logger) domain.ContactService {
+	return &ContactService{
+		contactRepo:   contactRepo,
+		friendService: friendService,
+		logger:        logger,
+	}
+}
+
+// ImportContacts 将通讯录摘要与已注册用户匹配，用于"找朋友"
+// 只返回允许被发现的活跃用户，结果按请求中摘要的顺序排列，同一用户的手机号和邮箱都命中时各返回一条；
+// 返回的资料不包含邮箱和手机号，客户端通过hash对应到本地联系人
+func (s *ContactService) ImportContacts(ctx context.Context, userID string, req *domain.ContactImportRequest) (*domain.ContactImportResponse, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	phoneHashes := normalizeHashes(req.PhoneHashes)
+	emailHashes := normalizeHashes(req.EmailHashes)
+
+	candidates, err := s.contactRepo.FindDiscoverable(ctx, phoneHashes, emailHashes)
+	if err != nil {
+		s.logger.Error("Failed to match contacts", zap.String("user_id", userID), zap.Error(err))
+		return nil, errors.New("failed to import contacts")
+	}
+
+	byPhone := make(map[string]*domain.ContactCandidate, len(candidates))
+	byEmail := make(map[string]*domain.ContactCandidate, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.ID == userID {
+			continue
+		}
+		if candidate.PhoneHash != "" {
+			byPhone[candidate.PhoneHash] = candidate
+		}
+		if candidate.EmailHash != "" {
+			byEmail[candidate.EmailHash] = candidate
+		}
+	}
+
+	profiles := make(map[string]*domain.PublicProfile)
+	response := &domain.ContactImportResponse{Matches: []*domain.ContactMatch{}}
+	appendMatches := func(hashes []string, index map[string]*domain.ContactCandidate, matchedBy string) {
+		for _, hash := range hashes {
+			candidate, ok := index[hash]
+			if !ok {
+				continue
+			}
+			profile, ok := profiles[candidate.ID]
+			if !ok {
+				profile = s.contactProfile(ctx, userID, candidate)
+				profiles[candidate.ID] = profile
+			}
+			response.Matches = append(response.Matches, &domain.ContactMatch{
+				Hash:      hash,
+				MatchedBy: matchedBy,
+				Profile:   profile,
+			})
+		}
+	}
+	appendMatches(phoneHashes, byPhone, domain.ContactMatchedByPhone)
+	appendMatches(emailHashes, byEmail, domain.ContactMatchedByEmail)
+
+	s.logger.Info("Contacts imported",
+		zap.String("user_id", userID),
+		zap.Int("phone_hashes", len(phoneHashes)),
+		zap.Int("email_hashes", len(emailHashes)),
+		zap.Int("matches", len(response.Matches)))
+	return response, nil
+}
+
+// contactProfile 构建匹配用户的资料，只包含公开字段和好友关系
+func (s *ContactService) contactProfile(ctx context.Context, viewerID string, candidate *domain.ContactCandidate) *domain.PublicProfile {
+	profile := &domain.PublicProfile{
+		ID:        candidate.ID,
+		Username:  candidate.Username,
+		FullName:  candidate.FullName,
+		AvatarURL: candidate.AvatarURL,
+	}
+
+	isFriend, err := s.friendService.CheckFriendship(ctx, viewerID, candidate.ID)
+	if err != nil {
+		// 无法确认好友关系时按非好友处理
+		s.logger.Warn("Failed to check friendship", zap.String("viewer", viewerID), zap.String("id", candidate.ID), zap.Error(err))
+	}
+	profile.IsFriend = err == nil && isFriend
+	return profile
+}
+
+// normalizeHashes 将摘要转为小写并去重，保持原有顺序
+func normalizeHashes(hashes []string) []string {
+	seen := make(map[string]bool, len(hashes))
+	normalized := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		hash = strings.ToLower(hash)
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		normalized = append(normalized, hash)
+	}
+	return normalized
+}
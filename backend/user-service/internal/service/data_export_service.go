@@ -0,0 +1,424 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/client"
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/jobs"
+)
+
+const (
+	// buildDataExportJob 后台生成导出归档的任务名
+	buildDataExportJob = "build_data_export"
+	// exportPageSize 调用消息服务和媒体服务时的每页数量
+	exportPageSize = 100
+	// exportStaleAfter 超过这个时间仍未结束的任务视为已中断（如实例崩溃），允许重新发起
+	exportStaleAfter = time.Hour
+)
+
+// DataExportConfig 个人数据导出配置
+type DataExportConfig struct {
+	Retention   time.Duration // 归档保留时长
+	MaxMessages int           // 导出的消息数量上限
+}
+
+// exportProfile 归档中profile.json的内容
+type exportProfile struct {
+	User     *domain.User            `json:"user"`
+	Privacy  *domain.PrivacySettings `json:"privacy"`
+	Consents []*domain.UserConsent   `json:"consents"`
+	Settings []*domain.UserSetting   `json:"settings"`
+}
+
+// exportFriend 归档中friends.json的一项
+type exportFriend struct {
+	UserID   string    `json:"user_id"`
+	Username string    `json:"username"`
+	FullName string    `json:"full_name,omitempty"`
+	Since    time.Time `json:"since"`
+}
+
+// exportFriendRequests 归档中friend_requests.json的内容
+type exportFriendRequests struct {
+	Sent     []*domain.FriendRequest `json:"sent"`
+	Received []*domain.FriendRequest `json:"received"`
+}
+
+// DataExportService 实现domain.DataExportService接口
+type DataExportService struct {
+	exportRepo    domain.DataExportRepository
+	userRepo      domain.UserRepository
+	friendRepo    domain.FriendRepository
+	privacyRepo   domain.PrivacyRepository
+	consentRepo   domain.ConsentRepository
+	settingsRepo  domain.SettingsRepository
+	messageClient *client.MessageClient
+	mediaClient   *client.MediaClient
+	runner        *jobs.Runner
+	config        DataExportConfig
+	logger        *zap.Logger
+}
+
+// NewDataExportService 创建一个新的个人数据导出服务
+func NewDataExportService(
+	exportRepo domain.DataExportRepository,
+	userRepo domain.UserRepository,
+	friendRepo domain.FriendRepository,
+	privacyRepo domain.PrivacyRepository,
+	consentRepo domain.ConsentRepository,
+	settingsRepo domain.SettingsRepository,
+	messageClient *client.MessageClient,
+	mediaClient *client.MediaClient,
+	runner *jobs.Runner,
+	config DataExportConfig,
+	logger *zap.Logger,
+) domain.DataExportService {
+	if config.Retention <= 0 {
+		config.Retention = 7 * 24 * time.Hour
+	}
+	if config.MaxMessages <= 0 {
+		config.MaxMessages = 100000
+	}
+
+	return &DataExportService{
+		exportRepo:    exportRepo,
+		userRepo:      userRepo,
+		friendRepo:    friendRepo,
+		privacyRepo:   privacyRepo,
+		consentRepo:   consentRepo,
+		settingsRepo:  settingsRepo,
+		messageClient: messageClient,
+		mediaClient:   mediaClient,
+		runner:        runner,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// ScheduleJobs 注册生成归档的后台任务和清理过期归档的定时任务，需要在任务执行器启动前调用
+func (s *DataExportService) ScheduleJobs(runner *jobs.Runner) error {
+	// 生成失败时标记任务失败，由用户重新发起，不自动重试
+	runner.Register(buildDataExportJob, func(ctx context.Context, payload json.RawMessage) error {
+		var args struct {
+			ExportID string `json:"export_id"`
+		}
+		if err := json.Unmarshal(payload, &args); err != nil {
+			return err
+		}
+		return s.runExport(ctx, args.ExportID)
+	}, jobs.MaxAttempts(1), jobs.Timeout(30*time.Minute))
+
+	return runner.Schedule("cleanup_data_exports", "@hourly", func(ctx context.Context, _ json.RawMessage) error {
+		count, err := s.exportRepo.DeleteExpired(ctx, time.Now())
+		if err == nil && count > 0 {
+			s.logger.Info("Deleted expired data exports", zap.Int64("count", count))
+		}
+		return err
+	}, jobs.MaxAttempts(1))
+}
+
+// RequestExport 创建导出任务，已有等待或进行中的任务时直接返回该任务
+func (s *DataExportService) RequestExport(ctx context.Context, userID string) (*domain.DataExport, error) {
+	latest, err := s.exportRepo.GetLatest(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get latest data export", zap.String("user_id", userID), zap.Error(err))
+		return nil, errors.New("failed to get data export")
+	}
+	if latest != nil && (latest.Status == domain.DataExportStatusPending || latest.Status == domain.DataExportStatusRunning) {
+		if time.Since(latest.CreatedAt) < exportStaleAfter {
+			return latest, nil
+		}
+		s.failExport(ctx, latest, "export interrupted")
+	}
+
+	export := &domain.DataExport{
+		UserID: userID,
+		Status: domain.DataExportStatusPending,
+	}
+	if err := s.exportRepo.Create(ctx, export); err != nil {
+		s.logger.Error("Failed to create data export", zap.String("user_id", userID), zap.Error(err))
+		return nil, errors.New("failed to create data export")
+	}
+	if err := s.runner.Enqueue(ctx, buildDataExportJob, map[string]string{"export_id": export.ID}); err != nil {
+		s.logger.Error("Failed to enqueue data export", zap.String("export_id", export.ID), zap.Error(err))
+		s.failExport(ctx, export, "failed to schedule export")
+		return nil, errors.New("failed to create data export")
+	}
+
+	s.logger.Info("Data export requested", zap.String("user_id", userID), zap.String("export_id", export.ID))
+	return export, nil
+}
+
+// GetLatestExport 获取用户最近一次导出的状态
+func (s *DataExportService) GetLatestExport(ctx context.Context, userID string) (*domain.DataExport, error) {
+	export, err := s.exportRepo.GetLatest(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get latest data export", zap.String("user_id", userID), zap.Error(err))
+		return nil, errors.New("failed to get data export")
+	}
+	if export == nil {
+		return nil, errors.New("export not found")
+	}
+	return export, nil
+}
+
+// DownloadExport 获取最近一次已完成且未过期的导出归档
+func (s *DataExportService) DownloadExport(ctx context.Context, userID string) (*domain.DataExport, []byte, error) {
+	export, err := s.GetLatestExport(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if export.Status != domain.DataExportStatusCompleted {
+		return nil, nil, fmt.Errorf("export is %s", export.Status)
+	}
+	if export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt) {
+		return nil, nil, errors.New("export expired")
+	}
+
+	archive, err := s.exportRepo.GetArchive(ctx, export.ID)
+	if err != nil {
+		s.logger.Error("Failed to get data export archive", zap.String("export_id", export.ID), zap.Error(err))
+		return nil, nil, errors.New("export archive not found")
+	}
+	return export, archive, nil
+}
+
+// runExport 后台生成导出归档，失败时记录原因
+func (s *DataExportService) runExport(ctx context.Context, exportID string) error {
+	export, err := s.exportRepo.Get(ctx, exportID)
+	if err != nil {
+		return err
+	}
+	// 实例崩溃后任务可能被重新领取，已结束的任务不再处理
+	if export.Status == domain.DataExportStatusCompleted || export.Status == domain.DataExportStatusFailed {
+		return nil
+	}
+
+	startedAt := time.Now()
+	export.Status = domain.DataExportStatusRunning
+	export.StartedAt = &startedAt
+	if err := s.exportRepo.Update(ctx, export); err != nil {
+		return err
+	}
+
+	archive, err := s.buildArchive(ctx, export)
+	if err != nil {
+		s.logger.Warn("Failed to build data export", zap.String("export_id", export.ID), zap.String("user_id", export.UserID), zap.Error(err))
+		s.failExport(ctx, export, err.Error())
+		return nil
+	}
+
+	finishedAt := time.Now()
+	expiresAt := finishedAt.Add(s.config.Retention)
+	export.Status = domain.DataExportStatusCompleted
+	export.SizeBytes = int64(len(archive))
+	export.FinishedAt = &finishedAt
+	export.ExpiresAt = &expiresAt
+	if err := s.exportRepo.Complete(ctx, export, archive); err != nil {
+		s.logger.Error("Failed to save data export", zap.String("export_id", export.ID), zap.Error(err))
+		s.failExport(ctx, export, "failed to save export archive")
+		return nil
+	}
+
+	s.logger.Info("Data export completed",
+		zap.String("export_id", export.ID),
+		zap.String("user_id", export.UserID),
+		zap.Int64("size_bytes", export.SizeBytes),
+		zap.Int("messages", export.MessageCount),
+		zap.Int("media", export.MediaCount),
+		zap.Duration("duration", finishedAt.Sub(startedAt)),
+	)
+	return nil
+}
+
+// buildArchive 汇总资料、好友、消息和文件清单并打包为zip
+func (s *DataExportService) buildArchive(ctx context.Context, export *domain.DataExport) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	profile, err := s.collectProfile(ctx, export.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSONEntry(archive, "profile.json", profile); err != nil {
+		return nil, err
+	}
+
+	friends, requests, err := s.collectFriends(ctx, export.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSONEntry(archive, "friends.json", friends); err != nil {
+		return nil, err
+	}
+	if err := writeJSONEntry(archive, "friend_requests.json", requests); err != nil {
+		return nil, err
+	}
+
+	messages, truncated, err := s.collectMessages(ctx, export.UserID)
+	if err != nil {
+		return nil, err
+	}
+	export.MessageCount = len(messages)
+	export.Truncated = truncated
+	if err := writeJSONEntry(archive, "messages.json", messages); err != nil {
+		return nil, err
+	}
+
+	media, err := s.collectMedia(ctx, export.UserID)
+	if err != nil {
+		return nil, err
+	}
+	export.MediaCount = len(media)
+	if err := writeJSONEntry(archive, "media.json", media); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// collectProfile 获取用户资料、隐私设置、协议同意记录和同步的设置
+func (s *DataExportService) collectProfile(ctx context.Context, userID string) (*exportProfile, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	privacy, err := s.privacyRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get privacy settings: %w", err)
+	}
+	consents, err := s.consentRepo.GetUserConsents(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get consents: %w", err)
+	}
+	settings, err := s.settingsRepo.List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	return &exportProfile{User: user, Privacy: privacy, Consents: consents, Settings: settings}, nil
+}
+
+// collectFriends 获取好友列表和好友请求，好友只导出用户名和姓名
+func (s *DataExportService) collectFriends(ctx context.Context, userID string) ([]exportFriend, *exportFriendRequests, error) {
+	friendships, err := s.friendRepo.GetFriendships(ctx, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get friendships: %w", err)
+	}
+	friends := make([]exportFriend, 0, len(friendships))
+	for _, friendship := range friendships {
+		friend := friendship.User2
+		if friendship.User2ID == userID {
+			friend = friendship.User1
+		}
+		if friend == nil {
+			continue
+		}
+		friends = append(friends, exportFriend{
+			UserID:   friend.ID,
+			Username: friend.Username,
+			FullName: friend.FullName,
+			Since:    friendship.CreatedAt,
+		})
+	}
+
+	sent, err := s.friendRepo.GetSentFriendRequests(ctx, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get sent friend requests: %w", err)
+	}
+	received, err := s.friendRepo.GetPendingFriendRequests(ctx, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get received friend requests: %w", err)
+	}
+	return friends, &exportFriendRequests{Sent: sent, Received: received}, nil
+}
+
+// collectMessages 通过消息服务逐个会话获取用户发送的消息，超过上限时停止
+func (s *DataExportService) collectMessages(ctx context.Context, userID string) ([]json.RawMessage, bool, error) {
+	messages := []json.RawMessage{}
+	for offset := 0; ; offset += exportPageSize {
+		conversations, err := s.messageClient.GetUserConversationIDs(ctx, userID, exportPageSize, offset)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get conversations: %w", err)
+		}
+
+		for _, conversationID := range conversations {
+			for messageOffset := 0; ; messageOffset += exportPageSize {
+				page, err := s.messageClient.GetConversationMessages(ctx, userID, conversationID, exportPageSize, messageOffset)
+				if err != nil {
+					return nil, false, fmt.Errorf("failed to get messages of conversation %s: %w", conversationID, err)
+				}
+				for _, raw := range page {
+					var message struct {
+						SenderID string `json:"sender_id"`
+					}
+					if err := json.Unmarshal(raw, &message); err != nil || message.SenderID != userID {
+						continue
+					}
+					if len(messages) >= s.config.MaxMessages {
+						return messages, true, nil
+					}
+					messages = append(messages, raw)
+				}
+				if len(page) < exportPageSize {
+					break
+				}
+			}
+		}
+
+		if len(conversations) < exportPageSize {
+			return messages, false, nil
+		}
+	}
+}
+
+// collectMedia 通过媒体服务获取用户上传的文件清单，只包含文件记录，不包含文件内容
+func (s *DataExportService) collectMedia(ctx context.Context, userID string) ([]json.RawMessage, error) {
+	media := []json.RawMessage{}
+	for offset := 0; ; offset += exportPageSize {
+		page, total, err := s.mediaClient.GetUserMedia(ctx, userID, exportPageSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get media: %w", err)
+		}
+		media = append(media, page...)
+		if len(page) == 0 || offset+len(page) >= total {
+			return media, nil
+		}
+	}
+}
+
+// failExport 把任务标记为失败，失败只记录日志
+func (s *DataExportService) failExport(ctx context.Context, export *domain.DataExport, message string) {
+	finishedAt := time.Now()
+	export.Status = domain.DataExportStatusFailed
+	export.Message = message
+	export.FinishedAt = &finishedAt
+	if err := s.exportRepo.Update(ctx, export); err != nil {
+		s.logger.Error("Failed to save data export", zap.String("export_id", export.ID), zap.Error(err))
+	}
+}
+
+// writeJSONEntry 把数据以缩进的JSON写入归档中的一个文件
+func writeJSONEntry(archive *zip.Writer, name string, data interface{}) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
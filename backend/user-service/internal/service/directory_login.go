@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/auth"
+)
+
+// DirectoryLogin 目录（LDAP/AD）登录配置
+type DirectoryLogin struct {
+	Authenticator auth.DirectoryAuthenticator
+	// GroupRoles 目录组DN到角色的映射，键不区分大小写
+	GroupRoles map[string]string
+	// AllowLocalLogin 目录中不存在的用户是否允许使用本地账户登录
+	AllowLocalLogin bool
+}
+
+// authenticateWithDirectory 通过目录认证并即时创建/同步本地用户；handled为false表示应回退到本地登录
+func (s *UserService) authenticateWithDirectory(ctx context.Context, identifier, password string) (*domain.User, bool, error) {
+	identity, err := s.directory.Authenticator.Authenticate(identifier, password)
+	switch {
+	case err == nil:
+	case errors.Is(err, auth.ErrDirectoryUserNotFound) && s.directory.AllowLocalLogin:
+		return nil, false, nil
+	case errors.Is(err, auth.ErrDirectoryUserNotFound), errors.Is(err, auth.ErrDirectoryInvalidCredentials):
+		s.logger.Info("Directory authentication failed", zap.String("identifier", identifier), zap.Error(err))
+		return nil, true, errors.New("invalid credentials")
+	default:
+		s.logger.Error("Directory authentication error", zap.String("identifier", identifier), zap.Error(err))
+		return nil, true, errors.New("directory authentication unavailable")
+	}
+
+	user, err := s.provisionDirectoryUser(ctx, identity)
+	if err != nil {
+		return nil, true, err
+	}
+	return user, true, nil
+}
+
+// provisionDirectoryUser 首次登录时创建本地用户，之后每次登录同步资料和角色
+func (s *UserService) provisionDirectoryUser(ctx context.Context, identity *auth.DirectoryIdentity) (*domain.User, error) {
+	if identity.Username == "" || identity.Email == "" {
+		s.logger.Error("Directory entry missing username or email", zap.String("dn", identity.DN))
+		return nil, errors.New("directory account is missing required attributes")
+	}
+
+	role := s.mapDirectoryRole(identity.Groups)
+	fullName := identity.FullName
+	if fullName == "" {
+		fullName = identity.Username
+	}
+
+	user, err := s.userRepo.GetByUsername(ctx, identity.Username)
+	if err != nil {
+		user, err = s.userRepo.GetByEmail(ctx, identity.Email)
+	}
+
+	if err != nil {
+		// 目录账户不使用本地密码，写入随机哈希使本地登录不可用
+		password, err := randomPassword()
+		if err != nil {
+			return nil, errors.New("failed to provision user")
+		}
+		hashedPassword, err := auth.HashPassword(password)
+		if err != nil {
+			return nil, errors.New("failed to provision user")
+		}
+
+		user = &domain.User{
+			Username:        identity.Username,
+			Email:           identity.Email,
+			Password:        hashedPassword,
+			PasswordVersion: auth.PasswordVersion(),
+			FullName:        fullName,
+			Status:          domain.UserStatusActive,
+			Role:            role,
+			AuthProvider:    domain.AuthProviderLDAP,
+			Language:        domain.DefaultLanguage,
+		}
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			s.logger.Error("Failed to provision directory user", zap.String("username", identity.Username), zap.Error(err))
+			return nil, errors.New("failed to provision user")
+		}
+		s.logger.Info("Directory user provisioned", zap.String("userID", user.ID), zap.String("username", user.Username), zap.String("role", role))
+		return user, nil
+	}
+
+	// 不允许目录账户接管同名的本地账户
+	if user.AuthProvider != domain.AuthProviderLDAP {
+		s.logger.Warn("Directory login conflicts with local account", zap.String("username", identity.Username), zap.String("userID", user.ID))
+		return nil, errors.New("invalid credentials")
+	}
+
+	if user.Email != identity.Email || user.FullName != fullName || user.Role != role {
+		user.Email = identity.Email
+		user.FullName = fullName
+		user.Role = role
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			s.logger.Error("Failed to sync directory user", zap.String("userID", user.ID), zap.Error(err))
+			return nil, errors.New("failed to sync user")
+		}
+	}
+	return user, nil
+}
+
+// mapDirectoryRole 根据目录组映射角色，管理员优先
+func (s *UserService) mapDirectoryRole(groups []string) string {
+	role := domain.RoleUser
+	for _, group := range groups {
+		if mapped, ok := s.directory.GroupRoles[strings.ToLower(group)]; ok && mapped == domain.RoleAdmin {
+			return domain.RoleAdmin
+		}
+	}
+	return role
+}
+
+// randomPassword 生成随机密码
+func randomPassword() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -8,20 +8,26 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/events"
 )
 
 // FriendService 实现domain.FriendService接口
 type FriendService struct {
 	friendRepo domain.FriendRepository
 	userRepo   domain.UserRepository
+	events     events.Publisher
 	logger     *zap.Logger
 }
 
-// NewFriendService 创建一个新的好友服务
-func NewFriendService(friendRepo domain.FriendRepository, userRepo domain.UserRepository, logger *zap.Logger) domain.FriendService {
+// NewFriendService 创建一个新的好友服务，publisher为nil时不发布好友请求事件
+func NewFriendService(friendRepo domain.FriendRepository, userRepo domain.UserRepository, publisher events.Publisher, logger *zap.Logger) domain.FriendService {
+	if publisher == nil {
+		publisher = events.NopPublisher()
+	}
 	return &FriendService{
 		friendRepo: friendRepo,
 		userRepo:   userRepo,
+		events:     publisher,
 		logger:     logger,
 	}
 }
@@ -78,9 +84,35 @@ func (s *FriendService) SendFriendRequest(ctx context.Context, fromUserID, toUse
 		zap.String("to_user_id", toUserID),
 		zap.String("request_id", request.ID))
 
+	s.publishFriendRequestSent(ctx, request)
+
 	return nil
 }
 
+// publishFriendRequestSent 发布好友请求事件，由通知服务通知接收者，发布失败不影响请求结果
+func (s *FriendService) publishFriendRequestSent(ctx context.Context, request *domain.FriendRequest) {
+	senderName := request.FromUserID
+	if sender, err := s.userRepo.GetByID(ctx, request.FromUserID); err == nil && sender != nil {
+		senderName = sender.FullName
+		if senderName == "" {
+			senderName = sender.Username
+		}
+	}
+
+	err := s.events.Publish(ctx, events.TypeFriendRequestSent, events.FriendRequestSent{
+		RequestID:   request.ID,
+		SenderID:    request.FromUserID,
+		SenderName:  senderName,
+		RecipientID: request.ToUserID,
+		Message:     request.Message,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to publish friend request event",
+			zap.String("request_id", request.ID),
+			zap.Error(err))
+	}
+}
+
 // AcceptFriendRequest 接受好友请求
 func (s *FriendService) AcceptFriendRequest(ctx context.Context, requestID, userID string) error {
 	// 获取好友请求
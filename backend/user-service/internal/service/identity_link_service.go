@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// IdentityLinkService 实现domain.IdentityLinkService接口
+type IdentityLinkService struct {
+	linkRepo domain.IdentityLinkRepository
+	userRepo domain.UserRepository
+	logger   *zap.Logger
+}
+
+// NewIdentityLinkService 创建一个新的外部身份映射服务
+func NewIdentityLinkService(linkRepo domain.IdentityLinkRepository, userRepo domain.UserRepository, logger *zap.Logger) domain.IdentityLinkService {
+	return &IdentityLinkService{
+		linkRepo: linkRepo,
+		userRepo: userRepo,
+		logger:   logger,
+	}
+}
+
+// CreateLink 将外部IdP主体映射到内部用户，同一主体只能映射到一个用户
+func (s *IdentityLinkService) CreateLink(ctx context.Context, adminID string, req *domain.CreateIdentityLinkRequest) (*domain.IdentityLink, error) {
+	req.Issuer = strings.TrimSpace(req.Issuer)
+	req.Subject = strings.TrimSpace(req.Subject)
+	if req.Issuer == "" || req.Subject == "" {
+		return nil, errors.New("invalid identity link: issuer and subject are required")
+	}
+	if _, err := uuid.Parse(req.UserID); err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	if _, err := s.userRepo.GetByID(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	if _, err := s.linkRepo.GetBySubject(ctx, req.Issuer, req.Subject); err == nil {
+		return nil, errors.New("identity link already exists")
+	} else if !strings.Contains(err.Error(), "not found") {
+		return nil, err
+	}
+
+	link := &domain.IdentityLink{
+		Issuer:    req.Issuer,
+		Subject:   req.Subject,
+		UserID:    req.UserID,
+		CreatedBy: adminID,
+	}
+	if err := s.linkRepo.Create(ctx, link); err != nil {
+		s.logger.Error("Failed to create identity link", zap.Error(err))
+		return nil, errors.New("failed to create identity link")
+	}
+
+	s.logger.Info("Identity link created",
+		zap.String("issuer", link.Issuer),
+		zap.String("subject", link.Subject),
+		zap.String("user_id", link.UserID),
+		zap.String("admin_id", adminID),
+	)
+	return link, nil
+}
+
+// ListLinks 获取映射列表，userID为空时返回全部
+func (s *IdentityLinkService) ListLinks(ctx context.Context, userID string) ([]*domain.IdentityLink, error) {
+	if userID != "" {
+		if _, err := uuid.Parse(userID); err != nil {
+			return nil, errors.New("invalid user id")
+		}
+	}
+	return s.linkRepo.List(ctx, userID)
+}
+
+// DeleteLink 删除映射，之后该主体的令牌在网关无法通过校验
+func (s *IdentityLinkService) DeleteLink(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return errors.New("identity link not found")
+	}
+	return s.linkRepo.Delete(ctx, id)
+}
+
+// Resolve 解析外部主体对应的内部用户，仅返回状态正常的用户
+func (s *IdentityLinkService) Resolve(ctx context.Context, issuer, subject string) (*domain.ResolvedIdentity, error) {
+	if issuer == "" || subject == "" {
+		return nil, errors.New("invalid identity: issuer and subject are required")
+	}
+
+	link, err := s.linkRepo.GetBySubject(ctx, issuer, subject)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(ctx, link.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != domain.UserStatusActive {
+		return nil, errors.New("user is not active")
+	}
+
+	return &domain.ResolvedIdentity{
+		UserID:   user.ID,
+		Email:    user.Email,
+		Username: user.Username,
+	}, nil
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/auth"
+	mailer "github.com/neohope/chatapp/user-service/pkg/mail"
+	"github.com/neohope/chatapp/user-service/pkg/sms"
+)
+
+const (
+	// maxLoginIdentities 每个账户最多关联的登录身份数量
+	maxLoginIdentities = 10
+	// identityCodeResendInterval 同一邮箱或手机号重新发送验证码的最小间隔
+	identityCodeResendInterval = time.Minute
+)
+
+// phonePattern E.164格式手机号
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// LoginIdentityConfig 登录身份验证配置
+type LoginIdentityConfig struct {
+	CodeTTL     time.Duration
+	MaxAttempts int // 验证码允许输错的次数，超过后需重新发送
+}
+
+// LoginIdentityService 实现domain.LoginIdentityService接口
+type LoginIdentityService struct {
+	identityRepo        domain.LoginIdentityRepository
+	userRepo            domain.UserRepository
+	mailer              mailer.Mailer
+	smsSender           sms.Sender
+	oauth               *auth.OAuthVerifier
+	refreshTokenService domain.RefreshTokenService
+	config              LoginIdentityConfig
+	logger              *zap.Logger
+}
+
+// NewLoginIdentityService 创建一个新的登录身份服务
+func NewLoginIdentityService(identityRepo domain.LoginIdentityRepository, userRepo domain.UserRepository, mailer mailer.Mailer, smsSender sms.Sender, oauth *auth.OAuthVerifier, refreshTokenService domain.RefreshTokenService, config LoginIdentityConfig, logger *zap.Logger) domain.LoginIdentityService {
+	if config.CodeTTL <= 0 {
+		config.CodeTTL = 10 * time.Minute
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+
+	return &LoginIdentityService{
+		identityRepo:        identityRepo,
+		userRepo:            userRepo,
+		mailer:              mailer,
+		smsSender:           smsSender,
+		oauth:               oauth,
+		refreshTokenService: refreshTokenService,
+		config:              config,
+		logger:              logger,
+	}
+}
+
+// ListIdentities 获取当前用户的登录身份
+func (s *LoginIdentityService) ListIdentities(ctx context.Context, userID string) ([]*domain.LoginIdentity, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureAccountIdentity(ctx, s.identityRepo, user); err != nil {
+		return nil, err
+	}
+	return s.identityRepo.List(ctx, userID)
+}
+
+// StartVerification 向待关联的邮箱或手机号发送6位验证码
+func (s *LoginIdentityService) StartVerification(ctx context.Context, userID string, req *domain.LinkIdentityRequest) error {
+	identifier, err := normalizeIdentifier(req.Type, req.Identifier)
+	if err != nil {
+		return err
+	}
+	user, err := s.prepareLink(ctx, userID, req.Type, identifier)
+	if err != nil {
+		return err
+	}
+	if previous, err := s.identityRepo.GetVerification(ctx, userID, req.Type, identifier); err == nil &&
+		time.Since(previous.CreatedAt) < identityCodeResendInterval {
+		return errors.New("verification code recently sent")
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	verification := &domain.IdentityVerification{
+		UserID:     userID,
+		Type:       req.Type,
+		Identifier: identifier,
+		CodeHash:   hashVerificationToken(code),
+		ExpiresAt:  time.Now().Add(s.config.CodeTTL),
+	}
+	if err := s.identityRepo.SaveVerification(ctx, verification); err != nil {
+		s.logger.Error("Failed to save identity verification", zap.String("user_id", userID), zap.Error(err))
+		return errors.New("failed to save verification code")
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, verificationMailTimeout)
+	defer cancel()
+	subject, text := identityCodeMessage(user, code, s.config.CodeTTL)
+	if req.Type == domain.LoginIdentityEmail {
+		err = s.mailer.Send(sendCtx, identifier, subject, text)
+	} else {
+		err = s.smsSender.Send(sendCtx, identifier, text)
+	}
+	if err != nil {
+		s.logger.Error("Failed to send identity verification code", zap.String("user_id", userID), zap.String("type", string(req.Type)), zap.Error(err))
+		return errors.New("failed to send verification code")
+	}
+
+	s.logger.Info("Identity verification code sent", zap.String("user_id", userID), zap.String("type", string(req.Type)))
+	return nil
+}
+
+// VerifyIdentity 校验验证码，通过后关联邮箱或手机号，验证码只能使用一次
+func (s *LoginIdentityService) VerifyIdentity(ctx context.Context, userID string, req *domain.VerifyIdentityRequest) (*domain.LoginIdentity, error) {
+	identifier, err := normalizeIdentifier(req.Type, req.Identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	verification, err := s.identityRepo.GetVerification(ctx, userID, req.Type, identifier)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.New("invalid verification code")
+		}
+		return nil, err
+	}
+	if time.Now().After(verification.ExpiresAt) {
+		_ = s.identityRepo.DeleteVerification(ctx, userID, req.Type, identifier)
+		return nil, errors.New("verification code expired")
+	}
+	if verification.Attempts >= s.config.MaxAttempts {
+		_ = s.identityRepo.DeleteVerification(ctx, userID, req.Type, identifier)
+		return nil, errors.New("too many verification attempts")
+	}
+	if subtle.ConstantTimeCompare([]byte(hashVerificationToken(req.Code)), []byte(verification.CodeHash)) != 1 {
+		if err := s.identityRepo.IncrementVerificationAttempts(ctx, userID, req.Type, identifier); err != nil {
+			s.logger.Warn("Failed to record verification attempt", zap.String("user_id", userID), zap.Error(err))
+		}
+		return nil, errors.New("invalid verification code")
+	}
+
+	if _, err := s.prepareLink(ctx, userID, req.Type, identifier); err != nil {
+		return nil, err
+	}
+	identity := &domain.LoginIdentity{
+		UserID:     userID,
+		Type:       req.Type,
+		Identifier: identifier,
+		VerifiedAt: time.Now(),
+	}
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		return nil, err
+	}
+	if err := s.identityRepo.DeleteVerification(ctx, userID, req.Type, identifier); err != nil {
+		s.logger.Warn("Failed to delete used verification code", zap.String("user_id", userID), zap.Error(err))
+	}
+
+	s.logger.Info("Login identity linked", zap.String("user_id", userID), zap.String("type", string(req.Type)))
+	return identity, nil
+}
+
+// LinkOAuth 校验Google/GitHub令牌并关联账号
+func (s *LoginIdentityService) LinkOAuth(ctx context.Context, userID string, req *domain.OAuthIdentityRequest) (*domain.LoginIdentity, error) {
+	account, err := s.oauth.Verify(ctx, string(req.Provider), req.Token)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.prepareLink(ctx, userID, req.Provider, account.Subject); err != nil {
+		return nil, err
+	}
+
+	identity := &domain.LoginIdentity{
+		UserID:      userID,
+		Type:        req.Provider,
+		Identifier:  account.Subject,
+		DisplayName: account.Name,
+		VerifiedAt:  time.Now(),
+	}
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Login identity linked", zap.String("user_id", userID), zap.String("type", string(req.Provider)))
+	return identity, nil
+}
+
+// UnlinkIdentity 取消关联登录身份，最后一个登录身份不能取消
+func (s *LoginIdentityService) UnlinkIdentity(ctx context.Context, userID, id string) error {
+	if err := s.identityRepo.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+	s.logger.Info("Login identity unlinked", zap.String("user_id", userID), zap.String("identity_id", id))
+	return nil
+}
+
+// LoginWithOAuth 使用已关联的Google/GitHub账号登录，不会自动创建账户
+func (s *LoginIdentityService) LoginWithOAuth(ctx context.Context, req *domain.OAuthIdentityRequest) (*domain.LoginResponse, error) {
+	account, err := s.oauth.Verify(ctx, string(req.Provider), req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := s.identityRepo.GetByIdentifier(ctx, req.Provider, account.Subject)
+	if err != nil {
+		s.logger.Info("OAuth account not linked", zap.String("provider", string(req.Provider)), zap.Error(err))
+		return nil, errors.New("invalid credentials")
+	}
+	user, err := s.userRepo.GetByID(ctx, identity.UserID)
+	if err != nil {
+		return nil, errors.New("invalid credentials")
+	}
+
+	if user.Status != domain.UserStatusActive {
+		s.logger.Info("User account is not active", zap.String("userID", user.ID), zap.String("status", string(user.Status)))
+		switch user.Status {
+		case domain.UserStatusDeactivated:
+			return nil, errors.New("account is deactivated")
+		case domain.UserStatusSuspended:
+			return nil, errors.New("account is suspended")
+		}
+		return nil, errors.New("account is not active")
+	}
+
+	// 记录最后在线时间，失败不影响登录
+	if err := s.userRepo.UpdateLastSeen(ctx, user.ID, time.Now()); err != nil {
+		s.logger.Warn("Failed to update last seen", zap.String("userID", user.ID), zap.Error(err))
+	}
+
+	response, err := s.refreshTokenService.IssueSession(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("OAuth login succeeded", zap.String("provider", string(req.Provider)), zap.String("userID", user.ID))
+	return response, nil
+}
+
+// prepareLink 关联前检查身份是否已被占用和账户的身份数量，旧账户先补写账户邮箱身份
+func (s *LoginIdentityService) prepareLink(ctx context.Context, userID string, identityType domain.LoginIdentityType, identifier string) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureAccountIdentity(ctx, s.identityRepo, user); err != nil {
+		return nil, err
+	}
+
+	if existing, err := s.identityRepo.GetByIdentifier(ctx, identityType, identifier); err == nil {
+		if existing.UserID == userID {
+			return nil, errors.New("login identity already linked to this account")
+		}
+		return nil, errors.New("login identity already exists")
+	}
+	// 其他账户尚未补写的账户邮箱同样视为已占用
+	if identityType == domain.LoginIdentityEmail {
+		if owner, err := s.userRepo.GetByEmail(ctx, identifier); err == nil && owner.ID != userID {
+			return nil, errors.New("login identity already exists")
+		}
+	}
+
+	count, err := s.identityRepo.Count(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxLoginIdentities {
+		return nil, fmt.Errorf("too many login identities: at most %d", maxLoginIdentities)
+	}
+	return user, nil
+}
+
+// ensureAccountIdentity 没有任何登录身份的账户（登录身份上线前注册、导入或SSO创建的账户）以账户邮箱补写一条
+// 取消关联不能删除最后一个身份，所以身份数为0只可能是尚未补写
+func ensureAccountIdentity(ctx context.Context, identityRepo domain.LoginIdentityRepository, user *domain.User) error {
+	count, err := identityRepo.Count(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 || user.Email == "" {
+		return nil
+	}
+
+	err = identityRepo.Create(ctx, &domain.LoginIdentity{
+		UserID:     user.ID,
+		Type:       domain.LoginIdentityEmail,
+		Identifier: normalizeEmail(user.Email),
+		VerifiedAt: user.CreatedAt,
+	})
+	if err != nil && !strings.Contains(err.Error(), "already exists") {
+		return err
+	}
+	return nil
+}
+
+// userByLoginIdentity 通过邮箱或手机号登录身份查找用户
+// 账户邮箱尚未补写为登录身份时按账户邮箱查找，账户已有其他身份说明账户邮箱已取消关联，不再允许登录
+func userByLoginIdentity(ctx context.Context, identityRepo domain.LoginIdentityRepository, userRepo domain.UserRepository, identityType domain.LoginIdentityType, identifier string) (*domain.User, error) {
+	normalized, err := normalizeIdentifier(identityType, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := identityRepo.GetByIdentifier(ctx, identityType, normalized)
+	if err == nil {
+		return userRepo.GetByID(ctx, identity.UserID)
+	}
+	if identityType != domain.LoginIdentityEmail || !strings.Contains(err.Error(), "not found") {
+		return nil, err
+	}
+
+	user, err := userRepo.GetByEmail(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	count, err := identityRepo.Count(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("user not found")
+	}
+	if err := ensureAccountIdentity(ctx, identityRepo, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// normalizeIdentifier 邮箱转为小写，手机号去掉空格、横线和括号后须为E.164格式
+func normalizeIdentifier(identityType domain.LoginIdentityType, identifier string) (string, error) {
+	switch identityType {
+	case domain.LoginIdentityEmail:
+		email := normalizeEmail(identifier)
+		if !strings.Contains(email, "@") || strings.ContainsAny(email, " \r\n") {
+			return "", errors.New("invalid email address")
+		}
+		return email, nil
+	case domain.LoginIdentityPhone:
+		phone, ok := normalizePhone(identifier)
+		if !ok {
+			return "", errors.New("invalid phone number: use E.164 format such as +8613800138000")
+		}
+		return phone, nil
+	default:
+		return "", fmt.Errorf("unsupported login identity type: %s", identityType)
+	}
+}
+
+// normalizeEmail 登录身份中的邮箱统一为小写
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// normalizePhone 去掉分隔符后校验E.164格式
+func normalizePhone(phone string) (string, bool) {
+	phone = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+	return phone, phonePattern.MatchString(phone)
+}
+
+// identityCodeMessage 按用户语言生成验证码邮件/短信
+func identityCodeMessage(user *domain.User, code string, ttl time.Duration) (string, string) {
+	minutes := int(ttl.Minutes())
+	if user.Language == "en-US" {
+		return "Your ChatApp verification code",
+			fmt.Sprintf("ChatApp verification code: %s. Use it within %d minutes to add this sign-in method to %s. "+
+				"If you did not request this, ignore this message.", code, minutes, user.Username)
+	}
+	return "ChatApp 验证码",
+		fmt.Sprintf("ChatApp 验证码：%s，%d 分钟内有效，用于为账户 %s 添加登录方式。如果不是您本人的操作，请忽略。",
+			code, minutes, user.Username)
+}
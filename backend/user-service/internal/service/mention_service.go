@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/client"
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// participantCacheTTL 会话成员缓存时间，输入@时每次按键都会查询，避免每次都请求消息服务
+const participantCacheTTL = 30 * time.Second
+
+// maxMentionPrefixLength 前缀最大长度，与用户名长度上限一致
+const maxMentionPrefixLength = 50
+
+type participantCacheEntry struct {
+	participants []string
+	expiresAt    time.Time
+}
+
+// MentionService 实现domain.MentionService接口
+type MentionService struct {
+	mentionRepo   domain.MentionRepository
+	messageClient *client.MessageClient
+	logger        *zap.Logger
+
+	mu           sync.Mutex
+	participants map[string]participantCacheEntry
+}
+
+// NewMentionService 创建一个新的@提及自动补全服务
+func NewMentionService(mentionRepo domain.MentionRepository, messageClient *client.MessageClient, logger *zap.Logger) domain.MentionService {
+	return &MentionService{
+		mentionRepo:   mentionRepo,
+		messageClient: messageClient,
+		logger:        logger,
+		participants:  make(map[string]participantCacheEntry),
+	}
+}
+
+// Autocomplete 按用户名前缀返回可@的用户：指定会话时为会话成员和好友，会话成员在前；只有会话成员才能查询该会话
+func (s *MentionService) Autocomplete(ctx context.Context, userID, prefix, conversationID string, limit int) ([]*domain.MentionCandidate, error) {
+	prefix = strings.TrimPrefix(strings.TrimSpace(prefix), "@")
+	if utf8.RuneCountInString(prefix) > maxMentionPrefixLength {
+		return nil, errors.New("invalid prefix: too long")
+	}
+	if limit <= 0 {
+		limit = domain.DefaultMentionLimit
+	}
+	if limit > domain.MaxMentionLimit {
+		limit = domain.MaxMentionLimit
+	}
+
+	var participants []string
+	if conversationID != "" {
+		var err error
+		participants, err = s.getParticipants(ctx, userID, conversationID)
+		if err != nil {
+			return nil, err
+		}
+		if !containsString(participants, userID) {
+			return nil, errors.New("access denied: not a participant of this conversation")
+		}
+	}
+
+	candidates, err := s.mentionRepo.FindByUsernamePrefix(ctx, userID, prefix, participants, limit)
+	if err != nil {
+		s.logger.Error("Failed to autocomplete mentions", zap.String("user_id", userID), zap.Error(err))
+		return nil, errors.New("failed to autocomplete mentions")
+	}
+	return candidates, nil
+}
+
+// getParticipants 获取会话成员，优先使用缓存
+func (s *MentionService) getParticipants(ctx context.Context, userID, conversationID string) ([]string, error) {
+	s.mu.Lock()
+	entry, ok := s.participants[conversationID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.participants, nil
+	}
+
+	if s.messageClient == nil {
+		return nil, errors.New("conversation lookup unavailable")
+	}
+	participants, err := s.messageClient.GetConversationParticipants(ctx, userID, conversationID)
+	if err != nil {
+		if errors.Is(err, client.ErrConversationNotFound) {
+			return nil, err
+		}
+		s.logger.Error("Failed to get conversation participants", zap.String("conversation_id", conversationID), zap.Error(err))
+		return nil, errors.New("failed to get conversation participants")
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	// 顺带清理过期条目，缓存大小受活跃会话数量约束
+	for id, cached := range s.participants {
+		if now.After(cached.expiresAt) {
+			delete(s.participants, id)
+		}
+	}
+	s.participants[conversationID] = participantCacheEntry{participants: participants, expiresAt: now.Add(participantCacheTTL)}
+	s.mu.Unlock()
+	return participants, nil
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
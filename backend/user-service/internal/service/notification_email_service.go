@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	mailer "github.com/neohope/chatapp/user-service/pkg/mail"
+)
+
+// verificationMailTimeout 验证邮件的发送超时
+const verificationMailTimeout = 30 * time.Second
+
+// NotificationEmailConfig 通知邮箱验证配置
+type NotificationEmailConfig struct {
+	VerificationURL string // 前端验证页面地址，令牌以token查询参数附加
+	VerificationTTL time.Duration
+}
+
+// NotificationEmailService 实现domain.NotificationEmailService接口
+type NotificationEmailService struct {
+	emailRepo domain.NotificationEmailRepository
+	userRepo  domain.UserRepository
+	mailer    mailer.Mailer
+	config    NotificationEmailConfig
+	logger    *zap.Logger
+}
+
+// NewNotificationEmailService 创建一个新的通知邮箱服务
+func NewNotificationEmailService(emailRepo domain.NotificationEmailRepository, userRepo domain.UserRepository, mailer mailer.Mailer, config NotificationEmailConfig, logger *zap.Logger) domain.NotificationEmailService {
+	return &NotificationEmailService{
+		emailRepo: emailRepo,
+		userRepo:  userRepo,
+		mailer:    mailer,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// GetNotificationEmail 获取当前用户的通知邮箱
+func (s *NotificationEmailService) GetNotificationEmail(ctx context.Context, userID string) (*domain.NotificationEmail, error) {
+	return s.emailRepo.Get(ctx, userID)
+}
+
+// SetNotificationEmail 设置通知邮箱，验证通过前通知邮件仍发送到登录邮箱
+func (s *NotificationEmailService) SetNotificationEmail(ctx context.Context, userID, email string) (*domain.NotificationEmail, error) {
+	email = strings.TrimSpace(email)
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(email, user.Email) {
+		return nil, errors.New("notification email must differ from account email")
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	expiresAt := time.Now().Add(s.config.VerificationTTL)
+
+	notificationEmail := &domain.NotificationEmail{
+		UserID:         userID,
+		Email:          email,
+		TokenHash:      hashVerificationToken(token),
+		TokenExpiresAt: &expiresAt,
+	}
+	if err := s.emailRepo.Upsert(ctx, notificationEmail); err != nil {
+		s.logger.Error("Failed to save notification email", zap.String("user_id", userID), zap.Error(err))
+		return nil, errors.New("failed to save notification email")
+	}
+
+	link := s.config.VerificationURL
+	if strings.Contains(link, "?") {
+		link += "&token=" + url.QueryEscape(token)
+	} else {
+		link += "?token=" + url.QueryEscape(token)
+	}
+	subject, body := verificationMail(user, link, expiresAt)
+
+	mailCtx, cancel := context.WithTimeout(ctx, verificationMailTimeout)
+	defer cancel()
+	if err := s.mailer.Send(mailCtx, email, subject, body); err != nil {
+		// 设置已保存，用户可以重新设置以再次发送验证邮件
+		s.logger.Error("Failed to send verification email", zap.String("user_id", userID), zap.Error(err))
+		return nil, errors.New("failed to send verification email")
+	}
+
+	s.logger.Info("Notification email verification sent", zap.String("user_id", userID))
+	return notificationEmail, nil
+}
+
+// VerifyNotificationEmail 使用验证邮件中的令牌确认通知邮箱，令牌只能使用一次
+func (s *NotificationEmailService) VerifyNotificationEmail(ctx context.Context, token string) (*domain.NotificationEmail, error) {
+	tokenHash := hashVerificationToken(token)
+	notificationEmail, err := s.emailRepo.GetByTokenHash(ctx, tokenHash)
+	if err != nil {
+		return nil, errors.New("invalid verification token")
+	}
+	if notificationEmail.TokenExpiresAt == nil || time.Now().After(*notificationEmail.TokenExpiresAt) {
+		return nil, errors.New("verification token expired")
+	}
+
+	now := time.Now()
+	if err := s.emailRepo.MarkVerified(ctx, notificationEmail.UserID, tokenHash, now); err != nil {
+		return nil, err
+	}
+	notificationEmail.Verified = true
+	notificationEmail.VerifiedAt = &now
+	notificationEmail.UpdatedAt = now
+	notificationEmail.TokenHash = ""
+	notificationEmail.TokenExpiresAt = nil
+
+	s.logger.Info("Notification email verified", zap.String("user_id", notificationEmail.UserID))
+	return notificationEmail, nil
+}
+
+// RemoveNotificationEmail 删除通知邮箱，之后通知邮件发送到登录邮箱
+func (s *NotificationEmailService) RemoveNotificationEmail(ctx context.Context, userID string) error {
+	return s.emailRepo.Delete(ctx, userID)
+}
+
+// ResolveAddress 已验证的通知邮箱优先，否则使用登录邮箱
+func (s *NotificationEmailService) ResolveAddress(ctx context.Context, userID string) (*domain.NotificationAddress, error) {
+	notificationEmail, err := s.emailRepo.Get(ctx, userID)
+	if err == nil && notificationEmail.Verified {
+		return &domain.NotificationAddress{Email: notificationEmail.Email, Source: domain.NotificationEmailSourceNotification}, nil
+	}
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.NotificationAddress{Email: user.Email, Source: domain.NotificationEmailSourceAccount}, nil
+}
+
+// verificationMail 按用户语言生成通知邮箱验证邮件
+func verificationMail(user *domain.User, link string, expiresAt time.Time) (string, string) {
+	expires := expiresAt.UTC().Format("2006-01-02 15:04 UTC")
+	if user.Language == "en-US" {
+		return "Confirm your ChatApp notification email",
+			fmt.Sprintf("Hi %s,\n\nThis address was set to receive ChatApp notifications for %s.\n"+
+				"Open the link below to confirm it:\n\n%s\n\nThis link expires at %s. If you did not request this, ignore this email.\n",
+				user.FullName, user.Username, link, expires)
+	}
+	return "请确认 ChatApp 通知邮箱",
+		fmt.Sprintf("%s，您好：\n\n此地址被设置为账户 %s 的 ChatApp 通知邮箱。\n"+
+			"请打开以下链接确认：\n\n%s\n\n链接将于 %s 失效。如果不是您本人的操作，请忽略此邮件。\n",
+			user.FullName, user.Username, link, expires)
+}
+
+// hashVerificationToken 验证令牌只保存SHA-256哈希
+func hashVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+// ProfileService 实现domain.ProfileService接口
+type ProfileService struct {
+	userRepo      domain.UserRepository
+	privacyRepo   domain.PrivacyRepository
+	friendService domain.FriendService
+	logger        *zap.Logger
+}
+
+// NewProfileService 创建一个新的用户资料服务
+func NewProfileService(userRepo domain.UserRepository, privacyRepo domain.PrivacyRepository, friendService domain.FriendService, logger *zap.Logger) domain.ProfileService {
+	return &ProfileService{
+		userRepo:      userRepo,
+		privacyRepo:   privacyRepo,
+		friendService: friendService,
+		logger:        logger,
+	}
+}
+
+// GetProfile 获取用户资料，按资料主人的隐私设置过滤查看者不可见的字段
+func (s *ProfileService) GetProfile(ctx context.Context, viewerID, userID string) (*domain.PublicProfile, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.Info("User not found for profile", zap.String("id", userID), zap.Error(err))
+		return nil, errors.New("user not found")
+	}
+
+	// 停用或封禁的账户对他人隐藏
+	if viewerID != userID && user.Status != domain.UserStatusActive {
+		return nil, errors.New("user not found")
+	}
+
+	// 本人可见全部字段
+	profile := domain.NewPublicProfile(user, viewerID)
+	if profile.IsSelf {
+		return profile, nil
+	}
+
+	settings, err := s.privacyRepo.Get(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get privacy settings", zap.String("id", userID), zap.Error(err))
+		return nil, errors.New("failed to get profile")
+	}
+
+	profile.IsFriend, err = s.friendService.CheckFriendship(ctx, viewerID, userID)
+	if err != nil {
+		// 无法确认好友关系时按非好友处理
+		s.logger.Warn("Failed to check friendship", zap.String("viewer", viewerID), zap.String("id", userID), zap.Error(err))
+		profile.IsFriend = false
+	}
+
+	if isVisible(settings.EmailVisibility, profile.IsFriend) {
+		profile.Email = user.Email
+	}
+	if isVisible(settings.PhoneVisibility, profile.IsFriend) {
+		profile.Phone = user.Phone
+	}
+	if isVisible(settings.LastSeenVisibility, profile.IsFriend) {
+		profile.LastSeenAt = user.LastSeenAt
+	}
+
+	return profile, nil
+}
+
+// GetPrivacySettings 获取用户隐私设置
+func (s *ProfileService) GetPrivacySettings(ctx context.Context, userID string) (*domain.PrivacySettings, error) {
+	settings, err := s.privacyRepo.Get(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get privacy settings", zap.String("id", userID), zap.Error(err))
+		return nil, errors.New("failed to get privacy settings")
+	}
+	return settings, nil
+}
+
+// UpdatePrivacySettings 更新用户隐私设置
+func (s *ProfileService) UpdatePrivacySettings(ctx context.Context, userID string, req *domain.UpdatePrivacySettingsRequest) (*domain.PrivacySettings, error) {
+	for _, v := range []domain.Visibility{req.EmailVisibility, req.PhoneVisibility, req.LastSeenVisibility} {
+		if v != "" && !domain.IsValidVisibility(v) {
+			return nil, errors.New("invalid visibility")
+		}
+	}
+
+	settings, err := s.GetPrivacySettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.EmailVisibility != "" {
+		settings.EmailVisibility = req.EmailVisibility
+	}
+	if req.PhoneVisibility != "" {
+		settings.PhoneVisibility = req.PhoneVisibility
+	}
+	if req.LastSeenVisibility != "" {
+		settings.LastSeenVisibility = req.LastSeenVisibility
+	}
+	if req.Discoverable != nil {
+		settings.Discoverable = *req.Discoverable
+	}
+
+	if err := s.privacyRepo.Upsert(ctx, settings); err != nil {
+		s.logger.Error("Failed to update privacy settings", zap.String("id", userID), zap.Error(err))
+		return nil, errors.New("failed to update privacy settings")
+	}
+
+	return settings, nil
+}
+
+// isVisible 判断字段对查看者是否可见
+func isVisible(visibility domain.Visibility, isFriend bool) bool {
+	switch visibility {
+	case domain.VisibilityEveryone:
+		return true
+	case domain.VisibilityFriends:
+		return isFriend
+	default:
+		return false
+	}
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/ai"
+	"github.com/neohope/chatapp/user-service/pkg/jobs"
+)
+
+// ErrInvalidInterests 简介或兴趣不符合限制
+var ErrInvalidInterests = errors.New("invalid interests")
+
+// RecommendationConfig 推荐用户配置
+type RecommendationConfig struct {
+	// EmbeddingEnabled 是否开启向量近邻推荐，关闭时推荐列表为空
+	EmbeddingEnabled bool
+	RefreshInterval  time.Duration
+	RefreshBatch     int
+}
+
+// RecommendationService 实现domain.RecommendationService接口
+type RecommendationService struct {
+	interestRepo  domain.InterestRepository
+	embeddingRepo domain.EmbeddingRepository
+	embedder      ai.Embedder
+	cfg           RecommendationConfig
+	logger        *zap.Logger
+}
+
+// NewRecommendationService 创建一个新的推荐用户服务，未开启向量推荐时embeddingRepo可为nil
+func NewRecommendationService(interestRepo domain.InterestRepository, embeddingRepo domain.EmbeddingRepository, embedder ai.Embedder, cfg RecommendationConfig, logger *zap.Logger) domain.RecommendationService {
+	if embeddingRepo == nil {
+		cfg.EmbeddingEnabled = false
+	}
+	return &RecommendationService{
+		interestRepo:  interestRepo,
+		embeddingRepo: embeddingRepo,
+		embedder:      embedder,
+		cfg:           cfg,
+		logger:        logger,
+	}
+}
+
+// ScheduleJobs 注册后台任务：启动时及之后定期为资料有变更的用户补算向量，未开启向量推荐时不注册
+func (s *RecommendationService) ScheduleJobs(runner *jobs.Runner) error {
+	if !s.cfg.EmbeddingEnabled {
+		return nil
+	}
+
+	interval := s.cfg.RefreshInterval
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+
+	return runner.Schedule("refresh_user_embeddings", fmt.Sprintf("@every %s", interval), func(ctx context.Context, _ json.RawMessage) error {
+		count, err := s.RefreshStaleEmbeddings(ctx)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			s.logger.Info("Refreshed user embeddings", zap.Int("count", count))
+		}
+		return nil
+	}, jobs.RunOnStart(), jobs.MaxAttempts(1))
+}
+
+// GetInterests 获取用户简介和兴趣
+func (s *RecommendationService) GetInterests(ctx context.Context, userID string) (*domain.InterestProfile, error) {
+	profile, err := s.interestRepo.Get(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get interests", zap.String("user_id", userID), zap.Error(err))
+		return nil, errors.New("failed to get interests")
+	}
+	return profile, nil
+}
+
+// UpdateInterests 更新用户简介和兴趣，开启向量推荐时同步重新生成向量
+// 生成失败不影响保存，由后台任务稍后补算
+func (s *RecommendationService) UpdateInterests(ctx context.Context, userID string, req *domain.UpdateInterestsRequest) (*domain.InterestProfile, error) {
+	profile, err := normalizeInterests(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.interestRepo.Upsert(ctx, profile); err != nil {
+		s.logger.Error("Failed to save interests", zap.String("user_id", userID), zap.Error(err))
+		return nil, errors.New("failed to save interests")
+	}
+
+	if s.cfg.EmbeddingEnabled {
+		if err := s.refreshEmbedding(ctx, profile); err != nil {
+			s.logger.Warn("Failed to embed interests, will retry in background", zap.String("user_id", userID), zap.Error(err))
+		}
+	}
+
+	return profile, nil
+}
+
+// GetRecommendedUsers 按简介/兴趣向量推荐可能认识的人，当前用户尚无向量时返回空列表
+func (s *RecommendationService) GetRecommendedUsers(ctx context.Context, userID string, limit, offset int) ([]*domain.RecommendedUser, error) {
+	if !s.cfg.EmbeddingEnabled {
+		return []*domain.RecommendedUser{}, nil
+	}
+
+	embedding, err := s.embeddingRepo.Get(ctx, userID, s.embedder.Model())
+	if err != nil {
+		s.logger.Error("Failed to get user embedding", zap.String("user_id", userID), zap.Error(err))
+		return nil, errors.New("failed to get recommended users")
+	}
+	if embedding == nil {
+		return []*domain.RecommendedUser{}, nil
+	}
+
+	users, err := s.embeddingRepo.NearestNeighbors(ctx, userID, s.embedder.Model(), embedding, limit, offset)
+	if err != nil {
+		s.logger.Error("Failed to query nearest users", zap.String("user_id", userID), zap.Error(err))
+		return nil, errors.New("failed to get recommended users")
+	}
+
+	profile, err := s.interestRepo.Get(ctx, userID)
+	if err != nil {
+		// 共同兴趣只是辅助展示，获取失败时不影响推荐结果
+		s.logger.Warn("Failed to get interests for recommendations", zap.String("user_id", userID), zap.Error(err))
+		profile = &domain.InterestProfile{}
+	}
+	for _, user := range users {
+		user.SharedInterests = sharedInterests(profile.Interests, user.Interests)
+	}
+	return users, nil
+}
+
+// RefreshStaleEmbeddings 批量为过期的兴趣资料重新生成向量，返回更新的用户数
+func (s *RecommendationService) RefreshStaleEmbeddings(ctx context.Context) (int, error) {
+	batch := s.cfg.RefreshBatch
+	if batch <= 0 {
+		batch = 100
+	}
+
+	profiles, err := s.embeddingRepo.ListStale(ctx, s.embedder.Model(), batch)
+	if err != nil {
+		return 0, err
+	}
+	if len(profiles) == 0 {
+		return 0, nil
+	}
+
+	texts := make([]string, len(profiles))
+	for i, profile := range profiles {
+		texts[i] = embeddingText(profile)
+	}
+	embeddings, err := s.embedder.Embed(ctx, texts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to embed interests: %w", err)
+	}
+
+	updated := 0
+	for i, profile := range profiles {
+		if err := s.embeddingRepo.Save(ctx, profile.UserID, s.embedder.Model(), embeddings[i], profile.UpdatedAt); err != nil {
+			s.logger.Error("Failed to save user embedding", zap.String("user_id", profile.UserID), zap.Error(err))
+			continue
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// refreshEmbedding 重新生成单个用户的向量，资料为空时删除向量
+func (s *RecommendationService) refreshEmbedding(ctx context.Context, profile *domain.InterestProfile) error {
+	if profile.Bio == "" && len(profile.Interests) == 0 {
+		return s.embeddingRepo.Delete(ctx, profile.UserID)
+	}
+
+	embeddings, err := s.embedder.Embed(ctx, []string{embeddingText(profile)})
+	if err != nil {
+		return err
+	}
+	return s.embeddingRepo.Save(ctx, profile.UserID, s.embedder.Model(), embeddings[0], profile.UpdatedAt)
+}
+
+// normalizeInterests 校验并整理简介和兴趣：去除首尾空白，兴趣去重（不区分大小写）并保留原顺序
+func normalizeInterests(userID string, req *domain.UpdateInterestsRequest) (*domain.InterestProfile, error) {
+	bio := strings.TrimSpace(req.Bio)
+	if utf8.RuneCountInString(bio) > domain.MaxBioLength {
+		return nil, fmt.Errorf("%w: bio must be at most %d characters", ErrInvalidInterests, domain.MaxBioLength)
+	}
+
+	interests := make([]string, 0, len(req.Interests))
+	seen := make(map[string]bool)
+	for _, interest := range req.Interests {
+		interest = strings.TrimSpace(interest)
+		if interest == "" {
+			continue
+		}
+		if utf8.RuneCountInString(interest) > domain.MaxInterestLength {
+			return nil, fmt.Errorf("%w: each interest must be at most %d characters", ErrInvalidInterests, domain.MaxInterestLength)
+		}
+		key := strings.ToLower(interest)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		interests = append(interests, interest)
+	}
+	if len(interests) > domain.MaxInterests {
+		return nil, fmt.Errorf("%w: at most %d interests are allowed", ErrInvalidInterests, domain.MaxInterests)
+	}
+
+	return &domain.InterestProfile{
+		UserID:    userID,
+		Bio:       bio,
+		Interests: interests,
+	}, nil
+}
+
+// embeddingText 生成用于向量化的文本
+func embeddingText(profile *domain.InterestProfile) string {
+	var parts []string
+	if profile.Bio != "" {
+		parts = append(parts, "Bio: "+profile.Bio)
+	}
+	if len(profile.Interests) > 0 {
+		parts = append(parts, "Interests: "+strings.Join(profile.Interests, ", "))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// sharedInterests 返回双方共同的兴趣（不区分大小写），按当前用户的兴趣顺序
+func sharedInterests(mine, theirs []string) []string {
+	shared := []string{}
+	if len(mine) == 0 || len(theirs) == 0 {
+		return shared
+	}
+
+	other := make(map[string]bool, len(theirs))
+	for _, interest := range theirs {
+		other[strings.ToLower(interest)] = true
+	}
+	for _, interest := range mine {
+		if other[strings.ToLower(interest)] {
+			shared = append(shared, interest)
+		}
+	}
+	return shared
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/auth"
+)
+
+// RefreshTokenService 实现domain.RefreshTokenService接口
+// 刷新令牌每次使用后轮换，已轮换的令牌再次出现时吊销整个令牌族
+type RefreshTokenService struct {
+	tokenRepo  domain.RefreshTokenRepository
+	userRepo   domain.UserRepository
+	jwtManager *auth.JWTManager
+	ttl        time.Duration
+	logger     *zap.Logger
+}
+
+// NewRefreshTokenService 创建刷新令牌服务，ttl为每个刷新令牌的有效期
+func NewRefreshTokenService(tokenRepo domain.RefreshTokenRepository, userRepo domain.UserRepository, jwtManager *auth.JWTManager, ttl time.Duration, logger *zap.Logger) domain.RefreshTokenService {
+	return &RefreshTokenService{
+		tokenRepo:  tokenRepo,
+		userRepo:   userRepo,
+		jwtManager: jwtManager,
+		ttl:        ttl,
+		logger:     logger,
+	}
+}
+
+// IssueSession 签发访问令牌和新令牌族的刷新令牌
+func (s *RefreshTokenService) IssueSession(ctx context.Context, user *domain.User) (*domain.LoginResponse, error) {
+	accessToken, err := s.jwtManager.GenerateToken(user)
+	if err != nil {
+		s.logger.Error("Failed to generate token", zap.Error(err))
+		return nil, errors.New("failed to generate authentication token")
+	}
+	refreshToken, err := s.issue(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Password = ""
+	return &domain.LoginResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		User:         user,
+	}, nil
+}
+
+// issue 签发新令牌族的刷新令牌
+func (s *RefreshTokenService) issue(ctx context.Context, userID string) (string, error) {
+	token := &domain.RefreshToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	token.FamilyID = token.ID
+
+	signed, err := s.jwtManager.GenerateRefreshToken(userID, token.ID, token.ExpiresAt)
+	if err != nil {
+		return "", err
+	}
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		s.logger.Error("Failed to save refresh token", zap.String("userID", userID), zap.Error(err))
+		return "", errors.New("failed to issue refresh token")
+	}
+	return signed, nil
+}
+
+// Refresh 轮换刷新令牌并签发新的访问令牌
+func (s *RefreshTokenService) Refresh(ctx context.Context, refreshToken string) (*domain.LoginResponse, error) {
+	current, err := s.lookup(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.RevokedAt != nil {
+		if current.ReplacedBy != nil {
+			return nil, s.revokeReused(ctx, current)
+		}
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, current.UserID)
+	if err != nil || user.Status != domain.UserStatusActive {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	next := &domain.RefreshToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		FamilyID:  current.FamilyID,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	signedRefresh, err := s.jwtManager.GenerateRefreshToken(user.ID, next.ID, next.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := s.jwtManager.GenerateToken(user)
+	if err != nil {
+		s.logger.Error("Failed to generate token", zap.Error(err))
+		return nil, errors.New("failed to generate authentication token")
+	}
+
+	rotated, err := s.tokenRepo.Rotate(ctx, current.ID, next)
+	if err != nil {
+		s.logger.Error("Failed to rotate refresh token", zap.String("userID", user.ID), zap.Error(err))
+		return nil, errors.New("failed to rotate refresh token")
+	}
+	if !rotated {
+		// 并发请求已经轮换了同一个令牌
+		return nil, s.revokeReused(ctx, current)
+	}
+
+	user.Password = ""
+	return &domain.LoginResponse{
+		Token:        accessToken,
+		RefreshToken: signedRefresh,
+		User:         user,
+	}, nil
+}
+
+// Revoke 吊销刷新令牌所在的令牌族
+func (s *RefreshTokenService) Revoke(ctx context.Context, refreshToken string) error {
+	current, err := s.lookup(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+	return s.tokenRepo.RevokeFamily(ctx, current.FamilyID)
+}
+
+// RevokeAll 吊销用户的所有刷新令牌
+func (s *RefreshTokenService) RevokeAll(ctx context.Context, userID string) error {
+	return s.tokenRepo.RevokeAllForUser(ctx, userID)
+}
+
+// lookup 校验刷新令牌并获取仓库中的记录
+func (s *RefreshTokenService) lookup(ctx context.Context, refreshToken string) (*domain.RefreshToken, error) {
+	claims, err := s.jwtManager.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	token, err := s.tokenRepo.GetByID(ctx, claims.ID)
+	if err != nil || token.UserID != claims.UserID {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	return token, nil
+}
+
+// revokeReused 已轮换的令牌被再次使用，吊销整个令牌族迫使重新登录
+func (s *RefreshTokenService) revokeReused(ctx context.Context, token *domain.RefreshToken) error {
+	s.logger.Warn("Refresh token reuse detected, revoking token family",
+		zap.String("userID", token.UserID),
+		zap.String("familyID", token.FamilyID),
+	)
+	if err := s.tokenRepo.RevokeFamily(ctx, token.FamilyID); err != nil {
+		s.logger.Error("Failed to revoke refresh token family", zap.String("familyID", token.FamilyID), zap.Error(err))
+	}
+	return domain.ErrRefreshTokenReused
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+)
+
+const (
+	// maxSettingsPerUser 每个用户最多保存的设置项数
+	maxSettingsPerUser = 100
+	// maxSettingClockSkew 客户端修改时间最多允许超前服务端的时长，超出时按服务端时间处理，避免时钟错误的设备永远胜出
+	maxSettingClockSkew = 5 * time.Minute
+	// maxSettingSaveAttempts 并发修改同一设置时重新读取合并的次数
+	maxSettingSaveAttempts = 3
+)
+
+// SettingsService 实现domain.SettingsService接口
+type SettingsService struct {
+	settingsRepo domain.SettingsRepository
+	logger       *zap.Logger
+}
+
+// NewSettingsService 创建一个新的用户设置同步服务
+func NewSettingsService(settingsRepo domain.SettingsRepository, logger *zap.Logger) domain.SettingsService {
+	return &SettingsService{
+		settingsRepo: settingsRepo,
+		logger:       logger,
+	}
+}
+
+// GetSettings 获取用户的全部设置及其向量时间戳
+func (s *SettingsService) GetSettings(ctx context.Context, userID string) (*domain.UserSettings, error) {
+	settings, err := s.settingsRepo.List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list settings: %w", err)
+	}
+	return &domain.UserSettings{Settings: settings}, nil
+}
+
+// SyncSettings 逐项合并客户端的修改：客户端时间戳较新时采用，较旧时忽略，并发修改时修改时间较晚者胜出
+// 每项结果都带有合并后服务端的状态，客户端以此覆盖本地值和时间戳
+func (s *SettingsService) SyncSettings(ctx context.Context, userID string, req *domain.SyncSettingsRequest) ([]*domain.SettingResult, error) {
+	seen := make(map[string]bool, len(req.Settings))
+	for _, change := range req.Settings {
+		if seen[change.Key] {
+			return nil, fmt.Errorf("invalid settings: duplicate key %s", change.Key)
+		}
+		seen[change.Key] = true
+		// 客户端修改前需要递增本设备的计数，否则无法与之前的修改区分先后
+		if change.Clock[req.DeviceID] == 0 {
+			return nil, fmt.Errorf("invalid clock for %s: must include device_id", change.Key)
+		}
+	}
+
+	now := time.Now()
+	results := make([]*domain.SettingResult, 0, len(req.Settings))
+	for _, change := range req.Settings {
+		modifiedAt := change.ModifiedAt
+		if modifiedAt.IsZero() || modifiedAt.After(now.Add(maxSettingClockSkew)) {
+			modifiedAt = now
+		}
+		incoming := &domain.UserSetting{
+			Key:        change.Key,
+			Value:      change.Value,
+			Clock:      change.Clock,
+			DeviceID:   req.DeviceID,
+			ModifiedAt: modifiedAt,
+		}
+
+		result, err := s.syncSetting(ctx, userID, incoming)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	s.logger.Debug("Settings synced", zap.String("user_id", userID), zap.String("device_id", req.DeviceID), zap.Int("count", len(results)))
+	return results, nil
+}
+
+// syncSetting 合并一项设置，保存时发现已被并发修改则重新读取后再合并
+func (s *SettingsService) syncSetting(ctx context.Context, userID string, incoming *domain.UserSetting) (*domain.SettingResult, error) {
+	for attempt := 0; attempt < maxSettingSaveAttempts; attempt++ {
+		current, err := s.settingsRepo.Get(ctx, userID, incoming.Key)
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			return nil, fmt.Errorf("failed to get setting: %w", err)
+		}
+
+		next, status := resolveSetting(current, incoming)
+		if status == domain.SettingStale {
+			return &domain.SettingResult{Key: incoming.Key, Status: status, Setting: current}, nil
+		}
+		if current == nil {
+			count, err := s.settingsRepo.Count(ctx, userID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to count settings: %w", err)
+			}
+			if count >= maxSettingsPerUser {
+				return nil, fmt.Errorf("too many settings: at most %d keys per user", maxSettingsPerUser)
+			}
+		}
+
+		err = s.settingsRepo.Save(ctx, userID, next)
+		if errors.Is(err, domain.ErrSettingModified) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to save setting: %w", err)
+		}
+		return &domain.SettingResult{Key: incoming.Key, Status: status, Setting: next}, nil
+	}
+
+	return nil, fmt.Errorf("failed to sync setting %s: %w", incoming.Key, domain.ErrSettingModified)
+}
+
+// resolveSetting 按向量时间戳决定合并后的设置；并发修改时值取修改时间较晚者（相同时比较设备ID），时间戳取两者的合并
+func resolveSetting(current, incoming *domain.UserSetting) (*domain.UserSetting, string) {
+	if current == nil {
+		next := *incoming
+		return &next, domain.SettingApplied
+	}
+
+	switch incoming.Clock.Compare(current.Clock) {
+	case domain.ClockAfter:
+		next := *incoming
+		next.Revision = current.Revision
+		return &next, domain.SettingApplied
+	case domain.ClockConcurrent:
+		incomingWins := incoming.ModifiedAt.After(current.ModifiedAt) ||
+			(incoming.ModifiedAt.Equal(current.ModifiedAt) && incoming.DeviceID > current.DeviceID)
+
+		var next domain.UserSetting
+		status := domain.SettingConflictLost
+		if incomingWins {
+			next = *incoming
+			status = domain.SettingConflictWon
+		} else {
+			next = *current
+		}
+		next.Clock = current.Clock.Merge(incoming.Clock)
+		next.Revision = current.Revision
+		return &next, status
+	default:
+		return current, domain.SettingStale
+	}
+}
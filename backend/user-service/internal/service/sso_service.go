@@ -0,0 +1,745 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/auth"
+)
+
+// tenantPattern 租户标识格式，会出现在登录URL中
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,63}$`)
+
+// SSOConfig 单点登录服务端（SP）配置
+type SSOConfig struct {
+	// BaseURL 对外访问地址（通常为网关地址），用于拼接回调URL和SAML实体ID
+	BaseURL string
+	// SAML签名/解密使用的SP证书和私钥，可选
+	Certificate *x509.Certificate
+	Key         *rsa.PrivateKey
+	// StateTTL 登录请求有效期
+	StateTTL time.Duration
+}
+
+// ssoLoginState 一次SP发起登录的上下文
+type ssoLoginState struct {
+	tenant       string
+	nonce        string
+	codeVerifier string
+	requestID    string
+	expiresAt    time.Time
+}
+
+// SSOService 实现domain.SSOService接口
+type SSOService struct {
+	ssoRepo             domain.SSORepository
+	userRepo            domain.UserRepository
+	refreshTokenService domain.RefreshTokenService
+	config              SSOConfig
+	httpClient          *http.Client
+	logger              *zap.Logger
+
+	mu        sync.Mutex
+	states    map[string]*ssoLoginState
+	oidcCache map[string]*oidc.Provider
+	samlCache map[string]*saml.EntityDescriptor
+}
+
+// NewSSOService 创建一个新的单点登录服务
+func NewSSOService(ssoRepo domain.SSORepository, userRepo domain.UserRepository, refreshTokenService domain.RefreshTokenService, config SSOConfig, logger *zap.Logger) domain.SSOService {
+	if config.StateTTL <= 0 {
+		config.StateTTL = 10 * time.Minute
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	return &SSOService{
+		ssoRepo:             ssoRepo,
+		userRepo:            userRepo,
+		refreshTokenService: refreshTokenService,
+		config:              config,
+		httpClient:          &http.Client{Timeout: 10 * time.Second},
+		logger:              logger,
+		states:              make(map[string]*ssoLoginState),
+		oidcCache:           make(map[string]*oidc.Provider),
+		samlCache:           make(map[string]*saml.EntityDescriptor),
+	}
+}
+
+// SaveProvider 创建或更新租户的IdP配置
+func (s *SSOService) SaveProvider(ctx context.Context, req *domain.SSOProviderRequest) (*domain.SSOProvider, error) {
+	tenant := strings.ToLower(strings.TrimSpace(req.Tenant))
+	if !tenantPattern.MatchString(tenant) {
+		return nil, errors.New("invalid tenant")
+	}
+
+	existing, err := s.ssoRepo.GetProviderByTenant(ctx, tenant)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return nil, err
+	}
+
+	provider := &domain.SSOProvider{
+		Tenant:           tenant,
+		Name:             strings.TrimSpace(req.Name),
+		Protocol:         req.Protocol,
+		Enabled:          req.Enabled,
+		Issuer:           strings.TrimSpace(req.Issuer),
+		ClientID:         strings.TrimSpace(req.ClientID),
+		ClientSecret:     req.ClientSecret,
+		Scopes:           req.Scopes,
+		IdPMetadataURL:   strings.TrimSpace(req.IdPMetadataURL),
+		IdPMetadataXML:   strings.TrimSpace(req.IdPMetadataXML),
+		AttributeMapping: req.AttributeMapping,
+		GroupRoles:       make(map[string]string, len(req.GroupRoles)),
+		AllowedDomains:   make([]string, 0, len(req.AllowedDomains)),
+	}
+	if existing != nil && provider.ClientSecret == "" {
+		provider.ClientSecret = existing.ClientSecret
+	}
+	for group, role := range req.GroupRoles {
+		if role != domain.RoleUser && role != domain.RoleAdmin {
+			return nil, fmt.Errorf("invalid role: %s", role)
+		}
+		provider.GroupRoles[strings.ToLower(group)] = role
+	}
+	for _, d := range req.AllowedDomains {
+		if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+			provider.AllowedDomains = append(provider.AllowedDomains, d)
+		}
+	}
+
+	switch provider.Protocol {
+	case domain.SSOProtocolOIDC:
+		if provider.Issuer == "" || provider.ClientID == "" || provider.ClientSecret == "" {
+			return nil, errors.New("invalid oidc provider: issuer, client_id and client_secret are required")
+		}
+	case domain.SSOProtocolSAML:
+		if provider.IdPMetadataURL == "" && provider.IdPMetadataXML == "" {
+			return nil, errors.New("invalid saml provider: idp_metadata_url or idp_metadata_xml is required")
+		}
+		if provider.IdPMetadataXML != "" {
+			if _, err := samlsp.ParseMetadata([]byte(provider.IdPMetadataXML)); err != nil {
+				return nil, errors.New("invalid saml provider: cannot parse idp metadata")
+			}
+		}
+	default:
+		return nil, errors.New("invalid protocol")
+	}
+
+	if existing == nil {
+		err = s.ssoRepo.CreateProvider(ctx, provider)
+	} else {
+		provider.ID = existing.ID
+		provider.CreatedAt = existing.CreatedAt
+		err = s.ssoRepo.UpdateProvider(ctx, provider)
+	}
+	if err != nil {
+		s.logger.Error("Failed to save sso provider", zap.String("tenant", tenant), zap.Error(err))
+		return nil, errors.New("failed to save sso provider")
+	}
+
+	s.invalidate(tenant)
+	s.logger.Info("SSO provider saved", zap.String("tenant", tenant), zap.String("protocol", string(provider.Protocol)))
+	return provider, nil
+}
+
+// ListProviders 获取全部IdP配置
+func (s *SSOService) ListProviders(ctx context.Context) ([]*domain.SSOProvider, error) {
+	providers, err := s.ssoRepo.ListProviders(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list sso providers", zap.Error(err))
+		return nil, errors.New("failed to list sso providers")
+	}
+	return providers, nil
+}
+
+// DeleteProvider 删除租户的IdP配置
+func (s *SSOService) DeleteProvider(ctx context.Context, tenant string) error {
+	if err := s.ssoRepo.DeleteProvider(ctx, tenant); err != nil {
+		return err
+	}
+	s.invalidate(tenant)
+	return nil
+}
+
+// BeginLogin 生成登录状态并返回IdP登录地址
+func (s *SSOService) BeginLogin(ctx context.Context, tenant string) (string, error) {
+	provider, err := s.getEnabledProvider(ctx, tenant)
+	if err != nil {
+		return "", err
+	}
+
+	stateID, err := randomToken()
+	if err != nil {
+		return "", errors.New("failed to start sso login")
+	}
+	state := &ssoLoginState{tenant: provider.Tenant}
+
+	var redirectURL string
+	switch provider.Protocol {
+	case domain.SSOProtocolOIDC:
+		oauthConfig, _, err := s.oidcClient(ctx, provider)
+		if err != nil {
+			return "", err
+		}
+		if state.nonce, err = randomToken(); err != nil {
+			return "", errors.New("failed to start sso login")
+		}
+		if state.codeVerifier, err = randomToken(); err != nil {
+			return "", errors.New("failed to start sso login")
+		}
+		challenge := sha256.Sum256([]byte(state.codeVerifier))
+		redirectURL = oauthConfig.AuthCodeURL(stateID,
+			oidc.Nonce(state.nonce),
+			oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+			oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+		)
+
+	case domain.SSOProtocolSAML:
+		sp, err := s.samlServiceProvider(ctx, provider)
+		if err != nil {
+			return "", err
+		}
+		authnRequest, err := sp.MakeAuthenticationRequest(sp.GetSSOBindingLocation(saml.HTTPRedirectBinding), saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+		if err != nil {
+			s.logger.Error("Failed to create saml authn request", zap.String("tenant", tenant), zap.Error(err))
+			return "", errors.New("failed to start sso login")
+		}
+		location, err := authnRequest.Redirect(stateID, sp)
+		if err != nil {
+			s.logger.Error("Failed to encode saml authn request", zap.String("tenant", tenant), zap.Error(err))
+			return "", errors.New("failed to start sso login")
+		}
+		state.requestID = authnRequest.ID
+		redirectURL = location.String()
+	}
+
+	s.saveState(stateID, state)
+	return redirectURL, nil
+}
+
+// CompleteOIDCLogin 处理OIDC授权码回调：换取并校验ID Token后签发会话
+func (s *SSOService) CompleteOIDCLogin(ctx context.Context, tenant, stateID, code string) (*domain.LoginResponse, error) {
+	state := s.takeState(stateID, tenant)
+	if state == nil {
+		return nil, errors.New("invalid or expired sso state")
+	}
+
+	provider, err := s.getEnabledProvider(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	if provider.Protocol != domain.SSOProtocolOIDC {
+		return nil, errors.New("invalid protocol")
+	}
+
+	oauthConfig, oidcProvider, err := s.oidcClient(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", state.codeVerifier))
+	if err != nil {
+		s.logger.Info("OIDC code exchange failed", zap.String("tenant", tenant), zap.Error(err))
+		return nil, errors.New("invalid sso assertion")
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("invalid sso assertion: missing id_token")
+	}
+
+	idToken, err := oidcProvider.Verifier(&oidc.Config{ClientID: provider.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		s.logger.Info("OIDC id token verification failed", zap.String("tenant", tenant), zap.Error(err))
+		return nil, errors.New("invalid sso assertion")
+	}
+	if idToken.Nonce != state.nonce {
+		return nil, errors.New("invalid sso assertion: nonce mismatch")
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, errors.New("invalid sso assertion")
+	}
+	if verified, ok := claims["email_verified"].(bool); ok && !verified {
+		return nil, errors.New("invalid sso assertion: email is not verified")
+	}
+
+	mapping := withDefaultMapping(provider.AttributeMapping, domain.SSOAttributeMapping{
+		Username: "preferred_username",
+		Email:    "email",
+		FullName: "name",
+		Groups:   "groups",
+	})
+	identity := &domain.SSOIdentity{
+		Issuer:   idToken.Issuer,
+		Subject:  idToken.Subject,
+		Username: claimString(claims[mapping.Username]),
+		Email:    claimString(claims[mapping.Email]),
+		FullName: claimString(claims[mapping.FullName]),
+		Groups:   claimStrings(claims[mapping.Groups]),
+	}
+
+	return s.issueSession(ctx, provider, identity)
+}
+
+// CompleteSAMLLogin 处理SAML断言消费（ACS）：校验签名、受众和请求ID后签发会话
+func (s *SSOService) CompleteSAMLLogin(ctx context.Context, tenant, samlResponse, relayState string) (*domain.LoginResponse, error) {
+	state := s.takeState(relayState, tenant)
+	if state == nil {
+		return nil, errors.New("invalid or expired sso state")
+	}
+
+	provider, err := s.getEnabledProvider(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	if provider.Protocol != domain.SSOProtocolSAML {
+		return nil, errors.New("invalid protocol")
+	}
+
+	sp, err := s.samlServiceProvider(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	rawResponse, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		return nil, errors.New("invalid sso assertion")
+	}
+	assertion, err := sp.ParseXMLResponse(rawResponse, []string{state.requestID})
+	if err != nil {
+		var invalid *saml.InvalidResponseError
+		if errors.As(err, &invalid) {
+			err = invalid.PrivateErr
+		}
+		s.logger.Info("SAML assertion validation failed", zap.String("tenant", tenant), zap.Error(err))
+		return nil, errors.New("invalid sso assertion")
+	}
+
+	mapping := withDefaultMapping(provider.AttributeMapping, domain.SSOAttributeMapping{
+		Username: "uid",
+		Email:    "email",
+		FullName: "displayName",
+		Groups:   "groups",
+	})
+	identity := &domain.SSOIdentity{
+		Issuer:   assertion.Issuer.Value,
+		Username: samlAttribute(assertion, mapping.Username),
+		Email:    samlAttribute(assertion, mapping.Email),
+		FullName: samlAttribute(assertion, mapping.FullName),
+		Groups:   samlAttributes(assertion, mapping.Groups),
+	}
+	if assertion.Subject != nil && assertion.Subject.NameID != nil {
+		identity.Subject = assertion.Subject.NameID.Value
+		if identity.Email == "" && strings.Contains(identity.Subject, "@") {
+			identity.Email = identity.Subject
+		}
+	}
+
+	return s.issueSession(ctx, provider, identity)
+}
+
+// SAMLMetadata 生成租户的SP元数据，供IdP管理员导入
+func (s *SSOService) SAMLMetadata(ctx context.Context, tenant string) ([]byte, error) {
+	provider, err := s.ssoRepo.GetProviderByTenant(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	if provider.Protocol != domain.SSOProtocolSAML {
+		return nil, errors.New("sso provider not found")
+	}
+
+	sp := s.newServiceProvider(provider.Tenant)
+	metadata, err := xml.MarshalIndent(sp.Metadata(), "", "  ")
+	if err != nil {
+		return nil, errors.New("failed to generate metadata")
+	}
+	return metadata, nil
+}
+
+// issueSession 按映射结果创建或同步本地用户，并通过常规JWT流程签发令牌
+func (s *SSOService) issueSession(ctx context.Context, provider *domain.SSOProvider, identity *domain.SSOIdentity) (*domain.LoginResponse, error) {
+	identity.Email = strings.ToLower(strings.TrimSpace(identity.Email))
+	if identity.Username == "" && identity.Email != "" {
+		identity.Username = strings.SplitN(identity.Email, "@", 2)[0]
+	}
+	if identity.Subject == "" || identity.Username == "" || identity.Email == "" {
+		s.logger.Error("SSO assertion missing username or email", zap.String("tenant", provider.Tenant), zap.String("subject", identity.Subject))
+		return nil, errors.New("invalid sso assertion: missing required attributes")
+	}
+	if !emailDomainAllowed(identity.Email, provider.AllowedDomains) {
+		s.logger.Info("SSO email domain not allowed", zap.String("tenant", provider.Tenant), zap.String("email", identity.Email))
+		return nil, errors.New("email domain is not allowed for this tenant")
+	}
+
+	user, err := s.provisionSSOUser(ctx, provider, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Status != domain.UserStatusActive {
+		s.logger.Info("User account is not active", zap.String("userID", user.ID), zap.String("status", string(user.Status)))
+		if user.Status == domain.UserStatusDeactivated {
+			return nil, errors.New("account is deactivated")
+		}
+		return nil, errors.New("account is not active")
+	}
+
+	// 记录最后在线时间，失败不影响登录
+	if err := s.userRepo.UpdateLastSeen(ctx, user.ID, time.Now()); err != nil {
+		s.logger.Warn("Failed to update last seen", zap.String("userID", user.ID), zap.Error(err))
+	}
+
+	response, err := s.refreshTokenService.IssueSession(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("SSO login succeeded", zap.String("tenant", provider.Tenant), zap.String("userID", user.ID))
+	return response, nil
+}
+
+// provisionSSOUser 按租户IdP主体的关联查找本地用户，之后每次登录同步资料和角色
+// 不按用户名或邮箱匹配已有账户，其他租户的IdP或同名身份不能接管账户
+func (s *SSOService) provisionSSOUser(ctx context.Context, provider *domain.SSOProvider, identity *domain.SSOIdentity) (*domain.User, error) {
+	role := domain.RoleUser
+	for _, group := range identity.Groups {
+		if provider.GroupRoles[strings.ToLower(group)] == domain.RoleAdmin {
+			role = domain.RoleAdmin
+			break
+		}
+	}
+	fullName := identity.FullName
+	if fullName == "" {
+		fullName = identity.Username
+	}
+
+	link, err := s.ssoRepo.GetIdentityLink(ctx, provider.Tenant, identity.Issuer, identity.Subject)
+	if err != nil {
+		return s.createSSOUser(ctx, provider, identity, role, fullName)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, link.UserID)
+	if err != nil {
+		s.logger.Error("Failed to get linked sso user", zap.String("tenant", provider.Tenant), zap.String("userID", link.UserID), zap.Error(err))
+		return nil, errors.New("failed to sync user")
+	}
+
+	if user.Email != identity.Email || user.FullName != fullName || user.Role != role {
+		user.Email = identity.Email
+		user.FullName = fullName
+		user.Role = role
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			s.logger.Error("Failed to sync sso user", zap.String("userID", user.ID), zap.Error(err))
+			return nil, errors.New("failed to sync user")
+		}
+	}
+	return user, nil
+}
+
+// createSSOUser IdP主体首次登录时创建本地用户并建立关联
+// 用户名或邮箱已被其他账户使用时拒绝，不自动关联已有的本地账户
+func (s *SSOService) createSSOUser(ctx context.Context, provider *domain.SSOProvider, identity *domain.SSOIdentity, role, fullName string) (*domain.User, error) {
+	usernameTaken, err := s.userRepo.ExistsByUsername(ctx, identity.Username)
+	if err != nil {
+		return nil, errors.New("failed to provision user")
+	}
+	emailTaken, err := s.userRepo.ExistsByEmail(ctx, identity.Email)
+	if err != nil {
+		return nil, errors.New("failed to provision user")
+	}
+	if usernameTaken || emailTaken {
+		s.logger.Warn("SSO login conflicts with existing account", zap.String("tenant", provider.Tenant), zap.String("subject", identity.Subject))
+		return nil, errors.New("account already exists with a different sign-in method")
+	}
+
+	// SSO账户不使用本地密码，写入随机哈希使本地登录不可用
+	password, err := randomPassword()
+	if err != nil {
+		return nil, errors.New("failed to provision user")
+	}
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, errors.New("failed to provision user")
+	}
+
+	user := &domain.User{
+		Username:        identity.Username,
+		Email:           identity.Email,
+		Password:        hashedPassword,
+		PasswordVersion: auth.PasswordVersion(),
+		FullName:        fullName,
+		Status:          domain.UserStatusActive,
+		Role:            role,
+		AuthProvider:    domain.AuthProviderSSO,
+		Language:        domain.DefaultLanguage,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		s.logger.Error("Failed to provision sso user", zap.String("tenant", provider.Tenant), zap.String("username", identity.Username), zap.Error(err))
+		return nil, errors.New("failed to provision user")
+	}
+
+	link := &domain.SSOIdentityLink{
+		Tenant:  provider.Tenant,
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		UserID:  user.ID,
+	}
+	if err := s.ssoRepo.CreateIdentityLink(ctx, link); err != nil {
+		// 关联失败（如同一主体并发首次登录）时删除刚创建的用户，避免留下无法登录的账户
+		s.logger.Error("Failed to link sso identity", zap.String("tenant", provider.Tenant), zap.String("userID", user.ID), zap.Error(err))
+		if err := s.userRepo.Delete(ctx, user.ID); err != nil {
+			s.logger.Warn("Failed to remove unlinked sso user", zap.String("userID", user.ID), zap.Error(err))
+		}
+		return nil, errors.New("failed to provision user")
+	}
+
+	s.logger.Info("SSO user provisioned", zap.String("tenant", provider.Tenant), zap.String("userID", user.ID), zap.String("role", role))
+	return user, nil
+}
+
+// getEnabledProvider 获取已启用的租户IdP配置
+func (s *SSOService) getEnabledProvider(ctx context.Context, tenant string) (*domain.SSOProvider, error) {
+	provider, err := s.ssoRepo.GetProviderByTenant(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	if !provider.Enabled {
+		return nil, errors.New("sso is disabled for this tenant")
+	}
+	return provider, nil
+}
+
+// oidcClient 获取租户的OAuth2配置和OIDC发现结果，发现结果按租户缓存
+func (s *SSOService) oidcClient(ctx context.Context, provider *domain.SSOProvider) (*oauth2.Config, *oidc.Provider, error) {
+	s.mu.Lock()
+	oidcProvider := s.oidcCache[provider.Tenant]
+	s.mu.Unlock()
+
+	if oidcProvider == nil {
+		discovered, err := oidc.NewProvider(oidc.ClientContext(ctx, s.httpClient), provider.Issuer)
+		if err != nil {
+			s.logger.Error("OIDC discovery failed", zap.String("tenant", provider.Tenant), zap.String("issuer", provider.Issuer), zap.Error(err))
+			return nil, nil, errors.New("identity provider unavailable")
+		}
+		oidcProvider = discovered
+		s.mu.Lock()
+		s.oidcCache[provider.Tenant] = oidcProvider
+		s.mu.Unlock()
+	}
+
+	scopes := []string{oidc.ScopeOpenID, "profile", "email"}
+	for _, scope := range provider.Scopes {
+		if scope != oidc.ScopeOpenID && scope != "profile" && scope != "email" {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &oauth2.Config{
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		Endpoint:     oidcProvider.Endpoint(),
+		RedirectURL:  s.tenantURL(provider.Tenant, "oidc/callback"),
+		Scopes:       scopes,
+	}, oidcProvider, nil
+}
+
+// samlServiceProvider 构造租户的SAML SP，IdP元数据按租户缓存
+func (s *SSOService) samlServiceProvider(ctx context.Context, provider *domain.SSOProvider) (*saml.ServiceProvider, error) {
+	s.mu.Lock()
+	metadata := s.samlCache[provider.Tenant]
+	s.mu.Unlock()
+
+	if metadata == nil {
+		var err error
+		if provider.IdPMetadataXML != "" {
+			metadata, err = samlsp.ParseMetadata([]byte(provider.IdPMetadataXML))
+		} else {
+			var metadataURL *url.URL
+			if metadataURL, err = url.Parse(provider.IdPMetadataURL); err == nil {
+				metadata, err = samlsp.FetchMetadata(ctx, s.httpClient, *metadataURL)
+			}
+		}
+		if err != nil {
+			s.logger.Error("Failed to load saml idp metadata", zap.String("tenant", provider.Tenant), zap.Error(err))
+			return nil, errors.New("identity provider unavailable")
+		}
+		s.mu.Lock()
+		s.samlCache[provider.Tenant] = metadata
+		s.mu.Unlock()
+	}
+
+	sp := s.newServiceProvider(provider.Tenant)
+	sp.IDPMetadata = metadata
+	return sp, nil
+}
+
+// newServiceProvider 按租户构造SP基础信息，实体ID即元数据地址
+func (s *SSOService) newServiceProvider(tenant string) *saml.ServiceProvider {
+	metadataURL, _ := url.Parse(s.tenantURL(tenant, "saml/metadata"))
+	acsURL, _ := url.Parse(s.tenantURL(tenant, "saml/acs"))
+
+	return &saml.ServiceProvider{
+		EntityID:    metadataURL.String(),
+		Key:         s.config.Key,
+		Certificate: s.config.Certificate,
+		MetadataURL: *metadataURL,
+		AcsURL:      *acsURL,
+		HTTPClient:  s.httpClient,
+	}
+}
+
+// tenantURL 拼接租户SSO回调地址
+func (s *SSOService) tenantURL(tenant, path string) string {
+	return fmt.Sprintf("%s/api/v1/users/sso/%s/%s", s.config.BaseURL, url.PathEscape(tenant), path)
+}
+
+// saveState 保存登录状态，同时清理过期状态
+func (s *SSOService) saveState(id string, state *ssoLoginState) {
+	now := time.Now()
+	state.expiresAt = now.Add(s.config.StateTTL)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, st := range s.states {
+		if now.After(st.expiresAt) {
+			delete(s.states, key)
+		}
+	}
+	s.states[id] = state
+}
+
+// takeState 取出并删除登录状态，状态只能使用一次
+func (s *SSOService) takeState(id, tenant string) *ssoLoginState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[id]
+	if !ok {
+		return nil
+	}
+	delete(s.states, id)
+	if state.tenant != tenant || time.Now().After(state.expiresAt) {
+		return nil
+	}
+	return state
+}
+
+// invalidate 配置变更后清除租户的IdP缓存
+func (s *SSOService) invalidate(tenant string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.oidcCache, tenant)
+	delete(s.samlCache, tenant)
+}
+
+// withDefaultMapping 未配置的映射项使用协议默认值
+func withDefaultMapping(mapping, defaults domain.SSOAttributeMapping) domain.SSOAttributeMapping {
+	if mapping.Username == "" {
+		mapping.Username = defaults.Username
+	}
+	if mapping.Email == "" {
+		mapping.Email = defaults.Email
+	}
+	if mapping.FullName == "" {
+		mapping.FullName = defaults.FullName
+	}
+	if mapping.Groups == "" {
+		mapping.Groups = defaults.Groups
+	}
+	return mapping
+}
+
+// claimString 读取字符串声明
+func claimString(value interface{}) string {
+	str, _ := value.(string)
+	return strings.TrimSpace(str)
+}
+
+// claimStrings 读取字符串数组声明，兼容单个字符串
+func claimStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				items = append(items, str)
+			}
+		}
+		return items
+	}
+	return nil
+}
+
+// samlAttribute 读取SAML属性的第一个值，按Name或FriendlyName匹配
+func samlAttribute(assertion *saml.Assertion, name string) string {
+	if values := samlAttributes(assertion, name); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// samlAttributes 读取SAML属性的全部值
+func samlAttributes(assertion *saml.Assertion, name string) []string {
+	var values []string
+	for _, statement := range assertion.AttributeStatements {
+		for _, attr := range statement.Attributes {
+			if attr.Name != name && attr.FriendlyName != name {
+				continue
+			}
+			for _, value := range attr.Values {
+				values = append(values, value.Value)
+			}
+		}
+	}
+	return values
+}
+
+// emailDomainAllowed 检查邮箱域名是否在允许列表中
+func emailDomainAllowed(email string, domains []string) bool {
+	if len(domains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	emailDomain := email[at+1:]
+	for _, d := range domains {
+		if emailDomain == d {
+			return true
+		}
+	}
+	return false
+}
+
+// randomToken 生成URL安全的随机串
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
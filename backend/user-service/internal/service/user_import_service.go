@@ -0,0 +1,469 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/neohope/chatapp/user-service/internal/domain"
+	"github.com/neohope/chatapp/user-service/pkg/auth"
+	mailer "github.com/neohope/chatapp/user-service/pkg/mail"
+)
+
+const (
+	// importProgressInterval 每处理多少行保存一次进度
+	importProgressInterval = 50
+	// importMailTimeout 单封邀请邮件的发送超时
+	importMailTimeout = 30 * time.Second
+)
+
+// importRequiredColumns CSV必需的列，其余支持的列为role、language、phone
+var importRequiredColumns = []string{"username", "email", "full_name"}
+
+// UserImportConfig 批量导入配置
+type UserImportConfig struct {
+	// InvitationURL 前端接受邀请页面地址，令牌以token查询参数附加
+	InvitationURL string
+	InvitationTTL time.Duration
+	MaxRows       int
+}
+
+// importRow 已解析的CSV行
+type importRow struct {
+	line     int
+	username string
+	email    string
+	fullName string
+	role     string
+	language string
+	phone    string
+}
+
+// UserImportService 实现domain.UserImportService接口
+type UserImportService struct {
+	userRepo            domain.UserRepository
+	importRepo          domain.UserImportRepository
+	mailer              mailer.Mailer
+	refreshTokenService domain.RefreshTokenService
+	config              UserImportConfig
+	// sem 同一时间只处理一个导入任务，避免批量写入挤占数据库
+	sem chan struct{}
+	// running 后台处理中（含等待执行）的导入任务
+	running sync.WaitGroup
+	logger  *zap.Logger
+}
+
+// NewUserImportService 创建一个新的批量导入服务
+func NewUserImportService(userRepo domain.UserRepository, importRepo domain.UserImportRepository, mailer mailer.Mailer, refreshTokenService domain.RefreshTokenService, config UserImportConfig, logger *zap.Logger) domain.UserImportService {
+	if config.InvitationTTL <= 0 {
+		config.InvitationTTL = 72 * time.Hour
+	}
+	if config.MaxRows <= 0 {
+		config.MaxRows = 5000
+	}
+
+	return &UserImportService{
+		userRepo:            userRepo,
+		importRepo:          importRepo,
+		mailer:              mailer,
+		refreshTokenService: refreshTokenService,
+		config:              config,
+		sem:                 make(chan struct{}, 1),
+		logger:              logger,
+	}
+}
+
+// StartImport 解析CSV并创建任务，逐行校验和创建账户在后台进行
+func (s *UserImportService) StartImport(ctx context.Context, adminID, fileName string, data []byte) (*domain.UserImportJob, error) {
+	rows, err := s.parseCSV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &domain.UserImportJob{
+		CreatedBy: adminID,
+		FileName:  fileName,
+		Status:    domain.ImportJobStatusPending,
+		TotalRows: len(rows),
+	}
+	if err := s.importRepo.CreateJob(ctx, job); err != nil {
+		s.logger.Error("Failed to create import job", zap.Error(err))
+		return nil, errors.New("failed to create import job")
+	}
+
+	s.logger.Info("User import job created",
+		zap.String("job_id", job.ID),
+		zap.String("created_by", adminID),
+		zap.Int("rows", len(rows)),
+	)
+
+	// 后台任务使用副本，避免与返回给调用方的对象并发读写
+	running := *job
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.runImport(&running, rows)
+	}()
+
+	return job, nil
+}
+
+// Wait 等待后台处理中的导入任务结束
+func (s *UserImportService) Wait() {
+	s.running.Wait()
+}
+
+// GetJob 获取导入任务状态和逐行错误报告
+func (s *UserImportService) GetJob(ctx context.Context, id string) (*domain.UserImportJob, error) {
+	return s.importRepo.GetJob(ctx, id)
+}
+
+// ListJobs 获取导入任务列表
+func (s *UserImportService) ListJobs(ctx context.Context, limit, offset int) ([]*domain.UserImportJob, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.importRepo.ListJobs(ctx, limit, offset)
+}
+
+// AcceptInvitation 被邀请用户设置密码并激活账户
+func (s *UserImportService) AcceptInvitation(ctx context.Context, token, password string) (*domain.LoginResponse, error) {
+	if len(password) < 8 {
+		return nil, errors.New("password must be at least 8 characters long")
+	}
+
+	tokenHash := hashInvitationToken(token)
+	invitation, err := s.importRepo.GetInvitation(ctx, tokenHash)
+	if err != nil {
+		return nil, errors.New("invalid invitation")
+	}
+	if invitation.AcceptedAt != nil {
+		return nil, errors.New("invitation already accepted")
+	}
+	if time.Now().After(invitation.ExpiresAt) {
+		return nil, errors.New("invitation expired")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, invitation.UserID)
+	if err != nil {
+		return nil, errors.New("invalid invitation")
+	}
+	// 管理员可能已封禁该账户，只激活仍处于待激活状态的账户
+	if user.Status != domain.UserStatusInactive {
+		return nil, errors.New("account is not awaiting activation")
+	}
+
+	// 先占用邀请，保证令牌只能使用一次
+	if err := s.importRepo.AcceptInvitation(ctx, tokenHash, time.Now()); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		s.logger.Error("Failed to hash password", zap.Error(err))
+		return nil, errors.New("failed to process password")
+	}
+	user.Password = hashedPassword
+	user.PasswordVersion = auth.PasswordVersion()
+	user.Status = domain.UserStatusActive
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("Failed to activate invited user", zap.String("id", user.ID), zap.Error(err))
+		return nil, errors.New("failed to activate account")
+	}
+
+	s.logger.Info("Invitation accepted", zap.String("id", user.ID), zap.String("job_id", invitation.JobID))
+	return s.refreshTokenService.IssueSession(ctx, user)
+}
+
+// parseCSV 校验表头并读取全部数据行，空行跳过
+func (s *UserImportService) parseCSV(data []byte) ([]*importRow, error) {
+	// 兼容Excel导出的UTF-8 BOM
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("csv file is empty")
+		}
+		return nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range importRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv is missing required column: %s", name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []*importRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := &importRow{
+			line:     line,
+			username: field(record, "username"),
+			email:    field(record, "email"),
+			fullName: field(record, "full_name"),
+			role:     strings.ToLower(field(record, "role")),
+			language: field(record, "language"),
+			phone:    field(record, "phone"),
+		}
+		if row.username == "" && row.email == "" && row.fullName == "" {
+			continue
+		}
+
+		rows = append(rows, row)
+		if len(rows) > s.config.MaxRows {
+			return nil, fmt.Errorf("csv exceeds the maximum of %d rows", s.config.MaxRows)
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("csv contains no users")
+	}
+	return rows, nil
+}
+
+// runImport 后台逐行处理导入任务
+func (s *UserImportService) runImport(job *domain.UserImportJob, rows []*importRow) {
+	s.sem <- struct{}{}
+	defer func() { <-s.sem }()
+
+	ctx := context.Background()
+	startedAt := time.Now()
+	job.Status = domain.ImportJobStatusRunning
+	job.StartedAt = &startedAt
+	s.saveJob(ctx, job)
+
+	seenUsernames := make(map[string]bool, len(rows))
+	seenEmails := make(map[string]bool, len(rows))
+
+	for i, row := range rows {
+		if rowErr := s.importRow(ctx, job, row, seenUsernames, seenEmails); rowErr != nil {
+			job.Errors = append(job.Errors, *rowErr)
+			if rowErr.UserID == "" {
+				job.FailedCount++
+			}
+		}
+		job.ProcessedRows++
+
+		if (i+1)%importProgressInterval == 0 {
+			s.saveJob(ctx, job)
+		}
+	}
+
+	finishedAt := time.Now()
+	job.Status = domain.ImportJobStatusCompleted
+	job.FinishedAt = &finishedAt
+	s.saveJob(ctx, job)
+
+	s.logger.Info("User import job finished",
+		zap.String("job_id", job.ID),
+		zap.Int("created", job.CreatedCount),
+		zap.Int("failed", job.FailedCount),
+		zap.Duration("duration", finishedAt.Sub(startedAt)),
+	)
+}
+
+// importRow 校验并创建单个用户，失败时返回该行的错误
+// 账户已创建但邀请邮件发送失败时，返回的错误带有UserID且计入创建数
+func (s *UserImportService) importRow(ctx context.Context, job *domain.UserImportJob, row *importRow, seenUsernames, seenEmails map[string]bool) *domain.ImportRowError {
+	rowError := func(msg string) *domain.ImportRowError {
+		return &domain.ImportRowError{Row: row.line, Username: row.username, Email: row.email, Error: msg}
+	}
+
+	if msg := validateImportRow(row); msg != "" {
+		return rowError(msg)
+	}
+
+	usernameKey := strings.ToLower(row.username)
+	emailKey := strings.ToLower(row.email)
+	if seenUsernames[usernameKey] {
+		return rowError("duplicate username in file")
+	}
+	if seenEmails[emailKey] {
+		return rowError("duplicate email in file")
+	}
+	seenUsernames[usernameKey] = true
+	seenEmails[emailKey] = true
+
+	if exists, err := s.userRepo.ExistsByUsername(ctx, row.username); err != nil {
+		return rowError("failed to check username")
+	} else if exists {
+		return rowError("username already exists")
+	}
+	if exists, err := s.userRepo.ExistsByEmail(ctx, row.email); err != nil {
+		return rowError("failed to check email")
+	} else if exists {
+		return rowError("email already exists")
+	}
+
+	// 账户在接受邀请并设置密码前不可登录
+	password, err := randomPassword()
+	if err != nil {
+		return rowError("failed to create user")
+	}
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return rowError("failed to create user")
+	}
+
+	user := &domain.User{
+		Username:        row.username,
+		Email:           row.email,
+		Password:        hashedPassword,
+		PasswordVersion: auth.PasswordVersion(),
+		FullName:        row.fullName,
+		Phone:           row.phone,
+		Status:          domain.UserStatusInactive,
+		Role:            row.role,
+		AuthProvider:    domain.AuthProviderLocal,
+		Language:        row.language,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		s.logger.Warn("Failed to create imported user", zap.String("job_id", job.ID), zap.Int("row", row.line), zap.Error(err))
+		return rowError("failed to create user")
+	}
+	job.CreatedCount++
+
+	if err := s.inviteUser(ctx, job.ID, user); err != nil {
+		s.logger.Warn("Failed to send invitation", zap.String("job_id", job.ID), zap.String("user_id", user.ID), zap.Error(err))
+		rowErr := rowError("user created but invitation could not be sent")
+		rowErr.UserID = user.ID
+		return rowErr
+	}
+	return nil
+}
+
+// inviteUser 生成邀请令牌并发送邀请邮件
+func (s *UserImportService) inviteUser(ctx context.Context, jobID string, user *domain.User) error {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	invitation := &domain.UserInvitation{
+		TokenHash: hashInvitationToken(token),
+		UserID:    user.ID,
+		JobID:     jobID,
+		ExpiresAt: time.Now().Add(s.config.InvitationTTL),
+	}
+	if err := s.importRepo.CreateInvitation(ctx, invitation); err != nil {
+		return err
+	}
+
+	link := s.config.InvitationURL
+	if strings.Contains(link, "?") {
+		link += "&token=" + url.QueryEscape(token)
+	} else {
+		link += "?token=" + url.QueryEscape(token)
+	}
+	subject, body := invitationMail(user, link, invitation.ExpiresAt)
+
+	mailCtx, cancel := context.WithTimeout(ctx, importMailTimeout)
+	defer cancel()
+	return s.mailer.Send(mailCtx, user.Email, subject, body)
+}
+
+// saveJob 保存任务进度，失败只记录日志
+func (s *UserImportService) saveJob(ctx context.Context, job *domain.UserImportJob) {
+	if err := s.importRepo.UpdateJob(ctx, job); err != nil {
+		s.logger.Error("Failed to save import job", zap.String("job_id", job.ID), zap.Error(err))
+	}
+}
+
+// validateImportRow 校验单行数据并补全默认值，返回错误信息
+func validateImportRow(row *importRow) string {
+	if len(row.username) < 3 || len(row.username) > 50 {
+		return "username must be 3-50 characters long"
+	}
+	if strings.ContainsAny(row.username, " \t\r\n") {
+		return "username must not contain whitespace"
+	}
+	address, err := mail.ParseAddress(row.email)
+	if err != nil || address.Address != row.email || len(row.email) > 100 {
+		return "invalid email"
+	}
+	if row.fullName == "" || len(row.fullName) > 100 {
+		return "full name is required and must be at most 100 characters"
+	}
+	if len(row.phone) > 32 {
+		return "phone must be at most 32 characters"
+	}
+
+	switch row.role {
+	case "":
+		row.role = domain.RoleUser
+	case domain.RoleUser, domain.RoleAdmin:
+	default:
+		return "unsupported role"
+	}
+
+	if row.language == "" {
+		row.language = domain.DefaultLanguage
+	} else if !domain.IsSupportedLanguage(row.language) {
+		return "unsupported language"
+	}
+	return ""
+}
+
+// invitationMail 按用户语言生成邀请邮件
+func invitationMail(user *domain.User, link string, expiresAt time.Time) (string, string) {
+	expires := expiresAt.UTC().Format("2006-01-02 15:04 UTC")
+	if user.Language == "en-US" {
+		return "You're invited to ChatApp",
+			fmt.Sprintf("Hi %s,\n\nAn account has been created for you (username: %s).\n"+
+				"Open the link below to set your password and activate it:\n\n%s\n\nThis link expires at %s.\n",
+				user.FullName, user.Username, link, expires)
+	}
+	return "欢迎加入 ChatApp",
+		fmt.Sprintf("%s，您好：\n\n管理员已为您创建账户（用户名：%s）。\n"+
+			"请打开以下链接设置密码并激活账户：\n\n%s\n\n链接将于 %s 失效。\n",
+			user.FullName, user.Username, link, expires)
+}
+
+// hashInvitationToken 邀请令牌只保存SHA-256哈希
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -13,17 +14,23 @@ import (
 
 // UserService 实现domain.UserService接口
 type UserService struct {
-	userRepo   domain.UserRepository
-	jwtManager *auth.JWTManager
-	logger     *zap.Logger
+	userRepo            domain.UserRepository
+	deactivationRepo    domain.DeactivationRepository
+	identityRepo        domain.LoginIdentityRepository
+	directory           *DirectoryLogin
+	refreshTokenService domain.RefreshTokenService
+	logger              *zap.Logger
 }
 
-// NewUserService 创建一个新的用户服务
-func NewUserService(userRepo domain.UserRepository, jwtManager *auth.JWTManager, logger *zap.Logger) domain.UserService {
+// NewUserService 创建一个新的用户服务，directory为nil时仅使用本地账户登录
+func NewUserService(userRepo domain.UserRepository, deactivationRepo domain.DeactivationRepository, identityRepo domain.LoginIdentityRepository, directory *DirectoryLogin, refreshTokenService domain.RefreshTokenService, logger *zap.Logger) domain.UserService {
 	return &UserService{
-		userRepo:   userRepo,
-		jwtManager: jwtManager,
-		logger:     logger,
+		userRepo:            userRepo,
+		deactivationRepo:    deactivationRepo,
+		identityRepo:        identityRepo,
+		directory:           directory,
+		refreshTokenService: refreshTokenService,
+		logger:              logger,
 	}
 }
 
@@ -35,6 +42,11 @@ func (s *UserService) Register(ctx context.Context, user *domain.User, password
 		return errors.New("email already exists")
 	}
 
+	// 邮箱已被其他账户关联为登录身份
+	if _, identityErr := s.identityRepo.GetByIdentifier(ctx, domain.LoginIdentityEmail, normalizeEmail(user.Email)); identityErr == nil {
+		return errors.New("email already exists")
+	}
+
 	// 验证用户名是否已存在
 	existingUser, err = s.userRepo.GetByUsername(ctx, user.Username)
 	if err == nil && existingUser != nil {
@@ -51,6 +63,10 @@ func (s *UserService) Register(ctx context.Context, user *domain.User, password
 	// 设置用户状态和密码
 	user.Status = domain.UserStatusActive
 	user.Password = hashedPassword
+	user.PasswordVersion = auth.PasswordVersion()
+	if user.Language == "" {
+		user.Language = domain.DefaultLanguage
+	}
 
 	// 创建用户
 	if createErr := s.userRepo.Create(ctx, user); createErr != nil {
@@ -58,9 +74,57 @@ func (s *UserService) Register(ctx context.Context, user *domain.User, password
 		return errors.New("failed to create user")
 	}
 
+	// 账户邮箱作为第一个登录身份，失败时首次登录再补写
+	if identityErr := ensureAccountIdentity(ctx, s.identityRepo, user); identityErr != nil {
+		s.logger.Warn("Failed to create account login identity", zap.String("userID", user.ID), zap.Error(identityErr))
+	}
+
 	return nil
 }
 
+// CheckAvailability 检查注册时用户名和邮箱是否可用，参数为空的字段不检查
+func (s *UserService) CheckAvailability(ctx context.Context, username, email string) (*domain.AvailabilityResult, error) {
+	result := &domain.AvailabilityResult{}
+
+	if username = strings.TrimSpace(username); username != "" {
+		field := &domain.FieldAvailability{Value: username}
+		if len(username) < 3 || len(username) > 50 {
+			field.Reason = domain.AvailabilityReasonInvalid
+		} else {
+			exists, err := s.userRepo.ExistsByUsername(ctx, username)
+			if err != nil {
+				s.logger.Error("Failed to check username availability", zap.Error(err))
+				return nil, errors.New("failed to check availability")
+			}
+			field.Available = !exists
+			if exists {
+				field.Reason = domain.AvailabilityReasonTaken
+			}
+		}
+		result.Username = field
+	}
+
+	if email = strings.TrimSpace(email); email != "" {
+		field := &domain.FieldAvailability{Value: email}
+		if !strings.Contains(email, "@") || len(email) > 100 {
+			field.Reason = domain.AvailabilityReasonInvalid
+		} else {
+			exists, err := s.userRepo.ExistsByEmail(ctx, email)
+			if err != nil {
+				s.logger.Error("Failed to check email availability", zap.Error(err))
+				return nil, errors.New("failed to check availability")
+			}
+			field.Available = !exists
+			if exists {
+				field.Reason = domain.AvailabilityReasonTaken
+			}
+		}
+		result.Email = field
+	}
+
+	return result, nil
+}
+
 // SearchUsers 搜索用户
 func (s *UserService) SearchUsers(ctx context.Context, query string, limit, offset int) ([]*domain.User, error) {
 	// 验证查询参数
@@ -92,53 +156,141 @@ func (s *UserService) SearchUsers(ctx context.Context, query string, limit, offs
 }
 
 // Login 用户登录
-func (s *UserService) Login(ctx context.Context, identifier, password string) (string, error) {
+func (s *UserService) Login(ctx context.Context, identifier, password string) (*domain.LoginResponse, error) {
+	// 配置了目录认证时优先通过LDAP/AD登录
+	if s.directory != nil {
+		user, handled, err := s.authenticateWithDirectory(ctx, identifier, password)
+		if err != nil {
+			return nil, err
+		}
+		if handled {
+			reactivate, err := s.checkLoginStatus(ctx, user)
+			if err != nil {
+				return nil, err
+			}
+			return s.issueLoginToken(ctx, user, reactivate)
+		}
+	}
+
 	// 判断identifier是邮箱还是用户名
 	var user *domain.User
 	var err error
 	
-	// 简单判断：包含@符号的认为是邮箱，否则认为是用户名
+	// 简单判断：包含@符号的认为是邮箱，以+开头的认为是手机号，否则认为是用户名
+	// 邮箱和手机号按已关联的登录身份查找
 	if strings.Contains(identifier, "@") {
-		// 通过邮箱查找用户
-		user, err = s.userRepo.GetByEmail(ctx, identifier)
+		user, err = userByLoginIdentity(ctx, s.identityRepo, s.userRepo, domain.LoginIdentityEmail, identifier)
 		if err != nil {
 			s.logger.Info("User not found by email", zap.String("email", identifier), zap.Error(err))
-			return "", errors.New("invalid credentials")
+			return nil, errors.New("invalid credentials")
+		}
+	} else if strings.HasPrefix(strings.TrimSpace(identifier), "+") {
+		user, err = userByLoginIdentity(ctx, s.identityRepo, s.userRepo, domain.LoginIdentityPhone, identifier)
+		if err != nil {
+			s.logger.Info("User not found by phone", zap.Error(err))
+			return nil, errors.New("invalid credentials")
 		}
 	} else {
 		// 通过用户名查找用户
 		user, err = s.userRepo.GetByUsername(ctx, identifier)
 		if err != nil {
 			s.logger.Info("User not found by username", zap.String("username", identifier), zap.Error(err))
-			return "", errors.New("invalid credentials")
+			return nil, errors.New("invalid credentials")
 		}
 	}
 
 	s.logger.Info("User found for login", zap.String("identifier", identifier), zap.String("userID", user.ID))
 
-	// 检查用户状态
-	if user.Status != domain.UserStatusActive {
-		s.logger.Info("User account is not active", zap.String("identifier", identifier), zap.String("status", string(user.Status)))
-		return "", errors.New("account is not active")
-	}
-
 	// 验证密码
 	s.logger.Info("Checking password", zap.String("identifier", identifier))
 	if checkErr := auth.CheckPassword(password, user.Password); checkErr != nil {
 		s.logger.Info("Invalid password", zap.String("identifier", identifier), zap.Error(checkErr))
-		return "", errors.New("invalid credentials")
+		return nil, errors.New("invalid credentials")
 	}
 
 	s.logger.Info("Password verified successfully", zap.String("identifier", identifier))
 
-	// 生成JWT令牌
-	token, err := s.jwtManager.GenerateToken(user)
+	// 密码验证通过后再检查账户状态，避免未认证的请求探测账户是否被停用或封禁
+	reactivate, err := s.checkLoginStatus(ctx, user)
 	if err != nil {
-		s.logger.Error("Failed to generate token", zap.Error(err))
-		return "", errors.New("failed to generate authentication token")
+		return nil, err
 	}
 
-	return token, nil
+	rehashPassword(ctx, s.userRepo, user, password, s.logger)
+
+	return s.issueLoginToken(ctx, user, reactivate)
+}
+
+// rehashPassword 登录验证通过后，把bcrypt或旧参数版本的哈希按当前参数重新生成，失败不影响登录
+func rehashPassword(ctx context.Context, userRepo domain.UserRepository, user *domain.User, password string, logger *zap.Logger) {
+	if !auth.NeedsRehash(user.Password, user.PasswordVersion) {
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		logger.Warn("Failed to rehash password", zap.String("userID", user.ID), zap.Error(err))
+		return
+	}
+	version := auth.PasswordVersion()
+	if err := userRepo.UpdatePassword(ctx, user.ID, hashedPassword, version); err != nil {
+		logger.Warn("Failed to store rehashed password", zap.String("userID", user.ID), zap.Error(err))
+		return
+	}
+
+	logger.Info("Password hash upgraded",
+		zap.String("userID", user.ID),
+		zap.Int("from_version", user.PasswordVersion),
+		zap.Int("to_version", version),
+	)
+	user.Password = hashedPassword
+	user.PasswordVersion = version
+}
+
+// checkLoginStatus 检查账户是否允许登录，选择了登录自动启用的停用账户允许继续
+func (s *UserService) checkLoginStatus(ctx context.Context, user *domain.User) (bool, error) {
+	reactivate := user.Status == domain.UserStatusDeactivated && s.reactivatesOnLogin(ctx, user.ID)
+	if user.Status != domain.UserStatusActive && !reactivate {
+		s.logger.Info("User account is not active", zap.String("userID", user.ID), zap.String("status", string(user.Status)))
+		switch user.Status {
+		case domain.UserStatusDeactivated:
+			return false, errors.New("account is deactivated")
+		case domain.UserStatusSuspended:
+			return false, errors.New("account is suspended")
+		}
+		return false, errors.New("account is not active")
+	}
+	return reactivate, nil
+}
+
+// issueLoginToken 认证通过后完成登录，签发访问令牌和刷新令牌
+func (s *UserService) issueLoginToken(ctx context.Context, user *domain.User, reactivate bool) (*domain.LoginResponse, error) {
+	if reactivate {
+		if err := reactivateUser(ctx, s.userRepo, s.deactivationRepo, user); err != nil {
+			s.logger.Error("Failed to reactivate user on login", zap.String("userID", user.ID), zap.Error(err))
+			return nil, errors.New("failed to reactivate account")
+		}
+		s.logger.Info("Account reactivated on login", zap.String("userID", user.ID))
+	}
+
+	// 记录最后在线时间，失败不影响登录
+	if err := s.userRepo.UpdateLastSeen(ctx, user.ID, time.Now()); err != nil {
+		s.logger.Warn("Failed to update last seen", zap.String("userID", user.ID), zap.Error(err))
+	}
+
+	return s.refreshTokenService.IssueSession(ctx, user)
+}
+
+// reactivatesOnLogin 检查停用账户是否设置了登录时自动启用
+func (s *UserService) reactivatesOnLogin(ctx context.Context, userID string) bool {
+	if s.deactivationRepo == nil {
+		return false
+	}
+	deactivation, err := s.deactivationRepo.Get(ctx, userID)
+	if err != nil {
+		return false
+	}
+	return deactivation.ReactivateOnLogin
 }
 
 // GetUserByID 通过ID获取用户
@@ -222,6 +374,15 @@ func (s *UserService) ChangePassword(ctx context.Context, userID, oldPassword, n
 		return errors.New("user not found")
 	}
 
+	// 目录账户的密码由LDAP/AD管理
+	if user.AuthProvider == domain.AuthProviderLDAP {
+		return errors.New("password is managed by the directory")
+	}
+	// SSO账户的密码由企业IdP管理
+	if user.AuthProvider == domain.AuthProviderSSO {
+		return errors.New("password is managed by the identity provider")
+	}
+
 	// 验证旧密码
 	if checkErr := auth.CheckPassword(oldPassword, user.Password); checkErr != nil {
 		s.logger.Info("Invalid old password", zap.String("id", userID))
@@ -242,6 +403,7 @@ func (s *UserService) ChangePassword(ctx context.Context, userID, oldPassword, n
 
 	// 更新密码
 	user.Password = hashedPassword
+	user.PasswordVersion = auth.PasswordVersion()
 	if updateErr := s.userRepo.Update(ctx, user); updateErr != nil {
 		s.logger.Error("Failed to update password", zap.String("id", userID), zap.Error(updateErr))
 		return errors.New("failed to update password")
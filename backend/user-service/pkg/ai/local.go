@@ -0,0 +1,88 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// LocalEmbedder 本地模型服务（Ollama /api/embed 接口）
+type LocalEmbedder struct {
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+type localEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type localEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// NewLocalEmbedder 创建本地向量化提供方，baseURL形如 http://localhost:11434
+func NewLocalEmbedder(baseURL, model string, client *http.Client) *LocalEmbedder {
+	return &LocalEmbedder{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		client:  client,
+	}
+}
+
+// Name 提供方名称
+func (e *LocalEmbedder) Name() string {
+	return ProviderLocal
+}
+
+// Model 模型名称
+func (e *LocalEmbedder) Model() string {
+	return e.model
+}
+
+// Embed 调用本地模型批量向量化
+func (e *LocalEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(localEmbedRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding response: %w", err)
+	}
+
+	var result localEmbedResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid embedding response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != "" {
+			return nil, fmt.Errorf("embedding request failed with status %d: %s", resp.StatusCode, result.Error)
+		}
+		return nil, fmt.Errorf("embedding request failed with status %d", resp.StatusCode)
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embedding response has %d vectors, expected %d", len(result.Embeddings), len(texts))
+	}
+
+	return result.Embeddings, nil
+}
@@ -0,0 +1,26 @@
+package ai
+
+import "context"
+
+// NoopEmbedder 未接入模型时使用，所有请求返回ErrDisabled
+type NoopEmbedder struct{}
+
+// NewNoopEmbedder 创建一个空的向量化提供方
+func NewNoopEmbedder() *NoopEmbedder {
+	return &NoopEmbedder{}
+}
+
+// Name 提供方名称
+func (e *NoopEmbedder) Name() string {
+	return ProviderNoop
+}
+
+// Model 模型名称
+func (e *NoopEmbedder) Model() string {
+	return ""
+}
+
+// Embed 始终返回ErrDisabled
+func (e *NoopEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, ErrDisabled
+}
@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrDisabled 未配置AI提供方
+var ErrDisabled = errors.New("ai provider is not configured")
+
+// AI提供方，与消息服务的大模型配置保持一致
+const (
+	ProviderNoop   = "noop"   // 不接入模型
+	ProviderOpenAI = "openai" // OpenAI兼容的 /embeddings 接口
+	ProviderLocal  = "local"  // 本地模型（Ollama /api/embed 接口）
+)
+
+// Config AI提供方配置
+type Config struct {
+	Provider string
+	BaseURL  string
+	APIKey   string
+	Model    string // 向量模型
+	Timeout  time.Duration
+}
+
+// Embedder 文本向量化提供方，实现方需保证并发安全
+type Embedder interface {
+	Name() string
+	Model() string
+	// Embed 批量向量化，返回的向量与输入一一对应
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbedder 根据配置创建向量化提供方
+func NewEmbedder(cfg Config) (Embedder, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	switch cfg.Provider {
+	case "", ProviderNoop:
+		return NewNoopEmbedder(), nil
+	case ProviderOpenAI:
+		if cfg.BaseURL == "" || cfg.Model == "" {
+			return nil, fmt.Errorf("openai provider requires LLM_BASE_URL and EMBEDDING_MODEL")
+		}
+		return NewOpenAIEmbedder(cfg.BaseURL, cfg.APIKey, cfg.Model, client), nil
+	case ProviderLocal:
+		if cfg.BaseURL == "" || cfg.Model == "" {
+			return nil, fmt.Errorf("local provider requires LLM_BASE_URL and EMBEDDING_MODEL")
+		}
+		return NewLocalEmbedder(cfg.BaseURL, cfg.Model, client), nil
+	default:
+		return nil, fmt.Errorf("unsupported ai provider: %s", cfg.Provider)
+	}
+}
@@ -9,6 +9,12 @@ import (
 	"github.com/neohope/chatapp/user-service/internal/domain"
 )
 
+// 令牌类型，未携带token_type的旧令牌视为访问令牌
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 // JWTManager JWT管理器
 type JWTManager struct {
 	secretKey       string
@@ -21,6 +27,12 @@ type CustomClaims struct {
 	Username string            `json:"username"`
 	Email    string            `json:"email"`
 	Status   domain.UserStatus `json:"status"`
+	// Role 用户角色，管理员为admin，其他服务据此判断管理员权限
+	Role string `json:"role,omitempty"`
+	// 刷新令牌只能用于换取新的访问令牌，不能访问接口
+	TokenType string `json:"token_type,omitempty"`
+	// TokenVersion 签发时用户的令牌版本，与数据库不一致说明令牌已被吊销
+	TokenVersion int `json:"tv,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -39,10 +51,13 @@ func (m *JWTManager) GenerateToken(user *domain.User) (string, error) {
 
 	// 创建声明
 	claims := CustomClaims{
-		UserID:   user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Status:   user.Status,
+		UserID:       user.ID,
+		Username:     user.Username,
+		Email:        user.Email,
+		Status:       user.Status,
+		Role:         user.Role,
+		TokenType:    TokenTypeAccess,
+		TokenVersion: user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiration),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -62,8 +77,49 @@ func (m *JWTManager) GenerateToken(user *domain.User) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateToken 验证JWT令牌
+// GenerateRefreshToken 生成刷新令牌，tokenID写入jti，用于在仓库中查找、轮换和吊销
+func (m *JWTManager) GenerateRefreshToken(userID, tokenID string, expiresAt time.Time) (string, error) {
+	claims := CustomClaims{
+		UserID:    userID,
+		TokenType: TokenTypeRefresh,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   userID,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(m.secretKey))
+}
+
+// ValidateToken 验证JWT访问令牌，拒绝刷新令牌
 func (m *JWTManager) ValidateToken(tokenString string) (*CustomClaims, error) {
+	claims, err := m.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType == TokenTypeRefresh {
+		return nil, errors.New("refresh token cannot be used as access token")
+	}
+	return claims, nil
+}
+
+// ValidateRefreshToken 验证刷新令牌的签名、有效期和类型，是否已吊销由调用方查询仓库
+func (m *JWTManager) ValidateRefreshToken(tokenString string) (*CustomClaims, error) {
+	claims, err := m.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != TokenTypeRefresh || claims.ID == "" {
+		return nil, errors.New("not a refresh token")
+	}
+	return claims, nil
+}
+
+// parse 解析并验证JWT令牌
+func (m *JWTManager) parse(tokenString string) (*CustomClaims, error) {
 	// 解析令牌
 	token, err := jwt.ParseWithClaims(
 		tokenString,
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ErrDirectoryUserNotFound 目录中不存在该用户
+var ErrDirectoryUserNotFound = errors.New("directory user not found")
+
+// ErrDirectoryInvalidCredentials 目录认证失败
+var ErrDirectoryInvalidCredentials = errors.New("invalid directory credentials")
+
+// DirectoryIdentity 目录认证成功后返回的用户属性
+type DirectoryIdentity struct {
+	DN       string
+	Username string
+	Email    string
+	FullName string
+	Groups   []string
+}
+
+// DirectoryAuthenticator 外部目录认证接口
+type DirectoryAuthenticator interface {
+	Authenticate(identifier, password string) (*DirectoryIdentity, error)
+}
+
+// LDAPConfig LDAP连接与属性映射配置
+type LDAPConfig struct {
+	URL                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+	BindDN             string
+	BindPassword       string
+	BaseDN             string
+	UserFilter         string // {identifier} 会被替换为转义后的登录名
+	UsernameAttribute  string
+	EmailAttribute     string
+	FullNameAttribute  string
+	GroupAttribute     string
+	Timeout            time.Duration
+}
+
+// LDAPAuthenticator 通过LDAP/AD验证用户密码
+type LDAPAuthenticator struct {
+	config LDAPConfig
+}
+
+// NewLDAPAuthenticator 创建LDAP认证器
+func NewLDAPAuthenticator(config LDAPConfig) *LDAPAuthenticator {
+	if config.UserFilter == "" {
+		config.UserFilter = "(&(objectClass=person)(|(uid={identifier})(sAMAccountName={identifier})(mail={identifier})))"
+	}
+	if config.UsernameAttribute == "" {
+		config.UsernameAttribute = "uid"
+	}
+	if config.EmailAttribute == "" {
+		config.EmailAttribute = "mail"
+	}
+	if config.FullNameAttribute == "" {
+		config.FullNameAttribute = "cn"
+	}
+	if config.GroupAttribute == "" {
+		config.GroupAttribute = "memberOf"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	return &LDAPAuthenticator{config: config}
+}
+
+// Authenticate 先用服务账号查找用户DN，再以用户DN和密码绑定验证
+func (a *LDAPAuthenticator) Authenticate(identifier, password string) (*DirectoryIdentity, error) {
+	// 空密码会触发匿名绑定并被部分服务器视为成功，必须拒绝
+	if identifier == "" || password == "" {
+		return nil, ErrDirectoryInvalidCredentials
+	}
+
+	conn, err := a.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if a.config.BindDN != "" {
+		if err := conn.Bind(a.config.BindDN, a.config.BindPassword); err != nil {
+			return nil, fmt.Errorf("ldap service bind failed: %w", err)
+		}
+	}
+
+	filter := strings.ReplaceAll(a.config.UserFilter, "{identifier}", ldap.EscapeFilter(identifier))
+	request := ldap.NewSearchRequest(
+		a.config.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(a.config.Timeout/time.Second), false,
+		filter,
+		[]string{a.config.UsernameAttribute, a.config.EmailAttribute, a.config.FullNameAttribute, a.config.GroupAttribute},
+		nil,
+	)
+
+	result, err := conn.Search(request)
+	if err != nil {
+		return nil, fmt.Errorf("ldap search failed: %w", err)
+	}
+	if len(result.Entries) == 0 {
+		return nil, ErrDirectoryUserNotFound
+	}
+	if len(result.Entries) > 1 {
+		return nil, fmt.Errorf("ldap search returned multiple entries for %q", identifier)
+	}
+
+	entry := result.Entries[0]
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrDirectoryInvalidCredentials
+		}
+		return nil, fmt.Errorf("ldap user bind failed: %w", err)
+	}
+
+	return &DirectoryIdentity{
+		DN:       entry.DN,
+		Username: entry.GetAttributeValue(a.config.UsernameAttribute),
+		Email:    entry.GetAttributeValue(a.config.EmailAttribute),
+		FullName: entry.GetAttributeValue(a.config.FullNameAttribute),
+		Groups:   entry.GetAttributeValues(a.config.GroupAttribute),
+	}, nil
+}
+
+// dial 建立LDAP连接，按配置启用StartTLS
+func (a *LDAPAuthenticator) dial() (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: a.config.InsecureSkipVerify}
+
+	conn, err := ldap.DialURL(a.config.URL, ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ldap: %w", err)
+	}
+	conn.SetTimeout(a.config.Timeout)
+
+	if a.config.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap starttls failed: %w", err)
+		}
+	}
+	return conn, nil
+}
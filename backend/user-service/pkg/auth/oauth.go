@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 支持关联和登录的第三方账号
+const (
+	OAuthProviderGoogle = "google"
+	OAuthProviderGitHub = "github"
+)
+
+const (
+	defaultGoogleTokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+	defaultGitHubAPIURL       = "https://api.github.com"
+)
+
+// OAuthConfig 第三方账号令牌校验配置
+type OAuthConfig struct {
+	// GoogleClientIDs 允许的Google客户端ID，ID令牌的aud必须是其中之一，为空时不支持Google
+	GoogleClientIDs []string
+	// GitHubEnabled 是否支持GitHub账号
+	GitHubEnabled bool
+	// GoogleTokenInfoURL、GitHubAPIURL 为空时使用公网地址，GitHub企业版可修改API地址
+	GoogleTokenInfoURL string
+	GitHubAPIURL       string
+	Timeout            time.Duration
+}
+
+// OAuthAccount 令牌校验通过后得到的第三方账号
+type OAuthAccount struct {
+	Provider string
+	Subject  string // 第三方账号的稳定标识：Google的sub、GitHub的数字ID
+	Name     string // 展示用：Google邮箱或GitHub登录名
+}
+
+// OAuthVerifier 使用客户端从第三方拿到的令牌向第三方确认账号身份
+type OAuthVerifier struct {
+	cfg        OAuthConfig
+	httpClient *http.Client
+}
+
+// NewOAuthVerifier 创建第三方账号令牌校验器
+func NewOAuthVerifier(cfg OAuthConfig) *OAuthVerifier {
+	if cfg.GoogleTokenInfoURL == "" {
+		cfg.GoogleTokenInfoURL = defaultGoogleTokenInfoURL
+	}
+	if cfg.GitHubAPIURL == "" {
+		cfg.GitHubAPIURL = defaultGitHubAPIURL
+	}
+	cfg.GitHubAPIURL = strings.TrimSuffix(cfg.GitHubAPIURL, "/")
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &OAuthVerifier{cfg: cfg, httpClient: &http.Client{Timeout: cfg.Timeout}}
+}
+
+// Verify 校验令牌：Google为ID令牌，GitHub为访问令牌
+func (v *OAuthVerifier) Verify(ctx context.Context, provider, token string) (*OAuthAccount, error) {
+	if token == "" {
+		return nil, errors.New("invalid oauth token")
+	}
+	switch provider {
+	case OAuthProviderGoogle:
+		if len(v.cfg.GoogleClientIDs) == 0 {
+			return nil, errors.New("google sign-in is not configured")
+		}
+		return v.verifyGoogle(ctx, token)
+	case OAuthProviderGitHub:
+		if !v.cfg.GitHubEnabled {
+			return nil, errors.New("github sign-in is not configured")
+		}
+		return v.verifyGitHub(ctx, token)
+	default:
+		return nil, fmt.Errorf("unsupported oauth provider: %s", provider)
+	}
+}
+
+// verifyGoogle 通过tokeninfo端点校验ID令牌的签名、有效期和受众
+func (v *OAuthVerifier) verifyGoogle(ctx context.Context, idToken string) (*OAuthAccount, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.GoogleTokenInfoURL+"?id_token="+url.QueryEscape(idToken), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		Issuer        string `json:"iss"`
+		Audience      string `json:"aud"`
+		Subject       string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified string `json:"email_verified"`
+	}
+	if err := v.do(req, &info); err != nil {
+		return nil, err
+	}
+
+	if info.Issuer != "accounts.google.com" && info.Issuer != "https://accounts.google.com" {
+		return nil, errors.New("invalid oauth token: unexpected issuer")
+	}
+	audienceAllowed := false
+	for _, clientID := range v.cfg.GoogleClientIDs {
+		if info.Audience == clientID {
+			audienceAllowed = true
+			break
+		}
+	}
+	if !audienceAllowed {
+		return nil, errors.New("invalid oauth token: unexpected audience")
+	}
+	if info.Subject == "" {
+		return nil, errors.New("invalid oauth token: missing subject")
+	}
+
+	name := ""
+	if info.EmailVerified == "true" {
+		name = info.Email
+	}
+	return &OAuthAccount{Provider: OAuthProviderGoogle, Subject: info.Subject, Name: name}, nil
+}
+
+// verifyGitHub 用访问令牌读取当前GitHub用户，能读到即说明令牌有效
+func (v *OAuthVerifier) verifyGitHub(ctx context.Context, accessToken string) (*OAuthAccount, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.GitHubAPIURL+"/user", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+	}
+	if err := v.do(req, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, errors.New("invalid oauth token: missing subject")
+	}
+
+	return &OAuthAccount{Provider: OAuthProviderGitHub, Subject: strconv.FormatInt(user.ID, 10), Name: user.Login}, nil
+}
+
+// do 发送请求并解析JSON，第三方拒绝令牌时返回invalid oauth token
+func (v *OAuthVerifier) do(req *http.Request, out interface{}) error {
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach oauth provider: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		return errors.New("invalid oauth token")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oauth provider returned status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -1,19 +1,152 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword 对密码进行哈希处理
+// PasswordVersionBcrypt 旧的bcrypt哈希对应的参数版本，升级前的账户均为此版本
+const PasswordVersionBcrypt = 1
+
+// argon2idPrefix Argon2id编码哈希的前缀
+const argon2idPrefix = "$argon2id$"
+
+// ErrPasswordMismatch 密码不匹配
+var ErrPasswordMismatch = errors.New("password does not match")
+
+// PasswordParams Argon2id哈希参数
+// 调整任何参数时都应递增Version，已有账户会在下次登录时自动按新参数重新哈希
+type PasswordParams struct {
+	Version     int    // 参数版本，写入users.password_version
+	Memory      uint32 // 内存开销（KiB）
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultPasswordParams 默认的Argon2id参数
+var DefaultPasswordParams = PasswordParams{
+	Version:     2,
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+var (
+	paramsMu       sync.RWMutex
+	passwordParams = DefaultPasswordParams
+)
+
+// SetPasswordParams 设置新密码使用的哈希参数，服务启动时调用
+func SetPasswordParams(params PasswordParams) error {
+	if params.Version <= PasswordVersionBcrypt {
+		return fmt.Errorf("password hash version must be greater than %d", PasswordVersionBcrypt)
+	}
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return errors.New("argon2id memory, iterations and parallelism must be positive")
+	}
+	if params.SaltLength < 8 || params.KeyLength < 16 {
+		return errors.New("argon2id salt length must be at least 8 and key length at least 16")
+	}
+
+	paramsMu.Lock()
+	passwordParams = params
+	paramsMu.Unlock()
+	return nil
+}
+
+// CurrentPasswordParams 获取当前的哈希参数
+func CurrentPasswordParams() PasswordParams {
+	paramsMu.RLock()
+	defer paramsMu.RUnlock()
+	return passwordParams
+}
+
+// PasswordVersion 获取当前哈希参数版本
+func PasswordVersion() int {
+	return CurrentPasswordParams().Version
+}
+
+// HashPassword 使用当前参数对密码进行Argon2id哈希
+// 返回PHC格式字符串：$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
 func HashPassword(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
+	params := CurrentPasswordParams()
+
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
 		return "", err
 	}
-	return string(hashedBytes), nil
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
 }
 
-// CheckPassword 验证密码是否匹配
+// CheckPassword 验证密码是否匹配，兼容Argon2id和旧的bcrypt哈希
 func CheckPassword(password, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-}
\ No newline at end of file
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return ErrPasswordMismatch
+			}
+			return err
+		}
+		return nil
+	}
+
+	// 按哈希中记录的参数验证，参数升级前的哈希仍可校验
+	var version int
+	var memory, iterations uint32
+	var parallelism uint8
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 {
+		return errors.New("invalid argon2id hash format")
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return errors.New("unsupported argon2id version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+		return fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return fmt.Errorf("invalid argon2id hash: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, uint32(len(expected)))
+	if subtle.ConstantTimeCompare(key, expected) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+// NeedsRehash 判断密码哈希是否需要按当前参数重新生成
+// 旧的bcrypt哈希和参数版本低于当前版本的哈希都需要升级
+func NeedsRehash(hashedPassword string, version int) bool {
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		return true
+	}
+	return version < PasswordVersion()
+}
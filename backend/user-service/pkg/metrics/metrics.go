@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// unmatchedRoute 没有匹配到路由的请求（404/405）使用的route标签，避免按原始路径产生大量时间序列
+const unmatchedRoute = "unmatched"
+
+// Metrics 服务的Prometheus指标，所有指标带有service标签
+// 各服务使用相同的指标名，便于在同一个面板中按service对比
+type Metrics struct {
+	registry        *prometheus.Registry
+	registerer      prometheus.Registerer
+	requests        *prometheus.CounterVec
+	duration        *prometheus.HistogramVec
+	inFlight        prometheus.Gauge
+	storageOps      *prometheus.CounterVec
+	storageDuration *prometheus.HistogramVec
+}
+
+// New 创建服务的指标注册表，包含HTTP请求、存储操作以及Go运行时和进程指标
+func New(service string) *Metrics {
+	registry := prometheus.NewRegistry()
+	registerer := prometheus.WrapRegistererWith(prometheus.Labels{"service": service}, registry)
+
+	m := &Metrics{
+		registry:   registry,
+		registerer: registerer,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method and route template.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests currently being served.",
+		}),
+		storageOps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "storage_operations_total",
+			Help: "Object storage operations by backend, operation and result.",
+		}, []string{"backend", "operation", "result"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "storage_operation_duration_seconds",
+			Help:    "Object storage operation latency by backend and operation.",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"backend", "operation"}),
+	}
+	registerer.MustRegister(
+		m.requests,
+		m.duration,
+		m.inFlight,
+		m.storageOps,
+		m.storageDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Registerer 注册服务自定义指标，注册的指标同样带有service标签
+func (m *Metrics) Registerer() prometheus.Registerer {
+	return m.registerer
+}
+
+// Handler /metrics端点，token不为空时要求抓取请求携带 Authorization: Bearer <token>
+func (m *Metrics) Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return handler
+	}
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Instrument 统计请求数、延迟和处理中的请求数，route标签使用router中匹配到的路由模板（如/api/v1/groups/{id}）
+func (m *Metrics) Instrument(router *mux.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := unmatchedRoute
+		var match mux.RouteMatch
+		if router.Match(r, &match) && match.Route != nil {
+			if template, err := match.Route.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		m.inFlight.Inc()
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			m.inFlight.Dec()
+			m.requests.WithLabelValues(r.Method, route, strconv.Itoa(recorder.status)).Inc()
+			m.duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+		}()
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// WebSocketConnections 注册WebSocket连接数指标，抓取时调用count获取当前连接数
+func (m *Metrics) WebSocketConnections(kind string, count func() int) {
+	m.registerer.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "websocket_connections",
+		Help:        "Open WebSocket connections.",
+		ConstLabels: prometheus.Labels{"kind": kind},
+	}, func() float64 {
+		return float64(count())
+	}))
+}
+
+// RegisterDB 注册数据库连接池指标（打开/使用中/空闲连接数、等待次数和时长等）
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registerer.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveStorage 记录一次存储操作的结果和耗时，result为ok或error
+func (m *Metrics) ObserveStorage(backend, operation string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.storageOps.WithLabelValues(backend, operation, result).Inc()
+	m.storageDuration.WithLabelValues(backend, operation).Observe(time.Since(start).Seconds())
+}
+
+// statusRecorder 记录响应状态码，保留流式响应和连接接管能力
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(data)
+}
+
+// Flush 支持流式响应（如SSE）
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 支持WebSocket升级，升级成功的请求记为101
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		r.status = http.StatusSwitchingProtocols
+		r.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap 供http.ResponseController访问底层ResponseWriter
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
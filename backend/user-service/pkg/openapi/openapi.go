@@ -0,0 +1,325 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Version 生成的文档使用的OpenAPI版本
+const Version = "3.0.3"
+
+// bearerScheme 需要登录的接口使用的安全方案名称
+const bearerScheme = "bearerAuth"
+
+// Operation 一个接口的说明，在各服务的路由说明表中登记
+type Operation struct {
+	Summary     string
+	Description string
+	// Tags 为空时使用服务名
+	Tags []string
+	// Query 查询参数名
+	Query []string
+	// Request 请求体类型的零值（如CreateGroupRequest{}），nil表示没有JSON请求体
+	Request interface{}
+	// Response 成功响应体类型的零值，nil表示不描述响应体
+	Response interface{}
+	// Status 成功响应的状态码，默认200
+	Status int
+	// Public 不需要Bearer令牌
+	Public bool
+}
+
+// Operations 按"方法 路由模板"登记的接口说明，如"GET /api/v1/groups/{groupId}"
+type Operations map[string]Operation
+
+// Merge 返回包含两组接口说明的新表，用于只在启用某个功能时才注册的路由
+func (ops Operations) Merge(more Operations) Operations {
+	merged := make(Operations, len(ops)+len(more))
+	for key, op := range ops {
+		merged[key] = op
+	}
+	for key, op := range more {
+		merged[key] = op
+	}
+	return merged
+}
+
+// Document OpenAPI文档
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info 文档基本信息
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag 接口分组
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem 一个路径下按小写方法名索引的接口
+type PathItem map[string]*OperationObject
+
+// OperationObject 文档中的接口
+type OperationObject struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter 路径或查询参数
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody 请求体
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response 响应
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType 请求体或响应体的内容
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema JSON Schema（OpenAPI 3.0子集）
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Components 可复用的schema和安全方案
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme 安全方案
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// pathParamPattern 匹配路由模板中的路径参数，如{id}或{id:[0-9]+}
+var pathParamPattern = regexp.MustCompile(`\{([^{}:]+)(:[^{}]*)?\}`)
+
+// Build 遍历router中的对外路由生成服务的文档，路由只提供路径、方法和路径参数，其余内容来自ops
+// 内部路由（/internal/）、健康检查、指标和文档本身不包含在文档中
+// 返回的问题列表包括没有登记说明的路由和登记了说明但router中不存在的接口，用于启动时检查文档与路由是否一致
+func Build(router *mux.Router, service string, ops Operations) (*Document, []string) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: service, Version: "v1"},
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerScheme: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+	schemas := newSchemaRegistry(doc.Components.Schemas)
+	documented := make(map[string]bool, len(ops))
+	normalizedOps := make(Operations, len(ops))
+	for key, op := range ops {
+		normalizedOps[normalizeKey(key)] = op
+	}
+
+	var problems []string
+	routed := map[string]bool{}
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil || !isPublicPath(template) {
+			return nil
+		}
+		// 没有限制方法的路由（如静态文件目录）按GET记录
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{http.MethodGet}
+		}
+		path := normalizePath(template)
+		for _, method := range methods {
+			if method == http.MethodOptions || method == http.MethodHead {
+				continue
+			}
+			key := method + " " + path
+			if routed[key] {
+				continue
+			}
+			routed[key] = true
+			op, ok := normalizedOps[key]
+			if !ok {
+				problems = append(problems, "undocumented route "+key)
+			}
+			documented[key] = true
+			item, exists := doc.Paths[path]
+			if !exists {
+				item = PathItem{}
+				doc.Paths[path] = item
+			}
+			item[strings.ToLower(method)] = buildOperation(service, method, path, op, schemas)
+		}
+		return nil
+	})
+
+	for key := range normalizedOps {
+		if !documented[key] {
+			problems = append(problems, "documented operation without route "+key)
+		}
+	}
+	sort.Strings(problems)
+	doc.Tags = collectTags(doc.Paths)
+	return doc, problems
+}
+
+// Handler 返回文档的JSON，文档在启动时生成，每次请求直接输出
+func Handler(doc *Document) http.HandlerFunc {
+	body, err := json.Marshal(doc)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			http.Error(w, "failed to encode openapi document", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+func buildOperation(service, method, path string, op Operation, schemas *schemaRegistry) *OperationObject {
+	obj := &OperationObject{
+		OperationID: operationID(method, path),
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Responses:   map[string]Response{},
+	}
+	if len(obj.Tags) == 0 {
+		obj.Tags = []string{service}
+	}
+
+	for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+		obj.Parameters = append(obj.Parameters, Parameter{Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	for _, name := range op.Query {
+		obj.Parameters = append(obj.Parameters, Parameter{Name: name, In: "query", Schema: &Schema{Type: "string"}})
+	}
+
+	if op.Request != nil {
+		obj.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: schemas.schemaFor(op.Request)}},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	if op.Response != nil {
+		success.Content = map[string]MediaType{"application/json": {Schema: schemas.schemaFor(op.Response)}}
+	}
+	obj.Responses[fmt.Sprint(status)] = success
+	obj.Responses["default"] = Response{Description: "Error"}
+
+	if !op.Public {
+		obj.Security = []map[string][]string{{bearerScheme: {}}}
+		obj.Responses["401"] = Response{Description: http.StatusText(http.StatusUnauthorized)}
+	}
+	return obj
+}
+
+// isPublicPath 判断路由是否为对外接口
+func isPublicPath(template string) bool {
+	switch template {
+	case "/", "/health", "/metrics", "/openapi.json":
+		return false
+	}
+	return !strings.HasPrefix(template, "/internal/")
+}
+
+// normalizeKey 统一登记键中的方法大小写和路径参数写法
+func normalizeKey(key string) string {
+	method, path, found := strings.Cut(strings.TrimSpace(key), " ")
+	if !found {
+		return key
+	}
+	return strings.ToUpper(method) + " " + normalizePath(strings.TrimSpace(path))
+}
+
+// normalizePath 去掉路径参数中的正则，{id:[0-9]+}记为{id}
+func normalizePath(template string) string {
+	return pathParamPattern.ReplaceAllString(template, "{$1}")
+}
+
+// operationID 由方法和路径生成，省略/api/v1前缀，如GET /api/v1/groups/{groupId}为get_groups_by_groupId
+func operationID(method, path string) string {
+	parts := []string{strings.ToLower(method)}
+	for _, segment := range strings.Split(strings.TrimPrefix(strings.Trim(path, "/"), "api/v1"), "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") {
+			segment = "by_" + strings.Trim(segment, "{}")
+		}
+		parts = append(parts, strings.ReplaceAll(segment, "-", "_"))
+	}
+	return strings.Join(parts, "_")
+}
+
+func collectTags(paths map[string]PathItem) []Tag {
+	seen := map[string]bool{}
+	var tags []Tag
+	for _, item := range paths {
+		for _, op := range item {
+			for _, tag := range op.Tags {
+				if !seen[tag] {
+					seen[tag] = true
+					tags = append(tags, Tag{Name: tag})
+				}
+			}
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags
+}
@@ -0,0 +1,152 @@
+package shutdown
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Step 关闭步骤，ctx在总超时到达时取消
+type Step func(ctx context.Context) error
+
+// Options 关闭配置，零值字段使用默认值
+type Options struct {
+	// DrainDelay 进入排空状态后等待多久再停止接收连接，供负载均衡根据健康检查摘除本实例
+	// 收到SIGINT（本地Ctrl+C）时不等待
+	DrainDelay time.Duration
+	// Timeout 所有关闭步骤的总超时时间，默认30秒
+	Timeout time.Duration
+}
+
+// step 已注册的关闭步骤
+type step struct {
+	name string
+	fn   Step
+}
+
+// Coordinator 协调进程的优雅关闭
+// 收到信号后先进入排空状态（健康检查返回503，响应带Connection: close），等待DrainDelay后
+// 按注册顺序执行关闭步骤：通常依次为停止接收HTTP请求、清空发送队列、断开长连接、等待后台任务
+type Coordinator struct {
+	opts     Options
+	steps    []step
+	mu       sync.Mutex
+	draining atomic.Bool
+	once     sync.Once
+	logger   *zap.Logger
+}
+
+// New 创建关闭协调器
+func New(opts Options, logger *zap.Logger) *Coordinator {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	return &Coordinator{opts: opts, logger: logger}
+}
+
+// Register 注册关闭步骤，按注册顺序执行
+func (c *Coordinator) Register(name string, fn Step) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.steps = append(c.steps, step{name: name, fn: fn})
+}
+
+// Draining 是否已进入排空状态
+func (c *Coordinator) Draining() bool {
+	return c.draining.Load()
+}
+
+// Middleware 排空期间健康检查（路径以/health结尾）和WebSocket升级请求返回503，
+// 其余请求照常处理但要求客户端关闭连接，使keep-alive连接在下一个请求时转到其他实例
+func (c *Coordinator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !c.draining.Load() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/health") {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Connection", "close")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{"status": "draining"}) // nolint: errcheck
+			return
+		}
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Connection", "close")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Wait 阻塞直到收到SIGINT或SIGTERM，然后执行关闭
+func (c *Coordinator) Wait() {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+	signal.Stop(quit)
+
+	c.logger.Info("Received shutdown signal", zap.String("signal", sig.String()))
+	c.Shutdown(sig != syscall.SIGINT)
+}
+
+// Shutdown 进入排空状态并依次执行关闭步骤，delay为false时不等待DrainDelay
+// 某个步骤失败或超时只记录日志，后续步骤照常执行；重复调用时只执行一次
+func (c *Coordinator) Shutdown(delay bool) {
+	c.once.Do(func() {
+		c.draining.Store(true)
+		if delay && c.opts.DrainDelay > 0 {
+			c.logger.Info("Draining before shutdown", zap.Duration("delay", c.opts.DrainDelay))
+			time.Sleep(c.opts.DrainDelay)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+		defer cancel()
+
+		c.mu.Lock()
+		steps := append([]step(nil), c.steps...)
+		c.mu.Unlock()
+
+		start := time.Now()
+		for _, s := range steps {
+			stepStart := time.Now()
+			if err := s.fn(ctx); err != nil {
+				c.logger.Error("Shutdown step failed", zap.String("step", s.name), zap.Error(err))
+				continue
+			}
+			c.logger.Info("Shutdown step completed", zap.String("step", s.name), zap.Duration("duration", time.Since(stepStart)))
+		}
+		c.logger.Info("Shutdown completed", zap.Duration("duration", time.Since(start)))
+	})
+}
+
+// Await 在ctx内等待阻塞的wait返回，ctx先取消时返回ctx的错误（wait仍在后台运行），
+// 用于把jobs.Runner.Wait等不接受ctx的等待接入关闭步骤
+func Await(ctx context.Context, wait func()) error {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
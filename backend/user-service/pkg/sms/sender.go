@@ -0,0 +1,31 @@
+package sms
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// Sender 发送短信
+type Sender interface {
+	Send(ctx context.Context, phone, text string) error
+}
+
+// LogSender 未接入短信网关时使用，只把短信内容写入日志，便于本地开发
+type LogSender struct {
+	logger *zap.Logger
+}
+
+// NewLogSender 创建日志短信发送器
+func NewLogSender(logger *zap.Logger) *LogSender {
+	return &LogSender{logger: logger}
+}
+
+// Send 记录短信内容
+func (s *LogSender) Send(ctx context.Context, phone, text string) error {
+	s.logger.Info("SMS not sent (gateway not configured)",
+		zap.String("phone", phone),
+		zap.String("text", text),
+	)
+	return nil
+}
@@ -0,0 +1,187 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/semconv/v1.17.0/httpconv"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// 支持的导出器
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterJaeger = "jaeger"
+)
+
+// instrumentationName 本包创建的span使用的tracer名称
+const instrumentationName = "github.com/neohope/chatapp/pkg/tracing"
+
+// Options 链路追踪配置
+type Options struct {
+	Service string
+	// Exporter 导出器：none（生成并传播trace上下文，不导出span）、otlp或jaeger
+	Exporter string
+	// Endpoint OTLP为collector的gRPC地址（host:4317），Jaeger为collector的HTTP地址（http://host:14268/api/traces）
+	// 为空时使用导出器的默认地址和OTEL_EXPORTER_*环境变量
+	Endpoint string
+	// Insecure OTLP连接不使用TLS
+	Insecure bool
+	// SamplePercent 新trace的采样百分比（0-100），请求带有traceparent时沿用上游的采样决定
+	SamplePercent int
+}
+
+// Init 设置全局的W3C trace上下文传播器和TracerProvider
+// 导出器为none时仍然生成trace上下文并传给下游，下游服务可以单独开启导出
+// 返回的函数在关闭时导出缓冲中的span
+func Init(ctx context.Context, opts Options, logger *zap.Logger) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("Tracing error", zap.Error(err))
+	}))
+
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(opts.Service))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(float64(opts.SamplePercent) / 100))),
+	}
+	switch opts.Exporter {
+	case "", ExporterNone:
+		// 不导出span
+	case ExporterOTLP:
+		clientOpts := []otlptracegrpc.Option{}
+		if opts.Endpoint != "" {
+			clientOpts = append(clientOpts, otlptracegrpc.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			clientOpts = append(clientOpts, otlptracegrpc.WithInsecure())
+		}
+		// 不等待连接建立，collector暂时不可用时span在导出时重试
+		otlpExporter, err := otlptracegrpc.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("create otlp exporter: %w", err)
+		}
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(otlpExporter))
+	case ExporterJaeger:
+		endpointOpts := []jaeger.CollectorEndpointOption{}
+		if opts.Endpoint != "" {
+			endpointOpts = append(endpointOpts, jaeger.WithEndpoint(opts.Endpoint))
+		}
+		jaegerExporter, err := jaeger.New(jaeger.WithCollectorEndpoint(endpointOpts...))
+		if err != nil {
+			return nil, fmt.Errorf("create jaeger exporter: %w", err)
+		}
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(jaegerExporter))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", opts.Exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(providerOpts...)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Middleware 为每个请求创建server span，span名称使用router中匹配到的路由模板（如GET /api/v1/users/{id}）
+// 请求带有traceparent时作为上游span的子span，健康检查和指标抓取不创建span
+func Middleware(service string, router *mux.Router, next http.Handler) http.Handler {
+	tracer := otel.Tracer(instrumentationName)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" || r.URL.Path == "/metrics" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		name := "HTTP " + r.Method
+		attrs := httpconv.ServerRequest(service, r)
+		var match mux.RouteMatch
+		if router.Match(r, &match) && match.Route != nil {
+			if template, err := match.Route.GetPathTemplate(); err == nil {
+				name = r.Method + " " + template
+				attrs = append(attrs, semconv.HTTPRoute(template))
+			}
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPStatusCode(recorder.status))
+		span.SetStatus(httpconv.ServerStatus(recorder.status))
+	})
+}
+
+// Transport 为出站HTTP请求创建client span并注入traceparent头，base为nil时使用http.DefaultTransport
+// 只用于调用内部服务，第三方接口不应收到内部的trace上下文；上下文中没有span的请求（如健康检查）直接发送
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base,
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return trace.SpanContextFromContext(r.Context()).IsValid()
+		}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return "HTTP " + r.Method + " " + r.URL.Host
+		}),
+	)
+}
+
+// statusRecorder 记录响应状态码，保留流式响应和连接接管能力
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(data)
+}
+
+// Flush 支持流式响应（如SSE）
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 支持WebSocket升级，升级成功的请求记为101
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		r.status = http.StatusSwitchingProtocols
+		r.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap 供http.ResponseController访问底层ResponseWriter
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -24,8 +24,8 @@ func (m *MockUserService) Register(ctx context.Context, user *domain.User, passw
 	return nil
 }
 
-func (m *MockUserService) Login(ctx context.Context, identifier, password string) (string, error) {
-	return "mock-token", nil
+func (m *MockUserService) Login(ctx context.Context, identifier, password string) (*domain.LoginResponse, error) {
+	return &domain.LoginResponse{Token: "mock-token"}, nil
 }
 
 func (m *MockUserService) GetUserByID(ctx context.Context, id string) (*domain.User, error) {
@@ -71,6 +71,10 @@ func (m *MockUserService) SearchUsers(ctx context.Context, query string, limit,
 	return []*domain.User{}, nil
 }
 
+func (m *MockUserService) CheckAvailability(ctx context.Context, username, email string) (*domain.AvailabilityResult, error) {
+	return &domain.AvailabilityResult{}, nil
+}
+
 func (m *MockUserService) ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) error {
 	return nil
 }
@@ -118,7 +122,7 @@ func TestFriendRequestHandlers(t *testing.T) {
 	jwtManager := auth.NewJWTManager("test-secret", 24)
 	logger := zap.NewNop()
 
-	handler := httpdelivery.NewUserHandler(mockUserService, mockFriendService, jwtManager, logger)
+	handler := httpdelivery.NewUserHandler(mockUserService, mockFriendService, nil, nil, jwtManager, logger)
 
 	// 创建测试用户
 	testUser := &domain.User{